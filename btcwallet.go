@@ -5,6 +5,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -13,8 +15,15 @@ import (
 	"path/filepath"
 	"runtime"
 	"sync"
+	"time"
 
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/lockfile"
+	"github.com/btcsuite/btcwallet/internal/pricefeed"
+	"github.com/btcsuite/btcwallet/internal/signerprovider"
+	"github.com/btcsuite/btcwallet/internal/webhook"
+	"github.com/btcsuite/btcwallet/netparams"
 	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
 	"github.com/btcsuite/btcwallet/wallet"
 	"github.com/btcsuite/btcwallet/walletdb"
@@ -57,9 +66,30 @@ func walletMain() error {
 	// Show version at startup.
 	log.Infof("Version %s", version())
 
+	// Two processes using the same data directory would overwrite each
+	// other's changes to the wallet files, so the network directory is
+	// locked for the life of the process.
+	if !cfg.MemoryWallet {
+		lock, err := lockDataDir(
+			networkDir(cfg.AppDataDir.Value, activeNet.Params),
+		)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		defer func() {
+			if err := lock.Release(); err != nil {
+				log.Errorf("Unable to release data directory "+
+					"lock: %v", err)
+			}
+		}()
+	}
+
+	// The profile server exposes the internals of the process, so it is
+	// only reachable from the local host.
 	if cfg.Profile != "" {
 		go func() {
-			listenAddr := net.JoinHostPort("", cfg.Profile)
+			listenAddr := net.JoinHostPort("localhost", cfg.Profile)
 			log.Infof("Profile server listening on %s", listenAddr)
 			profileRedirect := http.RedirectHandler("/debug/pprof",
 				http.StatusSeeOther)
@@ -68,10 +98,20 @@ func walletMain() error {
 		}()
 	}
 
-	dbDir := networkDir(cfg.AppDataDir.Value, activeNet.Params)
-	loader := wallet.NewLoader(
-		activeNet.Params, dbDir, true, cfg.DBTimeout, 250,
-	)
+	var loader *wallet.Loader
+	if cfg.MemoryWallet {
+		loader, err = newMemoryWalletLoader()
+		if err != nil {
+			log.Errorf("Unable to create in-memory wallet database: %v",
+				err)
+			return err
+		}
+		loader.SetIntegrityCheck(cfg.WalletFsck, cfg.WalletFsckRepair)
+	} else {
+		loader = newWalletLoader(
+			activeNet.Params, networkDir(cfg.AppDataDir.Value, activeNet.Params),
+		)
+	}
 
 	// Create and start HTTP server to serve wallet client connections.
 	// This will be updated with the wallet and chain server RPC client
@@ -82,20 +122,43 @@ func walletMain() error {
 		return err
 	}
 
+	// Wallets other than the wallet loaded at startup may be loaded by
+	// legacy RPC clients.
+	wallets := newWalletManager()
+	if legacyRPCServer != nil {
+		legacyRPCServer.SetWalletManager(wallets)
+	}
+
 	// Create and start chain RPC client so it's ready to connect to
 	// the wallet when loaded later.
 	if !cfg.NoInitialLoad {
-		go rpcClientConnectLoop(legacyRPCServer, loader)
+		go rpcClientConnectLoop(legacyRPCServer, loader, activeNet, nil)
 	}
 
 	loader.RunAfterLoad(func(w *wallet.Wallet) {
+		configureWallet(w)
+		if cfg.BackupDir != "" {
+			w.SetBackupConfig(&wallet.BackupConfig{
+				Target:     wallet.NewDirBackupTarget(cfg.BackupDir),
+				Passphrase: []byte(cfg.BackupPass),
+				Interval:   cfg.BackupInterval,
+				Retain:     cfg.BackupRetain,
+			})
+		}
 		startWalletRPCServices(w, rpcs, legacyRPCServer)
 	})
 
 	if !cfg.NoInitialLoad {
 		// Load the wallet database.  It must have been created already
-		// or this will return an appropriate error.
-		_, err = loader.OpenExistingWallet([]byte(cfg.WalletPass), true)
+		// or this will return an appropriate error.  In-memory wallets
+		// are created instead.
+		if cfg.MemoryWallet {
+			err = createMemoryWallet(loader, cfg)
+		} else {
+			_, err = loader.OpenExistingWallet(
+				[]byte(cfg.WalletPass), true,
+			)
+		}
 		if err != nil {
 			log.Error(err)
 			return err
@@ -111,12 +174,11 @@ func walletMain() error {
 			log.Errorf("Failed to close wallet: %v", err)
 		}
 	})
+	addInterruptHandler(wallets.unloadAll)
 	if rpcs != nil {
 		addInterruptHandler(func() {
-			// TODO: Does this need to wait for the grpc server to
-			// finish up any requests?
 			log.Warn("Stopping RPC server...")
-			rpcs.Stop()
+			stopGRPCServer(rpcs)
 			log.Info("RPC server shutdown")
 		})
 	}
@@ -125,6 +187,7 @@ func walletMain() error {
 			log.Warn("Stopping legacy RPC server...")
 			legacyRPCServer.Stop()
 			log.Info("Legacy RPC server shutdown")
+			removeRPCCookie()
 		})
 		go func() {
 			<-legacyRPCServer.RequestProcessShutdown()
@@ -137,6 +200,77 @@ func walletMain() error {
 	return nil
 }
 
+// newWalletLoader returns a loader of the wallet database of the network in
+// dbDir, which is opened as set by the configuration.
+func newWalletLoader(chainParams *chaincfg.Params, dbDir string) *wallet.Loader {
+	loader := wallet.NewLoader(
+		chainParams, dbDir, true, cfg.DBTimeout, 250,
+	)
+	loader.SetDBDriver(cfg.DBDriver)
+	if cfg.DBPass != "" {
+		loader.SetDBPassphrase([]byte(cfg.DBPass))
+	}
+	loader.SetIntegrityCheck(cfg.WalletFsck, cfg.WalletFsckRepair)
+	return loader
+}
+
+// newHTTPClient returns an HTTP client for the requests made by the wallet to
+// other services, which connects through the proxies of the configuration with
+// walletDial.  Proxies of the environment are not used, so that requests are
+// never made outside of the configured proxies.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	dial := func(_ context.Context, network, addr string) (net.Conn, error) {
+		return walletDial(network, addr)
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dial,
+			TLSHandshakeTimeout: timeout,
+		},
+		Timeout: timeout,
+	}
+}
+
+// configureWallet applies the options of the configuration to a loaded
+// wallet.
+func configureWallet(w *wallet.Wallet) {
+	if err := w.SetLookaheadWindow(cfg.Lookahead); err != nil {
+		log.Errorf("Unable to set address lookahead window: %v", err)
+	}
+	w.SetChainStallTimeout(cfg.ChainStallTimeout)
+	w.SetUnminedExpiry(cfg.UnminedExpiry)
+	if cfg.DepositWebhook != "" {
+		w.SetDepositWebhook(webhook.New(
+			newHTTPClient(webhook.Timeout), cfg.DepositWebhook,
+			[]byte(cfg.DepositHookSecret),
+		))
+	}
+	if cfg.PriceFeedURL != "" {
+		w.SetPriceFeed(&wallet.PriceFeedConfig{
+			Source: pricefeed.NewHTTPSource(
+				newHTTPClient(pricefeed.Timeout),
+				cfg.PriceFeedURL, cfg.PriceFeedField,
+				cfg.PriceFeedCurrency,
+			),
+			Interval: cfg.PriceFeedInterval,
+		})
+	}
+	for name, signURL := range cfg.signerProviders {
+		err := w.RegisterSignerProvider(signerprovider.NewHTTPProvider(
+			newHTTPClient(signerprovider.Timeout), name, signURL,
+		))
+		if err != nil {
+			log.Errorf("Unable to register signer provider %s: %v",
+				name, err)
+		}
+	}
+	if cfg.SpendTOTPSecret != "" {
+		// The secret was validated when the configuration was loaded.
+		secret, _ := decodeTOTPSecret(cfg.SpendTOTPSecret)
+		w.SetSpendConfirmationSecret(secret)
+	}
+}
+
 // rpcClientConnectLoop continuously attempts a connection to the consensus RPC
 // server.  When a connection is established, the client is used to sync the
 // loaded wallet, either immediately or when loaded at a later time.
@@ -144,13 +278,41 @@ func walletMain() error {
 // The legacy RPC is optional.  If set, the connected RPC client will be
 // associated with the server for RPC passthrough and to enable additional
 // methods.
-func rpcClientConnectLoop(legacyRPCServer *legacyrpc.Server, loader *wallet.Loader) {
+//
+// The wallet of the loader is bound to the network of netParams.  Wallets of networks
+// other than the active network are always synchronized with the btcd server
+// of their network.
+//
+// The loop returns once quit is closed, stopping the connected client.  A nil
+// quit channel keeps the loop running for the lifetime of the process.
+func rpcClientConnectLoop(legacyRPCServer *legacyrpc.Server, loader *wallet.Loader,
+	netParams *netparams.Params, quit <-chan struct{}) {
+
 	var certs []byte
-	if !cfg.UseSPV {
+	switch {
+	case cfg.UseElectrum:
+		certs = readElectrumCAFile()
+	case !cfg.UseSPV && !cfg.UseBitcoind:
 		certs = readCAFile()
 	}
 
+	// When fallback servers are configured, each connection attempt and
+	// each disconnect fails over to the next server, in turn.
+	servers := chainRPCServers(netParams)
+	failover := len(servers) > 1
+	nextServer := 0
+
+	// The bitcoind connection is shared by every chain client created
+	// while the wallet runs.
+	var bitcoindConn *chain.BitcoindConn
+
 	for {
+		select {
+		case <-quit:
+			return
+		default:
+		}
+
 		var (
 			chainClient chain.Interface
 			err         error
@@ -178,6 +340,11 @@ func rpcClientConnectLoop(legacyRPCServer *legacyrpc.Server, loader *wallet.Load
 					ChainParams:  *activeNet.Params,
 					ConnectPeers: cfg.ConnectPeers,
 					AddPeers:     cfg.AddPeers,
+					Dialer: func(addr net.Addr) (net.Conn, error) {
+						return walletDial(addr.Network(),
+							addr.String())
+					},
+					NameResolver: walletLookup,
 				})
 			if err != nil {
 				log.Errorf("Couldn't create Neutrino ChainService: %s", err)
@@ -188,8 +355,38 @@ func rpcClientConnectLoop(legacyRPCServer *legacyrpc.Server, loader *wallet.Load
 			if err != nil {
 				log.Errorf("Couldn't start Neutrino client: %s", err)
 			}
+		} else if cfg.UseBitcoind {
+			if bitcoindConn == nil {
+				bitcoindConn, err = startBitcoindConn()
+				if err != nil {
+					log.Errorf("Unable to connect to bitcoind: %v", err)
+					time.Sleep(chainRetryInterval)
+					continue
+				}
+				defer bitcoindConn.Stop()
+			}
+			chainClient = bitcoindConn.NewBitcoindClient()
+			err = chainClient.Start()
+			if err != nil {
+				log.Errorf("Couldn't start bitcoind client: %v", err)
+				chainClient.Stop()
+				time.Sleep(chainRetryInterval)
+				continue
+			}
+		} else if cfg.UseElectrum {
+			chainClient, err = startChainElectrum(certs)
+			if err != nil {
+				log.Errorf("Unable to connect to electrum server: %v",
+					err)
+				time.Sleep(chainRetryInterval)
+				continue
+			}
 		} else {
-			chainClient, err = startChainRPC(certs)
+			server := servers[nextServer]
+			nextServer = (nextServer + 1) % len(servers)
+			chainClient, err = startChainRPC(
+				netParams.Params, certs, server, failover,
+			)
 			if err != nil {
 				log.Errorf("Unable to open connection to consensus RPC server: %v", err)
 				continue
@@ -218,7 +415,18 @@ func rpcClientConnectLoop(legacyRPCServer *legacyrpc.Server, loader *wallet.Load
 			}
 		})
 
+		// Stop the client when the loop is quit, as it is otherwise
+		// only stopped by the wallet when it is unloaded.
+		clientDone := make(chan struct{})
+		go func() {
+			select {
+			case <-quit:
+				chainClient.Stop()
+			case <-clientDone:
+			}
+		}()
 		chainClient.WaitForShutdown()
+		close(clientDone)
 
 		mu.Lock()
 		associateRPCClient = nil
@@ -243,6 +451,39 @@ func rpcClientConnectLoop(legacyRPCServer *legacyrpc.Server, loader *wallet.Load
 	}
 }
 
+// chainRPCServers returns the btcd RPC servers wallets of the network connect
+// to.  Wallets of the active network connect to the rpcconnect server and fail
+// over to the rpcconnectfallback servers, while wallets of other networks
+// connect to the netrpcconnect server of their network, or to the default
+// port of the network on localhost.
+func chainRPCServers(netParams *netparams.Params) []string {
+	if netParams == activeNet {
+		return append([]string{cfg.RPCConnect}, cfg.RPCConnectFallbacks...)
+	}
+	if server, ok := cfg.netRPCConnect[netParams.Name]; ok {
+		return []string{server}
+	}
+	return []string{net.JoinHostPort("localhost", netParams.RPCClientPort)}
+}
+
+const (
+	// failoverConnectAttempts is the number of connection attempts made
+	// to a btcd server before failing over to the next one.
+	failoverConnectAttempts = 3
+
+	// chainRetryInterval is the time waited before retrying a failed
+	// connection to bitcoind or an Electrum server.
+	chainRetryInterval = 5 * time.Second
+
+	// bitcoindZMQReadDeadline is the read deadline of the ZMQ
+	// notifications from bitcoind.
+	bitcoindZMQReadDeadline = 5 * time.Second
+
+	// bitcoindPrunedMaxPeers is the maximum number of peers blocks are
+	// requested from when the bitcoind node has pruned them.
+	bitcoindPrunedMaxPeers = 4
+)
+
 func readCAFile() []byte {
 	// Read certificate file if TLS is not disabled.
 	var certs []byte
@@ -262,17 +503,126 @@ func readCAFile() []byte {
 	return certs
 }
 
-// startChainRPC opens a RPC client connection to a btcd server for blockchain
-// services.  This function uses the RPC options from the global config and
-// there is no recovery in case the server is not available or if there is an
-// authentication error.  Instead, all requests to the client will simply error.
-func startChainRPC(certs []byte) (*chain.RPCClient, error) {
-	log.Infof("Attempting RPC client connection to %v", cfg.RPCConnect)
-	rpcc, err := chain.NewRPCClient(activeNet.Params, cfg.RPCConnect,
-		cfg.BtcdUsername, cfg.BtcdPassword, certs, cfg.DisableClientTLS, 0)
+// readElectrumCAFile reads the certificates the TLS certificate of the
+// Electrum server is verified with, if set.  When unset, or when the file
+// cannot be read, the system roots are used instead.
+func readElectrumCAFile() []byte {
+	if cfg.ElectrumNoTLS {
+		log.Info("Electrum server TLS is disabled")
+		return nil
+	}
+	if cfg.ElectrumCAFile == "" {
+		return nil
+	}
+	certs, err := ioutil.ReadFile(cfg.ElectrumCAFile)
+	if err != nil {
+		log.Warnf("Cannot open electrum CA file: %v", err)
+		return nil
+	}
+	return certs
+}
+
+// startChainRPC opens a RPC client connection to the btcd server at connect
+// for blockchain services.  This function uses the RPC options from the global
+// config and there is no recovery in case the server is not available or if
+// there is an authentication error.  Instead, all requests to the client will
+// simply error.  With failover, the connection is only attempted a limited
+// number of times and the client is stopped rather than reconnected when it is
+// disconnected, so the next server may be tried.
+func startChainRPC(chainParams *chaincfg.Params, certs []byte, connect string,
+	failover bool) (*chain.RPCClient, error) {
+
+	var reconnectAttempts int
+	if failover {
+		reconnectAttempts = failoverConnectAttempts
+	}
+
+	log.Infof("Attempting RPC client connection to %v", connect)
+	rpcc, err := chain.NewRPCClient(chainParams, connect,
+		cfg.BtcdUsername, cfg.BtcdPassword, certs, cfg.DisableClientTLS,
+		reconnectAttempts)
 	if err != nil {
 		return nil, err
 	}
+	rpcc.SetProxy(chainProxy(connect))
+	if failover {
+		rpcc.DisableAutoReconnect()
+	}
 	err = rpcc.Start()
 	return rpcc, err
 }
+
+// startChainElectrum opens a connection to the Electrum server for
+// blockchain services.  The client is stopped when it is disconnected from the
+// server.
+func startChainElectrum(certs []byte) (*chain.ElectrumClient, error) {
+	log.Infof("Attempting connection to electrum server %v",
+		cfg.ElectrumServer)
+	client := chain.NewElectrumClient(&chain.ElectrumConfig{
+		ChainParams:  activeNet.Params,
+		Host:         cfg.ElectrumServer,
+		DisableTLS:   cfg.ElectrumNoTLS,
+		Certificates: certs,
+		Dialer:       walletDial,
+	})
+	err := client.Start()
+	if err != nil {
+		client.Stop()
+		return nil, err
+	}
+	return client, nil
+}
+
+// startBitcoindConn opens the RPC and ZMQ connections to the bitcoind node
+// which serve the bitcoind chain clients.  The RPC connection is not secured
+// with TLS, as bitcoind does not support it.
+func startBitcoindConn() (*chain.BitcoindConn, error) {
+	log.Infof("Attempting bitcoind RPC connection to %v",
+		cfg.BitcoindRPCConnect)
+	conn, err := chain.NewBitcoindConn(&chain.BitcoindConfig{
+		ChainParams:     activeNet.Params,
+		Host:            cfg.BitcoindRPCConnect,
+		User:            cfg.BtcdUsername,
+		Pass:            cfg.BtcdPassword,
+		ZMQBlockHost:    cfg.BitcoindZMQBlock,
+		ZMQTxHost:       cfg.BitcoindZMQTx,
+		ZMQReadDeadline: bitcoindZMQReadDeadline,
+		Dialer: func(addr string) (net.Conn, error) {
+			return walletDial("tcp", addr)
+		},
+		PrunedModeMaxPeers: bitcoindPrunedMaxPeers,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = conn.Start()
+	if err != nil {
+		conn.Stop()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// dataDirLockName is the name of the lock file held in the network directory
+// by the process using it.
+const dataDirLockName = "btcwallet.lock"
+
+// lockDataDir acquires the lock of a network directory, creating the
+// directory if it does not exist, and returns an error if the directory is in
+// use by another process.
+func lockDataDir(dir string) (*lockfile.Lock, error) {
+	if err := checkCreateDir(dir); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, dataDirLockName)
+	lock, err := lockfile.Acquire(path)
+	if err == lockfile.ErrLocked {
+		return nil, fmt.Errorf("data directory %s is in use by another "+
+			"btcwallet process (lock file %s)", dir, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock data directory %s: %v",
+			dir, err)
+	}
+	return lock, nil
+}
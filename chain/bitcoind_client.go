@@ -104,8 +104,9 @@ type BitcoindClient struct {
 }
 
 // A compile-time check to ensure that BitcoindClient satisfies the
-// chain.Interface interface.
+// chain.Interface and chain.FeeEstimator interfaces.
 var _ Interface = (*BitcoindClient)(nil)
+var _ FeeEstimator = (*BitcoindClient)(nil)
 
 // BackEnd returns the name of the driver.
 func (c *BitcoindClient) BackEnd() string {
@@ -183,6 +184,26 @@ func (c *BitcoindClient) IsCurrent() bool {
 	return bestHeader.Timestamp.After(time.Now().Add(-isCurrentDelta))
 }
 
+// EstimateFeePerKb returns the fee per kilobyte estimated by bitcoind for a
+// transaction to be mined within confTarget blocks.
+//
+// NOTE: This is part of the FeeEstimator interface.
+func (c *BitcoindClient) EstimateFeePerKb(
+	confTarget uint32) (btcutil.Amount, error) {
+
+	mode := btcjson.EstimateModeConservative
+	res, err := c.chainConn.client.EstimateSmartFee(
+		int64(confTarget), &mode,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if res.FeeRate == nil || *res.FeeRate <= 0 {
+		return 0, ErrNoFeeEstimate
+	}
+	return btcutil.NewAmount(*res.FeeRate)
+}
+
 // GetRawTransactionVerbose returns a transaction from the tx hash.
 func (c *BitcoindClient) GetRawTransactionVerbose(
 	hash *chainhash.Hash) (*btcjson.TxRawResult, error) {
@@ -0,0 +1,1007 @@
+package chain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+const (
+	// electrumProtocolVersion is the version of the Electrum protocol
+	// negotiated with the server.
+	electrumProtocolVersion = "1.4"
+
+	// electrumHeadersBatch is the maximum number of headers requested
+	// from the server at once, which is the limit of most servers.
+	electrumHeadersBatch = 2016
+)
+
+// ErrElectrumNoBlocks is the error returned when a full block is requested
+// from an Electrum server, which only serves headers and the transactions of
+// the wallet.
+var ErrElectrumNoBlocks = errors.New("blocks are not served by electrum " +
+	"servers")
+
+// ElectrumConfig contains the parameters required to connect to an Electrum
+// protocol server, such as ElectrumX or Fulcrum.
+type ElectrumConfig struct {
+	// ChainParams are the parameters of the chain the server must serve.
+	ChainParams *chaincfg.Params
+
+	// Host is the IP address and port of the server.
+	Host string
+
+	// DisableTLS connects to the server over plain TCP rather than TLS.
+	DisableTLS bool
+
+	// Certificates are the PEM encoded certificates the TLS certificate of
+	// the server is verified with.  The system roots are used if empty.
+	Certificates []byte
+	// Dialer is an optional function the connection to the server is
+	// dialed with, such as one dialing through a proxy.
+	Dialer func(network, addr string) (net.Conn, error)
+}
+
+// electrumHistoryItem is a transaction in the history of a script hash.  The
+// height is zero or negative for unmined transactions.
+type electrumHistoryItem struct {
+	Height int32  `json:"height"`
+	TxHash string `json:"tx_hash"`
+}
+
+// electrumHeader is the header notified by a headers subscription.
+type electrumHeader struct {
+	Height int32  `json:"height"`
+	Hex    string `json:"hex"`
+}
+
+// ElectrumClient is an implementation of the chain.Interface interface backed
+// by an Electrum protocol server.  Rather than scanning blocks, the client
+// subscribes to the script hashes of the wallet's addresses and fetches the
+// transactions in their histories, so neither a full node nor full blocks are
+// required.
+type ElectrumClient struct {
+	started int32 // To be used atomically.
+	stopped int32 // To be used atomically.
+
+	// notifyBlocks is set when block notifications have been requested.
+	notifyBlocks int32 // To be used atomically.
+
+	cfg  ElectrumConfig
+	conn *electrumConn
+
+	// headersMtx protects the cache of the headers of the main chain and
+	// the best block.  Electrum servers only serve headers by height, so
+	// headers requested by hash must have been cached.
+	headersMtx sync.RWMutex
+	headers    map[int32]*wire.BlockHeader
+	heights    map[chainhash.Hash]int32
+	bestBlock  waddrmgr.BlockStamp
+
+	// watchMtx protects the watched script hashes and the heights of
+	// the wallet transactions which have been notified.
+	watchMtx  sync.Mutex
+	watched   map[string]struct{}
+	txHeights map[chainhash.Hash]int32
+
+	notificationQueue *ConcurrentQueue
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Compile time check to ensure ElectrumClient satisfies the chain.Interface
+// and chain.FeeEstimator interfaces.
+var _ Interface = (*ElectrumClient)(nil)
+var _ FeeEstimator = (*ElectrumClient)(nil)
+
+// NewElectrumClient creates a client of the Electrum server described by the
+// config.  The connection is not established until Start is called.
+func NewElectrumClient(cfg *ElectrumConfig) *ElectrumClient {
+	return &ElectrumClient{
+		cfg:               *cfg,
+		headers:           make(map[int32]*wire.BlockHeader),
+		heights:           make(map[chainhash.Hash]int32),
+		watched:           make(map[string]struct{}),
+		txHeights:         make(map[chainhash.Hash]int32),
+		notificationQueue: NewConcurrentQueue(20),
+		quit:              make(chan struct{}),
+	}
+}
+
+// BackEnd returns the name of the driver.
+func (c *ElectrumClient) BackEnd() string {
+	return "electrum"
+}
+
+// Start connects to the Electrum server, verifies it serves the expected
+// chain, and subscribes to its headers.  The client is stopped when the
+// connection to the server is lost.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) Start() error {
+	if !atomic.CompareAndSwapInt32(&c.started, 0, 1) {
+		return nil
+	}
+
+	conn, err := dialElectrum(&c.cfg)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+
+	var version []string
+	err = conn.call("server.version", &version, "btcwallet",
+		electrumProtocolVersion)
+	if err != nil {
+		conn.close()
+		return fmt.Errorf("unable to negotiate electrum protocol "+
+			"version: %v", err)
+	}
+
+	// Verify that the server is serving the expected chain.
+	var features struct {
+		GenesisHash string `json:"genesis_hash"`
+	}
+	if err := conn.call("server.features", &features); err != nil {
+		conn.close()
+		return err
+	}
+	if features.GenesisHash != c.cfg.ChainParams.GenesisHash.String() {
+		conn.close()
+		return fmt.Errorf("expected network %v, electrum server has "+
+			"genesis block %v", c.cfg.ChainParams.Name,
+			features.GenesisHash)
+	}
+
+	var tip electrumHeader
+	if err := conn.call("blockchain.headers.subscribe", &tip); err != nil {
+		conn.close()
+		return err
+	}
+	tipHeader, err := parseHeader(tip.Hex)
+	if err != nil {
+		conn.close()
+		return err
+	}
+	c.headersMtx.Lock()
+	c.cacheHeader(tip.Height, tipHeader)
+	c.bestBlock = waddrmgr.BlockStamp{
+		Hash:      tipHeader.BlockHash(),
+		Height:    tip.Height,
+		Timestamp: tipHeader.Timestamp,
+	}
+	c.headersMtx.Unlock()
+
+	// Cache the most recent headers, which the wallet looks up by hash
+	// when verifying the blocks it is synced to.
+	start := tip.Height - electrumHeadersBatch + 1
+	if start < 0 {
+		start = 0
+	}
+	if _, err := c.fetchHeaders(start, tip.Height-start); err != nil {
+		conn.close()
+		return err
+	}
+
+	// Start the notification queue and immediately dispatch a
+	// ClientConnected notification to the caller.
+	c.notificationQueue.Start()
+	c.notificationQueue.ChanIn() <- ClientConnected{}
+
+	c.wg.Add(1)
+	go c.ntfnHandler()
+
+	log.Infof("Connected to electrum server %v (%v)", c.cfg.Host,
+		version)
+
+	return nil
+}
+
+// Stop disconnects from the Electrum server and stops the client.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) Stop() {
+	if !atomic.CompareAndSwapInt32(&c.stopped, 0, 1) {
+		return
+	}
+
+	close(c.quit)
+	if c.conn != nil {
+		c.conn.close()
+	}
+	c.notificationQueue.Stop()
+}
+
+// WaitForShutdown blocks until the client has finished disconnecting and all
+// handlers have exited.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) WaitForShutdown() {
+	c.wg.Wait()
+	if c.conn != nil {
+		c.conn.waitForShutdown()
+	}
+}
+
+// Notifications returns a channel to retrieve notifications from.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) Notifications() <-chan interface{} {
+	return c.notificationQueue.ChanOut()
+}
+
+// GetBestBlock returns the hash and height of the best block known to the
+// server.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) GetBestBlock() (*chainhash.Hash, int32, error) {
+	c.headersMtx.RLock()
+	defer c.headersMtx.RUnlock()
+
+	hash := c.bestBlock.Hash
+	return &hash, c.bestBlock.Height, nil
+}
+
+// BlockStamp returns the best block known to the server.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) BlockStamp() (*waddrmgr.BlockStamp, error) {
+	c.headersMtx.RLock()
+	defer c.headersMtx.RUnlock()
+
+	bestBlock := c.bestBlock
+	return &bestBlock, nil
+}
+
+// IsCurrent returns whether the best block known to the server is recent.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) IsCurrent() bool {
+	c.headersMtx.RLock()
+	defer c.headersMtx.RUnlock()
+
+	return c.bestBlock.Timestamp.After(time.Now().Add(-isCurrentDelta))
+}
+
+// GetBlock always returns ErrElectrumNoBlocks, as Electrum servers do not
+// serve blocks.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) GetBlock(*chainhash.Hash) (*wire.MsgBlock, error) {
+	return nil, ErrElectrumNoBlocks
+}
+
+// GetBlockHash returns the hash of the main chain block at the height.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) GetBlockHash(height int64) (*chainhash.Hash, error) {
+	header, err := c.headerByHeight(int32(height))
+	if err != nil {
+		return nil, err
+	}
+	hash := header.BlockHash()
+	return &hash, nil
+}
+
+// GetBlockHeader returns the header of the block with the hash.  Only the
+// headers of main chain blocks which were previously looked up by height or
+// notified are known.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) GetBlockHeader(
+	hash *chainhash.Hash) (*wire.BlockHeader, error) {
+
+	c.headersMtx.RLock()
+	defer c.headersMtx.RUnlock()
+
+	height, ok := c.heights[*hash]
+	if !ok {
+		return nil, fmt.Errorf("block %v is unknown", hash)
+	}
+	return c.headers[height], nil
+}
+
+// SendRawTransaction broadcasts the transaction through the server.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) SendRawTransaction(tx *wire.MsgTx,
+	_ bool) (*chainhash.Hash, error) {
+
+	var buf bytes.Buffer
+	buf.Grow(tx.SerializeSize())
+	if err := tx.Serialize(&buf); err != nil {
+		return nil, err
+	}
+
+	var txid string
+	err := c.conn.call("blockchain.transaction.broadcast", &txid,
+		hex.EncodeToString(buf.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	return chainhash.NewHashFromStr(txid)
+}
+
+// EstimateFeePerKb returns the fee per kilobyte estimated by the server for a
+// transaction to be mined within confTarget blocks.
+//
+// NOTE: This is part of the FeeEstimator interface.
+func (c *ElectrumClient) EstimateFeePerKb(
+	confTarget uint32) (btcutil.Amount, error) {
+
+	var feeRate float64
+	err := c.conn.call("blockchain.estimatefee", &feeRate, confTarget)
+	if err != nil {
+		return 0, err
+	}
+	if feeRate <= 0 {
+		return 0, ErrNoFeeEstimate
+	}
+	return btcutil.NewAmount(feeRate)
+}
+
+// NotifyBlocks requests notifications of connected and disconnected blocks.
+// The headers of the server are always subscribed to, so this only enables
+// their delivery.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) NotifyBlocks() error {
+	atomic.StoreInt32(&c.notifyBlocks, 1)
+	return nil
+}
+
+// NotifyReceived subscribes to the script hashes of the addresses, so that
+// transactions paying to or spending from them are notified.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) NotifyReceived(addrs []btcutil.Address) error {
+	for _, addr := range addrs {
+		if _, err := c.subscribe(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rescan notifies the transactions of the addresses and outpoints mined after
+// the start block, and unmined transactions, followed by a RescanFinished
+// notification.  The addresses and those of the outpoints are subscribed to,
+// so later transactions are notified as well.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) Rescan(startHash *chainhash.Hash,
+	addrs []btcutil.Address,
+	outPoints map[wire.OutPoint]btcutil.Address) error {
+
+	// When the start block is not known, the complete history of the
+	// addresses is rescanned, as the transactions of blocks which were
+	// scanned previously are simply notified again.
+	c.headersMtx.RLock()
+	startHeight := c.heights[*startHash]
+	c.headersMtx.RUnlock()
+
+	for _, addr := range outPoints {
+		addrs = append(addrs, addr)
+	}
+
+	var history []electrumHistoryItem
+	seen := make(map[string]struct{})
+	for _, addr := range addrs {
+		scriptHash, err := c.subscribe(addr)
+		if err != nil {
+			return err
+		}
+		if _, ok := seen[scriptHash]; ok {
+			continue
+		}
+		seen[scriptHash] = struct{}{}
+
+		items, err := c.getHistory(scriptHash)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if item.Height <= 0 || item.Height >= startHeight {
+				history = append(history, item)
+				continue
+			}
+
+			// Transactions mined before the start block are
+			// already known, and are only recorded so that they
+			// are not notified when the history changes.
+			txHash, err := chainhash.NewHashFromStr(item.TxHash)
+			if err != nil {
+				return err
+			}
+			c.watchMtx.Lock()
+			c.txHeights[*txHash] = item.Height
+			c.watchMtx.Unlock()
+		}
+	}
+
+	if err := c.notifyHistory(history); err != nil {
+		return err
+	}
+
+	bestBlock, err := c.BlockStamp()
+	if err != nil {
+		return err
+	}
+	c.notify(&RescanFinished{
+		Hash:   &bestBlock.Hash,
+		Height: bestBlock.Height,
+		Time:   bestBlock.Timestamp,
+	})
+	return nil
+}
+
+// FilterBlocks returns the transactions of the addresses and outpoints of the
+// request in the first of the requested blocks which contains any, or nil if
+// none of the blocks contain any.  Rather than scanning the blocks, the
+// histories of the addresses are looked up.
+//
+// NOTE: This is part of the chain.Interface interface.
+func (c *ElectrumClient) FilterBlocks(
+	req *FilterBlocksRequest) (*FilterBlocksResponse, error) {
+
+	if len(req.Blocks) == 0 {
+		return nil, nil
+	}
+
+	blockIndex := make(map[int32]int, len(req.Blocks))
+	for i, blk := range req.Blocks {
+		blockIndex[blk.Height] = i
+	}
+
+	var addrs []btcutil.Address
+	for _, addr := range req.ExternalAddrs {
+		addrs = append(addrs, addr)
+	}
+	for _, addr := range req.InternalAddrs {
+		addrs = append(addrs, addr)
+	}
+	for _, addr := range req.WatchedOutPoints {
+		addrs = append(addrs, addr)
+	}
+
+	// Find the first requested block containing transactions of any of
+	// the addresses, and all of their transactions in that block.
+	firstIndex := -1
+	var txHashes []string
+	seen := make(map[string]struct{})
+	for _, addr := range addrs {
+		scriptHash, err := addrScriptHash(addr)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[scriptHash]; ok {
+			continue
+		}
+		seen[scriptHash] = struct{}{}
+
+		items, err := c.getHistory(scriptHash)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			i, ok := blockIndex[item.Height]
+			switch {
+			case !ok || item.Height <= 0:
+				continue
+			case firstIndex == -1 || i < firstIndex:
+				firstIndex = i
+				txHashes = []string{item.TxHash}
+			case i == firstIndex:
+				txHashes = append(txHashes, item.TxHash)
+			}
+		}
+	}
+	if firstIndex == -1 {
+		return nil, nil
+	}
+
+	// Filter the transactions as a block, in the order of the block, so
+	// that outputs spent in the same block are found.
+	txHashes = dedupeStrings(txHashes)
+	blk := req.Blocks[firstIndex]
+	positions, err := c.blockPositions(blk.Height, txHashes)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(txHashes, func(i, j int) bool {
+		return positions[txHashes[i]] < positions[txHashes[j]]
+	})
+
+	block := &wire.MsgBlock{}
+	for _, txHash := range txHashes {
+		tx, err := c.getTransaction(txHash)
+		if err != nil {
+			return nil, err
+		}
+		block.Transactions = append(block.Transactions, tx)
+	}
+
+	blockFilterer := NewBlockFilterer(c.cfg.ChainParams, req)
+	if !blockFilterer.FilterBlock(block) {
+		return nil, nil
+	}
+
+	return &FilterBlocksResponse{
+		BatchIndex:         uint32(firstIndex),
+		BlockMeta:          blk,
+		FoundExternalAddrs: blockFilterer.FoundExternal,
+		FoundInternalAddrs: blockFilterer.FoundInternal,
+		FoundOutPoints:     blockFilterer.FoundOutPoints,
+		RelevantTxns:       blockFilterer.RelevantTxns,
+	}, nil
+}
+
+// ntfnHandler handles the notifications of the server's subscriptions, and
+// stops the client when the connection to the server is lost.
+//
+// NOTE: This must be run as a goroutine.
+func (c *ElectrumClient) ntfnHandler() {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case n := <-c.conn.notifications():
+			ntfn := n.(*electrumNotification)
+			var err error
+			switch ntfn.Method {
+			case "blockchain.headers.subscribe":
+				err = c.onHeadersNtfn(ntfn.Params)
+			case "blockchain.scripthash.subscribe":
+				err = c.onScriptHashNtfn(ntfn.Params)
+			default:
+				continue
+			}
+			if err != nil {
+				log.Errorf("Unable to handle electrum %s "+
+					"notification: %v", ntfn.Method, err)
+			}
+
+		case <-c.conn.disconnected():
+			log.Infof("Disconnected from electrum server %v",
+				c.cfg.Host)
+			c.Stop()
+			return
+
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// onHeadersNtfn handles the notification of a new best block.  Blocks of the
+// previous best chain which are no longer in the main chain are notified as
+// disconnected, and the blocks of the new chain as connected.
+func (c *ElectrumClient) onHeadersNtfn(params json.RawMessage) error {
+	var tips []electrumHeader
+	if err := json.Unmarshal(params, &tips); err != nil {
+		return err
+	}
+	if len(tips) == 0 {
+		return errors.New("missing header")
+	}
+	tip := tips[0]
+	tipHeader, err := parseHeader(tip.Hex)
+	if err != nil {
+		return err
+	}
+
+	c.headersMtx.RLock()
+	prevBest := c.bestBlock
+	c.headersMtx.RUnlock()
+
+	// Find the fork point of the new and the previous best chains, which
+	// is the previous best block unless the chain was reorganized.
+	fork := prevBest.Height
+	if fork >= tip.Height {
+		fork = tip.Height - 1
+	}
+	if tip.Height != prevBest.Height+1 ||
+		tipHeader.PrevBlock != prevBest.Hash {
+
+		for ; fork > 0; fork-- {
+			c.headersMtx.RLock()
+			cached, ok := c.headers[fork]
+			c.headersMtx.RUnlock()
+			if !ok {
+				break
+			}
+
+			var headerHex string
+			err := c.conn.call("blockchain.block.header",
+				&headerHex, fork)
+			if err != nil {
+				return err
+			}
+			header, err := parseHeader(headerHex)
+			if err != nil {
+				return err
+			}
+			if header.BlockHash() == cached.BlockHash() {
+				break
+			}
+		}
+	}
+
+	notifyBlocks := atomic.LoadInt32(&c.notifyBlocks) == 1
+	for height := prevBest.Height; height > fork; height-- {
+		c.headersMtx.Lock()
+		header, ok := c.headers[height]
+		if ok {
+			delete(c.heights, header.BlockHash())
+			delete(c.headers, height)
+		}
+		c.headersMtx.Unlock()
+		if !ok {
+			continue
+		}
+
+		// Transactions of the disconnected block are notified again
+		// when they are mined in the new chain.
+		c.watchMtx.Lock()
+		for txHash, txHeight := range c.txHeights {
+			if txHeight == height {
+				delete(c.txHeights, txHash)
+			}
+		}
+		c.watchMtx.Unlock()
+
+		if notifyBlocks {
+			c.notify(BlockDisconnected{
+				Block: wtxmgr.Block{
+					Hash:   header.BlockHash(),
+					Height: height,
+				},
+				Time: header.Timestamp,
+			})
+		}
+	}
+
+	// Fetch the headers of the new chain before the tip, and notify each
+	// block as connected.
+	var headers []*wire.BlockHeader
+	for height := fork + 1; height < tip.Height; {
+		count := tip.Height - height
+		if count > electrumHeadersBatch {
+			count = electrumHeadersBatch
+		}
+		batch, err := c.fetchHeaders(height, count)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return errors.New("missing headers")
+		}
+		headers = append(headers, batch...)
+		height += int32(len(batch))
+	}
+	headers = append(headers, tipHeader)
+
+	c.headersMtx.Lock()
+	c.cacheHeader(tip.Height, tipHeader)
+	c.bestBlock = waddrmgr.BlockStamp{
+		Hash:      tipHeader.BlockHash(),
+		Height:    tip.Height,
+		Timestamp: tipHeader.Timestamp,
+	}
+	c.headersMtx.Unlock()
+
+	if notifyBlocks {
+		for i, header := range headers {
+			c.notify(BlockConnected{
+				Block: wtxmgr.Block{
+					Hash:   header.BlockHash(),
+					Height: fork + 1 + int32(i),
+				},
+				Time: header.Timestamp,
+			})
+		}
+	}
+	return nil
+}
+
+// onScriptHashNtfn handles the notification of a change to the history of a
+// subscribed script hash, notifying its new transactions and transactions
+// which were mined.
+func (c *ElectrumClient) onScriptHashNtfn(params json.RawMessage) error {
+	var ntfn []*string
+	if err := json.Unmarshal(params, &ntfn); err != nil {
+		return err
+	}
+	if len(ntfn) == 0 || ntfn[0] == nil {
+		return errors.New("missing script hash")
+	}
+
+	history, err := c.getHistory(*ntfn[0])
+	if err != nil {
+		return err
+	}
+	return c.notifyHistory(history)
+}
+
+// notifyHistory notifies the transactions of the history items, in the order
+// they were mined, which were not notified before at the same height.
+func (c *ElectrumClient) notifyHistory(history []electrumHistoryItem) error {
+	sort.SliceStable(history, func(i, j int) bool {
+		hi, hj := history[i].Height, history[j].Height
+		if hi <= 0 || hj <= 0 {
+			return hi > 0 && hj <= 0
+		}
+		return hi < hj
+	})
+
+	for _, item := range history {
+		txHash, err := chainhash.NewHashFromStr(item.TxHash)
+		if err != nil {
+			return err
+		}
+		height := item.Height
+		if height < 0 {
+			height = 0
+		}
+
+		c.watchMtx.Lock()
+		notifiedHeight, ok := c.txHeights[*txHash]
+		c.watchMtx.Unlock()
+		if ok && notifiedHeight == height {
+			continue
+		}
+
+		tx, err := c.getTransaction(item.TxHash)
+		if err != nil {
+			return err
+		}
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+		if err != nil {
+			return err
+		}
+
+		var block *wtxmgr.BlockMeta
+		if height > 0 {
+			header, err := c.headerByHeight(height)
+			if err != nil {
+				return err
+			}
+			block = &wtxmgr.BlockMeta{
+				Block: wtxmgr.Block{
+					Hash:   header.BlockHash(),
+					Height: height,
+				},
+				Time: header.Timestamp,
+			}
+		}
+
+		c.notify(RelevantTx{TxRecord: rec, Block: block})
+
+		c.watchMtx.Lock()
+		c.txHeights[*txHash] = height
+		c.watchMtx.Unlock()
+	}
+	return nil
+}
+
+// subscribe subscribes to the script hash of the address, unless already
+// subscribed, and returns the script hash.
+func (c *ElectrumClient) subscribe(addr btcutil.Address) (string, error) {
+	scriptHash, err := addrScriptHash(addr)
+	if err != nil {
+		return "", err
+	}
+
+	c.watchMtx.Lock()
+	_, ok := c.watched[scriptHash]
+	c.watchMtx.Unlock()
+	if ok {
+		return scriptHash, nil
+	}
+
+	err = c.conn.call("blockchain.scripthash.subscribe", nil, scriptHash)
+	if err != nil {
+		return "", err
+	}
+
+	c.watchMtx.Lock()
+	c.watched[scriptHash] = struct{}{}
+	c.watchMtx.Unlock()
+	return scriptHash, nil
+}
+
+// getHistory returns the history of the script hash.
+func (c *ElectrumClient) getHistory(
+	scriptHash string) ([]electrumHistoryItem, error) {
+
+	var history []electrumHistoryItem
+	err := c.conn.call("blockchain.scripthash.get_history", &history,
+		scriptHash)
+	return history, err
+}
+
+// getTransaction returns the transaction with the hash.
+func (c *ElectrumClient) getTransaction(txHash string) (*wire.MsgTx, error) {
+	var txHex string
+	err := c.conn.call("blockchain.transaction.get", &txHex, txHash)
+	if err != nil {
+		return nil, err
+	}
+	serializedTx, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, err
+	}
+	tx := &wire.MsgTx{}
+	if err := tx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// blockPositions returns the positions in the block at the height of the
+// transactions with the hashes.
+func (c *ElectrumClient) blockPositions(height int32,
+	txHashes []string) (map[string]int, error) {
+
+	positions := make(map[string]int, len(txHashes))
+	if len(txHashes) < 2 {
+		return positions, nil
+	}
+	for _, txHash := range txHashes {
+		var merkle struct {
+			Pos int `json:"pos"`
+		}
+		err := c.conn.call("blockchain.transaction.get_merkle",
+			&merkle, txHash, height)
+		if err != nil {
+			return nil, err
+		}
+		positions[txHash] = merkle.Pos
+	}
+	return positions, nil
+}
+
+// headerByHeight returns the header of the main chain block at the height,
+// fetching it and the headers following it when not cached.
+func (c *ElectrumClient) headerByHeight(height int32) (*wire.BlockHeader,
+	error) {
+
+	c.headersMtx.RLock()
+	header, ok := c.headers[height]
+	c.headersMtx.RUnlock()
+	if ok {
+		return header, nil
+	}
+
+	headers, err := c.fetchHeaders(height, electrumHeadersBatch)
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("no block at height %d", height)
+	}
+	return headers[0], nil
+}
+
+// fetchHeaders fetches and caches at most count main chain headers starting
+// at the height.
+func (c *ElectrumClient) fetchHeaders(start, count int32) ([]*wire.BlockHeader,
+	error) {
+
+	if count <= 0 {
+		return nil, nil
+	}
+
+	var reply struct {
+		Count int32  `json:"count"`
+		Hex   string `json:"hex"`
+	}
+	err := c.conn.call("blockchain.block.headers", &reply, start, count)
+	if err != nil {
+		return nil, err
+	}
+	serialized, err := hex.DecodeString(reply.Hex)
+	if err != nil {
+		return nil, err
+	}
+	if len(serialized) != int(reply.Count)*wire.MaxBlockHeaderPayload {
+		return nil, errors.New("malformed headers")
+	}
+
+	headers := make([]*wire.BlockHeader, 0, reply.Count)
+	r := bytes.NewReader(serialized)
+	for i := int32(0); i < reply.Count; i++ {
+		header := &wire.BlockHeader{}
+		if err := header.Deserialize(r); err != nil {
+			return nil, err
+		}
+		headers = append(headers, header)
+	}
+
+	c.headersMtx.Lock()
+	for i, header := range headers {
+		c.cacheHeader(start+int32(i), header)
+	}
+	c.headersMtx.Unlock()
+
+	return headers, nil
+}
+
+// cacheHeader caches the header of the main chain block at the height,
+// replacing any header previously cached at the height.
+//
+// NOTE: This must be called with the headers mutex held for writes.
+func (c *ElectrumClient) cacheHeader(height int32, header *wire.BlockHeader) {
+	if prev, ok := c.headers[height]; ok {
+		delete(c.heights, prev.BlockHash())
+	}
+	c.headers[height] = header
+	c.heights[header.BlockHash()] = height
+}
+
+// notify queues the notification for the caller of Notifications.
+func (c *ElectrumClient) notify(n interface{}) {
+	select {
+	case c.notificationQueue.ChanIn() <- n:
+	case <-c.quit:
+	}
+}
+
+// addrScriptHash returns the Electrum script hash of the output script paying
+// to the address, which is the reversed SHA256 hash of the script in hex.
+func addrScriptHash(addr btcutil.Address) (string, error) {
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(pkScript)
+	for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+		hash[i], hash[j] = hash[j], hash[i]
+	}
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// parseHeader parses a hex encoded block header.
+func parseHeader(headerHex string) (*wire.BlockHeader, error) {
+	serialized, err := hex.DecodeString(headerHex)
+	if err != nil {
+		return nil, err
+	}
+	header := &wire.BlockHeader{}
+	if err := header.Deserialize(bytes.NewReader(serialized)); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// dedupeStrings returns the strings without duplicates, in their original
+// order.
+func dedupeStrings(strs []string) []string {
+	seen := make(map[string]struct{}, len(strs))
+	deduped := strs[:0]
+	for _, s := range strs {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		deduped = append(deduped, s)
+	}
+	return deduped
+}
@@ -0,0 +1,323 @@
+package chain
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// electrumDialTimeout is the timeout of dialing an Electrum server.
+	electrumDialTimeout = 30 * time.Second
+
+	// electrumRequestTimeout is the time waited for the reply to a request
+	// before the request is considered failed.
+	electrumRequestTimeout = 2 * time.Minute
+
+	// electrumWriteTimeout is the timeout of writing a request to the
+	// connection.
+	electrumWriteTimeout = 30 * time.Second
+
+	// electrumPingInterval is the interval at which the server is pinged to
+	// keep the connection alive.  Servers disconnect idle clients.
+	electrumPingInterval = time.Minute
+)
+
+// ErrElectrumDisconnected is the error returned for requests made after the
+// connection to the Electrum server was closed.
+var ErrElectrumDisconnected = errors.New("electrum server disconnected")
+
+// ElectrumError is an error replied by an Electrum server to a request.
+type ElectrumError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error satisfies the error interface.
+func (e *ElectrumError) Error() string {
+	return fmt.Sprintf("electrum server error %d: %s", e.Code, e.Message)
+}
+
+// electrumRequest is a JSON-RPC 2.0 request to an Electrum server.
+type electrumRequest struct {
+	Jsonrpc string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// electrumMessage is a message sent by an Electrum server, which is either the
+// response to a request or, when the id is missing, a notification of a
+// subscription.
+type electrumMessage struct {
+	ID     *uint64         `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *ElectrumError  `json:"error"`
+}
+
+// electrumNotification is a notification of a subscription sent by an
+// Electrum server.
+type electrumNotification struct {
+	Method string
+	Params json.RawMessage
+}
+
+// electrumConn is a connection to an Electrum server over which newline
+// delimited JSON-RPC 2.0 requests are made.  Requests may be made concurrently,
+// and notifications are queued for the caller to read from ntfns.
+type electrumConn struct {
+	conn   net.Conn
+	nextID uint64 // To be used atomically.
+
+	writeMtx sync.Mutex
+
+	pendingMtx sync.Mutex
+	pending    map[uint64]chan *electrumMessage
+
+	ntfns *ConcurrentQueue
+
+	closeOnce sync.Once
+	quit      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// dialElectrum opens a connection to the Electrum server described by the
+// config, and starts the goroutines which read the messages of the server and
+// keep the connection alive.
+func dialElectrum(cfg *ElectrumConfig) (*electrumConn, error) {
+	dial := cfg.Dialer
+	if dial == nil {
+		dialer := &net.Dialer{Timeout: electrumDialTimeout}
+		dial = dialer.Dial
+	}
+	conn, err := dial("tcp", cfg.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.DisableTLS {
+		host, _, err := net.SplitHostPort(cfg.Host)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		tlsConfig := &tls.Config{
+			ServerName: host,
+			MinVersion: tls.VersionTLS12,
+		}
+		if len(cfg.Certificates) != 0 {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(cfg.Certificates) {
+				conn.Close()
+				return nil, errors.New("no certificates found " +
+					"for the electrum server")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		err = tlsConn.SetDeadline(time.Now().Add(electrumDialTimeout))
+		if err == nil {
+			err = tlsConn.Handshake()
+		}
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if err := tlsConn.SetDeadline(time.Time{}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	c := &electrumConn{
+		conn:    conn,
+		pending: make(map[uint64]chan *electrumMessage),
+		ntfns:   NewConcurrentQueue(20),
+		quit:    make(chan struct{}),
+	}
+	c.ntfns.Start()
+
+	c.wg.Add(2)
+	go c.readHandler()
+	go c.pingHandler()
+
+	return c, nil
+}
+
+// call makes the request of the method with the params and unmarshals the
+// result of the reply into result, which may be nil to ignore it.
+func (c *electrumConn) call(method string, result interface{},
+	params ...interface{}) error {
+
+	if params == nil {
+		params = []interface{}{}
+	}
+	req := electrumRequest{
+		Jsonrpc: "2.0",
+		ID:      atomic.AddUint64(&c.nextID, 1),
+		Method:  method,
+		Params:  params,
+	}
+	b, err := json.Marshal(&req)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	replyChan := make(chan *electrumMessage, 1)
+	c.pendingMtx.Lock()
+	c.pending[req.ID] = replyChan
+	c.pendingMtx.Unlock()
+	defer func() {
+		c.pendingMtx.Lock()
+		delete(c.pending, req.ID)
+		c.pendingMtx.Unlock()
+	}()
+
+	c.writeMtx.Lock()
+	err = c.conn.SetWriteDeadline(time.Now().Add(electrumWriteTimeout))
+	if err == nil {
+		_, err = c.conn.Write(b)
+	}
+	c.writeMtx.Unlock()
+	if err != nil {
+		c.close()
+		return ErrElectrumDisconnected
+	}
+
+	timeout := time.NewTimer(electrumRequestTimeout)
+	defer timeout.Stop()
+
+	select {
+	case reply := <-replyChan:
+		if reply.Error != nil {
+			return reply.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(reply.Result, result)
+
+	case <-timeout.C:
+		return fmt.Errorf("electrum request %s timed out", method)
+
+	case <-c.quit:
+		return ErrElectrumDisconnected
+	}
+}
+
+// readHandler reads the messages of the server, delivering responses to the
+// pending requests and queueing notifications.  The connection is closed
+// when it can no longer be read.
+//
+// NOTE: This must be run as a goroutine.
+func (c *electrumConn) readHandler() {
+	defer c.wg.Done()
+	defer c.close()
+
+	reader := bufio.NewReader(c.conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			select {
+			case <-c.quit:
+			default:
+				log.Errorf("Unable to read from electrum "+
+					"server: %v", err)
+			}
+			return
+		}
+
+		var msg electrumMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			log.Errorf("Unable to parse electrum server "+
+				"message: %v", err)
+			continue
+		}
+
+		if msg.ID == nil {
+			if msg.Method == "" {
+				continue
+			}
+			select {
+			case c.ntfns.ChanIn() <- &electrumNotification{
+				Method: msg.Method,
+				Params: msg.Params,
+			}:
+			case <-c.quit:
+				return
+			}
+			continue
+		}
+
+		c.pendingMtx.Lock()
+		replyChan, ok := c.pending[*msg.ID]
+		c.pendingMtx.Unlock()
+		if ok {
+			replyChan <- &msg
+		}
+	}
+}
+
+// pingHandler periodically pings the server to keep the connection alive,
+// closing the connection when a ping fails.
+//
+// NOTE: This must be run as a goroutine.
+func (c *electrumConn) pingHandler() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(electrumPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := c.call("server.ping", nil)
+			if err == ErrElectrumDisconnected {
+				return
+			}
+			if err != nil {
+				log.Errorf("Electrum server ping failed: %v", err)
+				c.close()
+				return
+			}
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// notifications returns the channel of the notifications sent by the server.
+func (c *electrumConn) notifications() <-chan interface{} {
+	return c.ntfns.ChanOut()
+}
+
+// disconnected returns a channel which is closed when the connection is
+// closed.
+func (c *electrumConn) disconnected() <-chan struct{} {
+	return c.quit
+}
+
+// close closes the connection, failing all pending and future requests.
+func (c *electrumConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.quit)
+		c.conn.Close()
+		c.ntfns.Stop()
+	})
+}
+
+// waitForShutdown blocks until the goroutines of the connection have exited.
+func (c *electrumConn) waitForShutdown() {
+	c.wg.Wait()
+}
@@ -0,0 +1,474 @@
+package chain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// fakeElectrumServer is an Electrum server serving a chain of headers and the
+// histories of script hashes to a single client.
+type fakeElectrumServer struct {
+	t        *testing.T
+	listener net.Listener
+
+	mtx       sync.Mutex
+	conn      net.Conn
+	headers   []*wire.BlockHeader
+	histories map[string][]electrumHistoryItem
+	txs       map[string]*wire.MsgTx
+	broadcast []*wire.MsgTx
+}
+
+func newFakeElectrumServer(t *testing.T) *fakeElectrumServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	s := &fakeElectrumServer{
+		t:         t,
+		listener:  listener,
+		headers:   []*wire.BlockHeader{&chaincfg.SimNetParams.GenesisBlock.Header},
+		histories: make(map[string][]electrumHistoryItem),
+		txs:       make(map[string]*wire.MsgTx),
+	}
+	go s.serve()
+	return s
+}
+
+// extend appends n headers to the chain after the header at the height.
+func (s *fakeElectrumServer) extend(height int32, n int, nonce uint32) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.headers = s.headers[:height+1]
+	for i := 0; i < n; i++ {
+		prev := s.headers[len(s.headers)-1]
+		s.headers = append(s.headers, &wire.BlockHeader{
+			Version:   1,
+			PrevBlock: prev.BlockHash(),
+			Timestamp: time.Unix(time.Now().Unix(), 0),
+			Nonce:     nonce,
+		})
+	}
+}
+
+// addTx adds a transaction to the history of the address at the height.
+func (s *fakeElectrumServer) addTx(addr btcutil.Address, tx *wire.MsgTx,
+	height int32) {
+
+	scriptHash, err := addrScriptHash(addr)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	txHash := tx.TxHash().String()
+	s.txs[txHash] = tx
+	s.histories[scriptHash] = append(s.histories[scriptHash],
+		electrumHistoryItem{Height: height, TxHash: txHash})
+}
+
+// notifyTip notifies the client of the tip of the chain.
+func (s *fakeElectrumServer) notifyTip() {
+	s.mtx.Lock()
+	tip := s.tipLocked()
+	conn := s.conn
+	s.mtx.Unlock()
+	s.write(conn, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "blockchain.headers.subscribe",
+		"params":  []interface{}{tip},
+	})
+}
+
+func (s *fakeElectrumServer) tipLocked() electrumHeader {
+	height := len(s.headers) - 1
+	return electrumHeader{
+		Height: int32(height),
+		Hex:    headerHex(s.t, s.headers[height]),
+	}
+}
+
+func (s *fakeElectrumServer) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	s.mtx.Lock()
+	s.conn = conn
+	s.mtx.Unlock()
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.t.Errorf("invalid request: %v", err)
+			return
+		}
+		s.write(conn, map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  s.handle(req.Method, req.Params),
+		})
+	}
+}
+
+func (s *fakeElectrumServer) handle(method string,
+	params []json.RawMessage) interface{} {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var (
+		str    string
+		height int32
+		count  int32
+	)
+	switch method {
+	case "server.version":
+		return []string{"fake", electrumProtocolVersion}
+	case "server.features":
+		return map[string]string{
+			"genesis_hash": chaincfg.SimNetParams.GenesisHash.String(),
+		}
+	case "blockchain.headers.subscribe":
+		return s.tipLocked()
+	case "blockchain.block.header":
+		_ = json.Unmarshal(params[0], &height)
+		return headerHex(s.t, s.headers[height])
+	case "blockchain.block.headers":
+		_ = json.Unmarshal(params[0], &height)
+		_ = json.Unmarshal(params[1], &count)
+		var buf bytes.Buffer
+		n := int32(0)
+		for h := height; h < height+count && int(h) < len(s.headers); h++ {
+			_ = s.headers[h].Serialize(&buf)
+			n++
+		}
+		return map[string]interface{}{
+			"count": n,
+			"hex":   hex.EncodeToString(buf.Bytes()),
+		}
+	case "blockchain.scripthash.subscribe":
+		return nil
+	case "blockchain.scripthash.get_history":
+		_ = json.Unmarshal(params[0], &str)
+		history := s.histories[str]
+		if history == nil {
+			history = []electrumHistoryItem{}
+		}
+		return history
+	case "blockchain.transaction.get":
+		_ = json.Unmarshal(params[0], &str)
+		var buf bytes.Buffer
+		_ = s.txs[str].Serialize(&buf)
+		return hex.EncodeToString(buf.Bytes())
+	case "blockchain.transaction.broadcast":
+		_ = json.Unmarshal(params[0], &str)
+		serialized, _ := hex.DecodeString(str)
+		tx := &wire.MsgTx{}
+		_ = tx.Deserialize(bytes.NewReader(serialized))
+		s.broadcast = append(s.broadcast, tx)
+		return tx.TxHash().String()
+	}
+	return nil
+}
+
+func (s *fakeElectrumServer) write(conn net.Conn, msg interface{}) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	_, _ = conn.Write(append(b, '\n'))
+}
+
+func headerHex(t *testing.T, header *wire.BlockHeader) string {
+	var buf bytes.Buffer
+	if err := header.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(buf.Bytes())
+}
+
+// testTx returns a transaction paying to the address, which is made unique by
+// the index of its input.
+func testTx(t *testing.T, addr btcutil.Address, index uint32) *wire.MsgTx {
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, index),
+		nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, pkScript))
+	return tx
+}
+
+// startElectrumClient starts a client of the server and reads its
+// ClientConnected notification.
+func startElectrumClient(t *testing.T, s *fakeElectrumServer) *ElectrumClient {
+	c := NewElectrumClient(&ElectrumConfig{
+		ChainParams: &chaincfg.SimNetParams,
+		Host:        s.listener.Addr().String(),
+		DisableTLS:  true,
+	})
+	if err := c.Start(); err != nil {
+		t.Fatalf("unable to start client: %v", err)
+	}
+	if _, ok := nextNtfn(t, c).(ClientConnected); !ok {
+		t.Fatal("expected ClientConnected notification")
+	}
+	return c
+}
+
+func nextNtfn(t *testing.T, c *ElectrumClient) interface{} {
+	select {
+	case n := <-c.Notifications():
+		return n
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for notification")
+		return nil
+	}
+}
+
+// TestElectrumClientRescan tests that a rescan notifies the transactions of the
+// addresses mined after the start block and unmined transactions.
+func TestElectrumClientRescan(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeElectrumServer(t)
+	defer s.listener.Close()
+	s.extend(0, 5, 0)
+
+	addr, err := btcutil.NewAddressPubKeyHash(
+		make([]byte, 20), &chaincfg.SimNetParams,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldTx, minedTx, unminedTx := testTx(t, addr, 0), testTx(t, addr, 1),
+		testTx(t, addr, 2)
+	s.addTx(addr, unminedTx, 0)
+	s.addTx(addr, minedTx, 3)
+	s.addTx(addr, oldTx, 1)
+
+	c := startElectrumClient(t, s)
+	defer c.WaitForShutdown()
+	defer c.Stop()
+
+	bestHash, bestHeight, err := c.GetBestBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bestHeight != 5 || *bestHash != s.headers[5].BlockHash() {
+		t.Fatalf("unexpected best block %v (height %d)", bestHash,
+			bestHeight)
+	}
+
+	startHash, err := c.GetBlockHash(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *startHash != s.headers[2].BlockHash() {
+		t.Fatalf("unexpected hash %v of height 2", startHash)
+	}
+	header, err := c.GetBlockHeader(startHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.BlockHash() != *startHash {
+		t.Fatal("unexpected header")
+	}
+
+	err = c.Rescan(startHash, []btcutil.Address{addr}, nil)
+	if err != nil {
+		t.Fatalf("rescan failed: %v", err)
+	}
+
+	n := nextNtfn(t, c).(RelevantTx)
+	if n.TxRecord.Hash != minedTx.TxHash() || n.Block == nil ||
+		n.Block.Height != 3 || n.Block.Hash != s.headers[3].BlockHash() {
+
+		t.Fatalf("unexpected mined relevant tx %v", n.TxRecord.Hash)
+	}
+	n = nextNtfn(t, c).(RelevantTx)
+	if n.TxRecord.Hash != unminedTx.TxHash() || n.Block != nil {
+		t.Fatalf("unexpected unmined relevant tx %v", n.TxRecord.Hash)
+	}
+	finished := nextNtfn(t, c).(*RescanFinished)
+	if finished.Height != 5 || *finished.Hash != s.headers[5].BlockHash() {
+		t.Fatalf("unexpected rescan finished height %d",
+			finished.Height)
+	}
+
+	// Mining the unmined transaction notifies it again with its block.
+	s.mtx.Lock()
+	for scriptHash, history := range s.histories {
+		for i := range history {
+			if history[i].TxHash == unminedTx.TxHash().String() {
+				history[i].Height = 4
+			}
+		}
+		s.histories[scriptHash] = history
+	}
+	s.mtx.Unlock()
+	scriptHash, _ := addrScriptHash(addr)
+	s.write(s.conn, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "blockchain.scripthash.subscribe",
+		"params":  []interface{}{scriptHash, "status"},
+	})
+	n = nextNtfn(t, c).(RelevantTx)
+	if n.TxRecord.Hash != unminedTx.TxHash() || n.Block == nil ||
+		n.Block.Height != 4 {
+
+		t.Fatalf("unexpected relevant tx %v", n.TxRecord.Hash)
+	}
+}
+
+// TestElectrumClientBlockNtfns tests that new tips of the server are notified
+// as connected blocks, and that reorganized blocks are notified as
+// disconnected.
+func TestElectrumClientBlockNtfns(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeElectrumServer(t)
+	defer s.listener.Close()
+	s.extend(0, 3, 0)
+
+	c := startElectrumClient(t, s)
+	defer c.WaitForShutdown()
+	defer c.Stop()
+
+	if err := c.NotifyBlocks(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.extend(3, 2, 0)
+	s.notifyTip()
+	for height := int32(4); height <= 5; height++ {
+		n := nextNtfn(t, c).(BlockConnected)
+		if n.Height != height || n.Hash != s.headers[height].BlockHash() {
+			t.Fatalf("unexpected connected block %v (height %d)",
+				n.Hash, n.Height)
+		}
+	}
+
+	// Replace the blocks after height 3 with a longer chain.
+	oldHeaders := append([]*wire.BlockHeader(nil), s.headers...)
+	s.extend(3, 3, 1)
+	s.notifyTip()
+	for height := int32(5); height >= 4; height-- {
+		n := nextNtfn(t, c).(BlockDisconnected)
+		if n.Height != height || n.Hash != oldHeaders[height].BlockHash() {
+			t.Fatalf("unexpected disconnected block %v (height %d)",
+				n.Hash, n.Height)
+		}
+	}
+	for height := int32(4); height <= 6; height++ {
+		n := nextNtfn(t, c).(BlockConnected)
+		if n.Height != height || n.Hash != s.headers[height].BlockHash() {
+			t.Fatalf("unexpected connected block %v (height %d)",
+				n.Hash, n.Height)
+		}
+	}
+
+	bs, err := c.BlockStamp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs.Height != 6 || bs.Hash != s.headers[6].BlockHash() {
+		t.Fatalf("unexpected best block %v (height %d)", bs.Hash,
+			bs.Height)
+	}
+	oldHash := oldHeaders[5].BlockHash()
+	if _, err := c.GetBlockHeader(&oldHash); err == nil {
+		t.Fatal("expected disconnected block to be unknown")
+	}
+}
+
+// TestElectrumClientFilterBlocks tests that the first requested block with
+// transactions of the requested addresses is found, and that transactions are
+// broadcast through the server.
+func TestElectrumClientFilterBlocks(t *testing.T) {
+	t.Parallel()
+
+	s := newFakeElectrumServer(t)
+	defer s.listener.Close()
+	s.extend(0, 6, 0)
+
+	addr, err := btcutil.NewAddressPubKeyHash(
+		make([]byte, 20), &chaincfg.SimNetParams,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	earlyTx, tx := testTx(t, addr, 0), testTx(t, addr, 1)
+	s.addTx(addr, earlyTx, 1)
+	s.addTx(addr, tx, 5)
+
+	c := startElectrumClient(t, s)
+	defer c.WaitForShutdown()
+	defer c.Stop()
+
+	req := &FilterBlocksRequest{
+		ExternalAddrs: map[waddrmgr.ScopedIndex]btcutil.Address{
+			{Scope: waddrmgr.KeyScopeBIP0044, Index: 7}: addr,
+		},
+	}
+	for height := int32(3); height <= 6; height++ {
+		req.Blocks = append(req.Blocks, wtxmgr.BlockMeta{
+			Block: wtxmgr.Block{
+				Hash:   s.headers[height].BlockHash(),
+				Height: height,
+			},
+		})
+	}
+	resp, err := c.FilterBlocks(req)
+	if err != nil {
+		t.Fatalf("unable to filter blocks: %v", err)
+	}
+	if resp == nil || resp.BatchIndex != 2 || resp.BlockMeta.Height != 5 {
+		t.Fatalf("unexpected response %v", resp)
+	}
+	if len(resp.RelevantTxns) != 1 ||
+		resp.RelevantTxns[0].TxHash() != tx.TxHash() {
+
+		t.Fatalf("unexpected relevant transactions %v",
+			resp.RelevantTxns)
+	}
+	found := resp.FoundExternalAddrs[waddrmgr.KeyScopeBIP0044]
+	if _, ok := found[7]; !ok {
+		t.Fatalf("external address not found")
+	}
+
+	txHash, err := c.SendRawTransaction(tx, false)
+	if err != nil {
+		t.Fatalf("unable to broadcast: %v", err)
+	}
+	if *txHash != tx.TxHash() || len(s.broadcast) != 1 {
+		t.Fatalf("unexpected broadcast of %v", txHash)
+	}
+}
@@ -1,6 +1,7 @@
 package chain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -25,6 +26,18 @@ func BackEnds() []string {
 	}
 }
 
+// ErrNoFeeEstimate is returned by FeeEstimator implementations when the chain
+// server has too little data to estimate a fee rate.
+var ErrNoFeeEstimate = errors.New("no fee rate estimate is available")
+
+// FeeEstimator is implemented by chain clients whose server estimates the fee
+// rate a transaction must pay to be mined within a number of blocks.
+type FeeEstimator interface {
+	// EstimateFeePerKb returns the fee per kilobyte of virtual size
+	// estimated for a transaction to be mined within confTarget blocks.
+	EstimateFeePerKb(confTarget uint32) (btcutil.Amount, error)
+}
+
 // Interface allows more than one backing blockchain source, such as a
 // btcd RPC chain server, or an SPV library, as long as we write a driver for
 // it.
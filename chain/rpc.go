@@ -6,6 +6,7 @@ package chain
 
 import (
 	"errors"
+	"runtime"
 	"sync"
 	"time"
 
@@ -21,6 +22,11 @@ import (
 	"github.com/btcsuite/btcwallet/wtxmgr"
 )
 
+// filterBlocksWindow is the number of compact filters FilterBlocks requests
+// from btcd before matching them, pipelining the requests of a window while
+// the filters of the previous window are matched.
+const filterBlocksWindow = 100
+
 // RPCClient represents a persistent client connection to a bitcoin RPC server
 // for information regarding the current best block chain.
 type RPCClient struct {
@@ -120,9 +126,33 @@ func (c *RPCClient) Start() error {
 
 	c.wg.Add(1)
 	go c.handler()
+
+	// Without automatic reconnects, the client shuts down when it is
+	// disconnected, and the RPC client is stopped with it.
+	if c.connConfig.DisableAutoReconnect {
+		go func() {
+			c.Client.WaitForShutdown()
+			c.Stop()
+		}()
+	}
 	return nil
 }
 
+// DisableAutoReconnect disables reconnecting to the server after the client is
+// disconnected.  Instead, the client is stopped, allowing the caller to fail
+// over to another server.  It must be called before Start.
+func (c *RPCClient) DisableAutoReconnect() {
+	c.connConfig.DisableAutoReconnect = true
+}
+
+// SetProxy sets the SOCKS5 proxy, and its credentials, the connection to the
+// server is made through.  It must be called before Start.
+func (c *RPCClient) SetProxy(proxy, user, pass string) {
+	c.connConfig.Proxy = proxy
+	c.connConfig.ProxyUser = user
+	c.connConfig.ProxyPass = pass
+}
+
 // Stop disconnects the client and signals the shutdown of all goroutines
 // started by Start.
 func (c *RPCClient) Stop() {
@@ -186,6 +216,21 @@ func (c *RPCClient) Notifications() <-chan interface{} {
 	return c.dequeueNotification
 }
 
+// EstimateFeePerKb returns the fee per kilobyte estimated by btcd for a
+// transaction to be mined within confTarget blocks.
+//
+// NOTE: This is part of the FeeEstimator interface.
+func (c *RPCClient) EstimateFeePerKb(confTarget uint32) (btcutil.Amount, error) {
+	feeRate, err := c.EstimateFee(int64(confTarget))
+	if err != nil {
+		return 0, err
+	}
+	if feeRate <= 0 {
+		return 0, ErrNoFeeEstimate
+	}
+	return btcutil.NewAmount(feeRate)
+}
+
 // BlockStamp returns the latest block notified by the client, or an error
 // if the client has been shut down.
 func (c *RPCClient) BlockStamp() (*waddrmgr.BlockStamp, error) {
@@ -216,72 +261,162 @@ func (c *RPCClient) FilterBlocks(
 		return nil, err
 	}
 
-	// Iterate over the requested blocks, fetching the compact filter for
-	// each one, and matching it against the watchlist generated above. If
-	// the filter returns a positive match, the full block is then requested
+	// The compact filters are requested in windows of blocks, with the
+	// filters of the next window requested before those of the current
+	// window are matched, so that btcd serves them while matching.  If a
+	// filter returns a positive match, the full block is then requested
 	// and scanned for addresses using the block filterer.
-	for i, blk := range req.Blocks {
-		rawFilter, err := c.GetCFilter(&blk.Hash, wire.GCSFilterRegular)
-		if err != nil {
-			return nil, err
+	requestFilters := func(start int) []rpcclient.FutureGetCFilterResult {
+		end := start + filterBlocksWindow
+		if end > len(req.Blocks) {
+			end = len(req.Blocks)
 		}
+		futures := make([]rpcclient.FutureGetCFilterResult, end-start)
+		for i := range futures {
+			futures[i] = c.GetCFilterAsync(
+				&req.Blocks[start+i].Hash, wire.GCSFilterRegular,
+			)
+		}
+		return futures
+	}
 
-		// Ensure the filter is large enough to be deserialized.
-		if len(rawFilter.Data) < 4 {
-			continue
+	var nextFilters []rpcclient.FutureGetCFilterResult
+	if len(req.Blocks) > 0 {
+		nextFilters = requestFilters(0)
+	}
+	for start := 0; start < len(req.Blocks); start += filterBlocksWindow {
+		filters := nextFilters
+		if start+filterBlocksWindow < len(req.Blocks) {
+			nextFilters = requestFilters(start + filterBlocksWindow)
 		}
 
-		filter, err := gcs.FromNBytes(
-			builder.DefaultP, builder.DefaultM, rawFilter.Data,
-		)
+		blocks := req.Blocks[start : start+len(filters)]
+		matched, err := matchCFilters(blocks, watchList,
+			func(i int) (*wire.MsgCFilter, error) {
+				return filters[i].Receive()
+			})
 		if err != nil {
 			return nil, err
 		}
 
-		// Skip any empty filters.
-		if filter.N() == 0 {
-			continue
+		// Request every matched block of the window at once, and scan
+		// them in order.
+		rawBlocks := make(map[int]rpcclient.FutureGetBlockResult)
+		for i, blk := range blocks {
+			if matched[i] {
+				rawBlocks[i] = c.GetBlockAsync(&blk.Hash)
+			}
 		}
+		for i, blk := range blocks {
+			if !matched[i] {
+				continue
+			}
 
-		key := builder.DeriveKey(&blk.Hash)
-		matched, err := filter.MatchAny(key, watchList)
-		if err != nil {
-			return nil, err
-		} else if !matched {
-			continue
+			log.Infof("Fetching block height=%d hash=%v",
+				blk.Height, blk.Hash)
+
+			rawBlock, err := rawBlocks[i].Receive()
+			if err != nil {
+				return nil, err
+			}
+
+			if !blockFilterer.FilterBlock(rawBlock) {
+				continue
+			}
+
+			// If any external or internal addresses were detected
+			// in this block, we return them to the caller so that
+			// the rescan windows can widened with subsequent
+			// addresses. The `BatchIndex` is returned so that the
+			// caller can compute the *next* block from which to
+			// begin again.
+			resp := &FilterBlocksResponse{
+				BatchIndex:         uint32(start + i),
+				BlockMeta:          blk,
+				FoundExternalAddrs: blockFilterer.FoundExternal,
+				FoundInternalAddrs: blockFilterer.FoundInternal,
+				FoundOutPoints:     blockFilterer.FoundOutPoints,
+				RelevantTxns:       blockFilterer.RelevantTxns,
+			}
+
+			return resp, nil
 		}
+	}
+
+	// No addresses were found for this range.
+	return nil, nil
+}
+
+// matchCFilters matches the compact filters of the blocks against the
+// watchlist, returning whether each block may contain a watched item.  The
+// filter of the block at each index is retrieved with fetch, and filters are
+// retrieved and matched concurrently by a pool of workers.
+func matchCFilters(blocks []wtxmgr.BlockMeta, watchList [][]byte,
+	fetch func(i int) (*wire.MsgCFilter, error)) ([]bool, error) {
 
-		log.Infof("Fetching block height=%d hash=%v",
-			blk.Height, blk.Hash)
+	matched := make([]bool, len(blocks))
+	errs := make([]error, len(blocks))
+
+	workers := runtime.NumCPU()
+	if workers > len(blocks) {
+		workers = len(blocks)
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				matched[i], errs[i] = matchCFilter(
+					&blocks[i].Hash, watchList, fetch, i,
+				)
+			}
+		}()
+	}
+	for i := range blocks {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
 
-		rawBlock, err := c.GetBlock(&blk.Hash)
+	for _, err := range errs {
 		if err != nil {
 			return nil, err
 		}
+	}
+	return matched, nil
+}
 
-		if !blockFilterer.FilterBlock(rawBlock) {
-			continue
-		}
+// matchCFilter matches the compact filter of a block, retrieved by fetch,
+// against the watchlist.
+func matchCFilter(blockHash *chainhash.Hash, watchList [][]byte,
+	fetch func(i int) (*wire.MsgCFilter, error), i int) (bool, error) {
 
-		// If any external or internal addresses were detected in this
-		// block, we return them to the caller so that the rescan
-		// windows can widened with subsequent addresses. The
-		// `BatchIndex` is returned so that the caller can compute the
-		// *next* block from which to begin again.
-		resp := &FilterBlocksResponse{
-			BatchIndex:         uint32(i),
-			BlockMeta:          blk,
-			FoundExternalAddrs: blockFilterer.FoundExternal,
-			FoundInternalAddrs: blockFilterer.FoundInternal,
-			FoundOutPoints:     blockFilterer.FoundOutPoints,
-			RelevantTxns:       blockFilterer.RelevantTxns,
-		}
+	rawFilter, err := fetch(i)
+	if err != nil {
+		return false, err
+	}
 
-		return resp, nil
+	// Ensure the filter is large enough to be deserialized.
+	if len(rawFilter.Data) < 4 {
+		return false, nil
 	}
 
-	// No addresses were found for this range.
-	return nil, nil
+	filter, err := gcs.FromNBytes(
+		builder.DefaultP, builder.DefaultM, rawFilter.Data,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	// Skip any empty filters.
+	if filter.N() == 0 {
+		return false, nil
+	}
+
+	key := builder.DeriveKey(blockHash)
+	return filter.MatchAny(key, watchList)
 }
 
 // parseBlock parses a btcws definition of the block a tx is mined it to the
@@ -338,6 +473,11 @@ func (c *RPCClient) onBlockDisconnected(hash *chainhash.Hash, height int32, time
 	}
 }
 
+// onRecvTx handles a recvtx notification of a transaction paying to an address
+// registered by NotifyReceived or by a rescan through the best block.  btcd
+// notifies transactions as they are accepted to its mempool as well as when
+// they are mined, so unconfirmed credits are notified as relevant transactions
+// without a block as soon as btcd sees them.
 func (c *RPCClient) onRecvTx(tx *btcutil.Tx, block *btcjson.BlockDetails) {
 	blk, err := parseBlock(block)
 	if err != nil {
@@ -0,0 +1,109 @@
+package chain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/gcs"
+	"github.com/btcsuite/btcutil/gcs/builder"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMatchCFilters ensures the compact filters of a batch of blocks are
+// matched against the watchlist at the index of their block.
+func TestMatchCFilters(t *testing.T) {
+	t.Parallel()
+
+	watched := []byte("watched script")
+	unwatched := []byte("unwatched script")
+
+	blocks := make([]wtxmgr.BlockMeta, 20)
+	filters := make([]*wire.MsgCFilter, len(blocks))
+	for i := range blocks {
+		blocks[i].Hash = chainhash.Hash{byte(i)}
+		blocks[i].Height = int32(i)
+
+		var entries [][]byte
+		switch {
+		// Every third block has an empty filter.
+		case i%3 == 0:
+			filters[i] = &wire.MsgCFilter{}
+			continue
+
+		case i%5 == 0:
+			entries = [][]byte{unwatched, watched}
+
+		default:
+			entries = [][]byte{unwatched}
+		}
+
+		key := builder.DeriveKey(&blocks[i].Hash)
+		filter, err := gcs.BuildGCSFilter(
+			builder.DefaultP, builder.DefaultM, key, entries,
+		)
+		require.NoError(t, err)
+		data, err := filter.NBytes()
+		require.NoError(t, err)
+		filters[i] = &wire.MsgCFilter{Data: data}
+	}
+
+	fetch := func(i int) (*wire.MsgCFilter, error) {
+		return filters[i], nil
+	}
+	matched, err := matchCFilters(blocks, [][]byte{watched}, fetch)
+	require.NoError(t, err)
+	for i := range blocks {
+		require.Equal(t, i%3 != 0 && i%5 == 0, matched[i],
+			"block %d", i)
+	}
+
+	// A failure to fetch any of the filters should be returned.
+	errFetch := errors.New("fetch failed")
+	_, err = matchCFilters(blocks, [][]byte{watched},
+		func(i int) (*wire.MsgCFilter, error) {
+			if i == 7 {
+				return nil, errFetch
+			}
+			return filters[i], nil
+		})
+	require.Equal(t, errFetch, err)
+}
+
+// TestRecvTxMempool ensures that transactions btcd notifies as accepted to its
+// mempool are notified as unmined relevant transactions, and mined
+// transactions with their block.
+func TestRecvTxMempool(t *testing.T) {
+	t.Parallel()
+
+	c := &RPCClient{
+		enqueueNotification: make(chan interface{}, 1),
+		quit:                make(chan struct{}),
+	}
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e6, []byte{txscript.OP_TRUE}))
+	tx := btcutil.NewTx(msgTx)
+
+	c.onRecvTx(tx, nil)
+	n := (<-c.enqueueNotification).(RelevantTx)
+	require.Equal(t, msgTx.TxHash(), n.TxRecord.Hash)
+	require.Nil(t, n.Block)
+
+	blockHash := chainhash.Hash{1}
+	c.onRecvTx(tx, &btcjson.BlockDetails{
+		Hash:   blockHash.String(),
+		Height: 100,
+		Time:   1600000000,
+	})
+	n = (<-c.enqueueNotification).(RelevantTx)
+	require.Equal(t, msgTx.TxHash(), n.TxRecord.Hash)
+	require.NotNil(t, n.Block)
+	require.Equal(t, blockHash, n.Block.Hash)
+	require.Equal(t, int32(100), n.Block.Height)
+}
@@ -0,0 +1,322 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/cfgutil"
+	_ "github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/netparams"
+	"github.com/btcsuite/websocket"
+	"github.com/jessevdk/go-flags"
+)
+
+var (
+	walletDataDirectory = btcutil.AppDataDir("btcwallet", false)
+	newlineBytes        = []byte{'\n'}
+)
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format, args...)
+	os.Stderr.Write(newlineBytes)
+	os.Exit(1)
+}
+
+func errContext(err error, context string) error {
+	return fmt.Errorf("%s: %v", context, err)
+}
+
+// Flags.
+var opts = struct {
+	TestNet3           bool   `long:"testnet" description:"Use the test bitcoin network (version 3)"`
+	SimNet             bool   `long:"simnet" description:"Use the simulation bitcoin network"`
+	RegTest            bool   `long:"regtest" description:"Use the regression test bitcoin network"`
+	SigNet             bool   `long:"signet" description:"Use the signet test bitcoin network"`
+	RPCConnect         string `short:"c" long:"connect" description:"Hostname[:port] of wallet RPC server"`
+	RPCUsername        string `short:"u" long:"rpcuser" description:"Wallet RPC username (the RPC cookie is used if unset)"`
+	RPCPassword        string `short:"P" long:"rpcpass" default-mask:"-" description:"Wallet RPC password"`
+	RPCCertificateFile string `long:"cafile" description:"Wallet RPC TLS certificate"`
+	ClientCertFile     string `long:"clientcert" description:"TLS certificate to authenticate to the wallet RPC server with"`
+	ClientKeyFile      string `long:"clientkey" description:"Key of the TLS client certificate"`
+	NoTLS              bool   `long:"notls" description:"Disable TLS"`
+	Timeout            uint32 `long:"timeout" description:"Seconds to wait for the reply to the request"`
+	ListCommands       bool   `short:"l" long:"listcommands" description:"List the wallet commands and exit"`
+}{
+	RPCConnect:         "localhost",
+	RPCCertificateFile: filepath.Join(walletDataDirectory, "rpc.cert"),
+	Timeout:            60,
+}
+
+// activeNet is the network of the wallet RPC server.
+var activeNet = &netparams.MainNetParams
+
+// args are the command and its parameters.
+var args []string
+
+// Parse and validate flags.
+func init() {
+	parser := flags.NewParser(&opts, flags.Default)
+	parser.Usage = "[OPTIONS] <command> <args...>"
+	var err error
+	args, err = parser.Parse()
+	if err != nil {
+		os.Exit(1)
+	}
+	if opts.ListCommands {
+		listCommands()
+		os.Exit(0)
+	}
+
+	numNets := 0
+	for _, net := range []bool{opts.TestNet3, opts.SimNet, opts.RegTest, opts.SigNet} {
+		if net {
+			numNets++
+		}
+	}
+	if numNets > 1 {
+		fatalf("Multiple bitcoin networks may not be used simultaneously")
+	}
+	if opts.TestNet3 {
+		activeNet = &netparams.TestNet3Params
+	} else if opts.SimNet {
+		activeNet = &netparams.SimNetParams
+	} else if opts.RegTest {
+		activeNet = &netparams.RegressionNetParams
+	} else if opts.SigNet {
+		activeNet = &netparams.SigNetParams
+	}
+
+	rpcConnect, err := cfgutil.NormalizeAddress(opts.RPCConnect, activeNet.RPCServerPort)
+	if err != nil {
+		fatalf("Invalid RPC network address `%v`: %v", opts.RPCConnect, err)
+	}
+	opts.RPCConnect = rpcConnect
+
+	if (opts.ClientCertFile == "") != (opts.ClientKeyFile == "") {
+		fatalf("Both a client certificate and key are required")
+	}
+	if len(args) == 0 {
+		fatalf("No command specified (specify -l to list commands)")
+	}
+}
+
+// listCommands prints the usage of every wallet command.
+func listCommands() {
+	var usages []string
+	for _, method := range btcjson.RegisteredCmdMethods() {
+		flags, err := btcjson.MethodUsageFlags(method)
+		if err != nil || flags&btcjson.UFWalletOnly == 0 ||
+			flags&btcjson.UFNotification != 0 {
+
+			continue
+		}
+		usage, err := btcjson.MethodUsageText(method)
+		if err != nil {
+			continue
+		}
+		usages = append(usages, usage)
+	}
+	sort.Strings(usages)
+	for _, usage := range usages {
+		fmt.Println(usage)
+	}
+}
+
+// networkDir returns the directory of the wallet files of the active network.
+func networkDir() string {
+	netname := activeNet.Params.Name
+	if activeNet.Net == wire.TestNet3 {
+		netname = "testnet"
+	}
+	return filepath.Join(walletDataDirectory, netname)
+}
+
+// credentials returns the RPC username and password, which are read from the
+// RPC cookie when no username is set.
+func credentials() (username, password string, err error) {
+	if opts.RPCUsername != "" {
+		return opts.RPCUsername, opts.RPCPassword, nil
+	}
+	cookie, err := ioutil.ReadFile(filepath.Join(networkDir(), ".cookie"))
+	if err != nil {
+		return "", "", errContext(err, "RPC username is unset and "+
+			"the RPC cookie can not be read")
+	}
+	parts := strings.SplitN(strings.TrimSpace(string(cookie)), ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("malformed RPC cookie")
+	}
+	return parts[0], parts[1], nil
+}
+
+// dial opens an authenticated websocket connection to the wallet RPC server.
+func dial() (*websocket.Conn, error) {
+	username, password, err := credentials()
+	if err != nil {
+		return nil, err
+	}
+	login := username + ":" + password
+	header := make(http.Header)
+	header.Set("Authorization", "Basic "+
+		base64.StdEncoding.EncodeToString([]byte(login)))
+
+	dialer := websocket.Dialer{
+		HandshakeTimeout: 10 * time.Second,
+	}
+	scheme := "ws"
+	if !opts.NoTLS {
+		scheme = "wss"
+		pem, err := ioutil.ReadFile(opts.RPCCertificateFile)
+		if err != nil {
+			return nil, errContext(err, "failed to read RPC certificate")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s",
+				opts.RPCCertificateFile)
+		}
+		dialer.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+		if opts.ClientCertFile != "" {
+			cert, err := tls.LoadX509KeyPair(opts.ClientCertFile,
+				opts.ClientKeyFile)
+			if err != nil {
+				return nil, errContext(err, "failed to load "+
+					"client certificate")
+			}
+			dialer.TLSClientConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	url := fmt.Sprintf("%s://%s/ws", scheme, opts.RPCConnect)
+	conn, resp, err := dialer.Dial(url, header)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("RPC authentication failed")
+		}
+		return nil, errContext(err, "failed to connect to wallet RPC server")
+	}
+	return conn, nil
+}
+
+// request sends the request to the wallet RPC server and returns the result of
+// its reply.  Notifications received before the reply are ignored.
+func request(conn *websocket.Conn, marshalledJSON []byte) (json.RawMessage, error) {
+	err := conn.WriteMessage(websocket.TextMessage, marshalledJSON)
+	if err != nil {
+		return nil, errContext(err, "failed to send request")
+	}
+
+	deadline := time.Now().Add(time.Duration(opts.Timeout) * time.Second)
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return nil, errContext(err, "failed to read reply")
+		}
+		var resp struct {
+			Result json.RawMessage   `json:"result"`
+			Error  *btcjson.RPCError `json:"error"`
+			ID     *json.RawMessage  `json:"id"`
+			Method *string           `json:"method"`
+		}
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			return nil, errContext(err, "failed to parse reply")
+		}
+		if resp.Method != nil || resp.ID == nil {
+			continue
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	}
+}
+
+func main() {
+	err := run()
+	if err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func run() error {
+	method := args[0]
+	usageFlags, err := btcjson.MethodUsageFlags(method)
+	if err != nil {
+		return fmt.Errorf("unrecognized command `%s` (specify -l to "+
+			"list commands)", method)
+	}
+	if usageFlags&btcjson.UFNotification != 0 {
+		return fmt.Errorf("`%s` is a notification, not a command",
+			method)
+	}
+
+	params := make([]interface{}, 0, len(args[1:]))
+	for _, arg := range args[1:] {
+		params = append(params, arg)
+	}
+	cmd, err := btcjson.NewCmd(method, params...)
+	if err != nil {
+		usage, _ := btcjson.MethodUsageText(method)
+		return fmt.Errorf("%s command: %v\nUsage:\n  %s", method, err,
+			usage)
+	}
+	marshalledJSON, err := btcjson.MarshalCmd(btcjson.RpcVersion1, 1, cmd)
+	if err != nil {
+		return err
+	}
+
+	conn, err := dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	result, err := request(conn, marshalledJSON)
+	if err != nil {
+		return err
+	}
+
+	// Objects and arrays are indented, strings are printed unquoted, and
+	// null results are not printed.
+	switch {
+	case bytes.HasPrefix(result, []byte("{")), bytes.HasPrefix(result, []byte("[")):
+		var dst bytes.Buffer
+		if err := json.Indent(&dst, result, "", "  "); err != nil {
+			return errContext(err, "failed to format result")
+		}
+		fmt.Println(dst.String())
+	case bytes.HasPrefix(result, []byte(`"`)):
+		var str string
+		if err := json.Unmarshal(result, &str); err != nil {
+			return errContext(err, "failed to unmarshal result")
+		}
+		fmt.Println(str)
+	case len(result) != 0 && string(result) != "null":
+		fmt.Println(string(result))
+	}
+	return nil
+}
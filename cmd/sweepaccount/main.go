@@ -19,6 +19,7 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/internal/cfgutil"
+	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/btcsuite/btcwallet/netparams"
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
@@ -45,6 +46,8 @@ func errContext(err error, context string) error {
 var opts = struct {
 	TestNet3              bool                `long:"testnet" description:"Use the test bitcoin network (version 3)"`
 	SimNet                bool                `long:"simnet" description:"Use the simulation bitcoin network"`
+	RegTest               bool                `long:"regtest" description:"Use the regression test bitcoin network"`
+	SigNet                bool                `long:"signet" description:"Use the signet test bitcoin network"`
 	RPCConnect            string              `short:"c" long:"connect" description:"Hostname[:port] of wallet RPC server"`
 	RPCUsername           string              `short:"u" long:"rpcuser" description:"Wallet RPC username"`
 	RPCCertificateFile    string              `long:"cafile" description:"Wallet RPC TLS certificate"`
@@ -55,6 +58,8 @@ var opts = struct {
 }{
 	TestNet3:              false,
 	SimNet:                false,
+	RegTest:               false,
+	SigNet:                false,
 	RPCConnect:            "localhost",
 	RPCUsername:           "",
 	RPCCertificateFile:    filepath.Join(walletDataDirectory, "rpc.cert"),
@@ -81,7 +86,13 @@ func init() {
 		os.Exit(1)
 	}
 
-	if opts.TestNet3 && opts.SimNet {
+	numNets := 0
+	for _, net := range []bool{opts.TestNet3, opts.SimNet, opts.RegTest, opts.SigNet} {
+		if net {
+			numNets++
+		}
+	}
+	if numNets > 1 {
 		fatalf("Multiple bitcoin networks may not be used simultaneously")
 	}
 	var activeNet = &netparams.MainNetParams
@@ -89,6 +100,10 @@ func init() {
 		activeNet = &netparams.TestNet3Params
 	} else if opts.SimNet {
 		activeNet = &netparams.SimNetParams
+	} else if opts.RegTest {
+		activeNet = &netparams.RegressionNetParams
+	} else if opts.SigNet {
+		activeNet = &netparams.SigNetParams
 	}
 
 	if opts.RPCConnect == "" {
@@ -341,6 +356,7 @@ func promptSecret(what string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	defer zero.Bytes(input)
 	return string(input), nil
 }
 
@@ -5,35 +5,49 @@
 package main
 
 import (
+	"encoding/base32"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/connmgr"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/internal/cfgutil"
 	"github.com/btcsuite/btcwallet/internal/legacy/keystore"
 	"github.com/btcsuite/btcwallet/netparams"
+	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
 	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/go-socks/socks"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/lightninglabs/neutrino"
 )
 
 const (
-	defaultCAFilename       = "btcd.cert"
-	defaultConfigFilename   = "btcwallet.conf"
-	defaultLogLevel         = "info"
-	defaultLogDirname       = "logs"
-	defaultLogFilename      = "btcwallet.log"
-	defaultRPCMaxClients    = 10
-	defaultRPCMaxWebsockets = 25
+	defaultCAFilename         = "btcd.cert"
+	defaultConfigFilename     = "btcwallet.conf"
+	defaultLogLevel           = "info"
+	defaultLogFormat          = "text"
+	defaultLogDirname         = "logs"
+	defaultLogFilename        = "btcwallet.log"
+	defaultRPCMaxClients      = 10
+	defaultRPCMaxWebsockets   = 25
+	defaultPublicRPCRateLimit = 60
+	defaultBitcoindZMQBlock   = "tcp://localhost:28332"
+	defaultBitcoindZMQTx      = "tcp://localhost:28333"
+	defaultBackupInterval     = 24 * time.Hour
+	defaultPriceFeedField     = "price"
+	defaultPriceFeedCurrency  = "USD"
 )
 
 var (
@@ -47,34 +61,63 @@ var (
 
 type config struct {
 	// General application behavior
-	ConfigFile      *cfgutil.ExplicitString `short:"C" long:"configfile" description:"Path to configuration file"`
-	ShowVersion     bool                    `short:"V" long:"version" description:"Display version information and exit"`
-	Create          bool                    `long:"create" description:"Create the wallet if it does not exist"`
-	CreateTemp      bool                    `long:"createtemp" description:"Create a temporary simulation wallet (pass=password) in the data directory indicated; must call with --datadir"`
-	AppDataDir      *cfgutil.ExplicitString `short:"A" long:"appdata" description:"Application data directory for wallet config, databases and logs"`
-	TestNet3        bool                    `long:"testnet" description:"Use the test Bitcoin network (version 3) (default mainnet)"`
-	SimNet          bool                    `long:"simnet" description:"Use the simulation test network (default mainnet)"`
-	SigNet          bool                    `long:"signet" description:"Use the signet test network (default mainnet)"`
-	SigNetChallenge string                  `long:"signetchallenge" description:"Connect to a custom signet network defined by this challenge instead of using the global default signet test network -- Can be specified multiple times"`
-	SigNetSeedNode  []string                `long:"signetseednode" description:"Specify a seed node for the signet network instead of using the global default signet network seed nodes"`
-	NoInitialLoad   bool                    `long:"noinitialload" description:"Defer wallet creation/opening on startup and enable loading wallets over RPC"`
-	DebugLevel      string                  `short:"d" long:"debuglevel" description:"Logging level {trace, debug, info, warn, error, critical}"`
-	LogDir          string                  `long:"logdir" description:"Directory to log output."`
-	Profile         string                  `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
-	DBTimeout       time.Duration           `long:"dbtimeout" description:"The timeout value to use when opening the wallet database."`
+	ConfigFile       *cfgutil.ExplicitString `short:"C" long:"configfile" description:"Path to configuration file"`
+	ShowVersion      bool                    `short:"V" long:"version" description:"Display version information and exit"`
+	Create           bool                    `long:"create" description:"Create the wallet if it does not exist"`
+	CreateTemp       bool                    `long:"createtemp" description:"Create a temporary simulation wallet (pass=password) in the data directory indicated; must call with --datadir and either --simnet or --regtest"`
+	AppDataDir       *cfgutil.ExplicitString `short:"A" long:"appdata" description:"Application data directory for wallet config, databases and logs"`
+	TestNet3         bool                    `long:"testnet" description:"Use the test Bitcoin network (version 3) (default mainnet)"`
+	SimNet           bool                    `long:"simnet" description:"Use the simulation test network (default mainnet)"`
+	RegTest          bool                    `long:"regtest" description:"Use the regression test network (default mainnet)"`
+	SigNet           bool                    `long:"signet" description:"Use the signet test network (default mainnet)"`
+	SigNetChallenge  string                  `long:"signetchallenge" description:"Connect to a custom signet network defined by this challenge instead of using the global default signet test network -- Can be specified multiple times"`
+	SigNetSeedNode   []string                `long:"signetseednode" description:"Specify a seed node for the signet network instead of using the global default signet network seed nodes"`
+	NoInitialLoad    bool                    `long:"noinitialload" description:"Defer wallet creation/opening on startup and enable loading wallets over RPC"`
+	DebugLevel       string                  `short:"d" long:"debuglevel" description:"Logging level {trace, debug, info, warn, error, critical}"`
+	LogDir           string                  `long:"logdir" description:"Directory to log output."`
+	LogFormat        string                  `long:"logformat" description:"Format of log output {text, json} -- JSON output holds one object per line with the time, level, subsystem and message of each record"`
+	Profile          string                  `long:"profile" description:"Enable HTTP profiling on given localhost port -- NOTE port must be between 1024 and 65535"`
+	DBTimeout        time.Duration           `long:"dbtimeout" description:"The timeout value to use when opening the wallet database."`
+	DBDriver         string                  `long:"dbdriver" description:"Database backend of the wallet {bdb, sqlite} -- Only used when the wallet is created or opened, existing wallets are not converted"`
+	MemoryWallet     bool                    `long:"memorywallet" description:"Keep the wallet in memory and never write it to disk -- A new wallet is created on every start, or over RPC when used with --noinitialload"`
+	MemoryWalletPass string                  `long:"memorywalletpass" default-mask:"-" description:"The private passphrase of the wallet created on start by --memorywallet -- A random passphrase which is never revealed is used when unset, leaving the private keys locked"`
+	DBPass           string                  `long:"dbpass" default-mask:"-" description:"Passphrase to encrypt the entire wallet database on disk with, separate from the private passphrase -- An existing unencrypted wallet is encrypted when opened"`
 
 	// Wallet options
-	WalletPass string `long:"walletpass" default-mask:"-" description:"The public wallet password -- Only required if the wallet was created with one"`
+	WalletPass        string        `long:"walletpass" default-mask:"-" description:"The public wallet password -- Only required if the wallet was created with one"`
+	Lookahead         uint32        `long:"lookahead" description:"Number of addresses past the last address handed out on each branch of every account that are watched for payments (0 disables the lookahead)"`
+	ChainStallTimeout time.Duration `long:"chainstalltimeout" description:"Duration without a new block after which the chain is considered stalled and sends are reported as risky (0 to disable)"`
+	UnminedExpiry     time.Duration `long:"unminedexpiry" description:"Duration after which sends which remain unmined are reported for abandoning or fee bumping, and notified to websocket clients by btcwallet:txstuck (0 to disable)"`
+	DepositWebhook    string        `long:"depositwebhook" description:"URL to post a JSON object to for each deposit to an account of at least the amount set by setdepositalert"`
+	DepositHookSecret string        `long:"depositwebhooksecret" default-mask:"-" description:"Secret shared with the receiver of depositwebhook to sign each post with an HMAC-SHA256 of its body -- Required with depositwebhook"`
+	SpendTOTPSecret   string        `long:"spendtotpsecret" default-mask:"-" description:"Base32 encoded TOTP secret -- When set, send RPCs return a pending spend token and only publish the transaction once confirmed by confirmspend with a code from an authenticator app"`
+	BackupDir         string        `long:"backupdir" description:"Directory to periodically write encrypted backups of the wallet to (backups are disabled if unset)"`
+	BackupPass        string        `long:"backuppass" default-mask:"-" description:"Passphrase to encrypt wallet backups with -- Required with backupdir"`
+	BackupInterval    time.Duration `long:"backupinterval" description:"Duration between wallet backups"`
+	BackupRetain      int           `long:"backupretain" description:"Number of most recent wallet backups to keep (0 to keep all)"`
+	PriceFeedURL      string        `long:"pricefeedurl" description:"URL of an HTTP ticker returning the price of bitcoin as JSON, used to value balances in fiat (disabled if unset)"`
+	PriceFeedField    string        `long:"pricefeedfield" description:"Dot separated path of the field holding the price in the JSON object returned by pricefeedurl"`
+	PriceFeedCurrency string        `long:"pricefeedcurrency" description:"Fiat currency of the prices returned by pricefeedurl"`
+	PriceFeedInterval time.Duration `long:"pricefeedinterval" description:"Duration between fetches of the price from pricefeedurl"`
+	SignerProviders   []string      `long:"signerprovider" description:"External signer provider signing with keys held by an HTTP signing service, such as a gateway to an HSM or cloud KMS, as name=url -- May be specified multiple times"`
+	WalletFsck        bool          `long:"walletfsck" description:"Check the integrity of the wallet database when it is opened"`
+	WalletFsckRepair  bool          `long:"walletfsckrepair" description:"Check and repair the integrity of the wallet database when it is opened -- Transaction history which cannot be repaired is rebuilt by rescanning the chain"`
 
 	// RPC client options
-	RPCConnect       string                  `short:"c" long:"rpcconnect" description:"Hostname/IP and port of btcd RPC server to connect to (default localhost:8334, testnet: localhost:18334, simnet: localhost:18556)"`
-	CAFile           *cfgutil.ExplicitString `long:"cafile" description:"File containing root certificates to authenticate a TLS connections with btcd"`
-	DisableClientTLS bool                    `long:"noclienttls" description:"Disable TLS for the RPC client -- NOTE: This is only allowed if the RPC client is connecting to localhost"`
-	BtcdUsername     string                  `long:"btcdusername" description:"Username for btcd authentication"`
-	BtcdPassword     string                  `long:"btcdpassword" default-mask:"-" description:"Password for btcd authentication"`
-	Proxy            string                  `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
-	ProxyUser        string                  `long:"proxyuser" description:"Username for proxy server"`
-	ProxyPass        string                  `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
+	RPCConnect          string                  `short:"c" long:"rpcconnect" description:"Hostname/IP and port of btcd RPC server to connect to (default localhost:8334, testnet: localhost:18334, simnet: localhost:18556, signet: localhost:38334, regtest: localhost:18334)"`
+	RPCConnectFallbacks []string                `long:"rpcconnectfallback" description:"Hostname/IP and port of a btcd RPC server to fail over to when the current server is unavailable (may be specified multiple times)"`
+	NetRPCConnect       []string                `long:"netrpcconnect" description:"Network and hostname/IP and port of the btcd RPC server that wallets of another network loaded over RPC connect to, as network=host[:port] (default localhost and the RPC port of the network) -- May be specified multiple times"`
+	CAFile              *cfgutil.ExplicitString `long:"cafile" description:"File containing root certificates to authenticate a TLS connections with btcd"`
+	DisableClientTLS    bool                    `long:"noclienttls" description:"Disable TLS for the RPC client -- NOTE: This is only allowed if the RPC client is connecting to localhost"`
+	BtcdUsername        string                  `long:"btcdusername" description:"Username for btcd authentication"`
+	BtcdPassword        string                  `long:"btcdpassword" default-mask:"-" description:"Password for btcd authentication"`
+	Proxy               string                  `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
+	ProxyUser           string                  `long:"proxyuser" description:"Username for proxy server"`
+	ProxyPass           string                  `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
+	OnionProxy          string                  `long:"onion" description:"Connect to tor hidden services via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
+	OnionProxyUser      string                  `long:"onionuser" description:"Username for onion proxy server"`
+	OnionProxyPass      string                  `long:"onionpass" default-mask:"-" description:"Password for onion proxy server"`
+	NoOnion             bool                    `long:"noonion" description:"Disable connecting to tor hidden services"`
 
 	// SPV client options
 	UseSPV       bool          `long:"usespv" description:"Enables the experimental use of SPV rather than RPC for chain synchronization"`
@@ -84,6 +127,18 @@ type config struct {
 	BanDuration  time.Duration `long:"banduration" description:"How long to ban misbehaving peers.  Valid time units are {s, m, h}.  Minimum 1 second"`
 	BanThreshold uint32        `long:"banthreshold" description:"Maximum allowed ban score before disconnecting and banning misbehaving peers."`
 
+	// Bitcoind client options
+	UseBitcoind        bool   `long:"usebitcoind" description:"Use a bitcoind node rather than btcd for chain synchronization (authenticates with btcdusername and btcdpassword)"`
+	BitcoindRPCConnect string `long:"bitcoindrpcconnect" description:"Hostname/IP and port of the bitcoind RPC server to connect to (default localhost:8332, testnet: localhost:18332, signet: localhost:38332, regtest: localhost:18443)"`
+	BitcoindZMQBlock   string `long:"bitcoindzmqblock" description:"ZMQ endpoint of the bitcoind rawblock notifications"`
+	BitcoindZMQTx      string `long:"bitcoindzmqtx" description:"ZMQ endpoint of the bitcoind rawtx notifications"`
+
+	// Electrum client options
+	UseElectrum    bool   `long:"useelectrum" description:"Use an Electrum protocol server (ElectrumX, Fulcrum) rather than btcd for chain synchronization"`
+	ElectrumServer string `long:"electrumserver" description:"Hostname/IP and port of the Electrum server to connect to"`
+	ElectrumNoTLS  bool   `long:"electrumnotls" description:"Connect to the Electrum server over TCP without TLS"`
+	ElectrumCAFile string `long:"electrumcafile" description:"File containing the certificates to verify the Electrum server's TLS certificate with (system roots are used if unset)"`
+
 	// RPC server options
 	//
 	// The legacy server is still enabled by default (and eventually will be
@@ -96,11 +151,27 @@ type config struct {
 	RPCKey                 *cfgutil.ExplicitString `long:"rpckey" description:"File containing the certificate key"`
 	OneTimeTLSKey          bool                    `long:"onetimetlskey" description:"Generate a new TLS certpair at startup, but only write the certificate to disk"`
 	DisableServerTLS       bool                    `long:"noservertls" description:"Disable TLS for the RPC server -- NOTE: This is only allowed if the RPC server is bound to localhost"`
-	LegacyRPCListeners     []string                `long:"rpclisten" description:"Listen for legacy RPC connections on this interface/port (default port: 8332, testnet: 18332, simnet: 18554)"`
+	RPCClientCA            string                  `long:"rpcclientca" description:"File containing the CA certificates which must have issued the certificates RPC clients authenticate with (client certificates are not required if unset)"`
+	LegacyRPCListeners     []string                `long:"rpclisten" description:"Listen for legacy RPC connections on this interface/port (default port: 8332, testnet: 18332, simnet: 18554, signet: 38332, regtest: 18332)"`
 	LegacyRPCMaxClients    int64                   `long:"rpcmaxclients" description:"Max number of legacy RPC clients for standard connections"`
 	LegacyRPCMaxWebsockets int64                   `long:"rpcmaxwebsockets" description:"Max number of legacy RPC websocket connections"`
+	LegacyRPCMaxHandlers   int                     `long:"rpcmaxhandlers" description:"Max number of legacy RPC requests handled concurrently"`
+	LegacyRPCRateLimit     uint32                  `long:"rpcratelimit" description:"Max number of legacy RPC requests per minute from each client host (0 for no limit)"`
 	Username               string                  `short:"u" long:"username" description:"Username for legacy RPC and btcd authentication (if btcdusername is unset)"`
 	Password               string                  `short:"P" long:"password" default-mask:"-" description:"Password for legacy RPC and btcd authentication (if btcdpassword is unset)"`
+	RPCCookie              bool                    `long:"rpccookie" description:"Authenticate legacy RPC clients with a random password written to the .cookie file of the network directory when username or password is unset"`
+	AmountUnit             *cfgutil.AmountUnitFlag `long:"amountunit" description:"Unit of amounts passed to legacy RPC send requests which do not specify one {BTC, mBTC, uBTC, satoshi}"`
+	DisplayUnit            *cfgutil.AmountUnitFlag `long:"displayunit" description:"Unit of amounts in legacy RPC results and notifications for clients which do not request one {BTC, mBTC, uBTC, satoshi}"`
+	PublicUsername         string                  `long:"publicrpcuser" description:"Username for the public legacy RPC tier, which may only call validateaddress and getreceivedbyaddress of reserved or labeled addresses (disabled if unset)"`
+	PublicPassword         string                  `long:"publicrpcpass" default-mask:"-" description:"Password for the public legacy RPC tier"`
+	PublicRateLimit        uint32                  `long:"publicrpclimit" description:"Max number of requests of each method per minute from each public legacy RPC client host"`
+	LimitedUsername        string                  `long:"limitedrpcuser" description:"Username for the limited legacy RPC tier, which may only call read-only methods (disabled if unset)"`
+	LimitedPassword        string                  `long:"limitedrpcpass" default-mask:"-" description:"Password for the limited legacy RPC tier"`
+	LegacyBalanceNtfns     bool                    `long:"legacybalancentfns" description:"Also notify legacy RPC websocket clients of account balances with the deprecated accountbalance notifications"`
+	DisableRPCMethods      []string                `long:"disablerpcmethod" description:"Legacy RPC method to refuse for every client, including methods passed through to the chain server (may be used multiple times)"`
+	AllowRPCMethods        []string                `long:"allowrpcmethod" description:"Legacy RPC method to allow, refusing every method not allowed (may be used multiple times; all methods are allowed if unset)"`
+	AuditLog               string                  `long:"auditlog" description:"File to append a tamper-evident record of legacy RPC requests which reveal private keys, unlock the wallet or send funds to (disabled if unset)"`
+	AuditLogSecret         string                  `long:"auditlogsecret" default-mask:"-" description:"Secret keying the HMAC-SHA256 chaining the records of auditlog, which must not be stored with the log -- Required with auditlog"`
 
 	// EXPERIMENTAL RPC server options
 	//
@@ -110,6 +181,23 @@ type config struct {
 
 	// Deprecated options
 	DataDir *cfgutil.ExplicitString `short:"b" long:"datadir" default-mask:"-" description:"DEPRECATED -- use appdata instead"`
+
+	// dial and oniondial are the functions outbound connections to other
+	// and to tor hidden service addresses are dialed with, and lookup is
+	// the function hosts are resolved with.  These route connections and
+	// DNS requests through the configured proxies.
+	dial      func(string, string) (net.Conn, error)
+	oniondial func(string, string) (net.Conn, error)
+	lookup    func(string) ([]net.IP, error)
+
+	// netRPCConnect maps the names of networks other than the active
+	// network to the btcd RPC server of that network set by the
+	// netrpcconnect option.
+	netRPCConnect map[string]string
+
+	// signerProviders maps the names of the external signer providers set
+	// by the signerprovider option to the URL of their signing service.
+	signerProviders map[string]string
 }
 
 // cleanAndExpandPath expands environement variables and leading ~ in the
@@ -214,7 +302,8 @@ func parseAndSetDebugLevels(debugLevel string) error {
 	}
 
 	// Split the specified string into subsystem/level pairs while detecting
-	// issues and update the log levels accordingly.
+	// issues, and only update the log levels once every pair is valid.
+	levels := make(map[string]string)
 	for _, logLevelPair := range strings.Split(debugLevel, ",") {
 		if !strings.Contains(logLevelPair, "=") {
 			str := "the specified debug level contains an invalid " +
@@ -239,6 +328,9 @@ func parseAndSetDebugLevels(debugLevel string) error {
 			return fmt.Errorf(str, logLevel)
 		}
 
+		levels[subsysID] = logLevel
+	}
+	for subsysID, logLevel := range levels {
 		setLogLevel(subsysID, logLevel)
 	}
 
@@ -261,15 +353,22 @@ func loadConfig() (*config, []string, error) {
 	// Default config.
 	cfg := config{
 		DebugLevel:             defaultLogLevel,
+		LogFormat:              defaultLogFormat,
 		ConfigFile:             cfgutil.NewExplicitString(defaultConfigFile),
 		AppDataDir:             cfgutil.NewExplicitString(defaultAppDataDir),
 		LogDir:                 defaultLogDir,
 		WalletPass:             wallet.InsecurePubPassphrase,
+		Lookahead:              wallet.DefaultLookaheadWindow,
+		ChainStallTimeout:      wallet.DefaultChainStallTimeout,
 		CAFile:                 cfgutil.NewExplicitString(""),
 		RPCKey:                 cfgutil.NewExplicitString(defaultRPCKeyFile),
 		RPCCert:                cfgutil.NewExplicitString(defaultRPCCertFile),
 		LegacyRPCMaxClients:    defaultRPCMaxClients,
 		LegacyRPCMaxWebsockets: defaultRPCMaxWebsockets,
+		LegacyRPCMaxHandlers:   legacyrpc.DefaultMaxHandlers,
+		PublicRateLimit:        defaultPublicRPCRateLimit,
+		AmountUnit:             cfgutil.NewAmountUnitFlag(btcutil.AmountBTC),
+		DisplayUnit:            cfgutil.NewAmountUnitFlag(btcutil.AmountBTC),
 		DataDir:                cfgutil.NewExplicitString(defaultAppDataDir),
 		UseSPV:                 false,
 		AddPeers:               []string{},
@@ -277,7 +376,15 @@ func loadConfig() (*config, []string, error) {
 		MaxPeers:               neutrino.MaxPeers,
 		BanDuration:            neutrino.BanDuration,
 		BanThreshold:           neutrino.BanThreshold,
+		BitcoindZMQBlock:       defaultBitcoindZMQBlock,
+		BitcoindZMQTx:          defaultBitcoindZMQTx,
 		DBTimeout:              wallet.DefaultDBTimeout,
+		DBDriver:               wallet.BoltDBDriver,
+		BackupInterval:         defaultBackupInterval,
+		BackupRetain:           wallet.DefaultBackupRetain,
+		PriceFeedField:         defaultPriceFeedField,
+		PriceFeedCurrency:      defaultPriceFeedCurrency,
+		PriceFeedInterval:      wallet.DefaultPriceFeedInterval,
 	}
 
 	// Pre-parse the command line options to see if an alternative config
@@ -370,6 +477,10 @@ func loadConfig() (*config, []string, error) {
 		activeNet = &netparams.SimNetParams
 		numNets++
 	}
+	if cfg.RegTest {
+		activeNet = &netparams.RegressionNetParams
+		numNets++
+	}
 	if cfg.SigNet {
 		activeNet = &netparams.SigNetParams
 		numNets++
@@ -410,17 +521,133 @@ func loadConfig() (*config, []string, error) {
 		activeNet.Params = &chainParams
 	}
 	if numNets > 1 {
-		str := "%s: The testnet, signet and simnet params can't be " +
-			"used together -- choose one"
+		str := "%s: The testnet, signet, simnet and regtest params " +
+			"can't be used together -- choose one"
 		err := fmt.Errorf(str, "loadConfig")
 		fmt.Fprintln(os.Stderr, err)
 		parser.WriteHelp(os.Stderr)
 		return nil, nil, err
 	}
+	if !cfg.SigNet && (cfg.SigNetChallenge != "" ||
+		len(cfg.SigNetSeedNode) > 0) {
+
+		str := "%s: The signetchallenge and signetseednode options " +
+			"require --signet"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
 
 	// Append the network type to the log directory so it is "namespaced"
 	// per network.
 	cfg.LogDir = cleanAndExpandPath(cfg.LogDir)
+
+	// Validate the profile port number.
+	if cfg.Profile != "" {
+		profilePort, err := strconv.Atoi(cfg.Profile)
+		if err != nil || profilePort < 1024 || profilePort > 65535 {
+			err := fmt.Errorf("%s: the profile port must be between "+
+				"1024 and 65535", funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	if cfg.DepositWebhook != "" {
+		u, err := url.Parse(cfg.DepositWebhook)
+		if err == nil && u.Scheme != "http" && u.Scheme != "https" {
+			err = errors.New("scheme must be http or https")
+		}
+		if err != nil {
+			err := fmt.Errorf("%s: invalid depositwebhook: %v",
+				funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.DepositHookSecret == "" {
+			err := fmt.Errorf("%s: depositwebhooksecret is "+
+				"required with depositwebhook", funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	if cfg.PriceFeedURL != "" {
+		u, err := url.Parse(cfg.PriceFeedURL)
+		if err == nil && u.Scheme != "http" && u.Scheme != "https" {
+			err = errors.New("scheme must be http or https")
+		}
+		if err != nil {
+			err := fmt.Errorf("%s: invalid pricefeedurl: %v",
+				funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.PriceFeedField == "" || cfg.PriceFeedCurrency == "" {
+			err := fmt.Errorf("%s: pricefeedfield and "+
+				"pricefeedcurrency are required with "+
+				"pricefeedurl", funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.PriceFeedInterval <= 0 {
+			err := fmt.Errorf("%s: pricefeedinterval must be "+
+				"positive", funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	cfg.signerProviders = make(map[string]string)
+	for _, provider := range cfg.SignerProviders {
+		if err := parseSignerProvider(&cfg, provider); err != nil {
+			err = fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	if cfg.SpendTOTPSecret != "" {
+		if _, err := decodeTOTPSecret(cfg.SpendTOTPSecret); err != nil {
+			err := fmt.Errorf("%s: invalid spendtotpsecret: %v",
+				funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	if cfg.AuditLog != "" {
+		cfg.AuditLog = cleanAndExpandPath(cfg.AuditLog)
+		if cfg.AuditLogSecret == "" {
+			err := fmt.Errorf("%s: auditlogsecret is required "+
+				"with auditlog", funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	if cfg.BackupDir != "" {
+		cfg.BackupDir = cleanAndExpandPath(cfg.BackupDir)
+		if cfg.BackupPass == "" {
+			err := fmt.Errorf("%s: backuppass is required with "+
+				"backupdir", funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.BackupInterval <= 0 {
+			err := fmt.Errorf("%s: backupinterval must be positive",
+				funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.BackupRetain < 0 {
+			err := fmt.Errorf("%s: backupretain may not be negative",
+				funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
 	cfg.LogDir = filepath.Join(cfg.LogDir, activeNet.Params.Name)
 
 	// Special show command to list supported subsystems and exit.
@@ -429,6 +656,20 @@ func loadConfig() (*config, []string, error) {
 		os.Exit(0)
 	}
 
+	// Validate the log format, which must be chosen before the loggers are
+	// used.
+	switch cfg.LogFormat {
+	case "text":
+	case "json":
+		jsonLogs = true
+	default:
+		err := fmt.Errorf("%s: the log format [%v] is invalid -- "+
+			"supported formats are text and json", funcName,
+			cfg.LogFormat)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	// Initialize log rotation.  After log rotation has been initialized, the
 	// logger variables may be used.
 	initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename))
@@ -450,17 +691,59 @@ func loadConfig() (*config, []string, error) {
 	}
 
 	// Exit if you try to use a simulation wallet on anything other than
-	// simnet or testnet3.
-	if !cfg.SimNet && cfg.CreateTemp {
+	// simnet or regtest.
+	if !cfg.SimNet && !cfg.RegTest && cfg.CreateTemp {
 		fmt.Fprintln(os.Stderr, "Tried to create a temporary simulation "+
-			"wallet for network other than simnet!")
+			"wallet for network other than simnet or regtest!")
 		os.Exit(0)
 	}
 
+	switch cfg.DBDriver {
+	case wallet.BoltDBDriver, wallet.SQLiteDBDriver:
+	default:
+		err := fmt.Errorf("%s: unknown database driver %q -- "+
+			"supported drivers are %s and %s", funcName,
+			cfg.DBDriver, wallet.BoltDBDriver,
+			wallet.SQLiteDBDriver)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	// Ensure the wallet exists or create it when the create flag is set.
 	netDir := networkDir(cfg.AppDataDir.Value, activeNet.Params)
 	dbPath := filepath.Join(netDir, wallet.WalletDBName)
 
+	// In-memory wallets are created on startup and never stored, so they
+	// can not be combined with options creating or storing the wallet
+	// database.
+	if cfg.MemoryWallet {
+		var conflict string
+		switch {
+		case cfg.Create:
+			conflict = "--create"
+		case cfg.CreateTemp:
+			conflict = "--createtemp"
+		case cfg.DBPass != "":
+			conflict = "--dbpass"
+		case cfg.BackupDir != "":
+			conflict = "--backupdir"
+		}
+		if conflict != "" {
+			err := fmt.Errorf("the flags --memorywallet and %s can "+
+				"not be specified together. Use --help for "+
+				"more information", conflict)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	if cfg.MemoryWalletPass != "" && !cfg.MemoryWallet {
+		err := fmt.Errorf("the flag --memorywalletpass requires " +
+			"--memorywallet. Use --help for more information")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	if cfg.CreateTemp && cfg.Create {
 		err := fmt.Errorf("the flags --create and --createtemp can not " +
 			"be specified together. Use --help for more information")
@@ -521,7 +804,7 @@ func loadConfig() (*config, []string, error) {
 
 		// Created successfully, so exit now with success.
 		os.Exit(0)
-	} else if !dbFileExists && !cfg.NoInitialLoad {
+	} else if !dbFileExists && !cfg.NoInitialLoad && !cfg.MemoryWallet {
 		keystorePath := filepath.Join(netDir, keystore.Filename)
 		keystoreExists, err := cfgutil.FileExists(keystorePath)
 		if err != nil {
@@ -545,11 +828,58 @@ func loadConfig() (*config, []string, error) {
 		"::1":       {},
 	}
 
-	if cfg.UseSPV {
+	numBackends := 0
+	for _, useBackend := range []bool{cfg.UseSPV, cfg.UseBitcoind, cfg.UseElectrum} {
+		if useBackend {
+			numBackends++
+		}
+	}
+	if numBackends > 1 {
+		err := fmt.Errorf("%s: only one of the usespv, usebitcoind, "+
+			"and useelectrum options may be used", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	switch {
+	case cfg.UseSPV:
 		neutrino.MaxPeers = cfg.MaxPeers
 		neutrino.BanDuration = cfg.BanDuration
 		neutrino.BanThreshold = cfg.BanThreshold
-	} else {
+	case cfg.UseBitcoind:
+		if activeNet.BitcoindRPCPort == "" {
+			err := fmt.Errorf("%s: bitcoind does not support the %s "+
+				"network", funcName, activeNet.Params.Name)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if cfg.BitcoindRPCConnect == "" {
+			cfg.BitcoindRPCConnect = net.JoinHostPort("localhost",
+				activeNet.BitcoindRPCPort)
+		}
+		cfg.BitcoindRPCConnect, err = cfgutil.NormalizeAddress(
+			cfg.BitcoindRPCConnect, activeNet.BitcoindRPCPort)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid bitcoindrpcconnect "+
+				"network address: %v\n", err)
+			return nil, nil, err
+		}
+	case cfg.UseElectrum:
+		if cfg.ElectrumServer == "" {
+			err := fmt.Errorf("%s: the electrumserver option is "+
+				"required with useelectrum", funcName)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		if _, _, err := net.SplitHostPort(cfg.ElectrumServer); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid electrumserver network "+
+				"address: %v\n", err)
+			return nil, nil, err
+		}
+		if cfg.ElectrumCAFile != "" {
+			cfg.ElectrumCAFile = cleanAndExpandPath(cfg.ElectrumCAFile)
+		}
+	default:
 		if cfg.RPCConnect == "" {
 			cfg.RPCConnect = net.JoinHostPort("localhost", activeNet.RPCClientPort)
 		}
@@ -563,19 +893,51 @@ func loadConfig() (*config, []string, error) {
 			return nil, nil, err
 		}
 
+		for i, addr := range cfg.RPCConnectFallbacks {
+			cfg.RPCConnectFallbacks[i], err = cfgutil.NormalizeAddress(
+				addr, activeNet.RPCClientPort)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid rpcconnectfallback "+
+					"network address: %v\n", err)
+				return nil, nil, err
+			}
+		}
+
+		cfg.netRPCConnect = make(map[string]string)
+		for _, netConnect := range cfg.NetRPCConnect {
+			err := parseNetRPCConnect(&cfg, netConnect)
+			if err != nil {
+				err = fmt.Errorf("%s: %v", funcName, err)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+		}
+
 		RPCHost, _, err := net.SplitHostPort(cfg.RPCConnect)
 		if err != nil {
 			return nil, nil, err
 		}
 		if cfg.DisableClientTLS {
-			if _, ok := localhostListeners[RPCHost]; !ok {
-				str := "%s: the --noclienttls option may not be used " +
-					"when connecting RPC to non localhost " +
-					"addresses: %s"
-				err := fmt.Errorf(str, funcName, cfg.RPCConnect)
-				fmt.Fprintln(os.Stderr, err)
-				fmt.Fprintln(os.Stderr, usageMessage)
-				return nil, nil, err
+			servers := append([]string{cfg.RPCConnect},
+				cfg.RPCConnectFallbacks...)
+			for _, server := range cfg.netRPCConnect {
+				servers = append(servers, server)
+			}
+			for _, server := range servers {
+				host, _, err := net.SplitHostPort(server)
+				if err != nil {
+					return nil, nil, err
+				}
+				if _, ok := localhostListeners[host]; !ok {
+					str := "%s: the --noclienttls option may " +
+						"not be used when connecting RPC to " +
+						"non localhost addresses: %s"
+					err := fmt.Errorf(str, funcName, server)
+					fmt.Fprintln(os.Stderr, err)
+					fmt.Fprintln(os.Stderr, usageMessage)
+					return nil, nil, err
+				}
 			}
 		} else {
 			// If CAFile is unset, choose either the copy or local btcd cert.
@@ -606,6 +968,96 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// --noonion and --onion do not mix.
+	if cfg.NoOnion && cfg.OnionProxy != "" {
+		err := fmt.Errorf("%s: the --noonion and --onion options may "+
+			"not be activated at the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Setup dial and DNS resolution (lookup) functions depending on the
+	// specified options.  The default is to use the standard net.Dial
+	// function as well as the system DNS resolver.  When a proxy is
+	// specified, the dial function is set to the proxy specific dial
+	// function and the lookup is set to use tor (unless --noonion is
+	// specified in which case the system DNS resolver is used).
+	cfg.dial = net.Dial
+	cfg.lookup = net.LookupIP
+	if cfg.Proxy != "" {
+		_, _, err := net.SplitHostPort(cfg.Proxy)
+		if err != nil {
+			str := "%s: proxy address '%s' is invalid: %v"
+			err := fmt.Errorf(str, funcName, cfg.Proxy, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		proxy := &socks.Proxy{
+			Addr:     cfg.Proxy,
+			Username: cfg.ProxyUser,
+			Password: cfg.ProxyPass,
+		}
+		cfg.dial = proxy.Dial
+
+		// Treat the proxy as tor and perform DNS resolution through it
+		// unless the --noonion flag is set or there is an
+		// onion-specific proxy configured.
+		if !cfg.NoOnion && cfg.OnionProxy == "" {
+			cfg.lookup = func(host string) ([]net.IP, error) {
+				return connmgr.TorLookupIP(host, cfg.Proxy)
+			}
+		}
+	}
+
+	// Setup onion address dial function depending on the specified options.
+	// When an onion-specific proxy is specified, tor hidden service
+	// addresses are dialed through it while leaving the normal dial
+	// function as selected above.  When both proxies are specified, the
+	// proxy is not a tor proxy, so DNS resolution is performed through the
+	// onion-specific proxy instead.
+	if cfg.OnionProxy != "" {
+		_, _, err := net.SplitHostPort(cfg.OnionProxy)
+		if err != nil {
+			str := "%s: onion proxy address '%s' is invalid: %v"
+			err := fmt.Errorf(str, funcName, cfg.OnionProxy, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		proxy := &socks.Proxy{
+			Addr:     cfg.OnionProxy,
+			Username: cfg.OnionProxyUser,
+			Password: cfg.OnionProxyPass,
+		}
+		cfg.oniondial = proxy.Dial
+
+		if cfg.Proxy != "" {
+			cfg.lookup = func(host string) ([]net.IP, error) {
+				return connmgr.TorLookupIP(host, cfg.OnionProxy)
+			}
+		}
+	} else {
+		cfg.oniondial = cfg.dial
+	}
+
+	// Specifying --noonion means the onion address dial function results in
+	// an error.
+	if cfg.NoOnion {
+		cfg.oniondial = func(string, string) (net.Conn, error) {
+			return nil, errors.New("tor has been disabled")
+		}
+	}
+
+	// The bitcoind RPC and ZMQ connections can not be proxied.
+	if cfg.UseBitcoind && cfg.Proxy != "" {
+		log.Warnf("The bitcoind RPC and ZMQ connections are not made " +
+			"through the proxy")
+	}
+
 	// Only set default RPC listeners when there are no listeners set for
 	// the experimental RPC server.  This is required to prevent the old RPC
 	// server from sharing listen addresses, since it is impossible to
@@ -685,10 +1137,23 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Client certificates are verified during the TLS handshake, so they
+	// can not be required without server TLS.
+	if cfg.DisableServerTLS && cfg.RPCClientCA != "" {
+		err := fmt.Errorf("%s: the --rpcclientca option may not be "+
+			"used with --noservertls", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Expand environment variable and leading ~ for filepaths.
 	cfg.CAFile.Value = cleanAndExpandPath(cfg.CAFile.Value)
 	cfg.RPCCert.Value = cleanAndExpandPath(cfg.RPCCert.Value)
 	cfg.RPCKey.Value = cleanAndExpandPath(cfg.RPCKey.Value)
+	if cfg.RPCClientCA != "" {
+		cfg.RPCClientCA = cleanAndExpandPath(cfg.RPCClientCA)
+	}
 
 	// If the btcd username or password are unset, use the same auth as for
 	// the client.  The two settings were previously shared for btcd and
@@ -701,6 +1166,16 @@ func loadConfig() (*config, []string, error) {
 		cfg.BtcdPassword = cfg.Password
 	}
 
+	// The public tier credentials must be distinguishable from the full
+	// access credentials.
+	if cfg.PublicUsername != "" && cfg.PublicUsername == cfg.Username {
+		str := "%s: the --publicrpcuser option must differ from " +
+			"--username"
+		err := fmt.Errorf(str, "loadConfig")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	// Warn about missing config file after the final command line parse
 	// succeeds.  This prevents the warning on help messages and invalid
 	// options.
@@ -710,3 +1185,119 @@ func loadConfig() (*config, []string, error) {
 
 	return &cfg, remainingArgs, nil
 }
+
+// isOnionAddr returns whether the host of the network address is a tor hidden
+// service.
+func isOnionAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return strings.HasSuffix(host, ".onion")
+}
+
+// walletDial connects to the address on the named network, using the onion
+// proxy for tor hidden service addresses and the proxy for other addresses when
+// configured.
+func walletDial(network, addr string) (net.Conn, error) {
+	if isOnionAddr(addr) {
+		return cfg.oniondial(network, addr)
+	}
+	return cfg.dial(network, addr)
+}
+
+// walletLookup resolves the IP of the given host using the correct DNS lookup
+// function depending on the configuration options.
+func walletLookup(host string) ([]net.IP, error) {
+	if strings.HasSuffix(host, ".onion") {
+		return nil, fmt.Errorf("attempt to resolve tor address %s", host)
+	}
+	return cfg.lookup(host)
+}
+
+// chainProxy returns the SOCKS5 proxy, and its credentials, through which the
+// RPC connection to the chain server at addr is made.  The proxy is empty when
+// the connection is made directly.
+func chainProxy(addr string) (proxy, user, pass string) {
+	if isOnionAddr(addr) && cfg.OnionProxy != "" {
+		return cfg.OnionProxy, cfg.OnionProxyUser, cfg.OnionProxyPass
+	}
+	return cfg.Proxy, cfg.ProxyUser, cfg.ProxyPass
+}
+
+// decodeTOTPSecret decodes a base32 encoded TOTP secret as displayed by
+// authenticator apps, ignoring case, spaces and padding.
+func decodeTOTPSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	s = strings.TrimRight(s, "=")
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).
+		DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) < 10 {
+		return nil, errors.New("secret must be at least 80 bits")
+	}
+	return secret, nil
+}
+
+// parseNetRPCConnect parses a netrpcconnect option of the form
+// network=host[:port] into the btcd RPC servers of the configuration.
+func parseNetRPCConnect(cfg *config, netConnect string) error {
+	i := strings.IndexByte(netConnect, '=')
+	if i == -1 {
+		return fmt.Errorf("invalid netrpcconnect %q: must be of the "+
+			"form network=host[:port]", netConnect)
+	}
+	name, addr := netConnect[:i], netConnect[i+1:]
+	params, ok := netparams.ByName(name)
+	if !ok {
+		return fmt.Errorf("invalid netrpcconnect %q: unknown network "+
+			"%q", netConnect, name)
+	}
+	if params.Name == activeNet.Params.Name {
+		return fmt.Errorf("invalid netrpcconnect %q: the btcd RPC "+
+			"server of the active network is set by rpcconnect",
+			netConnect)
+	}
+	if _, ok := cfg.netRPCConnect[name]; ok {
+		return fmt.Errorf("invalid netrpcconnect %q: the %s network "+
+			"is set more than once", netConnect, name)
+	}
+	addr, err := cfgutil.NormalizeAddress(addr, params.RPCClientPort)
+	if err != nil {
+		return fmt.Errorf("invalid netrpcconnect %q: %v", netConnect,
+			err)
+	}
+	cfg.netRPCConnect[name] = addr
+	return nil
+}
+
+// parseSignerProvider parses a signerprovider option of the form name=url
+// into the external signer providers of the configuration.
+func parseSignerProvider(cfg *config, provider string) error {
+	i := strings.IndexByte(provider, '=')
+	if i == -1 {
+		return fmt.Errorf("invalid signerprovider %q: must be of the "+
+			"form name=url", provider)
+	}
+	name, rawURL := provider[:i], provider[i+1:]
+	if len(name) == 0 || len(name) > 255 {
+		return fmt.Errorf("invalid signerprovider %q: name must be "+
+			"between 1 and 255 bytes", provider)
+	}
+	if _, ok := cfg.signerProviders[name]; ok {
+		return fmt.Errorf("invalid signerprovider %q: the provider %s "+
+			"is set more than once", provider, name)
+	}
+	u, err := url.Parse(rawURL)
+	if err == nil && u.Scheme != "http" && u.Scheme != "https" {
+		err = errors.New("scheme must be http or https")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid signerprovider %q: %v", provider,
+			err)
+	}
+	cfg.signerProviders[name] = rawURL
+	return nil
+}
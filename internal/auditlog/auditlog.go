@@ -0,0 +1,253 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package auditlog provides an append-only log of sensitive operations.
+//
+// Each record is written as a line of JSON which includes the HMAC-SHA256 of
+// the line of the previous record, chaining the records together.  The MACs
+// are keyed by a secret which must be kept outside of the log, such as in the
+// configuration of the application, so that a record which is modified,
+// removed or inserted after it was written breaks the chain, which is detected
+// by Verify, even when the whole log is rewritten by someone who does not know
+// the secret.  Removing records from the end of the log does not break the
+// chain, and is only detected by comparing the MAC of the last record with one
+// noted elsewhere, such as from Log.Head.
+package auditlog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outcomes of audited operations.
+const (
+	Success = "success"
+	Failure = "failure"
+)
+
+// maxLineLen is the longest line of a log which is read.
+const maxLineLen = 64 * 1024
+
+// Record is a record of an operation.  Records must never hold secrets such as
+// private keys or passphrases.
+type Record struct {
+	Time    time.Time `json:"time"`
+	Client  string    `json:"client"`
+	Tier    string    `json:"tier,omitempty"`
+	Wallet  string    `json:"wallet,omitempty"`
+	Method  string    `json:"method"`
+	Outcome string    `json:"outcome"`
+
+	// Code is the code of the error an operation failed with.  Error
+	// messages are not recorded, as they may quote the parameters of the
+	// operation.
+	Code int `json:"code,omitempty"`
+
+	// Prev is the hex encoded HMAC-SHA256 of the line of the previous
+	// record, or of nothing for the first record of a log, keyed by the
+	// secret of the log.
+	Prev string `json:"prev"`
+}
+
+// Log is an audit log file which records are appended to.  It is safe for
+// concurrent use.
+type Log struct {
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	secret []byte
+	head   []byte
+}
+
+// chainMAC returns the MAC of a line of a log keyed by the secret, which the
+// next record is chained to.
+func chainMAC(secret, line []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(line)
+	return mac.Sum(nil)
+}
+
+// Open opens the log at a path for appending, creating it if it does not
+// exist.  Records are chained by MACs keyed by the secret, which must not be
+// empty, and records appended to an existing log are chained to its last
+// record.  The records already in the log are not verified.  An incomplete
+// record at the end of the log, such as one left partially written by a
+// crash, is removed.
+func Open(path string, secret []byte) (*Log, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("audit log secret must not be empty")
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	secret = append([]byte(nil), secret...)
+	head := chainMAC(secret, nil)
+	size, err := readLines(f, func(line []byte) error {
+		head = chainMAC(secret, line)
+		return nil
+	})
+	if err == errIncompleteRecord {
+		log.Warnf("Removing incomplete record at the end of audit "+
+			"log %s", path)
+		err = f.Truncate(size)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to read audit log %s: %v", path,
+			err)
+	}
+	return &Log{file: f, size: size, secret: secret, head: head}, nil
+}
+
+// Append sets the time and previous hash of a record and appends it to the
+// log, syncing the log to disk before returning.  When the record can not be
+// written, the log is truncated to its last complete record, so that the
+// records appended later remain chained to it.
+func (l *Log) Append(r *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	r.Time = r.Time.UTC().Truncate(time.Second)
+	r.Prev = hex.EncodeToString(l.head)
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	_, err = l.file.Write(line)
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		if truncErr := l.file.Truncate(l.size); truncErr != nil {
+			log.Errorf("Unable to remove partially written audit "+
+				"log record: %v", truncErr)
+		}
+		return err
+	}
+	l.size += int64(len(line))
+	l.head = chainMAC(l.secret, line[:len(line)-1])
+	return nil
+}
+
+// Head returns the hex encoded MAC of the last record of the log.
+func (l *Log) Head() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return hex.EncodeToString(l.head)
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// ErrBrokenChain describes a record of a log which is not chained to the
+// record before it.
+type ErrBrokenChain struct {
+	// Line is the line number of the record, starting from 1.
+	Line int
+}
+
+// Error implements the error interface.
+func (e *ErrBrokenChain) Error() string {
+	return fmt.Sprintf("audit log record on line %d does not chain to "+
+		"the previous record", e.Line)
+}
+
+// Verify reads a log, checking that each record is chained to the record
+// before it by a MAC keyed by the secret of the log.  It returns the hex
+// encoded MAC of the last record, or an *ErrBrokenChain for the first record
+// which is not chained.
+func Verify(r io.Reader, secret []byte) (string, error) {
+	head := chainMAC(secret, nil)
+	n := 0
+	_, err := readLines(r, func(line []byte) error {
+		n++
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("audit log record on line %d: %v", n,
+				err)
+		}
+		prev, err := hex.DecodeString(rec.Prev)
+		if err != nil || !hmac.Equal(prev, head) {
+			return &ErrBrokenChain{Line: n}
+		}
+		head = chainMAC(secret, line)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(head), nil
+}
+
+// errIncompleteRecord describes a log whose final line is not terminated,
+// such as one left partially written by a crash.
+var errIncompleteRecord = errors.New("audit log ends with an incomplete " +
+	"record")
+
+// readLines calls fn with each non-empty line read from r, returning the
+// number of bytes read up to the end of the last terminated line.  A final
+// line which is not terminated is not passed to fn, and errIncompleteRecord is
+// returned.
+func readLines(r io.Reader, fn func(line []byte) error) (int64, error) {
+	br := bufio.NewReaderSize(r, 4096)
+	var n int64
+	for {
+		line, err := readLine(br)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		n += int64(len(line)) + 1
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return n, err
+		}
+	}
+}
+
+// readLine reads a newline terminated line, without the newline.
+func readLine(br *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxLineLen {
+			return nil, errors.New("audit log line is too long")
+		}
+		switch err {
+		case nil:
+			return bytes.TrimSuffix(line, []byte{'\n'}), nil
+		case bufio.ErrBufferFull:
+			continue
+		case io.EOF:
+			if len(line) != 0 {
+				return nil, errIncompleteRecord
+			}
+		}
+		return nil, err
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package auditlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestChain ensures that records appended to a log, including after it is
+// reopened, are chained together, and that modified records and logs verified
+// with another secret break the chain.
+func TestChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	secret := []byte("audit log secret")
+
+	if _, err := Open(path, nil); err == nil {
+		t.Fatal("log was opened without a secret")
+	}
+	l, err := Open(path, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = l.Append(&Record{Client: "127.0.0.1:1", Method: "dumpprivkey",
+		Outcome: Success})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = Open(path, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = l.Append(&Record{Client: "127.0.0.1:2", Method: "sendmany",
+		Outcome: Failure, Code: -6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := l.Head()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(contents, []byte{'\n'}); n != 2 {
+		t.Fatalf("log has %d records, want 2", n)
+	}
+	verified, err := Verify(bytes.NewReader(contents), secret)
+	if err != nil {
+		t.Fatalf("unable to verify log: %v", err)
+	}
+	if verified != head {
+		t.Errorf("verified head %s, want %s", verified, head)
+	}
+
+	// Modifying the first record breaks the chain at the second.
+	modified := strings.Replace(string(contents), "dumpprivkey",
+		"dumpwallet", 1)
+	_, err = Verify(strings.NewReader(modified), secret)
+	if e, ok := err.(*ErrBrokenChain); !ok || e.Line != 2 {
+		t.Errorf("modified log: got error %v, want broken chain on "+
+			"line 2", err)
+	}
+
+	// Removing the first record breaks the chain at the new first record.
+	removed := contents[bytes.IndexByte(contents, '\n')+1:]
+	_, err = Verify(bytes.NewReader(removed), secret)
+	if e, ok := err.(*ErrBrokenChain); !ok || e.Line != 1 {
+		t.Errorf("truncated log: got error %v, want broken chain on "+
+			"line 1", err)
+	}
+
+	// A log can't be verified without its secret, so that it can't be
+	// rewritten with a consistent chain by someone who doesn't know it.
+	_, err = Verify(bytes.NewReader(contents), []byte("other secret"))
+	if e, ok := err.(*ErrBrokenChain); !ok || e.Line != 1 {
+		t.Errorf("log with other secret: got error %v, want broken "+
+			"chain on line 1", err)
+	}
+
+	// A partial record at the end of a log is removed when it is opened,
+	// and records appended later chain to the last complete record.
+	err = ioutil.WriteFile(path, contents[:len(contents)-10], 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err = Open(path, secret)
+	if err != nil {
+		t.Fatalf("unable to open log with a partial record: %v", err)
+	}
+	err = l.Append(&Record{Client: "127.0.0.1:3", Method: "dumpwallet",
+		Outcome: Success})
+	if err != nil {
+		t.Fatal(err)
+	}
+	head = l.Head()
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	contents, err = ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(contents, []byte{'\n'}); n != 2 {
+		t.Fatalf("log has %d records, want 2", n)
+	}
+	verified, err = Verify(bytes.NewReader(contents), secret)
+	if err != nil {
+		t.Fatalf("unable to verify log: %v", err)
+	}
+	if verified != head {
+		t.Errorf("verified head %s, want %s", verified, head)
+	}
+}
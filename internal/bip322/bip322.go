@@ -0,0 +1,174 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package bip322 implements the BIP0322 generic signed message format, which
+// proves control of the script of any address by signing a virtual
+// transaction spending from it.  Proofs are encoded in the simple format when
+// the signature only has witness data, and in the full format otherwise.
+package bip322
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+var (
+	// ErrMalformedSignature describes an error where a signature is
+	// neither a simple nor a full BIP0322 signature.
+	ErrMalformedSignature = errors.New("malformed BIP0322 signature")
+
+	// ErrInvalidSignature describes an error where a signature does not
+	// prove control of the script of an address for a message.
+	ErrInvalidSignature = errors.New("invalid BIP0322 signature")
+)
+
+// messageTag is the tag of the tagged hash of signed messages.
+const messageTag = "BIP0322-signed-message"
+
+// MessageHash returns the tagged hash of a message committed to by the virtual
+// transaction of its signature.
+func MessageHash(message []byte) chainhash.Hash {
+	tag := sha256.Sum256([]byte(messageTag))
+	h := sha256.New()
+	h.Write(tag[:])
+	h.Write(tag[:])
+	h.Write(message)
+	var hash chainhash.Hash
+	copy(hash[:], h.Sum(nil))
+	return hash
+}
+
+// ToSpend returns the virtual to_spend transaction of a message, paying to the
+// output script of the signing address.
+func ToSpend(pkScript, message []byte) *wire.MsgTx {
+	hash := MessageHash(message)
+	sigScript := make([]byte, 0, 2+len(hash))
+	sigScript = append(sigScript, txscript.OP_0, txscript.OP_DATA_32)
+	sigScript = append(sigScript, hash[:]...)
+
+	tx := wire.NewMsgTx(0)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  sigScript,
+		Sequence:         0,
+	})
+	tx.AddTxOut(wire.NewTxOut(0, pkScript))
+	return tx
+}
+
+// ToSign returns the unsigned virtual to_sign transaction spending the output
+// of a to_spend transaction, which is signed to prove control of its script.
+func ToSign(toSpend *wire.MsgTx) *wire.MsgTx {
+	tx := wire.NewMsgTx(0)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: toSpend.TxHash()},
+		Sequence:         0,
+	})
+	tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return tx
+}
+
+// Encode returns the base64 encoded signature of a signed to_sign
+// transaction.  The signature is the witness of its input in the simple
+// format, or the whole transaction in the full format when the input has a
+// signature script.
+func Encode(toSign *wire.MsgTx) (string, error) {
+	var buf bytes.Buffer
+	if len(toSign.TxIn[0].SignatureScript) > 0 {
+		if err := toSign.Serialize(&buf); err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+	}
+
+	witness := toSign.TxIn[0].Witness
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(witness))); err != nil {
+		return "", err
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(&buf, 0, item); err != nil {
+			return "", err
+		}
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// Verify checks that a base64 encoded signature, in either the simple or the
+// full format, proves control of an output script for a message.
+func Verify(pkScript, message []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrMalformedSignature
+	}
+
+	toSpend := ToSpend(pkScript, message)
+	toSign, err := decodeSimple(toSpend, sig)
+	if err != nil {
+		toSign, err = decodeFull(toSpend, sig)
+		if err != nil {
+			return err
+		}
+	}
+
+	engine, err := txscript.NewEngine(
+		pkScript, toSign, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(toSign), 0,
+	)
+	if err != nil || engine.Execute() != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// decodeSimple returns the to_sign transaction of a simple signature, which
+// is the serialized witness stack of its input.
+func decodeSimple(toSpend *wire.MsgTx, sig []byte) (*wire.MsgTx, error) {
+	r := bytes.NewReader(sig)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil || count > uint64(len(sig)) {
+		return nil, ErrMalformedSignature
+	}
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(
+			r, 0, uint32(len(sig)), "witness item",
+		)
+		if err != nil {
+			return nil, ErrMalformedSignature
+		}
+	}
+	if r.Len() != 0 {
+		return nil, ErrMalformedSignature
+	}
+
+	toSign := ToSign(toSpend)
+	toSign.TxIn[0].Witness = witness
+	return toSign, nil
+}
+
+// decodeFull returns the to_sign transaction of a full signature, which must
+// spend the output of the to_spend transaction to a single empty OP_RETURN
+// output.
+func decodeFull(toSpend *wire.MsgTx, sig []byte) (*wire.MsgTx, error) {
+	r := bytes.NewReader(sig)
+	var toSign wire.MsgTx
+	if err := toSign.Deserialize(r); err != nil || r.Len() != 0 {
+		return nil, ErrMalformedSignature
+	}
+
+	prevOut := wire.OutPoint{Hash: toSpend.TxHash()}
+	if len(toSign.TxIn) != 1 || toSign.TxIn[0].PreviousOutPoint != prevOut ||
+		len(toSign.TxOut) != 1 || toSign.TxOut[0].Value != 0 ||
+		!bytes.Equal(toSign.TxOut[0].PkScript, []byte{txscript.OP_RETURN}) {
+
+		return nil, ErrInvalidSignature
+	}
+	return &toSign, nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bip322
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+)
+
+// The test vectors of BIP0322, signed by the key of testAddress.
+const (
+	testAddress = "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+	testWIF     = "L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k"
+)
+
+var tests = []struct {
+	name      string
+	message   string
+	hash      string
+	toSpend   string
+	toSign    string
+	signature string
+}{
+	{
+		name:      "empty message",
+		message:   "",
+		hash:      "c90c269c4f8fcbe6880f72a721ddfbf1914268a794cbb21cfafee13770ae19f1",
+		toSpend:   "c5680aa69bb8d860bf82d4e9cd3504b55dde018de765a91bb566283c545a99a7",
+		toSign:    "1e9654e951a5ba44c8604c4de6c67fd78a27e81dcadcfe1edf638ba3aaebaed6",
+		signature: "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+	},
+	{
+		name:      "Hello World",
+		message:   "Hello World",
+		hash:      "f0eb03b1a75ac6d9847f55c624a99169b5dccba2a31f5b23bea77ba270de0a7a",
+		toSpend:   "b79d196740ad5217771c1098fc4a4b51e0535c32236c71f1ea4d61a2d603352b",
+		toSign:    "88737ae86f2077145f93cc4b153ae9a1cb8d56afa511988c149c5c8c9d93bddf",
+		signature: "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+	},
+}
+
+func testPkScript(t *testing.T) []byte {
+	addr, err := btcutil.DecodeAddress(testAddress, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkScript
+}
+
+func TestVirtualTransactions(t *testing.T) {
+	pkScript := testPkScript(t)
+	for _, test := range tests {
+		hash := MessageHash([]byte(test.message))
+		if hex.EncodeToString(hash[:]) != test.hash {
+			t.Errorf("%s: message hash %x, want %s", test.name,
+				hash[:], test.hash)
+		}
+		toSpend := ToSpend(pkScript, []byte(test.message))
+		if toSpend.TxHash().String() != test.toSpend {
+			t.Errorf("%s: to_spend txid %v, want %s", test.name,
+				toSpend.TxHash(), test.toSpend)
+		}
+		toSign := ToSign(toSpend)
+		if toSign.TxHash().String() != test.toSign {
+			t.Errorf("%s: to_sign txid %v, want %s", test.name,
+				toSign.TxHash(), test.toSign)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	pkScript := testPkScript(t)
+	for _, test := range tests {
+		err := Verify(pkScript, []byte(test.message), test.signature)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+	}
+
+	// Signatures of other messages are invalid.
+	err := Verify(pkScript, []byte("Hello World"), tests[0].signature)
+	if err != ErrInvalidSignature {
+		t.Errorf("signature of other message: got %v, want %v", err,
+			ErrInvalidSignature)
+	}
+	err = Verify(pkScript, []byte(""), "bm90IGEgc2lnbmF0dXJl")
+	if err != ErrMalformedSignature {
+		t.Errorf("malformed signature: got %v, want %v", err,
+			ErrMalformedSignature)
+	}
+}
+
+func TestSignP2PKH(t *testing.T) {
+	wif, err := btcutil.DecodeWIF(testWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(wif.SerializePubKey()), &chaincfg.MainNetParams,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// P2PKH inputs are signed with a signature script, so the signature
+	// is encoded in the full format.
+	message := []byte("Hello World")
+	toSign := ToSign(ToSpend(pkScript, message))
+	toSign.TxIn[0].SignatureScript, err = txscript.SignatureScript(
+		toSign, 0, pkScript, txscript.SigHashAll, wif.PrivKey, true,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := Encode(toSign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(pkScript, message, sig); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(pkScript, []byte("other"), sig); err != ErrInvalidSignature {
+		t.Errorf("signature of other message: got %v, want %v", err,
+			ErrInvalidSignature)
+	}
+}
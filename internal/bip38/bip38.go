@@ -0,0 +1,325 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package bip38 implements the BIP0038 passphrase-protected private key
+// encoding.  Keys in both the non-EC-multiplied and the EC-multiplied modes,
+// the latter being what most paper wallet generators produce, can be
+// decrypted, while keys are always encrypted in the non-EC-multiplied mode.
+package bip38
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcwallet/internal/zero"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrMalformedKey describes an error where a string is not a valid
+	// BIP0038 encrypted private key.
+	ErrMalformedKey = errors.New("malformed BIP0038 encrypted key")
+
+	// ErrWrongPassphrase describes an error where a key does not decrypt to
+	// the key of the address it was encrypted for, which is the result of
+	// decrypting it with the wrong passphrase.
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+)
+
+// Key prefixes and flags, with an encoded key laid out as the prefix, the
+// flag byte, the address hash, and 32 bytes depending on the mode.
+const (
+	encodedLen = 39
+
+	prefixNonECMultiplied = 0x0142
+	prefixECMultiplied    = 0x0143
+
+	flagNonECMultiplied = 0xc0
+	flagCompressed      = 0x20
+	flagLotSequence     = 0x04
+)
+
+// scrypt parameters of the passphrase and EC-multiplied passpoint KDFs.
+const (
+	scryptN, scryptR, scryptP                            = 16384, 8, 8
+	passpointScryptN, passpointScryptR, passpointScryptP = 1024, 1, 1
+)
+
+// Encrypt encrypts the private key of wif with the passphrase in the
+// non-EC-multiplied mode, returning the base58 encoded key.  The address the
+// key is encrypted for, which is checked when decrypting, is the P2PKH address
+// of the public key, using the compression of the WIF, on the passed network.
+func Encrypt(wif *btcutil.WIF, passphrase []byte,
+	net *chaincfg.Params) (string, error) {
+
+	addrHash, err := addressHash(
+		wif.PrivKey.PubKey(), wif.CompressPubKey, net,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	derived, err := deriveKey(passphrase, addrHash)
+	if err != nil {
+		return "", err
+	}
+	defer zero.Bytes(derived)
+
+	block, err := aes.NewCipher(derived[32:])
+	if err != nil {
+		return "", err
+	}
+
+	privKey := wif.PrivKey.Serialize()
+	defer zero.Bytes(privKey)
+	for i := range privKey {
+		privKey[i] ^= derived[i]
+	}
+
+	flag := byte(flagNonECMultiplied)
+	if wif.CompressPubKey {
+		flag |= flagCompressed
+	}
+	encoded := make([]byte, 0, encodedLen+4)
+	encoded = append(encoded, prefixNonECMultiplied>>8,
+		prefixNonECMultiplied&0xff, flag)
+	encoded = append(encoded, addrHash...)
+	encrypted := encoded[len(encoded) : len(encoded)+32]
+	block.Encrypt(encrypted[:16], privKey[:16])
+	block.Encrypt(encrypted[16:], privKey[16:])
+	encoded = encoded[:encodedLen]
+
+	return encodeCheck(encoded), nil
+}
+
+// Decrypt decrypts a base58 encoded BIP0038 key with the passphrase, returning
+// the private key as a WIF for the passed network.  ErrWrongPassphrase is
+// returned when the decrypted key does not match the address hash of the
+// encoded key.
+func Decrypt(encrypted string, passphrase []byte,
+	net *chaincfg.Params) (*btcutil.WIF, error) {
+
+	encoded, err := decodeCheck(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	defer zero.Bytes(encoded)
+
+	prefix := uint16(encoded[0])<<8 | uint16(encoded[1])
+	flag := encoded[2]
+	compressed := flag&flagCompressed != 0
+
+	var privKey *btcec.PrivateKey
+	switch prefix {
+	case prefixNonECMultiplied:
+		if flag&^flagCompressed != flagNonECMultiplied {
+			return nil, ErrMalformedKey
+		}
+		privKey, err = decryptNonECMultiplied(encoded, passphrase)
+	case prefixECMultiplied:
+		if flag&^(flagCompressed|flagLotSequence) != 0 {
+			return nil, ErrMalformedKey
+		}
+		privKey, err = decryptECMultiplied(encoded, passphrase)
+	default:
+		return nil, ErrMalformedKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	addrHash, err := addressHash(privKey.PubKey(), compressed, net)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(addrHash, encoded[3:7]) {
+		zero.BigInt(privKey.D)
+		return nil, ErrWrongPassphrase
+	}
+
+	return btcutil.NewWIF(privKey, net, compressed)
+}
+
+// decryptNonECMultiplied decrypts the private key of a non-EC-multiplied key.
+func decryptNonECMultiplied(encoded, passphrase []byte) (*btcec.PrivateKey, error) {
+	derived, err := deriveKey(passphrase, encoded[3:7])
+	if err != nil {
+		return nil, err
+	}
+	defer zero.Bytes(derived)
+
+	block, err := aes.NewCipher(derived[32:])
+	if err != nil {
+		return nil, err
+	}
+
+	var privKey [32]byte
+	defer zero.Bytea32(&privKey)
+	block.Decrypt(privKey[:16], encoded[7:23])
+	block.Decrypt(privKey[16:], encoded[23:39])
+	for i := range privKey {
+		privKey[i] ^= derived[i]
+	}
+
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), privKey[:])
+	if !validScalar(key.D) {
+		return nil, ErrWrongPassphrase
+	}
+	return key, nil
+}
+
+// decryptECMultiplied decrypts the private key of an EC-multiplied key, which
+// is the product of the passphrase-derived passfactor and the factor derived
+// from the encrypted seed.
+func decryptECMultiplied(encoded, passphrase []byte) (*btcec.PrivateKey, error) {
+	lotSequence := encoded[2]&flagLotSequence != 0
+	addrHash := encoded[3:7]
+	ownerEntropy := encoded[7:15]
+
+	ownerSalt := ownerEntropy
+	if lotSequence {
+		ownerSalt = ownerEntropy[:4]
+	}
+	passFactor, err := scrypt.Key(
+		norm.NFC.Bytes(passphrase), ownerSalt,
+		scryptN, scryptR, scryptP, 32,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lotSequence {
+		preFactor := make([]byte, 0, 40)
+		preFactor = append(preFactor, passFactor...)
+		preFactor = append(preFactor, ownerEntropy...)
+		zero.Bytes(passFactor)
+		passFactor = chainhash.DoubleHashB(preFactor)
+		zero.Bytes(preFactor)
+	}
+	defer zero.Bytes(passFactor)
+
+	passFactorInt := new(big.Int).SetBytes(passFactor)
+	defer zero.BigInt(passFactorInt)
+	if !validScalar(passFactorInt) {
+		return nil, ErrWrongPassphrase
+	}
+	_, passPoint := btcec.PrivKeyFromBytes(btcec.S256(), passFactor)
+
+	salt := make([]byte, 0, 12)
+	salt = append(salt, addrHash...)
+	salt = append(salt, ownerEntropy...)
+	derived, err := scrypt.Key(
+		passPoint.SerializeCompressed(), salt,
+		passpointScryptN, passpointScryptR, passpointScryptP, 64,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer zero.Bytes(derived)
+
+	block, err := aes.NewCipher(derived[32:])
+	if err != nil {
+		return nil, err
+	}
+
+	// The second encrypted part holds the remainder of the first encrypted
+	// part along with the last 8 bytes of seedb.
+	var part2, part1, seedB [16]byte
+	defer zero.Bytes(part2[:])
+	block.Decrypt(part2[:], encoded[23:39])
+	for i := range part2 {
+		part2[i] ^= derived[16+i]
+	}
+	copy(part1[:8], encoded[15:23])
+	copy(part1[8:], part2[:8])
+	block.Decrypt(seedB[:], part1[:])
+	defer zero.Bytes(seedB[:])
+	for i := range seedB {
+		seedB[i] ^= derived[i]
+	}
+
+	seed := make([]byte, 0, 24)
+	seed = append(seed, seedB[:]...)
+	seed = append(seed, part2[8:]...)
+	factorB := chainhash.DoubleHashB(seed)
+	zero.Bytes(seed)
+	defer zero.Bytes(factorB)
+
+	d := new(big.Int).SetBytes(factorB)
+	d.Mul(d, passFactorInt)
+	d.Mod(d, btcec.S256().N)
+	defer zero.BigInt(d)
+	if d.Sign() == 0 {
+		return nil, ErrWrongPassphrase
+	}
+
+	var privKey [32]byte
+	defer zero.Bytea32(&privKey)
+	b := d.Bytes()
+	copy(privKey[32-len(b):], b)
+	zero.Bytes(b)
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), privKey[:])
+	return key, nil
+}
+
+// deriveKey derives the 64 byte key of the non-EC-multiplied mode from the
+// NFC normalized passphrase, salted with the address hash.
+func deriveKey(passphrase, addrHash []byte) ([]byte, error) {
+	return scrypt.Key(
+		norm.NFC.Bytes(passphrase), addrHash,
+		scryptN, scryptR, scryptP, 64,
+	)
+}
+
+// addressHash returns the first four bytes of the double SHA256 of the P2PKH
+// address of the public key.
+func addressHash(pubKey *btcec.PublicKey, compressed bool,
+	net *chaincfg.Params) ([]byte, error) {
+
+	serialized := pubKey.SerializeUncompressed()
+	if compressed {
+		serialized = pubKey.SerializeCompressed()
+	}
+	addr, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(serialized), net,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return chainhash.DoubleHashB([]byte(addr.EncodeAddress()))[:4], nil
+}
+
+// validScalar returns whether d is a valid private key on the secp256k1
+// curve.
+func validScalar(d *big.Int) bool {
+	return d.Sign() > 0 && d.Cmp(btcec.S256().N) < 0
+}
+
+// encodeCheck base58 encodes b followed by its four byte checksum.  BIP0038
+// keys have no separate version byte, so base58.CheckEncode can't be used.
+func encodeCheck(b []byte) string {
+	checksum := chainhash.DoubleHashB(b)[:4]
+	return base58.Encode(append(b[:len(b):len(b)], checksum...))
+}
+
+// decodeCheck decodes a base58 encoded key, verifying and stripping its
+// checksum.
+func decodeCheck(s string) ([]byte, error) {
+	decoded := base58.Decode(s)
+	if len(decoded) != encodedLen+4 {
+		return nil, ErrMalformedKey
+	}
+	b, checksum := decoded[:encodedLen], decoded[encodedLen:]
+	if !bytes.Equal(chainhash.DoubleHashB(b)[:4], checksum) {
+		return nil, ErrMalformedKey
+	}
+	return b, nil
+}
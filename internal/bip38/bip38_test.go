@@ -0,0 +1,116 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bip38
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+// The test vectors of BIP0038.
+var tests = []struct {
+	name       string
+	passphrase string
+	encrypted  string
+	wif        string
+}{
+	{
+		name:       "no EC multiply, uncompressed",
+		passphrase: "TestingOneTwoThree",
+		encrypted:  "6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGg",
+		wif:        "5KN7MzqK5wt2TP1fQCYyHBtDrXdJuXbUzm4A9rKAteGu3Qi5CVR",
+	},
+	{
+		name:       "no EC multiply, uncompressed 2",
+		passphrase: "Satoshi",
+		encrypted:  "6PRNFFkZc2NZ6dJqFfhRoFNMR9Lnyj7dYGrzdgXXVMXcxoKTePPX1dWByq",
+		wif:        "5HtasZ6ofTHP6HCwTqTkLDuLQisYPah7aUnSKfC7h4hMUVw2gi5",
+	},
+	{
+		name:       "no EC multiply, compressed",
+		passphrase: "TestingOneTwoThree",
+		encrypted:  "6PYNKZ1EAgYgmQfmNVamxyXVWHzK5s6DGhwP4J5o44cvXdoY7sRzhtpUeo",
+		wif:        "L44B5gGEpqEDRS9vVPz7QT35jcBG2r3CZwSwQ4fCewXAhAhqGVpP",
+	},
+	{
+		name:       "no EC multiply, compressed 2",
+		passphrase: "Satoshi",
+		encrypted:  "6PYLtMnXvfG3oJde97zRyLYFZCYizPU5T3LwgdYJz1fRhh16bU7u6PPmY7",
+		wif:        "KwYgW8gcxj1JWJXhPSu4Fqwzfhp5Yfi42mdYmMa4XqK7NJxXUSK7",
+	},
+	{
+		name:       "EC multiply, no lot/sequence",
+		passphrase: "TestingOneTwoThree",
+		encrypted:  "6PfQu77ygVyJLZjfvMLyhLMQbYnu5uguoJJ4kMCLqWwPEdfpwANVS76gTX",
+		wif:        "5K4caxezwjGCGfnoPTZ8tMcJBLB7Jvyjv4xxeacadhq8nLisLR2",
+	},
+	{
+		name:       "EC multiply, no lot/sequence 2",
+		passphrase: "Satoshi",
+		encrypted:  "6PfLGnQs6VZnrNpmVKfjotbnQuaJK4KZoPFrAjx1JMJUa1Ft8gnf5WxfKd",
+		wif:        "5KJ51SgxWaAYR13zd9ReMhJpwrcX47xTJh2D3fGPG9CM8vkv5sH",
+	},
+	{
+		name:       "EC multiply, lot/sequence",
+		passphrase: "MOLON LABE",
+		encrypted:  "6PgNBNNzDkKdhkT6uJntUXwwzQV8Rr2tZcbkDcuC9DZRsS6AtHts4Ypo1j",
+		wif:        "5JLdxTtcTHcfYcmJsNVy1v2PMDx432JPoYcBTVVRHpPaxUrdtf8",
+	},
+}
+
+func TestDecrypt(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range tests {
+		wif, err := Decrypt(
+			test.encrypted, []byte(test.passphrase),
+			&chaincfg.MainNetParams,
+		)
+		if err != nil {
+			t.Fatalf("%s: unable to decrypt: %v", test.name, err)
+		}
+		if wif.String() != test.wif {
+			t.Fatalf("%s: want %s, got %s", test.name, test.wif,
+				wif.String())
+		}
+
+		_, err = Decrypt(
+			test.encrypted, []byte("wrong"), &chaincfg.MainNetParams,
+		)
+		if err != ErrWrongPassphrase {
+			t.Fatalf("%s: want ErrWrongPassphrase, got %v",
+				test.name, err)
+		}
+	}
+
+	_, err := Decrypt("5KN7MzqK5wt2TP1fQCYyHBtDrXdJuXbUzm4A9rKAteGu3Qi5CVR",
+		nil, &chaincfg.MainNetParams)
+	if err != ErrMalformedKey {
+		t.Fatalf("want ErrMalformedKey, got %v", err)
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range tests[:4] {
+		wif, err := btcutil.DecodeWIF(test.wif)
+		if err != nil {
+			t.Fatalf("%s: bad test WIF: %v", test.name, err)
+		}
+		encrypted, err := Encrypt(
+			wif, []byte(test.passphrase), &chaincfg.MainNetParams,
+		)
+		if err != nil {
+			t.Fatalf("%s: unable to encrypt: %v", test.name, err)
+		}
+		if encrypted != test.encrypted {
+			t.Fatalf("%s: want %s, got %s", test.name,
+				test.encrypted, encrypted)
+		}
+	}
+}
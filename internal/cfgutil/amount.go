@@ -5,6 +5,8 @@
 package cfgutil
 
 import (
+	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
@@ -41,3 +43,56 @@ func (a *AmountFlag) UnmarshalFlag(value string) error {
 	a.Amount = amount
 	return nil
 }
+
+// ParseAmountUnit parses the name of a bitcoin denomination.  Names are
+// matched case insensitively, and both the unit symbol (e.g. "mBTC") and the
+// name of the smallest unit ("satoshi", "sat" or "sats") are accepted.
+func ParseAmountUnit(s string) (btcutil.AmountUnit, error) {
+	switch strings.ToLower(s) {
+	case "btc":
+		return btcutil.AmountBTC, nil
+	case "mbtc":
+		return btcutil.AmountMilliBTC, nil
+	case "ubtc", "μbtc", "bits":
+		return btcutil.AmountMicroBTC, nil
+	case "satoshi", "satoshis", "sat", "sats":
+		return btcutil.AmountSatoshi, nil
+	default:
+		return 0, fmt.Errorf("unknown amount unit %q", s)
+	}
+}
+
+// AmountUnitFlag embeds a btcutil.AmountUnit and implements the
+// flags.Marshaler and Unmarshaler interfaces so it can be used as a config
+// struct field.
+type AmountUnitFlag struct {
+	btcutil.AmountUnit
+}
+
+// NewAmountUnitFlag creates an AmountUnitFlag with a default
+// btcutil.AmountUnit.
+func NewAmountUnitFlag(defaultValue btcutil.AmountUnit) *AmountUnitFlag {
+	return &AmountUnitFlag{defaultValue}
+}
+
+// MarshalFlag satisfies the flags.Marshaler interface.
+func (u *AmountUnitFlag) MarshalFlag() (string, error) {
+	return u.AmountUnit.String(), nil
+}
+
+// UnmarshalFlag satisfies the flags.Unmarshaler interface.
+func (u *AmountUnitFlag) UnmarshalFlag(value string) error {
+	unit, err := ParseAmountUnit(value)
+	if err != nil {
+		return err
+	}
+	u.AmountUnit = unit
+	return nil
+}
+
+// NewAmountInUnit creates a btcutil.Amount from a floating point value
+// denominated in unit.  Values which are not integral numbers of satoshi are
+// rounded to the nearest satoshi.
+func NewAmountInUnit(value float64, unit btcutil.AmountUnit) (btcutil.Amount, error) {
+	return btcutil.NewAmount(value * math.Pow10(int(unit)))
+}
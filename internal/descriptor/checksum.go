@@ -0,0 +1,78 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// inputCharset is the set of characters which may appear in a descriptor,
+// ordered so that the characters of key and script expressions checksum into
+// the low bits of each symbol.
+const inputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+	"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+	"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+// checksumCharset is the alphabet of the checksum, shared with bech32.
+const checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// checksumLen is the number of characters of a checksum.
+const checksumLen = 8
+
+// generator holds the generator coefficients of the BCH code of checksums.
+var generator = [5]uint64{
+	0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd,
+}
+
+// polymod feeds a symbol into the checksum polynomial chk, returning the
+// updated remainder.
+func polymod(chk uint64, symbol uint64) uint64 {
+	top := chk >> 35
+	chk = (chk&0x7ffffffff)<<5 ^ symbol
+	for i, g := range generator {
+		if (top>>uint(i))&1 != 0 {
+			chk ^= g
+		}
+	}
+	return chk
+}
+
+// Checksum returns the BIP0380 checksum of a descriptor without one.
+func Checksum(desc string) (string, error) {
+	chk := uint64(1)
+	var groups [3]uint64
+	n := 0
+	for i := 0; i < len(desc); i++ {
+		pos := strings.IndexByte(inputCharset, desc[i])
+		if pos < 0 {
+			return "", fmt.Errorf("invalid character %q in "+
+				"descriptor", desc[i])
+		}
+		chk = polymod(chk, uint64(pos&31))
+		groups[n] = uint64(pos >> 5)
+		n++
+		if n == 3 {
+			chk = polymod(chk, groups[0]*9+groups[1]*3+groups[2])
+			n = 0
+		}
+	}
+	switch n {
+	case 1:
+		chk = polymod(chk, groups[0])
+	case 2:
+		chk = polymod(chk, groups[0]*3+groups[1])
+	}
+	for i := 0; i < checksumLen; i++ {
+		chk = polymod(chk, 0)
+	}
+	chk ^= 1
+
+	sum := make([]byte, checksumLen)
+	for i := range sum {
+		sum[i] = checksumCharset[(chk>>(5*uint(checksumLen-1-i)))&31]
+	}
+	return string(sum), nil
+}
@@ -0,0 +1,590 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package descriptor implements the output script descriptors of BIP0380 for
+// the script types the wallet can hold: pkh, wpkh and sh(wpkh) of a single
+// key, and multi and sortedmulti scripts nested in sh or wsh.  Keys may be hex
+// encoded public keys, WIF private keys, or extended keys derived along a path
+// which may end in a wildcard, making the descriptor ranged.
+package descriptor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+var (
+	// ErrChecksum describes an error where the checksum of a descriptor
+	// does not match it.
+	ErrChecksum = errors.New("descriptor checksum mismatch")
+
+	// ErrUnsupported describes an error where a descriptor is of a script
+	// type which is not supported.
+	ErrUnsupported = errors.New("unsupported descriptor")
+)
+
+// Type is the script template of a descriptor.
+type Type uint8
+
+const (
+	// PKH is a pay-to-pubkey-hash descriptor, pkh(KEY).
+	PKH Type = iota
+
+	// WPKH is a pay-to-witness-pubkey-hash descriptor, wpkh(KEY).
+	WPKH
+
+	// SHWPKH is a pay-to-witness-pubkey-hash descriptor nested in
+	// pay-to-script-hash, sh(wpkh(KEY)).
+	SHWPKH
+
+	// SHMulti is a pay-to-script-hash multisig descriptor,
+	// sh(multi(k,KEY,...)).
+	SHMulti
+
+	// WSHMulti is a pay-to-witness-script-hash multisig descriptor,
+	// wsh(multi(k,KEY,...)).
+	WSHMulti
+)
+
+// maxMultiKeys is the maximum number of keys of the multisig scripts of each
+// type, limited by the maximum size of redeem scripts for sh and by the
+// maximum number of public keys of CHECKMULTISIG for wsh.
+var maxMultiKeys = map[Type]int{
+	SHMulti:  15,
+	WSHMulti: 20,
+}
+
+// Key is a key expression of a descriptor.
+type Key struct {
+	// HasOrigin is set when the key expression includes the fingerprint
+	// of the master key the key was derived from, and the path of the
+	// derivation.
+	HasOrigin   bool
+	Fingerprint uint32
+	OriginPath  []uint32
+
+	// PubKey is set for single keys, which are uncompressed unless
+	// Compressed is set.  WIF is additionally set for private keys.
+	PubKey     *btcec.PublicKey
+	Compressed bool
+	WIF        *btcutil.WIF
+
+	// ExtKey is set for extended keys, from which the key is derived
+	// along Path.  Ranged keys are derived further by the index the
+	// descriptor is expanded at, as a hardened child when HardenedRange
+	// is set.
+	ExtKey        *hdkeychain.ExtendedKey
+	Path          []uint32
+	Ranged        bool
+	HardenedRange bool
+}
+
+// Descriptor is a parsed output script descriptor.
+type Descriptor struct {
+	Type Type
+	Keys []*Key
+
+	// Threshold is the number of signatures required by multisig
+	// descriptors, whose keys are ordered by their encoding in the script
+	// when Sorted is set.
+	Threshold int
+	Sorted    bool
+}
+
+// DerivedKey is a key of a descriptor expanded at an index.  PrivKey is nil
+// unless the key expression is of a private key.
+type DerivedKey struct {
+	PubKey     *btcec.PublicKey
+	PrivKey    *btcec.PrivateKey
+	Compressed bool
+}
+
+// Expansion holds the output script of a descriptor expanded at an index, the
+// scripts required to spend it, and its keys.
+type Expansion struct {
+	PkScript      []byte
+	RedeemScript  []byte
+	WitnessScript []byte
+	Keys          []DerivedKey
+}
+
+// Parse parses a descriptor for the network, checking its checksum when it
+// has one.
+func Parse(desc string, params *chaincfg.Params) (*Descriptor, error) {
+	if i := strings.LastIndexByte(desc, '#'); i >= 0 {
+		want, err := Checksum(desc[:i])
+		if err != nil {
+			return nil, err
+		}
+		if desc[i+1:] != want {
+			return nil, ErrChecksum
+		}
+		desc = desc[:i]
+	}
+
+	if inner, ok := unwrap(desc, "pkh"); ok {
+		return parseSingle(PKH, inner, params)
+	}
+	if inner, ok := unwrap(desc, "wpkh"); ok {
+		return parseSingle(WPKH, inner, params)
+	}
+	if inner, ok := unwrap(desc, "sh"); ok {
+		if inner, ok := unwrap(inner, "wpkh"); ok {
+			return parseSingle(SHWPKH, inner, params)
+		}
+		return parseMulti(SHMulti, inner, params)
+	}
+	if inner, ok := unwrap(desc, "wsh"); ok {
+		return parseMulti(WSHMulti, inner, params)
+	}
+	return nil, ErrUnsupported
+}
+
+// unwrap returns the argument of a script expression if it is of the named
+// function.
+func unwrap(expr, name string) (string, bool) {
+	if !strings.HasPrefix(expr, name+"(") || !strings.HasSuffix(expr, ")") {
+		return "", false
+	}
+	return expr[len(name)+1 : len(expr)-1], true
+}
+
+// parseSingle parses the key of a single key descriptor.
+func parseSingle(typ Type, expr string, params *chaincfg.Params) (*Descriptor,
+	error) {
+
+	key, err := parseKey(expr, params)
+	if err != nil {
+		return nil, err
+	}
+	if typ != PKH && !key.compressed() {
+		return nil, errors.New("segwit descriptors require compressed " +
+			"keys")
+	}
+	return &Descriptor{Type: typ, Keys: []*Key{key}}, nil
+}
+
+// parseMulti parses the multi or sortedmulti expression of a multisig
+// descriptor.
+func parseMulti(typ Type, expr string, params *chaincfg.Params) (*Descriptor,
+	error) {
+
+	d := &Descriptor{Type: typ}
+	inner, ok := unwrap(expr, "multi")
+	if !ok {
+		inner, ok = unwrap(expr, "sortedmulti")
+		if !ok {
+			return nil, ErrUnsupported
+		}
+		d.Sorted = true
+	}
+
+	args := strings.Split(inner, ",")
+	threshold, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid multisig threshold %q", args[0])
+	}
+	if len(args) < 2 || len(args)-1 > maxMultiKeys[typ] {
+		return nil, fmt.Errorf("multisig descriptors require 1 to %d "+
+			"keys", maxMultiKeys[typ])
+	}
+	if threshold < 1 || threshold > len(args)-1 {
+		return nil, fmt.Errorf("multisig threshold %d is not between "+
+			"1 and the number of keys", threshold)
+	}
+	d.Threshold = threshold
+
+	for _, arg := range args[1:] {
+		key, err := parseKey(arg, params)
+		if err != nil {
+			return nil, err
+		}
+		if typ == WSHMulti && !key.compressed() {
+			return nil, errors.New("segwit descriptors require " +
+				"compressed keys")
+		}
+		d.Keys = append(d.Keys, key)
+	}
+	return d, nil
+}
+
+// parseKey parses a key expression, with its optional origin.
+func parseKey(expr string, params *chaincfg.Params) (*Key, error) {
+	key := new(Key)
+	if strings.HasPrefix(expr, "[") {
+		end := strings.IndexByte(expr, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated key origin in %q", expr)
+		}
+		origin := strings.Split(expr[1:end], "/")
+		fingerprint, err := hex.DecodeString(origin[0])
+		if err != nil || len(fingerprint) != 4 {
+			return nil, fmt.Errorf("invalid key origin fingerprint %q",
+				origin[0])
+		}
+		key.Fingerprint = binary.LittleEndian.Uint32(fingerprint)
+		key.OriginPath, err = parsePath(origin[1:])
+		if err != nil {
+			return nil, err
+		}
+		key.HasOrigin = true
+		expr = expr[end+1:]
+	}
+
+	parts := strings.Split(expr, "/")
+	if len(parts) == 1 {
+		if b, err := hex.DecodeString(expr); err == nil {
+			pubKey, err := btcec.ParsePubKey(b, btcec.S256())
+			if err != nil {
+				return nil, fmt.Errorf("invalid public key %q", expr)
+			}
+			key.PubKey = pubKey
+			key.Compressed = len(b) == btcec.PubKeyBytesLenCompressed
+			return key, nil
+		}
+		if wif, err := btcutil.DecodeWIF(expr); err == nil {
+			if !wif.IsForNet(params) {
+				return nil, errors.New("private key is not " +
+					"for the network")
+			}
+			key.PubKey = wif.PrivKey.PubKey()
+			key.Compressed = wif.CompressPubKey
+			key.WIF = wif
+			return key, nil
+		}
+	}
+
+	extKey, err := hdkeychain.NewKeyFromString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %q", parts[0])
+	}
+	if !extKey.IsForNet(params) {
+		return nil, errors.New("extended key is not for the network")
+	}
+	key.ExtKey = extKey
+
+	parts = parts[1:]
+	if n := len(parts); n > 0 {
+		switch parts[n-1] {
+		case "*":
+			key.Ranged = true
+		case "*'", "*h":
+			key.Ranged = true
+			key.HardenedRange = true
+		}
+		if key.Ranged {
+			parts = parts[:n-1]
+		}
+	}
+	key.Path, err = parsePath(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !extKey.IsPrivate() {
+		hardened := key.HardenedRange
+		for _, index := range key.Path {
+			hardened = hardened || index >= hdkeychain.HardenedKeyStart
+		}
+		if hardened {
+			return nil, errors.New("hardened derivation requires " +
+				"an extended private key")
+		}
+	}
+	return key, nil
+}
+
+// parsePath parses the elements of a derivation path, which are hardened when
+// followed by an apostrophe or h.
+func parsePath(elems []string) ([]uint32, error) {
+	path := make([]uint32, 0, len(elems))
+	for _, elem := range elems {
+		hardened := strings.HasSuffix(elem, "'") ||
+			strings.HasSuffix(elem, "h")
+		if hardened {
+			elem = elem[:len(elem)-1]
+		}
+		index, err := strconv.ParseUint(elem, 10, 32)
+		if err != nil || index >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("invalid derivation path "+
+				"element %q", elem)
+		}
+		if hardened {
+			index += hdkeychain.HardenedKeyStart
+		}
+		path = append(path, uint32(index))
+	}
+	return path, nil
+}
+
+// compressed returns whether the key serializes compressed, which is true of
+// all extended keys.
+func (k *Key) compressed() bool {
+	return k.ExtKey != nil || k.Compressed
+}
+
+// IsPrivate returns whether the key expression is of a private key, so that
+// the keys derived from it may sign.
+func (k *Key) IsPrivate() bool {
+	if k.ExtKey != nil {
+		return k.ExtKey.IsPrivate()
+	}
+	return k.WIF != nil
+}
+
+// derive returns the key at an index, which is only used by ranged keys.
+func (k *Key) derive(index uint32) (DerivedKey, error) {
+	if k.ExtKey == nil {
+		key := DerivedKey{PubKey: k.PubKey, Compressed: k.Compressed}
+		if k.WIF != nil {
+			key.PrivKey = k.WIF.PrivKey
+		}
+		return key, nil
+	}
+
+	path := k.Path
+	if k.Ranged {
+		if index >= hdkeychain.HardenedKeyStart {
+			return DerivedKey{}, fmt.Errorf("index %d is out of "+
+				"range", index)
+		}
+		if k.HardenedRange {
+			index += hdkeychain.HardenedKeyStart
+		}
+		path = append(path[:len(path):len(path)], index)
+	}
+	extKey := k.ExtKey
+	for _, i := range path {
+		var err error
+		extKey, err = extKey.Derive(i)
+		if err != nil {
+			return DerivedKey{}, err
+		}
+	}
+
+	pubKey, err := extKey.ECPubKey()
+	if err != nil {
+		return DerivedKey{}, err
+	}
+	key := DerivedKey{PubKey: pubKey, Compressed: true}
+	if extKey.IsPrivate() {
+		key.PrivKey, err = extKey.ECPrivKey()
+		if err != nil {
+			return DerivedKey{}, err
+		}
+	}
+	return key, nil
+}
+
+// serialize returns the encoding of a derived public key in scripts.
+func (k *DerivedKey) serialize() []byte {
+	if k.Compressed {
+		return k.PubKey.SerializeCompressed()
+	}
+	return k.PubKey.SerializeUncompressed()
+}
+
+// IsRange returns whether the descriptor has a ranged key, so that it
+// describes a different script at each index.
+func (d *Descriptor) IsRange() bool {
+	for _, key := range d.Keys {
+		if key.Ranged {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand returns the output script of the descriptor at an index, which is
+// only used by ranged descriptors, and the scripts and keys needed to spend
+// it.
+func (d *Descriptor) Expand(index uint32) (*Expansion, error) {
+	keys := make([]DerivedKey, len(d.Keys))
+	for i, key := range d.Keys {
+		var err error
+		keys[i], err = key.derive(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	e := &Expansion{Keys: keys}
+	var err error
+	switch d.Type {
+	case PKH:
+		e.PkScript, err = payToPubKeyHashScript(keys[0].serialize())
+
+	case WPKH:
+		e.PkScript, err = payToWitnessPubKeyHashScript(
+			keys[0].serialize(),
+		)
+
+	case SHWPKH:
+		e.RedeemScript, err = payToWitnessPubKeyHashScript(
+			keys[0].serialize(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		e.PkScript, err = payToScriptHashScript(e.RedeemScript)
+
+	case SHMulti:
+		e.RedeemScript, err = d.multiSigScript(keys)
+		if err != nil {
+			return nil, err
+		}
+		e.PkScript, err = payToScriptHashScript(e.RedeemScript)
+
+	case WSHMulti:
+		e.WitnessScript, err = d.multiSigScript(keys)
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.Sum256(e.WitnessScript)
+		e.PkScript, err = txscript.NewScriptBuilder().
+			AddOp(txscript.OP_0).AddData(hash[:]).Script()
+
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// multiSigScript returns the multisig script of the derived keys of a
+// descriptor.
+func (d *Descriptor) multiSigScript(keys []DerivedKey) ([]byte, error) {
+	pubKeys := make([][]byte, len(keys))
+	for i := range keys {
+		pubKeys[i] = keys[i].serialize()
+	}
+	if d.Sorted {
+		sort.Slice(pubKeys, func(i, j int) bool {
+			return bytes.Compare(pubKeys[i], pubKeys[j]) < 0
+		})
+	}
+
+	b := txscript.NewScriptBuilder().AddInt64(int64(d.Threshold))
+	for _, pubKey := range pubKeys {
+		b.AddData(pubKey)
+	}
+	return b.AddInt64(int64(len(pubKeys))).
+		AddOp(txscript.OP_CHECKMULTISIG).Script()
+}
+
+// payToPubKeyHashScript returns the pay-to-pubkey-hash script of a key.
+func payToPubKeyHashScript(pubKey []byte) ([]byte, error) {
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(pubKey)).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).
+		Script()
+}
+
+// payToWitnessPubKeyHashScript returns the pay-to-witness-pubkey-hash script
+// of a key.
+func payToWitnessPubKeyHashScript(pubKey []byte) ([]byte, error) {
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(btcutil.Hash160(pubKey)).Script()
+}
+
+// payToScriptHashScript returns the pay-to-script-hash script of a redeem
+// script.
+func payToScriptHashScript(script []byte) ([]byte, error) {
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(script)).AddOp(txscript.OP_EQUAL).
+		Script()
+}
+
+// String returns the descriptor followed by its checksum.
+func (d *Descriptor) String() string {
+	var desc string
+	switch d.Type {
+	case PKH:
+		desc = "pkh(" + d.Keys[0].String() + ")"
+	case WPKH:
+		desc = "wpkh(" + d.Keys[0].String() + ")"
+	case SHWPKH:
+		desc = "sh(wpkh(" + d.Keys[0].String() + "))"
+	case SHMulti:
+		desc = "sh(" + d.multiString() + ")"
+	case WSHMulti:
+		desc = "wsh(" + d.multiString() + ")"
+	}
+
+	// Descriptors are only made of characters of the checksum charset.
+	sum, _ := Checksum(desc)
+	return desc + "#" + sum
+}
+
+// multiString returns the multi or sortedmulti expression of a multisig
+// descriptor.
+func (d *Descriptor) multiString() string {
+	name := "multi"
+	if d.Sorted {
+		name = "sortedmulti"
+	}
+	args := []string{strconv.Itoa(d.Threshold)}
+	for _, key := range d.Keys {
+		args = append(args, key.String())
+	}
+	return name + "(" + strings.Join(args, ",") + ")"
+}
+
+// String returns the key expression, with its origin.
+func (k *Key) String() string {
+	var b strings.Builder
+	if k.HasOrigin {
+		var fingerprint [4]byte
+		binary.LittleEndian.PutUint32(fingerprint[:], k.Fingerprint)
+		b.WriteString("[" + hex.EncodeToString(fingerprint[:]))
+		writePath(&b, k.OriginPath)
+		b.WriteString("]")
+	}
+
+	switch {
+	case k.ExtKey != nil:
+		b.WriteString(k.ExtKey.String())
+		writePath(&b, k.Path)
+		if k.Ranged {
+			b.WriteString("/*")
+			if k.HardenedRange {
+				b.WriteString("'")
+			}
+		}
+	case k.WIF != nil:
+		b.WriteString(k.WIF.String())
+	case k.Compressed:
+		b.WriteString(hex.EncodeToString(k.PubKey.SerializeCompressed()))
+	default:
+		b.WriteString(hex.EncodeToString(k.PubKey.SerializeUncompressed()))
+	}
+	return b.String()
+}
+
+// writePath writes the elements of a derivation path, each preceded by a
+// slash.
+func writePath(b *strings.Builder, path []uint32) {
+	for _, index := range path {
+		if index >= hdkeychain.HardenedKeyStart {
+			fmt.Fprintf(b, "/%d'", index-hdkeychain.HardenedKeyStart)
+		} else {
+			fmt.Fprintf(b, "/%d", index)
+		}
+	}
+}
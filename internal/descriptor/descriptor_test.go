@@ -0,0 +1,246 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+)
+
+func TestChecksum(t *testing.T) {
+	tests := []struct {
+		desc string
+		sum  string
+	}{
+		{"raw(deadbeef)", "89f8spxm"},
+		{"pkh(02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5)", "8fhd9pwu"},
+	}
+	for _, test := range tests {
+		sum, err := Checksum(test.desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum != test.sum {
+			t.Errorf("checksum of %s is %s, want %s", test.desc, sum,
+				test.sum)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		desc     string
+		pkScript string
+	}{
+		{
+			desc:     "pkh(02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5)#8fhd9pwu",
+			pkScript: "76a91406afd46bcdfd22ef94ac122aa11f241244a37ecc88ac",
+		},
+		{
+			desc:     "wpkh(03a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd)",
+			pkScript: "00149a1c78a507689f6f54b847ad1cef1e614ee23f1e",
+		},
+		{
+			desc:     "wpkh(L4rK1yDtCWekvXuE6oXD9jCYfFNV2cWRpVuPLBcCU2z8TrisoyY1)",
+			pkScript: "00149a1c78a507689f6f54b847ad1cef1e614ee23f1e",
+		},
+		{
+			desc:     "sh(wpkh(03a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd))",
+			pkScript: "a91484ab21b1b2fd065d4504ff693d832434b6108d7b87",
+		},
+	}
+	for _, test := range tests {
+		d, err := Parse(test.desc, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("%s: %v", test.desc, err)
+		}
+		if d.IsRange() {
+			t.Errorf("%s: is ranged", test.desc)
+		}
+		e, err := d.Expand(0)
+		if err != nil {
+			t.Fatalf("%s: %v", test.desc, err)
+		}
+		if hex.EncodeToString(e.PkScript) != test.pkScript {
+			t.Errorf("%s: script %x, want %s", test.desc, e.PkScript,
+				test.pkScript)
+		}
+
+		// The canonical encoding parses to the same script.
+		d2, err := Parse(d.String(), &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("%s: %v", d.String(), err)
+		}
+		e2, err := d2.Expand(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(e2.PkScript, e.PkScript) {
+			t.Errorf("%s: reparsed script %x", d.String(), e2.PkScript)
+		}
+	}
+}
+
+func TestRanged(t *testing.T) {
+	const xprv = "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"
+	master, err := hdkeychain.NewKeyFromString(xprv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := master.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Parse("wpkh([deadbeef/84'/0'/0']"+pub.String()+"/1/*)",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.IsRange() {
+		t.Fatal("descriptor is not ranged")
+	}
+	for _, index := range []uint32{0, 7} {
+		e, err := d.Expand(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		child, err := pub.Derive(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		child, err = child.Derive(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubKey, err := child.ECPubKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := payToWitnessPubKeyHashScript(
+			pubKey.SerializeCompressed(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(e.PkScript, want) {
+			t.Errorf("index %d: script %x, want %x", index,
+				e.PkScript, want)
+		}
+		if e.Keys[0].PrivKey != nil {
+			t.Errorf("index %d: public key has private key", index)
+		}
+	}
+
+	// Keys derived from extended private keys have their private key.
+	d, err = Parse("pkh("+xprv+"/0'/*')", &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := d.Expand(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Keys[0].PrivKey == nil {
+		t.Error("private key is not derived")
+	}
+	if d.Keys[0].String() != xprv+"/0'/*'" {
+		t.Errorf("key encoded as %s", d.Keys[0].String())
+	}
+
+	// Hardened derivation from extended public keys is impossible.
+	_, err = Parse("pkh("+pub.String()+"/0'/*)", &chaincfg.MainNetParams)
+	if err == nil {
+		t.Error("hardened derivation from public key was parsed")
+	}
+}
+
+func TestMulti(t *testing.T) {
+	const (
+		key1 = "03a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd"
+		key2 = "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+	)
+	multi, err := Parse("wsh(multi(1,"+key1+","+key2+"))",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sorted, err := Parse("wsh(sortedmulti(1,"+key1+","+key2+"))",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversed, err := Parse("wsh(multi(1,"+key2+","+key1+"))",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	em, err := multi.Expand(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es, err := sorted.Expand(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	er, err := reversed.Expand(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(em.PkScript, er.PkScript) {
+		t.Error("multi scripts do not depend on the order of keys")
+	}
+	if !bytes.Equal(es.WitnessScript, er.WitnessScript) {
+		t.Error("sortedmulti script does not order keys")
+	}
+	if len(es.PkScript) != 34 || es.RedeemScript != nil {
+		t.Errorf("unexpected wsh scripts %x %x", es.PkScript,
+			es.RedeemScript)
+	}
+
+	for _, desc := range []string{
+		"wsh(multi(3," + key1 + "," + key2 + "))",
+		"wsh(multi(0," + key1 + "))",
+		"sh(wsh(multi(1," + key1 + ")))",
+	} {
+		if _, err := Parse(desc, &chaincfg.MainNetParams); err == nil {
+			t.Errorf("invalid descriptor %s was parsed", desc)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	const key = "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+	_, err := Parse("pkh("+key+")#8fhd9pwx", &chaincfg.MainNetParams)
+	if err != ErrChecksum {
+		t.Errorf("bad checksum: got %v, want %v", err, ErrChecksum)
+	}
+	_, err = Parse("tr("+key+")", &chaincfg.MainNetParams)
+	if err != ErrUnsupported {
+		t.Errorf("unsupported type: got %v, want %v", err,
+			ErrUnsupported)
+	}
+
+	// Segwit descriptors can not have uncompressed keys.
+	const uncompressed = "04c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee51ae168fea63dc339a3c58419466ceaeef7f632653266d0e1236431a950cfe52a"
+	if _, err := Parse("pkh("+uncompressed+")", &chaincfg.MainNetParams); err != nil {
+		t.Errorf("uncompressed pkh: %v", err)
+	}
+	if _, err := Parse("wpkh("+uncompressed+")", &chaincfg.MainNetParams); err == nil {
+		t.Error("uncompressed wpkh was parsed")
+	}
+
+	// Keys must be for the network.
+	_, err = Parse("wpkh(L4rK1yDtCWekvXuE6oXD9jCYfFNV2cWRpVuPLBcCU2z8TrisoyY1)",
+		&chaincfg.TestNet3Params)
+	if err == nil {
+		t.Error("mainnet key was parsed for testnet")
+	}
+}
@@ -476,6 +476,8 @@ func (net *netParams) ReadFrom(r io.Reader) (int64, error) {
 		*net = (netParams)(chaincfg.TestNet3Params)
 	case wire.SimNet:
 		*net = (netParams)(chaincfg.SimNetParams)
+	case wire.TestNet:
+		*net = (netParams)(chaincfg.RegressionNetParams)
 
 	// The legacy key store won't be compatible with custom signets, only
 	// the main public one.
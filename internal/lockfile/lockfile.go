@@ -0,0 +1,63 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package lockfile provides exclusive locks of files held for the life of a
+// process, such as to ensure that a single process uses a data directory.
+//
+// Locks are advisory, and are released by the operating system when the
+// process holding them exits, so a lock file left behind by a process which
+// crashed does not prevent a new process from acquiring the lock.
+package lockfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrLocked is returned by Acquire when the lock is held by another process.
+var ErrLocked = errors.New("lock is held by another process")
+
+// Lock is an exclusive lock of a file.
+type Lock struct {
+	file *os.File
+}
+
+// Acquire creates the lock file at a path if it does not exist and acquires
+// an exclusive lock of it without waiting, returning ErrLocked if the lock is
+// held by another process.  The process ID of the holder is written to the
+// file to help identify the process holding the lock.
+func Acquire(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := f.Truncate(0); err != nil {
+		unlockFile(f)
+		f.Close()
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(f, "%d\n", os.Getpid()); err != nil {
+		unlockFile(f)
+		f.Close()
+		return nil, err
+	}
+	return &Lock{file: f}, nil
+}
+
+// Release releases the lock.  The lock file is left in place, since removing
+// it could allow two processes to hold locks of different files at the same
+// path.
+func (l *Lock) Release() error {
+	err := unlockFile(l.file)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,20 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package lockfile
+
+import "os"
+
+// lockFile does nothing, since files can't be locked on this platform.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile does nothing, since files can't be locked on this platform.
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package lockfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestAcquire ensures that a lock can only be held once at a time, and can be
+// acquired again once released.
+func TestAcquire(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lockfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.lock")
+
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatalf("unable to acquire lock: %v", err)
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := strconv.Itoa(os.Getpid())
+	if strings.TrimSpace(string(contents)) != pid {
+		t.Errorf("lock file contains %q, want process ID %s", contents,
+			pid)
+	}
+
+	if _, err := Acquire(path); err != ErrLocked {
+		t.Fatalf("acquired held lock: got %v, want %v", err, ErrLocked)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("unable to release lock: %v", err)
+	}
+	lock, err = Acquire(path)
+	if err != nil {
+		t.Fatalf("unable to acquire released lock: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("unable to release lock: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package pricefeed provides price sources of the wallet fetching the price of
+// bitcoin over HTTP.
+//
+// Requests are made with the HTTP client passed by the caller, so that they
+// are routed through the proxies configured for the application rather than
+// connecting directly.
+package pricefeed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+const (
+	// Timeout is the suggested time a single request of an HTTP price
+	// source may take.
+	Timeout = 10 * time.Second
+
+	// maxResponse is the largest response of an HTTP price source which
+	// is read.
+	maxResponse = 1 << 20
+)
+
+// HTTPSource is a wallet.PriceSource fetching prices from an HTTP ticker which
+// responds with a JSON object.
+type HTTPSource struct {
+	url      string
+	field    []string
+	currency string
+	client   *http.Client
+}
+
+// Enforce HTTPSource implements the wallet.PriceSource interface.
+var _ wallet.PriceSource = (*HTTPSource)(nil)
+
+// NewHTTPSource returns a wallet.PriceSource fetching the price of bitcoin in
+// a currency from an HTTP ticker with the client.  The price is read from the
+// field of the JSON object returned by the ticker, which is a dot separated
+// path of object keys, such as "data.amount", and may be a number or a string
+// of a number.
+func NewHTTPSource(client *http.Client, url, field,
+	currency string) *HTTPSource {
+
+	return &HTTPSource{
+		url:      url,
+		field:    strings.Split(field, "."),
+		currency: currency,
+		client:   client,
+	}
+}
+
+// FetchRate requests the price from the ticker.  The time of the rate is the
+// time it was fetched.
+//
+// This function is part of the wallet.PriceSource interface implementation.
+func (s *HTTPSource) FetchRate() (*wallet.FiatRate, error) {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("price ticker responded with status %s",
+			resp.Status)
+	}
+
+	var doc interface{}
+	body := io.LimitReader(resp.Body, maxResponse)
+	if err := json.NewDecoder(body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid price ticker response: %v", err)
+	}
+	rate, err := s.readField(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &wallet.FiatRate{
+		Currency: s.currency,
+		Rate:     rate,
+		Time:     time.Now(),
+	}, nil
+}
+
+// readField returns the positive price at the field of a ticker response.
+func (s *HTTPSource) readField(doc interface{}) (float64, error) {
+	for _, key := range s.field {
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("price ticker response has no "+
+				"field %q", strings.Join(s.field, "."))
+		}
+		doc = obj[key]
+	}
+
+	var rate float64
+	switch v := doc.(type) {
+	case float64:
+		rate = v
+	case string:
+		var err error
+		rate, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid price %q", v)
+		}
+	default:
+		return 0, fmt.Errorf("price ticker response has no numeric "+
+			"field %q", strings.Join(s.field, "."))
+	}
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return 0, fmt.Errorf("invalid price %v", rate)
+	}
+	return rate, nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pricefeed
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestHTTPSource ensures that prices are read from the configured field of
+// the responses of HTTP tickers.
+func TestHTTPSource(t *testing.T) {
+	t.Parallel()
+
+	var response string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter,
+		r *http.Request) {
+
+		if response == "" {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(rw, response)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		field    string
+		response string
+		rate     float64
+	}{
+		{"number", "price", `{"price": 45000.5}`, 45000.5},
+		{"nested string", "data.amount",
+			`{"data": {"base": "BTC", "amount": "45000.50"}}`, 45000.5},
+		{"missing field", "price", `{"last": 45000}`, 0},
+		{"not an object", "data.amount", `{"data": 45000}`, 0},
+		{"not a number", "price", `{"price": "n/a"}`, 0},
+		{"negative", "price", `{"price": -1}`, 0},
+		{"invalid JSON", "price", `price`, 0},
+		{"error status", "price", ``, 0},
+	}
+	client := &http.Client{Timeout: Timeout}
+	for _, test := range tests {
+		response = test.response
+		src := NewHTTPSource(client, srv.URL, test.field, "USD")
+		before := time.Now()
+		rate, err := src.FetchRate()
+		if test.rate == 0 {
+			require.Error(t, err, test.name)
+			continue
+		}
+		require.NoError(t, err, test.name)
+		require.Equal(t, "USD", rate.Currency, test.name)
+		require.Equal(t, test.rate, rate.Rate, test.name)
+		require.False(t, rate.Time.Before(before), test.name)
+	}
+}
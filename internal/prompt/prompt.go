@@ -17,6 +17,7 @@ import (
 
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/internal/legacy/keystore"
+	"github.com/btcsuite/btcwallet/internal/zero"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
@@ -144,8 +145,10 @@ func promptPass(_ *bufio.Reader, prefix string, confirm bool) ([]byte, error) {
 			return nil, err
 		}
 		fmt.Print("\n")
-		confirm = bytes.TrimSpace(confirm)
-		if !bytes.Equal(pass, confirm) {
+		match := bytes.Equal(pass, bytes.TrimSpace(confirm))
+		zero.Bytes(confirm)
+		if !match {
+			zero.Bytes(pass)
 			fmt.Println("The entered passphrases do not match")
 			continue
 		}
@@ -183,6 +186,7 @@ func PrivatePass(reader *bufio.Reader, legacyKeyStore *keystore.Store) ([]byte,
 
 		// Keep prompting the user until the passphrase is correct.
 		if err := legacyKeyStore.Unlock(privPass); err != nil {
+			zero.Bytes(privPass)
 			if err == keystore.ErrWrongPassphrase {
 				fmt.Println(err)
 				continue
@@ -29,6 +29,15 @@ var helpDescsEnUS = map[string]string{
 	"dumpprivkey-address":   "The address to return a private key for",
 	"dumpprivkey--result0":  "The WIF-encoded private key",
 
+	// DumpWalletCmd help.
+	"dumpwallet--synopsis": "Writes the private key of every wallet address to a new file, in the format of the reference implementation's dumpwallet.\n" +
+		"Keys are written to the file as they are read rather than returned, so wallets with any number of keys can be dumped.\n" +
+		"The file is created by the wallet process with permissions allowing only its owner to read it, must not already exist, and the wallet must be unlocked at the full level.",
+	"dumpwallet-filename": "The absolute path of the file to create",
+
+	// DumpWalletResult help.
+	"dumpwalletresult-filename": "The path of the written file",
+
 	// GetAccountCmd help.
 	"getaccount--synopsis": "DEPRECATED -- Lookup the account name that some wallet address belongs to.",
 	"getaccount-address":   "The address to query the account for",
@@ -41,9 +50,31 @@ var helpDescsEnUS = map[string]string{
 	"getaccountaddress--result0": "The unused address for 'account'",
 
 	// GetAddressesByAccountCmd help.
-	"getaddressesbyaccount--synopsis": "DEPRECATED -- Returns all addresses strings controlled by a single account.",
-	"getaddressesbyaccount-account":   "Account name to fetch addresses for",
-	"getaddressesbyaccount--result0":  "All addresses controlled by 'account'",
+	"getaddressesbyaccount--synopsis": "DEPRECATED -- Returns the payment address strings of a single account.\n" +
+		"Change addresses of the account's internal branch are excluded.",
+	"getaddressesbyaccount-account":  "Account name to fetch addresses for",
+	"getaddressesbyaccount--result0": "All payment addresses of 'account'",
+
+	// GetAddressInfoCmd help.
+	"getaddressinfo--synopsis": "Returns information about an address and, for addresses of the wallet, the key behind it.\n" +
+		"The HD key path and master key fingerprint are reported for keys derived from the wallet seed, so that they may be reproduced from it.",
+	"getaddressinfo-address": "The address to describe",
+
+	// GetAddressInfoResult help.
+	"getaddressinforesult-address":             "The address",
+	"getaddressinforesult-scriptPubKey":        "The hex encoded output script of the address",
+	"getaddressinforesult-ismine":              "Whether the address belongs to the wallet",
+	"getaddressinforesult-iswatchonly":         "Whether the wallet holds no private key for the address",
+	"getaddressinforesult-isscript":            "Whether the address pays to a script hash",
+	"getaddressinforesult-iswitness":           "Whether the address is a segregated witness address",
+	"getaddressinforesult-ischange":            "Whether the address is of an internal (change) branch",
+	"getaddressinforesult-pubkey":              "The hex encoded public key of the address, if it is of a single key",
+	"getaddressinforesult-iscompressed":        "Whether the public key is compressed, if it is of a single key",
+	"getaddressinforesult-account":             "The account of the address",
+	"getaddressinforesult-label":               "The label of the address",
+	"getaddressinforesult-timestamp":           "The unix time of the birthday block of the address, if recorded",
+	"getaddressinforesult-hdkeypath":           "The BIP0032 derivation path of the key from the master key, if derived from the wallet seed",
+	"getaddressinforesult-hdmasterfingerprint": "The hex encoded fingerprint of the master key the key was derived from, if known",
 
 	// GetBalanceCmd help.
 	"getbalance--synopsis":   "Calculates and returns the balance of one or all accounts.",
@@ -54,6 +85,33 @@ var helpDescsEnUS = map[string]string{
 	"getbalance--result0":    "The balance of 'account' valued in bitcoin",
 	"getbalance--result1":    "The balance of all accounts valued in bitcoin",
 
+	// GetBalancesCmd help.
+	"getbalances--synopsis": "Returns the balances of the wallet and of each account, valued in bitcoin.\n" +
+		"Balances are split into the trusted balance of confirmed outputs and of unconfirmed outputs of transactions which only spend wallet outputs, the untrusted balance of other unconfirmed outputs, and the balance of immature coinbase outputs.  Locked outputs are excluded.\n" +
+		"When the wallet has a price feed, the total balances are also valued in fiat with the last fetched exchange rate, whose time should be checked as the rate is not refreshed while the price source fails.",
+
+	// GetBalancesResult help.
+	"getbalancesresult-mine":                 "The balances of the wallet",
+	"getbalancesresult-accounts":             "The balances of each account",
+	"getbalancesresult-accounts--desc":       "JSON object with account names as keys and their balances as values",
+	"getbalancesresult-accounts--key":        "The account name",
+	"getbalancesresult-accounts--value":      "The balances of the account",
+	"getbalancesresult-fiat":                 "The fiat values of the balances, only set when the wallet has a price feed",
+	"balancedetailsresult-trusted":           "The balance of confirmed outputs and of unconfirmed outputs of transactions which only spend wallet outputs",
+	"balancedetailsresult-untrusted_pending": "The balance of unconfirmed outputs of transactions paid by other wallets",
+	"balancedetailsresult-immature":          "The balance of coinbase outputs which have not yet reached maturity",
+	"balancedetailsresult-used":              "Unset",
+
+	// FiatBalancesResult help.
+	"fiatbalancesresult-currency":        "The fiat currency of the exchange rate",
+	"fiatbalancesresult-rate":            "The price of one bitcoin in the fiat currency",
+	"fiatbalancesresult-ratetime":        "The time the exchange rate was fetched as a unix timestamp",
+	"fiatbalancesresult-mine":            "The value of the total balance of the wallet, including unconfirmed and immature outputs",
+	"fiatbalancesresult-accounts":        "The values of the total balances of each account",
+	"fiatbalancesresult-accounts--desc":  "JSON object with account names as keys and the values of their total balances as values",
+	"fiatbalancesresult-accounts--key":   "The account name",
+	"fiatbalancesresult-accounts--value": "The value of the total balance of the account",
+
 	// GetBestBlockHashCmd help.
 	"getbestblockhash--synopsis": "Returns the hash of the newest block in the best chain that wallet has finished syncing with.",
 	"getbestblockhash--result0":  "The hash of the most recent synced-to block",
@@ -94,7 +152,7 @@ var helpDescsEnUS = map[string]string{
 	"getrawchangeaddress--result0":  "The internal payment address",
 
 	// GetReceivedByAccountCmd help.
-	"getreceivedbyaccount--synopsis": "DEPRECATED -- Returns the total amount received by addresses of some account, including spent outputs.",
+	"getreceivedbyaccount--synopsis": "DEPRECATED -- Returns the total amount received by addresses of some account, including spent outputs and excluding change.",
 	"getreceivedbyaccount-account":   "Account name to query total received amount for",
 	"getreceivedbyaccount-minconf":   "Minimum number of block confirmations required before an output's value is included in the total",
 	"getreceivedbyaccount--result0":  "The total received amount valued in bitcoin",
@@ -110,6 +168,19 @@ var helpDescsEnUS = map[string]string{
 	"gettransaction-txid":             "Hash of the transaction to query",
 	"gettransaction-includewatchonly": "Also consider transactions involving watched addresses",
 
+	// GetWalletInfoCmd help.
+	"getwalletinfo--synopsis": "Returns the wallet's balances and lock state, and whether the chain followed by the chain server appears to be stalled or on a minority fork.",
+
+	// GetWalletInfoResult help.
+	"getwalletinforesult-balance":             "The balance of all accounts with at least one confirmation, excluding immature coinbase outputs, valued in bitcoin",
+	"getwalletinforesult-unconfirmed_balance": "The balance of all unconfirmed outputs, valued in bitcoin",
+	"getwalletinforesult-immature_balance":    "The balance of all coinbase outputs which have not yet reached maturity and can not be spent, valued in bitcoin",
+	"getwalletinforesult-unlocked":            "Whether the wallet is unlocked",
+	"getwalletinforesult-chain_stalled":       "Whether no new block has been seen for longer than the stall timeout",
+	"getwalletinforesult-minority_fork":       "Whether most peers of the chain server report a best block well ahead of the wallet's",
+	"getwalletinforesult-last_block_seen":     "The Unix time the last block was connected",
+	"getwalletinforesult-sends_risky":         "Whether transactions sent now risk being invalidated or never confirming, as the chain appears stalled or on a minority fork. Changes are notified to websocket clients by 'btcwallet:chainhealth'",
+
 	// HelpCmd help.
 	"help--synopsis":   "Returns a list of all commands or help for a specified command.",
 	"help-command":     "The command to retrieve help for",
@@ -129,6 +200,8 @@ var helpDescsEnUS = map[string]string{
 	"gettransactionresult-walletconflicts": "Unset",
 	"gettransactionresult-time":            "The earliest Unix time this transaction was known to exist",
 	"gettransactionresult-timereceived":    "The earliest Unix time this transaction was known to exist",
+	"gettransactionresult-comment":         "The comment of a send describing its purpose, if any",
+	"gettransactionresult-to":              "The comment of a send naming the person or organization paid, if any",
 	"gettransactionresult-details":         "Additional details for each recorded wallet credit and debit",
 	"gettransactionresult-hex":             "The transaction encoded as a hexadecimal string",
 
@@ -141,23 +214,82 @@ var helpDescsEnUS = map[string]string{
 	"gettransactiondetailsresult-vout":              "The transaction output index",
 	"gettransactiondetailsresult-involveswatchonly": "Unset",
 
+	// ImportDescriptorsCmd help.
+	"importdescriptors--synopsis": "Imports the scripts of output descriptors.\n" +
+		"Ranged descriptors are imported at each index of their range, which is [0,999] unless specified. " +
+		"Keys of single key descriptors are imported to the 'imported' account of the key scope of the descriptor's address type, and are spendable when private. " +
+		"The scripts of multisig descriptors are imported to be watched. " +
+		"The chain is rescanned from the block of the timestamp of each request unless it is \"now\".",
+	"importdescriptors-requests": "The descriptors to import",
+
+	// ImportDescriptorsRequest help.
+	"importdescriptorsrequest-desc":      "The output descriptor, which must include its checksum",
+	"importdescriptorsrequest-range":     "The end of the range of a ranged descriptor, or the beginning and end of the range as an array",
+	"importdescriptorsrequest-timestamp": `The Unix time of the earliest transaction of the scripts, 0 to rescan from the genesis block, or "now" to not rescan`,
+	"importdescriptorsrequest-label":     "The label of the address of a descriptor which is not ranged",
+
+	// ImportDescriptorsResult help.
+	"importdescriptorsresult-success":  "Whether the descriptor was imported",
+	"importdescriptorsresult-warnings": "Warnings about the import",
+	"importdescriptorsresult-error":    "The error importing the descriptor, if it was not imported",
+
+	// RPCError help.
+	"rpcerror-code":    "The JSON-RPC error code",
+	"rpcerror-message": "The error message",
+
 	// ImportPrivKeyCmd help.
-	"importprivkey--synopsis": "Imports a WIF-encoded private key to the 'imported' account.",
-	"importprivkey-privkey":   "The WIF-encoded private key",
-	"importprivkey-label":     "Unused (must be unset or 'imported')",
-	"importprivkey-rescan":    "Rescan the blockchain (since the genesis block) for outputs controlled by the imported key",
+	"importprivkey--synopsis": "Imports a WIF-encoded private key to the 'imported' account.\n" +
+		"btcwallet extension: A BIP0038 encrypted private key, such as that of a paper wallet, is imported when its passphrase is passed as a fourth parameter.\n" +
+		"btcwallet extension: The birthday of the key may be passed as a fifth parameter, following a passphrase or null, as either a block height or, when not less than 500000000, a Unix timestamp. " +
+		"The rescan starts at the birthday block instead of the genesis block, and the birthday block is recorded for the imported address.",
+	"importprivkey-privkey": "The WIF-encoded private key",
+	"importprivkey-label":   "Unused (must be unset or 'imported')",
+	"importprivkey-rescan":  "Rescan the blockchain (since the genesis block) for outputs controlled by the imported key",
+
+	// ImportPubKeyCmd help.
+	"importpubkey--synopsis": "Imports a public key to be watched as its pay-to-pubkey-hash, nested witness and witness addresses in the 'imported' account.\n" +
+		"Only compressed public keys can be watched.",
+	"importpubkey-pubkey": "The hex encoded public key",
+	"importpubkey-rescan": "Rescan the blockchain (since the genesis block) for outputs paying to the key, which are otherwise only watched from the block the wallet is synced to",
 
 	// KeypoolRefillCmd help.
 	"keypoolrefill--synopsis": "DEPRECATED -- This request does nothing since no keypool is maintained.",
 	"keypoolrefill-newsize":   "Unused",
 
 	// ListAccountsCmd help.
-	"listaccounts--synopsis":       "DEPRECATED -- Returns a JSON object of all accounts and their balances.",
+	"listaccounts--synopsis": "DEPRECATED -- Returns a JSON object of all accounts and their balances.\n" +
+		"btcwallet extension: a boolean verbose flag may be passed after minconf to instead return a JSON array of objects which include the metadata of each account.",
 	"listaccounts-minconf":         "Minimum number of block confirmations required before an unspent output's value is included in the balance",
+	"listaccounts--condition0":     "verbose=false",
+	"listaccounts--condition1":     "verbose=true",
 	"listaccounts--result0--desc":  "JSON object with account names as keys and bitcoin amounts as values",
 	"listaccounts--result0--key":   "The account name",
 	"listaccounts--result0--value": "The account balance valued in bitcoin",
 
+	// ListAccountsVerboseResult help.
+	"listaccountsverboseresult-account":     "The account name",
+	"listaccountsverboseresult-balance":     "The account balance valued in bitcoin",
+	"listaccountsverboseresult-description": "The description of the account",
+	"listaccountsverboseresult-created":     "The Unix time the account was created, omitted if unknown",
+	"listaccountsverboseresult-tags":        "Tags describing the purpose of the account",
+	"listaccountsverboseresult-avoid_reuse": "Whether the account avoids combining outputs to dirty and clean addresses",
+
+	// ListDescriptorsCmd help.
+	"listdescriptors--synopsis": "Returns output descriptors of the scripts held by the wallet.\n" +
+		"Each account with an extended public key is described by ranged descriptors of its external and internal branches, and each imported key and multisig script by a descriptor of its own. " +
+		"Imported scripts are only described when the wallet is unlocked or watching-only.",
+
+	// ListDescriptorsResult help.
+	"listdescriptorsresult-descriptors": "The descriptors of the wallet",
+
+	// ListDescriptorsDescriptor help.
+	"listdescriptorsdescriptor-desc":      "The output descriptor, including its checksum",
+	"listdescriptorsdescriptor-timestamp": "The Unix time of the earliest transaction the scripts may have, or 0 if unknown",
+	"listdescriptorsdescriptor-active":    "Whether the descriptor is of an account branch from which new addresses are derived",
+	"listdescriptorsdescriptor-internal":  "Whether the descriptor is of an internal (change) branch, for active descriptors",
+	"listdescriptorsdescriptor-range":     "The range of indexes the scripts of an active descriptor are watched at",
+	"listdescriptorsdescriptor-next":      "The index the next address of an active descriptor is derived at",
+
 	// ListLockUnspentCmd help.
 	"listlockunspent--synopsis": "Returns a JSON array of outpoints marked as locked (with lockunspent) for this wallet session.",
 
@@ -166,7 +298,8 @@ var helpDescsEnUS = map[string]string{
 	"transactioninput-vout": "The output index of the referenced output",
 
 	// ListReceivedByAccountCmd help.
-	"listreceivedbyaccount--synopsis":        "DEPRECATED -- Returns a JSON array of objects listing all accounts and the total amount received by each account.",
+	"listreceivedbyaccount--synopsis": "DEPRECATED -- Returns a JSON array of objects listing all accounts and the total amount received by each account, excluding change.\n" +
+		"An options object may be passed as an additional final parameter.  The 'starttime' and 'endtime' options, in seconds since the Unix epoch, restrict the results to transactions received by the wallet at or after starttime and before endtime.  The 'cursor' option continues the listing after the result identified by its account name, which is the last result of the previous page, and the 'skip' and 'count' options skip and limit the results which follow it.",
 	"listreceivedbyaccount-minconf":          "Minimum number of block confirmations required before a transaction is considered",
 	"listreceivedbyaccount-includeempty":     "Unused",
 	"listreceivedbyaccount-includewatchonly": "Unused",
@@ -177,7 +310,9 @@ var helpDescsEnUS = map[string]string{
 	"listreceivedbyaccountresult-confirmations": "Number of block confirmations of the most recent transaction relevant to the account",
 
 	// ListReceivedByAddressCmd help.
-	"listreceivedbyaddress--synopsis":        "Returns a JSON array of objects listing wallet payment addresses and their total received amounts.",
+	"listreceivedbyaddress--synopsis": "Returns a JSON array of objects listing wallet payment addresses and their total received amounts.\n" +
+		"Change addresses and the change they received are excluded.\n" +
+		"An options object may be passed as an additional final parameter.  The 'starttime' and 'endtime' options, in seconds since the Unix epoch, restrict the results to transactions received by the wallet at or after starttime and before endtime.  The 'cursor' option continues the listing after the result identified by its address, which is the last result of the previous page, and the 'skip' and 'count' options skip and limit the results which follow it.",
 	"listreceivedbyaddress-minconf":          "Minimum number of block confirmations required before a transaction is considered",
 	"listreceivedbyaddress-includeempty":     "Unused",
 	"listreceivedbyaddress-includewatchonly": "Unused",
@@ -223,21 +358,26 @@ var helpDescsEnUS = map[string]string{
 	"listtransactionsresult-time":               "The earliest Unix time this transaction was known to exist",
 	"listtransactionsresult-timereceived":       "The earliest Unix time this transaction was known to exist",
 	"listtransactionsresult-involveswatchonly":  "Unset",
-	"listtransactionsresult-comment":            "Unset",
+	"listtransactionsresult-comment":            "The comment of a send describing its purpose, if any",
+	"listtransactionsresult-to":                 "The comment of a send naming the person or organization paid, if any",
 	"listtransactionsresult-otheraccount":       "Unset",
 	"listtransactionsresult-trusted":            "Unset",
 	"listtransactionsresult-bip125-replaceable": "Unset",
 	"listtransactionsresult-abandoned":          "Unset",
 
 	// ListTransactionsCmd help.
-	"listtransactions--synopsis":        "Returns a JSON array of objects containing verbose details for wallet transactions.",
+	"listtransactions--synopsis": "Returns a JSON array of objects containing verbose details for wallet transactions.\n" +
+		"An options object may be passed as an additional final parameter.  The 'starttime' and 'endtime' options, in seconds since the Unix epoch, restrict the results to transactions received by the wallet at or after starttime and before endtime.  The 'cursor' option continues the listing after the transaction with the given hash, which is the last transaction of the previous page, so that new transactions do not shift the pages.  The count and from parameters page the transactions which follow it.",
 	"listtransactions-account":          "DEPRECATED -- Unused (must be unset or \"*\")",
 	"listtransactions-count":            "Maximum number of transactions to create results from",
 	"listtransactions-from":             "Number of transactions to skip before results are created",
 	"listtransactions-includewatchonly": "Unused",
 
 	// ListUnspentCmd help.
-	"listunspent--synopsis": "Returns a JSON array of objects representing unlocked unspent outputs controlled by wallet keys.",
+	"listunspent--synopsis": "Returns a JSON array of objects representing unlocked unspent outputs controlled by wallet keys.\n" +
+		"An options object may be passed as an additional final parameter.  The 'starttime' and 'endtime' options, in seconds since the Unix epoch, restrict the results to transactions received by the wallet at or after starttime and before endtime.  The 'cursor' option continues the listing after the result identified by its \"txid:vout\" outpoint, which is the last result of the previous page, and the 'skip' and 'count' options skip and limit the results which follow it.\n" +
+		"As by the reference implementation, the 'include_unsafe' flag may be passed as the fourth parameter, and setting it to false excludes unsafe outputs.  The options object then follows it as the fifth parameter.\n" +
+		"The query options of the reference implementation are also accepted in the options object: 'minimumAmount' and 'maximumAmount' restrict the results to outputs of at least and at most the amounts valued in bitcoin, 'maximumCount' is an alias of 'count', and 'include_unsafe' may be set in place of the fourth parameter.",
 	"listunspent-minconf":   "Minimum number of block confirmations required before a transaction output is considered",
 	"listunspent-maxconf":   "Maximum number of block confirmations required before a transaction output is excluded",
 	"listunspent-addresses": "If set, limits the returned details to unspent outputs received by any of these payment addresses",
@@ -252,6 +392,8 @@ var helpDescsEnUS = map[string]string{
 	"listunspentresult-amount":        "The amount of the output valued in bitcoin",
 	"listunspentresult-confirmations": "The number of block confirmations of the transaction",
 	"listunspentresult-spendable":     "Whether the output is entirely controlled by wallet keys/scripts (false for partially controlled multisig outputs or outputs to watch-only addresses)",
+	"listunspentresult-safe":          "Whether the output is safe to spend: mined, or unmined in a transaction which only spends wallet outputs, such as change",
+	"listunspentresult-reused":        "Whether the output pays to a dirty address, one which has previously been spent from",
 
 	// LockUnspentCmd help.
 	"lockunspent--synopsis": "Locks or unlocks an unspent output.\n" +
@@ -264,36 +406,39 @@ var helpDescsEnUS = map[string]string{
 
 	// SendFromCmd help.
 	"sendfrom--synopsis": "DEPRECATED -- Authors, signs, and sends a transaction that outputs some amount to a payment address.\n" +
-		"A change output is automatically included to send extra output value back to the original account.",
+		"A change output is automatically included to send extra output value back to the original account.\n" +
+		"An options object may be passed as an additional final parameter.  The 'unit' option ('BTC', 'mBTC', 'uBTC' or 'satoshi') sets the denomination of all amounts of the request, otherwise the server's default unit (bitcoin unless configured otherwise) is used.  The 'allowreuse' option permits combining outputs to dirty and clean addresses when spending from an account with the avoid_reuse flag set.  The 'changeaddress' option pays change to the given address, which may belong to another wallet, and the 'nochange' option omits the change output, adding any remaining value to the fee.  The 'subtractfeefromamount' option deducts the fee from the amounts paid to all recipients, and the 'subtractfeefrom' option, an array of recipient addresses, deducts it from the amounts paid to those addresses only, splitting the fee equally.  The 'feerate' option sets the fee per kilobyte of the transaction in the unit of the request, overriding the default fee rate for this transaction only, and must be at least the minimum relay fee.",
 	"sendfrom-fromaccount": "Account to pick unspent outputs from",
 	"sendfrom-toaddress":   "Address to pay",
-	"sendfrom-amount":      "Amount to send to the payment address valued in bitcoin",
+	"sendfrom-amount":      "Amount to send to the payment address",
 	"sendfrom-minconf":     "Minimum number of block confirmations required before a transaction output is eligible to be spent",
-	"sendfrom-comment":     "Unused",
-	"sendfrom-commentto":   "Unused",
-	"sendfrom--result0":    "The transaction hash of the sent transaction",
+	"sendfrom-comment":     "A comment describing the purpose of the transaction, returned by gettransaction and listtransactions",
+	"sendfrom-commentto":   "A comment naming the person or organization paid, returned by gettransaction",
+	"sendfrom--result0":    "The transaction hash of the sent transaction, or the pending spend token to pass to confirmspend when spends require a TOTP confirmation",
 
 	// SendManyCmd help.
 	"sendmany--synopsis": "Authors, signs, and sends a transaction that outputs to many payment addresses.\n" +
-		"A change output is automatically included to send extra output value back to the original account.",
+		"A change output is automatically included to send extra output value back to the original account.\n" +
+		"An options object may be passed as an additional final parameter.  The 'unit' option ('BTC', 'mBTC', 'uBTC' or 'satoshi') sets the denomination of all amounts of the request, otherwise the server's default unit (bitcoin unless configured otherwise) is used.  The 'allowreuse' option permits combining outputs to dirty and clean addresses when spending from an account with the avoid_reuse flag set.  The 'changeaddress' option pays change to the given address, which may belong to another wallet, and the 'nochange' option omits the change output, adding any remaining value to the fee.  The 'subtractfeefromamount' option deducts the fee from the amounts paid to all recipients, and the 'subtractfeefrom' option, an array of recipient addresses, deducts it from the amounts paid to those addresses only, splitting the fee equally.  The 'feerate' option sets the fee per kilobyte of the transaction in the unit of the request, overriding the default fee rate for this transaction only, and must be at least the minimum relay fee.",
 	"sendmany-fromaccount":    "DEPRECATED -- Account to pick unspent outputs from",
 	"sendmany-amounts":        "Pairs of payment addresses and the output amount to pay each",
-	"sendmany-amounts--desc":  "JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address",
+	"sendmany-amounts--desc":  "JSON object using payment addresses as keys and output amounts to send to each address",
 	"sendmany-amounts--key":   "Address to pay",
-	"sendmany-amounts--value": "Amount to send to the payment address valued in bitcoin",
+	"sendmany-amounts--value": "Amount to send to the payment address",
 	"sendmany-minconf":        "Minimum number of block confirmations required before a transaction output is eligible to be spent",
-	"sendmany-comment":        "Unused",
-	"sendmany--result0":       "The transaction hash of the sent transaction",
+	"sendmany-comment":        "A comment describing the purpose of the transaction, returned by gettransaction and listtransactions",
+	"sendmany--result0":       "The transaction hash of the sent transaction, or the pending spend token to pass to confirmspend when spends require a TOTP confirmation",
 
 	// SendToAddressCmd help.
 	"sendtoaddress--synopsis": "Authors, signs, and sends a transaction that outputs some amount to a payment address.\n" +
 		"Unlike sendfrom, outputs are always chosen from the default account.\n" +
-		"A change output is automatically included to send extra output value back to the original account.",
+		"A change output is automatically included to send extra output value back to the original account.\n" +
+		"An options object may be passed as an additional final parameter.  The 'unit' option ('BTC', 'mBTC', 'uBTC' or 'satoshi') sets the denomination of all amounts of the request, otherwise the server's default unit (bitcoin unless configured otherwise) is used.  The 'allowreuse' option permits combining outputs to dirty and clean addresses when spending from an account with the avoid_reuse flag set.  The 'changeaddress' option pays change to the given address, which may belong to another wallet, and the 'nochange' option omits the change output, adding any remaining value to the fee.  The 'subtractfeefromamount' option deducts the fee from the amounts paid to all recipients, and the 'subtractfeefrom' option, an array of recipient addresses, deducts it from the amounts paid to those addresses only, splitting the fee equally.  The 'feerate' option sets the fee per kilobyte of the transaction in the unit of the request, overriding the default fee rate for this transaction only, and must be at least the minimum relay fee.",
 	"sendtoaddress-address":   "Address to pay",
-	"sendtoaddress-amount":    "Amount to send to the payment address valued in bitcoin",
-	"sendtoaddress-comment":   "Unused",
-	"sendtoaddress-commentto": "Unused",
-	"sendtoaddress--result0":  "The transaction hash of the sent transaction",
+	"sendtoaddress-amount":    "Amount to send to the payment address",
+	"sendtoaddress-comment":   "A comment describing the purpose of the transaction, returned by gettransaction and listtransactions",
+	"sendtoaddress-commentto": "A comment naming the person or organization paid, returned by gettransaction",
+	"sendtoaddress--result0":  "The transaction hash of the sent transaction, or the pending spend token to pass to confirmspend when spends require a TOTP confirmation",
 
 	// SetTxFeeCmd help.
 	"settxfee--synopsis": "Modify the increment used each time more fee is required for an authored transaction.",
@@ -326,6 +471,20 @@ var helpDescsEnUS = map[string]string{
 	"signrawtransactionerror-txid":      "The transaction hash of the referenced previous output",
 	"signrawtransactionerror-vout":      "The output index of the referenced previous output",
 
+	// SignRawTransactionWithWalletCmd help.
+	"signrawtransactionwithwallet--synopsis": "Signs the inputs of a transaction, including witness inputs, using private keys from this wallet.\n" +
+		"The outputs spent by inputs which are not transactions of the wallet must be described by the inputs parameter, including the amount of witness inputs, so that transactions built by external tools may be signed.\n" +
+		"Signatures of cosigners of multisig witness scripts are kept.\n" +
+		"The valid sighashtype options are ALL, NONE, SINGLE, ALL|ANYONECANPAY, NONE|ANYONECANPAY, and SINGLE|ANYONECANPAY.",
+	"signrawtransactionwithwallet-rawtx":       "Unsigned or partially unsigned transaction to sign encoded as a hexadecimal string",
+	"signrawtransactionwithwallet-inputs":      "The outputs spent by the transaction which the wallet may not be tracking, with their scriptPubKey, redeemScript, witnessScript and amount",
+	"signrawtransactionwithwallet-sighashtype": "The signature hash type",
+
+	// SignRawTransactionWithWalletResult help.
+	"signrawtransactionwithwalletresult-hex":      "The resulting transaction encoded as a hexadecimal string",
+	"signrawtransactionwithwalletresult-complete": "Whether all input signatures have been created",
+	"signrawtransactionwithwalletresult-errors":   "Script verification errors (if exists)",
+
 	// ValidateAddressCmd help.
 	"validateaddress--synopsis": "Verify that an address is valid.\n" +
 		"Extra details are returned if the address is controlled by this wallet.\n" +
@@ -357,23 +516,289 @@ var helpDescsEnUS = map[string]string{
 	"verifymessage--result0":  "Whether the message was signed with the private key of 'address'",
 
 	// WalletLockCmd help.
-	"walletlock--synopsis": "Lock the wallet.",
+	"walletlock--synopsis": "Lock the wallet.\n" +
+		"btcwallet extension: an account name may be passed to instead lock an account protected by its own passphrase, or '*' to lock the wallet and every such account.",
 
 	// WalletPassphraseCmd help.
-	"walletpassphrase--synopsis":  "Unlock the wallet.",
+	"walletpassphrase--synopsis": "Unlock the wallet.\n" +
+		"btcwallet extension: an account name may be passed after timeout to instead unlock an account protected by its own passphrase, or '*' to unlock the wallet and every such account which accepts the passphrase.\n" +
+		"btcwallet extension: an unlock level may be passed after a null account to restrict the private keys of the wallet: 'view' only allows deriving accounts and importing keys, 'spend' also allows signing, and 'full' (the default) also allows exporting private keys.",
 	"walletpassphrase-passphrase": "The wallet passphrase",
-	"walletpassphrase-timeout":    "The number of seconds to wait before the wallet automatically locks",
+	"walletpassphrase-timeout":    "The number of seconds to wait before the wallet automatically locks, or 0 to keep the wallet unlocked until it is locked with walletlock",
 
 	// WalletPassphraseChangeCmd help.
 	"walletpassphrasechange--synopsis":     "Change the wallet passphrase.",
 	"walletpassphrasechange-oldpassphrase": "The old wallet passphrase",
 	"walletpassphrasechange-newpassphrase": "The new wallet passphrase",
 
+	// CancelRescanCmd help.
+	"cancelrescan--synopsis": "Cancels a queued rescan job, removing it from the queue.\n" +
+		"Running jobs can not be cancelled, since the chain server can not interrupt a rescan, and an error is returned for them.",
+	"cancelrescan-id": "The id of the rescan job, as reported by 'listrescans' and 'btcwallet:rescanprogress' notifications",
+
+	// RescanBlockchainCmd help.
+	"rescanblockchain--synopsis": "Scans a range of blocks for transactions paying to or spending from the addresses of an account, recording transactions the wallet missed and deriving the credits of every transaction found again.\n" +
+		"This recovers transactions after a wallet database was restored from a backup, and returns once the range has been scanned.",
+	"rescanblockchain-startheight": "The height of the first block to scan (default=the earliest birthday block of the scanned addresses)",
+	"rescanblockchain-stopheight":  "The height of the last block to scan (default=the wallet's best block)",
+	"rescanblockchain-account":     "btcwallet extension: Only scan for transactions of this account (default=all accounts)",
+
+	// RescanBlockchainResult help.
+	"rescanblockchainresult-start_height": "The height of the first block scanned",
+	"rescanblockchainresult-stop_height":  "The height of the last block scanned",
+
+	// AnalyzePsbtCmd help.
+	"analyzepsbt--synopsis": "Analyzes a PSBT, reporting which role must process each input next and which inputs the wallet can sign.\n" +
+		"The PSBT is analyzed by the wallet alone, using only the outputs it includes, so that PSBTs can be inspected by wallets of offline signing setups.",
+	"analyzepsbt-psbt": "A base64 string of a PSBT",
+
+	// AnalyzePsbtResult help.
+	"analyzepsbtresult-inputs":  "The analysis of each input",
+	"analyzepsbtresult-fee":     "The fee paid by the transaction valued in bitcoin, omitted if the PSBT lacks any spent output",
+	"analyzepsbtresult-next":    "The role of the next step processing the PSBT (updater, signer, finalizer or extractor)",
+	"analyzepsbtinput-has_utxo": "Whether the PSBT includes the output spent by the input",
+	"analyzepsbtinput-is_final": "Whether the input scripts of the input are final",
+	"analyzepsbtinput-next":     "The role of the next step processing the input (updater, signer, finalizer or extractor)",
+	"analyzepsbtinput-ismine":   "Whether the spent output pays to an address of the wallet",
+	"analyzepsbtinput-cansign":  "Whether the wallet holds the key signing for the spent output",
+
+	// AuthorizeKeyUseCmd help.
+	"authorizekeyuse--synopsis": "Authorizes a single use of the private keys of the wallet when spends require a TOTP confirmation.\n" +
+		"While spends require confirmation, 'signrawtransaction', 'signrawtransactionwithwallet', 'signmessage', 'dumpprivkey', 'exportprivkeybip38' and 'dumpwallet' fail unless the use of the keys was authorized with this method within the last minute.  " +
+		"Each authorization is consumed by the next such request, and each code is only accepted once.  Authorization is refused for ten minutes after three invalid codes.",
+	"authorizekeyuse-code": "The current 6 digit code of the authenticator app",
+
+	// CancelDraftTxCmd help.
+	"canceldrafttx--synopsis": "Discards a draft transaction created by 'createtx', unlocking the outputs it spends.",
+	"canceldrafttx-id":        "The id of the draft transaction returned by 'createtx'",
+
+	// CommitTxCmd help.
+	"committx--synopsis": "Signs the draft transaction created by 'createtx' and publishes it.\n" +
+		"The wallet must be unlocked for this request to succeed, and the draft is kept when it can not be signed.\n" +
+		"When spends require a TOTP confirmation, the signed transaction awaits confirmation with 'confirmspend' and the token of the pending spend is returned instead of the transaction hash.",
+	"committx-id":       "The id of the draft transaction returned by 'createtx'",
+	"committx--result0": "The transaction hash of the sent transaction, or the token of the pending spend",
+
+	// CreateTxCmd help.
+	"createtx--synopsis": "Creates a transaction paying the amounts from an account, as 'sendmany' does, and returns it unsigned along with its inputs, outputs and fee for review.\n" +
+		"The transaction is only signed and published once it is committed with 'committx'.  " +
+		"Its inputs are locked until the draft is committed or discarded with 'canceldrafttx', or expires after ten minutes.  " +
+		"As the transaction is not signed, the wallet need not be unlocked.\n" +
+		"An options object may be passed as an additional final parameter, which accepts the options of 'sendmany'.",
+	"createtx-amounts":        "Pairs of payment addresses and the output amount to pay each",
+	"createtx-amounts--desc":  "JSON object using payment addresses as keys and output amounts to send to each address",
+	"createtx-amounts--key":   "Address to pay",
+	"createtx-amounts--value": "Amount to send to the payment address",
+	"createtx-account":        "Account to pick unspent outputs from",
+	"createtx-minconf":        "Minimum number of block confirmations required before a transaction output is eligible to be spent",
+	"createtx-comment":        "A comment describing the purpose of the transaction, returned by gettransaction and listtransactions",
+
+	// CreateTxResult help.
+	"createtxresult-id":      "The id of the draft transaction, passed to 'committx' or 'canceldrafttx'",
+	"createtxresult-hex":     "The hex-encoded unsigned transaction",
+	"createtxresult-inputs":  "The wallet outputs spent by the transaction",
+	"createtxresult-outputs": "The outputs of the transaction",
+	"createtxresult-fee":     "The fee paid by the transaction valued in bitcoin",
+	"createtxresult-expires": "The Unix time the draft expires unless it is committed",
+	"createtxinput-txid":     "The hash of the transaction of the spent output",
+	"createtxinput-vout":     "The output index of the spent output",
+	"createtxinput-address":  "The address paid by the spent output, omitted if unknown",
+	"createtxinput-amount":   "The value of the spent output valued in bitcoin",
+	"createtxoutput-address": "The address paid by the output, omitted for nonstandard scripts",
+	"createtxoutput-amount":  "The value of the output valued in bitcoin",
+	"createtxoutput-change":  "Whether the output pays change back to the wallet",
+
+	// CancelSpendCmd help.
+	"cancelspend--synopsis": "Cancels a send awaiting TOTP confirmation, unlocking the outputs it spends.",
+	"cancelspend-token":     "The pending spend token returned by the send",
+
+	// ConfirmSpendCmd help.
+	"confirmspend--synopsis": "Publishes the transaction of a send awaiting confirmation when spends require a TOTP confirmation.\n" +
+		"The code is that of the authenticator app holding the configured TOTP secret, and each code is only accepted once.  " +
+		"Pending spends are cancelled when they are not confirmed within ten minutes, or after three invalid codes.",
+	"confirmspend-token":    "The pending spend token returned by the send",
+	"confirmspend-code":     "The current 6 digit code of the authenticator app",
+	"confirmspend--result0": "The transaction hash of the sent transaction",
+
 	// CreateNewAccountCmd help.
 	"createnewaccount--synopsis": "Creates a new account.\n" +
 		"The wallet must be unlocked for this request to succeed.",
 	"createnewaccount-account": "Name of the new account",
 
+	// GetAccountingReportCmd help.
+	"getaccountingreport--synopsis": "Returns the totals received, sent and paid in fees by each account between two dates, overall and for each daily or monthly period, to reconcile the wallet without exporting its history.\n" +
+		"Totals are computed from the entries written by 'exporthistory': sent totals exclude change, transfers between accounts are both sent and received, and each transaction is dated by its block, or by the time it was received while unmined.\n" +
+		"Only accounts and periods with any transactions are reported.",
+	"getaccountingreport-startdate": "The first day of the report as a UTC date in the format YYYY-MM-DD",
+	"getaccountingreport-enddate":   "The last day of the report as a UTC date in the format YYYY-MM-DD",
+	"getaccountingreport-period":    "The periods to total over, \"daily\" or \"monthly\"",
+	"getaccountingreport-account":   "The account to report, or \"*\" for every account",
+
+	// AccountingReportResult help.
+	"accountingreportresult-startdate": "The first day of the report",
+	"accountingreportresult-enddate":   "The last day of the report",
+	"accountingreportresult-period":    "The periods totals are summed over",
+	"accountingreportresult-accounts":  "The totals of each account, sorted by account name",
+
+	// AccountingReportAccount help.
+	"accountingreportaccount-account":      "The name of the account",
+	"accountingreportaccount-received":     "The total received by the account valued in bitcoin",
+	"accountingreportaccount-sent":         "The total sent from the account, excluding fees, valued in bitcoin",
+	"accountingreportaccount-fees":         "The total fees paid by the account valued in bitcoin",
+	"accountingreportaccount-transactions": "The number of transactions of the account",
+	"accountingreportaccount-periods":      "The totals of each period with transactions, oldest first",
+
+	// AccountingReportPeriod help.
+	"accountingreportperiod-startdate":    "The first day of the period",
+	"accountingreportperiod-received":     "The total received during the period valued in bitcoin",
+	"accountingreportperiod-sent":         "The total sent during the period, excluding fees, valued in bitcoin",
+	"accountingreportperiod-fees":         "The total fees paid during the period valued in bitcoin",
+	"accountingreportperiod-transactions": "The number of transactions during the period",
+
+	// GetAccountMetadataCmd help.
+	"getaccountmetadata--synopsis": "Returns the description, creation time and purpose tags of an account.",
+	"getaccountmetadata-account":   "The account name",
+
+	// GetAccountXpubCmd help.
+	"getaccountxpub--synopsis": "Returns the extended public key of an account, from which every address of the account may be derived without exposing private keys.",
+	"getaccountxpub-account":   "The account name",
+
+	// GetAccountXpubResult help.
+	"getaccountxpubresult-account":           "The account name",
+	"getaccountxpubresult-xpub":              "The extended public key of the account, serialized with the version of the network",
+	"getaccountxpubresult-masterfingerprint": "The hex encoded fingerprint of the master key the account was derived from, if known",
+	"getaccountxpubresult-path":              "The BIP0032 derivation path of the account key from the master key",
+
+	// AccountMetadataResult help.
+	"accountmetadataresult-account":       "The account name",
+	"accountmetadataresult-description":   "The description of the account",
+	"accountmetadataresult-created":       "The Unix time the account was created, omitted if unknown",
+	"accountmetadataresult-tags":          "Tags describing the purpose of the account",
+	"accountmetadataresult-avoid_reuse":   "Whether the account avoids combining outputs to dirty and clean addresses",
+	"accountmetadataresult-deposit_alert": "The amount at or above which deposits to the account are alerted valued in bitcoin, or 0 if deposits are not alerted",
+
+	// DecodePsbtCmd help.
+	"decodepsbt--synopsis": "Returns a JSON object describing a PSBT and the transaction it builds, annotating the inputs the wallet can sign.\n" +
+		"The PSBT is decoded by the wallet alone, so that PSBTs can be inspected by wallets of offline signing setups.",
+	"decodepsbt-psbt": "A base64 string of a PSBT",
+
+	// DecodePsbtResult help.
+	"decodepsbtresult-tx":      "The unsigned transaction of the PSBT",
+	"decodepsbtresult-inputs":  "The input fields of the PSBT",
+	"decodepsbtresult-outputs": "The output fields of the PSBT",
+	"decodepsbtresult-fee":     "The fee paid by the transaction valued in bitcoin, omitted if the PSBT lacks any spent output",
+
+	"decodepsbtinput-non_witness_utxo":          "The transaction of the output spent by a non-witness input",
+	"decodepsbtinput-witness_utxo":              "The output spent by a witness input",
+	"decodepsbtinput-partial_signatures":        "The partial signatures of the input",
+	"decodepsbtinput-partial_signatures--desc":  "JSON object using public keys as keys and their signatures as values",
+	"decodepsbtinput-partial_signatures--key":   "The hex-encoded public key",
+	"decodepsbtinput-partial_signatures--value": "The hex-encoded signature",
+	"decodepsbtinput-sighash":                   "The signature hash type to sign the input with",
+	"decodepsbtinput-redeem_script":             "The redeem script of the input",
+	"decodepsbtinput-witness_script":            "The witness script of the input",
+	"decodepsbtinput-bip32_derivs":              "The BIP 32 derivations of the public keys of the input",
+	"decodepsbtinput-final_scriptSig":           "The final signature script of the input",
+	"decodepsbtinput-final_scriptwitness":       "The hex-encoded items of the final witness of the input",
+	"decodepsbtinput-ismine":                    "Whether the spent output pays to an address of the wallet",
+	"decodepsbtinput-cansign":                   "Whether the wallet holds the key signing for the spent output",
+
+	"decodepsbtoutput-redeem_script":  "The redeem script of the output",
+	"decodepsbtoutput-witness_script": "The witness script of the output",
+	"decodepsbtoutput-bip32_derivs":   "The BIP 32 derivations of the public keys of the output",
+
+	"decodepsbtutxo-amount":       "The value of the output valued in bitcoin",
+	"decodepsbtutxo-scriptPubKey": "The output script",
+
+	"decodepsbtscript-asm":  "Disassembly of the script",
+	"decodepsbtscript-hex":  "The hex-encoded script",
+	"decodepsbtscript-type": "The type of the script (e.g. 'multisig' or 'witness_v0_keyhash')",
+
+	"decodepsbtbip32deriv-pubkey":             "The hex-encoded public key",
+	"decodepsbtbip32deriv-master_fingerprint": "The hex-encoded fingerprint of the master key",
+	"decodepsbtbip32deriv-path":               "The derivation path of the public key",
+
+	// TxRawDecodeResult help.
+	"txrawdecoderesult-txid":     "The hash of the transaction",
+	"txrawdecoderesult-version":  "The transaction version",
+	"txrawdecoderesult-locktime": "The transaction lock time",
+	"txrawdecoderesult-vin":      "The transaction inputs as JSON objects",
+	"txrawdecoderesult-vout":     "The transaction outputs as JSON objects",
+
+	// Vin help.
+	"vin-coinbase":    "The hex-encoded bytes of the signature script (coinbase txns only)",
+	"vin-txid":        "The hash of the origin transaction (non-coinbase txns only)",
+	"vin-vout":        "The index of the output being redeemed from the origin transaction (non-coinbase txns only)",
+	"vin-scriptSig":   "The signature script used to redeem the origin transaction as a JSON object (non-coinbase txns only)",
+	"vin-txinwitness": "The witness used to redeem the input encoded as a string array of its items",
+	"vin-sequence":    "The script sequence number",
+
+	// ScriptSig help.
+	"scriptsig-asm": "Disassembly of the script",
+	"scriptsig-hex": "Hex-encoded bytes of the script",
+
+	// Vout help.
+	"vout-value":        "The amount in BTC",
+	"vout-n":            "The index of this transaction output",
+	"vout-scriptPubKey": "The public key script used to pay coins as a JSON object",
+
+	// ScriptPubKeyResult help.
+	"scriptpubkeyresult-asm":       "Disassembly of the script",
+	"scriptpubkeyresult-hex":       "Hex-encoded bytes of the script",
+	"scriptpubkeyresult-reqSigs":   "The number of required signatures",
+	"scriptpubkeyresult-type":      "The type of the script (e.g. 'pubkeyhash')",
+	"scriptpubkeyresult-addresses": "The bitcoin addresses associated with this script",
+
+	// EstimateSendFeeCmd help.
+	"estimatesendfee--synopsis": "Runs coin selection for a send of the amounts from an account, as 'sendmany' does, and returns the fee, size, inputs and change of the transaction it would create.\n" +
+		"The transaction is neither signed nor published, and the wallet is left unmodified, so the wallet need not be unlocked.\n" +
+		"An options object may be passed as an additional final parameter, which accepts the options of 'sendmany'.",
+	"estimatesendfee-amounts":        "Pairs of payment addresses and the output amount to pay each",
+	"estimatesendfee-amounts--desc":  "JSON object using payment addresses as keys and output amounts to send to each address",
+	"estimatesendfee-amounts--key":   "Address to pay",
+	"estimatesendfee-amounts--value": "Amount to send to the payment address",
+	"estimatesendfee-account":        "Account to pick unspent outputs from",
+	"estimatesendfee-minconf":        "Minimum number of block confirmations required before a transaction output is eligible to be spent",
+
+	// EstimateSendFeeResult help.
+	"estimatesendfeeresult-fee":          "The fee the transaction would pay valued in bitcoin",
+	"estimatesendfeeresult-vsize":        "The estimated virtual size of the signed transaction in vbytes",
+	"estimatesendfeeresult-inputs":       "The number of wallet outputs the transaction would spend",
+	"estimatesendfeeresult-change":       "Whether the transaction would create a change output",
+	"estimatesendfeeresult-changeamount": "The value of the change output valued in bitcoin, or zero without change",
+
+	// ExportAuditSnapshotCmd help.
+	"exportauditsnapshot--synopsis": "Returns a signed JSON document describing every address, unspent output and account balance of the wallet as of a block of the main chain, without any private keys.\n" +
+		"The document may be checked with verifymessage using the returned address, signature and snapshot string, and each unspent output may be verified against the chain using the block hash.",
+	"exportauditsnapshot-address": "The pay-to-pubkey-hash wallet address used to sign the snapshot",
+	"exportauditsnapshot-height":  "The height of the block to snapshot (default=the block the wallet is synced to)",
+
+	// ExportAuditSnapshotResult help.
+	"exportauditsnapshotresult-snapshot":  "The snapshot document encoded as a JSON string",
+	"exportauditsnapshotresult-address":   "The address which signed the snapshot",
+	"exportauditsnapshotresult-signature": "The base64-encoded signature of the snapshot string",
+
+	// ExportHistoryCmd help.
+	"exporthistory--synopsis": "Writes the transaction history of the wallet, or of one account, to a new file as CSV or JSON for spreadsheets and accounting software.\n" +
+		"Each received output and each output sent to another wallet is an entry with its date, transaction hash, category, account, address, amount, fee, address label, transaction label, comment and block height, oldest first with unmined transactions last.\n" +
+		"Sent amounts and fees are negative, and the fee of a send is only recorded by its first entry.\n" +
+		"Dates are in UTC, and are the time of the block for mined transactions and the time the transaction was received for unmined ones.\n" +
+		"The file is created by the wallet process with permissions allowing only its owner to read it, and must not already exist.",
+	"exporthistory-filename": "The absolute path of the file to create",
+	"exporthistory-account":  "The account to export the history of, or \"*\" for every account",
+	"exporthistory-format":   "The format of the file, \"csv\" or \"json\"",
+
+	// ExportHistoryResult help.
+	"exporthistoryresult-filename": "The path of the written file",
+	"exporthistoryresult-entries":  "The number of written history entries",
+
+	// ExportPrivKeyBIP38Cmd help.
+	"exportprivkeybip38--synopsis": "Returns the private key that controls some wallet address, encrypted with a passphrase as a BIP0038 key.\n" +
+		"The key is encrypted for the pay-to-pubkey-hash address of its public key, which is checked when the key is decrypted, and the wallet must be unlocked at the full level.",
+	"exportprivkeybip38-address":    "The address to return a private key for",
+	"exportprivkeybip38-passphrase": "The passphrase to encrypt the private key with",
+	"exportprivkeybip38--result0":   "The BIP0038 encrypted private key",
+
 	// ExportWatchingWalletCmd help.
 	"exportwatchingwallet--synopsis": "Creates and returns a duplicate of the wallet database without any private keys to be used as a watching-only wallet.",
 	"exportwatchingwallet-account":   "Unused (must be unset or \"*\")",
@@ -387,11 +812,73 @@ var helpDescsEnUS = map[string]string{
 	"getbestblockresult-hash":   "The hash of the block",
 	"getbestblockresult-height": "The blockchain height of the block",
 
+	// GetAddressesByLabelCmd help.
+	"getaddressesbylabel--synopsis":       "Returns the addresses with a label, which are set by setlabel.",
+	"getaddressesbylabel-label":           "The label",
+	"getaddressesbylabel--result0--desc":  "JSON object with addresses as keys and their purposes as values",
+	"getaddressesbylabel--result0--key":   "The address",
+	"getaddressesbylabel--result0--value": "The purpose of the address",
+
+	// AddressPurposeResult help.
+	"addresspurposeresult-purpose": "\"receive\" for addresses of the wallet, or \"send\" for addresses of other wallets",
+
+	// GetLookaheadCmd help.
+	"getlookahead--synopsis": "Returns the number of addresses past the last address handed out on each branch of every account which are watched for payments.",
+	"getlookahead--result0":  "The size of the lookahead window",
+
+	// GetPaymentQRCmd help.
+	"getpaymentqr--synopsis": "Returns the payload of a QR code requesting payment to a new address of an account, and optionally the QR code as a PNG image.\n" +
+		"The payload is the BIP0021 bitcoin: URI of the request, as returned by getpaymenturi. The label is recorded as the label of the address.",
+	"getpaymentqr-amount":  "The amount requested, valued in bitcoin",
+	"getpaymentqr-label":   "The label of the request and of the address",
+	"getpaymentqr-message": "A message describing the request to the payer",
+	"getpaymentqr-account": "The account to create the address for",
+	"getpaymentqr-png":     "Also return the QR code as a PNG image",
+	"getpaymentqr-size":    "The width and height of the PNG image in pixels, from 64 to 1024",
+
+	// GetPaymentQRResult help.
+	"getpaymentqrresult-payload": "The payload to encode in a QR code",
+	"getpaymentqrresult-address": "The address payment is requested to",
+	"getpaymentqrresult-png":     "The base64 encoded PNG image of the QR code, if requested",
+
+	// GetPaymentURICmd help.
+	"getpaymenturi--synopsis": "Returns a BIP0021 bitcoin: URI requesting payment to a new address of an account.\n" +
+		"The label is recorded as the label of the address, so that payments to it can be matched to the request.",
+	"getpaymenturi-amount":  "The amount requested, valued in bitcoin",
+	"getpaymenturi-label":   "The label of the request and of the address",
+	"getpaymenturi-message": "A message describing the request to the payer",
+	"getpaymenturi-account": "The account to create the address for",
+
+	// GetPaymentURIResult help.
+	"getpaymenturiresult-uri":     "The payment URI",
+	"getpaymenturiresult-address": "The address payment is requested to",
+
+	// GetSpendPolicyCmd help.
+	"getspendpolicy--synopsis":   "Returns the spend policy of an account along with the amount it sent during the last 24 hours.",
+	"getspendpolicy-account":     "The account name",
+	"getspendpolicy-addresstype": "The address type of the account: 'legacy' for BIP0044, 'p2sh-segwit' for BIP0049 or 'bech32' for BIP0084 accounts",
+
+	// SpendPolicyResult help.
+	"spendpolicyresult-account":   "The account name",
+	"spendpolicyresult-maxpertx":  "The maximum amount paid by a single transaction, or 0 if unlimited",
+	"spendpolicyresult-maxperday": "The maximum amount sent during any 24 hours, or 0 if unlimited",
+	"spendpolicyresult-whitelist": "The addresses which transactions may pay to, or empty if any address may be paid",
+	"spendpolicyresult-spent24h":  "The amount sent by transactions of the account during the last 24 hours",
+
 	// GetUnconfirmedBalanceCmd help.
 	"getunconfirmedbalance--synopsis": "Calculates the unspent output value of all unmined transaction outputs for an account.",
 	"getunconfirmedbalance-account":   "The account to query the unconfirmed balance for (default=\"default\")",
 	"getunconfirmedbalance--result0":  "Total amount of all unmined unspent outputs of the account valued in bitcoin.",
 
+	// ImportScriptCmd help.
+	"importscript--synopsis": "Imports a redeem script, or a witness script, to be watched as its pay-to-script-hash or pay-to-witness-script-hash address in the 'imported' account.\n" +
+		"Outputs paying to the script are included in balances, and may be spent by signing PSBTs. The wallet must be unlocked to import redeem scripts.",
+	"importscript-script":   "The hex encoded script",
+	"importscript-rescan":   "Rescan the blockchain for outputs paying to the script, which are otherwise only watched from the block the wallet is synced to",
+	"importscript-witness":  "Import a witness script as a pay-to-witness-script-hash address instead of a redeem script as a pay-to-script-hash address",
+	"importscript-birthday": "The birthday of the script as either a block height or, when not less than 500000000, a Unix timestamp. The rescan starts at the birthday block instead of the genesis block",
+	"importscript--result0": "The address of the script",
+
 	// ListAddressTransactionsCmd help.
 	"listaddresstransactions--synopsis": "Returns a JSON array of objects containing verbose details for wallet transactions pertaining some addresses.",
 	"listaddresstransactions-addresses": "Addresses to filter transaction results by",
@@ -401,12 +888,249 @@ var helpDescsEnUS = map[string]string{
 	"listalltransactions--synopsis": "Returns a JSON array of objects in the same format as 'listtransactions' without limiting the number of returned objects.",
 	"listalltransactions-account":   "Unused (must be unset or \"*\")",
 
+	// ListExpiredTransactionsCmd help.
+	"listexpiredtransactions--synopsis": "Returns the sends of the wallet which remain unmined longer than the unmined expiry set by the 'unminedexpiry' option, oldest first.\n" +
+		"Expired sends should be abandoned or replaced with a higher fee.  The result is empty when expiry is disabled.\n" +
+		"Websocket clients are notified of each send when it expires by a 'btcwallet:txstuck' notification, which suggests the fee rate of a replacement.",
+
+	// ListExpiredTransactionsResult help.
+	"listexpiredtransactionsresult-txid":         "The hash of the transaction",
+	"listexpiredtransactionsresult-timereceived": "The earliest Unix time this transaction was known to exist",
+	"listexpiredtransactionsresult-fee":          "The fee paid by the transaction valued in bitcoin, or 0 if it spends outputs not controlled by the wallet",
+
+	// ListRescansCmd help.
+	"listrescans--synopsis": "Returns the running rescan jobs followed by the queued jobs, which are rescanned one batch at a time.\n" +
+		"Websocket clients may subscribe to 'btcwallet:rescanprogress' notifications reporting the progress and completion of each job.",
+
+	// ListRescansResult help.
+	"listrescansresult-id":          "The id of the rescan job",
+	"listrescansresult-state":       "Whether the job is 'running' or 'queued'",
+	"listrescansresult-addresses":   "The number of addresses rescanned by the job",
+	"listrescansresult-startheight": "The height of the block the job rescans from",
+	"listrescansresult-height":      "The height of the last block rescanned, omitted for queued jobs",
+	"listrescansresult-percent":     "The progress of the rescan towards the best block when it started, omitted for queued jobs",
+
+	// CreateWalletCmd help.
+	"createwallet--synopsis": "Creates a wallet at runtime and loads it as 'loadwallet' does, serving it at the URL '/wallet/<name>'.\n" +
+		"The wallet database is protected by the public passphrase set by the 'walletpass' option.\n" +
+		"An options object may be passed as an additional final parameter.  The 'network' option ('mainnet', 'testnet3', 'regtest', 'signet' or 'simnet') binds the wallet to a network other than the network of the server, synchronizing it with a btcd server of that network set by the 'netrpcconnect' option.  Networks are bound per wallet rather than per account, so every account of a wallet belongs to the wallet's network; accounts of different networks are served by separate wallets.  The network directory of the wallet is locked while wallets of the network are loaded, so it can't be used by another btcwallet process.",
+	"createwallet-walletname":         "The name of the new wallet, which names the directory of its database within the network directory of the application data of the wallet's network and must not contain path separators",
+	"createwallet-disableprivatekeys": "Create a watching-only wallet which holds no private keys",
+	"createwallet-blank":              "Create a wallet without a seed, holding no keys until they are imported",
+	"createwallet-passphrase":         "The private passphrase protecting the private keys of the wallet, which is required unless private keys are disabled",
+	"createwallet-avoidreuse":         "Set the avoid_reuse flag on the accounts of the wallet which hold private keys",
+
+	// CreateWalletResult help.
+	"createwalletresult-name":    "The name of the created wallet",
+	"createwalletresult-warning": "A warning about creating the wallet, if any",
+
+	// DebugLevelCmd help.
+	"debuglevel--synopsis": "Sets the logging levels of the process, which apply to every loaded wallet.\n" +
+		"The level specification is either a single level for every subsystem or comma separated subsystem=level pairs, such as 'WLLT=debug,RPCS=trace'.\n" +
+		"The levels are trace, debug, info, warn, error and critical.  No level is changed when the specification is invalid.  The special specification 'show' lists the supported subsystems instead.",
+	"debuglevel-levelspec": "The logging level specification, or 'show'",
+	"debuglevel--result0":  "'Done.' once the levels are set, or the supported subsystems when 'show' is requested",
+
+	// ListLabelsCmd help.
+	"listlabels--synopsis": "Returns the distinct labels of all labeled addresses, sorted.",
+	"listlabels-purpose":   "Only return the labels of addresses of the wallet (\"receive\") or of other wallets (\"send\")",
+	"listlabels--result0":  "The labels",
+
+	// ListWalletsCmd help.
+	"listwallets--synopsis": "Returns the names of the loaded wallets.\n" +
+		"The wallet opened at startup is named by the empty string and is served at the root URL, while wallets loaded with 'loadwallet' are served at '/wallet/<name>'.",
+	"listwallets--result0": "The names of the loaded wallets",
+
+	// LoadWalletCmd help.
+	"loadwallet--synopsis": "Loads a wallet at runtime, synchronizing it over its own connection to the chain server, and serves it at the URL '/wallet/<name>'.\n" +
+		"The wallet is opened with the public passphrase set by the 'walletpass' option.\n" +
+		"An options object may be passed as an additional final parameter.  The 'network' option ('mainnet', 'testnet3', 'regtest', 'signet' or 'simnet') names the network the wallet is bound to when it is not the network of the server, synchronizing it with a btcd server of that network set by the 'netrpcconnect' option.  Networks are bound per wallet rather than per account, so every account of a wallet belongs to the wallet's network; accounts of different networks are served by separate wallets.  The network directory of the wallet is locked while wallets of the network are loaded, so it can't be used by another btcwallet process.",
+	"loadwallet-walletname": "The name of the wallet, which names the directory of its database within the network directory of the application data of the wallet's network and must not contain path separators",
+
+	// LoadWalletResult help.
+	"loadwalletresult-name":    "The name of the loaded wallet",
+	"loadwalletresult-warning": "A warning about loading the wallet, if any",
+
+	// NotifyTxConfirmationsCmd help.
+	"notifytxconfirmations--synopsis": "Subscribes a websocket client to the confirmations of a transaction.\n" +
+		"A 'btcwallet:txconfirmed' notification is sent once the transaction reaches the requested depth, ending the subscription.\n" +
+		"A 'btcwallet:txreorged' notification is sent each time the transaction is removed from the main chain before then.\n" +
+		"This method is only available over websocket connections.",
+	"notifytxconfirmations-txid":  "The hash of the transaction",
+	"notifytxconfirmations-depth": "The number of confirmations to notify the transaction at",
+
+	// MergeAccountsCmd help.
+	"mergeaccounts--synopsis": "Moves all addresses of an account, along with their unspent outputs, balance and transaction history, into another account.\n" +
+		"Only the bookkeeping of the wallet changes: no transaction is created, and the keys of the addresses are still derived from the account they were derived from.\n" +
+		"The account merged from is kept, and addresses created for it after the merge belong to it.\n" +
+		"Accounts protected by their own passphrase, and watch-only accounts with spendable accounts, cannot be merged.",
+	"mergeaccounts-fromaccount": "The account to merge from",
+	"mergeaccounts-toaccount":   "The account to merge into",
+
 	// RenameAccountCmd help.
 	"renameaccount--synopsis":  "Renames an account.",
 	"renameaccount-oldaccount": "The old account name to rename",
 	"renameaccount-newaccount": "The new name for the account",
 
+	// SetAccountFlagCmd help.
+	"setaccountflag--synopsis": "Changes the state of an account flag.\n" +
+		"The only flag is 'avoid_reuse': when set, coin selection for the account never combines outputs paying to dirty addresses, those which have previously been spent from, with outputs paying to clean addresses.",
+	"setaccountflag-account": "The account name",
+	"setaccountflag-flag":    "The name of the flag to change",
+	"setaccountflag-value":   "The new state of the flag (default=true)",
+
+	// SetAccountFlagResult help.
+	"setaccountflagresult-flag_name":  "The name of the changed flag",
+	"setaccountflagresult-flag_state": "The new state of the flag",
+
+	// SetAccountMetadataCmd help.
+	"setaccountmetadata--synopsis":   "Replaces the description and purpose tags of an account.",
+	"setaccountmetadata-account":     "The account name",
+	"setaccountmetadata-description": "The new description of the account",
+	"setaccountmetadata-tags":        "Tags describing the purpose of the account (default=[])",
+
+	// SetAccountPassphraseCmd help.
+	"setaccountpassphrase--synopsis": "Protects the private keys of an account with their own passphrase, so that the account is locked and unlocked independently of the wallet.\n" +
+		"The account must be unlocked, and remains unlocked with the new passphrase.\n" +
+		"An empty passphrase returns the account to the protection of the wallet passphrase.\n" +
+		"The account passphrase does not protect the account from holders of the wallet passphrase: the keys of every account are derived from a key which remains encrypted with the wallet passphrase, so unlocking the wallet allows re-deriving the private keys of the account.",
+	"setaccountpassphrase-account":    "The account name",
+	"setaccountpassphrase-passphrase": "The new passphrase of the account",
+
+	// SetDepositAlertCmd help.
+	"setdepositalert--synopsis": "Sets the amount at or above which a deposit to an account is alerted.\n" +
+		"A transaction paying external addresses of the account at least the amount is logged as a warning, notified to every websocket client by a 'btcwallet:largedeposit' notification, whatever their subscriptions, and posted to the webhook set by the 'depositwebhook' option.\n" +
+		"Deposits are alerted once, when their transaction is first seen in the mempool or a block.  Changes of the amount are recorded by the audit log.",
+	"setdepositalert-account": "The account name",
+	"setdepositalert-amount":  "The least amount of an alerted deposit valued in bitcoin, or 0 to disable deposit alerts",
+
+	// ReserveAddressCmd help.
+	"reserveaddress--synopsis": "Reserves a payment address of an account for an invoice and labels it.\n" +
+		"The address is claimed atomically, so frontends sharing the wallet never reserve the same address, and getaccountaddress does not return it.\n" +
+		"A released address which has not received any payment is reserved again before a new address is created.\n" +
+		"The reservation ends when the address is released with releaseaddress or receives a payment.",
+	"reserveaddress-account":  "The account of the address",
+	"reserveaddress-label":    "The label of the address, replacing the label of a previous reservation (default=no label)",
+	"reserveaddress--result0": "The reserved payment address",
+
+	// ReleaseAddressCmd help.
+	"releaseaddress--synopsis": "Releases an address reserved with reserveaddress, such as when its invoice expires.\n" +
+		"An address which has not received any payment may be reserved again.",
+	"releaseaddress-address": "The reserved address",
+
+	// SetLabelCmd help.
+	"setlabel--synopsis": "Sets the label of an address, such as the invoice it was handed out for.\n" +
+		"Labels are kept separately from accounts, and addresses of other wallets may be labeled as well.\n" +
+		"An empty label removes the label of the address.",
+	"setlabel-address": "The address to label",
+	"setlabel-label":   "The label",
+
+	// SetLookaheadCmd help.
+	"setlookahead--synopsis": "Changes the number of addresses past the last address handed out on each branch of every account which are watched for payments.\n" +
+		"Payments to addresses within the window are detected and extend the account through the paid address.\n" +
+		"A window of zero disables the lookahead.",
+	"setlookahead-window": "The new size of the lookahead window",
+
+	// SetSpendPolicyCmd help.
+	"setspendpolicy--synopsis": "Replaces the spend policy of an account, which limits the sends spending from the account as well as the transactions spending from it signed by 'signrawtransaction' and 'signrawtransactionwithwallet'.\n" +
+		"Sends and signing requests violating the policy are refused with error code -40 and recorded by the audit log, as are changes of the policy.  " +
+		"Sends which are not published yet, such as those awaiting confirmation, and transactions signed by the wallet count towards the daily limit until they are cancelled or recorded.  " +
+		"The amount of a send is the total paid to its recipients, excluding change and fees, and the daily limit counts the sends received during the last 24 hours.\n" +
+		"Passing only the account removes its policy.",
+	"setspendpolicy-account":     "The account name",
+	"setspendpolicy-maxpertx":    "The maximum amount paid by a single transaction, valued in bitcoin (default=0, unlimited)",
+	"setspendpolicy-maxperday":   "The maximum amount sent during any 24 hours, valued in bitcoin (default=0, unlimited)",
+	"setspendpolicy-whitelist":   "The addresses which transactions may pay to (default=[], any address)",
+	"setspendpolicy-addresstype": "The address type of the account: 'legacy' for BIP0044, 'p2sh-segwit' for BIP0049 or 'bech32' for BIP0084 accounts",
+
+	// SignMessageBIP322Cmd help.
+	"signmessagebip322--synopsis": "Signs a message with the key of an address of any type the wallet spends from, returning a BIP0322 signature.\n" +
+		"Unlike 'signmessage', which only proves control of pay-to-pubkey-hash addresses, the signature proves control of the script of the address.  " +
+		"Signatures for native segwit addresses are in the simple format, and those for other addresses in the full format.",
+	"signmessagebip322-address":  "The address whose key signs the message",
+	"signmessagebip322-message":  "The message to sign",
+	"signmessagebip322--result0": "The BIP0322 signature encoded as a base64 string",
+
+	// SubscribeNotificationsCmd help.
+	"subscribenotifications--synopsis": "Subscribes a websocket client to notifications, either of every account or only of a single account.\n" +
+		"Clients receive every notification until they first subscribe, after which only subscribed notifications are sent.\n" +
+		"The notifications are 'btcwallet:newtx', 'btcwallet:txconflict', 'btcwallet:blockconnected', 'btcwallet:blockdisconnected', 'btcwallet:chainhealth', 'btcwallet:backupfailed', 'btcwallet:accountbalances', 'btcwallet:lockstate', 'btcwallet:rescanprogress', 'btcwallet:txstuck' and the deprecated 'accountbalance', of which only 'btcwallet:newtx' and 'accountbalance' are specific to an account.\n" +
+		"The 'btcwallet:shutdown' notification, sent before the server disconnects clients when it shuts down, and the 'btcwallet:largedeposit' notification of deposits alerted by 'setdepositalert' are always sent.\n" +
+		"This method is only available over websocket connections.",
+	"subscribenotifications-notifications": "The notifications to subscribe to",
+	"subscribenotifications-account":       "Only subscribe to the notifications of this account (default=all accounts)",
+
+	// SweepPrivKeyCmd help.
+	"sweepprivkey--synopsis": "Finds all unspent outputs controlled by a WIF-encoded private key and sends their entire value, less the transaction fee, to a new address of a wallet account.\n" +
+		"The private key is only used to sign the sweep transaction and is not imported into the wallet.",
+	"sweepprivkey-privkey":     "The WIF-encoded private key to sweep",
+	"sweepprivkey-account":     "The account to receive the swept funds (default=\"default\")",
+	"sweepprivkey-startheight": "Block height to begin scanning for outputs controlled by the key (default=0)",
+
+	// SweepPrivKeyResult help.
+	"sweepprivkeyresult-txid":    "The hash of the sweep transaction",
+	"sweepprivkeyresult-address": "The wallet address receiving the swept funds",
+	"sweepprivkeyresult-amount":  "The amount received by the wallet address valued in bitcoin",
+	"sweepprivkeyresult-fee":     "The fee paid by the sweep transaction valued in bitcoin",
+	"sweepprivkeyresult-inputs":  "The number of outputs spent by the sweep transaction",
+
+	// UnloadWalletCmd help.
+	"unloadwallet--synopsis": "Unloads a wallet loaded with 'loadwallet', closing its connection to the chain server so that its addresses are no longer tracked.\n" +
+		"The wallet opened at startup can not be unloaded.",
+	"unloadwallet-walletname": "The name of the wallet to unload (default=the wallet of the request URL)",
+
+	// UnsubscribeNotificationsCmd help.
+	"unsubscribenotifications--synopsis": "Removes subscriptions of a websocket client to notifications made with 'subscribenotifications'.\n" +
+		"When an account is specified, only subscriptions made for that account are removed.\n" +
+		"This method is only available over websocket connections.",
+	"unsubscribenotifications-notifications": "The notifications to unsubscribe from",
+	"unsubscribenotifications-account":       "Only remove the subscriptions made for this account (default=all subscriptions)",
+
+	// VerifyMessageBIP322Cmd help.
+	"verifymessagebip322--synopsis": "Verifies a BIP0322 signature of a message, in the simple or full format, proving control of the script of an address.\n" +
+		"Legacy signatures created by 'signmessage' are accepted for pay-to-pubkey-hash addresses.",
+	"verifymessagebip322-address":   "The address the message was signed with",
+	"verifymessagebip322-signature": "The base64 encoded signature to verify",
+	"verifymessagebip322-message":   "The signed message",
+	"verifymessagebip322--result0":  "Whether the signature proves control of 'address'",
+
+	// WalletFsckCmd help.
+	"walletfsck--synopsis": "Checks the integrity of the wallet database, verifying every transaction record against the checksum of its transaction hash and cross-checking the unspent outputs and unmined transaction indexes against the transaction records.\n" +
+		"Records failing their checksum are lost transaction records. Orphaned index entries, missing unspent output entries and an incorrect balance are repaired in place when requested.\n" +
+		"Lost transaction records cannot be repaired while the wallet runs; restart with the 'walletfsckrepair' option to rebuild the transaction history by rescanning the chain.",
+	"walletfsck-repair": "Repair the inconsistencies which can be repaired in place",
+
+	// WalletFsckResult help.
+	"walletfsckresult-inconsistencies": "The inconsistencies found",
+	"walletfsckresult-repaired":        "Whether the repairable inconsistencies were repaired",
+
+	// WalletFsckInconsistency help.
+	"walletfsckinconsistency-bucket":      "The kind of record which is inconsistent",
+	"walletfsckinconsistency-key":         "The key of the record as a hex string",
+	"walletfsckinconsistency-description": "A description of the inconsistency",
+	"walletfsckinconsistency-repairable":  "Whether the inconsistency can be repaired in place",
+
 	// WalletIsLockedCmd help.
-	"walletislocked--synopsis": "Returns whether or not the wallet is locked.",
-	"walletislocked--result0":  "Whether the wallet is locked",
+	"walletislocked--synopsis": "Returns whether or not the wallet is locked.\n" +
+		"btcwallet extension: an account name may be passed to instead return whether the account is locked, or '*' to return whether the wallet or any account protected by its own passphrase is locked.",
+	"walletislocked--result0": "Whether the wallet is locked",
+
+	// WalletExtendUnlockCmd help.
+	"walletextendunlock--synopsis": "Replaces the timeout of the unlocked wallet, or of an unlocked account protected by its own passphrase, without requiring the passphrase again.\n" +
+		"The wallet or account is locked once the new timeout has elapsed from now. An error is returned if it is locked.",
+	"walletextendunlock-timeout": "The number of seconds from now after which the wallet or account is locked, or 0 to keep it unlocked until it is locked explicitly",
+	"walletextendunlock-account": "The account protected by its own passphrase to extend instead of the wallet",
+
+	// WalletLockAllCmd help.
+	"walletlockall--synopsis": "Locks the wallet and every account protected by its own passphrase at once, like walletlock with the '*' account.\n" +
+		"Websocket clients receive a single 'btcwallet:lockstate' notification naming everything which was locked.",
+
+	// WalletUnlockedUntilCmd help.
+	"walletunlockeduntil--synopsis": "Returns whether the wallet, or an account protected by its own passphrase, is unlocked and when it will be locked again.",
+	"walletunlockeduntil-account":   "The account protected by its own passphrase to query instead of the wallet",
+
+	// WalletUnlockedUntilResult help.
+	"walletunlockeduntilresult-unlocked":       "Whether the wallet or account is unlocked",
+	"walletunlockeduntilresult-unlocked_until": "The Unix time at which the wallet or account will be locked, or 0 when it is locked or unlocked without a time limit",
+	"walletunlockeduntilresult-unlock_level":   "The unlock level of the unlocked wallet ('view', 'spend' or 'full'), omitted for accounts",
 }
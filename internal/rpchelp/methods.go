@@ -7,7 +7,10 @@
 
 package rpchelp
 
-import "github.com/btcsuite/btcd/btcjson"
+import (
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+)
 
 // Common return types.
 var (
@@ -15,7 +18,7 @@ var (
 	returnsNumber      = []interface{}{(*float64)(nil)}
 	returnsString      = []interface{}{(*string)(nil)}
 	returnsStringArray = []interface{}{(*[]string)(nil)}
-	returnsLTRArray    = []interface{}{(*[]btcjson.ListTransactionsResult)(nil)}
+	returnsLTRArray    = []interface{}{(*[]walletjson.ListTransactionsResult)(nil)}
 )
 
 // Methods contains all methods and result types that help is generated for,
@@ -25,12 +28,17 @@ var Methods = []struct {
 	ResultTypes []interface{}
 }{
 	{"addmultisigaddress", returnsString},
+	{"analyzepsbt", []interface{}{(*walletjson.AnalyzePsbtResult)(nil)}},
 	{"createmultisig", []interface{}{(*btcjson.CreateMultiSigResult)(nil)}},
+	{"decodepsbt", []interface{}{(*walletjson.DecodePsbtResult)(nil)}},
 	{"dumpprivkey", returnsString},
+	{"dumpwallet", []interface{}{(*walletjson.DumpWalletResult)(nil)}},
 	{"getaccount", returnsString},
 	{"getaccountaddress", returnsString},
 	{"getaddressesbyaccount", returnsStringArray},
+	{"getaddressinfo", []interface{}{(*walletjson.GetAddressInfoResult)(nil)}},
 	{"getbalance", append(returnsNumber, returnsNumber[0])},
+	{"getbalances", []interface{}{(*walletjson.GetBalancesResult)(nil)}},
 	{"getbestblockhash", returnsString},
 	{"getblockcount", returnsNumber},
 	{"getinfo", []interface{}{(*btcjson.InfoWalletResult)(nil)}},
@@ -38,17 +46,21 @@ var Methods = []struct {
 	{"getrawchangeaddress", returnsString},
 	{"getreceivedbyaccount", returnsNumber},
 	{"getreceivedbyaddress", returnsNumber},
-	{"gettransaction", []interface{}{(*btcjson.GetTransactionResult)(nil)}},
+	{"gettransaction", []interface{}{(*walletjson.GetTransactionResult)(nil)}},
+	{"getwalletinfo", []interface{}{(*walletjson.GetWalletInfoResult)(nil)}},
 	{"help", append(returnsString, returnsString[0])},
+	{"importdescriptors", []interface{}{(*[]walletjson.ImportDescriptorsResult)(nil)}},
 	{"importprivkey", nil},
+	{"importpubkey", nil},
 	{"keypoolrefill", nil},
-	{"listaccounts", []interface{}{(*map[string]float64)(nil)}},
+	{"listaccounts", []interface{}{(*map[string]float64)(nil), (*[]walletjson.ListAccountsVerboseResult)(nil)}},
+	{"listdescriptors", []interface{}{(*walletjson.ListDescriptorsResult)(nil)}},
 	{"listlockunspent", []interface{}{(*[]btcjson.TransactionInput)(nil)}},
 	{"listreceivedbyaccount", []interface{}{(*[]btcjson.ListReceivedByAccountResult)(nil)}},
 	{"listreceivedbyaddress", []interface{}{(*[]btcjson.ListReceivedByAddressResult)(nil)}},
-	{"listsinceblock", []interface{}{(*btcjson.ListSinceBlockResult)(nil)}},
+	{"listsinceblock", []interface{}{(*walletjson.ListSinceBlockResult)(nil)}},
 	{"listtransactions", returnsLTRArray},
-	{"listunspent", []interface{}{(*btcjson.ListUnspentResult)(nil)}},
+	{"listunspent", []interface{}{(*walletjson.ListUnspentResult)(nil)}},
 	{"lockunspent", returnsBool},
 	{"sendfrom", returnsString},
 	{"sendmany", returnsString},
@@ -56,19 +68,69 @@ var Methods = []struct {
 	{"settxfee", returnsBool},
 	{"signmessage", returnsString},
 	{"signrawtransaction", []interface{}{(*btcjson.SignRawTransactionResult)(nil)}},
+	{"signrawtransactionwithwallet", []interface{}{(*btcjson.SignRawTransactionWithWalletResult)(nil)}},
 	{"validateaddress", []interface{}{(*btcjson.ValidateAddressWalletResult)(nil)}},
 	{"verifymessage", returnsBool},
 	{"walletlock", nil},
 	{"walletpassphrase", nil},
 	{"walletpassphrasechange", nil},
+	{"authorizekeyuse", nil},
+	{"canceldrafttx", nil},
+	{"cancelrescan", nil},
+	{"cancelspend", nil},
+	{"committx", returnsString},
+	{"confirmspend", returnsString},
 	{"createnewaccount", nil},
+	{"createtx", []interface{}{(*walletjson.CreateTxResult)(nil)}},
+	{"createwallet", []interface{}{(*btcjson.CreateWalletResult)(nil)}},
+	{"debuglevel", returnsString},
+	{"estimatesendfee", []interface{}{(*walletjson.EstimateSendFeeResult)(nil)}},
+	{"exportauditsnapshot", []interface{}{(*walletjson.ExportAuditSnapshotResult)(nil)}},
+	{"exporthistory", []interface{}{(*walletjson.ExportHistoryResult)(nil)}},
+	{"exportprivkeybip38", returnsString},
 	{"exportwatchingwallet", returnsString},
+	{"getaccountingreport", []interface{}{(*walletjson.AccountingReportResult)(nil)}},
+	{"getaccountmetadata", []interface{}{(*walletjson.AccountMetadataResult)(nil)}},
+	{"getaccountxpub", []interface{}{(*walletjson.GetAccountXpubResult)(nil)}},
 	{"getbestblock", []interface{}{(*btcjson.GetBestBlockResult)(nil)}},
+	{"getaddressesbylabel", []interface{}{(*map[string]walletjson.AddressPurposeResult)(nil)}},
+	{"getlookahead", returnsNumber},
+	{"getpaymentqr", []interface{}{(*walletjson.GetPaymentQRResult)(nil)}},
+	{"getpaymenturi", []interface{}{(*walletjson.GetPaymentURIResult)(nil)}},
+	{"getspendpolicy", []interface{}{(*walletjson.SpendPolicyResult)(nil)}},
 	{"getunconfirmedbalance", returnsNumber},
+	{"importscript", returnsString},
 	{"listaddresstransactions", returnsLTRArray},
 	{"listalltransactions", returnsLTRArray},
+	{"listexpiredtransactions", []interface{}{(*[]walletjson.ListExpiredTransactionsResult)(nil)}},
+	{"listlabels", returnsStringArray},
+	{"listrescans", []interface{}{(*[]walletjson.ListRescansResult)(nil)}},
+	{"listwallets", returnsStringArray},
+	{"loadwallet", []interface{}{(*btcjson.LoadWalletResult)(nil)}},
+	{"mergeaccounts", nil},
+	{"notifytxconfirmations", nil},
 	{"renameaccount", nil},
+	{"releaseaddress", nil},
+	{"rescanblockchain", []interface{}{(*walletjson.RescanBlockchainResult)(nil)}},
+	{"reserveaddress", returnsString},
+	{"setaccountflag", []interface{}{(*walletjson.SetAccountFlagResult)(nil)}},
+	{"setaccountmetadata", nil},
+	{"setaccountpassphrase", nil},
+	{"setdepositalert", nil},
+	{"setlabel", nil},
+	{"setlookahead", nil},
+	{"setspendpolicy", nil},
+	{"signmessagebip322", returnsString},
+	{"subscribenotifications", nil},
+	{"sweepprivkey", []interface{}{(*walletjson.SweepPrivKeyResult)(nil)}},
+	{"unloadwallet", nil},
+	{"unsubscribenotifications", nil},
+	{"verifymessagebip322", returnsBool},
+	{"walletfsck", []interface{}{(*walletjson.WalletFsckResult)(nil)}},
 	{"walletislocked", returnsBool},
+	{"walletextendunlock", nil},
+	{"walletlockall", nil},
+	{"walletunlockeduntil", []interface{}{(*walletjson.WalletUnlockedUntilResult)(nil)}},
 }
 
 // HelpDescs contains the locale-specific help strings along with the locale.
@@ -0,0 +1,17 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package securemem
+
+// alloc allocates the memory of a buffer on the heap, since memory can't be
+// locked on this platform.
+func alloc(size int) (mem []byte, mapped, locked bool) {
+	return make([]byte, size), false, false
+}
+
+// free releases the zeroed memory of a buffer to the garbage collector.
+func free(mem []byte, mapped, locked bool) {}
@@ -0,0 +1,43 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package securemem
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// alloc maps anonymous pages for a secret of size bytes and attempts to lock
+// them into RAM.  The heap is used instead when the pages can't be mapped.
+func alloc(size int) (mem []byte, mapped, locked bool) {
+	pageSize := os.Getpagesize()
+	n := (size + pageSize - 1) / pageSize * pageSize
+	if n == 0 {
+		n = pageSize
+	}
+	mem, err := unix.Mmap(
+		-1, 0, n, unix.PROT_READ|unix.PROT_WRITE,
+		unix.MAP_ANON|unix.MAP_PRIVATE,
+	)
+	if err != nil {
+		return make([]byte, size), false, false
+	}
+	locked = unix.Mlock(mem) == nil
+	return mem, true, locked
+}
+
+// free unlocks and unmaps the zeroed memory of a buffer.
+func free(mem []byte, mapped, locked bool) {
+	if locked {
+		_ = unix.Munlock(mem)
+	}
+	if mapped {
+		_ = unix.Munmap(mem)
+	}
+}
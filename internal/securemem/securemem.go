@@ -0,0 +1,201 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package securemem provides buffers for secrets such as passphrases and
+// decrypted key material.  Where the platform allows it, the memory of a buffer
+// is allocated outside of the garbage collected heap, so the runtime never
+// copies it, and is locked into RAM so it is never written to swap.  The
+// memory is always zeroed when the buffer is freed.
+package securemem
+
+import (
+	"errors"
+	"runtime"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/btcsuite/btcwallet/internal/zero"
+)
+
+// Buffer holds a secret in memory which is zeroed when the buffer is freed.
+// Buffers which are not freed explicitly are freed when they are garbage
+// collected, but callers should free them as soon as the secret is no longer
+// needed.
+type Buffer struct {
+	mem    []byte // the whole allocation, which may exceed the secret
+	size   int
+	mapped bool
+	locked bool
+}
+
+// New returns a zeroed buffer for a secret of size bytes.
+func New(size int) *Buffer {
+	mem, mapped, locked := alloc(size)
+	b := &Buffer{mem: mem, size: size, mapped: mapped, locked: locked}
+	runtime.SetFinalizer(b, (*Buffer).Free)
+	return b
+}
+
+// FromBytes returns a buffer holding a copy of the secret, and zeroes the
+// passed slice.
+func FromBytes(secret []byte) *Buffer {
+	b := New(len(secret))
+	copy(b.mem, secret)
+	zero.Bytes(secret)
+	return b
+}
+
+// Bytes returns the secret held by the buffer.  The slice must not be used
+// after the buffer is freed.
+func (b *Buffer) Bytes() []byte {
+	if b.mem == nil {
+		return nil
+	}
+	return b.mem[:b.size]
+}
+
+// Len returns the length of the secret held by the buffer.
+func (b *Buffer) Len() int {
+	return b.size
+}
+
+// Locked returns whether the memory of the buffer is locked into RAM.
+// Locking is best effort, and fails when the platform does not support it or
+// the limit of locked memory of the process is reached.
+func (b *Buffer) Locked() bool {
+	return b.locked
+}
+
+// Free zeroes the secret and releases the memory of the buffer.  Freeing a nil
+// buffer, or a buffer more than once, has no effect.
+func (b *Buffer) Free() {
+	if b == nil || b.mem == nil {
+		return
+	}
+	zero.Bytes(b.mem)
+	free(b.mem, b.mapped, b.locked)
+	b.mem = nil
+	b.size = 0
+	runtime.SetFinalizer(b, nil)
+}
+
+// ErrNotString is returned when decoding a JSON value which is not a string.
+var ErrNotString = errors.New("JSON value is not a string")
+
+// DecodeJSONString decodes the JSON string encoded by data into a new buffer,
+// without ever creating a Go string of its contents.  The encoded data is not
+// modified, and callers should zero it once it is no longer needed.
+func DecodeJSONString(data []byte) (*Buffer, error) {
+	// Skip surrounding whitespace.
+	for len(data) > 0 && isSpace(data[0]) {
+		data = data[1:]
+	}
+	for len(data) > 0 && isSpace(data[len(data)-1]) {
+		data = data[:len(data)-1]
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return nil, ErrNotString
+	}
+	data = data[1 : len(data)-1]
+
+	// The decoded string is never longer than its encoding.
+	b := New(len(data))
+	n := 0
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '"' || c < 0x20:
+			b.Free()
+			return nil, errors.New("invalid character in JSON string")
+
+		case c != '\\':
+			b.mem[n] = c
+			n++
+			i++
+			continue
+		}
+
+		if i+1 >= len(data) {
+			b.Free()
+			return nil, errors.New("invalid escape in JSON string")
+		}
+		switch data[i+1] {
+		case '"', '\\', '/':
+			b.mem[n] = data[i+1]
+		case 'b':
+			b.mem[n] = '\b'
+		case 'f':
+			b.mem[n] = '\f'
+		case 'n':
+			b.mem[n] = '\n'
+		case 'r':
+			b.mem[n] = '\r'
+		case 't':
+			b.mem[n] = '\t'
+		case 'u':
+			r, ok := hexRune(data[i+2:])
+			if !ok {
+				b.Free()
+				return nil, errors.New("invalid escape in " +
+					"JSON string")
+			}
+			i += 6
+
+			// Combine surrogate pairs, replacing invalid ones as
+			// encoding/json does.
+			if utf16.IsSurrogate(r) {
+				r2, ok := rune(-1), false
+				if len(data) > i+1 && data[i] == '\\' &&
+					data[i+1] == 'u' {
+
+					r2, ok = hexRune(data[i+2:])
+				}
+				dec := utf16.DecodeRune(r, r2)
+				if ok && dec != utf8.RuneError {
+					i += 6
+					r = dec
+				} else {
+					r = utf8.RuneError
+				}
+			}
+			n += utf8.EncodeRune(b.mem[n:], r)
+			continue
+		default:
+			b.Free()
+			return nil, errors.New("invalid escape in JSON string")
+		}
+		n++
+		i += 2
+	}
+	zero.Bytes(b.mem[n:])
+	b.size = n
+	return b, nil
+}
+
+// isSpace returns whether the byte is JSON whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// hexRune decodes the four hexadecimal digits at the start of data.
+func hexRune(data []byte) (rune, bool) {
+	if len(data) < 4 {
+		return 0, false
+	}
+	var r rune
+	for _, c := range data[:4] {
+		switch {
+		case '0' <= c && c <= '9':
+			c -= '0'
+		case 'a' <= c && c <= 'f':
+			c = c - 'a' + 10
+		case 'A' <= c && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
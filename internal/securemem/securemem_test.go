@@ -0,0 +1,90 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package securemem
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// TestBuffer ensures buffers hold a copy of their secret, zero the source of
+// the copy, and zero their memory when freed.
+func TestBuffer(t *testing.T) {
+	secret := []byte("passphrase")
+	b := FromBytes(secret)
+	if !bytes.Equal(b.Bytes(), []byte("passphrase")) {
+		t.Fatalf("unexpected secret %q", b.Bytes())
+	}
+	if !bytes.Equal(secret, make([]byte, len(secret))) {
+		t.Fatalf("source of the secret was not zeroed: %q", secret)
+	}
+
+	mem := b.mem
+	b.Free()
+	if b.Bytes() != nil || b.Len() != 0 {
+		t.Fatal("freed buffer still holds a secret")
+	}
+	if !b.mapped && !bytes.Equal(mem, make([]byte, len(mem))) {
+		t.Fatal("memory of the freed buffer was not zeroed")
+	}
+
+	// Freeing a buffer again has no effect.
+	b.Free()
+
+	empty := New(0)
+	if len(empty.Bytes()) != 0 {
+		t.Fatalf("unexpected secret %q", empty.Bytes())
+	}
+	empty.Free()
+}
+
+// TestDecodeJSONString ensures JSON strings are decoded into buffers like
+// encoding/json decodes them into Go strings.
+func TestDecodeJSONString(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "plain", data: `"passphrase"`},
+		{name: "empty", data: `""`},
+		{name: "whitespace", data: " \"pass phrase\"\n"},
+		{name: "escapes", data: `"a\"b\\c\/d\b\f\n\r\t"`},
+		{name: "unicode escapes", data: `"caf\u00e9 \u20AC"`},
+		{name: "utf-8", data: `"café €"`},
+		{name: "surrogate pair", data: `"\ud83d\ude00"`},
+		{name: "lone surrogate", data: `"\ud83d x"`},
+		{name: "not a string", data: `123`, wantErr: true},
+		{name: "null", data: `null`, wantErr: true},
+		{name: "unterminated", data: `"abc`, wantErr: true},
+		{name: "bad escape", data: `"a\x"`, wantErr: true},
+		{name: "short unicode escape", data: `"\u12"`, wantErr: true},
+		{name: "control character", data: "\"a\x01\"", wantErr: true},
+	}
+
+	for _, test := range tests {
+		b, err := DecodeJSONString([]byte(test.data))
+		if test.wantErr {
+			if err == nil {
+				t.Fatalf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+
+		var want string
+		if err := json.Unmarshal([]byte(test.data), &want); err != nil {
+			t.Fatalf("%s: bad test data: %v", test.name, err)
+		}
+		if string(b.Bytes()) != want {
+			t.Fatalf("%s: want %q, got %q", test.name, want,
+				b.Bytes())
+		}
+		b.Free()
+	}
+}
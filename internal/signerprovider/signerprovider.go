@@ -0,0 +1,147 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package signerprovider provides external signer providers of the wallet
+// which sign with keys held by an HTTP signing service, such as a gateway to a
+// hardware security module or a cloud key management service.
+//
+// Requests are made with the HTTP client passed by the caller, so that they
+// are routed through the proxies configured for the application rather than
+// connecting directly.
+package signerprovider
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+const (
+	// Timeout is the suggested time a single request of an HTTP signer
+	// provider may take.
+	Timeout = 30 * time.Second
+
+	// maxResponse is the largest response of an HTTP signing service
+	// which is read.
+	maxResponse = 1 << 16
+)
+
+// request is the JSON object posted to the signing service.  Hash is only set
+// when requesting a signature.
+type request struct {
+	Method string `json:"method"`
+	Ref    string `json:"ref"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+// response is the JSON object returned by the signing service, holding the
+// hex encoded public key or DER signature requested.
+type response struct {
+	PubKey    string `json:"pubkey"`
+	Signature string `json:"signature"`
+}
+
+// HTTPProvider is a wallet.SignerProvider signing with the keys of an HTTP
+// signing service.  Each request posts a JSON object with the method, either
+// "publickey" or "signhash", and the hex encoded key reference and hash.  The
+// service responds with a JSON object holding the hex encoded compressed
+// public key in "pubkey" or the DER encoded signature in "signature".
+type HTTPProvider struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+// Enforce HTTPProvider implements the wallet.SignerProvider interface.
+var _ wallet.SignerProvider = (*HTTPProvider)(nil)
+
+// NewHTTPProvider returns a wallet.SignerProvider with the name signing with
+// the keys of the signing service at url, which is requested with the client.
+func NewHTTPProvider(client *http.Client, name, url string) *HTTPProvider {
+	return &HTTPProvider{
+		name:   name,
+		url:    url,
+		client: client,
+	}
+}
+
+// Name returns the name of the provider.
+//
+// This function is part of the wallet.SignerProvider interface implementation.
+func (p *HTTPProvider) Name() string {
+	return p.name
+}
+
+// PublicKey requests the public key of the referenced key from the signing
+// service.
+//
+// This function is part of the wallet.SignerProvider interface implementation.
+func (p *HTTPProvider) PublicKey(ref []byte) (*btcec.PublicKey, error) {
+	resp, err := p.post(&request{
+		Method: "publickey",
+		Ref:    hex.EncodeToString(ref),
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, err := hex.DecodeString(resp.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+	return btcec.ParsePubKey(b, btcec.S256())
+}
+
+// SignHash requests the signature of a hash by the referenced key from the
+// signing service.
+//
+// This function is part of the wallet.SignerProvider interface implementation.
+func (p *HTTPProvider) SignHash(ref []byte, hash []byte) (*btcec.Signature,
+	error) {
+
+	resp, err := p.post(&request{
+		Method: "signhash",
+		Ref:    hex.EncodeToString(ref),
+		Hash:   hex.EncodeToString(hash),
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, err := hex.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	}
+	return btcec.ParseDERSignature(b, btcec.S256())
+}
+
+// post posts a request to the signing service and decodes its response.
+func (p *HTTPProvider) post(req *request) (*response, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Post(p.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("signer %s responded with status %s",
+			p.name, resp.Status)
+	}
+
+	var r response
+	body := io.LimitReader(resp.Body, maxResponse)
+	if err := json.NewDecoder(body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid signer %s response: %v",
+			p.name, err)
+	}
+	return &r, nil
+}
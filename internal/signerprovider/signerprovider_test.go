@@ -0,0 +1,87 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package signerprovider
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHTTPProvider ensures that public keys and signatures are requested from
+// HTTP signing services by the reference of the key.
+func TestHTTPProvider(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	ref := []byte("key-1")
+
+	fail := false
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter,
+		r *http.Request) {
+
+		var req request
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if fail || err != nil || req.Ref != hex.EncodeToString(ref) {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var resp response
+		switch req.Method {
+		case "publickey":
+			resp.PubKey = hex.EncodeToString(
+				privKey.PubKey().SerializeCompressed(),
+			)
+		case "signhash":
+			hash, _ := hex.DecodeString(req.Hash)
+			sig, err := privKey.Sign(hash)
+			if err != nil {
+				rw.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			resp.Signature = hex.EncodeToString(sig.Serialize())
+		}
+		json.NewEncoder(rw).Encode(&resp)
+	}))
+	defer srv.Close()
+
+	p := NewHTTPProvider(&http.Client{Timeout: Timeout}, "hsm", srv.URL)
+	require.Equal(t, "hsm", p.Name())
+
+	pubKey, err := p.PublicKey(ref)
+	require.NoError(t, err)
+	require.True(t, pubKey.IsEqual(privKey.PubKey()))
+
+	hash := chainhash.HashB([]byte("message"))
+	sig, err := p.SignHash(ref, hash)
+	require.NoError(t, err)
+	require.True(t, sig.Verify(hash, pubKey))
+
+	// Unknown references and failing services are errors.
+	_, err = p.PublicKey([]byte("key-2"))
+	require.Error(t, err)
+	fail = true
+	_, err = p.SignHash(ref, hash)
+	require.Error(t, err)
+
+	// Responses which are not keys or signatures are rejected.
+	fail = false
+	srv.Config.Handler = http.HandlerFunc(func(rw http.ResponseWriter,
+		r *http.Request) {
+
+		rw.Write([]byte(`{"pubkey": "00", "signature": "00"}`))
+	})
+	_, err = p.PublicKey(ref)
+	require.Error(t, err)
+	_, err = p.SignHash(ref, hash)
+	require.Error(t, err)
+}
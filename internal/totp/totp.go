@@ -0,0 +1,70 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package totp implements the RFC 6238 time-based one-time password algorithm
+// with the parameters used by common authenticator apps: HMAC-SHA1, a 30
+// second time step and 6 digit codes.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+const (
+	// Period is the duration of the time step of a code.
+	Period = 30 * time.Second
+
+	// Digits is the number of decimal digits of a code.
+	Digits = 6
+
+	// modulus is 10^Digits, which reduces a truncated HMAC to a code.
+	modulus = 1000000
+)
+
+// Step returns the time step counter of the time.
+func Step(t time.Time) uint64 {
+	return uint64(t.Unix()) / uint64(Period/time.Second)
+}
+
+// Code returns the code of the secret for the time step counter.
+func Code(secret []byte, step uint64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], step)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation of RFC 4226 section 5.3.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%modulus)
+}
+
+// Validate returns whether the code is valid for the secret at the time,
+// allowing for skew time steps of clock drift in either direction, along with
+// the time step counter the code was matched for.  Callers should reject codes
+// matched for a time step not after that of the last accepted code to prevent
+// replays.
+func Validate(secret []byte, code string, t time.Time,
+	skew uint64) (uint64, bool) {
+
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	step := Step(t)
+	for s := step - skew; s <= step+skew; s++ {
+		expected := Code(secret, s)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return s, true
+		}
+	}
+	return 0, false
+}
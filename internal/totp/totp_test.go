@@ -0,0 +1,66 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// The SHA1 test vectors of RFC 6238 appendix B, truncated to 6 digits.
+var tests = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+var secret = []byte("12345678901234567890")
+
+func TestCode(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range tests {
+		code := Code(secret, Step(time.Unix(test.unix, 0)))
+		if code != test.code {
+			t.Fatalf("time %d: want code %s, got %s", test.unix,
+				test.code, code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1111111109, 0)
+	step := Step(now)
+
+	// Codes of the adjacent time steps are accepted with a skew of one.
+	for _, s := range []uint64{step - 1, step, step + 1} {
+		matched, ok := Validate(secret, Code(secret, s), now, 1)
+		if !ok || matched != s {
+			t.Fatalf("step %d: want match for %d, got %d (%v)", s, s,
+				matched, ok)
+		}
+	}
+
+	// Codes outside of the skew, of the wrong length, and of other
+	// secrets are rejected.
+	invalid := []string{
+		Code(secret, step+2),
+		Code(secret, step)[1:],
+		Code([]byte("other secret"), step),
+	}
+	for _, code := range invalid {
+		if _, ok := Validate(secret, code, now, 1); ok {
+			t.Fatalf("code %s was accepted", code)
+		}
+	}
+}
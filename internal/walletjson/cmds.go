@@ -0,0 +1,1024 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package walletjson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// SendOptions describes btcwallet extension options which may be passed as a
+// JSON object following the reference parameters of the sendfrom, sendmany
+// and sendtoaddress commands, and following the parameters of the
+// estimatesendfee command.
+type SendOptions struct {
+	// Unit is the denomination of every amount of the request, such as
+	// "BTC", "mBTC" or "satoshi".  The server's default unit is used when
+	// unset.
+	Unit *string `json:"unit,omitempty"`
+
+	// AllowReuse permits spending outputs paying to dirty addresses
+	// together with outputs paying to clean addresses from an account
+	// which avoids address reuse.
+	AllowReuse *bool `json:"allowreuse,omitempty"`
+
+	// ChangeAddress receives the change output of the transaction instead
+	// of a new internal address of the account.  It may be an address of
+	// another wallet.
+	ChangeAddress *string `json:"changeaddress,omitempty"`
+
+	// NoChange omits the change output of the transaction, adding any
+	// remaining output value to the fee.
+	NoChange *bool `json:"nochange,omitempty"`
+
+	// SubtractFeeFromAmount deducts the transaction fee from the amounts
+	// paid to every recipient of the request, split equally, rather than
+	// paying it from the account.
+	SubtractFeeFromAmount *bool `json:"subtractfeefromamount,omitempty"`
+
+	// SubtractFeeFrom deducts the transaction fee from the amounts paid to
+	// the listed recipient addresses of the request, split equally.
+	SubtractFeeFrom *[]string `json:"subtractfeefrom,omitempty"`
+
+	// FeeRate is the fee per kilobyte paid by the transaction, in the
+	// unit of the request, overriding the default fee rate for this
+	// transaction only.
+	FeeRate *float64 `json:"feerate,omitempty"`
+}
+
+// ListOptions describes btcwallet extension options which may be passed as a
+// JSON object following the reference parameters of the listtransactions,
+// listunspent, listreceivedbyaddress and listreceivedbyaccount commands to
+// page and filter their results.
+type ListOptions struct {
+	// Count is the maximum number of results returned.  All remaining
+	// results are returned when unset.  It is not accepted by
+	// listtransactions, which has a reference count parameter.
+	Count *int `json:"count,omitempty"`
+
+	// Skip is the number of results skipped before the first returned
+	// result.  It is not accepted by listtransactions, which has a
+	// reference from parameter.
+	Skip *int `json:"skip,omitempty"`
+
+	// Cursor continues a listing after the result it identifies, which
+	// is the last result of the previous page: the transaction hash for
+	// listtransactions, the "txid:vout" outpoint for listunspent, the
+	// address for listreceivedbyaddress and the account name for
+	// listreceivedbyaccount.
+	Cursor *string `json:"cursor,omitempty"`
+
+	// StartTime and EndTime restrict the results to transactions received
+	// by the wallet at or after StartTime and before EndTime, in seconds
+	// since the Unix epoch.
+	StartTime *int64 `json:"starttime,omitempty"`
+	EndTime   *int64 `json:"endtime,omitempty"`
+
+	// MinimumAmount and MaximumAmount restrict the results to outputs
+	// of at least and at most the amounts, valued in bitcoin.
+	// MaximumCount is the reference name of Count.  IncludeUnsafe, which
+	// defaults to true, includes unmined outputs of transactions paid by
+	// other wallets.  These options are named as the query options of the
+	// reference implementation and are only accepted by listunspent.
+	MinimumAmount *float64 `json:"minimumAmount,omitempty"`
+	MaximumAmount *float64 `json:"maximumAmount,omitempty"`
+	MaximumCount  *int     `json:"maximumCount,omitempty"`
+	IncludeUnsafe *bool    `json:"include_unsafe,omitempty"`
+}
+
+// WalletOptions describes btcwallet extension options which may be passed as a
+// JSON object following the reference parameters of the loadwallet and
+// createwallet commands.
+type WalletOptions struct {
+	// Network is the name of the network the wallet is bound to, such as
+	// "mainnet", "testnet3", "regtest", "signet" or "simnet".  The wallet
+	// is synchronized over its own connection to a chain server of that
+	// network.  The network of the server is used when unset.
+	Network *string `json:"network,omitempty"`
+}
+
+// AnalyzePsbtCmd defines the analyzepsbt JSON-RPC command.
+type AnalyzePsbtCmd struct {
+	Psbt string
+}
+
+// NewAnalyzePsbtCmd returns a new instance which can be used to issue an
+// analyzepsbt JSON-RPC command.
+func NewAnalyzePsbtCmd(psbt string) *AnalyzePsbtCmd {
+	return &AnalyzePsbtCmd{
+		Psbt: psbt,
+	}
+}
+
+// AuthorizeKeyUseCmd defines the authorizekeyuse JSON-RPC command.
+type AuthorizeKeyUseCmd struct {
+	Code string
+}
+
+// NewAuthorizeKeyUseCmd returns a new instance which can be used to issue an
+// authorizekeyuse JSON-RPC command.
+func NewAuthorizeKeyUseCmd(code string) *AuthorizeKeyUseCmd {
+	return &AuthorizeKeyUseCmd{
+		Code: code,
+	}
+}
+
+// CancelDraftTxCmd defines the canceldrafttx JSON-RPC command.
+type CancelDraftTxCmd struct {
+	ID string
+}
+
+// NewCancelDraftTxCmd returns a new instance which can be used to issue a
+// canceldrafttx JSON-RPC command.
+func NewCancelDraftTxCmd(id string) *CancelDraftTxCmd {
+	return &CancelDraftTxCmd{
+		ID: id,
+	}
+}
+
+// CancelRescanCmd defines the cancelrescan JSON-RPC command.
+type CancelRescanCmd struct {
+	ID uint64
+}
+
+// NewCancelRescanCmd returns a new instance which can be used to issue a
+// cancelrescan JSON-RPC command.
+func NewCancelRescanCmd(id uint64) *CancelRescanCmd {
+	return &CancelRescanCmd{
+		ID: id,
+	}
+}
+
+// CancelSpendCmd defines the cancelspend JSON-RPC command.
+type CancelSpendCmd struct {
+	Token string
+}
+
+// NewCancelSpendCmd returns a new instance which can be used to issue a
+// cancelspend JSON-RPC command.
+func NewCancelSpendCmd(token string) *CancelSpendCmd {
+	return &CancelSpendCmd{
+		Token: token,
+	}
+}
+
+// CommitTxCmd defines the committx JSON-RPC command.
+type CommitTxCmd struct {
+	ID string
+}
+
+// NewCommitTxCmd returns a new instance which can be used to issue a committx
+// JSON-RPC command.
+func NewCommitTxCmd(id string) *CommitTxCmd {
+	return &CommitTxCmd{
+		ID: id,
+	}
+}
+
+// ConfirmSpendCmd defines the confirmspend JSON-RPC command.
+type ConfirmSpendCmd struct {
+	Token string
+	Code  string
+}
+
+// NewConfirmSpendCmd returns a new instance which can be used to issue a
+// confirmspend JSON-RPC command.
+func NewConfirmSpendCmd(token, code string) *ConfirmSpendCmd {
+	return &ConfirmSpendCmd{
+		Token: token,
+		Code:  code,
+	}
+}
+
+// CreateTxCmd defines the createtx JSON-RPC command.
+type CreateTxCmd struct {
+	Amounts map[string]float64 `jsonrpcusage:"{\"address\":amount,...}"`
+	Account *string            `jsonrpcdefault:"\"default\""`
+	MinConf *int               `jsonrpcdefault:"1"`
+	Comment *string
+}
+
+// NewCreateTxCmd returns a new instance which can be used to issue a createtx
+// JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewCreateTxCmd(amounts map[string]float64, account *string, minConf *int,
+	comment *string) *CreateTxCmd {
+
+	return &CreateTxCmd{
+		Amounts: amounts,
+		Account: account,
+		MinConf: minConf,
+		Comment: comment,
+	}
+}
+
+// DecodePsbtCmd defines the decodepsbt JSON-RPC command.
+type DecodePsbtCmd struct {
+	Psbt string
+}
+
+// NewDecodePsbtCmd returns a new instance which can be used to issue a
+// decodepsbt JSON-RPC command.
+func NewDecodePsbtCmd(psbt string) *DecodePsbtCmd {
+	return &DecodePsbtCmd{
+		Psbt: psbt,
+	}
+}
+
+// EstimateSendFeeCmd defines the estimatesendfee JSON-RPC command.
+type EstimateSendFeeCmd struct {
+	Amounts map[string]float64 `jsonrpcusage:"{\"address\":amount,...}"`
+	Account *string            `jsonrpcdefault:"\"default\""`
+	MinConf *int               `jsonrpcdefault:"1"`
+}
+
+// NewEstimateSendFeeCmd returns a new instance which can be used to issue an
+// estimatesendfee JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewEstimateSendFeeCmd(amounts map[string]float64, account *string,
+	minConf *int) *EstimateSendFeeCmd {
+
+	return &EstimateSendFeeCmd{
+		Amounts: amounts,
+		Account: account,
+		MinConf: minConf,
+	}
+}
+
+// ExportAuditSnapshotCmd defines the exportauditsnapshot JSON-RPC command.
+type ExportAuditSnapshotCmd struct {
+	Address string
+	Height  *int32
+}
+
+// NewExportAuditSnapshotCmd returns a new instance which can be used to issue
+// an exportauditsnapshot JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewExportAuditSnapshotCmd(address string, height *int32) *ExportAuditSnapshotCmd {
+	return &ExportAuditSnapshotCmd{
+		Address: address,
+		Height:  height,
+	}
+}
+
+// ExportHistoryCmd defines the exporthistory JSON-RPC command.
+type ExportHistoryCmd struct {
+	Filename string
+	Account  *string `jsonrpcdefault:"\"*\""`
+	Format   *string `jsonrpcdefault:"\"csv\""`
+}
+
+// NewExportHistoryCmd returns a new instance which can be used to issue an
+// exporthistory JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewExportHistoryCmd(filename string, account, format *string) *ExportHistoryCmd {
+	return &ExportHistoryCmd{
+		Filename: filename,
+		Account:  account,
+		Format:   format,
+	}
+}
+
+// ExportPrivKeyBIP38Cmd defines the exportprivkeybip38 JSON-RPC command.
+type ExportPrivKeyBIP38Cmd struct {
+	Address    string
+	Passphrase string
+}
+
+// NewExportPrivKeyBIP38Cmd returns a new instance which can be used to issue
+// an exportprivkeybip38 JSON-RPC command.
+func NewExportPrivKeyBIP38Cmd(address, passphrase string) *ExportPrivKeyBIP38Cmd {
+	return &ExportPrivKeyBIP38Cmd{
+		Address:    address,
+		Passphrase: passphrase,
+	}
+}
+
+// GetAccountingReportCmd defines the getaccountingreport JSON-RPC command.
+type GetAccountingReportCmd struct {
+	StartDate string
+	EndDate   string
+	Period    *string `jsonrpcdefault:"\"monthly\""`
+	Account   *string `jsonrpcdefault:"\"*\""`
+}
+
+// NewGetAccountingReportCmd returns a new instance which can be used to issue
+// a getaccountingreport JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetAccountingReportCmd(startDate, endDate string, period,
+	account *string) *GetAccountingReportCmd {
+
+	return &GetAccountingReportCmd{
+		StartDate: startDate,
+		EndDate:   endDate,
+		Period:    period,
+		Account:   account,
+	}
+}
+
+// GetAccountMetadataCmd defines the getaccountmetadata JSON-RPC command.
+type GetAccountMetadataCmd struct {
+	Account string
+}
+
+// NewGetAccountMetadataCmd returns a new instance which can be used to issue a
+// getaccountmetadata JSON-RPC command.
+func NewGetAccountMetadataCmd(account string) *GetAccountMetadataCmd {
+	return &GetAccountMetadataCmd{
+		Account: account,
+	}
+}
+
+// GetAccountXpubCmd defines the getaccountxpub JSON-RPC command.
+type GetAccountXpubCmd struct {
+	Account *string `jsonrpcdefault:"\"default\""`
+}
+
+// NewGetAccountXpubCmd returns a new instance which can be used to issue a
+// getaccountxpub JSON-RPC command.
+func NewGetAccountXpubCmd(account *string) *GetAccountXpubCmd {
+	return &GetAccountXpubCmd{
+		Account: account,
+	}
+}
+
+// GetAddressesByLabelCmd defines the getaddressesbylabel JSON-RPC command.
+type GetAddressesByLabelCmd struct {
+	Label string
+}
+
+// NewGetAddressesByLabelCmd returns a new instance which can be used to issue
+// a getaddressesbylabel JSON-RPC command.
+func NewGetAddressesByLabelCmd(label string) *GetAddressesByLabelCmd {
+	return &GetAddressesByLabelCmd{
+		Label: label,
+	}
+}
+
+// GetLookaheadCmd defines the getlookahead JSON-RPC command.
+type GetLookaheadCmd struct{}
+
+// NewGetLookaheadCmd returns a new instance which can be used to issue a
+// getlookahead JSON-RPC command.
+func NewGetLookaheadCmd() *GetLookaheadCmd {
+	return &GetLookaheadCmd{}
+}
+
+// GetPaymentQRCmd defines the getpaymentqr JSON-RPC command.
+type GetPaymentQRCmd struct {
+	Amount  *float64
+	Label   *string
+	Message *string
+	Account *string `jsonrpcdefault:"\"default\""`
+	PNG     *bool   `jsonrpcdefault:"false"`
+	Size    *int    `jsonrpcdefault:"256"`
+}
+
+// NewGetPaymentQRCmd returns a new instance which can be used to issue a
+// getpaymentqr JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetPaymentQRCmd(amount *float64, label, message, account *string,
+	png *bool, size *int) *GetPaymentQRCmd {
+
+	return &GetPaymentQRCmd{
+		Amount:  amount,
+		Label:   label,
+		Message: message,
+		Account: account,
+		PNG:     png,
+		Size:    size,
+	}
+}
+
+// GetPaymentURICmd defines the getpaymenturi JSON-RPC command.
+type GetPaymentURICmd struct {
+	Amount  *float64
+	Label   *string
+	Message *string
+	Account *string `jsonrpcdefault:"\"default\""`
+}
+
+// NewGetPaymentURICmd returns a new instance which can be used to issue a
+// getpaymenturi JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetPaymentURICmd(amount *float64, label, message,
+	account *string) *GetPaymentURICmd {
+
+	return &GetPaymentURICmd{
+		Amount:  amount,
+		Label:   label,
+		Message: message,
+		Account: account,
+	}
+}
+
+// GetSpendPolicyCmd defines the getspendpolicy JSON-RPC command.
+type GetSpendPolicyCmd struct {
+	Account     string
+	AddressType *string `jsonrpcdefault:"\"legacy\""`
+}
+
+// NewGetSpendPolicyCmd returns a new instance which can be used to issue a
+// getspendpolicy JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetSpendPolicyCmd(account string,
+	addressType *string) *GetSpendPolicyCmd {
+
+	return &GetSpendPolicyCmd{
+		Account:     account,
+		AddressType: addressType,
+	}
+}
+
+// DescriptorRange is the range of indexes at which a ranged descriptor is
+// imported.  It is given in JSON either as the end of the range, which then
+// begins at zero, or as an array of the beginning and end of the range.
+type DescriptorRange [2]uint32
+
+// UnmarshalJSON unmarshals the end of a range, or the beginning and end of a
+// range.
+func (r *DescriptorRange) UnmarshalJSON(data []byte) error {
+	var end uint32
+	if err := json.Unmarshal(data, &end); err == nil {
+		*r = DescriptorRange{0, end}
+		return nil
+	}
+	var bounds []uint32
+	if err := json.Unmarshal(data, &bounds); err != nil ||
+		len(bounds) != 2 {
+
+		return fmt.Errorf("descriptor range must be an end index " +
+			"or an array of a beginning and end index")
+	}
+	*r = DescriptorRange{bounds[0], bounds[1]}
+	return nil
+}
+
+// DescriptorTimestampNow is the DescriptorTimestamp of descriptors imported
+// with the timestamp "now".
+const DescriptorTimestampNow DescriptorTimestamp = -1
+
+// DescriptorTimestamp is the Unix time of the earliest transaction of the
+// scripts of an imported descriptor.  It is given in JSON either as a
+// non-negative integer or as the string "now", which is represented by
+// DescriptorTimestampNow.
+type DescriptorTimestamp int64
+
+// UnmarshalJSON unmarshals a Unix time or the string "now".
+func (t *DescriptorTimestamp) UnmarshalJSON(data []byte) error {
+	var now string
+	if err := json.Unmarshal(data, &now); err == nil {
+		if now != "now" {
+			return fmt.Errorf("invalid timestamp %q", now)
+		}
+		*t = DescriptorTimestampNow
+		return nil
+	}
+	var ts int64
+	if err := json.Unmarshal(data, &ts); err != nil || ts < 0 {
+		return fmt.Errorf("timestamp must be a Unix time or \"now\"")
+	}
+	*t = DescriptorTimestamp(ts)
+	return nil
+}
+
+// ImportDescriptorsRequest describes a descriptor to import with the
+// importdescriptors command.
+type ImportDescriptorsRequest struct {
+	Desc      string               `json:"desc"`
+	Range     *DescriptorRange     `json:"range,omitempty"`
+	Timestamp *DescriptorTimestamp `json:"timestamp"`
+	Label     string               `json:"label,omitempty"`
+}
+
+// ImportDescriptorsCmd defines the importdescriptors JSON-RPC command.
+type ImportDescriptorsCmd struct {
+	Requests []ImportDescriptorsRequest
+}
+
+// NewImportDescriptorsCmd returns a new instance which can be used to issue an
+// importdescriptors JSON-RPC command.
+func NewImportDescriptorsCmd(requests []ImportDescriptorsRequest) *ImportDescriptorsCmd {
+	return &ImportDescriptorsCmd{
+		Requests: requests,
+	}
+}
+
+// ImportScriptCmd defines the importscript JSON-RPC command.
+type ImportScriptCmd struct {
+	Script   string
+	Rescan   *bool `jsonrpcdefault:"true"`
+	Witness  *bool `jsonrpcdefault:"false"`
+	Birthday *int64
+}
+
+// NewImportScriptCmd returns a new instance which can be used to issue an
+// importscript JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewImportScriptCmd(script string, rescan, witness *bool,
+	birthday *int64) *ImportScriptCmd {
+
+	return &ImportScriptCmd{
+		Script:   script,
+		Rescan:   rescan,
+		Witness:  witness,
+		Birthday: birthday,
+	}
+}
+
+// ListDescriptorsCmd defines the listdescriptors JSON-RPC command.
+type ListDescriptorsCmd struct{}
+
+// NewListDescriptorsCmd returns a new instance which can be used to issue a
+// listdescriptors JSON-RPC command.
+func NewListDescriptorsCmd() *ListDescriptorsCmd {
+	return &ListDescriptorsCmd{}
+}
+
+// ListExpiredTransactionsCmd defines the listexpiredtransactions JSON-RPC
+// command.
+type ListExpiredTransactionsCmd struct{}
+
+// NewListExpiredTransactionsCmd returns a new instance which can be used to
+// issue a listexpiredtransactions JSON-RPC command.
+func NewListExpiredTransactionsCmd() *ListExpiredTransactionsCmd {
+	return &ListExpiredTransactionsCmd{}
+}
+
+// ListLabelsCmd defines the listlabels JSON-RPC command.
+type ListLabelsCmd struct {
+	Purpose *string
+}
+
+// NewListLabelsCmd returns a new instance which can be used to issue a
+// listlabels JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewListLabelsCmd(purpose *string) *ListLabelsCmd {
+	return &ListLabelsCmd{
+		Purpose: purpose,
+	}
+}
+
+// ListRescansCmd defines the listrescans JSON-RPC command.
+type ListRescansCmd struct{}
+
+// NewListRescansCmd returns a new instance which can be used to issue a
+// listrescans JSON-RPC command.
+func NewListRescansCmd() *ListRescansCmd {
+	return &ListRescansCmd{}
+}
+
+// ListWalletsCmd defines the listwallets JSON-RPC command.
+type ListWalletsCmd struct{}
+
+// NewListWalletsCmd returns a new instance which can be used to issue a
+// listwallets JSON-RPC command.
+func NewListWalletsCmd() *ListWalletsCmd {
+	return &ListWalletsCmd{}
+}
+
+// MergeAccountsCmd defines the mergeaccounts JSON-RPC command.
+type MergeAccountsCmd struct {
+	FromAccount string
+	ToAccount   string
+}
+
+// NewMergeAccountsCmd returns a new instance which can be used to issue a
+// mergeaccounts JSON-RPC command.
+func NewMergeAccountsCmd(fromAccount, toAccount string) *MergeAccountsCmd {
+	return &MergeAccountsCmd{
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+	}
+}
+
+// NotifyTxConfirmationsCmd defines the notifytxconfirmations JSON-RPC command.
+type NotifyTxConfirmationsCmd struct {
+	TxID  string
+	Depth *int32 `jsonrpcdefault:"1"`
+}
+
+// NewNotifyTxConfirmationsCmd returns a new instance which can be used to
+// issue a notifytxconfirmations JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewNotifyTxConfirmationsCmd(txID string, depth *int32) *NotifyTxConfirmationsCmd {
+	return &NotifyTxConfirmationsCmd{
+		TxID:  txID,
+		Depth: depth,
+	}
+}
+
+// ReleaseAddressCmd defines the releaseaddress JSON-RPC command.
+type ReleaseAddressCmd struct {
+	Address string
+}
+
+// NewReleaseAddressCmd returns a new instance which can be used to issue a
+// releaseaddress JSON-RPC command.
+func NewReleaseAddressCmd(address string) *ReleaseAddressCmd {
+	return &ReleaseAddressCmd{
+		Address: address,
+	}
+}
+
+// RescanBlockchainCmd defines the rescanblockchain JSON-RPC command.
+type RescanBlockchainCmd struct {
+	StartHeight *int32
+	StopHeight  *int32
+	Account     *string `jsonrpcdefault:"\"*\""`
+}
+
+// NewRescanBlockchainCmd returns a new instance which can be used to issue a
+// rescanblockchain JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewRescanBlockchainCmd(startHeight, stopHeight *int32,
+	account *string) *RescanBlockchainCmd {
+
+	return &RescanBlockchainCmd{
+		StartHeight: startHeight,
+		StopHeight:  stopHeight,
+		Account:     account,
+	}
+}
+
+// ReserveAddressCmd defines the reserveaddress JSON-RPC command.
+type ReserveAddressCmd struct {
+	Account *string `jsonrpcdefault:"\"default\""`
+	Label   *string `jsonrpcdefault:"\"\""`
+}
+
+// NewReserveAddressCmd returns a new instance which can be used to issue a
+// reserveaddress JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewReserveAddressCmd(account, label *string) *ReserveAddressCmd {
+	return &ReserveAddressCmd{
+		Account: account,
+		Label:   label,
+	}
+}
+
+// SetAccountFlagCmd defines the setaccountflag JSON-RPC command.
+type SetAccountFlagCmd struct {
+	Account string
+	Flag    string
+	Value   *bool `jsonrpcdefault:"true"`
+}
+
+// NewSetAccountFlagCmd returns a new instance which can be used to issue a
+// setaccountflag JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSetAccountFlagCmd(account, flag string, value *bool) *SetAccountFlagCmd {
+	return &SetAccountFlagCmd{
+		Account: account,
+		Flag:    flag,
+		Value:   value,
+	}
+}
+
+// SetAccountPassphraseCmd defines the setaccountpassphrase JSON-RPC command.
+type SetAccountPassphraseCmd struct {
+	Account    string
+	Passphrase string
+}
+
+// NewSetAccountPassphraseCmd returns a new instance which can be used to issue
+// a setaccountpassphrase JSON-RPC command.
+func NewSetAccountPassphraseCmd(account, passphrase string) *SetAccountPassphraseCmd {
+	return &SetAccountPassphraseCmd{
+		Account:    account,
+		Passphrase: passphrase,
+	}
+}
+
+// SetAccountMetadataCmd defines the setaccountmetadata JSON-RPC command.
+type SetAccountMetadataCmd struct {
+	Account     string
+	Description string
+	Tags        *[]string
+}
+
+// NewSetAccountMetadataCmd returns a new instance which can be used to issue a
+// setaccountmetadata JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSetAccountMetadataCmd(account, description string,
+	tags *[]string) *SetAccountMetadataCmd {
+
+	return &SetAccountMetadataCmd{
+		Account:     account,
+		Description: description,
+		Tags:        tags,
+	}
+}
+
+// SetDepositAlertCmd defines the setdepositalert JSON-RPC command.
+type SetDepositAlertCmd struct {
+	Account string
+	Amount  float64
+}
+
+// NewSetDepositAlertCmd returns a new instance which can be used to issue a
+// setdepositalert JSON-RPC command.
+func NewSetDepositAlertCmd(account string, amount float64) *SetDepositAlertCmd {
+	return &SetDepositAlertCmd{
+		Account: account,
+		Amount:  amount,
+	}
+}
+
+// SetLabelCmd defines the setlabel JSON-RPC command.
+type SetLabelCmd struct {
+	Address string
+	Label   string
+}
+
+// NewSetLabelCmd returns a new instance which can be used to issue a setlabel
+// JSON-RPC command.
+func NewSetLabelCmd(address, label string) *SetLabelCmd {
+	return &SetLabelCmd{
+		Address: address,
+		Label:   label,
+	}
+}
+
+// SetLookaheadCmd defines the setlookahead JSON-RPC command.
+type SetLookaheadCmd struct {
+	Window uint32
+}
+
+// NewSetLookaheadCmd returns a new instance which can be used to issue a
+// setlookahead JSON-RPC command.
+func NewSetLookaheadCmd(window uint32) *SetLookaheadCmd {
+	return &SetLookaheadCmd{
+		Window: window,
+	}
+}
+
+// SetSpendPolicyCmd defines the setspendpolicy JSON-RPC command.
+type SetSpendPolicyCmd struct {
+	Account     string
+	MaxPerTx    *float64
+	MaxPerDay   *float64
+	Whitelist   *[]string
+	AddressType *string `jsonrpcdefault:"\"legacy\""`
+}
+
+// NewSetSpendPolicyCmd returns a new instance which can be used to issue a
+// setspendpolicy JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSetSpendPolicyCmd(account string, maxPerTx, maxPerDay *float64,
+	whitelist *[]string, addressType *string) *SetSpendPolicyCmd {
+
+	return &SetSpendPolicyCmd{
+		Account:     account,
+		MaxPerTx:    maxPerTx,
+		MaxPerDay:   maxPerDay,
+		Whitelist:   whitelist,
+		AddressType: addressType,
+	}
+}
+
+// SignMessageBIP322Cmd defines the signmessagebip322 JSON-RPC command.
+type SignMessageBIP322Cmd struct {
+	Address string
+	Message string
+}
+
+// NewSignMessageBIP322Cmd returns a new instance which can be used to issue a
+// signmessagebip322 JSON-RPC command.
+func NewSignMessageBIP322Cmd(address, message string) *SignMessageBIP322Cmd {
+	return &SignMessageBIP322Cmd{
+		Address: address,
+		Message: message,
+	}
+}
+
+// SubscribeNotificationsCmd defines the subscribenotifications JSON-RPC
+// command.
+type SubscribeNotificationsCmd struct {
+	Notifications []string
+	Account       *string
+}
+
+// NewSubscribeNotificationsCmd returns a new instance which can be used to
+// issue a subscribenotifications JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSubscribeNotificationsCmd(notifications []string,
+	account *string) *SubscribeNotificationsCmd {
+
+	return &SubscribeNotificationsCmd{
+		Notifications: notifications,
+		Account:       account,
+	}
+}
+
+// SweepPrivKeyCmd defines the sweepprivkey JSON-RPC command.
+type SweepPrivKeyCmd struct {
+	PrivKey     string
+	Account     *string `jsonrpcdefault:"\"default\""`
+	StartHeight *int32  `jsonrpcdefault:"0"`
+}
+
+// NewSweepPrivKeyCmd returns a new instance which can be used to issue a
+// sweepprivkey JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSweepPrivKeyCmd(privKey string, account *string, startHeight *int32) *SweepPrivKeyCmd {
+	return &SweepPrivKeyCmd{
+		PrivKey:     privKey,
+		Account:     account,
+		StartHeight: startHeight,
+	}
+}
+
+// UnsubscribeNotificationsCmd defines the unsubscribenotifications JSON-RPC
+// command.
+type UnsubscribeNotificationsCmd struct {
+	Notifications []string
+	Account       *string
+}
+
+// NewUnsubscribeNotificationsCmd returns a new instance which can be used to
+// issue an unsubscribenotifications JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewUnsubscribeNotificationsCmd(notifications []string,
+	account *string) *UnsubscribeNotificationsCmd {
+
+	return &UnsubscribeNotificationsCmd{
+		Notifications: notifications,
+		Account:       account,
+	}
+}
+
+// VerifyMessageBIP322Cmd defines the verifymessagebip322 JSON-RPC command.
+type VerifyMessageBIP322Cmd struct {
+	Address   string
+	Signature string
+	Message   string
+}
+
+// NewVerifyMessageBIP322Cmd returns a new instance which can be used to issue
+// a verifymessagebip322 JSON-RPC command.
+func NewVerifyMessageBIP322Cmd(address, signature,
+	message string) *VerifyMessageBIP322Cmd {
+
+	return &VerifyMessageBIP322Cmd{
+		Address:   address,
+		Signature: signature,
+		Message:   message,
+	}
+}
+
+// WalletFsckCmd defines the walletfsck JSON-RPC command.
+type WalletFsckCmd struct {
+	Repair *bool `jsonrpcdefault:"false"`
+}
+
+// NewWalletFsckCmd returns a new instance which can be used to issue a
+// walletfsck JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewWalletFsckCmd(repair *bool) *WalletFsckCmd {
+	return &WalletFsckCmd{
+		Repair: repair,
+	}
+}
+
+// WalletExtendUnlockCmd defines the walletextendunlock JSON-RPC command.
+type WalletExtendUnlockCmd struct {
+	Timeout int64
+	Account *string
+}
+
+// NewWalletExtendUnlockCmd returns a new instance which can be used to issue a
+// walletextendunlock JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewWalletExtendUnlockCmd(timeout int64, account *string) *WalletExtendUnlockCmd {
+	return &WalletExtendUnlockCmd{
+		Timeout: timeout,
+		Account: account,
+	}
+}
+
+// WalletLockAllCmd defines the walletlockall JSON-RPC command.
+type WalletLockAllCmd struct{}
+
+// NewWalletLockAllCmd returns a new instance which can be used to issue a
+// walletlockall JSON-RPC command.
+func NewWalletLockAllCmd() *WalletLockAllCmd {
+	return &WalletLockAllCmd{}
+}
+
+// WalletUnlockedUntilCmd defines the walletunlockeduntil JSON-RPC command.
+type WalletUnlockedUntilCmd struct {
+	Account *string
+}
+
+// NewWalletUnlockedUntilCmd returns a new instance which can be used to issue a
+// walletunlockeduntil JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewWalletUnlockedUntilCmd(account *string) *WalletUnlockedUntilCmd {
+	return &WalletUnlockedUntilCmd{
+		Account: account,
+	}
+}
+
+func init() {
+	// The commands in this file are only usable with a wallet server.
+	flags := btcjson.UFWalletOnly
+
+	btcjson.MustRegisterCmd("analyzepsbt", (*AnalyzePsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("authorizekeyuse", (*AuthorizeKeyUseCmd)(nil), flags)
+	btcjson.MustRegisterCmd("canceldrafttx", (*CancelDraftTxCmd)(nil), flags)
+	btcjson.MustRegisterCmd("cancelrescan", (*CancelRescanCmd)(nil), flags)
+	btcjson.MustRegisterCmd("cancelspend", (*CancelSpendCmd)(nil), flags)
+	btcjson.MustRegisterCmd("committx", (*CommitTxCmd)(nil), flags)
+	btcjson.MustRegisterCmd("confirmspend", (*ConfirmSpendCmd)(nil), flags)
+	btcjson.MustRegisterCmd("createtx", (*CreateTxCmd)(nil), flags)
+	btcjson.MustRegisterCmd("decodepsbt", (*DecodePsbtCmd)(nil), flags)
+	btcjson.MustRegisterCmd("estimatesendfee", (*EstimateSendFeeCmd)(nil), flags)
+	btcjson.MustRegisterCmd("exportauditsnapshot", (*ExportAuditSnapshotCmd)(nil), flags)
+	btcjson.MustRegisterCmd("exporthistory", (*ExportHistoryCmd)(nil), flags)
+	btcjson.MustRegisterCmd("exportprivkeybip38", (*ExportPrivKeyBIP38Cmd)(nil), flags)
+	btcjson.MustRegisterCmd("getaccountingreport", (*GetAccountingReportCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getaccountmetadata", (*GetAccountMetadataCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getaccountxpub", (*GetAccountXpubCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getaddressesbylabel", (*GetAddressesByLabelCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getlookahead", (*GetLookaheadCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getpaymentqr", (*GetPaymentQRCmd)(nil), flags)
+	btcjson.MustRegisterCmd("getpaymenturi", (*GetPaymentURICmd)(nil), flags)
+	btcjson.MustRegisterCmd("getspendpolicy", (*GetSpendPolicyCmd)(nil), flags)
+	btcjson.MustRegisterCmd("importdescriptors", (*ImportDescriptorsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("importscript", (*ImportScriptCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listdescriptors", (*ListDescriptorsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listexpiredtransactions", (*ListExpiredTransactionsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listlabels", (*ListLabelsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listrescans", (*ListRescansCmd)(nil), flags)
+	btcjson.MustRegisterCmd("listwallets", (*ListWalletsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("mergeaccounts", (*MergeAccountsCmd)(nil), flags)
+	btcjson.MustRegisterCmd("notifytxconfirmations", (*NotifyTxConfirmationsCmd)(nil), flags|btcjson.UFWebsocketOnly)
+	btcjson.MustRegisterCmd("releaseaddress", (*ReleaseAddressCmd)(nil), flags)
+	btcjson.MustRegisterCmd("rescanblockchain", (*RescanBlockchainCmd)(nil), flags)
+	btcjson.MustRegisterCmd("reserveaddress", (*ReserveAddressCmd)(nil), flags)
+	btcjson.MustRegisterCmd("setaccountflag", (*SetAccountFlagCmd)(nil), flags)
+	btcjson.MustRegisterCmd("setaccountmetadata", (*SetAccountMetadataCmd)(nil), flags)
+	btcjson.MustRegisterCmd("setaccountpassphrase", (*SetAccountPassphraseCmd)(nil), flags)
+	btcjson.MustRegisterCmd("setdepositalert", (*SetDepositAlertCmd)(nil), flags)
+	btcjson.MustRegisterCmd("setlabel", (*SetLabelCmd)(nil), flags)
+	btcjson.MustRegisterCmd("setlookahead", (*SetLookaheadCmd)(nil), flags)
+	btcjson.MustRegisterCmd("setspendpolicy", (*SetSpendPolicyCmd)(nil), flags)
+	btcjson.MustRegisterCmd("signmessagebip322", (*SignMessageBIP322Cmd)(nil), flags)
+	btcjson.MustRegisterCmd("subscribenotifications", (*SubscribeNotificationsCmd)(nil), flags|btcjson.UFWebsocketOnly)
+	btcjson.MustRegisterCmd("sweepprivkey", (*SweepPrivKeyCmd)(nil), flags)
+	btcjson.MustRegisterCmd("unsubscribenotifications", (*UnsubscribeNotificationsCmd)(nil), flags|btcjson.UFWebsocketOnly)
+	btcjson.MustRegisterCmd("verifymessagebip322", (*VerifyMessageBIP322Cmd)(nil), flags)
+	btcjson.MustRegisterCmd("walletextendunlock", (*WalletExtendUnlockCmd)(nil), flags)
+	btcjson.MustRegisterCmd("walletfsck", (*WalletFsckCmd)(nil), flags)
+	btcjson.MustRegisterCmd("walletlockall", (*WalletLockAllCmd)(nil), flags)
+	btcjson.MustRegisterCmd("walletunlockeduntil", (*WalletUnlockedUntilCmd)(nil), flags)
+}
@@ -0,0 +1,11 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package walletjson defines the JSON-RPC commands and results of btcwallet
+// extension methods which are not provided by the btcjson package.
+//
+// Importing this package registers every command with btcjson so that
+// requests for these methods may be unmarshaled with btcjson.UnmarshalCmd and
+// help text may be generated with btcjson.GenerateHelp.
+package walletjson
@@ -0,0 +1,408 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// NOTE: This file is intended to house the RPC websocket notifications of
+// btcwallet extensions.
+
+package walletjson
+
+import "github.com/btcsuite/btcd/btcjson"
+
+const (
+	// TxConflictNtfnMethod is the method used to notify that an unmined
+	// transaction of the wallet was removed for being double spent.
+	TxConflictNtfnMethod = "btcwallet:txconflict"
+
+	// TxConfirmedNtfnMethod is the method used to notify that a
+	// transaction subscribed to with notifytxconfirmations reached the
+	// requested number of confirmations.
+	TxConfirmedNtfnMethod = "btcwallet:txconfirmed"
+
+	// TxReorgedNtfnMethod is the method used to notify that a transaction
+	// subscribed to with notifytxconfirmations was removed from the main
+	// chain by a reorganization.
+	TxReorgedNtfnMethod = "btcwallet:txreorged"
+
+	// NewTxNtfnMethod is the method used to notify that a transaction
+	// relevant to the wallet was first seen or mined.
+	NewTxNtfnMethod = "btcwallet:newtx"
+
+	// BlockConnectedNtfnMethod is the method used to notify that the
+	// wallet attached a block to its main chain.
+	BlockConnectedNtfnMethod = "btcwallet:blockconnected"
+
+	// BlockDisconnectedNtfnMethod is the method used to notify that the
+	// wallet detached a block from its main chain.
+	BlockDisconnectedNtfnMethod = "btcwallet:blockdisconnected"
+
+	// AccountBalancesNtfnMethod is the method used to notify the balance
+	// breakdown of every account after the balance of any account changes.
+	AccountBalancesNtfnMethod = "btcwallet:accountbalances"
+
+	// LockStateNtfnMethod is the method used to notify that the wallet or
+	// accounts protected by their own passphrase were locked or unlocked.
+	LockStateNtfnMethod = "btcwallet:lockstate"
+
+	// RescanProgressNtfnMethod is the method used to notify the progress
+	// and completion of rescan jobs.
+	RescanProgressNtfnMethod = "btcwallet:rescanprogress"
+
+	// ShutdownNtfnMethod is the method used to notify websocket clients
+	// that the server is shutting down and is about to disconnect them.
+	ShutdownNtfnMethod = "btcwallet:shutdown"
+
+	// TxStuckNtfnMethod is the method used to notify that a send of the
+	// wallet remains unmined longer than the unmined expiry.
+	TxStuckNtfnMethod = "btcwallet:txstuck"
+
+	// LargeDepositNtfnMethod is the method used to notify that a
+	// transaction paying an account at least its deposit alert amount was
+	// first seen.
+	LargeDepositNtfnMethod = "btcwallet:largedeposit"
+
+	// ChainHealthNtfnMethod is the method used to notify that the chain
+	// followed by the wallet was detected to be stalled or on a minority
+	// fork, or that such a problem was resolved.
+	ChainHealthNtfnMethod = "btcwallet:chainhealth"
+
+	// BackupFailedNtfnMethod is the method used to notify that a
+	// scheduled backup of the wallet failed.
+	BackupFailedNtfnMethod = "btcwallet:backupfailed"
+)
+
+// AccountBalance describes the confirmed and unconfirmed balances of an
+// account, valued in bitcoin.  The balance is also split as by the getbalances
+// command into the trusted balance, which is spendable, the unconfirmed
+// balance paid by other wallets, and the immature coinbase balance.  Fiat is
+// the value of the total balance, including immature coinbase outputs, in the
+// currency of the exchange rate of the notification, and is only set with a
+// rate.
+type AccountBalance struct {
+	Confirmed        float64  `json:"confirmed"`
+	Unconfirmed      float64  `json:"unconfirmed"`
+	Trusted          float64  `json:"trusted"`
+	UntrustedPending float64  `json:"untrusted_pending"`
+	Immature         float64  `json:"immature"`
+	Fiat             *float64 `json:"fiat,omitempty"`
+}
+
+// FiatRate describes the price of one bitcoin in a fiat currency used to value
+// balances, and the time the price was fetched as a unix timestamp.
+type FiatRate struct {
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate"`
+	RateTime int64   `json:"ratetime"`
+}
+
+// AccountBalancesNtfn defines the btcwallet:accountbalances JSON-RPC
+// notification.  Balances maps the name of every account to its balances.
+// FiatRate is the exchange rate the balances are valued with, and is only set
+// when the wallet has a price feed.
+type AccountBalancesNtfn struct {
+	Balances map[string]AccountBalance
+	FiatRate *FiatRate
+}
+
+// NewAccountBalancesNtfn returns a new instance which can be used to issue a
+// btcwallet:accountbalances JSON-RPC notification.
+func NewAccountBalancesNtfn(balances map[string]AccountBalance,
+	fiatRate *FiatRate) *AccountBalancesNtfn {
+
+	return &AccountBalancesNtfn{
+		Balances: balances,
+		FiatRate: fiatRate,
+	}
+}
+
+// BlockConnectedNtfn defines the btcwallet:blockconnected JSON-RPC
+// notification.  SyncedTo is the height of the block the wallet is synced to,
+// and Synced reports whether the wallet has caught up with its chain server,
+// after which its balances are authoritative.
+type BlockConnectedNtfn struct {
+	Hash     string
+	Height   int32
+	Time     int64
+	SyncedTo int32
+	Synced   bool
+}
+
+// NewBlockConnectedNtfn returns a new instance which can be used to issue a
+// btcwallet:blockconnected JSON-RPC notification.
+func NewBlockConnectedNtfn(hash string, height int32, time int64,
+	syncedTo int32, synced bool) *BlockConnectedNtfn {
+
+	return &BlockConnectedNtfn{
+		Hash:     hash,
+		Height:   height,
+		Time:     time,
+		SyncedTo: syncedTo,
+		Synced:   synced,
+	}
+}
+
+// BlockDisconnectedNtfn defines the btcwallet:blockdisconnected JSON-RPC
+// notification.  SyncedTo and Synced have the same meaning as for
+// BlockConnectedNtfn.
+type BlockDisconnectedNtfn struct {
+	Hash     string
+	SyncedTo int32
+	Synced   bool
+}
+
+// NewBlockDisconnectedNtfn returns a new instance which can be used to issue a
+// btcwallet:blockdisconnected JSON-RPC notification.
+func NewBlockDisconnectedNtfn(hash string, syncedTo int32,
+	synced bool) *BlockDisconnectedNtfn {
+
+	return &BlockDisconnectedNtfn{
+		Hash:     hash,
+		SyncedTo: syncedTo,
+		Synced:   synced,
+	}
+}
+
+// LockStateNtfn defines the btcwallet:lockstate JSON-RPC notification.  Wallet
+// reports whether the lock state of the wallet changed, and Accounts names the
+// accounts protected by their own passphrase whose lock state changed.  Locking
+// or unlocking the wallet together with every account is notified once.
+type LockStateNtfn struct {
+	Locked   bool
+	Wallet   bool
+	Accounts []string
+}
+
+// NewLockStateNtfn returns a new instance which can be used to issue a
+// btcwallet:lockstate JSON-RPC notification.
+func NewLockStateNtfn(locked, wallet bool, accounts []string) *LockStateNtfn {
+	return &LockStateNtfn{
+		Locked:   locked,
+		Wallet:   wallet,
+		Accounts: accounts,
+	}
+}
+
+// RescanProgressNtfn defines the btcwallet:rescanprogress JSON-RPC
+// notification.  Height is the height of the last block rescanned by the job
+// with the ID, and Percent its progress towards the best block when the rescan
+// started.  Done is set once the job completed, and Error describes why a job
+// failed or was cancelled.
+type RescanProgressNtfn struct {
+	ID      uint64
+	Height  int32
+	Percent float64
+	Done    bool
+	Error   *string
+}
+
+// NewRescanProgressNtfn returns a new instance which can be used to issue a
+// btcwallet:rescanprogress JSON-RPC notification.
+func NewRescanProgressNtfn(id uint64, height int32, percent float64,
+	done bool, err *string) *RescanProgressNtfn {
+
+	return &RescanProgressNtfn{
+		ID:      id,
+		Height:  height,
+		Percent: percent,
+		Done:    done,
+		Error:   err,
+	}
+}
+
+// ShutdownNtfn defines the btcwallet:shutdown JSON-RPC notification.
+type ShutdownNtfn struct{}
+
+// NewShutdownNtfn returns a new instance which can be used to issue a
+// btcwallet:shutdown JSON-RPC notification.
+func NewShutdownNtfn() *ShutdownNtfn {
+	return &ShutdownNtfn{}
+}
+
+// NewTxNtfn defines the btcwallet:newtx JSON-RPC notification.  A notification
+// is sent for each output of a transaction received by the wallet, and for each
+// output paying another wallet when the wallet sends the transaction.  Change
+// outputs are not notified.
+type NewTxNtfn struct {
+	Account       string
+	Address       string
+	Amount        float64 // In BTC, negative for sends.
+	TxID          string
+	Confirmations int32
+}
+
+// NewNewTxNtfn returns a new instance which can be used to issue a
+// btcwallet:newtx JSON-RPC notification.
+func NewNewTxNtfn(account, address string, amount float64, txID string,
+	confirmations int32) *NewTxNtfn {
+
+	return &NewTxNtfn{
+		Account:       account,
+		Address:       address,
+		Amount:        amount,
+		TxID:          txID,
+		Confirmations: confirmations,
+	}
+}
+
+// TxConflictNtfn defines the btcwallet:txconflict JSON-RPC notification.
+type TxConflictNtfn struct {
+	TxID            string
+	ConflictingTxID string
+}
+
+// NewTxConflictNtfn returns a new instance which can be used to issue a
+// btcwallet:txconflict JSON-RPC notification.
+func NewTxConflictNtfn(txID, conflictingTxID string) *TxConflictNtfn {
+	return &TxConflictNtfn{
+		TxID:            txID,
+		ConflictingTxID: conflictingTxID,
+	}
+}
+
+// TxStuckNtfn defines the btcwallet:txstuck JSON-RPC notification.  Fee is the
+// fee paid by the transaction in BTC, or 0 when it spends outputs of another
+// wallet, and FeeRate and BumpFeeRate are the fee rate it pays and the fee rate
+// suggested for a replacement, in BTC per kilobyte of virtual size.
+type TxStuckNtfn struct {
+	TxID         string
+	TimeReceived int64
+	Fee          float64
+	FeeRate      float64
+	BumpFeeRate  float64
+}
+
+// NewTxStuckNtfn returns a new instance which can be used to issue a
+// btcwallet:txstuck JSON-RPC notification.
+func NewTxStuckNtfn(txID string, timeReceived int64, fee, feeRate,
+	bumpFeeRate float64) *TxStuckNtfn {
+
+	return &TxStuckNtfn{
+		TxID:         txID,
+		TimeReceived: timeReceived,
+		Fee:          fee,
+		FeeRate:      feeRate,
+		BumpFeeRate:  bumpFeeRate,
+	}
+}
+
+// LargeDepositNtfn defines the btcwallet:largedeposit JSON-RPC notification.
+// Amount is the total paid to the account by the transaction and Threshold the
+// deposit alert amount of the account, both in BTC.  Mined is set when the
+// transaction was first seen in a block rather than in the mempool.
+type LargeDepositNtfn struct {
+	TxID      string
+	Account   string
+	Amount    float64
+	Threshold float64
+	Mined     bool
+}
+
+// NewLargeDepositNtfn returns a new instance which can be used to issue a
+// btcwallet:largedeposit JSON-RPC notification.
+func NewLargeDepositNtfn(txID, account string, amount, threshold float64,
+	mined bool) *LargeDepositNtfn {
+
+	return &LargeDepositNtfn{
+		TxID:      txID,
+		Account:   account,
+		Amount:    amount,
+		Threshold: threshold,
+		Mined:     mined,
+	}
+}
+
+// ChainHealthNtfn defines the btcwallet:chainhealth JSON-RPC notification.
+// LastBlockSeen is the Unix time the wallet last connected a block.  Sends are
+// risky while either problem is reported.
+type ChainHealthNtfn struct {
+	Stalled       bool
+	MinorityFork  bool
+	LastBlockSeen int64
+}
+
+// NewChainHealthNtfn returns a new instance which can be used to issue a
+// btcwallet:chainhealth JSON-RPC notification.
+func NewChainHealthNtfn(stalled, minorityFork bool,
+	lastBlockSeen int64) *ChainHealthNtfn {
+
+	return &ChainHealthNtfn{
+		Stalled:       stalled,
+		MinorityFork:  minorityFork,
+		LastBlockSeen: lastBlockSeen,
+	}
+}
+
+// BackupFailedNtfn defines the btcwallet:backupfailed JSON-RPC notification.
+// Time is the Unix time the backup was attempted, and Error describes why it
+// failed.
+type BackupFailedNtfn struct {
+	Time  int64
+	Error string
+}
+
+// NewBackupFailedNtfn returns a new instance which can be used to issue a
+// btcwallet:backupfailed JSON-RPC notification.
+func NewBackupFailedNtfn(timestamp int64, err string) *BackupFailedNtfn {
+	return &BackupFailedNtfn{
+		Time:  timestamp,
+		Error: err,
+	}
+}
+
+// TxConfirmedNtfn defines the btcwallet:txconfirmed JSON-RPC notification.
+type TxConfirmedNtfn struct {
+	TxID          string
+	Confirmations int32
+	BlockHash     string
+	BlockHeight   int32
+}
+
+// NewTxConfirmedNtfn returns a new instance which can be used to issue a
+// btcwallet:txconfirmed JSON-RPC notification.
+func NewTxConfirmedNtfn(txID string, confirmations int32, blockHash string,
+	blockHeight int32) *TxConfirmedNtfn {
+
+	return &TxConfirmedNtfn{
+		TxID:          txID,
+		Confirmations: confirmations,
+		BlockHash:     blockHash,
+		BlockHeight:   blockHeight,
+	}
+}
+
+// TxReorgedNtfn defines the btcwallet:txreorged JSON-RPC notification.
+type TxReorgedNtfn struct {
+	TxID      string
+	BlockHash string
+}
+
+// NewTxReorgedNtfn returns a new instance which can be used to issue a
+// btcwallet:txreorged JSON-RPC notification.
+func NewTxReorgedNtfn(txID, blockHash string) *TxReorgedNtfn {
+	return &TxReorgedNtfn{
+		TxID:      txID,
+		BlockHash: blockHash,
+	}
+}
+
+func init() {
+	// The notifications in this file are only usable with a wallet server
+	// via websockets.
+	flags := btcjson.UFWalletOnly | btcjson.UFWebsocketOnly |
+		btcjson.UFNotification
+
+	btcjson.MustRegisterCmd(TxConflictNtfnMethod, (*TxConflictNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(TxConfirmedNtfnMethod, (*TxConfirmedNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(TxReorgedNtfnMethod, (*TxReorgedNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(NewTxNtfnMethod, (*NewTxNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(BlockConnectedNtfnMethod, (*BlockConnectedNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(BlockDisconnectedNtfnMethod, (*BlockDisconnectedNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(AccountBalancesNtfnMethod, (*AccountBalancesNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(LockStateNtfnMethod, (*LockStateNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(RescanProgressNtfnMethod, (*RescanProgressNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(ShutdownNtfnMethod, (*ShutdownNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(TxStuckNtfnMethod, (*TxStuckNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(LargeDepositNtfnMethod, (*LargeDepositNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(ChainHealthNtfnMethod, (*ChainHealthNtfn)(nil), flags)
+	btcjson.MustRegisterCmd(BackupFailedNtfnMethod, (*BackupFailedNtfn)(nil), flags)
+}
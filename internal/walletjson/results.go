@@ -0,0 +1,461 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package walletjson
+
+import "github.com/btcsuite/btcd/btcjson"
+
+// AccountingReportResult models the data from the getaccountingreport command.
+type AccountingReportResult struct {
+	StartDate string                    `json:"startdate"`
+	EndDate   string                    `json:"enddate"`
+	Period    string                    `json:"period"`
+	Accounts  []AccountingReportAccount `json:"accounts"`
+}
+
+// AccountingReportAccount models the totals of an account in the
+// getaccountingreport command's result.
+type AccountingReportAccount struct {
+	Account      string                   `json:"account"`
+	Received     float64                  `json:"received"`
+	Sent         float64                  `json:"sent"`
+	Fees         float64                  `json:"fees"`
+	Transactions int                      `json:"transactions"`
+	Periods      []AccountingReportPeriod `json:"periods"`
+}
+
+// AccountingReportPeriod models the totals of an account over one period in
+// the getaccountingreport command's result.
+type AccountingReportPeriod struct {
+	StartDate    string  `json:"startdate"`
+	Received     float64 `json:"received"`
+	Sent         float64 `json:"sent"`
+	Fees         float64 `json:"fees"`
+	Transactions int     `json:"transactions"`
+}
+
+// AccountMetadataResult models the data from the getaccountmetadata command.
+type AccountMetadataResult struct {
+	Account      string   `json:"account"`
+	Description  string   `json:"description"`
+	Created      int64    `json:"created,omitempty"`
+	Tags         []string `json:"tags"`
+	AvoidReuse   bool     `json:"avoid_reuse"`
+	DepositAlert float64  `json:"deposit_alert"`
+}
+
+// AnalyzePsbtResult models the data from the analyzepsbt command.
+type AnalyzePsbtResult struct {
+	Inputs []AnalyzePsbtInput `json:"inputs"`
+	Fee    *float64           `json:"fee,omitempty"`
+	Next   string             `json:"next"`
+}
+
+// AnalyzePsbtInput describes an input of a PSBT analyzed by the analyzepsbt
+// command.
+type AnalyzePsbtInput struct {
+	HasUtxo bool   `json:"has_utxo"`
+	IsFinal bool   `json:"is_final"`
+	Next    string `json:"next"`
+	IsMine  bool   `json:"ismine"`
+	CanSign bool   `json:"cansign"`
+}
+
+// AuditSnapshot is the document signed by the exportauditsnapshot command.
+type AuditSnapshot struct {
+	Version     int32                 `json:"version"`
+	Created     int64                 `json:"created"`
+	BlockHash   string                `json:"blockhash"`
+	BlockHeight int32                 `json:"blockheight"`
+	Addresses   []AuditSnapshotAddr   `json:"addresses"`
+	Unspent     []AuditSnapshotOutput `json:"unspent"`
+	Balances    []AuditSnapshotAcct   `json:"balances"`
+	Total       float64               `json:"total"`
+}
+
+// AuditSnapshotAddr describes an address of the wallet in an audit snapshot.
+type AuditSnapshotAddr struct {
+	Address  string `json:"address"`
+	Account  string `json:"account"`
+	KeyScope string `json:"keyscope"`
+}
+
+// AuditSnapshotOutput describes an unspent output of the wallet in an audit
+// snapshot.
+type AuditSnapshotOutput struct {
+	TxID         string  `json:"txid"`
+	Vout         uint32  `json:"vout"`
+	ScriptPubKey string  `json:"scriptPubKey"`
+	Amount       float64 `json:"amount"`
+	Height       int32   `json:"height"`
+}
+
+// AuditSnapshotAcct describes the balance of an account in an audit snapshot.
+type AuditSnapshotAcct struct {
+	Account  string  `json:"account"`
+	KeyScope string  `json:"keyscope"`
+	Balance  float64 `json:"balance"`
+}
+
+// CreateTxResult models the data from the createtx command.
+type CreateTxResult struct {
+	ID      string           `json:"id"`
+	Hex     string           `json:"hex"`
+	Inputs  []CreateTxInput  `json:"inputs"`
+	Outputs []CreateTxOutput `json:"outputs"`
+	Fee     float64          `json:"fee"`
+	Expires int64            `json:"expires"`
+}
+
+// CreateTxInput describes an output of the wallet spent by a draft transaction
+// of the createtx command.
+type CreateTxInput struct {
+	TxID    string  `json:"txid"`
+	Vout    uint32  `json:"vout"`
+	Address string  `json:"address,omitempty"`
+	Amount  float64 `json:"amount"`
+}
+
+// CreateTxOutput describes an output of a draft transaction of the createtx
+// command.
+type CreateTxOutput struct {
+	Address string  `json:"address,omitempty"`
+	Amount  float64 `json:"amount"`
+	Change  bool    `json:"change"`
+}
+
+// DecodePsbtResult models the data from the decodepsbt command.
+type DecodePsbtResult struct {
+	Tx      btcjson.TxRawDecodeResult `json:"tx"`
+	Inputs  []DecodePsbtInput         `json:"inputs"`
+	Outputs []DecodePsbtOutput        `json:"outputs"`
+	Fee     *float64                  `json:"fee,omitempty"`
+}
+
+// DecodePsbtInput describes an input of a PSBT decoded by the decodepsbt
+// command.
+type DecodePsbtInput struct {
+	NonWitnessUtxo     *btcjson.TxRawDecodeResult `json:"non_witness_utxo,omitempty"`
+	WitnessUtxo        *DecodePsbtUtxo            `json:"witness_utxo,omitempty"`
+	PartialSignatures  map[string]string          `json:"partial_signatures,omitempty"`
+	Sighash            string                     `json:"sighash,omitempty"`
+	RedeemScript       *DecodePsbtScript          `json:"redeem_script,omitempty"`
+	WitnessScript      *DecodePsbtScript          `json:"witness_script,omitempty"`
+	Bip32Derivs        []DecodePsbtBip32Deriv     `json:"bip32_derivs,omitempty"`
+	FinalScriptSig     *btcjson.ScriptSig         `json:"final_scriptSig,omitempty"`
+	FinalScriptWitness []string                   `json:"final_scriptwitness,omitempty"`
+	IsMine             bool                       `json:"ismine"`
+	CanSign            bool                       `json:"cansign"`
+}
+
+// DecodePsbtOutput describes an output of a PSBT decoded by the decodepsbt
+// command.
+type DecodePsbtOutput struct {
+	RedeemScript  *DecodePsbtScript      `json:"redeem_script,omitempty"`
+	WitnessScript *DecodePsbtScript      `json:"witness_script,omitempty"`
+	Bip32Derivs   []DecodePsbtBip32Deriv `json:"bip32_derivs,omitempty"`
+}
+
+// DecodePsbtUtxo describes the output spent by a witness input of a PSBT.
+type DecodePsbtUtxo struct {
+	Amount       float64                    `json:"amount"`
+	ScriptPubKey btcjson.ScriptPubKeyResult `json:"scriptPubKey"`
+}
+
+// DecodePsbtScript describes a redeem or witness script of a PSBT.
+type DecodePsbtScript struct {
+	Asm  string `json:"asm"`
+	Hex  string `json:"hex"`
+	Type string `json:"type"`
+}
+
+// DecodePsbtBip32Deriv describes the BIP 32 derivation of a public key of a
+// PSBT.
+type DecodePsbtBip32Deriv struct {
+	PubKey            string `json:"pubkey"`
+	MasterFingerprint string `json:"master_fingerprint"`
+	Path              string `json:"path"`
+}
+
+// DumpWalletResult models the data from the dumpwallet command.
+type DumpWalletResult struct {
+	Filename string `json:"filename"`
+}
+
+// EstimateSendFeeResult models the data from the estimatesendfee command.
+type EstimateSendFeeResult struct {
+	Fee          float64 `json:"fee"`
+	VSize        int     `json:"vsize"`
+	Inputs       int     `json:"inputs"`
+	Change       bool    `json:"change"`
+	ChangeAmount float64 `json:"changeamount"`
+}
+
+// ExportAuditSnapshotResult models the data from the exportauditsnapshot
+// command.
+type ExportAuditSnapshotResult struct {
+	Snapshot  string `json:"snapshot"`
+	Address   string `json:"address"`
+	Signature string `json:"signature"`
+}
+
+// ExportHistoryResult models the data from the exporthistory command.
+type ExportHistoryResult struct {
+	Filename string `json:"filename"`
+	Entries  int    `json:"entries"`
+}
+
+// GetAccountXpubResult models the data from the getaccountxpub command.
+type GetAccountXpubResult struct {
+	Account           string `json:"account"`
+	Xpub              string `json:"xpub"`
+	MasterFingerprint string `json:"masterfingerprint,omitempty"`
+	Path              string `json:"path"`
+}
+
+// GetAddressInfoResult models the data from the getaddressinfo command.
+type GetAddressInfoResult struct {
+	Address             string `json:"address"`
+	ScriptPubKey        string `json:"scriptPubKey"`
+	IsMine              bool   `json:"ismine"`
+	IsWatchOnly         bool   `json:"iswatchonly"`
+	IsScript            bool   `json:"isscript"`
+	IsWitness           bool   `json:"iswitness"`
+	IsChange            bool   `json:"ischange"`
+	PubKey              string `json:"pubkey,omitempty"`
+	IsCompressed        *bool  `json:"iscompressed,omitempty"`
+	Account             string `json:"account,omitempty"`
+	Label               string `json:"label"`
+	Timestamp           int64  `json:"timestamp,omitempty"`
+	HDKeyPath           string `json:"hdkeypath,omitempty"`
+	HDMasterFingerprint string `json:"hdmasterfingerprint,omitempty"`
+}
+
+// GetBalancesResult models the data from the getbalances command.  It extends
+// the reference result with the balances of each account, and with their fiat
+// values when the wallet has a price feed.
+type GetBalancesResult struct {
+	Mine     btcjson.BalanceDetailsResult            `json:"mine"`
+	Accounts map[string]btcjson.BalanceDetailsResult `json:"accounts"`
+	Fiat     *FiatBalancesResult                     `json:"fiat,omitempty"`
+}
+
+// FiatBalancesResult models the fiat values of the total balances of the
+// wallet and of each account in the getbalances command's result.
+type FiatBalancesResult struct {
+	Currency string             `json:"currency"`
+	Rate     float64            `json:"rate"`
+	RateTime int64              `json:"ratetime"`
+	Mine     float64            `json:"mine"`
+	Accounts map[string]float64 `json:"accounts"`
+}
+
+// GetPaymentQRResult models the data from the getpaymentqr command.
+type GetPaymentQRResult struct {
+	Payload string `json:"payload"`
+	Address string `json:"address"`
+	PNG     string `json:"png,omitempty"`
+}
+
+// GetPaymentURIResult models the data from the getpaymenturi command.
+type GetPaymentURIResult struct {
+	URI     string `json:"uri"`
+	Address string `json:"address"`
+}
+
+// GetTransactionResult models the data from the gettransaction command.  It
+// extends the reference result with the comments of sends.
+type GetTransactionResult struct {
+	Amount          float64                               `json:"amount"`
+	Fee             float64                               `json:"fee,omitempty"`
+	Confirmations   int64                                 `json:"confirmations"`
+	BlockHash       string                                `json:"blockhash"`
+	BlockIndex      int64                                 `json:"blockindex"`
+	BlockTime       int64                                 `json:"blocktime"`
+	TxID            string                                `json:"txid"`
+	WalletConflicts []string                              `json:"walletconflicts"`
+	Time            int64                                 `json:"time"`
+	TimeReceived    int64                                 `json:"timereceived"`
+	Comment         string                                `json:"comment,omitempty"`
+	CommentTo       string                                `json:"to,omitempty"`
+	Details         []btcjson.GetTransactionDetailsResult `json:"details"`
+	Hex             string                                `json:"hex"`
+}
+
+// GetWalletInfoResult models the data from the getwalletinfo command.
+type GetWalletInfoResult struct {
+	Balance            float64 `json:"balance"`
+	UnconfirmedBalance float64 `json:"unconfirmed_balance"`
+	ImmatureBalance    float64 `json:"immature_balance"`
+	Unlocked           bool    `json:"unlocked"`
+	ChainStalled       bool    `json:"chain_stalled"`
+	MinorityFork       bool    `json:"minority_fork"`
+	LastBlockSeen      int64   `json:"last_block_seen"`
+	SendsRisky         bool    `json:"sends_risky"`
+}
+
+// ImportDescriptorsResult models the outcome of each request of the
+// importdescriptors command.
+type ImportDescriptorsResult struct {
+	Success  bool              `json:"success"`
+	Warnings []string          `json:"warnings,omitempty"`
+	Error    *btcjson.RPCError `json:"error,omitempty"`
+}
+
+// ListDescriptorsResult models the data from the listdescriptors command.
+type ListDescriptorsResult struct {
+	Descriptors []ListDescriptorsDescriptor `json:"descriptors"`
+}
+
+// ListDescriptorsDescriptor models each descriptor of a
+// ListDescriptorsResult.
+type ListDescriptorsDescriptor struct {
+	Descriptor string   `json:"desc"`
+	Timestamp  int64    `json:"timestamp"`
+	Active     bool     `json:"active"`
+	Internal   *bool    `json:"internal,omitempty"`
+	Range      []uint32 `json:"range,omitempty"`
+	Next       *uint32  `json:"next,omitempty"`
+}
+
+// ListExpiredTransactionsResult models the data of each transaction returned
+// by the listexpiredtransactions command.
+type ListExpiredTransactionsResult struct {
+	TxID         string  `json:"txid"`
+	TimeReceived int64   `json:"timereceived"`
+	Fee          float64 `json:"fee"`
+}
+
+// ListRescansResult models each rescan job returned by the listrescans
+// command.
+type ListRescansResult struct {
+	ID          uint64  `json:"id"`
+	State       string  `json:"state"`
+	Addresses   int     `json:"addresses"`
+	StartHeight int32   `json:"startheight"`
+	Height      int32   `json:"height,omitempty"`
+	Percent     float64 `json:"percent,omitempty"`
+}
+
+// ListAccountsVerboseResult models the data of each account returned by the
+// listaccounts command when the verbose flag is set.
+type ListAccountsVerboseResult struct {
+	Account     string   `json:"account"`
+	Balance     float64  `json:"balance"`
+	Description string   `json:"description"`
+	Created     int64    `json:"created,omitempty"`
+	Tags        []string `json:"tags"`
+	AvoidReuse  bool     `json:"avoid_reuse"`
+}
+
+// ListSinceBlockResult models the data from the listsinceblock command.
+type ListSinceBlockResult struct {
+	Transactions []ListTransactionsResult `json:"transactions"`
+	LastBlock    string                   `json:"lastblock"`
+}
+
+// ListTransactionsResult models the data from the listtransactions command.
+// It extends the reference result with the comment of sends naming the person
+// or organization paid.
+type ListTransactionsResult struct {
+	Abandoned         bool     `json:"abandoned"`
+	Account           string   `json:"account"`
+	Address           string   `json:"address,omitempty"`
+	Amount            float64  `json:"amount"`
+	BIP125Replaceable string   `json:"bip125-replaceable,omitempty"`
+	BlockHash         string   `json:"blockhash,omitempty"`
+	BlockHeight       *int32   `json:"blockheight,omitempty"`
+	BlockIndex        *int64   `json:"blockindex,omitempty"`
+	BlockTime         int64    `json:"blocktime,omitempty"`
+	Category          string   `json:"category"`
+	Confirmations     int64    `json:"confirmations"`
+	Fee               *float64 `json:"fee,omitempty"`
+	Generated         bool     `json:"generated,omitempty"`
+	InvolvesWatchOnly bool     `json:"involveswatchonly,omitempty"`
+	Label             *string  `json:"label,omitempty"`
+	Time              int64    `json:"time"`
+	TimeReceived      int64    `json:"timereceived"`
+	Trusted           bool     `json:"trusted"`
+	TxID              string   `json:"txid"`
+	Vout              uint32   `json:"vout"`
+	WalletConflicts   []string `json:"walletconflicts"`
+	Comment           string   `json:"comment,omitempty"`
+	CommentTo         string   `json:"to,omitempty"`
+	OtherAccount      string   `json:"otheraccount,omitempty"`
+}
+
+// ListUnspentResult models a successful response from the listunspent
+// request.  It extends the reference result with the reused flag.
+type ListUnspentResult struct {
+	TxID          string  `json:"txid"`
+	Vout          uint32  `json:"vout"`
+	Address       string  `json:"address"`
+	Account       string  `json:"account"`
+	ScriptPubKey  string  `json:"scriptPubKey"`
+	RedeemScript  string  `json:"redeemScript,omitempty"`
+	Amount        float64 `json:"amount"`
+	Confirmations int64   `json:"confirmations"`
+	Spendable     bool    `json:"spendable"`
+	Safe          bool    `json:"safe"`
+	Reused        bool    `json:"reused"`
+}
+
+// RescanBlockchainResult models the data from the rescanblockchain command.
+type RescanBlockchainResult struct {
+	StartHeight int32 `json:"start_height"`
+	StopHeight  int32 `json:"stop_height"`
+}
+
+// SetAccountFlagResult models the data from the setaccountflag command.
+type SetAccountFlagResult struct {
+	FlagName  string `json:"flag_name"`
+	FlagState bool   `json:"flag_state"`
+}
+
+// AddressPurposeResult models the purpose of each address returned by the
+// getaddressesbylabel command.
+type AddressPurposeResult struct {
+	Purpose string `json:"purpose"`
+}
+
+// SpendPolicyResult models the data from the getspendpolicy command.
+type SpendPolicyResult struct {
+	Account   string   `json:"account"`
+	MaxPerTx  float64  `json:"maxpertx"`
+	MaxPerDay float64  `json:"maxperday"`
+	Whitelist []string `json:"whitelist"`
+	Spent24h  float64  `json:"spent24h"`
+}
+
+// SweepPrivKeyResult models the data from the sweepprivkey command.
+type SweepPrivKeyResult struct {
+	TxID    string  `json:"txid"`
+	Address string  `json:"address"`
+	Amount  float64 `json:"amount"`
+	Fee     float64 `json:"fee"`
+	Inputs  int     `json:"inputs"`
+}
+
+// WalletFsckInconsistency models each inconsistency returned by the walletfsck
+// command.
+type WalletFsckInconsistency struct {
+	Bucket      string `json:"bucket"`
+	Key         string `json:"key"`
+	Description string `json:"description"`
+	Repairable  bool   `json:"repairable"`
+}
+
+// WalletFsckResult models the data from the walletfsck command.
+type WalletFsckResult struct {
+	Inconsistencies []WalletFsckInconsistency `json:"inconsistencies"`
+	Repaired        bool                      `json:"repaired"`
+}
+
+// WalletUnlockedUntilResult models the data from the walletunlockeduntil
+// command.
+type WalletUnlockedUntilResult struct {
+	Unlocked      bool   `json:"unlocked"`
+	UnlockedUntil int64  `json:"unlocked_until"`
+	UnlockLevel   string `json:"unlock_level,omitempty"`
+}
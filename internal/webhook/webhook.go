@@ -0,0 +1,109 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package webhook posts JSON objects of the wallet to HTTP webhooks.
+//
+// Each post is signed with an HMAC-SHA256 of the body keyed by a secret shared
+// with the receiver of the webhook, which is sent in the SignatureHeader
+// header as "sha256=" followed by the hex encoded MAC.  Receivers must verify
+// the signature with Verify, or an equivalent constant time comparison, before
+// trusting the body.
+//
+// Requests are made with the HTTP client passed by the caller, so that they
+// are routed through the proxies configured for the application rather than
+// connecting directly.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+const (
+	// Timeout is the suggested time a single post to a webhook may take.
+	Timeout = 10 * time.Second
+
+	// SignatureHeader is the HTTP header holding the signature of the
+	// body of a post.
+	SignatureHeader = "X-Btcwallet-Signature"
+
+	// signaturePrefix prefixes the hex encoded MAC of a signature, naming
+	// its hash function.
+	signaturePrefix = "sha256="
+)
+
+// Webhook is a wallet.DepositWebhook posting to an HTTP endpoint.
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// Enforce Webhook implements the wallet.DepositWebhook interface.
+var _ wallet.DepositWebhook = (*Webhook)(nil)
+
+// New returns a webhook posting to the URL with the client, signing each post
+// with the secret.
+func New(client *http.Client, url string, secret []byte) *Webhook {
+	return &Webhook{
+		url:    url,
+		secret: append([]byte(nil), secret...),
+		client: client,
+	}
+}
+
+// Post posts a JSON body to the webhook along with its signature, failing for
+// responses without a 2xx status code.
+//
+// This function is part of the wallet.DepositWebhook interface implementation.
+func (h *Webhook) Post(body []byte) error {
+	req, err := http.NewRequest("POST", h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(h.secret, body))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %s",
+			resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature of a body keyed by the secret, as sent in the
+// SignatureHeader header.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns whether a signature sent in the SignatureHeader header is the
+// signature of the body keyed by the secret.
+func Verify(secret, body []byte, signature string) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	got, err := hex.DecodeString(signature[len(signaturePrefix):])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestWebhook ensures that posts are signed with the secret of the webhook,
+// and that the signatures are verified only with the same secret and body.
+func TestWebhook(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("webhook secret")
+	var (
+		body      []byte
+		signature string
+		status    = http.StatusOK
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter,
+		r *http.Request) {
+
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		signature = r.Header.Get(SignatureHeader)
+		rw.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	hook := New(&http.Client{Timeout: Timeout}, srv.URL, secret)
+	posted := []byte(`{"txid":"00","amount":2}`)
+	require.NoError(t, hook.Post(posted))
+	require.Equal(t, posted, body)
+	require.True(t, Verify(secret, body, signature))
+	require.False(t, Verify([]byte("other secret"), body, signature))
+	require.False(t, Verify(secret, []byte(`{"txid":"00","amount":3}`),
+		signature))
+	require.False(t, Verify(secret, body, signature[len("sha256="):]))
+
+	status = http.StatusServiceUnavailable
+	require.Error(t, hook.Post(posted))
+}
@@ -5,14 +5,18 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btclog"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/auditlog"
 	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
 	"github.com/btcsuite/btcwallet/rpc/rpcserver"
 	"github.com/btcsuite/btcwallet/wallet"
@@ -26,9 +30,95 @@ import (
 type logWriter struct{}
 
 func (logWriter) Write(p []byte) (n int, err error) {
+	n = len(p)
+	if jsonLogs {
+		p = formatJSONRecord(p)
+	}
 	_, _ = os.Stdout.Write(p)
 	_, _ = logRotatorPipe.Write(p)
-	return len(p), nil
+	return n, nil
+}
+
+// jsonLogs is set when log records are written as JSON objects rather than as
+// text, for ingestion into log pipelines.  It must be set before the loggers
+// are used.
+var jsonLogs bool
+
+// backendTimeLayout is the layout of the local time which begins each record
+// written by the backend logger.
+const backendTimeLayout = "2006-01-02 15:04:05.000"
+
+// jsonLevels maps the levels of records written by the backend logger to the
+// names of the levels as they are configured.
+var jsonLevels = map[string]string{
+	"TRC": "trace",
+	"DBG": "debug",
+	"INF": "info",
+	"WRN": "warn",
+	"ERR": "error",
+	"CRT": "critical",
+}
+
+// jsonRecord is a log record written as a JSON object.
+type jsonRecord struct {
+	Time      string `json:"time,omitempty"`
+	Level     string `json:"level,omitempty"`
+	Subsystem string `json:"subsystem,omitempty"`
+	Message   string `json:"message"`
+}
+
+// formatJSONRecord converts a record written by the backend logger, in the
+// form "<time> [<level>] <subsystem>: <message>", to a line holding a JSON
+// object.  Records which can not be parsed are written with the whole record
+// as their message.
+func formatJSONRecord(p []byte) []byte {
+	line := strings.TrimSuffix(string(p), "\n")
+	rec, ok := parseBackendRecord(line)
+	if !ok {
+		rec = jsonRecord{Message: line}
+	}
+	b, err := json.Marshal(&rec)
+	if err != nil {
+		return p
+	}
+	return append(b, '\n')
+}
+
+// parseBackendRecord parses a record written by the backend logger.
+func parseBackendRecord(line string) (jsonRecord, bool) {
+	var rec jsonRecord
+	if len(line) < len(backendTimeLayout) {
+		return rec, false
+	}
+	t, err := time.ParseInLocation(backendTimeLayout,
+		line[:len(backendTimeLayout)], time.Local)
+	if err != nil {
+		return rec, false
+	}
+	rest := line[len(backendTimeLayout):]
+	if !strings.HasPrefix(rest, " [") {
+		return rec, false
+	}
+	rest = rest[len(" ["):]
+	end := strings.Index(rest, "] ")
+	if end == -1 {
+		return rec, false
+	}
+	level, ok := jsonLevels[rest[:end]]
+	if !ok {
+		return rec, false
+	}
+	rest = rest[end+len("] "):]
+	end = strings.Index(rest, ": ")
+	if end == -1 {
+		return rec, false
+	}
+
+	rec.Time = t.Format("2006-01-02T15:04:05.000Z07:00")
+	rec.Level = level
+	rec.Subsystem = rest[:end]
+	rec.Message = rest[end+len(": "):]
+	return rec, true
 }
 
 // Loggers per subsystem.  A single backend logger is created and all subsytem
@@ -55,6 +145,7 @@ var (
 
 	log          = backendLog.Logger("BTCW")
 	walletLog    = backendLog.Logger("WLLT")
+	auditLog     = backendLog.Logger("AUDT")
 	txmgrLog     = backendLog.Logger("TMGR")
 	chainLog     = backendLog.Logger("CHNS")
 	grpcLog      = backendLog.Logger("GRPC")
@@ -65,6 +156,8 @@ var (
 // Initialize package-global logger variables.
 func init() {
 	wallet.UseLogger(walletLog)
+	wallet.UseAuditLogger(auditLog)
+	auditlog.UseLogger(auditLog)
 	wtxmgr.UseLogger(txmgrLog)
 	chain.UseLogger(chainLog)
 	rpcclient.UseLogger(chainLog)
@@ -77,6 +170,7 @@ func init() {
 var subsystemLoggers = map[string]btclog.Logger{
 	"BTCW": log,
 	"WLLT": walletLog,
+	"AUDT": auditLog,
 	"TMGR": txmgrLog,
 	"CHNS": chainLog,
 	"GRPC": grpcLog,
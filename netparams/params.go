@@ -15,22 +15,28 @@ type Params struct {
 	*chaincfg.Params
 	RPCClientPort string
 	RPCServerPort string
+
+	// BitcoindRPCPort is the default port of the bitcoind RPC server, or
+	// empty when bitcoind does not support the network.
+	BitcoindRPCPort string
 }
 
 // MainNetParams contains parameters specific running btcwallet and
 // btcd on the main network (wire.MainNet).
 var MainNetParams = Params{
-	Params:        &chaincfg.MainNetParams,
-	RPCClientPort: "8334",
-	RPCServerPort: "8332",
+	Params:          &chaincfg.MainNetParams,
+	RPCClientPort:   "8334",
+	RPCServerPort:   "8332",
+	BitcoindRPCPort: "8332",
 }
 
 // TestNet3Params contains parameters specific running btcwallet and
 // btcd on the test network (version 3) (wire.TestNet3).
 var TestNet3Params = Params{
-	Params:        &chaincfg.TestNet3Params,
-	RPCClientPort: "18334",
-	RPCServerPort: "18332",
+	Params:          &chaincfg.TestNet3Params,
+	RPCClientPort:   "18334",
+	RPCServerPort:   "18332",
+	BitcoindRPCPort: "18332",
 }
 
 // SimNetParams contains parameters specific to the simulation test network
@@ -41,12 +47,36 @@ var SimNetParams = Params{
 	RPCServerPort: "18554",
 }
 
+// RegressionNetParams contains parameters specific to the regression test
+// network (wire.TestNet), a private chain on which blocks are mined on demand.
+var RegressionNetParams = Params{
+	Params:          &chaincfg.RegressionNetParams,
+	RPCClientPort:   "18334",
+	RPCServerPort:   "18332",
+	BitcoindRPCPort: "18443",
+}
+
 // SigNetParams contains parameters specific to the signet test network
 // (wire.SigNet).
 var SigNetParams = Params{
-	Params:        &chaincfg.SigNetParams,
-	RPCClientPort: "38334",
-	RPCServerPort: "38332",
+	Params:          &chaincfg.SigNetParams,
+	RPCClientPort:   "38334",
+	RPCServerPort:   "38332",
+	BitcoindRPCPort: "38332",
+}
+
+// ByName returns the parameters of the network named by the name of its chain
+// parameters, such as "testnet3".
+func ByName(name string) (*Params, bool) {
+	for _, params := range []*Params{
+		&MainNetParams, &TestNet3Params, &SimNetParams,
+		&RegressionNetParams, &SigNetParams,
+	} {
+		if params.Name == name {
+			return params, true
+		}
+	}
+	return nil, false
 }
 
 // SigNetWire is a helper function that either returns the given chain
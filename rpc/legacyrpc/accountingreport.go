@@ -0,0 +1,104 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// reportDateFormat is the format of the dates of accounting reports.
+const reportDateFormat = "2006-01-02"
+
+// getAccountingReport handles a getaccountingreport request by returning the
+// totals received, sent and paid in fees by each account, or by one account,
+// between two dates, overall and for each period of the report.
+func getAccountingReport(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetAccountingReportCmd)
+
+	start, err := time.Parse(reportDateFormat, cmd.StartDate)
+	if err != nil {
+		return nil, InvalidParameterError{
+			fmt.Errorf("invalid start date %q", cmd.StartDate),
+		}
+	}
+	end, err := time.Parse(reportDateFormat, cmd.EndDate)
+	if err != nil {
+		return nil, InvalidParameterError{
+			fmt.Errorf("invalid end date %q", cmd.EndDate),
+		}
+	}
+	if end.Before(start) {
+		return nil, InvalidParameterError{
+			errors.New("end date is before start date"),
+		}
+	}
+
+	var period wallet.ReportPeriod
+	switch *cmd.Period {
+	case wallet.ReportDaily.String():
+		period = wallet.ReportDaily
+	case wallet.ReportMonthly.String():
+		period = wallet.ReportMonthly
+	default:
+		return nil, InvalidParameterError{
+			fmt.Errorf("unknown period %q, must be %q or %q",
+				*cmd.Period, wallet.ReportDaily,
+				wallet.ReportMonthly),
+		}
+	}
+
+	account := *cmd.Account
+	if account != "*" {
+		_, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The end date is included in the report.
+	reports, err := w.AccountingReport(start, end.AddDate(0, 0, 1), period)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &walletjson.AccountingReportResult{
+		StartDate: cmd.StartDate,
+		EndDate:   cmd.EndDate,
+		Period:    period.String(),
+		Accounts:  []walletjson.AccountingReportAccount{},
+	}
+	for _, report := range reports {
+		if account != "*" && report.Account != account {
+			continue
+		}
+		acct := walletjson.AccountingReportAccount{
+			Account:      report.Account,
+			Received:     report.Totals.Received.ToBTC(),
+			Sent:         report.Totals.Sent.ToBTC(),
+			Fees:         report.Totals.Fees.ToBTC(),
+			Transactions: report.Totals.Transactions,
+			Periods: make([]walletjson.AccountingReportPeriod, 0,
+				len(report.Periods)),
+		}
+		for _, p := range report.Periods {
+			acct.Periods = append(acct.Periods,
+				walletjson.AccountingReportPeriod{
+					StartDate:    p.Start.Format(reportDateFormat),
+					Received:     p.Received.ToBTC(),
+					Sent:         p.Sent.ToBTC(),
+					Fees:         p.Fees.ToBTC(),
+					Transactions: p.Transactions,
+				})
+		}
+		result.Accounts = append(result.Accounts, acct)
+	}
+	return result, nil
+}
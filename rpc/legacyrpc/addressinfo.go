@@ -0,0 +1,112 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// getAddressInfo handles a getaddressinfo request by describing an address
+// and, for addresses of the wallet, the key behind it.  The HD key path of
+// derived keys is reported so that the key may be reproduced from the seed.
+func getAddressInfo(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.GetAddressInfoCmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+	class := txscript.GetScriptClass(pkScript)
+
+	result := &walletjson.GetAddressInfoResult{
+		Address:      addr.EncodeAddress(),
+		ScriptPubKey: hex.EncodeToString(pkScript),
+		IsScript: class == txscript.ScriptHashTy ||
+			class == txscript.WitnessV0ScriptHashTy,
+		IsWitness: txscript.IsWitnessProgram(pkScript),
+	}
+
+	// The account of the address is looked up separately, as addresses of
+	// merged accounts belong to the account they were merged into.
+	var account uint32
+	ma, err := w.AddressInfo(addr)
+	if err == nil {
+		account, err = w.AccountOfAddress(addr)
+	}
+	if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.IsMine = true
+	result.IsChange = ma.Internal()
+	result.IsWatchOnly = w.Manager.WatchOnly()
+
+	acctName, err := w.AccountName(waddrmgr.KeyScopeBIP0044, account)
+	if err != nil {
+		return nil, &ErrAccountNameNotFound
+	}
+	result.Account = acctName
+	result.Label, err = w.AddressLabel(addr)
+	if err != nil {
+		return nil, err
+	}
+	birthday, err := w.AddressBirthday(addr)
+	if err != nil {
+		return nil, err
+	}
+	if birthday != nil && !birthday.Timestamp.IsZero() {
+		result.Timestamp = birthday.Timestamp.Unix()
+	}
+
+	pka, ok := ma.(waddrmgr.ManagedPubKeyAddress)
+	if !ok {
+		return result, nil
+	}
+	compressed := pka.Compressed()
+	result.PubKey = pka.ExportPubKey()
+	result.IsCompressed = &compressed
+
+	scope, path, derived := pka.DerivationInfo()
+	if !derived {
+		return result, nil
+	}
+	props, err := w.AccountProperties(scope, path.InternalAccount)
+	if err != nil {
+		return nil, err
+	}
+	result.IsWatchOnly = props.IsWatchOnly
+	result.HDKeyPath = hdKeyPath(scope, path)
+	if path.MasterKeyFingerprint != 0 {
+		result.HDMasterFingerprint = fingerprintHex(
+			path.MasterKeyFingerprint,
+		)
+	}
+	return result, nil
+}
+
+// hdKeyPath formats the BIP0032 path of a key derived from the HD root of the
+// wallet.
+func hdKeyPath(scope waddrmgr.KeyScope, path waddrmgr.DerivationPath) string {
+	return bip32PathString([]uint32{
+		scope.Purpose + hdkeychain.HardenedKeyStart,
+		scope.Coin + hdkeychain.HardenedKeyStart,
+		path.Account | hdkeychain.HardenedKeyStart,
+		path.Branch,
+		path.Index,
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/internal/auditlog"
+)
+
+// auditedMethods is the set of methods whose requests are recorded to the
+// audit log.  These methods reveal or import private keys, sign with them,
+// unlock the wallet or change its passphrases, or spend outputs.
+var auditedMethods = map[string]struct{}{
+	"authorizekeyuse":              {},
+	"committx":                     {},
+	"confirmspend":                 {},
+	"dumpprivkey":                  {},
+	"dumpwallet":                   {},
+	"encryptwallet":                {},
+	"exportprivkeybip38":           {},
+	"importdescriptors":            {},
+	"importprivkey":                {},
+	"importwallet":                 {},
+	"sendfrom":                     {},
+	"sendmany":                     {},
+	"sendrawtransaction":           {},
+	"sendtoaddress":                {},
+	"setaccountpassphrase":         {},
+	"signrawtransaction":           {},
+	"signrawtransactionwithwallet": {},
+	"sweepprivkey":                 {},
+	"walletpassphrase":             {},
+	"walletpassphrasechange":       {},
+}
+
+// audit records the outcome of a request of an audited method from the client
+// at remoteAddr to the audit log, if enabled.  Only the method and the code of
+// the error it failed with are recorded, never its parameters or result.
+func (s *Server) audit(remoteAddr string, tier authTier, walletName,
+	method string, jsonErr *btcjson.RPCError) {
+
+	if s.auditLog == nil {
+		return
+	}
+	if _, ok := auditedMethods[method]; !ok {
+		return
+	}
+	r := &auditlog.Record{
+		Client:  remoteAddr,
+		Tier:    tier.String(),
+		Wallet:  walletName,
+		Method:  method,
+		Outcome: auditlog.Success,
+	}
+	if jsonErr != nil {
+		r.Outcome = auditlog.Failure
+		r.Code = int(jsonErr.Code)
+	}
+	if err := s.auditLog.Append(r); err != nil {
+		log.Errorf("Unable to record %s request to the audit log: %v",
+			method, err)
+	}
+}
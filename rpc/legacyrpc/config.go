@@ -4,11 +4,76 @@
 
 package legacyrpc
 
+import (
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/auditlog"
+)
+
 // Options contains the required options for running the legacy RPC server.
 type Options struct {
 	Username string
 	Password string
 
+	// PublicUsername and PublicPassword are the credentials of the public
+	// tier, which may only call a curated set of read-only methods at a
+	// rate of at most PublicRateLimit requests of each method per minute.
+	// The public tier is disabled when PublicUsername is empty.
+	PublicUsername  string
+	PublicPassword  string
+	PublicRateLimit uint32
+
+	// LimitedUsername and LimitedPassword are the credentials of the
+	// limited tier, which may only call read-only methods.  The limited
+	// tier is disabled when LimitedUsername is empty.
+	LimitedUsername string
+	LimitedPassword string
+
 	MaxPOSTClients      int64
 	MaxWebsocketClients int64
+
+	// MaxConcurrentHandlers is the maximum number of client requests
+	// handled concurrently.  DefaultMaxHandlers is used when it is zero.
+	MaxConcurrentHandlers int
+
+	// ClientRateLimit is the maximum number of requests per minute from
+	// each client host, including requests passed through to the chain
+	// server.  Clients are not rate limited when it is zero.
+	ClientRateLimit uint32
+
+	// AmountUnit is the denomination of amounts passed to send requests
+	// which do not specify a unit.
+	AmountUnit btcutil.AmountUnit
+
+	// DisplayUnit is the denomination of the amounts of results and
+	// notifications for clients which do not request a unit.  Amounts are
+	// left as numbers valued in bitcoin when it is btcutil.AmountBTC.
+	DisplayUnit btcutil.AmountUnit
+
+	// LegacyBalanceNtfns additionally notifies websocket clients of the
+	// balances of each account with a pair of accountbalance
+	// notifications, for clients which predate the consolidated
+	// btcwallet:accountbalances notification.
+	LegacyBalanceNtfns bool
+
+	// DisabledMethods are refused for every client, including methods
+	// which would be passed through to the chain server.  When
+	// AllowedMethods is not empty, every method it does not list is
+	// refused as well.
+	DisabledMethods []string
+	AllowedMethods  []string
+
+	// AuditLog records requests of methods which reveal private keys,
+	// unlock the wallet or spend outputs, and is closed when the server is
+	// stopped.  Requests are not recorded when it is nil.
+	AuditLog *auditlog.Log
+
+	// SetLogLevels sets the logging levels of the process from a level
+	// specification, either a single level for every subsystem or comma
+	// separated subsystem=level pairs.  The debuglevel method is
+	// disabled when it is nil.
+	SetLogLevels func(levelSpec string) error
+
+	// LogSubsystems are the logging subsystems listed by the debuglevel
+	// method.
+	LogSubsystems []string
 }
@@ -0,0 +1,151 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// confSubscription is the subscription of a websocket client to the
+// confirmations of a transaction.
+type confSubscription struct {
+	depth int32
+
+	// block is the block the transaction was last known to be mined in,
+	// or nil when it was unmined.
+	block *wtxmgr.Block
+}
+
+// update records the block a subscribed transaction is mined in, which is nil
+// when the transaction is unmined or no longer exists, given the height the
+// wallet is synced to.  It returns the notifications for the subscriber, and
+// whether the transaction reached the subscribed depth, completing the
+// subscription.
+func (sub *confSubscription) update(txHash *chainhash.Hash,
+	block *wtxmgr.Block, syncHeight int32) ([]interface{}, bool) {
+
+	var ntfns []interface{}
+	if sub.block != nil && (block == nil || block.Hash != sub.block.Hash) {
+		ntfns = append(ntfns, walletjson.NewTxReorgedNtfn(
+			txHash.String(), sub.block.Hash.String(),
+		))
+	}
+	sub.block = nil
+	if block == nil {
+		return ntfns, false
+	}
+	b := *block
+	sub.block = &b
+
+	confs := confirms(block.Height, syncHeight)
+	if confs < sub.depth {
+		return ntfns, false
+	}
+	ntfns = append(ntfns, walletjson.NewTxConfirmedNtfn(
+		txHash.String(), confs, block.Hash.String(), block.Height,
+	))
+	return ntfns, true
+}
+
+// notifyTxConfirmations handles a notifytxconfirmations request of a
+// websocket client by subscribing the client to the confirmations of a
+// transaction.  The client is notified once the transaction reaches the
+// requested depth, and each time the transaction is removed from the main
+// chain before then.
+func (s *Server) notifyTxConfirmations(wsc *websocketClient,
+	req *btcjson.Request) (interface{}, error) {
+
+	s.handlerMu.Lock()
+	w := s.wallet
+	s.handlerMu.Unlock()
+	if w == nil {
+		return nil, &ErrUnloadedWallet
+	}
+
+	icmd, err := btcjson.UnmarshalCmd(req)
+	if err != nil {
+		return nil, btcjson.ErrRPCInvalidRequest
+	}
+	cmd := icmd.(*walletjson.NotifyTxConfirmationsCmd)
+
+	txHash, err := chainhash.NewHashFromStr(cmd.TxID)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDecodeHexString,
+			Message: "Transaction hash string decode failed: " + err.Error(),
+		}
+	}
+	if *cmd.Depth < 1 {
+		return nil, InvalidParameterError{
+			errors.New("depth must be positive"),
+		}
+	}
+
+	wsc.confSubsMtx.Lock()
+	wsc.confSubs[*txHash] = &confSubscription{depth: *cmd.Depth}
+	wsc.confSubsMtx.Unlock()
+
+	return nil, nil
+}
+
+// removeConfSubscriptions removes every confirmation subscription of a
+// websocket client, which is done once the client disconnects.
+func (c *websocketClient) removeConfSubscriptions() {
+	c.confSubsMtx.Lock()
+	for txHash := range c.confSubs {
+		delete(c.confSubs, txHash)
+	}
+	c.confSubsMtx.Unlock()
+}
+
+// checkConfirmations notifies a websocket client of the subscribed
+// transactions which reached their requested depth or were removed from the
+// main chain.  Subscriptions are removed once their confirmation is delivered,
+// and all subscriptions of the client are removed when it has disconnected.
+func (s *Server) checkConfirmations(wsc *websocketClient, w *wallet.Wallet) {
+	syncHeight := w.Manager.SyncedTo().Height
+
+	wsc.confSubsMtx.Lock()
+	defer wsc.confSubsMtx.Unlock()
+
+	for txHash, sub := range wsc.confSubs {
+		txHash := txHash
+		details, err := wallet.UnstableAPI(w).TxDetails(&txHash)
+		if err != nil {
+			log.Errorf("Cannot look up transaction %v: %v", txHash,
+				err)
+			continue
+		}
+		var block *wtxmgr.Block
+		if details != nil && details.Block.Height != -1 {
+			block = &details.Block.Block
+		}
+
+		ntfns, done := sub.update(&txHash, block, syncHeight)
+		for _, ntfn := range ntfns {
+			b, err := btcjson.MarshalCmd(btcjson.RpcVersion1, nil, ntfn)
+			if err != nil {
+				log.Errorf("Unable to marshal confirmation "+
+					"notification: %v", err)
+				continue
+			}
+			if err := wsc.send(b); err != nil {
+				for txHash := range wsc.confSubs {
+					delete(wsc.confSubs, txHash)
+				}
+				return
+			}
+		}
+		if done {
+			delete(wsc.confSubs, txHash)
+		}
+	}
+}
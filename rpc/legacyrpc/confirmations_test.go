@@ -0,0 +1,97 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestConfSubscriptionUpdate ensures that subscribers are notified when a
+// transaction reaches the subscribed depth, and each time the transaction is
+// removed from the main chain before then.
+func TestConfSubscriptionUpdate(t *testing.T) {
+	txHash := chainhash.Hash{1}
+	txID := txHash.String()
+	blockA := &wtxmgr.Block{Hash: chainhash.Hash{2}, Height: 100}
+	blockB := &wtxmgr.Block{Hash: chainhash.Hash{3}, Height: 101}
+
+	sub := &confSubscription{depth: 3}
+
+	steps := []struct {
+		name       string
+		block      *wtxmgr.Block
+		syncHeight int32
+		ntfns      []interface{}
+		done       bool
+	}{
+		{
+			name:       "unmined",
+			syncHeight: 99,
+		},
+		{
+			name:       "mined below depth",
+			block:      blockA,
+			syncHeight: 101,
+		},
+		{
+			name:       "reorged out",
+			syncHeight: 99,
+			ntfns: []interface{}{
+				walletjson.NewTxReorgedNtfn(
+					txID, blockA.Hash.String(),
+				),
+			},
+		},
+		{
+			name:       "mined again",
+			block:      blockB,
+			syncHeight: 102,
+		},
+		{
+			name:       "reorged into another block at depth",
+			block:      blockA,
+			syncHeight: 102,
+			ntfns: []interface{}{
+				walletjson.NewTxReorgedNtfn(
+					txID, blockB.Hash.String(),
+				),
+				walletjson.NewTxConfirmedNtfn(
+					txID, 3, blockA.Hash.String(), 100,
+				),
+			},
+			done: true,
+		},
+	}
+
+	for _, step := range steps {
+		ntfns, done := sub.update(&txHash, step.block, step.syncHeight)
+		if !reflect.DeepEqual(ntfns, step.ntfns) {
+			t.Fatalf("%s: expected notifications %v, got %v",
+				step.name, step.ntfns, ntfns)
+		}
+		if done != step.done {
+			t.Fatalf("%s: expected done %v, got %v", step.name,
+				step.done, done)
+		}
+	}
+}
+
+// TestRemoveConfSubscriptions ensures that the confirmation subscriptions of
+// a websocket client are removed when it disconnects.
+func TestRemoveConfSubscriptions(t *testing.T) {
+	wsc := newWebsocketClient(nil, true, fullTier, "", nil)
+	wsc.confSubs[chainhash.Hash{1}] = &confSubscription{depth: 1}
+	wsc.confSubs[chainhash.Hash{2}] = &confSubscription{depth: 6}
+
+	wsc.removeConfSubscriptions()
+	if len(wsc.confSubs) != 0 {
+		t.Fatalf("expected no subscriptions, got %d", len(wsc.confSubs))
+	}
+}
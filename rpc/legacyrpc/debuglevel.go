@@ -0,0 +1,35 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// debugLevel handles a debuglevel request by setting the logging levels of
+// the process, or with the special level specification "show", by returning
+// the supported logging subsystems.  The levels apply to the whole process
+// rather than to a single wallet.
+func debugLevel(s *Server, icmd interface{}, _ string) (interface{}, error) {
+	cmd := icmd.(*btcjson.DebugLevelCmd)
+
+	if s.setLogLevels == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Log levels can not be set by this server",
+		}
+	}
+
+	if cmd.LevelSpec == "show" {
+		return fmt.Sprintf("Supported subsystems %v", s.logSubsystems),
+			nil
+	}
+	if err := s.setLogLevels(cmd.LevelSpec); err != nil {
+		return nil, InvalidParameterError{err}
+	}
+	return "Done.", nil
+}
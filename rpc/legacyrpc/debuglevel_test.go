@@ -0,0 +1,52 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// TestDebugLevel ensures that debuglevel sets the log levels with the level
+// specification, lists the subsystems for "show", and reports invalid
+// specifications as invalid parameters.
+func TestDebugLevel(t *testing.T) {
+	var set []string
+	s := &Server{
+		setLogLevels: func(levelSpec string) error {
+			if levelSpec == "bogus" {
+				return errors.New("invalid level")
+			}
+			set = append(set, levelSpec)
+			return nil
+		},
+		logSubsystems: []string{"RPCS", "WLLT"},
+	}
+
+	res, err := debugLevel(s, &btcjson.DebugLevelCmd{
+		LevelSpec: "WLLT=debug",
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "Done." || len(set) != 1 || set[0] != "WLLT=debug" {
+		t.Fatalf("got result %v, set levels %v", res, set)
+	}
+
+	res, err = debugLevel(s, &btcjson.DebugLevelCmd{LevelSpec: "show"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "Supported subsystems [RPCS WLLT]" || len(set) != 1 {
+		t.Fatalf("got result %v, set levels %v", res, set)
+	}
+
+	_, err = debugLevel(s, &btcjson.DebugLevelCmd{LevelSpec: "bogus"}, "")
+	if _, ok := err.(InvalidParameterError); !ok {
+		t.Fatalf("invalid level specification: got error %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/internal/descriptor"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+const (
+	// defaultDescriptorRangeEnd is the end of the range ranged descriptors
+	// are imported at when the request does not specify one.
+	defaultDescriptorRangeEnd = 999
+
+	// maxDescriptorRangeSize is the largest number of indexes a ranged
+	// descriptor may be imported at by a single request.
+	maxDescriptorRangeSize = 1000000
+)
+
+// importDescriptors handles an importdescriptors request by importing the
+// scripts of each descriptor.  Requests are imported independently, and the
+// outcome of each is reported in the result at its position.
+func importDescriptors(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ImportDescriptorsCmd)
+
+	results := make([]walletjson.ImportDescriptorsResult, len(cmd.Requests))
+	for i := range cmd.Requests {
+		warnings, err := importDescriptor(w, &cmd.Requests[i])
+		if err != nil {
+			results[i].Error = jsonError(err)
+			continue
+		}
+		results[i].Success = true
+		results[i].Warnings = warnings
+	}
+	return results, nil
+}
+
+// importDescriptor imports the scripts of the descriptor of a single
+// importdescriptors request, returning warnings about the import.
+func importDescriptor(w *wallet.Wallet,
+	req *walletjson.ImportDescriptorsRequest) ([]string, error) {
+
+	// Unlike other commands accepting descriptors, a checksum is required
+	// so that mistyped descriptors are not imported.
+	if !strings.Contains(req.Desc, "#") {
+		return nil, InvalidParameterError{
+			errors.New("missing descriptor checksum"),
+		}
+	}
+	desc, err := descriptor.Parse(req.Desc, w.ChainParams())
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: err.Error(),
+		}
+	}
+
+	var start, end uint32
+	switch {
+	case desc.IsRange() && req.Range != nil:
+		start, end = req.Range[0], req.Range[1]
+	case desc.IsRange():
+		end = defaultDescriptorRangeEnd
+	case req.Range != nil:
+		return nil, InvalidParameterError{
+			errors.New("range may not be specified for a " +
+				"descriptor which is not ranged"),
+		}
+	}
+	switch {
+	case start > end:
+		return nil, InvalidParameterError{
+			errors.New("range end is before its start"),
+		}
+	case end-start >= maxDescriptorRangeSize:
+		return nil, InvalidParameterError{
+			errors.New("range is too large"),
+		}
+	}
+	if desc.IsRange() && req.Label != "" {
+		return nil, InvalidParameterError{
+			errors.New("ranged descriptors may not have a label"),
+		}
+	}
+
+	// Scripts imported with a timestamp are rescanned for from the block
+	// of the timestamp, while those imported "now" are only watched from
+	// the block the wallet is synced to.
+	var bs *waddrmgr.BlockStamp
+	switch {
+	case req.Timestamp == nil:
+		return nil, InvalidParameterError{
+			errors.New("missing timestamp"),
+		}
+	case *req.Timestamp != walletjson.DescriptorTimestampNow:
+		bs, err = w.BirthdayBlockAtTime(time.Unix(int64(*req.Timestamp), 0))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	addrs, err := w.ImportDescriptor(desc, start, end, bs, bs != nil)
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		return nil, &ErrWalletUnlockNeeded
+	}
+	if err != nil {
+		return nil, err
+	}
+	if req.Label != "" {
+		for _, addr := range addrs {
+			if err := w.SetAddressLabel(addr, req.Label); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var warnings []string
+	switch desc.Type {
+	case descriptor.SHMulti, descriptor.WSHMulti:
+		warnings = append(warnings, "Multisig scripts are imported "+
+			"watch-only, and are spent by signing PSBTs")
+	default:
+		if !desc.Keys[0].IsPrivate() {
+			warnings = append(warnings, "Private keys are missing, "+
+				"outputs will be considered watch-only")
+		}
+	}
+	return warnings, nil
+}
+
+// listDescriptors handles a listdescriptors request by describing the scripts
+// held by the wallet.  The range of the ranged descriptors of accounts spans
+// the addresses handed out and the lookahead window past them.
+func listDescriptors(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	descs, err := w.Descriptors()
+	if err != nil {
+		return nil, err
+	}
+	window := w.LookaheadWindow()
+
+	result := &walletjson.ListDescriptorsResult{
+		Descriptors: make([]walletjson.ListDescriptorsDescriptor, 0,
+			len(descs)),
+	}
+	for _, wd := range descs {
+		item := walletjson.ListDescriptorsDescriptor{
+			Descriptor: wd.Descriptor.String(),
+			Active:     wd.Descriptor.IsRange(),
+		}
+
+		// Scripts without a known birthday may have transactions from
+		// the genesis block on, which is described by a timestamp of 0.
+		if !wd.Birthday.IsZero() {
+			item.Timestamp = wd.Birthday.Unix()
+		}
+		if wd.Descriptor.IsRange() {
+			internal, next := wd.Internal, wd.NextIndex
+			end := next + window
+			if end > 0 {
+				end--
+			}
+			item.Internal = &internal
+			item.Range = []uint32{0, end}
+			item.Next = &next
+		}
+		result.Descriptors = append(result.Descriptors, item)
+	}
+	return result, nil
+}
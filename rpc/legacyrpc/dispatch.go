@@ -0,0 +1,245 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+)
+
+const (
+	// DefaultMaxHandlers is the number of requests handled concurrently
+	// when the server options do not set a limit.
+	DefaultMaxHandlers = 16
+
+	// queuedPerHandler is the number of requests which may wait for a
+	// handler for each request handled concurrently.  Requests beyond the
+	// limit are refused with ErrServerBusy.
+	queuedPerHandler = 16
+)
+
+// errPoolFull is returned by submit when the queue of the pool is full.
+var errPoolFull = errors.New("handler pool queue is full")
+
+// handlerPool runs request handlers on a bounded number of goroutines.  Jobs
+// are started in the order they are submitted, and jobs submitted with the
+// same key are run one at a time in that order.  The number of jobs waiting to
+// run is bounded as well.
+type handlerPool struct {
+	mu sync.Mutex
+
+	maxWorkers int
+	workers    int
+
+	// queued is the number of submitted jobs which have not started, which
+	// may not exceed maxQueued.
+	queued    int
+	maxQueued int
+
+	// pending holds the jobs waiting for a worker.
+	pending []func()
+
+	// keyed holds the jobs of each key which are waiting to be run after
+	// the running job of the key.  A key is present while any of its jobs
+	// is pending or running.
+	keyed map[string][]func()
+}
+
+func newHandlerPool(maxWorkers int) *handlerPool {
+	if maxWorkers <= 0 {
+		maxWorkers = DefaultMaxHandlers
+	}
+	return &handlerPool{
+		maxWorkers: maxWorkers,
+		maxQueued:  maxWorkers * queuedPerHandler,
+		keyed:      make(map[string][]func()),
+	}
+}
+
+// submit schedules the job to run, or returns errPoolFull without scheduling
+// it when too many jobs are waiting to run.  Jobs with an empty key are not
+// ordered with respect to any other job.  This function does not block.
+func (p *handlerPool) submit(key string, job func()) error {
+	p.mu.Lock()
+	if p.queued == p.maxQueued {
+		p.mu.Unlock()
+		return errPoolFull
+	}
+	p.queued++
+	p.mu.Unlock()
+
+	userJob := job
+	job = func() {
+		p.mu.Lock()
+		p.queued--
+		p.mu.Unlock()
+		userJob()
+	}
+
+	if key == "" {
+		p.schedule(job)
+		return nil
+	}
+
+	p.mu.Lock()
+	queue, running := p.keyed[key]
+	p.keyed[key] = append(queue, job)
+	p.mu.Unlock()
+
+	if !running {
+		p.schedule(func() { p.runKeyed(key) })
+	}
+	return nil
+}
+
+// schedule starts the job on a new worker, or queues it for the next free
+// worker if every worker is busy.
+func (p *handlerPool) schedule(job func()) {
+	p.mu.Lock()
+	if p.workers == p.maxWorkers {
+		p.pending = append(p.pending, job)
+		p.mu.Unlock()
+		return
+	}
+	p.workers++
+	p.mu.Unlock()
+
+	go p.work(job)
+}
+
+// work runs the job and then every pending job until none remain.
+func (p *handlerPool) work(job func()) {
+	for {
+		job()
+
+		p.mu.Lock()
+		if len(p.pending) == 0 {
+			p.workers--
+			p.mu.Unlock()
+			return
+		}
+		job = p.pending[0]
+		p.pending[0] = nil
+		p.pending = p.pending[1:]
+		p.mu.Unlock()
+	}
+}
+
+// runKeyed runs the jobs of a key in order until none remain.
+func (p *handlerPool) runKeyed(key string) {
+	for {
+		p.mu.Lock()
+		queue := p.keyed[key]
+		if len(queue) == 0 {
+			delete(p.keyed, key)
+			p.mu.Unlock()
+			return
+		}
+		job := queue[0]
+		queue[0] = nil
+		p.keyed[key] = queue[1:]
+		p.mu.Unlock()
+
+		job()
+	}
+}
+
+// handlerResult is the result of a handled request.
+type handlerResult struct {
+	result  interface{}
+	jsonErr *btcjson.RPCError
+}
+
+// dispatch submits the handler of a request to the server's handler pool and
+// returns a channel which receives the result once it has run.  Requests which
+// modify the state of an account are handled in the order they are dispatched
+// with respect to the other requests of the account.  Requests locking or
+// unlocking the wallet bypass the pool, so that a wallet can always be locked
+// while the pool is busy.  Requests are refused with ErrServerBusy when the
+// queue of the pool is full.
+func (s *Server) dispatch(req *btcjson.Request, walletName string,
+	f lazyHandler) <-chan handlerResult {
+
+	c := make(chan handlerResult, 1)
+	job := func() {
+		result, jsonErr := f()
+		c <- handlerResult{result: result, jsonErr: jsonErr}
+	}
+	switch req.Method {
+	case "walletlock", "walletpassphrase", "walletextendunlock":
+		go job()
+		return c
+	}
+	err := s.handlers.submit(requestKey(req, walletName, s.amountUnit), job)
+	if err != nil {
+		c <- handlerResult{jsonErr: &ErrServerBusy}
+	}
+	return c
+}
+
+// runHandler handles a request on the server's handler pool, blocking until
+// the result is available.
+func (s *Server) runHandler(req *btcjson.Request, walletName string,
+	f lazyHandler) (interface{}, *btcjson.RPCError) {
+
+	r := <-s.dispatch(req, walletName, f)
+	return r.result, r.jsonErr
+}
+
+// requestKey returns the key of the account modified by a request, such as
+// by deriving addresses or spending outputs of the account, or an empty key
+// for requests which need not be ordered.  Keys are qualified by the name of
+// the wallet handling the request.
+func requestKey(req *btcjson.Request, walletName string,
+	defaultUnit btcutil.AmountUnit) string {
+
+	// Only the parameters of keyed requests are unmarshaled, as
+	// unmarshaling the requests of some other methods clears their
+	// passphrase parameters.
+	switch req.Method {
+	case "createtx", "getnewaddress", "getrawchangeaddress", "sendfrom",
+		"sendmany", "sendtoaddress":
+	default:
+		return ""
+	}
+
+	cmd, err := unmarshalCmd(req, defaultUnit)
+	if err != nil {
+		return ""
+	}
+	if scmd, ok := cmd.(*sendCmd); ok {
+		cmd = scmd.cmd
+	}
+
+	account := ""
+	switch cmd := cmd.(type) {
+	case *btcjson.GetNewAddressCmd:
+		account = defaultAccountName
+		if cmd.Account != nil {
+			account = *cmd.Account
+		}
+	case *btcjson.GetRawChangeAddressCmd:
+		account = defaultAccountName
+		if cmd.Account != nil {
+			account = *cmd.Account
+		}
+	case *btcjson.SendFromCmd:
+		account = cmd.FromAccount
+	case *btcjson.SendManyCmd:
+		account = cmd.FromAccount
+	case *btcjson.SendToAddressCmd:
+		// sendtoaddress always spends from the default account.
+		account = defaultAccountName
+	case *walletjson.CreateTxCmd:
+		account = *cmd.Account
+	default:
+		return ""
+	}
+	return walletName + "/" + account
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestHandlerPoolLimit ensures that no more than the maximum number of jobs
+// run concurrently, and that queued jobs run once workers are free.
+func TestHandlerPoolLimit(t *testing.T) {
+	const maxWorkers = 3
+	const numJobs = 20
+
+	p := newHandlerPool(maxWorkers)
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	var wg sync.WaitGroup
+	wg.Add(numJobs)
+	for i := 0; i < numJobs; i++ {
+		p.submit("", func() {
+			defer wg.Done()
+
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	if maxRunning > maxWorkers {
+		t.Fatalf("%d jobs ran concurrently, limit is %d", maxRunning,
+			maxWorkers)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.workers != 0 || len(p.pending) != 0 {
+		t.Fatalf("pool not idle: %d workers, %d pending jobs",
+			p.workers, len(p.pending))
+	}
+}
+
+// TestHandlerPoolKeyOrder ensures that jobs submitted with the same key run
+// one at a time in the order they were submitted, while jobs of other keys
+// are not held back by them.
+func TestHandlerPoolKeyOrder(t *testing.T) {
+	p := newHandlerPool(4)
+
+	// Block the first job of key a until a job of key b has run.
+	release := make(chan struct{})
+	p.submit("a", func() { <-release })
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		i := i
+		p.submit("a", func() {
+			defer wg.Done()
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		})
+	}
+
+	done := make(chan struct{})
+	p.submit("b", func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job of other key blocked")
+	}
+	close(release)
+	wg.Wait()
+
+	for i, n := range order {
+		if n != i {
+			t.Fatalf("jobs ran out of order: %v", order)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.keyed) != 0 {
+		t.Fatalf("keys not removed after their jobs ran: %v", p.keyed)
+	}
+}
+
+// TestDispatchQueueLimit ensures that requests are refused once too many are
+// waiting to be handled, while requests locking and unlocking the wallet are
+// handled regardless.
+func TestDispatchQueueLimit(t *testing.T) {
+	s := &Server{handlers: newHandlerPool(1)}
+
+	// Block the only worker and fill the queue.
+	started := make(chan struct{}, 1+queuedPerHandler)
+	release := make(chan struct{})
+	blocked := func() (interface{}, *btcjson.RPCError) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	}
+	req := &btcjson.Request{Jsonrpc: "1.0", Method: "getbalance"}
+	results := []<-chan handlerResult{s.dispatch(req, "", blocked)}
+	<-started
+	for i := 0; i < queuedPerHandler; i++ {
+		results = append(results, s.dispatch(req, "", blocked))
+	}
+
+	r := <-s.dispatch(req, "", blocked)
+	if r.jsonErr == nil || *r.jsonErr != ErrServerBusy {
+		t.Fatalf("got error %v, want %v", r.jsonErr, &ErrServerBusy)
+	}
+
+	lockReq := &btcjson.Request{Jsonrpc: "1.0", Method: "walletlock"}
+	select {
+	case r := <-s.dispatch(lockReq, "", func() (interface{},
+		*btcjson.RPCError) {
+
+		return "locked", nil
+	}):
+		if r.result != "locked" {
+			t.Fatalf("got result %v, want locked", r.result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("walletlock blocked by busy handlers")
+	}
+
+	// Queued requests are all handled once the worker is free.
+	close(release)
+	for _, c := range results {
+		if r := <-c; r.jsonErr != nil {
+			t.Fatalf("queued request failed: %v", r.jsonErr)
+		}
+	}
+
+	s.handlers.mu.Lock()
+	defer s.handlers.mu.Unlock()
+	if s.handlers.queued != 0 {
+		t.Fatalf("%d jobs still counted as queued", s.handlers.queued)
+	}
+}
+
+// TestRequestKey ensures that requests are keyed by the account they modify.
+func TestRequestKey(t *testing.T) {
+	tests := []struct {
+		method string
+		params []interface{}
+		key    string
+	}{
+		{"getnewaddress", nil, "w/default"},
+		{"getnewaddress", []interface{}{"acct"}, "w/acct"},
+		{"getrawchangeaddress", nil, "w/default"},
+		{"sendfrom", []interface{}{"acct", "addr", 1}, "w/acct"},
+		{"sendmany", []interface{}{"acct", map[string]float64{"addr": 1}},
+			"w/acct"},
+		{"sendtoaddress", []interface{}{"addr", 1}, "w/default"},
+		{"createtx", []interface{}{map[string]float64{"addr": 1}},
+			"w/default"},
+		{"createtx", []interface{}{map[string]float64{"addr": 1}, "acct"},
+			"w/acct"},
+		{"getbalance", nil, ""},
+		{"unknownmethod", nil, ""},
+		{"walletpassphrase", []interface{}{"secret", 60}, ""},
+	}
+
+	for _, test := range tests {
+		params := make([]json.RawMessage, 0, len(test.params))
+		for _, param := range test.params {
+			b, err := json.Marshal(param)
+			if err != nil {
+				t.Fatal(err)
+			}
+			params = append(params, b)
+		}
+		req := &btcjson.Request{
+			Jsonrpc: "1.0",
+			Method:  test.method,
+			Params:  params,
+		}
+		key := requestKey(req, "w", btcutil.AmountBTC)
+		if key != test.key {
+			t.Errorf("%s: got key %q, want %q", test.method, key,
+				test.key)
+		}
+
+		// The parameters must be left intact for the handler.
+		for i, param := range test.params {
+			b, _ := json.Marshal(param)
+			if string(req.Params[i]) != string(b) {
+				t.Errorf("%s: parameter %d changed to %s",
+					test.method, i, req.Params[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// unknownKeyTime is written as the time of keys without a recorded birthday,
+// as done by the reference implementation.
+const unknownKeyTime = "1970-01-01T00:00:01Z"
+
+// dumpWallet handles a dumpwallet request by writing the private key of every
+// wallet address to a new file.  The keys are written to the file one at a
+// time as they are read from the wallet, rather than returned in the reply,
+// so that wallets with any number of keys can be dumped.
+func dumpWallet(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.DumpWalletCmd)
+
+	// The file is written by the wallet process, so a relative path would
+	// be resolved against its working directory rather than anything
+	// known to the client.
+	if !filepath.IsAbs(cmd.Filename) {
+		return nil, InvalidParameterError{
+			errors.New("dump file path must be absolute"),
+		}
+	}
+
+	// Existing files are never overwritten, and the dump is only readable
+	// by the owner as it holds every private key of the wallet.
+	f, err := os.OpenFile(
+		cmd.Filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600,
+	)
+	if os.IsExist(err) {
+		return nil, InvalidParameterError{
+			fmt.Errorf("%s already exists", cmd.Filename),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeWalletDump(f, w)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(cmd.Filename)
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return nil, &ErrWalletUnlockNeeded
+		}
+		return nil, err
+	}
+
+	return &walletjson.DumpWalletResult{Filename: cmd.Filename}, nil
+}
+
+// writeWalletDump writes the private keys of the wallet in the format of the
+// reference implementation's dumpwallet.  Each key is written on a line with
+// the birthday of its address, marked as change if it is of an internal
+// branch, and followed by a comment holding its address and, for keys derived
+// from the seed, its HD key path.
+func writeWalletDump(out io.Writer, w *wallet.Wallet) error {
+	b := bufio.NewWriter(out)
+
+	syncedTo := w.Manager.SyncedTo()
+	fmt.Fprintf(b, "# Wallet dump created by btcwallet\n")
+	fmt.Fprintf(b, "# * Created on %s\n",
+		w.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(b, "# * Best block at time of backup was %d (%s),\n",
+		syncedTo.Height, syncedTo.Hash)
+	fmt.Fprintf(b, "#   mined on %s\n\n",
+		syncedTo.Timestamp.UTC().Format(time.RFC3339))
+
+	err := w.ForEachPrivKey(func(key *wallet.DumpedKey) error {
+		keyTime := unknownKeyTime
+		if key.Birthday != nil && !key.Birthday.Timestamp.IsZero() {
+			keyTime = key.Birthday.Timestamp.UTC().Format(time.RFC3339)
+		}
+		flag := ""
+		if key.Internal {
+			flag = " change=1"
+		}
+		path := ""
+		if key.Derived {
+			path = " hdkeypath=" +
+				hdKeyPath(key.KeyScope, key.DerivationPath)
+		}
+		_, err := fmt.Fprintf(b, "%s %s%s # addr=%s%s\n", key.WIF,
+			keyTime, flag, key.Address.EncodeAddress(), path)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(b, "\n# End of dump\n")
+	return b.Flush()
+}
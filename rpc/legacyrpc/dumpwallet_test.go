@@ -0,0 +1,132 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// TestDumpWallet ensures that dumpwallet writes a line with the private key
+// of every wallet address to a new file, and leaves no file behind when the
+// keys can not be exported.
+func TestDumpWallet(t *testing.T) {
+	w, cleanup := goldenWallet(t)
+	defer cleanup()
+
+	if _, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0044); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.NewChangeAddress(0, waddrmgr.KeyScopeBIP0044); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "dumpwallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dump.txt")
+
+	// The keys of a locked wallet can not be dumped.
+	_, err = dumpWallet(&btcjson.DumpWalletCmd{Filename: path}, w)
+	if err != &ErrWalletUnlockNeeded {
+		t.Fatalf("dump of locked wallet: got error %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("dump file left behind: %v", err)
+	}
+
+	if err := w.Unlock([]byte("private"), nil); err != nil {
+		t.Fatal(err)
+	}
+	res, err := dumpWallet(&btcjson.DumpWalletCmd{Filename: path}, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.(*walletjson.DumpWalletResult).Filename != path {
+		t.Fatalf("got filename %v, want %s", res, path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("dump file has mode %v", info.Mode())
+	}
+
+	wantKeys, err := w.DumpPrivKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var keys []string
+	change, end := 0, false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "# End of dump" {
+			end = true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[len(fields)-2], "addr=") {
+			t.Fatalf("malformed key line %q", line)
+		}
+
+		// Every key of the wallet is derived from its seed, and the
+		// HD key path of change keys is of the internal branch.
+		path := fields[len(fields)-1]
+		if !strings.HasPrefix(path, "hdkeypath=m/44'/0'/0'/") {
+			t.Fatalf("key line %q has no HD key path", line)
+		}
+		if fields[2] == "change=1" {
+			if !strings.HasPrefix(path, "hdkeypath=m/44'/0'/0'/1/") {
+				t.Fatalf("change key line %q has external "+
+					"path", line)
+			}
+			change++
+		}
+		keys = append(keys, fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !end {
+		t.Fatal("dump is not terminated")
+	}
+	if len(keys) != len(wantKeys) {
+		t.Fatalf("dumped %d keys, want %d", len(keys), len(wantKeys))
+	}
+	for i := range keys {
+		if keys[i] != wantKeys[i] {
+			t.Fatalf("key %d is %s, want %s", i, keys[i], wantKeys[i])
+		}
+	}
+	if change == 0 {
+		t.Fatal("no change keys marked")
+	}
+
+	// An existing file is never overwritten.
+	_, err = dumpWallet(&btcjson.DumpWalletCmd{Filename: path}, w)
+	if _, ok := err.(InvalidParameterError); !ok {
+		t.Fatalf("dump to existing file: got error %v", err)
+	}
+}
@@ -37,6 +37,12 @@ type (
 	}
 )
 
+// ErrRPCSpendPolicy is the error code of sends refused because they violate
+// the spend policy of the account they spend from.  It is a btcwallet
+// extension, chosen not to conflict with the codes of the reference
+// implementation.
+const ErrRPCSpendPolicy btcjson.RPCErrorCode = -40
+
 // Errors variables that are defined once here to avoid duplication below.
 var (
 	ErrNeedPositiveAmount = InvalidParameterError{
@@ -81,4 +87,53 @@ var (
 		Code:    btcjson.ErrRPCInvalidParameter,
 		Message: "Account name is reserved by RPC server",
 	}
+
+	ErrPublicMethodNotAllowed = btcjson.RPCError{
+		Code:    btcjson.ErrRPCMethodNotFound.Code,
+		Message: "Method not available to public clients",
+	}
+
+	ErrLimitedMethodNotAllowed = btcjson.RPCError{
+		Code:    btcjson.ErrRPCMethodNotFound.Code,
+		Message: "Method not available to limited clients",
+	}
+
+	ErrMethodDisabled = btcjson.RPCError{
+		Code:    btcjson.ErrRPCMethodNotFound.Code,
+		Message: "Method disabled by server configuration",
+	}
+
+	ErrPublicRateLimited = btcjson.RPCError{
+		Code:    btcjson.ErrRPCMisc,
+		Message: "Request rate limit exceeded for method",
+	}
+
+	ErrClientRateLimited = btcjson.RPCError{
+		Code:    btcjson.ErrRPCMisc,
+		Message: "Request rate limit exceeded for client",
+	}
+
+	ErrServerBusy = btcjson.RPCError{
+		Code:    btcjson.ErrRPCMisc,
+		Message: "Too many requests are waiting to be handled",
+	}
+
+	ErrWalletNotFound = btcjson.RPCError{
+		Code:    btcjson.ErrRPCWalletNotFound,
+		Message: "Requested wallet does not exist or is not loaded",
+	}
+
+	ErrWalletLoadingDisabled = btcjson.RPCError{
+		Code:    btcjson.ErrRPCWallet,
+		Message: "Wallets can not be loaded at runtime by this server",
+	}
+
+	ErrDefaultWalletUnload = btcjson.RPCError{
+		Code:    btcjson.ErrRPCWallet,
+		Message: "The wallet opened at startup can not be unloaded",
+	}
+
+	ErrUnknownNetwork = InvalidParameterError{
+		errors.New("unknown network"),
+	}
 )
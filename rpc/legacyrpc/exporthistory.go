@@ -0,0 +1,242 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// Formats of exported transaction histories.
+const (
+	historyFormatCSV  = "csv"
+	historyFormatJSON = "json"
+)
+
+// historyCSVHeader is the header row of transaction histories exported as CSV.
+var historyCSVHeader = []string{
+	"date", "txid", "category", "account", "address", "amount", "fee",
+	"label", "txlabel", "comment", "blockheight",
+}
+
+// historyJSONEntry is an entry of a transaction history exported as JSON.
+type historyJSONEntry struct {
+	Date        string  `json:"date"`
+	TxID        string  `json:"txid"`
+	Category    string  `json:"category"`
+	Account     string  `json:"account"`
+	Address     string  `json:"address"`
+	Amount      float64 `json:"amount"`
+	Fee         float64 `json:"fee"`
+	Label       string  `json:"label"`
+	TxLabel     string  `json:"txlabel"`
+	Comment     string  `json:"comment"`
+	BlockHeight *int32  `json:"blockheight,omitempty"`
+}
+
+// exportHistory handles an exporthistory request by writing the transaction
+// history of the wallet, or of one account, to a new file as CSV or JSON.  The
+// entries are written to the file as they are read from the wallet, so
+// histories of any length can be exported.
+func exportHistory(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ExportHistoryCmd)
+
+	// The file is written by the wallet process, so a relative path would
+	// be resolved against its working directory rather than anything
+	// known to the client.
+	if !filepath.IsAbs(cmd.Filename) {
+		return nil, InvalidParameterError{
+			errors.New("history file path must be absolute"),
+		}
+	}
+	switch *cmd.Format {
+	case historyFormatCSV, historyFormatJSON:
+	default:
+		return nil, InvalidParameterError{
+			fmt.Errorf("unknown format %q, must be %q or %q",
+				*cmd.Format, historyFormatCSV,
+				historyFormatJSON),
+		}
+	}
+	account := *cmd.Account
+	if account != "*" {
+		_, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Existing files are never overwritten, and the history is only
+	// readable by the owner as it reveals every payment of the wallet.
+	f, err := os.OpenFile(
+		cmd.Filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600,
+	)
+	if os.IsExist(err) {
+		return nil, InvalidParameterError{
+			fmt.Errorf("%s already exists", cmd.Filename),
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := writeHistory(f, w, account, *cmd.Format)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(cmd.Filename)
+		return nil, err
+	}
+
+	return &walletjson.ExportHistoryResult{
+		Filename: cmd.Filename,
+		Entries:  n,
+	}, nil
+}
+
+// writeHistory writes the entries of the transaction history of an account,
+// or of every account when account is "*", in a format, returning the number
+// of written entries.
+func writeHistory(out io.Writer, w *wallet.Wallet, account,
+	format string) (int, error) {
+
+	b := bufio.NewWriter(out)
+	var (
+		write  func(e *wallet.HistoryEntry) error
+		finish func() error
+	)
+	if format == historyFormatJSON {
+		write, finish = historyJSONWriter(b)
+	} else {
+		write, finish = historyCSVWriter(b)
+	}
+
+	n := 0
+	err := w.ForEachHistoryEntry(func(e *wallet.HistoryEntry) error {
+		if account != "*" && e.Account != account {
+			return nil
+		}
+		n++
+		return write(e)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := finish(); err != nil {
+		return 0, err
+	}
+	return n, b.Flush()
+}
+
+// historyDate returns the time of a history entry in UTC.
+func historyDate(e *wallet.HistoryEntry) string {
+	return e.Time().UTC().Format(time.RFC3339)
+}
+
+// historyFee returns the fee of a history entry as reported by the
+// listtransactions method, which is negative for sends.
+func historyFee(e *wallet.HistoryEntry) btcutil.Amount {
+	return -e.Fee
+}
+
+// historyCSVWriter returns functions writing the entries of a transaction
+// history as CSV rows, following a header row, and finishing the history.
+// Amounts are written in bitcoin with eight decimal places.
+func historyCSVWriter(out io.Writer) (func(*wallet.HistoryEntry) error,
+	func() error) {
+
+	c := csv.NewWriter(out)
+	wroteHeader := false
+	writeHeader := func() error {
+		if wroteHeader {
+			return nil
+		}
+		wroteHeader = true
+		return c.Write(historyCSVHeader)
+	}
+	formatAmount := func(a btcutil.Amount) string {
+		return strconv.FormatFloat(a.ToBTC(), 'f', 8, 64)
+	}
+
+	write := func(e *wallet.HistoryEntry) error {
+		if err := writeHeader(); err != nil {
+			return err
+		}
+		var height string
+		if e.Height != -1 {
+			height = strconv.FormatInt(int64(e.Height), 10)
+		}
+		return c.Write([]string{
+			historyDate(e), e.Hash.String(), e.Category, e.Account,
+			e.Address, formatAmount(e.Amount),
+			formatAmount(historyFee(e)), e.Label, e.TxLabel,
+			e.Comment, height,
+		})
+	}
+	finish := func() error {
+		if err := writeHeader(); err != nil {
+			return err
+		}
+		c.Flush()
+		return c.Error()
+	}
+	return write, finish
+}
+
+// historyJSONWriter returns functions writing the entries of a transaction
+// history as the objects of a JSON array, one per line, and finishing the
+// array.
+func historyJSONWriter(out io.Writer) (func(*wallet.HistoryEntry) error,
+	func() error) {
+
+	enc := json.NewEncoder(out)
+	sep := "["
+	write := func(e *wallet.HistoryEntry) error {
+		entry := &historyJSONEntry{
+			Date:     historyDate(e),
+			TxID:     e.Hash.String(),
+			Category: e.Category,
+			Account:  e.Account,
+			Address:  e.Address,
+			Amount:   e.Amount.ToBTC(),
+			Fee:      historyFee(e).ToBTC(),
+			Label:    e.Label,
+			TxLabel:  e.TxLabel,
+			Comment:  e.Comment,
+		}
+		if e.Height != -1 {
+			height := e.Height
+			entry.BlockHeight = &height
+		}
+		if _, err := io.WriteString(out, sep+"\n"); err != nil {
+			return err
+		}
+		sep = ","
+		return enc.Encode(entry)
+	}
+	finish := func() error {
+		if sep == "[" {
+			_, err := io.WriteString(out, "[]\n")
+			return err
+		}
+		_, err := io.WriteString(out, "]\n")
+		return err
+	}
+	return write, finish
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+// TestExportHistory ensures that exporthistory writes the history of the
+// wallet to a new file in the requested format, and never overwrites files.
+func TestExportHistory(t *testing.T) {
+	w, cleanup := goldenWallet(t)
+	defer cleanup()
+
+	dir, err := ioutil.TempDir("", "exporthistory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	all, csvFormat, jsonFormat := "*", "csv", "json"
+	exportTo := func(name string, account, format *string) (string, error) {
+		path := filepath.Join(dir, name)
+		res, err := exportHistory(&walletjson.ExportHistoryCmd{
+			Filename: path,
+			Account:  account,
+			Format:   format,
+		}, w)
+		if err != nil {
+			return path, err
+		}
+		want := &walletjson.ExportHistoryResult{Filename: path}
+		if !reflect.DeepEqual(res, want) {
+			t.Fatalf("got result %v, want %v", res, want)
+		}
+		return path, nil
+	}
+
+	// The history of a wallet without transactions is only a header.
+	path, err := exportTo("history.csv", &all, &csvFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("history file has mode %v", info.Mode())
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(f).ReadAll()
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || !reflect.DeepEqual(records[0], historyCSVHeader) {
+		t.Fatalf("got records %q", records)
+	}
+
+	// Existing files are not overwritten.
+	_, err = exportTo("history.csv", &all, &csvFormat)
+	if _, ok := err.(InvalidParameterError); !ok ||
+		!strings.Contains(err.Error(), "already exists") {
+
+		t.Fatalf("export to existing file: got error %v", err)
+	}
+
+	// The history of an account as JSON is an array of entries.
+	account := "default"
+	path, err = exportTo("history.json", &account, &jsonFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []historyJSONEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		t.Fatalf("invalid JSON history %q: %v", b, err)
+	}
+	if entries == nil || len(entries) != 0 {
+		t.Fatalf("got entries %v", entries)
+	}
+
+	// Unknown accounts and formats are refused without creating a file.
+	unknown := "unknown"
+	path, err = exportTo("unknown-account.csv", &unknown, &csvFormat)
+	if !waddrmgr.IsError(err, waddrmgr.ErrAccountNotFound) {
+		t.Fatalf("export of unknown account: got error %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("history file created: %v", err)
+	}
+	xml := "xml"
+	path, err = exportTo("history.xml", &all, &xml)
+	if _, ok := err.(InvalidParameterError); !ok {
+		t.Fatalf("export as unknown format: got error %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("history file created: %v", err)
+	}
+}
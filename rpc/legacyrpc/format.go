@@ -0,0 +1,299 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/cfgutil"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+)
+
+// amountFields is the set of object keys of results whose values are amounts
+// valued in bitcoin.
+var amountFields = map[string]struct{}{
+	"amount":              {},
+	"balance":             {},
+	"confirmed":           {},
+	"fee":                 {},
+	"immature":            {},
+	"maxperday":           {},
+	"maxpertx":            {},
+	"paytxfee":            {},
+	"relayfee":            {},
+	"spent24h":            {},
+	"trusted":             {},
+	"unconfirmed":         {},
+	"unconfirmed_balance": {},
+	"untrusted_pending":   {},
+	"used":                {},
+}
+
+// timeFields is the set of object keys of results whose values are Unix
+// times.
+var timeFields = map[string]struct{}{
+	"blocktime":       {},
+	"created":         {},
+	"last_block_seen": {},
+	"ratetime":        {},
+	"time":            {},
+	"timereceived":    {},
+}
+
+// amountResultMethods is the set of methods whose results are amounts, or
+// objects mapping names to amounts, valued in bitcoin.
+var amountResultMethods = map[string]struct{}{
+	"getbalance":            {},
+	"getreceivedbyaccount":  {},
+	"getreceivedbyaddress":  {},
+	"getunconfirmedbalance": {},
+	"listaccounts":          {},
+}
+
+// ntfnParam describes how a positional parameter of a notification is
+// formatted.
+type ntfnParam uint8
+
+const (
+	// ntfnAmount parameters are amounts valued in bitcoin.
+	ntfnAmount ntfnParam = iota + 1
+
+	// ntfnTime parameters are Unix times.
+	ntfnTime
+)
+
+// ntfnParams maps the methods of notifications to their positional parameters
+// which are amounts or timestamps, by index.  Parameters which are objects are
+// formatted by key, as results are.
+var ntfnParams = map[string]map[int]ntfnParam{
+	btcjson.AccountBalanceNtfnMethod:    {1: ntfnAmount},
+	walletjson.BackupFailedNtfnMethod:   {0: ntfnTime},
+	walletjson.BlockConnectedNtfnMethod: {2: ntfnTime},
+	walletjson.ChainHealthNtfnMethod:    {2: ntfnTime},
+	walletjson.LargeDepositNtfnMethod:   {2: ntfnAmount, 3: ntfnAmount},
+	walletjson.NewTxNtfnMethod:          {2: ntfnAmount},
+	walletjson.TxStuckNtfnMethod:        {1: ntfnTime, 2: ntfnAmount},
+}
+
+// resultFormat describes how amounts and timestamps of results are formatted
+// for a client which negotiated a format when connecting.  Amounts are
+// formatted as strings in the negotiated unit and precision, and timestamps
+// as either Unix times or ISO 8601 strings.
+type resultFormat struct {
+	// formatAmounts is set when the client requested a unit or precision
+	// for amounts.  Amounts are left as numbers valued in bitcoin when
+	// unset.
+	formatAmounts bool
+	unit          btcutil.AmountUnit
+	precision     int
+
+	// isoTime formats timestamps as ISO 8601 strings in UTC.
+	isoTime bool
+}
+
+// parseResultFormat parses the result format requested by the "unit",
+// "precision" and "timeformat" query parameters of a client's request URL.
+// Amounts are formatted in the display unit of the server when the client does
+// not request a unit.  A nil format is returned when no parameters are set and
+// the display unit is bitcoin, leaving results unchanged.
+func parseResultFormat(query url.Values,
+	displayUnit btcutil.AmountUnit) (*resultFormat, error) {
+
+	unit, precision := query.Get("unit"), query.Get("precision")
+	timeFormat := query.Get("timeformat")
+	if unit == "" && precision == "" && timeFormat == "" &&
+		displayUnit == btcutil.AmountBTC {
+
+		return nil, nil
+	}
+
+	f := &resultFormat{
+		formatAmounts: displayUnit != btcutil.AmountBTC,
+		unit:          displayUnit,
+	}
+	if unit != "" {
+		var err error
+		f.unit, err = cfgutil.ParseAmountUnit(unit)
+		if err != nil {
+			return nil, err
+		}
+		f.formatAmounts = true
+	}
+
+	// Amounts are formatted with all significant digits of the unit by
+	// default.
+	f.precision = 8 + int(f.unit)
+	if precision != "" {
+		p, err := strconv.ParseUint(precision, 10, 8)
+		if err != nil || p > 16 {
+			return nil, fmt.Errorf("invalid precision %q", precision)
+		}
+		f.precision = int(p)
+		f.formatAmounts = true
+	}
+
+	switch timeFormat {
+	case "", "unix":
+	case "iso8601":
+		f.isoTime = true
+	default:
+		return nil, fmt.Errorf("unknown time format %q", timeFormat)
+	}
+
+	return f, nil
+}
+
+// apply returns the result of a request of the method with its amounts and
+// timestamps formatted.
+func (f *resultFormat) apply(method string, result interface{}) (interface{}, error) {
+	if f == nil || result == nil {
+		return result, nil
+	}
+
+	// The result is reencoded as generic JSON values so that the fields
+	// of every result type may be rewritten by key.
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if _, ok := amountResultMethods[method]; ok {
+		switch v := v.(type) {
+		case json.Number:
+			return f.amount(v), nil
+		case map[string]interface{}:
+			for k, amount := range v {
+				if n, ok := amount.(json.Number); ok {
+					v[k] = f.amount(n)
+				}
+			}
+			return v, nil
+		}
+	}
+	return f.rewrite(v), nil
+}
+
+// applyNtfn returns the marshaled notification b with its amounts and
+// timestamps formatted.
+func (f *resultFormat) applyNtfn(b []byte) ([]byte, error) {
+	if f == nil {
+		return b, nil
+	}
+
+	var req btcjson.Request
+	if err := json.Unmarshal(b, &req); err != nil {
+		return nil, err
+	}
+	params := ntfnParams[req.Method]
+	for i, param := range req.Params {
+		dec := json.NewDecoder(bytes.NewReader(param))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+
+		if n, ok := v.(json.Number); ok {
+			switch params[i] {
+			case ntfnAmount:
+				v = f.amount(n)
+			case ntfnTime:
+				v = f.time(n)
+			}
+		} else {
+			v = f.rewrite(v)
+		}
+
+		var err error
+		req.Params[i], err = json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(&req)
+}
+
+// rewrite formats the amount and timestamp fields of every object within the
+// generic JSON value v.
+func (f *resultFormat) rewrite(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = f.rewrite(v[i])
+		}
+	case map[string]interface{}:
+		for k, field := range v {
+			n, ok := field.(json.Number)
+			if !ok {
+				v[k] = f.rewrite(field)
+				continue
+			}
+			if _, ok := amountFields[k]; ok {
+				v[k] = f.amount(n)
+			} else if _, ok := timeFields[k]; ok {
+				v[k] = f.time(n)
+			}
+		}
+	}
+	return v
+}
+
+// amount formats an amount valued in bitcoin.  Amounts are left unchanged
+// unless the client requested a unit or precision.
+func (f *resultFormat) amount(n json.Number) interface{} {
+	if !f.formatAmounts {
+		return n
+	}
+	btc, err := n.Float64()
+	if err != nil {
+		return n
+	}
+	amt, err := btcutil.NewAmount(btc)
+	if err != nil {
+		return n
+	}
+	return strconv.FormatFloat(amt.ToUnit(f.unit), 'f', f.precision, 64)
+}
+
+// time formats a Unix time.  Zero times, which indicate an unknown time, are
+// left unchanged.
+func (f *resultFormat) time(n json.Number) interface{} {
+	if !f.isoTime {
+		return n
+	}
+	secs, err := n.Int64()
+	if err != nil || secs == 0 {
+		return n
+	}
+	return time.Unix(secs, 0).UTC().Format(time.RFC3339)
+}
+
+// formatResult applies the format negotiated by a client, which may be nil, to
+// the result of a request of the method.
+func formatResult(f *resultFormat, method string, result interface{}) (
+	interface{}, *btcjson.RPCError) {
+
+	result, err := f.apply(method, result)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInternal.Code,
+			Message: "Unable to format result: " + err.Error(),
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestResultFormat ensures that amounts and timestamps of results are
+// formatted as negotiated by the query parameters of the client.
+func TestResultFormat(t *testing.T) {
+	txs := []btcjson.ListTransactionsResult{{
+		Amount:        1.5,
+		Confirmations: 1,
+		Time:          1600000000,
+		TimeReceived:  1600000000,
+	}}
+
+	tests := []struct {
+		name        string
+		query       string
+		displayUnit btcutil.AmountUnit
+		method      string
+		result      interface{}
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:   "no format",
+			query:  "",
+			method: "listtransactions",
+			result: txs,
+			want:   mustMarshal(t, txs),
+		},
+		{
+			name:   "unit",
+			query:  "unit=mbtc",
+			method: "getbalance",
+			result: 1.5,
+			want:   `"1500.00000"`,
+		},
+		{
+			name:        "display unit",
+			query:       "",
+			displayUnit: btcutil.AmountSatoshi,
+			method:      "getbalance",
+			result:      1.5,
+			want:        `"150000000"`,
+		},
+		{
+			name:        "unit overrides display unit",
+			query:       "unit=mbtc",
+			displayUnit: btcutil.AmountSatoshi,
+			method:      "getbalance",
+			result:      1.5,
+			want:        `"1500.00000"`,
+		},
+		{
+			name:   "precision",
+			query:  "unit=sat&precision=2",
+			method: "listaccounts",
+			result: map[string]float64{"default": 0.00001},
+			want:   `{"default":"1000.00"}`,
+		},
+		{
+			name:   "non-amount number",
+			query:  "unit=sat",
+			method: "getblockcount",
+			result: 100,
+			want:   `100`,
+		},
+		{
+			name:   "iso8601",
+			query:  "timeformat=iso8601",
+			method: "gettransaction",
+			result: btcjson.GetTransactionResult{
+				Amount:    1.5,
+				BlockTime: 0,
+				Time:      1600000000,
+			},
+		},
+		{
+			name:    "bad unit",
+			query:   "unit=dollars",
+			wantErr: true,
+		},
+		{
+			name:    "bad time format",
+			query:   "timeformat=rfc822",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		query, err := url.ParseQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := parseResultFormat(query, test.displayUnit)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+
+		res, jsonErr := formatResult(f, test.method, test.result)
+		if jsonErr != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, jsonErr)
+			continue
+		}
+		got := mustMarshal(t, res)
+
+		if test.method == "gettransaction" {
+			var tx map[string]interface{}
+			if err := json.Unmarshal([]byte(got), &tx); err != nil {
+				t.Fatal(err)
+			}
+			if tx["time"] != "2020-09-13T12:26:40Z" {
+				t.Errorf("%s: time formatted as %v", test.name,
+					tx["time"])
+			}
+			if tx["blocktime"] != float64(0) {
+				t.Errorf("%s: zero blocktime formatted as %v",
+					test.name, tx["blocktime"])
+			}
+			if tx["amount"] != 1.5 {
+				t.Errorf("%s: amount formatted as %v",
+					test.name, tx["amount"])
+			}
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got,
+				test.want)
+		}
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
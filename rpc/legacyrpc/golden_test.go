@@ -0,0 +1,399 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+)
+
+var updateGolden = flag.Bool("update", false,
+	"update the golden RPC responses in testdata/golden")
+
+// goldenRequest is a request sent to the server by the golden test.  Requests
+// are sent in order against a single wallet, so later responses observe the
+// changes made by earlier requests.
+type goldenRequest struct {
+	// name names the golden file of the response.
+	name   string
+	method string
+	params string
+}
+
+// goldenRequests exercises every method of the server.  Methods depending on
+// a chain server are recorded responding with the error returned while no
+// chain server is connected.
+var goldenRequests = []goldenRequest{
+	{"help", "help", `["getbalance"]`},
+	{"getbestblockhash", "getbestblockhash", `[]`},
+	{"getblockcount", "getblockcount", `[]`},
+	{"getbestblock", "getbestblock", `[]`},
+	{"getinfo", "getinfo", `[]`},
+	{"getwalletinfo", "getwalletinfo", `[]`},
+	{"walletislocked", "walletislocked", `[]`},
+	{"walletpassphrase", "walletpassphrase", `["private", 3600]`},
+	{"walletislocked-unlocked", "walletislocked", `[]`},
+	{"getnewaddress", "getnewaddress", `[]`},
+	{"getaccountaddress", "getaccountaddress", `["default"]`},
+	{"getrawchangeaddress", "getrawchangeaddress", `[]`},
+	{"getaddressesbyaccount", "getaddressesbyaccount", `["default"]`},
+	{"getaccount", "getaccount", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]`},
+	{"validateaddress", "validateaddress", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]`},
+	{"dumpprivkey", "dumpprivkey", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]`},
+	{"signmessage", "signmessage", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "golden"]`},
+	{"verifymessage", "verifymessage", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "ICGa6xnBZmjSB4jX/qGlCdLcVv3xIXulM/bvzAa7mnnUb717NZTK+RfwH81gSfmr68bT8O5EfZfKTUytDCn0560=", "golden"]`},
+	{"importprivkey", "importprivkey", `["cMec2DGaTXkYJYfi7x3ZGjRXkeqmAvYAoWzMAcWj5fdLaqudWsNi", "imported", false]`},
+	{"createmultisig", "createmultisig", `[1, ["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]]`},
+	{"addmultisigaddress", "addmultisigaddress", `[1, ["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]]`},
+	{"keypoolrefill", "keypoolrefill", `[]`},
+	{"createnewaccount", "createnewaccount", `["savings"]`},
+	{"renameaccount", "renameaccount", `["savings", "reserve"]`},
+	{"setaccountmetadata", "setaccountmetadata", `["default", "Everyday spending", ["hot"]]`},
+	{"getaccountmetadata", "getaccountmetadata", `["default"]`},
+	{"setaccountflag", "setaccountflag", `["default", "avoid_reuse"]`},
+	{"listaccounts", "listaccounts", `[]`},
+	{"getbalance", "getbalance", `[]`},
+	{"getunconfirmedbalance", "getunconfirmedbalance", `[]`},
+	{"getreceivedbyaccount", "getreceivedbyaccount", `["default"]`},
+	{"getreceivedbyaddress", "getreceivedbyaddress", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]`},
+	{"listreceivedbyaccount", "listreceivedbyaccount", `[]`},
+	{"listreceivedbyaddress", "listreceivedbyaddress", `[]`},
+	{"listunspent", "listunspent", `[]`},
+	{"lockunspent", "lockunspent", `[false, [{"txid": "0000000000000000000000000000000000000000000000000000000000000001", "vout": 0}]]`},
+	{"listlockunspent", "listlockunspent", `[]`},
+	{"listtransactions", "listtransactions", `[]`},
+	{"listalltransactions", "listalltransactions", `[]`},
+	{"listaddresstransactions", "listaddresstransactions", `[["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]]`},
+	{"listsinceblock", "listsinceblock", `[]`},
+	{"listexpiredtransactions", "listexpiredtransactions", `[]`},
+	{"gettransaction", "gettransaction", `["0000000000000000000000000000000000000000000000000000000000000001"]`},
+	{"getlookahead", "getlookahead", `[]`},
+	{"setlookahead", "setlookahead", `[5]`},
+	{"exportauditsnapshot", "exportauditsnapshot", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]`},
+	{"settxfee", "settxfee", `[0.0001]`},
+	{"sendtoaddress", "sendtoaddress", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", 1]`},
+	{"sendfrom", "sendfrom", `["default", "muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", 1]`},
+	{"sendmany", "sendmany", `["default", {"muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu": 1}]`},
+	{"signrawtransaction", "signrawtransaction", `["0100000000000000000000"]`},
+	{"sweepprivkey", "sweepprivkey", `["cMec2DGaTXkYJYfi7x3ZGjRXkeqmAvYAoWzMAcWj5fdLaqudWsNi"]`},
+	{"backupwallet", "backupwallet", `["backup.db"]`},
+	{"dumpwallet", "dumpwallet", `["dump.txt"]`},
+	{"importwallet", "importwallet", `["dump.txt"]`},
+	{"listaddressgroupings", "listaddressgroupings", `[]`},
+	{"encryptwallet", "encryptwallet", `["passphrase"]`},
+	{"move", "move", `["default", "reserve", 1]`},
+	{"setaccount", "setaccount", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "reserve"]`},
+	{"walletpassphrasechange", "walletpassphrasechange", `["private", "changed"]`},
+	{"walletlock", "walletlock", `[]`},
+	{"walletislocked-locked", "walletislocked", `[]`},
+	{"dumpprivkey-locked", "dumpprivkey", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]`},
+	{"invalid-params", "getnewaddress", `["default", "extra"]`},
+	{"unknown-method", "getpeerinfo", `[]`},
+	{"notifytxconfirmations", "notifytxconfirmations", `["0000000000000000000000000000000000000000000000000000000000000000", 6]`},
+	{"subscribenotifications", "subscribenotifications", `[["btcwallet:newtx"], "default"]`},
+	{"unsubscribenotifications", "unsubscribenotifications", `[["btcwallet:newtx"]]`},
+	{"walletfsck", "walletfsck", `[true]`},
+	{"listwallets", "listwallets", `[]`},
+	{"loadwallet", "loadwallet", `["other"]`},
+	{"unloadwallet", "unloadwallet", `["other"]`},
+	{"createwallet", "createwallet", `["other", false, false, "private"]`},
+	{"walletpassphrase-all", "walletpassphrase", `["changed", 3600, "*"]`},
+	{"setaccountpassphrase", "setaccountpassphrase", `["reserve", "account"]`},
+	{"walletlock-account", "walletlock", `["reserve"]`},
+	{"walletislocked-account", "walletislocked", `["reserve"]`},
+	{"walletislocked-all", "walletislocked", `["*"]`},
+	{"walletpassphrase-account", "walletpassphrase", `["account", 3600, "reserve"]`},
+	{"walletislocked-account-unlocked", "walletislocked", `["reserve"]`},
+	{"walletunlockeduntil", "walletunlockeduntil", `[]`},
+	{"walletunlockeduntil-account", "walletunlockeduntil", `["reserve"]`},
+	{"walletpassphrase-until-lock", "walletpassphrase", `["changed", 0]`},
+	{"walletunlockeduntil-until-lock", "walletunlockeduntil", `[]`},
+	{"walletpassphrase-negative-timeout", "walletpassphrase", `["changed", -1]`},
+	{"walletpassphrase-view", "walletpassphrase", `["changed", 0, null, "view"]`},
+	{"walletunlockeduntil-view", "walletunlockeduntil", `[]`},
+	{"signmessage-view", "signmessage", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "golden"]`},
+	{"walletpassphrase-spend", "walletpassphrase", `["changed", 0, null, "spend"]`},
+	{"dumpprivkey-spend", "dumpprivkey", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]`},
+	{"walletpassphrase-unknown-level", "walletpassphrase", `["changed", 0, null, "admin"]`},
+	{"exportprivkeybip38-spend", "exportprivkeybip38", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "paper"]`},
+	{"walletpassphrase-full", "walletpassphrase", `["changed", 0, null, "full"]`},
+	{"exportprivkeybip38", "exportprivkeybip38", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "paper"]`},
+	{"importprivkey-bip38", "importprivkey", `["6PYVRmfYz8kAABXqawTknsbYNDKHYU88sHLg5X2M5Zn3D7RHPwzJNBfKpm", "imported", false, "paper"]`},
+	{"validateaddress-bip38", "validateaddress", `["mkDsXt96y4snkBGHBPFY8Dd936Ruduih5e"]`},
+	{"importprivkey-bip38-wrong-passphrase", "importprivkey", `["6PYVRmfYz8kAABXqawTknsbYNDKHYU88sHLg5X2M5Zn3D7RHPwzJNBfKpm", "imported", false, "wrong"]`},
+	{"importprivkey-bip38-malformed", "importprivkey", `["cMec2DGaTXkYJYfi7x3ZGjRXkeqmAvYAoWzMAcWj5fdLaqudWsNi", "imported", false, "paper"]`},
+	{"confirmspend-disabled", "confirmspend", `["00112233445566778899aabbccddeeff", "123456"]`},
+	{"cancelspend-unknown", "cancelspend", `["00112233445566778899aabbccddeeff"]`},
+	{"setspendpolicy", "setspendpolicy", `["default", 0.5, 1, ["mkDsXt96y4snkBGHBPFY8Dd936Ruduih5e"]]`},
+	{"getspendpolicy", "getspendpolicy", `["default"]`},
+	{"sendtoaddress-spendpolicy-whitelist", "sendtoaddress", `["mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", 0.1]`},
+	{"sendtoaddress-spendpolicy-maxpertx", "sendtoaddress", `["mkDsXt96y4snkBGHBPFY8Dd936Ruduih5e", 1]`},
+	{"sendtoaddress-spendpolicy", "sendtoaddress", `["mkDsXt96y4snkBGHBPFY8Dd936Ruduih5e", 0.1]`},
+	{"setspendpolicy-remove", "setspendpolicy", `["default"]`},
+	{"getspendpolicy-none", "getspendpolicy", `["default"]`},
+	{"setlabel", "setlabel", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "invoice #123"]`},
+	{"setlabel-foreign", "setlabel", `["mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", "supplier"]`},
+	{"getaddressesbylabel", "getaddressesbylabel", `["invoice #123"]`},
+	{"getaddressesbylabel-unknown", "getaddressesbylabel", `["unknown"]`},
+	{"listlabels", "listlabels", `[]`},
+	{"listlabels-send", "listlabels", `["send"]`},
+	{"walletlockall", "walletlockall", `[]`},
+	{"walletislocked-after-lockall", "walletislocked", `["*"]`},
+	{"walletpassphrase-after-lockall", "walletpassphrase", `["changed", 0, "*"]`},
+	{"importprivkey-birthday-negative", "importprivkey", `["cMec2DGaTXkYJYfi7x3ZGjRXkeqmAvYAoWzMAcWj5fdLaqudWsNi", "imported", true, null, -1]`},
+	{"importprivkey-birthday-out-of-range", "importprivkey", `["cMec2DGaTXkYJYfi7x3ZGjRXkeqmAvYAoWzMAcWj5fdLaqudWsNi", "imported", true, null, 1]`},
+	{"listrescans", "listrescans", `[]`},
+	{"cancelrescan-unknown", "cancelrescan", `[1000]`},
+	{"rescanblockchain-invalid-range", "rescanblockchain", `[5, 1]`},
+	{"rescanblockchain-unknown-account", "rescanblockchain", `[0, 0, "unknown"]`},
+	{"listreceivedbyaddress-page", "listreceivedbyaddress", `[1, false, false, {"count": 2}]`},
+	{"listreceivedbyaddress-cursor-unknown", "listreceivedbyaddress", `[1, false, false, {"cursor": "unknown"}]`},
+	{"listreceivedbyaccount-skip", "listreceivedbyaccount", `[1, false, false, {"skip": 1}]`},
+	{"listtransactions-count-option", "listtransactions", `["*", 10, 0, false, {"count": 5}]`},
+	{"listtransactions-cursor-unknown", "listtransactions", `["*", 10, 0, false, {"cursor": "0000000000000000000000000000000000000000000000000000000000000001"}]`},
+	{"listunspent-timerange", "listunspent", `[1, 9999999, null, {"starttime": 1600000000, "endtime": 1600003600}]`},
+	{"listunspent-count-negative", "listunspent", `[1, 9999999, null, {"count": -1}]`},
+	{"getbalances", "getbalances", `[]`},
+	{"debuglevel-disabled", "debuglevel", `["debug"]`},
+	{"sendtoaddress-feerate", "sendtoaddress", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", 1, null, null, {"feerate": 0.0002}]`},
+	{"sendtoaddress-feerate-below-relay", "sendtoaddress", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", 1, null, null, {"feerate": 0.000001}]`},
+	{"estimatesendfee", "estimatesendfee", `[{"muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu": 1}]`},
+	{"estimatesendfee-invalid-address", "estimatesendfee", `[{"invalid": 1}, "default", 1, {"feerate": 0.0002}]`},
+	{"createtx", "createtx", `[{"muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu": 1}, "default", 1, "rent"]`},
+	{"committx-unknown", "committx", `["00112233445566778899aabbccddeeff"]`},
+	{"canceldrafttx-unknown", "canceldrafttx", `["00112233445566778899aabbccddeeff"]`},
+	{"decodepsbt", "decodepsbt", `["cHNidP8BAH4CAAAAAgEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAD/////AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABAAAAAP////8BkF8BAAAAAAAZdqkUmSidgAIGNxGm+3ozcMRj9be/IBWIrAAAAAAAAQEfoIYBAAAAAAAWABTnpDqkHvbXLca67qrYNizt9jt5owEDBAEAAAAAACICAnm+Zn753LusVaBilc6HCwcCm/zbLc4o2VnygVsW+BeYGBI0VnhUAACAAQAAgAAAAIAAAAAABQAAAAA="]`},
+	{"decodepsbt-invalid", "decodepsbt", `["cHNidP8="]`},
+	{"analyzepsbt", "analyzepsbt", `["cHNidP8BAH4CAAAAAgEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAD/////AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABAAAAAP////8BkF8BAAAAAAAZdqkUmSidgAIGNxGm+3ozcMRj9be/IBWIrAAAAAAAAQEfoIYBAAAAAAAWABTnpDqkHvbXLca67qrYNizt9jt5owEDBAEAAAAAACICAnm+Zn753LusVaBilc6HCwcCm/zbLc4o2VnygVsW+BeYGBI0VnhUAACAAQAAgAAAAIAAAAAABQAAAAA="]`},
+	{"signmessagebip322", "signmessagebip322", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "golden"]`},
+	{"verifymessagebip322-legacy", "verifymessagebip322", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "ICGa6xnBZmjSB4jX/qGlCdLcVv3xIXulM/bvzAa7mnnUb717NZTK+RfwH81gSfmr68bT8O5EfZfKTUytDCn0560=", "golden"]`},
+	{"verifymessagebip322-invalid", "verifymessagebip322", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "ICGa6xnBZmjSB4jX/qGlCdLcVv3xIXulM/bvzAa7mnnUb717NZTK+RfwH81gSfmr68bT8O5EfZfKTUytDCn0560=", "other"]`},
+	{"verifymessagebip322", "verifymessagebip322", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", "AAAAAAERxMzlor3vP4Pi5zWakdUVGDsN2eaNt+pckzJM7HMsygAAAABrSDBFAiEAwrHwUeHCYUJnZWKr328wjpQWbGPPhvB/JilMBZSx138CIBupHooytGAmD3BQbxoanH3xLrSxKRbVjEIbCxaF+7SJASECqCXlbRMtJTPUL6xH6Iq7lRetD9wwKnr9ZKpuEKmFcTgAAAAAAQAAAAAAAAAAAWoAAAAA", "golden"]`},
+	{"getaccountxpub", "getaccountxpub", `[]`},
+	{"getaccountxpub-unknown", "getaccountxpub", `["nonexistent"]`},
+	{"getaddressinfo", "getaddressinfo", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]`},
+	{"getaddressinfo-foreign", "getaddressinfo", `["tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"]`},
+	{"importdescriptors", "importdescriptors", `[[{"desc":"wpkh(03a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd)#ah7klf29","timestamp":"now","label":"imported"}]]`},
+	{"importdescriptors-invalid", "importdescriptors", `[[{"desc":"wpkh(03a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd)","timestamp":"now"},{"desc":"wpkh(03a34b99f22c790c4e36b2b3c2c35a36db06226e41c692fc82b8b56ac1c540c5bd)#ah7klf29"}]]`},
+	{"listdescriptors", "listdescriptors", `[]`},
+	{"importpubkey", "importpubkey", `["02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5", false]`},
+	{"importpubkey-invalid", "importpubkey", `["02c6047f"]`},
+	{"importscript", "importscript", `["512102a825e56d132d2533d42fac47e88abb9517ad0fdc302a7afd64aa6e10a985713851ae", false, true]`},
+	{"importscript-invalid", "importscript", `["zz"]`},
+	{"getpaymenturi", "getpaymenturi", `[0.015, "Order #42", "Coffee & cake"]`},
+	{"getpaymenturi-noparams", "getpaymenturi", `[]`},
+	{"getpaymenturi-negative", "getpaymenturi", `[-1]`},
+	{"getpaymentqr", "getpaymentqr", `[0.5, "Table 7"]`},
+	{"getpaymentqr-size", "getpaymentqr", `[null, null, null, "default", true, 16]`},
+	{"setdepositalert", "setdepositalert", `["default", 1]`},
+	{"setdepositalert-negative", "setdepositalert", `["default", -1]`},
+	{"getaccountmetadata-depositalert", "getaccountmetadata", `["default"]`},
+	{"exporthistory", "exporthistory", `["history.csv"]`},
+	{"getaccountingreport", "getaccountingreport", `["2021-01-01", "2021-12-31", "daily", "default"]`},
+	{"getaccountingreport-baddate", "getaccountingreport", `["2021-01-01", "2021-13-01"]`},
+	{"getaccountingreport-badperiod", "getaccountingreport", `["2021-01-01", "2021-12-31", "weekly"]`},
+	{"listunspent-queryoptions", "listunspent", `[0, 9999999, null, {"minimumAmount": 0.001, "maximumAmount": 1, "maximumCount": 10, "include_unsafe": false}]`},
+	{"listunspent-minimumamount-negative", "listunspent", `[1, 9999999, null, {"minimumAmount": -1}]`},
+	{"listunspent-maximumcount-count", "listunspent", `[1, 9999999, null, {"count": 1, "maximumCount": 1}]`},
+	{"listreceivedbyaddress-minimumamount", "listreceivedbyaddress", `[1, false, false, {"minimumAmount": 1}]`},
+	{"reserveaddress", "reserveaddress", `["default", "invoice #7"]`},
+	{"reserveaddress-unknown", "reserveaddress", `["nonexistent"]`},
+	{"releaseaddress-notreserved", "releaseaddress", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu"]`},
+	{"signrawtransactionwithwallet", "signrawtransactionwithwallet", `["010000000111111111111111111111111111111111111111111111111111111111111111110000000000ffffffff0000000000", [{"txid": "1111111111111111111111111111111111111111111111111111111111111111", "vout": 0, "scriptPubKey": "76a91499289d8002063711a6fb7a3370c463f5b7bf201588ac", "amount": 0.01}]]`},
+	{"signrawtransactionwithwallet-unknown", "signrawtransactionwithwallet", `["010000000111111111111111111111111111111111111111111111111111111111111111110000000000ffffffff0000000000"]`},
+	{"signrawtransactionwithwallet-sighashtype", "signrawtransactionwithwallet", `["010000000111111111111111111111111111111111111111111111111111111111111111110000000000ffffffff0000000000", null, "BOGUS"]`},
+	{"mergeaccounts-self", "mergeaccounts", `["default", "default"]`},
+	{"mergeaccounts-unknown", "mergeaccounts", `["nosuchaccount", "default"]`},
+	{"mergeaccounts-passphrase", "mergeaccounts", `["reserve", "default"]`},
+	{"mergeaccounts-createnewaccount", "createnewaccount", `["spending"]`},
+	{"mergeaccounts-getnewaddress", "getnewaddress", `["spending"]`},
+	{"mergeaccounts", "mergeaccounts", `["spending", "default"]`},
+	{"mergeaccounts-getaddressesbyaccount", "getaddressesbyaccount", `["spending"]`},
+	{"mergeaccounts-validateaddress", "validateaddress", `["mtJRTnKuRmasBtrtDZNwSZgt3UmawB1a31"]`},
+	{"authorizekeyuse-disabled", "authorizekeyuse", `["123456"]`},
+	{"setspendpolicy-bech32", "setspendpolicy", `["default", 0.5, null, null, "bech32"]`},
+	{"getspendpolicy-bech32", "getspendpolicy", `["default", "bech32"]`},
+	{"getspendpolicy-legacy-none", "getspendpolicy", `["default"]`},
+	{"getspendpolicy-unknown-addresstype", "getspendpolicy", `["default", "taproot"]`},
+	{"signrawtransactionwithwallet-spendpolicy-setspendpolicy", "setspendpolicy", `["default", null, null, ["mkDsXt96y4snkBGHBPFY8Dd936Ruduih5e"]]`},
+	{"signrawtransactionwithwallet-spendpolicy", "signrawtransactionwithwallet", `["010000000111111111111111111111111111111111111111111111111111111111111111110000000000ffffffff01e8030000000000001976a914000000000000000000000000000000000000000188ac00000000", [{"txid": "1111111111111111111111111111111111111111111111111111111111111111", "vout": 0, "scriptPubKey": "76a91499289d8002063711a6fb7a3370c463f5b7bf201588ac", "amount": 0.01}]]`},
+	{"signrawtransactionwithwallet-spendpolicy-remove", "setspendpolicy", `["default"]`},
+	{"listunspent-includeunsafe", "listunspent", `[0, 9999999, null, false]`},
+	{"listunspent-includeunsafe-queryoptions", "listunspent", `[0, 9999999, null, false, {"minimumAmount": 0.001, "maximumCount": 10}]`},
+	{"listunspent-includeunsafe-invalid", "listunspent", `[0, 9999999, null, "false"]`},
+	{"listunspent-includeunsafe-twice", "listunspent", `[0, 9999999, null, false, {"include_unsafe": true}]`},
+	{"walletextendunlock", "walletextendunlock", `[60]`},
+	{"walletunlockeduntil-extended", "walletunlockeduntil", `[]`},
+	{"walletextendunlock-unlock-account", "walletpassphrase", `["account", 3600, "reserve"]`},
+	{"walletextendunlock-account", "walletextendunlock", `[60, "reserve"]`},
+	{"walletunlockeduntil-account-extended", "walletunlockeduntil", `["reserve"]`},
+	{"walletextendunlock-negative-timeout", "walletextendunlock", `[-1]`},
+	{"walletextendunlock-lock", "walletlock", `[]`},
+	{"walletextendunlock-locked", "walletextendunlock", `[60]`},
+	{"sendtoaddress-comment-too-long", "sendtoaddress", `["muUnLQ9zSZGzukmQXSh41zRi5V9Jsw9XGu", 1, "` + strings.Repeat("c", 501) + `"]`},
+}
+
+// goldenChainClient is a chain client which never delivers notifications, so
+// that the wallet may create addresses without ever syncing.
+type goldenChainClient struct {
+	notifications chan interface{}
+}
+
+var _ chain.Interface = (*goldenChainClient)(nil)
+
+func (c *goldenChainClient) Start() error     { return nil }
+func (c *goldenChainClient) Stop()            {}
+func (c *goldenChainClient) WaitForShutdown() {}
+func (c *goldenChainClient) GetBestBlock() (*chainhash.Hash, int32, error) {
+	return chaincfg.TestNet3Params.GenesisHash, 0, nil
+}
+func (c *goldenChainClient) GetBlock(*chainhash.Hash) (*wire.MsgBlock, error) {
+	return nil, errGoldenChain
+}
+func (c *goldenChainClient) GetBlockHash(int64) (*chainhash.Hash, error) {
+	return nil, errGoldenChain
+}
+func (c *goldenChainClient) GetBlockHeader(*chainhash.Hash) (*wire.BlockHeader, error) {
+	return nil, errGoldenChain
+}
+func (c *goldenChainClient) IsCurrent() bool { return false }
+func (c *goldenChainClient) FilterBlocks(*chain.FilterBlocksRequest) (*chain.FilterBlocksResponse, error) {
+	return nil, errGoldenChain
+}
+func (c *goldenChainClient) BlockStamp() (*waddrmgr.BlockStamp, error) {
+	return &waddrmgr.BlockStamp{
+		Hash: *chaincfg.TestNet3Params.GenesisHash,
+	}, nil
+}
+func (c *goldenChainClient) SendRawTransaction(*wire.MsgTx, bool) (*chainhash.Hash, error) {
+	return nil, errGoldenChain
+}
+func (c *goldenChainClient) Rescan(*chainhash.Hash, []btcutil.Address,
+	map[wire.OutPoint]btcutil.Address) error {
+
+	return errGoldenChain
+}
+func (c *goldenChainClient) NotifyReceived([]btcutil.Address) error { return nil }
+func (c *goldenChainClient) NotifyBlocks() error                    { return nil }
+func (c *goldenChainClient) Notifications() <-chan interface{} {
+	return c.notifications
+}
+func (c *goldenChainClient) BackEnd() string { return "golden" }
+
+// errGoldenChain is returned by the golden chain client for requests which
+// require a real chain server.
+var errGoldenChain = errors.New("no chain server")
+
+// goldenWallet creates a wallet in deterministic mode: the seed, birthday and
+// clock of the wallet are fixed, so the addresses, keys and times of its
+// responses are the same on every run.
+func goldenWallet(t *testing.T) (*wallet.Wallet, func()) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "legacyrpc_golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := time.Unix(1600000000, 0)
+	loader := wallet.NewLoader(
+		&chaincfg.TestNet3Params, dir, true, 10*time.Second, 0,
+	)
+	loader.SetClock(func() time.Time { return clock })
+
+	seed := bytes.Repeat([]byte{0x01}, 32)
+	w, err := loader.CreateNewWallet(
+		[]byte(wallet.InsecurePubPassphrase), []byte("private"), seed,
+		clock,
+	)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	w.SynchronizeRPC(&goldenChainClient{
+		notifications: make(chan interface{}),
+	})
+
+	return w, func() {
+		w.Stop()
+		w.WaitForShutdown()
+		os.RemoveAll(dir)
+	}
+}
+
+// TestGoldenResponses sends requests of every method to the server and
+// compares the responses with the golden responses in testdata/golden, so
+// that changes to the responses seen by clients are caught.  Run the test
+// with -update to record new golden responses after an intended change.
+func TestGoldenResponses(t *testing.T) {
+	covered := make(map[string]struct{})
+	for _, req := range goldenRequests {
+		covered[req.method] = struct{}{}
+	}
+	for method := range rpcHandlers {
+		if _, ok := covered[method]; !ok {
+			t.Errorf("no golden request of method %s", method)
+		}
+	}
+
+	w, cleanup := goldenWallet(t)
+	defer cleanup()
+
+	srv := NewServer(&Options{}, nil, nil)
+	srv.RegisterWallet(w)
+
+	for i, req := range goldenRequests {
+		body := fmt.Sprintf(`{"jsonrpc":"1.0","id":%d,"method":%q,"params":%s}`,
+			i, req.method, req.params)
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.postClientRPC(rec, r, fullTier)
+
+		var got bytes.Buffer
+		if err := json.Indent(&got, rec.Body.Bytes(), "", "  "); err != nil {
+			t.Fatalf("%s: invalid response %q: %v", req.name,
+				rec.Body.String(), err)
+		}
+		got.WriteByte('\n')
+
+		path := filepath.Join("testdata", "golden", req.name+".json")
+		if *updateGolden {
+			err := ioutil.WriteFile(path, got.Bytes(), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		want, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", req.name, err)
+		}
+		if !bytes.Equal(got.Bytes(), want) {
+			t.Errorf("%s: response changed\ngot:\n%s\nwant:\n%s",
+				req.name, got.Bytes(), want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/internal/zero"
+)
+
+// response2 is a JSON-RPC 2.0 response.  Unlike the responses to earlier
+// versions of requests, exactly one of the result and error members is
+// included.
+type response2 struct {
+	Jsonrpc btcjson.RPCVersion `json:"jsonrpc"`
+	Result  json.RawMessage    `json:"result,omitempty"`
+	Error   *btcjson.RPCError  `json:"error,omitempty"`
+	ID      interface{}        `json:"id"`
+}
+
+// parseRequest unmarshals a JSON-RPC request.  The notification flag is set
+// for JSON-RPC 2.0 requests without an id, which are processed without being
+// replied to.  A non-nil error is the error the request must be replied to
+// with, in which case the returned request only holds the version and id to
+// reply with.  The raw request is not modified, but the copies made of it
+// other than the returned parameters are zeroed, as they may hold secrets.
+func parseRequest(b []byte) (req btcjson.Request, notification bool,
+	jsonErr *btcjson.RPCError) {
+
+	// The members are decoded separately to determine whether the request
+	// declared JSON-RPC 2.0 even when it is invalid, and whether it
+	// included an id at all, which btcjson.Request does not record.
+	var members map[string]json.RawMessage
+	_ = json.Unmarshal(b, &members)
+	var version string
+	_ = json.Unmarshal(members["jsonrpc"], &version)
+	params, ok := members["params"]
+	validParams := !ok || string(params) == "null" ||
+		(len(params) != 0 && params[0] == '[')
+	zero.Bytes(params)
+
+	err := json.Unmarshal(b, &req)
+	if btcjson.RPCVersion(version) != btcjson.RpcVersion2 {
+		if err != nil {
+			return req, false, btcjson.ErrRPCInvalidRequest
+		}
+		return req, false, nil
+	}
+
+	// Invalid JSON-RPC 2.0 requests are replied to with a null id unless
+	// the id of the request is known.  Requests whose only invalid member
+	// is the params, which must be an array, are replied to with an
+	// invalid params error.
+	req.Jsonrpc = btcjson.RpcVersion2
+	if err != nil {
+		var id interface{}
+		err := json.Unmarshal(members["id"], &id)
+		if err != nil || !btcjson.IsValidIDType(id) {
+			id = nil
+		}
+		var method string
+		err = json.Unmarshal(members["method"], &method)
+		if err != nil || method == "" || validParams {
+			return btcjson.Request{Jsonrpc: btcjson.RpcVersion2,
+				ID: id}, false, btcjson.ErrRPCInvalidRequest
+		}
+		return btcjson.Request{Jsonrpc: btcjson.RpcVersion2, ID: id},
+			false, btcjson.ErrRPCInvalidParams
+	}
+	switch {
+	case !btcjson.IsValidIDType(req.ID):
+		return btcjson.Request{Jsonrpc: btcjson.RpcVersion2}, false,
+			btcjson.ErrRPCInvalidRequest
+	case req.Method == "":
+		return btcjson.Request{Jsonrpc: btcjson.RpcVersion2, ID: req.ID},
+			false, btcjson.ErrRPCInvalidRequest
+	}
+	_, hasID := members["id"]
+	return req, !hasID, nil
+}
+
+// marshalResponse marshals the response to a request with the result and error
+// of its handler.  JSON-RPC 2.0 requests are replied to with a JSON-RPC 2.0
+// response, and all other requests with a JSON-RPC 1.0 response, which always
+// includes both the result and error.
+func marshalResponse(req *btcjson.Request, result interface{},
+	jsonErr *btcjson.RPCError) ([]byte, error) {
+
+	if req.Jsonrpc != btcjson.RpcVersion2 {
+		return btcjson.MarshalResponse(
+			btcjson.RpcVersion1, req.ID, result, jsonErr,
+		)
+	}
+
+	resp := response2{
+		Jsonrpc: btcjson.RpcVersion2,
+		Error:   jsonErr,
+		ID:      req.ID,
+	}
+	if jsonErr == nil {
+		var err error
+		resp.Result, err = json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(&resp)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestJSONRPCVersions ensures that JSON-RPC 2.0 requests are replied to with
+// spec-compliant responses, that JSON-RPC 2.0 notifications are not replied
+// to, and that the responses to JSON-RPC 1.0 requests are unchanged.
+func TestJSONRPCVersions(t *testing.T) {
+	srv := NewServer(&Options{}, nil, nil)
+
+	tests := []struct {
+		name    string
+		request string
+		status  int
+		resp    string
+	}{
+		{
+			name:    "1.0 success",
+			request: `{"jsonrpc":"1.0","method":"stop","params":[],"id":1}`,
+			status:  http.StatusOK,
+			resp:    `{"jsonrpc":"1.0","result":"btcwallet stopping","error":null,"id":1}`,
+		},
+		{
+			name:    "2.0 success",
+			request: `{"jsonrpc":"2.0","method":"stop","params":[],"id":1}`,
+			status:  http.StatusOK,
+			resp:    `{"jsonrpc":"2.0","result":"btcwallet stopping","id":1}`,
+		},
+		{
+			name:    "2.0 error",
+			request: `{"jsonrpc":"2.0","method":"nosuchmethod","params":[],"id":"a"}`,
+			status:  http.StatusOK,
+			resp:    `{"jsonrpc":"2.0","error":{"code":-1,"message":"Chain RPC is inactive"},"id":"a"}`,
+		},
+		{
+			name:    "2.0 null id",
+			request: `{"jsonrpc":"2.0","method":"stop","id":null}`,
+			status:  http.StatusOK,
+			resp:    `{"jsonrpc":"2.0","result":"btcwallet stopping","id":null}`,
+		},
+		{
+			name:    "2.0 notification",
+			request: `{"jsonrpc":"2.0","method":"stop","params":[]}`,
+			status:  http.StatusNoContent,
+		},
+		{
+			name:    "2.0 missing method",
+			request: `{"jsonrpc":"2.0","params":[],"id":2}`,
+			status:  http.StatusOK,
+			resp:    `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid request"},"id":2}`,
+		},
+		{
+			name:    "2.0 invalid id",
+			request: `{"jsonrpc":"2.0","method":"stop","id":{}}`,
+			status:  http.StatusOK,
+			resp:    `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid request"},"id":null}`,
+		},
+		{
+			name:    "2.0 invalid params",
+			request: `{"jsonrpc":"2.0","method":"stop","params":{},"id":3}`,
+			status:  http.StatusOK,
+			resp:    `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid parameters"},"id":3}`,
+		},
+		{
+			name:    "2.0 invalid params and id",
+			request: `{"jsonrpc":"2.0","method":"stop","params":"a","id":{}}`,
+			status:  http.StatusOK,
+			resp:    `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid parameters"},"id":null}`,
+		},
+		{
+			name:    "2.0 invalid method",
+			request: `{"jsonrpc":"2.0","method":1,"params":[],"id":4}`,
+			status:  http.StatusOK,
+			resp:    `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid request"},"id":4}`,
+		},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/",
+			strings.NewReader(test.request))
+		rec := httptest.NewRecorder()
+		srv.postClientRPC(rec, r, fullTier)
+
+		if rec.Code != test.status {
+			t.Errorf("%s: expected status %d, got %d", test.name,
+				test.status, rec.Code)
+			continue
+		}
+		if resp := rec.Body.String(); resp != test.resp {
+			t.Errorf("%s: expected response %s, got %s", test.name,
+				test.resp, resp)
+		}
+	}
+}
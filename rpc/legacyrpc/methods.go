@@ -12,6 +12,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -24,8 +25,15 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/bip322"
+	"github.com/btcsuite/btcwallet/internal/bip38"
+	"github.com/btcsuite/btcwallet/internal/cfgutil"
+	"github.com/btcsuite/btcwallet/internal/securemem"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 )
@@ -72,48 +80,56 @@ var rpcHandlers = map[string]struct {
 	noHelp bool
 }{
 	// Reference implementation wallet methods (implemented)
-	"addmultisigaddress":     {handler: addMultiSigAddress},
-	"createmultisig":         {handler: createMultiSig},
-	"dumpprivkey":            {handler: dumpPrivKey},
-	"getaccount":             {handler: getAccount},
-	"getaccountaddress":      {handler: getAccountAddress},
-	"getaddressesbyaccount":  {handler: getAddressesByAccount},
-	"getbalance":             {handler: getBalance},
-	"getbestblockhash":       {handler: getBestBlockHash},
-	"getblockcount":          {handler: getBlockCount},
-	"getinfo":                {handlerWithChain: getInfo},
-	"getnewaddress":          {handler: getNewAddress},
-	"getrawchangeaddress":    {handler: getRawChangeAddress},
-	"getreceivedbyaccount":   {handler: getReceivedByAccount},
-	"getreceivedbyaddress":   {handler: getReceivedByAddress},
-	"gettransaction":         {handler: getTransaction},
-	"help":                   {handler: helpNoChainRPC, handlerWithChain: helpWithChainRPC},
-	"importprivkey":          {handler: importPrivKey},
-	"keypoolrefill":          {handler: keypoolRefill},
-	"listaccounts":           {handler: listAccounts},
-	"listlockunspent":        {handler: listLockUnspent},
-	"listreceivedbyaccount":  {handler: listReceivedByAccount},
-	"listreceivedbyaddress":  {handler: listReceivedByAddress},
-	"listsinceblock":         {handlerWithChain: listSinceBlock},
-	"listtransactions":       {handler: listTransactions},
-	"listunspent":            {handler: listUnspent},
-	"lockunspent":            {handler: lockUnspent},
-	"sendfrom":               {handlerWithChain: sendFrom},
-	"sendmany":               {handler: sendMany},
-	"sendtoaddress":          {handler: sendToAddress},
-	"settxfee":               {handler: setTxFee},
-	"signmessage":            {handler: signMessage},
-	"signrawtransaction":     {handlerWithChain: signRawTransaction},
-	"validateaddress":        {handler: validateAddress},
-	"verifymessage":          {handler: verifyMessage},
-	"walletlock":             {handler: walletLock},
-	"walletpassphrase":       {handler: walletPassphrase},
-	"walletpassphrasechange": {handler: walletPassphraseChange},
+	"addmultisigaddress":           {handler: addMultiSigAddress},
+	"analyzepsbt":                  {handler: analyzePsbt},
+	"createmultisig":               {handler: createMultiSig},
+	"decodepsbt":                   {handler: decodePsbt},
+	"dumpprivkey":                  {handler: dumpPrivKey},
+	"dumpwallet":                   {handler: dumpWallet},
+	"getaccount":                   {handler: getAccount},
+	"getaccountaddress":            {handler: getAccountAddress},
+	"getaddressesbyaccount":        {handler: getAddressesByAccount},
+	"getaddressinfo":               {handler: getAddressInfo},
+	"getbalance":                   {handler: getBalance},
+	"getbalances":                  {handler: getBalances},
+	"getbestblockhash":             {handler: getBestBlockHash},
+	"getblockcount":                {handler: getBlockCount},
+	"getinfo":                      {handlerWithChain: getInfo},
+	"getnewaddress":                {handler: getNewAddress},
+	"getrawchangeaddress":          {handler: getRawChangeAddress},
+	"getreceivedbyaccount":         {handler: getReceivedByAccount},
+	"getreceivedbyaddress":         {handler: getReceivedByAddress},
+	"gettransaction":               {handler: getTransaction},
+	"getwalletinfo":                {handler: getWalletInfo},
+	"help":                         {handler: helpNoChainRPC, handlerWithChain: helpWithChainRPC},
+	"importdescriptors":            {handler: importDescriptors},
+	"importprivkey":                {handler: importPrivKey},
+	"importpubkey":                 {handler: importPubKey},
+	"keypoolrefill":                {handler: keypoolRefill},
+	"listaccounts":                 {handler: listAccounts},
+	"listdescriptors":              {handler: listDescriptors},
+	"listlockunspent":              {handler: listLockUnspent},
+	"listreceivedbyaccount":        {handler: listReceivedByAccount},
+	"listreceivedbyaddress":        {handler: listReceivedByAddress},
+	"listsinceblock":               {handlerWithChain: listSinceBlock},
+	"listtransactions":             {handler: listTransactions},
+	"listunspent":                  {handler: listUnspent},
+	"lockunspent":                  {handler: lockUnspent},
+	"sendfrom":                     {handlerWithChain: sendFrom},
+	"sendmany":                     {handler: sendMany},
+	"sendtoaddress":                {handler: sendToAddress},
+	"settxfee":                     {handler: setTxFee},
+	"signmessage":                  {handler: signMessage},
+	"signrawtransaction":           {handlerWithChain: signRawTransaction},
+	"signrawtransactionwithwallet": {handler: signRawTransactionWithWallet},
+	"validateaddress":              {handler: validateAddress},
+	"verifymessage":                {handler: verifyMessage},
+	"walletlock":                   {handler: walletLock},
+	"walletpassphrase":             {handler: walletPassphrase},
+	"walletpassphrasechange":       {handler: walletPassphraseChange},
 
 	// Reference implementation methods (still unimplemented)
 	"backupwallet":         {handler: unimplemented, noHelp: true},
-	"dumpwallet":           {handler: unimplemented, noHelp: true},
-	"getwalletinfo":        {handler: unimplemented, noHelp: true},
 	"importwallet":         {handler: unimplemented, noHelp: true},
 	"listaddressgroupings": {handler: unimplemented, noHelp: true},
 
@@ -124,17 +140,66 @@ var rpcHandlers = map[string]struct {
 	"setaccount":    {handler: unsupported, noHelp: true},
 
 	// Extensions to the reference client JSON-RPC API
-	"createnewaccount": {handler: createNewAccount},
-	"getbestblock":     {handler: getBestBlock},
+	"authorizekeyuse":     {handler: authorizeKeyUse},
+	"canceldrafttx":       {handler: cancelDraftTx},
+	"cancelrescan":        {handler: cancelRescan},
+	"cancelspend":         {handler: cancelSpend},
+	"committx":            {handler: commitTx},
+	"confirmspend":        {handler: confirmSpend},
+	"createnewaccount":    {handler: createNewAccount},
+	"createtx":            {handler: createTx},
+	"createwallet":        {handler: managementOnly},
+	"debuglevel":          {handler: managementOnly},
+	"estimatesendfee":     {handler: estimateSendFee},
+	"exportauditsnapshot": {handler: exportAuditSnapshot},
+	"exporthistory":       {handler: exportHistory},
+	"exportprivkeybip38":  {handler: exportPrivKeyBIP38},
+	"getaccountingreport": {handler: getAccountingReport},
+	"getaccountmetadata":  {handler: getAccountMetadata},
+	"getaccountxpub":      {handler: getAccountXpub},
+	"getaddressesbylabel": {handler: getAddressesByLabel},
+	"getbestblock":        {handler: getBestBlock},
+	"getlookahead":        {handler: getLookahead},
+	"getpaymentqr":        {handler: getPaymentQR},
+	"getpaymenturi":       {handler: getPaymentURI},
+	"getspendpolicy":      {handler: getSpendPolicy},
 	// This was an extension but the reference implementation added it as
 	// well, but with a different API (no account parameter).  It's listed
 	// here because it hasn't been update to use the reference
 	// implemenation's API.
-	"getunconfirmedbalance":   {handler: getUnconfirmedBalance},
-	"listaddresstransactions": {handler: listAddressTransactions},
-	"listalltransactions":     {handler: listAllTransactions},
-	"renameaccount":           {handler: renameAccount},
-	"walletislocked":          {handler: walletIsLocked},
+	"getunconfirmedbalance":    {handler: getUnconfirmedBalance},
+	"importscript":             {handler: importScript},
+	"listaddresstransactions":  {handler: listAddressTransactions},
+	"listalltransactions":      {handler: listAllTransactions},
+	"listexpiredtransactions":  {handler: listExpiredTransactions},
+	"listlabels":               {handler: listLabels},
+	"listrescans":              {handler: listRescans},
+	"listwallets":              {handler: managementOnly},
+	"loadwallet":               {handler: managementOnly},
+	"mergeaccounts":            {handler: mergeAccounts},
+	"notifytxconfirmations":    {handler: websocketOnly},
+	"renameaccount":            {handler: renameAccount},
+	"releaseaddress":           {handler: releaseAddress},
+	"rescanblockchain":         {handler: rescanBlockchain},
+	"reserveaddress":           {handler: reserveAddress},
+	"setaccountflag":           {handler: setAccountFlag},
+	"setaccountmetadata":       {handler: setAccountMetadata},
+	"setaccountpassphrase":     {handler: setAccountPassphrase},
+	"setdepositalert":          {handler: setDepositAlert},
+	"setlabel":                 {handler: setLabel},
+	"setlookahead":             {handler: setLookahead},
+	"setspendpolicy":           {handler: setSpendPolicy},
+	"signmessagebip322":        {handler: signMessageBIP322},
+	"subscribenotifications":   {handler: websocketOnly},
+	"sweepprivkey":             {handler: sweepPrivKey},
+	"unloadwallet":             {handler: managementOnly},
+	"unsubscribenotifications": {handler: websocketOnly},
+	"verifymessagebip322":      {handler: verifyMessageBIP322},
+	"walletfsck":               {handler: walletFsck},
+	"walletislocked":           {handler: walletIsLocked},
+	"walletextendunlock":       {handler: walletExtendUnlock},
+	"walletlockall":            {handler: walletLockAll},
+	"walletunlockeduntil":      {handler: walletUnlockedUntil},
 }
 
 // unimplemented handles an unimplemented RPC request with the
@@ -155,20 +220,606 @@ func unsupported(interface{}, *wallet.Wallet) (interface{}, error) {
 	}
 }
 
+// websocketOnly handles a request of a method which is only supported over
+// websocket connections with the appropriate error.
+func websocketOnly(interface{}, *wallet.Wallet) (interface{}, error) {
+	return nil, &btcjson.RPCError{
+		Code:    -1,
+		Message: "Request requires a websocket connection",
+	}
+}
+
 // lazyHandler is a closure over a requestHandler or passthrough request with
 // the RPC server's wallet and chain server variables as part of the closure
 // context.
 type lazyHandler func() (interface{}, *btcjson.RPCError)
 
+// sendOptionsParams maps the reference send methods, and the estimatesendfee
+// and createtx extensions previewing them, which accept a trailing walletjson.SendOptions
+// object to the number of parameters of the command.
+var sendOptionsParams = map[string]int{
+	"sendfrom":      6,
+	"sendmany":      4,
+	"sendtoaddress": 4,
+
+	"createtx":        4,
+	"estimatesendfee": 3,
+}
+
+// sendCmd is a parsed reference send command along with the btcwallet
+// extension options provided with it.
+type sendCmd struct {
+	cmd  interface{}
+	opts walletjson.SendOptions
+
+	// defaultUnit is the denomination of the request's amounts when the
+	// options do not specify a unit.
+	defaultUnit btcutil.AmountUnit
+}
+
+// amount converts an amount parameter of the send request to a
+// btcutil.Amount using the unit of the request.
+func (c *sendCmd) amount(value float64) (btcutil.Amount, error) {
+	unit := c.defaultUnit
+	if c.opts.Unit != nil {
+		var err error
+		unit, err = cfgutil.ParseAmountUnit(*c.opts.Unit)
+		if err != nil {
+			return 0, InvalidParameterError{err}
+		}
+	}
+	return cfgutil.NewAmountInUnit(value, unit)
+}
+
+// feeRate returns the fee per kilobyte of the transaction of the send request,
+// which is the default relay fee unless the options override it.  Fee rates
+// below the default relay fee are rejected, as transactions paying them would
+// not be relayed.
+func (c *sendCmd) feeRate() (btcutil.Amount, error) {
+	if c.opts.FeeRate == nil {
+		return txrules.DefaultRelayFeePerKb, nil
+	}
+	feeRate, err := c.amount(*c.opts.FeeRate)
+	if err != nil {
+		return 0, err
+	}
+	if feeRate < txrules.DefaultRelayFeePerKb {
+		return 0, InvalidParameterError{fmt.Errorf("feerate must "+
+			"be at least the minimum relay fee of %v/kB",
+			txrules.DefaultRelayFeePerKb)}
+	}
+	return feeRate, nil
+}
+
+// txCreateOptions returns the wallet transaction creation options requested
+// by the send options.
+func (c *sendCmd) txCreateOptions(params *chaincfg.Params) (
+	[]wallet.TxCreateOption, error) {
+
+	var optFuncs []wallet.TxCreateOption
+	if c.opts.AllowReuse != nil && *c.opts.AllowReuse {
+		optFuncs = append(optFuncs, wallet.WithAllowAddressReuse())
+	}
+
+	noChange := c.opts.NoChange != nil && *c.opts.NoChange
+	if noChange && c.opts.ChangeAddress != nil {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: "Options 'changeaddress' and 'nochange' can " +
+				"not be combined",
+		}
+	}
+	switch {
+	case noChange:
+		optFuncs = append(optFuncs, wallet.WithNoChange())
+
+	case c.opts.ChangeAddress != nil:
+		addr, err := decodeAddress(*c.opts.ChangeAddress, params)
+		if err != nil {
+			return nil, err
+		}
+		optFuncs = append(optFuncs, wallet.WithChangeAddress(addr))
+	}
+
+	subtractAll := c.opts.SubtractFeeFromAmount != nil &&
+		*c.opts.SubtractFeeFromAmount
+	if subtractAll && c.opts.SubtractFeeFrom != nil {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: "Options 'subtractfeefromamount' and " +
+				"'subtractfeefrom' can not be combined",
+		}
+	}
+	var subtractFrom []string
+	switch {
+	case subtractAll:
+		subtractFrom = c.recipients()
+
+	case c.opts.SubtractFeeFrom != nil:
+		recipients := make(map[string]struct{})
+		for _, addr := range c.recipients() {
+			recipients[addr] = struct{}{}
+		}
+		for _, addr := range *c.opts.SubtractFeeFrom {
+			if _, ok := recipients[addr]; !ok {
+				return nil, &btcjson.RPCError{
+					Code: btcjson.ErrRPCInvalidParameter,
+					Message: fmt.Sprintf("Address %s of "+
+						"'subtractfeefrom' is not a "+
+						"recipient", addr),
+				}
+			}
+			subtractFrom = append(subtractFrom, addr)
+		}
+	}
+	if len(subtractFrom) > 0 {
+		pkScripts := make([][]byte, 0, len(subtractFrom))
+		for _, encoded := range subtractFrom {
+			addr, err := decodeAddress(encoded, params)
+			if err != nil {
+				return nil, err
+			}
+			pkScript, err := txscript.PayToAddrScript(addr)
+			if err != nil {
+				return nil, err
+			}
+			pkScripts = append(pkScripts, pkScript)
+		}
+		optFuncs = append(optFuncs, wallet.WithSubtractFeeFrom(pkScripts...))
+	}
+
+	return optFuncs, nil
+}
+
+// recipients returns the encoded addresses paid by the send request.
+func (c *sendCmd) recipients() []string {
+	switch cmd := c.cmd.(type) {
+	case *btcjson.SendFromCmd:
+		return []string{cmd.ToAddress}
+	case *btcjson.SendToAddressCmd:
+		return []string{cmd.Address}
+	case *btcjson.SendManyCmd:
+		addrs := make([]string, 0, len(cmd.Amounts))
+		for addr := range cmd.Amounts {
+			addrs = append(addrs, addr)
+		}
+		return addrs
+	case *walletjson.EstimateSendFeeCmd:
+		addrs := make([]string, 0, len(cmd.Amounts))
+		for addr := range cmd.Amounts {
+			addrs = append(addrs, addr)
+		}
+		return addrs
+	case *walletjson.CreateTxCmd:
+		addrs := make([]string, 0, len(cmd.Amounts))
+		for addr := range cmd.Amounts {
+			addrs = append(addrs, addr)
+		}
+		return addrs
+	}
+	return nil
+}
+
+// listOptionsParams maps the reference list methods which accept a trailing
+// walletjson.ListOptions object to the number of parameters of the reference
+// command.
+var listOptionsParams = map[string]int{
+	"listreceivedbyaccount": 3,
+	"listreceivedbyaddress": 3,
+	"listtransactions":      4,
+	"listunspent":           3,
+}
+
+// listCmd is a parsed reference list command along with the btcwallet
+// extension options provided with it.
+type listCmd struct {
+	cmd  interface{}
+	opts walletjson.ListOptions
+}
+
+// unmarshalListCmd unmarshals a request of a method of listOptionsParams,
+// which accepts a trailing options object following the numParams reference
+// parameters.
+func unmarshalListCmd(request *btcjson.Request, numParams int) (*listCmd, error) {
+	lcmd := new(listCmd)
+	if len(request.Params) > numParams {
+		if len(request.Params) != numParams+1 {
+			return nil, errors.New("too many parameters")
+		}
+		err := json.Unmarshal(request.Params[numParams], &lcmd.opts)
+		if err != nil {
+			return nil, err
+		}
+		r := *request
+		r.Params = request.Params[:numParams]
+		request = &r
+	}
+	cmd, err := btcjson.UnmarshalCmd(request)
+	if err != nil {
+		return nil, err
+	}
+	lcmd.cmd = cmd
+	return lcmd, nil
+}
+
+// unmarshalListUnspentCmd unmarshals a listunspent request.  As by the
+// reference implementation, the include_unsafe flag may be passed as the
+// fourth parameter followed by the query options object as the fifth.  As an
+// extension, the options object may instead be passed as the fourth parameter.
+func unmarshalListUnspentCmd(request *btcjson.Request) (*listCmd, error) {
+	numParams := listOptionsParams[request.Method]
+	if len(request.Params) <= numParams ||
+		bytes.HasPrefix(bytes.TrimSpace(request.Params[numParams]), []byte("{")) {
+
+		return unmarshalListCmd(request, numParams)
+	}
+
+	var includeUnsafe *bool
+	err := json.Unmarshal(request.Params[numParams], &includeUnsafe)
+	if err != nil {
+		return nil, errors.New("include_unsafe must be a boolean")
+	}
+	r := *request
+	r.Params = make([]json.RawMessage, 0, len(request.Params)-1)
+	r.Params = append(r.Params, request.Params[:numParams]...)
+	r.Params = append(r.Params, request.Params[numParams+1:]...)
+	lcmd, err := unmarshalListCmd(&r, numParams)
+	if err != nil {
+		return nil, err
+	}
+	if includeUnsafe != nil {
+		if lcmd.opts.IncludeUnsafe != nil {
+			return nil, errors.New("include_unsafe must not be " +
+				"passed as both a parameter and an option")
+		}
+		lcmd.opts.IncludeUnsafe = includeUnsafe
+	}
+	return lcmd, nil
+}
+
+// checkNoUnspentOptions returns an error when options only accepted by
+// listunspent are set.
+func (c *listCmd) checkNoUnspentOptions() error {
+	if c.opts.MinimumAmount != nil || c.opts.MaximumAmount != nil ||
+		c.opts.MaximumCount != nil || c.opts.IncludeUnsafe != nil {
+
+		return InvalidParameterError{
+			errors.New("options 'minimumAmount', 'maximumAmount', " +
+				"'maximumCount' and 'include_unsafe' are only " +
+				"accepted by listunspent"),
+		}
+	}
+	return nil
+}
+
+// timeRange returns the range of times the listed transactions must have been
+// received in.
+func (c *listCmd) timeRange() *wallet.TimeRange {
+	var r wallet.TimeRange
+	if c.opts.StartTime != nil {
+		r.Start = time.Unix(*c.opts.StartTime, 0)
+	}
+	if c.opts.EndTime != nil {
+		r.End = time.Unix(*c.opts.EndTime, 0)
+	}
+	return &r
+}
+
+// page returns the bounds of the page of n results requested by the cursor,
+// skip and count options.  The cursor identifying each result is returned by
+// key.
+func (c *listCmd) page(n int, key func(i int) string) (int, int, error) {
+	start := 0
+	if c.opts.Cursor != nil {
+		start = -1
+		for i := 0; i < n; i++ {
+			if key(i) == *c.opts.Cursor {
+				start = i + 1
+				break
+			}
+		}
+		if start == -1 {
+			return 0, 0, InvalidParameterError{
+				fmt.Errorf("cursor %q not found", *c.opts.Cursor),
+			}
+		}
+	}
+	if c.opts.Skip != nil {
+		if *c.opts.Skip < 0 {
+			return 0, 0, InvalidParameterError{
+				errors.New("skip must not be negative"),
+			}
+		}
+		start += *c.opts.Skip
+		if start > n {
+			start = n
+		}
+	}
+	end := n
+	if c.opts.Count != nil {
+		if *c.opts.Count < 0 {
+			return 0, 0, InvalidParameterError{
+				errors.New("count must not be negative"),
+			}
+		}
+		if start+*c.opts.Count < end {
+			end = start + *c.opts.Count
+		}
+	}
+	return start, end, nil
+}
+
+// listAccountsCmd is a parsed listaccounts command along with the btcwallet
+// extension parameter requesting verbose results.
+type listAccountsCmd struct {
+	cmd     *btcjson.ListAccountsCmd
+	verbose bool
+}
+
+// unmarshalListAccountsCmd unmarshals a listaccounts request, which accepts an
+// optional verbose flag following the reference minconf parameter.
+func unmarshalListAccountsCmd(request *btcjson.Request) (*listAccountsCmd, error) {
+	lcmd := new(listAccountsCmd)
+	if len(request.Params) > 1 {
+		if len(request.Params) != 2 {
+			return nil, errors.New("too many parameters")
+		}
+		err := json.Unmarshal(request.Params[1], &lcmd.verbose)
+		if err != nil {
+			return nil, err
+		}
+		r := *request
+		r.Params = request.Params[:1]
+		request = &r
+	}
+	cmd, err := btcjson.UnmarshalCmd(request)
+	if err != nil {
+		return nil, err
+	}
+	lcmd.cmd = cmd.(*btcjson.ListAccountsCmd)
+	return lcmd, nil
+}
+
+// lockParams maps the methods which lock, unlock, or report the lock state of
+// the wallet to the number of parameters of the reference command.
+var lockParams = map[string]int{
+	"walletislocked":   0,
+	"walletlock":       0,
+	"walletpassphrase": 2,
+}
+
+// allAccounts is the account name which selects the wallet and every account
+// protected by its own passphrase in lock requests.
+const allAccounts = "*"
+
+// lockCmd is a parsed lock command along with the btcwallet extension
+// parameters.  A nil account selects the wallet itself, and the unlock level
+// is only accepted by walletpassphrase.  The passphrase of walletpassphrase
+// requests is decoded into secure memory rather than the reference command,
+// and must be freed by the handler.
+type lockCmd struct {
+	cmd        interface{}
+	account    *string
+	level      *string
+	passphrase *securemem.Buffer
+}
+
+// unmarshalLockCmd unmarshals a lock request, which accepts an optional
+// account name following the numParams reference parameters, and for
+// walletpassphrase an optional unlock level following the account.
+func unmarshalLockCmd(request *btcjson.Request, numParams int) (*lockCmd, error) {
+	maxParams := numParams + 1
+	if request.Method == "walletpassphrase" {
+		maxParams++
+	}
+
+	lcmd := new(lockCmd)
+	if request.Method == "walletpassphrase" {
+		var err error
+		lcmd.passphrase, request, err = extractPassphrase(request, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(request.Params) > numParams {
+		if len(request.Params) > maxParams {
+			return nil, errors.New("too many parameters")
+		}
+		err := json.Unmarshal(request.Params[numParams], &lcmd.account)
+		if err != nil {
+			return nil, err
+		}
+		if len(request.Params) > numParams+1 {
+			err := json.Unmarshal(
+				request.Params[numParams+1], &lcmd.level,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+		r := *request
+		r.Params = request.Params[:numParams]
+		request = &r
+	}
+	cmd, err := btcjson.UnmarshalCmd(request)
+	if err != nil {
+		lcmd.passphrase.Free()
+		return nil, err
+	}
+	lcmd.cmd = cmd
+	return lcmd, nil
+}
+
+// extractPassphrase decodes the passphrase parameter at index i of a request
+// into secure memory and scrubs it from the request, so that it never ends up
+// in a garbage collected string.  The returned request holds an empty string
+// in place of the passphrase, and the passphrase is nil if the request has no
+// parameter at the index.
+func extractPassphrase(request *btcjson.Request, i int) (*securemem.Buffer,
+	*btcjson.Request, error) {
+
+	if len(request.Params) <= i {
+		return nil, request, nil
+	}
+	passphrase, err := securemem.DecodeJSONString(request.Params[i])
+	if err != nil {
+		return nil, nil, err
+	}
+	zero.Bytes(request.Params[i])
+
+	r := *request
+	r.Params = make([]json.RawMessage, len(request.Params))
+	copy(r.Params, request.Params)
+	r.Params[i] = []byte(`""`)
+	return passphrase, &r, nil
+}
+
+// importPrivKeyCmd is a parsed importprivkey command along with the btcwallet
+// extension parameters holding the passphrase of a BIP0038 encrypted key,
+// which is decoded into secure memory and must be freed by the handler, and
+// the birthday of the key.
+type importPrivKeyCmd struct {
+	cmd        *btcjson.ImportPrivKeyCmd
+	passphrase *securemem.Buffer
+	birthday   *int64
+}
+
+// unmarshalImportPrivKeyCmd unmarshals an importprivkey request, which accepts
+// an optional BIP0038 passphrase and key birthday following the reference
+// rescan parameter.
+func unmarshalImportPrivKeyCmd(request *btcjson.Request) (*importPrivKeyCmd, error) {
+	icmd := new(importPrivKeyCmd)
+	if len(request.Params) > 3 {
+		if len(request.Params) > 5 {
+			return nil, errors.New("too many parameters")
+		}
+		if len(request.Params) == 5 {
+			err := json.Unmarshal(request.Params[4], &icmd.birthday)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if string(request.Params[3]) != "null" {
+			var err error
+			icmd.passphrase, request, err = extractPassphrase(
+				request, 3,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+		r := *request
+		r.Params = request.Params[:3]
+		request = &r
+	}
+	cmd, err := btcjson.UnmarshalCmd(request)
+	if err != nil {
+		icmd.passphrase.Free()
+		return nil, err
+	}
+	icmd.cmd = cmd.(*btcjson.ImportPrivKeyCmd)
+	return icmd, nil
+}
+
+// exportPrivKeyBIP38Cmd is a parsed exportprivkeybip38 command.  The
+// passphrase is decoded into secure memory rather than the command, and must
+// be freed by the handler.
+type exportPrivKeyBIP38Cmd struct {
+	cmd        *walletjson.ExportPrivKeyBIP38Cmd
+	passphrase *securemem.Buffer
+}
+
+// unmarshalExportPrivKeyBIP38Cmd unmarshals an exportprivkeybip38 request.
+func unmarshalExportPrivKeyBIP38Cmd(request *btcjson.Request) (*exportPrivKeyBIP38Cmd, error) {
+	ecmd := new(exportPrivKeyBIP38Cmd)
+	passphrase, request, err := extractPassphrase(request, 1)
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := btcjson.UnmarshalCmd(request)
+	if err != nil {
+		passphrase.Free()
+		return nil, err
+	}
+	ecmd.cmd = cmd.(*walletjson.ExportPrivKeyBIP38Cmd)
+	ecmd.passphrase = passphrase
+	return ecmd, nil
+}
+
+// unmarshalCmd unmarshals the parameters of a request into the request's
+// command type.  Send requests are returned as a *sendCmd, with any options
+// following the reference parameters parsed into the sendCmd's options,
+// listaccounts requests are returned as a *listAccountsCmd, lock requests are
+// returned as a *lockCmd, and wallet loading requests are returned as a
+// *walletCmd.
+func unmarshalCmd(request *btcjson.Request, defaultUnit btcutil.AmountUnit) (interface{}, error) {
+	switch request.Method {
+	case "listaccounts":
+		return unmarshalListAccountsCmd(request)
+	case "importprivkey":
+		return unmarshalImportPrivKeyCmd(request)
+	case "exportprivkeybip38":
+		return unmarshalExportPrivKeyBIP38Cmd(request)
+	case "listunspent":
+		return unmarshalListUnspentCmd(request)
+	}
+	if numParams, ok := lockParams[request.Method]; ok {
+		return unmarshalLockCmd(request, numParams)
+	}
+	if numParams, ok := listOptionsParams[request.Method]; ok {
+		return unmarshalListCmd(request, numParams)
+	}
+	if numParams, ok := walletOptionsParams[request.Method]; ok {
+		return unmarshalWalletCmd(request, numParams)
+	}
+
+	numParams, ok := sendOptionsParams[request.Method]
+	if !ok {
+		return btcjson.UnmarshalCmd(request)
+	}
+
+	scmd := &sendCmd{defaultUnit: defaultUnit}
+	if len(request.Params) > numParams {
+		if len(request.Params) != numParams+1 {
+			return nil, errors.New("too many parameters")
+		}
+		err := json.Unmarshal(request.Params[numParams], &scmd.opts)
+		if err != nil {
+			return nil, err
+		}
+		r := *request
+		r.Params = request.Params[:numParams]
+		request = &r
+	}
+	cmd, err := btcjson.UnmarshalCmd(request)
+	if err != nil {
+		return nil, err
+	}
+	scmd.cmd = cmd
+	return scmd, nil
+}
+
 // lazyApplyHandler looks up the best request handler func for the method,
 // returning a closure that will execute it with the (required) wallet and
 // (optional) consensus RPC server.  If no handlers are found and the
 // chainClient is not nil, the returned handler performs RPC passthrough.
-func lazyApplyHandler(request *btcjson.Request, w *wallet.Wallet, chainClient chain.Interface) lazyHandler {
+// Amounts of send requests that do not specify a unit are interpreted using
+// defaultUnit.
+func lazyApplyHandler(request *btcjson.Request, w *wallet.Wallet,
+	chainClient chain.Interface, defaultUnit btcutil.AmountUnit,
+	tier authTier) lazyHandler {
+
 	handlerData, ok := rpcHandlers[request.Method]
+	if handler, public := publicHandlers[request.Method]; public &&
+		tier == publicTier {
+
+		handlerData.handler = handler
+		handlerData.handlerWithChain = nil
+	}
 	if ok && handlerData.handlerWithChain != nil && w != nil && chainClient != nil {
 		return func() (interface{}, *btcjson.RPCError) {
-			cmd, err := btcjson.UnmarshalCmd(request)
+			cmd, err := unmarshalCmd(request, defaultUnit)
 			if err != nil {
 				return nil, btcjson.ErrRPCInvalidRequest
 			}
@@ -190,7 +841,7 @@ func lazyApplyHandler(request *btcjson.Request, w *wallet.Wallet, chainClient ch
 	}
 	if ok && handlerData.handler != nil && w != nil {
 		return func() (interface{}, *btcjson.RPCError) {
-			cmd, err := btcjson.UnmarshalCmd(request)
+			cmd, err := unmarshalCmd(request, defaultUnit)
 			if err != nil {
 				return nil, btcjson.ErrRPCInvalidRequest
 			}
@@ -227,33 +878,6 @@ func lazyApplyHandler(request *btcjson.Request, w *wallet.Wallet, chainClient ch
 	}
 }
 
-// makeResponse makes the JSON-RPC response struct for the result and error
-// returned by a requestHandler.  The returned response is not ready for
-// marshaling and sending off to a client, but must be
-func makeResponse(id, result interface{}, err error) btcjson.Response {
-	idPtr := idPointer(id)
-	if err != nil {
-		return btcjson.Response{
-			ID:    idPtr,
-			Error: jsonError(err),
-		}
-	}
-	resultBytes, err := json.Marshal(result)
-	if err != nil {
-		return btcjson.Response{
-			ID: idPtr,
-			Error: &btcjson.RPCError{
-				Code:    btcjson.ErrRPCInternal.Code,
-				Message: "Unexpected error marshalling result",
-			},
-		}
-	}
-	return btcjson.Response{
-		ID:     idPtr,
-		Result: json.RawMessage(resultBytes),
-	}
-}
-
 // jsonError creates a JSON-RPC error from the Go error.
 func jsonError(err error) *btcjson.RPCError {
 	if err == nil {
@@ -276,6 +900,8 @@ func jsonError(err error) *btcjson.RPCError {
 		if e.ErrorCode == waddrmgr.ErrWrongPassphrase {
 			code = btcjson.ErrRPCWalletPassphraseIncorrect
 		}
+	case *wallet.SpendPolicyError:
+		code = ErrRPCSpendPolicy
 	}
 	return &btcjson.RPCError{
 		Code:    code,
@@ -392,9 +1018,29 @@ func dumpPrivKey(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	return key, err
 }
 
+// exportPrivKeyBIP38 handles an exportprivkeybip38 request with the private
+// key of the address encrypted as a BIP0038 key.
+func exportPrivKeyBIP38(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	ecmd := icmd.(*exportPrivKeyBIP38Cmd)
+	defer ecmd.passphrase.Free()
+
+	addr, err := decodeAddress(ecmd.cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := w.DumpBIP38PrivateKey(addr, ecmd.passphrase.Bytes())
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		// Address was found, but the private key isn't
+		// accessible.
+		return nil, &ErrWalletUnlockNeeded
+	}
+	return key, err
+}
+
 // getAddressesByAccount handles a getaddressesbyaccount request by returning
-// all addresses for an account, or an error if the requested account does
-// not exist.
+// the payment addresses of an account, excluding its change addresses, or an
+// error if the requested account does not exist.
 func getAddressesByAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	cmd := icmd.(*btcjson.GetAddressesByAccountCmd)
 
@@ -403,7 +1049,7 @@ func getAddressesByAccount(icmd interface{}, w *wallet.Wallet) (interface{}, err
 		return nil, err
 	}
 
-	addrs, err := w.AccountAddresses(account)
+	addrs, err := w.AccountReceiveAddresses(account)
 	if err != nil {
 		return nil, err
 	}
@@ -415,6 +1061,40 @@ func getAddressesByAccount(icmd interface{}, w *wallet.Wallet) (interface{}, err
 	return addrStrs, nil
 }
 
+// getAddressesByLabel handles a getaddressesbylabel request by returning the
+// addresses with a label and whether they are addresses of the wallet, or an
+// error if no address has the label.
+func getAddressesByLabel(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetAddressesByLabelCmd)
+
+	addrs, err := w.AddressesByLabel(cmd.Label)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWalletInvalidAccountName,
+			Message: fmt.Sprintf("No addresses with label %s", cmd.Label),
+		}
+	}
+
+	result := make(map[string]walletjson.AddressPurposeResult, len(addrs))
+	for _, addr := range addrs {
+		mine, err := w.HaveAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+		purpose := "send"
+		if mine {
+			purpose = "receive"
+		}
+		result[addr.EncodeAddress()] = walletjson.AddressPurposeResult{
+			Purpose: purpose,
+		}
+	}
+	return result, nil
+}
+
 // getBalance handles a getbalance request by returning the balance for an
 // account (wallet), or an error if the requested account does not
 // exist.
@@ -447,25 +1127,117 @@ func getBalance(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	return balance.ToBTC(), nil
 }
 
-// getBestBlock handles a getbestblock request by returning a JSON object
-// with the height and hash of the most recently processed block.
-func getBestBlock(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	blk := w.Manager.SyncedTo()
-	result := &btcjson.GetBestBlockResult{
-		Hash:   blk.Hash.String(),
-		Height: blk.Height,
+// getBalances handles a getbalances request by returning the balances of the
+// wallet and of each account, split into the trusted balance, the unconfirmed
+// balance paid by other wallets and the immature coinbase balance.
+func getBalances(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	total, accounts, err := w.BalanceBreakdowns(waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		return nil, err
 	}
-	return result, nil
-}
 
-// getBestBlockHash handles a getbestblockhash request by returning the hash
-// of the most recently processed block.
-func getBestBlockHash(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	blk := w.Manager.SyncedTo()
-	return blk.Hash.String(), nil
-}
+	res := &walletjson.GetBalancesResult{
+		Mine:     balanceDetails(total),
+		Accounts: make(map[string]btcjson.BalanceDetailsResult, len(accounts)),
+	}
+	for i := range accounts {
+		res.Accounts[accounts[i].AccountName] = balanceDetails(
+			&accounts[i].BalanceBreakdown,
+		)
+	}
 
-// getBlockCount handles a getblockcount request by returning the chain height
+	if rate := w.FiatRate(); rate != nil {
+		res.Fiat = &walletjson.FiatBalancesResult{
+			Currency: rate.Currency,
+			Rate:     rate.Rate,
+			RateTime: rate.Time.Unix(),
+			Mine:     rate.Value(balanceTotal(total)),
+			Accounts: make(map[string]float64, len(accounts)),
+		}
+		for i := range accounts {
+			b := &accounts[i].BalanceBreakdown
+			res.Fiat.Accounts[accounts[i].AccountName] = rate.Value(
+				balanceTotal(b),
+			)
+		}
+	}
+	return res, nil
+}
+
+// balanceTotal returns the total of a balance breakdown, including unconfirmed
+// and immature outputs.
+func balanceTotal(b *wallet.BalanceBreakdown) btcutil.Amount {
+	return b.Confirmed + b.TrustedPending + b.UntrustedPending + b.Immature
+}
+
+// balanceDetails returns the getbalances details of a balance breakdown.  The
+// confirmed balance and the unconfirmed balance of transactions only spending
+// wallet outputs are trusted.
+func balanceDetails(b *wallet.BalanceBreakdown) btcjson.BalanceDetailsResult {
+	return btcjson.BalanceDetailsResult{
+		Trusted:          (b.Confirmed + b.TrustedPending).ToBTC(),
+		UntrustedPending: b.UntrustedPending.ToBTC(),
+		Immature:         b.Immature.ToBTC(),
+	}
+}
+
+// getWalletInfo handles a getwalletinfo request by returning the wallet's
+// balances, lock state and the health of the chain followed by the chain
+// server.  Sends are reported as risky while the chain appears stalled or on a
+// minority fork.
+func getWalletInfo(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	balance, err := w.CalculateBalance(1)
+	if err != nil {
+		return nil, err
+	}
+	unconfirmed, err := w.CalculateBalance(0)
+	if err != nil {
+		return nil, err
+	}
+	immature, err := w.CalculateImmatureBalance()
+	if err != nil {
+		return nil, err
+	}
+
+	health := w.ChainHealth()
+	return &walletjson.GetWalletInfoResult{
+		Balance:            balance.ToBTC(),
+		UnconfirmedBalance: (unconfirmed - balance).ToBTC(),
+		ImmatureBalance:    immature.ToBTC(),
+		Unlocked:           !w.Locked(),
+		ChainStalled:       health.Stalled,
+		MinorityFork:       health.MinorityFork,
+		LastBlockSeen:      health.LastBlockSeen.Unix(),
+		SendsRisky:         health.Risky(),
+	}, nil
+}
+
+// getBestBlock handles a getbestblock request by returning a JSON object
+// with the height and hash of the most recently processed block.
+func getBestBlock(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	blk := w.Manager.SyncedTo()
+	result := &btcjson.GetBestBlockResult{
+		Hash:   blk.Hash.String(),
+		Height: blk.Height,
+	}
+	return result, nil
+}
+
+// getLookahead handles a getlookahead request by returning the number of
+// addresses past the last handed out address of each account branch which are
+// watched for payments.
+func getLookahead(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	return w.LookaheadWindow(), nil
+}
+
+// getBestBlockHash handles a getbestblockhash request by returning the hash
+// of the most recently processed block.
+func getBestBlockHash(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	blk := w.Manager.SyncedTo()
+	return blk.Hash.String(), nil
+}
+
+// getBlockCount handles a getblockcount request by returning the chain height
 // of the most recently processed block.
 func getBlockCount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	blk := w.Manager.SyncedTo()
@@ -544,267 +1316,845 @@ func getAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	return acctName, nil
 }
 
-// getAccountAddress handles a getaccountaddress by returning the most
-// recently-created chained address that has not yet been used (does not yet
-// appear in the blockchain, or any tx that has arrived in the btcd mempool).
-// If the most recently-requested address has been used, a new address (the
-// next chained address in the keypool) is used.  This can fail if the keypool
-// runs out (and will return btcjson.ErrRPCWalletKeypoolRanOut if that happens).
-func getAccountAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.GetAccountAddressCmd)
-
-	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
-	if err != nil {
-		return nil, err
+// accountMetadataResult returns the JSON-RPC representation of the metadata of
+// the named account.
+func accountMetadataResult(name string, meta *waddrmgr.AccountMetadata) *walletjson.AccountMetadataResult {
+	result := &walletjson.AccountMetadataResult{
+		Account:      name,
+		Description:  meta.Description,
+		Tags:         meta.Tags,
+		AvoidReuse:   meta.AvoidReuse,
+		DepositAlert: meta.DepositAlert.ToBTC(),
 	}
-	addr, err := w.CurrentAddress(account, waddrmgr.KeyScopeBIP0044)
-	if err != nil {
-		return nil, err
+	if !meta.Created.IsZero() {
+		result.Created = meta.Created.Unix()
 	}
-
-	return addr.EncodeAddress(), err
+	if result.Tags == nil {
+		result.Tags = []string{}
+	}
+	return result
 }
 
-// getUnconfirmedBalance handles a getunconfirmedbalance extension request
-// by returning the current unconfirmed balance of an account.
-func getUnconfirmedBalance(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.GetUnconfirmedBalanceCmd)
+// getAccountMetadata handles a getaccountmetadata request by returning the
+// description, creation time and purpose tags of an account.
+func getAccountMetadata(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetAccountMetadataCmd)
 
-	acctName := defaultAccountName
-	if cmd.Account != nil {
-		acctName = *cmd.Account
-	}
-	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, acctName)
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
 	if err != nil {
 		return nil, err
 	}
-	bals, err := w.CalculateAccountBalances(account, 1)
+	meta, err := w.AccountMetadata(waddrmgr.KeyScopeBIP0044, account)
 	if err != nil {
 		return nil, err
 	}
-
-	return (bals.Total - bals.Spendable).ToBTC(), nil
+	return accountMetadataResult(cmd.Account, meta), nil
 }
 
-// importPrivKey handles an importprivkey request by parsing
-// a WIF-encoded private key and adding it to an account.
-func importPrivKey(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.ImportPrivKeyCmd)
-
-	// Ensure that private keys are only imported to the correct account.
-	//
-	// Yes, Label is the account name.
-	if cmd.Label != nil && *cmd.Label != waddrmgr.ImportedAddrAccountName {
-		return nil, &ErrNotImportedAccount
-	}
+// getAccountXpub handles a getaccountxpub extension request by returning the
+// extended public key of an account along with the origin of the key, which is
+// sufficient to watch the account from another wallet.
+func getAccountXpub(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetAccountXpubCmd)
 
-	wif, err := btcutil.DecodeWIF(cmd.PrivKey)
+	scope := waddrmgr.KeyScopeBIP0044
+	props, err := w.AccountPropertiesByName(scope, *cmd.Account)
 	if err != nil {
-		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCInvalidAddressOrKey,
-			Message: "WIF decode failed: " + err.Error(),
-		}
+		return nil, err
 	}
-	if !wif.IsForNet(w.ChainParams()) {
+	if props.AccountPubKey == nil {
 		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCInvalidAddressOrKey,
-			Message: "Key is not intended for " + w.ChainParams().Name,
+			Code: btcjson.ErrRPCWallet,
+			Message: fmt.Sprintf("account %q has no extended public "+
+				"key", *cmd.Account),
 		}
 	}
 
-	// Import the private key, handling any errors.
-	_, err = w.ImportPrivateKey(waddrmgr.KeyScopeBIP0044, wif, nil, *cmd.Rescan)
-	switch {
-	case waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress):
-		// Do not return duplicate key errors to the client.
-		return nil, nil
-	case waddrmgr.IsError(err, waddrmgr.ErrLocked):
-		return nil, &ErrWalletUnlockNeeded
+	result := &walletjson.GetAccountXpubResult{
+		Account: *cmd.Account,
+		Xpub:    props.AccountPubKey.String(),
+		Path: fmt.Sprintf("m/%d'/%d'/%d'", scope.Purpose, scope.Coin,
+			props.AccountNumber),
 	}
-
-	return nil, err
+	if props.MasterKeyFingerprint != 0 {
+		result.MasterFingerprint = fingerprintHex(
+			props.MasterKeyFingerprint,
+		)
+	}
+	return result, nil
 }
 
-// keypoolRefill handles the keypoolrefill command. Since we handle the keypool
-// automatically this does nothing since refilling is never manually required.
-func keypoolRefill(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	return nil, nil
+// addressTypeScopes maps the address types of the reference implementation to
+// the key scopes of the accounts deriving addresses of the type.
+var addressTypeScopes = map[string]waddrmgr.KeyScope{
+	"legacy":      waddrmgr.KeyScopeBIP0044,
+	"p2sh-segwit": waddrmgr.KeyScopeBIP0049Plus,
+	"bech32":      waddrmgr.KeyScopeBIP0084,
 }
 
-// createNewAccount handles a createnewaccount request by creating and
-// returning a new account. If the last account has no transaction history
-// as per BIP 0044 a new account cannot be created so an error will be returned.
-func createNewAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.CreateNewAccountCmd)
-
-	// The wildcard * is reserved by the rpc server with the special meaning
-	// of "all accounts", so disallow naming accounts to this string.
-	if cmd.Account == "*" {
-		return nil, &ErrReservedAccountName
-	}
-
-	_, err := w.NextAccount(waddrmgr.KeyScopeBIP0044, cmd.Account)
-	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
-		return nil, &btcjson.RPCError{
-			Code: btcjson.ErrRPCWalletUnlockNeeded,
-			Message: "Creating an account requires the wallet to be unlocked. " +
-				"Enter the wallet passphrase with walletpassphrase to unlock",
+// addressTypeScope returns the key scope of the accounts deriving addresses
+// of an address type.
+func addressTypeScope(addressType string) (waddrmgr.KeyScope, error) {
+	scope, ok := addressTypeScopes[addressType]
+	if !ok {
+		return waddrmgr.KeyScope{}, InvalidParameterError{
+			fmt.Errorf("unknown address type %q", addressType),
 		}
 	}
-	return nil, err
+	return scope, nil
 }
 
-// renameAccount handles a renameaccount request by renaming an account.
-// If the account does not exist an appropriate error will be returned.
-func renameAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.RenameAccountCmd)
-
-	// The wildcard * is reserved by the rpc server with the special meaning
-	// of "all accounts", so disallow naming accounts to this string.
-	if cmd.NewAccount == "*" {
-		return nil, &ErrReservedAccountName
-	}
+// getSpendPolicy handles a getspendpolicy request by returning the spend
+// policy of an account and the amount the account sent during the last 24
+// hours.
+func getSpendPolicy(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetSpendPolicyCmd)
 
-	// Check that given account exists
-	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.OldAccount)
+	scope, err := addressTypeScope(*cmd.AddressType)
 	if err != nil {
 		return nil, err
 	}
-	return nil, w.RenameAccount(waddrmgr.KeyScopeBIP0044, account, cmd.NewAccount)
-}
-
-// getNewAddress handles a getnewaddress request by returning a new
-// address for an account.  If the account does not exist an appropriate
-// error is returned.
-// TODO: Follow BIP 0044 and warn if number of unused addresses exceeds
-// the gap limit.
-func getNewAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.GetNewAddressCmd)
-
-	acctName := defaultAccountName
-	if cmd.Account != nil {
-		acctName = *cmd.Account
+	account, err := w.AccountNumber(scope, cmd.Account)
+	if err != nil {
+		return nil, err
 	}
-	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, acctName)
+	policy, err := w.SpendPolicy(scope, account)
 	if err != nil {
 		return nil, err
 	}
-	addr, err := w.NewAddress(account, waddrmgr.KeyScopeBIP0044)
+	spent, err := w.SpentInPolicyWindow(scope, account)
 	if err != nil {
 		return nil, err
 	}
 
-	// Return the new payment address string.
-	return addr.EncodeAddress(), nil
+	result := &walletjson.SpendPolicyResult{
+		Account:   cmd.Account,
+		Whitelist: []string{},
+		Spent24h:  spent.ToBTC(),
+	}
+	if policy != nil {
+		result.MaxPerTx = policy.MaxPerTx.ToBTC()
+		result.MaxPerDay = policy.MaxPerDay.ToBTC()
+		if len(policy.Whitelist) > 0 {
+			result.Whitelist = policy.Whitelist
+		}
+	}
+	return result, nil
 }
 
-// getRawChangeAddress handles a getrawchangeaddress request by creating
-// and returning a new change address for an account.
-//
-// Note: bitcoind allows specifying the account as an optional parameter,
-// but ignores the parameter.
-func getRawChangeAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.GetRawChangeAddressCmd)
+// setAccountFlag handles a setaccountflag request by changing the state of a
+// flag of an account.  The only flag is avoid_reuse.
+func setAccountFlag(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetAccountFlagCmd)
 
-	acctName := defaultAccountName
-	if cmd.Account != nil {
-		acctName = *cmd.Account
+	if cmd.Flag != "avoid_reuse" {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Unknown account flag '%s'", cmd.Flag),
+		}
 	}
-	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, acctName)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
 	if err != nil {
 		return nil, err
 	}
-	addr, err := w.NewChangeAddress(account, waddrmgr.KeyScopeBIP0044)
+	err = w.SetAccountAvoidReuse(waddrmgr.KeyScopeBIP0044, account, *cmd.Value)
 	if err != nil {
 		return nil, err
 	}
-
-	// Return the new payment address string.
-	return addr.EncodeAddress(), nil
+	return &walletjson.SetAccountFlagResult{
+		FlagName:  cmd.Flag,
+		FlagState: *cmd.Value,
+	}, nil
 }
 
-// getReceivedByAccount handles a getreceivedbyaccount request by returning
-// the total amount received by addresses of an account.
-func getReceivedByAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.GetReceivedByAccountCmd)
+// setAccountMetadata handles a setaccountmetadata request by replacing the
+// description and purpose tags of an account.
+func setAccountMetadata(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetAccountMetadataCmd)
 
 	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
 	if err != nil {
 		return nil, err
 	}
-
-	// TODO: This is more inefficient that it could be, but the entire
-	// algorithm is already dominated by reading every transaction in the
-	// wallet's history.
-	results, err := w.TotalReceivedForAccounts(
-		waddrmgr.KeyScopeBIP0044, int32(*cmd.MinConf),
+	var tags []string
+	if cmd.Tags != nil {
+		tags = *cmd.Tags
+	}
+	return nil, w.SetAccountMetadata(
+		waddrmgr.KeyScopeBIP0044, account, cmd.Description, tags,
 	)
+}
+
+// setDepositAlert handles a setdepositalert request by setting the amount at or
+// above which a deposit to an account is alerted.
+func setDepositAlert(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetDepositAlertCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
 	if err != nil {
 		return nil, err
 	}
-	acctIndex := int(account)
-	if account == waddrmgr.ImportedAddrAccount {
-		acctIndex = len(results) - 1
+	amount, err := btcutil.NewAmount(cmd.Amount)
+	if err != nil {
+		return nil, err
 	}
-	return results[acctIndex].TotalReceived.ToBTC(), nil
+	if amount < 0 {
+		return nil, ErrNeedPositiveAmount
+	}
+	return nil, w.SetDepositAlert(waddrmgr.KeyScopeBIP0044, account, amount)
 }
 
-// getReceivedByAddress handles a getreceivedbyaddress request by returning
-// the total amount received by a single address.
-func getReceivedByAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.GetReceivedByAddressCmd)
+// setAccountPassphrase handles a setaccountpassphrase request by protecting
+// the private keys of an account with their own passphrase.
+func setAccountPassphrase(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetAccountPassphraseCmd)
 
-	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
 	if err != nil {
 		return nil, err
 	}
-	total, err := w.TotalReceivedForAddr(addr, int32(*cmd.MinConf))
+	return nil, w.SetAccountPassphrase(
+		waddrmgr.KeyScopeBIP0044, account, []byte(cmd.Passphrase),
+	)
+}
+
+// listExpiredTransactions handles a listexpiredtransactions request by
+// returning the sends which remain unmined past the unmined expiry.
+func listExpiredTransactions(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	expired, err := w.ExpiredTransactions()
 	if err != nil {
 		return nil, err
 	}
 
-	return total.ToBTC(), nil
+	results := make([]walletjson.ListExpiredTransactionsResult, 0, len(expired))
+	for i := range expired {
+		tx := &expired[i]
+		results = append(results, walletjson.ListExpiredTransactionsResult{
+			TxID:         tx.Hash.String(),
+			TimeReceived: tx.Received.Unix(),
+			Fee:          tx.Fee.ToBTC(),
+		})
+	}
+	return results, nil
 }
 
-// getTransaction handles a gettransaction request by returning details about
-// a single transaction saved by wallet.
-func getTransaction(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.GetTransactionCmd)
-
-	txHash, err := chainhash.NewHashFromStr(cmd.Txid)
-	if err != nil {
-		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCDecodeHexString,
-			Message: "Transaction hash string decode failed: " + err.Error(),
+// listLabels handles a listlabels request by returning the distinct labels of
+// all labeled addresses, optionally only those of addresses of the wallet
+// ("receive") or of other wallets ("send").
+func listLabels(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ListLabelsCmd)
+
+	var mine *bool
+	if cmd.Purpose != nil {
+		var m bool
+		switch *cmd.Purpose {
+		case "receive":
+			m = true
+		case "send":
+		default:
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("Invalid purpose '%s'", *cmd.Purpose),
+			}
 		}
+		mine = &m
 	}
+	return w.AddressLabels(mine)
+}
 
-	details, err := wallet.UnstableAPI(w).TxDetails(txHash)
+// walletFsck handles a walletfsck request by checking the integrity of the
+// wallet's transaction store, repairing it in place if requested.
+func walletFsck(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.WalletFsckCmd)
+
+	repair := cmd.Repair != nil && *cmd.Repair
+	found, err := w.CheckIntegrity(repair)
 	if err != nil {
 		return nil, err
 	}
-	if details == nil {
-		return nil, &ErrNoTransactionInfo
+
+	result := walletjson.WalletFsckResult{
+		Inconsistencies: make([]walletjson.WalletFsckInconsistency, 0,
+			len(found)),
+		Repaired: repair,
 	}
+	for i := range found {
+		result.Inconsistencies = append(result.Inconsistencies,
+			walletjson.WalletFsckInconsistency{
+				Bucket:      found[i].Bucket,
+				Key:         hex.EncodeToString(found[i].Key),
+				Description: found[i].Description,
+				Repairable:  found[i].Repairable,
+			})
+	}
+	return result, nil
+}
 
-	syncBlock := w.Manager.SyncedTo()
+// setLabel handles a setlabel request by setting the label of an address.
+func setLabel(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetLabelCmd)
 
-	// TODO: The serialized transaction is already in the DB, so
-	// reserializing can be avoided here.
-	var txBuf bytes.Buffer
-	txBuf.Grow(details.MsgTx.SerializeSize())
-	err = details.MsgTx.Serialize(&txBuf)
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
 	if err != nil {
 		return nil, err
 	}
+	err = w.SetAddressLabel(addr, cmd.Label)
+	if err == wallet.ErrAddressLabelTooLong {
+		return nil, InvalidParameterError{err}
+	}
+	return nil, err
+}
 
-	// TODO: Add a "generated" field to this result type.  "generated":true
-	// is only added if the transaction is a coinbase.
-	ret := btcjson.GetTransactionResult{
-		TxID:            cmd.Txid,
+// setLookahead handles a setlookahead request by changing the number of
+// addresses past the last handed out address of each account branch which are
+// watched for payments.
+func setLookahead(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetLookaheadCmd)
+
+	return nil, w.SetLookaheadWindow(cmd.Window)
+}
+
+// setSpendPolicy handles a setspendpolicy request by replacing the spend
+// policy of an account.  A request without any limit removes the policy.
+func setSpendPolicy(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SetSpendPolicyCmd)
+
+	scope, err := addressTypeScope(*cmd.AddressType)
+	if err != nil {
+		return nil, err
+	}
+	account, err := w.AccountNumber(scope, cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &waddrmgr.SpendPolicy{}
+	for _, limit := range []struct {
+		value  *float64
+		amount *btcutil.Amount
+	}{
+		{cmd.MaxPerTx, &policy.MaxPerTx},
+		{cmd.MaxPerDay, &policy.MaxPerDay},
+	} {
+		if limit.value == nil {
+			continue
+		}
+		*limit.amount, err = btcutil.NewAmount(*limit.value)
+		if err != nil {
+			return nil, err
+		}
+		if *limit.amount < 0 {
+			return nil, ErrNeedPositiveAmount
+		}
+	}
+	if cmd.Whitelist != nil {
+		for _, encoded := range *cmd.Whitelist {
+			addr, err := decodeAddress(encoded, w.ChainParams())
+			if err != nil {
+				return nil, err
+			}
+			policy.Whitelist = append(
+				policy.Whitelist, addr.EncodeAddress(),
+			)
+		}
+	}
+	if policy.MaxPerTx == 0 && policy.MaxPerDay == 0 &&
+		len(policy.Whitelist) == 0 {
+
+		policy = nil
+	}
+
+	return nil, w.SetSpendPolicy(scope, account, policy)
+}
+
+// getAccountAddress handles a getaccountaddress by returning the most
+// recently-created chained address that has not yet been used (does not yet
+// appear in the blockchain, or any tx that has arrived in the btcd mempool).
+// If the most recently-requested address has been used, a new address (the
+// next chained address in the keypool) is used.  This can fail if the keypool
+// runs out (and will return btcjson.ErrRPCWalletKeypoolRanOut if that happens).
+func getAccountAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.GetAccountAddressCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := w.CurrentAddress(account, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		return nil, err
+	}
+
+	return addr.EncodeAddress(), err
+}
+
+// getUnconfirmedBalance handles a getunconfirmedbalance extension request
+// by returning the current unconfirmed balance of an account.
+func getUnconfirmedBalance(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.GetUnconfirmedBalanceCmd)
+
+	acctName := defaultAccountName
+	if cmd.Account != nil {
+		acctName = *cmd.Account
+	}
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, acctName)
+	if err != nil {
+		return nil, err
+	}
+	bals, err := w.CalculateAccountBalances(account, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return (bals.Total - bals.Spendable).ToBTC(), nil
+}
+
+// importPrivKey handles an importprivkey request by parsing
+// a WIF-encoded private key and adding it to an account.
+func importPrivKey(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	pcmd := icmd.(*importPrivKeyCmd)
+	cmd := pcmd.cmd
+	defer pcmd.passphrase.Free()
+
+	// Ensure that private keys are only imported to the correct account.
+	//
+	// Yes, Label is the account name.
+	if cmd.Label != nil && *cmd.Label != waddrmgr.ImportedAddrAccountName {
+		return nil, &ErrNotImportedAccount
+	}
+
+	// Without a birthday, the chain is rescanned from the genesis block.
+	var bs *waddrmgr.BlockStamp
+	if pcmd.birthday != nil {
+		var err error
+		bs, err = keyBirthdayBlock(w, *pcmd.birthday)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// BIP0038 encrypted keys are decrypted for the wallet's network, while
+	// WIF-encoded keys must be encoded for it.
+	if pcmd.passphrase != nil {
+		wif, err := bip38.Decrypt(
+			cmd.PrivKey, pcmd.passphrase.Bytes(), w.ChainParams(),
+		)
+		switch err {
+		case nil:
+		case bip38.ErrWrongPassphrase:
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCWalletPassphraseIncorrect,
+				Message: "BIP0038 passphrase is incorrect",
+			}
+		default:
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "BIP0038 decrypt failed: " + err.Error(),
+			}
+		}
+		defer zero.BigInt(wif.PrivKey.D)
+		return importWIF(w, wif, bs, *cmd.Rescan)
+	}
+
+	wif, err := btcutil.DecodeWIF(cmd.PrivKey)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "WIF decode failed: " + err.Error(),
+		}
+	}
+	if !wif.IsForNet(w.ChainParams()) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Key is not intended for " + w.ChainParams().Name,
+		}
+	}
+
+	return importWIF(w, wif, bs, *cmd.Rescan)
+}
+
+// keyBirthdayBlock returns the block from which the chain is rescanned for a
+// key with the birthday, which is a block height when below the lock time
+// threshold, as for transaction lock times, and a Unix timestamp otherwise.
+func keyBirthdayBlock(w *wallet.Wallet, birthday int64) (*waddrmgr.BlockStamp,
+	error) {
+
+	switch {
+	case birthday < 0:
+		return nil, InvalidParameterError{
+			errors.New("birthday must not be negative"),
+		}
+	case birthday < txscript.LockTimeThreshold:
+		return w.BirthdayBlockAtHeight(int32(birthday))
+	default:
+		return w.BirthdayBlockAtTime(time.Unix(birthday, 0))
+	}
+}
+
+// importWIF imports a private key to the imported account, rescanning from the
+// birthday block when requested, ignoring duplicate keys.
+func importWIF(w *wallet.Wallet, wif *btcutil.WIF, bs *waddrmgr.BlockStamp,
+	rescan bool) (interface{}, error) {
+
+	// Import the private key, handling any errors.
+	_, err := w.ImportPrivateKey(waddrmgr.KeyScopeBIP0044, wif, bs, rescan)
+	switch {
+	case waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress):
+		// Do not return duplicate key errors to the client.
+		return nil, nil
+	case waddrmgr.IsError(err, waddrmgr.ErrLocked):
+		return nil, &ErrWalletUnlockNeeded
+	}
+
+	return nil, err
+}
+
+// importPubKey handles an importpubkey request by importing a public key to be
+// watched as each of its address types.  Without a rescan, payments to the key
+// are only found from the block the wallet is synced to.
+func importPubKey(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.ImportPubKeyCmd)
+
+	pubKey, err := hex.DecodeString(cmd.PubKey)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Pubkey must be a hex string",
+		}
+	}
+	if _, err := btcec.ParsePubKey(pubKey, btcec.S256()); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Pubkey is not a valid public key",
+		}
+	}
+
+	bs, err := importBirthdayBlock(w, nil, *cmd.Rescan)
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.ImportWatchedPubKey(pubKey, bs, *cmd.Rescan)
+	return nil, err
+}
+
+// importScript handles an importscript request by importing a redeem or
+// witness script to be watched, returning the address of the script.  Like
+// importprivkey, the chain is rescanned from the birthday block of the script,
+// or from the genesis block without a birthday.
+func importScript(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ImportScriptCmd)
+
+	script, err := decodeHexStr(cmd.Script)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := importBirthdayBlock(w, cmd.Birthday, *cmd.Rescan)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := w.ImportScript(script, *cmd.Witness, bs, *cmd.Rescan)
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		return nil, &ErrWalletUnlockNeeded
+	}
+	if err != nil {
+		return nil, err
+	}
+	return addr.EncodeAddress(), nil
+}
+
+// importBirthdayBlock returns the birthday block of watched keys and scripts
+// imported with an optional birthday.  Without a birthday, imports which
+// rescan do so from the genesis block, while others are watched from the block
+// the wallet is synced to, which is represented by a nil block stamp.
+func importBirthdayBlock(w *wallet.Wallet, birthday *int64,
+	rescan bool) (*waddrmgr.BlockStamp, error) {
+
+	switch {
+	case birthday != nil:
+		return keyBirthdayBlock(w, *birthday)
+	case rescan:
+		params := w.ChainParams()
+		return &waddrmgr.BlockStamp{
+			Hash:      *params.GenesisHash,
+			Timestamp: params.GenesisBlock.Header.Timestamp,
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// keypoolRefill handles the keypoolrefill command. Since we handle the keypool
+// automatically this does nothing since refilling is never manually required.
+func keypoolRefill(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	return nil, nil
+}
+
+// createNewAccount handles a createnewaccount request by creating and
+// returning a new account. If the last account has no transaction history
+// as per BIP 0044 a new account cannot be created so an error will be returned.
+func createNewAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.CreateNewAccountCmd)
+
+	// The wildcard * is reserved by the rpc server with the special meaning
+	// of "all accounts", so disallow naming accounts to this string.
+	if cmd.Account == "*" {
+		return nil, &ErrReservedAccountName
+	}
+
+	_, err := w.NextAccount(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCWalletUnlockNeeded,
+			Message: "Creating an account requires the wallet to be unlocked. " +
+				"Enter the wallet passphrase with walletpassphrase to unlock",
+		}
+	}
+	return nil, err
+}
+
+// renameAccount handles a renameaccount request by renaming an account.
+// If the account does not exist an appropriate error will be returned.
+func renameAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.RenameAccountCmd)
+
+	// The wildcard * is reserved by the rpc server with the special meaning
+	// of "all accounts", so disallow naming accounts to this string.
+	if cmd.NewAccount == "*" {
+		return nil, &ErrReservedAccountName
+	}
+
+	// Check that given account exists
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.OldAccount)
+	if err != nil {
+		return nil, err
+	}
+	return nil, w.RenameAccount(waddrmgr.KeyScopeBIP0044, account, cmd.NewAccount)
+}
+
+// mergeAccounts handles a mergeaccounts request by moving the addresses of an
+// account, along with their outputs and history, into another account.  No
+// transaction is created, and the account merged from is kept.
+func mergeAccounts(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.MergeAccountsCmd)
+
+	from, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.FromAccount)
+	if err != nil {
+		return nil, err
+	}
+	into, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.ToAccount)
+	if err != nil {
+		return nil, err
+	}
+	err = w.MergeAccounts(waddrmgr.KeyScopeBIP0044, from, into)
+	if waddrmgr.IsError(err, waddrmgr.ErrInvalidAccount) {
+		return nil, InvalidParameterError{err}
+	}
+	return nil, err
+}
+
+// getNewAddress handles a getnewaddress request by returning a new
+// address for an account.  If the account does not exist an appropriate
+// error is returned.
+// TODO: Follow BIP 0044 and warn if number of unused addresses exceeds
+// the gap limit.
+func getNewAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.GetNewAddressCmd)
+
+	acctName := defaultAccountName
+	if cmd.Account != nil {
+		acctName = *cmd.Account
+	}
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, acctName)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := w.NewAddress(account, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the new payment address string.
+	return addr.EncodeAddress(), nil
+}
+
+// reserveAddress handles a reserveaddress request by claiming a payment
+// address of an account for an invoice, which is not handed out again until
+// it is released.
+func reserveAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ReserveAddressCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := w.ReserveAddress(account, waddrmgr.KeyScopeBIP0044,
+		*cmd.Label)
+	if err == wallet.ErrAddressLabelTooLong {
+		return nil, InvalidParameterError{err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return addr.EncodeAddress(), nil
+}
+
+// releaseAddress handles a releaseaddress request by ending the reservation of
+// an address.
+func releaseAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ReleaseAddressCmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	err = w.ReleaseAddress(addr)
+	if err == wallet.ErrAddressNotReserved {
+		return nil, InvalidParameterError{err}
+	}
+	return nil, err
+}
+
+// getRawChangeAddress handles a getrawchangeaddress request by creating
+// and returning a new change address for an account.
+//
+// Note: bitcoind allows specifying the account as an optional parameter,
+// but ignores the parameter.
+func getRawChangeAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.GetRawChangeAddressCmd)
+
+	acctName := defaultAccountName
+	if cmd.Account != nil {
+		acctName = *cmd.Account
+	}
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, acctName)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := w.NewChangeAddress(account, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the new payment address string.
+	return addr.EncodeAddress(), nil
+}
+
+// getReceivedByAccount handles a getreceivedbyaccount request by returning
+// the total amount received by addresses of an account, excluding change.
+func getReceivedByAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.GetReceivedByAccountCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO: This is more inefficient that it could be, but the entire
+	// algorithm is already dominated by reading every transaction in the
+	// wallet's history.
+	results, err := w.TotalReceivedForAccounts(
+		waddrmgr.KeyScopeBIP0044, int32(*cmd.MinConf),
+	)
+	if err != nil {
+		return nil, err
+	}
+	acctIndex := int(account)
+	if account == waddrmgr.ImportedAddrAccount {
+		acctIndex = len(results) - 1
+	}
+	return results[acctIndex].TotalReceived.ToBTC(), nil
+}
+
+// getReceivedByAddress handles a getreceivedbyaddress request by returning
+// the total amount received by a single address.
+func getReceivedByAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.GetReceivedByAddressCmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	total, err := w.TotalReceivedForAddr(addr, int32(*cmd.MinConf))
+	if err != nil {
+		return nil, err
+	}
+
+	return total.ToBTC(), nil
+}
+
+// publicHandlers are the handlers of public methods which replace the handlers
+// of rpcHandlers for clients of the public tier, restricting what they reveal.
+var publicHandlers = map[string]requestHandler{
+	"getreceivedbyaddress": getPaymentReceivedByAddress,
+}
+
+// getPaymentReceivedByAddress handles a getreceivedbyaddress request of a
+// public tier client.  Only addresses handed out for payments, being reserved
+// by reserveaddress or labeled, are answered for, so that the receive history
+// of other addresses is not revealed.  Other addresses are reported as not
+// belonging to the wallet.
+func getPaymentReceivedByAddress(icmd interface{},
+	w *wallet.Wallet) (interface{}, error) {
+
+	cmd := icmd.(*btcjson.GetReceivedByAddressCmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	payment, err := w.IsPaymentAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !payment {
+		return nil, &ErrAddressNotInWallet
+	}
+	return getReceivedByAddress(icmd, w)
+}
+
+// getTransaction handles a gettransaction request by returning details about
+// a single transaction saved by wallet.
+func getTransaction(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.GetTransactionCmd)
+
+	txHash, err := chainhash.NewHashFromStr(cmd.Txid)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDecodeHexString,
+			Message: "Transaction hash string decode failed: " + err.Error(),
+		}
+	}
+
+	details, err := wallet.UnstableAPI(w).TxDetails(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if details == nil {
+		return nil, &ErrNoTransactionInfo
+	}
+
+	syncBlock := w.Manager.SyncedTo()
+
+	// TODO: The serialized transaction is already in the DB, so
+	// reserializing can be avoided here.
+	var txBuf bytes.Buffer
+	txBuf.Grow(details.MsgTx.SerializeSize())
+	err = details.MsgTx.Serialize(&txBuf)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO: Add a "generated" field to this result type.  "generated":true
+	// is only added if the transaction is a coinbase.
+	ret := walletjson.GetTransactionResult{
+		TxID:            cmd.Txid,
 		Hex:             hex.EncodeToString(txBuf.Bytes()),
 		Time:            details.Received.Unix(),
 		TimeReceived:    details.Received.Unix(),
+		Comment:         details.Comment,
+		CommentTo:       details.CommentTo,
 		WalletConflicts: []string{}, // Not saved
 		//Generated:     blockchain.IsCoinBaseTx(&details.MsgTx),
 	}
@@ -1025,15 +2375,38 @@ func help(icmd interface{}, _ *wallet.Wallet, chainClient *chain.RPCClient) (int
 }
 
 // listAccounts handles a listaccounts request by returning a map of account
-// names to their balances.
+// names to their balances.  When the verbose flag is set, a list of objects
+// additionally including the metadata of each account is returned instead.
 func listAccounts(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.ListAccountsCmd)
+	lcmd := icmd.(*listAccountsCmd)
+	cmd := lcmd.cmd
 
 	accountBalances := map[string]float64{}
 	results, err := w.AccountBalances(waddrmgr.KeyScopeBIP0044, int32(*cmd.MinConf))
 	if err != nil {
 		return nil, err
 	}
+	if lcmd.verbose {
+		accounts := make([]walletjson.ListAccountsVerboseResult, 0, len(results))
+		for _, result := range results {
+			meta, err := w.AccountMetadata(
+				waddrmgr.KeyScopeBIP0044, result.AccountNumber,
+			)
+			if err != nil {
+				return nil, err
+			}
+			metaResult := accountMetadataResult(result.AccountName, meta)
+			accounts = append(accounts, walletjson.ListAccountsVerboseResult{
+				Account:     result.AccountName,
+				Balance:     result.AccountBalance.ToBTC(),
+				Description: metaResult.Description,
+				Created:     metaResult.Created,
+				Tags:        metaResult.Tags,
+				AvoidReuse:  metaResult.AvoidReuse,
+			})
+		}
+		return accounts, nil
+	}
 	for _, result := range results {
 		accountBalances[result.AccountName] = result.AccountBalance.ToBTC()
 	}
@@ -1058,14 +2431,25 @@ func listLockUnspent(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 //  "includeempty": whether or not to include addresses that have no transactions -
 //                  default: false.
 func listReceivedByAccount(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.ListReceivedByAccountCmd)
+	lcmd := icmd.(*listCmd)
+	cmd := lcmd.cmd.(*btcjson.ListReceivedByAccountCmd)
+	if err := lcmd.checkNoUnspentOptions(); err != nil {
+		return nil, err
+	}
 
-	results, err := w.TotalReceivedForAccounts(
-		waddrmgr.KeyScopeBIP0044, int32(*cmd.MinConf),
+	results, err := w.TotalReceivedForAccountsInRange(
+		waddrmgr.KeyScopeBIP0044, int32(*cmd.MinConf), lcmd.timeRange(),
 	)
 	if err != nil {
 		return nil, err
 	}
+	start, end, err := lcmd.page(len(results), func(i int) string {
+		return results[i].AccountName
+	})
+	if err != nil {
+		return nil, err
+	}
+	results = results[start:end]
 
 	jsonResults := make([]btcjson.ListReceivedByAccountResult, 0, len(results))
 	for _, result := range results {
@@ -1090,7 +2474,12 @@ func listReceivedByAccount(icmd interface{}, w *wallet.Wallet) (interface{}, err
 //  "includeempty": whether or not to include addresses that have no transactions -
 //                  default: false.
 func listReceivedByAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.ListReceivedByAddressCmd)
+	lcmd := icmd.(*listCmd)
+	cmd := lcmd.cmd.(*btcjson.ListReceivedByAddressCmd)
+	if err := lcmd.checkNoUnspentOptions(); err != nil {
+		return nil, err
+	}
+	received := lcmd.timeRange()
 
 	// Intermediate data for each address.
 	type AddrData struct {
@@ -1129,7 +2518,14 @@ func listReceivedByAddress(icmd interface{}, w *wallet.Wallet) (interface{}, err
 	err = wallet.UnstableAPI(w).RangeTransactions(0, endHeight, func(details []wtxmgr.TxDetails) (bool, error) {
 		confirmations := confirms(details[0].Block.Height, syncBlock.Height)
 		for _, tx := range details {
+			if !received.Contains(tx.Received) {
+				continue
+			}
 			for _, cred := range tx.Credits {
+				// Change is not received by the address.
+				if cred.Change {
+					continue
+				}
 				pkScript := tx.MsgTx.TxOut[cred.Index].PkScript
 				_, addrs, _, err := txscript.ExtractPkScriptAddrs(
 					pkScript, w.ChainParams())
@@ -1175,7 +2571,19 @@ func listReceivedByAddress(icmd interface{}, w *wallet.Wallet) (interface{}, err
 		}
 		idx++
 	}
-	return ret, nil
+
+	// Results are ordered by address, as map iteration would return them
+	// in a different order on every request.
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Address < ret[j].Address
+	})
+	start, end, err := lcmd.page(len(ret), func(i int) string {
+		return ret[i].Address
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret[start:end], nil
 }
 
 // listSinceBlock handles a listsinceblock request by returning an array of maps
@@ -1215,7 +2623,7 @@ func listSinceBlock(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCCl
 		return nil, err
 	}
 
-	res := btcjson.ListSinceBlockResult{
+	res := walletjson.ListSinceBlockResult{
 		Transactions: txInfoList,
 		LastBlock:    blockHash.String(),
 	}
@@ -1225,7 +2633,11 @@ func listSinceBlock(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCCl
 // listTransactions handles a listtransactions request by returning an
 // array of maps with details of sent and recevied wallet transactions.
 func listTransactions(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.ListTransactionsCmd)
+	lcmd := icmd.(*listCmd)
+	cmd := lcmd.cmd.(*btcjson.ListTransactionsCmd)
+	if err := lcmd.checkNoUnspentOptions(); err != nil {
+		return nil, err
+	}
 
 	// TODO: ListTransactions does not currently understand the difference
 	// between transactions pertaining to one account from another.  This
@@ -1241,7 +2653,28 @@ func listTransactions(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		}
 	}
 
-	return w.ListTransactions(*cmd.From, *cmd.Count)
+	// The transactions are paged by the reference count and from
+	// parameters.
+	if lcmd.opts.Count != nil || lcmd.opts.Skip != nil {
+		return nil, InvalidParameterError{
+			errors.New("options 'count' and 'skip' are not " +
+				"accepted, use the count and from parameters"),
+		}
+	}
+	filter := &wallet.TxListFilter{Received: *lcmd.timeRange()}
+	if lcmd.opts.Cursor != nil {
+		hash, err := chainhash.NewHashFromStr(*lcmd.opts.Cursor)
+		if err != nil {
+			return nil, DeserializationError{err}
+		}
+		filter.After = hash
+	}
+
+	txs, err := w.ListTransactionsFiltered(*cmd.From, *cmd.Count, filter)
+	if err == wallet.ErrListCursorNotFound {
+		return nil, InvalidParameterError{err}
+	}
+	return txs, err
 }
 
 // listAddressTransactions handles a listaddresstransactions request by
@@ -1291,7 +2724,8 @@ func listAllTransactions(icmd interface{}, w *wallet.Wallet) (interface{}, error
 
 // listUnspent handles the listunspent command.
 func listUnspent(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.ListUnspentCmd)
+	lcmd := icmd.(*listCmd)
+	cmd := lcmd.cmd.(*btcjson.ListUnspentCmd)
 
 	if cmd.Addresses != nil && len(*cmd.Addresses) > 0 {
 		return nil, &btcjson.RPCError{
@@ -1300,7 +2734,178 @@ func listUnspent(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		}
 	}
 
-	return w.ListUnspent(int32(*cmd.MinConf), int32(*cmd.MaxConf), "")
+	unspent, err := w.ListUnspent(int32(*cmd.MinConf), int32(*cmd.MaxConf), "")
+	if err != nil {
+		return nil, err
+	}
+	if lcmd.opts.StartTime != nil || lcmd.opts.EndTime != nil {
+		unspent, err = filterUnspentReceived(w, unspent, lcmd.timeRange())
+		if err != nil {
+			return nil, err
+		}
+	}
+	unspent, err = filterUnspentAmount(
+		unspent, lcmd.opts.MinimumAmount, lcmd.opts.MaximumAmount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unsafe outputs are included unless excluded by include_unsafe, as
+	// by the reference implementation.
+	safe, err := unspentSafe(w, unspent)
+	if err != nil {
+		return nil, err
+	}
+	if lcmd.opts.IncludeUnsafe != nil && !*lcmd.opts.IncludeUnsafe {
+		n := 0
+		for i, output := range unspent {
+			if safe[i] {
+				unspent[n], safe[n] = output, true
+				n++
+			}
+		}
+		unspent, safe = unspent[:n], safe[:n]
+	}
+
+	if lcmd.opts.MaximumCount != nil {
+		if lcmd.opts.Count != nil {
+			return nil, InvalidParameterError{
+				errors.New("options 'count' and 'maximumCount' " +
+					"may not be used together"),
+			}
+		}
+		lcmd.opts.Count = lcmd.opts.MaximumCount
+	}
+	start, end, err := lcmd.page(len(unspent), func(i int) string {
+		return fmt.Sprintf("%s:%d", unspent[i].TxID, unspent[i].Vout)
+	})
+	if err != nil {
+		return nil, err
+	}
+	unspent, safe = unspent[start:end], safe[start:end]
+
+	// Flag the outputs paying to addresses which have been spent from.
+	pkScripts := make([][]byte, len(unspent))
+	for i, output := range unspent {
+		pkScripts[i], err = hex.DecodeString(output.ScriptPubKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	reused, err := w.OutputsReused(pkScripts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]walletjson.ListUnspentResult, len(unspent))
+	for i, output := range unspent {
+		results[i] = walletjson.ListUnspentResult{
+			TxID:          output.TxID,
+			Vout:          output.Vout,
+			Address:       output.Address,
+			Account:       output.Account,
+			ScriptPubKey:  output.ScriptPubKey,
+			RedeemScript:  output.RedeemScript,
+			Amount:        output.Amount,
+			Confirmations: output.Confirmations,
+			Spendable:     output.Spendable,
+			Safe:          safe[i],
+			Reused:        reused[i],
+		}
+	}
+	return results, nil
+}
+
+// filterUnspentAmount returns the unspent outputs of at least the minimum and
+// at most the maximum amount, valued in bitcoin, when they are set.
+func filterUnspentAmount(unspent []*btcjson.ListUnspentResult,
+	minimum, maximum *float64) ([]*btcjson.ListUnspentResult, error) {
+
+	if minimum == nil && maximum == nil {
+		return unspent, nil
+	}
+
+	// Amounts are compared in satoshis, so that outputs of exactly the
+	// minimum or maximum are included.
+	bound := func(name string, amount *float64,
+		unset btcutil.Amount) (btcutil.Amount, error) {
+
+		if amount == nil {
+			return unset, nil
+		}
+		a, err := btcutil.NewAmount(*amount)
+		if err != nil || a < 0 {
+			return 0, InvalidParameterError{
+				fmt.Errorf("invalid %s", name),
+			}
+		}
+		return a, nil
+	}
+	min, err := bound("minimumAmount", minimum, 0)
+	if err != nil {
+		return nil, err
+	}
+	max, err := bound("maximumAmount", maximum, btcutil.MaxSatoshi)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := unspent[:0]
+	for _, output := range unspent {
+		amount, err := btcutil.NewAmount(output.Amount)
+		if err != nil {
+			return nil, err
+		}
+		if amount >= min && amount <= max {
+			filtered = append(filtered, output)
+		}
+	}
+	return filtered, nil
+}
+
+// unspentSafe returns, for each of the unspent outputs, whether the output is
+// safe to spend.
+func unspentSafe(w *wallet.Wallet,
+	unspent []*btcjson.ListUnspentResult) ([]bool, error) {
+
+	ops := make([]wire.OutPoint, len(unspent))
+	for i, output := range unspent {
+		hash, err := chainhash.NewHashFromStr(output.TxID)
+		if err != nil {
+			return nil, err
+		}
+		ops[i] = wire.OutPoint{Hash: *hash, Index: output.Vout}
+	}
+	return w.OutputsSafe(ops)
+}
+
+// filterUnspentReceived returns the unspent outputs whose transactions were
+// received within the time range.
+func filterUnspentReceived(w *wallet.Wallet,
+	unspent []*btcjson.ListUnspentResult,
+	received *wallet.TimeRange) ([]*btcjson.ListUnspentResult, error) {
+
+	ops := make([]wire.OutPoint, len(unspent))
+	for i, output := range unspent {
+		hash, err := chainhash.NewHashFromStr(output.TxID)
+		if err != nil {
+			return nil, err
+		}
+		ops[i] = wire.OutPoint{Hash: *hash, Index: output.Vout}
+	}
+	times, err := w.OutputsReceived(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := unspent[:0]
+	for i, output := range unspent {
+		if received.Contains(times[i]) {
+			filtered = append(filtered, output)
+		}
+	}
+	return filtered, nil
 }
 
 // lockUnspent handles the lockunspent command.
@@ -1344,68 +2949,266 @@ func makeOutputs(pairs map[string]btcutil.Amount, chainParams *chaincfg.Params)
 			return nil, fmt.Errorf("cannot create txout script: %s", err)
 		}
 
-		outputs = append(outputs, wire.NewTxOut(int64(amt), pkScript))
+		outputs = append(outputs, wire.NewTxOut(int64(amt), pkScript))
+	}
+	return outputs, nil
+}
+
+// sendPairs creates and sends payment transactions.
+// It returns the transaction hash in string format upon success, or the token
+// of the pending spend when spends must be confirmed with a TOTP code.
+// All errors are returned in btcjson.RPCError format
+func sendPairs(w *wallet.Wallet, amounts map[string]btcutil.Amount,
+	keyScope waddrmgr.KeyScope, account uint32, minconf int32,
+	feeSatPerKb btcutil.Amount, optFuncs ...wallet.TxCreateOption) (
+	string, error) {
+
+	outputs, err := makeOutputs(amounts, w.ChainParams())
+	if err != nil {
+		return "", err
+	}
+	var (
+		tx    *wire.MsgTx
+		token string
+	)
+	if w.SpendConfirmationRequired() {
+		token, tx, err = w.CreatePendingSpend(
+			outputs, &keyScope, account, minconf, feeSatPerKb,
+			wallet.CoinSelectionLargest, "", optFuncs...,
+		)
+	} else {
+		tx, err = w.SendOutputs(
+			outputs, &keyScope, account, minconf, feeSatPerKb,
+			wallet.CoinSelectionLargest, "", optFuncs...,
+		)
+	}
+	if err != nil {
+		if err == txrules.ErrAmountNegative {
+			return "", ErrNeedPositiveAmount
+		}
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return "", &ErrWalletUnlockNeeded
+		}
+		if _, ok := err.(btcjson.RPCError); ok {
+			return "", err
+		}
+		if _, ok := err.(*wallet.SpendPolicyError); ok {
+			return "", &btcjson.RPCError{
+				Code:    ErrRPCSpendPolicy,
+				Message: err.Error(),
+			}
+		}
+		if err == wallet.ErrTooManyPendingSpends {
+			return "", &btcjson.RPCError{
+				Code:    btcjson.ErrRPCWallet,
+				Message: err.Error(),
+			}
+		}
+
+		return "", &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInternal.Code,
+			Message: err.Error(),
+		}
+	}
+
+	if token != "" {
+		log.Infof("Created transaction %v awaiting spend confirmation",
+			tx.TxHash())
+		return token, nil
+	}
+
+	txHashStr := tx.TxHash().String()
+	log.Infof("Successfully sent transaction %v", txHashStr)
+	return txHashStr, nil
+}
+
+// confirmSpend handles a confirmspend extension request by publishing the
+// transaction of a pending spend once its TOTP code is validated.
+func confirmSpend(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ConfirmSpendCmd)
+
+	tx, err := w.ConfirmPendingSpend(cmd.Token, cmd.Code)
+	if err != nil {
+		return nil, pendingSpendError(err)
+	}
+
+	txHashStr := tx.TxHash().String()
+	log.Infof("Successfully sent confirmed transaction %v", txHashStr)
+	return txHashStr, nil
+}
+
+// cancelRescan handles a cancelrescan extension request by cancelling a queued
+// rescan job.
+func cancelRescan(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.CancelRescanCmd)
+
+	err := w.CancelRescan(cmd.ID)
+	switch err {
+	case wallet.ErrUnknownRescan:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("No queued or running rescan with id %d", cmd.ID),
+		}
+	case wallet.ErrRescanRunning:
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Rescan %d is running and can not "+
+				"be cancelled, only queued rescans can", cmd.ID),
+		}
+	}
+	return nil, err
+}
+
+// listRescans handles a listrescans extension request by returning the
+// running rescan jobs followed by the queued jobs.
+func listRescans(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	jobs, err := w.RescanJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]walletjson.ListRescansResult, 0, len(jobs))
+	for _, job := range jobs {
+		result := walletjson.ListRescansResult{
+			ID:          job.ID,
+			State:       "queued",
+			Addresses:   job.Addresses,
+			StartHeight: job.StartHeight,
+		}
+		if job.Running {
+			result.State = "running"
+			result.Height = job.Height
+			result.Percent = job.Percent
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// rescanBlockchain handles a rescanblockchain request by scanning a range of
+// blocks for transactions of an account, or of every account, which the
+// wallet missed.  The range starts at the earliest birthday block of the
+// scanned addresses and ends at the wallet's best block by default.
+func rescanBlockchain(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.RescanBlockchainCmd)
+
+	var account *uint32
+	if *cmd.Account != allAccounts {
+		acct, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *cmd.Account)
+		if err != nil {
+			return nil, err
+		}
+		account = &acct
+	}
+
+	var startHeight int32
+	if cmd.StartHeight != nil {
+		startHeight = *cmd.StartHeight
+	} else {
+		birthday, err := w.RescanBirthday(account)
+		if err != nil {
+			return nil, err
+		}
+		startHeight = birthday.Height
+	}
+	stopHeight := w.Manager.SyncedTo().Height
+	if cmd.StopHeight != nil {
+		stopHeight = *cmd.StopHeight
+	}
+
+	err := w.RescanBlockRange(account, startHeight, stopHeight)
+	if err == wallet.ErrInvalidBlockRange {
+		return nil, InvalidParameterError{err}
 	}
-	return outputs, nil
+	if err != nil {
+		return nil, err
+	}
+	return &walletjson.RescanBlockchainResult{
+		StartHeight: startHeight,
+		StopHeight:  stopHeight,
+	}, nil
 }
 
-// sendPairs creates and sends payment transactions.
-// It returns the transaction hash in string format upon success
-// All errors are returned in btcjson.RPCError format
-func sendPairs(w *wallet.Wallet, amounts map[string]btcutil.Amount,
-	keyScope waddrmgr.KeyScope, account uint32, minconf int32,
-	feeSatPerKb btcutil.Amount) (string, error) {
+// authorizeKeyUse handles an authorizekeyuse extension request by authorizing
+// a single use of the private keys of the wallet once its TOTP code is
+// validated.
+func authorizeKeyUse(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.AuthorizeKeyUseCmd)
 
-	outputs, err := makeOutputs(amounts, w.ChainParams())
-	if err != nil {
-		return "", err
-	}
-	tx, err := w.SendOutputs(
-		outputs, &keyScope, account, minconf, feeSatPerKb,
-		wallet.CoinSelectionLargest, "",
-	)
-	if err != nil {
-		if err == txrules.ErrAmountNegative {
-			return "", ErrNeedPositiveAmount
-		}
-		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
-			return "", &ErrWalletUnlockNeeded
+	return nil, pendingSpendError(w.AuthorizeKeyUse(cmd.Code))
+}
+
+// cancelSpend handles a cancelspend extension request by discarding a pending
+// spend.
+func cancelSpend(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.CancelSpendCmd)
+
+	return nil, pendingSpendError(w.CancelPendingSpend(cmd.Token))
+}
+
+// pendingSpendError returns the RPC error of an error confirming or cancelling
+// a pending spend, or authorizing the use of keys.
+func pendingSpendError(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case wallet.ErrSpendConfirmationDisabled:
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWallet,
+			Message: err.Error(),
 		}
-		if _, ok := err.(btcjson.RPCError); ok {
-			return "", err
+	case wallet.ErrUnknownPendingSpend:
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
 		}
-
-		return "", &btcjson.RPCError{
-			Code:    btcjson.ErrRPCInternal.Code,
+	case wallet.ErrInvalidSpendCode:
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWalletPassphraseIncorrect,
 			Message: err.Error(),
 		}
 	}
-
-	txHashStr := tx.TxHash().String()
-	log.Infof("Successfully sent transaction %v", txHashStr)
-	return txHashStr, nil
+	return &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInternal.Code,
+		Message: err.Error(),
+	}
 }
 
 func isNilOrEmpty(s *string) bool {
 	return s == nil || *s == ""
 }
 
+// txComment returns the option recording the optional comments of a send
+// request with the transaction, or no option if neither comment is set.  The
+// comments are checked against the limit of the transaction store here, so that
+// a send with a comment which can not be recorded is refused before any outputs
+// are selected.
+func txComment(comment, commentTo *string) ([]wallet.TxCreateOption, error) {
+	if isNilOrEmpty(comment) && isNilOrEmpty(commentTo) {
+		return nil, nil
+	}
+	var c, to string
+	if comment != nil {
+		c = *comment
+	}
+	if commentTo != nil {
+		to = *commentTo
+	}
+	if len(c) > wtxmgr.TxLabelLimit || len(to) > wtxmgr.TxLabelLimit {
+		return nil, InvalidParameterError{fmt.Errorf("comments may "+
+			"not exceed %d bytes", wtxmgr.TxLabelLimit)}
+	}
+	return []wallet.TxCreateOption{wallet.WithComment(c, to)}, nil
+}
+
 // sendFrom handles a sendfrom RPC request by creating a new transaction
 // spending unspent transaction outputs for a wallet to another payment
 // address.  Leftover inputs not sent to the payment address or a fee for
 // the miner are sent back to a new address in the wallet.  Upon success,
 // the TxID for the created transaction is returned.
 func sendFrom(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient) (interface{}, error) {
-	cmd := icmd.(*btcjson.SendFromCmd)
-
-	// Transaction comments are not yet supported.  Error instead of
-	// pretending to save them.
-	if !isNilOrEmpty(cmd.Comment) || !isNilOrEmpty(cmd.CommentTo) {
-		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCUnimplemented,
-			Message: "Transaction comments are not yet supported",
-		}
-	}
+	scmd := icmd.(*sendCmd)
+	cmd := scmd.cmd.(*btcjson.SendFromCmd)
 
 	account, err := w.AccountNumber(
 		waddrmgr.KeyScopeBIP0044, cmd.FromAccount,
@@ -1423,7 +3226,7 @@ func sendFrom(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient)
 		return nil, ErrNeedPositiveMinconf
 	}
 	// Create map of address and amount pairs.
-	amt, err := btcutil.NewAmount(cmd.Amount)
+	amt, err := scmd.amount(cmd.Amount)
 	if err != nil {
 		return nil, err
 	}
@@ -1431,8 +3234,21 @@ func sendFrom(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient)
 		cmd.ToAddress: amt,
 	}
 
+	optFuncs, err := scmd.txCreateOptions(w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	feeRate, err := scmd.feeRate()
+	if err != nil {
+		return nil, err
+	}
+	commentOpts, err := txComment(cmd.Comment, cmd.CommentTo)
+	if err != nil {
+		return nil, err
+	}
+	optFuncs = append(optFuncs, commentOpts...)
 	return sendPairs(w, pairs, waddrmgr.KeyScopeBIP0044, account, minConf,
-		txrules.DefaultRelayFeePerKb)
+		feeRate, optFuncs...)
 }
 
 // sendMany handles a sendmany RPC request by creating a new transaction
@@ -1441,16 +3257,8 @@ func sendFrom(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient)
 // or a fee for the miner are sent back to a new address in the wallet.
 // Upon success, the TxID for the created transaction is returned.
 func sendMany(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.SendManyCmd)
-
-	// Transaction comments are not yet supported.  Error instead of
-	// pretending to save them.
-	if !isNilOrEmpty(cmd.Comment) {
-		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCUnimplemented,
-			Message: "Transaction comments are not yet supported",
-		}
-	}
+	scmd := icmd.(*sendCmd)
+	cmd := scmd.cmd.(*btcjson.SendManyCmd)
 
 	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, cmd.FromAccount)
 	if err != nil {
@@ -1463,17 +3271,31 @@ func sendMany(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		return nil, ErrNeedPositiveMinconf
 	}
 
-	// Recreate address/amount pairs, using dcrutil.Amount.
+	// Recreate address/amount pairs, using btcutil.Amount.
 	pairs := make(map[string]btcutil.Amount, len(cmd.Amounts))
 	for k, v := range cmd.Amounts {
-		amt, err := btcutil.NewAmount(v)
+		amt, err := scmd.amount(v)
 		if err != nil {
 			return nil, err
 		}
 		pairs[k] = amt
 	}
 
-	return sendPairs(w, pairs, waddrmgr.KeyScopeBIP0044, account, minConf, txrules.DefaultRelayFeePerKb)
+	optFuncs, err := scmd.txCreateOptions(w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	feeRate, err := scmd.feeRate()
+	if err != nil {
+		return nil, err
+	}
+	commentOpts, err := txComment(cmd.Comment, nil)
+	if err != nil {
+		return nil, err
+	}
+	optFuncs = append(optFuncs, commentOpts...)
+	return sendPairs(w, pairs, waddrmgr.KeyScopeBIP0044, account, minConf,
+		feeRate, optFuncs...)
 }
 
 // sendToAddress handles a sendtoaddress RPC request by creating a new
@@ -1482,35 +3304,336 @@ func sendMany(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 // for the miner are sent back to a new address in the wallet.  Upon success,
 // the TxID for the created transaction is returned.
 func sendToAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.SendToAddressCmd)
+	scmd := icmd.(*sendCmd)
+	cmd := scmd.cmd.(*btcjson.SendToAddressCmd)
+
+	amt, err := scmd.amount(cmd.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check that signed integer parameters are positive.
+	if amt < 0 {
+		return nil, ErrNeedPositiveAmount
+	}
+
+	// Mock up map of address and amount pairs.
+	pairs := map[string]btcutil.Amount{
+		cmd.Address: amt,
+	}
+
+	// sendtoaddress always spends from the default account, this matches bitcoind
+	optFuncs, err := scmd.txCreateOptions(w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	feeRate, err := scmd.feeRate()
+	if err != nil {
+		return nil, err
+	}
+	commentOpts, err := txComment(cmd.Comment, cmd.CommentTo)
+	if err != nil {
+		return nil, err
+	}
+	optFuncs = append(optFuncs, commentOpts...)
+	return sendPairs(w, pairs, waddrmgr.KeyScopeBIP0044, waddrmgr.DefaultAccountNum, 1,
+		feeRate, optFuncs...)
+}
+
+// estimateSendFee handles an estimatesendfee extension request by running coin
+// selection for a send of the amounts from the account, returning the fee,
+// size, inputs and change of the transaction the send would create.  The
+// transaction is neither signed nor published.
+func estimateSendFee(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	scmd := icmd.(*sendCmd)
+	cmd := scmd.cmd.(*walletjson.EstimateSendFeeCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	minConf := int32(*cmd.MinConf)
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+
+	pairs := make(map[string]btcutil.Amount, len(cmd.Amounts))
+	for k, v := range cmd.Amounts {
+		amt, err := scmd.amount(v)
+		if err != nil {
+			return nil, err
+		}
+		if amt < 0 {
+			return nil, ErrNeedPositiveAmount
+		}
+		pairs[k] = amt
+	}
+	outputs, err := makeOutputs(pairs, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	optFuncs, err := scmd.txCreateOptions(w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	feeRate, err := scmd.feeRate()
+	if err != nil {
+		return nil, err
+	}
+
+	keyScope := waddrmgr.KeyScopeBIP0044
+	estimate, err := w.EstimateSendFee(
+		outputs, &keyScope, account, minConf, feeRate,
+		wallet.CoinSelectionLargest, optFuncs...,
+	)
+	if err != nil {
+		if err == txrules.ErrAmountNegative {
+			return nil, ErrNeedPositiveAmount
+		}
+		if _, ok := err.(txauthor.InputSourceError); ok {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCWalletInsufficientFunds,
+				Message: err.Error(),
+			}
+		}
+		return nil, err
+	}
+
+	return &walletjson.EstimateSendFeeResult{
+		Fee:          estimate.Fee.ToBTC(),
+		VSize:        estimate.VirtualSize,
+		Inputs:       estimate.Inputs,
+		Change:       estimate.HasChange,
+		ChangeAmount: estimate.Change.ToBTC(),
+	}, nil
+}
+
+// createTx handles a createtx extension request by creating an unsigned
+// transaction paying the amounts from the account, returning it along with
+// its inputs, outputs and fee for review.  The transaction is signed and
+// published by a committx request.
+func createTx(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	scmd := icmd.(*sendCmd)
+	cmd := scmd.cmd.(*walletjson.CreateTxCmd)
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	minConf := int32(*cmd.MinConf)
+	if minConf < 0 {
+		return nil, ErrNeedPositiveMinconf
+	}
+
+	pairs := make(map[string]btcutil.Amount, len(cmd.Amounts))
+	for k, v := range cmd.Amounts {
+		amt, err := scmd.amount(v)
+		if err != nil {
+			return nil, err
+		}
+		if amt < 0 {
+			return nil, ErrNeedPositiveAmount
+		}
+		pairs[k] = amt
+	}
+	outputs, err := makeOutputs(pairs, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	optFuncs, err := scmd.txCreateOptions(w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	feeRate, err := scmd.feeRate()
+	if err != nil {
+		return nil, err
+	}
+	commentOpts, err := txComment(cmd.Comment, nil)
+	if err != nil {
+		return nil, err
+	}
+	optFuncs = append(optFuncs, commentOpts...)
+
+	keyScope := waddrmgr.KeyScopeBIP0044
+	draft, err := w.CreateDraftTx(
+		outputs, &keyScope, account, minConf, feeRate,
+		wallet.CoinSelectionLargest, "", optFuncs...,
+	)
+	if err != nil {
+		if err == txrules.ErrAmountNegative {
+			return nil, ErrNeedPositiveAmount
+		}
+		if _, ok := err.(txauthor.InputSourceError); ok {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCWalletInsufficientFunds,
+				Message: err.Error(),
+			}
+		}
+		if _, ok := err.(*wallet.SpendPolicyError); ok {
+			return nil, &btcjson.RPCError{
+				Code:    ErrRPCSpendPolicy,
+				Message: err.Error(),
+			}
+		}
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(draft.Tx.SerializeSize())
+	if err := draft.Tx.Serialize(&buf); err != nil {
+		return nil, err
+	}
+
+	// Scripts of inputs and outputs which do not pay a single address are
+	// described without one.
+	scriptAddress := func(pkScript []byte) string {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			pkScript, w.ChainParams(),
+		)
+		if err != nil || len(addrs) != 1 {
+			return ""
+		}
+		return addrs[0].EncodeAddress()
+	}
+
+	inputs := make([]walletjson.CreateTxInput, 0, len(draft.Tx.TxIn))
+	for i, txIn := range draft.Tx.TxIn {
+		inputs = append(inputs, walletjson.CreateTxInput{
+			TxID:    txIn.PreviousOutPoint.Hash.String(),
+			Vout:    txIn.PreviousOutPoint.Index,
+			Address: scriptAddress(draft.PrevScripts[i]),
+			Amount:  draft.PrevInputValues[i].ToBTC(),
+		})
+	}
+	txOuts := make([]walletjson.CreateTxOutput, 0, len(draft.Tx.TxOut))
+	for i, txOut := range draft.Tx.TxOut {
+		txOuts = append(txOuts, walletjson.CreateTxOutput{
+			Address: scriptAddress(txOut.PkScript),
+			Amount:  btcutil.Amount(txOut.Value).ToBTC(),
+			Change:  i == draft.ChangeIndex,
+		})
+	}
+
+	log.Infof("Created draft transaction %v", draft.Tx.TxHash())
+
+	return &walletjson.CreateTxResult{
+		ID:      draft.ID,
+		Hex:     hex.EncodeToString(buf.Bytes()),
+		Inputs:  inputs,
+		Outputs: txOuts,
+		Fee:     draft.Fee.ToBTC(),
+		Expires: draft.Expiry.Unix(),
+	}, nil
+}
+
+// commitTx handles a committx extension request by signing and publishing a
+// draft transaction created by a createtx request.  It returns the transaction
+// hash, or the token of the pending spend when spends must be confirmed with a
+// TOTP code.
+func commitTx(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.CommitTxCmd)
+
+	token, tx, err := w.CommitDraftTx(cmd.ID)
+	if err != nil {
+		return nil, draftTxError(err)
+	}
+
+	if token != "" {
+		log.Infof("Committed transaction %v awaiting spend "+
+			"confirmation", tx.TxHash())
+		return token, nil
+	}
+
+	txHashStr := tx.TxHash().String()
+	log.Infof("Successfully sent committed transaction %v", txHashStr)
+	return txHashStr, nil
+}
+
+// cancelDraftTx handles a canceldrafttx extension request by discarding a
+// draft transaction.
+func cancelDraftTx(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.CancelDraftTxCmd)
+
+	err := w.CancelDraftTx(cmd.ID)
+	if err != nil {
+		return nil, draftTxError(err)
+	}
+	return nil, nil
+}
 
-	// Transaction comments are not yet supported.  Error instead of
-	// pretending to save them.
-	if !isNilOrEmpty(cmd.Comment) || !isNilOrEmpty(cmd.CommentTo) {
+// draftTxError returns the RPC error of an error committing or cancelling a
+// draft transaction.
+func draftTxError(err error) error {
+	switch {
+	case err == wallet.ErrUnknownDraftTx:
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	case waddrmgr.IsError(err, waddrmgr.ErrLocked):
+		return &ErrWalletUnlockNeeded
+	}
+	return &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInternal.Code,
+		Message: err.Error(),
+	}
+}
+
+// sweepPrivKey handles a sweepprivkey extension request by sending all
+// unspent outputs controlled by a WIF-encoded private key to a new address of
+// a wallet account.  The key is not imported into the wallet.
+func sweepPrivKey(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SweepPrivKeyCmd)
+
+	wif, err := btcutil.DecodeWIF(cmd.PrivKey)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "WIF decode failed: " + err.Error(),
+		}
+	}
+	if !wif.IsForNet(w.ChainParams()) {
 		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCUnimplemented,
-			Message: "Transaction comments are not yet supported",
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Key is not intended for " + w.ChainParams().Name,
 		}
 	}
 
-	amt, err := btcutil.NewAmount(cmd.Amount)
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *cmd.Account)
 	if err != nil {
 		return nil, err
 	}
+	if *cmd.StartHeight < 0 {
+		return nil, InvalidParameterError{
+			errors.New("startheight must not be negative"),
+		}
+	}
 
-	// Check that signed integer parameters are positive.
-	if amt < 0 {
-		return nil, ErrNeedPositiveAmount
+	res, err := w.SweepPrivKey(wif, waddrmgr.KeyScopeBIP0044, account,
+		*cmd.StartHeight, txrules.DefaultRelayFeePerKb)
+	switch {
+	case err == wallet.ErrNoSweepableOutputs, err == wallet.ErrSweepAmountDust:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWalletInsufficientFunds,
+			Message: err.Error(),
+		}
+	case err != nil:
+		return nil, err
 	}
 
-	// Mock up map of address and amount pairs.
-	pairs := map[string]btcutil.Amount{
-		cmd.Address: amt,
-	}
+	txHashStr := res.Tx.TxHash().String()
+	log.Infof("Successfully swept %v to %v in transaction %v",
+		res.Total-res.Fee, res.Address, txHashStr)
 
-	// sendtoaddress always spends from the default account, this matches bitcoind
-	return sendPairs(w, pairs, waddrmgr.KeyScopeBIP0044, waddrmgr.DefaultAccountNum, 1,
-		txrules.DefaultRelayFeePerKb)
+	return &walletjson.SweepPrivKeyResult{
+		TxID:    txHashStr,
+		Address: res.Address.EncodeAddress(),
+		Amount:  (res.Total - res.Fee).ToBTC(),
+		Fee:     res.Fee.ToBTC(),
+		Inputs:  res.InputCount,
+	}, nil
 }
 
 // setTxFee sets the transaction fee per kilobyte added to transactions.
@@ -1536,24 +3659,112 @@ func signMessage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		return nil, err
 	}
 
+	return signMessageWithAddress(w, addr, cmd.Message)
+}
+
+// signMessageWithAddress signs a message with the private key of a wallet
+// address, returning the base64 encoded compact signature which is checked by
+// verifymessage.
+func signMessageWithAddress(w *wallet.Wallet, addr btcutil.Address,
+	message string) (string, error) {
+
 	privKey, err := w.PrivKeyForAddress(addr)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	var buf bytes.Buffer
 	_ = wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
-	_ = wire.WriteVarString(&buf, 0, cmd.Message)
+	_ = wire.WriteVarString(&buf, 0, message)
 	messageHash := chainhash.DoubleHashB(buf.Bytes())
 	sigbytes, err := btcec.SignCompact(btcec.S256(), privKey,
 		messageHash, true)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	return base64.StdEncoding.EncodeToString(sigbytes), nil
 }
 
+// exportAuditSnapshot handles an exportauditsnapshot request by returning a
+// JSON document describing every address, unspent output and account balance
+// of the wallet as of a block, along with a signature of the document by a
+// wallet address.  The signature may be checked with verifymessage.
+func exportAuditSnapshot(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.ExportAuditSnapshotCmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+	switch addr.(type) {
+	case *btcutil.AddressPubKeyHash, *btcutil.AddressPubKey:
+	default:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Signing address must be a pay-to-pubkey-hash address",
+		}
+	}
+
+	height := w.Manager.SyncedTo().Height
+	if cmd.Height != nil {
+		height = *cmd.Height
+	}
+	snapshot, err := w.AuditSnapshot(height)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := walletjson.AuditSnapshot{
+		Version:     1,
+		Created:     w.Now().Unix(),
+		BlockHash:   snapshot.Block.Hash.String(),
+		BlockHeight: snapshot.Block.Height,
+		Addresses:   make([]walletjson.AuditSnapshotAddr, 0, len(snapshot.Addresses)),
+		Unspent:     make([]walletjson.AuditSnapshotOutput, 0, len(snapshot.Outputs)),
+		Balances:    make([]walletjson.AuditSnapshotAcct, 0, len(snapshot.Balances)),
+		Total:       snapshot.Total.ToBTC(),
+	}
+	for _, a := range snapshot.Addresses {
+		doc.Addresses = append(doc.Addresses, walletjson.AuditSnapshotAddr{
+			Address:  a.Address.EncodeAddress(),
+			Account:  a.AccountName,
+			KeyScope: a.Scope.String(),
+		})
+	}
+	for _, output := range snapshot.Outputs {
+		doc.Unspent = append(doc.Unspent, walletjson.AuditSnapshotOutput{
+			TxID:         output.OutPoint.Hash.String(),
+			Vout:         output.OutPoint.Index,
+			ScriptPubKey: hex.EncodeToString(output.PkScript),
+			Amount:       output.Amount.ToBTC(),
+			Height:       output.Height,
+		})
+	}
+	for _, b := range snapshot.Balances {
+		doc.Balances = append(doc.Balances, walletjson.AuditSnapshotAcct{
+			Account:  b.AccountName,
+			KeyScope: b.Scope.String(),
+			Balance:  b.Balance.ToBTC(),
+		})
+	}
+
+	serialized, err := json.Marshal(&doc)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signMessageWithAddress(w, addr, string(serialized))
+	if err != nil {
+		return nil, err
+	}
+
+	return &walletjson.ExportAuditSnapshotResult{
+		Snapshot:  string(serialized),
+		Address:   addr.EncodeAddress(),
+		Signature: signature,
+	}, nil
+}
+
 // signRawTransaction handles the signrawtransaction command.
 func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.RPCClient) (interface{}, error) {
 	cmd := icmd.(*btcjson.SignRawTransactionCmd)
@@ -1569,23 +3780,9 @@ func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.R
 		return nil, DeserializationError{e}
 	}
 
-	var hashType txscript.SigHashType
-	switch *cmd.Flags {
-	case "ALL":
-		hashType = txscript.SigHashAll
-	case "NONE":
-		hashType = txscript.SigHashNone
-	case "SINGLE":
-		hashType = txscript.SigHashSingle
-	case "ALL|ANYONECANPAY":
-		hashType = txscript.SigHashAll | txscript.SigHashAnyOneCanPay
-	case "NONE|ANYONECANPAY":
-		hashType = txscript.SigHashNone | txscript.SigHashAnyOneCanPay
-	case "SINGLE|ANYONECANPAY":
-		hashType = txscript.SigHashSingle | txscript.SigHashAnyOneCanPay
-	default:
-		e := errors.New("invalid sighash parameter")
-		return nil, InvalidParameterError{e}
+	hashType, err := parseSigHashType(*cmd.Flags)
+	if err != nil {
+		return nil, err
 	}
 
 	// TODO: really we probably should look these up with btcd anyway to
@@ -1709,6 +3906,133 @@ func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.R
 		panic(err)
 	}
 
+	signErrors := signRawTransactionErrors(&tx, signErrs)
+	return btcjson.SignRawTransactionResult{
+		Hex:      hex.EncodeToString(buf.Bytes()),
+		Complete: len(signErrors) == 0,
+		Errors:   signErrors,
+	}, nil
+}
+
+// signRawTransactionWithWallet handles the signrawtransactionwithwallet
+// command.  Unlike signrawtransaction, witness inputs are signed, and the
+// outputs spent by inputs which the wallet did not record must be described by
+// the request instead of being looked up by the chain server.
+func signRawTransactionWithWallet(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*btcjson.SignRawTransactionWithWalletCmd)
+
+	serializedTx, err := decodeHexStr(cmd.RawTx)
+	if err != nil {
+		return nil, err
+	}
+	var tx wire.MsgTx
+	err = tx.Deserialize(bytes.NewBuffer(serializedTx))
+	if err != nil {
+		e := errors.New("TX decode failed")
+		return nil, DeserializationError{e}
+	}
+
+	hashType, err := parseSigHashType(*cmd.SigHashType)
+	if err != nil {
+		return nil, err
+	}
+
+	prevOutputs := make(map[wire.OutPoint]*wallet.PrevOutput)
+	var cmdInputs []btcjson.RawTxWitnessInput
+	if cmd.Inputs != nil {
+		cmdInputs = *cmd.Inputs
+	}
+	for _, input := range cmdInputs {
+		inputHash, err := chainhash.NewHashFromStr(input.Txid)
+		if err != nil {
+			return nil, DeserializationError{err}
+		}
+
+		prevOut := &wallet.PrevOutput{}
+		prevOut.PkScript, err = decodeHexStr(input.ScriptPubKey)
+		if err != nil {
+			return nil, err
+		}
+		if input.RedeemScript != nil {
+			prevOut.RedeemScript, err = decodeHexStr(
+				*input.RedeemScript,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if input.WitnessScript != nil {
+			prevOut.WitnessScript, err = decodeHexStr(
+				*input.WitnessScript,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if input.Amount != nil {
+			prevOut.Amount, err = btcutil.NewAmount(*input.Amount)
+			if err != nil || prevOut.Amount < 0 {
+				return nil, InvalidParameterError{
+					fmt.Errorf("invalid amount %v of "+
+						"input %s:%d", *input.Amount,
+						input.Txid, input.Vout),
+				}
+			}
+		}
+		prevOutputs[wire.OutPoint{
+			Hash:  *inputHash,
+			Index: input.Vout,
+		}] = prevOut
+	}
+
+	signErrs, err := w.SignTransactionWithPrevOutputs(
+		&tx, hashType, prevOutputs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(tx.SerializeSize())
+	if err = tx.Serialize(&buf); err != nil {
+		panic(err)
+	}
+
+	signErrors := signRawTransactionErrors(&tx, signErrs)
+	return btcjson.SignRawTransactionWithWalletResult{
+		Hex:      hex.EncodeToString(buf.Bytes()),
+		Complete: len(signErrors) == 0,
+		Errors:   signErrors,
+	}, nil
+}
+
+// parseSigHashType parses the signature hash type of a signrawtransaction or
+// signrawtransactionwithwallet request.
+func parseSigHashType(name string) (txscript.SigHashType, error) {
+	switch name {
+	case "ALL":
+		return txscript.SigHashAll, nil
+	case "NONE":
+		return txscript.SigHashNone, nil
+	case "SINGLE":
+		return txscript.SigHashSingle, nil
+	case "ALL|ANYONECANPAY":
+		return txscript.SigHashAll | txscript.SigHashAnyOneCanPay, nil
+	case "NONE|ANYONECANPAY":
+		return txscript.SigHashNone | txscript.SigHashAnyOneCanPay, nil
+	case "SINGLE|ANYONECANPAY":
+		return txscript.SigHashSingle | txscript.SigHashAnyOneCanPay, nil
+	default:
+		e := errors.New("invalid sighash parameter")
+		return 0, InvalidParameterError{e}
+	}
+}
+
+// signRawTransactionErrors returns the errors of the inputs of a signed
+// transaction in the form of the signing methods' results.
+func signRawTransactionErrors(tx *wire.MsgTx,
+	signErrs []wallet.SignatureError) []btcjson.SignRawTransactionError {
+
 	signErrors := make([]btcjson.SignRawTransactionError, 0, len(signErrs))
 	for _, e := range signErrs {
 		input := tx.TxIn[e.InputIndex]
@@ -1720,12 +4044,7 @@ func signRawTransaction(icmd interface{}, w *wallet.Wallet, chainClient *chain.R
 			Error:     e.Error.Error(),
 		})
 	}
-
-	return btcjson.SignRawTransactionResult{
-		Hex:      hex.EncodeToString(buf.Bytes()),
-		Complete: len(signErrors) == 0,
-		Errors:   signErrors,
-	}, nil
+	return signErrors
 }
 
 // validateAddress handles the validateaddress command.
@@ -1746,7 +4065,13 @@ func validateAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	result.Address = addr.EncodeAddress()
 	result.IsValid = true
 
+	// The account of the address is looked up separately, as addresses of
+	// merged accounts belong to the account they were merged into.
+	var account uint32
 	ainfo, err := w.AddressInfo(addr)
+	if err == nil {
+		account, err = w.AccountOfAddress(addr)
+	}
 	if err != nil {
 		if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
 			// No additional information available about the address.
@@ -1758,9 +4083,7 @@ func validateAddress(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	// The address lookup was successful which means there is further
 	// information about it available and it is "mine".
 	result.IsMine = true
-	acctName, err := w.AccountName(
-		waddrmgr.KeyScopeBIP0044, ainfo.InternalAccount(),
-	)
+	acctName, err := w.AccountName(waddrmgr.KeyScopeBIP0044, account)
 	if err != nil {
 		return nil, &ErrAccountNameNotFound
 	}
@@ -1826,16 +4149,25 @@ func verifyMessage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 		return nil, err
 	}
 
+	return verifyLegacyMessage(addr, sig, cmd.Message)
+}
+
+// verifyLegacyMessage checks that a compact signature of a message, as created
+// by signmessage, was signed by the key of a pay-to-pubkey-hash or
+// pay-to-pubkey address.
+func verifyLegacyMessage(addr btcutil.Address, sig []byte,
+	message string) (bool, error) {
+
 	// Validate the signature - this just shows that it was valid at all.
 	// we will compare it with the key next.
 	var buf bytes.Buffer
 	_ = wire.WriteVarString(&buf, 0, "Bitcoin Signed Message:\n")
-	_ = wire.WriteVarString(&buf, 0, cmd.Message)
+	_ = wire.WriteVarString(&buf, 0, message)
 	expectedMessageHash := chainhash.DoubleHashB(buf.Bytes())
 	pk, wasCompressed, err := btcec.RecoverCompact(btcec.S256(), sig,
 		expectedMessageHash)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
 	var serializedPubKey []byte
@@ -1851,40 +4183,253 @@ func verifyMessage(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
 	case *btcutil.AddressPubKey: // ok
 		return string(serializedPubKey) == checkAddr.String(), nil
 	default:
-		return nil, errors.New("address type not supported")
+		return false, errors.New("address type not supported")
+	}
+}
+
+// signMessageBIP322 handles a signmessagebip322 extension request by signing a
+// message with the key of a wallet address of any type, returning the BIP0322
+// signature.
+func signMessageBIP322(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.SignMessageBIP322Cmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := w.SignMessageBIP322(addr, []byte(cmd.Message))
+	if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		return nil, &ErrWalletUnlockNeeded
+	}
+	if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+		return nil, &ErrAddressNotInWallet
 	}
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// verifyMessageBIP322 handles a verifymessagebip322 extension request by
+// checking that a BIP0322 signature of a message proves control of the script
+// of an address.  Legacy signatures are checked for pay-to-pubkey-hash
+// addresses.
+func verifyMessageBIP322(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.VerifyMessageBIP322Cmd)
+
+	addr, err := decodeAddress(cmd.Address, w.ChainParams())
+	if err != nil {
+		return nil, err
+	}
+
+	// Legacy compact signatures are always 65 bytes, which is too short
+	// for the full format signature of a pay-to-pubkey-hash address.
+	if _, ok := addr.(*btcutil.AddressPubKeyHash); ok {
+		sig, err := base64.StdEncoding.DecodeString(cmd.Signature)
+		if err == nil && len(sig) == 65 {
+			valid, err := verifyLegacyMessage(addr, sig, cmd.Message)
+			return err == nil && valid, nil
+		}
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+	err = bip322.Verify(pkScript, []byte(cmd.Message), cmd.Signature)
+	switch err {
+	case nil:
+		return true, nil
+	case bip322.ErrInvalidSignature:
+		return false, nil
+	}
+	return nil, InvalidParameterError{err}
 }
 
 // walletIsLocked handles the walletislocked extension request by
 // returning the current lock state (false for unlocked, true for locked)
-// of an account.
+// of the wallet, or of the requested account.
 func walletIsLocked(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	return w.Locked(), nil
+	lcmd := icmd.(*lockCmd)
+
+	switch {
+	case lcmd.account == nil:
+		return w.Locked(), nil
+	case *lcmd.account == allAccounts:
+		unlocked, err := w.AllAccountsUnlocked()
+		return !unlocked, err
+	}
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *lcmd.account)
+	if err != nil {
+		return nil, err
+	}
+	return w.AccountLocked(waddrmgr.KeyScopeBIP0044, account)
 }
 
 // walletLock handles a walletlock request by locking the all account
 // wallets, returning an error if any wallet is not encrypted (for example,
-// a watching-only wallet).
+// a watching-only wallet).  An account protected by its own passphrase is
+// locked instead when requested.
 func walletLock(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	w.Lock()
+	lcmd := icmd.(*lockCmd)
+
+	switch {
+	case lcmd.account == nil:
+		w.Lock()
+		return nil, nil
+	case *lcmd.account == allAccounts:
+		w.LockAllAccounts()
+		return nil, nil
+	}
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *lcmd.account)
+	if err != nil {
+		return nil, err
+	}
+	return nil, w.LockAccount(waddrmgr.KeyScopeBIP0044, account)
+}
+
+// walletExtendUnlock handles a walletextendunlock request by replacing the
+// timeout of the unlocked wallet, or of the requested account, so that it is
+// locked once timeout seconds have elapsed.  A zero timeout keeps it unlocked
+// until it is locked explicitly.
+func walletExtendUnlock(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.WalletExtendUnlockCmd)
+
+	if cmd.Timeout < 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Timeout cannot be negative",
+		}
+	}
+	timeout := time.Second * time.Duration(cmd.Timeout)
+
+	if cmd.Account == nil {
+		return nil, w.ExtendUnlock(timeout)
+	}
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *cmd.Account)
+	if err != nil {
+		return nil, err
+	}
+	err = w.ExtendAccountUnlock(waddrmgr.KeyScopeBIP0044, account, timeout)
+	return nil, err
+}
+
+// walletLockAll handles a walletlockall request by locking the wallet and
+// every account protected by its own passphrase at once.
+func walletLockAll(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	w.LockAllAccounts()
 	return nil, nil
 }
 
 // walletPassphrase responds to the walletpassphrase request by unlocking
-// the wallet.  The decryption key is saved in the wallet until timeout
-// seconds expires, after which the wallet is locked.
+// the wallet, or the requested account.  The decryption key is saved in the
+// wallet until timeout seconds expires, after which the wallet is locked.
 func walletPassphrase(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
-	cmd := icmd.(*btcjson.WalletPassphraseCmd)
+	lcmd := icmd.(*lockCmd)
+	cmd := lcmd.cmd.(*btcjson.WalletPassphraseCmd)
+	defer lcmd.passphrase.Free()
 
+	if cmd.Timeout < 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Timeout cannot be negative",
+		}
+	}
 	timeout := time.Second * time.Duration(cmd.Timeout)
-	var unlockAfter <-chan time.Time
-	if timeout != 0 {
-		unlockAfter = time.After(timeout)
+	passphrase := lcmd.passphrase.Bytes()
+
+	if lcmd.level != nil {
+		if lcmd.account != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Unlock levels only apply to the wallet",
+			}
+		}
+		level, err := parseUnlockLevel(*lcmd.level)
+		if err != nil {
+			return nil, err
+		}
+		return nil, w.UnlockWithLevel(passphrase, level, timeout)
+	}
+
+	switch {
+	case lcmd.account == nil:
+		return nil, w.UnlockWithTimeout(passphrase, timeout)
+	case *lcmd.account == allAccounts:
+		return nil, w.UnlockAllAccounts(passphrase, timeout)
+	}
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, *lcmd.account)
+	if err != nil {
+		return nil, err
 	}
-	err := w.Unlock([]byte(cmd.Passphrase), unlockAfter)
+	err = w.UnlockAccount(
+		waddrmgr.KeyScopeBIP0044, account, passphrase, timeout,
+	)
 	return nil, err
 }
 
+// parseUnlockLevel returns the unlock level with the name.
+func parseUnlockLevel(name string) (waddrmgr.UnlockLevel, error) {
+	levels := []waddrmgr.UnlockLevel{
+		waddrmgr.UnlockView, waddrmgr.UnlockSpend, waddrmgr.UnlockFull,
+	}
+	for _, level := range levels {
+		if level.String() == name {
+			return level, nil
+		}
+	}
+	return 0, &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParameter,
+		Message: fmt.Sprintf("Unknown unlock level '%s'", name),
+	}
+}
+
+// walletUnlockedUntil handles a walletunlockeduntil request by returning
+// whether the wallet, or the requested account, is unlocked and the time at
+// which it will be locked again.
+func walletUnlockedUntil(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.WalletUnlockedUntilCmd)
+
+	var (
+		until    time.Time
+		unlocked bool
+	)
+	var level string
+	if cmd.Account == nil {
+		until, unlocked = w.UnlockedUntil()
+		if l, ok := w.UnlockLevel(); ok {
+			level = l.String()
+		}
+	} else {
+		account, err := w.AccountNumber(
+			waddrmgr.KeyScopeBIP0044, *cmd.Account,
+		)
+		if err != nil {
+			return nil, err
+		}
+		until, unlocked, err = w.AccountUnlockedUntil(
+			waddrmgr.KeyScopeBIP0044, account,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &walletjson.WalletUnlockedUntilResult{
+		Unlocked:    unlocked,
+		UnlockLevel: level,
+	}
+	if !until.IsZero() {
+		result.UnlockedUntil = until.Unix()
+	}
+	return result, nil
+}
+
 // walletPassphraseChange responds to the walletpassphrasechange request
 // by unlocking all accounts with the provided old passphrase, and
 // re-encrypting each private key with an AES key derived from the new
@@ -0,0 +1,454 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+)
+
+// TestSendCmdAmounts ensures send requests are parsed with any trailing
+// options object and that amounts are converted using the requested unit.
+func TestSendCmdAmounts(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      string
+		defaultUnit btcutil.AmountUnit
+		want        btcutil.Amount
+		wantErr     bool
+	}{
+		{
+			name:        "no options",
+			params:      `["addr", 1.5]`,
+			defaultUnit: btcutil.AmountBTC,
+			want:        150000000,
+		},
+		{
+			name:        "default unit",
+			params:      `["addr", 1500]`,
+			defaultUnit: btcutil.AmountSatoshi,
+			want:        1500,
+		},
+		{
+			name:        "satoshi option",
+			params:      `["addr", 1500, "", "", {"unit": "sat"}]`,
+			defaultUnit: btcutil.AmountBTC,
+			want:        1500,
+		},
+		{
+			name:        "mBTC option",
+			params:      `["addr", 2.5, null, null, {"unit": "mBTC"}]`,
+			defaultUnit: btcutil.AmountSatoshi,
+			want:        250000,
+		},
+		{
+			name:        "unknown unit",
+			params:      `["addr", 1, "", "", {"unit": "ounces"}]`,
+			defaultUnit: btcutil.AmountBTC,
+			wantErr:     true,
+		},
+	}
+
+	for _, test := range tests {
+		var params []json.RawMessage
+		if err := json.Unmarshal([]byte(test.params), &params); err != nil {
+			t.Fatalf("%s: bad test params: %v", test.name, err)
+		}
+		req := &btcjson.Request{
+			Jsonrpc: "1.0",
+			Method:  "sendtoaddress",
+			Params:  params,
+			ID:      1,
+		}
+		icmd, err := unmarshalCmd(req, test.defaultUnit)
+		if err != nil {
+			t.Fatalf("%s: unable to unmarshal request: %v",
+				test.name, err)
+		}
+		scmd, ok := icmd.(*sendCmd)
+		if !ok {
+			t.Fatalf("%s: unexpected command type %T", test.name, icmd)
+		}
+		cmd := scmd.cmd.(*btcjson.SendToAddressCmd)
+		amt, err := scmd.amount(cmd.Amount)
+		if test.wantErr {
+			if err == nil {
+				t.Fatalf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if amt != test.want {
+			t.Fatalf("%s: want amount %v, got %v", test.name,
+				test.want, amt)
+		}
+	}
+}
+
+// TestSendCmdFeeRate ensures the fee rate of send requests defaults to the
+// relay fee, may be overridden in the unit of the request, and is rejected
+// below the relay fee.
+func TestSendCmdFeeRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  string
+		want    btcutil.Amount
+		wantErr bool
+	}{
+		{
+			name:   "default",
+			params: `["addr", 1]`,
+			want:   txrules.DefaultRelayFeePerKb,
+		},
+		{
+			name:   "override",
+			params: `["addr", 1, null, null, {"feerate": 0.0005}]`,
+			want:   50000,
+		},
+		{
+			name:   "override in unit",
+			params: `["addr", 1, null, null, {"feerate": 2000, "unit": "sat"}]`,
+			want:   2000,
+		},
+		{
+			name:    "below relay fee",
+			params:  `["addr", 1, null, null, {"feerate": 0.000005}]`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		var params []json.RawMessage
+		if err := json.Unmarshal([]byte(test.params), &params); err != nil {
+			t.Fatalf("%s: bad test params: %v", test.name, err)
+		}
+		req := &btcjson.Request{
+			Jsonrpc: "1.0",
+			Method:  "sendtoaddress",
+			Params:  params,
+			ID:      1,
+		}
+		icmd, err := unmarshalCmd(req, btcutil.AmountBTC)
+		if err != nil {
+			t.Fatalf("%s: unable to unmarshal request: %v",
+				test.name, err)
+		}
+		feeRate, err := icmd.(*sendCmd).feeRate()
+		if test.wantErr {
+			if _, ok := err.(InvalidParameterError); !ok {
+				t.Fatalf("%s: want invalid parameter error, "+
+					"got %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if feeRate != test.want {
+			t.Fatalf("%s: want fee rate %v, got %v", test.name,
+				test.want, feeRate)
+		}
+	}
+}
+
+// TestListAccountsCmdVerbose ensures listaccounts requests are parsed with the
+// optional trailing verbose flag.
+func TestListAccountsCmdVerbose(t *testing.T) {
+	tests := []struct {
+		name        string
+		params      string
+		wantMinConf int
+		wantVerbose bool
+		wantErr     bool
+	}{
+		{
+			name:        "no params",
+			params:      `[]`,
+			wantMinConf: 1,
+		},
+		{
+			name:        "minconf only",
+			params:      `[6]`,
+			wantMinConf: 6,
+		},
+		{
+			name:        "verbose",
+			params:      `[0, true]`,
+			wantMinConf: 0,
+			wantVerbose: true,
+		},
+		{
+			name:    "too many params",
+			params:  `[0, true, 1]`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		var params []json.RawMessage
+		if err := json.Unmarshal([]byte(test.params), &params); err != nil {
+			t.Fatalf("%s: bad test params: %v", test.name, err)
+		}
+		req := &btcjson.Request{
+			Jsonrpc: "1.0",
+			Method:  "listaccounts",
+			Params:  params,
+			ID:      1,
+		}
+		icmd, err := unmarshalCmd(req, btcutil.AmountBTC)
+		if test.wantErr {
+			if err == nil {
+				t.Fatalf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unable to unmarshal request: %v",
+				test.name, err)
+		}
+		lcmd, ok := icmd.(*listAccountsCmd)
+		if !ok {
+			t.Fatalf("%s: unexpected command type %T", test.name, icmd)
+		}
+		if *lcmd.cmd.MinConf != test.wantMinConf {
+			t.Fatalf("%s: want minconf %d, got %d", test.name,
+				test.wantMinConf, *lcmd.cmd.MinConf)
+		}
+		if lcmd.verbose != test.wantVerbose {
+			t.Fatalf("%s: want verbose %v, got %v", test.name,
+				test.wantVerbose, lcmd.verbose)
+		}
+	}
+}
+
+// TestUnmarshalLockCmd ensures the optional account parameter of lock requests
+// is parsed after the reference parameters, followed by the unlock level of
+// walletpassphrase requests.
+func TestUnmarshalLockCmd(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		params      string
+		wantAccount string
+		wantLevel   string
+		wantErr     bool
+	}{
+		{
+			name:   "walletlock",
+			method: "walletlock",
+			params: `[]`,
+		},
+		{
+			name:        "walletlock account",
+			method:      "walletlock",
+			params:      `["savings"]`,
+			wantAccount: "savings",
+		},
+		{
+			name:   "walletpassphrase",
+			method: "walletpassphrase",
+			params: `["pass", 60]`,
+		},
+		{
+			name:        "walletpassphrase all accounts",
+			method:      "walletpassphrase",
+			params:      `["pass", 60, "*"]`,
+			wantAccount: "*",
+		},
+		{
+			name:      "walletpassphrase unlock level",
+			method:    "walletpassphrase",
+			params:    `["pass", 60, null, "spend"]`,
+			wantLevel: "spend",
+		},
+		{
+			name:    "walletlock unlock level",
+			method:  "walletlock",
+			params:  `[null, "spend"]`,
+			wantErr: true,
+		},
+		{
+			name:    "walletislocked too many params",
+			method:  "walletislocked",
+			params:  `["savings", "extra"]`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		var params []json.RawMessage
+		if err := json.Unmarshal([]byte(test.params), &params); err != nil {
+			t.Fatalf("%s: bad test params: %v", test.name, err)
+		}
+		req := &btcjson.Request{
+			Jsonrpc: "1.0",
+			Method:  test.method,
+			Params:  params,
+			ID:      1,
+		}
+		icmd, err := unmarshalCmd(req, btcutil.AmountBTC)
+		if test.wantErr {
+			if err == nil {
+				t.Fatalf("%s: expected error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unable to unmarshal request: %v",
+				test.name, err)
+		}
+		lcmd, ok := icmd.(*lockCmd)
+		if !ok {
+			t.Fatalf("%s: unexpected command type %T", test.name, icmd)
+		}
+		var account string
+		if lcmd.account != nil {
+			account = *lcmd.account
+		}
+		if account != test.wantAccount {
+			t.Fatalf("%s: want account %q, got %q", test.name,
+				test.wantAccount, account)
+		}
+		var level string
+		if lcmd.level != nil {
+			level = *lcmd.level
+		}
+		if level != test.wantLevel {
+			t.Fatalf("%s: want level %q, got %q", test.name,
+				test.wantLevel, level)
+		}
+
+		// The passphrase of walletpassphrase requests must only be
+		// found in secure memory.
+		if test.method != "walletpassphrase" {
+			continue
+		}
+		if lcmd.passphrase == nil ||
+			string(lcmd.passphrase.Bytes()) != "pass" {
+
+			t.Fatalf("%s: passphrase not decoded", test.name)
+		}
+		lcmd.passphrase.Free()
+		cmd := lcmd.cmd.(*btcjson.WalletPassphraseCmd)
+		if cmd.Passphrase != "" {
+			t.Fatalf("%s: passphrase left in command", test.name)
+		}
+		for _, b := range params[0] {
+			if b != 0 {
+				t.Fatalf("%s: passphrase left in request",
+					test.name)
+			}
+		}
+	}
+}
+
+// TestListCmdPage ensures the cursor, skip and count options of list requests
+// select the expected page of results.
+func TestListCmdPage(t *testing.T) {
+	keys := []string{"a", "b", "c", "d", "e"}
+
+	tests := []struct {
+		name       string
+		opts       string
+		start, end int
+		wantErr    bool
+	}{
+		{name: "no options", opts: `{}`, start: 0, end: 5},
+		{name: "count", opts: `{"count": 2}`, start: 0, end: 2},
+		{name: "skip", opts: `{"skip": 3}`, start: 3, end: 5},
+		{name: "skip past end", opts: `{"skip": 9}`, start: 5, end: 5},
+		{name: "cursor", opts: `{"cursor": "b"}`, start: 2, end: 5},
+		{name: "cursor and count", opts: `{"cursor": "b", "count": 2}`,
+			start: 2, end: 4},
+		{name: "cursor and skip", opts: `{"cursor": "a", "skip": 1,
+			"count": 10}`, start: 2, end: 5},
+		{name: "last cursor", opts: `{"cursor": "e"}`, start: 5, end: 5},
+		{name: "unknown cursor", opts: `{"cursor": "z"}`, wantErr: true},
+		{name: "negative count", opts: `{"count": -1}`, wantErr: true},
+		{name: "negative skip", opts: `{"skip": -1}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		var lcmd listCmd
+		if err := json.Unmarshal([]byte(test.opts), &lcmd.opts); err != nil {
+			t.Fatalf("%s: bad test options: %v", test.name, err)
+		}
+		start, end, err := lcmd.page(len(keys), func(i int) string {
+			return keys[i]
+		})
+		if test.wantErr {
+			if _, ok := err.(InvalidParameterError); !ok {
+				t.Errorf("%s: expected invalid parameter error, "+
+					"got %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if start != test.start || end != test.end {
+			t.Errorf("%s: got page [%d, %d), want [%d, %d)",
+				test.name, start, end, test.start, test.end)
+		}
+	}
+}
+
+// TestFilterUnspentAmount ensures the minimumAmount and maximumAmount options
+// of listunspent select outputs within the inclusive range of amounts.
+func TestFilterUnspentAmount(t *testing.T) {
+	amounts := []float64{0.0001, 0.5, 1, 2.5}
+	amount := func(a float64) *float64 { return &a }
+
+	tests := []struct {
+		name     string
+		min, max *float64
+		want     []float64
+		wantErr  bool
+	}{
+		{name: "no options", want: amounts},
+		{name: "minimum", min: amount(0.5), want: []float64{0.5, 1, 2.5}},
+		{name: "maximum", max: amount(1), want: []float64{0.0001, 0.5, 1}},
+		{name: "range", min: amount(0.2), max: amount(2),
+			want: []float64{0.5, 1}},
+		{name: "empty range", min: amount(2), max: amount(1)},
+		{name: "negative minimum", min: amount(-1), wantErr: true},
+		{name: "negative maximum", max: amount(-1), wantErr: true},
+	}
+
+	for _, test := range tests {
+		unspent := make([]*btcjson.ListUnspentResult, len(amounts))
+		for i, a := range amounts {
+			unspent[i] = &btcjson.ListUnspentResult{Amount: a}
+		}
+		filtered, err := filterUnspentAmount(unspent, test.min, test.max)
+		if test.wantErr {
+			if _, ok := err.(InvalidParameterError); !ok {
+				t.Errorf("%s: expected invalid parameter error, "+
+					"got %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		var got []float64
+		for _, output := range filtered {
+			got = append(got, output.Amount)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got amounts %v, want %v", test.name, got,
+				test.want)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// addNotificationClient registers an authenticated websocket client to receive
+// notifications.  Clients authenticated with the public tier credentials are
+// restricted to the public methods and are never notified.
+func (s *Server) addNotificationClient(wsc *websocketClient) {
+	if !wsc.authenticated || wsc.tier == publicTier {
+		return
+	}
+	s.ntfnClientsMtx.Lock()
+	s.ntfnClients[wsc] = struct{}{}
+	s.ntfnClientsMtx.Unlock()
+}
+
+// removeNotificationClient deregisters a websocket client from receiving
+// notifications.  It must be called before the client's responses channel is
+// closed.
+func (s *Server) removeNotificationClient(wsc *websocketClient) {
+	s.ntfnClientsMtx.Lock()
+	delete(s.ntfnClients, wsc)
+	s.ntfnClientsMtx.Unlock()
+}
+
+// forEachNotificationClient calls f with every registered websocket client.
+func (s *Server) forEachNotificationClient(f func(wsc *websocketClient)) {
+	// The waitgroup of each client is incremented while the client is
+	// still registered so that its responses channel is not closed until
+	// f returns.
+	s.ntfnClientsMtx.Lock()
+	clients := make([]*websocketClient, 0, len(s.ntfnClients))
+	for wsc := range s.ntfnClients {
+		wsc.wg.Add(1)
+		clients = append(clients, wsc)
+	}
+	s.ntfnClientsMtx.Unlock()
+
+	for _, wsc := range clients {
+		f(wsc)
+		wsc.wg.Done()
+	}
+}
+
+// broadcastNotification marshals a notification and sends it to every
+// registered websocket client subscribed to it, formatted as negotiated by
+// each client.
+func (s *Server) broadcastNotification(ntfn interface{}) {
+	method, err := btcjson.CmdMethod(ntfn)
+	if err != nil {
+		log.Errorf("Unknown notification %T: %v", ntfn, err)
+		return
+	}
+	b, err := btcjson.MarshalCmd(btcjson.RpcVersion1, nil, ntfn)
+	if err != nil {
+		log.Errorf("Unable to marshal %s notification: %v", method,
+			err)
+		return
+	}
+
+	// Only notifications of transactions and the deprecated balance
+	// notifications are specific to an account.  Large deposits are
+	// always notified, so that they are never missed by clients which
+	// only subscribed to some notifications.
+	var (
+		account *string
+		always  bool
+	)
+	switch n := ntfn.(type) {
+	case *walletjson.NewTxNtfn:
+		account = &n.Account
+	case *btcjson.AccountBalanceNtfn:
+		account = &n.Account
+	case *walletjson.LargeDepositNtfn:
+		always = true
+	}
+
+	s.forEachNotificationClient(func(wsc *websocketClient) {
+		if !always && !wsc.subscribed(method, account) {
+			return
+		}
+		fb, err := wsc.format.applyNtfn(b)
+		if err != nil {
+			log.Errorf("Unable to format %s notification: %v",
+				method, err)
+			return
+		}
+		_ = wsc.send(fb)
+	})
+}
+
+// notifyConflicts notifies websocket clients of each unmined transaction of
+// the wallet removed for being double spent, until the server is stopped.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *Server) notifyConflicts(w *wallet.Wallet) {
+	defer s.wg.Done()
+
+	client := w.NtfnServer.ConflictedTransactionNotifications()
+	defer client.Done()
+
+	for {
+		select {
+		case n := <-client.C:
+			s.broadcastNotification(walletjson.NewTxConflictNtfn(
+				n.Hash.String(), n.ConflictingHash.String(),
+			))
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// notifyBackupFailures notifies websocket clients of each scheduled backup of
+// the wallet which failed, until the server is stopped.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *Server) notifyBackupFailures(w *wallet.Wallet) {
+	defer s.wg.Done()
+
+	client := w.NtfnServer.BackupFailureNotifications()
+	defer client.Done()
+
+	for {
+		select {
+		case n := <-client.C:
+			s.broadcastNotification(walletjson.NewBackupFailedNtfn(
+				n.Time.Unix(), n.Err.Error(),
+			))
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// notifyChainHealth notifies websocket clients each time the chain followed by
+// the wallet is detected to be stalled or on a minority fork, or such a problem
+// is resolved, until the server is stopped.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *Server) notifyChainHealth(w *wallet.Wallet) {
+	defer s.wg.Done()
+
+	client := w.NtfnServer.ChainHealthNotifications()
+	defer client.Done()
+
+	for {
+		select {
+		case n := <-client.C:
+			s.broadcastNotification(walletjson.NewChainHealthNtfn(
+				n.Stalled, n.MinorityFork, n.LastBlockSeen.Unix(),
+			))
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// notifyLockState notifies websocket clients each time the wallet or accounts
+// protected by their own passphrase are locked or unlocked, until the server is
+// stopped.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *Server) notifyLockState(w *wallet.Wallet) {
+	defer s.wg.Done()
+
+	client := w.NtfnServer.LockStateNotifications()
+	defer client.Done()
+
+	for {
+		select {
+		case n := <-client.C:
+			accounts := make([]string, 0, len(n.Accounts))
+			for _, a := range n.Accounts {
+				name, err := w.AccountName(a.Scope, a.Index)
+				if err != nil {
+					log.Errorf("Cannot look up name of "+
+						"account %d: %v", a.Index, err)
+					continue
+				}
+				accounts = append(accounts, name)
+			}
+			sort.Strings(accounts)
+			s.broadcastNotification(walletjson.NewLockStateNtfn(
+				n.Locked, n.Wallet, accounts,
+			))
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// notifyStuckTransactions notifies websocket clients of each send of the wallet
+// which remains unmined longer than the unmined expiry, with the fee rate
+// suggested for a replacement, until the server is stopped.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *Server) notifyStuckTransactions(w *wallet.Wallet) {
+	defer s.wg.Done()
+
+	client := w.NtfnServer.ExpiredTransactionNotifications()
+	defer client.Done()
+
+	for {
+		select {
+		case n := <-client.C:
+			s.broadcastNotification(walletjson.NewTxStuckNtfn(
+				n.Hash.String(), n.Received.Unix(),
+				n.Fee.ToBTC(), n.FeeRate.ToBTC(),
+				n.BumpFeeRate.ToBTC(),
+			))
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// notifyLargeDeposits notifies websocket clients of each deposit to an account
+// of at least its deposit alert amount, until the server is stopped.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *Server) notifyLargeDeposits(w *wallet.Wallet) {
+	defer s.wg.Done()
+
+	client := w.NtfnServer.LargeDepositNotifications()
+	defer client.Done()
+
+	for {
+		select {
+		case n := <-client.C:
+			s.broadcastNotification(walletjson.NewLargeDepositNtfn(
+				n.Hash.String(), n.AccountName, n.Amount.ToBTC(),
+				n.Threshold.ToBTC(), n.Mined,
+			))
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// notifyRescanProgress broadcasts a btcwallet:rescanprogress notification as
+// rescan jobs progress and complete.
+func (s *Server) notifyRescanProgress(w *wallet.Wallet) {
+	defer s.wg.Done()
+
+	client := w.NtfnServer.RescanProgressNotifications()
+	defer client.Done()
+
+	for {
+		select {
+		case n := <-client.C:
+			var errStr *string
+			if n.Err != nil {
+				str := n.Err.Error()
+				errStr = &str
+			}
+			s.broadcastNotification(walletjson.NewRescanProgressNtfn(
+				n.ID, n.Height, n.Percent, n.Done, errStr,
+			))
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// newTxNtfns returns the btcwallet:newtx notifications of a transaction first
+// seen or mined by the wallet, which has the passed number of confirmations.
+func newTxNtfns(w *wallet.Wallet, tx *wallet.TransactionSummary,
+	confirmations int32) []*walletjson.NewTxNtfn {
+
+	var msgTx wire.MsgTx
+	err := msgTx.Deserialize(bytes.NewReader(tx.Transaction))
+	if err != nil {
+		log.Errorf("Cannot deserialize transaction %v: %v", tx.Hash, err)
+		return nil
+	}
+
+	// Outputs of the wallet map to whether they are change.
+	credits := make(map[uint32]bool, len(tx.MyOutputs))
+	for _, output := range tx.MyOutputs {
+		credits[output.Index] = output.Internal
+	}
+
+	// Outputs paying other wallets are sent from the account of the first
+	// input spending an output of the wallet.
+	send := len(tx.MyInputs) != 0
+	var sendAccount string
+	if send {
+		sendAccount, _ = w.AccountName(
+			waddrmgr.KeyScopeBIP0044, tx.MyInputs[0].PreviousAccount,
+		)
+	}
+
+	txID := tx.Hash.String()
+	var ntfns []*walletjson.NewTxNtfn
+	for i, output := range msgTx.TxOut {
+		change, credit := credits[uint32(i)]
+		if change || (!credit && !send) {
+			continue
+		}
+
+		var addr btcutil.Address
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			output.PkScript, w.ChainParams(),
+		)
+		if err == nil && len(addrs) == 1 {
+			addr = addrs[0]
+		}
+		var address string
+		if addr != nil {
+			address = addr.EncodeAddress()
+		}
+
+		amount := btcutil.Amount(output.Value).ToBTC()
+		account := sendAccount
+		if credit {
+			account = ""
+			if addr != nil {
+				account = addressAccountName(w, addr)
+			}
+		} else {
+			amount = -amount
+		}
+
+		ntfns = append(ntfns, walletjson.NewNewTxNtfn(
+			account, address, amount, txID, confirmations,
+		))
+	}
+	return ntfns
+}
+
+// addressAccountName returns the name of the account of a wallet address, or
+// the empty string if it can not be determined.
+func addressAccountName(w *wallet.Wallet, addr btcutil.Address) string {
+	account, err := w.AccountOfAddress(addr)
+	if err != nil {
+		return ""
+	}
+	name, err := w.AccountName(waddrmgr.KeyScopeBIP0044, account)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// blockNtfns returns the btcwallet:blockdisconnected and
+// btcwallet:blockconnected notifications of the blocks detached from and
+// attached to the wallet's main chain, in the order they were processed.
+func blockNtfns(n *wallet.TransactionNotifications, syncedTo int32,
+	synced bool) []interface{} {
+
+	ntfns := make([]interface{}, 0,
+		len(n.DetachedBlocks)+len(n.AttachedBlocks))
+	for _, hash := range n.DetachedBlocks {
+		ntfns = append(ntfns, walletjson.NewBlockDisconnectedNtfn(
+			hash.String(), syncedTo, synced,
+		))
+	}
+	for _, b := range n.AttachedBlocks {
+		ntfns = append(ntfns, walletjson.NewBlockConnectedNtfn(
+			b.Hash.String(), b.Height, b.Timestamp, syncedTo,
+			synced,
+		))
+	}
+	return ntfns
+}
+
+// balanceNtfns returns the btcwallet:accountbalances notification of the
+// balances of every account, given the balance breakdown of each account.
+// Balances are also valued with the exchange rate, when it is non-nil.  When
+// legacy is set, the deprecated pair of accountbalance notifications of each
+// account follows it.
+func balanceNtfns(accounts []wallet.AccountBalanceBreakdown,
+	rate *wallet.FiatRate, legacy bool) []interface{} {
+
+	balances := make(map[string]walletjson.AccountBalance, len(accounts))
+	for i := range accounts {
+		b := &accounts[i].BalanceBreakdown
+		details := balanceDetails(b)
+		balance := walletjson.AccountBalance{
+			Confirmed: b.Confirmed.ToBTC(),
+			Unconfirmed: (b.TrustedPending +
+				b.UntrustedPending).ToBTC(),
+			Trusted:          details.Trusted,
+			UntrustedPending: details.UntrustedPending,
+			Immature:         details.Immature,
+		}
+		if rate != nil {
+			fiat := rate.Value(balanceTotal(b))
+			balance.Fiat = &fiat
+		}
+		balances[accounts[i].AccountName] = balance
+	}
+
+	var fiatRate *walletjson.FiatRate
+	if rate != nil {
+		fiatRate = &walletjson.FiatRate{
+			Currency: rate.Currency,
+			Rate:     rate.Rate,
+			RateTime: rate.Time.Unix(),
+		}
+	}
+	ntfns := []interface{}{
+		walletjson.NewAccountBalancesNtfn(balances, fiatRate),
+	}
+	if !legacy {
+		return ntfns
+	}
+	for i := range accounts {
+		name := accounts[i].AccountName
+		ntfns = append(ntfns,
+			btcjson.NewAccountBalanceNtfn(name,
+				balances[name].Confirmed, true),
+			btcjson.NewAccountBalanceNtfn(name,
+				balances[name].Unconfirmed, false),
+		)
+	}
+	return ntfns
+}
+
+// accountBalanceNtfns returns the balance notifications of every account of
+// the BIP0044 scope of the wallet.
+func (s *Server) accountBalanceNtfns(w *wallet.Wallet) ([]interface{}, error) {
+	_, accounts, err := w.BalanceBreakdowns(waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		return nil, err
+	}
+	return balanceNtfns(accounts, w.FiatRate(), s.legacyBalanceNtfns), nil
+}
+
+// notifyTransactions notifies websocket clients of the blocks attached to and
+// detached from the wallet's main chain, of the transactions of the wallet
+// which are first seen or mined and of changed account balances, and checks
+// the confirmation subscriptions of the clients each time the wallet's
+// transactions or best block change, until the server is stopped.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *Server) notifyTransactions(w *wallet.Wallet) {
+	defer s.wg.Done()
+
+	client := w.NtfnServer.TransactionNotifications()
+	defer client.Done()
+
+	for {
+		var n *wallet.TransactionNotifications
+		select {
+		case n = <-client.C:
+		case <-s.quit:
+			return
+		}
+
+		syncHeight := w.Manager.SyncedTo().Height
+		ntfns := blockNtfns(n, syncHeight, w.ChainSynced())
+		for i := range n.UnminedTransactions {
+			for _, ntfn := range newTxNtfns(
+				w, &n.UnminedTransactions[i], 0,
+			) {
+				ntfns = append(ntfns, ntfn)
+			}
+		}
+		for _, b := range n.AttachedBlocks {
+			confs := confirms(b.Height, syncHeight)
+			for i := range b.Transactions {
+				for _, ntfn := range newTxNtfns(
+					w, &b.Transactions[i], confs,
+				) {
+					ntfns = append(ntfns, ntfn)
+				}
+			}
+		}
+		if len(n.NewBalances) != 0 {
+			bals, err := s.accountBalanceNtfns(w)
+			if err != nil {
+				log.Errorf("Unable to calculate account balances "+
+					"to notify: %v", err)
+			}
+			ntfns = append(ntfns, bals...)
+		}
+		for _, ntfn := range ntfns {
+			s.broadcastNotification(ntfn)
+		}
+
+		s.forEachNotificationClient(func(wsc *websocketClient) {
+			s.checkConfirmations(wsc, w)
+		})
+	}
+}
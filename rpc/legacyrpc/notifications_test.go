@@ -0,0 +1,351 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestBroadcastNotification ensures that notifications are only sent to
+// websocket clients authenticated with the full access credentials.
+func TestBroadcastNotification(t *testing.T) {
+	s := NewServer(&Options{}, nil, nil)
+
+	full := newWebsocketClient(nil, true, fullTier, "full", nil)
+	public := newWebsocketClient(nil, true, publicTier, "public", nil)
+	unauthenticated := newWebsocketClient(nil, false, fullTier, "none", nil)
+	for _, wsc := range []*websocketClient{full, public, unauthenticated} {
+		s.addNotificationClient(wsc)
+	}
+	if len(s.ntfnClients) != 1 {
+		t.Fatalf("expected 1 notification client, got %d",
+			len(s.ntfnClients))
+	}
+
+	ntfn := walletjson.NewTxConflictNtfn("a", "b")
+	go s.broadcastNotification(ntfn)
+
+	var b []byte
+	select {
+	case b = <-full.responses:
+	case <-time.After(time.Second):
+		t.Fatal("notification not sent")
+	}
+
+	var req btcjson.Request
+	if err := json.Unmarshal(b, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != walletjson.TxConflictNtfnMethod {
+		t.Fatalf("expected method %s, got %s",
+			walletjson.TxConflictNtfnMethod, req.Method)
+	}
+	cmd, err := btcjson.UnmarshalCmd(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := cmd.(*walletjson.TxConflictNtfn)
+	if n.TxID != "a" || n.ConflictingTxID != "b" {
+		t.Fatalf("unexpected notification %+v", n)
+	}
+
+	// Large deposits are sent to clients which did not subscribe to them.
+	full.subscribe([]string{walletjson.NewTxNtfnMethod}, nil)
+	go s.broadcastNotification(walletjson.NewLargeDepositNtfn(
+		"a", "default", 2, 1, false,
+	))
+	select {
+	case b = <-full.responses:
+	case <-time.After(time.Second):
+		t.Fatal("large deposit notification not sent")
+	}
+	if err := json.Unmarshal(b, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != walletjson.LargeDepositNtfnMethod {
+		t.Fatalf("expected method %s, got %s",
+			walletjson.LargeDepositNtfnMethod, req.Method)
+	}
+
+	// Removed clients are no longer notified.
+	s.removeNotificationClient(full)
+	if len(s.ntfnClients) != 0 {
+		t.Fatalf("expected no notification clients, got %d",
+			len(s.ntfnClients))
+	}
+	go s.broadcastNotification(ntfn)
+	select {
+	case b = <-full.responses:
+		t.Fatalf("notification %s sent to removed client", b)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestBroadcastNotificationFormat ensures that notifications are formatted as
+// negotiated by each websocket client.
+func TestBroadcastNotificationFormat(t *testing.T) {
+	s := NewServer(&Options{}, nil, nil)
+
+	query, err := url.ParseQuery("unit=sat&timeformat=iso8601")
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, err := parseResultFormat(query, btcutil.AmountBTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := newWebsocketClient(nil, true, fullTier, "plain", nil)
+	formatted := newWebsocketClient(nil, true, fullTier, "formatted", format)
+	s.addNotificationClient(plain)
+	s.addNotificationClient(formatted)
+
+	go s.broadcastNotification(walletjson.NewTxStuckNtfn(
+		"a", 1600000000, 0.0001, 0.00002, 0.00004,
+	))
+
+	// The clients are notified in no particular order, so both are read
+	// at once.
+	want := map[*websocketClient]string{
+		plain:     `["a",1600000000,0.0001,0.00002,0.00004]`,
+		formatted: `["a","2020-09-13T12:26:40Z","10000",0.00002,0.00004]`,
+	}
+	for range want {
+		var (
+			b   []byte
+			wsc *websocketClient
+		)
+		select {
+		case b = <-plain.responses:
+			wsc = plain
+		case b = <-formatted.responses:
+			wsc = formatted
+		case <-time.After(time.Second):
+			t.Fatal("notification not sent")
+		}
+
+		var req btcjson.Request
+		if err := json.Unmarshal(b, &req); err != nil {
+			t.Fatal(err)
+		}
+		params, err := json.Marshal(req.Params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(params) != want[wsc] {
+			t.Errorf("%s client: got params %s, want %s",
+				wsc.remoteAddr, params, want[wsc])
+		}
+	}
+}
+
+// TestNewTxNtfns ensures that new transactions are notified for each output
+// received by the wallet and each output sent to another wallet, but not for
+// change.
+func TestNewTxNtfns(t *testing.T) {
+	w, cleanup := goldenWallet(t)
+	defer cleanup()
+
+	recvAddr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeAddr, err := w.NewChangeAddress(0, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recvScript, _ := txscript.PayToAddrScript(recvAddr)
+	changeScript, _ := txscript.PayToAddrScript(changeAddr)
+
+	sendAddr, err := btcutil.NewAddressPubKeyHash(
+		bytes.Repeat([]byte{0x23}, 20), w.ChainParams(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendScript, _ := txscript.PayToAddrScript(sendAddr)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxOut(wire.NewTxOut(1e8, recvScript))
+	tx.AddTxOut(wire.NewTxOut(2e7, changeScript))
+	tx.AddTxOut(wire.NewTxOut(5e7, sendScript))
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	txHash := tx.TxHash()
+
+	summary := wallet.TransactionSummary{
+		Hash:        &txHash,
+		Transaction: buf.Bytes(),
+		MyOutputs: []wallet.TransactionSummaryOutput{
+			{Index: 0},
+			{Index: 1, Internal: true},
+		},
+	}
+
+	// Without inputs of the wallet, only the received output is notified.
+	ntfns := newTxNtfns(w, &summary, 0)
+	want := []*walletjson.NewTxNtfn{
+		walletjson.NewNewTxNtfn(
+			"default", recvAddr.EncodeAddress(), 1, txHash.String(), 0,
+		),
+	}
+	if !reflect.DeepEqual(ntfns, want) {
+		t.Fatalf("expected notifications %v, got %v",
+			spew.Sdump(want), spew.Sdump(ntfns))
+	}
+
+	// Sends of the wallet also notify the outputs paying other wallets.
+	summary.MyInputs = []wallet.TransactionSummaryInput{{}}
+	ntfns = newTxNtfns(w, &summary, 2)
+	want = []*walletjson.NewTxNtfn{
+		walletjson.NewNewTxNtfn(
+			"default", recvAddr.EncodeAddress(), 1, txHash.String(), 2,
+		),
+		walletjson.NewNewTxNtfn(
+			"default", sendAddr.EncodeAddress(), -0.5,
+			txHash.String(), 2,
+		),
+	}
+	if !reflect.DeepEqual(ntfns, want) {
+		t.Fatalf("expected notifications %v, got %v",
+			spew.Sdump(want), spew.Sdump(ntfns))
+	}
+}
+
+// TestBlockNtfns ensures that detached blocks are notified before attached
+// blocks, along with the height the wallet is synced to.
+func TestBlockNtfns(t *testing.T) {
+	detached := chainhash.Hash{1}
+	attached := chainhash.Hash{2}
+	n := &wallet.TransactionNotifications{
+		DetachedBlocks: []*chainhash.Hash{&detached},
+		AttachedBlocks: []wallet.Block{{
+			Hash:      &attached,
+			Height:    100,
+			Timestamp: 1600000000,
+		}},
+	}
+
+	ntfns := blockNtfns(n, 100, true)
+	want := []interface{}{
+		walletjson.NewBlockDisconnectedNtfn(detached.String(), 100, true),
+		walletjson.NewBlockConnectedNtfn(
+			attached.String(), 100, 1600000000, 100, true,
+		),
+	}
+	if !reflect.DeepEqual(ntfns, want) {
+		t.Fatalf("expected notifications %v, got %v",
+			spew.Sdump(want), spew.Sdump(ntfns))
+	}
+}
+
+// TestBalanceNtfns ensures that the balance breakdowns of every account are
+// notified with a single btcwallet:accountbalances notification, followed by the pair of
+// accountbalance notifications of each account only when requested.
+func TestBalanceNtfns(t *testing.T) {
+	accounts := []wallet.AccountBalanceBreakdown{
+		{
+			AccountName: "default",
+			BalanceBreakdown: wallet.BalanceBreakdown{
+				Confirmed:        1e8,
+				TrustedPending:   0.25e8,
+				UntrustedPending: 0.25e8,
+			},
+		},
+		{
+			AccountName: "savings",
+			BalanceBreakdown: wallet.BalanceBreakdown{
+				UntrustedPending: 2e8,
+				Immature:         0.5e8,
+			},
+		},
+	}
+
+	consolidated := walletjson.NewAccountBalancesNtfn(
+		map[string]walletjson.AccountBalance{
+			"default": {
+				Confirmed:        1,
+				Unconfirmed:      0.5,
+				Trusted:          1.25,
+				UntrustedPending: 0.25,
+			},
+			"savings": {
+				Unconfirmed:      2,
+				UntrustedPending: 2,
+				Immature:         0.5,
+			},
+		},
+		nil,
+	)
+
+	ntfns := balanceNtfns(accounts, nil, false)
+	want := []interface{}{consolidated}
+	if !reflect.DeepEqual(ntfns, want) {
+		t.Fatalf("expected notifications %v, got %v",
+			spew.Sdump(want), spew.Sdump(ntfns))
+	}
+
+	ntfns = balanceNtfns(accounts, nil, true)
+	want = []interface{}{
+		consolidated,
+		btcjson.NewAccountBalanceNtfn("default", 1, true),
+		btcjson.NewAccountBalanceNtfn("default", 0.5, false),
+		btcjson.NewAccountBalanceNtfn("savings", 0, true),
+		btcjson.NewAccountBalanceNtfn("savings", 2, false),
+	}
+	if !reflect.DeepEqual(ntfns, want) {
+		t.Fatalf("expected notifications %v, got %v",
+			spew.Sdump(want), spew.Sdump(ntfns))
+	}
+
+	// With an exchange rate, the total balance of each account is also
+	// valued in fiat.
+	rate := &wallet.FiatRate{
+		Currency: "USD",
+		Rate:     40000,
+		Time:     time.Unix(1617000000, 0),
+	}
+	ntfns = balanceNtfns(accounts, rate, false)
+	valued := consolidated.Balances["default"]
+	defaultFiat := 60000.0
+	valued.Fiat = &defaultFiat
+	savings := consolidated.Balances["savings"]
+	savingsFiat := 100000.0
+	savings.Fiat = &savingsFiat
+	want = []interface{}{
+		walletjson.NewAccountBalancesNtfn(
+			map[string]walletjson.AccountBalance{
+				"default": valued,
+				"savings": savings,
+			},
+			&walletjson.FiatRate{
+				Currency: "USD",
+				Rate:     40000,
+				RateTime: 1617000000,
+			},
+		),
+	}
+	if !reflect.DeepEqual(ntfns, want) {
+		t.Fatalf("expected notifications %v, got %v",
+			spew.Sdump(want), spew.Sdump(ntfns))
+	}
+}
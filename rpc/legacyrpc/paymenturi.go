@@ -0,0 +1,167 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	// defaultPaymentQRSize is the width and height in pixels of the PNG
+	// images of payment QR codes when a request does not specify a size.
+	defaultPaymentQRSize = 256
+
+	// minPaymentQRSize and maxPaymentQRSize bound the size of the PNG
+	// images of payment QR codes.
+	minPaymentQRSize = 64
+	maxPaymentQRSize = 1024
+)
+
+// getPaymentURI handles a getpaymenturi request by returning a BIP0021 URI
+// requesting payment to a new address of an account.  The label of the
+// request is recorded as the label of the address, so that payments to it can
+// be matched to the request.
+func getPaymentURI(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetPaymentURICmd)
+
+	addr, uri, err := newPaymentRequest(
+		w, cmd.Amount, cmd.Label, cmd.Message, *cmd.Account,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &walletjson.GetPaymentURIResult{
+		URI:     uri,
+		Address: addr.EncodeAddress(),
+	}, nil
+}
+
+// getPaymentQR handles a getpaymentqr request by returning the payload of a
+// QR code requesting payment to a new address of an account, which is the
+// BIP0021 URI of the request, and optionally the QR code as a PNG image.  The
+// label of the request is recorded as the label of the address, as with
+// getpaymenturi.
+func getPaymentQR(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.GetPaymentQRCmd)
+
+	size := defaultPaymentQRSize
+	if cmd.Size != nil {
+		size = *cmd.Size
+	}
+	if size < minPaymentQRSize || size > maxPaymentQRSize {
+		return nil, InvalidParameterError{
+			fmt.Errorf("size must be between %d and %d pixels",
+				minPaymentQRSize, maxPaymentQRSize),
+		}
+	}
+
+	addr, uri, err := newPaymentRequest(
+		w, cmd.Amount, cmd.Label, cmd.Message, *cmd.Account,
+	)
+	if err != nil {
+		return nil, err
+	}
+	result := &walletjson.GetPaymentQRResult{
+		Payload: uri,
+		Address: addr.EncodeAddress(),
+	}
+	if *cmd.PNG {
+		png, err := qrcode.Encode(uri, qrcode.Medium, size)
+		if err != nil {
+			return nil, err
+		}
+		result.PNG = base64.StdEncoding.EncodeToString(png)
+	}
+	return result, nil
+}
+
+// newPaymentRequest returns a new address of an account and the BIP0021 URI
+// requesting payment to it, recording the label of the request as the label
+// of the address.  The amount, label and message are optional.
+func newPaymentRequest(w *wallet.Wallet, btc *float64, label,
+	message *string, accountName string) (btcutil.Address, string, error) {
+
+	var amount btcutil.Amount
+	if btc != nil {
+		var err error
+		amount, err = btcutil.NewAmount(*btc)
+		if err != nil {
+			return nil, "", InvalidParameterError{err}
+		}
+		if amount <= 0 {
+			return nil, "", InvalidParameterError{
+				errors.New("amount must be positive"),
+			}
+		}
+	}
+	var l, m string
+	if label != nil {
+		l = *label
+	}
+	if message != nil {
+		m = *message
+	}
+	if len(l) > wallet.MaxAddressLabelLen {
+		return nil, "", InvalidParameterError{
+			wallet.ErrAddressLabelTooLong,
+		}
+	}
+
+	account, err := w.AccountNumber(waddrmgr.KeyScopeBIP0044, accountName)
+	if err != nil {
+		return nil, "", err
+	}
+	addr, err := w.NewAddress(account, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		return nil, "", err
+	}
+	if l != "" {
+		if err := w.SetAddressLabel(addr, l); err != nil {
+			return nil, "", err
+		}
+	}
+	return addr, paymentURI(addr, amount, l, m), nil
+}
+
+// paymentURI returns the BIP0021 URI requesting payment to an address, with
+// the amount, label and message parameters included when they are set.
+func paymentURI(addr btcutil.Address, amount btcutil.Amount, label,
+	message string) string {
+
+	var params []string
+	if amount != 0 {
+		params = append(params, "amount="+
+			strconv.FormatFloat(amount.ToBTC(), 'f', -1, 64))
+	}
+	if label != "" {
+		params = append(params, "label="+uriEscape(label))
+	}
+	if message != "" {
+		params = append(params, "message="+uriEscape(message))
+	}
+
+	uri := "bitcoin:" + addr.EncodeAddress()
+	if len(params) != 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri
+}
+
+// uriEscape escapes a parameter value of a URI.  Spaces are escaped as %20
+// rather than the + of form encoding, which BIP0021 does not decode.
+func uriEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+// TestPaymentURI ensures that the parameters of payment URIs are included
+// only when set, and that their values are escaped.
+func TestPaymentURI(t *testing.T) {
+	addr, err := btcutil.DecodeAddress(
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", &chaincfg.MainNetParams,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		amount  btcutil.Amount
+		label   string
+		message string
+		uri     string
+	}{
+		{
+			uri: "bitcoin:1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		},
+		{
+			amount: 2000000000,
+			uri:    "bitcoin:1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa?amount=20",
+		},
+		{
+			amount:  1,
+			label:   "Luke-Jr",
+			message: "Donation for project xyz",
+			uri: "bitcoin:1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa" +
+				"?amount=0.00000001&label=Luke-Jr" +
+				"&message=Donation%20for%20project%20xyz",
+		},
+		{
+			label: "a&b=c?d",
+			uri: "bitcoin:1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa" +
+				"?label=a%26b%3Dc%3Fd",
+		},
+	}
+	for _, test := range tests {
+		uri := paymentURI(addr, test.amount, test.label, test.message)
+		if uri != test.uri {
+			t.Errorf("got %s, want %s", uri, test.uri)
+		}
+	}
+}
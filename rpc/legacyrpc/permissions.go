@@ -0,0 +1,133 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import "github.com/btcsuite/btcd/btcjson"
+
+// authTier is the tier of the credentials a client authenticated with, which
+// determines the methods the client may call.
+type authTier uint8
+
+const (
+	// fullTier clients may call every method.
+	fullTier authTier = iota
+
+	// limitedTier clients may only call the read-only limitedMethods.
+	limitedTier
+
+	// publicTier clients may only call the publicMethods, and are rate
+	// limited.
+	publicTier
+)
+
+// String returns the name of the tier.
+func (t authTier) String() string {
+	switch t {
+	case fullTier:
+		return "full"
+	case limitedTier:
+		return "limited"
+	case publicTier:
+		return "public"
+	}
+	return "unknown"
+}
+
+// limitedMethods is the set of methods which may be called by clients
+// authenticated with the limited credentials.  These methods only read the
+// state of the wallet, and never reveal private keys, spend outputs, or unlock
+// the wallet.  Requests of other methods, including those passed through to
+// the chain server, are refused.
+var limitedMethods = map[string]struct{}{
+	"analyzepsbt":              {},
+	"decodepsbt":               {},
+	"estimatesendfee":          {},
+	"getaccount":               {},
+	"getaccountingreport":      {},
+	"getaccountmetadata":       {},
+	"getaccountxpub":           {},
+	"getaddressesbyaccount":    {},
+	"getaddressesbylabel":      {},
+	"getaddressinfo":           {},
+	"getbalance":               {},
+	"getbalances":              {},
+	"getbestblock":             {},
+	"getbestblockhash":         {},
+	"getblockcount":            {},
+	"getinfo":                  {},
+	"getlookahead":             {},
+	"getreceivedbyaccount":     {},
+	"getreceivedbyaddress":     {},
+	"getspendpolicy":           {},
+	"gettransaction":           {},
+	"getunconfirmedbalance":    {},
+	"getwalletinfo":            {},
+	"help":                     {},
+	"listaccounts":             {},
+	"listaddressgroupings":     {},
+	"listaddresstransactions":  {},
+	"listalltransactions":      {},
+	"listdescriptors":          {},
+	"listexpiredtransactions":  {},
+	"listlabels":               {},
+	"listlockunspent":          {},
+	"listrescans":              {},
+	"listreceivedbyaccount":    {},
+	"listreceivedbyaddress":    {},
+	"listsinceblock":           {},
+	"listtransactions":         {},
+	"listunspent":              {},
+	"listwallets":              {},
+	"notifytxconfirmations":    {},
+	"subscribenotifications":   {},
+	"unsubscribenotifications": {},
+	"validateaddress":          {},
+	"verifymessage":            {},
+	"verifymessagebip322":      {},
+	"walletislocked":           {},
+	"walletunlockeduntil":      {},
+}
+
+// checkRequest returns an error if a request of the method from the client at
+// remoteAddr may not be handled for a client authenticated with credentials of
+// the tier, or if the method is disabled for every client.
+func (s *Server) checkRequest(tier authTier, method,
+	remoteAddr string) *btcjson.RPCError {
+
+	if s.methodDisabled(method) {
+		return &ErrMethodDisabled
+	}
+	switch tier {
+	case limitedTier:
+		if _, ok := limitedMethods[method]; !ok {
+			return &ErrLimitedMethodNotAllowed
+		}
+	case publicTier:
+		return s.checkPublicRequest(remoteAddr, method)
+	}
+	return nil
+}
+
+// methodDisabled returns whether the method is disabled by the configuration
+// of the server.
+func (s *Server) methodDisabled(method string) bool {
+	if _, ok := s.disabledMethods[method]; ok {
+		return true
+	}
+	if s.allowedMethods != nil {
+		_, ok := s.allowedMethods[method]
+		return !ok
+	}
+	return false
+}
+
+// methodSet returns the set of the named methods.
+func methodSet(methods []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(methods))
+	for _, method := range methods {
+		set[method] = struct{}{}
+	}
+	return set
+}
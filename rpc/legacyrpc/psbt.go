@@ -0,0 +1,353 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// psbtRoles are the roles which may process a PSBT next, in the order they
+// process it.
+var psbtRoles = []string{"updater", "signer", "finalizer", "extractor"}
+
+// decodePsbt handles a decodepsbt request by decoding a base64 PSBT without
+// consulting the chain server, annotating the inputs which spend outputs of
+// the wallet and those the wallet can sign.
+func decodePsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.DecodePsbtCmd)
+
+	packet, analysis, err := analyzePacket(cmd.Psbt, w)
+	if err != nil {
+		return nil, err
+	}
+	chainParams := w.ChainParams()
+
+	inputs := make([]walletjson.DecodePsbtInput, len(packet.Inputs))
+	for i, in := range packet.Inputs {
+		input := &inputs[i]
+		if in.NonWitnessUtxo != nil {
+			tx := decodeTx(in.NonWitnessUtxo, chainParams)
+			input.NonWitnessUtxo = &tx
+		}
+		if in.WitnessUtxo != nil {
+			input.WitnessUtxo = &walletjson.DecodePsbtUtxo{
+				Amount: btcutil.Amount(in.WitnessUtxo.Value).ToBTC(),
+				ScriptPubKey: scriptPubKeyResult(
+					in.WitnessUtxo.PkScript, chainParams,
+				),
+			}
+		}
+		if len(in.PartialSigs) > 0 {
+			input.PartialSignatures = make(
+				map[string]string, len(in.PartialSigs),
+			)
+			for _, sig := range in.PartialSigs {
+				input.PartialSignatures[hex.EncodeToString(sig.PubKey)] =
+					hex.EncodeToString(sig.Signature)
+			}
+		}
+		if in.SighashType != 0 {
+			input.Sighash = sigHashName(in.SighashType)
+		}
+		input.RedeemScript = decodePsbtScript(in.RedeemScript)
+		input.WitnessScript = decodePsbtScript(in.WitnessScript)
+		input.Bip32Derivs = decodeBip32Derivs(in.Bip32Derivation)
+		if len(in.FinalScriptSig) > 0 {
+			asm, _ := txscript.DisasmString(in.FinalScriptSig)
+			input.FinalScriptSig = &btcjson.ScriptSig{
+				Asm: asm,
+				Hex: hex.EncodeToString(in.FinalScriptSig),
+			}
+		}
+		if len(in.FinalScriptWitness) > 0 {
+			witness, err := readWitness(in.FinalScriptWitness)
+			if err != nil {
+				return nil, DeserializationError{err}
+			}
+			input.FinalScriptWitness = witnessToHex(witness)
+		}
+		input.IsMine = analysis[i].IsMine
+		input.CanSign = analysis[i].CanSign
+	}
+
+	outputs := make([]walletjson.DecodePsbtOutput, len(packet.Outputs))
+	for i, out := range packet.Outputs {
+		outputs[i] = walletjson.DecodePsbtOutput{
+			RedeemScript:  decodePsbtScript(out.RedeemScript),
+			WitnessScript: decodePsbtScript(out.WitnessScript),
+			Bip32Derivs:   decodeBip32Derivs(out.Bip32Derivation),
+		}
+	}
+
+	return &walletjson.DecodePsbtResult{
+		Tx:      decodeTx(packet.UnsignedTx, chainParams),
+		Inputs:  inputs,
+		Outputs: outputs,
+		Fee:     psbtFee(packet, analysis),
+	}, nil
+}
+
+// analyzePsbt handles an analyzepsbt request by reporting, without consulting
+// the chain server, which role must process each input of a base64 PSBT next,
+// and which inputs spend outputs of the wallet and can be signed by it.
+func analyzePsbt(icmd interface{}, w *wallet.Wallet) (interface{}, error) {
+	cmd := icmd.(*walletjson.AnalyzePsbtCmd)
+
+	packet, analysis, err := analyzePacket(cmd.Psbt, w)
+	if err != nil {
+		return nil, err
+	}
+
+	// A packet without inputs must be updated with inputs funding it.
+	next := 0
+	if len(analysis) > 0 {
+		next = len(psbtRoles) - 1
+	}
+	inputs := make([]walletjson.AnalyzePsbtInput, len(analysis))
+	for i, a := range analysis {
+		// The role is the index of the next role in psbtRoles.
+		var role int
+		switch {
+		case a.Final:
+			role = 3
+		case a.Finalizable:
+			role = 2
+		case a.HasUtxo:
+			role = 1
+		}
+		if role < next {
+			next = role
+		}
+		inputs[i] = walletjson.AnalyzePsbtInput{
+			HasUtxo: a.HasUtxo,
+			IsFinal: a.Final,
+			Next:    psbtRoles[role],
+			IsMine:  a.IsMine,
+			CanSign: a.CanSign,
+		}
+	}
+
+	return &walletjson.AnalyzePsbtResult{
+		Inputs: inputs,
+		Fee:    psbtFee(packet, analysis),
+		Next:   psbtRoles[next],
+	}, nil
+}
+
+// analyzePacket decodes a base64 PSBT and analyzes its inputs with the
+// wallet.
+func analyzePacket(b64 string, w *wallet.Wallet) (*psbt.Packet,
+	[]wallet.PsbtInputAnalysis, error) {
+
+	packet, err := psbt.NewFromRawBytes(strings.NewReader(b64), true)
+	if err != nil {
+		return nil, nil, DeserializationError{err}
+	}
+	analysis, err := w.AnalyzePsbt(packet)
+	if err != nil {
+		return nil, nil, err
+	}
+	return packet, analysis, nil
+}
+
+// psbtFee returns the fee paid by a PSBT valued in bitcoin, or nil when the
+// packet does not include every output spent by its inputs.
+func psbtFee(packet *psbt.Packet, analysis []wallet.PsbtInputAnalysis) *float64 {
+	var fee btcutil.Amount
+	for _, a := range analysis {
+		if !a.HasUtxo {
+			return nil
+		}
+		fee += btcutil.Amount(a.Utxo.Value)
+	}
+	for _, txOut := range packet.UnsignedTx.TxOut {
+		fee -= btcutil.Amount(txOut.Value)
+	}
+	feeBTC := fee.ToBTC()
+	return &feeBTC
+}
+
+// decodeTx describes a transaction as the decoderawtransaction method of the
+// chain server does.
+func decodeTx(tx *wire.MsgTx, chainParams *chaincfg.Params) btcjson.TxRawDecodeResult {
+	vin := make([]btcjson.Vin, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		// The disassembled string will contain [error] inline if the
+		// script doesn't fully parse, so the error is ignored.
+		asm, _ := txscript.DisasmString(txIn.SignatureScript)
+		vin[i] = btcjson.Vin{
+			Txid:     txIn.PreviousOutPoint.Hash.String(),
+			Vout:     txIn.PreviousOutPoint.Index,
+			Sequence: txIn.Sequence,
+			ScriptSig: &btcjson.ScriptSig{
+				Asm: asm,
+				Hex: hex.EncodeToString(txIn.SignatureScript),
+			},
+			Witness: witnessToHex(txIn.Witness),
+		}
+	}
+	vout := make([]btcjson.Vout, len(tx.TxOut))
+	for i, txOut := range tx.TxOut {
+		vout[i] = btcjson.Vout{
+			Value:        btcutil.Amount(txOut.Value).ToBTC(),
+			N:            uint32(i),
+			ScriptPubKey: scriptPubKeyResult(txOut.PkScript, chainParams),
+		}
+	}
+	return btcjson.TxRawDecodeResult{
+		Txid:     tx.TxHash().String(),
+		Version:  tx.Version,
+		Locktime: tx.LockTime,
+		Vin:      vin,
+		Vout:     vout,
+	}
+}
+
+// scriptPubKeyResult describes an output script.
+func scriptPubKeyResult(pkScript []byte,
+	chainParams *chaincfg.Params) btcjson.ScriptPubKeyResult {
+
+	asm, _ := txscript.DisasmString(pkScript)
+
+	// An error means the script couldn't be parsed, and there is no
+	// additional information about it.
+	class, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(
+		pkScript, chainParams,
+	)
+	encodedAddrs := make([]string, len(addrs))
+	for i, addr := range addrs {
+		encodedAddrs[i] = addr.EncodeAddress()
+	}
+	return btcjson.ScriptPubKeyResult{
+		Asm:       asm,
+		Hex:       hex.EncodeToString(pkScript),
+		ReqSigs:   int32(reqSigs),
+		Type:      class.String(),
+		Addresses: encodedAddrs,
+	}
+}
+
+// decodePsbtScript describes a redeem or witness script of a PSBT, or returns
+// nil when the script is unset.
+func decodePsbtScript(script []byte) *walletjson.DecodePsbtScript {
+	if len(script) == 0 {
+		return nil
+	}
+	asm, _ := txscript.DisasmString(script)
+	return &walletjson.DecodePsbtScript{
+		Asm:  asm,
+		Hex:  hex.EncodeToString(script),
+		Type: txscript.GetScriptClass(script).String(),
+	}
+}
+
+// decodeBip32Derivs describes the BIP 32 derivations of the public keys of an
+// input or output of a PSBT.
+func decodeBip32Derivs(derivs []*psbt.Bip32Derivation) []walletjson.DecodePsbtBip32Deriv {
+	if len(derivs) == 0 {
+		return nil
+	}
+	results := make([]walletjson.DecodePsbtBip32Deriv, len(derivs))
+	for i, deriv := range derivs {
+		results[i] = walletjson.DecodePsbtBip32Deriv{
+			PubKey:            hex.EncodeToString(deriv.PubKey),
+			MasterFingerprint: fingerprintHex(deriv.MasterKeyFingerprint),
+			Path:              bip32PathString(deriv.Bip32Path),
+		}
+	}
+	return results
+}
+
+// bip32PathString formats the child indexes of a BIP 32 derivation from the
+// master key, marking hardened indexes with an apostrophe.
+func bip32PathString(indexes []uint32) string {
+	path := "m"
+	for _, index := range indexes {
+		if index >= hdkeychain.HardenedKeyStart {
+			path += fmt.Sprintf("/%d'", index-hdkeychain.HardenedKeyStart)
+		} else {
+			path += fmt.Sprintf("/%d", index)
+		}
+	}
+	return path
+}
+
+// fingerprintHex hex encodes a BIP0032 key fingerprint in the byte order it is
+// serialized in.
+func fingerprintHex(fingerprint uint32) string {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], fingerprint)
+	return hex.EncodeToString(b[:])
+}
+
+// sigHashName returns the name of a signature hash type as accepted by the
+// signrawtransaction method.
+func sigHashName(hashType txscript.SigHashType) string {
+	var name string
+	switch hashType &^ txscript.SigHashAnyOneCanPay {
+	case txscript.SigHashAll:
+		name = "ALL"
+	case txscript.SigHashNone:
+		name = "NONE"
+	case txscript.SigHashSingle:
+		name = "SINGLE"
+	default:
+		return fmt.Sprintf("0x%x", uint32(hashType))
+	}
+	if hashType&txscript.SigHashAnyOneCanPay != 0 {
+		name += "|ANYONECANPAY"
+	}
+	return name
+}
+
+// readWitness parses the serialized witness stack of a finalized PSBT input.
+func readWitness(serialized []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(serialized)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(serialized)) {
+		return nil, fmt.Errorf("witness stack of %d items exceeds its "+
+			"serialized size", count)
+	}
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(
+			r, 0, uint32(len(serialized)), "witness item",
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return witness, nil
+}
+
+// witnessToHex hex encodes the items of a witness stack, or returns nil for
+// an empty stack.
+func witnessToHex(witness wire.TxWitness) []string {
+	if len(witness) == 0 {
+		return nil
+	}
+	result := make([]string, len(witness))
+	for i, item := range witness {
+		result[i] = hex.EncodeToString(item)
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"sync"
+	"time"
+)
+
+// publicMethods is the set of methods which may be called by clients
+// authenticated with the public credentials.  These methods reveal nothing
+// beyond what a payer of the wallet already knows.
+var publicMethods = map[string]struct{}{
+	"getreceivedbyaddress": {},
+	"validateaddress":      {},
+}
+
+// rateLimiter limits the number of requests with each key, such as the method
+// or the client host of the request, which are allowed within a fixed window of
+// time.
+type rateLimiter struct {
+	mtx         sync.Mutex
+	limit       uint32
+	window      time.Duration
+	windowStart time.Time
+	counts      map[string]uint32
+
+	// now returns the current time and may be replaced by tests.
+	now func() time.Time
+}
+
+// newRateLimiter returns a rate limiter allowing limit requests with each key
+// per window.
+func newRateLimiter(limit uint32, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+		counts: make(map[string]uint32),
+		now:    time.Now,
+	}
+}
+
+// allow records a request with the key and returns whether it is within the
+// rate limit.
+func (l *rateLimiter) allow(key string) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := l.now()
+	if now.Sub(l.windowStart) >= l.window {
+		l.windowStart = now
+		l.counts = make(map[string]uint32)
+	}
+
+	if l.counts[key] >= l.limit {
+		return false
+	}
+	l.counts[key]++
+	return true
+}
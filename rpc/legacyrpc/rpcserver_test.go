@@ -5,10 +5,21 @@
 package legacyrpc
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/auditlog"
+	"github.com/btcsuite/btcwallet/waddrmgr"
 )
 
 func TestThrottle(t *testing.T) {
@@ -48,3 +59,354 @@ func TestThrottle(t *testing.T) {
 		t.Fatalf("status codes: want: %v, got: %v", want, got)
 	}
 }
+
+func TestPublicTier(t *testing.T) {
+	opts := Options{
+		Username:        "user",
+		Password:        "pass",
+		PublicUsername:  "public",
+		PublicPassword:  "publicpass",
+		PublicRateLimit: 2,
+	}
+	srv := NewServer(&opts, nil, nil)
+
+	tests := []struct {
+		user, pass string
+		public     bool
+		fail       bool
+	}{
+		{user: "user", pass: "pass"},
+		{user: "public", pass: "publicpass", public: true},
+		{user: "public", pass: "pass", fail: true},
+		{user: "user", pass: "publicpass", fail: true},
+	}
+	for i, test := range tests {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.SetBasicAuth(test.user, test.pass)
+		tier, err := srv.checkAuthHeader(r)
+		if (err != nil) != test.fail {
+			t.Fatalf("test %d: unexpected auth error: %v", i, err)
+		}
+		if public := tier == publicTier; public != test.public {
+			t.Fatalf("test %d: public: want %v, got %v", i,
+				test.public, public)
+		}
+	}
+
+	const client, other = "10.0.0.1:5000", "10.0.0.2:5000"
+	if err := srv.checkPublicRequest(client, "dumpprivkey"); err == nil {
+		t.Fatal("non-public method allowed for public client")
+	}
+
+	now := time.Unix(1000, 0)
+	srv.publicLimiter.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		err := srv.checkPublicRequest(client, "validateaddress")
+		if err != nil {
+			t.Fatalf("request %d rate limited: %v", i, err)
+		}
+	}
+	if err := srv.checkPublicRequest(client, "validateaddress"); err == nil {
+		t.Fatal("request exceeding rate limit allowed")
+	}
+	err := srv.checkPublicRequest("10.0.0.1:5001", "validateaddress")
+	if err == nil {
+		t.Fatal("rate limit not shared between ports of a client")
+	}
+	if err := srv.checkPublicRequest(client, "getreceivedbyaddress"); err != nil {
+		t.Fatalf("rate limit shared between methods: %v", err)
+	}
+	if err := srv.checkPublicRequest(other, "validateaddress"); err != nil {
+		t.Fatalf("rate limit shared between clients: %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := srv.checkPublicRequest(client, "validateaddress"); err != nil {
+		t.Fatalf("rate limit not reset after window: %v", err)
+	}
+}
+
+// TestPublicReceivedByAddress ensures that public tier clients are only
+// answered getreceivedbyaddress requests of addresses handed out for payments.
+func TestPublicReceivedByAddress(t *testing.T) {
+	w, cleanup := goldenWallet(t)
+	defer cleanup()
+
+	srv := NewServer(&Options{
+		PublicUsername:  "public",
+		PublicPassword:  "public",
+		PublicRateLimit: 100,
+	}, nil, nil)
+	srv.RegisterWallet(w)
+
+	reserved, err := w.ReserveAddress(0, waddrmgr.KeyScopeBIP0044, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	labeled, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetAddressLabel(labeled, "invoice"); err != nil {
+		t.Fatal(err)
+	}
+	other, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		addr btcutil.Address
+		tier authTier
+		resp string
+	}{
+		{reserved, publicTier, `{"jsonrpc":"1.0","result":0,"error":null,"id":1}`},
+		{labeled, publicTier, `{"jsonrpc":"1.0","result":0,"error":null,"id":1}`},
+		{other, publicTier, `{"jsonrpc":"1.0","result":null,"error":{"code":-4,"message":"address not found in wallet"},"id":1}`},
+		{other, fullTier, `{"jsonrpc":"1.0","result":0,"error":null,"id":1}`},
+	}
+	for _, test := range tests {
+		body := `{"jsonrpc":"1.0","id":1,"method":"getreceivedbyaddress",` +
+			`"params":["` + test.addr.EncodeAddress() + `"]}`
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.postClientRPC(rec, r, test.tier)
+		if resp := rec.Body.String(); resp != test.resp {
+			t.Errorf("%v (tier %v): expected response %s, got %s",
+				test.addr, test.tier, test.resp, resp)
+		}
+	}
+}
+
+func TestLimitedTier(t *testing.T) {
+	opts := Options{
+		Username:        "user",
+		Password:        "pass",
+		LimitedUsername: "limited",
+		LimitedPassword: "limitedpass",
+	}
+	srv := NewServer(&opts, nil, nil)
+
+	tests := []struct {
+		user, pass string
+		tier       authTier
+		fail       bool
+	}{
+		{user: "user", pass: "pass", tier: fullTier},
+		{user: "limited", pass: "limitedpass", tier: limitedTier},
+		{user: "limited", pass: "pass", fail: true},
+	}
+	for i, test := range tests {
+		r := httptest.NewRequest("POST", "/", nil)
+		r.SetBasicAuth(test.user, test.pass)
+		tier, err := srv.checkAuthHeader(r)
+		if (err != nil) != test.fail {
+			t.Fatalf("test %d: unexpected auth error: %v", i, err)
+		}
+		if !test.fail && tier != test.tier {
+			t.Fatalf("test %d: tier: want %v, got %v", i,
+				test.tier, tier)
+		}
+	}
+
+	for _, method := range []string{"getbalance", "listtransactions"} {
+		if err := srv.checkRequest(limitedTier, method, ""); err != nil {
+			t.Fatalf("read-only method %s refused: %v", method, err)
+		}
+	}
+	refused := []string{
+		"dumpprivkey", "sendfrom", "sendmany", "sendtoaddress",
+		"walletpassphrase", "stop", "getblock",
+	}
+	for _, method := range refused {
+		if err := srv.checkRequest(limitedTier, method, ""); err == nil {
+			t.Fatalf("method %s allowed for limited client", method)
+		}
+		if err := srv.checkRequest(fullTier, method, ""); err != nil {
+			t.Fatalf("method %s refused for full client: %v",
+				method, err)
+		}
+	}
+}
+
+func TestPublicTierDisabled(t *testing.T) {
+	opts := Options{
+		Username: "user",
+		Password: "pass",
+	}
+	srv := NewServer(&opts, nil, nil)
+
+	// Without public credentials, the zero hash of the public tier must
+	// never be matched.
+	r := httptest.NewRequest("POST", "/", nil)
+	r.SetBasicAuth("", "")
+	if _, err := srv.checkAuthHeader(r); err == nil {
+		t.Fatal("empty credentials accepted")
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	opts := Options{
+		Username:        "user",
+		Password:        "pass",
+		ClientRateLimit: 2,
+	}
+	srv := NewServer(&opts, nil, nil)
+
+	now := time.Unix(1000, 0)
+	srv.clientLimiter.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		if !srv.allowClientRequest("127.0.0.1:1000") {
+			t.Fatalf("request %d rate limited", i)
+		}
+	}
+
+	// Connections of the same host share a single limit.
+	if srv.allowClientRequest("127.0.0.1:2000") {
+		t.Fatal("request exceeding rate limit allowed")
+	}
+	if !srv.allowClientRequest("127.0.0.2:1000") {
+		t.Fatal("rate limit shared between client hosts")
+	}
+
+	now = now.Add(time.Minute)
+	if !srv.allowClientRequest("127.0.0.1:1000") {
+		t.Fatal("rate limit not reset after window")
+	}
+
+	// Clients are never limited without a configured rate limit.
+	srv = NewServer(&Options{}, nil, nil)
+	for i := 0; i < 100; i++ {
+		if !srv.allowClientRequest("127.0.0.1:1000") {
+			t.Fatalf("request %d rate limited without a limit", i)
+		}
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "legacyrpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+	auditSecret := []byte("audit log secret")
+	auditLog, err := auditlog.Open(path, auditSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{
+		Username: "user",
+		Password: "pass",
+		AuditLog: auditLog,
+	}
+	srv := NewServer(&opts, nil, nil)
+
+	// Requests of audited methods are recorded, even when refused, while
+	// requests of other methods are not.
+	const secret = "cVt4o7BGAig1UXywgGSmARhxMdzP5qvQsxKkSsc1XEkw3tDTQFpy"
+	requests := []struct {
+		body string
+		tier authTier
+	}{
+		{`{"jsonrpc":"1.0","id":1,"method":"importprivkey","params":["` + secret + `"]}`, limitedTier},
+		{`{"jsonrpc":"1.0","id":2,"method":"getbalance","params":[]}`, limitedTier},
+	}
+	for _, req := range requests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(req.body))
+		r.RemoteAddr = "192.0.2.1:8332"
+		srv.postClientRPC(httptest.NewRecorder(), r, req.tier)
+	}
+	srv.Stop()
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(contents), secret) {
+		t.Fatal("audit log records request parameters")
+	}
+	_, err = auditlog.Verify(bytes.NewReader(contents), auditSecret)
+	if err != nil {
+		t.Fatalf("unable to verify audit log: %v", err)
+	}
+	var records []auditlog.Record
+	for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+		var r auditlog.Record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 1 {
+		t.Fatalf("audit log has %d records, want 1", len(records))
+	}
+	r := records[0]
+	if r.Method != "importprivkey" || r.Client != "192.0.2.1:8332" ||
+		r.Tier != "limited" || r.Outcome != auditlog.Failure ||
+		r.Code != int(ErrLimitedMethodNotAllowed.Code) {
+
+		t.Errorf("unexpected audit record %+v", r)
+	}
+}
+
+func TestDisabledMethods(t *testing.T) {
+	opts := Options{
+		Username:        "user",
+		Password:        "pass",
+		DisabledMethods: []string{"dumpprivkey", "getblock"},
+	}
+	srv := NewServer(&opts, nil, nil)
+
+	// Disabled methods are refused for full clients, including methods
+	// passed through to the chain server.
+	for _, method := range []string{"dumpprivkey", "getblock"} {
+		if err := srv.checkRequest(fullTier, method, ""); err != &ErrMethodDisabled {
+			t.Errorf("disabled method %s: got error %v, want %v",
+				method, err, &ErrMethodDisabled)
+		}
+	}
+	if err := srv.checkRequest(fullTier, "dumpwallet", ""); err != nil {
+		t.Errorf("method dumpwallet refused: %v", err)
+	}
+
+	// Disabled methods are refused before they are dispatched.
+	body := `{"jsonrpc":"1.0","id":1,"method":"dumpprivkey","params":["1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"]}`
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	srv.postClientRPC(rec, r, fullTier)
+	var resp struct {
+		Error *struct{ Code int } `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || resp.Error.Code != int(ErrMethodDisabled.Code) {
+		t.Errorf("disabled method response %s", rec.Body.Bytes())
+	}
+
+	// Only allowed methods are handled when any are allowed, and disabled
+	// methods are refused even when allowed.
+	opts = Options{
+		Username:        "user",
+		Password:        "pass",
+		DisabledMethods: []string{"getbalance"},
+		AllowedMethods:  []string{"getbalance", "listunspent"},
+	}
+	srv = NewServer(&opts, nil, nil)
+	tests := []struct {
+		method  string
+		refused bool
+	}{
+		{"listunspent", false},
+		{"getbalance", true},
+		{"sendtoaddress", true},
+	}
+	for _, test := range tests {
+		err := srv.checkRequest(fullTier, test.method, "")
+		if (err != nil) != test.refused {
+			t.Errorf("method %s: refused %v, want %v", test.method,
+				err != nil, test.refused)
+		}
+	}
+}
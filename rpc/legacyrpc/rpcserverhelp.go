@@ -4,51 +4,110 @@ package legacyrpc
 
 func helpDescsEnUS() map[string]string {
 	return map[string]string{
-		"addmultisigaddress":      "addmultisigaddress nrequired [\"key\",...] (\"account\")\n\nGenerates and imports a multisig address and redeeming script to the 'imported' account.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n3. account   (string, optional)          DEPRECATED -- Unused (all imported addresses belong to the imported account)\n\nResult:\n\"value\" (string) The imported pay-to-script-hash address\n",
-		"createmultisig":          "createmultisig nrequired [\"key\",...]\n\nGenerate a multisig address and redeem script.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n\nResult:\n{\n \"address\": \"value\",      (string) The generated pay-to-script-hash address\n \"redeemScript\": \"value\", (string) The script required to redeem outputs paid to the multisig address\n}                         \n",
-		"dumpprivkey":             "dumpprivkey \"address\"\n\nReturns the private key in WIF encoding that controls some wallet address.\n\nArguments:\n1. address (string, required) The address to return a private key for\n\nResult:\n\"value\" (string) The WIF-encoded private key\n",
-		"getaccount":              "getaccount \"address\"\n\nDEPRECATED -- Lookup the account name that some wallet address belongs to.\n\nArguments:\n1. address (string, required) The address to query the account for\n\nResult:\n\"value\" (string) The name of the account that 'address' belongs to\n",
-		"getaccountaddress":       "getaccountaddress \"account\"\n\nDEPRECATED -- Returns the most recent external payment address for an account that has not been seen publicly.\nA new address is generated for the account if the most recently generated address has been seen on the blockchain or in mempool.\n\nArguments:\n1. account (string, required) The account of the returned address\n\nResult:\n\"value\" (string) The unused address for 'account'\n",
-		"getaddressesbyaccount":   "getaddressesbyaccount \"account\"\n\nDEPRECATED -- Returns all addresses strings controlled by a single account.\n\nArguments:\n1. account (string, required) Account name to fetch addresses for\n\nResult:\n[\"value\",...] (array of string) All addresses controlled by 'account'\n",
-		"getbalance":              "getbalance (\"account\" minconf=1)\n\nCalculates and returns the balance of one or all accounts.\n\nArguments:\n1. account (string, optional)             DEPRECATED -- The account name to query the balance for, or \"*\" to consider all accounts (default=\"*\")\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an unspent output's value is included in the balance\n\nResult (account != \"*\"):\nn.nnn (numeric) The balance of 'account' valued in bitcoin\n\nResult (account = \"*\"):\nn.nnn (numeric) The balance of all accounts valued in bitcoin\n",
-		"getbestblockhash":        "getbestblockhash\n\nReturns the hash of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\n\"value\" (string) The hash of the most recent synced-to block\n",
-		"getblockcount":           "getblockcount\n\nReturns the blockchain height of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\nn.nnn (numeric) The blockchain height of the most recent synced-to block\n",
-		"getinfo":                 "getinfo\n\nReturns a JSON object containing various state info.\n\nArguments:\nNone\n\nResult:\n{\n \"version\": n,          (numeric) The version of the server\n \"protocolversion\": n,  (numeric) The latest supported protocol version\n \"walletversion\": n,    (numeric) The version of the address manager database\n \"balance\": n.nnn,      (numeric) The balance of all accounts calculated with one block confirmation\n \"blocks\": n,           (numeric) The number of blocks processed\n \"timeoffset\": n,       (numeric) The time offset\n \"connections\": n,      (numeric) The number of connected peers\n \"proxy\": \"value\",      (string)  The proxy used by the server\n \"difficulty\": n.nnn,   (numeric) The current target difficulty\n \"testnet\": true|false, (boolean) Whether or not server is using testnet\n \"keypoololdest\": n,    (numeric) Unset\n \"keypoolsize\": n,      (numeric) Unset\n \"unlocked_until\": n,   (numeric) Unset\n \"paytxfee\": n.nnn,     (numeric) The increment used each time more fee is required for an authored transaction\n \"relayfee\": n.nnn,     (numeric) The minimum relay fee for non-free transactions in BTC/KB\n \"errors\": \"value\",     (string)  Any current errors\n}                       \n",
-		"getnewaddress":           "getnewaddress (\"account\")\n\nGenerates and returns a new payment address.\n\nArguments:\n1. account (string, optional) DEPRECATED -- Account name the new address will belong to (default=\"default\")\n\nResult:\n\"value\" (string) The payment address\n",
-		"getrawchangeaddress":     "getrawchangeaddress (\"account\")\n\nGenerates and returns a new internal payment address for use as a change address in raw transactions.\n\nArguments:\n1. account (string, optional) Account name the new internal address will belong to (default=\"default\")\n\nResult:\n\"value\" (string) The internal payment address\n",
-		"getreceivedbyaccount":    "getreceivedbyaccount \"account\" (minconf=1)\n\nDEPRECATED -- Returns the total amount received by addresses of some account, including spent outputs.\n\nArguments:\n1. account (string, required)             Account name to query total received amount for\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an output's value is included in the total\n\nResult:\nn.nnn (numeric) The total received amount valued in bitcoin\n",
-		"getreceivedbyaddress":    "getreceivedbyaddress \"address\" (minconf=1)\n\nReturns the total amount received by a single address, including spent outputs.\n\nArguments:\n1. address (string, required)             Payment address which received outputs to include in total\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an output's value is included in the total\n\nResult:\nn.nnn (numeric) The total received amount valued in bitcoin\n",
-		"gettransaction":          "gettransaction \"txid\" (includewatchonly=false)\n\nReturns a JSON object with details regarding a transaction relevant to this wallet.\n\nArguments:\n1. txid             (string, required)                 Hash of the transaction to query\n2. includewatchonly (boolean, optional, default=false) Also consider transactions involving watched addresses\n\nResult:\n{\n \"amount\": n.nnn,                  (numeric)         The total amount this transaction credits to the wallet, valued in bitcoin\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value, or 0 if 'txid' is not a sent transaction\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"txid\": \"value\",                  (string)          The transaction hash\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"details\": [{                     (array of object) Additional details for each recorded wallet credit and debit\n  \"account\": \"value\",              (string)          DEPRECATED -- Unset\n  \"address\": \"value\",              (string)          The address an output was paid to, or the empty string if the output is nonstandard or this detail is regarding a transaction input\n  \"amount\": n.nnn,                 (numeric)         The amount of a received output\n  \"category\": \"value\",             (string)          The kind of detail: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs\n  \"involveswatchonly\": true|false, (boolean)         Unset\n  \"fee\": n.nnn,                    (numeric)         The included fee for a sent transaction\n  \"vout\": n,                       (numeric)         The transaction output index\n },...],                                             \n \"hex\": \"value\",                   (string)          The transaction encoded as a hexadecimal string\n}                                  \n",
-		"help":                    "help (\"command\")\n\nReturns a list of all commands or help for a specified command.\n\nArguments:\n1. command (string, optional) The command to retrieve help for\n\nResult (no command provided):\n\"value\" (string) List of commands\n\nResult (command specified):\n\"value\" (string) Help for specified command\n",
-		"importprivkey":           "importprivkey \"privkey\" (\"label\" rescan=true)\n\nImports a WIF-encoded private key to the 'imported' account.\n\nArguments:\n1. privkey (string, required)                The WIF-encoded private key\n2. label   (string, optional)                Unused (must be unset or 'imported')\n3. rescan  (boolean, optional, default=true) Rescan the blockchain (since the genesis block) for outputs controlled by the imported key\n\nResult:\nNothing\n",
-		"keypoolrefill":           "keypoolrefill (newsize=100)\n\nDEPRECATED -- This request does nothing since no keypool is maintained.\n\nArguments:\n1. newsize (numeric, optional, default=100) Unused\n\nResult:\nNothing\n",
-		"listaccounts":            "listaccounts (minconf=1)\n\nDEPRECATED -- Returns a JSON object of all accounts and their balances.\n\nArguments:\n1. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an unspent output's value is included in the balance\n\nResult:\n{\n \"The account name\": The account balance valued in bitcoin, (object) JSON object with account names as keys and bitcoin amounts as values\n ...\n}\n",
-		"listlockunspent":         "listlockunspent\n\nReturns a JSON array of outpoints marked as locked (with lockunspent) for this wallet session.\n\nArguments:\nNone\n\nResult:\n[{\n \"txid\": \"value\", (string)  The transaction hash of the referenced output\n \"vout\": n,       (numeric) The output index of the referenced output\n},...]\n",
-		"listreceivedbyaccount":   "listreceivedbyaccount (minconf=1 includeempty=false includewatchonly=false)\n\nDEPRECATED -- Returns a JSON array of objects listing all accounts and the total amount received by each account.\n\nArguments:\n1. minconf          (numeric, optional, default=1)     Minimum number of block confirmations required before a transaction is considered\n2. includeempty     (boolean, optional, default=false) Unused\n3. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"account\": \"value\", (string)  The name of the account\n \"amount\": n.nnn,    (numeric) Total amount received by payment addresses of the account valued in bitcoin\n \"confirmations\": n, (numeric) Number of block confirmations of the most recent transaction relevant to the account\n},...]\n",
-		"listreceivedbyaddress":   "listreceivedbyaddress (minconf=1 includeempty=false includewatchonly=false)\n\nReturns a JSON array of objects listing wallet payment addresses and their total received amounts.\n\nArguments:\n1. minconf          (numeric, optional, default=1)     Minimum number of block confirmations required before a transaction is considered\n2. includeempty     (boolean, optional, default=false) Unused\n3. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"account\": \"value\",              (string)          DEPRECATED -- Unset\n \"address\": \"value\",              (string)          The payment address\n \"amount\": n.nnn,                 (numeric)         Total amount received by the payment address valued in bitcoin\n \"confirmations\": n,              (numeric)         Number of block confirmations of the most recent transaction relevant to the address\n \"txids\": [\"value\",...],          (array of string) Transaction hashes of all transactions involving this address\n \"involvesWatchonly\": true|false, (boolean)         Unset\n},...]\n",
-		"listsinceblock":          "listsinceblock (\"blockhash\" targetconfirmations=1 includewatchonly=false)\n\nReturns a JSON array of objects listing details of all wallet transactions after some block.\n\nArguments:\n1. blockhash           (string, optional)                 Hash of the parent block of the first block to consider transactions from, or unset to list all transactions\n2. targetconfirmations (numeric, optional, default=1)     Minimum number of block confirmations of the last block in the result object.  Must be 1 or greater.  Note: The transactions array in the result object is not affected by this parameter\n3. includewatchonly    (boolean, optional, default=false) Unused\n\nResult:\n{\n \"transactions\": [{                 (array of object) JSON array of objects containing verbose details of the each transaction\n  \"abandoned\": true|false,          (boolean)         Unset\n  \"account\": \"value\",               (string)          DEPRECATED -- Unset\n  \"address\": \"value\",               (string)          Payment address for a transaction output\n  \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n  \"bip125-replaceable\": \"value\",    (string)          Unset\n  \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n  \"blockheight\": n,                 (numeric)         The block height containing the transaction.\n  \"blockindex\": n,                  (numeric)         Unset\n  \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n  \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n  \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n  \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n  \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n  \"involveswatchonly\": true|false,  (boolean)         Unset\n  \"label\": \"value\",                 (string)          A comment for the address/transaction, if any\n  \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n  \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n  \"trusted\": true|false,            (boolean)         Unset\n  \"txid\": \"value\",                  (string)          The hash of the transaction\n  \"vout\": n,                        (numeric)         The transaction output index\n  \"walletconflicts\": [\"value\",...], (array of string) Unset\n  \"comment\": \"value\",               (string)          Unset\n  \"otheraccount\": \"value\",          (string)          Unset\n },...],                                              \n \"lastblock\": \"value\",              (string)          Hash of the latest-synced block to be used in later calls to listsinceblock\n}                                   \n",
-		"listtransactions":        "listtransactions (\"account\" count=10 from=0 includewatchonly=false)\n\nReturns a JSON array of objects containing verbose details for wallet transactions.\n\nArguments:\n1. account          (string, optional)                 DEPRECATED -- Unused (must be unset or \"*\")\n2. count            (numeric, optional, default=10)    Maximum number of transactions to create results from\n3. from             (numeric, optional, default=0)     Number of transactions to skip before results are created\n4. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockheight\": n,                 (numeric)         The block height containing the transaction.\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"label\": \"value\",                 (string)          A comment for the address/transaction, if any\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
-		"listunspent":             "listunspent (minconf=1 maxconf=9999999 [\"address\",...])\n\nReturns a JSON array of objects representing unlocked unspent outputs controlled by wallet keys.\n\nArguments:\n1. minconf   (numeric, optional, default=1)       Minimum number of block confirmations required before a transaction output is considered\n2. maxconf   (numeric, optional, default=9999999) Maximum number of block confirmations required before a transaction output is excluded\n3. addresses (array of string, optional)          If set, limits the returned details to unspent outputs received by any of these payment addresses\n\nResult:\n{\n \"txid\": \"value\",         (string)  The transaction hash of the referenced output\n \"vout\": n,               (numeric) The output index of the referenced output\n \"address\": \"value\",      (string)  The payment address that received the output\n \"account\": \"value\",      (string)  The account associated with the receiving payment address\n \"scriptPubKey\": \"value\", (string)  The output script encoded as a hexadecimal string\n \"redeemScript\": \"value\", (string)  Unset\n \"amount\": n.nnn,         (numeric) The amount of the output valued in bitcoin\n \"confirmations\": n,      (numeric) The number of block confirmations of the transaction\n \"spendable\": true|false, (boolean) Whether the output is entirely controlled by wallet keys/scripts (false for partially controlled multisig outputs or outputs to watch-only addresses)\n}                         \n",
-		"lockunspent":             "lockunspent unlock [{\"txid\":\"value\",\"vout\":n},...]\n\nLocks or unlocks an unspent output.\nLocked outputs are not chosen for transaction inputs of authored transactions and are not included in 'listunspent' results.\nLocked outputs are volatile and are not saved across wallet restarts.\nIf unlock is true and no transaction outputs are specified, all locked outputs are marked unlocked.\n\nArguments:\n1. unlock       (boolean, required)         True to unlock outputs, false to lock\n2. transactions (array of object, required) Transaction outputs to lock or unlock\n[{\n \"txid\": \"value\", (string)  The transaction hash of the referenced output\n \"vout\": n,       (numeric) The output index of the referenced output\n},...]\n\nResult:\ntrue|false (boolean) The boolean 'true'\n",
-		"sendfrom":                "sendfrom \"fromaccount\" \"toaddress\" amount (minconf=1 \"comment\" \"commentto\")\n\nDEPRECATED -- Authors, signs, and sends a transaction that outputs some amount to a payment address.\nA change output is automatically included to send extra output value back to the original account.\n\nArguments:\n1. fromaccount (string, required)             Account to pick unspent outputs from\n2. toaddress   (string, required)             Address to pay\n3. amount      (numeric, required)            Amount to send to the payment address valued in bitcoin\n4. minconf     (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n5. comment     (string, optional)             Unused\n6. commentto   (string, optional)             Unused\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
-		"sendmany":                "sendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\")\n\nAuthors, signs, and sends a transaction that outputs to many payment addresses.\nA change output is automatically included to send extra output value back to the original account.\n\nArguments:\n1. fromaccount (string, required) DEPRECATED -- Account to pick unspent outputs from\n2. amounts     (object, required) Pairs of payment addresses and the output amount to pay each\n{\n \"Address to pay\": Amount to send to the payment address valued in bitcoin, (object) JSON object using payment addresses as keys and output amounts valued in bitcoin to send to each address\n ...\n}\n3. minconf (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n4. comment (string, optional)             Unused\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
-		"sendtoaddress":           "sendtoaddress \"address\" amount (\"comment\" \"commentto\")\n\nAuthors, signs, and sends a transaction that outputs some amount to a payment address.\nUnlike sendfrom, outputs are always chosen from the default account.\nA change output is automatically included to send extra output value back to the original account.\n\nArguments:\n1. address   (string, required)  Address to pay\n2. amount    (numeric, required) Amount to send to the payment address valued in bitcoin\n3. comment   (string, optional)  Unused\n4. commentto (string, optional)  Unused\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
-		"settxfee":                "settxfee amount\n\nModify the increment used each time more fee is required for an authored transaction.\n\nArguments:\n1. amount (numeric, required) The new fee increment valued in bitcoin\n\nResult:\ntrue|false (boolean) The boolean 'true'\n",
-		"signmessage":             "signmessage \"address\" \"message\"\n\nSigns a message using the private key of a payment address.\n\nArguments:\n1. address (string, required) Payment address of private key used to sign the message with\n2. message (string, required) Message to sign\n\nResult:\n\"value\" (string) The signed message encoded as a base64 string\n",
-		"signrawtransaction":      "signrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\n\nSigns transaction inputs using private keys from this wallet and request.\nThe valid flags options are ALL, NONE, SINGLE, ALL|ANYONECANPAY, NONE|ANYONECANPAY, and SINGLE|ANYONECANPAY.\n\nArguments:\n1. rawtx    (string, required)                Unsigned or partially unsigned transaction to sign encoded as a hexadecimal string\n2. inputs   (array of object, optional)       Additional data regarding inputs that this wallet may not be tracking\n3. privkeys (array of string, optional)       Additional WIF-encoded private keys to use when creating signatures\n4. flags    (string, optional, default=\"ALL\") Sighash flags\n\nResult:\n{\n \"hex\": \"value\",         (string)          The resulting transaction encoded as a hexadecimal string\n \"complete\": true|false, (boolean)         Whether all input signatures have been created\n \"errors\": [{            (array of object) Script verification errors (if exists)\n  \"txid\": \"value\",       (string)          The transaction hash of the referenced previous output\n  \"vout\": n,             (numeric)         The output index of the referenced previous output\n  \"scriptSig\": \"value\",  (string)          The hex-encoded signature script\n  \"sequence\": n,         (numeric)         Script sequence number\n  \"error\": \"value\",      (string)          Verification or signing error related to the input\n },...],                                   \n}                        \n",
-		"validateaddress":         "validateaddress \"address\"\n\nVerify that an address is valid.\nExtra details are returned if the address is controlled by this wallet.\nThe following fields are valid only when the address is controlled by this wallet (ismine=true): isscript, pubkey, iscompressed, account, addresses, hex, script, and sigsrequired.\nThe following fields are only valid when address has an associated public key: pubkey, iscompressed.\nThe following fields are only valid when address is a pay-to-script-hash address: addresses, hex, and script.\nIf the address is a multisig address controlled by this wallet, the multisig fields will be left unset if the wallet is locked since the redeem script cannot be decrypted.\n\nArguments:\n1. address (string, required) Address to validate\n\nResult:\n{\n \"isvalid\": true|false,      (boolean)         Whether or not the address is valid\n \"address\": \"value\",         (string)          The payment address (only when isvalid is true)\n \"ismine\": true|false,       (boolean)         Whether this address is controlled by the wallet (only when isvalid is true)\n \"iswatchonly\": true|false,  (boolean)         Unset\n \"isscript\": true|false,     (boolean)         Whether the payment address is a pay-to-script-hash address (only when isvalid is true)\n \"pubkey\": \"value\",          (string)          The associated public key of the payment address, if any (only when isvalid is true)\n \"iscompressed\": true|false, (boolean)         Whether the address was created by hashing a compressed public key, if any (only when isvalid is true)\n \"account\": \"value\",         (string)          The account this payment address belongs to (only when isvalid is true)\n \"addresses\": [\"value\",...], (array of string) All associated payment addresses of the script if address is a multisig address (only when isvalid is true)\n \"hex\": \"value\",             (string)          The redeem script \n \"script\": \"value\",          (string)          The class of redeem script for a multisig address\n \"sigsrequired\": n,          (numeric)         The number of required signatures to redeem outputs to the multisig address\n}                            \n",
-		"verifymessage":           "verifymessage \"address\" \"signature\" \"message\"\n\nVerify a message was signed with the associated private key of some address.\n\nArguments:\n1. address   (string, required) Address used to sign message\n2. signature (string, required) The signature to verify\n3. message   (string, required) The message to verify\n\nResult:\ntrue|false (boolean) Whether the message was signed with the private key of 'address'\n",
-		"walletlock":              "walletlock\n\nLock the wallet.\n\nArguments:\nNone\n\nResult:\nNothing\n",
-		"walletpassphrase":        "walletpassphrase \"passphrase\" timeout\n\nUnlock the wallet.\n\nArguments:\n1. passphrase (string, required)  The wallet passphrase\n2. timeout    (numeric, required) The number of seconds to wait before the wallet automatically locks\n\nResult:\nNothing\n",
-		"walletpassphrasechange":  "walletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\n\nChange the wallet passphrase.\n\nArguments:\n1. oldpassphrase (string, required) The old wallet passphrase\n2. newpassphrase (string, required) The new wallet passphrase\n\nResult:\nNothing\n",
-		"createnewaccount":        "createnewaccount \"account\"\n\nCreates a new account.\nThe wallet must be unlocked for this request to succeed.\n\nArguments:\n1. account (string, required) Name of the new account\n\nResult:\nNothing\n",
-		"exportwatchingwallet":    "exportwatchingwallet (\"account\" download=false)\n\nCreates and returns a duplicate of the wallet database without any private keys to be used as a watching-only wallet.\n\nArguments:\n1. account  (string, optional)                 Unused (must be unset or \"*\")\n2. download (boolean, optional, default=false) Unused\n\nResult:\n\"value\" (string) The watching-only database encoded as a base64 string\n",
-		"getbestblock":            "getbestblock\n\nReturns the hash and height of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\n{\n \"hash\": \"value\", (string)  The hash of the block\n \"height\": n,     (numeric) The blockchain height of the block\n}                 \n",
-		"getunconfirmedbalance":   "getunconfirmedbalance (\"account\")\n\nCalculates the unspent output value of all unmined transaction outputs for an account.\n\nArguments:\n1. account (string, optional) The account to query the unconfirmed balance for (default=\"default\")\n\nResult:\nn.nnn (numeric) Total amount of all unmined unspent outputs of the account valued in bitcoin.\n",
-		"listaddresstransactions": "listaddresstransactions [\"address\",...] (\"account\")\n\nReturns a JSON array of objects containing verbose details for wallet transactions pertaining some addresses.\n\nArguments:\n1. addresses (array of string, required) Addresses to filter transaction results by\n2. account   (string, optional)          Unused (must be unset or \"*\")\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockheight\": n,                 (numeric)         The block height containing the transaction.\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"label\": \"value\",                 (string)          A comment for the address/transaction, if any\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
-		"listalltransactions":     "listalltransactions (\"account\")\n\nReturns a JSON array of objects in the same format as 'listtransactions' without limiting the number of returned objects.\n\nArguments:\n1. account (string, optional) Unused (must be unset or \"*\")\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockheight\": n,                 (numeric)         The block height containing the transaction.\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"label\": \"value\",                 (string)          A comment for the address/transaction, if any\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          Unset\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
-		"renameaccount":           "renameaccount \"oldaccount\" \"newaccount\"\n\nRenames an account.\n\nArguments:\n1. oldaccount (string, required) The old account name to rename\n2. newaccount (string, required) The new name for the account\n\nResult:\nNothing\n",
-		"walletislocked":          "walletislocked\n\nReturns whether or not the wallet is locked.\n\nArguments:\nNone\n\nResult:\ntrue|false (boolean) Whether the wallet is locked\n",
+		"addmultisigaddress":           "addmultisigaddress nrequired [\"key\",...] (\"account\")\n\nGenerates and imports a multisig address and redeeming script to the 'imported' account.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n3. account   (string, optional)          DEPRECATED -- Unused (all imported addresses belong to the imported account)\n\nResult:\n\"value\" (string) The imported pay-to-script-hash address\n",
+		"analyzepsbt":                  "analyzepsbt \"psbt\"\n\nAnalyzes a PSBT, reporting which role must process each input next and which inputs the wallet can sign.\nThe PSBT is analyzed by the wallet alone, using only the outputs it includes, so that PSBTs can be inspected by wallets of offline signing setups.\n\nArguments:\n1. psbt (string, required) A base64 string of a PSBT\n\nResult:\n{\n \"inputs\": [{             (array of object) The analysis of each input\n  \"has_utxo\": true|false, (boolean)         Whether the PSBT includes the output spent by the input\n  \"is_final\": true|false, (boolean)         Whether the input scripts of the input are final\n  \"next\": \"value\",        (string)          The role of the next step processing the input (updater, signer, finalizer or extractor)\n  \"ismine\": true|false,   (boolean)         Whether the spent output pays to an address of the wallet\n  \"cansign\": true|false,  (boolean)         Whether the wallet holds the key signing for the spent output\n },...],                                    \n \"fee\": n.nnn,            (numeric)         The fee paid by the transaction valued in bitcoin, omitted if the PSBT lacks any spent output\n \"next\": \"value\",         (string)          The role of the next step processing the PSBT (updater, signer, finalizer or extractor)\n}                         \n",
+		"createmultisig":               "createmultisig nrequired [\"key\",...]\n\nGenerate a multisig address and redeem script.\n\nArguments:\n1. nrequired (numeric, required)         The number of signatures required to redeem outputs paid to this address\n2. keys      (array of string, required) Pubkeys and/or pay-to-pubkey-hash addresses to partially control the multisig address\n\nResult:\n{\n \"address\": \"value\",      (string) The generated pay-to-script-hash address\n \"redeemScript\": \"value\", (string) The script required to redeem outputs paid to the multisig address\n}                         \n",
+		"decodepsbt":                   "decodepsbt \"psbt\"\n\nReturns a JSON object describing a PSBT and the transaction it builds, annotating the inputs the wallet can sign.\nThe PSBT is decoded by the wallet alone, so that PSBTs can be inspected by wallets of offline signing setups.\n\nArguments:\n1. psbt (string, required) A base64 string of a PSBT\n\nResult:\n{\n \"tx\": {                          (object)          The unsigned transaction of the PSBT\n  \"txid\": \"value\",                (string)          The hash of the transaction\n  \"version\": n,                   (numeric)         The transaction version\n  \"locktime\": n,                  (numeric)         The transaction lock time\n  \"vin\": [{                       (array of object) The transaction inputs as JSON objects\n   \"coinbase\": \"value\",           (string)          The hex-encoded bytes of the signature script (coinbase txns only)\n   \"txid\": \"value\",               (string)          The hash of the origin transaction (non-coinbase txns only)\n   \"vout\": n,                     (numeric)         The index of the output being redeemed from the origin transaction (non-coinbase txns only)\n   \"scriptSig\": {                 (object)          The signature script used to redeem the origin transaction as a JSON object (non-coinbase txns only)\n    \"asm\": \"value\",               (string)          Disassembly of the script\n    \"hex\": \"value\",               (string)          Hex-encoded bytes of the script\n   },                                               \n   \"sequence\": n,                 (numeric)         The script sequence number\n   \"txinwitness\": [\"value\",...],  (array of string) The witness used to redeem the input encoded as a string array of its items\n  },...],                                           \n  \"vout\": [{                      (array of object) The transaction outputs as JSON objects\n   \"value\": n.nnn,                (numeric)         The amount in BTC\n   \"n\": n,                        (numeric)         The index of this transaction output\n   \"scriptPubKey\": {              (object)          The public key script used to pay coins as a JSON object\n    \"asm\": \"value\",               (string)          Disassembly of the script\n    \"hex\": \"value\",               (string)          Hex-encoded bytes of the script\n    \"reqSigs\": n,                 (numeric)         The number of required signatures\n    \"type\": \"value\",              (string)          The type of the script (e.g. 'pubkeyhash')\n    \"addresses\": [\"value\",...],   (array of string) The bitcoin addresses associated with this script\n   },                                               \n  },...],                                           \n },                                                 \n \"inputs\": [{                     (array of object) The input fields of the PSBT\n  \"non_witness_utxo\": {           (object)          The transaction of the output spent by a non-witness input\n   \"txid\": \"value\",               (string)          The hash of the transaction\n   \"version\": n,                  (numeric)         The transaction version\n   \"locktime\": n,                 (numeric)         The transaction lock time\n   \"vin\": [{                      (array of object) The transaction inputs as JSON objects\n    \"coinbase\": \"value\",          (string)          The hex-encoded bytes of the signature script (coinbase txns only)\n    \"txid\": \"value\",              (string)          The hash of the origin transaction (non-coinbase txns only)\n    \"vout\": n,                    (numeric)         The index of the output being redeemed from the origin transaction (non-coinbase txns only)\n    \"scriptSig\": {                (object)          The signature script used to redeem the origin transaction as a JSON object (non-coinbase txns only)\n     \"asm\": \"value\",              (string)          Disassembly of the script\n     \"hex\": \"value\",              (string)          Hex-encoded bytes of the script\n    },                                              \n    \"sequence\": n,                (numeric)         The script sequence number\n    \"txinwitness\": [\"value\",...], (array of string) The witness used to redeem the input encoded as a string array of its items\n   },...],                                          \n   \"vout\": [{                     (array of object) The transaction outputs as JSON objects\n    \"value\": n.nnn,               (numeric)         The amount in BTC\n    \"n\": n,                       (numeric)         The index of this transaction output\n    \"scriptPubKey\": {             (object)          The public key script used to pay coins as a JSON object\n     \"asm\": \"value\",              (string)          Disassembly of the script\n     \"hex\": \"value\",              (string)          Hex-encoded bytes of the script\n     \"reqSigs\": n,                (numeric)         The number of required signatures\n     \"type\": \"value\",             (string)          The type of the script (e.g. 'pubkeyhash')\n     \"addresses\": [\"value\",...],  (array of string) The bitcoin addresses associated with this script\n    },                                              \n   },...],                                          \n  },                                                \n  \"witness_utxo\": {               (object)          The output spent by a witness input\n   \"amount\": n.nnn,               (numeric)         The value of the output valued in bitcoin\n   \"scriptPubKey\": {              (object)          The output script\n    \"asm\": \"value\",               (string)          Disassembly of the script\n    \"hex\": \"value\",               (string)          Hex-encoded bytes of the script\n    \"reqSigs\": n,                 (numeric)         The number of required signatures\n    \"type\": \"value\",              (string)          The type of the script (e.g. 'pubkeyhash')\n    \"addresses\": [\"value\",...],   (array of string) The bitcoin addresses associated with this script\n   },                                               \n  },                                                \n  \"partial_signatures\": {         (object)          The partial signatures of the input\n   \"The hex-encoded public key\": The hex-encoded signature, (object) JSON object using public keys as keys and their signatures as values\n   ...\n  }\n  \"sighash\": \"value\",                   (string)          The signature hash type to sign the input with\n  \"redeem_script\": {                    (object)          The redeem script of the input\n   \"asm\": \"value\",                      (string)          Disassembly of the script\n   \"hex\": \"value\",                      (string)          The hex-encoded script\n   \"type\": \"value\",                     (string)          The type of the script (e.g. 'multisig' or 'witness_v0_keyhash')\n  },                                                      \n  \"witness_script\": {                   (object)          The witness script of the input\n   \"asm\": \"value\",                      (string)          Disassembly of the script\n   \"hex\": \"value\",                      (string)          The hex-encoded script\n   \"type\": \"value\",                     (string)          The type of the script (e.g. 'multisig' or 'witness_v0_keyhash')\n  },                                                      \n  \"bip32_derivs\": [{                    (array of object) The BIP 32 derivations of the public keys of the input\n   \"pubkey\": \"value\",                   (string)          The hex-encoded public key\n   \"master_fingerprint\": \"value\",       (string)          The hex-encoded fingerprint of the master key\n   \"path\": \"value\",                     (string)          The derivation path of the public key\n  },...],                                                 \n  \"final_scriptSig\": {                  (object)          The final signature script of the input\n   \"asm\": \"value\",                      (string)          Disassembly of the script\n   \"hex\": \"value\",                      (string)          Hex-encoded bytes of the script\n  },                                                      \n  \"final_scriptwitness\": [\"value\",...], (array of string) The hex-encoded items of the final witness of the input\n  \"ismine\": true|false,                 (boolean)         Whether the spent output pays to an address of the wallet\n  \"cansign\": true|false,                (boolean)         Whether the wallet holds the key signing for the spent output\n },...],                                                  \n \"outputs\": [{                          (array of object) The output fields of the PSBT\n  \"redeem_script\": {                    (object)          The redeem script of the output\n   \"asm\": \"value\",                      (string)          Disassembly of the script\n   \"hex\": \"value\",                      (string)          The hex-encoded script\n   \"type\": \"value\",                     (string)          The type of the script (e.g. 'multisig' or 'witness_v0_keyhash')\n  },                                                      \n  \"witness_script\": {                   (object)          The witness script of the output\n   \"asm\": \"value\",                      (string)          Disassembly of the script\n   \"hex\": \"value\",                      (string)          The hex-encoded script\n   \"type\": \"value\",                     (string)          The type of the script (e.g. 'multisig' or 'witness_v0_keyhash')\n  },                                                      \n  \"bip32_derivs\": [{                    (array of object) The BIP 32 derivations of the public keys of the output\n   \"pubkey\": \"value\",                   (string)          The hex-encoded public key\n   \"master_fingerprint\": \"value\",       (string)          The hex-encoded fingerprint of the master key\n   \"path\": \"value\",                     (string)          The derivation path of the public key\n  },...],                                                 \n },...],                                                  \n \"fee\": n.nnn,                          (numeric)         The fee paid by the transaction valued in bitcoin, omitted if the PSBT lacks any spent output\n}                                       \n",
+		"dumpprivkey":                  "dumpprivkey \"address\"\n\nReturns the private key in WIF encoding that controls some wallet address.\n\nArguments:\n1. address (string, required) The address to return a private key for\n\nResult:\n\"value\" (string) The WIF-encoded private key\n",
+		"dumpwallet":                   "dumpwallet \"filename\"\n\nWrites the private key of every wallet address to a new file, in the format of the reference implementation's dumpwallet.\nKeys are written to the file as they are read rather than returned, so wallets with any number of keys can be dumped.\nThe file is created by the wallet process with permissions allowing only its owner to read it, must not already exist, and the wallet must be unlocked at the full level.\n\nArguments:\n1. filename (string, required) The absolute path of the file to create\n\nResult:\n{\n \"filename\": \"value\", (string) The path of the written file\n}                     \n",
+		"getaccount":                   "getaccount \"address\"\n\nDEPRECATED -- Lookup the account name that some wallet address belongs to.\n\nArguments:\n1. address (string, required) The address to query the account for\n\nResult:\n\"value\" (string) The name of the account that 'address' belongs to\n",
+		"getaccountaddress":            "getaccountaddress \"account\"\n\nDEPRECATED -- Returns the most recent external payment address for an account that has not been seen publicly.\nA new address is generated for the account if the most recently generated address has been seen on the blockchain or in mempool.\n\nArguments:\n1. account (string, required) The account of the returned address\n\nResult:\n\"value\" (string) The unused address for 'account'\n",
+		"getaddressesbyaccount":        "getaddressesbyaccount \"account\"\n\nDEPRECATED -- Returns the payment address strings of a single account.\nChange addresses of the account's internal branch are excluded.\n\nArguments:\n1. account (string, required) Account name to fetch addresses for\n\nResult:\n[\"value\",...] (array of string) All payment addresses of 'account'\n",
+		"getaddressinfo":               "getaddressinfo \"address\"\n\nReturns information about an address and, for addresses of the wallet, the key behind it.\nThe HD key path and master key fingerprint are reported for keys derived from the wallet seed, so that they may be reproduced from it.\n\nArguments:\n1. address (string, required) The address to describe\n\nResult:\n{\n \"address\": \"value\",             (string)  The address\n \"scriptPubKey\": \"value\",        (string)  The hex encoded output script of the address\n \"ismine\": true|false,           (boolean) Whether the address belongs to the wallet\n \"iswatchonly\": true|false,      (boolean) Whether the wallet holds no private key for the address\n \"isscript\": true|false,         (boolean) Whether the address pays to a script hash\n \"iswitness\": true|false,        (boolean) Whether the address is a segregated witness address\n \"ischange\": true|false,         (boolean) Whether the address is of an internal (change) branch\n \"pubkey\": \"value\",              (string)  The hex encoded public key of the address, if it is of a single key\n \"iscompressed\": true|false,     (boolean) Whether the public key is compressed, if it is of a single key\n \"account\": \"value\",             (string)  The account of the address\n \"label\": \"value\",               (string)  The label of the address\n \"timestamp\": n,                 (numeric) The unix time of the birthday block of the address, if recorded\n \"hdkeypath\": \"value\",           (string)  The BIP0032 derivation path of the key from the master key, if derived from the wallet seed\n \"hdmasterfingerprint\": \"value\", (string)  The hex encoded fingerprint of the master key the key was derived from, if known\n}                                \n",
+		"getbalance":                   "getbalance (\"account\" minconf=1)\n\nCalculates and returns the balance of one or all accounts.\n\nArguments:\n1. account (string, optional)             DEPRECATED -- The account name to query the balance for, or \"*\" to consider all accounts (default=\"*\")\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an unspent output's value is included in the balance\n\nResult (account != \"*\"):\nn.nnn (numeric) The balance of 'account' valued in bitcoin\n\nResult (account = \"*\"):\nn.nnn (numeric) The balance of all accounts valued in bitcoin\n",
+		"getbalances":                  "getbalances\n\nReturns the balances of the wallet and of each account, valued in bitcoin.\nBalances are split into the trusted balance of confirmed outputs and of unconfirmed outputs of transactions which only spend wallet outputs, the untrusted balance of other unconfirmed outputs, and the balance of immature coinbase outputs.  Locked outputs are excluded.\nWhen the wallet has a price feed, the total balances are also valued in fiat with the last fetched exchange rate, whose time should be checked as the rate is not refreshed while the price source fails.\n\nArguments:\nNone\n\nResult:\n{\n \"mine\": {                    (object)  The balances of the wallet\n  \"trusted\": n.nnn,           (numeric) The balance of confirmed outputs and of unconfirmed outputs of transactions which only spend wallet outputs\n  \"untrusted_pending\": n.nnn, (numeric) The balance of unconfirmed outputs of transactions paid by other wallets\n  \"immature\": n.nnn,          (numeric) The balance of coinbase outputs which have not yet reached maturity\n  \"used\": n.nnn,              (numeric) Unset\n },                                     \n \"accounts\": {                (object)  The balances of each account\n  \"The account name\": The balances of the account, (object) JSON object with account names as keys and their balances as values\n  ...\n }\n \"fiat\": {             (object)  The fiat values of the balances, only set when the wallet has a price feed\n  \"currency\": \"value\", (string)  The fiat currency of the exchange rate\n  \"rate\": n.nnn,       (numeric) The price of one bitcoin in the fiat currency\n  \"ratetime\": n,       (numeric) The time the exchange rate was fetched as a unix timestamp\n  \"mine\": n.nnn,       (numeric) The value of the total balance of the wallet, including unconfirmed and immature outputs\n  \"accounts\": {        (object)  The values of the total balances of each account\n   \"The account name\": The value of the total balance of the account, (object) JSON object with account names as keys and the values of their total balances as values\n   ...\n  }\n },  \n}   \n",
+		"getbestblockhash":             "getbestblockhash\n\nReturns the hash of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\n\"value\" (string) The hash of the most recent synced-to block\n",
+		"getblockcount":                "getblockcount\n\nReturns the blockchain height of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\nn.nnn (numeric) The blockchain height of the most recent synced-to block\n",
+		"getinfo":                      "getinfo\n\nReturns a JSON object containing various state info.\n\nArguments:\nNone\n\nResult:\n{\n \"version\": n,          (numeric) The version of the server\n \"protocolversion\": n,  (numeric) The latest supported protocol version\n \"walletversion\": n,    (numeric) The version of the address manager database\n \"balance\": n.nnn,      (numeric) The balance of all accounts calculated with one block confirmation\n \"blocks\": n,           (numeric) The number of blocks processed\n \"timeoffset\": n,       (numeric) The time offset\n \"connections\": n,      (numeric) The number of connected peers\n \"proxy\": \"value\",      (string)  The proxy used by the server\n \"difficulty\": n.nnn,   (numeric) The current target difficulty\n \"testnet\": true|false, (boolean) Whether or not server is using testnet\n \"keypoololdest\": n,    (numeric) Unset\n \"keypoolsize\": n,      (numeric) Unset\n \"unlocked_until\": n,   (numeric) Unset\n \"paytxfee\": n.nnn,     (numeric) The increment used each time more fee is required for an authored transaction\n \"relayfee\": n.nnn,     (numeric) The minimum relay fee for non-free transactions in BTC/KB\n \"errors\": \"value\",     (string)  Any current errors\n}                       \n",
+		"getnewaddress":                "getnewaddress (\"account\")\n\nGenerates and returns a new payment address.\n\nArguments:\n1. account (string, optional) DEPRECATED -- Account name the new address will belong to (default=\"default\")\n\nResult:\n\"value\" (string) The payment address\n",
+		"getrawchangeaddress":          "getrawchangeaddress (\"account\")\n\nGenerates and returns a new internal payment address for use as a change address in raw transactions.\n\nArguments:\n1. account (string, optional) Account name the new internal address will belong to (default=\"default\")\n\nResult:\n\"value\" (string) The internal payment address\n",
+		"getreceivedbyaccount":         "getreceivedbyaccount \"account\" (minconf=1)\n\nDEPRECATED -- Returns the total amount received by addresses of some account, including spent outputs and excluding change.\n\nArguments:\n1. account (string, required)             Account name to query total received amount for\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an output's value is included in the total\n\nResult:\nn.nnn (numeric) The total received amount valued in bitcoin\n",
+		"getreceivedbyaddress":         "getreceivedbyaddress \"address\" (minconf=1)\n\nReturns the total amount received by a single address, including spent outputs.\n\nArguments:\n1. address (string, required)             Payment address which received outputs to include in total\n2. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an output's value is included in the total\n\nResult:\nn.nnn (numeric) The total received amount valued in bitcoin\n",
+		"gettransaction":               "gettransaction \"txid\" (includewatchonly=false)\n\nReturns a JSON object with details regarding a transaction relevant to this wallet.\n\nArguments:\n1. txid             (string, required)                 Hash of the transaction to query\n2. includewatchonly (boolean, optional, default=false) Also consider transactions involving watched addresses\n\nResult:\n{\n \"amount\": n.nnn,                  (numeric)         The total amount this transaction credits to the wallet, valued in bitcoin\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value, or 0 if 'txid' is not a sent transaction\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"txid\": \"value\",                  (string)          The transaction hash\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"comment\": \"value\",               (string)          The comment of a send describing its purpose, if any\n \"to\": \"value\",                    (string)          The comment of a send naming the person or organization paid, if any\n \"details\": [{                     (array of object) Additional details for each recorded wallet credit and debit\n  \"account\": \"value\",              (string)          DEPRECATED -- Unset\n  \"address\": \"value\",              (string)          The address an output was paid to, or the empty string if the output is nonstandard or this detail is regarding a transaction input\n  \"amount\": n.nnn,                 (numeric)         The amount of a received output\n  \"category\": \"value\",             (string)          The kind of detail: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs\n  \"involveswatchonly\": true|false, (boolean)         Unset\n  \"fee\": n.nnn,                    (numeric)         The included fee for a sent transaction\n  \"vout\": n,                       (numeric)         The transaction output index\n },...],                                             \n \"hex\": \"value\",                   (string)          The transaction encoded as a hexadecimal string\n}                                  \n",
+		"getwalletinfo":                "getwalletinfo\n\nReturns the wallet's balances and lock state, and whether the chain followed by the chain server appears to be stalled or on a minority fork.\n\nArguments:\nNone\n\nResult:\n{\n \"balance\": n.nnn,             (numeric) The balance of all accounts with at least one confirmation, excluding immature coinbase outputs, valued in bitcoin\n \"unconfirmed_balance\": n.nnn, (numeric) The balance of all unconfirmed outputs, valued in bitcoin\n \"immature_balance\": n.nnn,    (numeric) The balance of all coinbase outputs which have not yet reached maturity and can not be spent, valued in bitcoin\n \"unlocked\": true|false,       (boolean) Whether the wallet is unlocked\n \"chain_stalled\": true|false,  (boolean) Whether no new block has been seen for longer than the stall timeout\n \"minority_fork\": true|false,  (boolean) Whether most peers of the chain server report a best block well ahead of the wallet's\n \"last_block_seen\": n,         (numeric) The Unix time the last block was connected\n \"sends_risky\": true|false,    (boolean) Whether transactions sent now risk being invalidated or never confirming, as the chain appears stalled or on a minority fork. Changes are notified to websocket clients by 'btcwallet:chainhealth'\n}                              \n",
+		"help":                         "help (\"command\")\n\nReturns a list of all commands or help for a specified command.\n\nArguments:\n1. command (string, optional) The command to retrieve help for\n\nResult (no command provided):\n\"value\" (string) List of commands\n\nResult (command specified):\n\"value\" (string) Help for specified command\n",
+		"importdescriptors":            "importdescriptors [{\"desc\":\"value\",\"range\":range,\"timestamp\":timestamp,\"label\":\"value\"},...]\n\nImports the scripts of output descriptors.\nRanged descriptors are imported at each index of their range, which is [0,999] unless specified. Keys of single key descriptors are imported to the 'imported' account of the key scope of the descriptor's address type, and are spendable when private. The scripts of multisig descriptors are imported to be watched. The chain is rescanned from the block of the timestamp of each request unless it is \"now\".\n\nArguments:\n1. requests (array of object, required) The descriptors to import\n[{\n \"desc\": \"value\",  (string)           The output descriptor, which must include its checksum\n \"range\": [n,...], (array of numeric) The end of the range of a ranged descriptor, or the beginning and end of the range as an array\n \"timestamp\": n,   (numeric)          The Unix time of the earliest transaction of the scripts, 0 to rescan from the genesis block, or \"now\" to not rescan\n \"label\": \"value\", (string)           The label of the address of a descriptor which is not ranged\n},...]\n\nResult:\n[{\n \"success\": true|false,     (boolean)         Whether the descriptor was imported\n \"warnings\": [\"value\",...], (array of string) Warnings about the import\n \"error\": {                 (object)          The error importing the descriptor, if it was not imported\n  \"code\": n,                (numeric)         The JSON-RPC error code\n  \"message\": \"value\",       (string)          The error message\n },                                           \n},...]\n",
+		"importprivkey":                "importprivkey \"privkey\" (\"label\" rescan=true)\n\nImports a WIF-encoded private key to the 'imported' account.\nbtcwallet extension: A BIP0038 encrypted private key, such as that of a paper wallet, is imported when its passphrase is passed as a fourth parameter.\nbtcwallet extension: The birthday of the key may be passed as a fifth parameter, following a passphrase or null, as either a block height or, when not less than 500000000, a Unix timestamp. The rescan starts at the birthday block instead of the genesis block, and the birthday block is recorded for the imported address.\n\nArguments:\n1. privkey (string, required)                The WIF-encoded private key\n2. label   (string, optional)                Unused (must be unset or 'imported')\n3. rescan  (boolean, optional, default=true) Rescan the blockchain (since the genesis block) for outputs controlled by the imported key\n\nResult:\nNothing\n",
+		"importpubkey":                 "importpubkey \"pubkey\" (rescan=true)\n\nImports a public key to be watched as its pay-to-pubkey-hash, nested witness and witness addresses in the 'imported' account.\nOnly compressed public keys can be watched.\n\nArguments:\n1. pubkey (string, required)                The hex encoded public key\n2. rescan (boolean, optional, default=true) Rescan the blockchain (since the genesis block) for outputs paying to the key, which are otherwise only watched from the block the wallet is synced to\n\nResult:\nNothing\n",
+		"keypoolrefill":                "keypoolrefill (newsize=100)\n\nDEPRECATED -- This request does nothing since no keypool is maintained.\n\nArguments:\n1. newsize (numeric, optional, default=100) Unused\n\nResult:\nNothing\n",
+		"listaccounts":                 "listaccounts (minconf=1)\n\nDEPRECATED -- Returns a JSON object of all accounts and their balances.\nbtcwallet extension: a boolean verbose flag may be passed after minconf to instead return a JSON array of objects which include the metadata of each account.\n\nArguments:\n1. minconf (numeric, optional, default=1) Minimum number of block confirmations required before an unspent output's value is included in the balance\n\nResult (verbose=false):\n{\n \"The account name\": The account balance valued in bitcoin, (object) JSON object with account names as keys and bitcoin amounts as values\n ...\n}\n\nResult (verbose=true):\n[{\n \"account\": \"value\",        (string)          The account name\n \"balance\": n.nnn,          (numeric)         The account balance valued in bitcoin\n \"description\": \"value\",    (string)          The description of the account\n \"created\": n,              (numeric)         The Unix time the account was created, omitted if unknown\n \"tags\": [\"value\",...],     (array of string) Tags describing the purpose of the account\n \"avoid_reuse\": true|false, (boolean)         Whether the account avoids combining outputs to dirty and clean addresses\n},...]\n",
+		"listdescriptors":              "listdescriptors\n\nReturns output descriptors of the scripts held by the wallet.\nEach account with an extended public key is described by ranged descriptors of its external and internal branches, and each imported key and multisig script by a descriptor of its own. Imported scripts are only described when the wallet is unlocked or watching-only.\n\nArguments:\nNone\n\nResult:\n{\n \"descriptors\": [{        (array of object)  The descriptors of the wallet\n  \"desc\": \"value\",        (string)           The output descriptor, including its checksum\n  \"timestamp\": n,         (numeric)          The Unix time of the earliest transaction the scripts may have, or 0 if unknown\n  \"active\": true|false,   (boolean)          Whether the descriptor is of an account branch from which new addresses are derived\n  \"internal\": true|false, (boolean)          Whether the descriptor is of an internal (change) branch, for active descriptors\n  \"range\": [n,...],       (array of numeric) The range of indexes the scripts of an active descriptor are watched at\n  \"next\": n,              (numeric)          The index the next address of an active descriptor is derived at\n },...],                                     \n}                         \n",
+		"listlockunspent":              "listlockunspent\n\nReturns a JSON array of outpoints marked as locked (with lockunspent) for this wallet session.\n\nArguments:\nNone\n\nResult:\n[{\n \"txid\": \"value\", (string)  The transaction hash of the referenced output\n \"vout\": n,       (numeric) The output index of the referenced output\n},...]\n",
+		"listreceivedbyaccount":        "listreceivedbyaccount (minconf=1 includeempty=false includewatchonly=false)\n\nDEPRECATED -- Returns a JSON array of objects listing all accounts and the total amount received by each account, excluding change.\nAn options object may be passed as an additional final parameter.  The 'starttime' and 'endtime' options, in seconds since the Unix epoch, restrict the results to transactions received by the wallet at or after starttime and before endtime.  The 'cursor' option continues the listing after the result identified by its account name, which is the last result of the previous page, and the 'skip' and 'count' options skip and limit the results which follow it.\n\nArguments:\n1. minconf          (numeric, optional, default=1)     Minimum number of block confirmations required before a transaction is considered\n2. includeempty     (boolean, optional, default=false) Unused\n3. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"account\": \"value\", (string)  The name of the account\n \"amount\": n.nnn,    (numeric) Total amount received by payment addresses of the account valued in bitcoin\n \"confirmations\": n, (numeric) Number of block confirmations of the most recent transaction relevant to the account\n},...]\n",
+		"listreceivedbyaddress":        "listreceivedbyaddress (minconf=1 includeempty=false includewatchonly=false)\n\nReturns a JSON array of objects listing wallet payment addresses and their total received amounts.\nChange addresses and the change they received are excluded.\nAn options object may be passed as an additional final parameter.  The 'starttime' and 'endtime' options, in seconds since the Unix epoch, restrict the results to transactions received by the wallet at or after starttime and before endtime.  The 'cursor' option continues the listing after the result identified by its address, which is the last result of the previous page, and the 'skip' and 'count' options skip and limit the results which follow it.\n\nArguments:\n1. minconf          (numeric, optional, default=1)     Minimum number of block confirmations required before a transaction is considered\n2. includeempty     (boolean, optional, default=false) Unused\n3. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"account\": \"value\",              (string)          DEPRECATED -- Unset\n \"address\": \"value\",              (string)          The payment address\n \"amount\": n.nnn,                 (numeric)         Total amount received by the payment address valued in bitcoin\n \"confirmations\": n,              (numeric)         Number of block confirmations of the most recent transaction relevant to the address\n \"txids\": [\"value\",...],          (array of string) Transaction hashes of all transactions involving this address\n \"involvesWatchonly\": true|false, (boolean)         Unset\n},...]\n",
+		"listsinceblock":               "listsinceblock (\"blockhash\" targetconfirmations=1 includewatchonly=false)\n\nReturns a JSON array of objects listing details of all wallet transactions after some block.\n\nArguments:\n1. blockhash           (string, optional)                 Hash of the parent block of the first block to consider transactions from, or unset to list all transactions\n2. targetconfirmations (numeric, optional, default=1)     Minimum number of block confirmations of the last block in the result object.  Must be 1 or greater.  Note: The transactions array in the result object is not affected by this parameter\n3. includewatchonly    (boolean, optional, default=false) Unused\n\nResult:\n{\n \"transactions\": [{                 (array of object) JSON array of objects containing verbose details of the each transaction\n  \"abandoned\": true|false,          (boolean)         Unset\n  \"account\": \"value\",               (string)          DEPRECATED -- Unset\n  \"address\": \"value\",               (string)          Payment address for a transaction output\n  \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n  \"bip125-replaceable\": \"value\",    (string)          Unset\n  \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n  \"blockheight\": n,                 (numeric)         The block height containing the transaction.\n  \"blockindex\": n,                  (numeric)         Unset\n  \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n  \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n  \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n  \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n  \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n  \"involveswatchonly\": true|false,  (boolean)         Unset\n  \"label\": \"value\",                 (string)          A comment for the address/transaction, if any\n  \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n  \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n  \"trusted\": true|false,            (boolean)         Unset\n  \"txid\": \"value\",                  (string)          The hash of the transaction\n  \"vout\": n,                        (numeric)         The transaction output index\n  \"walletconflicts\": [\"value\",...], (array of string) Unset\n  \"comment\": \"value\",               (string)          The comment of a send describing its purpose, if any\n  \"to\": \"value\",                    (string)          The comment of a send naming the person or organization paid, if any\n  \"otheraccount\": \"value\",          (string)          Unset\n },...],                                              \n \"lastblock\": \"value\",              (string)          Hash of the latest-synced block to be used in later calls to listsinceblock\n}                                   \n",
+		"listtransactions":             "listtransactions (\"account\" count=10 from=0 includewatchonly=false)\n\nReturns a JSON array of objects containing verbose details for wallet transactions.\nAn options object may be passed as an additional final parameter.  The 'starttime' and 'endtime' options, in seconds since the Unix epoch, restrict the results to transactions received by the wallet at or after starttime and before endtime.  The 'cursor' option continues the listing after the transaction with the given hash, which is the last transaction of the previous page, so that new transactions do not shift the pages.  The count and from parameters page the transactions which follow it.\n\nArguments:\n1. account          (string, optional)                 DEPRECATED -- Unused (must be unset or \"*\")\n2. count            (numeric, optional, default=10)    Maximum number of transactions to create results from\n3. from             (numeric, optional, default=0)     Number of transactions to skip before results are created\n4. includewatchonly (boolean, optional, default=false) Unused\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockheight\": n,                 (numeric)         The block height containing the transaction.\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"label\": \"value\",                 (string)          A comment for the address/transaction, if any\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          The comment of a send describing its purpose, if any\n \"to\": \"value\",                    (string)          The comment of a send naming the person or organization paid, if any\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
+		"listunspent":                  "listunspent (minconf=1 maxconf=9999999 [\"address\",...])\n\nReturns a JSON array of objects representing unlocked unspent outputs controlled by wallet keys.\nAn options object may be passed as an additional final parameter.  The 'starttime' and 'endtime' options, in seconds since the Unix epoch, restrict the results to transactions received by the wallet at or after starttime and before endtime.  The 'cursor' option continues the listing after the result identified by its \"txid:vout\" outpoint, which is the last result of the previous page, and the 'skip' and 'count' options skip and limit the results which follow it.\nAs by the reference implementation, the 'include_unsafe' flag may be passed as the fourth parameter, and setting it to false excludes unsafe outputs.  The options object then follows it as the fifth parameter.\nThe query options of the reference implementation are also accepted in the options object: 'minimumAmount' and 'maximumAmount' restrict the results to outputs of at least and at most the amounts valued in bitcoin, 'maximumCount' is an alias of 'count', and 'include_unsafe' may be set in place of the fourth parameter.\n\nArguments:\n1. minconf   (numeric, optional, default=1)       Minimum number of block confirmations required before a transaction output is considered\n2. maxconf   (numeric, optional, default=9999999) Maximum number of block confirmations required before a transaction output is excluded\n3. addresses (array of string, optional)          If set, limits the returned details to unspent outputs received by any of these payment addresses\n\nResult:\n{\n \"txid\": \"value\",         (string)  The transaction hash of the referenced output\n \"vout\": n,               (numeric) The output index of the referenced output\n \"address\": \"value\",      (string)  The payment address that received the output\n \"account\": \"value\",      (string)  The account associated with the receiving payment address\n \"scriptPubKey\": \"value\", (string)  The output script encoded as a hexadecimal string\n \"redeemScript\": \"value\", (string)  Unset\n \"amount\": n.nnn,         (numeric) The amount of the output valued in bitcoin\n \"confirmations\": n,      (numeric) The number of block confirmations of the transaction\n \"spendable\": true|false, (boolean) Whether the output is entirely controlled by wallet keys/scripts (false for partially controlled multisig outputs or outputs to watch-only addresses)\n \"safe\": true|false,      (boolean) Whether the output is safe to spend: mined, or unmined in a transaction which only spends wallet outputs, such as change\n \"reused\": true|false,    (boolean) Whether the output pays to a dirty address, one which has previously been spent from\n}                         \n",
+		"lockunspent":                  "lockunspent unlock [{\"txid\":\"value\",\"vout\":n},...]\n\nLocks or unlocks an unspent output.\nLocked outputs are not chosen for transaction inputs of authored transactions and are not included in 'listunspent' results.\nLocked outputs are volatile and are not saved across wallet restarts.\nIf unlock is true and no transaction outputs are specified, all locked outputs are marked unlocked.\n\nArguments:\n1. unlock       (boolean, required)         True to unlock outputs, false to lock\n2. transactions (array of object, required) Transaction outputs to lock or unlock\n[{\n \"txid\": \"value\", (string)  The transaction hash of the referenced output\n \"vout\": n,       (numeric) The output index of the referenced output\n},...]\n\nResult:\ntrue|false (boolean) The boolean 'true'\n",
+		"sendfrom":                     "sendfrom \"fromaccount\" \"toaddress\" amount (minconf=1 \"comment\" \"commentto\")\n\nDEPRECATED -- Authors, signs, and sends a transaction that outputs some amount to a payment address.\nA change output is automatically included to send extra output value back to the original account.\nAn options object may be passed as an additional final parameter.  The 'unit' option ('BTC', 'mBTC', 'uBTC' or 'satoshi') sets the denomination of all amounts of the request, otherwise the server's default unit (bitcoin unless configured otherwise) is used.  The 'allowreuse' option permits combining outputs to dirty and clean addresses when spending from an account with the avoid_reuse flag set.  The 'changeaddress' option pays change to the given address, which may belong to another wallet, and the 'nochange' option omits the change output, adding any remaining value to the fee.  The 'subtractfeefromamount' option deducts the fee from the amounts paid to all recipients, and the 'subtractfeefrom' option, an array of recipient addresses, deducts it from the amounts paid to those addresses only, splitting the fee equally.  The 'feerate' option sets the fee per kilobyte of the transaction in the unit of the request, overriding the default fee rate for this transaction only, and must be at least the minimum relay fee.\n\nArguments:\n1. fromaccount (string, required)             Account to pick unspent outputs from\n2. toaddress   (string, required)             Address to pay\n3. amount      (numeric, required)            Amount to send to the payment address\n4. minconf     (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n5. comment     (string, optional)             A comment describing the purpose of the transaction, returned by gettransaction and listtransactions\n6. commentto   (string, optional)             A comment naming the person or organization paid, returned by gettransaction\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction, or the pending spend token to pass to confirmspend when spends require a TOTP confirmation\n",
+		"sendmany":                     "sendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\")\n\nAuthors, signs, and sends a transaction that outputs to many payment addresses.\nA change output is automatically included to send extra output value back to the original account.\nAn options object may be passed as an additional final parameter.  The 'unit' option ('BTC', 'mBTC', 'uBTC' or 'satoshi') sets the denomination of all amounts of the request, otherwise the server's default unit (bitcoin unless configured otherwise) is used.  The 'allowreuse' option permits combining outputs to dirty and clean addresses when spending from an account with the avoid_reuse flag set.  The 'changeaddress' option pays change to the given address, which may belong to another wallet, and the 'nochange' option omits the change output, adding any remaining value to the fee.  The 'subtractfeefromamount' option deducts the fee from the amounts paid to all recipients, and the 'subtractfeefrom' option, an array of recipient addresses, deducts it from the amounts paid to those addresses only, splitting the fee equally.  The 'feerate' option sets the fee per kilobyte of the transaction in the unit of the request, overriding the default fee rate for this transaction only, and must be at least the minimum relay fee.\n\nArguments:\n1. fromaccount (string, required) DEPRECATED -- Account to pick unspent outputs from\n2. amounts     (object, required) Pairs of payment addresses and the output amount to pay each\n{\n \"Address to pay\": Amount to send to the payment address, (object) JSON object using payment addresses as keys and output amounts to send to each address\n ...\n}\n3. minconf (numeric, optional, default=1) Minimum number of block confirmations required before a transaction output is eligible to be spent\n4. comment (string, optional)             A comment describing the purpose of the transaction, returned by gettransaction and listtransactions\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction, or the pending spend token to pass to confirmspend when spends require a TOTP confirmation\n",
+		"sendtoaddress":                "sendtoaddress \"address\" amount (\"comment\" \"commentto\")\n\nAuthors, signs, and sends a transaction that outputs some amount to a payment address.\nUnlike sendfrom, outputs are always chosen from the default account.\nA change output is automatically included to send extra output value back to the original account.\nAn options object may be passed as an additional final parameter.  The 'unit' option ('BTC', 'mBTC', 'uBTC' or 'satoshi') sets the denomination of all amounts of the request, otherwise the server's default unit (bitcoin unless configured otherwise) is used.  The 'allowreuse' option permits combining outputs to dirty and clean addresses when spending from an account with the avoid_reuse flag set.  The 'changeaddress' option pays change to the given address, which may belong to another wallet, and the 'nochange' option omits the change output, adding any remaining value to the fee.  The 'subtractfeefromamount' option deducts the fee from the amounts paid to all recipients, and the 'subtractfeefrom' option, an array of recipient addresses, deducts it from the amounts paid to those addresses only, splitting the fee equally.  The 'feerate' option sets the fee per kilobyte of the transaction in the unit of the request, overriding the default fee rate for this transaction only, and must be at least the minimum relay fee.\n\nArguments:\n1. address   (string, required)  Address to pay\n2. amount    (numeric, required) Amount to send to the payment address\n3. comment   (string, optional)  A comment describing the purpose of the transaction, returned by gettransaction and listtransactions\n4. commentto (string, optional)  A comment naming the person or organization paid, returned by gettransaction\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction, or the pending spend token to pass to confirmspend when spends require a TOTP confirmation\n",
+		"settxfee":                     "settxfee amount\n\nModify the increment used each time more fee is required for an authored transaction.\n\nArguments:\n1. amount (numeric, required) The new fee increment valued in bitcoin\n\nResult:\ntrue|false (boolean) The boolean 'true'\n",
+		"signmessage":                  "signmessage \"address\" \"message\"\n\nSigns a message using the private key of a payment address.\n\nArguments:\n1. address (string, required) Payment address of private key used to sign the message with\n2. message (string, required) Message to sign\n\nResult:\n\"value\" (string) The signed message encoded as a base64 string\n",
+		"signrawtransaction":           "signrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\n\nSigns transaction inputs using private keys from this wallet and request.\nThe valid flags options are ALL, NONE, SINGLE, ALL|ANYONECANPAY, NONE|ANYONECANPAY, and SINGLE|ANYONECANPAY.\n\nArguments:\n1. rawtx    (string, required)                Unsigned or partially unsigned transaction to sign encoded as a hexadecimal string\n2. inputs   (array of object, optional)       Additional data regarding inputs that this wallet may not be tracking\n3. privkeys (array of string, optional)       Additional WIF-encoded private keys to use when creating signatures\n4. flags    (string, optional, default=\"ALL\") Sighash flags\n\nResult:\n{\n \"hex\": \"value\",         (string)          The resulting transaction encoded as a hexadecimal string\n \"complete\": true|false, (boolean)         Whether all input signatures have been created\n \"errors\": [{            (array of object) Script verification errors (if exists)\n  \"txid\": \"value\",       (string)          The transaction hash of the referenced previous output\n  \"vout\": n,             (numeric)         The output index of the referenced previous output\n  \"scriptSig\": \"value\",  (string)          The hex-encoded signature script\n  \"sequence\": n,         (numeric)         Script sequence number\n  \"error\": \"value\",      (string)          Verification or signing error related to the input\n },...],                                   \n}                        \n",
+		"signrawtransactionwithwallet": "signrawtransactionwithwallet \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":redeemscript,\"witnessscript\":witnessscript,\"amount\":amount},...] sighashtype=\"ALL\")\n\nSigns the inputs of a transaction, including witness inputs, using private keys from this wallet.\nThe outputs spent by inputs which are not transactions of the wallet must be described by the inputs parameter, including the amount of witness inputs, so that transactions built by external tools may be signed.\nSignatures of cosigners of multisig witness scripts are kept.\nThe valid sighashtype options are ALL, NONE, SINGLE, ALL|ANYONECANPAY, NONE|ANYONECANPAY, and SINGLE|ANYONECANPAY.\n\nArguments:\n1. rawtx       (string, required)                Unsigned or partially unsigned transaction to sign encoded as a hexadecimal string\n2. inputs      (array of object, optional)       The outputs spent by the transaction which the wallet may not be tracking, with their scriptPubKey, redeemScript, witnessScript and amount\n3. sighashtype (string, optional, default=\"ALL\") The signature hash type\n\nResult:\n{\n \"hex\": \"value\",         (string)          The resulting transaction encoded as a hexadecimal string\n \"complete\": true|false, (boolean)         Whether all input signatures have been created\n \"errors\": [{            (array of object) Script verification errors (if exists)\n  \"txid\": \"value\",       (string)          The transaction hash of the referenced previous output\n  \"vout\": n,             (numeric)         The output index of the referenced previous output\n  \"scriptSig\": \"value\",  (string)          The hex-encoded signature script\n  \"sequence\": n,         (numeric)         Script sequence number\n  \"error\": \"value\",      (string)          Verification or signing error related to the input\n },...],                                   \n}                        \n",
+		"validateaddress":              "validateaddress \"address\"\n\nVerify that an address is valid.\nExtra details are returned if the address is controlled by this wallet.\nThe following fields are valid only when the address is controlled by this wallet (ismine=true): isscript, pubkey, iscompressed, account, addresses, hex, script, and sigsrequired.\nThe following fields are only valid when address has an associated public key: pubkey, iscompressed.\nThe following fields are only valid when address is a pay-to-script-hash address: addresses, hex, and script.\nIf the address is a multisig address controlled by this wallet, the multisig fields will be left unset if the wallet is locked since the redeem script cannot be decrypted.\n\nArguments:\n1. address (string, required) Address to validate\n\nResult:\n{\n \"isvalid\": true|false,      (boolean)         Whether or not the address is valid\n \"address\": \"value\",         (string)          The payment address (only when isvalid is true)\n \"ismine\": true|false,       (boolean)         Whether this address is controlled by the wallet (only when isvalid is true)\n \"iswatchonly\": true|false,  (boolean)         Unset\n \"isscript\": true|false,     (boolean)         Whether the payment address is a pay-to-script-hash address (only when isvalid is true)\n \"pubkey\": \"value\",          (string)          The associated public key of the payment address, if any (only when isvalid is true)\n \"iscompressed\": true|false, (boolean)         Whether the address was created by hashing a compressed public key, if any (only when isvalid is true)\n \"account\": \"value\",         (string)          The account this payment address belongs to (only when isvalid is true)\n \"addresses\": [\"value\",...], (array of string) All associated payment addresses of the script if address is a multisig address (only when isvalid is true)\n \"hex\": \"value\",             (string)          The redeem script \n \"script\": \"value\",          (string)          The class of redeem script for a multisig address\n \"sigsrequired\": n,          (numeric)         The number of required signatures to redeem outputs to the multisig address\n}                            \n",
+		"verifymessage":                "verifymessage \"address\" \"signature\" \"message\"\n\nVerify a message was signed with the associated private key of some address.\n\nArguments:\n1. address   (string, required) Address used to sign message\n2. signature (string, required) The signature to verify\n3. message   (string, required) The message to verify\n\nResult:\ntrue|false (boolean) Whether the message was signed with the private key of 'address'\n",
+		"walletlock":                   "walletlock\n\nLock the wallet.\nbtcwallet extension: an account name may be passed to instead lock an account protected by its own passphrase, or '*' to lock the wallet and every such account.\n\nArguments:\nNone\n\nResult:\nNothing\n",
+		"walletpassphrase":             "walletpassphrase \"passphrase\" timeout\n\nUnlock the wallet.\nbtcwallet extension: an account name may be passed after timeout to instead unlock an account protected by its own passphrase, or '*' to unlock the wallet and every such account which accepts the passphrase.\nbtcwallet extension: an unlock level may be passed after a null account to restrict the private keys of the wallet: 'view' only allows deriving accounts and importing keys, 'spend' also allows signing, and 'full' (the default) also allows exporting private keys.\n\nArguments:\n1. passphrase (string, required)  The wallet passphrase\n2. timeout    (numeric, required) The number of seconds to wait before the wallet automatically locks, or 0 to keep the wallet unlocked until it is locked with walletlock\n\nResult:\nNothing\n",
+		"walletpassphrasechange":       "walletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\n\nChange the wallet passphrase.\n\nArguments:\n1. oldpassphrase (string, required) The old wallet passphrase\n2. newpassphrase (string, required) The new wallet passphrase\n\nResult:\nNothing\n",
+		"authorizekeyuse":              "authorizekeyuse \"code\"\n\nAuthorizes a single use of the private keys of the wallet when spends require a TOTP confirmation.\nWhile spends require confirmation, 'signrawtransaction', 'signrawtransactionwithwallet', 'signmessage', 'dumpprivkey', 'exportprivkeybip38' and 'dumpwallet' fail unless the use of the keys was authorized with this method within the last minute.  Each authorization is consumed by the next such request, and each code is only accepted once.  Authorization is refused for ten minutes after three invalid codes.\n\nArguments:\n1. code (string, required) The current 6 digit code of the authenticator app\n\nResult:\nNothing\n",
+		"canceldrafttx":                "canceldrafttx \"id\"\n\nDiscards a draft transaction created by 'createtx', unlocking the outputs it spends.\n\nArguments:\n1. id (string, required) The id of the draft transaction returned by 'createtx'\n\nResult:\nNothing\n",
+		"cancelrescan":                 "cancelrescan id\n\nCancels a queued rescan job, removing it from the queue.\nRunning jobs can not be cancelled, since the chain server can not interrupt a rescan, and an error is returned for them.\n\nArguments:\n1. id (numeric, required) The id of the rescan job, as reported by 'listrescans' and 'btcwallet:rescanprogress' notifications\n\nResult:\nNothing\n",
+		"cancelspend":                  "cancelspend \"token\"\n\nCancels a send awaiting TOTP confirmation, unlocking the outputs it spends.\n\nArguments:\n1. token (string, required) The pending spend token returned by the send\n\nResult:\nNothing\n",
+		"committx":                     "committx \"id\"\n\nSigns the draft transaction created by 'createtx' and publishes it.\nThe wallet must be unlocked for this request to succeed, and the draft is kept when it can not be signed.\nWhen spends require a TOTP confirmation, the signed transaction awaits confirmation with 'confirmspend' and the token of the pending spend is returned instead of the transaction hash.\n\nArguments:\n1. id (string, required) The id of the draft transaction returned by 'createtx'\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction, or the token of the pending spend\n",
+		"confirmspend":                 "confirmspend \"token\" \"code\"\n\nPublishes the transaction of a send awaiting confirmation when spends require a TOTP confirmation.\nThe code is that of the authenticator app holding the configured TOTP secret, and each code is only accepted once.  Pending spends are cancelled when they are not confirmed within ten minutes, or after three invalid codes.\n\nArguments:\n1. token (string, required) The pending spend token returned by the send\n2. code  (string, required) The current 6 digit code of the authenticator app\n\nResult:\n\"value\" (string) The transaction hash of the sent transaction\n",
+		"createnewaccount":             "createnewaccount \"account\"\n\nCreates a new account.\nThe wallet must be unlocked for this request to succeed.\n\nArguments:\n1. account (string, required) Name of the new account\n\nResult:\nNothing\n",
+		"createtx":                     "createtx {\"address\":amount,...} (account=\"default\" minconf=1 \"comment\")\n\nCreates a transaction paying the amounts from an account, as 'sendmany' does, and returns it unsigned along with its inputs, outputs and fee for review.\nThe transaction is only signed and published once it is committed with 'committx'.  Its inputs are locked until the draft is committed or discarded with 'canceldrafttx', or expires after ten minutes.  As the transaction is not signed, the wallet need not be unlocked.\nAn options object may be passed as an additional final parameter, which accepts the options of 'sendmany'.\n\nArguments:\n1. amounts (object, required) Pairs of payment addresses and the output amount to pay each\n{\n \"Address to pay\": Amount to send to the payment address, (object) JSON object using payment addresses as keys and output amounts to send to each address\n ...\n}\n2. account (string, optional, default=\"default\") Account to pick unspent outputs from\n3. minconf (numeric, optional, default=1)        Minimum number of block confirmations required before a transaction output is eligible to be spent\n4. comment (string, optional)                    A comment describing the purpose of the transaction, returned by gettransaction and listtransactions\n\nResult:\n{\n \"id\": \"value\",         (string)          The id of the draft transaction, passed to 'committx' or 'canceldrafttx'\n \"hex\": \"value\",        (string)          The hex-encoded unsigned transaction\n \"inputs\": [{           (array of object) The wallet outputs spent by the transaction\n  \"txid\": \"value\",      (string)          The hash of the transaction of the spent output\n  \"vout\": n,            (numeric)         The output index of the spent output\n  \"address\": \"value\",   (string)          The address paid by the spent output, omitted if unknown\n  \"amount\": n.nnn,      (numeric)         The value of the spent output valued in bitcoin\n },...],                                  \n \"outputs\": [{          (array of object) The outputs of the transaction\n  \"address\": \"value\",   (string)          The address paid by the output, omitted for nonstandard scripts\n  \"amount\": n.nnn,      (numeric)         The value of the output valued in bitcoin\n  \"change\": true|false, (boolean)         Whether the output pays change back to the wallet\n },...],                                  \n \"fee\": n.nnn,          (numeric)         The fee paid by the transaction valued in bitcoin\n \"expires\": n,          (numeric)         The Unix time the draft expires unless it is committed\n}                       \n",
+		"createwallet":                 "createwallet \"walletname\" (disableprivatekeys=false blank=false passphrase=\"\" avoidreuse=false)\n\nCreates a wallet at runtime and loads it as 'loadwallet' does, serving it at the URL '/wallet/<name>'.\nThe wallet database is protected by the public passphrase set by the 'walletpass' option.\nAn options object may be passed as an additional final parameter.  The 'network' option ('mainnet', 'testnet3', 'regtest', 'signet' or 'simnet') binds the wallet to a network other than the network of the server, synchronizing it with a btcd server of that network set by the 'netrpcconnect' option.  Networks are bound per wallet rather than per account, so every account of a wallet belongs to the wallet's network; accounts of different networks are served by separate wallets.  The network directory of the wallet is locked while wallets of the network are loaded, so it can't be used by another btcwallet process.\n\nArguments:\n1. walletname         (string, required)                 The name of the new wallet, which names the directory of its database within the network directory of the application data of the wallet's network and must not contain path separators\n2. disableprivatekeys (boolean, optional, default=false) Create a watching-only wallet which holds no private keys\n3. blank              (boolean, optional, default=false) Create a wallet without a seed, holding no keys until they are imported\n4. passphrase         (string, optional, default=\"\")     The private passphrase protecting the private keys of the wallet, which is required unless private keys are disabled\n5. avoidreuse         (boolean, optional, default=false) Set the avoid_reuse flag on the accounts of the wallet which hold private keys\n\nResult:\n{\n \"name\": \"value\",    (string) The name of the created wallet\n \"warning\": \"value\", (string) A warning about creating the wallet, if any\n}                    \n",
+		"debuglevel":                   "debuglevel \"levelspec\"\n\nSets the logging levels of the process, which apply to every loaded wallet.\nThe level specification is either a single level for every subsystem or comma separated subsystem=level pairs, such as 'WLLT=debug,RPCS=trace'.\nThe levels are trace, debug, info, warn, error and critical.  No level is changed when the specification is invalid.  The special specification 'show' lists the supported subsystems instead.\n\nArguments:\n1. levelspec (string, required) The logging level specification, or 'show'\n\nResult:\n\"value\" (string) 'Done.' once the levels are set, or the supported subsystems when 'show' is requested\n",
+		"estimatesendfee":              "estimatesendfee {\"address\":amount,...} (account=\"default\" minconf=1)\n\nRuns coin selection for a send of the amounts from an account, as 'sendmany' does, and returns the fee, size, inputs and change of the transaction it would create.\nThe transaction is neither signed nor published, and the wallet is left unmodified, so the wallet need not be unlocked.\nAn options object may be passed as an additional final parameter, which accepts the options of 'sendmany'.\n\nArguments:\n1. amounts (object, required) Pairs of payment addresses and the output amount to pay each\n{\n \"Address to pay\": Amount to send to the payment address, (object) JSON object using payment addresses as keys and output amounts to send to each address\n ...\n}\n2. account (string, optional, default=\"default\") Account to pick unspent outputs from\n3. minconf (numeric, optional, default=1)        Minimum number of block confirmations required before a transaction output is eligible to be spent\n\nResult:\n{\n \"fee\": n.nnn,          (numeric) The fee the transaction would pay valued in bitcoin\n \"vsize\": n,            (numeric) The estimated virtual size of the signed transaction in vbytes\n \"inputs\": n,           (numeric) The number of wallet outputs the transaction would spend\n \"change\": true|false,  (boolean) Whether the transaction would create a change output\n \"changeamount\": n.nnn, (numeric) The value of the change output valued in bitcoin, or zero without change\n}                       \n",
+		"exportauditsnapshot":          "exportauditsnapshot \"address\" (height)\n\nReturns a signed JSON document describing every address, unspent output and account balance of the wallet as of a block of the main chain, without any private keys.\nThe document may be checked with verifymessage using the returned address, signature and snapshot string, and each unspent output may be verified against the chain using the block hash.\n\nArguments:\n1. address (string, required)  The pay-to-pubkey-hash wallet address used to sign the snapshot\n2. height  (numeric, optional) The height of the block to snapshot (default=the block the wallet is synced to)\n\nResult:\n{\n \"snapshot\": \"value\",  (string) The snapshot document encoded as a JSON string\n \"address\": \"value\",   (string) The address which signed the snapshot\n \"signature\": \"value\", (string) The base64-encoded signature of the snapshot string\n}                      \n",
+		"exporthistory":                "exporthistory \"filename\" (account=\"*\" format=\"csv\")\n\nWrites the transaction history of the wallet, or of one account, to a new file as CSV or JSON for spreadsheets and accounting software.\nEach received output and each output sent to another wallet is an entry with its date, transaction hash, category, account, address, amount, fee, address label, transaction label, comment and block height, oldest first with unmined transactions last.\nSent amounts and fees are negative, and the fee of a send is only recorded by its first entry.\nDates are in UTC, and are the time of the block for mined transactions and the time the transaction was received for unmined ones.\nThe file is created by the wallet process with permissions allowing only its owner to read it, and must not already exist.\n\nArguments:\n1. filename (string, required)                The absolute path of the file to create\n2. account  (string, optional, default=\"*\")   The account to export the history of, or \"*\" for every account\n3. format   (string, optional, default=\"csv\") The format of the file, \"csv\" or \"json\"\n\nResult:\n{\n \"filename\": \"value\", (string)  The path of the written file\n \"entries\": n,        (numeric) The number of written history entries\n}                     \n",
+		"exportprivkeybip38":           "exportprivkeybip38 \"address\" \"passphrase\"\n\nReturns the private key that controls some wallet address, encrypted with a passphrase as a BIP0038 key.\nThe key is encrypted for the pay-to-pubkey-hash address of its public key, which is checked when the key is decrypted, and the wallet must be unlocked at the full level.\n\nArguments:\n1. address    (string, required) The address to return a private key for\n2. passphrase (string, required) The passphrase to encrypt the private key with\n\nResult:\n\"value\" (string) The BIP0038 encrypted private key\n",
+		"exportwatchingwallet":         "exportwatchingwallet (\"account\" download=false)\n\nCreates and returns a duplicate of the wallet database without any private keys to be used as a watching-only wallet.\n\nArguments:\n1. account  (string, optional)                 Unused (must be unset or \"*\")\n2. download (boolean, optional, default=false) Unused\n\nResult:\n\"value\" (string) The watching-only database encoded as a base64 string\n",
+		"getaccountingreport":          "getaccountingreport \"startdate\" \"enddate\" (period=\"monthly\" account=\"*\")\n\nReturns the totals received, sent and paid in fees by each account between two dates, overall and for each daily or monthly period, to reconcile the wallet without exporting its history.\nTotals are computed from the entries written by 'exporthistory': sent totals exclude change, transfers between accounts are both sent and received, and each transaction is dated by its block, or by the time it was received while unmined.\nOnly accounts and periods with any transactions are reported.\n\nArguments:\n1. startdate (string, required)                    The first day of the report as a UTC date in the format YYYY-MM-DD\n2. enddate   (string, required)                    The last day of the report as a UTC date in the format YYYY-MM-DD\n3. period    (string, optional, default=\"monthly\") The periods to total over, \"daily\" or \"monthly\"\n4. account   (string, optional, default=\"*\")       The account to report, or \"*\" for every account\n\nResult:\n{\n \"startdate\": \"value\",   (string)          The first day of the report\n \"enddate\": \"value\",     (string)          The last day of the report\n \"period\": \"value\",      (string)          The periods totals are summed over\n \"accounts\": [{          (array of object) The totals of each account, sorted by account name\n  \"account\": \"value\",    (string)          The name of the account\n  \"received\": n.nnn,     (numeric)         The total received by the account valued in bitcoin\n  \"sent\": n.nnn,         (numeric)         The total sent from the account, excluding fees, valued in bitcoin\n  \"fees\": n.nnn,         (numeric)         The total fees paid by the account valued in bitcoin\n  \"transactions\": n,     (numeric)         The number of transactions of the account\n  \"periods\": [{          (array of object) The totals of each period with transactions, oldest first\n   \"startdate\": \"value\", (string)          The first day of the period\n   \"received\": n.nnn,    (numeric)         The total received during the period valued in bitcoin\n   \"sent\": n.nnn,        (numeric)         The total sent during the period, excluding fees, valued in bitcoin\n   \"fees\": n.nnn,        (numeric)         The total fees paid during the period valued in bitcoin\n   \"transactions\": n,    (numeric)         The number of transactions during the period\n  },...],                                  \n },...],                                   \n}                        \n",
+		"getaccountmetadata":           "getaccountmetadata \"account\"\n\nReturns the description, creation time and purpose tags of an account.\n\nArguments:\n1. account (string, required) The account name\n\nResult:\n{\n \"account\": \"value\",        (string)          The account name\n \"description\": \"value\",    (string)          The description of the account\n \"created\": n,              (numeric)         The Unix time the account was created, omitted if unknown\n \"tags\": [\"value\",...],     (array of string) Tags describing the purpose of the account\n \"avoid_reuse\": true|false, (boolean)         Whether the account avoids combining outputs to dirty and clean addresses\n \"deposit_alert\": n.nnn,    (numeric)         The amount at or above which deposits to the account are alerted valued in bitcoin, or 0 if deposits are not alerted\n}                           \n",
+		"getaccountxpub":               "getaccountxpub (account=\"default\")\n\nReturns the extended public key of an account, from which every address of the account may be derived without exposing private keys.\n\nArguments:\n1. account (string, optional, default=\"default\") The account name\n\nResult:\n{\n \"account\": \"value\",           (string) The account name\n \"xpub\": \"value\",              (string) The extended public key of the account, serialized with the version of the network\n \"masterfingerprint\": \"value\", (string) The hex encoded fingerprint of the master key the account was derived from, if known\n \"path\": \"value\",              (string) The BIP0032 derivation path of the account key from the master key\n}                              \n",
+		"getbestblock":                 "getbestblock\n\nReturns the hash and height of the newest block in the best chain that wallet has finished syncing with.\n\nArguments:\nNone\n\nResult:\n{\n \"hash\": \"value\", (string)  The hash of the block\n \"height\": n,     (numeric) The blockchain height of the block\n}                 \n",
+		"getaddressesbylabel":          "getaddressesbylabel \"label\"\n\nReturns the addresses with a label, which are set by setlabel.\n\nArguments:\n1. label (string, required) The label\n\nResult:\n{\n \"The address\": The purpose of the address, (object) JSON object with addresses as keys and their purposes as values\n ...\n}\n",
+		"getlookahead":                 "getlookahead\n\nReturns the number of addresses past the last address handed out on each branch of every account which are watched for payments.\n\nArguments:\nNone\n\nResult:\nn.nnn (numeric) The size of the lookahead window\n",
+		"getpaymentqr":                 "getpaymentqr (amount \"label\" \"message\" account=\"default\" png=false size=256)\n\nReturns the payload of a QR code requesting payment to a new address of an account, and optionally the QR code as a PNG image.\nThe payload is the BIP0021 bitcoin: URI of the request, as returned by getpaymenturi. The label is recorded as the label of the address.\n\nArguments:\n1. amount  (numeric, optional)                   The amount requested, valued in bitcoin\n2. label   (string, optional)                    The label of the request and of the address\n3. message (string, optional)                    A message describing the request to the payer\n4. account (string, optional, default=\"default\") The account to create the address for\n5. png     (boolean, optional, default=false)    Also return the QR code as a PNG image\n6. size    (numeric, optional, default=256)      The width and height of the PNG image in pixels, from 64 to 1024\n\nResult:\n{\n \"payload\": \"value\", (string) The payload to encode in a QR code\n \"address\": \"value\", (string) The address payment is requested to\n \"png\": \"value\",     (string) The base64 encoded PNG image of the QR code, if requested\n}                    \n",
+		"getpaymenturi":                "getpaymenturi (amount \"label\" \"message\" account=\"default\")\n\nReturns a BIP0021 bitcoin: URI requesting payment to a new address of an account.\nThe label is recorded as the label of the address, so that payments to it can be matched to the request.\n\nArguments:\n1. amount  (numeric, optional)                   The amount requested, valued in bitcoin\n2. label   (string, optional)                    The label of the request and of the address\n3. message (string, optional)                    A message describing the request to the payer\n4. account (string, optional, default=\"default\") The account to create the address for\n\nResult:\n{\n \"uri\": \"value\",     (string) The payment URI\n \"address\": \"value\", (string) The address payment is requested to\n}                    \n",
+		"getspendpolicy":               "getspendpolicy \"account\" (addresstype=\"legacy\")\n\nReturns the spend policy of an account along with the amount it sent during the last 24 hours.\n\nArguments:\n1. account     (string, required)                   The account name\n2. addresstype (string, optional, default=\"legacy\") The address type of the account: 'legacy' for BIP0044, 'p2sh-segwit' for BIP0049 or 'bech32' for BIP0084 accounts\n\nResult:\n{\n \"account\": \"value\",         (string)          The account name\n \"maxpertx\": n.nnn,          (numeric)         The maximum amount paid by a single transaction, or 0 if unlimited\n \"maxperday\": n.nnn,         (numeric)         The maximum amount sent during any 24 hours, or 0 if unlimited\n \"whitelist\": [\"value\",...], (array of string) The addresses which transactions may pay to, or empty if any address may be paid\n \"spent24h\": n.nnn,          (numeric)         The amount sent by transactions of the account during the last 24 hours\n}                            \n",
+		"getunconfirmedbalance":        "getunconfirmedbalance (\"account\")\n\nCalculates the unspent output value of all unmined transaction outputs for an account.\n\nArguments:\n1. account (string, optional) The account to query the unconfirmed balance for (default=\"default\")\n\nResult:\nn.nnn (numeric) Total amount of all unmined unspent outputs of the account valued in bitcoin.\n",
+		"importscript":                 "importscript \"script\" (rescan=true witness=false birthday)\n\nImports a redeem script, or a witness script, to be watched as its pay-to-script-hash or pay-to-witness-script-hash address in the 'imported' account.\nOutputs paying to the script are included in balances, and may be spent by signing PSBTs. The wallet must be unlocked to import redeem scripts.\n\nArguments:\n1. script   (string, required)                 The hex encoded script\n2. rescan   (boolean, optional, default=true)  Rescan the blockchain for outputs paying to the script, which are otherwise only watched from the block the wallet is synced to\n3. witness  (boolean, optional, default=false) Import a witness script as a pay-to-witness-script-hash address instead of a redeem script as a pay-to-script-hash address\n4. birthday (numeric, optional)                The birthday of the script as either a block height or, when not less than 500000000, a Unix timestamp. The rescan starts at the birthday block instead of the genesis block\n\nResult:\n\"value\" (string) The address of the script\n",
+		"listaddresstransactions":      "listaddresstransactions [\"address\",...] (\"account\")\n\nReturns a JSON array of objects containing verbose details for wallet transactions pertaining some addresses.\n\nArguments:\n1. addresses (array of string, required) Addresses to filter transaction results by\n2. account   (string, optional)          Unused (must be unset or \"*\")\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockheight\": n,                 (numeric)         The block height containing the transaction.\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"label\": \"value\",                 (string)          A comment for the address/transaction, if any\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          The comment of a send describing its purpose, if any\n \"to\": \"value\",                    (string)          The comment of a send naming the person or organization paid, if any\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
+		"listalltransactions":          "listalltransactions (\"account\")\n\nReturns a JSON array of objects in the same format as 'listtransactions' without limiting the number of returned objects.\n\nArguments:\n1. account (string, optional) Unused (must be unset or \"*\")\n\nResult:\n[{\n \"abandoned\": true|false,          (boolean)         Unset\n \"account\": \"value\",               (string)          DEPRECATED -- Unset\n \"address\": \"value\",               (string)          Payment address for a transaction output\n \"amount\": n.nnn,                  (numeric)         The value of the transaction output valued in bitcoin\n \"bip125-replaceable\": \"value\",    (string)          Unset\n \"blockhash\": \"value\",             (string)          The hash of the block this transaction is mined in, or the empty string if unmined\n \"blockheight\": n,                 (numeric)         The block height containing the transaction.\n \"blockindex\": n,                  (numeric)         Unset\n \"blocktime\": n,                   (numeric)         The Unix time of the block header this transaction is mined in, or 0 if unmined\n \"category\": \"value\",              (string)          The kind of transaction: \"send\" for sent transactions, \"immature\" for immature coinbase outputs, \"generate\" for mature coinbase outputs, or \"recv\" for all other received outputs.  Note: A single output may be included multiple times under different categories\n \"confirmations\": n,               (numeric)         The number of block confirmations of the transaction\n \"fee\": n.nnn,                     (numeric)         The total input value minus the total output value for sent transactions\n \"generated\": true|false,          (boolean)         Whether the transaction output is a coinbase output\n \"involveswatchonly\": true|false,  (boolean)         Unset\n \"label\": \"value\",                 (string)          A comment for the address/transaction, if any\n \"time\": n,                        (numeric)         The earliest Unix time this transaction was known to exist\n \"timereceived\": n,                (numeric)         The earliest Unix time this transaction was known to exist\n \"trusted\": true|false,            (boolean)         Unset\n \"txid\": \"value\",                  (string)          The hash of the transaction\n \"vout\": n,                        (numeric)         The transaction output index\n \"walletconflicts\": [\"value\",...], (array of string) Unset\n \"comment\": \"value\",               (string)          The comment of a send describing its purpose, if any\n \"to\": \"value\",                    (string)          The comment of a send naming the person or organization paid, if any\n \"otheraccount\": \"value\",          (string)          Unset\n},...]\n",
+		"listexpiredtransactions":      "listexpiredtransactions\n\nReturns the sends of the wallet which remain unmined longer than the unmined expiry set by the 'unminedexpiry' option, oldest first.\nExpired sends should be abandoned or replaced with a higher fee.  The result is empty when expiry is disabled.\nWebsocket clients are notified of each send when it expires by a 'btcwallet:txstuck' notification, which suggests the fee rate of a replacement.\n\nArguments:\nNone\n\nResult:\n[{\n \"txid\": \"value\",   (string)  The hash of the transaction\n \"timereceived\": n, (numeric) The earliest Unix time this transaction was known to exist\n \"fee\": n.nnn,      (numeric) The fee paid by the transaction valued in bitcoin, or 0 if it spends outputs not controlled by the wallet\n},...]\n",
+		"listlabels":                   "listlabels (\"purpose\")\n\nReturns the distinct labels of all labeled addresses, sorted.\n\nArguments:\n1. purpose (string, optional) Only return the labels of addresses of the wallet (\"receive\") or of other wallets (\"send\")\n\nResult:\n[\"value\",...] (array of string) The labels\n",
+		"listrescans":                  "listrescans\n\nReturns the running rescan jobs followed by the queued jobs, which are rescanned one batch at a time.\nWebsocket clients may subscribe to 'btcwallet:rescanprogress' notifications reporting the progress and completion of each job.\n\nArguments:\nNone\n\nResult:\n[{\n \"id\": n,          (numeric) The id of the rescan job\n \"state\": \"value\", (string)  Whether the job is 'running' or 'queued'\n \"addresses\": n,   (numeric) The number of addresses rescanned by the job\n \"startheight\": n, (numeric) The height of the block the job rescans from\n \"height\": n,      (numeric) The height of the last block rescanned, omitted for queued jobs\n \"percent\": n.nnn, (numeric) The progress of the rescan towards the best block when it started, omitted for queued jobs\n},...]\n",
+		"listwallets":                  "listwallets\n\nReturns the names of the loaded wallets.\nThe wallet opened at startup is named by the empty string and is served at the root URL, while wallets loaded with 'loadwallet' are served at '/wallet/<name>'.\n\nArguments:\nNone\n\nResult:\n[\"value\",...] (array of string) The names of the loaded wallets\n",
+		"loadwallet":                   "loadwallet \"walletname\"\n\nLoads a wallet at runtime, synchronizing it over its own connection to the chain server, and serves it at the URL '/wallet/<name>'.\nThe wallet is opened with the public passphrase set by the 'walletpass' option.\nAn options object may be passed as an additional final parameter.  The 'network' option ('mainnet', 'testnet3', 'regtest', 'signet' or 'simnet') names the network the wallet is bound to when it is not the network of the server, synchronizing it with a btcd server of that network set by the 'netrpcconnect' option.  Networks are bound per wallet rather than per account, so every account of a wallet belongs to the wallet's network; accounts of different networks are served by separate wallets.  The network directory of the wallet is locked while wallets of the network are loaded, so it can't be used by another btcwallet process.\n\nArguments:\n1. walletname (string, required) The name of the wallet, which names the directory of its database within the network directory of the application data of the wallet's network and must not contain path separators\n\nResult:\n{\n \"name\": \"value\",    (string) The name of the loaded wallet\n \"warning\": \"value\", (string) A warning about loading the wallet, if any\n}                    \n",
+		"mergeaccounts":                "mergeaccounts \"fromaccount\" \"toaccount\"\n\nMoves all addresses of an account, along with their unspent outputs, balance and transaction history, into another account.\nOnly the bookkeeping of the wallet changes: no transaction is created, and the keys of the addresses are still derived from the account they were derived from.\nThe account merged from is kept, and addresses created for it after the merge belong to it.\nAccounts protected by their own passphrase, and watch-only accounts with spendable accounts, cannot be merged.\n\nArguments:\n1. fromaccount (string, required) The account to merge from\n2. toaccount   (string, required) The account to merge into\n\nResult:\nNothing\n",
+		"notifytxconfirmations":        "notifytxconfirmations \"txid\" (depth=1)\n\nSubscribes a websocket client to the confirmations of a transaction.\nA 'btcwallet:txconfirmed' notification is sent once the transaction reaches the requested depth, ending the subscription.\nA 'btcwallet:txreorged' notification is sent each time the transaction is removed from the main chain before then.\nThis method is only available over websocket connections.\n\nArguments:\n1. txid  (string, required)             The hash of the transaction\n2. depth (numeric, optional, default=1) The number of confirmations to notify the transaction at\n\nResult:\nNothing\n",
+		"renameaccount":                "renameaccount \"oldaccount\" \"newaccount\"\n\nRenames an account.\n\nArguments:\n1. oldaccount (string, required) The old account name to rename\n2. newaccount (string, required) The new name for the account\n\nResult:\nNothing\n",
+		"releaseaddress":               "releaseaddress \"address\"\n\nReleases an address reserved with reserveaddress, such as when its invoice expires.\nAn address which has not received any payment may be reserved again.\n\nArguments:\n1. address (string, required) The reserved address\n\nResult:\nNothing\n",
+		"rescanblockchain":             "rescanblockchain (startheight stopheight account=\"*\")\n\nScans a range of blocks for transactions paying to or spending from the addresses of an account, recording transactions the wallet missed and deriving the credits of every transaction found again.\nThis recovers transactions after a wallet database was restored from a backup, and returns once the range has been scanned.\n\nArguments:\n1. startheight (numeric, optional)             The height of the first block to scan (default=the earliest birthday block of the scanned addresses)\n2. stopheight  (numeric, optional)             The height of the last block to scan (default=the wallet's best block)\n3. account     (string, optional, default=\"*\") btcwallet extension: Only scan for transactions of this account (default=all accounts)\n\nResult:\n{\n \"start_height\": n, (numeric) The height of the first block scanned\n \"stop_height\": n,  (numeric) The height of the last block scanned\n}                   \n",
+		"reserveaddress":               "reserveaddress (account=\"default\" label=\"\")\n\nReserves a payment address of an account for an invoice and labels it.\nThe address is claimed atomically, so frontends sharing the wallet never reserve the same address, and getaccountaddress does not return it.\nA released address which has not received any payment is reserved again before a new address is created.\nThe reservation ends when the address is released with releaseaddress or receives a payment.\n\nArguments:\n1. account (string, optional, default=\"default\") The account of the address\n2. label   (string, optional, default=\"\")        The label of the address, replacing the label of a previous reservation (default=no label)\n\nResult:\n\"value\" (string) The reserved payment address\n",
+		"setaccountflag":               "setaccountflag \"account\" \"flag\" (value=true)\n\nChanges the state of an account flag.\nThe only flag is 'avoid_reuse': when set, coin selection for the account never combines outputs paying to dirty addresses, those which have previously been spent from, with outputs paying to clean addresses.\n\nArguments:\n1. account (string, required)                The account name\n2. flag    (string, required)                The name of the flag to change\n3. value   (boolean, optional, default=true) The new state of the flag (default=true)\n\nResult:\n{\n \"flag_name\": \"value\",     (string)  The name of the changed flag\n \"flag_state\": true|false, (boolean) The new state of the flag\n}                          \n",
+		"setaccountmetadata":           "setaccountmetadata \"account\" \"description\" ([\"tag\",...])\n\nReplaces the description and purpose tags of an account.\n\nArguments:\n1. account     (string, required)          The account name\n2. description (string, required)          The new description of the account\n3. tags        (array of string, optional) Tags describing the purpose of the account (default=[])\n\nResult:\nNothing\n",
+		"setaccountpassphrase":         "setaccountpassphrase \"account\" \"passphrase\"\n\nProtects the private keys of an account with their own passphrase, so that the account is locked and unlocked independently of the wallet.\nThe account must be unlocked, and remains unlocked with the new passphrase.\nAn empty passphrase returns the account to the protection of the wallet passphrase.\nThe account passphrase does not protect the account from holders of the wallet passphrase: the keys of every account are derived from a key which remains encrypted with the wallet passphrase, so unlocking the wallet allows re-deriving the private keys of the account.\n\nArguments:\n1. account    (string, required) The account name\n2. passphrase (string, required) The new passphrase of the account\n\nResult:\nNothing\n",
+		"setdepositalert":              "setdepositalert \"account\" amount\n\nSets the amount at or above which a deposit to an account is alerted.\nA transaction paying external addresses of the account at least the amount is logged as a warning, notified to every websocket client by a 'btcwallet:largedeposit' notification, whatever their subscriptions, and posted to the webhook set by the 'depositwebhook' option.\nDeposits are alerted once, when their transaction is first seen in the mempool or a block.  Changes of the amount are recorded by the audit log.\n\nArguments:\n1. account (string, required)  The account name\n2. amount  (numeric, required) The least amount of an alerted deposit valued in bitcoin, or 0 to disable deposit alerts\n\nResult:\nNothing\n",
+		"setlabel":                     "setlabel \"address\" \"label\"\n\nSets the label of an address, such as the invoice it was handed out for.\nLabels are kept separately from accounts, and addresses of other wallets may be labeled as well.\nAn empty label removes the label of the address.\n\nArguments:\n1. address (string, required) The address to label\n2. label   (string, required) The label\n\nResult:\nNothing\n",
+		"setlookahead":                 "setlookahead window\n\nChanges the number of addresses past the last address handed out on each branch of every account which are watched for payments.\nPayments to addresses within the window are detected and extend the account through the paid address.\nA window of zero disables the lookahead.\n\nArguments:\n1. window (numeric, required) The new size of the lookahead window\n\nResult:\nNothing\n",
+		"setspendpolicy":               "setspendpolicy \"account\" (maxpertx maxperday [\"whitelist\",...] addresstype=\"legacy\")\n\nReplaces the spend policy of an account, which limits the sends spending from the account as well as the transactions spending from it signed by 'signrawtransaction' and 'signrawtransactionwithwallet'.\nSends and signing requests violating the policy are refused with error code -40 and recorded by the audit log, as are changes of the policy.  Sends which are not published yet, such as those awaiting confirmation, and transactions signed by the wallet count towards the daily limit until they are cancelled or recorded.  The amount of a send is the total paid to its recipients, excluding change and fees, and the daily limit counts the sends received during the last 24 hours.\nPassing only the account removes its policy.\n\nArguments:\n1. account     (string, required)                   The account name\n2. maxpertx    (numeric, optional)                  The maximum amount paid by a single transaction, valued in bitcoin (default=0, unlimited)\n3. maxperday   (numeric, optional)                  The maximum amount sent during any 24 hours, valued in bitcoin (default=0, unlimited)\n4. whitelist   (array of string, optional)          The addresses which transactions may pay to (default=[], any address)\n5. addresstype (string, optional, default=\"legacy\") The address type of the account: 'legacy' for BIP0044, 'p2sh-segwit' for BIP0049 or 'bech32' for BIP0084 accounts\n\nResult:\nNothing\n",
+		"signmessagebip322":            "signmessagebip322 \"address\" \"message\"\n\nSigns a message with the key of an address of any type the wallet spends from, returning a BIP0322 signature.\nUnlike 'signmessage', which only proves control of pay-to-pubkey-hash addresses, the signature proves control of the script of the address.  Signatures for native segwit addresses are in the simple format, and those for other addresses in the full format.\n\nArguments:\n1. address (string, required) The address whose key signs the message\n2. message (string, required) The message to sign\n\nResult:\n\"value\" (string) The BIP0322 signature encoded as a base64 string\n",
+		"subscribenotifications":       "subscribenotifications [\"notification\",...] (\"account\")\n\nSubscribes a websocket client to notifications, either of every account or only of a single account.\nClients receive every notification until they first subscribe, after which only subscribed notifications are sent.\nThe notifications are 'btcwallet:newtx', 'btcwallet:txconflict', 'btcwallet:blockconnected', 'btcwallet:blockdisconnected', 'btcwallet:chainhealth', 'btcwallet:backupfailed', 'btcwallet:accountbalances', 'btcwallet:lockstate', 'btcwallet:rescanprogress', 'btcwallet:txstuck' and the deprecated 'accountbalance', of which only 'btcwallet:newtx' and 'accountbalance' are specific to an account.\nThe 'btcwallet:shutdown' notification, sent before the server disconnects clients when it shuts down, and the 'btcwallet:largedeposit' notification of deposits alerted by 'setdepositalert' are always sent.\nThis method is only available over websocket connections.\n\nArguments:\n1. notifications (array of string, required) The notifications to subscribe to\n2. account       (string, optional)          Only subscribe to the notifications of this account (default=all accounts)\n\nResult:\nNothing\n",
+		"sweepprivkey":                 "sweepprivkey \"privkey\" (account=\"default\" startheight=0)\n\nFinds all unspent outputs controlled by a WIF-encoded private key and sends their entire value, less the transaction fee, to a new address of a wallet account.\nThe private key is only used to sign the sweep transaction and is not imported into the wallet.\n\nArguments:\n1. privkey     (string, required)                    The WIF-encoded private key to sweep\n2. account     (string, optional, default=\"default\") The account to receive the swept funds (default=\"default\")\n3. startheight (numeric, optional, default=0)        Block height to begin scanning for outputs controlled by the key (default=0)\n\nResult:\n{\n \"txid\": \"value\",    (string)  The hash of the sweep transaction\n \"address\": \"value\", (string)  The wallet address receiving the swept funds\n \"amount\": n.nnn,    (numeric) The amount received by the wallet address valued in bitcoin\n \"fee\": n.nnn,       (numeric) The fee paid by the sweep transaction valued in bitcoin\n \"inputs\": n,        (numeric) The number of outputs spent by the sweep transaction\n}                    \n",
+		"unloadwallet":                 "unloadwallet (\"walletname\")\n\nUnloads a wallet loaded with 'loadwallet', closing its connection to the chain server so that its addresses are no longer tracked.\nThe wallet opened at startup can not be unloaded.\n\nArguments:\n1. walletname (string, optional) The name of the wallet to unload (default=the wallet of the request URL)\n\nResult:\nNothing\n",
+		"unsubscribenotifications":     "unsubscribenotifications [\"notification\",...] (\"account\")\n\nRemoves subscriptions of a websocket client to notifications made with 'subscribenotifications'.\nWhen an account is specified, only subscriptions made for that account are removed.\nThis method is only available over websocket connections.\n\nArguments:\n1. notifications (array of string, required) The notifications to unsubscribe from\n2. account       (string, optional)          Only remove the subscriptions made for this account (default=all subscriptions)\n\nResult:\nNothing\n",
+		"verifymessagebip322":          "verifymessagebip322 \"address\" \"signature\" \"message\"\n\nVerifies a BIP0322 signature of a message, in the simple or full format, proving control of the script of an address.\nLegacy signatures created by 'signmessage' are accepted for pay-to-pubkey-hash addresses.\n\nArguments:\n1. address   (string, required) The address the message was signed with\n2. signature (string, required) The base64 encoded signature to verify\n3. message   (string, required) The signed message\n\nResult:\ntrue|false (boolean) Whether the signature proves control of 'address'\n",
+		"walletfsck":                   "walletfsck (repair=false)\n\nChecks the integrity of the wallet database, verifying every transaction record against the checksum of its transaction hash and cross-checking the unspent outputs and unmined transaction indexes against the transaction records.\nRecords failing their checksum are lost transaction records. Orphaned index entries, missing unspent output entries and an incorrect balance are repaired in place when requested.\nLost transaction records cannot be repaired while the wallet runs; restart with the 'walletfsckrepair' option to rebuild the transaction history by rescanning the chain.\n\nArguments:\n1. repair (boolean, optional, default=false) Repair the inconsistencies which can be repaired in place\n\nResult:\n{\n \"inconsistencies\": [{      (array of object) The inconsistencies found\n  \"bucket\": \"value\",        (string)          The kind of record which is inconsistent\n  \"key\": \"value\",           (string)          The key of the record as a hex string\n  \"description\": \"value\",   (string)          A description of the inconsistency\n  \"repairable\": true|false, (boolean)         Whether the inconsistency can be repaired in place\n },...],                                      \n \"repaired\": true|false,    (boolean)         Whether the repairable inconsistencies were repaired\n}                           \n",
+		"walletislocked":               "walletislocked\n\nReturns whether or not the wallet is locked.\nbtcwallet extension: an account name may be passed to instead return whether the account is locked, or '*' to return whether the wallet or any account protected by its own passphrase is locked.\n\nArguments:\nNone\n\nResult:\ntrue|false (boolean) Whether the wallet is locked\n",
+		"walletextendunlock":           "walletextendunlock timeout (\"account\")\n\nReplaces the timeout of the unlocked wallet, or of an unlocked account protected by its own passphrase, without requiring the passphrase again.\nThe wallet or account is locked once the new timeout has elapsed from now. An error is returned if it is locked.\n\nArguments:\n1. timeout (numeric, required) The number of seconds from now after which the wallet or account is locked, or 0 to keep it unlocked until it is locked explicitly\n2. account (string, optional)  The account protected by its own passphrase to extend instead of the wallet\n\nResult:\nNothing\n",
+		"walletlockall":                "walletlockall\n\nLocks the wallet and every account protected by its own passphrase at once, like walletlock with the '*' account.\nWebsocket clients receive a single 'btcwallet:lockstate' notification naming everything which was locked.\n\nArguments:\nNone\n\nResult:\nNothing\n",
+		"walletunlockeduntil":          "walletunlockeduntil (\"account\")\n\nReturns whether the wallet, or an account protected by its own passphrase, is unlocked and when it will be locked again.\n\nArguments:\n1. account (string, optional) The account protected by its own passphrase to query instead of the wallet\n\nResult:\n{\n \"unlocked\": true|false,  (boolean) Whether the wallet or account is unlocked\n \"unlocked_until\": n,     (numeric) The Unix time at which the wallet or account will be locked, or 0 when it is locked or unlocked without a time limit\n \"unlock_level\": \"value\", (string)  The unlock level of the unlocked wallet ('view', 'spend' or 'full'), omitted for accounts\n}                         \n",
 	}
 }
 
@@ -56,4 +115,4 @@ var localeHelpDescs = map[string]func() map[string]string{
 	"en_US": helpDescsEnUS,
 }
 
-var requestUsages = "addmultisigaddress nrequired [\"key\",...] (\"account\")\ncreatemultisig nrequired [\"key\",...]\ndumpprivkey \"address\"\ngetaccount \"address\"\ngetaccountaddress \"account\"\ngetaddressesbyaccount \"account\"\ngetbalance (\"account\" minconf=1)\ngetbestblockhash\ngetblockcount\ngetinfo\ngetnewaddress (\"account\")\ngetrawchangeaddress (\"account\")\ngetreceivedbyaccount \"account\" (minconf=1)\ngetreceivedbyaddress \"address\" (minconf=1)\ngettransaction \"txid\" (includewatchonly=false)\nhelp (\"command\")\nimportprivkey \"privkey\" (\"label\" rescan=true)\nkeypoolrefill (newsize=100)\nlistaccounts (minconf=1)\nlistlockunspent\nlistreceivedbyaccount (minconf=1 includeempty=false includewatchonly=false)\nlistreceivedbyaddress (minconf=1 includeempty=false includewatchonly=false)\nlistsinceblock (\"blockhash\" targetconfirmations=1 includewatchonly=false)\nlisttransactions (\"account\" count=10 from=0 includewatchonly=false)\nlistunspent (minconf=1 maxconf=9999999 [\"address\",...])\nlockunspent unlock [{\"txid\":\"value\",\"vout\":n},...]\nsendfrom \"fromaccount\" \"toaddress\" amount (minconf=1 \"comment\" \"commentto\")\nsendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\")\nsendtoaddress \"address\" amount (\"comment\" \"commentto\")\nsettxfee amount\nsignmessage \"address\" \"message\"\nsignrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\nvalidateaddress \"address\"\nverifymessage \"address\" \"signature\" \"message\"\nwalletlock\nwalletpassphrase \"passphrase\" timeout\nwalletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\ncreatenewaccount \"account\"\nexportwatchingwallet (\"account\" download=false)\ngetbestblock\ngetunconfirmedbalance (\"account\")\nlistaddresstransactions [\"address\",...] (\"account\")\nlistalltransactions (\"account\")\nrenameaccount \"oldaccount\" \"newaccount\"\nwalletislocked"
+var requestUsages = "addmultisigaddress nrequired [\"key\",...] (\"account\")\nanalyzepsbt \"psbt\"\ncreatemultisig nrequired [\"key\",...]\ndecodepsbt \"psbt\"\ndumpprivkey \"address\"\ndumpwallet \"filename\"\ngetaccount \"address\"\ngetaccountaddress \"account\"\ngetaddressesbyaccount \"account\"\ngetaddressinfo \"address\"\ngetbalance (\"account\" minconf=1)\ngetbalances\ngetbestblockhash\ngetblockcount\ngetinfo\ngetnewaddress (\"account\")\ngetrawchangeaddress (\"account\")\ngetreceivedbyaccount \"account\" (minconf=1)\ngetreceivedbyaddress \"address\" (minconf=1)\ngettransaction \"txid\" (includewatchonly=false)\ngetwalletinfo\nhelp (\"command\")\nimportdescriptors [{\"desc\":\"value\",\"range\":range,\"timestamp\":timestamp,\"label\":\"value\"},...]\nimportprivkey \"privkey\" (\"label\" rescan=true)\nimportpubkey \"pubkey\" (rescan=true)\nkeypoolrefill (newsize=100)\nlistaccounts (minconf=1)\nlistdescriptors\nlistlockunspent\nlistreceivedbyaccount (minconf=1 includeempty=false includewatchonly=false)\nlistreceivedbyaddress (minconf=1 includeempty=false includewatchonly=false)\nlistsinceblock (\"blockhash\" targetconfirmations=1 includewatchonly=false)\nlisttransactions (\"account\" count=10 from=0 includewatchonly=false)\nlistunspent (minconf=1 maxconf=9999999 [\"address\",...])\nlockunspent unlock [{\"txid\":\"value\",\"vout\":n},...]\nsendfrom \"fromaccount\" \"toaddress\" amount (minconf=1 \"comment\" \"commentto\")\nsendmany \"fromaccount\" {\"address\":amount,...} (minconf=1 \"comment\")\nsendtoaddress \"address\" amount (\"comment\" \"commentto\")\nsettxfee amount\nsignmessage \"address\" \"message\"\nsignrawtransaction \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":\"value\"},...] [\"privkey\",...] flags=\"ALL\")\nsignrawtransactionwithwallet \"rawtx\" ([{\"txid\":\"value\",\"vout\":n,\"scriptpubkey\":\"value\",\"redeemscript\":redeemscript,\"witnessscript\":witnessscript,\"amount\":amount},...] sighashtype=\"ALL\")\nvalidateaddress \"address\"\nverifymessage \"address\" \"signature\" \"message\"\nwalletlock\nwalletpassphrase \"passphrase\" timeout\nwalletpassphrasechange \"oldpassphrase\" \"newpassphrase\"\nauthorizekeyuse \"code\"\ncanceldrafttx \"id\"\ncancelrescan id\ncancelspend \"token\"\ncommittx \"id\"\nconfirmspend \"token\" \"code\"\ncreatenewaccount \"account\"\ncreatetx {\"address\":amount,...} (account=\"default\" minconf=1 \"comment\")\ncreatewallet \"walletname\" (disableprivatekeys=false blank=false passphrase=\"\" avoidreuse=false)\ndebuglevel \"levelspec\"\nestimatesendfee {\"address\":amount,...} (account=\"default\" minconf=1)\nexportauditsnapshot \"address\" (height)\nexporthistory \"filename\" (account=\"*\" format=\"csv\")\nexportprivkeybip38 \"address\" \"passphrase\"\nexportwatchingwallet (\"account\" download=false)\ngetaccountingreport \"startdate\" \"enddate\" (period=\"monthly\" account=\"*\")\ngetaccountmetadata \"account\"\ngetaccountxpub (account=\"default\")\ngetbestblock\ngetaddressesbylabel \"label\"\ngetlookahead\ngetpaymentqr (amount \"label\" \"message\" account=\"default\" png=false size=256)\ngetpaymenturi (amount \"label\" \"message\" account=\"default\")\ngetspendpolicy \"account\" (addresstype=\"legacy\")\ngetunconfirmedbalance (\"account\")\nimportscript \"script\" (rescan=true witness=false birthday)\nlistaddresstransactions [\"address\",...] (\"account\")\nlistalltransactions (\"account\")\nlistexpiredtransactions\nlistlabels (\"purpose\")\nlistrescans\nlistwallets\nloadwallet \"walletname\"\nmergeaccounts \"fromaccount\" \"toaccount\"\nnotifytxconfirmations \"txid\" (depth=1)\nrenameaccount \"oldaccount\" \"newaccount\"\nreleaseaddress \"address\"\nrescanblockchain (startheight stopheight account=\"*\")\nreserveaddress (account=\"default\" label=\"\")\nsetaccountflag \"account\" \"flag\" (value=true)\nsetaccountmetadata \"account\" \"description\" ([\"tag\",...])\nsetaccountpassphrase \"account\" \"passphrase\"\nsetdepositalert \"account\" amount\nsetlabel \"address\" \"label\"\nsetlookahead window\nsetspendpolicy \"account\" (maxpertx maxperday [\"whitelist\",...] addresstype=\"legacy\")\nsignmessagebip322 \"address\" \"message\"\nsubscribenotifications [\"notification\",...] (\"account\")\nsweepprivkey \"privkey\" (account=\"default\" startheight=0)\nunloadwallet (\"walletname\")\nunsubscribenotifications [\"notification\",...] (\"account\")\nverifymessagebip322 \"address\" \"signature\" \"message\"\nwalletfsck (repair=false)\nwalletislocked\nwalletextendunlock timeout (\"account\")\nwalletlockall\nwalletunlockeduntil (\"account\")"
@@ -5,10 +5,10 @@
 package legacyrpc
 
 import (
+	"context"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"io"
 	"io/ioutil"
@@ -19,7 +19,12 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/auditlog"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/btcsuite/btcwallet/wallet"
 	"github.com/btcsuite/websocket"
 )
@@ -27,18 +32,29 @@ import (
 type websocketClient struct {
 	conn          *websocket.Conn
 	authenticated bool
+	tier          authTier
 	remoteAddr    string
+	format        *resultFormat // Negotiated when connecting, may be nil.
+	confSubs      map[chainhash.Hash]*confSubscription
+	confSubsMtx   sync.Mutex
+	ntfnSubs      map[string]*ntfnSubscription // nil until first subscribing.
+	ntfnSubsMtx   sync.Mutex
 	allRequests   chan []byte
 	responses     chan []byte
 	quit          chan struct{} // closed on disconnect
 	wg            sync.WaitGroup
 }
 
-func newWebsocketClient(c *websocket.Conn, authenticated bool, remoteAddr string) *websocketClient {
+func newWebsocketClient(c *websocket.Conn, authenticated bool, tier authTier,
+	remoteAddr string, format *resultFormat) *websocketClient {
+
 	return &websocketClient{
 		conn:          c,
 		authenticated: authenticated,
+		tier:          tier,
 		remoteAddr:    remoteAddr,
+		format:        format,
+		confSubs:      make(map[chainhash.Hash]*confSubscription),
 		allRequests:   make(chan []byte),
 		responses:     make(chan []byte),
 		quit:          make(chan struct{}),
@@ -54,6 +70,23 @@ func (c *websocketClient) send(b []byte) error {
 	}
 }
 
+// respond sends the response to a request to the client, unless the request
+// is a JSON-RPC 2.0 notification.  Responses which can not be marshaled are
+// logged and dropped.
+func (c *websocketClient) respond(req *btcjson.Request, notification bool,
+	result interface{}, jsonErr *btcjson.RPCError) error {
+
+	if notification {
+		return nil
+	}
+	mresp, err := marshalResponse(req, result, jsonErr)
+	if err != nil {
+		log.Errorf("Unable to marshal response: %v", err)
+		return nil
+	}
+	return c.send(mresp)
+}
+
 // Server holds the items the RPC server may need to access (auth,
 // config, shutdown, etc.)
 type Server struct {
@@ -63,13 +96,68 @@ type Server struct {
 	chainClient  chain.Interface
 	handlerMu    sync.Mutex
 
-	listeners []net.Listener
-	authsha   [sha256.Size]byte
-	upgrader  websocket.Upgrader
+	// walletManager loads the wallets served in addition to wallet, and
+	// is nil when wallets can not be loaded at runtime.
+	walletManager WalletManager
+
+	authsha  [sha256.Size]byte
+	upgrader websocket.Upgrader
+
+	// publicAuthsha is the hash of the HTTP basic auth string of the
+	// public tier, and is only checked when publicLimiter is non-nil.
+	publicAuthsha [sha256.Size]byte
+	publicLimiter *rateLimiter
+
+	// limitedAuthsha is the hash of the HTTP basic auth string of the
+	// limited tier, and is only checked when limitedAuth is set.
+	limitedAuthsha [sha256.Size]byte
+	limitedAuth    bool
+
+	// disabledMethods are refused for every client, as are methods not in
+	// allowedMethods when it is non-nil.
+	disabledMethods map[string]struct{}
+	allowedMethods  map[string]struct{}
+
+	// clientLimiter limits the number of requests from each client host,
+	// and is nil when clients are not rate limited.
+	clientLimiter *rateLimiter
 
 	maxPostClients      int64 // Max concurrent HTTP POST clients.
 	maxWebsocketClients int64 // Max concurrent websocket clients.
 
+	// handlers runs the handlers of client requests, limiting the number
+	// of requests handled concurrently.
+	handlers *handlerPool
+
+	amountUnit  btcutil.AmountUnit // Default unit of send request amounts.
+	displayUnit btcutil.AmountUnit // Default unit of result amounts.
+
+	// legacyBalanceNtfns additionally sends the deprecated accountbalance
+	// notifications whenever btcwallet:accountbalances is sent.
+	legacyBalanceNtfns bool
+
+	// auditLog records requests of the auditedMethods, and is nil when
+	// requests are not recorded.
+	auditLog *auditlog.Log
+
+	// setLogLevels and logSubsystems are used by the debuglevel method,
+	// which is disabled when setLogLevels is nil.
+	setLogLevels  func(levelSpec string) error
+	logSubsystems []string
+
+	// ntfnClients is the set of authenticated websocket clients which
+	// receive notifications.
+	ntfnClients    map[*websocketClient]struct{}
+	ntfnClientsMtx sync.Mutex
+
+	// draining is closed when the server begins shutting down, after
+	// which no new requests are read from clients.  wsClients tracks the
+	// websocket clients still replying to their requests, and wsMtx
+	// orders the tracking of new clients with the closing of draining.
+	draining  chan struct{}
+	wsClients sync.WaitGroup
+	wsMtx     sync.Mutex
+
 	wg      sync.WaitGroup
 	quit    chan struct{}
 	quitMtx sync.Mutex
@@ -91,7 +179,7 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 
 	server := &Server{
 		httpServer: http.Server{
-			Handler: serveMux,
+			Handler: walletPathHandler(serveMux),
 
 			// Timeout connections which don't complete the initial
 			// handshake within the allowed timeframe.
@@ -100,7 +188,14 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 		walletLoader:        walletLoader,
 		maxPostClients:      opts.MaxPOSTClients,
 		maxWebsocketClients: opts.MaxWebsocketClients,
-		listeners:           listeners,
+		handlers:            newHandlerPool(opts.MaxConcurrentHandlers),
+		amountUnit:          opts.AmountUnit,
+		displayUnit:         opts.DisplayUnit,
+		legacyBalanceNtfns:  opts.LegacyBalanceNtfns,
+		auditLog:            opts.AuditLog,
+		setLogLevels:        opts.SetLogLevels,
+		logSubsystems:       opts.LogSubsystems,
+		ntfnClients:         make(map[*websocketClient]struct{}),
 		// A hash of the HTTP basic auth string is used for a constant
 		// time comparison.
 		authsha: sha256.Sum256(httpBasicAuth(opts.Username, opts.Password)),
@@ -108,9 +203,35 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 			// Allow all origins.
 			CheckOrigin: func(r *http.Request) bool { return true },
 		},
+		draining:            make(chan struct{}),
 		quit:                make(chan struct{}),
 		requestShutdownChan: make(chan struct{}, 1),
 	}
+	if opts.PublicUsername != "" {
+		server.publicAuthsha = sha256.Sum256(httpBasicAuth(
+			opts.PublicUsername, opts.PublicPassword,
+		))
+		server.publicLimiter = newRateLimiter(
+			opts.PublicRateLimit, time.Minute,
+		)
+	}
+	if opts.ClientRateLimit != 0 {
+		server.clientLimiter = newRateLimiter(
+			opts.ClientRateLimit, time.Minute,
+		)
+	}
+	if opts.LimitedUsername != "" {
+		server.limitedAuthsha = sha256.Sum256(httpBasicAuth(
+			opts.LimitedUsername, opts.LimitedPassword,
+		))
+		server.limitedAuth = true
+	}
+	if len(opts.DisabledMethods) != 0 {
+		server.disabledMethods = methodSet(opts.DisabledMethods)
+	}
+	if len(opts.AllowedMethods) != 0 {
+		server.allowedMethods = methodSet(opts.AllowedMethods)
+	}
 
 	serveMux.Handle("/", throttledFn(opts.MaxPOSTClients,
 		func(w http.ResponseWriter, r *http.Request) {
@@ -118,20 +239,27 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 			w.Header().Set("Content-Type", "application/json")
 			r.Close = true
 
-			if err := server.checkAuthHeader(r); err != nil {
+			tier, err := server.checkAuthHeader(r)
+			if err != nil {
 				log.Warnf("Unauthorized client connection attempt")
 				jsonAuthFail(w)
 				return
 			}
+			if !server.allowClientRequest(r.RemoteAddr) {
+				http.Error(w, "429 Too Many Requests",
+					http.StatusTooManyRequests)
+				return
+			}
 			server.wg.Add(1)
-			server.postClientRPC(w, r)
+			server.postClientRPC(w, r, tier)
 			server.wg.Done()
 		}))
 
 	serveMux.Handle("/ws", throttledFn(opts.MaxWebsocketClients,
 		func(w http.ResponseWriter, r *http.Request) {
 			authenticated := false
-			switch server.checkAuthHeader(r) {
+			tier, err := server.checkAuthHeader(r)
+			switch err {
 			case nil:
 				authenticated = true
 			case ErrNoAuth:
@@ -145,13 +273,23 @@ func NewServer(opts *Options, walletLoader *wallet.Loader, listeners []net.Liste
 				return
 			}
 
+			format, err := parseResultFormat(
+				r.URL.Query(), server.displayUnit,
+			)
+			if err != nil {
+				http.Error(w, "400 Bad Request: "+err.Error(),
+					http.StatusBadRequest)
+				return
+			}
+
 			conn, err := server.upgrader.Upgrade(w, r, nil)
 			if err != nil {
 				log.Warnf("Cannot websocket upgrade client %s: %v",
 					r.RemoteAddr, err)
 				return
 			}
-			wsc := newWebsocketClient(conn, authenticated, r.RemoteAddr)
+			wsc := newWebsocketClient(conn, authenticated, tier,
+				r.RemoteAddr, format)
 			server.websocketClientRPC(wsc)
 		}))
 
@@ -200,11 +338,27 @@ func (s *Server) RegisterWallet(w *wallet.Wallet) {
 	s.handlerMu.Lock()
 	s.wallet = w
 	s.handlerMu.Unlock()
+
+	s.wg.Add(8)
+	go s.notifyBackupFailures(w)
+	go s.notifyChainHealth(w)
+	go s.notifyConflicts(w)
+	go s.notifyLargeDeposits(w)
+	go s.notifyLockState(w)
+	go s.notifyRescanProgress(w)
+	go s.notifyStuckTransactions(w)
+	go s.notifyTransactions(w)
 }
 
+// shutdownTimeout is the longest time the server waits for the requests being
+// handled when it is stopped.
+const shutdownTimeout = 30 * time.Second
+
 // Stop gracefully shuts down the rpc server by stopping and disconnecting all
 // clients, disconnecting the chain server connection, and closing the wallet's
-// account files.  This blocks until shutdown completes.
+// account files.  Requests being handled are replied to, and websocket clients
+// are notified of the shutdown, before the wallet is stopped.  This blocks
+// until shutdown completes.
 func (s *Server) Stop() {
 	s.quitMtx.Lock()
 	select {
@@ -214,6 +368,8 @@ func (s *Server) Stop() {
 	default:
 	}
 
+	s.drain(shutdownTimeout)
+
 	// Stop the connected wallet and chain server, if any.
 	s.handlerMu.Lock()
 	wallet := s.wallet
@@ -226,15 +382,6 @@ func (s *Server) Stop() {
 		chainClient.Stop()
 	}
 
-	// Stop all the listeners.
-	for _, listener := range s.listeners {
-		err := listener.Close()
-		if err != nil {
-			log.Errorf("Cannot close listener `%s`: %v",
-				listener.Addr(), err)
-		}
-	}
-
 	// Signal the remaining goroutines to stop.
 	close(s.quit)
 	s.quitMtx.Unlock()
@@ -250,6 +397,46 @@ func (s *Server) Stop() {
 
 	// Wait for all remaining goroutines to exit.
 	s.wg.Wait()
+
+	if s.auditLog != nil {
+		if err := s.auditLog.Close(); err != nil {
+			log.Errorf("Unable to close audit log: %v", err)
+		}
+	}
+}
+
+// drain stops the server from accepting connections and requests, and waits
+// up to the timeout for the requests being handled to be replied to.  HTTP
+// POST connections are closed once replied to, and websocket clients are
+// notified of the shutdown and disconnected.
+func (s *Server) drain(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	s.wsMtx.Lock()
+	close(s.draining)
+	s.wsMtx.Unlock()
+
+	// Shutting down the HTTP server closes the listeners and waits for
+	// the HTTP POST requests, but not for the hijacked websocket
+	// connections, which are waited on below.
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		log.Warnf("Closing HTTP POST clients with requests still "+
+			"being handled: %v", err)
+		s.httpServer.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wsClients.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warnf("Disconnecting websocket clients with requests " +
+			"still being handled")
+	}
 }
 
 // SetChainServer sets the chain server client component needed to run a fully
@@ -266,10 +453,22 @@ func (s *Server) SetChainServer(chainClient chain.Interface) {
 // method.  This may be a request that is handled directly by btcwallet, or
 // a chain server request that is handled by passing the request down to btcd.
 //
+// Requests are handled by the wallet loaded with the name, or the registered
+// wallet when the name is empty.
+//
 // NOTE: These handlers do not handle special cases, such as the authenticate
 // method.  Each of these must be checked beforehand (the method is already
 // known) and handled accordingly.
-func (s *Server) handlerClosure(request *btcjson.Request) lazyHandler {
+func (s *Server) handlerClosure(request *btcjson.Request, walletName string,
+	tier authTier) lazyHandler {
+
+	if handler, ok := managementHandlers[request.Method]; ok {
+		return s.managementHandlerClosure(request, handler, walletName)
+	}
+	if walletName != "" {
+		return s.loadedWalletHandlerClosure(request, walletName, tier)
+	}
+
 	s.handlerMu.Lock()
 	// With the lock held, make copies of these pointers for the closure.
 	wallet := s.wallet
@@ -280,7 +479,8 @@ func (s *Server) handlerClosure(request *btcjson.Request) lazyHandler {
 	}
 	s.handlerMu.Unlock()
 
-	return lazyApplyHandler(request, wallet, chainClient)
+	return lazyApplyHandler(request, wallet, chainClient, s.amountUnit,
+		tier)
 }
 
 // ErrNoAuth represents an error where authentication could not succeed
@@ -290,23 +490,83 @@ var ErrNoAuth = errors.New("no auth")
 // checkAuthHeader checks the HTTP Basic authentication supplied by a client
 // in the HTTP request r.  It errors with ErrNoAuth if the request does not
 // contain the Authorization header, or another non-nil error if the
-// authentication was provided but incorrect.
+// authentication was provided but incorrect.  The returned tier is the tier
+// of the credentials the client authenticated with.
 //
 // This check is time-constant.
-func (s *Server) checkAuthHeader(r *http.Request) error {
+func (s *Server) checkAuthHeader(r *http.Request) (authTier, error) {
 	authhdr := r.Header["Authorization"]
 	if len(authhdr) == 0 {
-		return ErrNoAuth
+		return fullTier, ErrNoAuth
+	}
+
+	return s.checkAuthSha(sha256.Sum256([]byte(authhdr[0])))
+}
+
+// checkAuthSha compares the hash of an HTTP Basic authentication string with
+// the hashes of the server, limited tier and public tier credentials,
+// returning the tier of the credentials which matched.
+//
+// This check is time-constant.
+func (s *Server) checkAuthSha(authsha [sha256.Size]byte) (authTier, error) {
+	if subtle.ConstantTimeCompare(authsha[:], s.authsha[:]) == 1 {
+		return fullTier, nil
+	}
+	if s.limitedAuth && subtle.ConstantTimeCompare(
+		authsha[:], s.limitedAuthsha[:]) == 1 {
+
+		return limitedTier, nil
 	}
+	if s.publicLimiter != nil && subtle.ConstantTimeCompare(
+		authsha[:], s.publicAuthsha[:]) == 1 {
 
-	authsha := sha256.Sum256([]byte(authhdr[0]))
-	cmp := subtle.ConstantTimeCompare(authsha[:], s.authsha[:])
-	if cmp != 1 {
-		return errors.New("bad auth")
+		return publicTier, nil
+	}
+	return fullTier, errors.New("bad auth")
+}
+
+// checkPublicRequest returns an error if a request of the method from the
+// client at remoteAddr may not be handled for a client authenticated with the
+// public tier credentials, either because the method is not public or the
+// client's rate limit of the method is exceeded.  Each client host is limited
+// separately, so that one client can not exhaust the limit of every other.
+func (s *Server) checkPublicRequest(remoteAddr, method string) *btcjson.RPCError {
+	if _, ok := publicMethods[method]; !ok {
+		return &ErrPublicMethodNotAllowed
+	}
+	if !s.publicLimiter.allow(clientHost(remoteAddr) + " " + method) {
+		log.Warnf("Rate limited public request of method %s from "+
+			"client %s", method, remoteAddr)
+		return &ErrPublicRateLimited
 	}
 	return nil
 }
 
+// clientHost returns the host of a client's remote address, which identifies
+// the client for rate limiting.
+func clientHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// allowClientRequest records a request from the client at remoteAddr and
+// returns whether it is within the rate limit of each client.  Clients are
+// identified by host, so the HTTP POST requests and websocket connections of a
+// client share a single limit.
+func (s *Server) allowClientRequest(remoteAddr string) bool {
+	if s.clientLimiter == nil {
+		return true
+	}
+	if !s.clientLimiter.allow(clientHost(remoteAddr)) {
+		log.Warnf("Rate limited request from client %s", remoteAddr)
+		return false
+	}
+	return true
+}
+
 // throttledFn wraps an http.HandlerFunc with throttling of concurrent active
 // clients by responding with an HTTP 429 when the threshold is crossed.
 func throttledFn(threshold int64, f http.HandlerFunc) http.Handler {
@@ -332,34 +592,24 @@ func throttled(threshold int64, h http.Handler) http.Handler {
 	})
 }
 
-// idPointer returns a pointer to the passed ID, or nil if the interface is nil.
-// Interface pointers are usually a red flag of doing something incorrectly,
-// but this is only implemented here to work around an oddity with btcjson,
-// which uses empty interface pointers for response IDs.
-func idPointer(id interface{}) (p *interface{}) {
-	if id != nil {
-		p = &id
-	}
-	return
-}
-
 // invalidAuth checks whether a websocket request is a valid (parsable)
 // authenticate request and checks the supplied username and passphrase
-// against the server auth.
-func (s *Server) invalidAuth(req *btcjson.Request) bool {
+// against the server auth.  When the credentials are valid, the returned
+// tier is the tier of the credentials.
+func (s *Server) invalidAuth(req *btcjson.Request) (invalid bool, tier authTier) {
 	cmd, err := btcjson.UnmarshalCmd(req)
 	if err != nil {
-		return false
+		return false, fullTier
 	}
 	authCmd, ok := cmd.(*btcjson.AuthenticateCmd)
 	if !ok {
-		return false
+		return false, fullTier
 	}
 	// Check credentials.
 	login := authCmd.Username + ":" + authCmd.Passphrase
 	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-	authSha := sha256.Sum256([]byte(auth))
-	return subtle.ConstantTimeCompare(authSha[:], s.authsha[:]) != 1
+	tier, err = s.checkAuthSha(sha256.Sum256([]byte(auth)))
+	return err != nil, tier
 }
 
 func (s *Server) websocketClientRead(wsc *websocketClient) {
@@ -383,6 +633,7 @@ func (s *Server) websocketClientRespond(wsc *websocketClient) {
 	// WebsocketClientRead (which sends to the allRequests chan) not closing
 	// allRequests during shutdown if the remote websocket client is still
 	// connected.
+	s.addNotificationClient(wsc)
 out:
 	for {
 		select {
@@ -392,23 +643,14 @@ out:
 				break out
 			}
 
-			var req btcjson.Request
-			err := json.Unmarshal(reqBytes, &req)
-			if err != nil {
+			req, notification, jsonErr := parseRequest(reqBytes)
+			zero.Bytes(reqBytes)
+			if jsonErr != nil {
 				if !wsc.authenticated {
 					// Disconnect immediately.
 					break out
 				}
-				resp := makeResponse(req.ID, nil,
-					btcjson.ErrRPCInvalidRequest)
-				mresp, err := json.Marshal(resp)
-				// We expect the marshal to succeed.  If it
-				// doesn't, it indicates some non-marshalable
-				// type in the response.
-				if err != nil {
-					panic(err)
-				}
-				err = wsc.send(mresp)
+				err := wsc.respond(&req, false, nil, jsonErr)
 				if err != nil {
 					break out
 				}
@@ -416,18 +658,19 @@ out:
 			}
 
 			if req.Method == "authenticate" {
-				if wsc.authenticated || s.invalidAuth(&req) {
+				if wsc.authenticated {
 					// Disconnect immediately.
 					break out
 				}
-				wsc.authenticated = true
-				resp := makeResponse(req.ID, nil, nil)
-				// Expected to never fail.
-				mresp, err := json.Marshal(resp)
-				if err != nil {
-					panic(err)
+				invalid, tier := s.invalidAuth(&req)
+				if invalid {
+					// Disconnect immediately.
+					break out
 				}
-				err = wsc.send(mresp)
+				wsc.authenticated = true
+				wsc.tier = tier
+				s.addNotificationClient(wsc)
+				err := wsc.respond(&req, notification, nil, nil)
 				if err != nil {
 					break out
 				}
@@ -439,53 +682,130 @@ out:
 				break out
 			}
 
+			if !s.allowClientRequest(wsc.remoteAddr) {
+				err := wsc.respond(&req, notification, nil,
+					&ErrClientRateLimited)
+				if err != nil {
+					break out
+				}
+				continue
+			}
+
+			jsonErr = s.checkRequest(
+				wsc.tier, req.Method, wsc.remoteAddr,
+			)
+			if jsonErr != nil {
+				s.audit(wsc.remoteAddr, wsc.tier, "", req.Method,
+					jsonErr)
+				err := wsc.respond(&req, notification, nil, jsonErr)
+				if err != nil {
+					break out
+				}
+				continue
+			}
+
 			switch req.Method {
 			case "stop":
-				resp := makeResponse(req.ID,
+				err := wsc.respond(&req, notification,
 					"btcwallet stopping.", nil)
-				mresp, err := json.Marshal(resp)
-				// Expected to never fail.
-				if err != nil {
-					panic(err)
-				}
-				err = wsc.send(mresp)
 				if err != nil {
 					break out
 				}
 				s.requestProcessShutdown()
 				break out
 
+			case "notifytxconfirmations":
+				resp, err := s.notifyTxConfirmations(wsc, &req)
+				err = wsc.respond(&req, notification, resp,
+					jsonError(err))
+				if err != nil {
+					break out
+				}
+
+				// Notify the client right away if the
+				// transaction is already at the requested
+				// depth.
+				s.handlerMu.Lock()
+				w := s.wallet
+				s.handlerMu.Unlock()
+				if w != nil {
+					s.checkConfirmations(wsc, w)
+				}
+
+			case "subscribenotifications", "unsubscribenotifications":
+				resp, err := s.updateNtfnSubscriptions(wsc, &req)
+				err = wsc.respond(&req, notification, resp,
+					jsonError(err))
+				if err != nil {
+					break out
+				}
+
 			default:
+				// The handler is dispatched before starting the
+				// goroutine waiting for its result, so that
+				// requests are handled in the order received.
 				req := req // Copy for the closure
-				f := s.handlerClosure(&req)
+				f := s.handlerClosure(&req, "", wsc.tier)
+				result := s.dispatch(&req, "", f)
 				wsc.wg.Add(1)
 				go func() {
-					resp, jsonErr := f()
-					mresp, err := btcjson.MarshalResponse(
-						btcjson.RpcVersion1, req.ID,
-						resp, jsonErr,
-					)
-					if err != nil {
-						log.Errorf("Unable to marshal "+
-							"response: %v", err)
-					} else {
-						_ = wsc.send(mresp)
+					r := <-result
+					resp, jsonErr := r.result, r.jsonErr
+					s.audit(wsc.remoteAddr, wsc.tier, "",
+						req.Method, jsonErr)
+					if jsonErr == nil {
+						resp, jsonErr = formatResult(
+							wsc.format, req.Method,
+							resp,
+						)
 					}
+					_ = wsc.respond(&req, notification,
+						resp, jsonErr)
 					wsc.wg.Done()
 				}()
 			}
 
+		case <-s.draining:
+			break out
+
 		case <-s.quit:
 			break out
 		}
 	}
 
 	// allow client to disconnect after all handler goroutines are done
+	s.removeNotificationClient(wsc)
 	wsc.wg.Wait()
+	wsc.removeConfSubscriptions()
+
+	// Clients still connected when the server shuts down are told so
+	// after the replies to their requests.
+	select {
+	case <-s.draining:
+		if wsc.authenticated {
+			s.notifyShutdown(wsc)
+		}
+	default:
+	}
 	close(wsc.responses)
+	s.wsClients.Done()
 	s.wg.Done()
 }
 
+// notifyShutdown sends the btcwallet:shutdown notification to a websocket
+// client.  It is sent regardless of the notifications the client subscribed
+// to.
+func (s *Server) notifyShutdown(wsc *websocketClient) {
+	ntfn := walletjson.NewShutdownNtfn()
+	b, err := btcjson.MarshalCmd(btcjson.RpcVersion1, nil, ntfn)
+	if err != nil {
+		log.Errorf("Unable to marshal %s notification: %v",
+			walletjson.ShutdownNtfnMethod, err)
+		return
+	}
+	_ = wsc.send(b)
+}
+
 func (s *Server) websocketClientSend(wsc *websocketClient) {
 	const deadline time.Duration = 2 * time.Second
 out:
@@ -513,6 +833,17 @@ out:
 			break out
 		}
 	}
+
+	// Connections are closed by the server when it shuts down.
+	select {
+	case <-s.draining:
+		msg := websocket.FormatCloseMessage(websocket.CloseGoingAway,
+			"btcwallet shutting down")
+		_ = wsc.conn.WriteControl(websocket.CloseMessage, msg,
+			time.Now().Add(deadline))
+		wsc.conn.Close()
+	default:
+	}
 	close(wsc.quit)
 	log.Infof("Disconnected websocket client %s", wsc.remoteAddr)
 	s.wg.Done()
@@ -529,6 +860,18 @@ func (s *Server) websocketClientRPC(wsc *websocketClient) {
 		log.Warnf("Cannot remove read deadline: %v", err)
 	}
 
+	// Clients connecting while the server shuts down are not served.
+	s.wsMtx.Lock()
+	select {
+	case <-s.draining:
+		s.wsMtx.Unlock()
+		wsc.conn.Close()
+		return
+	default:
+	}
+	s.wsClients.Add(1)
+	s.wsMtx.Unlock()
+
 	// WebsocketClientRead is intentionally not run with the waitgroup
 	// so it is ignored during shutdown.  This is to prevent a hang during
 	// shutdown where the goroutine is blocked on a read of the
@@ -546,8 +889,25 @@ func (s *Server) websocketClientRPC(wsc *websocketClient) {
 // that may be read from a client.  This is currently limited to 4MB.
 const maxRequestSize = 1024 * 1024 * 4
 
-// postClientRPC processes and replies to a JSON-RPC client request.
-func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
+// postClientRPC processes and replies to a JSON-RPC client request.  Requests of
+// clients authenticated with the limited or public tier credentials are
+// restricted to the methods of the tier, and methods disabled by the
+// configuration are refused for every client.  Results are formatted as requested by
+// the query parameters of the request URL.
+func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request, tier authTier) {
+	format, err := parseResultFormat(r.URL.Query(), s.displayUnit)
+	if err != nil {
+		http.Error(w, "400 Bad Request: "+err.Error(),
+			http.StatusBadRequest)
+		return
+	}
+	walletName, ok := requestWalletName(r.URL.Path)
+	if !ok {
+		http.Error(w, "404 Not Found: invalid wallet name",
+			http.StatusNotFound)
+		return
+	}
+
 	body := http.MaxBytesReader(w, r.Body, maxRequestSize)
 	rpcRequest, err := ioutil.ReadAll(body)
 	if err != nil {
@@ -561,13 +921,10 @@ func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
 	// If unfound, the request is sent to the chain server for further
 	// processing.  While checking the methods, disallow authenticate
 	// requests, as they are invalid for HTTP POST clients.
-	var req btcjson.Request
-	err = json.Unmarshal(rpcRequest, &req)
-	if err != nil {
-		resp, err := btcjson.MarshalResponse(
-			btcjson.RpcVersion1, req.ID, nil,
-			btcjson.ErrRPCInvalidRequest,
-		)
+	req, notification, jsonErr := parseRequest(rpcRequest)
+	zero.Bytes(rpcRequest)
+	if jsonErr != nil {
+		resp, err := marshalResponse(&req, nil, jsonErr)
 		if err != nil {
 			log.Errorf("Unable to marshal response: %v", err)
 			http.Error(w, "500 Internal Server Error",
@@ -585,31 +942,43 @@ func (s *Server) postClientRPC(w http.ResponseWriter, r *http.Request) {
 	// Create the response and error from the request.  Two special cases
 	// are handled for the authenticate and stop request methods.
 	var res interface{}
-	var jsonErr *btcjson.RPCError
 	var stop bool
-	switch req.Method {
-	case "authenticate":
+	if req.Method == "authenticate" {
 		// Drop it.
 		return
-	case "stop":
+	}
+	jsonErr = s.checkRequest(tier, req.Method, r.RemoteAddr)
+	switch {
+	case jsonErr != nil:
+		// The method is refused.
+	case req.Method == "stop":
 		stop = true
 		res = "btcwallet stopping"
 	default:
-		res, jsonErr = s.handlerClosure(&req)()
+		res, jsonErr = s.runHandler(
+			&req, walletName,
+			s.handlerClosure(&req, walletName, tier),
+		)
 	}
-
-	// Marshal and send.
-	mresp, err := btcjson.MarshalResponse(
-		btcjson.RpcVersion1, req.ID, res, jsonErr,
-	)
-	if err != nil {
-		log.Errorf("Unable to marshal response: %v", err)
-		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
-		return
+	s.audit(r.RemoteAddr, tier, walletName, req.Method, jsonErr)
+	if jsonErr == nil {
+		res, jsonErr = formatResult(format, req.Method, res)
 	}
-	_, err = w.Write(mresp)
-	if err != nil {
-		log.Warnf("Unable to respond to client: %v", err)
+
+	// Marshal and send.  JSON-RPC 2.0 notifications are not replied to.
+	if notification {
+		w.WriteHeader(http.StatusNoContent)
+	} else {
+		mresp, err := marshalResponse(&req, res, jsonErr)
+		if err != nil {
+			log.Errorf("Unable to marshal response: %v", err)
+			http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		_, err = w.Write(mresp)
+		if err != nil {
+			log.Warnf("Unable to respond to client: %v", err)
+		}
 	}
 
 	if stop {
@@ -0,0 +1,113 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/websocket"
+)
+
+// TestStopDrainsWebsocketClients ensures that stopping the server waits for
+// the requests being handled to be replied to, then notifies websocket
+// clients of the shutdown and disconnects them.
+func TestStopDrainsWebsocketClients(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The debuglevel handler blocks until released, standing in for any
+	// request taking a while to handle.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s := NewServer(&Options{
+		Username:            "user",
+		Password:            "pass",
+		MaxWebsocketClients: 1,
+		SetLogLevels: func(string) error {
+			close(started)
+			<-release
+			return nil
+		},
+	}, nil, []net.Listener{lis})
+
+	url := "ws://" + lis.Addr().String() + "/ws"
+	header := http.Header{
+		"Authorization": {string(httpBasicAuth("user", "pass"))},
+	}
+	conn, _, err := (&websocket.Dialer{}).Dial(url, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	req := `{"jsonrpc":"1.0","id":1,"method":"debuglevel","params":["info"]}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("server stopped before the request was handled")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	// The reply to the request is followed by the shutdown notification.
+	var resp btcjson.Response
+	readJSON(t, conn, &resp)
+	if resp.Error != nil || string(resp.Result) != `"Done."` {
+		t.Fatalf("got response %s, error %v", resp.Result, resp.Error)
+	}
+	var ntfn btcjson.Request
+	readJSON(t, conn, &ntfn)
+	if ntfn.Method != walletjson.ShutdownNtfnMethod {
+		t.Fatalf("got notification %q, want %q", ntfn.Method,
+			walletjson.ShutdownNtfnMethod)
+	}
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Fatal("connection not closed after the shutdown notification")
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("server did not stop")
+	}
+
+	// New clients are refused.
+	if _, _, err := (&websocket.Dialer{}).Dial(url, header); err == nil {
+		t.Fatal("connected to stopped server")
+	}
+}
+
+// readJSON reads a websocket message and decodes it into v.
+func readJSON(t *testing.T, conn *websocket.Conn, v interface{}) {
+	t.Helper()
+
+	if err := conn.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(msg, v); err != nil {
+		t.Fatalf("unable to decode %s: %v", msg, err)
+	}
+}
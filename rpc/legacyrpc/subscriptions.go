@@ -0,0 +1,160 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+)
+
+// subscribableNtfns is the set of notification methods websocket clients may
+// subscribe to.  Notifications of transaction confirmations are always sent,
+// since clients subscribe to each transaction with notifytxconfirmations.
+var subscribableNtfns = map[string]struct{}{
+	btcjson.AccountBalanceNtfnMethod:       {},
+	walletjson.AccountBalancesNtfnMethod:   {},
+	walletjson.BackupFailedNtfnMethod:      {},
+	walletjson.BlockConnectedNtfnMethod:    {},
+	walletjson.BlockDisconnectedNtfnMethod: {},
+	walletjson.ChainHealthNtfnMethod:       {},
+	walletjson.LockStateNtfnMethod:         {},
+	walletjson.NewTxNtfnMethod:             {},
+	walletjson.RescanProgressNtfnMethod:    {},
+	walletjson.TxConflictNtfnMethod:        {},
+	walletjson.TxStuckNtfnMethod:           {},
+}
+
+// ntfnSubscription is the subscription of a websocket client to the
+// notifications of a method.
+type ntfnSubscription struct {
+	// allAccounts is set when the client subscribed to the notifications
+	// of every account.  Otherwise, only notifications of the accounts
+	// are sent.
+	allAccounts bool
+	accounts    map[string]struct{}
+}
+
+// subscribed returns whether the client is subscribed to a notification of
+// the method, which is specific to an account if account is non-nil.  Clients
+// which never subscribed to notifications receive every notification.
+func (c *websocketClient) subscribed(method string, account *string) bool {
+	c.ntfnSubsMtx.Lock()
+	defer c.ntfnSubsMtx.Unlock()
+
+	if c.ntfnSubs == nil {
+		return true
+	}
+	sub, ok := c.ntfnSubs[method]
+	if !ok {
+		return false
+	}
+	if sub.allAccounts || account == nil {
+		return true
+	}
+	_, ok = sub.accounts[*account]
+	return ok
+}
+
+// subscribe subscribes the client to the notifications of the methods, either
+// of every account or only of the account when it is non-nil.
+func (c *websocketClient) subscribe(methods []string, account *string) {
+	c.ntfnSubsMtx.Lock()
+	defer c.ntfnSubsMtx.Unlock()
+
+	if c.ntfnSubs == nil {
+		c.ntfnSubs = make(map[string]*ntfnSubscription)
+	}
+	for _, method := range methods {
+		sub, ok := c.ntfnSubs[method]
+		if !ok {
+			sub = &ntfnSubscription{
+				accounts: make(map[string]struct{}),
+			}
+			c.ntfnSubs[method] = sub
+		}
+		if account == nil {
+			sub.allAccounts = true
+		} else {
+			sub.accounts[*account] = struct{}{}
+		}
+	}
+}
+
+// unsubscribe removes the subscriptions of the client to the notifications of
+// the methods.  When account is non-nil, only the subscription to the
+// notifications of the account is removed, and a subscription to the
+// notifications of every account is left unchanged.
+func (c *websocketClient) unsubscribe(methods []string, account *string) {
+	c.ntfnSubsMtx.Lock()
+	defer c.ntfnSubsMtx.Unlock()
+
+	if c.ntfnSubs == nil {
+		c.ntfnSubs = make(map[string]*ntfnSubscription)
+	}
+	for _, method := range methods {
+		sub, ok := c.ntfnSubs[method]
+		if !ok {
+			continue
+		}
+		if account == nil {
+			delete(c.ntfnSubs, method)
+			continue
+		}
+		delete(sub.accounts, *account)
+		if !sub.allAccounts && len(sub.accounts) == 0 {
+			delete(c.ntfnSubs, method)
+		}
+	}
+}
+
+// checkNtfnMethods returns an error if any of the methods may not be
+// subscribed to.
+func checkNtfnMethods(methods []string) error {
+	if len(methods) == 0 {
+		return InvalidParameterError{
+			errors.New("no notifications specified"),
+		}
+	}
+	for _, method := range methods {
+		if _, ok := subscribableNtfns[method]; !ok {
+			return InvalidParameterError{
+				fmt.Errorf("unknown notification %q", method),
+			}
+		}
+	}
+	return nil
+}
+
+// updateNtfnSubscriptions handles a subscribenotifications or
+// unsubscribenotifications request of a websocket client by updating the
+// notifications sent to the client.  Until a client first subscribes to
+// notifications, it receives every notification.
+func (s *Server) updateNtfnSubscriptions(wsc *websocketClient,
+	req *btcjson.Request) (interface{}, error) {
+
+	icmd, err := btcjson.UnmarshalCmd(req)
+	if err != nil {
+		return nil, btcjson.ErrRPCInvalidRequest
+	}
+
+	switch cmd := icmd.(type) {
+	case *walletjson.SubscribeNotificationsCmd:
+		if err := checkNtfnMethods(cmd.Notifications); err != nil {
+			return nil, err
+		}
+		wsc.subscribe(cmd.Notifications, cmd.Account)
+
+	case *walletjson.UnsubscribeNotificationsCmd:
+		if err := checkNtfnMethods(cmd.Notifications); err != nil {
+			return nil, err
+		}
+		wsc.unsubscribe(cmd.Notifications, cmd.Account)
+	}
+
+	return nil, nil
+}
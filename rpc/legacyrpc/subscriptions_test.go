@@ -0,0 +1,100 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+)
+
+// TestNtfnSubscriptions ensures that websocket clients only receive the
+// notifications they subscribed to once they first subscribe.
+func TestNtfnSubscriptions(t *testing.T) {
+	const (
+		newTx   = walletjson.NewTxNtfnMethod
+		block   = walletjson.BlockConnectedNtfnMethod
+		account = "default"
+		other   = "other"
+	)
+	acct := func(name string) *string { return &name }
+
+	type check struct {
+		method   string
+		account  *string
+		expected bool
+	}
+	steps := []struct {
+		name   string
+		update func(wsc *websocketClient)
+		checks []check
+	}{
+		{
+			name:   "never subscribed",
+			update: func(*websocketClient) {},
+			checks: []check{
+				{newTx, acct(account), true},
+				{block, nil, true},
+			},
+		},
+		{
+			name: "subscribe to account",
+			update: func(wsc *websocketClient) {
+				wsc.subscribe([]string{newTx}, acct(account))
+			},
+			checks: []check{
+				{newTx, acct(account), true},
+				{newTx, acct(other), false},
+				{block, nil, false},
+			},
+		},
+		{
+			name: "subscribe to every account",
+			update: func(wsc *websocketClient) {
+				wsc.subscribe([]string{newTx, block}, nil)
+			},
+			checks: []check{
+				{newTx, acct(other), true},
+				{block, nil, true},
+			},
+		},
+		{
+			name: "unsubscribe account",
+			update: func(wsc *websocketClient) {
+				wsc.unsubscribe([]string{newTx}, acct(account))
+			},
+			checks: []check{
+				{newTx, acct(account), true},
+				{newTx, acct(other), true},
+			},
+		},
+		{
+			name: "unsubscribe",
+			update: func(wsc *websocketClient) {
+				wsc.unsubscribe([]string{newTx, block}, nil)
+			},
+			checks: []check{
+				{newTx, acct(account), false},
+				{block, nil, false},
+			},
+		},
+	}
+
+	wsc := newWebsocketClient(nil, true, fullTier, "", nil)
+	for _, step := range steps {
+		step.update(wsc)
+		for _, c := range step.checks {
+			got := wsc.subscribed(c.method, c.account)
+			if got != c.expected {
+				t.Errorf("%s: expected subscribed(%s) %v, got %v",
+					step.name, c.method, c.expected, got)
+			}
+		}
+	}
+
+	if err := checkNtfnMethods([]string{"btcwallet:unknown"}); err == nil {
+		t.Error("expected error subscribing to unknown notification")
+	}
+}
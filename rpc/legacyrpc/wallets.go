@@ -0,0 +1,369 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// walletPathPrefix is the prefix of the URL paths of HTTP POST requests made
+// to a wallet loaded at runtime, which is followed by the name of the wallet.
+const walletPathPrefix = "/wallet/"
+
+// WalletManager loads and unloads wallets at runtime, which are served in
+// addition to the wallet registered with the server.  Each wallet is
+// identified by the name it was loaded with.
+//
+// Wallets are bound to a network, which need not be the network of the wallet
+// registered with the server.  The network is named by the name of its chain
+// parameters, or is empty for the network of the server.  Managers return
+// ErrUnknownNetwork for networks they can not synchronize wallets with.
+type WalletManager interface {
+	// LoadWallet opens the wallet of the name on the network and begins
+	// synchronizing it with the chain.
+	LoadWallet(name, network string) (*wallet.Wallet, error)
+
+	// CreateWallet creates the wallet of the name on the network and
+	// begins synchronizing it with the chain.  The private passphrase
+	// protects the private keys of the wallet, and must be empty for
+	// watching-only wallets.  Blank wallets hold no keys until they are
+	// imported.
+	CreateWallet(name, network string, privPassphrase []byte,
+		watchingOnly, blank bool) (*wallet.Wallet, error)
+
+	// UnloadWallet stops synchronizing the wallet of the name, tearing
+	// down its registrations with the chain server, and closes it.  It
+	// returns wallet.ErrNotLoaded when no wallet of the name is loaded.
+	UnloadWallet(name string) error
+
+	// Wallet returns the loaded wallet of the name, if any.
+	Wallet(name string) (*wallet.Wallet, bool)
+
+	// WalletNames returns the names of the loaded wallets in sorted order.
+	WalletNames() []string
+}
+
+// ValidateWalletName returns an InvalidParameterError unless a wallet name is
+// a single path element, which names the directory of the wallet database
+// within the network directory.  Names which are empty, absolute, contain a
+// path separator, or refer to the current or parent directory are rejected,
+// so that wallets can't be loaded from or created in arbitrary directories.
+func ValidateWalletName(name string) error {
+	if name == "" {
+		return InvalidParameterError{
+			errors.New("wallet name must not be empty"),
+		}
+	}
+	if filepath.Base(name) != name || name == "." || name == ".." ||
+		strings.ContainsAny(name, `/\`) {
+
+		return InvalidParameterError{
+			fmt.Errorf("invalid wallet name %q: wallet names must "+
+				"not contain path separators or refer to the "+
+				"current or parent directory", name),
+		}
+	}
+	return nil
+}
+
+// managementHandler is a handler function of a request which manages the
+// loaded wallets rather than operating on a single wallet.  The wallet name is
+// the name of the wallet of the request URL, or the empty string for the
+// wallet registered with the server.
+type managementHandler func(s *Server, icmd interface{},
+	walletName string) (interface{}, error)
+
+// managementHandlers maps the methods which are handled by the server itself
+// to their handlers.  These methods are listed in rpcHandlers as well, so that
+// help is generated for them.
+var managementHandlers = map[string]managementHandler{
+	"createwallet": createWallet,
+	"debuglevel":   debugLevel,
+	"listwallets":  listWallets,
+	"loadwallet":   loadWallet,
+	"unloadwallet": unloadWallet,
+}
+
+// managementOnly handles a request of a method which is handled by the server
+// itself.  It is never called, as requests of these methods are dispatched to
+// the managementHandlers instead.
+func managementOnly(interface{}, *wallet.Wallet) (interface{}, error) {
+	return nil, &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInternal.Code,
+		Message: "Request must be handled by the server",
+	}
+}
+
+// SetWalletManager enables the loadwallet and unloadwallet methods, and the
+// serving of the wallets loaded by the manager.
+func (s *Server) SetWalletManager(m WalletManager) {
+	s.handlerMu.Lock()
+	s.walletManager = m
+	s.handlerMu.Unlock()
+}
+
+// requestWalletName returns the name of the wallet a request made to the URL
+// path is for, and whether the path names a valid wallet.  Requests made to
+// paths other than those of loaded wallets are for the wallet registered with
+// the server, which is named by the empty string.
+func requestWalletName(path string) (string, bool) {
+	if !strings.HasPrefix(path, walletPathPrefix) {
+		return "", true
+	}
+	name := path[len(walletPathPrefix):]
+	return name, ValidateWalletName(name) == nil
+}
+
+// walletPathHandler serves requests made to the URL paths of loaded wallets
+// with the handler of the root path of the mux, which rejects the paths which
+// do not name a valid wallet.  The paths must not be cleaned or redirected by
+// the mux, as this would serve requests to invalid wallet names to another
+// wallet.
+func walletPathHandler(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, walletPathPrefix) {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		rootReq := *r
+		rootReq.URL = &url.URL{Path: "/"}
+		h, _ := mux.Handler(&rootReq)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// managementHandlerClosure returns a closure calling the handler of a request
+// of a wallet management method.
+func (s *Server) managementHandlerClosure(request *btcjson.Request,
+	handler managementHandler, walletName string) lazyHandler {
+
+	return func() (interface{}, *btcjson.RPCError) {
+		cmd, err := unmarshalCmd(request, s.amountUnit)
+		if err != nil {
+			return nil, btcjson.ErrRPCInvalidRequest
+		}
+		resp, err := handler(s, cmd, walletName)
+		if err != nil {
+			return nil, jsonError(err)
+		}
+		return resp, nil
+	}
+}
+
+// loadedWalletHandlerClosure returns a closure handling a request for the
+// wallet loaded with the name, using the chain client of that wallet.
+func (s *Server) loadedWalletHandlerClosure(request *btcjson.Request,
+	walletName string, tier authTier) lazyHandler {
+
+	s.handlerMu.Lock()
+	m := s.walletManager
+	s.handlerMu.Unlock()
+
+	var (
+		w  *wallet.Wallet
+		ok bool
+	)
+	if m != nil {
+		w, ok = m.Wallet(walletName)
+	}
+	if !ok {
+		return func() (interface{}, *btcjson.RPCError) {
+			return nil, &ErrWalletNotFound
+		}
+	}
+	return lazyApplyHandler(request, w, w.ChainClient(), s.amountUnit,
+		tier)
+}
+
+// listWallets handles a listwallets request by returning the names of the
+// loaded wallets, beginning with the empty name of the wallet registered with
+// the server.
+func listWallets(s *Server, icmd interface{}, _ string) (interface{}, error) {
+	s.handlerMu.Lock()
+	registered := s.wallet != nil
+	m := s.walletManager
+	s.handlerMu.Unlock()
+
+	names := []string{}
+	if registered {
+		names = append(names, "")
+	}
+	if m != nil {
+		names = append(names, m.WalletNames()...)
+	}
+	return names, nil
+}
+
+// walletOptionsParams maps the reference wallet loading methods which accept a
+// trailing walletjson.WalletOptions object to the number of parameters of the
+// reference command.
+var walletOptionsParams = map[string]int{
+	"createwallet": 5,
+	"loadwallet":   1,
+}
+
+// walletCmd is a parsed reference wallet loading command along with the
+// btcwallet extension options provided with it.
+type walletCmd struct {
+	cmd  interface{}
+	opts walletjson.WalletOptions
+}
+
+// unmarshalWalletCmd unmarshals a request of a method of walletOptionsParams,
+// which accepts a trailing options object following the numParams reference
+// parameters.
+func unmarshalWalletCmd(request *btcjson.Request, numParams int) (*walletCmd, error) {
+	wcmd := new(walletCmd)
+	if len(request.Params) > numParams {
+		if len(request.Params) != numParams+1 {
+			return nil, errors.New("too many parameters")
+		}
+		err := json.Unmarshal(request.Params[numParams], &wcmd.opts)
+		if err != nil {
+			return nil, err
+		}
+		r := *request
+		r.Params = request.Params[:numParams]
+		request = &r
+	}
+	cmd, err := btcjson.UnmarshalCmd(request)
+	if err != nil {
+		return nil, err
+	}
+	wcmd.cmd = cmd
+	return wcmd, nil
+}
+
+// network returns the network named by the options, or the empty string for
+// the network of the server.
+func (c *walletCmd) network() string {
+	if c.opts.Network == nil {
+		return ""
+	}
+	return *c.opts.Network
+}
+
+// loadWallet handles a loadwallet request by loading the wallet of the name
+// on the network of the options with the wallet manager.
+func loadWallet(s *Server, icmd interface{}, _ string) (interface{}, error) {
+	wcmd := icmd.(*walletCmd)
+	cmd := wcmd.cmd.(*btcjson.LoadWalletCmd)
+
+	s.handlerMu.Lock()
+	m := s.walletManager
+	s.handlerMu.Unlock()
+	if m == nil {
+		return nil, &ErrWalletLoadingDisabled
+	}
+
+	if err := ValidateWalletName(cmd.WalletName); err != nil {
+		return nil, err
+	}
+	if _, err := m.LoadWallet(cmd.WalletName, wcmd.network()); err != nil {
+		return nil, err
+	}
+	return &btcjson.LoadWalletResult{Name: cmd.WalletName}, nil
+}
+
+// createWallet handles a createwallet request by creating the wallet of the
+// name on the network of the options with the wallet manager.  The avoid_reuse
+// flag is set on the accounts of the new wallet which hold private keys.
+func createWallet(s *Server, icmd interface{}, _ string) (interface{}, error) {
+	wcmd := icmd.(*walletCmd)
+	cmd := wcmd.cmd.(*btcjson.CreateWalletCmd)
+
+	s.handlerMu.Lock()
+	m := s.walletManager
+	s.handlerMu.Unlock()
+	if m == nil {
+		return nil, &ErrWalletLoadingDisabled
+	}
+
+	if err := ValidateWalletName(cmd.WalletName); err != nil {
+		return nil, err
+	}
+	watchingOnly := *cmd.DisablePrivateKeys
+	switch {
+	case watchingOnly && *cmd.Passphrase != "":
+		return nil, InvalidParameterError{
+			errors.New("a passphrase only protects private keys, " +
+				"which are disabled"),
+		}
+	case !watchingOnly && *cmd.Passphrase == "":
+		return nil, InvalidParameterError{
+			errors.New("a passphrase is required to protect the " +
+				"private keys of the wallet"),
+		}
+	}
+
+	w, err := m.CreateWallet(
+		cmd.WalletName, wcmd.network(), []byte(*cmd.Passphrase),
+		watchingOnly, *cmd.Blank,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &btcjson.CreateWalletResult{Name: cmd.WalletName}
+	if *cmd.AvoidReuse {
+		if watchingOnly {
+			result.Warning = "avoid_reuse was not set, as the " +
+				"wallet holds no private keys"
+			return result, nil
+		}
+		accounts := []uint32{waddrmgr.ImportedAddrAccount}
+		if !*cmd.Blank {
+			accounts = append(accounts, waddrmgr.DefaultAccountNum)
+		}
+		for _, account := range accounts {
+			err := w.SetAccountAvoidReuse(
+				waddrmgr.KeyScopeBIP0044, account, true,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// unloadWallet handles an unloadwallet request by unloading the wallet of the
+// name, or of the request URL when no name is given, with the wallet manager.
+func unloadWallet(s *Server, icmd interface{}, walletName string) (interface{}, error) {
+	cmd := icmd.(*btcjson.UnloadWalletCmd)
+
+	s.handlerMu.Lock()
+	m := s.walletManager
+	s.handlerMu.Unlock()
+	if m == nil {
+		return nil, &ErrWalletLoadingDisabled
+	}
+
+	if cmd.WalletName != nil {
+		walletName = *cmd.WalletName
+	}
+	if walletName == "" {
+		return nil, &ErrDefaultWalletUnload
+	}
+	switch err := m.UnloadWallet(walletName); err {
+	case nil:
+		return nil, nil
+	case wallet.ErrNotLoaded:
+		return nil, &ErrWalletNotFound
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package legacyrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// testWalletManager is a WalletManager loading the wallets of a fixed set on
+// the network of the server or on testnet3.
+type testWalletManager struct {
+	available map[string]*wallet.Wallet
+	loaded    map[string]*wallet.Wallet
+	networks  map[string]string
+}
+
+func (m *testWalletManager) LoadWallet(name, network string) (*wallet.Wallet, error) {
+	if network != "" && network != "testnet3" {
+		return nil, ErrUnknownNetwork
+	}
+	if _, ok := m.loaded[name]; ok {
+		return nil, wallet.ErrLoaded
+	}
+	w, ok := m.available[name]
+	if !ok {
+		return nil, fmt.Errorf("no wallet %q", name)
+	}
+	m.loaded[name] = w
+	m.networks[name] = network
+	return w, nil
+}
+
+func (m *testWalletManager) CreateWallet(name, network string,
+	privPassphrase []byte, watchingOnly, blank bool) (*wallet.Wallet, error) {
+
+	return nil, fmt.Errorf("wallet %q can not be created", name)
+}
+
+func (m *testWalletManager) UnloadWallet(name string) error {
+	if _, ok := m.loaded[name]; !ok {
+		return wallet.ErrNotLoaded
+	}
+	delete(m.loaded, name)
+	return nil
+}
+
+func (m *testWalletManager) Wallet(name string) (*wallet.Wallet, bool) {
+	w, ok := m.loaded[name]
+	return w, ok
+}
+
+func (m *testWalletManager) WalletNames() []string {
+	names := make([]string, 0, len(m.loaded))
+	for name := range m.loaded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TestLoadedWallets ensures that wallets loaded at runtime are listed, served
+// at their URL paths, and no longer served once unloaded.
+func TestLoadedWallets(t *testing.T) {
+	w, cleanup := goldenWallet(t)
+	defer cleanup()
+
+	srv := NewServer(&Options{}, nil, nil)
+	srv.RegisterWallet(w)
+	m := &testWalletManager{
+		available: map[string]*wallet.Wallet{"other": w},
+		loaded:    make(map[string]*wallet.Wallet),
+		networks:  make(map[string]string),
+	}
+	srv.SetWalletManager(m)
+
+	request := func(path, method, params string) (json.RawMessage,
+		*btcjson.RPCError) {
+
+		body := fmt.Sprintf(`{"jsonrpc":"1.0","id":1,"method":%q,`+
+			`"params":%s}`, method, params)
+		r := httptest.NewRequest("POST", path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		srv.postClientRPC(rec, r, fullTier)
+
+		var resp struct {
+			Result json.RawMessage   `json:"result"`
+			Error  *btcjson.RPCError `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: invalid response %q: %v", method,
+				rec.Body.String(), err)
+		}
+		return resp.Result, resp.Error
+	}
+	listWallets := func() []string {
+		result, jsonErr := request("/", "listwallets", `[]`)
+		if jsonErr != nil {
+			t.Fatalf("listwallets: %v", jsonErr)
+		}
+		var names []string
+		if err := json.Unmarshal(result, &names); err != nil {
+			t.Fatalf("listwallets: %v", err)
+		}
+		return names
+	}
+
+	if names := listWallets(); !reflect.DeepEqual(names, []string{""}) {
+		t.Fatalf("listwallets: want [\"\"], got %q", names)
+	}
+	_, jsonErr := request("/wallet/other", "walletislocked", `[]`)
+	if jsonErr == nil || jsonErr.Code != btcjson.ErrRPCWalletNotFound {
+		t.Fatalf("request to unloaded wallet: want wallet not found "+
+			"error, got %v", jsonErr)
+	}
+
+	// Wallet names must be a single path element, so wallets can't be
+	// loaded from directories outside of the network directory.
+	for _, name := range []string{".", "..", "../other", "/tmp/other", "a/b"} {
+		params := fmt.Sprintf(`[%q]`, name)
+		_, jsonErr = request("/", "loadwallet", params)
+		if jsonErr == nil || jsonErr.Code != btcjson.ErrRPCInvalidParameter {
+			t.Fatalf("loadwallet %s: want invalid parameter "+
+				"error, got %v", params, jsonErr)
+		}
+	}
+	r := httptest.NewRequest("POST", "/wallet/../other",
+		strings.NewReader(`{"jsonrpc":"1.0","id":1,"method":"walletislocked","params":[]}`))
+	rec := httptest.NewRecorder()
+	srv.postClientRPC(rec, r, fullTier)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("request to invalid wallet name: want status %d, "+
+			"got %d", http.StatusNotFound, rec.Code)
+	}
+
+	if _, jsonErr := request("/", "loadwallet", `["other"]`); jsonErr != nil {
+		t.Fatalf("loadwallet: %v", jsonErr)
+	}
+	if _, jsonErr := request("/", "loadwallet", `["other"]`); jsonErr == nil {
+		t.Fatal("loadwallet: loaded wallet loaded again")
+	}
+	want := []string{"", "other"}
+	if names := listWallets(); !reflect.DeepEqual(names, want) {
+		t.Fatalf("listwallets: want %q, got %q", want, names)
+	}
+	result, jsonErr := request("/wallet/other", "walletislocked", `[]`)
+	if jsonErr != nil || string(result) != "true" {
+		t.Fatalf("request to loaded wallet: got %s, %v", result,
+			jsonErr)
+	}
+
+	// The wallet of the request URL is unloaded when no name is given,
+	// but the wallet registered with the server can not be unloaded.
+	_, jsonErr = request("/", "unloadwallet", `[]`)
+	if jsonErr == nil || jsonErr.Message != ErrDefaultWalletUnload.Message {
+		t.Fatalf("unloadwallet: want %v, got %v",
+			&ErrDefaultWalletUnload, jsonErr)
+	}
+	if _, jsonErr := request("/wallet/other", "unloadwallet", `[]`); jsonErr != nil {
+		t.Fatalf("unloadwallet: %v", jsonErr)
+	}
+	_, jsonErr = request("/", "unloadwallet", `["other"]`)
+	if jsonErr == nil || jsonErr.Code != btcjson.ErrRPCWalletNotFound {
+		t.Fatalf("unloadwallet of unloaded wallet: want wallet not "+
+			"found error, got %v", jsonErr)
+	}
+	if names := listWallets(); !reflect.DeepEqual(names, []string{""}) {
+		t.Fatalf("listwallets: want [\"\"], got %q", names)
+	}
+
+	// Private keys must be protected by a passphrase, and a passphrase
+	// is rejected when private keys are disabled.  Wallets can't be
+	// created outside of the network directory.
+	for _, params := range []string{
+		`["new"]`, `["new", true, false, "private"]`,
+		`["../new", false, false, "private"]`, `["/tmp/new", true]`,
+	} {
+		_, jsonErr = request("/", "createwallet", params)
+		if jsonErr == nil || jsonErr.Code != btcjson.ErrRPCInvalidParameter {
+			t.Fatalf("createwallet %s: want invalid parameter "+
+				"error, got %v", params, jsonErr)
+		}
+	}
+
+	// Wallets may be loaded on a network other than the network of the
+	// server with a trailing options object.
+	_, jsonErr = request("/", "loadwallet", `["other", {"network": "litecoin"}]`)
+	if jsonErr == nil || jsonErr.Code != btcjson.ErrRPCInvalidParameter {
+		t.Fatalf("loadwallet on unknown network: want invalid "+
+			"parameter error, got %v", jsonErr)
+	}
+	_, jsonErr = request("/", "loadwallet", `["other", {"network": "testnet3"}]`)
+	if jsonErr != nil {
+		t.Fatalf("loadwallet on testnet3: %v", jsonErr)
+	}
+	if network := m.networks["other"]; network != "testnet3" {
+		t.Fatalf("loadwallet: want wallet loaded on testnet3, got %q",
+			network)
+	}
+}
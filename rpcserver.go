@@ -5,7 +5,10 @@
 package main
 
 import (
+	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -17,6 +20,7 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/auditlog"
 	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
 	"github.com/btcsuite/btcwallet/rpc/rpcserver"
 	"github.com/btcsuite/btcwallet/wallet"
@@ -102,6 +106,97 @@ func generateRPCKeyPair(writeKey bool) (tls.Certificate, error) {
 	return keyPair, nil
 }
 
+// rpcCookieUsername is the username of the legacy RPC cookie credentials.
+const rpcCookieUsername = "__cookie__"
+
+// useRPCCookie returns whether legacy RPC clients authenticate with the
+// credentials of the cookie file rather than the configured username and
+// password.
+func useRPCCookie() bool {
+	return cfg.RPCCookie && (cfg.Username == "" || cfg.Password == "")
+}
+
+// rpcCookiePath returns the path of the legacy RPC cookie file.
+func rpcCookiePath() string {
+	return filepath.Join(networkDir(cfg.AppDataDir.Value, activeNet.Params),
+		".cookie")
+}
+
+// writeRPCCookie generates random legacy RPC credentials and writes them to
+// the cookie file as "username:password", readable only by the current user.
+// A new password is generated each time the process is started.
+func writeRPCCookie() (username, password string, err error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", "", err
+	}
+	password = hex.EncodeToString(b[:])
+
+	path := rpcCookiePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", "", err
+	}
+	cookie := []byte(rpcCookieUsername + ":" + password)
+	if err := ioutil.WriteFile(path, cookie, 0600); err != nil {
+		return "", "", err
+	}
+	return rpcCookieUsername, password, nil
+}
+
+// removeRPCCookie removes the legacy RPC cookie file, if one was written.
+func removeRPCCookie() {
+	if !useRPCCookie() {
+		return
+	}
+	err := os.Remove(rpcCookiePath())
+	if err != nil && !os.IsNotExist(err) {
+		log.Warnf("Unable to remove RPC cookie: %v", err)
+	}
+}
+
+// openAuditLog opens the legacy RPC audit log at a path, creating it and its
+// directory if they do not exist.  The records of the log are chained by MACs
+// keyed by the secret.  The records already in the log are verified, and a
+// warning is logged if any were modified or removed, but the log is still
+// opened so that requests continue to be recorded.
+func openAuditLog(path string, secret []byte) (*auditlog.Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	switch {
+	case err == nil:
+		head, err := auditlog.Verify(f, secret)
+		f.Close()
+		if err != nil {
+			log.Warnf("Audit log %s may have been tampered "+
+				"with: %v", path, err)
+		} else {
+			log.Infof("Verified audit log %s (last record %s)",
+				path, head)
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	return auditlog.Open(path, secret)
+}
+
+// loadRPCClientCAs loads the CA certificates which must have issued the
+// certificates of RPC clients from the file specified by the application
+// config.
+func loadRPCClientCAs() (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(cfg.RPCClientCA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s",
+			cfg.RPCClientCA)
+	}
+	return pool, nil
+}
+
 func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Server, error) {
 	var (
 		server       *grpc.Server
@@ -124,6 +219,15 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 			MinVersion:   tls.VersionTLS12,
 			NextProtos:   []string{"h2"}, // HTTP/2 over TLS
 		}
+		if cfg.RPCClientCA != "" {
+			tlsConfig.ClientCAs, err = loadRPCClientCAs()
+			if err != nil {
+				return nil, nil, err
+			}
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+			log.Info("RPC clients must authenticate with a " +
+				"client certificate")
+		}
 		legacyListen = func(net string, laddr string) (net.Listener, error) {
 			return tls.Listen(net, laddr, tlsConfig)
 		}
@@ -134,7 +238,7 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 				err := errors.New("failed to create listeners for RPC server")
 				return nil, nil, err
 			}
-			creds := credentials.NewServerTLSFromCert(&keyPair)
+			creds := credentials.NewTLS(tlsConfig)
 			server = grpc.NewServer(grpc.Creds(creds))
 			rpcserver.StartVersionService(server)
 			rpcserver.StartWalletLoaderService(server, walletLoader, activeNet)
@@ -151,19 +255,53 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 		}
 	}
 
-	if cfg.Username == "" || cfg.Password == "" {
-		log.Info("Legacy RPC server disabled (requires username and password)")
+	if (cfg.Username == "" || cfg.Password == "") && !useRPCCookie() {
+		log.Info("Legacy RPC server disabled (requires username and " +
+			"password, or rpccookie)")
 	} else if len(cfg.LegacyRPCListeners) != 0 {
 		listeners := makeListeners(cfg.LegacyRPCListeners, legacyListen)
 		if len(listeners) == 0 {
 			err := errors.New("failed to create listeners for legacy RPC server")
 			return nil, nil, err
 		}
+		username, password := cfg.Username, cfg.Password
+		if useRPCCookie() {
+			username, password, err = writeRPCCookie()
+			if err != nil {
+				return nil, nil, err
+			}
+			log.Infof("Legacy RPC clients authenticate with the "+
+				"cookie written to %s", rpcCookiePath())
+		}
+		var auditLog *auditlog.Log
+		if cfg.AuditLog != "" {
+			auditLog, err = openAuditLog(
+				cfg.AuditLog, []byte(cfg.AuditLogSecret),
+			)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
 		opts := legacyrpc.Options{
-			Username:            cfg.Username,
-			Password:            cfg.Password,
-			MaxPOSTClients:      cfg.LegacyRPCMaxClients,
-			MaxWebsocketClients: cfg.LegacyRPCMaxWebsockets,
+			Username:              username,
+			Password:              password,
+			MaxPOSTClients:        cfg.LegacyRPCMaxClients,
+			MaxWebsocketClients:   cfg.LegacyRPCMaxWebsockets,
+			MaxConcurrentHandlers: cfg.LegacyRPCMaxHandlers,
+			ClientRateLimit:       cfg.LegacyRPCRateLimit,
+			AmountUnit:            cfg.AmountUnit.AmountUnit,
+			DisplayUnit:           cfg.DisplayUnit.AmountUnit,
+			PublicUsername:        cfg.PublicUsername,
+			PublicPassword:        cfg.PublicPassword,
+			PublicRateLimit:       cfg.PublicRateLimit,
+			LimitedUsername:       cfg.LimitedUsername,
+			LimitedPassword:       cfg.LimitedPassword,
+			LegacyBalanceNtfns:    cfg.LegacyBalanceNtfns,
+			DisabledMethods:       cfg.DisableRPCMethods,
+			AllowedMethods:        cfg.AllowRPCMethods,
+			AuditLog:              auditLog,
+			SetLogLevels:          parseAndSetDebugLevels,
+			LogSubsystems:         supportedSubsystems(),
 		}
 		legacyServer = legacyrpc.NewServer(&opts, walletLoader, listeners)
 	}
@@ -176,6 +314,29 @@ func startRPCServers(walletLoader *wallet.Loader) (*grpc.Server, *legacyrpc.Serv
 	return server, legacyServer, nil
 }
 
+// grpcShutdownTimeout is the longest time the requests being handled by the
+// gRPC server are waited on when it is stopped, before they are cancelled.
+const grpcShutdownTimeout = 30 * time.Second
+
+// stopGRPCServer stops the gRPC server from accepting connections and requests,
+// and waits for the requests being handled to finish before closing the
+// connections.  Requests still being handled after grpcShutdownTimeout, such
+// as notification streams, are cancelled.
+func stopGRPCServer(server *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(grpcShutdownTimeout):
+		log.Warn("Cancelling gRPC requests still being handled")
+		server.Stop()
+		<-done
+	}
+}
+
 type listenFunc func(net string, laddr string) (net.Listener, error)
 
 // makeListeners splits the normalized listen addresses into IPv4 and IPv6
@@ -13,6 +13,7 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/internal/securemem"
 	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/btcsuite/btcwallet/walletdb"
 )
@@ -137,8 +138,8 @@ type managedAddress struct {
 	compressed       bool
 	addrType         AddressType
 	pubKey           *btcec.PublicKey
-	privKeyEncrypted []byte // nil if part of watch-only account
-	privKeyCT        []byte // non-nil if unlocked
+	privKeyEncrypted []byte            // nil if part of watch-only account
+	privKeyCT        *securemem.Buffer // non-nil if unlocked
 	privKeyMutex     sync.Mutex
 }
 
@@ -161,7 +162,7 @@ func (a *managedAddress) unlock(key EncryptorDecryptor) ([]byte, error) {
 		return nil, managerError(ErrWatchingOnly, errWatchingOnly, nil)
 	}
 
-	if len(a.privKeyCT) == 0 {
+	if a.privKeyCT == nil {
 		privKey, err := key.Decrypt(a.privKeyEncrypted)
 		if err != nil {
 			str := fmt.Sprintf("failed to decrypt private key for "+
@@ -169,20 +170,55 @@ func (a *managedAddress) unlock(key EncryptorDecryptor) ([]byte, error) {
 			return nil, managerError(ErrCrypto, str, err)
 		}
 
-		a.privKeyCT = privKey
+		a.privKeyCT = securemem.FromBytes(privKey)
 	}
 
-	privKeyCopy := make([]byte, len(a.privKeyCT))
-	copy(privKeyCopy, a.privKeyCT)
+	privKeyCopy := make([]byte, a.privKeyCT.Len())
+	copy(privKeyCopy, a.privKeyCT.Bytes())
 	return privKeyCopy, nil
 }
 
+// reencrypt re-encrypts the private key with the new crypto key.  The private
+// key is removed when it can not be re-encrypted, which leaves the address
+// without access to it.
+func (a *managedAddress) reencrypt(oldKey, newKey EncryptorDecryptor) error {
+	privKey, err := a.unlock(oldKey)
+	if err != nil {
+		a.removePrivKey()
+		return err
+	}
+	privKeyEncrypted, err := newKey.Encrypt(privKey)
+	zero.Bytes(privKey)
+	if err != nil {
+		a.removePrivKey()
+		str := fmt.Sprintf("failed to encrypt private key for %s",
+			a.address)
+		return managerError(ErrCrypto, str, err)
+	}
+
+	a.privKeyMutex.Lock()
+	a.privKeyEncrypted = privKeyEncrypted
+	a.privKeyMutex.Unlock()
+	return nil
+}
+
+// removePrivKey zeroes and removes both the clear text and the encrypted
+// private key.
+func (a *managedAddress) removePrivKey() {
+	a.privKeyMutex.Lock()
+	a.privKeyCT.Free()
+	a.privKeyCT = nil
+	zero.Bytes(a.privKeyEncrypted)
+	a.privKeyEncrypted = nil
+	a.privKeyMutex.Unlock()
+}
+
 // lock zeroes the associated clear text private key.
 func (a *managedAddress) lock() {
 	// Zero and nil the clear text private key associated with this
 	// address.
 	a.privKeyMutex.Lock()
-	zero.Bytes(a.privKeyCT)
+	a.privKeyCT.Free()
 	a.privKeyCT = nil
 	a.privKeyMutex.Unlock()
 }
@@ -284,10 +320,18 @@ func (a *managedAddress) ExportPubKey() string {
 }
 
 // PrivKey returns the private key for the address.  It can fail if the address
-// manager is watching-only or locked, or the address does not have any keys.
+// manager is watching-only, locked, or unlocked at a level which doesn't allow
+// signing, or the address does not have any keys.
 //
 // This is part of the ManagedPubKeyAddress interface implementation.
 func (a *managedAddress) PrivKey() (*btcec.PrivateKey, error) {
+	return a.privKey(UnlockSpend)
+}
+
+// privKey returns the private key for the address if the private passphrase of
+// the manager was unlocked at least at the passed level.  The level does not
+// apply to the keys of accounts protected by their own passphrase.
+func (a *managedAddress) privKey(level UnlockLevel) (*btcec.PrivateKey, error) {
 	// No private keys are available for a watching-only address manager.
 	if a.manager.rootManager.WatchOnly() {
 		return nil, managerError(ErrWatchingOnly, errWatchingOnly, nil)
@@ -296,15 +340,25 @@ func (a *managedAddress) PrivKey() (*btcec.PrivateKey, error) {
 	a.manager.mtx.Lock()
 	defer a.manager.mtx.Unlock()
 
-	// Account manager must be unlocked to decrypt the private key.
-	if a.manager.rootManager.IsLocked() {
+	// The account must be unlocked to decrypt the private key.
+	account := a.InternalAccount()
+	if a.manager.accountLocked(account) {
 		return nil, managerError(ErrLocked, errLocked, nil)
 	}
+	if !a.manager.hasAccountPassphrase(account) {
+		unlocked := a.manager.rootManager.UnlockLevel()
+		if unlocked < level {
+			str := fmt.Sprintf("address manager is unlocked at "+
+				"the %v level, while the %v level is required",
+				unlocked, level)
+			return nil, managerError(ErrLocked, str, nil)
+		}
+	}
 
 	// Decrypt the key as needed.  Also, make sure it's a copy since the
 	// private key stored in memory can be cleared at any time.  Otherwise
 	// the returned private key could be invalidated from under the caller.
-	privKeyCopy, err := a.unlock(a.manager.rootManager.cryptoKeyPriv)
+	privKeyCopy, err := a.unlock(a.manager.accountCryptoKey(account))
 	if err != nil {
 		return nil, err
 	}
@@ -315,11 +369,12 @@ func (a *managedAddress) PrivKey() (*btcec.PrivateKey, error) {
 }
 
 // ExportPrivKey returns the private key associated with the address in Wallet
-// Import Format (WIF).
+// Import Format (WIF).  Exporting the key requires the manager to be fully
+// unlocked.
 //
 // This is part of the ManagedPubKeyAddress interface implementation.
 func (a *managedAddress) ExportPrivKey() (*btcutil.WIF, error) {
-	pk, err := a.PrivKey()
+	pk, err := a.privKey(UnlockFull)
 	if err != nil {
 		return nil, err
 	}
@@ -440,10 +495,11 @@ func newManagedAddress(s *ScopedKeyManager, derivationPath DerivationPath,
 
 	// Encrypt the private key.
 	//
-	// NOTE: The privKeyBytes here are set into the managed address which
-	// are cleared when locked, so they aren't cleared here.
+	// NOTE: The privKeyBytes here are moved into secure memory of the
+	// managed address, which is freed when locked.
 	privKeyBytes := privKey.Serialize()
-	privKeyEncrypted, err := s.rootManager.cryptoKeyPriv.Encrypt(privKeyBytes)
+	cryptoKey := s.accountCryptoKey(derivationPath.InternalAccount)
+	privKeyEncrypted, err := cryptoKey.Encrypt(privKeyBytes)
 	if err != nil {
 		str := "failed to encrypt private key"
 		return nil, managerError(ErrCrypto, str, err)
@@ -459,7 +515,7 @@ func newManagedAddress(s *ScopedKeyManager, derivationPath DerivationPath,
 		return nil, err
 	}
 	managedAddr.privKeyEncrypted = privKeyEncrypted
-	managedAddr.privKeyCT = privKeyBytes
+	managedAddr.privKeyCT = securemem.FromBytes(privKeyBytes)
 
 	return managedAddr, nil
 }
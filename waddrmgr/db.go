@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/walletdb"
 )
 
@@ -200,6 +201,9 @@ var (
 	// scopeBucket -> scope -> addrAcctIdxBucket
 	// scopeBucket -> scope -> acctNameIdxBucket
 	// scopeBucket -> scope -> acctIDIdxBucketName
+	// scopeBucket -> scope -> acctMetaBucket
+	// scopeBucket -> scope -> acctPassphraseBucket
+	// scopeBucket -> scope -> acctPolicyBucket
 	// scopeBucket -> scope -> metaBucket
 	// scopeBucket -> scope -> metaBucket -> lastAccountNameKey
 	// scopeBucket -> scope -> coinTypePrivKey
@@ -256,8 +260,41 @@ var (
 	// account_id => string
 	acctIDIdxBucketName = []byte("acctididx")
 
+	// acctMetaBucketName is the name of the bucket that stores the
+	// user-provided metadata of an account, keyed by account number.  The
+	// bucket was added after manager version 8 and is created on first use
+	// for older wallets.
+	//
+	// account_id => metadata
+	acctMetaBucketName = []byte("acctmeta")
+
+	// acctPassphraseBucketName is the name of the bucket that stores the
+	// keys of the accounts protected by their own passphrase, keyed by
+	// account number.  The bucket was added after manager version 8 and is
+	// created on first use.
+	//
+	// account_id => master key params || encrypted crypto key
+	acctPassphraseBucketName = []byte("acctpassphrase")
+
+	// acctPolicyBucketName is the name of the bucket that stores the
+	// spend policies of accounts, keyed by account number.  The bucket
+	// was added after manager version 8 and is created on first use.
+	//
+	// account_id => spend policy
+	acctPolicyBucketName = []byte("acctpolicy")
+
+	// extKeyRefBucketName is the name of the bucket that stores the
+	// references to the keys of imported public keys whose private keys
+	// are held by an external signer, keyed by the hash160 of the
+	// compressed public key.  The bucket was added after manager version
+	// 8 and is created on first use.
+	//
+	// pubkey_hash => provider name length || provider name || reference
+	extKeyRefBucketName = []byte("extkeyref")
+
 	// usedAddrBucketName is the name of the bucket that stores an
-	// addresses hash if the address has been used or not.
+	// addresses hash if the address has been used or not.  The value is
+	// usedAddrDirty for addresses which have also been spent from.
 	usedAddrBucketName = []byte("usedaddrs")
 
 	// meta is used to store meta-data about the address manager
@@ -288,6 +325,35 @@ var (
 	// sync state of the root manager.
 	syncBucketName = []byte("sync")
 
+	// addrLabelBucketName is the name of the bucket that stores the
+	// user-provided labels of addresses, keyed by encoded address.  Any
+	// address of the network may be labeled, not only those of the
+	// wallet.  The bucket was added after manager version 8 and is
+	// created on first use.
+	//
+	// encoded address => label
+	addrLabelBucketName = []byte("addrlabels")
+
+	// addrBirthdayBucketName is the name of the bucket that stores the
+	// birthday blocks of addresses, from which the chain is scanned for
+	// their transactions, keyed by encoded address.  Derived addresses
+	// record the block the manager was synced to when they were created,
+	// and imported addresses the block stamp they were imported with.
+	// The bucket was added after manager version 8 and is created on
+	// first use.
+	//
+	// encoded address => <blockheight><blockhash><timestamp>
+	addrBirthdayBucketName = []byte("addrbirthdays")
+
+	// addrReservationBucketName is the name of the bucket that stores the
+	// reservations of external addresses handed out for invoices, keyed
+	// by encoded address.  Released addresses stay in the bucket so they
+	// may be reserved again while unused.  The bucket was added after
+	// manager version 8 and is created on first use.
+	//
+	// encoded address => <released><timestamp>
+	addrReservationBucketName = []byte("addrreservations")
+
 	// Db related key names (main bucket).
 	mgrVersionName    = []byte("mgrver")
 	mgrCreateDateName = []byte("mgrcreated")
@@ -308,6 +374,10 @@ var (
 	birthdayBlockVerifiedName = []byte("birthdayblockverified")
 )
 
+// usedAddrDirty is the value stored in the used address bucket for dirty
+// addresses.  Addresses which are only used are stored with a value of 0.
+const usedAddrDirty = 1
+
 // uint32ToBytes converts a 32 bit unsigned integer into a 4-byte slice in
 // little-endian order: 1 -> [1 0 0 0].
 func uint32ToBytes(number uint32) []byte {
@@ -1010,6 +1080,10 @@ func forEachAccount(ns walletdb.ReadBucket, scope *KeyScope,
 	})
 }
 
+// noLastAccount is the last account of a scope without accounts, the twos-
+// complement representation of -1, so that the next account is zero.
+const noLastAccount = (1 << 32) - 1
+
 // fetchLastAccount retrieves the last account from the database.
 // If no accounts, returns twos-complement representation of -1, so that the next account is zero
 func fetchLastAccount(ns walletdb.ReadBucket, scope *KeyScope) (uint32, error) {
@@ -1022,7 +1096,7 @@ func fetchLastAccount(ns walletdb.ReadBucket, scope *KeyScope) (uint32, error) {
 
 	val := metaBucket.Get(lastAccountName)
 	if val == nil {
-		return (1 << 32) - 1, nil
+		return noLastAccount, nil
 	}
 	if len(val) != 4 {
 		str := fmt.Sprintf("malformed metadata '%s' stored in database",
@@ -1229,6 +1303,74 @@ func putAddrAccountIndex(ns walletdb.ReadWriteBucket, scope *KeyScope,
 	return nil
 }
 
+// moveAddrAccountIndex moves the addresses of an account in the address account
+// index of the database to another account, so that they are looked up as
+// addresses of the other account.
+func moveAddrAccountIndex(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	from, into uint32) error {
+
+	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
+	if err != nil {
+		return err
+	}
+
+	bucket := scopedBucket.NestedReadWriteBucket(addrAcctIdxBucketName)
+	fromBucket := bucket.NestedReadWriteBucket(uint32ToBytes(from))
+
+	// If index bucket is missing the account, there hasn't been any
+	// address entries yet.
+	if fromBucket == nil {
+		return nil
+	}
+
+	// The address hashes are collected first, as the account bucket is
+	// deleted once they are moved.
+	var addrHashes [][]byte
+	err = fromBucket.ForEach(func(k, v []byte) error {
+		// Skip buckets.
+		if v == nil {
+			return nil
+		}
+
+		addrHash := make([]byte, len(k))
+		copy(addrHash, k)
+		addrHashes = append(addrHashes, addrHash)
+		return nil
+	})
+	if err != nil {
+		return maybeConvertDbError(err)
+	}
+
+	intoBucket, err := bucket.CreateBucketIfNotExists(uint32ToBytes(into))
+	if err != nil {
+		str := fmt.Sprintf("failed to create address account index "+
+			"bucket for account %d", into)
+		return managerError(ErrDatabase, str, err)
+	}
+	for _, addrHash := range addrHashes {
+		err := bucket.Put(addrHash, uint32ToBytes(into))
+		if err != nil {
+			str := fmt.Sprintf("failed to store address account "+
+				"index key %x", addrHash)
+			return managerError(ErrDatabase, str, err)
+		}
+		err = intoBucket.Put(addrHash, nullVal)
+		if err != nil {
+			str := fmt.Sprintf("failed to store address account "+
+				"index key %x", addrHash)
+			return managerError(ErrDatabase, str, err)
+		}
+	}
+
+	err = bucket.DeleteNestedBucket(uint32ToBytes(from))
+	if err != nil {
+		str := fmt.Sprintf("failed to delete address account index "+
+			"bucket for account %d", from)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
 // putAccountRow stores the provided account information to the database.  This
 // is used a common base for storing the various account types.
 func putAccountRow(ns walletdb.ReadWriteBucket, scope *KeyScope,
@@ -1331,6 +1473,648 @@ func putLastAccount(ns walletdb.ReadWriteBucket, scope *KeyScope,
 	return nil
 }
 
+// acctMetaFlagAvoidReuse is the account metadata flag set for accounts which
+// avoid address reuse.
+const acctMetaFlagAvoidReuse = 1 << 0
+
+// serializeAccountMetadata returns the serialization of the passed account
+// metadata.
+//
+// The metadata is serialized as follows:
+//   [0:8]   creation timestamp (0 if unknown)
+//   [8:12]  description length
+//   [12:n]  description
+//   [n:n+4] number of tags
+//   each tag is serialized as a 4 byte length followed by the tag
+//   1 byte of flags, which may be absent in metadata written before any flag
+//   was defined
+//   8 byte deposit alert amount, which may be absent in metadata written
+//   before deposit alerts were recorded
+func serializeAccountMetadata(meta *AccountMetadata) []byte {
+	var created uint64
+	if !meta.Created.IsZero() {
+		created = uint64(meta.Created.Unix())
+	}
+
+	size := 8 + 4 + len(meta.Description) + 4 + 1 + 8
+	for _, tag := range meta.Tags {
+		size += 4 + len(tag)
+	}
+	buf := make([]byte, 8, size)
+	binary.BigEndian.PutUint64(buf, created)
+	buf = append(buf, stringToBytes(meta.Description)...)
+	buf = append(buf, uint32ToBytes(uint32(len(meta.Tags)))...)
+	for _, tag := range meta.Tags {
+		buf = append(buf, stringToBytes(tag)...)
+	}
+
+	var flags byte
+	if meta.AvoidReuse {
+		flags |= acctMetaFlagAvoidReuse
+	}
+	buf = append(buf, flags)
+
+	var depositAlert [8]byte
+	binary.LittleEndian.PutUint64(depositAlert[:], uint64(meta.DepositAlert))
+	return append(buf, depositAlert[:]...)
+}
+
+// deserializeAccountMetadata deserializes the passed serialized account
+// metadata.
+func deserializeAccountMetadata(serialized []byte) (*AccountMetadata, error) {
+	str := "malformed account metadata stored in database"
+
+	// readString reads a length-prefixed string from the remaining
+	// serialized bytes.
+	readString := func() (string, bool) {
+		if len(serialized) < 4 {
+			return "", false
+		}
+		n := binary.LittleEndian.Uint32(serialized[0:4])
+		serialized = serialized[4:]
+		if uint32(len(serialized)) < n {
+			return "", false
+		}
+		s := string(serialized[:n])
+		serialized = serialized[n:]
+		return s, true
+	}
+
+	if len(serialized) < 8 {
+		return nil, managerError(ErrDatabase, str, nil)
+	}
+	meta := &AccountMetadata{}
+	if created := binary.BigEndian.Uint64(serialized[0:8]); created != 0 {
+		meta.Created = time.Unix(int64(created), 0)
+	}
+	serialized = serialized[8:]
+
+	var ok bool
+	meta.Description, ok = readString()
+	if !ok || len(serialized) < 4 {
+		return nil, managerError(ErrDatabase, str, nil)
+	}
+	numTags := binary.LittleEndian.Uint32(serialized[0:4])
+	serialized = serialized[4:]
+	for i := uint32(0); i < numTags; i++ {
+		tag, ok := readString()
+		if !ok {
+			return nil, managerError(ErrDatabase, str, nil)
+		}
+		meta.Tags = append(meta.Tags, tag)
+	}
+
+	if len(serialized) > 0 {
+		meta.AvoidReuse = serialized[0]&acctMetaFlagAvoidReuse != 0
+		serialized = serialized[1:]
+	}
+	if len(serialized) >= 8 {
+		meta.DepositAlert = btcutil.Amount(
+			binary.LittleEndian.Uint64(serialized[0:8]),
+		)
+	}
+
+	return meta, nil
+}
+
+// fetchAccountMetadata retrieves the metadata of an account from the
+// database.  Empty metadata is returned for accounts that have none stored.
+func fetchAccountMetadata(ns walletdb.ReadBucket, scope *KeyScope,
+	account uint32) (*AccountMetadata, error) {
+
+	scopedBucket, err := fetchReadScopeBucket(ns, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := scopedBucket.NestedReadBucket(acctMetaBucketName)
+	if bucket == nil {
+		return &AccountMetadata{}, nil
+	}
+
+	serialized := bucket.Get(uint32ToBytes(account))
+	if serialized == nil {
+		return &AccountMetadata{}, nil
+	}
+	return deserializeAccountMetadata(serialized)
+}
+
+// putAccountMetadata stores the metadata of an account to the database,
+// creating the account metadata bucket if necessary.
+func putAccountMetadata(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	account uint32, meta *AccountMetadata) error {
+
+	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := scopedBucket.CreateBucketIfNotExists(acctMetaBucketName)
+	if err != nil {
+		str := "failed to create account metadata bucket"
+		return managerError(ErrDatabase, str, err)
+	}
+
+	err = bucket.Put(uint32ToBytes(account), serializeAccountMetadata(meta))
+	if err != nil {
+		str := fmt.Sprintf("failed to store metadata for account %d",
+			account)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// serializeAccountPassphraseKey returns the serialization of the master key
+// parameters and encrypted crypto key of an account protected by its own
+// passphrase.
+//
+// The key is serialized as follows:
+//   [0:4]   master key parameters length
+//   [4:n]   master key parameters
+//   [n:]    encrypted crypto key
+func serializeAccountPassphraseKey(masterKeyParams,
+	cryptoKeyEncrypted []byte) []byte {
+
+	buf := make([]byte, 0, 4+len(masterKeyParams)+len(cryptoKeyEncrypted))
+	buf = append(buf, uint32ToBytes(uint32(len(masterKeyParams)))...)
+	buf = append(buf, masterKeyParams...)
+	return append(buf, cryptoKeyEncrypted...)
+}
+
+// fetchAccountPassphraseKey retrieves the master key parameters and encrypted
+// crypto key of an account protected by its own passphrase.  Nil keys are
+// returned for accounts protected by the private passphrase of the manager.
+func fetchAccountPassphraseKey(ns walletdb.ReadBucket, scope *KeyScope,
+	account uint32) ([]byte, []byte, error) {
+
+	scopedBucket, err := fetchReadScopeBucket(ns, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bucket := scopedBucket.NestedReadBucket(acctPassphraseBucketName)
+	if bucket == nil {
+		return nil, nil, nil
+	}
+
+	serialized := bucket.Get(uint32ToBytes(account))
+	if serialized == nil {
+		return nil, nil, nil
+	}
+	if len(serialized) < 4 {
+		str := fmt.Sprintf("malformed passphrase key for account %d",
+			account)
+		return nil, nil, managerError(ErrDatabase, str, nil)
+	}
+	paramsLen := binary.LittleEndian.Uint32(serialized[0:4])
+	if uint32(len(serialized)-4) < paramsLen {
+		str := fmt.Sprintf("malformed passphrase key for account %d",
+			account)
+		return nil, nil, managerError(ErrDatabase, str, nil)
+	}
+
+	masterKeyParams := make([]byte, paramsLen)
+	copy(masterKeyParams, serialized[4:4+paramsLen])
+	cryptoKeyEncrypted := make([]byte, len(serialized)-4-int(paramsLen))
+	copy(cryptoKeyEncrypted, serialized[4+paramsLen:])
+	return masterKeyParams, cryptoKeyEncrypted, nil
+}
+
+// putAccountPassphraseKey stores the master key parameters and encrypted crypto
+// key of an account protected by its own passphrase, creating the account
+// passphrase bucket if necessary.
+func putAccountPassphraseKey(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	account uint32, masterKeyParams, cryptoKeyEncrypted []byte) error {
+
+	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := scopedBucket.CreateBucketIfNotExists(
+		acctPassphraseBucketName,
+	)
+	if err != nil {
+		str := "failed to create account passphrase bucket"
+		return managerError(ErrDatabase, str, err)
+	}
+
+	serialized := serializeAccountPassphraseKey(
+		masterKeyParams, cryptoKeyEncrypted,
+	)
+	err = bucket.Put(uint32ToBytes(account), serialized)
+	if err != nil {
+		str := fmt.Sprintf("failed to store passphrase key for "+
+			"account %d", account)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// deleteAccountPassphraseKey removes the passphrase key of an account, so that
+// it is protected by the private passphrase of the manager again.
+func deleteAccountPassphraseKey(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	account uint32) error {
+
+	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
+	if err != nil {
+		return err
+	}
+
+	bucket := scopedBucket.NestedReadWriteBucket(acctPassphraseBucketName)
+	if bucket == nil {
+		return nil
+	}
+
+	err = bucket.Delete(uint32ToBytes(account))
+	if err != nil {
+		str := fmt.Sprintf("failed to delete passphrase key for "+
+			"account %d", account)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// fetchExternalKeyRef retrieves the name of the external signer provider and
+// the provider's reference to the key with the public key hash.  An empty
+// provider name is returned for keys which are not held externally.
+func fetchExternalKeyRef(ns walletdb.ReadBucket, scope *KeyScope,
+	pubKeyHash []byte) (string, []byte, error) {
+
+	scopedBucket, err := fetchReadScopeBucket(ns, scope)
+	if err != nil {
+		return "", nil, err
+	}
+
+	bucket := scopedBucket.NestedReadBucket(extKeyRefBucketName)
+	if bucket == nil {
+		return "", nil, nil
+	}
+
+	serialized := bucket.Get(pubKeyHash)
+	if serialized == nil {
+		return "", nil, nil
+	}
+	if len(serialized) < 1 || len(serialized)-1 < int(serialized[0]) {
+		str := fmt.Sprintf("malformed external key reference for "+
+			"public key hash %x", pubKeyHash)
+		return "", nil, managerError(ErrDatabase, str, nil)
+	}
+	nameLen := int(serialized[0])
+	provider := string(serialized[1 : 1+nameLen])
+	ref := make([]byte, len(serialized)-1-nameLen)
+	copy(ref, serialized[1+nameLen:])
+	return provider, ref, nil
+}
+
+// putExternalKeyRef stores the name of the external signer provider and the
+// provider's reference to the key with the public key hash, creating the
+// external key reference bucket if necessary.
+func putExternalKeyRef(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	pubKeyHash []byte, provider string, ref []byte) error {
+
+	if len(provider) == 0 || len(provider) > 255 {
+		str := fmt.Sprintf("external signer provider name %q is not "+
+			"between 1 and 255 bytes", provider)
+		return managerError(ErrDatabase, str, nil)
+	}
+
+	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := scopedBucket.CreateBucketIfNotExists(extKeyRefBucketName)
+	if err != nil {
+		str := "failed to create external key reference bucket"
+		return managerError(ErrDatabase, str, err)
+	}
+
+	serialized := make([]byte, 0, 1+len(provider)+len(ref))
+	serialized = append(serialized, byte(len(provider)))
+	serialized = append(serialized, provider...)
+	serialized = append(serialized, ref...)
+	err = bucket.Put(pubKeyHash, serialized)
+	if err != nil {
+		str := fmt.Sprintf("failed to store external key reference "+
+			"for public key hash %x", pubKeyHash)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// serializeSpendPolicy returns the serialization of the passed spend policy.
+//
+// The policy is serialized as follows:
+//   [0:8]   maximum amount per transaction (0 if unlimited)
+//   [8:16]  maximum amount per 24 hours (0 if unlimited)
+//   [16:20] number of whitelisted addresses
+//   each address is serialized as a 4 byte length followed by the encoded
+//   address
+func serializeSpendPolicy(policy *SpendPolicy) []byte {
+	size := 8 + 8 + 4
+	for _, addr := range policy.Whitelist {
+		size += 4 + len(addr)
+	}
+	buf := make([]byte, 16, size)
+	binary.LittleEndian.PutUint64(buf[0:8], uint64(policy.MaxPerTx))
+	binary.LittleEndian.PutUint64(buf[8:16], uint64(policy.MaxPerDay))
+	buf = append(buf, uint32ToBytes(uint32(len(policy.Whitelist)))...)
+	for _, addr := range policy.Whitelist {
+		buf = append(buf, stringToBytes(addr)...)
+	}
+	return buf
+}
+
+// deserializeSpendPolicy deserializes the passed serialized spend policy.
+func deserializeSpendPolicy(serialized []byte) (*SpendPolicy, error) {
+	str := "malformed spend policy stored in database"
+
+	if len(serialized) < 20 {
+		return nil, managerError(ErrDatabase, str, nil)
+	}
+	policy := &SpendPolicy{
+		MaxPerTx:  btcutil.Amount(binary.LittleEndian.Uint64(serialized[0:8])),
+		MaxPerDay: btcutil.Amount(binary.LittleEndian.Uint64(serialized[8:16])),
+	}
+	numAddrs := binary.LittleEndian.Uint32(serialized[16:20])
+	serialized = serialized[20:]
+	for i := uint32(0); i < numAddrs; i++ {
+		if len(serialized) < 4 {
+			return nil, managerError(ErrDatabase, str, nil)
+		}
+		n := binary.LittleEndian.Uint32(serialized[0:4])
+		serialized = serialized[4:]
+		if uint32(len(serialized)) < n {
+			return nil, managerError(ErrDatabase, str, nil)
+		}
+		policy.Whitelist = append(policy.Whitelist, string(serialized[:n]))
+		serialized = serialized[n:]
+	}
+	return policy, nil
+}
+
+// fetchSpendPolicy retrieves the spend policy of an account from the
+// database.  Nil is returned for accounts without a policy.
+func fetchSpendPolicy(ns walletdb.ReadBucket, scope *KeyScope,
+	account uint32) (*SpendPolicy, error) {
+
+	scopedBucket, err := fetchReadScopeBucket(ns, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := scopedBucket.NestedReadBucket(acctPolicyBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+
+	serialized := bucket.Get(uint32ToBytes(account))
+	if serialized == nil {
+		return nil, nil
+	}
+	return deserializeSpendPolicy(serialized)
+}
+
+// putSpendPolicy stores the spend policy of an account to the database,
+// creating the account policy bucket if necessary.  A nil policy removes the
+// policy of the account.
+func putSpendPolicy(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	account uint32, policy *SpendPolicy) error {
+
+	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
+	if err != nil {
+		return err
+	}
+
+	if policy == nil {
+		bucket := scopedBucket.NestedReadWriteBucket(acctPolicyBucketName)
+		if bucket == nil {
+			return nil
+		}
+		err = bucket.Delete(uint32ToBytes(account))
+		if err != nil {
+			str := fmt.Sprintf("failed to delete spend policy for "+
+				"account %d", account)
+			return managerError(ErrDatabase, str, err)
+		}
+		return nil
+	}
+
+	bucket, err := scopedBucket.CreateBucketIfNotExists(acctPolicyBucketName)
+	if err != nil {
+		str := "failed to create account policy bucket"
+		return managerError(ErrDatabase, str, err)
+	}
+
+	err = bucket.Put(uint32ToBytes(account), serializeSpendPolicy(policy))
+	if err != nil {
+		str := fmt.Sprintf("failed to store spend policy for account %d",
+			account)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// fetchAddressLabel retrieves the label of an encoded address from the
+// database.  An empty label is returned for addresses without a label.
+func fetchAddressLabel(ns walletdb.ReadBucket, addr string) string {
+	bucket := ns.NestedReadBucket(addrLabelBucketName)
+	if bucket == nil {
+		return ""
+	}
+	return string(bucket.Get([]byte(addr)))
+}
+
+// putAddressLabel stores the label of an encoded address to the database,
+// creating the address label bucket if necessary.  An empty label removes the
+// label of the address.
+func putAddressLabel(ns walletdb.ReadWriteBucket, addr, label string) error {
+	if label == "" {
+		bucket := ns.NestedReadWriteBucket(addrLabelBucketName)
+		if bucket == nil {
+			return nil
+		}
+		if err := bucket.Delete([]byte(addr)); err != nil {
+			str := fmt.Sprintf("failed to delete label of address %s",
+				addr)
+			return managerError(ErrDatabase, str, err)
+		}
+		return nil
+	}
+
+	bucket, err := ns.CreateBucketIfNotExists(addrLabelBucketName)
+	if err != nil {
+		str := "failed to create address label bucket"
+		return managerError(ErrDatabase, str, err)
+	}
+	if err := bucket.Put([]byte(addr), []byte(label)); err != nil {
+		str := fmt.Sprintf("failed to store label of address %s", addr)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// forEachAddressLabel calls fn with every labeled encoded address and its
+// label stored in the database.
+func forEachAddressLabel(ns walletdb.ReadBucket,
+	fn func(addr, label string) error) error {
+
+	bucket := ns.NestedReadBucket(addrLabelBucketName)
+	if bucket == nil {
+		return nil
+	}
+	return bucket.ForEach(func(k, v []byte) error {
+		return fn(string(k), string(v))
+	})
+}
+
+// fetchAddressBirthday retrieves the birthday block of an encoded address from
+// the database.  Nil is returned for addresses without a birthday block.
+func fetchAddressBirthday(ns walletdb.ReadBucket, addr string) (*BlockStamp,
+	error) {
+
+	bucket := ns.NestedReadBucket(addrBirthdayBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+	buf := bucket.Get([]byte(addr))
+	if buf == nil {
+		return nil, nil
+	}
+
+	// The serialized birthday block format is:
+	//   <blockheight><blockhash><timestamp>
+	//
+	// 4 bytes block height + 32 bytes hash length + 8 byte timestamp
+	if len(buf) != 44 {
+		str := fmt.Sprintf("malformed birthday block of address %s",
+			addr)
+		return nil, managerError(ErrDatabase, str, nil)
+	}
+
+	var bs BlockStamp
+	bs.Height = int32(binary.BigEndian.Uint32(buf[:4]))
+	copy(bs.Hash[:], buf[4:36])
+	bs.Timestamp = time.Unix(int64(binary.BigEndian.Uint64(buf[36:])), 0)
+	return &bs, nil
+}
+
+// putAddressBirthday stores the birthday block of an encoded address to the
+// database, creating the address birthday bucket if necessary.
+func putAddressBirthday(ns walletdb.ReadWriteBucket, addr string,
+	bs *BlockStamp) error {
+
+	bucket, err := ns.CreateBucketIfNotExists(addrBirthdayBucketName)
+	if err != nil {
+		str := "failed to create address birthday bucket"
+		return managerError(ErrDatabase, str, err)
+	}
+
+	var buf [44]byte
+	binary.BigEndian.PutUint32(buf[:4], uint32(bs.Height))
+	copy(buf[4:36], bs.Hash[:])
+	binary.BigEndian.PutUint64(buf[36:], uint64(bs.Timestamp.Unix()))
+	if err := bucket.Put([]byte(addr), buf[:]); err != nil {
+		str := fmt.Sprintf("failed to store birthday block of "+
+			"address %s", addr)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// deserializeAddressReservation deserializes the reservation of an encoded
+// address.
+func deserializeAddressReservation(addr string,
+	buf []byte) (*AddressReservation, error) {
+
+	// The serialized reservation format is:
+	//   <released><timestamp>
+	//
+	// 1 byte released flag + 8 byte timestamp
+	if len(buf) != 9 {
+		str := fmt.Sprintf("malformed reservation of address %s", addr)
+		return nil, managerError(ErrDatabase, str, nil)
+	}
+
+	return &AddressReservation{
+		Released: buf[0] != 0,
+		Time:     time.Unix(int64(binary.BigEndian.Uint64(buf[1:])), 0),
+	}, nil
+}
+
+// fetchAddressReservation retrieves the reservation of an encoded address from
+// the database.  Nil is returned for addresses without a reservation.
+func fetchAddressReservation(ns walletdb.ReadBucket,
+	addr string) (*AddressReservation, error) {
+
+	bucket := ns.NestedReadBucket(addrReservationBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+	buf := bucket.Get([]byte(addr))
+	if buf == nil {
+		return nil, nil
+	}
+	return deserializeAddressReservation(addr, buf)
+}
+
+// putAddressReservation stores the reservation of an encoded address to the
+// database, creating the address reservation bucket if necessary.  A nil
+// reservation removes the reservation of the address.
+func putAddressReservation(ns walletdb.ReadWriteBucket, addr string,
+	r *AddressReservation) error {
+
+	if r == nil {
+		bucket := ns.NestedReadWriteBucket(addrReservationBucketName)
+		if bucket == nil {
+			return nil
+		}
+		if err := bucket.Delete([]byte(addr)); err != nil {
+			str := fmt.Sprintf("failed to delete reservation of "+
+				"address %s", addr)
+			return managerError(ErrDatabase, str, err)
+		}
+		return nil
+	}
+
+	bucket, err := ns.CreateBucketIfNotExists(addrReservationBucketName)
+	if err != nil {
+		str := "failed to create address reservation bucket"
+		return managerError(ErrDatabase, str, err)
+	}
+
+	var buf [9]byte
+	if r.Released {
+		buf[0] = 1
+	}
+	binary.BigEndian.PutUint64(buf[1:], uint64(r.Time.Unix()))
+	if err := bucket.Put([]byte(addr), buf[:]); err != nil {
+		str := fmt.Sprintf("failed to store reservation of address %s",
+			addr)
+		return managerError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// forEachAddressReservation calls fn with every encoded address and its
+// reservation stored in the database.
+func forEachAddressReservation(ns walletdb.ReadBucket,
+	fn func(addr string, r *AddressReservation) error) error {
+
+	bucket := ns.NestedReadBucket(addrReservationBucketName)
+	if bucket == nil {
+		return nil
+	}
+	return bucket.ForEach(func(k, v []byte) error {
+		r, err := deserializeAddressReservation(string(k), v)
+		if err != nil {
+			return err
+		}
+		return fn(string(k), r)
+	})
+}
+
 // deserializeAddressRow deserializes the passed serialized address
 // information.  This is used as a common base for the various address types to
 // deserialize the common parts.
@@ -1669,6 +2453,46 @@ func markAddressUsed(ns walletdb.ReadWriteBucket, scope *KeyScope,
 	return nil
 }
 
+// fetchAddressDirty returns true if the provided address id was flagged as
+// dirty.
+func fetchAddressDirty(ns walletdb.ReadBucket, scope *KeyScope,
+	addressID []byte) bool {
+
+	scopedBucket, err := fetchReadScopeBucket(ns, scope)
+	if err != nil {
+		return false
+	}
+
+	bucket := scopedBucket.NestedReadBucket(usedAddrBucketName)
+
+	addrHash := sha256.Sum256(addressID)
+	val := bucket.Get(addrHash[:])
+	return len(val) == 1 && val[0] == usedAddrDirty
+}
+
+// markAddressDirty flags the provided address id as dirty in the database.
+// Dirty addresses are recorded in the used address bucket with a value of
+// usedAddrDirty, so marking an address dirty also marks it used.
+func markAddressDirty(ns walletdb.ReadWriteBucket, scope *KeyScope,
+	addressID []byte) error {
+
+	scopedBucket, err := fetchWriteScopeBucket(ns, scope)
+	if err != nil {
+		return err
+	}
+
+	bucket := scopedBucket.NestedReadWriteBucket(usedAddrBucketName)
+
+	addrHash := sha256.Sum256(addressID)
+	err = bucket.Put(addrHash[:], []byte{usedAddrDirty})
+	if err != nil {
+		str := fmt.Sprintf("failed to mark address dirty %x", addressID)
+		return managerError(ErrDatabase, str, err)
+	}
+
+	return nil
+}
+
 // fetchAddress loads address information for the provided address id from the
 // database.  The returned value is one of the address rows for the specific
 // address type.  The caller should use type assertions to ascertain the type.
@@ -2069,6 +2893,18 @@ func deletePrivateKeys(ns walletdb.ReadWriteBucket) error {
 			return maybeConvertDbError(err)
 		}
 
+		// Delete the keys of the accounts protected by their own
+		// passphrase.
+		if managerScopeBucket.NestedReadBucket(acctPassphraseBucketName) != nil {
+			err := managerScopeBucket.DeleteNestedBucket(
+				acctPassphraseBucketName,
+			)
+			if err != nil {
+				str := "failed to delete account passphrase keys"
+				return managerError(ErrDatabase, str, err)
+			}
+		}
+
 		// Delete the private key for all imported addresses.
 		bucket = managerScopeBucket.NestedReadWriteBucket(addrBucketName)
 		err = bucket.ForEach(func(k, v []byte) error {
@@ -2544,6 +3380,12 @@ func createScopedManagerNS(ns walletdb.ReadWriteBucket, scope *KeyScope) error {
 		return managerError(ErrDatabase, str, err)
 	}
 
+	_, err = scopeBucket.CreateBucket(acctMetaBucketName)
+	if err != nil {
+		str := "failed to create an account metadata bucket"
+		return managerError(ErrDatabase, str, err)
+	}
+
 	_, err = scopeBucket.CreateBucket(metaBucketName)
 	if err != nil {
 		str := "failed to create a meta bucket"
@@ -49,7 +49,9 @@ accomplished by typically having the address manager locked meaning no private
 keys or scripts are in memory.  Unlocking the address manager causes the crypto
 private and script keys to be decrypted and loaded in memory which in turn are
 used to decrypt private keys and scripts on demand.  Relocking the address
-manager actively zeros all private material from memory.  In addition, temp
+manager actively zeros all private material from memory.  Decrypted private
+keys of addresses are held in memory which is locked into RAM where the
+platform allows it, so they are never written to swap.  In addition, temp
 private key material used internally is zeroed as soon as it's used.
 
 Locking and Unlocking
@@ -175,6 +175,29 @@ type accountInfo struct {
 	// derivation path m/). This may be required by some hardware wallets
 	// for proper identification and signing.
 	masterKeyFingerprint uint32
+
+	// passphraseKey protects the account private extended key of an
+	// account protected by its own passphrase rather than the private
+	// passphrase of the manager.  It is nil for all other accounts.
+	passphraseKey *accountPassphraseKey
+}
+
+// accountPassphraseKey houses the keys of an account protected by its own
+// passphrase.  The master key derived from the account passphrase encrypts the
+// crypto key, which in turn encrypts the account private extended key and the
+// private keys of the account's addresses.  The derived master key and the
+// crypto key are only held in memory while the account is unlocked, which is
+// independent of whether the manager is locked.
+type accountPassphraseKey struct {
+	masterKey          *snacl.SecretKey
+	cryptoKeyEncrypted []byte
+	cryptoKey          EncryptorDecryptor
+}
+
+// zero removes the derived master key and the crypto key from memory.
+func (k *accountPassphraseKey) zero() {
+	k.masterKey.Zero()
+	k.cryptoKey.Zero()
 }
 
 // AccountProperties contains properties associated with each account, such as
@@ -222,6 +245,58 @@ type AccountProperties struct {
 	AddrSchema *ScopeAddrSchema
 }
 
+// AccountMetadata houses user-provided information describing an account.
+// Unlike AccountProperties, none of it affects how the account derives keys.
+type AccountMetadata struct {
+	// Description is a free-form description of the account.
+	Description string
+
+	// Created is the time the account was created.  This is the zero time
+	// for accounts created before account metadata was recorded, which
+	// includes the default accounts of every wallet.
+	Created time.Time
+
+	// Tags is a list of labels describing the purpose of the account.
+	Tags []string
+
+	// AvoidReuse indicates that coin selection for the account must not
+	// combine outputs paying to dirty addresses, those which have
+	// previously been spent from, with outputs paying to clean addresses.
+	AvoidReuse bool
+
+	// DepositAlert is the amount at or above which a deposit to the
+	// account is alerted.  Zero disables deposit alerts.
+	DepositAlert btcutil.Amount
+}
+
+// SpendPolicy describes limits on the transactions spending from an account.
+// Zero amounts and an empty whitelist impose no limit.
+type SpendPolicy struct {
+	// MaxPerTx is the maximum total amount of the outputs of a single
+	// transaction.
+	MaxPerTx btcutil.Amount
+
+	// MaxPerDay is the maximum total amount sent by the transactions of
+	// the last 24 hours.
+	MaxPerDay btcutil.Amount
+
+	// Whitelist is the set of encoded addresses which transactions may
+	// pay to.
+	Whitelist []string
+}
+
+// AddressReservation describes the claim of an external address handed out for
+// an invoice.  Released reservations are kept so the unused address may be
+// handed out again.
+type AddressReservation struct {
+	// Released indicates the address was released and may be reserved
+	// again while unused.
+	Released bool
+
+	// Time is when the address was reserved or released.
+	Time time.Time
+}
+
 // unlockDeriveInfo houses the information needed to derive a private key for a
 // managed address when the address manager is unlocked.  See the
 // deriveOnUnlock field in the Manager struct for more details on how this is
@@ -327,6 +402,40 @@ const (
 	CKTPublic
 )
 
+// UnlockLevel is the privilege granted by unlocking the manager with its
+// private passphrase.  Each level allows everything the lower levels allow, so
+// that automated services can run with the minimum privilege they need.
+type UnlockLevel uint8
+
+// Unlock levels.
+const (
+	// UnlockView decrypts the private keys only for operations which
+	// reveal public data, such as deriving new accounts and their extended
+	// public keys, or importing private keys.  Nothing can be signed.
+	UnlockView UnlockLevel = iota
+
+	// UnlockSpend additionally allows the private keys to sign
+	// transactions and messages, but not to be exported.
+	UnlockSpend
+
+	// UnlockFull additionally allows the private keys to be exported.
+	UnlockFull
+)
+
+// String returns the name of the unlock level.
+func (l UnlockLevel) String() string {
+	switch l {
+	case UnlockView:
+		return "view"
+	case UnlockSpend:
+		return "spend"
+	case UnlockFull:
+		return "full"
+	default:
+		return fmt.Sprintf("unknown unlock level %d", uint8(l))
+	}
+}
+
 // newCryptoKey is used as a way to replace the new crypto key generation
 // function used so tests can provide a version that fails for testing error
 // paths.
@@ -348,6 +457,7 @@ type Manager struct {
 	watchingOnly bool
 	birthday     time.Time
 	locked       bool
+	unlockLevel  UnlockLevel
 	closed       bool
 	chainParams  *chaincfg.Params
 
@@ -435,8 +545,12 @@ func (m *Manager) IsWatchOnlyAccount(ns walletdb.ReadBucket, keyScope KeyScope,
 // This function MUST be called with the manager lock held for writes.
 func (m *Manager) lock() {
 	for _, manager := range m.scopedManagers {
-		// Clear all of the account private keys.
+		// Clear all of the account private keys.  Accounts protected
+		// by their own passphrase are locked independently.
 		for _, acctInfo := range manager.acctInfo {
+			if acctInfo.passphraseKey != nil {
+				continue
+			}
 			if acctInfo.acctKeyPriv != nil {
 				acctInfo.acctKeyPriv.Zero()
 			}
@@ -449,6 +563,10 @@ func (m *Manager) lock() {
 		for _, ma := range manager.addrs {
 			switch addr := ma.(type) {
 			case *managedAddress:
+				account := addr.InternalAccount()
+				if manager.hasAccountPassphrase(account) {
+					continue
+				}
 				addr.lock()
 			case *scriptAddress:
 				addr.lock()
@@ -746,6 +864,39 @@ func (m *Manager) MarkUsed(ns walletdb.ReadWriteBucket, address btcutil.Address)
 	return managerError(ErrAddressNotFound, str, nil)
 }
 
+// MarkDirty updates the dirty flag for the provided address.  An address is
+// dirty once any output paying to it has been spent.  Dirty addresses are
+// always considered used as well.
+func (m *Manager) MarkDirty(ns walletdb.ReadWriteBucket, address btcutil.Address) error {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	for _, scopedMgr := range m.scopedManagers {
+		if _, err := scopedMgr.Address(ns, address); err != nil {
+			continue
+		}
+
+		return scopedMgr.MarkDirty(ns, address)
+	}
+
+	str := fmt.Sprintf("unable to find key for addr %v", address)
+	return managerError(ErrAddressNotFound, str, nil)
+}
+
+// IsDirty returns whether the provided address has been marked dirty.
+// Addresses unknown to the manager are never dirty.
+func (m *Manager) IsDirty(ns walletdb.ReadBucket, address btcutil.Address) bool {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	for _, scopedMgr := range m.scopedManagers {
+		if scopedMgr.IsDirty(ns, address) {
+			return true
+		}
+	}
+	return false
+}
+
 // AddrAccount returns the account to which the given address belongs. We also
 // return the scoped manager that owns the addr+account combo.
 func (m *Manager) AddrAccount(ns walletdb.ReadBucket,
@@ -868,6 +1019,63 @@ func (m *Manager) ForEachAccountAddress(ns walletdb.ReadBucket, account uint32,
 	return nil
 }
 
+// AddressLabel returns the label of an address, or an empty string if the
+// address has no label.
+func (m *Manager) AddressLabel(ns walletdb.ReadBucket,
+	addr btcutil.Address) string {
+
+	return fetchAddressLabel(ns, addr.EncodeAddress())
+}
+
+// SetAddressLabel sets the label of an address, which need not belong to the
+// manager.  An empty label removes the label of the address.
+func (m *Manager) SetAddressLabel(ns walletdb.ReadWriteBucket,
+	addr btcutil.Address, label string) error {
+
+	return putAddressLabel(ns, addr.EncodeAddress(), label)
+}
+
+// ForEachAddressLabel calls fn with every labeled address and its label.
+// Addresses are passed encoded, since they may not belong to the manager.
+func (m *Manager) ForEachAddressLabel(ns walletdb.ReadBucket,
+	fn func(addr, label string) error) error {
+
+	return forEachAddressLabel(ns, fn)
+}
+
+// AddressReservation returns the reservation of an address, or nil if the
+// address was never reserved or its reservation ended.
+func (m *Manager) AddressReservation(ns walletdb.ReadBucket,
+	addr btcutil.Address) (*AddressReservation, error) {
+
+	return fetchAddressReservation(ns, addr.EncodeAddress())
+}
+
+// SetAddressReservation stores the reservation of an address.  A nil
+// reservation removes the reservation of the address.
+func (m *Manager) SetAddressReservation(ns walletdb.ReadWriteBucket,
+	addr btcutil.Address, r *AddressReservation) error {
+
+	return putAddressReservation(ns, addr.EncodeAddress(), r)
+}
+
+// ForEachAddressReservation calls fn with every reserved or released address,
+// encoded, and its reservation, ordered by their encoding.
+func (m *Manager) ForEachAddressReservation(ns walletdb.ReadBucket,
+	fn func(addr string, r *AddressReservation) error) error {
+
+	return forEachAddressReservation(ns, fn)
+}
+
+// AddressBirthday returns the birthday block recorded for an address when it
+// was created or imported, or nil if none was recorded.  Addresses created
+// before birthday blocks were recorded have none.
+func (m *Manager) AddressBirthday(ns walletdb.ReadBucket,
+	addr btcutil.Address) (*BlockStamp, error) {
+
+	return fetchAddressBirthday(ns, addr.EncodeAddress())
+}
+
 // ChainParams returns the chain parameters for this address manager.
 func (m *Manager) ChainParams() *chaincfg.Params {
 	// NOTE: No need for mutex here since the net field does not change
@@ -1079,9 +1287,14 @@ func (m *Manager) ConvertToWatchingOnly(ns walletdb.ReadWriteBucket) error {
 	// is being converted to watching-only, the encrypted private key
 	// material is no longer needed.
 
-	// Clear and remove all of the encrypted acount private keys.
+	// Clear and remove all of the encrypted acount private keys, along with
+	// the keys of the accounts protected by their own passphrase.
 	for _, manager := range m.scopedManagers {
-		for _, acctInfo := range manager.acctInfo {
+		for account, acctInfo := range manager.acctInfo {
+			if acctInfo.passphraseKey != nil {
+				manager.lockAccount(account, acctInfo)
+				acctInfo.passphraseKey = nil
+			}
 			zero.Bytes(acctInfo.acctKeyEncrypted)
 			acctInfo.acctKeyEncrypted = nil
 		}
@@ -1131,6 +1344,15 @@ func (m *Manager) IsLocked() bool {
 	return m.isLocked()
 }
 
+// UnlockLevel returns the privilege granted to the private keys of the accounts
+// protected by the private passphrase while the manager is unlocked.
+func (m *Manager) UnlockLevel() UnlockLevel {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	return m.unlockLevel
+}
+
 // isLocked is an internal method returning whether or not the address manager
 // is locked via an unprotected read.
 //
@@ -1172,6 +1394,16 @@ func (m *Manager) Lock() error {
 // This function will return an error if invoked on a watching-only address
 // manager.
 func (m *Manager) Unlock(ns walletdb.ReadBucket, passphrase []byte) error {
+	return m.UnlockWithLevel(ns, passphrase, UnlockFull)
+}
+
+// UnlockWithLevel unlocks the manager like Unlock, but only grants the
+// privilege of the unlock level to the private keys of the accounts protected
+// by the private passphrase.  Unlocking an unlocked manager replaces its
+// unlock level.
+func (m *Manager) UnlockWithLevel(ns walletdb.ReadBucket, passphrase []byte,
+	level UnlockLevel) error {
+
 	// A watching-only address manager can't be unlocked.
 	if m.watchingOnly {
 		return managerError(ErrWatchingOnly, errWatchingOnly, nil)
@@ -1192,6 +1424,7 @@ func (m *Manager) Unlock(ns walletdb.ReadBucket, passphrase []byte) error {
 			str := "invalid passphrase for master private key"
 			return managerError(ErrWrongPassphrase, str, nil)
 		}
+		m.unlockLevel = level
 		return nil
 	}
 
@@ -1218,9 +1451,13 @@ func (m *Manager) Unlock(ns walletdb.ReadBucket, passphrase []byte) error {
 	zero.Bytes(decryptedKey)
 
 	// Use the crypto private key to decrypt all of the account private
-	// extended keys.
+	// extended keys, except for those of the accounts protected by their
+	// own passphrase.
 	for _, manager := range m.scopedManagers {
 		for account, acctInfo := range manager.acctInfo {
+			if acctInfo.passphraseKey != nil {
+				continue
+			}
 			decrypted, err := m.cryptoKeyPriv.Decrypt(acctInfo.acctKeyEncrypted)
 			if err != nil {
 				m.lock()
@@ -1242,46 +1479,21 @@ func (m *Manager) Unlock(ns walletdb.ReadBucket, passphrase []byte) error {
 
 		// We'll also derive any private keys that are pending due to
 		// them being created while the address manager was locked.
-		for _, info := range manager.deriveOnUnlock {
-			addressKey, _, _, err := manager.deriveKeyFromPath(
-				ns, info.managedAddr.InternalAccount(),
-				info.branch, info.index, true,
-			)
-			if err != nil {
-				m.lock()
-				return err
-			}
-
-			// It's ok to ignore the error here since it can only
-			// fail if the extended key is not private, however it
-			// was just derived as a private key.
-			privKey, _ := addressKey.ECPrivKey()
-			addressKey.Zero()
-
-			privKeyBytes := privKey.Serialize()
-			privKeyEncrypted, err := m.cryptoKeyPriv.Encrypt(privKeyBytes)
-			zero.BigInt(privKey.D)
-			if err != nil {
-				m.lock()
-				str := fmt.Sprintf("failed to encrypt private key for "+
-					"address %s", info.managedAddr.Address())
-				return managerError(ErrCrypto, str, err)
-			}
-
-			switch a := info.managedAddr.(type) {
-			case *managedAddress:
-				a.privKeyEncrypted = privKeyEncrypted
-				a.privKeyCT = privKeyBytes
-			case *scriptAddress:
-			}
-
-			// Avoid re-deriving this key on subsequent unlocks.
-			manager.deriveOnUnlock[0] = nil
-			manager.deriveOnUnlock = manager.deriveOnUnlock[1:]
+		// The keys of accounts protected by their own passphrase are
+		// derived once those accounts are unlocked.
+		err := manager.deriveKeysOnUnlock(
+			ns, m.cryptoKeyPriv, func(account uint32) bool {
+				return !manager.hasAccountPassphrase(account)
+			},
+		)
+		if err != nil {
+			m.lock()
+			return err
 		}
 	}
 
 	m.locked = false
+	m.unlockLevel = level
 	saltedPassphrase := append(m.privPassphraseSalt[:], passphrase...)
 	m.hashedPrivPassphrase = sha512.Sum512(saltedPassphrase)
 	zero.Bytes(saltedPassphrase)
@@ -1808,8 +2020,43 @@ func Create(ns walletdb.ReadWriteBucket, rootKey *hdkeychain.ExtendedKey,
 	chainParams *chaincfg.Params, config *ScryptOptions,
 	birthday time.Time) error {
 
-	// If the seed argument is nil we create in watchingOnly mode.
-	isWatchingOnly := rootKey == nil
+	return create(
+		ns, rootKey, pubPassphrase, privPassphrase, chainParams,
+		config, birthday, false,
+	)
+}
+
+// CreateBlank creates a new address manager in the given namespace which
+// holds private keys, but has no master root node from which hierarchical
+// deterministic addresses are derived.  The default scoped managers are
+// created with only their imported accounts, so the manager holds no keys
+// until they are imported.
+//
+// The passphrases protect the keys of the manager as described for Create.
+//
+// A ManagerError with an error code of ErrAlreadyExists will be
+// returned the address manager already exists in the specified
+// namespace.
+func CreateBlank(ns walletdb.ReadWriteBucket, pubPassphrase,
+	privPassphrase []byte, chainParams *chaincfg.Params,
+	config *ScryptOptions, birthday time.Time) error {
+
+	return create(
+		ns, nil, pubPassphrase, privPassphrase, chainParams, config,
+		birthday, true,
+	)
+}
+
+// create creates a new address manager in the given namespace, which is blank
+// when set and the root key is nil.
+func create(ns walletdb.ReadWriteBucket, rootKey *hdkeychain.ExtendedKey,
+	pubPassphrase, privPassphrase []byte,
+	chainParams *chaincfg.Params, config *ScryptOptions,
+	birthday time.Time, blank bool) error {
+
+	// If the seed argument is nil we create in watchingOnly mode, unless
+	// the manager is blank.
+	isWatchingOnly := rootKey == nil && !blank
 
 	// Return an error if the manager has already been created in
 	// the given database namespace.
@@ -1876,6 +2123,7 @@ func Create(ns walletdb.ReadWriteBucket, rootKey *hdkeychain.ExtendedKey,
 
 	var privParams []byte
 	var masterKeyPriv *snacl.SecretKey
+	var cryptoKeyPriv EncryptorDecryptor
 	var cryptoKeyPrivEnc []byte
 	var cryptoKeyScriptEnc []byte
 	if !isWatchingOnly {
@@ -1896,7 +2144,7 @@ func Create(ns walletdb.ReadWriteBucket, rootKey *hdkeychain.ExtendedKey,
 			return managerError(ErrCrypto, str, err)
 		}
 
-		cryptoKeyPriv, err := newCryptoKey()
+		cryptoKeyPriv, err = newCryptoKey()
 		if err != nil {
 			str := "failed to generate crypto private key"
 			return managerError(ErrCrypto, str, err)
@@ -1922,6 +2170,29 @@ func Create(ns walletdb.ReadWriteBucket, rootKey *hdkeychain.ExtendedKey,
 			return managerError(ErrCrypto, str, err)
 		}
 
+		privParams = masterKeyPriv.Marshal()
+	}
+
+	// Blank managers only hold the imported accounts of the default
+	// scopes, so no last account is recorded for them.
+	if blank {
+		for _, defaultScope := range DefaultKeyScopes {
+			defaultScope := defaultScope
+			err := putDefaultAccountInfo(
+				ns, &defaultScope, ImportedAddrAccount, nil,
+				nil, 0, 0, ImportedAddrAccountName,
+			)
+			if err != nil {
+				return maybeConvertDbError(err)
+			}
+			err = putLastAccount(ns, &defaultScope, noLastAccount)
+			if err != nil {
+				return maybeConvertDbError(err)
+			}
+		}
+	}
+
+	if rootKey != nil {
 		// Generate the BIP0044 HD key structure to ensure the
 		// provided seed can generate the required structure with no
 		// issues.
@@ -1961,8 +2232,6 @@ func Create(ns walletdb.ReadWriteBucket, rootKey *hdkeychain.ExtendedKey,
 		if err != nil {
 			return maybeConvertDbError(err)
 		}
-
-		privParams = masterKeyPriv.Marshal()
 	}
 
 	// Save the master key params to the database.
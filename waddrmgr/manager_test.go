@@ -2957,3 +2957,469 @@ func TestDeriveFromKeyPathCache(t *testing.T) {
 	require.Equal(t, cachedKey.Serialize(), cachedKey2.Serialize())
 	require.Equal(t, derivedKey.Serialize(), cachedKey2.Serialize())
 }
+
+// TestAccountMetadata ensures that account metadata is recorded for new
+// accounts and can be updated without losing the account's creation time or
+// flags.
+func TestAccountMetadata(t *testing.T) {
+	t.Parallel()
+
+	teardown, db := emptyDB(t)
+	defer teardown()
+
+	var mgr *Manager
+	err := walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(waddrmgrNamespaceKey)
+		if err != nil {
+			return err
+		}
+		err = Create(
+			ns, rootKey, pubPassphrase, privPassphrase,
+			&chaincfg.MainNetParams, fastScrypt, time.Time{},
+		)
+		if err != nil {
+			return err
+		}
+
+		mgr, err = Open(ns, pubPassphrase, &chaincfg.MainNetParams)
+		if err != nil {
+			return err
+		}
+
+		return mgr.Unlock(ns, privPassphrase)
+	})
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	scopedMgr, err := mgr.FetchScopedKeyManager(KeyScopeBIP0084)
+	require.NoError(t, err)
+
+	// The default account predates any metadata, so it should have none.
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		meta, err := scopedMgr.AccountMetadata(ns, DefaultAccountNum)
+		if err != nil {
+			return err
+		}
+		require.Equal(t, &AccountMetadata{}, meta)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// New accounts should have their creation time recorded, which must
+	// survive updates of the description and tags.
+	before := time.Now().Add(-time.Second)
+	var account uint32
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		var err error
+		account, err = scopedMgr.NewAccount(ns, "savings")
+		if err != nil {
+			return err
+		}
+		err = scopedMgr.SetAccountMetadata(
+			ns, account, "long term savings", []string{"cold", "hodl"},
+		)
+		if err != nil {
+			return err
+		}
+		err = scopedMgr.SetAccountAvoidReuse(ns, account, true)
+		if err != nil {
+			return err
+		}
+		return scopedMgr.SetAccountDepositAlert(ns, account, 5e8)
+	})
+	require.NoError(t, err)
+
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		meta, err := scopedMgr.AccountMetadata(ns, account)
+		if err != nil {
+			return err
+		}
+		require.True(t, meta.Created.After(before))
+		require.Equal(t, "long term savings", meta.Description)
+		require.Equal(t, []string{"cold", "hodl"}, meta.Tags)
+		require.True(t, meta.AvoidReuse)
+		require.Equal(t, btcutil.Amount(5e8), meta.DepositAlert)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Metadata of unknown accounts can neither be fetched nor set.
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return scopedMgr.SetAccountMetadata(ns, account+1, "", nil)
+	})
+	require.True(t, IsError(err, ErrAccountNotFound))
+}
+
+// TestAccountPassphrase ensures that an account protected by its own
+// passphrase is locked and unlocked independently of the manager, including
+// across reopening the manager, and can be returned to the protection of the
+// private passphrase of the manager.
+func TestAccountPassphrase(t *testing.T) {
+	t.Parallel()
+
+	teardown, db := emptyDB(t)
+	defer teardown()
+
+	var mgr *Manager
+	err := walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(waddrmgrNamespaceKey)
+		if err != nil {
+			return err
+		}
+		err = Create(
+			ns, rootKey, pubPassphrase, privPassphrase,
+			&chaincfg.MainNetParams, fastScrypt, time.Time{},
+		)
+		if err != nil {
+			return err
+		}
+
+		mgr, err = Open(ns, pubPassphrase, &chaincfg.MainNetParams)
+		if err != nil {
+			return err
+		}
+
+		return mgr.Unlock(ns, privPassphrase)
+	})
+	require.NoError(t, err)
+
+	scopedMgr, err := mgr.FetchScopedKeyManager(KeyScopeBIP0084)
+	require.NoError(t, err)
+
+	acctPassphrase := []byte("account passphrase")
+	var (
+		account uint32
+		addr    ManagedPubKeyAddress
+	)
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		var err error
+		account, err = scopedMgr.NewAccount(ns, "savings")
+		if err != nil {
+			return err
+		}
+		addrs, err := scopedMgr.NextExternalAddresses(ns, account, 1)
+		if err != nil {
+			return err
+		}
+		addr = addrs[0].(ManagedPubKeyAddress)
+
+		return scopedMgr.SetAccountPassphrase(
+			ns, account, acctPassphrase, fastScrypt,
+		)
+	})
+	require.NoError(t, err)
+	privKey, err := addr.PrivKey()
+	require.NoError(t, err)
+
+	accountLocked := func(mgr *ScopedKeyManager, account uint32) bool {
+		var locked bool
+		err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+			ns := tx.ReadBucket(waddrmgrNamespaceKey)
+			var err error
+			locked, err = mgr.IsAccountLocked(ns, account)
+			return err
+		})
+		require.NoError(t, err)
+		return locked
+	}
+	unlockAccount := func(mgr *ScopedKeyManager, passphrase []byte) error {
+		return walletdb.View(db, func(tx walletdb.ReadTx) error {
+			ns := tx.ReadBucket(waddrmgrNamespaceKey)
+			return mgr.UnlockAccount(ns, account, passphrase)
+		})
+	}
+
+	// Locking the manager leaves the account unlocked, while locking the
+	// account leaves the manager unlocked.
+	require.NoError(t, mgr.Lock())
+	require.False(t, accountLocked(scopedMgr, account))
+	require.True(t, accountLocked(scopedMgr, DefaultAccountNum))
+	_, err = addr.PrivKey()
+	require.NoError(t, err)
+
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		return mgr.Unlock(ns, privPassphrase)
+	})
+	require.NoError(t, err)
+	require.NoError(t, scopedMgr.LockAccount(account))
+	require.True(t, accountLocked(scopedMgr, account))
+	require.False(t, accountLocked(scopedMgr, DefaultAccountNum))
+	_, err = addr.PrivKey()
+	require.True(t, IsError(err, ErrLocked))
+
+	// Addresses created while the account is locked have their private
+	// keys derived once the account is unlocked with its passphrase.
+	var lockedAddr ManagedPubKeyAddress
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		addrs, err := scopedMgr.NextExternalAddresses(ns, account, 1)
+		if err != nil {
+			return err
+		}
+		lockedAddr = addrs[0].(ManagedPubKeyAddress)
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = unlockAccount(scopedMgr, privPassphrase)
+	require.True(t, IsError(err, ErrWrongPassphrase))
+	require.NoError(t, unlockAccount(scopedMgr, acctPassphrase))
+	unlockedKey, err := addr.PrivKey()
+	require.NoError(t, err)
+	require.Equal(t, privKey.Serialize(), unlockedKey.Serialize())
+	_, err = lockedAddr.PrivKey()
+	require.NoError(t, err)
+
+	// The account must still be protected by its own passphrase once the
+	// manager is reopened.
+	mgr.Close()
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		var err error
+		mgr, err = Open(ns, pubPassphrase, &chaincfg.MainNetParams)
+		if err != nil {
+			return err
+		}
+		return mgr.Unlock(ns, privPassphrase)
+	})
+	require.NoError(t, err)
+	defer mgr.Close()
+	scopedMgr, err = mgr.FetchScopedKeyManager(KeyScopeBIP0084)
+	require.NoError(t, err)
+
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		hasPassphrase, err := scopedMgr.HasAccountPassphrase(ns, account)
+		if err != nil {
+			return err
+		}
+		require.True(t, hasPassphrase)
+
+		ma, err := scopedMgr.Address(ns, addr.Address())
+		if err != nil {
+			return err
+		}
+		addr = ma.(ManagedPubKeyAddress)
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, accountLocked(scopedMgr, account))
+	_, err = addr.PrivKey()
+	require.True(t, IsError(err, ErrLocked))
+	require.NoError(t, unlockAccount(scopedMgr, acctPassphrase))
+	unlockedKey, err = addr.PrivKey()
+	require.NoError(t, err)
+	require.Equal(t, privKey.Serialize(), unlockedKey.Serialize())
+
+	// Removing the passphrase returns the account to the protection of the
+	// private passphrase of the manager.
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return scopedMgr.SetAccountPassphrase(ns, account, nil, fastScrypt)
+	})
+	require.NoError(t, err)
+	require.NoError(t, mgr.Lock())
+	require.True(t, accountLocked(scopedMgr, account))
+	_, err = addr.PrivKey()
+	require.True(t, IsError(err, ErrLocked))
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		return mgr.Unlock(ns, privPassphrase)
+	})
+	require.NoError(t, err)
+	unlockedKey, err = addr.PrivKey()
+	require.NoError(t, err)
+	require.Equal(t, privKey.Serialize(), unlockedKey.Serialize())
+
+	// The imported account is always protected by the private passphrase
+	// of the manager.
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return scopedMgr.SetAccountPassphrase(
+			ns, ImportedAddrAccount, acctPassphrase, fastScrypt,
+		)
+	})
+	require.True(t, IsError(err, ErrInvalidAccount))
+}
+
+// TestUnlockLevel ensures the unlock level of the manager restricts the use of
+// the private keys protected by the private passphrase, while still allowing
+// new accounts to be derived at the lowest level.
+func TestUnlockLevel(t *testing.T) {
+	t.Parallel()
+
+	teardown, db := emptyDB(t)
+	defer teardown()
+
+	var mgr *Manager
+	err := walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket(waddrmgrNamespaceKey)
+		if err != nil {
+			return err
+		}
+		err = Create(
+			ns, rootKey, pubPassphrase, privPassphrase,
+			&chaincfg.MainNetParams, fastScrypt, time.Time{},
+		)
+		if err != nil {
+			return err
+		}
+
+		mgr, err = Open(ns, pubPassphrase, &chaincfg.MainNetParams)
+		return err
+	})
+	require.NoError(t, err)
+
+	scopedMgr, err := mgr.FetchScopedKeyManager(KeyScopeBIP0084)
+	require.NoError(t, err)
+
+	unlock := func(level UnlockLevel) {
+		t.Helper()
+		err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+			ns := tx.ReadBucket(waddrmgrNamespaceKey)
+			return mgr.UnlockWithLevel(ns, privPassphrase, level)
+		})
+		require.NoError(t, err)
+		require.Equal(t, level, mgr.UnlockLevel())
+	}
+
+	// Deriving a new account only requires the view level.
+	unlock(UnlockView)
+	var addr ManagedPubKeyAddress
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		account, err := scopedMgr.NewAccount(ns, "savings")
+		if err != nil {
+			return err
+		}
+		addrs, err := scopedMgr.NextExternalAddresses(ns, account, 1)
+		if err != nil {
+			return err
+		}
+		addr = addrs[0].(ManagedPubKeyAddress)
+		return nil
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		level     UnlockLevel
+		canSign   bool
+		canExport bool
+	}{
+		{level: UnlockView},
+		{level: UnlockSpend, canSign: true},
+		{level: UnlockFull, canSign: true, canExport: true},
+	}
+	for _, test := range tests {
+		// Unlocking the unlocked manager replaces its level.
+		unlock(test.level)
+
+		_, err := addr.PrivKey()
+		if test.canSign {
+			require.NoError(t, err, test.level)
+		} else {
+			require.True(t, IsError(err, ErrLocked), test.level)
+		}
+
+		_, err = addr.ExportPrivKey()
+		if test.canExport {
+			require.NoError(t, err, test.level)
+		} else {
+			require.True(t, IsError(err, ErrLocked), test.level)
+		}
+	}
+}
+
+// TestAddressBirthday ensures that derived addresses record the block the
+// manager is synced to as their birthday block, and imported addresses the
+// block stamp they were imported with, or the genesis block without one.
+func TestAddressBirthday(t *testing.T) {
+	t.Parallel()
+
+	teardown, db, mgr := setupManager(t)
+	defer teardown()
+
+	scopedMgr, err := mgr.FetchScopedKeyManager(KeyScopeBIP0084)
+	require.NoError(t, err)
+
+	syncedTo := BlockStamp{
+		Height:    1000,
+		Hash:      chainhash.Hash{0x01},
+		Timestamp: time.Unix(1600000000, 0),
+	}
+	importedAt := BlockStamp{
+		Height:    500,
+		Hash:      chainhash.Hash{0x02},
+		Timestamp: time.Unix(1500000000, 0),
+	}
+	genesis := BlockStamp{
+		Hash:      *chaincfg.MainNetParams.GenesisHash,
+		Timestamp: chaincfg.MainNetParams.GenesisBlock.Header.Timestamp,
+	}
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	privKey2, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+
+	var derived, imported, importedNoStamp ManagedAddress
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		if err := mgr.SetSyncedTo(ns, &syncedTo); err != nil {
+			return err
+		}
+
+		addrs, err := scopedMgr.NextExternalAddresses(
+			ns, DefaultAccountNum, 1,
+		)
+		if err != nil {
+			return err
+		}
+		derived = addrs[0]
+
+		imported, err = scopedMgr.ImportPublicKey(
+			ns, privKey.PubKey(), &importedAt,
+		)
+		if err != nil {
+			return err
+		}
+		importedNoStamp, err = scopedMgr.ImportPublicKey(
+			ns, privKey2.PubKey(), nil,
+		)
+		return err
+	})
+	require.NoError(t, err)
+
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		tests := []struct {
+			addr     ManagedAddress
+			birthday BlockStamp
+		}{
+			{derived, syncedTo},
+			{imported, importedAt},
+			{importedNoStamp, genesis},
+		}
+		for _, test := range tests {
+			birthday, err := mgr.AddressBirthday(ns, test.addr.Address())
+			if err != nil {
+				return err
+			}
+			require.NotNil(t, birthday)
+			require.Equal(t, test.birthday.Height, birthday.Height)
+			require.Equal(t, test.birthday.Hash, birthday.Hash)
+			require.Equal(
+				t, test.birthday.Timestamp.Unix(),
+				birthday.Timestamp.Unix(),
+			)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
@@ -12,8 +13,10 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/internal/securemem"
 	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/btcsuite/btcwallet/netparams"
+	"github.com/btcsuite/btcwallet/snacl"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/lightninglabs/neutrino/cache/lru"
 )
@@ -280,6 +283,14 @@ func (s *ScopedKeyManager) Close() {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
+	// Remove the private keys of the accounts protected by their own
+	// passphrase from memory, as these are not locked with the manager.
+	for account, acctInfo := range s.acctInfo {
+		if acctInfo.passphraseKey != nil {
+			s.lockAccount(account, acctInfo)
+		}
+	}
+
 	// Attempt to clear sensitive public key material from memory too.
 	s.zeroSensitivePublicData()
 }
@@ -424,6 +435,18 @@ func (s *ScopedKeyManager) loadAccountInfo(ns walletdb.ReadBucket,
 			return nil, managerError(ErrCrypto, str, err)
 		}
 
+		// Accounts protected by their own passphrase are loaded
+		// locked, regardless of whether the manager is unlocked.
+		acctInfo.passphraseKey, err = s.loadAccountPassphraseKey(
+			ns, account,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if acctInfo.passphraseKey != nil {
+			hasPrivateKey = false
+		}
+
 		if hasPrivateKey {
 			// Use the crypto private key to decrypt the account
 			// private extended keys.
@@ -642,7 +665,7 @@ func (s *ScopedKeyManager) DeriveFromKeyPathCache(
 	}
 
 	watchOnly := s.rootManager.WatchOnly()
-	private := !s.rootManager.IsLocked() && !watchOnly
+	private := !watchOnly && acctInfo.acctKeyPriv != nil
 
 	// Now that we have the account information, we can derive the key
 	// directly.
@@ -677,8 +700,9 @@ func (s *ScopedKeyManager) DeriveFromKeyPath(ns walletdb.ReadBucket,
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	watchOnly := s.rootManager.WatchOnly()
-	private := !s.rootManager.IsLocked() && !watchOnly
+	// The private key is derived whenever the account private key is
+	// available, which depends on the lock state of the account.
+	private := !s.rootManager.WatchOnly()
 
 	addrKey, _, _, err := s.deriveKeyFromPath(
 		ns, kp.InternalAccount, kp.Branch, kp.Index, private,
@@ -731,8 +755,10 @@ func (s *ScopedKeyManager) chainAddressRowToManaged(ns walletdb.ReadBucket,
 	row *dbChainAddressRow) (ManagedAddress, error) {
 
 	// Since the manger's mutex is assumed to held when invoking this
-	// function, we use the internal isLocked to avoid a deadlock.
-	private := !s.rootManager.isLocked() && !s.rootManager.watchOnly()
+	// function, we use the internal watchOnly to avoid a deadlock.  The
+	// private key is derived whenever the account private key is
+	// available, which depends on the lock state of the account.
+	private := !s.rootManager.watchOnly()
 
 	addressKey, acctKey, masterKeyFingerprint, err := s.deriveKeyFromPath(
 		ns, row.account, row.branch, row.index, private,
@@ -971,11 +997,12 @@ func (s *ScopedKeyManager) nextAddresses(ns walletdb.ReadWriteBucket,
 		return nil, err
 	}
 
-	// Choose the account key to used based on whether the address manager
-	// is locked.
+	// Choose the account key to used based on whether the account is
+	// locked.
 	acctKey := acctInfo.acctKeyPub
 	watchOnly := s.rootManager.WatchOnly() || len(acctInfo.acctKeyEncrypted) == 0
-	if !s.rootManager.IsLocked() && !watchOnly {
+	private := acctInfo.acctKeyPriv != nil && !watchOnly
+	if private {
 		acctKey = acctInfo.acctKeyPriv
 	}
 
@@ -1075,7 +1102,10 @@ func (s *ScopedKeyManager) nextAddresses(ns walletdb.ReadWriteBucket,
 	}
 
 	// Now that all addresses have been successfully generated, update the
-	// database in a single transaction.
+	// database in a single transaction.  The addresses could not have been
+	// used before the block the manager is synced to, which is recorded as
+	// their birthday block.
+	syncedTo := s.rootManager.SyncedTo()
 	for _, info := range addressInfo {
 		ma := info.managedAddr
 		addressID := ma.Address().ScriptAddress()
@@ -1105,6 +1135,13 @@ func (s *ScopedKeyManager) nextAddresses(ns walletdb.ReadWriteBucket,
 				return nil, maybeConvertDbError(err)
 			}
 		}
+
+		err := putAddressBirthday(
+			ns, ma.Address().EncodeAddress(), &syncedTo,
+		)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	managedAddresses := make([]ManagedAddress, 0, len(addressInfo))
@@ -1130,8 +1167,8 @@ func (s *ScopedKeyManager) nextAddresses(ns walletdb.ReadWriteBucket,
 
 			// Add the new managed address to the list of addresses
 			// that need their private keys derived when the
-			// address manager is next unlocked.
-			if s.rootManager.isLocked() && !watchOnly {
+			// account is next unlocked.
+			if !private && !watchOnly {
 				s.deriveOnUnlock = append(s.deriveOnUnlock, info)
 			}
 		}
@@ -1168,11 +1205,12 @@ func (s *ScopedKeyManager) extendAddresses(ns walletdb.ReadWriteBucket,
 		return err
 	}
 
-	// Choose the account key to used based on whether the address manager
-	// is locked.
+	// Choose the account key to used based on whether the account is
+	// locked.
 	acctKey := acctInfo.acctKeyPub
-	watchOnly := s.rootManager.WatchOnly() || acctInfo.acctKeyPriv != nil
-	if !s.rootManager.IsLocked() && !watchOnly {
+	watchOnly := s.rootManager.WatchOnly() || len(acctInfo.acctKeyEncrypted) == 0
+	private := acctInfo.acctKeyPriv != nil && !watchOnly
+	if private {
 		acctKey = acctInfo.acctKeyPriv
 	}
 
@@ -1279,7 +1317,10 @@ func (s *ScopedKeyManager) extendAddresses(ns walletdb.ReadWriteBucket,
 	}
 
 	// Now that all addresses have been successfully generated, update the
-	// database in a single transaction.
+	// database in a single transaction.  The addresses could not have been
+	// used before the block the manager is synced to, which is recorded as
+	// their birthday block.
+	syncedTo := s.rootManager.SyncedTo()
 	for _, info := range addressInfo {
 		ma := info.managedAddr
 		addressID := ma.Address().ScriptAddress()
@@ -1309,6 +1350,13 @@ func (s *ScopedKeyManager) extendAddresses(ns walletdb.ReadWriteBucket,
 				return maybeConvertDbError(err)
 			}
 		}
+
+		err := putAddressBirthday(
+			ns, ma.Address().EncodeAddress(), &syncedTo,
+		)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Finally update the next address tracking and add the addresses to
@@ -1319,9 +1367,9 @@ func (s *ScopedKeyManager) extendAddresses(ns walletdb.ReadWriteBucket,
 		s.addrs[addrKey(ma.Address().ScriptAddress())] = ma
 
 		// Add the new managed address to the list of addresses that
-		// need their private keys derived when the address manager is
-		// next unlocked.
-		if s.rootManager.IsLocked() && !watchOnly {
+		// need their private keys derived when the account is next
+		// unlocked.
+		if !private && !watchOnly {
 			s.deriveOnUnlock = append(s.deriveOnUnlock, info)
 		}
 	}
@@ -1643,6 +1691,14 @@ func (s *ScopedKeyManager) newAccount(ns walletdb.ReadWriteBucket,
 		return err
 	}
 
+	// Record the creation time of the account.
+	err = putAccountMetadata(
+		ns, &s.scope, account, &AccountMetadata{Created: time.Now()},
+	)
+	if err != nil {
+		return err
+	}
+
 	// Save last account metadata
 	return putLastAccount(ns, &s.scope, account)
 }
@@ -1732,6 +1788,14 @@ func (s *ScopedKeyManager) newAccountWatchingOnly(ns walletdb.ReadWriteBucket,
 		return err
 	}
 
+	// Record the creation time of the account.
+	err = putAccountMetadata(
+		ns, &s.scope, account, &AccountMetadata{Created: time.Now()},
+	)
+	if err != nil {
+		return err
+	}
+
 	// Save last account metadata
 	return putLastAccount(ns, &s.scope, account)
 }
@@ -1820,6 +1884,626 @@ func (s *ScopedKeyManager) RenameAccount(ns walletdb.ReadWriteBucket,
 	return err
 }
 
+// MergeAccount moves the addresses of an account into another account, so that
+// the addresses, and the outputs and transactions paying to them, are
+// accounted to the other account.  The merge only changes the bookkeeping of
+// the manager: the keys of the addresses are still derived from the account
+// they were derived from, which is kept, and the addresses derived from it
+// after the merge belong to it.  Reserved accounts can not be merged, and only
+// accounts of the same kind, which are both watch-only or both spendable and
+// are not protected by their own passphrase, can be merged.
+func (s *ScopedKeyManager) MergeAccount(ns walletdb.ReadWriteBucket,
+	from, into uint32) error {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if isReservedAccountNum(from) || isReservedAccountNum(into) {
+		str := "reserved account cannot be merged"
+		return managerError(ErrInvalidAccount, str, nil)
+	}
+	if from == into {
+		str := "account cannot be merged into itself"
+		return managerError(ErrInvalidAccount, str, nil)
+	}
+
+	var watchOnly [2]bool
+	for i, account := range []uint32{from, into} {
+		rowInterface, err := fetchAccountInfo(ns, &s.scope, account)
+		if err != nil {
+			return maybeConvertDbError(err)
+		}
+		_, watchOnly[i] = rowInterface.(*dbWatchOnlyAccountRow)
+
+		// The keys of accounts protected by their own passphrase
+		// could be used to spend from the other account without the
+		// passphrase, or not at all.
+		masterKeyParams, _, err := fetchAccountPassphraseKey(
+			ns, &s.scope, account,
+		)
+		if err != nil {
+			return maybeConvertDbError(err)
+		}
+		if masterKeyParams != nil {
+			str := fmt.Sprintf("account %d is protected by its "+
+				"own passphrase", account)
+			return managerError(ErrInvalidAccount, str, nil)
+		}
+	}
+	if watchOnly[0] != watchOnly[1] {
+		str := "watch-only and spendable accounts cannot be merged"
+		return managerError(ErrInvalidAccount, str, nil)
+	}
+
+	return moveAddrAccountIndex(ns, &s.scope, from, into)
+}
+
+// AccountMetadata returns the user-provided metadata of an account.  Empty
+// metadata is returned for accounts which have none recorded.
+func (s *ScopedKeyManager) AccountMetadata(ns walletdb.ReadBucket,
+	account uint32) (*AccountMetadata, error) {
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	// Ensure the account exists so that metadata is never reported for
+	// unknown accounts.
+	if _, err := fetchAccountInfo(ns, &s.scope, account); err != nil {
+		return nil, err
+	}
+
+	return fetchAccountMetadata(ns, &s.scope, account)
+}
+
+// SetAccountMetadata replaces the description and tags of an account.  The
+// creation time of the account is always preserved.
+func (s *ScopedKeyManager) SetAccountMetadata(ns walletdb.ReadWriteBucket,
+	account uint32, description string, tags []string) error {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, err := fetchAccountInfo(ns, &s.scope, account); err != nil {
+		return err
+	}
+
+	meta, err := fetchAccountMetadata(ns, &s.scope, account)
+	if err != nil {
+		return err
+	}
+	meta.Description = description
+	meta.Tags = tags
+
+	return putAccountMetadata(ns, &s.scope, account, meta)
+}
+
+// SetAccountAvoidReuse sets whether coin selection for an account must avoid
+// combining outputs paying to dirty addresses with outputs paying to clean
+// ones.
+func (s *ScopedKeyManager) SetAccountAvoidReuse(ns walletdb.ReadWriteBucket,
+	account uint32, avoidReuse bool) error {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, err := fetchAccountInfo(ns, &s.scope, account); err != nil {
+		return err
+	}
+
+	meta, err := fetchAccountMetadata(ns, &s.scope, account)
+	if err != nil {
+		return err
+	}
+	meta.AvoidReuse = avoidReuse
+
+	return putAccountMetadata(ns, &s.scope, account, meta)
+}
+
+// SetAccountDepositAlert sets the amount at or above which a deposit to an
+// account is alerted.  A zero amount disables deposit alerts.
+func (s *ScopedKeyManager) SetAccountDepositAlert(ns walletdb.ReadWriteBucket,
+	account uint32, threshold btcutil.Amount) error {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, err := fetchAccountInfo(ns, &s.scope, account); err != nil {
+		return err
+	}
+
+	meta, err := fetchAccountMetadata(ns, &s.scope, account)
+	if err != nil {
+		return err
+	}
+	meta.DepositAlert = threshold
+
+	return putAccountMetadata(ns, &s.scope, account, meta)
+}
+
+// SpendPolicy returns the spend policy of an account, or nil if the account
+// has no policy.
+func (s *ScopedKeyManager) SpendPolicy(ns walletdb.ReadBucket,
+	account uint32) (*SpendPolicy, error) {
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if _, err := fetchAccountInfo(ns, &s.scope, account); err != nil {
+		return nil, err
+	}
+
+	return fetchSpendPolicy(ns, &s.scope, account)
+}
+
+// SetSpendPolicy replaces the spend policy of an account.  A nil policy
+// removes the policy.
+func (s *ScopedKeyManager) SetSpendPolicy(ns walletdb.ReadWriteBucket,
+	account uint32, policy *SpendPolicy) error {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, err := fetchAccountInfo(ns, &s.scope, account); err != nil {
+		return err
+	}
+
+	return putSpendPolicy(ns, &s.scope, account, policy)
+}
+
+// loadAccountPassphraseKey loads the keys of an account protected by its own
+// passphrase, which start off locked.  Nil is returned for accounts protected
+// by the private passphrase of the manager.
+func (s *ScopedKeyManager) loadAccountPassphraseKey(ns walletdb.ReadBucket,
+	account uint32) (*accountPassphraseKey, error) {
+
+	masterKeyParams, cryptoKeyEncrypted, err := fetchAccountPassphraseKey(
+		ns, &s.scope, account,
+	)
+	if err != nil {
+		return nil, maybeConvertDbError(err)
+	}
+	if masterKeyParams == nil {
+		return nil, nil
+	}
+
+	var masterKey snacl.SecretKey
+	if err := masterKey.Unmarshal(masterKeyParams); err != nil {
+		str := fmt.Sprintf("failed to unmarshal master key for "+
+			"account %d", account)
+		return nil, managerError(ErrCrypto, str, err)
+	}
+	return &accountPassphraseKey{
+		masterKey:          &masterKey,
+		cryptoKeyEncrypted: cryptoKeyEncrypted,
+		cryptoKey:          &cryptoKey{},
+	}, nil
+}
+
+// hasAccountPassphrase returns whether the account is protected by its own
+// passphrase.  Only cached accounts are considered, which includes the
+// accounts of all addresses cached by the manager.
+//
+// This function MUST be called with the manager lock held.
+func (s *ScopedKeyManager) hasAccountPassphrase(account uint32) bool {
+	acctInfo, ok := s.acctInfo[account]
+	return ok && acctInfo.passphraseKey != nil
+}
+
+// accountCryptoKey returns the crypto key which encrypts the private keys of
+// the account's addresses in memory.  This is the account's own crypto key for
+// accounts protected by their own passphrase, and the crypto private key of
+// the manager otherwise.
+//
+// This function MUST be called with the manager lock held.
+func (s *ScopedKeyManager) accountCryptoKey(account uint32) EncryptorDecryptor {
+	if acctInfo, ok := s.acctInfo[account]; ok && acctInfo.passphraseKey != nil {
+		return acctInfo.passphraseKey.cryptoKey
+	}
+	return s.rootManager.cryptoKeyPriv
+}
+
+// accountLocked returns whether the private keys of the account's addresses
+// are unavailable, as the account, or the manager for accounts protected by
+// the private passphrase of the manager, is locked.
+//
+// This function MUST be called with the manager lock held.
+func (s *ScopedKeyManager) accountLocked(account uint32) bool {
+	if acctInfo, ok := s.acctInfo[account]; ok && acctInfo.passphraseKey != nil {
+		return acctInfo.acctKeyPriv == nil
+	}
+	return s.rootManager.IsLocked()
+}
+
+// deriveKeysOnUnlock derives the private keys that are pending due to their
+// addresses being created while their accounts were locked, encrypting them
+// with the crypto key.  Only the keys of the accounts matched by the filter
+// are derived, while the others remain pending.
+//
+// This function MUST be called with the manager lock held for writes.
+func (s *ScopedKeyManager) deriveKeysOnUnlock(ns walletdb.ReadBucket,
+	cryptoKey EncryptorDecryptor, filter func(account uint32) bool) error {
+
+	var pending []*unlockDeriveInfo
+	for i, info := range s.deriveOnUnlock {
+		account := info.managedAddr.InternalAccount()
+		if !filter(account) {
+			pending = append(pending, info)
+			continue
+		}
+
+		addressKey, _, _, err := s.deriveKeyFromPath(
+			ns, account, info.branch, info.index, true,
+		)
+		if err != nil {
+			s.deriveOnUnlock = append(pending, s.deriveOnUnlock[i:]...)
+			return err
+		}
+
+		// It's ok to ignore the error here since it can only fail if
+		// the extended key is not private, however it was just derived
+		// as a private key.
+		privKey, _ := addressKey.ECPrivKey()
+		addressKey.Zero()
+
+		privKeyBytes := privKey.Serialize()
+		privKeyEncrypted, err := cryptoKey.Encrypt(privKeyBytes)
+		zero.BigInt(privKey.D)
+		if err != nil {
+			s.deriveOnUnlock = append(pending, s.deriveOnUnlock[i:]...)
+			str := fmt.Sprintf("failed to encrypt private key for "+
+				"address %s", info.managedAddr.Address())
+			return managerError(ErrCrypto, str, err)
+		}
+
+		switch a := info.managedAddr.(type) {
+		case *managedAddress:
+			a.privKeyEncrypted = privKeyEncrypted
+			a.privKeyCT = securemem.FromBytes(privKeyBytes)
+		case *scriptAddress:
+		}
+	}
+
+	// Avoid re-deriving the derived keys on subsequent unlocks.
+	s.deriveOnUnlock = pending
+	return nil
+}
+
+// lockAccount removes the private keys of an account protected by its own
+// passphrase from memory.
+//
+// This function MUST be called with the manager lock held for writes.
+func (s *ScopedKeyManager) lockAccount(account uint32, acctInfo *accountInfo) {
+	if acctInfo.acctKeyPriv != nil {
+		acctInfo.acctKeyPriv.Zero()
+	}
+	acctInfo.acctKeyPriv = nil
+	acctInfo.passphraseKey.zero()
+
+	for _, ma := range s.addrs {
+		addr, ok := ma.(*managedAddress)
+		if ok && addr.InternalAccount() == account {
+			addr.lock()
+		}
+	}
+}
+
+// SetAccountPassphrase protects the private keys of an account with its own
+// passphrase, so that the account is locked and unlocked independently of the
+// manager with UnlockAccount and LockAccount.  An empty passphrase returns the
+// account to the protection of the private passphrase of the manager.
+//
+// The private keys of the account must be available, so the account, or the
+// manager for accounts protected by the private passphrase of the manager,
+// must be unlocked.  Removing the passphrase of an account also requires the
+// manager to be unlocked.  The account remains unlocked afterwards.  The
+// imported account is always protected by the private passphrase of the
+// manager.
+//
+// An account passphrase does not protect the account from holders of the
+// private passphrase of the manager.  The coin type private key of the scope,
+// from which the account keys are derived, remains encrypted with the private
+// passphrase of the manager, so unlocking the manager allows re-deriving the
+// private keys of every account of the scope.
+func (s *ScopedKeyManager) SetAccountPassphrase(ns walletdb.ReadWriteBucket,
+	account uint32, passphrase []byte, config *ScryptOptions) error {
+
+	if s.rootManager.WatchOnly() {
+		return managerError(ErrWatchingOnly, errWatchingOnly, nil)
+	}
+	if account == ImportedAddrAccount {
+		str := "the imported account is protected by the private " +
+			"passphrase of the manager"
+		return managerError(ErrInvalidAccount, str, nil)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	acctInfo, err := s.loadAccountInfo(ns, account)
+	if err != nil {
+		return err
+	}
+	if acctInfo.acctType != accountDefault ||
+		len(acctInfo.acctKeyEncrypted) == 0 {
+
+		return managerError(ErrWatchingOnly, errWatchingOnly, nil)
+	}
+	if acctInfo.acctKeyPriv == nil {
+		return managerError(ErrLocked, errLocked, nil)
+	}
+
+	// Choose the crypto key the private keys of the account are
+	// re-encrypted with, storing the keys of the account's own passphrase
+	// when one is set.
+	var (
+		newKey        EncryptorDecryptor
+		passphraseKey *accountPassphraseKey
+	)
+	if len(passphrase) == 0 {
+		if acctInfo.passphraseKey == nil {
+			return nil
+		}
+		if s.rootManager.IsLocked() {
+			return managerError(ErrLocked, errLocked, nil)
+		}
+		err := deleteAccountPassphraseKey(ns, &s.scope, account)
+		if err != nil {
+			return err
+		}
+		newKey = s.rootManager.cryptoKeyPriv
+	} else {
+		masterKey, err := newSecretKey(&passphrase, config)
+		if err != nil {
+			str := fmt.Sprintf("failed to create master key for "+
+				"account %d", account)
+			return managerError(ErrCrypto, str, err)
+		}
+		acctCryptoKey, err := newCryptoKey()
+		if err != nil {
+			str := fmt.Sprintf("failed to generate crypto key for "+
+				"account %d", account)
+			return managerError(ErrCrypto, str, err)
+		}
+		cryptoKeyEncrypted, err := masterKey.Encrypt(acctCryptoKey.Bytes())
+		if err != nil {
+			str := fmt.Sprintf("failed to encrypt crypto key for "+
+				"account %d", account)
+			return managerError(ErrCrypto, str, err)
+		}
+		err = putAccountPassphraseKey(
+			ns, &s.scope, account, masterKey.Marshal(),
+			cryptoKeyEncrypted,
+		)
+		if err != nil {
+			return err
+		}
+
+		passphraseKey = &accountPassphraseKey{
+			masterKey:          masterKey,
+			cryptoKeyEncrypted: cryptoKeyEncrypted,
+			cryptoKey:          acctCryptoKey,
+		}
+		newKey = acctCryptoKey
+	}
+
+	// Re-encrypt the account private extended key with the new crypto key.
+	acctKeyEncrypted, err := newKey.Encrypt(
+		[]byte(acctInfo.acctKeyPriv.String()),
+	)
+	if err != nil {
+		str := fmt.Sprintf("failed to encrypt private key for "+
+			"account %d", account)
+		return managerError(ErrCrypto, str, err)
+	}
+	rowInterface, err := fetchAccountInfo(ns, &s.scope, account)
+	if err != nil {
+		return maybeConvertDbError(err)
+	}
+	row, ok := rowInterface.(*dbDefaultAccountRow)
+	if !ok {
+		str := fmt.Sprintf("unsupported account type %T", rowInterface)
+		return managerError(ErrDatabase, str, nil)
+	}
+	err = putDefaultAccountInfo(
+		ns, &s.scope, account, row.pubKeyEncrypted, acctKeyEncrypted,
+		row.nextExternalIndex, row.nextInternalIndex, row.name,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Switch to the new keys once the database transaction is committed,
+	// re-encrypting the private keys of the account's addresses in memory.
+	// This includes addresses created by the same transaction, which are
+	// only cached once it is committed.  Addresses whose keys can not be
+	// re-encrypted are removed from the cache, so that they are loaded
+	// again with keys derived under the new crypto key, and an account
+	// with its own passphrase is locked.
+	ns.Tx().OnCommit(func() {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		oldKey := s.accountCryptoKey(account)
+		failed := false
+		for key, ma := range s.addrs {
+			addr, ok := ma.(*managedAddress)
+			if !ok || addr.InternalAccount() != account ||
+				len(addr.privKeyEncrypted) == 0 {
+
+				continue
+			}
+			err := addr.reencrypt(oldKey, newKey)
+			if err != nil {
+				log.Errorf("Unable to re-encrypt private key "+
+					"for address %s: %v", addr.Address(), err)
+				delete(s.addrs, key)
+				failed = true
+			}
+		}
+
+		if acctInfo.passphraseKey != nil {
+			acctInfo.passphraseKey.zero()
+		}
+		acctInfo.passphraseKey = passphraseKey
+		acctInfo.acctKeyEncrypted = acctKeyEncrypted
+
+		if failed && passphraseKey != nil {
+			log.Errorf("Locking account %d after failing to "+
+				"re-encrypt its private keys", account)
+			s.lockAccount(account, acctInfo)
+		}
+	})
+
+	return nil
+}
+
+// HasAccountPassphrase returns whether the account is protected by its own
+// passphrase rather than the private passphrase of the manager.
+func (s *ScopedKeyManager) HasAccountPassphrase(ns walletdb.ReadBucket,
+	account uint32) (bool, error) {
+
+	if account == ImportedAddrAccount {
+		return false, nil
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	acctInfo, err := s.loadAccountInfo(ns, account)
+	if err != nil {
+		return false, err
+	}
+	return acctInfo.passphraseKey != nil, nil
+}
+
+// IsAccountLocked returns whether the private keys of the account are
+// unavailable.  Accounts protected by their own passphrase are locked
+// independently of the manager, while all other accounts are locked when the
+// manager is.
+func (s *ScopedKeyManager) IsAccountLocked(ns walletdb.ReadBucket,
+	account uint32) (bool, error) {
+
+	if account == ImportedAddrAccount {
+		return s.rootManager.IsLocked(), nil
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, err := s.loadAccountInfo(ns, account); err != nil {
+		return false, err
+	}
+	return s.accountLocked(account), nil
+}
+
+// UnlockAccount derives the master key of an account protected by its own
+// passphrase from the passphrase, and uses it to decrypt the private keys of
+// the account, which are kept in memory until the account is locked.  An
+// invalid passphrase locks the account and returns an error.
+func (s *ScopedKeyManager) UnlockAccount(ns walletdb.ReadBucket,
+	account uint32, passphrase []byte) error {
+
+	if account == ImportedAddrAccount {
+		str := "the imported account is protected by the private " +
+			"passphrase of the manager"
+		return managerError(ErrInvalidAccount, str, nil)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	acctInfo, err := s.loadAccountInfo(ns, account)
+	if err != nil {
+		return err
+	}
+	key := acctInfo.passphraseKey
+	if key == nil {
+		str := fmt.Sprintf("account %d is protected by the private "+
+			"passphrase of the manager", account)
+		return managerError(ErrInvalidAccount, str, nil)
+	}
+
+	// Derive the master key of the account using the passphrase, and use
+	// it to decrypt the crypto key of the account.
+	if err := key.masterKey.DeriveKey(&passphrase); err != nil {
+		s.lockAccount(account, acctInfo)
+		if err == snacl.ErrInvalidPassword {
+			str := fmt.Sprintf("invalid passphrase for account %d",
+				account)
+			return managerError(ErrWrongPassphrase, str, nil)
+		}
+
+		str := fmt.Sprintf("failed to derive master key for account %d",
+			account)
+		return managerError(ErrCrypto, str, err)
+	}
+	decryptedKey, err := key.masterKey.Decrypt(key.cryptoKeyEncrypted)
+	if err != nil {
+		s.lockAccount(account, acctInfo)
+		str := fmt.Sprintf("failed to decrypt crypto key for account %d",
+			account)
+		return managerError(ErrCrypto, str, err)
+	}
+	key.cryptoKey.CopyBytes(decryptedKey)
+	zero.Bytes(decryptedKey)
+
+	// Use the crypto key to decrypt the account private extended key.
+	decrypted, err := key.cryptoKey.Decrypt(acctInfo.acctKeyEncrypted)
+	if err != nil {
+		s.lockAccount(account, acctInfo)
+		str := fmt.Sprintf("failed to decrypt account %d private key",
+			account)
+		return managerError(ErrCrypto, str, err)
+	}
+	acctKeyPriv, err := hdkeychain.NewKeyFromString(string(decrypted))
+	zero.Bytes(decrypted)
+	if err != nil {
+		s.lockAccount(account, acctInfo)
+		str := fmt.Sprintf("failed to regenerate account %d extended "+
+			"key", account)
+		return managerError(ErrKeyChain, str, err)
+	}
+	if acctInfo.acctKeyPriv != nil {
+		acctInfo.acctKeyPriv.Zero()
+	}
+	acctInfo.acctKeyPriv = acctKeyPriv
+
+	// Derive the private keys of the account's addresses which were
+	// created while the account was locked.
+	err = s.deriveKeysOnUnlock(
+		ns, key.cryptoKey, func(a uint32) bool {
+			return a == account
+		},
+	)
+	if err != nil {
+		s.lockAccount(account, acctInfo)
+		return err
+	}
+	return nil
+}
+
+// LockAccount removes the private keys of an account protected by its own
+// passphrase from memory.  An error is returned for accounts protected by the
+// private passphrase of the manager, and for accounts which are already
+// locked.
+func (s *ScopedKeyManager) LockAccount(account uint32) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	acctInfo, ok := s.acctInfo[account]
+	if !ok || acctInfo.passphraseKey == nil {
+		str := fmt.Sprintf("account %d is not protected by its own "+
+			"passphrase", account)
+		return managerError(ErrInvalidAccount, str, nil)
+	}
+	if acctInfo.acctKeyPriv == nil {
+		return managerError(ErrLocked, errLocked, nil)
+	}
+
+	s.lockAccount(account, acctInfo)
+	return nil
+}
+
 // ImportPrivateKey imports a WIF private key into the address manager.  The
 // imported address is created using either a compressed or uncompressed
 // serialized public key, depending on the CompressPubKey bool of the WIF.
@@ -1880,11 +2564,24 @@ func (s *ScopedKeyManager) ImportPrivateKey(ns walletdb.ReadWriteBucket,
 	}
 
 	// Create a new managed address based on the imported address.
+	var managedAddr *managedAddress
 	if !s.rootManager.WatchOnly() {
-		return s.toImportedPrivateManagedAddress(wif)
+		managedAddr, err = s.toImportedPrivateManagedAddress(wif)
+	} else {
+		pubKey := (*btcec.PublicKey)(&wif.PrivKey.PublicKey)
+		managedAddr, err = s.toImportedPublicManagedAddress(
+			pubKey, wif.CompressPubKey,
+		)
 	}
-	pubKey := (*btcec.PublicKey)(&wif.PrivKey.PublicKey)
-	return s.toImportedPublicManagedAddress(pubKey, wif.CompressPubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.putImportedAddressBirthday(ns, managedAddr.Address(), bs)
+	if err != nil {
+		return nil, err
+	}
+	return managedAddr, nil
 }
 
 // ImportPublicKey imports a public key into the address manager.
@@ -1905,7 +2602,85 @@ func (s *ScopedKeyManager) ImportPublicKey(ns walletdb.ReadWriteBucket,
 		return nil, err
 	}
 
-	return s.toImportedPublicManagedAddress(pubKey, true)
+	managedAddr, err := s.toImportedPublicManagedAddress(pubKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.putImportedAddressBirthday(ns, managedAddr.Address(), bs)
+	if err != nil {
+		return nil, err
+	}
+	return managedAddr, nil
+}
+
+// ImportExternalKey imports the public key of a key held by an external signer
+// into the address manager, along with the name of the signer's provider and
+// the provider's reference to the key.  No private key is stored for the
+// address, so inputs spending to it must be signed by the provider.
+//
+// All imported addresses will be part of the account defined by the
+// ImportedAddrAccount constant.
+func (s *ScopedKeyManager) ImportExternalKey(ns walletdb.ReadWriteBucket,
+	pubKey *btcec.PublicKey, provider string, ref []byte,
+	bs *BlockStamp) (ManagedAddress, error) {
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	serializedPubKey := pubKey.SerializeCompressed()
+	err := s.importPublicKey(
+		ns, serializedPubKey, nil, s.addrSchema.ExternalAddrType, bs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = putExternalKeyRef(
+		ns, &s.scope, btcutil.Hash160(serializedPubKey), provider, ref,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	managedAddr, err := s.toImportedPublicManagedAddress(pubKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.putImportedAddressBirthday(ns, managedAddr.Address(), bs)
+	if err != nil {
+		return nil, err
+	}
+	return managedAddr, nil
+}
+
+// ExternalKeyRef returns the name of the provider of the external signer
+// holding the key of the public key, along with the provider's reference to
+// the key.  An empty provider name is returned for keys which are not held by
+// an external signer.
+func (s *ScopedKeyManager) ExternalKeyRef(ns walletdb.ReadBucket,
+	pubKey *btcec.PublicKey) (string, []byte, error) {
+
+	return fetchExternalKeyRef(
+		ns, &s.scope, btcutil.Hash160(pubKey.SerializeCompressed()),
+	)
+}
+
+// putImportedAddressBirthday records the block stamp of an imported address as
+// its birthday block.  Without a block stamp, the key could have been used in
+// any block, so the genesis block is recorded.
+func (s *ScopedKeyManager) putImportedAddressBirthday(
+	ns walletdb.ReadWriteBucket, addr btcutil.Address,
+	bs *BlockStamp) error {
+
+	if bs == nil {
+		genesis := s.rootManager.chainParams.GenesisBlock
+		bs = &BlockStamp{
+			Hash:      *s.rootManager.chainParams.GenesisHash,
+			Timestamp: genesis.Header.Timestamp,
+		}
+	}
+	return putAddressBirthday(ns, addr.EncodeAddress(), bs)
 }
 
 // importPublicKey imports a public key into the address manager and updates the
@@ -2224,6 +2999,11 @@ func (s *ScopedKeyManager) importScriptAddress(ns walletdb.ReadWriteBucket,
 	baseScriptAddr.scriptClearText = make([]byte, len(script))
 	copy(baseScriptAddr.scriptClearText, script)
 
+	err = s.putImportedAddressBirthday(ns, managedAddr.Address(), bs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Add the new managed address to the cache of recent addresses and
 	// return it.
 	s.addrs[addrKey(scriptHash)] = managedAddr
@@ -2271,6 +3051,31 @@ func (s *ScopedKeyManager) MarkUsed(ns walletdb.ReadWriteBucket,
 	return nil
 }
 
+// MarkDirty updates the dirty flag for the provided address, marking it used
+// if it was not already.
+func (s *ScopedKeyManager) MarkDirty(ns walletdb.ReadWriteBucket,
+	address btcutil.Address) error {
+
+	addressID := address.ScriptAddress()
+	err := markAddressDirty(ns, &s.scope, addressID)
+	if err != nil {
+		return maybeConvertDbError(err)
+	}
+
+	// Clear caches which might have stale entries for used addresses
+	s.mtx.Lock()
+	delete(s.addrs, addrKey(addressID))
+	s.mtx.Unlock()
+	return nil
+}
+
+// IsDirty returns whether the provided address was marked dirty.
+func (s *ScopedKeyManager) IsDirty(ns walletdb.ReadBucket,
+	address btcutil.Address) bool {
+
+	return fetchAddressDirty(ns, &s.scope, address.ScriptAddress())
+}
+
 // ChainParams returns the chain parameters for this address manager.
 func (s *ScopedKeyManager) ChainParams() *chaincfg.Params {
 	// NOTE: No need for mutex here since the net field does not change
@@ -0,0 +1,153 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+// ReportPeriod is the length of the periods the totals of an accounting report
+// are summed over.
+type ReportPeriod uint8
+
+const (
+	// ReportDaily sums totals over UTC days.
+	ReportDaily ReportPeriod = iota
+
+	// ReportMonthly sums totals over UTC calendar months.
+	ReportMonthly
+)
+
+// String returns the name of the report period.
+func (p ReportPeriod) String() string {
+	switch p {
+	case ReportDaily:
+		return "daily"
+	case ReportMonthly:
+		return "monthly"
+	}
+	return "unknown"
+}
+
+// start returns the start of the period containing t.
+func (p ReportPeriod) start(t time.Time) time.Time {
+	t = t.UTC()
+	if p == ReportMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ReportTotals are the totals of the history entries of an account over a
+// time range.  Amounts are positive: Sent is the value of the outputs sent to
+// other wallets or accounts, excluding change, and Fees the fees of the sends.
+type ReportTotals struct {
+	Received     btcutil.Amount
+	Sent         btcutil.Amount
+	Fees         btcutil.Amount
+	Transactions int
+
+	// lastHash is the hash of the last counted transaction.  The entries
+	// of a transaction are consecutive, so each transaction is counted
+	// once by comparing against it.
+	lastHash *chainhash.Hash
+}
+
+// add adds a history entry to the totals.
+func (t *ReportTotals) add(e *HistoryEntry) {
+	if e.Category == "send" {
+		t.Sent -= e.Amount
+		t.Fees += e.Fee
+	} else {
+		t.Received += e.Amount
+	}
+	if t.lastHash == nil || *t.lastHash != e.Hash {
+		hash := e.Hash
+		t.lastHash = &hash
+		t.Transactions++
+	}
+}
+
+// ReportPeriodTotals are the totals of an account over one period of an
+// accounting report.
+type ReportPeriodTotals struct {
+	Start time.Time
+	ReportTotals
+}
+
+// AccountReport is the accounting report of an account, with its totals over
+// the whole report and over each period with any history entries, oldest
+// first.
+type AccountReport struct {
+	Account string
+	Totals  ReportTotals
+	Periods []ReportPeriodTotals
+}
+
+// AccountingReport returns the totals received, sent and paid in fees by each
+// account with history entries between start, inclusive, and end, exclusive,
+// summed over the whole range and over each daily or monthly period.  The
+// reports are sorted by account name, and computed from the entries of
+// ForEachHistoryEntry, so transfers between accounts are both sent and
+// received.
+func (w *Wallet) AccountingReport(start, end time.Time,
+	period ReportPeriod) ([]AccountReport, error) {
+
+	if period != ReportDaily && period != ReportMonthly {
+		return nil, errors.New("unknown report period")
+	}
+	if !start.Before(end) {
+		return nil, errors.New("report must start before it ends")
+	}
+
+	reports := make(map[string]*AccountReport)
+	err := w.ForEachHistoryEntry(func(e *HistoryEntry) error {
+		t := e.Time()
+		if t.Before(start) || !t.Before(end) {
+			return nil
+		}
+
+		report, ok := reports[e.Account]
+		if !ok {
+			report = &AccountReport{Account: e.Account}
+			reports[e.Account] = report
+		}
+		report.Totals.add(e)
+
+		// Mined entries are ordered by height, so a block with an
+		// earlier timestamp than its parent or an unmined transaction
+		// may fall in an earlier period than the last.
+		periodStart := period.start(t)
+		i := sort.Search(len(report.Periods), func(i int) bool {
+			return !report.Periods[i].Start.Before(periodStart)
+		})
+		if i == len(report.Periods) ||
+			!report.Periods[i].Start.Equal(periodStart) {
+
+			report.Periods = append(report.Periods, ReportPeriodTotals{})
+			copy(report.Periods[i+1:], report.Periods[i:])
+			report.Periods[i] = ReportPeriodTotals{Start: periodStart}
+		}
+		report.Periods[i].add(e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]AccountReport, 0, len(reports))
+	for _, report := range reports {
+		sorted = append(sorted, *report)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Account < sorted[j].Account
+	})
+	return sorted, nil
+}
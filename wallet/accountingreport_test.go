@@ -0,0 +1,131 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAccountingReport ensures that accounting reports sum the history of each
+// account over the report and over each of its periods.
+func TestAccountingReport(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	scope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.NewAddress(0, scope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	changeAddr, err := w.NewChangeAddress(0, scope)
+	require.NoError(t, err)
+	changeScript, err := txscript.PayToAddrScript(changeAddr)
+	require.NoError(t, err)
+	foreignAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		bytes.Repeat([]byte{0x23}, 20), w.ChainParams(),
+	)
+	require.NoError(t, err)
+	foreignScript, err := txscript.PayToAddrScript(foreignAddr)
+	require.NoError(t, err)
+
+	// addTx records a transaction mined at a height and time.
+	addTx := func(msgTx *wire.MsgTx, height int32,
+		blockTime time.Time) *wtxmgr.TxRecord {
+
+		t.Helper()
+
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, blockTime)
+		require.NoError(t, err)
+		block := &wtxmgr.BlockMeta{
+			Block: wtxmgr.Block{
+				Hash:   [32]byte{byte(height)},
+				Height: height,
+			},
+			Time: blockTime,
+		}
+		err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+			return w.addRelevantTx(tx, rec, block)
+		})
+		require.NoError(t, err)
+		return rec
+	}
+	day := func(month time.Month, day int) time.Time {
+		return time.Date(2021, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	// A payment to the wallet in January, a send with change and a fee
+	// in February, and another payment later in February.
+	recvTx := wire.NewMsgTx(wire.TxVersion)
+	recvTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	recvTx.AddTxOut(wire.NewTxOut(1e6, pkScript))
+	recvRec := addTx(recvTx, 100, day(time.January, 15))
+
+	sendTx := wire.NewMsgTx(wire.TxVersion)
+	sendTx.AddTxIn(wire.NewTxIn(
+		&wire.OutPoint{Hash: recvRec.Hash, Index: 0}, nil, nil,
+	))
+	sendTx.AddTxOut(wire.NewTxOut(4e5, foreignScript))
+	sendTx.AddTxOut(wire.NewTxOut(5e5, changeScript))
+	addTx(sendTx, 101, day(time.February, 2))
+
+	recvTx2 := wire.NewMsgTx(wire.TxVersion)
+	recvTx2.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 2}, nil, nil))
+	recvTx2.AddTxOut(wire.NewTxOut(2e5, pkScript))
+	addTx(recvTx2, 102, day(time.February, 20))
+
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	reports, err := w.AccountingReport(start, end, ReportMonthly)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	report := reports[0]
+	require.Equal(t, "default", report.Account)
+	require.Equal(t, btcutil.Amount(12e5), report.Totals.Received)
+	require.Equal(t, btcutil.Amount(4e5), report.Totals.Sent)
+	require.Equal(t, btcutil.Amount(1e5), report.Totals.Fees)
+	require.Equal(t, 3, report.Totals.Transactions)
+
+	require.Len(t, report.Periods, 2)
+	jan, feb := report.Periods[0], report.Periods[1]
+	require.Equal(t, start, jan.Start)
+	require.Equal(t, btcutil.Amount(1e6), jan.Received)
+	require.Equal(t, 1, jan.Transactions)
+	require.Equal(t, start.AddDate(0, 1, 0), feb.Start)
+	require.Equal(t, btcutil.Amount(2e5), feb.Received)
+	require.Equal(t, btcutil.Amount(4e5), feb.Sent)
+	require.Equal(t, btcutil.Amount(1e5), feb.Fees)
+	require.Equal(t, 2, feb.Transactions)
+
+	// Daily reports only have periods for days with entries, and entries
+	// outside of the report are excluded.
+	reports, err = w.AccountingReport(
+		start.AddDate(0, 1, 0), end, ReportDaily,
+	)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	report = reports[0]
+	require.Equal(t, btcutil.Amount(2e5), report.Totals.Received)
+	require.Len(t, report.Periods, 2)
+	require.Equal(t, time.Date(2021, time.February, 2, 0, 0, 0, 0,
+		time.UTC), report.Periods[0].Start)
+	require.Equal(t, time.Date(2021, time.February, 20, 0, 0, 0, 0,
+		time.UTC), report.Periods[1].Start)
+
+	// Reports must cover a time range.
+	_, err = w.AccountingReport(end, start, ReportDaily)
+	require.Error(t, err)
+}
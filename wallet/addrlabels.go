@@ -0,0 +1,117 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// ErrAddressLabelTooLong is returned when an address label exceeds the
+// maximum length.
+var ErrAddressLabelTooLong = errors.New("address label exceeds maximum " +
+	"length")
+
+// MaxAddressLabelLen is the maximum length of an address label in bytes.
+const MaxAddressLabelLen = 500
+
+// SetAddressLabel annotates an address with a label, such as the invoice it
+// was handed out for.  Labels are kept separately from accounts, and any
+// address of the wallet's network may be labeled, including addresses of
+// other wallets which are paid to.  An empty label removes the label of the
+// address.
+func (w *Wallet) SetAddressLabel(addr btcutil.Address, label string) error {
+	if len(label) > MaxAddressLabelLen {
+		return ErrAddressLabelTooLong
+	}
+
+	return walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return w.Manager.SetAddressLabel(addrmgrNs, addr, label)
+	})
+}
+
+// AddressLabel returns the label of an address, or an empty string if the
+// address has no label.
+func (w *Wallet) AddressLabel(addr btcutil.Address) (string, error) {
+	var label string
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		label = w.Manager.AddressLabel(addrmgrNs, addr)
+		return nil
+	})
+	return label, err
+}
+
+// AddressesByLabel returns every address with the label, ordered by their
+// encoding.
+func (w *Wallet) AddressesByLabel(label string) ([]btcutil.Address, error) {
+	var addrs []btcutil.Address
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		return w.Manager.ForEachAddressLabel(addrmgrNs,
+			func(encoded, l string) error {
+				if l != label {
+					return nil
+				}
+				addr, err := btcutil.DecodeAddress(
+					encoded, w.chainParams,
+				)
+				if err != nil {
+					return err
+				}
+				addrs = append(addrs, addr)
+				return nil
+			})
+	})
+	return addrs, err
+}
+
+// AddressLabels returns the distinct labels of all labeled addresses, sorted.
+// When mine is not nil, only the labels of addresses which do or do not
+// belong to the wallet, as indicated by *mine, are returned.
+func (w *Wallet) AddressLabels(mine *bool) ([]string, error) {
+	seen := make(map[string]struct{})
+	labels := []string{}
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		return w.Manager.ForEachAddressLabel(addrmgrNs,
+			func(encoded, label string) error {
+				if _, ok := seen[label]; ok {
+					return nil
+				}
+				if mine != nil {
+					addr, err := btcutil.DecodeAddress(
+						encoded, w.chainParams,
+					)
+					if err != nil {
+						return err
+					}
+					_, err = w.Manager.Address(addrmgrNs, addr)
+					notFound := waddrmgr.IsError(
+						err, waddrmgr.ErrAddressNotFound,
+					)
+					if err != nil && !notFound {
+						return err
+					}
+					if notFound == *mine {
+						return nil
+					}
+				}
+				seen[label] = struct{}{}
+				labels = append(labels, label)
+				return nil
+			})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
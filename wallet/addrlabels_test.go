@@ -0,0 +1,60 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddressLabels ensures that addresses of the wallet and foreign
+// addresses can be labeled and queried by label.
+func TestAddressLabels(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	mine, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	require.NoError(t, err)
+	foreign, err := btcutil.NewAddressWitnessPubKeyHash(
+		make([]byte, 20), w.ChainParams(),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, w.SetAddressLabel(mine, "invoice #123"))
+	require.NoError(t, w.SetAddressLabel(foreign, "supplier"))
+
+	label, err := w.AddressLabel(mine)
+	require.NoError(t, err)
+	require.Equal(t, "invoice #123", label)
+
+	addrs, err := w.AddressesByLabel("supplier")
+	require.NoError(t, err)
+	require.Equal(t, []btcutil.Address{foreign}, addrs)
+
+	labels, err := w.AddressLabels(nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"invoice #123", "supplier"}, labels)
+	isMine := true
+	labels, err = w.AddressLabels(&isMine)
+	require.NoError(t, err)
+	require.Equal(t, []string{"invoice #123"}, labels)
+
+	// Relabeling replaces the label, and an empty label removes it.
+	require.NoError(t, w.SetAddressLabel(mine, "supplier"))
+	addrs, err = w.AddressesByLabel("supplier")
+	require.NoError(t, err)
+	require.Len(t, addrs, 2)
+	require.NoError(t, w.SetAddressLabel(foreign, ""))
+	label, err = w.AddressLabel(foreign)
+	require.NoError(t, err)
+	require.Empty(t, label)
+
+	long := make([]byte, MaxAddressLabelLen+1)
+	err = w.SetAddressLabel(mine, string(long))
+	require.Equal(t, ErrAddressLabelTooLong, err)
+}
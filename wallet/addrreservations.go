@@ -0,0 +1,188 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// ErrAddressNotReserved is returned when releasing an address which is not
+// reserved.
+var ErrAddressNotReserved = errors.New("address is not reserved")
+
+// ReserveAddress claims an external address of an account, such as for an
+// invoice, and sets its label.  An address released by ReleaseAddress which has
+// not been used is handed out again before a new address is derived.  The
+// address is claimed atomically, so frontends sharing the wallet never reserve
+// the same address, and reserved addresses are not returned by CurrentAddress
+// until their reservation ends when they are released or used.  The label
+// replaces the label of a previous reservation, and an empty label removes it.
+func (w *Wallet) ReserveAddress(account uint32, scope waddrmgr.KeyScope,
+	label string) (btcutil.Address, error) {
+
+	if len(label) > MaxAddressLabelLen {
+		return nil, ErrAddressLabelTooLong
+	}
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		addr  btcutil.Address
+		props *waddrmgr.AccountProperties
+	)
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		var err error
+		addr, err = w.releasedAddress(addrmgrNs, account, scope)
+		if err != nil {
+			return err
+		}
+		if addr == nil {
+			addr, props, err = w.newAddress(addrmgrNs, account, scope)
+			if err != nil {
+				return err
+			}
+			if err := w.updateLookahead(addrmgrNs); err != nil {
+				return err
+			}
+		}
+
+		err = w.Manager.SetAddressReservation(
+			addrmgrNs, addr, &waddrmgr.AddressReservation{
+				Time: time.Now(),
+			},
+		)
+		if err != nil {
+			return err
+		}
+		return w.Manager.SetAddressLabel(addrmgrNs, addr, label)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Released addresses were watched since they were derived, so only a
+	// new address needs to be notified.
+	if props != nil {
+		err = chainClient.NotifyReceived([]btcutil.Address{addr})
+		if err != nil {
+			return nil, err
+		}
+
+		w.NtfnServer.notifyAccountProperties(props)
+	}
+
+	return addr, nil
+}
+
+// releasedAddress returns the first released and unused external address of an
+// account, ordered by encoding, or nil if there is none.  The reservations of
+// released addresses which have since been used are removed.
+func (w *Wallet) releasedAddress(addrmgrNs walletdb.ReadWriteBucket,
+	account uint32, scope waddrmgr.KeyScope) (btcutil.Address, error) {
+
+	// The reservations are collected first, as the bucket may not be
+	// modified while it is iterated.
+	var released []string
+	err := w.Manager.ForEachAddressReservation(addrmgrNs,
+		func(encoded string, r *waddrmgr.AddressReservation) error {
+			if r.Released {
+				released = append(released, encoded)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, encoded := range released {
+		addr, err := btcutil.DecodeAddress(encoded, w.chainParams)
+		if err != nil {
+			return nil, err
+		}
+		maddr, err := w.Manager.Address(addrmgrNs, addr)
+		if err != nil {
+			return nil, err
+		}
+		if maddr.Used(addrmgrNs) {
+			err := w.Manager.SetAddressReservation(
+				addrmgrNs, addr, nil,
+			)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		manager, acct, err := w.Manager.AddrAccount(addrmgrNs, addr)
+		if err != nil {
+			return nil, err
+		}
+		if acct == account && manager.Scope() == scope {
+			return addr, nil
+		}
+	}
+	return nil, nil
+}
+
+// IsPaymentAddress returns whether an address was handed out to receive a
+// payment, being reserved by ReserveAddress, including after its reservation
+// was released, or having a label.
+func (w *Wallet) IsPaymentAddress(addr btcutil.Address) (bool, error) {
+	var payment bool
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		if w.Manager.AddressLabel(addrmgrNs, addr) != "" {
+			payment = true
+			return nil
+		}
+		r, err := w.Manager.AddressReservation(addrmgrNs, addr)
+		payment = r != nil
+		return err
+	})
+	return payment, err
+}
+
+// ReleaseAddress ends the reservation of an address reserved by
+// ReserveAddress, such as when its invoice expires.  An unused address may be
+// reserved again, while the reservation of a used address is removed.  The
+// label of the address is kept until the address is reserved again.
+// ErrAddressNotReserved is returned if the address is not reserved.
+func (w *Wallet) ReleaseAddress(addr btcutil.Address) error {
+	return walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		r, err := w.Manager.AddressReservation(addrmgrNs, addr)
+		if err != nil {
+			return err
+		}
+		if r == nil || r.Released {
+			return ErrAddressNotReserved
+		}
+
+		maddr, err := w.Manager.Address(addrmgrNs, addr)
+		if err != nil {
+			return err
+		}
+		if maddr.Used(addrmgrNs) {
+			return w.Manager.SetAddressReservation(
+				addrmgrNs, addr, nil,
+			)
+		}
+		return w.Manager.SetAddressReservation(
+			addrmgrNs, addr, &waddrmgr.AddressReservation{
+				Released: true,
+				Time:     time.Now(),
+			},
+		)
+	})
+}
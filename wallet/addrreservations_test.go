@@ -0,0 +1,92 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAddressReservations ensures that reserved addresses are never handed out
+// twice, and that released addresses are reused only while unused.
+func TestAddressReservations(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	scope := waddrmgr.KeyScopeBIP0084
+
+	// The current address is not reserved until it is handed out again,
+	// so a reservation derives a new address, after which the current
+	// address moves past the reserved one.
+	current, err := w.CurrentAddress(0, scope)
+	require.NoError(t, err)
+	first, err := w.ReserveAddress(0, scope, "invoice #1")
+	require.NoError(t, err)
+	require.NotEqual(t, current, first)
+	label, err := w.AddressLabel(first)
+	require.NoError(t, err)
+	require.Equal(t, "invoice #1", label)
+
+	next, err := w.CurrentAddress(0, scope)
+	require.NoError(t, err)
+	require.NotEqual(t, first, next)
+
+	second, err := w.ReserveAddress(0, scope, "invoice #2")
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+	require.NotEqual(t, next, second)
+
+	// Released addresses are reserved again, replacing their label, but
+	// not for other accounts.
+	require.NoError(t, w.ReleaseAddress(first))
+	require.Equal(t, ErrAddressNotReserved, w.ReleaseAddress(first))
+	require.Equal(t, ErrAddressNotReserved, w.ReleaseAddress(current))
+
+	account, err := w.NextAccount(scope, "other")
+	require.NoError(t, err)
+	other, err := w.ReserveAddress(account, scope, "")
+	require.NoError(t, err)
+	require.NotEqual(t, first, other)
+
+	reused, err := w.ReserveAddress(0, scope, "invoice #3")
+	require.NoError(t, err)
+	require.Equal(t, first, reused)
+	label, err = w.AddressLabel(first)
+	require.NoError(t, err)
+	require.Equal(t, "invoice #3", label)
+
+	// Released addresses which were paid to are not reserved again.
+	require.NoError(t, w.ReleaseAddress(first))
+	pkScript, err := txscript.PayToAddrScript(first)
+	require.NoError(t, err)
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e6, pkScript))
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, time.Now())
+	require.NoError(t, err)
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return w.addRelevantTx(tx, rec, nil)
+	})
+	require.NoError(t, err)
+
+	third, err := w.ReserveAddress(0, scope, "")
+	require.NoError(t, err)
+	require.NotEqual(t, first, third)
+	require.NotEqual(t, second, third)
+	require.Equal(t, ErrAddressNotReserved, w.ReleaseAddress(first))
+
+	long := make([]byte, MaxAddressLabelLen+1)
+	_, err = w.ReserveAddress(0, scope, string(long))
+	require.Equal(t, ErrAddressLabelTooLong, err)
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// AuditAddress describes an address of the wallet recorded by an audit
+// snapshot.
+type AuditAddress struct {
+	Address     btcutil.Address
+	Scope       waddrmgr.KeyScope
+	Account     uint32
+	AccountName string
+}
+
+// AuditOutput describes an output paying to the wallet which was unspent at
+// the block of an audit snapshot.
+type AuditOutput struct {
+	OutPoint wire.OutPoint
+	Amount   btcutil.Amount
+	PkScript []byte
+	Height   int32
+}
+
+// AuditBalance is the sum of the unspent outputs paying to an account at the
+// block of an audit snapshot.
+type AuditBalance struct {
+	Scope       waddrmgr.KeyScope
+	Account     uint32
+	AccountName string
+	Balance     btcutil.Amount
+}
+
+// AuditSnapshot records every address, unspent output and account balance of
+// the wallet as of a block of the main chain.  It contains no private key
+// material, and each output may be verified against the chain using the block
+// hash.
+type AuditSnapshot struct {
+	Block     waddrmgr.BlockStamp
+	Addresses []AuditAddress
+	Outputs   []AuditOutput
+	Balances  []AuditBalance
+	Total     btcutil.Amount
+}
+
+// AuditSnapshot creates an audit snapshot of the wallet as of the block at the
+// passed height, which must not be past the block the wallet is synced to.
+// Outputs are included if they were mined at or before the height and not
+// spent by a transaction mined at or before the height.  Blocks before the
+// synced block are looked up with the chain backend, as the address manager
+// only records the hashes of recent blocks.
+func (w *Wallet) AuditSnapshot(height int32) (*AuditSnapshot, error) {
+	syncBlock := w.Manager.SyncedTo()
+	if height < 0 || height > syncBlock.Height {
+		return nil, fmt.Errorf("snapshot height %d is outside the "+
+			"synced range [0, %d]", height, syncBlock.Height)
+	}
+
+	snapshot := &AuditSnapshot{Block: syncBlock}
+	if height != syncBlock.Height {
+		chainClient, err := w.requireChainClient()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := chainClient.GetBlockHash(int64(height))
+		if err != nil {
+			return nil, err
+		}
+		header, err := chainClient.GetBlockHeader(hash)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Block = waddrmgr.BlockStamp{
+			Height:    height,
+			Hash:      *hash,
+			Timestamp: header.Timestamp,
+		}
+	}
+
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+
+		type accountKey struct {
+			scope   waddrmgr.KeyScope
+			account uint32
+		}
+		balances := make(map[accountKey]*AuditBalance)
+
+		// Key scopes are visited in a fixed order so that snapshots of
+		// the same wallet state are identical.
+		scopedMgrs := w.Manager.ActiveScopedKeyManagers()
+		sort.Slice(scopedMgrs, func(i, j int) bool {
+			a, b := scopedMgrs[i].Scope(), scopedMgrs[j].Scope()
+			if a.Purpose != b.Purpose {
+				return a.Purpose < b.Purpose
+			}
+			return a.Coin < b.Coin
+		})
+		for _, scopedMgr := range scopedMgrs {
+			scope := scopedMgr.Scope()
+			err := scopedMgr.ForEachAccount(addrmgrNs, func(account uint32) error {
+				name, err := scopedMgr.AccountName(addrmgrNs, account)
+				if err != nil {
+					return err
+				}
+				snapshot.Balances = append(snapshot.Balances, AuditBalance{
+					Scope:       scope,
+					Account:     account,
+					AccountName: name,
+				})
+
+				return scopedMgr.ForEachAccountAddress(
+					addrmgrNs, account,
+					func(maddr waddrmgr.ManagedAddress) error {
+						snapshot.Addresses = append(
+							snapshot.Addresses, AuditAddress{
+								Address:     maddr.Address(),
+								Scope:       scope,
+								Account:     account,
+								AccountName: name,
+							},
+						)
+						return nil
+					},
+				)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for i := range snapshot.Balances {
+			b := &snapshot.Balances[i]
+			balances[accountKey{b.Scope, b.Account}] = b
+		}
+
+		// Outputs spent within the same block as they were created may
+		// be visited in either order, so all credits and debits of the
+		// range are recorded before the spent outputs are removed.
+		credits := make(map[wire.OutPoint]AuditOutput)
+		var spent []wire.OutPoint
+		rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
+			for i := range details {
+				d := &details[i]
+				for _, cred := range d.Credits {
+					op := wire.OutPoint{
+						Hash:  d.Hash,
+						Index: cred.Index,
+					}
+					credits[op] = AuditOutput{
+						OutPoint: op,
+						Amount:   cred.Amount,
+						PkScript: d.MsgTx.TxOut[cred.Index].PkScript,
+						Height:   d.Block.Height,
+					}
+				}
+				for _, deb := range d.Debits {
+					prevOut := d.MsgTx.TxIn[deb.Index].PreviousOutPoint
+					spent = append(spent, prevOut)
+				}
+			}
+			return false, nil
+		}
+		err := w.TxStore.RangeTransactions(txmgrNs, 0, height, rangeFn)
+		if err != nil {
+			return err
+		}
+		for _, op := range spent {
+			delete(credits, op)
+		}
+
+		for _, output := range credits {
+			snapshot.Outputs = append(snapshot.Outputs, output)
+			snapshot.Total += output.Amount
+
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				output.PkScript, w.chainParams,
+			)
+			if err != nil || len(addrs) == 0 {
+				continue
+			}
+			scopedMgr, account, err := w.Manager.AddrAccount(
+				addrmgrNs, addrs[0],
+			)
+			if err != nil {
+				continue
+			}
+			b, ok := balances[accountKey{scopedMgr.Scope(), account}]
+			if ok {
+				b.Balance += output.Amount
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Order the outputs by the block which mined them so that snapshots of
+	// the same wallet state are identical.
+	sort.Slice(snapshot.Outputs, func(i, j int) bool {
+		a, b := &snapshot.Outputs[i], &snapshot.Outputs[j]
+		if a.Height != b.Height {
+			return a.Height < b.Height
+		}
+		if a.OutPoint.Hash != b.OutPoint.Hash {
+			return a.OutPoint.Hash.String() < b.OutPoint.Hash.String()
+		}
+		return a.OutPoint.Index < b.OutPoint.Index
+	})
+
+	return snapshot, nil
+}
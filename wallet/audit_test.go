@@ -0,0 +1,159 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditSnapshot ensures that audit snapshots include the outputs which
+// were unspent as of the snapshot block, even when they have since been spent.
+// Blocks before the synced block are looked up with the chain backend.
+func TestAuditSnapshot(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	require.NoError(t, err)
+
+	// The blocks before the synced block are looked up with this chain
+	// backend, whose block hashes match the blocks mined below.
+	w.chainClient = &rangeChainClient{}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	// mineTx records a transaction paying every output to the wallet in
+	// the block at the passed height, and syncs the wallet to it.
+	mineTx := func(height int32, tx *wire.MsgTx) {
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+		require.NoError(t, err)
+
+		block := &wtxmgr.BlockMeta{
+			Block: wtxmgr.Block{
+				Hash:   chainhash.Hash{byte(height)},
+				Height: height,
+			},
+			Time: time.Unix(1387737310, 0),
+		}
+		err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+			addrmgrNs := dbtx.ReadWriteBucket(waddrmgrNamespaceKey)
+			txmgrNs := dbtx.ReadWriteBucket(wtxmgrNamespaceKey)
+
+			err := w.TxStore.InsertTx(txmgrNs, rec, block)
+			if err != nil {
+				return err
+			}
+			for i := range tx.TxOut {
+				err := w.TxStore.AddCredit(
+					txmgrNs, rec, block, uint32(i), false,
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return w.Manager.SetSyncedTo(addrmgrNs, &waddrmgr.BlockStamp{
+				Height:    height,
+				Hash:      block.Hash,
+				Timestamp: block.Time,
+			})
+		})
+		require.NoError(t, err)
+	}
+
+	fundingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(60000, pkScript),
+			wire.NewTxOut(50000, pkScript),
+		},
+	}
+	mineTx(1, fundingTx)
+
+	spendingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{
+				Hash:  fundingTx.TxHash(),
+				Index: 0,
+			},
+		}},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(55000, pkScript),
+		},
+	}
+	mineTx(2, spendingTx)
+
+	tests := []struct {
+		height int32
+		total  btcutil.Amount
+		ops    []wire.OutPoint
+	}{
+		{
+			height: 1,
+			total:  110000,
+			ops: []wire.OutPoint{
+				{Hash: fundingTx.TxHash(), Index: 0},
+				{Hash: fundingTx.TxHash(), Index: 1},
+			},
+		},
+		{
+			height: 2,
+			total:  105000,
+			ops: []wire.OutPoint{
+				{Hash: fundingTx.TxHash(), Index: 1},
+				{Hash: spendingTx.TxHash(), Index: 0},
+			},
+		},
+	}
+	for _, test := range tests {
+		snapshot, err := w.AuditSnapshot(test.height)
+		require.NoError(t, err)
+		require.Equal(t, test.height, snapshot.Block.Height)
+		require.Equal(t, chainhash.Hash{byte(test.height)},
+			snapshot.Block.Hash)
+		require.Equal(t, test.total, snapshot.Total)
+		if test.height == 1 {
+			require.Equal(t, time.Unix(1600000600, 0),
+				snapshot.Block.Timestamp)
+		}
+
+		var ops []wire.OutPoint
+		for _, output := range snapshot.Outputs {
+			ops = append(ops, output.OutPoint)
+		}
+		require.ElementsMatch(t, test.ops, ops)
+
+		var addrFound bool
+		for _, a := range snapshot.Addresses {
+			if a.Address.EncodeAddress() == addr.EncodeAddress() {
+				addrFound = true
+			}
+		}
+		require.True(t, addrFound)
+
+		var balance btcutil.Amount
+		for _, b := range snapshot.Balances {
+			if b.Scope == waddrmgr.KeyScopeBIP0084 && b.Account == 0 {
+				balance = b.Balance
+			}
+		}
+		require.Equal(t, test.total, balance)
+	}
+
+	// Blocks past the synced block can't be snapshotted.
+	_, err = w.AuditSnapshot(3)
+	require.Error(t, err)
+}
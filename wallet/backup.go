@@ -0,0 +1,338 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcwallet/internal/legacy/rename"
+	"github.com/btcsuite/btcwallet/snacl"
+)
+
+const (
+	// DefaultBackupRetain is the suggested number of backups kept by the
+	// backup scheduler.
+	DefaultBackupRetain = 7
+
+	// backupCheckInterval is the interval at which the backup scheduler
+	// checks whether a backup is due.
+	backupCheckInterval = time.Minute
+
+	// backupRetryInterval is the longest time waited before retrying a
+	// failed backup.
+	backupRetryInterval = 10 * time.Minute
+
+	// backupNamePrefix and backupNameSuffix surround the time a backup was
+	// made in the names of backups.
+	backupNamePrefix = "wallet-"
+	backupNameSuffix = ".bak"
+
+	// backupTimeFormat is the format of the time in the names of backups,
+	// which sort in the order the backups were made.
+	backupTimeFormat = "20060102T150405Z"
+)
+
+// backupMagic begins every encrypted backup.
+var backupMagic = []byte("btcwbak1")
+
+// ErrBackupMalformed is returned when decrypting data which is not an
+// encrypted wallet backup.
+var ErrBackupMalformed = errors.New("malformed wallet backup")
+
+// BackupTarget stores encrypted wallet backups.  Backups are identified by
+// their names, which are valid file names.  Implementations may write backups
+// to local directories or to remote storage.
+type BackupTarget interface {
+	// Put stores the backup under the name, replacing any backup of the
+	// same name.  A backup must be stored completely or not at all.
+	Put(name string, backup []byte) error
+
+	// List returns the names of all stored backups.
+	List() ([]string, error)
+
+	// Remove deletes the backup with the name.
+	Remove(name string) error
+}
+
+// DirBackupTarget is a BackupTarget which stores backups as files in a local
+// directory.
+type DirBackupTarget struct {
+	dir string
+}
+
+// Enforce DirBackupTarget implements the BackupTarget interface.
+var _ BackupTarget = (*DirBackupTarget)(nil)
+
+// NewDirBackupTarget returns a BackupTarget storing backups in dir, which is
+// created when the first backup is stored.
+func NewDirBackupTarget(dir string) *DirBackupTarget {
+	return &DirBackupTarget{dir: dir}
+}
+
+// Put writes the backup to a temporary file which is renamed over the file of
+// the backup once it is synced to disk.
+//
+// This function is part of the BackupTarget interface implementation.
+func (t *DirBackupTarget) Put(name string, backup []byte) error {
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return err
+	}
+
+	// TempFile creates the file 0600, so no need to chmod it.
+	f, err := ioutil.TempFile(t.dir, name)
+	if err != nil {
+		return err
+	}
+	tempPath := f.Name()
+
+	_, err = f.Write(backup)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = rename.Atomic(tempPath, filepath.Join(t.dir, name))
+	}
+	if err != nil {
+		os.Remove(tempPath)
+	}
+	return err
+}
+
+// List returns the names of the backup files in the directory.
+//
+// This function is part of the BackupTarget interface implementation.
+func (t *DirBackupTarget) List() ([]string, error) {
+	files, err := ioutil.ReadDir(t.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		if isBackupName(f.Name()) && f.Mode().IsRegular() {
+			names = append(names, f.Name())
+		}
+	}
+	return names, nil
+}
+
+// Remove deletes the backup file.
+//
+// This function is part of the BackupTarget interface implementation.
+func (t *DirBackupTarget) Remove(name string) error {
+	return os.Remove(filepath.Join(t.dir, name))
+}
+
+// BackupConfig configures the backups made by the backup scheduler of a
+// wallet.
+type BackupConfig struct {
+	// Target stores the backups.
+	Target BackupTarget
+
+	// Passphrase encrypts the backups.  It is required to restore them.
+	Passphrase []byte
+
+	// Interval is the duration between backups.  Backups are only made
+	// when requested if the interval is zero.
+	Interval time.Duration
+
+	// Retain is the number of most recent backups kept in the target.
+	// Older backups are removed after every backup.  All backups are kept
+	// if it is zero.
+	Retain int
+}
+
+// BackupFailure describes a scheduled backup of the wallet which failed.
+type BackupFailure struct {
+	Time time.Time
+	Err  error
+}
+
+// SetBackupConfig configures the backups of the wallet.  A nil config disables
+// backups.  The first scheduled backup is made shortly after the wallet starts
+// or the config is set.
+func (w *Wallet) SetBackupConfig(cfg *BackupConfig) {
+	w.backupMtx.Lock()
+	w.backupConfig = cfg
+	w.nextBackup = time.Time{}
+	w.backupMtx.Unlock()
+}
+
+// Backup makes an encrypted backup of the wallet database with the configured
+// backup target and passphrase, removing backups beyond the configured number
+// to retain.  The name of the backup is returned.
+func (w *Wallet) Backup() (string, error) {
+	w.backupMtx.Lock()
+	cfg := w.backupConfig
+	w.backupMtx.Unlock()
+	if cfg == nil {
+		return "", errors.New("wallet backups are not configured")
+	}
+
+	var db bytes.Buffer
+	if err := w.db.Copy(&db); err != nil {
+		return "", err
+	}
+	backup, err := encryptBackup(db.Bytes(), cfg.Passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	name := backupNamePrefix + w.Now().UTC().Format(backupTimeFormat) +
+		backupNameSuffix
+	if err := cfg.Target.Put(name, backup); err != nil {
+		return "", err
+	}
+	log.Infof("Wrote wallet backup %v", name)
+
+	if cfg.Retain > 0 {
+		if err := pruneBackups(cfg.Target, cfg.Retain); err != nil {
+			return name, fmt.Errorf("unable to remove old "+
+				"backups: %v", err)
+		}
+	}
+
+	return name, nil
+}
+
+// DecryptBackup decrypts a backup made by Wallet.Backup with the backup
+// passphrase, returning the wallet database.
+func DecryptBackup(backup, passphrase []byte) ([]byte, error) {
+	db, key, err := unsealData(
+		backupMagic, backup, passphrase, ErrBackupMalformed,
+	)
+	if err != nil {
+		return nil, err
+	}
+	key.Zero()
+	return db, nil
+}
+
+// encryptBackup encrypts the wallet database with a key derived from the
+// passphrase.  The parameters of the key derivation are stored in the clear
+// following the backup magic, and are followed by the encrypted database.
+func encryptBackup(db, passphrase []byte) ([]byte, error) {
+	key, err := snacl.NewSecretKey(
+		&passphrase, snacl.DefaultN, snacl.DefaultR, snacl.DefaultP,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Zero()
+
+	return sealData(backupMagic, key, db)
+}
+
+// isBackupName returns whether name is the name of a backup.
+func isBackupName(name string) bool {
+	if !strings.HasPrefix(name, backupNamePrefix) ||
+		!strings.HasSuffix(name, backupNameSuffix) {
+
+		return false
+	}
+	ts := strings.TrimSuffix(
+		strings.TrimPrefix(name, backupNamePrefix), backupNameSuffix,
+	)
+	_, err := time.Parse(backupTimeFormat, ts)
+	return err == nil
+}
+
+// pruneBackups removes all but the retain most recent backups of the target.
+func pruneBackups(target BackupTarget, retain int) error {
+	names, err := target.List()
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, name := range names {
+		if isBackupName(name) {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= retain {
+		return nil
+	}
+
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-retain] {
+		if err := target.Remove(name); err != nil {
+			return err
+		}
+		log.Debugf("Removed wallet backup %v", name)
+	}
+	return nil
+}
+
+// scheduledBackup makes a backup of the wallet when one is due, notifying
+// clients of failed backups.
+func (w *Wallet) scheduledBackup() {
+	now := w.Now()
+
+	w.backupMtx.Lock()
+	cfg := w.backupConfig
+	due := cfg != nil && cfg.Interval > 0 && !now.Before(w.nextBackup)
+	w.backupMtx.Unlock()
+	if !due {
+		return
+	}
+
+	_, err := w.Backup()
+
+	// Failed backups are retried sooner than the next backup would be
+	// made.
+	next := now.Add(cfg.Interval)
+	if err != nil && cfg.Interval > backupRetryInterval {
+		next = now.Add(backupRetryInterval)
+	}
+	w.backupMtx.Lock()
+	if w.backupConfig == cfg {
+		w.nextBackup = next
+	}
+	w.backupMtx.Unlock()
+
+	if err != nil {
+		log.Errorf("Unable to back up wallet: %v", err)
+		w.NtfnServer.notifyBackupFailure(&BackupFailure{
+			Time: now,
+			Err:  err,
+		})
+	}
+}
+
+// backupScheduler periodically makes backups of the wallet as configured by
+// SetBackupConfig.
+//
+// NOTE: This must be run as a goroutine.
+func (w *Wallet) backupScheduler() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(backupCheckInterval)
+	defer ticker.Stop()
+
+	quit := w.quitChan()
+	for {
+		select {
+		case <-ticker.C:
+			w.scheduledBackup()
+		case <-quit:
+			return
+		}
+	}
+}
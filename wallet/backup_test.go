@@ -0,0 +1,140 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcwallet/snacl"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/stretchr/testify/require"
+)
+
+// failingBackupTarget is a BackupTarget which is unable to store backups.
+type failingBackupTarget struct{}
+
+func (failingBackupTarget) Put(string, []byte) error {
+	return errors.New("target unavailable")
+}
+
+func (failingBackupTarget) List() ([]string, error) {
+	return nil, nil
+}
+
+func (failingBackupTarget) Remove(string) error {
+	return nil
+}
+
+// TestBackup ensures that backups are encrypted with the backup passphrase,
+// restore to a usable wallet database, and that only the configured number of
+// backups is retained.
+func TestBackup(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	dir, err := ioutil.TempDir("", "backup_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(1600000000, 0)
+	w.clock = func() time.Time { return now }
+
+	_, err = w.Backup()
+	require.Error(t, err, "backup made without config")
+
+	passphrase := []byte("backup pass")
+	target := NewDirBackupTarget(filepath.Join(dir, "backups"))
+	w.SetBackupConfig(&BackupConfig{
+		Target:     target,
+		Passphrase: passphrase,
+		Interval:   time.Hour,
+		Retain:     2,
+	})
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		name, err := w.Backup()
+		require.NoError(t, err)
+		names = append(names, name)
+		now = now.Add(time.Hour)
+	}
+
+	// Only the two most recent backups are kept.
+	kept, err := target.List()
+	require.NoError(t, err)
+	require.Equal(t, names[1:], kept)
+
+	backup, err := ioutil.ReadFile(filepath.Join(dir, "backups", names[2]))
+	require.NoError(t, err)
+
+	_, err = DecryptBackup(backup, []byte("wrong"))
+	require.Equal(t, snacl.ErrInvalidPassword, err)
+	_, err = DecryptBackup(backup[1:], passphrase)
+	require.Equal(t, ErrBackupMalformed, err)
+
+	// The decrypted backup is a wallet database holding the namespaces of
+	// the wallet.
+	restored, err := DecryptBackup(backup, passphrase)
+	require.NoError(t, err)
+	dbPath := filepath.Join(dir, "restored.db")
+	require.NoError(t, ioutil.WriteFile(dbPath, restored, 0600))
+
+	db, err := walletdb.Open("bdb", dbPath, true, defaultDBTimeout)
+	require.NoError(t, err)
+	defer db.Close()
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		if tx.ReadBucket(waddrmgrNamespaceKey) == nil {
+			return errors.New("missing address manager namespace")
+		}
+		if tx.ReadBucket(wtxmgrNamespaceKey) == nil {
+			return errors.New("missing transaction manager namespace")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// TestScheduledBackupFailure ensures that clients are notified of failed
+// scheduled backups, and that failed backups are retried before the next
+// backup would be due.
+func TestScheduledBackupFailure(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	now := time.Unix(1600000000, 0)
+	w.clock = func() time.Time { return now }
+
+	// No backup is attempted until backups are configured.
+	w.scheduledBackup()
+
+	w.SetBackupConfig(&BackupConfig{
+		Target:     failingBackupTarget{},
+		Passphrase: []byte("backup pass"),
+		Interval:   24 * time.Hour,
+	})
+
+	client := w.NtfnServer.BackupFailureNotifications()
+	defer client.Done()
+
+	go w.scheduledBackup()
+
+	select {
+	case failure := <-client.C:
+		require.Equal(t, now, failure.Time)
+		require.EqualError(t, failure.Err, "target unavailable")
+	case <-time.After(time.Minute):
+		t.Fatal("no backup failure notification")
+	}
+
+	w.backupMtx.Lock()
+	nextBackup := w.nextBackup
+	w.backupMtx.Unlock()
+	require.Equal(t, now.Add(backupRetryInterval), nextBackup)
+}
@@ -0,0 +1,627 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// balanceTotals records the total amounts of a set of unspent outputs by how
+// they are confirmed.
+type balanceTotals struct {
+	// unconfirmed is the total of the unmined outputs.
+	unconfirmed btcutil.Amount
+
+	// confirmed is the total of the mined outputs, excluding immature
+	// coinbase outputs.
+	confirmed btcutil.Amount
+
+	// immature is the total of the coinbase outputs which have not yet
+	// reached maturity.
+	immature btcutil.Amount
+}
+
+// add adds amount to the total the output is counted in.
+func (t *balanceTotals) add(o *cachedOutput, amount btcutil.Amount) {
+	switch {
+	case o.height == -1:
+		t.unconfirmed += amount
+	case o.immature:
+		t.immature += amount
+	default:
+		t.confirmed += amount
+	}
+}
+
+// cachedOutput describes an unspent output counted in the balances of the
+// wallet.
+type cachedOutput struct {
+	amount   btcutil.Amount
+	pkScript []byte
+	height   int32
+	immature bool
+
+	// account is the account of the output, only valid when hasAccount
+	// is set.
+	account    uint32
+	hasAccount bool
+}
+
+// balanceState holds the unspent outputs of the wallet, including those which
+// are locked, along with running totals for the wallet and for each account.
+type balanceState struct {
+	outputs map[wire.OutPoint]*cachedOutput
+
+	// unresolved holds the outputs whose account has not yet been looked
+	// up, and immature holds the coinbase outputs which are not yet known
+	// to be mature.
+	unresolved map[wire.OutPoint]struct{}
+	immature   map[wire.OutPoint]struct{}
+
+	total    balanceTotals
+	accounts map[uint32]*balanceTotals
+
+	// maxHeight is the height of the most recently mined output.
+	maxHeight int32
+}
+
+func newBalanceState() *balanceState {
+	return &balanceState{
+		outputs:    make(map[wire.OutPoint]*cachedOutput),
+		unresolved: make(map[wire.OutPoint]struct{}),
+		immature:   make(map[wire.OutPoint]struct{}),
+		accounts:   make(map[uint32]*balanceTotals),
+		maxHeight:  -1,
+	}
+}
+
+// accountTotals returns the totals of an account.
+func (s *balanceState) accountTotals(account uint32) *balanceTotals {
+	totals, ok := s.accounts[account]
+	if !ok {
+		totals = new(balanceTotals)
+		s.accounts[account] = totals
+	}
+	return totals
+}
+
+// adjust adds amount to the totals the output is counted in.
+func (s *balanceState) adjust(o *cachedOutput, amount btcutil.Amount) {
+	s.total.add(o, amount)
+	if o.hasAccount {
+		s.accountTotals(o.account).add(o, amount)
+	}
+}
+
+// addOutput counts a new unspent output in the balances.
+func (s *balanceState) addOutput(op wire.OutPoint, amount btcutil.Amount,
+	pkScript []byte, height int32, coinbase bool) {
+
+	if _, ok := s.outputs[op]; ok {
+		return
+	}
+	o := &cachedOutput{
+		amount:   amount,
+		pkScript: pkScript,
+		height:   height,
+		immature: coinbase && height != -1,
+	}
+	s.outputs[op] = o
+	s.unresolved[op] = struct{}{}
+	if o.immature {
+		s.immature[op] = struct{}{}
+	}
+	if height > s.maxHeight {
+		s.maxHeight = height
+	}
+	s.adjust(o, amount)
+}
+
+// apply updates the balances for a change to the credits of the transaction
+// store.  Resets of the credits must be handled by the caller.
+func (s *balanceState) apply(change *wtxmgr.CreditChange) {
+	switch change.Type {
+	case wtxmgr.CreditAdded:
+		s.addOutput(
+			change.OutPoint, change.Amount, change.PkScript,
+			change.Height, change.FromCoinBase,
+		)
+
+	case wtxmgr.CreditSpent:
+		o, ok := s.outputs[change.OutPoint]
+		if !ok {
+			return
+		}
+		s.adjust(o, -o.amount)
+		delete(s.outputs, change.OutPoint)
+		delete(s.unresolved, change.OutPoint)
+		delete(s.immature, change.OutPoint)
+
+	case wtxmgr.CreditMined:
+		o, ok := s.outputs[change.OutPoint]
+		if !ok || o.height != -1 {
+			return
+		}
+		s.adjust(o, -o.amount)
+		o.height = change.Height
+		s.adjust(o, o.amount)
+		if o.height > s.maxHeight {
+			s.maxHeight = o.height
+		}
+	}
+}
+
+// mature moves the coinbase outputs which have matured by the sync height to
+// the confirmed totals.
+func (s *balanceState) mature(maturity, syncHeight int32) {
+	for op := range s.immature {
+		o := s.outputs[op]
+		if !confirmed(maturity, o.height, syncHeight) {
+			continue
+		}
+		s.adjust(o, -o.amount)
+		o.immature = false
+		s.adjust(o, o.amount)
+		delete(s.immature, op)
+	}
+}
+
+// lockedTotals returns the totals of the locked outputs, either of the whole
+// wallet or, if filter is set, of a single account.
+func (s *balanceState) lockedTotals(locked []*wtxmgr.LockedOutput,
+	filter bool, account uint32) balanceTotals {
+
+	var totals balanceTotals
+	for _, l := range locked {
+		o, ok := s.outputs[l.Outpoint]
+		if !ok {
+			continue
+		}
+		if filter && (!o.hasAccount || o.account != account) {
+			continue
+		}
+		totals.add(o, o.amount)
+	}
+	return totals
+}
+
+// balanceCache caches the balances of the wallet, keeping them updated with
+// each change to the credits of the transaction store so that they do not
+// need to be recalculated from every unspent output.  The cached balances are
+// dropped whenever the credits change in a way which is not tracked, such as
+// by a reorg or the removal of an unmined transaction, and are loaded again
+// when next requested.
+type balanceCache struct {
+	mu sync.Mutex
+
+	// gen is incremented with each change to the credits, so that
+	// balances loaded from an older view of the transaction store are not
+	// cached.
+	gen uint64
+
+	state *balanceState
+}
+
+// creditChanged updates the cached balances for a committed change to the
+// credits of the transaction store.
+func (c *balanceCache) creditChanged(change wtxmgr.CreditChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if c.state == nil {
+		return
+	}
+	if change.Type == wtxmgr.CreditsReset {
+		c.state = nil
+		return
+	}
+	c.state.apply(&change)
+}
+
+// reset drops the cached balances so that they are loaded again when next
+// requested, for changes which move outputs between accounts without changing
+// the credits, such as merging accounts.
+func (c *balanceCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.state = nil
+}
+
+// loadBalances loads the balances of the wallet from every unspent output of
+// the transaction store.
+func (w *Wallet) loadBalances(txmgrNs walletdb.ReadBucket) (*balanceState,
+	error) {
+
+	unspent, err := w.TxStore.UnspentOutputsWithLocked(txmgrNs)
+	if err != nil {
+		return nil, err
+	}
+	state := newBalanceState()
+	for i := range unspent {
+		output := &unspent[i]
+		state.addOutput(
+			output.OutPoint, output.Amount, output.PkScript,
+			output.Height, output.FromCoinBase,
+		)
+	}
+	return state, nil
+}
+
+// resolveBalanceAccounts counts each output whose account has not yet been
+// looked up in the totals of its account.  Outputs are attributed to the
+// account of the first address of their output script.
+func (w *Wallet) resolveBalanceAccounts(addrmgrNs walletdb.ReadBucket,
+	s *balanceState) {
+
+	for op := range s.unresolved {
+		o := s.outputs[op]
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			o.pkScript, w.chainParams,
+		)
+		if err != nil || len(addrs) == 0 {
+			// Outputs without an address never belong to an
+			// account.
+			delete(s.unresolved, op)
+			continue
+		}
+
+		// The address of the output may not yet be visible to this
+		// database transaction, so its account is looked up again
+		// the next time if not found.
+		_, account, err := w.Manager.AddrAccount(addrmgrNs, addrs[0])
+		if err != nil {
+			continue
+		}
+		delete(s.unresolved, op)
+		o.account = account
+		o.hasAccount = true
+		s.accountTotals(account).add(o, o.amount)
+	}
+}
+
+// viewBalances calls f with the balances of the wallet, loading them from the
+// transaction store if they are not cached, and with the currently locked
+// outputs.  Coinbase outputs are matured by the given sync height.  The
+// balances are not viewed, and false is returned, when outputs are known to
+// be mined above the sync height, as their confirmations can not be
+// determined.
+func (w *Wallet) viewBalances(tx walletdb.ReadTx, syncHeight int32,
+	f func(s *balanceState, locked []*wtxmgr.LockedOutput)) (bool, error) {
+
+	addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+	txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+
+	c := &w.balances
+	c.mu.Lock()
+	state, gen := c.state, c.gen
+	c.mu.Unlock()
+
+	if state == nil {
+		var err error
+		state, err = w.loadBalances(txmgrNs)
+		if err != nil {
+			return false, err
+		}
+
+		// The loaded balances are only cached if the credits have not
+		// changed since they were read.
+		c.mu.Lock()
+		if c.state == nil && c.gen == gen {
+			c.state = state
+		}
+		c.mu.Unlock()
+	}
+
+	locked, err := w.TxStore.ListLockedOutputs(txmgrNs)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if state.maxHeight > syncHeight {
+		return false, nil
+	}
+	w.resolveBalanceAccounts(addrmgrNs, state)
+	state.mature(int32(w.chainParams.CoinbaseMaturity), syncHeight)
+	f(state, locked)
+	return true, nil
+}
+
+// cachedBalance returns the spendable balance of the wallet with at least 0
+// or 1 confirmations from the cached balances.  False is returned if the
+// balance can not be determined from the cache.
+func (w *Wallet) cachedBalance(tx walletdb.ReadTx, confirms,
+	syncHeight int32) (btcutil.Amount, bool, error) {
+
+	if confirms != 0 && confirms != 1 {
+		return 0, false, nil
+	}
+
+	var balance btcutil.Amount
+	ok, err := w.viewBalances(tx, syncHeight, func(s *balanceState,
+		locked []*wtxmgr.LockedOutput) {
+
+		lockedTotals := s.lockedTotals(locked, false, 0)
+		balance = s.total.confirmed - lockedTotals.confirmed
+		if confirms == 0 {
+			balance += s.total.unconfirmed -
+				lockedTotals.unconfirmed
+		}
+	})
+	return balance, ok, err
+}
+
+// cachedImmatureBalance returns the immature coinbase balance of the wallet
+// from the cached balances.  False is returned if the balance can not be
+// determined from the cache.
+func (w *Wallet) cachedImmatureBalance(tx walletdb.ReadTx,
+	syncHeight int32) (btcutil.Amount, bool, error) {
+
+	var balance btcutil.Amount
+	ok, err := w.viewBalances(tx, syncHeight, func(s *balanceState,
+		locked []*wtxmgr.LockedOutput) {
+
+		balance = s.total.immature -
+			s.lockedTotals(locked, false, 0).immature
+	})
+	return balance, ok, err
+}
+
+// cachedAccountBalances returns the balances of an account with at least 0 or
+// 1 confirmations from the cached balances.  False is returned if the balances
+// can not be determined from the cache.
+func (w *Wallet) cachedAccountBalances(tx walletdb.ReadTx, account uint32,
+	confirms, syncHeight int32) (Balances, bool, error) {
+
+	if confirms != 0 && confirms != 1 {
+		return Balances{}, false, nil
+	}
+
+	var bals Balances
+	ok, err := w.viewBalances(tx, syncHeight, func(s *balanceState,
+		locked []*wtxmgr.LockedOutput) {
+
+		var totals balanceTotals
+		if t, ok := s.accounts[account]; ok {
+			totals = *t
+		}
+		lockedTotals := s.lockedTotals(locked, true, account)
+		totals.unconfirmed -= lockedTotals.unconfirmed
+		totals.confirmed -= lockedTotals.confirmed
+		totals.immature -= lockedTotals.immature
+
+		bals.Total = totals.unconfirmed + totals.confirmed +
+			totals.immature
+		bals.ImmatureReward = totals.immature
+		bals.Spendable = totals.confirmed
+		if confirms == 0 {
+			bals.Spendable += totals.unconfirmed
+		}
+	})
+	return bals, ok, err
+}
+
+// cachedAccountTotals sets the total balance of each account of the map from
+// the cached balances.  False is returned if the balances can not be
+// determined from the cache.
+func (w *Wallet) cachedAccountTotals(tx walletdb.ReadTx,
+	m map[uint32]btcutil.Amount, syncHeight int32) (bool, error) {
+
+	return w.viewBalances(tx, syncHeight, func(s *balanceState,
+		locked []*wtxmgr.LockedOutput) {
+
+		for account := range m {
+			var totals balanceTotals
+			if t, ok := s.accounts[account]; ok {
+				totals = *t
+			}
+			lockedTotals := s.lockedTotals(locked, true, account)
+			m[account] = totals.unconfirmed + totals.confirmed +
+				totals.immature - lockedTotals.unconfirmed -
+				lockedTotals.confirmed - lockedTotals.immature
+		}
+	})
+}
+
+// BalanceBreakdown splits a balance by how far its unspent outputs can be
+// trusted.  Locked outputs are excluded.
+type BalanceBreakdown struct {
+	// Confirmed is the total of the mined outputs, excluding immature
+	// coinbase outputs.
+	Confirmed btcutil.Amount
+
+	// TrustedPending is the total of the unmined outputs of transactions
+	// which only spend outputs of the wallet, such as change.
+	TrustedPending btcutil.Amount
+
+	// UntrustedPending is the total of the other unmined outputs.
+	UntrustedPending btcutil.Amount
+
+	// Immature is the total of the coinbase outputs which have not yet
+	// reached maturity.
+	Immature btcutil.Amount
+}
+
+// add adds each component of another breakdown to the breakdown.
+func (b *BalanceBreakdown) add(o *BalanceBreakdown) {
+	b.Confirmed += o.Confirmed
+	b.TrustedPending += o.TrustedPending
+	b.UntrustedPending += o.UntrustedPending
+	b.Immature += o.Immature
+}
+
+// AccountBalanceBreakdown is the balance breakdown of an account returned by
+// BalanceBreakdowns.
+type AccountBalanceBreakdown struct {
+	AccountNumber uint32
+	AccountName   string
+	BalanceBreakdown
+}
+
+// BalanceBreakdowns returns the balance breakdown of the whole wallet, and of
+// every account of the key scope followed by the imported account.
+func (w *Wallet) BalanceBreakdowns(scope waddrmgr.KeyScope) (*BalanceBreakdown,
+	[]AccountBalanceBreakdown, error) {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		total    BalanceBreakdown
+		accounts []AccountBalanceBreakdown
+	)
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+
+		syncBlock := w.Manager.SyncedTo()
+		maturity := int32(w.chainParams.CoinbaseMaturity)
+
+		// The last account wraps around to zero accounts in scopes
+		// holding only the imported account.
+		lastAcct, err := manager.LastAccount(addrmgrNs)
+		if err != nil {
+			return err
+		}
+		accounts = make([]AccountBalanceBreakdown, int(lastAcct+1)+1)
+		for i := range accounts[:len(accounts)-1] {
+			name, err := manager.AccountName(addrmgrNs, uint32(i))
+			if err != nil {
+				return err
+			}
+			accounts[i].AccountNumber = uint32(i)
+			accounts[i].AccountName = name
+		}
+		imported := &accounts[len(accounts)-1]
+		imported.AccountNumber = waddrmgr.ImportedAddrAccount
+		imported.AccountName = waddrmgr.ImportedAddrAccountName
+
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs)
+		if err != nil {
+			return err
+		}
+
+		// trusted records whether each unmined transaction paying the
+		// wallet only spends outputs of the wallet.
+		trusted := make(map[chainhash.Hash]bool)
+		isTrusted := func(hash *chainhash.Hash) (bool, error) {
+			t, ok := trusted[*hash]
+			if ok {
+				return t, nil
+			}
+			details, err := w.TxStore.TxDetails(txmgrNs, hash)
+			if err != nil {
+				return false, err
+			}
+			t = details != nil && spendsOnlyWallet(details)
+			trusted[*hash] = t
+			return t, nil
+		}
+
+		for i := range unspent {
+			output := &unspent[i]
+
+			// The output is counted in a single component of the
+			// breakdowns of the wallet and its account.
+			var b BalanceBreakdown
+			switch {
+			case output.Height == -1:
+				t, err := isTrusted(&output.OutPoint.Hash)
+				if err != nil {
+					return err
+				}
+				if t {
+					b.TrustedPending = output.Amount
+				} else {
+					b.UntrustedPending = output.Amount
+				}
+			case output.FromCoinBase && !confirmed(
+				maturity, output.Height, syncBlock.Height,
+			):
+				b.Immature = output.Amount
+			default:
+				b.Confirmed = output.Amount
+			}
+			total.add(&b)
+
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				output.PkScript, w.chainParams,
+			)
+			if err != nil || len(addrs) == 0 {
+				continue
+			}
+			account, err := manager.AddrAccount(addrmgrNs, addrs[0])
+			if err != nil {
+				continue
+			}
+			switch {
+			case account == waddrmgr.ImportedAddrAccount:
+				imported.add(&b)
+			case account > lastAcct:
+				return errors.New("waddrmgr.Manager.AddrAccount " +
+					"returned account beyond recorded last " +
+					"account")
+			default:
+				accounts[account].add(&b)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &total, accounts, nil
+}
+
+// spendsOnlyWallet returns whether a transaction only spends outputs of the
+// wallet, so that its outputs may be trusted before it is mined.
+func spendsOnlyWallet(details *wtxmgr.TxDetails) bool {
+	return len(details.Debits) == len(details.MsgTx.TxIn)
+}
+
+// OutputsSafe returns, for each of the passed outpoints, whether the output is
+// safe to spend: either mined, or an unmined output of a transaction which only
+// spends outputs of the wallet, counted by the trusted balance.  Unmined
+// outputs paid by other wallets may still be double spent or replaced by their
+// senders, and outputs of unknown transactions are not safe.
+func (w *Wallet) OutputsSafe(ops []wire.OutPoint) ([]bool, error) {
+	safe := make([]bool, len(ops))
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		txSafe := make(map[chainhash.Hash]bool)
+		for i := range ops {
+			hash := &ops[i].Hash
+			s, ok := txSafe[*hash]
+			if !ok {
+				details, err := w.TxStore.TxDetails(txmgrNs, hash)
+				if err != nil {
+					return err
+				}
+				s = details != nil && (details.Block.Height != -1 ||
+					spendsOnlyWallet(details))
+				txSafe[*hash] = s
+			}
+			safe[i] = s
+		}
+		return nil
+	})
+	return safe, err
+}
@@ -0,0 +1,365 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCachedBalances ensures that the cached balances of the wallet are kept
+// updated as transactions are recorded, and match the balances calculated
+// from every unspent output of the transaction store.
+func TestCachedBalances(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	// assertBalances checks the balances of the wallet and of the default
+	// account against those calculated without the cache.
+	assertBalances := func(unconfirmed, confirmed btcutil.Amount) {
+		t.Helper()
+
+		for confs, want := range []btcutil.Amount{
+			unconfirmed + confirmed, confirmed,
+		} {
+			balance, err := w.CalculateBalance(int32(confs))
+			require.NoError(t, err)
+			require.Equal(t, want, balance)
+
+			bals, err := w.CalculateAccountBalances(0, int32(confs))
+			require.NoError(t, err)
+			require.Equal(t, want, bals.Spendable)
+			require.Equal(t, unconfirmed+confirmed, bals.Total)
+		}
+
+		err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+			ns := tx.ReadBucket(wtxmgrNamespaceKey)
+			height := w.Manager.SyncedTo().Height
+			for confs := int32(0); confs <= 1; confs++ {
+				balance, err := w.TxStore.Balance(ns, confs, height)
+				if err != nil {
+					return err
+				}
+				want := confirmed
+				if confs == 0 {
+					want += unconfirmed
+				}
+				require.Equal(t, want, balance)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	cachedState := func() *balanceState {
+		w.balances.mu.Lock()
+		defer w.balances.mu.Unlock()
+		return w.balances.state
+	}
+
+	assertBalances(0, 0)
+	state := cachedState()
+	require.NotNil(t, state)
+
+	// Recording an unmined transaction paying to the wallet updates the
+	// cached balances.
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e6, pkScript))
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, time.Now())
+	require.NoError(t, err)
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return w.addRelevantTx(tx, rec, nil)
+	})
+	require.NoError(t, err)
+	assertBalances(1e6, 0)
+	require.Same(t, state, cachedState())
+
+	// Mining the transaction confirms its output.
+	syncedTo := w.Manager.SyncedTo()
+	block := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{
+			Hash:   syncedTo.Hash,
+			Height: syncedTo.Height,
+		},
+		Time: syncedTo.Timestamp,
+	}
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return w.addRelevantTx(tx, rec, block)
+	})
+	require.NoError(t, err)
+	assertBalances(0, 1e6)
+	require.Same(t, state, cachedState())
+
+	// A locked output is excluded from the balances until it is released.
+	op := wire.OutPoint{Hash: rec.Hash, Index: 0}
+	lockID := wtxmgr.LockID{1}
+	_, err = w.LeaseOutput(lockID, op, time.Hour)
+	require.NoError(t, err)
+	assertBalances(0, 0)
+	require.NoError(t, w.ReleaseOutput(lockID, op))
+	assertBalances(0, 1e6)
+
+	// An unmined spend of the output removes it from the balances, and
+	// its change output is added.
+	spendTx := wire.NewMsgTx(wire.TxVersion)
+	spendTx.AddTxIn(wire.NewTxIn(&op, nil, nil))
+	spendTx.AddTxOut(wire.NewTxOut(4e5, pkScript))
+	spendTx.AddTxOut(wire.NewTxOut(5e5, []byte{txscript.OP_TRUE}))
+	spendRec, err := wtxmgr.NewTxRecordFromMsgTx(spendTx, time.Now())
+	require.NoError(t, err)
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return w.addRelevantTx(tx, spendRec, nil)
+	})
+	require.NoError(t, err)
+	assertBalances(4e5, 0)
+	require.Same(t, state, cachedState())
+
+	// Removing the unmined spend drops the cached balances, which are
+	// loaded again when next requested.
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+		return w.TxStore.RemoveUnminedTx(ns, spendRec)
+	})
+	require.NoError(t, err)
+	require.Nil(t, cachedState())
+	assertBalances(0, 1e6)
+	require.NotNil(t, cachedState())
+
+	// Outputs mined above the height the wallet is synced to are not
+	// served from the cache.
+	aheadTx := wire.NewMsgTx(wire.TxVersion)
+	aheadTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 2}, nil, nil))
+	aheadTx.AddTxOut(wire.NewTxOut(2e6, pkScript))
+	aheadRec, err := wtxmgr.NewTxRecordFromMsgTx(aheadTx, time.Now())
+	require.NoError(t, err)
+	aheadBlock := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{
+			Hash:   chainhash.Hash{1},
+			Height: syncedTo.Height + 1,
+		},
+		Time: time.Now(),
+	}
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return w.addRelevantTx(tx, aheadRec, aheadBlock)
+	})
+	require.NoError(t, err)
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		_, ok, err := w.cachedBalance(tx, 1, syncedTo.Height)
+		require.False(t, ok)
+		return err
+	})
+	require.NoError(t, err)
+	for confs, want := range []btcutil.Amount{3e6, 1e6} {
+		balance, err := w.CalculateBalance(int32(confs))
+		require.NoError(t, err)
+		require.Equal(t, want, balance)
+	}
+}
+
+// TestBalanceBreakdowns ensures that unspent outputs are split between the
+// confirmed, trusted pending, untrusted pending and immature balances of the
+// wallet and of their account, and that only untrusted pending outputs are
+// reported as unsafe.
+func TestBalanceBreakdowns(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0044)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	syncedTo := w.Manager.SyncedTo()
+	block := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{
+			Hash:   syncedTo.Hash,
+			Height: syncedTo.Height,
+		},
+		Time: syncedTo.Timestamp,
+	}
+	addTx := func(msgTx *wire.MsgTx, block *wtxmgr.BlockMeta) *wtxmgr.TxRecord {
+		t.Helper()
+
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, time.Now())
+		require.NoError(t, err)
+		err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+			return w.addRelevantTx(tx, rec, block)
+		})
+		require.NoError(t, err)
+		return rec
+	}
+	assertBreakdowns := func(want BalanceBreakdown) {
+		t.Helper()
+
+		total, accounts, err := w.BalanceBreakdowns(
+			waddrmgr.KeyScopeBIP0044,
+		)
+		require.NoError(t, err)
+		require.Equal(t, want, *total)
+		require.Len(t, accounts, 2)
+		require.Equal(t, "default", accounts[0].AccountName)
+		require.Equal(t, want, accounts[0].BalanceBreakdown)
+		require.Equal(t, waddrmgr.ImportedAddrAccountName,
+			accounts[1].AccountName)
+		require.Equal(t, BalanceBreakdown{}, accounts[1].BalanceBreakdown)
+	}
+	assertSafe := func(hash chainhash.Hash, want bool) {
+		t.Helper()
+
+		safe, err := w.OutputsSafe([]wire.OutPoint{{Hash: hash}})
+		require.NoError(t, err)
+		require.Equal(t, []bool{want}, safe)
+	}
+
+	assertBreakdowns(BalanceBreakdown{})
+
+	// An unmined payment from another wallet is untrusted.
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e6, pkScript))
+	rec := addTx(msgTx, nil)
+	assertBreakdowns(BalanceBreakdown{UntrustedPending: 1e6})
+	assertSafe(rec.Hash, false)
+
+	// Mining it confirms its output.
+	addTx(msgTx, block)
+	assertBreakdowns(BalanceBreakdown{Confirmed: 1e6})
+	assertSafe(rec.Hash, true)
+
+	// The change of an unmined spend of the wallet is trusted.
+	spendTx := wire.NewMsgTx(wire.TxVersion)
+	spendTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: rec.Hash}, nil, nil))
+	spendTx.AddTxOut(wire.NewTxOut(4e5, pkScript))
+	spendTx.AddTxOut(wire.NewTxOut(5e5, []byte{txscript.OP_TRUE}))
+	spendRec := addTx(spendTx, nil)
+	assertBreakdowns(BalanceBreakdown{TrustedPending: 4e5})
+	assertSafe(spendRec.Hash, true)
+	assertSafe(chainhash.Hash{1}, false)
+
+	// A mined coinbase output is immature until it reaches maturity.
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(
+		wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		[]byte{0x51, 0x51}, nil,
+	))
+	coinbase.AddTxOut(wire.NewTxOut(5e8, pkScript))
+	addTx(coinbase, block)
+	assertBreakdowns(BalanceBreakdown{TrustedPending: 4e5, Immature: 5e8})
+}
+
+// TestImmatureCoinbase ensures that coinbase outputs are excluded from the
+// spendable balances and from coin selection until they reach maturity.
+func TestImmatureCoinbase(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	syncedTo := w.Manager.SyncedTo()
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(
+		wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		[]byte{0x51, 0x51}, nil,
+	))
+	coinbase.AddTxOut(wire.NewTxOut(5e8, pkScript))
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(coinbase, time.Now())
+	require.NoError(t, err)
+	block := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{
+			Hash:   syncedTo.Hash,
+			Height: syncedTo.Height,
+		},
+		Time: syncedTo.Timestamp,
+	}
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return w.addRelevantTx(tx, rec, block)
+	})
+	require.NoError(t, err)
+
+	// assertBalances checks the spendable and immature balances, both
+	// from the cached balances and calculated from every unspent output.
+	assertBalances := func(spendable, immature btcutil.Amount) {
+		t.Helper()
+
+		for i := 0; i < 2; i++ {
+			for confs := int32(0); confs <= 1; confs++ {
+				balance, err := w.CalculateBalance(confs)
+				require.NoError(t, err)
+				require.Equal(t, spendable, balance)
+			}
+			balance, err := w.CalculateImmatureBalance()
+			require.NoError(t, err)
+			require.Equal(t, immature, balance)
+
+			bals, err := w.CalculateAccountBalances(0, 1)
+			require.NoError(t, err)
+			require.Equal(t, spendable, bals.Spendable)
+			require.Equal(t, immature, bals.ImmatureReward)
+
+			// Drop the cached balances to calculate them again.
+			w.balances.mu.Lock()
+			w.balances.state = nil
+			w.balances.mu.Unlock()
+		}
+	}
+	eligible := func(bs *waddrmgr.BlockStamp) []wtxmgr.Credit {
+		t.Helper()
+
+		var credits []wtxmgr.Credit
+		err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+			var err error
+			credits, err = w.findEligibleOutputs(
+				tx, nil, 0, 1, bs,
+			)
+			return err
+		})
+		require.NoError(t, err)
+		return credits
+	}
+
+	assertBalances(0, 5e8)
+	require.Empty(t, eligible(&syncedTo))
+
+	// The output matures once it has the required number of
+	// confirmations.
+	maturity := int32(w.chainParams.CoinbaseMaturity)
+	almost := syncedTo
+	almost.Height += maturity - 2
+	require.Empty(t, eligible(&almost))
+
+	mature := syncedTo
+	mature.Height += maturity - 1
+	require.Len(t, eligible(&mature), 1)
+
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return w.Manager.SetSyncedTo(ns, &mature)
+	})
+	require.NoError(t, err)
+	assertBalances(5e8, 0)
+}
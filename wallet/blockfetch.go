@@ -0,0 +1,133 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// blockFetchWorkers is the number of concurrent requests for block hashes and
+// headers made while fetching the blocks scanned by recovery and rescans.
+const blockFetchWorkers = 8
+
+// blockBatch is a batch of consecutive main chain blocks, or the error which
+// prevented fetching it.
+type blockBatch struct {
+	blocks []wtxmgr.BlockMeta
+	err    error
+}
+
+// fetchBlockMeta fetches the hash and timestamp of the main chain block at the
+// height.
+func fetchBlockMeta(chainClient chainConn, height int32) (wtxmgr.BlockMeta,
+	error) {
+
+	hash, err := chainClient.GetBlockHash(int64(height))
+	if err != nil {
+		return wtxmgr.BlockMeta{}, err
+	}
+	header, err := chainClient.GetBlockHeader(hash)
+	if err != nil {
+		return wtxmgr.BlockMeta{}, err
+	}
+	return wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{Hash: *hash, Height: height},
+		Time:  header.Timestamp,
+	}, nil
+}
+
+// fetchBlockMetas fetches the hashes and timestamps of the main chain blocks
+// from startHeight through stopHeight, requesting them concurrently from the
+// chain backend.  The blocks are returned in order of their height.
+func fetchBlockMetas(chainClient chainConn, startHeight,
+	stopHeight int32) ([]wtxmgr.BlockMeta, error) {
+
+	if stopHeight < startHeight {
+		return nil, nil
+	}
+	blocks := make([]wtxmgr.BlockMeta, stopHeight-startHeight+1)
+
+	var (
+		wg       sync.WaitGroup
+		errMtx   sync.Mutex
+		firstErr error
+	)
+	failed := func() bool {
+		errMtx.Lock()
+		defer errMtx.Unlock()
+		return firstErr != nil
+	}
+
+	heights := make(chan int32)
+	for i := 0; i < blockFetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for height := range heights {
+				// Once any request failed, the remaining
+				// heights are drained without requesting them.
+				if failed() {
+					continue
+				}
+
+				block, err := fetchBlockMeta(chainClient, height)
+				if err == nil {
+					blocks[height-startHeight] = block
+					continue
+				}
+
+				errMtx.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errMtx.Unlock()
+			}
+		}()
+	}
+	for height := startHeight; height <= stopHeight; height++ {
+		heights <- height
+	}
+	close(heights)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return blocks, nil
+}
+
+// pipelineBlockBatches fetches the main chain blocks from startHeight through
+// stopHeight in batches of at most batchSize blocks, sending each batch over
+// the returned channel.  The next batch is fetched while the previous one is
+// processed by the receiver.  The channel is closed after the last batch or a
+// batch reporting an error, or once done is closed.
+func pipelineBlockBatches(chainClient chainConn, startHeight,
+	stopHeight int32, batchSize int,
+	done <-chan struct{}) <-chan blockBatch {
+
+	batches := make(chan blockBatch)
+	go func() {
+		defer close(batches)
+		for start := startHeight; start <= stopHeight; {
+			stop := start + int32(batchSize) - 1
+			if stop > stopHeight {
+				stop = stopHeight
+			}
+			blocks, err := fetchBlockMetas(chainClient, start, stop)
+			select {
+			case batches <- blockBatch{blocks: blocks, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+			start = stop + 1
+		}
+	}()
+	return batches
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestPipelineBlockBatches ensures the blocks of a range are fetched in
+// batches of the requested size, in order of their height, and that a failed
+// fetch is reported and ends the pipeline.
+func TestPipelineBlockBatches(t *testing.T) {
+	t.Parallel()
+
+	const chainTip = 250
+	chainConn := createMockChainConn(
+		chainParams.GenesisBlock, chainTip, defaultBlockInterval,
+	)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	var (
+		sizes  []int
+		height int32 = 5
+	)
+	for batch := range pipelineBlockBatches(chainConn, 5, 230, 100, done) {
+		require.NoError(t, batch.err)
+		sizes = append(sizes, len(batch.blocks))
+		for _, block := range batch.blocks {
+			require.Equal(t, height, block.Height)
+			require.Equal(
+				t, chainConn.blockHashes[uint32(height)],
+				block.Hash,
+			)
+			header := chainConn.blocks[block.Hash].Header
+			require.Equal(t, header.Timestamp, block.Time)
+			height++
+		}
+	}
+	require.Equal(t, []int{100, 100, 26}, sizes)
+	require.Equal(t, int32(231), height)
+
+	// Requesting blocks past the chain tip should deliver the batches
+	// before it, then the error, and close the channel.
+	var errs int
+	sizes = nil
+	for batch := range pipelineBlockBatches(chainConn, 0, 400, 100, done) {
+		if batch.err != nil {
+			errs++
+			continue
+		}
+		sizes = append(sizes, len(batch.blocks))
+	}
+	require.Equal(t, []int{100, 100}, sizes)
+	require.Equal(t, 1, errs)
+}
+
+// TestPipelineBlockBatchesDone ensures the pipeline stops once done is closed
+// without its batches being received.
+func TestPipelineBlockBatchesDone(t *testing.T) {
+	t.Parallel()
+
+	chainConn := createMockChainConn(
+		chainParams.GenesisBlock, 100, defaultBlockInterval,
+	)
+
+	done := make(chan struct{})
+	batches := pipelineBlockBatches(chainConn, 0, 100, 10, done)
+	batch := <-batches
+	require.NoError(t, batch.err)
+	require.Len(t, batch.blocks, 10)
+
+	close(done)
+	for range batches {
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"time"
+
+	"github.com/btcsuite/btcwallet/chain"
+)
+
+const (
+	// DefaultChainStallTimeout is the suggested duration without a new
+	// block after which the chain is considered stalled.  Blocks are
+	// expected every ten minutes, so a gap of this length is very unlikely
+	// on a healthy network.
+	DefaultChainStallTimeout = 90 * time.Minute
+
+	// minorityForkDepth is the number of blocks the majority of the chain
+	// server's peers must be ahead of the wallet's best block, while the
+	// chain server knows of no headers that far ahead, before the chain
+	// server is considered to be on a minority fork.
+	minorityForkDepth = 6
+
+	// chainHealthInterval is the interval at which the health of the
+	// chain is checked.
+	chainHealthInterval = time.Minute
+)
+
+// ChainHealth describes whether the chain followed by the wallet's chain
+// server appears to be stalled or on a minority fork.  Transactions created
+// while the chain is unhealthy risk being invalidated or never confirming.
+type ChainHealth struct {
+	// Stalled is set when no new block has been connected for longer
+	// than the stall timeout.
+	Stalled bool
+
+	// MinorityFork is set when the majority of the chain server's peers
+	// report a best block well ahead of the wallet's best block, which
+	// the chain server has not accepted the headers of.
+	MinorityFork bool
+
+	// LastBlockSeen is the time the last block was connected.
+	LastBlockSeen time.Time
+}
+
+// Risky returns whether transactions created while the chain is in this state
+// risk being invalidated or never confirming.
+func (h *ChainHealth) Risky() bool {
+	return h.Stalled || h.MinorityFork
+}
+
+// ChainHealth returns the current health of the chain followed by the wallet.
+func (w *Wallet) ChainHealth() ChainHealth {
+	w.chainHealthMtx.Lock()
+	defer w.chainHealthMtx.Unlock()
+
+	return w.chainHealth
+}
+
+// SetChainStallTimeout sets the duration without a new block after which the
+// chain is considered stalled.  A timeout of zero disables stall detection.
+func (w *Wallet) SetChainStallTimeout(timeout time.Duration) {
+	w.chainHealthMtx.Lock()
+	w.chainStallTimeout = timeout
+	w.chainHealthMtx.Unlock()
+}
+
+// recordBlockSeen records that a block was connected, resolving any stall.
+func (w *Wallet) recordBlockSeen() {
+	w.chainHealthMtx.Lock()
+	health := w.chainHealth
+	health.Stalled = false
+	health.LastBlockSeen = w.Now()
+	changed := w.setChainHealth(health)
+	w.chainHealthMtx.Unlock()
+
+	if changed {
+		w.NtfnServer.notifyChainHealth(health)
+	}
+}
+
+// setChainHealth replaces the chain health, returning whether a problem was
+// detected or resolved.  Clients should be notified of changes after the chain
+// health mutex, which must be held, is released.
+func (w *Wallet) setChainHealth(health ChainHealth) bool {
+	old := w.chainHealth
+	w.chainHealth = health
+	if old.Stalled == health.Stalled &&
+		old.MinorityFork == health.MinorityFork {
+
+		return false
+	}
+
+	switch {
+	case health.Stalled && !old.Stalled:
+		log.Warnf("No new block has been seen since %v, the chain "+
+			"may be stalled", health.LastBlockSeen)
+	case !health.Stalled && old.Stalled:
+		log.Infof("New block seen, the chain is no longer stalled")
+	}
+	switch {
+	case health.MinorityFork && !old.MinorityFork:
+		log.Warnf("Most peers of the chain server are more than %d "+
+			"blocks ahead, the chain server may be on a minority "+
+			"fork", minorityForkDepth)
+	case !health.MinorityFork && old.MinorityFork:
+		log.Infof("The chain server is no longer behind its peers")
+	}
+
+	return true
+}
+
+// evalChainHealth evaluates the health of the chain given the time the last
+// block was connected, the wallet's best block height, the height of the best
+// header known to the chain server, and the best block heights reported by the
+// chain server's peers.  Peers being ahead only indicates a minority fork when
+// the chain server has not accepted their headers.  Otherwise the chain server
+// is still downloading the blocks of the chain it follows.
+func evalChainHealth(now, lastBlockSeen time.Time, stallTimeout time.Duration,
+	bestHeight, headerHeight int32, peerHeights []int32) ChainHealth {
+
+	health := ChainHealth{
+		LastBlockSeen: lastBlockSeen,
+	}
+	if stallTimeout > 0 && now.Sub(lastBlockSeen) > stallTimeout {
+		health.Stalled = true
+	}
+
+	if headerHeight > bestHeight+minorityForkDepth {
+		return health
+	}
+	var ahead int
+	for _, height := range peerHeights {
+		if height > bestHeight+minorityForkDepth {
+			ahead++
+		}
+	}
+	if len(peerHeights) > 0 && ahead*2 > len(peerHeights) {
+		health.MinorityFork = true
+	}
+
+	return health
+}
+
+// peerHeights returns the height of the best header known to the chain server
+// and the best block heights reported by its peers.  Peer data is only
+// available from btcd chain servers, and no heights are returned for other
+// backends.
+func peerHeights(chainClient chain.Interface) (int32, []int32, error) {
+	rpcClient, ok := chainClient.(*chain.RPCClient)
+	if !ok {
+		return 0, nil, nil
+	}
+
+	info, err := rpcClient.GetBlockChainInfo()
+	if err != nil {
+		return 0, nil, err
+	}
+	peers, err := rpcClient.GetPeerInfo()
+	if err != nil {
+		return 0, nil, err
+	}
+	heights := make([]int32, 0, len(peers))
+	for _, peer := range peers {
+		height := peer.CurrentHeight
+		if height == 0 {
+			height = peer.StartingHeight
+		}
+		heights = append(heights, height)
+	}
+	return info.Headers, heights, nil
+}
+
+// initChainHealth starts the stall timeout from the current time if no block
+// has been seen yet.
+func (w *Wallet) initChainHealth() {
+	w.chainHealthMtx.Lock()
+	if w.chainHealth.LastBlockSeen.IsZero() {
+		w.chainHealth.LastBlockSeen = w.Now()
+	}
+	w.chainHealthMtx.Unlock()
+}
+
+// chainHealthMonitor periodically checks whether the chain followed by the
+// wallet is stalled or on a minority fork.
+//
+// NOTE: This MUST be run as a goroutine.
+func (w *Wallet) chainHealthMonitor() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(chainHealthInterval)
+	defer ticker.Stop()
+
+	quit := w.quitChan()
+	for {
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+
+		// The chain can't be judged until the wallet has caught up
+		// with the chain server.
+		if !w.ChainSynced() {
+			continue
+		}
+
+		var (
+			headerHeight int32
+			heights      []int32
+		)
+		if chainClient := w.ChainClient(); chainClient != nil {
+			var err error
+			headerHeight, heights, err = peerHeights(chainClient)
+			if err != nil {
+				log.Debugf("Unable to query chain server "+
+					"peers: %v", err)
+			}
+		}
+
+		bestHeight := w.Manager.SyncedTo().Height
+		w.chainHealthMtx.Lock()
+		health := evalChainHealth(
+			w.Now(), w.chainHealth.LastBlockSeen,
+			w.chainStallTimeout, bestHeight, headerHeight, heights,
+		)
+		changed := w.setChainHealth(health)
+		w.chainHealthMtx.Unlock()
+
+		if changed {
+			w.NtfnServer.notifyChainHealth(health)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestEvalChainHealth ensures that stalls and minority forks are detected
+// from the time since the last block, the best header of the chain server and
+// the best heights of peers.
+func TestEvalChainHealth(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1600000000, 0)
+	const bestHeight = 1000
+
+	tests := []struct {
+		name         string
+		sinceBlock   time.Duration
+		stallTimeout time.Duration
+		headerHeight int32
+		peerHeights  []int32
+		stalled      bool
+		minorityFork bool
+	}{
+		{
+			name:         "healthy",
+			sinceBlock:   10 * time.Minute,
+			stallTimeout: DefaultChainStallTimeout,
+			peerHeights:  []int32{1000, 1001, 1000},
+		},
+		{
+			name:         "stalled",
+			sinceBlock:   2 * time.Hour,
+			stallTimeout: DefaultChainStallTimeout,
+			stalled:      true,
+		},
+		{
+			name:         "stall detection disabled",
+			sinceBlock:   2 * time.Hour,
+			stallTimeout: 0,
+		},
+		{
+			name:         "minority fork",
+			sinceBlock:   time.Minute,
+			stallTimeout: DefaultChainStallTimeout,
+			headerHeight: bestHeight,
+			peerHeights:  []int32{1010, 1000, 1012},
+			minorityFork: true,
+		},
+		{
+			name:         "chain server downloading blocks",
+			sinceBlock:   time.Minute,
+			stallTimeout: DefaultChainStallTimeout,
+			headerHeight: 1012,
+			peerHeights:  []int32{1010, 1000, 1012},
+		},
+		{
+			name:         "minority of peers ahead",
+			sinceBlock:   time.Minute,
+			stallTimeout: DefaultChainStallTimeout,
+			peerHeights:  []int32{1010, 1000, 1001, 1002},
+		},
+		{
+			name:         "peers within fork depth",
+			sinceBlock:   time.Minute,
+			stallTimeout: DefaultChainStallTimeout,
+			peerHeights:  []int32{1006, 1006},
+		},
+	}
+
+	for _, test := range tests {
+		health := evalChainHealth(
+			now, now.Add(-test.sinceBlock), test.stallTimeout,
+			bestHeight, test.headerHeight, test.peerHeights,
+		)
+		require.Equal(t, test.stalled, health.Stalled, test.name)
+		require.Equal(t, test.minorityFork, health.MinorityFork,
+			test.name)
+		require.Equal(t, test.stalled || test.minorityFork,
+			health.Risky(), test.name)
+	}
+}
+
+// TestChainHealthNotifications ensures that clients are notified when a stall
+// is resolved by a new block.
+func TestChainHealthNotifications(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	client := w.NtfnServer.ChainHealthNotifications()
+	defer client.Done()
+
+	w.chainHealthMtx.Lock()
+	changed := w.setChainHealth(ChainHealth{Stalled: true})
+	w.chainHealthMtx.Unlock()
+	require.True(t, changed)
+	health := w.ChainHealth()
+	require.True(t, health.Risky())
+
+	go w.recordBlockSeen()
+	select {
+	case n := <-client.C:
+		require.False(t, n.Stalled)
+		require.False(t, n.Risky())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no chain health notification received")
+	}
+	health = w.ChainHealth()
+	require.False(t, health.Risky())
+}
@@ -129,6 +129,18 @@ func (w *Wallet) handleChainNotifications() {
 				notificationName = "block disconnected"
 			case chain.RelevantTx:
 				err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+					// A transaction accepted to the
+					// mempool supersedes any unmined
+					// transactions it double spends.
+					if n.Block == nil {
+						txmgrNs := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+						err := w.TxStore.RemoveDoubleSpends(
+							txmgrNs, n.TxRecord,
+						)
+						if err != nil {
+							return err
+						}
+					}
 					return w.addRelevantTx(tx, n.TxRecord, n.Block)
 				})
 				notificationName = "relevant transaction"
@@ -211,6 +223,7 @@ func (w *Wallet) connectBlock(dbtx walletdb.ReadWriteTx, b wtxmgr.BlockMeta) err
 	if err != nil {
 		return err
 	}
+	w.recordBlockSeen()
 
 	// Notify interested clients of the connected block.
 	//
@@ -232,12 +245,33 @@ func (w *Wallet) disconnectBlock(dbtx walletdb.ReadWriteTx, b wtxmgr.BlockMeta)
 
 	// Disconnect the removed block and all blocks after it if we know about
 	// the disconnected block. Otherwise, the block is in the future.
-	if b.Height <= w.Manager.SyncedTo().Height {
+	var rolledBack []TransactionSummary
+	syncedHeight := w.Manager.SyncedTo().Height
+	if b.Height <= syncedHeight {
 		hash, err := w.Manager.BlockHash(addrmgrNs, b.Height)
 		if err != nil {
 			return err
 		}
 		if bytes.Equal(hash[:], b.Hash[:]) {
+			// Summarize the transactions mined in the removed
+			// blocks before they are rolled back, so that the
+			// balances of their accounts are notified again once
+			// they revert to unconfirmed or are removed.
+			rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
+				for i := range details {
+					rolledBack = append(rolledBack, makeTxSummary(
+						dbtx, w, &details[i],
+					))
+				}
+				return false, nil
+			}
+			err = w.TxStore.RangeTransactions(
+				txmgrNs, b.Height, syncedHeight, rangeFn,
+			)
+			if err != nil {
+				return err
+			}
+
 			bs := waddrmgr.BlockStamp{
 				Height: b.Height - 1,
 			}
@@ -245,7 +279,6 @@ func (w *Wallet) disconnectBlock(dbtx walletdb.ReadWriteTx, b wtxmgr.BlockMeta)
 			if err != nil {
 				return err
 			}
-			b.Hash = *hash
 
 			client := w.ChainClient()
 			header, err := client.GetBlockHeader(hash)
@@ -267,7 +300,7 @@ func (w *Wallet) disconnectBlock(dbtx walletdb.ReadWriteTx, b wtxmgr.BlockMeta)
 	}
 
 	// Notify interested clients of the disconnected block.
-	w.NtfnServer.notifyDetachedBlock(&b.Hash)
+	w.NtfnServer.notifyDetachedBlock(&b.Hash, rolledBack)
 
 	return nil
 }
@@ -276,6 +309,26 @@ func (w *Wallet) addRelevantTx(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord,
 	addrmgrNs := dbtx.ReadWriteBucket(waddrmgrNamespaceKey)
 	txmgrNs := dbtx.ReadWriteBucket(wtxmgrNamespaceKey)
 
+	// Look up the output scripts of every wallet output spent by the
+	// transaction before it is inserted, since afterwards the outputs are
+	// no longer unspent.
+	prevScripts, err := w.TxStore.PreviousPkScripts(txmgrNs, rec, nil)
+	if err != nil {
+		return err
+	}
+
+	// Large deposits are alerted when their transaction is first seen, so
+	// deposits of mined transactions already recorded unmined are not
+	// alerted again.
+	var wasUnmined bool
+	if block != nil {
+		details, err := w.TxStore.UniqueTxDetails(txmgrNs, &rec.Hash, nil)
+		if err != nil {
+			return err
+		}
+		wasUnmined = details != nil
+	}
+
 	// At the moment all notified transactions are assumed to actually be
 	// relevant.  This assumption will not hold true when SPV support is
 	// added, but until then, simply insert the transaction because there
@@ -293,42 +346,42 @@ func (w *Wallet) addRelevantTx(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord,
 		return nil
 	}
 
-	// Check every output to determine whether it is controlled by a wallet
-	// key.  If so, mark the output as a credit.
-	for i, output := range rec.MsgTx.TxOut {
-		_, addrs, _, err := txscript.ExtractPkScriptAddrs(output.PkScript,
+	// Addresses which have been spent from are marked dirty so that coin
+	// selection for accounts avoiding address reuse can recognize any
+	// later outputs paying to them.
+	for _, pkScript := range prevScripts {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
 			w.chainParams)
 		if err != nil {
-			// Non-standard outputs are skipped.
 			continue
 		}
 		for _, addr := range addrs {
-			ma, err := w.Manager.Address(addrmgrNs, addr)
-			if err == nil {
-				// TODO: Credits should be added with the
-				// account they belong to, so wtxmgr is able to
-				// track per-account balances.
-				err = w.TxStore.AddCredit(txmgrNs, rec, block, uint32(i),
-					ma.Internal())
-				if err != nil {
-					return err
-				}
-				err = w.Manager.MarkUsed(addrmgrNs, addr)
-				if err != nil {
-					return err
-				}
-				log.Debugf("Marked address %v used", addr)
-				continue
-			}
-
-			// Missing addresses are skipped.  Other errors should
-			// be propagated.
-			if !waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+			err := w.Manager.MarkDirty(addrmgrNs, addr)
+			if err != nil && !waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
 				return err
 			}
 		}
 	}
 
+	if err := w.addCredits(addrmgrNs, txmgrNs, rec, block); err != nil {
+		return err
+	}
+
+	if !wasUnmined {
+		deposits, err := w.largeDeposits(addrmgrNs, rec)
+		if err != nil {
+			return err
+		}
+		if len(deposits) != 0 {
+			dbtx.OnCommit(func() {
+				for i := range deposits {
+					deposits[i].Mined = block != nil
+					w.alertLargeDeposit(&deposits[i])
+				}
+			})
+		}
+	}
+
 	// Send notification of mined or unmined transaction to any interested
 	// clients.
 	//
@@ -365,6 +418,67 @@ func (w *Wallet) addRelevantTx(dbtx walletdb.ReadWriteTx, rec *wtxmgr.TxRecord,
 	return nil
 }
 
+// addCredits marks every output of a transaction which is controlled by a
+// wallet key as a credit, and marks its address used.  Credits which were
+// already recorded are left unchanged.
+func (w *Wallet) addCredits(addrmgrNs, txmgrNs walletdb.ReadWriteBucket,
+	rec *wtxmgr.TxRecord, block *wtxmgr.BlockMeta) error {
+
+	// Check every output to determine whether it is controlled by a wallet
+	// key.  If so, mark the output as a credit.
+	var claimedLookahead bool
+	for i, output := range rec.MsgTx.TxOut {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(output.PkScript,
+			w.chainParams)
+		if err != nil {
+			// Non-standard outputs are skipped.
+			continue
+		}
+		for _, addr := range addrs {
+			// Payments to the lookahead window extend the paying
+			// account's addresses so the output can be credited.
+			claimed, err := w.claimLookaheadAddr(addrmgrNs, addr)
+			if err != nil {
+				return err
+			}
+			claimedLookahead = claimedLookahead || claimed
+
+			ma, err := w.Manager.Address(addrmgrNs, addr)
+			if err == nil {
+				// TODO: Credits should be added with the
+				// account they belong to, so wtxmgr is able to
+				// track per-account balances.
+				err = w.TxStore.AddCredit(txmgrNs, rec, block, uint32(i),
+					ma.Internal())
+				if err != nil {
+					return err
+				}
+				err = w.Manager.MarkUsed(addrmgrNs, addr)
+				if err != nil {
+					return err
+				}
+				log.Debugf("Marked address %v used", addr)
+				continue
+			}
+
+			// Missing addresses are skipped.  Other errors should
+			// be propagated.
+			if !waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+				return err
+			}
+		}
+	}
+
+	// Advance the lookahead window past any claimed addresses.
+	if claimedLookahead {
+		if err := w.updateLookahead(addrmgrNs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // chainConn is an interface that abstracts the chain connection logic required
 // to perform a wallet's birthday block sanity check.
 type chainConn interface {
@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
 	"testing"
@@ -8,9 +9,14 @@ import (
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
 	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -308,3 +314,142 @@ func TestBirthdaySanityCheckHigherEstimate(t *testing.T) {
 			"%v vs %v", birthdayStore.syncedTo, birthdayBlock)
 	}
 }
+
+// TestDisconnectBlockRollback ensures that transactions mined in a
+// disconnected block revert to unconfirmed, and that the balances of their
+// accounts are notified once the new best chain is attached.
+func TestDisconnectBlockRollback(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	blockMeta := func(height int32, hash byte) wtxmgr.BlockMeta {
+		return wtxmgr.BlockMeta{
+			Block: wtxmgr.Block{
+				Hash:   chainhash.Hash{hash},
+				Height: height,
+			},
+			Time: time.Unix(1387737310, 0),
+		}
+	}
+
+	// mineTx records a transaction in the block, crediting the wallet for
+	// each of its outputs paying to the wallet, and connects the block.
+	mineTx := func(block wtxmgr.BlockMeta, tx *wire.MsgTx) {
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+		require.NoError(t, err)
+
+		err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+			txmgrNs := dbtx.ReadWriteBucket(wtxmgrNamespaceKey)
+
+			err := w.TxStore.InsertTx(txmgrNs, rec, &block)
+			if err != nil {
+				return err
+			}
+			for i, txOut := range tx.TxOut {
+				if !bytes.Equal(txOut.PkScript, pkScript) {
+					continue
+				}
+				err := w.TxStore.AddCredit(
+					txmgrNs, rec, &block, uint32(i), false,
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return w.connectBlock(dbtx, block)
+		})
+		require.NoError(t, err)
+	}
+
+	fundingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(60000, pkScript),
+			wire.NewTxOut(50000, pkScript),
+		},
+	}
+	mineTx(blockMeta(1, 1), fundingTx)
+
+	spendingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{
+				Hash:  fundingTx.TxHash(),
+				Index: 0,
+			},
+		}},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(55000, []byte{txscript.OP_TRUE}),
+		},
+	}
+	mineTx(blockMeta(2, 2), spendingTx)
+
+	w.SetChainSynced(true)
+	client := w.NtfnServer.TransactionNotifications()
+	defer client.Done()
+
+	// Disconnect the block mining the spend.  The spend must revert to
+	// unconfirmed while still spending the output of the wallet.
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		return w.disconnectBlock(dbtx, blockMeta(2, 2))
+	})
+	require.NoError(t, err)
+
+	spendHash := spendingTx.TxHash()
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+
+		details, err := w.TxStore.TxDetails(txmgrNs, &spendHash)
+		if err != nil {
+			return err
+		}
+		require.NotNil(t, details)
+		require.Equal(t, int32(-1), details.Block.Height)
+
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs)
+		if err != nil {
+			return err
+		}
+		require.Len(t, unspent, 1)
+		require.Equal(t, uint32(1), unspent[0].Index)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), w.Manager.SyncedTo().Height)
+
+	// Attaching a longer chain of empty blocks notifies the detached block
+	// and the balance of the account of the rolled back spend.
+	ntfns := make(chan *TransactionNotifications, 1)
+	go func() {
+		ntfns <- <-client.C
+	}()
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		err := w.connectBlock(dbtx, blockMeta(2, 3))
+		if err != nil {
+			return err
+		}
+		return w.connectBlock(dbtx, blockMeta(3, 4))
+	})
+	require.NoError(t, err)
+
+	var n *TransactionNotifications
+	select {
+	case n = <-ntfns:
+	case <-time.After(time.Second):
+		t.Fatal("no transaction notification received")
+	}
+	require.Equal(t, []*chainhash.Hash{{2}}, n.DetachedBlocks)
+	require.Len(t, n.AttachedBlocks, 2)
+	require.Equal(t, []*chainhash.Hash{&spendHash},
+		n.UnminedTransactionHashes)
+	require.Equal(t, []AccountBalance{{
+		Account:      0,
+		TotalBalance: btcutil.Amount(50000),
+	}}, n.NewBalances)
+}
@@ -6,6 +6,8 @@
 package wallet
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"math/rand"
 	"sort"
@@ -16,6 +18,7 @@ import (
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
 	"github.com/btcsuite/btcwallet/wallet/txsizes"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/btcsuite/btcwallet/wtxmgr"
@@ -53,11 +56,125 @@ func makeInputSource(eligible []wtxmgr.Credit) txauthor.InputSource {
 	}
 }
 
+// txCreateOptions is a set of optional arguments to modify the tx creation
+// process.
+type txCreateOptions struct {
+	// allowAddressReuse permits coin selection to combine outputs paying
+	// to dirty addresses with those paying to clean addresses, even when
+	// the account avoids address reuse.
+	allowAddressReuse bool
+
+	// changeAddress, when set, receives the change output instead of a
+	// new internal address of the account.
+	changeAddress btcutil.Address
+
+	// noChange omits the change output, paying any remaining output
+	// value as fee.
+	noChange bool
+
+	// subtractFeeFrom holds the output scripts of the outputs which pay
+	// the transaction fee, split equally, instead of the spent inputs.
+	subtractFeeFrom [][]byte
+
+	// comment and commentTo are recorded with a published send, describing
+	// its purpose and the person or organization it pays.
+	comment   string
+	commentTo string
+
+	// unsigned creates the transaction without adding its input scripts,
+	// leaving it to be signed later, while still recording its change
+	// address.
+	unsigned bool
+
+	// lockInputs locks the outpoints spent by the transaction as they are
+	// selected, so that no other transaction created in the meantime
+	// selects them, for transactions which are held before they are
+	// published.
+	lockInputs bool
+}
+
+// TxCreateOption is a set of optional arguments to modify the tx creation
+// process. This can be used to do things like allow address reuse for a
+// single transaction.
+type TxCreateOption func(*txCreateOptions)
+
+// defaultTxCreateOptions is the default set of options.
+func defaultTxCreateOptions() *txCreateOptions {
+	return &txCreateOptions{}
+}
+
+// WithAllowAddressReuse is a functional option that permits coin selection to
+// combine outputs paying to dirty and clean addresses when spending from an
+// account which avoids address reuse.
+func WithAllowAddressReuse() TxCreateOption {
+	return func(opts *txCreateOptions) {
+		opts.allowAddressReuse = true
+	}
+}
+
+// WithChangeAddress is a functional option that pays the change output of the
+// transaction to the given address instead of a new internal address of the
+// spending account.
+func WithChangeAddress(addr btcutil.Address) TxCreateOption {
+	return func(opts *txCreateOptions) {
+		opts.changeAddress = addr
+	}
+}
+
+// WithNoChange is a functional option that omits the change output of the
+// transaction, adding any remaining output value to the fee.
+func WithNoChange() TxCreateOption {
+	return func(opts *txCreateOptions) {
+		opts.noChange = true
+	}
+}
+
+// withoutSigning is a functional option that creates the transaction without
+// signing its inputs, as for draft transactions which are signed once they are
+// committed.
+func withoutSigning() TxCreateOption {
+	return func(opts *txCreateOptions) {
+		opts.unsigned = true
+	}
+}
+
+// withLockedInputs is a functional option that locks the inputs of the
+// transaction when they are selected.  The caller must unlock them once the
+// transaction is published or discarded.
+func withLockedInputs() TxCreateOption {
+	return func(opts *txCreateOptions) {
+		opts.lockInputs = true
+	}
+}
+
+// WithSubtractFeeFrom is a functional option that deducts the transaction fee
+// from the values of the outputs paying to any of the given output scripts,
+// so that the recipients bear the fee.  The fee is split equally between these
+// outputs, with the first such output paying any remainder.
+func WithSubtractFeeFrom(pkScripts ...[]byte) TxCreateOption {
+	return func(opts *txCreateOptions) {
+		opts.subtractFeeFrom = append(opts.subtractFeeFrom, pkScripts...)
+	}
+}
+
+// WithComment is a functional option that records comments with the send
+// once it is published: comment describes the purpose of the transaction and
+// commentTo the person or organization paid.  Either may be empty.  The
+// comments are returned by the transaction's details.
+func WithComment(comment, commentTo string) TxCreateOption {
+	return func(opts *txCreateOptions) {
+		opts.comment = comment
+		opts.commentTo = commentTo
+	}
+}
+
 // secretSource is an implementation of txauthor.SecretSource for the wallet's
-// address manager.
+// address manager.  It also implements txauthor.ExternalSigner for the keys
+// held by the wallet's signer providers.
 type secretSource struct {
 	*waddrmgr.Manager
-	addrmgrNs walletdb.ReadBucket
+	addrmgrNs      walletdb.ReadBucket
+	signerProvider func(name string) (SignerProvider, error)
 }
 
 func (s secretSource) GetKey(addr btcutil.Address) (*btcec.PrivateKey, bool, error) {
@@ -103,13 +220,23 @@ func (s secretSource) GetScript(addr btcutil.Address) ([]byte, error) {
 // included based on the wallet's current relay fee. The wallet must be
 // unlocked to create the transaction.
 //
+// When the account avoids address reuse, outputs paying to dirty addresses
+// are never combined with outputs paying to clean addresses unless the
+// WithAllowAddressReuse option is passed.  Clean outputs are preferred, and
+// dirty outputs are only spent when the clean outputs are insufficient.
+//
 // NOTE: The dryRun argument can be set true to create a tx that doesn't alter
 // the database. A tx created with this set to true will intentionally have no
 // input scripts added and SHOULD NOT be broadcasted.
 func (w *Wallet) txToOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 	account uint32, minconf int32, feeSatPerKb btcutil.Amount,
-	coinSelectionStrategy CoinSelectionStrategy, dryRun bool) (
-	*txauthor.AuthoredTx, error) {
+	coinSelectionStrategy CoinSelectionStrategy, dryRun bool,
+	optFuncs ...TxCreateOption) (*txauthor.AuthoredTx, error) {
+
+	opts := defaultTxCreateOptions()
+	for _, optFunc := range optFuncs {
+		optFunc(opts)
+	}
 
 	chainClient, err := w.requireChainClient()
 	if err != nil {
@@ -130,6 +257,24 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 		if err != nil {
 			return err
 		}
+		switch {
+		case opts.noChange:
+			changeSource = &txauthor.ChangeSource{}
+
+		case opts.changeAddress != nil:
+			changeScript, err := txscript.PayToAddrScript(
+				opts.changeAddress,
+			)
+			if err != nil {
+				return err
+			}
+			changeSource = &txauthor.ChangeSource{
+				ScriptSize: len(changeScript),
+				NewScript: func() ([]byte, error) {
+					return changeScript, nil
+				},
+			}
+		}
 
 		eligible, err := w.findEligibleOutputs(
 			dbtx, keyScope, account, minconf, bs,
@@ -138,44 +283,47 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 			return err
 		}
 
-		var inputSource txauthor.InputSource
-
-		switch coinSelectionStrategy {
-		// Pick largest outputs first.
-		case CoinSelectionLargest:
-			sort.Sort(sort.Reverse(byAmount(eligible)))
-			inputSource = makeInputSource(eligible)
-
-		// Select coins at random. This prevents the creation of ever
-		// smaller utxos over time that may never become economical to
-		// spend.
-		case CoinSelectionRandom:
-			// Skip inputs that do not raise the total transaction
-			// output value at the requested fee rate.
-			var positivelyYielding []wtxmgr.Credit
-			for _, output := range eligible {
-				output := output
-
-				if !inputYieldsPositively(&output, feeSatPerKb) {
-					continue
-				}
-
-				positivelyYielding = append(
-					positivelyYielding, output,
+		// Accounts avoiding address reuse select inputs from the
+		// outputs to clean addresses first, and only from the outputs
+		// to dirty addresses when those are insufficient.
+		candidates := [][]wtxmgr.Credit{eligible}
+		if !opts.allowAddressReuse {
+			avoidReuse, err := w.accountAvoidsReuse(
+				addrmgrNs, keyScope, account,
+			)
+			if err != nil {
+				return err
+			}
+			if avoidReuse {
+				clean, dirty := w.partitionDirtyOutputs(
+					addrmgrNs, eligible,
 				)
+				candidates = [][]wtxmgr.Credit{clean, dirty}
 			}
+		}
 
-			rand.Shuffle(len(positivelyYielding), func(i, j int) {
-				positivelyYielding[i], positivelyYielding[j] =
-					positivelyYielding[j], positivelyYielding[i]
-			})
+		for i, candidate := range candidates {
+			inputSource := makeCoinSelectionInputSource(
+				candidate, coinSelectionStrategy, feeSatPerKb,
+			)
+			if len(opts.subtractFeeFrom) > 0 {
+				tx, err = authorTxSubtractFee(
+					outputs, opts.subtractFeeFrom,
+					feeSatPerKb, inputSource, changeSource,
+				)
+			} else {
+				tx, err = txauthor.NewUnsignedTransaction(
+					outputs, feeSatPerKb, inputSource,
+					changeSource,
+				)
+			}
+			if _, ok := err.(txauthor.InputSourceError); ok &&
+				i != len(candidates)-1 {
 
-			inputSource = makeInputSource(positivelyYielding)
+				continue
+			}
+			break
 		}
-
-		tx, err = txauthor.NewUnsignedTransaction(
-			outputs, feeSatPerKb, inputSource, changeSource,
-		)
 		if err != nil {
 			return err
 		}
@@ -217,10 +365,22 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 		if err != nil {
 			return err
 		}
-		if !watchOnly {
-			err = tx.AddAllInputScripts(
-				secretSource{w.Manager, addrmgrNs},
-			)
+
+		// The imported account is assumed to be watch-only, but its
+		// inputs are signed when all of their keys are held by signer
+		// providers.
+		secrets := secretSource{w.Manager, addrmgrNs, w.signerProvider}
+		if watchOnly && account == waddrmgr.ImportedAddrAccount &&
+			!w.Manager.WatchOnly() {
+
+			external, err := secrets.signsExternally(tx.PrevScripts)
+			if err != nil {
+				return err
+			}
+			watchOnly = !external
+		}
+		if !watchOnly && !opts.unsigned {
+			err = tx.AddAllInputScripts(secrets)
 			if err != nil {
 				return err
 			}
@@ -233,7 +393,9 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 			}
 		}
 
-		if tx.ChangeIndex >= 0 && account == waddrmgr.ImportedAddrAccount {
+		if tx.ChangeIndex >= 0 && account == waddrmgr.ImportedAddrAccount &&
+			opts.changeAddress == nil {
+
 			changeAmount := btcutil.Amount(
 				tx.Tx.TxOut[tx.ChangeIndex].Value,
 			)
@@ -244,8 +406,9 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 
 		// Finally, we'll request the backend to notify us of the
 		// transaction that pays to the change address, if there is one,
-		// when it confirms.
-		if tx.ChangeIndex >= 0 {
+		// when it confirms.  A custom change address is not necessarily
+		// a wallet address, so it is left to the caller to watch.
+		if tx.ChangeIndex >= 0 && opts.changeAddress == nil {
 			changePkScript := tx.Tx.TxOut[tx.ChangeIndex].PkScript
 			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
 				changePkScript, w.chainParams,
@@ -258,15 +421,254 @@ func (w *Wallet) txToOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 			}
 		}
 
+		// Transaction creation is serialized, so locking the inputs
+		// before the next transaction is created prevents them from
+		// being selected again.
+		if opts.lockInputs {
+			for _, txIn := range tx.Tx.TxIn {
+				w.LockOutpoint(txIn.PreviousOutPoint)
+			}
+		}
+
 		return nil
 	})
 	if err != nil && err != walletdb.ErrDryRunRollBack {
+		if opts.lockInputs && tx != nil {
+			w.unlockInputs(tx.Tx)
+		}
 		return nil, err
 	}
 
 	return tx, nil
 }
 
+// makeCoinSelectionInputSource returns an input source selecting from the
+// eligible outputs according to the coin selection strategy.
+func makeCoinSelectionInputSource(eligible []wtxmgr.Credit,
+	coinSelectionStrategy CoinSelectionStrategy,
+	feeSatPerKb btcutil.Amount) txauthor.InputSource {
+
+	var inputSource txauthor.InputSource
+
+	switch coinSelectionStrategy {
+	// Pick largest outputs first.
+	case CoinSelectionLargest:
+		sort.Sort(sort.Reverse(byAmount(eligible)))
+		inputSource = makeInputSource(eligible)
+
+	// Select coins at random. This prevents the creation of ever smaller
+	// utxos over time that may never become economical to spend.
+	case CoinSelectionRandom:
+		// Skip inputs that do not raise the total transaction output
+		// value at the requested fee rate.
+		var positivelyYielding []wtxmgr.Credit
+		for _, output := range eligible {
+			output := output
+
+			if !inputYieldsPositively(&output, feeSatPerKb) {
+				continue
+			}
+
+			positivelyYielding = append(positivelyYielding, output)
+		}
+
+		rand.Shuffle(len(positivelyYielding), func(i, j int) {
+			positivelyYielding[i], positivelyYielding[j] =
+				positivelyYielding[j], positivelyYielding[i]
+		})
+
+		inputSource = makeInputSource(positivelyYielding)
+	}
+
+	return inputSource
+}
+
+// maxSubtractFeeRounds is the maximum number of times a transaction is
+// authored while searching for the fee to deduct from its outputs.
+const maxSubtractFeeRounds = 10
+
+// authorTxSubtractFee creates an unsigned transaction paying to outputs, where
+// the fee is deducted from the outputs paying to any of the subtractFrom
+// scripts instead of being paid by additional input value.
+//
+// The fee depends on the size of the transaction, which in turn depends on the
+// selected inputs and whether a change output is needed, so the transaction is
+// authored repeatedly with the fee of the previous round deducted until the
+// fee no longer increases.
+func authorTxSubtractFee(outputs []*wire.TxOut, subtractFrom [][]byte,
+	feeSatPerKb btcutil.Amount, inputSource txauthor.InputSource,
+	changeSource *txauthor.ChangeSource) (*txauthor.AuthoredTx, error) {
+
+	var payers []int
+	for i, output := range outputs {
+		for _, pkScript := range subtractFrom {
+			if bytes.Equal(output.PkScript, pkScript) {
+				payers = append(payers, i)
+				break
+			}
+		}
+	}
+	if len(payers) == 0 {
+		return nil, errors.New("no outputs to subtract the fee from")
+	}
+
+	// The change script must not change between rounds, as deriving a
+	// new change address each round would leave gaps in the account.
+	if changeSource.NewScript != nil {
+		var changeScript []byte
+		newScript := changeSource.NewScript
+		changeSource = &txauthor.ChangeSource{
+			ScriptSize: changeSource.ScriptSize,
+			NewScript: func() ([]byte, error) {
+				if changeScript != nil {
+					return changeScript, nil
+				}
+				var err error
+				changeScript, err = newScript()
+				return changeScript, err
+			},
+		}
+	}
+
+	// The first round deducts the fee of spending the inputs which cover
+	// the unadjusted outputs, as the outputs can't be funded without
+	// deducting some fee when they spend the entire balance.
+	_, _, _, prevScripts, err := inputSource(
+		txauthor.SumOutputValues(outputs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var p2pkh, p2wpkh, nested int
+	for _, pkScript := range prevScripts {
+		switch {
+		case txscript.IsPayToScriptHash(pkScript):
+			nested++
+		case txscript.IsPayToWitnessPubKeyHash(pkScript):
+			p2wpkh++
+		default:
+			p2pkh++
+		}
+	}
+	fee := txrules.FeeForSerializeSize(feeSatPerKb,
+		txsizes.EstimateVirtualSize(
+			p2pkh, p2wpkh, nested, outputs,
+			changeSource.ScriptSize,
+		),
+	)
+
+	for round := 0; round < maxSubtractFeeRounds; round++ {
+		adjusted := make([]*wire.TxOut, len(outputs))
+		for i, output := range outputs {
+			adjusted[i] = wire.NewTxOut(output.Value, output.PkScript)
+		}
+		share := fee / btcutil.Amount(len(payers))
+		remainder := fee % btcutil.Amount(len(payers))
+		for i, idx := range payers {
+			deduction := share
+			if i == 0 {
+				deduction += remainder
+			}
+			adjusted[idx].Value -= int64(deduction)
+			if txrules.IsDustOutput(adjusted[idx],
+				txrules.DefaultRelayFeePerKb) {
+
+				return nil, fmt.Errorf("output %d is too small "+
+					"to pay its share of the fee", idx)
+			}
+		}
+
+		tx, err := txauthor.NewUnsignedTransaction(
+			adjusted, feeSatPerKb, inputSource, changeSource,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		paid := tx.TotalInput - txauthor.SumOutputValues(tx.Tx.TxOut)
+		if paid > fee {
+			fee = paid
+			continue
+		}
+
+		// The deducted fee may exceed the fee paid when an earlier
+		// round omitted a dust change output.  The excess is returned
+		// from the change output to the first paying output if the
+		// change remains above the dust limit.
+		if excess := fee - paid; excess > 0 && tx.ChangeIndex >= 0 {
+			change := tx.Tx.TxOut[tx.ChangeIndex]
+			reduced := wire.NewTxOut(
+				change.Value-int64(excess), change.PkScript,
+			)
+			if !txrules.IsDustOutput(reduced,
+				txrules.DefaultRelayFeePerKb) {
+
+				change.Value = reduced.Value
+				tx.Tx.TxOut[payers[0]].Value += int64(excess)
+			}
+		}
+		return tx, nil
+	}
+
+	return nil, errors.New("unable to determine the fee to subtract " +
+		"from the outputs")
+}
+
+// accountAvoidsReuse returns whether the account avoids address reuse.  When
+// a key scope is not specified, the account of the P2WKH key scope is checked,
+// matching the default accounts used for coin selection.
+func (w *Wallet) accountAvoidsReuse(addrmgrNs walletdb.ReadBucket,
+	keyScope *waddrmgr.KeyScope, account uint32) (bool, error) {
+
+	scope := waddrmgr.KeyScopeBIP0084
+	if keyScope != nil {
+		scope = *keyScope
+	}
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return false, err
+	}
+	meta, err := manager.AccountMetadata(addrmgrNs, account)
+	if err != nil {
+		return false, err
+	}
+	return meta.AvoidReuse, nil
+}
+
+// partitionDirtyOutputs splits outputs into those paying to clean addresses
+// and those paying to dirty addresses.
+func (w *Wallet) partitionDirtyOutputs(addrmgrNs walletdb.ReadBucket,
+	outputs []wtxmgr.Credit) ([]wtxmgr.Credit, []wtxmgr.Credit) {
+
+	var clean, dirty []wtxmgr.Credit
+	for _, output := range outputs {
+		if w.outputIsReused(addrmgrNs, output.PkScript) {
+			dirty = append(dirty, output)
+		} else {
+			clean = append(clean, output)
+		}
+	}
+	return clean, dirty
+}
+
+// outputIsReused returns whether an output script pays to any dirty address.
+func (w *Wallet) outputIsReused(addrmgrNs walletdb.ReadBucket,
+	pkScript []byte) bool {
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		pkScript, w.chainParams,
+	)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if w.Manager.IsDirty(addrmgrNs, addr) {
+			return true
+		}
+	}
+	return false
+}
+
 func (w *Wallet) findEligibleOutputs(dbtx walletdb.ReadTx,
 	keyScope *waddrmgr.KeyScope, account uint32, minconf int32,
 	bs *waddrmgr.BlockStamp) ([]wtxmgr.Credit, error) {
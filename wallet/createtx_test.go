@@ -299,3 +299,253 @@ func TestTxToOutputsRandom(t *testing.T) {
 
 	require.True(t, isRandom)
 }
+
+// TestTxToOutputsAvoidReuse tests that coin selection for an account avoiding
+// address reuse never combines outputs to dirty and clean addresses unless
+// explicitly allowed.
+func TestTxToOutputsAvoidReuse(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	// Create a dirty and a clean address of the default account, each
+	// receiving a single output.
+	keyScope := waddrmgr.KeyScopeBIP0084
+	dirtyAddr, err := w.NewAddress(0, keyScope)
+	require.NoError(t, err)
+	cleanAddr, err := w.NewAddress(0, keyScope)
+	require.NoError(t, err)
+	dirtyScript, err := txscript.PayToAddrScript(dirtyAddr)
+	require.NoError(t, err)
+	cleanScript, err := txscript.PayToAddrScript(cleanAddr)
+	require.NoError(t, err)
+
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return w.Manager.MarkDirty(ns, dirtyAddr)
+	})
+	require.NoError(t, err)
+
+	incomingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{},
+		},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(60000, dirtyScript),
+			wire.NewTxOut(50000, cleanScript),
+		},
+	}
+	addUtxo(t, w, incomingTx)
+
+	reused, err := w.OutputsReused([][]byte{dirtyScript, cleanScript})
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false}, reused)
+
+	require.NoError(t, w.SetAccountAvoidReuse(keyScope, 0, true))
+
+	createTx := func(amt int64, optFuncs ...TxCreateOption) (
+		*txauthor.AuthoredTx, error) {
+
+		txOuts := []*wire.TxOut{wire.NewTxOut(amt, cleanScript)}
+		return w.txToOutputs(
+			txOuts, nil, 0, 1, 1000, CoinSelectionLargest, true,
+			optFuncs...,
+		)
+	}
+
+	// Outputs to clean addresses are preferred, even though the largest
+	// output pays to the dirty address.
+	tx, err := createTx(30000)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{cleanScript}, tx.PrevScripts)
+
+	// When the clean outputs are insufficient, only dirty outputs are
+	// spent.
+	tx, err = createTx(55000)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{dirtyScript}, tx.PrevScripts)
+
+	// Neither set of outputs alone can fund the transaction, so it can
+	// only be created when address reuse is allowed.
+	_, err = createTx(80000)
+	require.Implements(t, (*txauthor.InputSourceError)(nil), err)
+	tx, err = createTx(80000, WithAllowAddressReuse())
+	require.NoError(t, err)
+	require.Len(t, tx.PrevScripts, 2)
+}
+
+// TestTxToOutputsChangeOptions tests that the change output can be paid to a
+// custom address or omitted entirely.
+func TestTxToOutputsChangeOptions(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	keyScope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.CurrentAddress(0, keyScope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	incomingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{},
+		},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(100000, pkScript),
+		},
+	}
+	addUtxo(t, w, incomingTx)
+
+	changeAddr, err := btcutil.DecodeAddress(
+		"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+		&chaincfg.TestNet3Params,
+	)
+	require.NoError(t, err)
+	changeScript, err := txscript.PayToAddrScript(changeAddr)
+	require.NoError(t, err)
+
+	createTx := func(optFuncs ...TxCreateOption) *txauthor.AuthoredTx {
+		txOuts := []*wire.TxOut{wire.NewTxOut(50000, pkScript)}
+		tx, err := w.txToOutputs(
+			txOuts, nil, 0, 1, 1000, CoinSelectionLargest, true,
+			optFuncs...,
+		)
+		require.NoError(t, err)
+		return tx
+	}
+
+	tx := createTx(WithChangeAddress(changeAddr))
+	require.GreaterOrEqual(t, tx.ChangeIndex, 0)
+	require.Equal(t, changeScript, tx.Tx.TxOut[tx.ChangeIndex].PkScript)
+
+	tx = createTx(WithNoChange())
+	require.Equal(t, -1, tx.ChangeIndex)
+	require.Len(t, tx.Tx.TxOut, 1)
+}
+
+// TestTxToOutputsSubtractFee ensures that the fee is deducted from the
+// requested outputs rather than paid by additional input value.
+func TestTxToOutputsSubtractFee(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	keyScope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.CurrentAddress(0, keyScope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	incomingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{},
+		},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(100000, pkScript),
+		},
+	}
+	addUtxo(t, w, incomingTx)
+
+	recipient, err := btcutil.DecodeAddress(
+		"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+		&chaincfg.TestNet3Params,
+	)
+	require.NoError(t, err)
+	recipientScript, err := txscript.PayToAddrScript(recipient)
+	require.NoError(t, err)
+
+	// Forwarding the entire balance must succeed with the recipient
+	// paying the fee.
+	txOuts := []*wire.TxOut{wire.NewTxOut(100000, recipientScript)}
+	tx, err := w.txToOutputs(
+		txOuts, nil, 0, 1, 1000, CoinSelectionLargest, true,
+		WithSubtractFeeFrom(recipientScript),
+	)
+	require.NoError(t, err)
+	require.Equal(t, -1, tx.ChangeIndex)
+	require.Len(t, tx.Tx.TxOut, 1)
+
+	fee := tx.TotalInput - txauthor.SumOutputValues(tx.Tx.TxOut)
+	require.Greater(t, int64(fee), int64(0))
+	require.Equal(t, int64(100000)-int64(fee), tx.Tx.TxOut[0].Value)
+
+	// With change, only the subtracting output pays the fee while the
+	// other output receives its full amount.
+	txOuts = []*wire.TxOut{
+		wire.NewTxOut(30000, recipientScript),
+		wire.NewTxOut(20000, pkScript),
+	}
+	tx, err = w.txToOutputs(
+		txOuts, nil, 0, 1, 1000, CoinSelectionLargest, true,
+		WithSubtractFeeFrom(recipientScript),
+	)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, tx.ChangeIndex, 0)
+
+	fee = tx.TotalInput - txauthor.SumOutputValues(tx.Tx.TxOut)
+	var recipientValue, otherValue, changeValue int64
+	for i, txOut := range tx.Tx.TxOut {
+		switch {
+		case i == tx.ChangeIndex:
+			changeValue = txOut.Value
+		case bytes.Equal(txOut.PkScript, recipientScript):
+			recipientValue = txOut.Value
+		default:
+			otherValue = txOut.Value
+		}
+	}
+	require.Equal(t, int64(30000)-int64(fee), recipientValue)
+	require.Equal(t, int64(20000), otherValue)
+	require.Equal(t, int64(50000), changeValue)
+
+	// Subtracting from an output which doesn't exist is an error.
+	_, err = w.txToOutputs(
+		txOuts, nil, 0, 1, 1000, CoinSelectionLargest, true,
+		WithSubtractFeeFrom([]byte{txscript.OP_TRUE}),
+	)
+	require.Error(t, err)
+}
+
+// TestSendOutputsComment ensures that the comments of a send are recorded
+// with the published transaction and returned when it is listed.
+func TestSendOutputsComment(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	keyScope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.CurrentAddress(0, keyScope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	addUtxo(t, w, &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{},
+		},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(100000, pkScript),
+		},
+	})
+
+	tx, err := w.SendOutputs(
+		[]*wire.TxOut{wire.NewTxOut(10000, pkScript)}, &keyScope, 0, 0,
+		1000, CoinSelectionLargest, "", WithComment("rent", "landlord"),
+	)
+	require.NoError(t, err)
+
+	txHash := tx.TxHash()
+	details, err := UnstableAPI(w).TxDetails(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, "rent", details.Comment)
+	require.Equal(t, "landlord", details.CommentTo)
+
+	txs, err := w.ListTransactions(0, 100)
+	require.NoError(t, err)
+	var listed bool
+	for _, result := range txs {
+		if result.TxID != txHash.String() {
+			continue
+		}
+		listed = true
+		require.Equal(t, "rent", result.Comment)
+		require.Equal(t, "landlord", result.CommentTo)
+	}
+	require.True(t, listed)
+}
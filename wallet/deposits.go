@@ -0,0 +1,314 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+const (
+	// depositWebhookQueueLen is the number of large deposits which may
+	// wait to be posted to the deposit webhook.  Further deposits are
+	// dropped from the webhook, though they are still notified.
+	depositWebhookQueueLen = 100
+
+	// depositWebhookAttempts is the number of times posting a deposit to
+	// the webhook is attempted, and depositWebhookRetryDelay the delay
+	// before the first retry, which doubles for each further retry.
+	depositWebhookAttempts   = 3
+	depositWebhookRetryDelay = 5 * time.Second
+)
+
+// LargeDeposit describes a transaction paying an account of the wallet at
+// least the deposit alert amount of the account.  Only outputs paying to
+// external addresses count towards the deposit, so change is never alerted.
+//
+// A deposit is alerted once, when the transaction is first seen by the wallet,
+// whether that is in the mempool or in a block.
+type LargeDeposit struct {
+	Hash        chainhash.Hash
+	Scope       waddrmgr.KeyScope
+	Account     uint32
+	AccountName string
+
+	// Amount is the total amount paid to the account by the transaction,
+	// and Threshold the deposit alert amount of the account.
+	Amount    btcutil.Amount
+	Threshold btcutil.Amount
+
+	// Mined is set when the transaction was first seen in a block.
+	Mined bool
+}
+
+// DepositAlert returns the amount at or above which a deposit to an account is
+// alerted.  Zero is returned for accounts without deposit alerts.
+func (w *Wallet) DepositAlert(scope waddrmgr.KeyScope,
+	account uint32) (btcutil.Amount, error) {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return 0, err
+	}
+
+	var threshold btcutil.Amount
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		meta, err := manager.AccountMetadata(addrmgrNs, account)
+		if err != nil {
+			return err
+		}
+		threshold = meta.DepositAlert
+		return nil
+	})
+	return threshold, err
+}
+
+// SetDepositAlert sets the amount at or above which a deposit to an account is
+// alerted, with a LargeDeposit notification and a post to the deposit webhook.
+// A zero amount disables deposit alerts.  Changes are recorded by the audit
+// log.
+func (w *Wallet) SetDepositAlert(scope waddrmgr.KeyScope, account uint32,
+	threshold btcutil.Amount) error {
+
+	if threshold < 0 {
+		return errors.New("deposit alert amount must not be negative")
+	}
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	var name string
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		err := manager.SetAccountDepositAlert(
+			addrmgrNs, account, threshold,
+		)
+		if err != nil {
+			return err
+		}
+		name, err = manager.AccountName(addrmgrNs, account)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if threshold == 0 {
+		auditLog.Infof("Disabled deposit alerts of account %q", name)
+	} else {
+		auditLog.Infof("Set deposit alert of account %q to %v", name,
+			threshold)
+	}
+	return nil
+}
+
+// DepositWebhook posts large deposits to a webhook.  Implementations may post
+// to HTTP endpoints, message queues or local services, such as the HTTP
+// webhooks of package internal/webhook.
+type DepositWebhook interface {
+	// Post posts the JSON object describing a large deposit, with the
+	// txid, account, amount, threshold and mined fields.
+	Post(body []byte) error
+}
+
+// depositHook returns the webhook large deposits are posted to, or nil
+// when no webhook is set.
+func (w *Wallet) depositHook() DepositWebhook {
+	w.depositMtx.Lock()
+	defer w.depositMtx.Unlock()
+
+	return w.depositWebhook
+}
+
+// SetDepositWebhook sets the webhook each large deposit is posted to as a JSON
+// object.  A nil webhook disables posting deposits.
+func (w *Wallet) SetDepositWebhook(hook DepositWebhook) {
+	w.depositMtx.Lock()
+	w.depositWebhook = hook
+	w.depositMtx.Unlock()
+}
+
+// largeDeposits returns the deposits of a transaction to accounts which are
+// at least the deposit alert amounts of the accounts.  The credits of the
+// transaction must already be recorded.
+func (w *Wallet) largeDeposits(addrmgrNs walletdb.ReadBucket,
+	rec *wtxmgr.TxRecord) ([]LargeDeposit, error) {
+
+	type accountKey struct {
+		scope   waddrmgr.KeyScope
+		account uint32
+	}
+	var (
+		keys     []accountKey
+		amounts  = make(map[accountKey]btcutil.Amount)
+		managers = make(map[accountKey]*waddrmgr.ScopedKeyManager)
+	)
+	for _, output := range rec.MsgTx.TxOut {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			output.PkScript, w.chainParams,
+		)
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+
+		// Outputs are counted towards the account of the first
+		// address of the wallet they pay to, as they are by the
+		// transaction notifications.
+		for _, addr := range addrs {
+			manager, account, err := w.Manager.AddrAccount(
+				addrmgrNs, addr,
+			)
+			if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			ma, err := manager.Address(addrmgrNs, addr)
+			if err != nil {
+				return nil, err
+			}
+			if ma.Internal() {
+				break
+			}
+			key := accountKey{manager.Scope(), account}
+			if _, ok := amounts[key]; !ok {
+				keys = append(keys, key)
+				managers[key] = manager
+			}
+			amounts[key] += btcutil.Amount(output.Value)
+			break
+		}
+	}
+
+	var deposits []LargeDeposit
+	for _, key := range keys {
+		manager := managers[key]
+		meta, err := manager.AccountMetadata(addrmgrNs, key.account)
+		if err != nil {
+			return nil, err
+		}
+		amount := amounts[key]
+		if meta.DepositAlert == 0 || amount < meta.DepositAlert {
+			continue
+		}
+		name, err := manager.AccountName(addrmgrNs, key.account)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, LargeDeposit{
+			Hash:        rec.Hash,
+			Scope:       key.scope,
+			Account:     key.account,
+			AccountName: name,
+			Amount:      amount,
+			Threshold:   meta.DepositAlert,
+		})
+	}
+	return deposits, nil
+}
+
+// alertLargeDeposit logs and notifies a large deposit, and queues it to be
+// posted to the deposit webhook.
+func (w *Wallet) alertLargeDeposit(d *LargeDeposit) {
+	log.Warnf("Large deposit of %v to account %q in transaction %v "+
+		"(alert amount %v)", d.Amount, d.AccountName, d.Hash,
+		d.Threshold)
+
+	w.NtfnServer.notifyLargeDeposit(d)
+
+	if w.depositHook() == nil {
+		return
+	}
+	select {
+	case w.depositWebhookQueue <- *d:
+	default:
+		log.Errorf("Deposit webhook queue is full, not posting "+
+			"deposit in transaction %v", d.Hash)
+	}
+}
+
+// depositWebhookPayload is the JSON object posted to the deposit webhook.
+type depositWebhookPayload struct {
+	TxID      string  `json:"txid"`
+	Account   string  `json:"account"`
+	Amount    float64 `json:"amount"`
+	Threshold float64 `json:"threshold"`
+	Mined     bool    `json:"mined"`
+}
+
+// depositWebhookPoster posts queued large deposits to the deposit webhook
+// until the wallet is stopped.
+//
+// NOTE: This must be run as a goroutine.
+func (w *Wallet) depositWebhookPoster() {
+	defer w.wg.Done()
+
+	quit := w.quitChan()
+	for {
+		select {
+		case d := <-w.depositWebhookQueue:
+			w.postDeposit(&d, depositWebhookRetryDelay, quit)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// postDeposit posts a large deposit to the deposit webhook, retrying failed
+// posts after a delay which doubles for each retry until the attempts are
+// exhausted or quit is closed.
+func (w *Wallet) postDeposit(d *LargeDeposit, delay time.Duration,
+	quit <-chan struct{}) {
+
+	body, err := json.Marshal(&depositWebhookPayload{
+		TxID:      d.Hash.String(),
+		Account:   d.AccountName,
+		Amount:    d.Amount.ToBTC(),
+		Threshold: d.Threshold.ToBTC(),
+		Mined:     d.Mined,
+	})
+	if err != nil {
+		log.Errorf("Unable to marshal deposit webhook payload: %v", err)
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		hook := w.depositHook()
+		if hook == nil {
+			return
+		}
+		err := hook.Post(body)
+		if err == nil {
+			log.Debugf("Posted deposit in transaction %v to webhook",
+				d.Hash)
+			return
+		}
+		if attempt == depositWebhookAttempts {
+			log.Errorf("Unable to post deposit in transaction %v "+
+				"to webhook: %v", d.Hash, err)
+			return
+		}
+		log.Warnf("Unable to post deposit in transaction %v to "+
+			"webhook, retrying in %v: %v", d.Hash, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-quit:
+			return
+		}
+		delay *= 2
+	}
+}
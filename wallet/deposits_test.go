@@ -0,0 +1,195 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLargeDeposits ensures that deposits to an account of at least its
+// deposit alert amount are notified once, when first seen, and that change
+// and smaller deposits are not.
+func TestLargeDeposits(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	scope := waddrmgr.KeyScopeBIP0084
+	require.NoError(t, w.SetDepositAlert(scope, 0, 1e6))
+	threshold, err := w.DepositAlert(scope, 0)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(1e6), threshold)
+	require.Error(t, w.SetDepositAlert(scope, 0, -1))
+
+	addr, err := w.NewAddress(0, scope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	changeAddr, err := w.NewChangeAddress(0, scope)
+	require.NoError(t, err)
+	changeScript, err := txscript.PayToAddrScript(changeAddr)
+	require.NoError(t, err)
+
+	client := w.NtfnServer.LargeDepositNotifications()
+	defer client.Done()
+
+	// addTx records a transaction paying the outputs and returns the
+	// large deposit notified for it, if any.  The notification is sent
+	// once the transaction is committed, before the update returns.
+	prevIndex := uint32(0)
+	addTx := func(block *wtxmgr.BlockMeta,
+		outputs ...*wire.TxOut) (*wtxmgr.TxRecord, *LargeDeposit) {
+
+		t.Helper()
+
+		prevIndex++
+		msgTx := wire.NewMsgTx(wire.TxVersion)
+		msgTx.AddTxIn(wire.NewTxIn(
+			&wire.OutPoint{Index: prevIndex}, nil, nil,
+		))
+		for _, output := range outputs {
+			msgTx.AddTxOut(output)
+		}
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, time.Now())
+		require.NoError(t, err)
+		return rec, addRec(t, w, client, rec, block)
+	}
+
+	// Outputs paying the account are summed, and a deposit of the alert
+	// amount is notified.
+	rec, d := addTx(nil, wire.NewTxOut(4e5, pkScript),
+		wire.NewTxOut(6e5, pkScript))
+	require.NotNil(t, d)
+	require.Equal(t, rec.Hash, d.Hash)
+	require.Equal(t, scope, d.Scope)
+	require.Equal(t, uint32(0), d.Account)
+	require.Equal(t, "default", d.AccountName)
+	require.Equal(t, btcutil.Amount(1e6), d.Amount)
+	require.Equal(t, btcutil.Amount(1e6), d.Threshold)
+	require.False(t, d.Mined)
+
+	// Mining the transaction does not notify the deposit again.
+	syncedTo := w.Manager.SyncedTo()
+	block := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{
+			Hash:   syncedTo.Hash,
+			Height: syncedTo.Height,
+		},
+		Time: syncedTo.Timestamp,
+	}
+	require.Nil(t, addRec(t, w, client, rec, block))
+
+	// Deposits first seen in a block are notified as mined.
+	_, d = addTx(block, wire.NewTxOut(2e6, pkScript))
+	require.NotNil(t, d)
+	require.True(t, d.Mined)
+
+	// Smaller deposits and change are not notified.
+	_, d = addTx(nil, wire.NewTxOut(9e5, pkScript))
+	require.Nil(t, d)
+	_, d = addTx(nil, wire.NewTxOut(9e5, pkScript),
+		wire.NewTxOut(5e6, changeScript))
+	require.Nil(t, d)
+
+	// Disabling the alert stops deposits from being notified.
+	require.NoError(t, w.SetDepositAlert(scope, 0, 0))
+	_, d = addTx(nil, wire.NewTxOut(5e6, pkScript))
+	require.Nil(t, d)
+}
+
+// addRec records a relevant transaction and returns the large deposit
+// notified to the client for it, or nil if none was notified.
+func addRec(t *testing.T, w *Wallet, client LargeDepositNotificationsClient,
+	rec *wtxmgr.TxRecord, block *wtxmgr.BlockMeta) *LargeDeposit {
+
+	t.Helper()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+			return w.addRelevantTx(tx, rec, block)
+		})
+	}()
+
+	var d *LargeDeposit
+	select {
+	case d = <-client.C:
+	case err := <-errc:
+		require.NoError(t, err)
+		return nil
+	case <-time.After(5 * time.Second):
+		t.Fatal("transaction was not recorded")
+	}
+	require.NoError(t, <-errc)
+	return d
+}
+
+// TestDepositWebhook ensures that large deposits are posted to the deposit
+// webhook, and that failed posts are retried.
+func TestDepositWebhook(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	hook := &testDepositWebhook{
+		posts: make(chan depositWebhookPayload, 2),
+	}
+	w.SetDepositWebhook(hook)
+	d := &LargeDeposit{
+		Hash:        [32]byte{1},
+		AccountName: "default",
+		Amount:      2e8,
+		Threshold:   1e8,
+		Mined:       true,
+	}
+	w.postDeposit(d, time.Millisecond, nil)
+
+	select {
+	case p := <-hook.posts:
+		require.Equal(t, depositWebhookPayload{
+			TxID:      d.Hash.String(),
+			Account:   "default",
+			Amount:    2,
+			Threshold: 1,
+			Mined:     true,
+		}, p)
+	default:
+		t.Fatal("deposit was not posted")
+	}
+	require.Equal(t, 2, hook.requests)
+}
+
+// testDepositWebhook is a DepositWebhook failing the first post and recording
+// the deposits of the following posts.
+type testDepositWebhook struct {
+	posts    chan depositWebhookPayload
+	requests int
+}
+
+func (h *testDepositWebhook) Post(body []byte) error {
+	h.requests++
+	if h.requests == 1 {
+		return errors.New("webhook unavailable")
+	}
+	var p depositWebhookPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return err
+	}
+	h.posts <- p
+	return nil
+}
@@ -0,0 +1,379 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/internal/descriptor"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// descriptorScopes are the key scopes single key descriptors are imported
+// into, which derive addresses of the type of the descriptor.
+var descriptorScopes = map[descriptor.Type]waddrmgr.KeyScope{
+	descriptor.PKH:      waddrmgr.KeyScopeBIP0044,
+	descriptor.WPKH:     waddrmgr.KeyScopeBIP0084,
+	descriptor.SHWPKH:   waddrmgr.KeyScopeBIP0049Plus,
+	descriptor.SHMulti:  waddrmgr.KeyScopeBIP0084,
+	descriptor.WSHMulti: waddrmgr.KeyScopeBIP0084,
+}
+
+// ImportDescriptor imports the scripts of a descriptor into the imported
+// account of the key scope of its type.  Ranged descriptors are expanded at
+// each index from start to end inclusive.  The key of single key descriptors
+// is imported, and is spendable if it is private, while the scripts of
+// multisig descriptors are imported to be watched.  Scripts already in the
+// wallet are left as they are.
+//
+// The block stamp is recorded as the birthday block of the imported addresses,
+// and the chain is rescanned from it if requested.  Without a block stamp, the
+// addresses are only watched from the block the wallet is synced to.  The
+// addresses of the imported scripts are returned.
+func (w *Wallet) ImportDescriptor(desc *descriptor.Descriptor, start,
+	end uint32, bs *waddrmgr.BlockStamp, rescan bool) ([]btcutil.Address,
+	error) {
+
+	if !desc.IsRange() {
+		start, end = 0, 0
+	}
+	if start > end {
+		return nil, errors.New("range start is after its end")
+	}
+	manager, err := w.Manager.FetchScopedKeyManager(
+		descriptorScopes[desc.Type],
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := w.importAddresses(bs, rescan, func(
+		addrmgrNs walletdb.ReadWriteBucket,
+		bs *waddrmgr.BlockStamp) ([]btcutil.Address, error) {
+
+		var addrs []btcutil.Address
+		for index := start; ; index++ {
+			addr, err := w.importExpansion(
+				addrmgrNs, manager, desc, index, bs,
+			)
+			if err != nil {
+				return nil, err
+			}
+			addrs = append(addrs, addr)
+			if index == end {
+				return addrs, nil
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Imported %d addresses of descriptor %v", len(addrs), desc)
+
+	return addrs, nil
+}
+
+// importExpansion imports the key or script of a descriptor expanded at an
+// index, returning its address.
+func (w *Wallet) importExpansion(addrmgrNs walletdb.ReadWriteBucket,
+	manager *waddrmgr.ScopedKeyManager, desc *descriptor.Descriptor,
+	index uint32, bs *waddrmgr.BlockStamp) (btcutil.Address, error) {
+
+	e, err := desc.Expand(index)
+	if err != nil {
+		return nil, err
+	}
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		e.PkScript, w.chainParams,
+	)
+	if err != nil || len(addrs) != 1 {
+		return nil, fmt.Errorf("descriptor script %x has no address",
+			e.PkScript)
+	}
+
+	switch desc.Type {
+	case descriptor.SHMulti:
+		_, err = manager.ImportScript(addrmgrNs, e.RedeemScript, bs)
+
+	case descriptor.WSHMulti:
+		_, err = manager.ImportWitnessScript(
+			addrmgrNs, e.WitnessScript, bs, 0, false,
+		)
+
+	default:
+		key := e.Keys[0]
+		switch {
+		case key.PrivKey != nil:
+			var wif *btcutil.WIF
+			wif, err = btcutil.NewWIF(
+				key.PrivKey, w.chainParams, key.Compressed,
+			)
+			if err != nil {
+				return nil, err
+			}
+			_, err = manager.ImportPrivateKey(addrmgrNs, wif, bs)
+
+		case !key.Compressed:
+			return nil, errors.New("uncompressed public keys can " +
+				"only be imported with their private key")
+
+		default:
+			_, err = manager.ImportPublicKey(addrmgrNs, key.PubKey, bs)
+		}
+	}
+	if waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return addrs[0], nil
+}
+
+// WalletDescriptor is a descriptor of scripts held by the wallet.
+type WalletDescriptor struct {
+	Descriptor *descriptor.Descriptor
+
+	// Birthday is the time of the earliest transaction the scripts may
+	// have.
+	Birthday time.Time
+
+	// Internal is set for descriptors of the internal (change) branch of
+	// an account, and NextIndex is the index the next address of a ranged
+	// descriptor is derived at.
+	Internal  bool
+	NextIndex uint32
+}
+
+// Descriptors returns descriptors of the scripts held by the wallet.  Each
+// account with an extended public key is described by ranged descriptors of
+// its external and internal branches, and imported keys and multisig scripts
+// by descriptors of each of them.  Imported scripts are only described when
+// the wallet is unlocked or watching-only.
+func (w *Wallet) Descriptors() ([]WalletDescriptor, error) {
+	managers := w.Manager.ActiveScopedKeyManagers()
+	sort.Slice(managers, func(i, j int) bool {
+		si, sj := managers[i].Scope(), managers[j].Scope()
+		if si.Purpose != sj.Purpose {
+			return si.Purpose < sj.Purpose
+		}
+		return si.Coin < sj.Coin
+	})
+
+	var descs []WalletDescriptor
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		birthday := w.Manager.Birthday()
+		for _, manager := range managers {
+			err := manager.ForEachAccount(addrmgrNs, func(account uint32) error {
+				if account == waddrmgr.ImportedAddrAccount {
+					return nil
+				}
+				props, err := manager.AccountProperties(
+					addrmgrNs, account,
+				)
+				if err != nil {
+					return err
+				}
+				accountDescs, err := w.accountDescriptors(
+					manager, props,
+				)
+				if err != nil {
+					return err
+				}
+				for i := range accountDescs {
+					accountDescs[i].Birthday = birthday
+				}
+				descs = append(descs, accountDescs...)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			// The imported addresses are listed before they are
+			// described, as their scripts can not be read while
+			// iterating over the addresses of the manager.
+			var imported []waddrmgr.ManagedAddress
+			err = manager.ForEachAccountAddress(
+				addrmgrNs, waddrmgr.ImportedAddrAccount,
+				func(ma waddrmgr.ManagedAddress) error {
+					imported = append(imported, ma)
+					return nil
+				},
+			)
+			if err != nil {
+				return err
+			}
+			for _, ma := range imported {
+				desc := importedDescriptor(ma, w.chainParams)
+				if desc == nil {
+					continue
+				}
+				bs, err := w.Manager.AddressBirthday(
+					addrmgrNs, ma.Address(),
+				)
+				if err != nil {
+					return err
+				}
+				wd := WalletDescriptor{Descriptor: desc}
+				if bs != nil {
+					wd.Birthday = bs.Timestamp
+				}
+				descs = append(descs, wd)
+			}
+		}
+		return nil
+	})
+	return descs, err
+}
+
+// accountDescriptors returns the descriptors of the external and internal
+// branches of an account, or none if the addresses of the account can not be
+// described.
+func (w *Wallet) accountDescriptors(manager *waddrmgr.ScopedKeyManager,
+	props *waddrmgr.AccountProperties) ([]WalletDescriptor, error) {
+
+	if props.AccountPubKey == nil {
+		return nil, nil
+	}
+	schema := manager.AddrSchema()
+	if props.AddrSchema != nil {
+		schema = *props.AddrSchema
+	}
+
+	// Descriptors encode extended keys with the standard version of the
+	// network rather than the version of the key scope.
+	accountKey, err := props.AccountPubKey.CloneWithVersion(
+		w.chainParams.HDPublicKeyID[:],
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := manager.Scope()
+	branches := []struct {
+		addrType waddrmgr.AddressType
+		branch   uint32
+		next     uint32
+	}{
+		{schema.ExternalAddrType, waddrmgr.ExternalBranch,
+			props.ExternalKeyCount},
+		{schema.InternalAddrType, waddrmgr.InternalBranch,
+			props.InternalKeyCount},
+	}
+	descs := make([]WalletDescriptor, 0, len(branches))
+	for _, b := range branches {
+		typ, ok := descriptorType(b.addrType)
+		if !ok {
+			return nil, nil
+		}
+		key := &descriptor.Key{
+			ExtKey: accountKey,
+			Path:   []uint32{b.branch},
+			Ranged: true,
+		}
+		if props.MasterKeyFingerprint != 0 {
+			key.HasOrigin = true
+			key.Fingerprint = props.MasterKeyFingerprint
+			key.OriginPath = []uint32{
+				scope.Purpose + hdkeychain.HardenedKeyStart,
+				scope.Coin + hdkeychain.HardenedKeyStart,
+				accountKey.ChildIndex(),
+			}
+		}
+		descs = append(descs, WalletDescriptor{
+			Descriptor: &descriptor.Descriptor{
+				Type: typ,
+				Keys: []*descriptor.Key{key},
+			},
+			Internal:  b.branch == waddrmgr.InternalBranch,
+			NextIndex: b.next,
+		})
+	}
+	return descs, nil
+}
+
+// importedDescriptor returns the descriptor of an imported key or multisig
+// script, or nil if the address can not be described.
+func importedDescriptor(ma waddrmgr.ManagedAddress,
+	params *chaincfg.Params) *descriptor.Descriptor {
+
+	switch ma := ma.(type) {
+	case waddrmgr.ManagedPubKeyAddress:
+		typ, ok := descriptorType(ma.AddrType())
+		if !ok {
+			return nil
+		}
+		return &descriptor.Descriptor{
+			Type: typ,
+			Keys: []*descriptor.Key{{
+				PubKey:     ma.PubKey(),
+				Compressed: ma.Compressed(),
+			}},
+		}
+
+	case waddrmgr.ManagedScriptAddress:
+		typ := descriptor.SHMulti
+		if ma.AddrType() == waddrmgr.WitnessScript {
+			typ = descriptor.WSHMulti
+		}
+
+		// Secret scripts are unavailable while the wallet is locked.
+		script, err := ma.Script()
+		if err != nil {
+			return nil
+		}
+		class, addrs, reqSigs, err := txscript.ExtractPkScriptAddrs(
+			script, params,
+		)
+		if err != nil || class != txscript.MultiSigTy {
+			return nil
+		}
+		keys := make([]*descriptor.Key, len(addrs))
+		for i, addr := range addrs {
+			pubKey, err := btcec.ParsePubKey(
+				addr.ScriptAddress(), btcec.S256(),
+			)
+			if err != nil {
+				return nil
+			}
+			keys[i] = &descriptor.Key{
+				PubKey: pubKey,
+				Compressed: len(addr.ScriptAddress()) ==
+					btcec.PubKeyBytesLenCompressed,
+			}
+		}
+		return &descriptor.Descriptor{
+			Type:      typ,
+			Keys:      keys,
+			Threshold: reqSigs,
+		}
+	}
+	return nil
+}
+
+// descriptorType returns the type of the single key descriptors of addresses
+// of a type.
+func descriptorType(addrType waddrmgr.AddressType) (descriptor.Type, bool) {
+	switch addrType {
+	case waddrmgr.PubKeyHash:
+		return descriptor.PKH, true
+	case waddrmgr.WitnessPubKey:
+		return descriptor.WPKH, true
+	case waddrmgr.NestedWitnessPubKey:
+		return descriptor.SHWPKH, true
+	}
+	return 0, false
+}
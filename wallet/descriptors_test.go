@@ -0,0 +1,104 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/internal/descriptor"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportDescriptor ensures that the scripts of imported descriptors are
+// held by the wallet, and are described by the descriptors of the wallet.
+func TestImportDescriptor(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	seed := make([]byte, hdkeychain.RecommendedSeedLen)
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	pub, err := master.Neuter()
+	require.NoError(t, err)
+
+	// A ranged descriptor is imported at each index of the range.
+	desc, err := descriptor.Parse(
+		"wpkh("+pub.String()+"/0/*)", &chaincfg.TestNet3Params,
+	)
+	require.NoError(t, err)
+	addrs, err := w.ImportDescriptor(desc, 0, 2, nil, false)
+	require.NoError(t, err)
+	require.Len(t, addrs, 3)
+	for i, addr := range addrs {
+		e, err := desc.Expand(uint32(i))
+		require.NoError(t, err)
+		ma, err := w.AddressInfo(addr)
+		require.NoError(t, err)
+		require.Equal(t, waddrmgr.WitnessPubKey, ma.AddrType())
+		require.Equal(t, e.Keys[0].PubKey,
+			ma.(waddrmgr.ManagedPubKeyAddress).PubKey())
+	}
+
+	// Importing a descriptor again leaves its scripts as they are.
+	again, err := w.ImportDescriptor(desc, 1, 3, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, addrs[1:], again[:2])
+
+	// Multisig scripts are imported to be watched.
+	multi, err := descriptor.Parse(
+		"wsh(sortedmulti(1,"+pub.String()+"/1/0,"+pub.String()+"/1/1))",
+		&chaincfg.TestNet3Params,
+	)
+	require.NoError(t, err)
+	multiAddrs, err := w.ImportDescriptor(multi, 0, 0, nil, false)
+	require.NoError(t, err)
+	require.Len(t, multiAddrs, 1)
+	ma, err := w.AddressInfo(multiAddrs[0])
+	require.NoError(t, err)
+	require.Equal(t, waddrmgr.WitnessScript, ma.AddrType())
+
+	descs, err := w.Descriptors()
+	require.NoError(t, err)
+	var accountDescs, importedDescs []string
+	for _, wd := range descs {
+		if wd.Descriptor.IsRange() {
+			accountDescs = append(accountDescs, wd.Descriptor.String())
+		} else {
+			importedDescs = append(importedDescs, wd.Descriptor.String())
+		}
+	}
+
+	// Both branches of the default account of each default scope are
+	// described, with the address types of the scope.
+	require.Len(t, accountDescs, 2*len(waddrmgr.DefaultKeyScopes))
+	require.True(t, strings.HasPrefix(accountDescs[0], "pkh("))
+	require.True(t, strings.HasPrefix(accountDescs[2], "sh(wpkh("))
+	require.True(t, strings.HasPrefix(accountDescs[3], "wpkh("))
+	require.True(t, strings.HasPrefix(accountDescs[4], "wpkh("))
+
+	// Every imported script is described, and the descriptors of the
+	// wallet describe the scripts the wallet holds.
+	imported := make(map[string]bool)
+	for _, addr := range append(append(addrs, again[2]), multiAddrs...) {
+		imported[addr.EncodeAddress()] = true
+	}
+	require.Len(t, importedDescs, len(imported))
+	for _, s := range importedDescs {
+		d, err := descriptor.Parse(s, &chaincfg.TestNet3Params)
+		require.NoError(t, err)
+		e, err := d.Expand(0)
+		require.NoError(t, err)
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			e.PkScript, &chaincfg.TestNet3Params,
+		)
+		require.NoError(t, err)
+		require.True(t, imported[addrs[0].EncodeAddress()], s)
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// DraftTxTimeout is the duration after which a draft transaction which has
+// not been committed expires, releasing its inputs.
+const DraftTxTimeout = 10 * time.Minute
+
+// ErrUnknownDraftTx describes an error where a draft transaction ID is
+// unknown, or belongs to a draft which was already committed, cancelled or has
+// expired.
+var ErrUnknownDraftTx = errors.New("unknown or expired draft transaction")
+
+// DraftTx is an unsigned transaction created for review before it is signed
+// and published with CommitDraftTx.
+type DraftTx struct {
+	// ID identifies the draft.
+	ID string
+
+	// Tx is the unsigned transaction.
+	Tx *wire.MsgTx
+
+	// PrevScripts and PrevInputValues are the output scripts and values
+	// of the outputs spent by each input of the transaction.
+	PrevScripts     [][]byte
+	PrevInputValues []btcutil.Amount
+
+	// Fee is the fee paid by the transaction.
+	Fee btcutil.Amount
+
+	// ChangeIndex is the index of the change output of the transaction,
+	// or -1 when it has no change output.
+	ChangeIndex int
+
+	// Expiry is the time the draft expires unless it is committed.
+	Expiry time.Time
+}
+
+// draftTx is a draft transaction held by the wallet along with the spending
+// account and the metadata recorded with it once it is published.
+type draftTx struct {
+	DraftTx
+
+	keyScope     *waddrmgr.KeyScope
+	account      uint32
+	reservations []*spendReservation
+	label        string
+	comment      string
+	commentTo    string
+	timer        *time.Timer
+}
+
+// CreateDraftTx selects inputs for a payment transaction like SendOutputs and
+// records its change address, but rather than signing and publishing the
+// transaction, holds it for review until it is committed with CommitDraftTx.
+// The inputs of the draft are locked until it is committed, cancelled or
+// expires after DraftTxTimeout.  As the transaction is not signed, the wallet
+// need not be unlocked.
+func (w *Wallet) CreateDraftTx(outputs []*wire.TxOut,
+	keyScope *waddrmgr.KeyScope, account uint32, minconf int32,
+	satPerKb btcutil.Amount, coinSelectionStrategy CoinSelectionStrategy,
+	label string, optFuncs ...TxCreateOption) (*DraftTx, error) {
+
+	if w.Manager.WatchOnly() {
+		return nil, ErrTxUnsigned
+	}
+
+	createdTx, reservations, err := w.createSendTx(
+		outputs, keyScope, account, minconf, satPerKb,
+		coinSelectionStrategy,
+		append(optFuncs, withoutSigning(), withLockedInputs())...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomToken()
+	if err != nil {
+		w.unlockInputs(createdTx.Tx)
+		w.releaseSpend(reservations)
+		return nil, err
+	}
+
+	opts := defaultTxCreateOptions()
+	for _, optFunc := range optFuncs {
+		optFunc(opts)
+	}
+
+	draft := &draftTx{
+		DraftTx: DraftTx{
+			ID:              id,
+			Tx:              createdTx.Tx,
+			PrevScripts:     createdTx.PrevScripts,
+			PrevInputValues: createdTx.PrevInputValues,
+			Fee: createdTx.TotalInput -
+				txauthor.SumOutputValues(createdTx.Tx.TxOut),
+			ChangeIndex: createdTx.ChangeIndex,
+			Expiry:      w.Now().Add(DraftTxTimeout),
+		},
+		keyScope:     keyScope,
+		account:      account,
+		reservations: reservations,
+		label:        label,
+		comment:      opts.comment,
+		commentTo:    opts.commentTo,
+	}
+	draft.timer = time.AfterFunc(DraftTxTimeout, func() {
+		if w.CancelDraftTx(id) == nil {
+			log.Infof("Draft transaction %v expired",
+				createdTx.Tx.TxHash())
+		}
+	})
+
+	w.draftTxMtx.Lock()
+	if w.draftTxs == nil {
+		w.draftTxs = make(map[string]*draftTx)
+	}
+	w.draftTxs[id] = draft
+	w.draftTxMtx.Unlock()
+
+	result := draft.DraftTx
+	return &result, nil
+}
+
+// CommitDraftTx signs the transaction of a draft and publishes it, returning
+// the signed transaction.  The spending account must be unlocked, and the
+// draft is kept when it can not be signed.  When spends must be confirmed
+// with a TOTP code, the signed transaction is held as a pending spend instead,
+// and the token of the pending spend is returned.
+func (w *Wallet) CommitDraftTx(id string) (string, *wire.MsgTx, error) {
+	w.draftTxMtx.Lock()
+	draft, ok := w.draftTxs[id]
+	if !ok {
+		w.draftTxMtx.Unlock()
+		return "", nil, ErrUnknownDraftTx
+	}
+	if err := w.signDraftTx(draft); err != nil {
+		w.draftTxMtx.Unlock()
+		return "", nil, err
+	}
+	draft.timer.Stop()
+	delete(w.draftTxs, id)
+	w.draftTxMtx.Unlock()
+
+	tx := draft.Tx
+	if w.SpendConfirmationRequired() {
+		token, err := w.addPendingSpend(
+			tx, draft.reservations, draft.label, draft.comment,
+			draft.commentTo,
+		)
+		if err != nil {
+			w.unlockInputs(tx)
+			w.releaseSpend(draft.reservations)
+			return "", nil, err
+		}
+		return token, tx, nil
+	}
+
+	// The inputs remain locked until the transaction spending them is
+	// recorded, preventing them from being selected by another send in
+	// the meantime.
+	txHash, err := w.reliablyPublishTransaction(
+		tx, draft.label, draft.comment, draft.commentTo,
+	)
+	w.unlockInputs(tx)
+	if err != nil {
+		w.releaseSpend(draft.reservations)
+		return "", nil, err
+	}
+
+	// Sanity check on the returned tx hash.
+	if *txHash != tx.TxHash() {
+		return "", nil, errors.New("tx hash mismatch")
+	}
+
+	return "", tx, nil
+}
+
+// signDraftTx adds the input scripts of the transaction of a draft, holding
+// the spending account unlocked while it is signed.  The draft transaction
+// mutex must be held.
+func (w *Wallet) signDraftTx(draft *draftTx) error {
+	w.accountLockMtx.RLock()
+	defer w.accountLockMtx.RUnlock()
+
+	release, err := w.holdSpendingAccount(draft.keyScope, draft.account)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// The transaction is signed in place only once every input is signed,
+	// so that a failure leaves the draft unsigned.
+	authoredTx := &txauthor.AuthoredTx{
+		Tx:              draft.Tx.Copy(),
+		PrevScripts:     draft.PrevScripts,
+		PrevInputValues: draft.PrevInputValues,
+		ChangeIndex:     draft.ChangeIndex,
+	}
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		secrets := secretSource{w.Manager, addrmgrNs, w.signerProvider}
+		return authoredTx.AddAllInputScripts(secrets)
+	})
+	if err != nil {
+		return err
+	}
+	err = validateMsgTx(
+		authoredTx.Tx, authoredTx.PrevScripts,
+		authoredTx.PrevInputValues,
+	)
+	if err != nil {
+		return err
+	}
+
+	draft.Tx = authoredTx.Tx
+	return nil
+}
+
+// CancelDraftTx discards a draft transaction, unlocking its inputs and
+// releasing the reservation of its spend.
+func (w *Wallet) CancelDraftTx(id string) error {
+	w.draftTxMtx.Lock()
+	defer w.draftTxMtx.Unlock()
+
+	draft, ok := w.draftTxs[id]
+	if !ok {
+		return ErrUnknownDraftTx
+	}
+	draft.timer.Stop()
+	delete(w.draftTxs, id)
+	w.unlockInputs(draft.Tx)
+	w.releaseSpend(draft.reservations)
+	return nil
+}
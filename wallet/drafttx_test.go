@@ -0,0 +1,88 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDraftTx ensures that draft transactions are created unsigned with their
+// inputs locked, and are only signed and published once committed.
+func TestDraftTx(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	keyScope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.CurrentAddress(0, keyScope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	addUtxo(t, w, &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{},
+		},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(100000, pkScript),
+			wire.NewTxOut(100000, pkScript),
+		},
+	})
+
+	requireLocked := func(tx *wire.MsgTx, locked bool) {
+		for _, txIn := range tx.TxIn {
+			require.Equal(
+				t, locked, w.LockedOutpoint(txIn.PreviousOutPoint),
+			)
+		}
+	}
+
+	// Drafts are created while the wallet is locked, and hold their
+	// inputs until they are committed.
+	w.Lock()
+	draft, err := w.CreateDraftTx(
+		[]*wire.TxOut{wire.NewTxOut(10000, pkScript)}, &keyScope, 0, 1,
+		1000, CoinSelectionLargest, "",
+	)
+	require.NoError(t, err)
+	require.Len(t, draft.Tx.TxIn, 1)
+	require.Empty(t, draft.Tx.TxIn[0].Witness)
+	require.Equal(t, btcutil.Amount(100000)-
+		txauthor.SumOutputValues(draft.Tx.TxOut), draft.Fee)
+	require.GreaterOrEqual(t, draft.ChangeIndex, 0)
+	requireLocked(draft.Tx, true)
+
+	// A draft can't be committed while the wallet is locked, and is kept
+	// unsigned when it fails.
+	_, _, err = w.CommitDraftTx(draft.ID)
+	require.Error(t, err)
+	requireLocked(draft.Tx, true)
+
+	err = w.Unlock([]byte("world"), nil)
+	require.NoError(t, err)
+	_, tx, err := w.CommitDraftTx(draft.ID)
+	require.NoError(t, err)
+	require.Equal(t, draft.Tx.TxHash(), tx.TxHash())
+	require.NotEmpty(t, tx.TxIn[0].Witness)
+	requireLocked(tx, false)
+	_, _, err = w.CommitDraftTx(draft.ID)
+	require.Equal(t, ErrUnknownDraftTx, err)
+
+	// Cancelled drafts unlock their inputs.
+	draft, err = w.CreateDraftTx(
+		[]*wire.TxOut{wire.NewTxOut(10000, pkScript)}, &keyScope, 0, 1,
+		1000, CoinSelectionLargest, "",
+	)
+	require.NoError(t, err)
+	requireLocked(draft.Tx, true)
+	require.NoError(t, w.CancelDraftTx(draft.ID))
+	requireLocked(draft.Tx, false)
+	require.Equal(t, ErrUnknownDraftTx, w.CancelDraftTx(draft.ID))
+}
@@ -0,0 +1,541 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/internal/legacy/rename"
+	"github.com/btcsuite/btcwallet/internal/zero"
+	"github.com/btcsuite/btcwallet/snacl"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+)
+
+// encryptedDBFlushDelay is the time writes to an encrypted wallet database
+// are batched for before the database is written to its encrypted file.
+const encryptedDBFlushDelay = 5 * time.Second
+
+// encryptedDBMagic begins every encrypted wallet database file.
+var encryptedDBMagic = []byte("btcwdbe1")
+
+var (
+	// ErrDBEncrypted is returned when opening an encrypted wallet database
+	// without a database passphrase.
+	ErrDBEncrypted = errors.New("wallet database is encrypted, its " +
+		"passphrase is required to open it")
+
+	// ErrEncryptedDBMalformed is returned when decrypting a file which is
+	// not an encrypted wallet database.
+	ErrEncryptedDBMalformed = errors.New("malformed encrypted wallet " +
+		"database")
+)
+
+// sealData encrypts data with the key.  The sealed data begins with the magic,
+// followed by the parameters of the key derivation in the clear and the
+// encrypted data.
+func sealData(magic []byte, key *snacl.SecretKey, data []byte) ([]byte, error) {
+	encrypted, err := key.Encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	params := key.Marshal()
+	sealed := make([]byte, 0, len(magic)+len(params)+len(encrypted))
+	sealed = append(sealed, magic...)
+	sealed = append(sealed, params...)
+	return append(sealed, encrypted...), nil
+}
+
+// unsealData decrypts data sealed by sealData with a key derived from the
+// passphrase.  The derived key is returned with the data and must be zeroed by
+// the caller.  errMalformed is returned when the sealed data does not begin
+// with the magic.
+func unsealData(magic, sealed, passphrase []byte,
+	errMalformed error) ([]byte, *snacl.SecretKey, error) {
+
+	paramsLen := len((&snacl.SecretKey{}).Marshal())
+	if len(sealed) < len(magic)+paramsLen ||
+		!bytes.Equal(sealed[:len(magic)], magic) {
+
+		return nil, nil, errMalformed
+	}
+	sealed = sealed[len(magic):]
+
+	var key snacl.SecretKey
+	if err := key.Unmarshal(sealed[:paramsLen]); err != nil {
+		return nil, nil, err
+	}
+	if err := key.DeriveKey(&passphrase); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := key.Decrypt(sealed[paramsLen:])
+	if err != nil {
+		key.Zero()
+		return nil, nil, err
+	}
+	return data, &key, nil
+}
+
+// isEncryptedDB returns whether the file at path is an encrypted wallet
+// database.
+func isEncryptedDB(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(encryptedDBMagic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		return false, err
+	}
+	return bytes.Equal(magic, encryptedDBMagic), nil
+}
+
+// encryptedDB is a wallet database stored encrypted on disk.  The database is
+// decrypted to an in-memory database while it is open, so that no decrypted
+// copy of it is ever written to disk.  Writes are batched for
+// encryptedDBFlushDelay before the database is written back to the encrypted
+// file, and any remaining writes are written when the database is closed.
+// Writes committed within encryptedDBFlushDelay of a crash are lost.
+type encryptedDB struct {
+	walletdb.DB
+
+	path string
+	key  *snacl.SecretKey
+
+	// mu serializes writing the encrypted file and closing the database.
+	mu     sync.Mutex
+	dirty  bool
+	timer  *time.Timer
+	closed bool
+}
+
+// Enforce encryptedDB implements the walletdb.DB interface.
+var _ walletdb.DB = (*encryptedDB)(nil)
+
+// openEncryptedDB opens the encrypted wallet database at path, or creates it
+// when create is set.  An existing unencrypted database at path is opened with
+// openPlain and encrypted with the passphrase.
+func openEncryptedDB(path string, passphrase []byte, create bool,
+	openPlain func(path string) (walletdb.DB, error)) (*encryptedDB, error) {
+
+	var (
+		dump  []byte
+		key   *snacl.SecretKey
+		flush = create
+		err   error
+	)
+	if !create {
+		dump, key, err = readPlainOrEncrypted(path, passphrase, openPlain)
+		if err != nil {
+			return nil, err
+		}
+		flush = key == nil
+	}
+	if key == nil {
+		key, err = snacl.NewSecretKey(
+			&passphrase, snacl.DefaultN, snacl.DefaultR,
+			snacl.DefaultP,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	memDB, err := walletdb.Create("memdb")
+	if err == nil && dump != nil {
+		err = loadDump(memDB, dump)
+		zero.Bytes(dump)
+		if err != nil {
+			memDB.Close()
+		}
+	}
+	if err != nil {
+		key.Zero()
+		return nil, err
+	}
+
+	db := &encryptedDB{
+		DB:   memDB,
+		path: path,
+		key:  key,
+	}
+	if flush {
+		if err := db.writeEncrypted(); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// readPlainOrEncrypted reads the contents of the wallet database at path as
+// written by writeDump.  An encrypted database is decrypted with the
+// passphrase, and its key is returned with the contents.  An unencrypted
+// database is opened with openPlain, and no key is returned.
+func readPlainOrEncrypted(path string, passphrase []byte,
+	openPlain func(path string) (walletdb.DB, error)) ([]byte,
+	*snacl.SecretKey, error) {
+
+	encrypted, err := isEncryptedDB(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if encrypted {
+		sealed, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return unsealData(
+			encryptedDBMagic, sealed, passphrase,
+			ErrEncryptedDBMalformed,
+		)
+	}
+
+	log.Infof("Encrypting wallet database %v", path)
+	plainDB, err := openPlain(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer plainDB.Close()
+
+	var dump bytes.Buffer
+	err = walletdb.View(plainDB, func(tx walletdb.ReadTx) error {
+		return writeDump(tx, &dump)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return dump.Bytes(), nil, nil
+}
+
+// encryptedTx is a read-write transaction of an encryptedDB, which schedules
+// writing the database to its encrypted file once committed.
+type encryptedTx struct {
+	walletdb.ReadWriteTx
+	db *encryptedDB
+}
+
+// Commit commits the transaction and schedules writing the database to its
+// encrypted file.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *encryptedTx) Commit() error {
+	if err := tx.ReadWriteTx.Commit(); err != nil {
+		return err
+	}
+	tx.db.scheduleFlush()
+	return nil
+}
+
+// BeginReadWriteTx starts a read-write transaction, which schedules writing
+// the database to its encrypted file when it is committed.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *encryptedDB) BeginReadWriteTx() (walletdb.ReadWriteTx, error) {
+	tx, err := db.DB.BeginReadWriteTx()
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedTx{ReadWriteTx: tx, db: db}, nil
+}
+
+// Update runs f in a read-write transaction, scheduling writing the database
+// to its encrypted file once it is committed.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *encryptedDB) Update(f func(tx walletdb.ReadWriteTx) error,
+	reset func()) error {
+
+	if err := db.DB.Update(f, reset); err != nil {
+		return err
+	}
+	db.scheduleFlush()
+	return nil
+}
+
+// Copy writes the database as its encrypted file, so that copies of the
+// database remain encrypted with its passphrase.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *encryptedDB) Copy(w io.Writer) error {
+	return db.copyEncrypted(w)
+}
+
+// Close writes any writes not yet written to the encrypted file and closes the
+// database.
+//
+// This function is part of the walletdb.DB interface implementation.
+func (db *encryptedDB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return walletdb.ErrDbNotOpen
+	}
+	db.closed = true
+	if db.timer != nil {
+		db.timer.Stop()
+	}
+
+	var err error
+	if db.dirty {
+		err = db.writeEncrypted()
+	}
+	if closeErr := db.DB.Close(); err == nil {
+		err = closeErr
+	}
+	db.key.Zero()
+	return err
+}
+
+// scheduleFlush schedules writing the database to its encrypted file after a
+// write was committed, unless a write is already scheduled.
+func (db *encryptedDB) scheduleFlush() {
+	db.mu.Lock()
+	if !db.closed && !db.dirty {
+		db.dirty = true
+		db.timer = time.AfterFunc(encryptedDBFlushDelay, db.flush)
+	}
+	db.mu.Unlock()
+}
+
+// flush writes the database to its encrypted file when writes were committed
+// since it was last written.  A failed write is retried after
+// encryptedDBFlushDelay or when the database is closed.
+func (db *encryptedDB) flush() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed || !db.dirty {
+		return
+	}
+	if err := db.writeEncrypted(); err != nil {
+		log.Errorf("Unable to write encrypted wallet database: %v", err)
+		db.timer = time.AfterFunc(encryptedDBFlushDelay, db.flush)
+		return
+	}
+	db.dirty = false
+}
+
+// writeEncrypted writes a snapshot of the database to the encrypted file,
+// replacing it atomically.
+func (db *encryptedDB) writeEncrypted() error {
+	f, err := ioutil.TempFile(filepath.Dir(db.path), filepath.Base(db.path))
+	if err != nil {
+		return err
+	}
+	tempPath := f.Name()
+
+	err = db.copyEncrypted(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = rename.Atomic(tempPath, db.path)
+	}
+	if err != nil {
+		os.Remove(tempPath)
+	}
+	return err
+}
+
+// copyEncrypted writes an encrypted snapshot of the database to w.
+func (db *encryptedDB) copyEncrypted(w io.Writer) error {
+	var dump bytes.Buffer
+	err := walletdb.View(db.DB, func(tx walletdb.ReadTx) error {
+		return writeDump(tx, &dump)
+	})
+	if err != nil {
+		return err
+	}
+	sealed, err := sealData(encryptedDBMagic, db.key, dump.Bytes())
+	zero.Bytes(dump.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(sealed)
+	return err
+}
+
+// Records of a database dump.  A dump is a sequence of top level bucket
+// records ending with a dumpEnd record.  Key/value pair records hold the key
+// and value, and bucket records hold the key and sequence of the bucket
+// followed by the records of its contents and a dumpEnd record.  Keys and
+// values are prefixed with their length, and lengths and sequences are
+// encoded as unsigned varints.
+const (
+	dumpEnd byte = iota
+	dumpKeyValue
+	dumpBucket
+)
+
+// sequencer is implemented by buckets reporting their sequence.
+type sequencer interface {
+	Sequence() uint64
+}
+
+// writeDump writes the contents of the database read by tx to w.
+func writeDump(tx walletdb.ReadTx, w *bytes.Buffer) error {
+	err := tx.ForEachBucket(func(key []byte) error {
+		return writeDumpBucket(w, key, tx.ReadBucket(key))
+	})
+	if err != nil {
+		return err
+	}
+	w.WriteByte(dumpEnd)
+	return nil
+}
+
+// writeDumpBucket writes the record of the bucket with the key to w.
+func writeDumpBucket(w *bytes.Buffer, key []byte,
+	bucket walletdb.ReadBucket) error {
+
+	var sequence uint64
+	if s, ok := bucket.(sequencer); ok {
+		sequence = s.Sequence()
+	}
+	w.WriteByte(dumpBucket)
+	writeDumpBytes(w, key)
+	writeDumpUint(w, sequence)
+
+	err := bucket.ForEach(func(k, v []byte) error {
+		if v == nil {
+			nested := bucket.NestedReadBucket(k)
+			if nested != nil {
+				return writeDumpBucket(w, k, nested)
+			}
+		}
+		w.WriteByte(dumpKeyValue)
+		writeDumpBytes(w, k)
+		writeDumpBytes(w, v)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.WriteByte(dumpEnd)
+	return nil
+}
+
+// writeDumpUint writes an unsigned varint to w.
+func writeDumpUint(w *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+// writeDumpBytes writes b prefixed with its length to w.
+func writeDumpBytes(w *bytes.Buffer, b []byte) {
+	writeDumpUint(w, uint64(len(b)))
+	w.Write(b)
+}
+
+// loadDump writes the contents of a database dump written by writeDump to the
+// empty database db.
+func loadDump(db walletdb.DB, dump []byte) error {
+	r := bytes.NewReader(dump)
+	return walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		for {
+			record, err := r.ReadByte()
+			if err != nil {
+				return ErrEncryptedDBMalformed
+			}
+			switch record {
+			case dumpEnd:
+				return nil
+			case dumpBucket:
+				key, err := readDumpBytes(r)
+				if err != nil {
+					return err
+				}
+				bucket, err := tx.CreateTopLevelBucket(key)
+				if err != nil {
+					return err
+				}
+				if err := loadDumpBucket(r, bucket); err != nil {
+					return err
+				}
+			default:
+				return ErrEncryptedDBMalformed
+			}
+		}
+	})
+}
+
+// loadDumpBucket reads the sequence and contents of a bucket record from r
+// into the bucket.
+func loadDumpBucket(r *bytes.Reader, bucket walletdb.ReadWriteBucket) error {
+	sequence, err := binary.ReadUvarint(r)
+	if err != nil {
+		return ErrEncryptedDBMalformed
+	}
+	if err := bucket.SetSequence(sequence); err != nil {
+		return err
+	}
+
+	for {
+		record, err := r.ReadByte()
+		if err != nil {
+			return ErrEncryptedDBMalformed
+		}
+		if record == dumpEnd {
+			return nil
+		}
+		if record != dumpKeyValue && record != dumpBucket {
+			return ErrEncryptedDBMalformed
+		}
+
+		key, err := readDumpBytes(r)
+		if err != nil {
+			return err
+		}
+		if record == dumpBucket {
+			nested, err := bucket.CreateBucket(key)
+			if err != nil {
+				return err
+			}
+			if err := loadDumpBucket(r, nested); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := readDumpBytes(r)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// readDumpBytes reads bytes prefixed with their length from r.
+func readDumpBytes(r *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > uint64(r.Len()) {
+		return nil, ErrEncryptedDBMalformed
+	}
+	b := make([]byte, n)
+	r.Read(b)
+	return b, nil
+}
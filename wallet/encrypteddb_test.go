@@ -0,0 +1,162 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcwallet/snacl"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// TestEncryptedDB ensures that an unencrypted wallet database is encrypted on
+// disk once opened with a database passphrase, that it can only be opened
+// again with the passphrase, and that writes persist across reopening it.
+func TestEncryptedDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encrypteddb_test")
+	if err != nil {
+		t.Fatalf("Failed to create db dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pubPass := []byte("hello")
+	dbPass := []byte("database")
+	dbPath := filepath.Join(dir, WalletDBName)
+
+	loader := NewLoader(
+		&chaincfg.TestNet3Params, dir, true, defaultDBTimeout, 250,
+	)
+	_, err = loader.CreateNewWatchingOnlyWallet(pubPass, time.Now())
+	if err != nil {
+		t.Fatalf("unable to create wallet: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	// Opening the unencrypted wallet with a database passphrase encrypts
+	// it.
+	loader.SetDBPassphrase(dbPass)
+	w, err := loader.OpenExistingWallet(pubPass, false)
+	if err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	encrypted, err := isEncryptedDB(dbPath)
+	if err != nil {
+		t.Fatalf("unable to read wallet database: %v", err)
+	}
+	if !encrypted {
+		t.Fatal("wallet database was not encrypted")
+	}
+
+	// Record a value which must be written to the encrypted file and read
+	// back when the wallet is reopened.
+	secret := []byte("secret value")
+	err = walletdb.Update(w.Database(), func(tx walletdb.ReadWriteTx) error {
+		ns, err := tx.CreateTopLevelBucket([]byte("test"))
+		if err != nil {
+			return err
+		}
+		return ns.Put([]byte("key"), secret)
+	})
+	if err != nil {
+		t.Fatalf("unable to write to database: %v", err)
+	}
+
+	// No decrypted copy of the database is written to disk while the
+	// wallet is open.
+	assertNoPlaintext(t, dir, secret)
+
+	// The write is in the encrypted file once flushed.
+	w.Database().(*encryptedDB).flush()
+	contents, err := ioutil.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("unable to read wallet database: %v", err)
+	}
+	data, key, err := unsealData(
+		encryptedDBMagic, contents, dbPass, ErrEncryptedDBMalformed,
+	)
+	if err != nil {
+		t.Fatalf("unable to decrypt wallet database: %v", err)
+	}
+	key.Zero()
+	if !bytes.Contains(data, secret) {
+		t.Fatal("committed write not in encrypted wallet database")
+	}
+
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	assertNoPlaintext(t, dir, secret)
+
+	// The database can not be opened without the correct passphrase.
+	loader.SetDBPassphrase(nil)
+	if _, err := loader.OpenExistingWallet(pubPass, false); err != ErrDBEncrypted {
+		t.Fatalf("expected ErrDBEncrypted, got %v", err)
+	}
+	loader.SetDBPassphrase([]byte("wrong"))
+	_, err = loader.OpenExistingWallet(pubPass, false)
+	if err != snacl.ErrInvalidPassword {
+		t.Fatalf("expected ErrInvalidPassword, got %v", err)
+	}
+
+	loader.SetDBPassphrase(dbPass)
+	w, err = loader.OpenExistingWallet(pubPass, false)
+	if err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	defer loader.UnloadWallet()
+	assertNoPlaintext(t, dir, secret)
+
+	var value []byte
+	err = walletdb.View(w.Database(), func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket([]byte("test"))
+		if ns == nil {
+			return walletdb.ErrBucketNotFound
+		}
+		value = ns.Get([]byte("key"))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to read from database: %v", err)
+	}
+	if !bytes.Equal(value, secret) {
+		t.Fatalf("read %q, expected %q", value, secret)
+	}
+}
+
+// assertNoPlaintext ensures that no file in dir contains the plaintext value or
+// the unencrypted wallet database.
+func assertNoPlaintext(t *testing.T, dir string, plaintext []byte) {
+	t.Helper()
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo,
+		err error) error {
+
+		if err != nil || info.IsDir() {
+			return err
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(contents, plaintext) ||
+			bytes.Contains(contents, waddrmgrNamespaceKey) {
+
+			t.Fatalf("%v contains plaintext", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to read %v: %v", dir, err)
+	}
+}
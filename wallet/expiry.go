@@ -0,0 +1,216 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+const (
+	// unminedExpiryInterval is the interval at which unmined sends are
+	// checked for expiry.
+	unminedExpiryInterval = time.Minute
+
+	// bumpConfTarget is the number of blocks the fee rate suggested for a
+	// replacement of an expired transaction is estimated to be mined
+	// within.
+	bumpConfTarget = 2
+)
+
+// ExpiredTransaction describes a transaction spending outputs of the wallet
+// which remains unmined longer than the unmined expiry.  Such transactions
+// should be abandoned or replaced with a higher fee.
+type ExpiredTransaction struct {
+	Hash     chainhash.Hash
+	Received time.Time
+
+	// Fee is the fee paid by the transaction, or zero when the transaction
+	// spends outputs which do not belong to the wallet.  FeeRate is the
+	// fee per kilobyte of virtual size paid by the transaction, and is
+	// zero when its fee is unknown.
+	Fee     btcutil.Amount
+	FeeRate btcutil.Amount
+
+	// BumpFeeRate is the fee per kilobyte suggested for a replacement of
+	// the transaction, which is the fee rate currently estimated by the
+	// chain server for the transaction to be mined soon, or the least fee
+	// rate a replacement may pay if that is higher.
+	BumpFeeRate btcutil.Amount
+}
+
+// UnminedExpiry returns the duration after which unmined sends expire.  A zero
+// duration indicates that unmined sends never expire.
+func (w *Wallet) UnminedExpiry() time.Duration {
+	w.expiryMtx.Lock()
+	defer w.expiryMtx.Unlock()
+
+	return w.unminedExpiry
+}
+
+// SetUnminedExpiry sets the duration after which unmined sends expire.  A zero
+// duration disables expiry.
+func (w *Wallet) SetUnminedExpiry(expiry time.Duration) {
+	w.expiryMtx.Lock()
+	w.unminedExpiry = expiry
+	w.expiryMtx.Unlock()
+}
+
+// ExpiredTransactions returns every unmined send which has expired, ordered by
+// the time it was first seen.  No transactions are returned when expiry is
+// disabled.
+func (w *Wallet) ExpiredTransactions() ([]ExpiredTransaction, error) {
+	expiry := w.UnminedExpiry()
+	if expiry == 0 {
+		return nil, nil
+	}
+
+	var expired []ExpiredTransaction
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
+			expired = append(expired, expiredSends(
+				details, w.Now(), expiry,
+			)...)
+			return false, nil
+		}
+		return w.TxStore.RangeTransactions(txmgrNs, -1, -1, rangeFn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+
+	estimate := w.estimateFeePerKb(bumpConfTarget)
+	for i := range expired {
+		expired[i].BumpFeeRate = bumpFeeRate(
+			expired[i].FeeRate, estimate,
+		)
+	}
+
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].Received.Before(expired[j].Received)
+	})
+	return expired, nil
+}
+
+// estimateFeePerKb returns the fee per kilobyte estimated by the chain server
+// for a transaction to be mined within confTarget blocks, or zero if the chain
+// server can not estimate fee rates.
+func (w *Wallet) estimateFeePerKb(confTarget uint32) btcutil.Amount {
+	estimator, ok := w.ChainClient().(chain.FeeEstimator)
+	if !ok {
+		return 0
+	}
+	feeRate, err := estimator.EstimateFeePerKb(confTarget)
+	if err != nil {
+		log.Debugf("Unable to estimate fee rate: %v", err)
+		return 0
+	}
+	return feeRate
+}
+
+// bumpFeeRate returns the fee rate suggested for a replacement of a
+// transaction paying feeRate, given the fee rate estimated by the chain server.
+// A replacement must pay at least the incremental relay fee rate more than the
+// transaction it replaces, so a lower estimate is never suggested.
+func bumpFeeRate(feeRate, estimate btcutil.Amount) btcutil.Amount {
+	minFeeRate := feeRate + txrules.DefaultRelayFeePerKb
+	if estimate > minFeeRate {
+		return estimate
+	}
+	return minFeeRate
+}
+
+// expiredSends returns the transactions of details which spend outputs of the
+// wallet and were first seen longer than expiry before now.
+func expiredSends(details []wtxmgr.TxDetails, now time.Time,
+	expiry time.Duration) []ExpiredTransaction {
+
+	var expired []ExpiredTransaction
+	for i := range details {
+		d := &details[i]
+		if len(d.Debits) == 0 || now.Sub(d.Received) <= expiry {
+			continue
+		}
+
+		var fee, feeRate btcutil.Amount
+		if len(d.Debits) == len(d.MsgTx.TxIn) {
+			for _, deb := range d.Debits {
+				fee += deb.Amount
+			}
+			for _, txOut := range d.MsgTx.TxOut {
+				fee -= btcutil.Amount(txOut.Value)
+			}
+			vsize := mempool.GetTxVirtualSize(btcutil.NewTx(&d.MsgTx))
+			feeRate = fee * 1000 / btcutil.Amount(vsize)
+		}
+
+		expired = append(expired, ExpiredTransaction{
+			Hash:     d.Hash,
+			Received: d.Received,
+			Fee:      fee,
+			FeeRate:  feeRate,
+		})
+	}
+	return expired
+}
+
+// unminedExpiryMonitor periodically checks for unmined sends which have
+// expired, notifying clients of each once.
+//
+// NOTE: This MUST be run as a goroutine.
+func (w *Wallet) unminedExpiryMonitor() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(unminedExpiryInterval)
+	defer ticker.Stop()
+
+	// notified records the expired transactions clients were notified
+	// of.  Transactions which are mined or removed are forgotten.
+	notified := make(map[chainhash.Hash]struct{})
+
+	quit := w.quitChan()
+	for {
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+
+		expired, err := w.ExpiredTransactions()
+		if err != nil {
+			log.Errorf("Unable to check unmined transactions for "+
+				"expiry: %v", err)
+			continue
+		}
+
+		current := make(map[chainhash.Hash]struct{}, len(expired))
+		for i := range expired {
+			tx := &expired[i]
+			current[tx.Hash] = struct{}{}
+			if _, ok := notified[tx.Hash]; ok {
+				continue
+			}
+
+			log.Warnf("Transaction %v has not been mined since %v "+
+				"and should be abandoned or replaced with a "+
+				"higher fee rate of at least %v/kvB", tx.Hash,
+				tx.Received, tx.BumpFeeRate)
+			w.NtfnServer.notifyExpiredTransaction(tx)
+		}
+		notified = current
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExpiredSends ensures that only sends first seen longer than the expiry
+// ago are reported as expired.
+func TestExpiredSends(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1600000000, 0)
+	expiry := time.Hour
+
+	newDetails := func(hash byte, age time.Duration, debits ...int64) wtxmgr.TxDetails {
+		d := wtxmgr.TxDetails{
+			TxRecord: wtxmgr.TxRecord{
+				Hash:     chainhash.Hash{hash},
+				Received: now.Add(-age),
+			},
+		}
+		for i, amount := range debits {
+			d.MsgTx.TxIn = append(d.MsgTx.TxIn, &wire.TxIn{})
+			d.Debits = append(d.Debits, wtxmgr.DebitRecord{
+				Amount: btcutil.Amount(amount),
+				Index:  uint32(i),
+			})
+		}
+		d.MsgTx.TxOut = []*wire.TxOut{wire.NewTxOut(9000, nil)}
+		return d
+	}
+
+	// A send spending an input of another wallet reports no fee.
+	foreign := newDetails(4, 2*time.Hour, 10000)
+	foreign.MsgTx.TxIn = append(foreign.MsgTx.TxIn, &wire.TxIn{})
+
+	details := []wtxmgr.TxDetails{
+		newDetails(1, 2*time.Hour, 10000),
+		newDetails(2, 30*time.Minute, 10000),
+		newDetails(3, 2*time.Hour),
+		foreign,
+	}
+
+	expired := expiredSends(details, now, expiry)
+	require.Len(t, expired, 2)
+
+	require.Equal(t, chainhash.Hash{1}, expired[0].Hash)
+	require.Equal(t, now.Add(-2*time.Hour), expired[0].Received)
+	require.Equal(t, btcutil.Amount(1000), expired[0].Fee)
+	vsize := mempool.GetTxVirtualSize(btcutil.NewTx(&details[0].MsgTx))
+	require.Equal(t, btcutil.Amount(1000*1000/vsize), expired[0].FeeRate)
+
+	require.Equal(t, chainhash.Hash{4}, expired[1].Hash)
+	require.Zero(t, expired[1].Fee)
+	require.Zero(t, expired[1].FeeRate)
+}
+
+// TestBumpFeeRate ensures that the fee rate suggested for a replacement is the
+// estimated fee rate, unless it is too low to replace the transaction.
+func TestBumpFeeRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		feeRate, estimate, bump btcutil.Amount
+	}{
+		{feeRate: 1000, estimate: 20000, bump: 20000},
+		{feeRate: 20000, estimate: 20000, bump: 21000},
+		{feeRate: 5000, estimate: 0, bump: 6000},
+		{feeRate: 0, estimate: 0, bump: 1000},
+	}
+	for _, test := range tests {
+		bump := bumpFeeRate(test.feeRate, test.estimate)
+		require.Equal(t, test.bump, bump, "fee rate %v, estimate %v",
+			test.feeRate, test.estimate)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// HistoryEntry is an entry of the transaction history of the wallet, which
+// records either an output received by an account or an output sent by a
+// transaction spending from an account.
+type HistoryEntry struct {
+	Hash     chainhash.Hash
+	Received time.Time
+
+	// Height and BlockTime describe the block the transaction is mined
+	// in.  Height is -1 and BlockTime the zero time for unmined
+	// transactions.
+	Height    int32
+	BlockTime time.Time
+
+	// Category is "send" for sent outputs, and the category of the credit
+	// for received outputs, which is "receive", "generate" or "immature".
+	Category string
+
+	// Account is the name of the account which received the output, or
+	// which the transaction spends from for sends.
+	Account string
+
+	// Address is the address the output pays to, or the empty string
+	// when it does not pay to a single address.  The fee entry of a send
+	// with no outputs other than change has no address.
+	Address string
+
+	// Amount is the value of the output, which is negative for sends.
+	Amount btcutil.Amount
+
+	// Fee is the fee of a send, which is only set for its first entry so
+	// that the fees of the history add up.  The fee is unknown, and zero,
+	// when the transaction also spends outputs of other wallets.
+	Fee btcutil.Amount
+
+	// Label is the label of the address, TxLabel the label of the
+	// transaction, and Comment the comment of the send which created the
+	// transaction.
+	Label   string
+	TxLabel string
+	Comment string
+}
+
+// Time returns the time of the entry, which is the time of the block for mined
+// transactions and the time the transaction was received for unmined ones.
+func (e *HistoryEntry) Time() time.Time {
+	if e.Height != -1 {
+		return e.BlockTime
+	}
+	return e.Received
+}
+
+// ForEachHistoryEntry calls fn with each entry of the transaction history of
+// the wallet, oldest first, with unmined transactions last.  Change outputs
+// are not part of the history.  Iteration stops at the first error returned
+// by fn, which is returned.
+//
+// Each received output is an entry of the receiving account.  Each output of
+// a transaction spending from the wallet, other than change, is a send entry
+// of the account of its first spent output, so transfers between the accounts
+// of the wallet are recorded both as a send and as a receive.
+func (w *Wallet) ForEachHistoryEntry(fn func(*HistoryEntry) error) error {
+	return walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		syncHeight := w.Manager.SyncedTo().Height
+
+		rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
+			for i := range details {
+				err := w.historyEntries(
+					addrmgrNs, txmgrNs, &details[i],
+					syncHeight, fn,
+				)
+				if err != nil {
+					return false, err
+				}
+			}
+			return false, nil
+		}
+		return w.TxStore.RangeTransactions(txmgrNs, 0, -1, rangeFn)
+	})
+}
+
+// historyEntries calls fn with each history entry of a transaction.
+func (w *Wallet) historyEntries(addrmgrNs, txmgrNs walletdb.ReadBucket,
+	details *wtxmgr.TxDetails, syncHeight int32,
+	fn func(*HistoryEntry) error) error {
+
+	txLabel, err := w.TxStore.TxLabel(txmgrNs, details.Hash)
+	if err != nil {
+		return err
+	}
+	base := HistoryEntry{
+		Hash:     details.Hash,
+		Received: details.Received,
+		Height:   details.Block.Height,
+		TxLabel:  txLabel,
+		Comment:  details.Comment,
+	}
+	if details.Block.Height != -1 {
+		base.BlockTime = details.Block.Time
+	}
+
+	// Sends are attributed to the account of their first spent output,
+	// and their fee is only known when every input spends an output of
+	// the wallet.
+	var (
+		sendAccount string
+		fee         btcutil.Amount
+	)
+	send := len(details.Debits) != 0
+	if send {
+		sendAccount, err = w.debitAccountName(
+			addrmgrNs, txmgrNs, details, details.Debits[0],
+		)
+		if err != nil {
+			return err
+		}
+		if len(details.Debits) == len(details.MsgTx.TxIn) {
+			for _, deb := range details.Debits {
+				fee += deb.Amount
+			}
+			for _, output := range details.MsgTx.TxOut {
+				fee -= btcutil.Amount(output.Value)
+			}
+		}
+	}
+	recvCategory := RecvCategory(details, syncHeight, w.chainParams).String()
+
+	sentEntries := 0
+	for i, output := range details.MsgTx.TxOut {
+		var credit *wtxmgr.CreditRecord
+		for j := range details.Credits {
+			if details.Credits[j].Index == uint32(i) {
+				credit = &details.Credits[j]
+				break
+			}
+		}
+		if credit != nil && credit.Change {
+			continue
+		}
+
+		entry := base
+		entry.Amount = btcutil.Amount(output.Value)
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			output.PkScript, w.chainParams,
+		)
+		if err == nil && len(addrs) == 1 {
+			entry.Address = addrs[0].EncodeAddress()
+			entry.Label = w.Manager.AddressLabel(addrmgrNs, addrs[0])
+		}
+
+		if send {
+			sent := entry
+			sent.Category = "send"
+			sent.Account = sendAccount
+			sent.Amount = -entry.Amount
+			if sentEntries == 0 {
+				sent.Fee = fee
+			}
+			sentEntries++
+			if err := fn(&sent); err != nil {
+				return err
+			}
+		}
+		if credit != nil {
+			entry.Category = recvCategory
+			entry.Account, err = w.outputAccountName(
+				addrmgrNs, addrs,
+			)
+			if err != nil {
+				return err
+			}
+			if err := fn(&entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The fee of a send paying only change is recorded by an entry of its
+	// own.
+	if send && sentEntries == 0 && fee != 0 {
+		entry := base
+		entry.Category = "send"
+		entry.Account = sendAccount
+		entry.Fee = fee
+		return fn(&entry)
+	}
+	return nil
+}
+
+// debitAccountName returns the name of the account of the output spent by a
+// debit of a transaction.
+func (w *Wallet) debitAccountName(addrmgrNs, txmgrNs walletdb.ReadBucket,
+	details *wtxmgr.TxDetails, deb wtxmgr.DebitRecord) (string, error) {
+
+	prevOP := &details.MsgTx.TxIn[deb.Index].PreviousOutPoint
+	prev, err := w.TxStore.TxDetails(txmgrNs, &prevOP.Hash)
+	if err != nil || prev == nil {
+		return "", err
+	}
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		prev.MsgTx.TxOut[prevOP.Index].PkScript, w.chainParams,
+	)
+	if err != nil {
+		return "", nil
+	}
+	return w.outputAccountName(addrmgrNs, addrs)
+}
+
+// outputAccountName returns the name of the account of the first address of
+// an output which belongs to the wallet, or the empty string if none does.
+func (w *Wallet) outputAccountName(addrmgrNs walletdb.ReadBucket,
+	addrs []btcutil.Address) (string, error) {
+
+	for _, addr := range addrs {
+		manager, account, err := w.Manager.AddrAccount(addrmgrNs, addr)
+		if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return manager.AccountName(addrmgrNs, account)
+	}
+	return "", nil
+}
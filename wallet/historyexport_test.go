@@ -0,0 +1,136 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestForEachHistoryEntry ensures that the history of the wallet records each
+// received output and each sent output other than change, oldest first, with
+// the fee of each send recorded once.
+func TestForEachHistoryEntry(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	scope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.NewAddress(0, scope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	changeAddr, err := w.NewChangeAddress(0, scope)
+	require.NoError(t, err)
+	changeScript, err := txscript.PayToAddrScript(changeAddr)
+	require.NoError(t, err)
+	foreignAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		bytes.Repeat([]byte{0x23}, 20), w.ChainParams(),
+	)
+	require.NoError(t, err)
+	foreignScript, err := txscript.PayToAddrScript(foreignAddr)
+	require.NoError(t, err)
+	require.NoError(t, w.SetAddressLabel(addr, "invoice #1"))
+
+	addTx := func(msgTx *wire.MsgTx, block *wtxmgr.BlockMeta) *wtxmgr.TxRecord {
+		t.Helper()
+
+		// Times are recorded to the second.
+		received := time.Unix(time.Now().Unix(), 0)
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, received)
+		require.NoError(t, err)
+		err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+			return w.addRelevantTx(tx, rec, block)
+		})
+		require.NoError(t, err)
+		return rec
+	}
+
+	// A mined payment to the wallet.
+	syncedTo := w.Manager.SyncedTo()
+	block := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{
+			Hash:   syncedTo.Hash,
+			Height: syncedTo.Height,
+		},
+		Time: syncedTo.Timestamp,
+	}
+	recvTx := wire.NewMsgTx(wire.TxVersion)
+	recvTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	recvTx.AddTxOut(wire.NewTxOut(1e6, pkScript))
+	recvRec := addTx(recvTx, block)
+
+	// An unmined send of part of it to another wallet, with change.
+	sendTx := wire.NewMsgTx(wire.TxVersion)
+	sendTx.AddTxIn(wire.NewTxIn(
+		&wire.OutPoint{Hash: recvRec.Hash, Index: 0}, nil, nil,
+	))
+	sendTx.AddTxOut(wire.NewTxOut(4e5, foreignScript))
+	sendTx.AddTxOut(wire.NewTxOut(5e5, changeScript))
+	sendRec := addTx(sendTx, nil)
+	require.NoError(t, w.LabelTransaction(sendRec.Hash, "rent", false))
+
+	// An unmined consolidation of the change, which only pays a fee.
+	consolidateTx := wire.NewMsgTx(wire.TxVersion)
+	consolidateTx.AddTxIn(wire.NewTxIn(
+		&wire.OutPoint{Hash: sendRec.Hash, Index: 1}, nil, nil,
+	))
+	consolidateTx.AddTxOut(wire.NewTxOut(45e4, changeScript))
+	consolidateRec := addTx(consolidateTx, nil)
+
+	var entries []HistoryEntry
+	err = w.ForEachHistoryEntry(func(e *HistoryEntry) error {
+		entries = append(entries, *e)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// The unmined transactions are unordered.
+	require.Len(t, entries, 3)
+	if entries[1].Hash != sendRec.Hash {
+		entries[1], entries[2] = entries[2], entries[1]
+	}
+
+	require.Equal(t, HistoryEntry{
+		Hash:      recvRec.Hash,
+		Received:  recvRec.Received,
+		Height:    syncedTo.Height,
+		BlockTime: syncedTo.Timestamp,
+		Category:  "receive",
+		Account:   "default",
+		Address:   addr.EncodeAddress(),
+		Amount:    1e6,
+		Label:     "invoice #1",
+	}, entries[0])
+	require.Equal(t, HistoryEntry{
+		Hash:     sendRec.Hash,
+		Received: sendRec.Received,
+		Height:   -1,
+		Category: "send",
+		Account:  "default",
+		Address:  foreignAddr.EncodeAddress(),
+		Amount:   -4e5,
+		Fee:      1e5,
+		TxLabel:  "rent",
+	}, entries[1])
+	require.Equal(t, HistoryEntry{
+		Hash:     consolidateRec.Hash,
+		Received: consolidateRec.Received,
+		Height:   -1,
+		Category: "send",
+		Account:  "default",
+		Fee:      5e4,
+	}, entries[2])
+}
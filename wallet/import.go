@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/wire"
@@ -405,7 +406,8 @@ func (w *Wallet) ImportPublicKey(pubKey *btcec.PublicKey,
 }
 
 // ImportPrivateKey imports a private key to the wallet and writes the new
-// wallet to disk.
+// wallet to disk.  The block stamp is recorded as the birthday block of the
+// imported address, and the chain is rescanned from it if requested.
 //
 // NOTE: If a block stamp is not provided, then the wallet's birthday will be
 // set to the genesis block of the corresponding chain.
@@ -450,28 +452,7 @@ func (w *Wallet) ImportPrivateKey(scope waddrmgr.KeyScope, wif *btcutil.WIF,
 		if err != nil {
 			return err
 		}
-
-		// We'll only update our birthday with the new one if it is
-		// before our current one. Otherwise, if we do, we can
-		// potentially miss detecting relevant chain events that
-		// occurred between them while rescanning.
-		birthdayBlock, _, err := w.Manager.BirthdayBlock(addrmgrNs)
-		if err != nil {
-			return err
-		}
-		if bs.Height >= birthdayBlock.Height {
-			return nil
-		}
-
-		err = w.Manager.SetBirthday(addrmgrNs, bs.Timestamp)
-		if err != nil {
-			return err
-		}
-
-		// To ensure this birthday block is correct, we'll mark it as
-		// unverified to prompt a sanity check at the next restart to
-		// ensure it is correct as it was provided by the caller.
-		return w.Manager.SetBirthdayBlock(addrmgrNs, *bs, false)
+		return w.lowerBirthday(addrmgrNs, bs)
 	})
 	if err != nil {
 		return "", err
@@ -491,6 +472,8 @@ func (w *Wallet) ImportPrivateKey(scope waddrmgr.KeyScope, wif *btcutil.WIF,
 		// or failure is logged elsewhere, and the channel is not
 		// required to be read, so discard the return value.
 		_ = w.SubmitRescan(job)
+		log.Infof("Submitted rescan job %d for imported address %s",
+			job.ID, addr.EncodeAddress())
 	} else {
 		err := w.chainClient.NotifyReceived([]btcutil.Address{addr})
 		if err != nil {
@@ -507,3 +490,104 @@ func (w *Wallet) ImportPrivateKey(scope waddrmgr.KeyScope, wif *btcutil.WIF,
 	// Return the payment address string of the imported private key.
 	return addrStr, nil
 }
+
+// lowerBirthday sets the birthday block of the wallet to the birthday block of
+// an imported key or script if it is earlier.
+func (w *Wallet) lowerBirthday(addrmgrNs walletdb.ReadWriteBucket,
+	bs *waddrmgr.BlockStamp) error {
+
+	// We'll only update our birthday with the new one if it is before our
+	// current one. Otherwise, if we do, we can potentially miss detecting
+	// relevant chain events that occurred between them while rescanning.
+	birthdayBlock, _, err := w.Manager.BirthdayBlock(addrmgrNs)
+	if err != nil {
+		return err
+	}
+	if bs.Height >= birthdayBlock.Height {
+		return nil
+	}
+
+	err = w.Manager.SetBirthday(addrmgrNs, bs.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	// To ensure this birthday block is correct, we'll mark it as
+	// unverified to prompt a sanity check at the next restart to ensure it
+	// is correct as it was provided by the caller.
+	return w.Manager.SetBirthdayBlock(addrmgrNs, *bs, false)
+}
+
+// AddressBirthday returns the birthday block recorded for an address when it
+// was created or imported, from which the chain is scanned for its
+// transactions, or nil if none was recorded.
+func (w *Wallet) AddressBirthday(addr btcutil.Address) (*waddrmgr.BlockStamp,
+	error) {
+
+	var bs *waddrmgr.BlockStamp
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		var err error
+		bs, err = w.Manager.AddressBirthday(addrmgrNs, addr)
+		return err
+	})
+	return bs, err
+}
+
+// BirthdayBlockAtHeight returns the block stamp of the main chain block at the
+// height, to be used as the birthday block of an imported key.
+func (w *Wallet) BirthdayBlockAtHeight(height int32) (*waddrmgr.BlockStamp,
+	error) {
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+	return blockStampAtHeight(chainClient, height)
+}
+
+// BirthdayBlockAtTime returns the block stamp of a main chain block mined no
+// later than the birthday of a key, to be used as the birthday block of the
+// key when it is imported.
+func (w *Wallet) BirthdayBlockAtTime(birthday time.Time) (*waddrmgr.BlockStamp,
+	error) {
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// The located block may be up to birthdayBlockDelta after the
+	// timestamp searched for, so search earlier to not miss transactions
+	// of the key mined shortly after its birthday.
+	return locateBirthdayBlock(chainClient, birthday.Add(-birthdayBlockDelta))
+}
+
+// blockStampAtHeight returns the block stamp of the main chain block at the
+// height.
+func blockStampAtHeight(chainClient chainConn,
+	height int32) (*waddrmgr.BlockStamp, error) {
+
+	_, bestHeight, err := chainClient.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+	if height < 0 || height > bestHeight {
+		return nil, fmt.Errorf("block height %d out of range [0, %d]",
+			height, bestHeight)
+	}
+
+	hash, err := chainClient.GetBlockHash(int64(height))
+	if err != nil {
+		return nil, err
+	}
+	header, err := chainClient.GetBlockHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+	return &waddrmgr.BlockStamp{
+		Hash:      *hash,
+		Height:    height,
+		Timestamp: header.Timestamp,
+	}, nil
+}
@@ -5,11 +5,13 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -289,3 +291,55 @@ func testImportAccount(t *testing.T, w *Wallet, tc *testCase, watchOnly bool,
 	require.NoError(t, err)
 	require.Equal(t, true, addrManaged.Imported())
 }
+
+// TestImportPrivateKeyBirthday ensures that the birthday block of an imported
+// private key is recorded for its address.
+func TestImportPrivateKeyBirthday(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	conn := createMockChainConn(
+		chainParams.GenesisBlock, 100, defaultBlockInterval,
+	)
+	bs, err := blockStampAtHeight(conn, 50)
+	require.NoError(t, err)
+	require.Equal(t, conn.blockHashes[50], bs.Hash)
+	require.Equal(t, int32(50), bs.Height)
+	_, err = blockStampAtHeight(conn, 101)
+	require.Error(t, err)
+
+	// The wallet's birthday block is lowered to the key's.
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return w.Manager.SetBirthdayBlock(ns, waddrmgr.BlockStamp{
+			Hash:   conn.blockHashes[100],
+			Height: 100,
+		}, true)
+	})
+	require.NoError(t, err)
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	wif, err := btcutil.NewWIF(privKey, w.ChainParams(), true)
+	require.NoError(t, err)
+	encoded, err := w.ImportPrivateKey(
+		waddrmgr.KeyScopeBIP0044, wif, bs, false,
+	)
+	require.NoError(t, err)
+
+	addr, err := btcutil.DecodeAddress(encoded, w.ChainParams())
+	require.NoError(t, err)
+	birthday, err := w.AddressBirthday(addr)
+	require.NoError(t, err)
+	require.Equal(t, bs.Hash, birthday.Hash)
+	require.Equal(t, bs.Height, birthday.Height)
+	require.Equal(t, bs.Timestamp.Unix(), birthday.Timestamp.Unix())
+
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(waddrmgrNamespaceKey)
+		birthdayBlock, _, err := w.Manager.BirthdayBlock(ns)
+		require.Equal(t, bs.Height, birthdayBlock.Height)
+		return err
+	})
+	require.NoError(t, err)
+}
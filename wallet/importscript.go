@@ -0,0 +1,227 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// watchedPubKeyScopes are the key scopes a watched public key is imported
+// into, so that payments to each address type of the key are found.
+var watchedPubKeyScopes = []waddrmgr.KeyScope{
+	waddrmgr.KeyScopeBIP0044,
+	waddrmgr.KeyScopeBIP0049Plus,
+	waddrmgr.KeyScopeBIP0084,
+}
+
+// ImportWatchedPubKey imports a compressed public key to be watched into the
+// imported account of the BIP0044, BIP0049 and BIP0084 key scopes, so that
+// payments to its pay-to-pubkey-hash, nested witness and witness addresses are
+// found.  Addresses of the key already in the wallet are left as they are.
+//
+// The block stamp is recorded as the birthday block of the imported addresses,
+// and the chain is rescanned from it if requested.  Without a block stamp, the
+// addresses are only watched from the block the wallet is synced to.  The
+// addresses of the key are returned.
+func (w *Wallet) ImportWatchedPubKey(pubKey []byte, bs *waddrmgr.BlockStamp,
+	rescan bool) ([]btcutil.Address, error) {
+
+	// Imported public keys are stored compressed, so the addresses of
+	// uncompressed keys could not be watched.
+	if len(pubKey) != btcec.PubKeyBytesLenCompressed {
+		return nil, errors.New("only compressed public keys can be " +
+			"imported to be watched")
+	}
+	key, err := btcec.ParsePubKey(pubKey, btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	return w.importAddresses(bs, rescan, func(
+		addrmgrNs walletdb.ReadWriteBucket,
+		bs *waddrmgr.BlockStamp) ([]btcutil.Address, error) {
+
+		addrs := make([]btcutil.Address, 0, len(watchedPubKeyScopes))
+		for _, scope := range watchedPubKeyScopes {
+			manager, err := w.Manager.FetchScopedKeyManager(scope)
+			if err != nil {
+				return nil, err
+			}
+			addr, err := pubKeyAddress(
+				pubKey, manager.AddrSchema().ExternalAddrType,
+				w.chainParams,
+			)
+			if err != nil {
+				return nil, err
+			}
+			_, err = manager.ImportPublicKey(addrmgrNs, key, bs)
+			if err != nil && !waddrmgr.IsError(
+				err, waddrmgr.ErrDuplicateAddress) {
+
+				return nil, err
+			}
+			addrs = append(addrs, addr)
+		}
+		return addrs, nil
+	})
+}
+
+// pubKeyAddress returns the address of a serialized public key of an address
+// type.
+func pubKeyAddress(pubKey []byte, addrType waddrmgr.AddressType,
+	params *chaincfg.Params) (btcutil.Address, error) {
+
+	pubKeyHash := btcutil.Hash160(pubKey)
+	switch addrType {
+	case waddrmgr.PubKeyHash:
+		return btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+
+	case waddrmgr.WitnessPubKey:
+		return btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+
+	case waddrmgr.NestedWitnessPubKey:
+		p2wkh, err := btcutil.NewAddressWitnessPubKeyHash(
+			pubKeyHash, params,
+		)
+		if err != nil {
+			return nil, err
+		}
+		witnessProgram, err := txscript.PayToAddrScript(p2wkh)
+		if err != nil {
+			return nil, err
+		}
+		return btcutil.NewAddressScriptHash(witnessProgram, params)
+
+	default:
+		return nil, fmt.Errorf("address type %v is not of a public key",
+			addrType)
+	}
+}
+
+// ImportScript imports a redeem script, or a witness script when witness is
+// set, to be watched as the pay-to-script-hash or pay-to-witness-script-hash
+// address of the script.  The manager must be unlocked to import redeem
+// scripts, which are stored encrypted.  A script already in the wallet is
+// left as it is.
+//
+// The block stamp is recorded as the birthday block of the imported address,
+// and the chain is rescanned from it if requested.  Without a block stamp, the
+// address is only watched from the block the wallet is synced to.  The address
+// of the script is returned.
+func (w *Wallet) ImportScript(script []byte, witness bool,
+	bs *waddrmgr.BlockStamp, rescan bool) (btcutil.Address, error) {
+
+	// Scripts are imported to the same scope as multisig scripts.
+	manager, err := w.Manager.FetchScopedKeyManager(
+		waddrmgr.KeyScopeBIP0084,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var addr btcutil.Address
+	if witness {
+		scriptHash := sha256.Sum256(script)
+		addr, err = btcutil.NewAddressWitnessScriptHash(
+			scriptHash[:], w.chainParams,
+		)
+	} else {
+		addr, err = btcutil.NewAddressScriptHash(script, w.chainParams)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.importAddresses(bs, rescan, func(
+		addrmgrNs walletdb.ReadWriteBucket,
+		bs *waddrmgr.BlockStamp) ([]btcutil.Address, error) {
+
+		var err error
+		if witness {
+			_, err = manager.ImportWitnessScript(
+				addrmgrNs, script, bs, 0, false,
+			)
+		} else {
+			_, err = manager.ImportScript(addrmgrNs, script, bs)
+		}
+		if waddrmgr.IsError(err, waddrmgr.ErrDuplicateAddress) {
+			err = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []btcutil.Address{addr}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addr, nil
+}
+
+// importAddresses imports addresses with the import function in a single
+// database transaction, recording the block stamp as their birthday block.
+// The chain is then rescanned for the addresses from the block stamp if
+// requested, or the addresses are watched from the block the wallet is synced
+// to.  Without a block stamp, the synced to block is recorded as the birthday
+// block and the chain is not rescanned.
+func (w *Wallet) importAddresses(bs *waddrmgr.BlockStamp, rescan bool,
+	importFn func(walletdb.ReadWriteBucket,
+		*waddrmgr.BlockStamp) ([]btcutil.Address, error)) (
+	[]btcutil.Address, error) {
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+	lowerBirthday := bs != nil
+	if bs == nil {
+		syncedTo := w.Manager.SyncedTo()
+		bs = &syncedTo
+		rescan = false
+	}
+
+	var addrs []btcutil.Address
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		var err error
+		addrs, err = importFn(addrmgrNs, bs)
+		if err != nil {
+			return err
+		}
+		if !lowerBirthday {
+			return nil
+		}
+		return w.lowerBirthday(addrmgrNs, bs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rescan {
+		job := &RescanJob{
+			Addrs:      addrs,
+			BlockStamp: *bs,
+		}
+		_ = w.SubmitRescan(job)
+		log.Infof("Submitted rescan job %d for %d imported addresses",
+			job.ID, len(addrs))
+	} else {
+		err := chainClient.NotifyReceived(addrs)
+		if err != nil {
+			return nil, fmt.Errorf("unable to subscribe for address "+
+				"notifications: %v", err)
+		}
+	}
+	return addrs, nil
+}
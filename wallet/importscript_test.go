@@ -0,0 +1,102 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportWatchedPubKey ensures that a watched public key is held by the
+// wallet as each address type of the key.
+func TestImportWatchedPubKey(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pubKey := privKey.PubKey().SerializeCompressed()
+
+	addrs, err := w.ImportWatchedPubKey(pubKey, nil, false)
+	require.NoError(t, err)
+	require.Len(t, addrs, 3)
+	for i, addr := range addrs {
+		manager, err := w.Manager.FetchScopedKeyManager(
+			watchedPubKeyScopes[i],
+		)
+		require.NoError(t, err)
+		err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+			addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+			ma, err := manager.Address(addrmgrNs, addr)
+			if err != nil {
+				return err
+			}
+			require.Equal(t, addr.String(), ma.Address().String())
+			require.Equal(t, manager.AddrSchema().ExternalAddrType,
+				ma.AddrType())
+			require.True(t, ma.Imported())
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	// Importing the key again returns the same addresses.
+	again, err := w.ImportWatchedPubKey(pubKey, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, addrs, again)
+
+	// Uncompressed keys can not be watched.
+	_, err = w.ImportWatchedPubKey(
+		privKey.PubKey().SerializeUncompressed(), nil, false,
+	)
+	require.Error(t, err)
+}
+
+// TestImportScript ensures that redeem and witness scripts are held by the
+// wallet as their script hash addresses.
+func TestImportScript(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	pubKey, err := btcutil.NewAddressPubKey(
+		privKey.PubKey().SerializeCompressed(), w.ChainParams(),
+	)
+	require.NoError(t, err)
+	script, err := txscript.MultiSigScript(
+		[]*btcutil.AddressPubKey{pubKey}, 1,
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		witness  bool
+		addrType waddrmgr.AddressType
+	}{
+		{false, waddrmgr.Script},
+		{true, waddrmgr.WitnessScript},
+	}
+	for _, test := range tests {
+		addr, err := w.ImportScript(script, test.witness, nil, false)
+		require.NoError(t, err)
+		ma, err := w.AddressInfo(addr)
+		require.NoError(t, err)
+		require.Equal(t, test.addrType, ma.AddrType())
+		held, err := ma.(waddrmgr.ManagedScriptAddress).Script()
+		require.NoError(t, err)
+		require.Equal(t, script, held)
+
+		// Importing the script again returns the same address.
+		again, err := w.ImportScript(script, test.witness, nil, false)
+		require.NoError(t, err)
+		require.Equal(t, addr, again)
+	}
+}
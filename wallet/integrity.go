@@ -0,0 +1,107 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// IntegrityReport describes the result of checking the integrity of a wallet
+// database.
+type IntegrityReport struct {
+	// Inconsistencies are the inconsistencies found in the transaction
+	// store.  When repairing, repairable inconsistencies have been repaired.
+	Inconsistencies []wtxmgr.Inconsistency
+
+	// HistoryDropped is set when inconsistencies which could not be
+	// repaired in place caused the transaction history to be dropped.  The
+	// history is rebuilt by rescanning the chain from the wallet birthday
+	// once the wallet syncs.
+	HistoryDropped bool
+}
+
+// Unrepairable returns the number of inconsistencies which could not be
+// repaired in place.
+func (r *IntegrityReport) Unrepairable() int {
+	var n int
+	for i := range r.Inconsistencies {
+		if !r.Inconsistencies[i].Repairable {
+			n++
+		}
+	}
+	return n
+}
+
+// CheckDBIntegrity checks the transaction store of a wallet database which is
+// not opened by a wallet.  When repair is set, repairable inconsistencies are
+// repaired, and the transaction history is dropped if any inconsistency could
+// not be repaired, so that it is re-derived from the chain backend.
+func CheckDBIntegrity(db walletdb.DB, repair bool) (*IntegrityReport, error) {
+	report := new(IntegrityReport)
+	var err error
+	if repair {
+		err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+			ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+			var err error
+			report.Inconsistencies, err = wtxmgr.RepairIntegrity(ns)
+			return err
+		})
+	} else {
+		err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+			ns := tx.ReadBucket(wtxmgrNamespaceKey)
+			var err error
+			report.Inconsistencies, err = wtxmgr.CheckIntegrity(ns)
+			return err
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if repair && report.Unrepairable() > 0 {
+		if err := DropTransactionHistory(db, true); err != nil {
+			return nil, err
+		}
+		report.HistoryDropped = true
+	}
+	return report, nil
+}
+
+// CheckIntegrity checks the transaction store of the wallet while it runs,
+// repairing repairable inconsistencies when repair is set.  Checks which do not
+// repair only read the database.  Inconsistencies which cannot be repaired in
+// place require the database to be repaired with CheckDBIntegrity before the
+// wallet is opened.  The cached balances are dropped when repairs are made, as
+// they may have been loaded from the inconsistent records.
+func (w *Wallet) CheckIntegrity(repair bool) ([]wtxmgr.Inconsistency, error) {
+	var found []wtxmgr.Inconsistency
+	if !repair {
+		err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+			ns := tx.ReadBucket(wtxmgrNamespaceKey)
+			var err error
+			found, err = wtxmgr.CheckIntegrity(ns)
+			return err
+		})
+		return found, err
+	}
+
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+		var err error
+		found, err = wtxmgr.RepairIntegrity(ns)
+		if err != nil {
+			return err
+		}
+		for _, inconsistency := range found {
+			if inconsistency.Repairable {
+				tx.OnCommit(w.balances.reset)
+				break
+			}
+		}
+		return nil
+	})
+	return found, err
+}
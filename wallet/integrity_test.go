@@ -0,0 +1,194 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+// TestCheckDBIntegrityDropsHistory ensures that transaction records which
+// cannot be repaired in place cause the transaction history to be dropped
+// when repairing the wallet database.
+func TestCheckDBIntegrityDropsHistory(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e8, nil))
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{Hash: chainhash.Hash{1}, Height: 100},
+		Time:  time.Now(),
+	}
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+		if err := w.TxStore.InsertTx(ns, rec, block); err != nil {
+			return err
+		}
+		return w.TxStore.AddCredit(ns, rec, block, 0, false)
+	})
+	if err != nil {
+		t.Fatalf("unable to record transaction: %v", err)
+	}
+
+	report, err := CheckDBIntegrity(w.db, false)
+	if err != nil {
+		t.Fatalf("unable to check integrity: %v", err)
+	}
+	if len(report.Inconsistencies) != 0 {
+		t.Fatalf("found inconsistencies in consistent wallet: %v",
+			report.Inconsistencies)
+	}
+
+	// Lose the record of the transaction, leaving its credit behind.
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		records := tx.ReadWriteBucket(wtxmgrNamespaceKey).
+			NestedReadWriteBucket([]byte("t"))
+		var keys [][]byte
+		err := records.ForEach(func(k, _ []byte) error {
+			keys = append(keys, append([]byte(nil), k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := records.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to delete transaction records: %v", err)
+	}
+
+	report, err = CheckDBIntegrity(w.db, false)
+	if err != nil {
+		t.Fatalf("unable to check integrity: %v", err)
+	}
+	if report.Unrepairable() == 0 || report.HistoryDropped {
+		t.Fatalf("expected unrepairable inconsistencies without "+
+			"dropping history, got %d and %v", report.Unrepairable(),
+			report.HistoryDropped)
+	}
+
+	report, err = CheckDBIntegrity(w.db, true)
+	if err != nil {
+		t.Fatalf("unable to repair integrity: %v", err)
+	}
+	if !report.HistoryDropped {
+		t.Fatal("expected transaction history to be dropped")
+	}
+
+	report, err = CheckDBIntegrity(w.db, false)
+	if err != nil {
+		t.Fatalf("unable to check integrity: %v", err)
+	}
+	if len(report.Inconsistencies) != 0 {
+		t.Fatalf("found inconsistencies after repair: %v",
+			report.Inconsistencies)
+	}
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(wtxmgrNamespaceKey)
+		credits, err := w.TxStore.UnspentOutputs(ns)
+		if err != nil {
+			return err
+		}
+		if len(credits) != 0 {
+			t.Fatalf("found %d credits after dropping history",
+				len(credits))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCheckIntegrityResetsBalances ensures that the cached balances are
+// dropped when inconsistencies are repaired while the wallet runs, so that
+// balances loaded from the inconsistent records are not reported.
+func TestCheckIntegrityResetsBalances(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e8, nil))
+	addUtxo(t, w, msgTx)
+
+	balance := func() btcutil.Amount {
+		var balance btcutil.Amount
+		err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+			var ok bool
+			var err error
+			balance, ok, err = w.cachedBalance(
+				tx, 1, testBlockHeight+1,
+			)
+			if err == nil && !ok {
+				t.Fatal("balance not determined from the cache")
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return balance
+	}
+
+	// Lose the unspent index entry of the credit, and cache the balance
+	// without it.
+	err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		unspent := tx.ReadWriteBucket(wtxmgrNamespaceKey).
+			NestedReadWriteBucket([]byte("u"))
+		var keys [][]byte
+		err := unspent.ForEach(func(k, _ []byte) error {
+			keys = append(keys, append([]byte(nil), k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := unspent.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to delete unspent index: %v", err)
+	}
+	if b := balance(); b != 0 {
+		t.Fatalf("expected balance 0 without unspent index, got %v", b)
+	}
+
+	found, err := w.CheckIntegrity(true)
+	if err != nil {
+		t.Fatalf("unable to repair integrity: %v", err)
+	}
+	if len(found) == 0 {
+		t.Fatal("expected missing unspent index entry to be found")
+	}
+	if b := balance(); b != 1e8 {
+		t.Fatalf("expected balance 1 BTC after repair, got %v", b)
+	}
+}
@@ -26,6 +26,14 @@ const (
 	// DefaultDBTimeout is the default timeout value when opening the wallet
 	// database.
 	DefaultDBTimeout = 60 * time.Second
+
+	// BoltDBDriver is the walletdb driver of the default bbolt backed
+	// wallet database.
+	BoltDBDriver = "bdb"
+
+	// SQLiteDBDriver is the walletdb driver of the SQLite backed wallet
+	// database.
+	SQLiteDBDriver = "sqlite"
 )
 
 var (
@@ -53,13 +61,18 @@ type Loader struct {
 	callbacks      []func(*Wallet)
 	chainParams    *chaincfg.Params
 	dbDirPath      string
+	dbDriver       string
+	dbPassphrase   []byte
 	noFreelistSync bool
+	fsck           bool
+	fsckRepair     bool
 	timeout        time.Duration
 	recoveryWindow uint32
 	wallet         *Wallet
 	localDB        bool
 	walletExists   func() (bool, error)
 	walletCreated  func(db walletdb.ReadWriteTx) error
+	clock          func() time.Time
 	db             walletdb.DB
 	mu             sync.Mutex
 }
@@ -74,6 +87,7 @@ func NewLoader(chainParams *chaincfg.Params, dbDirPath string,
 	return &Loader{
 		chainParams:    chainParams,
 		dbDirPath:      dbDirPath,
+		dbDriver:       BoltDBDriver,
 		noFreelistSync: noFreelistSync,
 		timeout:        timeout,
 		recoveryWindow: recoveryWindow,
@@ -105,6 +119,93 @@ func NewLoaderWithDB(chainParams *chaincfg.Params, recoveryWindow uint32,
 	}, nil
 }
 
+// SetDBDriver selects the walletdb driver, either BoltDBDriver or
+// SQLiteDBDriver, of the wallet database created or opened by the loader.  The
+// driver must have been registered with walletdb by importing its package.
+func (l *Loader) SetDBDriver(dbDriver string) {
+	l.mu.Lock()
+	l.dbDriver = dbDriver
+	l.mu.Unlock()
+}
+
+// SetDBPassphrase encrypts the entire wallet database on disk with the
+// passphrase, which is separate from the private passphrase protecting the
+// keys of the wallet.  An existing unencrypted wallet database is encrypted
+// when it is opened.  Encrypted wallet databases can not be opened without the
+// passphrase.
+func (l *Loader) SetDBPassphrase(passphrase []byte) {
+	l.mu.Lock()
+	l.dbPassphrase = passphrase
+	l.mu.Unlock()
+}
+
+// SetIntegrityCheck enables checking the integrity of the wallet database
+// before it is opened by OpenExistingWallet.  When repair is set, the
+// inconsistencies found are repaired, rebuilding the transaction history by
+// rescanning the chain if necessary.
+func (l *Loader) SetIntegrityCheck(check, repair bool) {
+	l.mu.Lock()
+	l.fsck = check || repair
+	l.fsckRepair = repair
+	l.mu.Unlock()
+}
+
+// openLocalDB opens, or creates when create is set, the wallet database in the
+// loader's directory with the selected driver.  The database is encrypted on
+// disk when a database passphrase is set.
+func (l *Loader) openLocalDB(create bool) (walletdb.DB, error) {
+	openDB := func(dbPath string, create bool) (walletdb.DB, error) {
+		if create {
+			return walletdb.Create(l.dbDriver, l.dbArgs(dbPath)...)
+		}
+		return walletdb.Open(l.dbDriver, l.dbArgs(dbPath)...)
+	}
+
+	dbPath := filepath.Join(l.dbDirPath, WalletDBName)
+	if l.dbPassphrase != nil {
+		openPlain := func(dbPath string) (walletdb.DB, error) {
+			return openDB(dbPath, false)
+		}
+		db, err := openEncryptedDB(
+			dbPath, l.dbPassphrase, create, openPlain,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+
+	if !create {
+		encrypted, err := isEncryptedDB(dbPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if encrypted {
+			return nil, ErrDBEncrypted
+		}
+	}
+	return openDB(dbPath, create)
+}
+
+// dbArgs returns the arguments to the walletdb Create and Open calls of the
+// loader's driver for the database at dbPath.
+func (l *Loader) dbArgs(dbPath string) []interface{} {
+	if l.dbDriver == SQLiteDBDriver {
+		return []interface{}{dbPath, l.timeout}
+	}
+	return []interface{}{dbPath, l.noFreelistSync, l.timeout}
+}
+
+// SetClock replaces the clock of wallets created or opened by the loader.  A
+// fixed clock makes the times reported by the wallet deterministic, which is
+// used by tests comparing results against recorded responses.  It must be
+// called before a wallet is loaded.
+func (l *Loader) SetClock(clock func() time.Time) {
+	l.mu.Lock()
+	l.clock = clock
+	l.mu.Unlock()
+}
+
 // onLoaded executes each added callback and prevents loader from loading any
 // additional wallets.  Requires mutex to be locked.
 func (l *Loader) onLoaded(w *Wallet) {
@@ -169,7 +270,7 @@ func (l *Loader) CreateNewWallet(pubPassphrase, privPassphrase, seed []byte,
 	}
 
 	return l.createNewWallet(
-		pubPassphrase, privPassphrase, rootKey, bday, false,
+		pubPassphrase, privPassphrase, rootKey, bday, false, false,
 	)
 }
 
@@ -181,7 +282,7 @@ func (l *Loader) CreateNewWalletExtendedKey(pubPassphrase, privPassphrase []byte
 	rootKey *hdkeychain.ExtendedKey, bday time.Time) (*Wallet, error) {
 
 	return l.createNewWallet(
-		pubPassphrase, privPassphrase, rootKey, bday, false,
+		pubPassphrase, privPassphrase, rootKey, bday, false, false,
 	)
 }
 
@@ -192,13 +293,24 @@ func (l *Loader) CreateNewWatchingOnlyWallet(pubPassphrase []byte,
 	bday time.Time) (*Wallet, error) {
 
 	return l.createNewWallet(
-		pubPassphrase, nil, nil, bday, true,
+		pubPassphrase, nil, nil, bday, true, false,
+	)
+}
+
+// CreateNewBlankWallet creates a new blank wallet using the provided public
+// and private passphrases.  The wallet has no seed, so it holds no keys until
+// private keys are imported.
+func (l *Loader) CreateNewBlankWallet(pubPassphrase, privPassphrase []byte,
+	bday time.Time) (*Wallet, error) {
+
+	return l.createNewWallet(
+		pubPassphrase, privPassphrase, nil, bday, false, true,
 	)
 }
 
 func (l *Loader) createNewWallet(pubPassphrase, privPassphrase []byte,
 	rootKey *hdkeychain.ExtendedKey, bday time.Time,
-	isWatchingOnly bool) (*Wallet, error) {
+	isWatchingOnly, isBlank bool) (*Wallet, error) {
 
 	defer l.mu.Unlock()
 	l.mu.Lock()
@@ -216,23 +328,20 @@ func (l *Loader) createNewWallet(pubPassphrase, privPassphrase []byte,
 	}
 
 	if l.localDB {
-		dbPath := filepath.Join(l.dbDirPath, WalletDBName)
-
-		// Create the wallet database backed by bolt db.
+		// Create the wallet database with the selected driver.
 		err = os.MkdirAll(l.dbDirPath, 0700)
 		if err != nil {
 			return nil, err
 		}
-		l.db, err = walletdb.Create(
-			"bdb", dbPath, l.noFreelistSync, l.timeout,
-		)
+		l.db, err = l.openLocalDB(true)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	// Initialize the newly created database for the wallet before opening.
-	if isWatchingOnly {
+	switch {
+	case isWatchingOnly:
 		err := CreateWatchingOnlyWithCallback(
 			l.db, pubPassphrase, l.chainParams, bday,
 			l.walletCreated,
@@ -240,7 +349,15 @@ func (l *Loader) createNewWallet(pubPassphrase, privPassphrase []byte,
 		if err != nil {
 			return nil, err
 		}
-	} else {
+	case isBlank:
+		err := CreateBlankWithCallback(
+			l.db, pubPassphrase, privPassphrase, l.chainParams,
+			bday, l.walletCreated,
+		)
+		if err != nil {
+			return nil, err
+		}
+	default:
 		err := CreateWithCallback(
 			l.db, pubPassphrase, privPassphrase, rootKey,
 			l.chainParams, bday, l.walletCreated,
@@ -255,6 +372,9 @@ func (l *Loader) createNewWallet(pubPassphrase, privPassphrase []byte,
 	if err != nil {
 		return nil, err
 	}
+	if l.clock != nil {
+		w.clock = l.clock
+	}
 	w.Start()
 
 	l.onLoaded(w)
@@ -286,15 +406,32 @@ func (l *Loader) OpenExistingWallet(pubPassphrase []byte, canConsolePrompt bool)
 			return nil, err
 		}
 
-		// Open the database using the boltdb backend.
-		dbPath := filepath.Join(l.dbDirPath, WalletDBName)
-		l.db, err = walletdb.Open(
-			"bdb", dbPath, l.noFreelistSync, l.timeout,
-		)
+		// Open the database using the selected driver.
+		l.db, err = l.openLocalDB(false)
 		if err != nil {
 			log.Errorf("Failed to open database: %v", err)
 			return nil, err
 		}
+
+		// Back up the database before any migrations are applied so
+		// that the wallet can be restored should an upgrade fail.
+		if err := l.backupBeforeUpgrade(); err != nil {
+			if e := l.db.Close(); e != nil {
+				log.Warnf("Error closing database: %v", e)
+			}
+			return nil, err
+		}
+	}
+
+	if l.fsck {
+		if err := l.checkIntegrity(); err != nil {
+			if l.localDB {
+				if e := l.db.Close(); e != nil {
+					log.Warnf("Error closing database: %v", e)
+				}
+			}
+			return nil, err
+		}
 	}
 
 	var cbs *waddrmgr.OpenCallbacks
@@ -323,12 +460,93 @@ func (l *Loader) OpenExistingWallet(pubPassphrase []byte, canConsolePrompt bool)
 
 		return nil, err
 	}
+	if l.clock != nil {
+		w.clock = l.clock
+	}
 	w.Start()
 
 	l.onLoaded(w)
 	return w, nil
 }
 
+// backupBeforeUpgrade writes a copy of the opened wallet database next to it
+// when the database is at an older version and will be migrated by Open.
+func (l *Loader) backupBeforeUpgrade() error {
+	needsUpgrade, err := dbNeedsUpgrade(l.db)
+	if err != nil {
+		return err
+	}
+	if !needsUpgrade {
+		return nil
+	}
+
+	now := time.Now
+	if l.clock != nil {
+		now = l.clock
+	}
+	backupPath := filepath.Join(l.dbDirPath, fmt.Sprintf("%s.%s.bak",
+		WalletDBName, now().UTC().Format("20060102150405")))
+
+	f, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to create wallet backup: %v", err)
+	}
+	// The backup of an encrypted database remains encrypted.
+	err = l.db.Copy(f)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(backupPath)
+		return fmt.Errorf("unable to back up wallet: %v", err)
+	}
+
+	log.Infof("Wallet database requires an upgrade, backed up to %v",
+		backupPath)
+	return nil
+}
+
+// checkIntegrity checks, and repairs if requested, the transaction store of
+// the wallet database before it is opened.  Databases which are migrated by
+// Open are not checked, as the checks only understand the latest version.
+func (l *Loader) checkIntegrity() error {
+	needsUpgrade, err := dbNeedsUpgrade(l.db)
+	if err != nil {
+		return err
+	}
+	if needsUpgrade {
+		log.Warnf("Skipping wallet integrity check until the database " +
+			"is upgraded")
+		return nil
+	}
+
+	log.Infof("Checking wallet database integrity")
+	report, err := CheckDBIntegrity(l.db, l.fsckRepair)
+	if err != nil {
+		return fmt.Errorf("unable to check wallet integrity: %v", err)
+	}
+	for _, i := range report.Inconsistencies {
+		log.Warnf("Wallet inconsistency: %v", i)
+	}
+
+	switch {
+	case len(report.Inconsistencies) == 0:
+		log.Infof("No wallet inconsistencies found")
+	case !l.fsckRepair:
+		log.Warnf("Found %d wallet inconsistencies which were not "+
+			"repaired", len(report.Inconsistencies))
+	case report.HistoryDropped:
+		log.Warnf("Repaired %d wallet inconsistencies, transaction "+
+			"history will be rebuilt by rescanning to repair %d "+
+			"more", len(report.Inconsistencies)-report.Unrepairable(),
+			report.Unrepairable())
+	default:
+		log.Infof("Repaired %d wallet inconsistencies",
+			len(report.Inconsistencies))
+	}
+	return nil
+}
+
 // WalletExists returns whether a file exists at the loader's database path.
 // This may return an error for unexpected I/O failures.
 func (l *Loader) WalletExists() (bool, error) {
@@ -369,10 +587,10 @@ func (l *Loader) UnloadWallet() error {
 		if err != nil {
 			return err
 		}
+		l.db = nil
 	}
 
 	l.wallet = nil
-	l.db = nil
 	return nil
 }
 
@@ -0,0 +1,250 @@
+// Copyright (c) 2018 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+)
+
+// TestOpenBacksUpBeforeUpgrade ensures that opening a wallet which requires a
+// database upgrade writes a backup of the database, and that no backup is
+// written once the wallet is on the latest version.
+func TestOpenBacksUpBeforeUpgrade(t *testing.T) {
+	dir, err := ioutil.TempDir("", "loader_test")
+	if err != nil {
+		t.Fatalf("Failed to create db dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pubPass := []byte("hello")
+	clockTime := time.Unix(1600000000, 0)
+
+	loader := NewLoader(
+		&chaincfg.TestNet3Params, dir, true, defaultDBTimeout, 250,
+	)
+	loader.SetClock(func() time.Time { return clockTime })
+	w, err := loader.CreateNewWatchingOnlyWallet(pubPass, clockTime)
+	if err != nil {
+		t.Fatalf("unable to create wallet: %v", err)
+	}
+
+	// Roll the address manager back to a previous version so that it is
+	// migrated when the wallet is opened again.
+	err = walletdb.Update(w.Database(), func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return waddrmgr.NewMigrationManager(ns).SetVersion(ns, 7)
+	})
+	if err != nil {
+		t.Fatalf("unable to set address manager version: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	backups := func() []string {
+		matches, err := filepath.Glob(
+			filepath.Join(dir, WalletDBName+".*.bak"),
+		)
+		if err != nil {
+			t.Fatalf("unable to list backups: %v", err)
+		}
+		return matches
+	}
+
+	if _, err := loader.OpenExistingWallet(pubPass, false); err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	if n := len(backups()); n != 1 {
+		t.Fatalf("expected 1 backup after upgrade, found %d", n)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	// The wallet is now on the latest version, so opening it again must
+	// not write another backup.
+	clockTime = clockTime.Add(time.Hour)
+	if _, err := loader.OpenExistingWallet(pubPass, false); err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	if n := len(backups()); n != 1 {
+		t.Fatalf("expected 1 backup without upgrade, found %d", n)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+}
+
+// TestInMemoryWallet ensures that a wallet can be created in and reopened from
+// an in-memory database.
+func TestInMemoryWallet(t *testing.T) {
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		t.Fatalf("unable to create database: %v", err)
+	}
+	defer db.Close()
+
+	walletExists := func() (bool, error) {
+		var exists bool
+		err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+			exists = tx.ReadBucket(waddrmgrNamespaceKey) != nil
+			return nil
+		})
+		return exists, err
+	}
+	loader, err := NewLoaderWithDB(
+		&chaincfg.TestNet3Params, 250, db, walletExists,
+	)
+	if err != nil {
+		t.Fatalf("unable to create loader: %v", err)
+	}
+
+	pubPass := []byte("hello")
+	w, err := loader.CreateNewWallet(
+		pubPass, []byte("world"), make([]byte, 32), time.Now(),
+	)
+	if err != nil {
+		t.Fatalf("unable to create wallet: %v", err)
+	}
+	w.chainClient = &mockChainClient{}
+	addr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	if err != nil {
+		t.Fatalf("unable to derive address: %v", err)
+	}
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+
+	w, err = loader.OpenExistingWallet(pubPass, false)
+	if err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	defer loader.UnloadWallet()
+	have, err := w.HaveAddress(addr)
+	if err != nil {
+		t.Fatalf("unable to look up address: %v", err)
+	}
+	if !have {
+		t.Fatalf("reopened wallet is missing address %v", addr)
+	}
+}
+
+// TestCreateBlankWallet ensures that a blank wallet holds no keys and derives
+// no addresses, but can import private keys once unlocked, including after it
+// is opened again.
+func TestCreateBlankWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "loader_test")
+	if err != nil {
+		t.Fatalf("Failed to create db dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pubPass := []byte("hello")
+	privPass := []byte("world")
+
+	loader := NewLoader(
+		&chaincfg.TestNet3Params, dir, true, defaultDBTimeout, 250,
+	)
+	w, err := loader.CreateNewBlankWallet(pubPass, privPass, time.Now())
+	if err != nil {
+		t.Fatalf("unable to create wallet: %v", err)
+	}
+	w.chainClient = &mockChainClient{}
+
+	if w.Manager.WatchOnly() {
+		t.Fatal("blank wallet is watching-only")
+	}
+	accounts, err := w.Accounts(waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatalf("unable to list accounts: %v", err)
+	}
+	if len(accounts.Accounts) != 1 ||
+		accounts.Accounts[0].AccountNumber != waddrmgr.ImportedAddrAccount {
+
+		t.Fatalf("expected only the imported account, got %v",
+			accounts.Accounts)
+	}
+	balances, err := w.AccountBalances(waddrmgr.KeyScopeBIP0044, 0)
+	if err != nil {
+		t.Fatalf("unable to fetch account balances: %v", err)
+	}
+	if len(balances) != 1 ||
+		balances[0].AccountNumber != waddrmgr.ImportedAddrAccount {
+
+		t.Fatalf("expected only the imported account balance, got %v",
+			balances)
+	}
+	if _, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0044); err == nil {
+		t.Fatal("blank wallet derived an address")
+	}
+	if err := w.SetLookaheadWindow(5); err != nil {
+		t.Fatalf("unable to set lookahead window: %v", err)
+	}
+
+	// The birthday block is set once the wallet syncs with the chain,
+	// which is required to import keys.
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return w.Manager.SetBirthdayBlock(ns, waddrmgr.BlockStamp{
+			Hash:      *chaincfg.TestNet3Params.GenesisHash,
+			Timestamp: chaincfg.TestNet3Params.GenesisBlock.Header.Timestamp,
+		}, true)
+	})
+	if err != nil {
+		t.Fatalf("unable to set birthday block: %v", err)
+	}
+
+	if err := w.Unlock(privPass, nil); err != nil {
+		t.Fatalf("unable to unlock wallet: %v", err)
+	}
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wif, err := btcutil.NewWIF(privKey, &chaincfg.TestNet3Params, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := w.ImportPrivateKey(waddrmgr.KeyScopeBIP0044, wif, nil, false)
+	if err != nil {
+		t.Fatalf("unable to import private key: %v", err)
+	}
+
+	if err := loader.UnloadWallet(); err != nil {
+		t.Fatalf("unable to unload wallet: %v", err)
+	}
+	w, err = loader.OpenExistingWallet(pubPass, false)
+	if err != nil {
+		t.Fatalf("unable to open wallet: %v", err)
+	}
+	defer loader.UnloadWallet()
+
+	if err := w.Unlock(privPass, nil); err != nil {
+		t.Fatalf("unable to unlock wallet: %v", err)
+	}
+	decoded, err := btcutil.DecodeAddress(addr, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := w.DumpWIFPrivateKey(decoded)
+	if err != nil {
+		t.Fatalf("unable to dump imported key: %v", err)
+	}
+	if imported != wif.String() {
+		t.Fatalf("dumped key %v, expected %v", imported, wif)
+	}
+}
@@ -16,6 +16,11 @@ import (
 // requests it.
 var log btclog.Logger
 
+// auditLog is a logger recording changes of the spend policies of accounts
+// and sends refused by them.  It is separate from log so that its entries may
+// be kept regardless of the level of other wallet logging.
+var auditLog btclog.Logger
+
 // The default amount of logging is none.
 func init() {
 	DisableLog()
@@ -25,6 +30,7 @@ func init() {
 // by default until either UseLogger or SetLogWriter are called.
 func DisableLog() {
 	UseLogger(btclog.Disabled)
+	UseAuditLogger(btclog.Disabled)
 }
 
 // UseLogger uses a specified Logger to output package logging info.
@@ -38,6 +44,11 @@ func UseLogger(logger btclog.Logger) {
 	wtxmgr.UseLogger(logger)
 }
 
+// UseAuditLogger uses a specified Logger to output audit log entries.
+func UseAuditLogger(logger btclog.Logger) {
+	auditLog = logger
+}
+
 // pickNoun returns the singular or plural form of a noun depending
 // on the count n.
 func pickNoun(n int, singular, plural string) string {
@@ -0,0 +1,238 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// DefaultLookaheadWindow is the suggested number of addresses past the last
+// address handed out on each branch of an account which are watched for
+// payments.  This matches the address gap limit of BIP0044.
+const DefaultLookaheadWindow = 20
+
+// lookaheadAddr describes an address derived within the lookahead window of
+// an account which has not yet been handed out.
+type lookaheadAddr struct {
+	addr    btcutil.Address
+	scope   waddrmgr.KeyScope
+	account uint32
+	branch  uint32
+	index   uint32
+}
+
+// LookaheadWindow returns the number of addresses past the last address handed
+// out on each branch of an account which are watched for payments.
+func (w *Wallet) LookaheadWindow() uint32 {
+	w.lookaheadMtx.Lock()
+	defer w.lookaheadMtx.Unlock()
+
+	return w.lookaheadWindow
+}
+
+// SetLookaheadWindow sets the number of addresses past the last address handed
+// out on each branch of an account which are watched for payments.  Payments
+// to addresses within the window are detected, and the account's addresses
+// are extended through the paid address.  A window of zero disables the
+// lookahead.  Wallets are opened with the lookahead disabled, and btcwallet
+// sets the window to DefaultLookaheadWindow unless configured otherwise.
+func (w *Wallet) SetLookaheadWindow(window uint32) error {
+	w.lookaheadMtx.Lock()
+	w.lookaheadWindow = window
+	w.lookaheadMtx.Unlock()
+
+	var (
+		lookahead map[string]lookaheadAddr
+		newAddrs  []btcutil.Address
+	)
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		var err error
+		lookahead, newAddrs, err = w.deriveLookahead(addrmgrNs)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	w.setLookahead(lookahead)
+	return w.notifyLookahead(newAddrs)
+}
+
+// notifyLookahead requests notifications for payments to the passed lookahead
+// addresses when the wallet is associated with a chain server.
+func (w *Wallet) notifyLookahead(addrs []btcutil.Address) error {
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	w.chainClientLock.Lock()
+	chainClient := w.chainClient
+	w.chainClientLock.Unlock()
+	if chainClient == nil {
+		return nil
+	}
+
+	return chainClient.NotifyReceived(addrs)
+}
+
+// updateLookahead derives the lookahead window of every account from the
+// addresses handed out within the database transaction of addrmgrNs.  Once the
+// transaction is committed, the window replaces the current one and
+// notifications are requested for the addresses which entered it.  Nothing
+// changes if the transaction is rolled back.
+func (w *Wallet) updateLookahead(addrmgrNs walletdb.ReadWriteBucket) error {
+	lookahead, newAddrs, err := w.deriveLookahead(addrmgrNs)
+	if err != nil {
+		return err
+	}
+
+	addrmgrNs.Tx().OnCommit(func() {
+		w.setLookahead(lookahead)
+		if err := w.notifyLookahead(newAddrs); err != nil {
+			log.Errorf("Unable to request notifications for "+
+				"lookahead addresses: %v", err)
+		}
+	})
+	return nil
+}
+
+// setLookahead replaces the current lookahead window.
+func (w *Wallet) setLookahead(lookahead map[string]lookaheadAddr) {
+	w.lookaheadMtx.Lock()
+	w.lookaheadAddrs = lookahead
+	w.lookaheadMtx.Unlock()
+}
+
+// deriveLookahead derives the lookahead window of every account, returning the
+// window and the addresses of it which are not part of the current window.
+// The current window is left unchanged.
+func (w *Wallet) deriveLookahead(addrmgrNs walletdb.ReadBucket) (
+	map[string]lookaheadAddr, []btcutil.Address, error) {
+
+	w.lookaheadMtx.Lock()
+	defer w.lookaheadMtx.Unlock()
+
+	lookahead := make(map[string]lookaheadAddr)
+	var newAddrs []btcutil.Address
+	if w.lookaheadWindow == 0 {
+		return lookahead, nil, nil
+	}
+
+	for _, scopedMgr := range w.Manager.ActiveScopedKeyManagers() {
+		lastAccount, err := scopedMgr.LastAccount(addrmgrNs)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The last account wraps around to zero accounts in scopes
+		// holding only the imported account.
+		for account := uint32(0); account < lastAccount+1; account++ {
+			props, err := scopedMgr.AccountProperties(
+				addrmgrNs, account,
+			)
+			switch {
+			// Raw accounts may leave gaps in the account numbers.
+			case waddrmgr.IsError(err, waddrmgr.ErrAccountNotFound):
+				continue
+			case err != nil:
+				return nil, nil, err
+			}
+
+			branches := []struct {
+				branch uint32
+				next   uint32
+			}{
+				{waddrmgr.ExternalBranch, props.ExternalKeyCount},
+				{waddrmgr.InternalBranch, props.InternalKeyCount},
+			}
+			for _, b := range branches {
+				for i := uint32(0); i < w.lookaheadWindow; i++ {
+					index := b.next + i
+					addr, err := scopedMgr.DeriveFromKeyPath(
+						addrmgrNs, waddrmgr.DerivationPath{
+							InternalAccount: account,
+							Account:         account,
+							Branch:          b.branch,
+							Index:           index,
+						},
+					)
+					switch {
+					case err == hdkeychain.ErrInvalidChild:
+						continue
+					case err != nil:
+						return nil, nil, err
+					}
+
+					key := addr.Address().EncodeAddress()
+					lookahead[key] = lookaheadAddr{
+						addr:    addr.Address(),
+						scope:   scopedMgr.Scope(),
+						account: account,
+						branch:  b.branch,
+						index:   index,
+					}
+					if _, ok := w.lookaheadAddrs[key]; !ok {
+						newAddrs = append(newAddrs, addr.Address())
+					}
+				}
+			}
+		}
+	}
+
+	return lookahead, newAddrs, nil
+}
+
+// lookaheadAddresses returns every address of the current lookahead window.
+func (w *Wallet) lookaheadAddresses() []btcutil.Address {
+	w.lookaheadMtx.Lock()
+	defer w.lookaheadMtx.Unlock()
+
+	addrs := make([]btcutil.Address, 0, len(w.lookaheadAddrs))
+	for _, la := range w.lookaheadAddrs {
+		addrs = append(addrs, la.addr)
+	}
+	return addrs
+}
+
+// claimLookaheadAddr extends the addresses of the account which derived the
+// passed lookahead address through that address, so that it is stored by the
+// address manager.  It returns false if the address is not within the
+// lookahead window.
+func (w *Wallet) claimLookaheadAddr(addrmgrNs walletdb.ReadWriteBucket,
+	addr btcutil.Address) (bool, error) {
+
+	w.lookaheadMtx.Lock()
+	la, ok := w.lookaheadAddrs[addr.EncodeAddress()]
+	w.lookaheadMtx.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	scopedMgr, err := w.Manager.FetchScopedKeyManager(la.scope)
+	if err != nil {
+		return false, err
+	}
+	if la.branch == waddrmgr.InternalBranch {
+		err = scopedMgr.ExtendInternalAddresses(
+			addrmgrNs, la.account, la.index,
+		)
+	} else {
+		err = scopedMgr.ExtendExternalAddresses(
+			addrmgrNs, la.account, la.index,
+		)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	log.Infof("Detected payment to lookahead address %v, extended "+
+		"account %d of scope %v through index %d", addr, la.account,
+		la.scope, la.index)
+
+	return true, nil
+}
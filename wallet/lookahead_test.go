@@ -0,0 +1,96 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLookaheadWindow ensures that the lookahead window covers the addresses
+// following the last handed out address of each account branch, and that a
+// payment to a lookahead address extends the account through it once the
+// database transaction is committed.
+func TestLookaheadWindow(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	const window = 10
+	require.NoError(t, w.SetLookaheadWindow(window))
+	require.EqualValues(t, window, w.LookaheadWindow())
+
+	scopes := w.Manager.ActiveScopedKeyManagers()
+	require.Len(t, w.lookaheadAddresses(), len(scopes)*2*window)
+
+	scopedMgr, err := w.Manager.FetchScopedKeyManager(
+		waddrmgr.KeyScopeBIP0084,
+	)
+	require.NoError(t, err)
+
+	// Claim an address in the middle of the external branch of the
+	// default account, which should extend the branch through it and
+	// shift the window by the same amount.
+	const claimIndex = 5
+	var (
+		claimed bool
+		addr    waddrmgr.ManagedAddress
+	)
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+
+		var err error
+		addr, err = scopedMgr.DeriveFromKeyPath(
+			ns, waddrmgr.DerivationPath{
+				Branch: waddrmgr.ExternalBranch,
+				Index:  claimIndex,
+			},
+		)
+		if err != nil {
+			return err
+		}
+		claimed, err = w.claimLookaheadAddr(ns, addr.Address())
+		if err != nil {
+			return err
+		}
+
+		_, newAddrs, err := w.deriveLookahead(ns)
+		if err != nil {
+			return err
+		}
+		require.Len(t, newAddrs, claimIndex+1)
+		if err := w.updateLookahead(ns); err != nil {
+			return err
+		}
+
+		// The window must not move before the transaction is
+		// committed.
+		require.Contains(
+			t, w.lookaheadAddresses(), addr.Address(),
+		)
+
+		props, err := scopedMgr.AccountProperties(
+			ns, waddrmgr.DefaultAccountNum,
+		)
+		if err != nil {
+			return err
+		}
+		require.EqualValues(t, claimIndex+1, props.ExternalKeyCount)
+		require.EqualValues(t, 0, props.InternalKeyCount)
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, claimed)
+	require.Len(t, w.lookaheadAddresses(), len(scopes)*2*window)
+	require.NotContains(t, w.lookaheadAddresses(), addr.Address())
+
+	// Disabling the lookahead should clear the window.
+	require.NoError(t, w.SetLookaheadWindow(0))
+	require.Empty(t, w.lookaheadAddresses())
+}
@@ -38,7 +38,7 @@ func (m *mockChainClient) GetBlockHash(int64) (*chainhash.Hash, error) {
 
 func (m *mockChainClient) GetBlockHeader(*chainhash.Hash) (*wire.BlockHeader,
 	error) {
-	return nil, nil
+	return &wire.BlockHeader{}, nil
 }
 
 func (m *mockChainClient) IsCurrent() bool {
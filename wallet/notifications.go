@@ -31,12 +31,27 @@ import (
 // order wallet created them, but there is no guaranteed synchronization between
 // different clients.
 type NotificationServer struct {
-	transactions   []chan *TransactionNotifications
-	currentTxNtfn  *TransactionNotifications // coalesce this since wallet does not add mined txs together
-	spentness      map[uint32][]chan *SpentnessNotifications
-	accountClients []chan *AccountNotification
-	mu             sync.Mutex // Only protects registered client channels
-	wallet         *Wallet    // smells like hacks
+	transactions    []chan *TransactionNotifications
+	currentTxNtfn   *TransactionNotifications // coalesce this since wallet does not add mined txs together
+	detachedAccts   map[uint32]btcutil.Amount // accounts of txs rolled back by currentTxNtfn's detached blocks
+	spentness       map[uint32][]chan *SpentnessNotifications
+	accountClients  []chan *AccountNotification
+	healthClients   []chan *ChainHealth
+	expiryClients   []chan *ExpiredTransaction
+	conflictClients []chan *ConflictedTransaction
+	depositClients  []chan *LargeDeposit
+	backupClients   []chan *BackupFailure
+	lockClients     []chan *LockStateChange
+	rescanClients   []chan *RescanJobProgress
+	mu              sync.Mutex // Only protects registered client channels
+	wallet          *Wallet    // smells like hacks
+
+	// lockQueue holds the lock state changes which have not yet been
+	// delivered to every client, oldest first.  Changes are delivered by
+	// a goroutine running while the queue is not empty, so that locking
+	// and unlocking never waits on clients.
+	lockQueue   []*LockStateChange
+	lockQueueMu sync.Mutex
 }
 
 func newNotificationServer(wallet *Wallet) *NotificationServer {
@@ -85,11 +100,15 @@ func lookupOutputChain(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetail
 	var ma waddrmgr.ManagedAddress
 	if err == nil && len(addrs) > 0 {
 		ma, err = w.Manager.Address(addrmgrNs, addrs[0])
+		if err == nil {
+			_, account, err = w.Manager.AddrAccount(
+				addrmgrNs, addrs[0],
+			)
+		}
 	}
 	if err != nil {
 		log.Errorf("Cannot fetch account for wallet output: %v", err)
 	} else {
-		account = ma.InternalAccount()
 		internal = ma.Internal()
 	}
 	return
@@ -152,6 +171,12 @@ func makeTxSummary(dbtx walletdb.ReadTx, w *Wallet, details *wtxmgr.TxDetails) T
 }
 
 func totalBalances(dbtx walletdb.ReadTx, w *Wallet, m map[uint32]btcutil.Amount) error {
+	syncHeight := w.Manager.SyncedTo().Height
+	ok, err := w.cachedAccountTotals(dbtx, m, syncHeight)
+	if err != nil || ok {
+		return err
+	}
+
 	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
 	unspent, err := w.TxStore.UnspentOutputs(dbtx.ReadBucket(wtxmgrNamespaceKey))
 	if err != nil {
@@ -194,7 +219,38 @@ func relevantAccounts(_ *Wallet, m map[uint32]btcutil.Amount, txs []TransactionS
 	}
 }
 
-func (s *NotificationServer) notifyUnminedTransaction(dbtx walletdb.ReadTx, details *wtxmgr.TxDetails) {
+// sendTransactionNotifications sends a notification to every transaction
+// notifications client, along with the new total balances of the accounts of
+// bals.  It must be called once the database transaction recording the
+// notified changes has been committed, so that the balances include them.
+func (s *NotificationServer) sendTransactionNotifications(n *TransactionNotifications,
+	bals map[uint32]btcutil.Amount) {
+
+	err := walletdb.View(s.wallet.db, func(dbtx walletdb.ReadTx) error {
+		return totalBalances(dbtx, s.wallet, bals)
+	})
+	if err != nil {
+		log.Errorf("Cannot determine balances for relevant accounts: %v", err)
+		return
+	}
+	n.NewBalances = flattenBalanceMap(bals)
+
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for _, c := range s.transactions {
+		c <- n
+	}
+}
+
+// hasTransactionClients returns whether there are any transaction
+// notifications clients.
+func (s *NotificationServer) hasTransactionClients() bool {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	return len(s.transactions) != 0
+}
+
+func (s *NotificationServer) notifyUnminedTransaction(dbtx walletdb.ReadWriteTx, details *wtxmgr.TxDetails) {
 	// Sanity check: should not be currently coalescing a notification for
 	// mined transactions at the same time that an unmined tx is notified.
 	if s.currentTxNtfn != nil {
@@ -202,10 +258,7 @@ func (s *NotificationServer) notifyUnminedTransaction(dbtx walletdb.ReadTx, deta
 			details.Hash)
 	}
 
-	defer s.mu.Unlock()
-	s.mu.Lock()
-	clients := s.transactions
-	if len(clients) == 0 {
+	if !s.hasTransactionClients() {
 		return
 	}
 
@@ -217,26 +270,32 @@ func (s *NotificationServer) notifyUnminedTransaction(dbtx walletdb.ReadTx, deta
 	}
 	bals := make(map[uint32]btcutil.Amount)
 	relevantAccounts(s.wallet, bals, unminedTxs)
-	err = totalBalances(dbtx, s.wallet, bals)
-	if err != nil {
-		log.Errorf("Cannot determine balances for relevant accounts: %v", err)
-		return
-	}
 	n := &TransactionNotifications{
 		UnminedTransactions:      unminedTxs,
 		UnminedTransactionHashes: unminedHashes,
-		NewBalances:              flattenBalanceMap(bals),
-	}
-	for _, c := range clients {
-		c <- n
 	}
+	dbtx.OnCommit(func() {
+		s.sendTransactionNotifications(n, bals)
+	})
 }
 
-func (s *NotificationServer) notifyDetachedBlock(hash *chainhash.Hash) {
+// notifyDetachedBlock records the hash of a block removed from the main chain
+// and the transactions which were rolled back with it.  The new balances of
+// the accounts involved in the rolled back transactions are included with the
+// coalesced notification sent once the new best chain is attached.
+func (s *NotificationServer) notifyDetachedBlock(hash *chainhash.Hash, rolledBack []TransactionSummary) {
 	if s.currentTxNtfn == nil {
 		s.currentTxNtfn = &TransactionNotifications{}
 	}
 	s.currentTxNtfn.DetachedBlocks = append(s.currentTxNtfn.DetachedBlocks, hash)
+
+	if len(rolledBack) == 0 {
+		return
+	}
+	if s.detachedAccts == nil {
+		s.detachedAccts = make(map[uint32]btcutil.Amount)
+	}
+	relevantAccounts(s.wallet, s.detachedAccts, rolledBack)
 }
 
 func (s *NotificationServer) notifyMinedTransaction(dbtx walletdb.ReadTx, details *wtxmgr.TxDetails, block *wtxmgr.BlockMeta) {
@@ -257,7 +316,7 @@ func (s *NotificationServer) notifyMinedTransaction(dbtx walletdb.ReadTx, detail
 		append(txs, makeTxSummary(dbtx, s.wallet, details)) //  nolint:gocritic
 }
 
-func (s *NotificationServer) notifyAttachedBlock(dbtx walletdb.ReadTx, block *wtxmgr.BlockMeta) {
+func (s *NotificationServer) notifyAttachedBlock(dbtx walletdb.ReadWriteTx, block *wtxmgr.BlockMeta) {
 	if s.currentTxNtfn == nil {
 		s.currentTxNtfn = &TransactionNotifications{}
 	}
@@ -281,11 +340,9 @@ func (s *NotificationServer) notifyAttachedBlock(dbtx walletdb.ReadTx, block *wt
 		}
 	}
 
-	defer s.mu.Unlock()
-	s.mu.Lock()
-	clients := s.transactions
-	if len(clients) == 0 {
+	if !s.hasTransactionClients() {
 		s.currentTxNtfn = nil
+		s.detachedAccts = nil
 		return
 	}
 
@@ -304,21 +361,22 @@ func (s *NotificationServer) notifyAttachedBlock(dbtx walletdb.ReadTx, block *wt
 	}
 	s.currentTxNtfn.UnminedTransactionHashes = unminedHashes
 
+	// Accounts of transactions rolled back by the detached blocks are
+	// included since their balances changed even if none of their
+	// transactions were mined again in the attached blocks.
 	bals := make(map[uint32]btcutil.Amount)
+	for acct := range s.detachedAccts {
+		bals[acct] = 0
+	}
 	for _, b := range s.currentTxNtfn.AttachedBlocks {
 		relevantAccounts(s.wallet, bals, b.Transactions)
 	}
-	err = totalBalances(dbtx, s.wallet, bals)
-	if err != nil {
-		log.Errorf("Cannot determine balances for relevant accounts: %v", err)
-		return
-	}
-	s.currentTxNtfn.NewBalances = flattenBalanceMap(bals)
-
-	for _, c := range clients {
-		c <- s.currentTxNtfn
-	}
+	ntfn := s.currentTxNtfn
+	dbtx.OnCommit(func() {
+		s.sendTransactionNotifications(ntfn, bals)
+	})
 	s.currentTxNtfn = nil
+	s.detachedAccts = nil
 }
 
 // TransactionNotifications is a notification of changes to the wallet's
@@ -613,3 +671,471 @@ func (c *AccountNotificationsClient) Done() {
 		s.mu.Unlock()
 	}()
 }
+
+func (s *NotificationServer) notifyChainHealth(health ChainHealth) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for _, c := range s.healthClients {
+		n := health
+		c <- &n
+	}
+}
+
+// ChainHealthNotificationsClient receives ChainHealth notifications over the
+// channel C whenever the chain followed by the wallet is detected to be
+// stalled or on a minority fork, or such a problem is resolved.
+type ChainHealthNotificationsClient struct {
+	C      chan *ChainHealth
+	server *NotificationServer
+}
+
+// ChainHealthNotifications returns a client for receiving ChainHealth
+// notifications over a channel.  The channel is unbuffered.  When finished,
+// the client's Done method should be called to disassociate the client from
+// the server.
+func (s *NotificationServer) ChainHealthNotifications() ChainHealthNotificationsClient {
+	c := make(chan *ChainHealth)
+	s.mu.Lock()
+	s.healthClients = append(s.healthClients, c)
+	s.mu.Unlock()
+	return ChainHealthNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *ChainHealthNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.healthClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.healthClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+func (s *NotificationServer) notifyExpiredTransaction(tx *ExpiredTransaction) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for _, c := range s.expiryClients {
+		n := *tx
+		c <- &n
+	}
+}
+
+// ExpiredTransactionNotificationsClient receives ExpiredTransaction
+// notifications over the channel C when an unmined send of the wallet expires.
+type ExpiredTransactionNotificationsClient struct {
+	C      chan *ExpiredTransaction
+	server *NotificationServer
+}
+
+// ExpiredTransactionNotifications returns a client for receiving
+// ExpiredTransaction notifications over a channel.  The channel is unbuffered.
+// When finished, the client's Done method should be called to disassociate the
+// client from the server.
+func (s *NotificationServer) ExpiredTransactionNotifications() ExpiredTransactionNotificationsClient {
+	c := make(chan *ExpiredTransaction)
+	s.mu.Lock()
+	s.expiryClients = append(s.expiryClients, c)
+	s.mu.Unlock()
+	return ExpiredTransactionNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *ExpiredTransactionNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.expiryClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.expiryClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// ConflictedTransaction describes an unmined transaction of the wallet which
+// was removed for double spending an output also spent by a transaction mined
+// in a block or accepted to the mempool of the chain server.  Unmined
+// transactions spending outputs of the conflicted transaction are removed as
+// well.
+type ConflictedTransaction struct {
+	Hash            chainhash.Hash
+	ConflictingHash chainhash.Hash
+}
+
+func (s *NotificationServer) notifyConflictedTransaction(hash, conflictHash *chainhash.Hash) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for _, c := range s.conflictClients {
+		c <- &ConflictedTransaction{
+			Hash:            *hash,
+			ConflictingHash: *conflictHash,
+		}
+	}
+}
+
+// ConflictedTransactionNotificationsClient receives ConflictedTransaction
+// notifications over the channel C when an unmined transaction of the wallet
+// is removed for being double spent.
+type ConflictedTransactionNotificationsClient struct {
+	C      chan *ConflictedTransaction
+	server *NotificationServer
+}
+
+// ConflictedTransactionNotifications returns a client for receiving
+// ConflictedTransaction notifications over a channel.  The channel is
+// unbuffered.  When finished, the client's Done method should be called to
+// disassociate the client from the server.
+func (s *NotificationServer) ConflictedTransactionNotifications() ConflictedTransactionNotificationsClient {
+	c := make(chan *ConflictedTransaction)
+	s.mu.Lock()
+	s.conflictClients = append(s.conflictClients, c)
+	s.mu.Unlock()
+	return ConflictedTransactionNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *ConflictedTransactionNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.conflictClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.conflictClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+func (s *NotificationServer) notifyLargeDeposit(deposit *LargeDeposit) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for _, c := range s.depositClients {
+		n := *deposit
+		c <- &n
+	}
+}
+
+// LargeDepositNotificationsClient receives LargeDeposit notifications over the
+// channel C when a transaction paying an account at least its deposit alert
+// amount is first seen.
+type LargeDepositNotificationsClient struct {
+	C      chan *LargeDeposit
+	server *NotificationServer
+}
+
+// LargeDepositNotifications returns a client for receiving LargeDeposit
+// notifications over a channel.  The channel is unbuffered.  When finished,
+// the client's Done method should be called to disassociate the client from
+// the server.
+func (s *NotificationServer) LargeDepositNotifications() LargeDepositNotificationsClient {
+	c := make(chan *LargeDeposit)
+	s.mu.Lock()
+	s.depositClients = append(s.depositClients, c)
+	s.mu.Unlock()
+	return LargeDepositNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *LargeDepositNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.depositClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.depositClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+func (s *NotificationServer) notifyBackupFailure(failure *BackupFailure) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for _, c := range s.backupClients {
+		n := *failure
+		c <- &n
+	}
+}
+
+// BackupFailureNotificationsClient receives BackupFailure notifications over
+// the channel C when a scheduled backup of the wallet fails.
+type BackupFailureNotificationsClient struct {
+	C      chan *BackupFailure
+	server *NotificationServer
+}
+
+// BackupFailureNotifications returns a client for receiving BackupFailure
+// notifications over a channel.  The channel is unbuffered.  When finished,
+// the client's Done method should be called to disassociate the client from
+// the server.
+func (s *NotificationServer) BackupFailureNotifications() BackupFailureNotificationsClient {
+	c := make(chan *BackupFailure)
+	s.mu.Lock()
+	s.backupClients = append(s.backupClients, c)
+	s.mu.Unlock()
+	return BackupFailureNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *BackupFailureNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.backupClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.backupClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// LockStateChange describes a change of the lock state of the wallet and of
+// accounts protected by their own passphrase.  Locking or unlocking the wallet
+// together with every account is described by a single change.
+type LockStateChange struct {
+	// Locked is set when the wallet or accounts were locked, and unset
+	// when they were unlocked.
+	Locked bool
+
+	// Wallet is set when the lock state of the wallet changed.
+	Wallet bool
+
+	// Accounts are the accounts protected by their own passphrase whose
+	// lock state changed.
+	Accounts []waddrmgr.ScopedIndex
+}
+
+// notifyLockState queues a lock state change for delivery to the clients.
+// It does not block, so it may be called while the locks of the wallet and
+// accounts are held, which orders the changes as they were made.
+func (s *NotificationServer) notifyLockState(change *LockStateChange) {
+	s.lockQueueMu.Lock()
+	s.lockQueue = append(s.lockQueue, change)
+	deliver := len(s.lockQueue) == 1
+	s.lockQueueMu.Unlock()
+
+	if deliver {
+		go s.deliverLockStates()
+	}
+}
+
+// deliverLockStates delivers the queued lock state changes to the clients in
+// order, until the queue is empty.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *NotificationServer) deliverLockStates() {
+	s.lockQueueMu.Lock()
+	change := s.lockQueue[0]
+	s.lockQueueMu.Unlock()
+
+	for {
+		s.mu.Lock()
+		for _, c := range s.lockClients {
+			n := *change
+			c <- &n
+		}
+		s.mu.Unlock()
+
+		s.lockQueueMu.Lock()
+		s.lockQueue[0] = nil
+		s.lockQueue = s.lockQueue[1:]
+		if len(s.lockQueue) == 0 {
+			s.lockQueueMu.Unlock()
+			return
+		}
+		change = s.lockQueue[0]
+		s.lockQueueMu.Unlock()
+	}
+}
+
+// LockStateNotificationsClient receives LockStateChange notifications over
+// the channel C when the wallet or accounts are locked or unlocked.
+type LockStateNotificationsClient struct {
+	C      chan *LockStateChange
+	server *NotificationServer
+}
+
+// LockStateNotifications returns a client for receiving LockStateChange
+// notifications over a channel.  The channel is unbuffered, but the wallet is
+// never blocked by a client which is slow to receive, as changes are queued
+// and delivered in order by a separate goroutine.  When finished,
+// the client's Done method should be called to disassociate the client from
+// the server.
+func (s *NotificationServer) LockStateNotifications() LockStateNotificationsClient {
+	c := make(chan *LockStateChange)
+	s.mu.Lock()
+	s.lockClients = append(s.lockClients, c)
+	s.mu.Unlock()
+	return LockStateNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *LockStateNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.lockClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.lockClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// RescanJobProgress describes the progress of a rescan job, identified by the
+// ID assigned when it was submitted.  Done is set once the job completed,
+// successfully or not, and Err is the error of a failed or cancelled job.
+type RescanJobProgress struct {
+	ID uint64
+
+	// Height is the height of the last block rescanned, and Percent the
+	// progress of the rescan towards the best block when it started.  Both
+	// are unset when the job failed or was cancelled while queued.
+	Height  int32
+	Percent float64
+
+	Done bool
+	Err  error
+}
+
+func (s *NotificationServer) notifyRescanProgress(n *RescanJobProgress) {
+	defer s.mu.Unlock()
+	s.mu.Lock()
+	for _, c := range s.rescanClients {
+		progress := *n
+		c <- &progress
+	}
+}
+
+// RescanProgressNotificationsClient receives RescanJobProgress notifications
+// over the channel C as rescan jobs progress and complete.
+type RescanProgressNotificationsClient struct {
+	C      chan *RescanJobProgress
+	server *NotificationServer
+}
+
+// RescanProgressNotifications returns a client for receiving RescanJobProgress
+// notifications over a channel.  The channel is unbuffered.  When finished,
+// the client's Done method should be called to disassociate the client from
+// the server.
+func (s *NotificationServer) RescanProgressNotifications() RescanProgressNotificationsClient {
+	c := make(chan *RescanJobProgress)
+	s.mu.Lock()
+	s.rescanClients = append(s.rescanClients, c)
+	s.mu.Unlock()
+	return RescanProgressNotificationsClient{
+		C:      c,
+		server: s,
+	}
+}
+
+// Done deregisters the client from the server and drains any remaining
+// messages.  It must be called exactly once when the client is finished
+// receiving notifications.
+func (c *RescanProgressNotificationsClient) Done() {
+	go func() {
+		for range c.C {
+		}
+	}()
+	go func() {
+		s := c.server
+		s.mu.Lock()
+		clients := s.rescanClients
+		for i, ch := range clients {
+			if c.C == ch {
+				clients[i] = clients[len(clients)-1]
+				s.rescanClients = clients[:len(clients)-1]
+				close(ch)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	// DefaultPriceFeedInterval is the suggested interval between fetches
+	// of the exchange rate by the price feed.
+	DefaultPriceFeedInterval = 5 * time.Minute
+
+	// priceFeedCheckInterval is the interval at which the price feed
+	// checks whether a fetch of the exchange rate is due.
+	priceFeedCheckInterval = 10 * time.Second
+
+	// priceFeedRetryInterval is the longest time waited before retrying a
+	// failed fetch of the exchange rate.
+	priceFeedRetryInterval = time.Minute
+)
+
+// FiatRate is the price of one bitcoin in a fiat currency at a time.
+type FiatRate struct {
+	Currency string
+	Rate     float64
+	Time     time.Time
+}
+
+// Value returns the value of an amount in the fiat currency of the rate,
+// rounded to two decimal places.
+func (r *FiatRate) Value(amount btcutil.Amount) float64 {
+	return math.Round(amount.ToBTC()*r.Rate*100) / 100
+}
+
+// PriceSource provides the price of bitcoin in a fiat currency, which is used
+// to value the balances of the wallet.  Implementations may fetch prices from
+// exchanges, price aggregators or local services, such as the HTTP tickers of
+// package internal/pricefeed.
+type PriceSource interface {
+	// FetchRate returns the current price of one bitcoin.
+	FetchRate() (*FiatRate, error)
+}
+
+// PriceFeedConfig configures the fetches of the exchange rate used to value the
+// balances of the wallet.
+type PriceFeedConfig struct {
+	// Source provides the exchange rate.
+	Source PriceSource
+
+	// Interval is the duration between fetches of the exchange rate.
+	Interval time.Duration
+}
+
+// SetPriceFeed configures the price feed of the wallet.  A nil config disables
+// the price feed and forgets the last fetched rate.  The first rate is fetched
+// shortly after the wallet starts or the config is set.
+func (w *Wallet) SetPriceFeed(cfg *PriceFeedConfig) {
+	w.priceMtx.Lock()
+	w.priceFeed = cfg
+	w.fiatRate = nil
+	w.nextPriceFetch = time.Time{}
+	w.priceMtx.Unlock()
+}
+
+// FiatRate returns the last exchange rate fetched by the price feed, or nil when
+// the price feed is disabled or has not fetched a rate yet.  The time of the
+// rate should be checked by callers, as the rate is not refreshed while the
+// price source fails.
+func (w *Wallet) FiatRate() *FiatRate {
+	w.priceMtx.Lock()
+	defer w.priceMtx.Unlock()
+
+	return w.fiatRate
+}
+
+// fetchPrice fetches the exchange rate when a fetch is due, retrying failed
+// fetches after the shorter of the fetch interval and priceFeedRetryInterval.
+func (w *Wallet) fetchPrice(now time.Time) {
+	w.priceMtx.Lock()
+	cfg := w.priceFeed
+	due := cfg != nil && !now.Before(w.nextPriceFetch)
+	w.priceMtx.Unlock()
+	if !due {
+		return
+	}
+
+	rate, err := cfg.Source.FetchRate()
+	if err == nil && rate == nil {
+		err = errors.New("price source returned no rate")
+	}
+
+	w.priceMtx.Lock()
+	defer w.priceMtx.Unlock()
+
+	// The config may have been replaced during the fetch, in which case
+	// the new config is fetched from next.
+	if w.priceFeed != cfg {
+		return
+	}
+	if err != nil {
+		log.Errorf("Unable to fetch exchange rate: %v", err)
+		retry := cfg.Interval
+		if retry > priceFeedRetryInterval {
+			retry = priceFeedRetryInterval
+		}
+		w.nextPriceFetch = now.Add(retry)
+		return
+	}
+	log.Debugf("Fetched exchange rate of %v %s", rate.Rate, rate.Currency)
+	w.fiatRate = rate
+	w.nextPriceFetch = now.Add(cfg.Interval)
+}
+
+// priceFeedPoller fetches the exchange rate of the price feed each time it is
+// due until the wallet is stopped.
+//
+// NOTE: This must be run as a goroutine.
+func (w *Wallet) priceFeedPoller() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(priceFeedCheckInterval)
+	defer ticker.Stop()
+
+	quit := w.quitChan()
+	for {
+		select {
+		case <-ticker.C:
+			w.fetchPrice(time.Now())
+		case <-quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testPriceSource is a PriceSource returning a fixed rate or error.
+type testPriceSource struct {
+	rate    *FiatRate
+	err     error
+	fetches int
+}
+
+func (s *testPriceSource) FetchRate() (*FiatRate, error) {
+	s.fetches++
+	return s.rate, s.err
+}
+
+// TestPriceFeed ensures that the price feed fetches the exchange rate each
+// interval, retries failed fetches sooner, and keeps the last fetched rate.
+func TestPriceFeed(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	require.Nil(t, w.FiatRate())
+
+	rate := &FiatRate{Currency: "EUR", Rate: 40000, Time: time.Now()}
+	src := &testPriceSource{rate: rate}
+	w.SetPriceFeed(&PriceFeedConfig{Source: src, Interval: time.Hour})
+
+	// The first rate is fetched immediately, and the next only once the
+	// interval has passed.
+	now := time.Now()
+	w.fetchPrice(now)
+	require.Equal(t, rate, w.FiatRate())
+	w.fetchPrice(now.Add(time.Hour - time.Second))
+	require.Equal(t, 1, src.fetches)
+
+	// A failed fetch keeps the last rate, and is retried within
+	// priceFeedRetryInterval.
+	src.err = errors.New("ticker unavailable")
+	now = now.Add(time.Hour)
+	w.fetchPrice(now)
+	require.Equal(t, 2, src.fetches)
+	require.Equal(t, rate, w.FiatRate())
+	w.fetchPrice(now.Add(priceFeedRetryInterval))
+	require.Equal(t, 3, src.fetches)
+
+	require.Equal(t, 5000.0, rate.Value(btcutil.Amount(125e5)))
+	require.Equal(t, 0.01, rate.Value(btcutil.Amount(25)))
+
+	// Disabling the price feed forgets the rate.
+	w.SetPriceFeed(nil)
+	require.Nil(t, w.FiatRate())
+	w.fetchPrice(now.Add(2 * time.Hour))
+	require.Equal(t, 3, src.fetches)
+}
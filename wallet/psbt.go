@@ -255,6 +255,10 @@ func (w *Wallet) FundPsbt(packet *psbt.Packet, keyScope *waddrmgr.KeyScope,
 // will fail. If no error is returned, the PSBT is ready to be extracted and the
 // final TX within to be broadcast.
 //
+// While spends require confirmation, the use of the keys of the wallet must
+// first be authorized with AuthorizeKeyUse.  A SpendPolicyError is returned
+// when the transaction violates the spend policy of an account it spends from.
+//
 // NOTE: This method does NOT publish the transaction after it's been finalized
 // successfully.
 func (w *Wallet) FinalizePsbt(keyScope *waddrmgr.KeyScope, account uint32,
@@ -266,12 +270,39 @@ func (w *Wallet) FinalizePsbt(keyScope *waddrmgr.KeyScope, account uint32,
 	if err != nil {
 		return err
 	}
+	if err := w.useKeys(); err != nil {
+		return err
+	}
 
 	// Go through each input that doesn't have final witness data attached
 	// to it already and try to sign it. We do expect that we're the last
 	// ones to sign. If there is any input without witness data that we
 	// cannot sign because it's not our UTXO, this will be a hard failure.
 	tx := packet.UnsignedTx
+
+	// The transaction must not violate the spend policies of the accounts
+	// whose outputs are signed.
+	prevScripts := make([][]byte, len(tx.TxIn))
+	for idx, txIn := range tx.TxIn {
+		if len(packet.Inputs[idx].FinalScriptWitness) > 0 {
+			continue
+		}
+		_, txOut, _, _, err := w.FetchInputInfo(&txIn.PreviousOutPoint)
+		if err == nil {
+			prevScripts[idx] = txOut.PkScript
+		}
+	}
+	var fee btcutil.Amount
+	if totalInput, err := psbt.SumUtxoInputValues(packet); err == nil {
+		fee = txFee(tx, btcutil.Amount(totalInput))
+	}
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		return w.reserveTxSpend(dbtx, tx, prevScripts, fee)
+	})
+	if err != nil {
+		return err
+	}
+
 	sigHashes := txscript.NewTxSigHashes(tx)
 	for idx, txIn := range tx.TxIn {
 		in := packet.Inputs[idx]
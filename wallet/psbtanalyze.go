@@ -0,0 +1,165 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// PsbtInputAnalysis describes an input of a PSBT packet, as analyzed by
+// AnalyzePsbt.
+type PsbtInputAnalysis struct {
+	// HasUtxo reports whether the packet includes the output spent by the
+	// input, and Utxo is that output.
+	HasUtxo bool
+	Utxo    *wire.TxOut
+
+	// Final reports whether the input scripts of the input are final.
+	Final bool
+
+	// Finalizable reports whether the partial signatures of the input
+	// suffice to finalize it.
+	Finalizable bool
+
+	// IsMine reports whether the spent output pays to an address of the
+	// wallet, and CanSign whether the wallet holds the private key of the
+	// address, itself or through a signer provider.
+	IsMine  bool
+	CanSign bool
+}
+
+// AnalyzePsbt describes each input of a PSBT packet, reporting which inputs
+// spend outputs of the wallet and which the wallet can sign.  Only the
+// packet itself is used to find the spent outputs, so that packets can be
+// analyzed by wallets which are not synced, such as those of offline signing
+// setups.  The wallet need not be unlocked.
+func (w *Wallet) AnalyzePsbt(packet *psbt.Packet) ([]PsbtInputAnalysis, error) {
+	if len(packet.Inputs) != len(packet.UnsignedTx.TxIn) {
+		return nil, fmt.Errorf("TX input length doesn't match PSBT " +
+			"input length")
+	}
+
+	// Inputs are finalized on a copy of the packet to learn whether they
+	// are finalizable.
+	var raw bytes.Buffer
+	if err := packet.Serialize(&raw); err != nil {
+		return nil, err
+	}
+
+	analysis := make([]PsbtInputAnalysis, len(packet.Inputs))
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		secrets := secretSource{w.Manager, addrmgrNs, w.signerProvider}
+
+		for idx, txIn := range packet.UnsignedTx.TxIn {
+			in := &packet.Inputs[idx]
+			a := &analysis[idx]
+
+			a.Final = len(in.FinalScriptSig) > 0 ||
+				len(in.FinalScriptWitness) > 0
+			if !a.Final && len(in.PartialSigs) > 0 {
+				p, err := psbt.NewFromRawBytes(
+					bytes.NewReader(raw.Bytes()), false,
+				)
+				if err != nil {
+					return err
+				}
+				a.Finalizable, _ = psbt.MaybeFinalize(p, idx)
+			}
+
+			a.Utxo = psbtInputUtxo(in, txIn.PreviousOutPoint)
+			if a.Utxo == nil {
+				continue
+			}
+			a.HasUtxo = true
+
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				a.Utxo.PkScript, w.chainParams,
+			)
+			if err != nil || len(addrs) != 1 {
+				continue
+			}
+			a.IsMine, a.CanSign, err = w.addressSigner(
+				addrmgrNs, secrets, addrs[0],
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return analysis, nil
+}
+
+// psbtInputUtxo returns the output spent by an input of a PSBT packet, or nil
+// if the packet does not include it.
+func psbtInputUtxo(in *psbt.PInput, prevOut wire.OutPoint) *wire.TxOut {
+	switch {
+	case in.WitnessUtxo != nil:
+		return in.WitnessUtxo
+
+	case in.NonWitnessUtxo != nil:
+		if in.NonWitnessUtxo.TxHash() != prevOut.Hash ||
+			prevOut.Index >= uint32(len(in.NonWitnessUtxo.TxOut)) {
+
+			return nil
+		}
+		return in.NonWitnessUtxo.TxOut[prevOut.Index]
+	}
+	return nil
+}
+
+// addressSigner reports whether an address belongs to the wallet and whether
+// the wallet can sign for it.  As when creating transactions, addresses of
+// watch-only accounts and of the imported account are only signed for when
+// their keys are held by signer providers.
+func (w *Wallet) addressSigner(addrmgrNs walletdb.ReadBucket,
+	secrets secretSource, addr btcutil.Address) (bool, bool, error) {
+
+	ma, err := w.Manager.Address(addrmgrNs, addr)
+	if waddrmgr.IsError(err, waddrmgr.ErrAddressNotFound) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	var (
+		keyScope waddrmgr.KeyScope
+		account  uint32 = waddrmgr.ImportedAddrAccount
+	)
+	if mpka, ok := ma.(waddrmgr.ManagedPubKeyAddress); ok {
+		scope, path, ok := mpka.DerivationInfo()
+		if ok {
+			keyScope, account = scope, path.InternalAccount
+		}
+	}
+	watchOnly, err := w.Manager.IsWatchOnlyAccount(
+		addrmgrNs, keyScope, account,
+	)
+	if err != nil {
+		return false, false, err
+	}
+	if !watchOnly {
+		return true, true, nil
+	}
+
+	pubKey, err := secrets.ExternalKey(addr)
+	if err != nil {
+		return false, false, err
+	}
+	return true, pubKey != nil, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/psbt"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnalyzePsbt ensures that the inputs of a PSBT spending outputs of the
+// wallet are reported as signable, using only the outputs included in the
+// packet.
+func TestAnalyzePsbt(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	prevTx := &wire.MsgTx{
+		TxIn:  []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{wire.NewTxOut(100000, pkScript)},
+	}
+	packet, err := psbt.New(
+		[]*wire.OutPoint{
+			{Hash: prevTx.TxHash()},
+			{Hash: chainhash.Hash{1}},
+			{Hash: chainhash.Hash{2}},
+			{Hash: chainhash.Hash{3}},
+		},
+		[]*wire.TxOut{wire.NewTxOut(50000, testScriptP2WKH)},
+		2, 0, []uint32{0, 0, 0, 0},
+	)
+	require.NoError(t, err)
+
+	// The first input spends an output of the wallet, unknown to the
+	// wallet as it was never synced, and the second an output of another
+	// wallet.  The packet lacks the output spent by the third input, and
+	// the fourth input is final.
+	packet.Inputs[0].NonWitnessUtxo = prevTx
+	packet.Inputs[1].WitnessUtxo = wire.NewTxOut(100000, testScriptP2WKH)
+	packet.Inputs[3].WitnessUtxo = wire.NewTxOut(100000, testScriptP2WKH)
+	packet.Inputs[3].FinalScriptWitness = []byte{0x00}
+
+	analysis, err := w.AnalyzePsbt(packet)
+	require.NoError(t, err)
+	require.Len(t, analysis, 4)
+
+	require.True(t, analysis[0].HasUtxo)
+	require.Equal(t, prevTx.TxOut[0], analysis[0].Utxo)
+	require.True(t, analysis[0].IsMine)
+	require.True(t, analysis[0].CanSign)
+	require.False(t, analysis[0].Final)
+
+	require.True(t, analysis[1].HasUtxo)
+	require.False(t, analysis[1].IsMine)
+	require.False(t, analysis[1].CanSign)
+
+	require.False(t, analysis[2].HasUtxo)
+	require.False(t, analysis[2].IsMine)
+
+	require.True(t, analysis[3].Final)
+	require.False(t, analysis[3].Finalizable)
+
+	// Outputs of other transactions don't describe the spent output.
+	packet.Inputs[0].NonWitnessUtxo = &wire.MsgTx{
+		TxOut: prevTx.TxOut,
+	}
+	analysis, err = w.AnalyzePsbt(packet)
+	require.NoError(t, err)
+	require.False(t, analysis[0].HasUtxo)
+	require.False(t, analysis[0].IsMine)
+}
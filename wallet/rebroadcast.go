@@ -0,0 +1,137 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+const (
+	// rebroadcastInterval is the interval at which unmined transactions
+	// are checked for rebroadcast.
+	rebroadcastInterval = time.Minute
+
+	// minRebroadcastBackoff is the delay before an unmined transaction is
+	// first rebroadcast.  The delay doubles after every rebroadcast of
+	// the transaction, up to maxRebroadcastBackoff.
+	minRebroadcastBackoff = 10 * time.Minute
+
+	// maxRebroadcastBackoff is the longest delay between rebroadcasts of
+	// an unmined transaction.
+	maxRebroadcastBackoff = 12 * time.Hour
+)
+
+// rebroadcastState records when an unmined transaction is next due to be
+// rebroadcast.
+type rebroadcastState struct {
+	next    time.Time
+	backoff time.Duration
+}
+
+// rebroadcastSchedule tracks the rebroadcast backoff of each unmined
+// transaction.
+type rebroadcastSchedule map[chainhash.Hash]*rebroadcastState
+
+// due returns the unmined transactions which are due to be rebroadcast at
+// now, recording the next rebroadcast of each.  Transactions seen for the
+// first time are scheduled rather than rebroadcast, and transactions which
+// are no longer unmined are forgotten.
+func (s rebroadcastSchedule) due(unmined []*wire.MsgTx,
+	now time.Time) []*wire.MsgTx {
+
+	current := make(map[chainhash.Hash]struct{}, len(unmined))
+	var due []*wire.MsgTx
+	for _, tx := range unmined {
+		hash := tx.TxHash()
+		current[hash] = struct{}{}
+
+		state, ok := s[hash]
+		if !ok {
+			s[hash] = &rebroadcastState{
+				next:    now.Add(minRebroadcastBackoff),
+				backoff: minRebroadcastBackoff,
+			}
+			continue
+		}
+		if now.Before(state.next) {
+			continue
+		}
+
+		state.backoff *= 2
+		if state.backoff > maxRebroadcastBackoff {
+			state.backoff = maxRebroadcastBackoff
+		}
+		state.next = now.Add(state.backoff)
+		due = append(due, tx)
+	}
+
+	// Transactions which were mined or removed are no longer
+	// rebroadcast.
+	for hash := range s {
+		if _, ok := current[hash]; !ok {
+			delete(s, hash)
+		}
+	}
+
+	return due
+}
+
+// rebroadcaster periodically rebroadcasts the unmined transactions of the
+// wallet, backing off exponentially for each transaction until it is mined.
+//
+// NOTE: This MUST be run as a goroutine.
+func (w *Wallet) rebroadcaster() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(rebroadcastInterval)
+	defer ticker.Stop()
+
+	schedule := make(rebroadcastSchedule)
+
+	quit := w.quitChan()
+	for {
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+
+		// Transactions are only rebroadcast once the wallet has
+		// caught up with the chain server, as an unsynced wallet may
+		// not yet know they were mined.
+		if !w.ChainSynced() {
+			continue
+		}
+
+		var unmined []*wire.MsgTx
+		err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+			txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+			var err error
+			unmined, err = w.TxStore.UnminedTxs(txmgrNs)
+			return err
+		})
+		if err != nil {
+			log.Errorf("Unable to retrieve unconfirmed transactions "+
+				"to rebroadcast: %v", err)
+			continue
+		}
+
+		for _, tx := range schedule.due(unmined, w.Now()) {
+			txHash, err := w.publishTransaction(tx)
+			if err != nil {
+				log.Debugf("Unable to rebroadcast transaction "+
+					"%v: %v", tx.TxHash(), err)
+				continue
+			}
+
+			log.Debugf("Rebroadcast unconfirmed transaction %v",
+				txHash)
+		}
+	}
+}
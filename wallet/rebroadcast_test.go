@@ -0,0 +1,51 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRebroadcastSchedule ensures that unmined transactions are rebroadcast
+// with an exponential backoff and forgotten once they are no longer unmined.
+func TestRebroadcastSchedule(t *testing.T) {
+	t.Parallel()
+
+	tx1 := &wire.MsgTx{Version: 1}
+	tx2 := &wire.MsgTx{Version: 2}
+	unmined := []*wire.MsgTx{tx1, tx2}
+
+	schedule := make(rebroadcastSchedule)
+	now := time.Unix(1600000000, 0)
+
+	// Newly seen transactions are scheduled but not rebroadcast.
+	require.Empty(t, schedule.due(unmined, now))
+	require.Empty(t, schedule.due(unmined, now.Add(time.Minute)))
+
+	// Each transaction is rebroadcast after the initial backoff, and then
+	// after twice the previous backoff.
+	now = now.Add(minRebroadcastBackoff)
+	require.Equal(t, unmined, schedule.due(unmined, now))
+	require.Empty(t, schedule.due(unmined, now.Add(minRebroadcastBackoff)))
+
+	now = now.Add(2 * minRebroadcastBackoff)
+	require.Equal(t, unmined, schedule.due(unmined, now))
+
+	// The backoff never exceeds the maximum.
+	for i := 0; i < 10; i++ {
+		now = now.Add(maxRebroadcastBackoff)
+		require.Equal(t, unmined, schedule.due(unmined, now))
+	}
+	require.Equal(t, maxRebroadcastBackoff, schedule[tx1.TxHash()].backoff)
+
+	// A mined transaction is forgotten and no longer rebroadcast.
+	now = now.Add(maxRebroadcastBackoff)
+	require.Equal(t, []*wire.MsgTx{tx2}, schedule.due(unmined[1:], now))
+	require.Len(t, schedule, 1)
+}
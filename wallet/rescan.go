@@ -5,11 +5,15 @@
 package wallet
 
 import (
+	"errors"
+	"sync"
+
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/chain"
 	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 )
 
@@ -27,6 +31,27 @@ type RescanFinishedMsg struct {
 	Notification *chain.RescanFinished
 }
 
+var (
+	// ErrRescanCancelled is the error of a rescan job which was cancelled
+	// before it completed.
+	ErrRescanCancelled = errors.New("rescan cancelled")
+
+	// ErrUnknownRescan is returned when a rescan job is cancelled which is
+	// neither queued nor running.
+	ErrUnknownRescan = errors.New("unknown rescan job")
+
+	// ErrRescanRunning is returned when a running rescan job is cancelled.
+	// The chain backends rescan to the best block in a single request
+	// which can not be interrupted, so only queued jobs can be cancelled.
+	ErrRescanRunning = errors.New("rescan job is running and can not be " +
+		"cancelled")
+
+	// ErrInvalidBlockRange is returned when a block range to rescan is
+	// not within the main chain, or its start is above its stop.
+	ErrInvalidBlockRange = errors.New("block range must be within the " +
+		"main chain and start must not exceed stop")
+)
+
 // RescanJob is a job to be processed by the RescanManager.  The job includes
 // a set of wallet addresses, a starting height to begin the rescan, and
 // outpoints spendable by the addresses thought to be unspent.  After the
@@ -37,7 +62,54 @@ type RescanJob struct {
 	Addrs       []btcutil.Address
 	OutPoints   map[wire.OutPoint]btcutil.Address
 	BlockStamp  waddrmgr.BlockStamp
-	err         chan error
+
+	// ID identifies the job in rescan progress notifications, and is
+	// assigned when the job is submitted.
+	ID uint64
+
+	err      chan error
+	finished bool
+	mtx      sync.Mutex
+}
+
+// finish sends the final error of the job on its error channel, unless the
+// job already finished, and returns whether the job finished now.
+func (job *RescanJob) finish(err error) bool {
+	job.mtx.Lock()
+	defer job.mtx.Unlock()
+	if job.finished {
+		return false
+	}
+	job.finished = true
+	job.err <- err
+	return true
+}
+
+// isFinished returns whether the final error of the job was sent.
+func (job *RescanJob) isFinished() bool {
+	job.mtx.Lock()
+	defer job.mtx.Unlock()
+	return job.finished
+}
+
+// RescanJobStatus describes a queued or running rescan job.
+type RescanJobStatus struct {
+	ID          uint64
+	Running     bool
+	Addresses   int
+	StartHeight int32
+
+	// Height is the height of the last block rescanned, and Percent the
+	// progress of the rescan towards the best block when it started.
+	// Both are only set for running jobs.
+	Height  int32
+	Percent float64
+}
+
+// cancelRescanRequest is a request to cancel the rescan job with the ID.
+type cancelRescanRequest struct {
+	id  uint64
+	err chan error
 }
 
 // rescanBatch is a collection of one or more RescanJobs that were merged
@@ -47,15 +119,25 @@ type rescanBatch struct {
 	addrs       []btcutil.Address
 	outpoints   map[wire.OutPoint]btcutil.Address
 	bs          waddrmgr.BlockStamp
-	errChans    []chan error
+	jobs        []*RescanJob
+
+	// endHeight is the best block height when the rescan was started, and
+	// height the height of the last block rescanned.  They are only
+	// accessed by the batch handler.
+	endHeight int32
+	height    int32
 }
 
-// SubmitRescan submits a RescanJob to the RescanManager.  A channel is
-// returned with the final error of the rescan.  The channel is buffered
-// and does not need to be read to prevent a deadlock.
+// SubmitRescan submits a RescanJob to the RescanManager, assigning the job's
+// ID.  A channel is returned with the final error of the rescan.  The channel
+// is buffered and does not need to be read to prevent a deadlock.
 func (w *Wallet) SubmitRescan(job *RescanJob) <-chan error {
 	errChan := make(chan error, 1)
 	job.err = errChan
+	w.rescanIDMtx.Lock()
+	w.lastRescanID++
+	job.ID = w.lastRescanID
+	w.rescanIDMtx.Unlock()
 	select {
 	case w.rescanAddJob <- job:
 	case <-w.quitChan():
@@ -64,15 +146,58 @@ func (w *Wallet) SubmitRescan(job *RescanJob) <-chan error {
 	return errChan
 }
 
+// CancelRescan cancels the queued rescan job with the ID, removing it from the
+// queue.  The job finishes with ErrRescanCancelled.  Since the chain backend
+// can not interrupt a rescan, ErrRescanRunning is returned for running jobs,
+// which always rescan to the best block.
+func (w *Wallet) CancelRescan(id uint64) error {
+	if _, err := w.requireChainClient(); err != nil {
+		return err
+	}
+
+	req := cancelRescanRequest{
+		id:  id,
+		err: make(chan error, 1),
+	}
+	select {
+	case w.rescanCancelRequests <- req:
+		return <-req.err
+	case <-w.quitChan():
+		return ErrWalletShuttingDown
+	}
+}
+
+// RescanJobs returns the status of the running rescan jobs followed by the
+// queued jobs, ordered by their ID.
+func (w *Wallet) RescanJobs() ([]RescanJobStatus, error) {
+	if _, err := w.requireChainClient(); err != nil {
+		return nil, err
+	}
+
+	c := make(chan []RescanJobStatus, 1)
+	select {
+	case w.rescanStatusRequests <- c:
+		return <-c, nil
+	case <-w.quitChan():
+		return nil, ErrWalletShuttingDown
+	}
+}
+
 // batch creates the rescanBatch for a single rescan job.
 func (job *RescanJob) batch() *rescanBatch {
-	return &rescanBatch{
+	b := &rescanBatch{
 		initialSync: job.InitialSync,
-		addrs:       job.Addrs,
-		outpoints:   job.OutPoints,
-		bs:          job.BlockStamp,
-		errChans:    []chan error{job.err},
+		addrs:       append([]btcutil.Address(nil), job.Addrs...),
+		outpoints: make(
+			map[wire.OutPoint]btcutil.Address, len(job.OutPoints),
+		),
+		bs:   job.BlockStamp,
+		jobs: []*RescanJob{job},
 	}
+	for op, addr := range job.OutPoints {
+		b.outpoints[op] = addr
+	}
+	return b
 }
 
 // merge merges the work from k into j, setting the starting height to
@@ -91,21 +216,168 @@ func (b *rescanBatch) merge(job *RescanJob) {
 	if job.BlockStamp.Height < b.bs.Height {
 		b.bs = job.BlockStamp
 	}
-	b.errChans = append(b.errChans, job.err)
+	b.jobs = append(b.jobs, job)
+}
+
+// without returns the batch of the jobs of b except the one with the ID.  Nil
+// is returned when no other job remains.
+func (b *rescanBatch) without(id uint64) *rescanBatch {
+	var remaining *rescanBatch
+	for _, job := range b.jobs {
+		switch {
+		case job.ID == id:
+		case remaining == nil:
+			remaining = job.batch()
+		default:
+			remaining.merge(job)
+		}
+	}
+	return remaining
+}
+
+// percent returns the progress of the rescan when it reached the height.
+func (b *rescanBatch) percent(height int32) float64 {
+	if b.endHeight <= b.bs.Height {
+		return 100
+	}
+	percent := float64(height-b.bs.Height) /
+		float64(b.endHeight-b.bs.Height) * 100
+	switch {
+	case percent < 0:
+		return 0
+	case percent > 100:
+		return 100
+	}
+	return percent
 }
 
-// done iterates through all error channels, duplicating sending the error
-// to inform callers that the rescan finished (or could not complete due
-// to an error).
-func (b *rescanBatch) done(err error) {
-	for _, c := range b.errChans {
-		c <- err
+// status returns the status of the unfinished jobs of the batch.
+func (b *rescanBatch) status(running bool) []RescanJobStatus {
+	var status []RescanJobStatus
+	for _, job := range b.jobs {
+		if job.isFinished() {
+			continue
+		}
+		s := RescanJobStatus{
+			ID:          job.ID,
+			Running:     running,
+			Addresses:   len(job.Addrs),
+			StartHeight: job.BlockStamp.Height,
+		}
+		if running {
+			s.Height = b.height
+			s.Percent = b.percent(b.height)
+		}
+		status = append(status, s)
+	}
+	return status
+}
+
+// done iterates through all jobs, sending the error to inform callers that
+// the rescan finished (or could not complete due to an error), and returns the
+// jobs which had not yet finished.
+func (b *rescanBatch) done(err error) []*RescanJob {
+	var finished []*RescanJob
+	for _, job := range b.jobs {
+		if job.finish(err) {
+			finished = append(finished, job)
+		}
+	}
+	return finished
+}
+
+// notifyRescanProgress notifies the progress of every unfinished job of the
+// batch.
+func (w *Wallet) notifyRescanProgress(b *rescanBatch) {
+	percent := b.percent(b.height)
+	for _, job := range b.jobs {
+		if job.isFinished() {
+			continue
+		}
+		w.NtfnServer.notifyRescanProgress(&RescanJobProgress{
+			ID:      job.ID,
+			Height:  b.height,
+			Percent: percent,
+		})
+	}
+}
+
+// notifyRescansDone notifies the completion of the jobs at the height with
+// the final error.
+func (w *Wallet) notifyRescansDone(jobs []*RescanJob, height int32,
+	percent float64, err error) {
+
+	for _, job := range jobs {
+		w.NtfnServer.notifyRescanProgress(&RescanJobProgress{
+			ID:      job.ID,
+			Height:  height,
+			Percent: percent,
+			Done:    true,
+			Err:     err,
+		})
+	}
+}
+
+// finishRescanBatch reports the final error of the rescan of a batch to the
+// unfinished jobs of the batch and notifies their completion.
+func (w *Wallet) finishRescanBatch(b *rescanBatch, err error) {
+	finished := b.done(err)
+	if err != nil {
+		w.notifyRescansDone(finished, 0, 0, err)
+		return
 	}
+	w.notifyRescansDone(finished, b.endHeight, 100, nil)
+}
+
+// cancelRescan cancels the job with the ID queued in the next batch, and
+// returns the next batch without the job.  Jobs running in the current batch
+// are not cancelled.
+func (w *Wallet) cancelRescan(id uint64, cur,
+	next *rescanBatch) (*rescanBatch, error) {
+
+	if next != nil {
+		for _, job := range next.jobs {
+			if job.ID != id {
+				continue
+			}
+			job.finish(ErrRescanCancelled)
+			w.notifyRescansDone(
+				[]*RescanJob{job}, 0, 0, ErrRescanCancelled,
+			)
+			return next.without(id), nil
+		}
+	}
+	if cur != nil {
+		for _, job := range cur.jobs {
+			if job.ID == id && !job.isFinished() {
+				return next, ErrRescanRunning
+			}
+		}
+	}
+	return next, ErrUnknownRescan
+}
+
+// startRescanBatch records the best block height the rescan of the batch
+// progresses towards before the batch is sent to be rescanned.
+func (w *Wallet) startRescanBatch(b *rescanBatch) {
+	b.height = b.bs.Height
+	b.endHeight = b.bs.Height
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return
+	}
+	_, bestHeight, err := chainClient.GetBestBlock()
+	if err != nil {
+		log.Warnf("Unable to determine rescan progress: %v", err)
+		return
+	}
+	b.endHeight = bestHeight
 }
 
 // rescanBatchHandler handles incoming rescan request, serializing rescan
 // submissions, and possibly batching many waiting requests together so they
-// can be handled by a single rescan after the current one completes.
+// can be handled by a single rescan after the current one completes.  Queued
+// jobs may be cancelled, and the progress of running jobs is notified.
 func (w *Wallet) rescanBatchHandler() {
 	defer w.wg.Done()
 
@@ -119,10 +391,11 @@ func (w *Wallet) rescanBatchHandler() {
 				// Set current batch as this job and send
 				// request.
 				curBatch = job.batch()
+				w.startRescanBatch(curBatch)
 				select {
 				case w.rescanBatch <- curBatch:
 				case <-quit:
-					job.err <- ErrWalletShuttingDown
+					job.finish(ErrWalletShuttingDown)
 					return
 				}
 			} else {
@@ -135,6 +408,21 @@ func (w *Wallet) rescanBatchHandler() {
 				}
 			}
 
+		case req := <-w.rescanCancelRequests:
+			var err error
+			nextBatch, err = w.cancelRescan(req.id, curBatch, nextBatch)
+			req.err <- err
+
+		case c := <-w.rescanStatusRequests:
+			var status []RescanJobStatus
+			if curBatch != nil {
+				status = append(status, curBatch.status(true)...)
+			}
+			if nextBatch != nil {
+				status = append(status, nextBatch.status(false)...)
+			}
+			c <- status
+
 		case n := <-w.rescanNotifications:
 			switch n := n.(type) {
 			case *chain.RescanProgress:
@@ -144,15 +432,15 @@ func (w *Wallet) rescanBatchHandler() {
 						"currently running")
 					continue
 				}
+				curBatch.height = n.Height
+				w.notifyRescanProgress(curBatch)
 				select {
 				case w.rescanProgress <- &RescanProgressMsg{
 					Addresses:    curBatch.addrs,
 					Notification: n,
 				}:
 				case <-quit:
-					for _, errChan := range curBatch.errChans {
-						errChan <- ErrWalletShuttingDown
-					}
+					curBatch.done(ErrWalletShuttingDown)
 					return
 				}
 
@@ -169,21 +457,18 @@ func (w *Wallet) rescanBatchHandler() {
 					Notification: n,
 				}:
 				case <-quit:
-					for _, errChan := range curBatch.errChans {
-						errChan <- ErrWalletShuttingDown
-					}
+					curBatch.done(ErrWalletShuttingDown)
 					return
 				}
 
 				curBatch, nextBatch = nextBatch, nil
 
 				if curBatch != nil {
+					w.startRescanBatch(curBatch)
 					select {
 					case w.rescanBatch <- curBatch:
 					case <-quit:
-						for _, errChan := range curBatch.errChans {
-							errChan <- ErrWalletShuttingDown
-						}
+						curBatch.done(ErrWalletShuttingDown)
 						return
 					}
 				}
@@ -260,7 +545,7 @@ out:
 				log.Errorf("Rescan for %d %s failed: %v", numAddrs,
 					noun, err)
 			}
-			batch.done(err)
+			w.finishRescanBatch(batch, err)
 		case <-quit:
 			break out
 		}
@@ -316,3 +601,241 @@ func (w *Wallet) rescanWithTarget(addrs []btcutil.Address,
 		return ErrWalletShuttingDown
 	}
 }
+
+// RescanBirthday returns the earliest birthday block of the addresses of an
+// account, or of every account when account is nil, from which the chain is
+// scanned to find every transaction of the addresses.  Addresses created
+// before birthday blocks were recorded are assumed to be as old as the wallet
+// birthday block, and imported ones as old as the genesis block.  The block
+// the wallet is synced to is returned when there are no addresses.
+func (w *Wallet) RescanBirthday(account *uint32) (waddrmgr.BlockStamp, error) {
+	genesis := waddrmgr.BlockStamp{
+		Hash:      *w.chainParams.GenesisHash,
+		Timestamp: w.chainParams.GenesisBlock.Header.Timestamp,
+	}
+
+	var earliest *waddrmgr.BlockStamp
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+
+		// The addresses are collected before their birthdays are
+		// looked up, as the manager is locked while iterating.
+		var addrs []btcutil.Address
+		addAddr := func(addr btcutil.Address) error {
+			addrs = append(addrs, addr)
+			return nil
+		}
+		var err error
+		if account != nil {
+			err = w.Manager.ForEachAccountAddress(
+				addrmgrNs, *account,
+				func(maddr waddrmgr.ManagedAddress) error {
+					return addAddr(maddr.Address())
+				},
+			)
+		} else {
+			err = w.Manager.ForEachActiveAddress(addrmgrNs, addAddr)
+		}
+		if err != nil {
+			return err
+		}
+
+		walletBirthday := genesis
+		birthdayBlock, _, err := w.Manager.BirthdayBlock(addrmgrNs)
+		if err == nil {
+			walletBirthday = birthdayBlock
+		}
+
+		for _, addr := range addrs {
+			birthday, err := w.Manager.AddressBirthday(
+				addrmgrNs, addr,
+			)
+			if err != nil {
+				return err
+			}
+			if birthday == nil {
+				_, acct, err := w.Manager.AddrAccount(
+					addrmgrNs, addr,
+				)
+				if err != nil {
+					return err
+				}
+				birthday = &walletBirthday
+				if acct == waddrmgr.ImportedAddrAccount {
+					birthday = &genesis
+				}
+			}
+			if earliest == nil || birthday.Height < earliest.Height {
+				earliest = birthday
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return waddrmgr.BlockStamp{}, err
+	}
+	if earliest == nil {
+		return w.Manager.SyncedTo(), nil
+	}
+	return *earliest, nil
+}
+
+// RescanBlockRange scans the main chain blocks from startHeight through
+// stopHeight for transactions paying to or spending from the addresses of an
+// account, or of every account when account is nil.  Transactions missing
+// from the wallet are recorded, and the credits of every transaction found are
+// derived again, which recovers transactions missed by the wallet, such as
+// after its database was restored from a backup.  Unlike a rescan job, the
+// scan stops at stopHeight and does not change the wallet's sync state.
+func (w *Wallet) RescanBlockRange(account *uint32, startHeight,
+	stopHeight int32) error {
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return err
+	}
+	_, bestHeight, err := chainClient.GetBestBlock()
+	if err != nil {
+		return err
+	}
+	if startHeight < 0 || startHeight > stopHeight ||
+		stopHeight > bestHeight {
+
+		return ErrInvalidBlockRange
+	}
+
+	// The filter request matches outputs by address, so index the
+	// addresses as external addresses of an unused key scope.  Unspent
+	// outputs of the addresses are watched for spends.
+	filterAddrs := make(map[waddrmgr.ScopedIndex]btcutil.Address)
+	watched := make(map[wire.OutPoint]btcutil.Address)
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+
+		addrs := make(map[string]struct{})
+		addAddr := func(addr btcutil.Address) error {
+			index := uint32(len(filterAddrs))
+			filterAddrs[waddrmgr.ScopedIndex{Index: index}] = addr
+			addrs[addr.EncodeAddress()] = struct{}{}
+			return nil
+		}
+		if account != nil {
+			err := w.Manager.ForEachAccountAddress(
+				addrmgrNs, *account,
+				func(maddr waddrmgr.ManagedAddress) error {
+					return addAddr(maddr.Address())
+				},
+			)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := w.Manager.ForEachActiveAddress(addrmgrNs, addAddr)
+			if err != nil {
+				return err
+			}
+		}
+
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs)
+		if err != nil {
+			return err
+		}
+		for _, output := range unspent {
+			_, outputAddrs, _, err := txscript.ExtractPkScriptAddrs(
+				output.PkScript, w.chainParams,
+			)
+			if err != nil || len(outputAddrs) == 0 {
+				continue
+			}
+			_, ok := addrs[outputAddrs[0].EncodeAddress()]
+			if ok {
+				watched[output.OutPoint] = outputAddrs[0]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Rescanning blocks %d-%d for %d %s", startHeight, stopHeight,
+		len(filterAddrs), pickNoun(len(filterAddrs), "address",
+			"addresses"))
+
+	// The blocks of the next batch are fetched while a batch is filtered.
+	done := make(chan struct{})
+	defer close(done)
+	batches := pipelineBlockBatches(
+		chainClient, startHeight, stopHeight, recoveryBatchSize, done,
+	)
+	for next := range batches {
+		if next.err != nil {
+			return next.err
+		}
+		batch := next.blocks
+
+		// Filter the batch, restarting after each block that reports
+		// relevant transactions so that newly found outputs are
+		// watched for spends in later blocks.
+		for len(batch) > 0 {
+			resp, err := chainClient.FilterBlocks(&chain.FilterBlocksRequest{
+				Blocks:           batch,
+				ExternalAddrs:    filterAddrs,
+				InternalAddrs:    map[waddrmgr.ScopedIndex]btcutil.Address{},
+				WatchedOutPoints: watched,
+			})
+			if err != nil {
+				return err
+			}
+			if resp == nil {
+				break
+			}
+
+			err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+				for _, txn := range resp.RelevantTxns {
+					err := w.addRescannedTx(
+						tx, txn, &resp.BlockMeta,
+					)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for op, addr := range resp.FoundOutPoints {
+				watched[op] = addr
+			}
+
+			batch = batch[resp.BatchIndex+1:]
+		}
+	}
+
+	log.Infof("Finished rescanning blocks %d-%d", startHeight, stopHeight)
+	return nil
+}
+
+// addRescannedTx records a mined transaction found by RescanBlockRange.  The
+// credits of a transaction already recorded in the block are derived again.
+func (w *Wallet) addRescannedTx(dbtx walletdb.ReadWriteTx, tx *wire.MsgTx,
+	block *wtxmgr.BlockMeta) error {
+
+	addrmgrNs := dbtx.ReadWriteBucket(waddrmgrNamespaceKey)
+	txmgrNs := dbtx.ReadWriteBucket(wtxmgrNamespaceKey)
+
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, block.Time)
+	if err != nil {
+		return err
+	}
+	details, err := w.TxStore.UniqueTxDetails(txmgrNs, &rec.Hash, &block.Block)
+	if err != nil {
+		return err
+	}
+	if details == nil {
+		return w.addRelevantTx(dbtx, rec, block)
+	}
+	return w.addCredits(addrmgrNs, txmgrNs, rec, block)
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// rescanChainClient is a mock chain client with a best block at height 100.
+type rescanChainClient struct {
+	mockChainClient
+}
+
+func (c *rescanChainClient) GetBestBlock() (*chainhash.Hash, int32, error) {
+	return &chainhash.Hash{}, 100, nil
+}
+
+// TestRescanJobs ensures that rescan jobs are serialized, report their
+// progress and completion, and may be cancelled.
+func TestRescanJobs(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+	w.chainClient = &rescanChainClient{}
+
+	// The batch handler is run without the RPC handler, so that batches
+	// are received and finished by the test.
+	w.wg.Add(1)
+	go w.rescanBatchHandler()
+	quit := w.quitChan()
+	go func() {
+		for {
+			select {
+			case <-w.rescanProgress:
+			case <-w.rescanFinished:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	client := w.NtfnServer.RescanProgressNotifications()
+	defer client.Done()
+	ntfns := make(chan *RescanJobProgress, 10)
+	go func() {
+		for n := range client.C {
+			ntfns <- n
+		}
+	}()
+	nextNtfn := func() *RescanJobProgress {
+		select {
+		case n := <-ntfns:
+			return n
+		case <-time.After(5 * time.Second):
+			t.Fatal("no rescan progress notification")
+			return nil
+		}
+	}
+	submit := func(height int32) (*RescanJob, <-chan error) {
+		job := &RescanJob{
+			BlockStamp: waddrmgr.BlockStamp{Height: height},
+		}
+		return job, w.SubmitRescan(job)
+	}
+
+	jobA, errA := submit(20)
+	batch := <-w.rescanBatch
+	require.Equal(t, []*RescanJob{jobA}, batch.jobs)
+	jobB, errB := submit(10)
+	jobC, errC := submit(30)
+
+	jobs, err := w.RescanJobs()
+	require.NoError(t, err)
+	require.Equal(t, []RescanJobStatus{
+		{ID: jobA.ID, Running: true, StartHeight: 20, Height: 20},
+		{ID: jobB.ID, StartHeight: 10},
+		{ID: jobC.ID, StartHeight: 30},
+	}, jobs)
+
+	w.rescanNotifications <- &chain.RescanProgress{Height: 60}
+	require.Equal(t, &RescanJobProgress{
+		ID: jobA.ID, Height: 60, Percent: 50,
+	}, nextNtfn())
+
+	// Queued jobs are removed from the queue when cancelled, while running
+	// jobs can't be cancelled and run to completion.
+	require.NoError(t, w.CancelRescan(jobB.ID))
+	require.Equal(t, ErrRescanCancelled, <-errB)
+	require.Equal(t, &RescanJobProgress{
+		ID: jobB.ID, Done: true, Err: ErrRescanCancelled,
+	}, nextNtfn())
+	require.Equal(t, ErrRescanRunning, w.CancelRescan(jobA.ID))
+	require.Equal(t, ErrUnknownRescan, w.CancelRescan(jobB.ID))
+	w.finishRescanBatch(batch, nil)
+	require.NoError(t, <-errA)
+	require.Equal(t, &RescanJobProgress{
+		ID: jobA.ID, Height: 100, Percent: 100, Done: true,
+	}, nextNtfn())
+
+	// The remaining queued job is rescanned once the running rescan
+	// finishes.
+	w.rescanNotifications <- &chain.RescanFinished{Height: 100}
+	batch = <-w.rescanBatch
+	require.Equal(t, []*RescanJob{jobC}, batch.jobs)
+	w.finishRescanBatch(batch, nil)
+	require.NoError(t, <-errC)
+	require.Equal(t, &RescanJobProgress{
+		ID: jobC.ID, Height: 100, Percent: 100, Done: true,
+	}, nextNtfn())
+
+	jobs, err = w.RescanJobs()
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+}
+
+// rangeChainClient is a mock chain client with a chain of ten blocks, of
+// which a single block contains a transaction relevant to the wallet.
+type rangeChainClient struct {
+	mockChainClient
+
+	relevantHeight int32
+	relevantTx     *wire.MsgTx
+	relevantAddr   btcutil.Address
+
+	// lastHeight is the height of the last block filtered.
+	lastHeight int32
+}
+
+func (c *rangeChainClient) GetBestBlock() (*chainhash.Hash, int32, error) {
+	return &chainhash.Hash{10}, 10, nil
+}
+
+func (c *rangeChainClient) GetBlockHash(height int64) (*chainhash.Hash,
+	error) {
+
+	return &chainhash.Hash{byte(height)}, nil
+}
+
+func (c *rangeChainClient) GetBlockHeader(hash *chainhash.Hash) (
+	*wire.BlockHeader, error) {
+
+	return &wire.BlockHeader{
+		Timestamp: time.Unix(1600000000+int64(hash[0])*600, 0),
+	}, nil
+}
+
+func (c *rangeChainClient) FilterBlocks(req *chain.FilterBlocksRequest) (
+	*chain.FilterBlocksResponse, error) {
+
+	for i, block := range req.Blocks {
+		c.lastHeight = block.Height
+		if block.Height != c.relevantHeight {
+			continue
+		}
+		op := wire.OutPoint{Hash: c.relevantTx.TxHash()}
+		return &chain.FilterBlocksResponse{
+			BatchIndex: uint32(i),
+			BlockMeta:  block,
+			FoundOutPoints: map[wire.OutPoint]btcutil.Address{
+				op: c.relevantAddr,
+			},
+			RelevantTxns: []*wire.MsgTx{c.relevantTx},
+		}, nil
+	}
+	return nil, nil
+}
+
+// TestRescanBlockRange ensures that rescanning a block range records missed
+// transactions and re-derives the credits of recorded transactions.
+func TestRescanBlockRange(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	tx := &wire.MsgTx{
+		TxIn:  []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{wire.NewTxOut(100000, pkScript)},
+	}
+	chainClient := &rangeChainClient{
+		relevantHeight: 5,
+		relevantTx:     tx,
+		relevantAddr:   addr,
+	}
+	w.chainClient = chainClient
+
+	unspent := func() []wtxmgr.Credit {
+		var credits []wtxmgr.Credit
+		err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+			ns := dbtx.ReadBucket(wtxmgrNamespaceKey)
+			var err error
+			credits, err = w.TxStore.UnspentOutputs(ns)
+			return err
+		})
+		require.NoError(t, err)
+		return credits
+	}
+
+	require.Equal(t, ErrInvalidBlockRange, w.RescanBlockRange(nil, 5, 4))
+	require.Equal(t, ErrInvalidBlockRange, w.RescanBlockRange(nil, 0, 11))
+
+	// The scan stops at the stop height.
+	require.NoError(t, w.RescanBlockRange(nil, 0, 4))
+	require.Equal(t, int32(4), chainClient.lastHeight)
+	require.Empty(t, unspent())
+
+	// A transaction recorded without its credit is credited.
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Unix(1600003000, 0))
+	require.NoError(t, err)
+	block := &wtxmgr.BlockMeta{
+		Block: wtxmgr.Block{Hash: chainhash.Hash{5}, Height: 5},
+		Time:  rec.Received,
+	}
+	err = walletdb.Update(w.db, func(dbtx walletdb.ReadWriteTx) error {
+		ns := dbtx.ReadWriteBucket(wtxmgrNamespaceKey)
+		return w.TxStore.InsertTx(ns, rec, block)
+	})
+	require.NoError(t, err)
+	require.Empty(t, unspent())
+
+	account := uint32(0)
+	require.NoError(t, w.RescanBlockRange(&account, 0, 10))
+	credits := unspent()
+	require.Len(t, credits, 1)
+	require.Equal(t, btcutil.Amount(100000), credits[0].Amount)
+	require.Equal(t, int32(5), credits[0].Height)
+
+	// Rescanning again does not duplicate the credit.
+	require.NoError(t, w.RescanBlockRange(nil, 0, 10))
+	require.Len(t, unspent(), 1)
+}
+
+// TestRescanBirthday ensures the rescan birthday of an account is the earliest
+// birthday block of its addresses.
+func TestRescanBirthday(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	scope := waddrmgr.KeyScopeBIP0084
+	account, err := w.NextAccount(scope, "savings")
+	require.NoError(t, err)
+
+	// Without addresses, nothing needs to be scanned before the block the
+	// wallet is synced to.
+	syncedTo := waddrmgr.BlockStamp{
+		Height:    50,
+		Hash:      chainhash.Hash{50},
+		Timestamp: time.Unix(1600000000, 0),
+	}
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		err := w.Manager.SetSyncedTo(ns, &syncedTo)
+		if err != nil {
+			return err
+		}
+		return w.Manager.SetBirthdayBlock(ns, syncedTo, true)
+	})
+	require.NoError(t, err)
+	birthday, err := w.RescanBirthday(&account)
+	require.NoError(t, err)
+	require.Equal(t, syncedTo.Height, birthday.Height)
+
+	// Addresses derived now cannot have been used before the block the
+	// wallet is synced to.
+	_, err = w.NewAddress(account, scope)
+	require.NoError(t, err)
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return w.Manager.SetSyncedTo(ns, &waddrmgr.BlockStamp{
+			Height:    51,
+			Hash:      chainhash.Hash{51},
+			Timestamp: time.Unix(1600000600, 0),
+		})
+	})
+	require.NoError(t, err)
+	_, err = w.NewAddress(account, scope)
+	require.NoError(t, err)
+	birthday, err = w.RescanBirthday(&account)
+	require.NoError(t, err)
+	require.Equal(t, syncedTo.Height, birthday.Height)
+	require.Equal(t, syncedTo.Hash, birthday.Hash)
+
+	// Imported keys are as old as the block stamp they are imported with.
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	wif, err := btcutil.NewWIF(privKey, w.ChainParams(), true)
+	require.NoError(t, err)
+	importedAt := &waddrmgr.BlockStamp{
+		Height:    30,
+		Hash:      chainhash.Hash{30},
+		Timestamp: time.Unix(1599990000, 0),
+	}
+	_, err = w.ImportPrivateKey(scope, wif, importedAt, false)
+	require.NoError(t, err)
+
+	imported := uint32(waddrmgr.ImportedAddrAccount)
+	birthday, err = w.RescanBirthday(&imported)
+	require.NoError(t, err)
+	require.Equal(t, importedAt.Height, birthday.Height)
+	birthday, err = w.RescanBirthday(nil)
+	require.NoError(t, err)
+	require.LessOrEqual(t, birthday.Height, importedAt.Height)
+}
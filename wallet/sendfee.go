@@ -0,0 +1,96 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wallet/txsizes"
+)
+
+// SendFeeEstimate describes the transaction a send of a set of outputs would
+// create, as estimated by EstimateSendFee.
+type SendFeeEstimate struct {
+	// Fee is the fee paid by the transaction.
+	Fee btcutil.Amount
+
+	// VirtualSize is the estimated virtual size of the signed transaction
+	// in vbytes.
+	VirtualSize int
+
+	// Inputs is the number of outputs of the wallet spent by the
+	// transaction.
+	Inputs int
+
+	// Change is the value of the change output of the transaction, or
+	// zero when no change output would be created.
+	Change btcutil.Amount
+
+	// HasChange reports whether the transaction creates a change output.
+	HasChange bool
+}
+
+// EstimateSendFee runs coin selection for a send of the outputs from the
+// account, as SendOutputs does with the same arguments, and returns the fee,
+// size, inputs and change of the transaction it would create.  The transaction
+// is neither signed nor published, and the wallet is left unmodified.
+func (w *Wallet) EstimateSendFee(outputs []*wire.TxOut,
+	keyScope *waddrmgr.KeyScope, account uint32, minconf int32,
+	satPerKb btcutil.Amount, coinSelectionStrategy CoinSelectionStrategy,
+	optFuncs ...TxCreateOption) (*SendFeeEstimate, error) {
+
+	for _, output := range outputs {
+		err := txrules.CheckOutput(
+			output, txrules.DefaultRelayFeePerKb,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := w.CreateSimpleTx(
+		keyScope, account, outputs, minconf, satPerKb,
+		coinSelectionStrategy, true, optFuncs...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sendFeeEstimate(tx), nil
+}
+
+// sendFeeEstimate returns the estimate of the unsigned transaction authored
+// for a send.
+func sendFeeEstimate(tx *txauthor.AuthoredTx) *SendFeeEstimate {
+	var nested, p2wpkh, p2pkh int
+	for _, pkScript := range tx.PrevScripts {
+		switch {
+		case txscript.IsPayToScriptHash(pkScript):
+			nested++
+		case txscript.IsPayToWitnessPubKeyHash(pkScript):
+			p2wpkh++
+		default:
+			p2pkh++
+		}
+	}
+
+	estimate := &SendFeeEstimate{
+		Fee: tx.TotalInput - txauthor.SumOutputValues(tx.Tx.TxOut),
+		VirtualSize: txsizes.EstimateVirtualSize(
+			p2pkh, p2wpkh, nested, tx.Tx.TxOut, 0,
+		),
+		Inputs: len(tx.Tx.TxIn),
+	}
+	if tx.ChangeIndex >= 0 {
+		estimate.HasChange = true
+		estimate.Change = btcutil.Amount(
+			tx.Tx.TxOut[tx.ChangeIndex].Value,
+		)
+	}
+	return estimate
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEstimateSendFee ensures that the fee, size, inputs and change of a send
+// are estimated without modifying the wallet.
+func TestEstimateSendFee(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	keyScope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.CurrentAddress(0, keyScope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	addUtxo(t, w, &wire.MsgTx{
+		TxIn:  []*wire.TxIn{{}},
+		TxOut: []*wire.TxOut{wire.NewTxOut(100000, pkScript)},
+	})
+
+	lastChangeIndex := func() uint32 {
+		var index uint32
+		err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+			ns := tx.ReadBucket(waddrmgrNamespaceKey)
+			scopedMgr, err := w.Manager.FetchScopedKeyManager(
+				keyScope,
+			)
+			if err != nil {
+				return err
+			}
+			props, err := scopedMgr.AccountProperties(ns, 0)
+			if err != nil {
+				return err
+			}
+			index = props.InternalKeyCount
+			return nil
+		})
+		require.NoError(t, err)
+		return index
+	}
+	changeIndex := lastChangeIndex()
+
+	txOuts := []*wire.TxOut{wire.NewTxOut(50000, pkScript)}
+	estimate, err := w.EstimateSendFee(
+		txOuts, nil, 0, 1, 1000, CoinSelectionLargest,
+	)
+	require.NoError(t, err)
+	require.Equal(t, 1, estimate.Inputs)
+	require.True(t, estimate.HasChange)
+	require.Equal(t, btcutil.Amount(100000-50000)-estimate.Fee,
+		estimate.Change)
+	require.Greater(t, estimate.VirtualSize, 0)
+	require.Equal(t, btcutil.Amount(estimate.VirtualSize), estimate.Fee)
+
+	// The estimate does not derive a change address.
+	require.Equal(t, changeIndex, lastChangeIndex())
+
+	// Nothing is signed, so the fee is estimated while the wallet is
+	// locked.
+	w.Lock()
+	_, err = w.EstimateSendFee(
+		txOuts, nil, 0, 1, 1000, CoinSelectionLargest,
+	)
+	require.NoError(t, err)
+
+	estimate, err = w.EstimateSendFee(
+		txOuts, nil, 0, 1, 1000, CoinSelectionLargest, WithNoChange(),
+	)
+	require.NoError(t, err)
+	require.False(t, estimate.HasChange)
+	require.Equal(t, btcutil.Amount(50000), estimate.Fee)
+}
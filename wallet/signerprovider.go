@@ -0,0 +1,213 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// SignerProvider signs with private keys held outside of the wallet, such as
+// by a hardware security module or a cloud key management service.  Keys are
+// identified by opaque references assigned by the provider, and the wallet
+// stores only these references along with the public keys.
+type SignerProvider interface {
+	// Name returns the name identifying the provider, which is stored
+	// with the references to its keys.  It must be between 1 and 255
+	// bytes long.
+	Name() string
+
+	// PublicKey returns the public key of the referenced key.
+	PublicKey(ref []byte) (*btcec.PublicKey, error)
+
+	// SignHash returns the signature of a 32 byte hash by the referenced
+	// key.
+	SignHash(ref []byte, hash []byte) (*btcec.Signature, error)
+}
+
+// RegisterSignerProvider registers an external signer provider, replacing any
+// provider registered with the same name.  Inputs spending to the keys of a
+// provider can only be signed while it is registered.
+func (w *Wallet) RegisterSignerProvider(provider SignerProvider) error {
+	name := provider.Name()
+	if len(name) == 0 || len(name) > 255 {
+		return fmt.Errorf("signer provider name %q is not between 1 "+
+			"and 255 bytes", name)
+	}
+
+	w.signerProvidersMtx.Lock()
+	defer w.signerProvidersMtx.Unlock()
+
+	if w.signerProviders == nil {
+		w.signerProviders = make(map[string]SignerProvider)
+	}
+	w.signerProviders[name] = provider
+	return nil
+}
+
+// signerProvider returns the registered external signer provider with the
+// name.
+func (w *Wallet) signerProvider(name string) (SignerProvider, error) {
+	w.signerProvidersMtx.RLock()
+	defer w.signerProvidersMtx.RUnlock()
+
+	provider, ok := w.signerProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("signer provider %q is not registered",
+			name)
+	}
+	return provider, nil
+}
+
+// ImportExternalKey imports the key referenced by ref of a registered signer
+// provider into the imported account of the key scope of the address type,
+// returning its address.  Only the public key and the reference are stored,
+// and transactions spending to the address are signed by the provider.
+func (w *Wallet) ImportExternalKey(providerName string, ref []byte,
+	addrType waddrmgr.AddressType) (btcutil.Address, error) {
+
+	provider, err := w.signerProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Determine what key scope the public key should belong to and import
+	// it into the key scope's default imported account.
+	var keyScope waddrmgr.KeyScope
+	switch addrType {
+	case waddrmgr.NestedWitnessPubKey:
+		keyScope = waddrmgr.KeyScopeBIP0049Plus
+	case waddrmgr.WitnessPubKey:
+		keyScope = waddrmgr.KeyScopeBIP0084
+	default:
+		return nil, fmt.Errorf("address type %v is not supported",
+			addrType)
+	}
+
+	scopedKeyManager, err := w.Manager.FetchScopedKeyManager(keyScope)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, err := provider.PublicKey(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var addr waddrmgr.ManagedAddress
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		addr, err = scopedKeyManager.ImportExternalKey(
+			ns, pubKey, providerName, ref, nil,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Imported address %v of signer provider %s", addr.Address(),
+		providerName)
+
+	err = w.chainClient.NotifyReceived([]btcutil.Address{addr.Address()})
+	if err != nil {
+		return nil, fmt.Errorf("unable to subscribe for address "+
+			"notifications: %v", err)
+	}
+
+	return addr.Address(), nil
+}
+
+// externalKeyRef returns the public key of an address along with the name of
+// the provider holding its key and the provider's reference to the key.  An
+// empty provider name is returned for addresses whose key is not held by an
+// external signer.
+func (s secretSource) externalKeyRef(addr btcutil.Address) (*btcec.PublicKey,
+	string, []byte, error) {
+
+	// Only imported keys may be held externally.
+	scopedKeyManager, account, err := s.AddrAccount(s.addrmgrNs, addr)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if account != waddrmgr.ImportedAddrAccount {
+		return nil, "", nil, nil
+	}
+
+	ma, err := scopedKeyManager.Address(s.addrmgrNs, addr)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	mpka, ok := ma.(waddrmgr.ManagedPubKeyAddress)
+	if !ok {
+		return nil, "", nil, nil
+	}
+
+	pubKey := mpka.PubKey()
+	provider, ref, err := scopedKeyManager.ExternalKeyRef(
+		s.addrmgrNs, pubKey,
+	)
+	return pubKey, provider, ref, err
+}
+
+// signsExternally returns whether the keys of all inputs spending the previous
+// output scripts are held by signer providers.
+func (s secretSource) signsExternally(prevScripts [][]byte) (bool, error) {
+	for _, pkScript := range prevScripts {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			pkScript, s.ChainParams(),
+		)
+		if err != nil {
+			return false, err
+		}
+		if len(addrs) != 1 {
+			return false, nil
+		}
+		pubKey, err := s.ExternalKey(addrs[0])
+		if err != nil || pubKey == nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// ExternalKey returns the public key of an address whose key is held by an
+// external signer, or nil if the address' key is not.
+//
+// This is part of the txauthor.ExternalSigner interface implementation.
+func (s secretSource) ExternalKey(addr btcutil.Address) (*btcec.PublicKey, error) {
+	pubKey, provider, _, err := s.externalKeyRef(addr)
+	if err != nil || provider == "" {
+		return nil, err
+	}
+	return pubKey, nil
+}
+
+// SignHash signs a signature hash with the key of an address held by an
+// external signer, using the registered provider of the key.
+//
+// This is part of the txauthor.ExternalSigner interface implementation.
+func (s secretSource) SignHash(addr btcutil.Address,
+	hash []byte) (*btcec.Signature, error) {
+
+	_, providerName, ref, err := s.externalKeyRef(addr)
+	if err != nil {
+		return nil, err
+	}
+	if providerName == "" {
+		return nil, fmt.Errorf("key of address %v is not held by a "+
+			"signer provider", addr)
+	}
+	provider, err := s.signerProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	return provider.SignHash(ref, hash)
+}
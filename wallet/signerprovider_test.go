@@ -0,0 +1,105 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSignerProvider is a SignerProvider holding keys in memory.
+type mockSignerProvider struct {
+	keys  map[string]*btcec.PrivateKey
+	signs int
+}
+
+func (p *mockSignerProvider) Name() string {
+	return "mock"
+}
+
+func (p *mockSignerProvider) PublicKey(ref []byte) (*btcec.PublicKey, error) {
+	key, ok := p.keys[string(ref)]
+	if !ok {
+		return nil, errors.New("unknown key")
+	}
+	return key.PubKey(), nil
+}
+
+func (p *mockSignerProvider) SignHash(ref []byte, hash []byte) (*btcec.Signature, error) {
+	key, ok := p.keys[string(ref)]
+	if !ok {
+		return nil, errors.New("unknown key")
+	}
+	p.signs++
+	return key.Sign(hash)
+}
+
+// TestExternalKeySigning ensures that outputs paying to keys imported from a
+// signer provider are spent with signatures created by the provider.
+func TestExternalKeySigning(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	provider := &mockSignerProvider{
+		keys: map[string]*btcec.PrivateKey{"key-1": privKey},
+	}
+
+	// Keys can't be imported before their provider is registered.
+	_, err = w.ImportExternalKey(
+		"mock", []byte("key-1"), waddrmgr.WitnessPubKey,
+	)
+	require.Error(t, err)
+
+	require.NoError(t, w.RegisterSignerProvider(provider))
+	for _, addrType := range []waddrmgr.AddressType{
+		waddrmgr.WitnessPubKey, waddrmgr.NestedWitnessPubKey,
+	} {
+		keyScope := waddrmgr.KeyScopeBIP0084
+		if addrType == waddrmgr.NestedWitnessPubKey {
+			keyScope = waddrmgr.KeyScopeBIP0049Plus
+		}
+
+		addr, err := w.ImportExternalKey(
+			"mock", []byte("key-1"), addrType,
+		)
+		require.NoError(t, err)
+
+		// The wallet must not hold the private key.
+		_, err = w.PrivKeyForAddress(addr)
+		require.Error(t, err)
+
+		pkScript, err := txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+		incomingTx := &wire.MsgTx{
+			TxIn: []*wire.TxIn{
+				{},
+			},
+			TxOut: []*wire.TxOut{
+				wire.NewTxOut(100000, pkScript),
+			},
+		}
+		addUtxo(t, w, incomingTx)
+
+		signs := provider.signs
+		txOuts := []*wire.TxOut{wire.NewTxOut(50000, pkScript)}
+		tx, err := w.txToOutputs(
+			txOuts, &keyScope, waddrmgr.ImportedAddrAccount, 1,
+			1000, CoinSelectionLargest, false,
+		)
+		require.NoError(t, err)
+		require.Equal(t, signs+1, provider.signs)
+
+		err = validateMsgTx(tx.Tx, tx.PrevScripts, tx.PrevInputValues)
+		require.NoError(t, err)
+	}
+}
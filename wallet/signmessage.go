@@ -0,0 +1,48 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/bip322"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// SignMessageBIP322 signs a message with the key of a wallet address of any
+// type the wallet spends from, returning the base64 encoded BIP0322 signature.
+// Signatures for native segwit addresses are in the simple format, and those
+// for other addresses in the full format.  The wallet must be unlocked unless
+// the key is held by a signer provider.
+func (w *Wallet) SignMessageBIP322(addr btcutil.Address,
+	message []byte) (string, error) {
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return "", err
+	}
+
+	tx := &txauthor.AuthoredTx{
+		Tx:              bip322.ToSign(bip322.ToSpend(pkScript, message)),
+		PrevScripts:     [][]byte{pkScript},
+		PrevInputValues: []btcutil.Amount{0},
+		ChangeIndex:     -1,
+	}
+	err = walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		secrets := secretSource{w.Manager, addrmgrNs, w.signerProvider}
+		return tx.AddAllInputScripts(secrets)
+	})
+	if err != nil {
+		return "", err
+	}
+	err = validateMsgTx(tx.Tx, tx.PrevScripts, tx.PrevInputValues)
+	if err != nil {
+		return "", err
+	}
+
+	return bip322.Encode(tx.Tx)
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcwallet/internal/bip322"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSignMessageBIP322 ensures that BIP0322 signatures prove control of
+// addresses of each type of the wallet.
+func TestSignMessageBIP322(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	message := []byte("Hello World")
+	for _, keyScope := range []waddrmgr.KeyScope{
+		waddrmgr.KeyScopeBIP0044,
+		waddrmgr.KeyScopeBIP0049Plus,
+		waddrmgr.KeyScopeBIP0084,
+	} {
+		addr, err := w.CurrentAddress(0, keyScope)
+		require.NoError(t, err)
+		pkScript, err := txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+
+		sig, err := w.SignMessageBIP322(addr, message)
+		require.NoError(t, err, keyScope)
+		require.NoError(t, bip322.Verify(pkScript, message, sig), keyScope)
+		require.Equal(t, bip322.ErrInvalidSignature,
+			bip322.Verify(pkScript, []byte("other"), sig))
+	}
+
+	// Messages can't be signed while the wallet is locked.
+	addr, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+	require.NoError(t, err)
+	w.Lock()
+	require.True(t, w.Locked())
+	_, err = w.SignMessageBIP322(addr, message)
+	require.True(t, waddrmgr.IsError(err, waddrmgr.ErrLocked))
+}
@@ -0,0 +1,579 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// PrevOutput is an output spent by a transaction being signed, along with the
+// scripts needed to redeem it when they are not known by the wallet.
+type PrevOutput struct {
+	// PkScript is the output script.
+	PkScript []byte
+
+	// Amount is the value of the output.  Witness inputs are only signed
+	// when their amount is known, and a zero amount is treated as unknown.
+	Amount btcutil.Amount
+
+	// RedeemScript is the redeem script of a pay-to-script-hash output.
+	RedeemScript []byte
+
+	// WitnessScript is the witness script of a pay-to-witness-script-hash
+	// output, which may be nested in a pay-to-script-hash output.
+	WitnessScript []byte
+}
+
+// SignTransactionWithPrevOutputs uses the keys of the wallet to sign the inputs
+// of a transaction, including witness inputs.  The outputs spent by the inputs
+// are looked up in prevOutputs, and otherwise in the transactions recorded by
+// the wallet, so that transactions built by external tools spending outputs
+// unknown to the wallet may be signed.  The amounts of outputs passed without
+// one, and the redeem and witness scripts missing from prevOutputs, are looked
+// up in the wallet.
+//
+// Witness inputs may only pay to a public key hash or to a multisig witness
+// script.  The valid signatures of cosigners already in the witness of a
+// multisig input are kept, so that the input may be signed in turns.  For any
+// input which could not be signed, or whose scripts do not validate yet, a
+// SignatureError is added to the returns.  While spends require confirmation,
+// the use of the keys must first be authorized with AuthorizeKeyUse.  A
+// SpendPolicyError is returned when the transaction violates the spend policy
+// of an account it spends from.
+//
+// The transaction pointed to by tx is modified by this function.
+func (w *Wallet) SignTransactionWithPrevOutputs(tx *wire.MsgTx,
+	hashType txscript.SigHashType,
+	prevOutputs map[wire.OutPoint]*PrevOutput) ([]SignatureError, error) {
+
+	return w.signTransaction(tx, hashType, prevOutputs, nil, nil)
+}
+
+// signTransaction signs the inputs of a transaction as described by
+// SignTransactionWithPrevOutputs.  When keys are passed, only those keys and
+// the redeem scripts passed with them, both by address, are used rather than
+// those of the wallet.
+func (w *Wallet) signTransaction(tx *wire.MsgTx,
+	hashType txscript.SigHashType, prevOutputs map[wire.OutPoint]*PrevOutput,
+	keys map[string]*btcutil.WIF,
+	scripts map[string][]byte) ([]SignatureError, error) {
+
+	if len(keys) == 0 {
+		if err := w.useKeys(); err != nil {
+			return nil, err
+		}
+	}
+
+	var signErrors []SignatureError
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+		s := &txSigner{
+			w:         w,
+			addrmgrNs: dbtx.ReadBucket(waddrmgrNamespaceKey),
+			keys:      keys,
+			scripts:   scripts,
+		}
+
+		prevOuts := make([]*PrevOutput, len(tx.TxIn))
+		prevScripts := make([][]byte, len(tx.TxIn))
+		var (
+			totalInput btcutil.Amount
+			feeKnown   = true
+		)
+		for i, txIn := range tx.TxIn {
+			prevOut, err := w.prevOutput(
+				txmgrNs, &txIn.PreviousOutPoint,
+				prevOutputs[txIn.PreviousOutPoint],
+			)
+			if err != nil {
+				return err
+			}
+			if prevOut != nil {
+				prevOuts[i] = prevOut
+				prevScripts[i] = prevOut.PkScript
+			}
+			if prevOut == nil || prevOut.Amount == 0 {
+				feeKnown = false
+			} else {
+				totalInput += prevOut.Amount
+			}
+		}
+
+		// Transactions signed with the keys of the wallet must not
+		// violate the spend policies of the accounts they spend from.
+		if len(keys) == 0 {
+			var fee btcutil.Amount
+			if feeKnown {
+				fee = txFee(tx, totalInput)
+			}
+			err := w.reserveTxSpend(dbtx, tx, prevScripts, fee)
+			if err != nil {
+				return err
+			}
+		}
+
+		sigHashes := txscript.NewTxSigHashes(tx)
+		for i, prevOut := range prevOuts {
+			if prevOut == nil {
+				signErrors = append(signErrors, SignatureError{
+					InputIndex: uint32(i),
+					Error: fmt.Errorf("%v not found",
+						tx.TxIn[i].PreviousOutPoint),
+				})
+				continue
+			}
+
+			// SigHashSingle inputs can only be signed if there's a
+			// corresponding output. However this could be already
+			// signed, so we always verify the output.
+			if (hashType&txscript.SigHashSingle) !=
+				txscript.SigHashSingle || i < len(tx.TxOut) {
+
+				err := s.signInput(
+					tx, i, sigHashes, hashType, prevOut,
+				)
+				// Failure to sign isn't an error, it just
+				// means that the tx isn't complete.
+				if err != nil {
+					signErrors = append(signErrors, SignatureError{
+						InputIndex: uint32(i),
+						Error:      err,
+					})
+					continue
+				}
+			}
+
+			// Either it was already signed or we just signed it.
+			// Find out if it is completely satisfied or still
+			// needs more.
+			vm, err := txscript.NewEngine(
+				prevOut.PkScript, tx, i,
+				txscript.StandardVerifyFlags, nil, sigHashes,
+				int64(prevOut.Amount),
+			)
+			if err == nil {
+				err = vm.Execute()
+			}
+			if err != nil {
+				signErrors = append(signErrors, SignatureError{
+					InputIndex: uint32(i),
+					Error:      err,
+				})
+			}
+		}
+		return nil
+	})
+	return signErrors, err
+}
+
+// prevOutput returns the output spent by an input, which is the output passed
+// in if any, with its amount looked up in the wallet when it is unknown, and
+// the output recorded by the wallet otherwise.  Nil is returned if the output
+// is unknown.
+func (w *Wallet) prevOutput(txmgrNs walletdb.ReadBucket, op *wire.OutPoint,
+	passed *PrevOutput) (*PrevOutput, error) {
+
+	if passed != nil && passed.Amount != 0 {
+		return passed, nil
+	}
+	recorded, err := w.recordedPrevOutput(txmgrNs, op)
+	if err != nil || passed == nil {
+		return recorded, err
+	}
+	if recorded == nil || !bytes.Equal(recorded.PkScript, passed.PkScript) {
+		return passed, nil
+	}
+	prevOut := *passed
+	prevOut.Amount = recorded.Amount
+	return &prevOut, nil
+}
+
+// recordedPrevOutput returns the output of a transaction recorded by the
+// wallet, or nil if the transaction or its output is unknown.
+func (w *Wallet) recordedPrevOutput(txmgrNs walletdb.ReadBucket,
+	op *wire.OutPoint) (*PrevOutput, error) {
+
+	details, err := w.TxStore.TxDetails(txmgrNs, &op.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot query previous transaction "+
+			"details for %v: %v", op, err)
+	}
+	if details == nil || op.Index >= uint32(len(details.MsgTx.TxOut)) {
+		return nil, nil
+	}
+	output := details.MsgTx.TxOut[op.Index]
+	return &PrevOutput{
+		PkScript: output.PkScript,
+		Amount:   btcutil.Amount(output.Value),
+	}, nil
+}
+
+// txSigner looks up the keys and scripts signing the inputs of a transaction.
+// These are the keys and scripts of the wallet, unless keys are passed in by
+// the caller, in which case only the passed keys and redeem scripts are used.
+type txSigner struct {
+	w         *Wallet
+	addrmgrNs walletdb.ReadBucket
+
+	// keys and scripts are the keys and redeem scripts passed in by the
+	// caller, by address.
+	keys    map[string]*btcutil.WIF
+	scripts map[string][]byte
+}
+
+// privKey returns the private key of an address, and whether its public key is
+// compressed.
+func (s *txSigner) privKey(addr btcutil.Address) (*btcec.PrivateKey, bool,
+	error) {
+
+	if len(s.keys) != 0 {
+		wif, ok := s.keys[addr.EncodeAddress()]
+		if !ok {
+			return nil, false, errors.New("no key for address")
+		}
+		return wif.PrivKey, wif.CompressPubKey, nil
+	}
+
+	pka, err := s.w.pubKeyAddress(s.addrmgrNs, addr)
+	if err != nil {
+		return nil, false, err
+	}
+	key, err := pka.PrivKey()
+	if err != nil {
+		return nil, false, err
+	}
+	return key, pka.Compressed(), nil
+}
+
+// outputScript returns the redeem or witness script of a pay-to-script-hash
+// or pay-to-witness-script-hash output script.
+func (s *txSigner) outputScript(pkScript []byte) ([]byte, error) {
+	if len(s.keys) == 0 {
+		return s.w.outputScript(s.addrmgrNs, pkScript)
+	}
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		pkScript, s.w.chainParams,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) != 1 {
+		return nil, errors.New("no script for output")
+	}
+	script, ok := s.scripts[addrs[0].EncodeAddress()]
+	if !ok {
+		return nil, errors.New("no script for address")
+	}
+	return script, nil
+}
+
+// signInput adds the signatures of the keys of the signer to an input spending
+// a previous output.
+func (s *txSigner) signInput(tx *wire.MsgTx, idx int,
+	sigHashes *txscript.TxSigHashes, hashType txscript.SigHashType,
+	prevOut *PrevOutput) error {
+
+	txIn := tx.TxIn[idx]
+	pkScript := prevOut.PkScript
+	redeemScript := prevOut.RedeemScript
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.WitnessV0PubKeyHashTy, txscript.WitnessV0ScriptHashTy:
+		return s.signWitnessInput(
+			tx, idx, sigHashes, hashType, prevOut, pkScript,
+		)
+
+	case txscript.ScriptHashTy:
+		if redeemScript == nil {
+			var err error
+			redeemScript, err = s.outputScript(pkScript)
+			if err != nil {
+				return err
+			}
+		}
+		if !txscript.IsWitnessProgram(redeemScript) {
+			break
+		}
+
+		// The signature script of a nested witness input only pushes
+		// the witness program.
+		sigScript, err := txscript.NewScriptBuilder().
+			AddData(redeemScript).Script()
+		if err != nil {
+			return err
+		}
+		txIn.SignatureScript = sigScript
+		return s.signWitnessInput(
+			tx, idx, sigHashes, hashType, prevOut, redeemScript,
+		)
+	}
+
+	getKey := txscript.KeyClosure(s.privKey)
+	getScript := txscript.ScriptClosure(func(addr btcutil.Address) (
+		[]byte, error) {
+
+		if redeemScript == nil {
+			return nil, errors.New("no script for address")
+		}
+		return redeemScript, nil
+	})
+	script, err := txscript.SignTxOutput(
+		s.w.chainParams, tx, idx, pkScript, hashType, getKey,
+		getScript, txIn.SignatureScript,
+	)
+	if err != nil {
+		return err
+	}
+	txIn.SignatureScript = script
+	return nil
+}
+
+// signWitnessInput sets the witness of an input spending a version 0 witness
+// program, which may be nested in a pay-to-script-hash output.
+func (s *txSigner) signWitnessInput(tx *wire.MsgTx, idx int,
+	sigHashes *txscript.TxSigHashes, hashType txscript.SigHashType,
+	prevOut *PrevOutput, program []byte) error {
+
+	if prevOut.Amount == 0 {
+		return errors.New("amount of witness input is unknown")
+	}
+	amount := int64(prevOut.Amount)
+
+	if txscript.IsPayToWitnessPubKeyHash(program) {
+		addr, err := btcutil.NewAddressPubKeyHash(
+			program[2:], s.w.chainParams,
+		)
+		if err != nil {
+			return err
+		}
+		key, _, err := s.privKey(addr)
+		if err != nil && len(s.keys) == 0 &&
+			!bytes.Equal(program, prevOut.PkScript) {
+
+			// Nested witness addresses of the wallet are recorded
+			// by their pay-to-script-hash address.
+			var pka waddrmgr.ManagedPubKeyAddress
+			pka, err = s.w.outputPubKeyAddress(
+				s.addrmgrNs, prevOut.PkScript,
+			)
+			if err == nil {
+				key, err = pka.PrivKey()
+			}
+		}
+		if err != nil {
+			return err
+		}
+		witness, err := txscript.WitnessSignature(
+			tx, sigHashes, idx, amount, program, hashType, key,
+			true,
+		)
+		if err != nil {
+			return err
+		}
+		tx.TxIn[idx].Witness = witness
+		return nil
+	}
+
+	if !txscript.IsPayToWitnessScriptHash(program) {
+		return errors.New("unsupported witness program")
+	}
+	witnessScript := prevOut.WitnessScript
+	if witnessScript == nil {
+		var err error
+		witnessScript, err = s.outputScript(program)
+		if err != nil {
+			return err
+		}
+	}
+	scriptHash := sha256.Sum256(witnessScript)
+	if !bytes.Equal(scriptHash[:], program[2:]) {
+		return errors.New("witness script does not match output")
+	}
+	return s.signMultiSigWitness(
+		tx, idx, sigHashes, hashType, amount, witnessScript,
+	)
+}
+
+// signMultiSigWitness sets the witness of an input redeemed by a multisig
+// witness script to the signatures of the keys of the signer, keeping the
+// valid signatures of cosigners already in the witness.
+func (s *txSigner) signMultiSigWitness(tx *wire.MsgTx, idx int,
+	sigHashes *txscript.TxSigHashes, hashType txscript.SigHashType,
+	amount int64, witnessScript []byte) error {
+
+	class, addrs, nRequired, err := txscript.ExtractPkScriptAddrs(
+		witnessScript, s.w.chainParams,
+	)
+	if err != nil {
+		return err
+	}
+	if class != txscript.MultiSigTy {
+		return errors.New("only multisig witness scripts can be " +
+			"signed")
+	}
+
+	// A multisig witness holds an empty item consumed by the
+	// OP_CHECKMULTISIG bug, the signatures and the witness script.
+	var prevSigs [][]byte
+	if witness := tx.TxIn[idx].Witness; len(witness) > 2 {
+		prevSigs = witness[1 : len(witness)-1]
+	}
+
+	// Signatures must be in the order of the keys of the script.
+	sigs := make([][]byte, 0, nRequired)
+	for _, addr := range addrs {
+		if len(sigs) == nRequired {
+			break
+		}
+		pubKeyAddr, ok := addr.(*btcutil.AddressPubKey)
+		if !ok {
+			continue
+		}
+
+		sig := witnessSigByKey(
+			prevSigs, pubKeyAddr.PubKey(), witnessScript, sigHashes,
+			tx, idx, amount,
+		)
+		if sig != nil {
+			sigs = append(sigs, sig)
+			continue
+		}
+
+		// Keys the signer does not have are those of cosigners.
+		key, _, err := s.privKey(addr)
+		if waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+			return err
+		}
+		if err != nil {
+			continue
+		}
+		sig, err = txscript.RawTxInWitnessSignature(
+			tx, sigHashes, idx, amount, witnessScript, hashType, key,
+		)
+		if err != nil {
+			return err
+		}
+		sigs = append(sigs, sig)
+	}
+
+	witness := make(wire.TxWitness, 0, len(sigs)+2)
+	witness = append(witness, nil)
+	witness = append(witness, sigs...)
+	witness = append(witness, witnessScript)
+	tx.TxIn[idx].Witness = witness
+	return nil
+}
+
+// witnessSigByKey returns the signature among sigs made by a public key over
+// an input redeemed by a witness script, or nil if the key did not sign.
+func witnessSigByKey(sigs [][]byte, pubKey *btcec.PublicKey,
+	witnessScript []byte, sigHashes *txscript.TxSigHashes, tx *wire.MsgTx,
+	idx int, amount int64) []byte {
+
+	for _, sig := range sigs {
+		if len(sig) == 0 {
+			continue
+		}
+		parsed, err := btcec.ParseDERSignature(
+			sig[:len(sig)-1], btcec.S256(),
+		)
+		if err != nil {
+			continue
+		}
+		hash, err := txscript.CalcWitnessSigHash(
+			witnessScript, sigHashes,
+			txscript.SigHashType(sig[len(sig)-1]), tx, idx, amount,
+		)
+		if err != nil {
+			continue
+		}
+		if parsed.Verify(hash, pubKey) {
+			return sig
+		}
+	}
+	return nil
+}
+
+// pubKeyAddress returns the address of the wallet with the public key of an
+// address.
+func (w *Wallet) pubKeyAddress(addrmgrNs walletdb.ReadBucket,
+	addr btcutil.Address) (waddrmgr.ManagedPubKeyAddress, error) {
+
+	maddr, err := w.Manager.Address(addrmgrNs, addr)
+	if err != nil {
+		return nil, err
+	}
+	pka, ok := maddr.(waddrmgr.ManagedPubKeyAddress)
+	if !ok {
+		return nil, fmt.Errorf("address %v is not a pubkey address",
+			addr)
+	}
+	return pka, nil
+}
+
+// outputPubKeyAddress returns the address of the wallet with the public key
+// paid to by an output script.
+func (w *Wallet) outputPubKeyAddress(addrmgrNs walletdb.ReadBucket,
+	pkScript []byte) (waddrmgr.ManagedPubKeyAddress, error) {
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		pkScript, w.chainParams,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) != 1 {
+		return nil, errors.New("no address for output")
+	}
+	return w.pubKeyAddress(addrmgrNs, addrs[0])
+}
+
+// outputScript returns the redeem or witness script known by the wallet for a
+// pay-to-script-hash or pay-to-witness-script-hash output script.  The redeem
+// script of a nested witness address of the wallet is its witness program.
+func (w *Wallet) outputScript(addrmgrNs walletdb.ReadBucket,
+	pkScript []byte) ([]byte, error) {
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		pkScript, w.chainParams,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) != 1 {
+		return nil, errors.New("no script for output")
+	}
+	maddr, err := w.Manager.Address(addrmgrNs, addrs[0])
+	if err != nil {
+		return nil, fmt.Errorf("no script for address %v: %v",
+			addrs[0], err)
+	}
+
+	switch a := maddr.(type) {
+	case waddrmgr.ManagedScriptAddress:
+		return a.Script()
+
+	case waddrmgr.ManagedPubKeyAddress:
+		if a.AddrType() == waddrmgr.NestedWitnessPubKey {
+			pubKeyHash := btcutil.Hash160(
+				a.PubKey().SerializeCompressed(),
+			)
+			return txscript.NewScriptBuilder().
+				AddOp(txscript.OP_0).AddData(pubKeyHash).
+				Script()
+		}
+	}
+	return nil, fmt.Errorf("no script for address %v", addrs[0])
+}
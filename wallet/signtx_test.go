@@ -0,0 +1,109 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSignTransactionWithPrevOutputs ensures that witness and nested witness
+// inputs spending outputs unknown to the wallet are signed with the previous
+// outputs passed in, and that the signatures of cosigners of multisig witness
+// scripts are kept.
+func TestSignTransactionWithPrevOutputs(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	witnessAddr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0084)
+	require.NoError(t, err)
+	witnessScript, err := txscript.PayToAddrScript(witnessAddr)
+	require.NoError(t, err)
+	nestedAddr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0049Plus)
+	require.NoError(t, err)
+	nestedScript, err := txscript.PayToAddrScript(nestedAddr)
+	require.NoError(t, err)
+
+	// A 2-of-2 multisig witness script of a key of the wallet and a key of
+	// a cosigner.
+	pubKey, err := w.PubKeyForAddress(witnessAddr)
+	require.NoError(t, err)
+	cosignerKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	keys := []*btcec.PublicKey{pubKey, cosignerKey.PubKey()}
+	builder := txscript.NewScriptBuilder().AddOp(txscript.OP_2)
+	for _, key := range keys {
+		builder.AddData(key.SerializeCompressed())
+	}
+	multiSigScript, err := builder.AddOp(txscript.OP_2).
+		AddOp(txscript.OP_CHECKMULTISIG).Script()
+	require.NoError(t, err)
+	scriptHash := sha256.Sum256(multiSigScript)
+	multiSigAddr, err := btcutil.NewAddressWitnessScriptHash(
+		scriptHash[:], w.ChainParams(),
+	)
+	require.NoError(t, err)
+	multiSigPkScript, err := txscript.PayToAddrScript(multiSigAddr)
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for i := uint32(0); i < 3; i++ {
+		tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: i}, nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(25e5, witnessScript))
+	prevOutputs := map[wire.OutPoint]*PrevOutput{
+		{Index: 0}: {PkScript: witnessScript, Amount: 1e6},
+		{Index: 1}: {PkScript: nestedScript, Amount: 1e6},
+		{Index: 2}: {
+			PkScript:      multiSigPkScript,
+			Amount:        1e6,
+			WitnessScript: multiSigScript,
+		},
+	}
+
+	// The cosigner signs first, and its signature is kept when the wallet
+	// signs.
+	sigHashes := txscript.NewTxSigHashes(tx)
+	cosignerSig, err := txscript.RawTxInWitnessSignature(
+		tx, sigHashes, 2, 1e6, multiSigScript, txscript.SigHashAll,
+		cosignerKey,
+	)
+	require.NoError(t, err)
+	tx.TxIn[2].Witness = wire.TxWitness{nil, cosignerSig, multiSigScript}
+
+	signErrs, err := w.SignTransactionWithPrevOutputs(
+		tx, txscript.SigHashAll, prevOutputs,
+	)
+	require.NoError(t, err)
+	require.Empty(t, signErrs)
+	require.Len(t, tx.TxIn[1].SignatureScript, 23)
+	require.Len(t, tx.TxIn[2].Witness, 4)
+	require.Equal(t, cosignerSig, tx.TxIn[2].Witness[2])
+
+	// Witness inputs cannot be signed without their amount, and inputs
+	// spending unknown outputs are reported.  Adding an input invalidates
+	// the signature of the cosigner, so the multisig input is incomplete.
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 3}, nil, nil))
+	prevOutputs[wire.OutPoint{Index: 0}].Amount = 0
+	signErrs, err = w.SignTransactionWithPrevOutputs(
+		tx, txscript.SigHashAll, prevOutputs,
+	)
+	require.NoError(t, err)
+	require.Len(t, signErrs, 3)
+	require.Equal(t, uint32(0), signErrs[0].InputIndex)
+	require.EqualError(t, signErrs[0].Error,
+		"amount of witness input is unknown")
+	require.Equal(t, uint32(2), signErrs[1].InputIndex)
+	require.Equal(t, uint32(3), signErrs[2].InputIndex)
+}
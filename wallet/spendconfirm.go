@@ -0,0 +1,360 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/internal/totp"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+)
+
+const (
+	// PendingSpendTimeout is the duration after which a pending spend
+	// which has not been confirmed is cancelled.
+	PendingSpendTimeout = 10 * time.Minute
+
+	// maxSpendCodeAttempts is the number of invalid codes after which a
+	// pending spend is cancelled, and after which codes are refused for
+	// spendCodeBlockDuration, preventing codes from being guessed.
+	maxSpendCodeAttempts = 3
+
+	// maxPendingSpends is the number of pending spends which may await
+	// confirmation at once.
+	maxPendingSpends = 16
+
+	// spendCodeSkew is the number of time steps that the clock of the
+	// authenticator generating codes may drift in either direction.
+	spendCodeSkew = 1
+
+	// KeyUseAuthorizationTimeout is the duration after which a key use
+	// authorized by AuthorizeKeyUse expires unless the keys were used.
+	KeyUseAuthorizationTimeout = time.Minute
+
+	// spendCodeBlockDuration is the duration for which all codes are
+	// refused after maxSpendCodeAttempts invalid codes, whether they were
+	// passed to confirm spends or to authorize key use.
+	spendCodeBlockDuration = 10 * time.Minute
+)
+
+var (
+	// ErrSpendConfirmationDisabled describes an error where a pending
+	// spend is confirmed or created while spends do not require
+	// confirmation.
+	ErrSpendConfirmationDisabled = errors.New("spend confirmation is " +
+		"not enabled")
+
+	// ErrUnknownPendingSpend describes an error where a pending spend
+	// token is unknown, or belongs to a spend which was already confirmed,
+	// cancelled or has timed out.
+	ErrUnknownPendingSpend = errors.New("unknown or expired pending spend")
+
+	// ErrInvalidSpendCode describes an error where a pending spend is
+	// confirmed with a code which is invalid or was already used.
+	ErrInvalidSpendCode = errors.New("invalid spend confirmation code")
+
+	// ErrTooManyPendingSpends describes an error where a pending spend is
+	// created while maxPendingSpends spends await confirmation.
+	ErrTooManyPendingSpends = errors.New("too many spends awaiting " +
+		"confirmation")
+
+	// ErrKeyUseNotAuthorized describes an error where the private keys
+	// of the wallet are used to sign a transaction or are exported while
+	// spends require confirmation, without being authorized by
+	// AuthorizeKeyUse.
+	ErrKeyUseNotAuthorized = errors.New("use of private keys must be " +
+		"authorized with a spend confirmation code")
+
+	// ErrSpendConfirmationRequired describes an error where a send is
+	// published without confirmation while spends require confirmation.
+	ErrSpendConfirmationRequired = errors.New("spend must be confirmed " +
+		"with a spend confirmation code")
+)
+
+// pendingSpend is a signed transaction awaiting confirmation before it is
+// published.
+type pendingSpend struct {
+	tx           *wire.MsgTx
+	reservations []*spendReservation
+	label        string
+	comment      string
+	commentTo    string
+	attempts     int
+	timer        *time.Timer
+}
+
+// SetSpendConfirmationSecret sets the TOTP secret of the codes confirming
+// spends.  While a secret is set, sends are not published when they are
+// created, but only once confirmed with a code generated from the secret by
+// an authenticator, protecting against spends requested by a compromised
+// frontend.  Likewise, transactions are only signed and private keys only
+// exported once authorized with AuthorizeKeyUse.  A nil secret disables spend
+// confirmation.
+func (w *Wallet) SetSpendConfirmationSecret(secret []byte) {
+	w.spendConfirmMtx.Lock()
+	w.spendTOTPSecret = secret
+	w.spendConfirmMtx.Unlock()
+}
+
+// SpendConfirmationRequired returns whether sends must be confirmed with a
+// TOTP code before they are published.
+func (w *Wallet) SpendConfirmationRequired() bool {
+	w.spendConfirmMtx.Lock()
+	defer w.spendConfirmMtx.Unlock()
+
+	return w.spendTOTPSecret != nil
+}
+
+// CreatePendingSpend creates a signed payment transaction like SendOutputs,
+// but rather than publishing it, holds it until it is confirmed with
+// ConfirmPendingSpend, returning the token identifying the pending spend.  The
+// inputs of the transaction are locked until the spend is confirmed, cancelled
+// or times out after PendingSpendTimeout.
+func (w *Wallet) CreatePendingSpend(outputs []*wire.TxOut,
+	keyScope *waddrmgr.KeyScope, account uint32, minconf int32,
+	satPerKb btcutil.Amount, coinSelectionStrategy CoinSelectionStrategy,
+	label string, optFuncs ...TxCreateOption) (string, *wire.MsgTx, error) {
+
+	if !w.SpendConfirmationRequired() {
+		return "", nil, ErrSpendConfirmationDisabled
+	}
+
+	createdTx, reservations, err := w.createSendTx(
+		outputs, keyScope, account, minconf, satPerKb,
+		coinSelectionStrategy, append(optFuncs, withLockedInputs())...,
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	if w.Manager.WatchOnly() {
+		w.unlockInputs(createdTx.Tx)
+		return "", createdTx.Tx, ErrTxUnsigned
+	}
+
+	opts := defaultTxCreateOptions()
+	for _, optFunc := range optFuncs {
+		optFunc(opts)
+	}
+
+	token, err := w.addPendingSpend(
+		createdTx.Tx, reservations, label, opts.comment, opts.commentTo,
+	)
+	if err != nil {
+		w.unlockInputs(createdTx.Tx)
+		w.releaseSpend(reservations)
+		return "", nil, err
+	}
+	return token, createdTx.Tx, nil
+}
+
+// addPendingSpend holds a signed transaction until it is confirmed and returns
+// the token identifying the pending spend.  The inputs of the transaction must
+// already be locked, and are unlocked once the pending spend is removed, along
+// with the release of the reservations of the spend.
+func (w *Wallet) addPendingSpend(tx *wire.MsgTx,
+	reservations []*spendReservation, label, comment,
+	commentTo string) (string, error) {
+
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	w.spendConfirmMtx.Lock()
+	if len(w.pendingSpends) >= maxPendingSpends {
+		w.spendConfirmMtx.Unlock()
+		return "", ErrTooManyPendingSpends
+	}
+	if w.pendingSpends == nil {
+		w.pendingSpends = make(map[string]*pendingSpend)
+	}
+	w.pendingSpends[token] = &pendingSpend{
+		tx:           tx,
+		reservations: reservations,
+		label:        label,
+		comment:      comment,
+		commentTo:    commentTo,
+		timer: time.AfterFunc(PendingSpendTimeout, func() {
+			if w.CancelPendingSpend(token) == nil {
+				log.Infof("Pending spend of transaction %v "+
+					"timed out", tx.TxHash())
+			}
+		}),
+	}
+	w.spendConfirmMtx.Unlock()
+
+	return token, nil
+}
+
+// randomToken returns a random hex encoded token identifying a transaction
+// held by the wallet.
+func randomToken() (string, error) {
+	var tokenBytes [16]byte
+	if _, err := rand.Read(tokenBytes[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes[:]), nil
+}
+
+// ConfirmPendingSpend publishes the transaction of a pending spend once the
+// TOTP code confirming it is validated.  Each code may only be used once, and
+// the pending spend is cancelled after too many invalid codes.  Invalid codes
+// are also counted across all spends and key use authorizations, refusing all
+// codes for a while after too many.
+func (w *Wallet) ConfirmPendingSpend(token, code string) (*wire.MsgTx, error) {
+	w.spendConfirmMtx.Lock()
+	if w.spendTOTPSecret == nil {
+		w.spendConfirmMtx.Unlock()
+		return nil, ErrSpendConfirmationDisabled
+	}
+	spend, ok := w.pendingSpends[token]
+	if !ok {
+		w.spendConfirmMtx.Unlock()
+		return nil, ErrUnknownPendingSpend
+	}
+
+	if !w.checkSpendCode(code) {
+		spend.attempts++
+		if spend.attempts >= maxSpendCodeAttempts {
+			w.removePendingSpend(token, spend)
+			log.Warnf("Cancelled pending spend of transaction %v "+
+				"after %d invalid confirmation codes",
+				spend.tx.TxHash(), spend.attempts)
+		}
+		w.spendConfirmMtx.Unlock()
+		return nil, ErrInvalidSpendCode
+	}
+	spend.timer.Stop()
+	delete(w.pendingSpends, token)
+	w.spendConfirmMtx.Unlock()
+
+	// The inputs remain locked until the transaction spending them is
+	// recorded, preventing them from being selected by another send in
+	// the meantime.
+	txHash, err := w.reliablyPublishTransaction(
+		spend.tx, spend.label, spend.comment, spend.commentTo,
+	)
+	w.unlockInputs(spend.tx)
+	if err != nil {
+		w.releaseSpend(spend.reservations)
+		return nil, err
+	}
+
+	// Sanity check on the returned tx hash.
+	if *txHash != spend.tx.TxHash() {
+		return nil, errors.New("tx hash mismatch")
+	}
+
+	return spend.tx, nil
+}
+
+// validateSpendCode returns whether a TOTP code generated from the spend
+// confirmation secret is valid and was not used before, marking it used.  The
+// spend confirmation mutex must be held.
+func (w *Wallet) validateSpendCode(code string) bool {
+	step, ok := totp.Validate(
+		w.spendTOTPSecret, code, w.Now(), spendCodeSkew,
+	)
+	if !ok || step <= w.lastSpendCodeStep {
+		return false
+	}
+	w.lastSpendCodeStep = step
+	return true
+}
+
+// checkSpendCode returns whether a code confirming a spend or authorizing key
+// use is valid like validateSpendCode.  Invalid codes are counted across the
+// wallet, and after maxSpendCodeAttempts of them, all codes are refused until
+// spendCodeBlockDuration has passed.  The spend confirmation mutex must be
+// held.
+func (w *Wallet) checkSpendCode(code string) bool {
+	now := w.Now()
+	if now.Before(w.spendCodeBlockedUntil) || !w.validateSpendCode(code) {
+		w.spendCodeAttempts++
+		if w.spendCodeAttempts >= maxSpendCodeAttempts {
+			w.spendCodeAttempts = 0
+			w.spendCodeBlockedUntil = now.Add(spendCodeBlockDuration)
+			log.Warnf("Refusing spend confirmation codes for %v "+
+				"after %d invalid codes", spendCodeBlockDuration,
+				maxSpendCodeAttempts)
+		}
+		return false
+	}
+	w.spendCodeAttempts = 0
+	return true
+}
+
+// AuthorizeKeyUse authorizes a single use of the private keys of the wallet,
+// either to sign a transaction or to export keys, with a TOTP code generated
+// from the spend confirmation secret.  The authorization expires after
+// KeyUseAuthorizationTimeout unless the keys are used.  After too many
+// invalid codes, key use is not authorized for a while, preventing codes from
+// being guessed.
+func (w *Wallet) AuthorizeKeyUse(code string) error {
+	w.spendConfirmMtx.Lock()
+	defer w.spendConfirmMtx.Unlock()
+
+	if w.spendTOTPSecret == nil {
+		return ErrSpendConfirmationDisabled
+	}
+
+	if !w.checkSpendCode(code) {
+		return ErrInvalidSpendCode
+	}
+	w.keyUseAuthorizedUntil = w.Now().Add(KeyUseAuthorizationTimeout)
+	return nil
+}
+
+// useKeys consumes the key use authorized by AuthorizeKeyUse before the
+// private keys of the wallet are used to sign a transaction or are exported.
+// Keys may always be used while spends do not require confirmation.
+func (w *Wallet) useKeys() error {
+	w.spendConfirmMtx.Lock()
+	defer w.spendConfirmMtx.Unlock()
+
+	if w.spendTOTPSecret == nil {
+		return nil
+	}
+	if !w.Now().Before(w.keyUseAuthorizedUntil) {
+		return ErrKeyUseNotAuthorized
+	}
+	w.keyUseAuthorizedUntil = time.Time{}
+	return nil
+}
+
+// CancelPendingSpend discards a pending spend, unlocking its inputs.
+func (w *Wallet) CancelPendingSpend(token string) error {
+	w.spendConfirmMtx.Lock()
+	defer w.spendConfirmMtx.Unlock()
+
+	spend, ok := w.pendingSpends[token]
+	if !ok {
+		return ErrUnknownPendingSpend
+	}
+	w.removePendingSpend(token, spend)
+	return nil
+}
+
+// removePendingSpend removes a pending spend, stopping its timeout, unlocking
+// its inputs and releasing its reservations.  The spend confirmation mutex
+// must be held.
+func (w *Wallet) removePendingSpend(token string, spend *pendingSpend) {
+	spend.timer.Stop()
+	delete(w.pendingSpends, token)
+	w.unlockInputs(spend.tx)
+	w.releaseSpend(spend.reservations)
+}
+
+// unlockInputs unlocks the outpoints spent by the inputs of a transaction.
+func (w *Wallet) unlockInputs(tx *wire.MsgTx) {
+	for _, txIn := range tx.TxIn {
+		w.UnlockOutpoint(txIn.PreviousOutPoint)
+	}
+}
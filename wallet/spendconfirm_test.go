@@ -0,0 +1,278 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/internal/totp"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPendingSpend ensures that sends requiring confirmation are only
+// published once confirmed with a valid TOTP code, and that their inputs are
+// locked while they are pending.
+func TestPendingSpend(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	now := time.Unix(1600000000, 0)
+	w.clock = func() time.Time { return now }
+
+	keyScope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.CurrentAddress(0, keyScope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	addUtxo(t, w, &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{},
+		},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(100000, pkScript),
+			wire.NewTxOut(100000, pkScript),
+		},
+	})
+
+	createSpend := func() (string, *wire.MsgTx) {
+		token, tx, err := w.CreatePendingSpend(
+			[]*wire.TxOut{wire.NewTxOut(10000, pkScript)},
+			&keyScope, 0, 1, 1000, CoinSelectionLargest, "",
+		)
+		require.NoError(t, err)
+		for _, txIn := range tx.TxIn {
+			require.True(t, w.LockedOutpoint(txIn.PreviousOutPoint))
+		}
+		return token, tx
+	}
+	requireUnlocked := func(tx *wire.MsgTx) {
+		for _, txIn := range tx.TxIn {
+			require.False(t, w.LockedOutpoint(txIn.PreviousOutPoint))
+		}
+	}
+
+	// Pending spends can't be created until a secret is set.
+	_, _, err = w.CreatePendingSpend(
+		[]*wire.TxOut{wire.NewTxOut(10000, pkScript)}, &keyScope, 0, 1,
+		1000, CoinSelectionLargest, "",
+	)
+	require.Equal(t, ErrSpendConfirmationDisabled, err)
+
+	secret := []byte("12345678901234567890")
+	w.SetSpendConfirmationSecret(secret)
+	require.True(t, w.SpendConfirmationRequired())
+
+	// An invalid code leaves the spend pending, while a valid one
+	// publishes it and unlocks its inputs.
+	token, tx := createSpend()
+	code := totp.Code(secret, totp.Step(now))
+	_, err = w.ConfirmPendingSpend(token, "000000")
+	require.Equal(t, ErrInvalidSpendCode, err)
+	confirmed, err := w.ConfirmPendingSpend(token, code)
+	require.NoError(t, err)
+	require.Equal(t, tx.TxHash(), confirmed.TxHash())
+	requireUnlocked(tx)
+	_, err = w.ConfirmPendingSpend(token, code)
+	require.Equal(t, ErrUnknownPendingSpend, err)
+
+	// A code can't be used twice, and the spend is cancelled after too
+	// many invalid codes.
+	token, tx = createSpend()
+	for i := 0; i < maxSpendCodeAttempts; i++ {
+		_, err = w.ConfirmPendingSpend(token, code)
+		require.Equal(t, ErrInvalidSpendCode, err)
+	}
+	requireUnlocked(tx)
+	_, err = w.ConfirmPendingSpend(
+		token, totp.Code(secret, totp.Step(now)+1),
+	)
+	require.Equal(t, ErrUnknownPendingSpend, err)
+
+	// Cancelled spends unlock their inputs.
+	token, tx = createSpend()
+	require.NoError(t, w.CancelPendingSpend(token))
+	requireUnlocked(tx)
+	require.Equal(t, ErrUnknownPendingSpend, w.CancelPendingSpend(token))
+}
+
+// TestPendingSpendsConcurrent ensures that pending spends created at the same
+// time never select the same inputs.
+func TestPendingSpendsConcurrent(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	keyScope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.CurrentAddress(0, keyScope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	addUtxo(t, w, &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{},
+		},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(100000, pkScript),
+			wire.NewTxOut(100000, pkScript),
+		},
+	})
+	w.SetSpendConfirmationSecret([]byte("12345678901234567890"))
+
+	const numSpends = 2
+	txs := make(chan *wire.MsgTx, numSpends)
+	errs := make(chan error, numSpends)
+	for i := 0; i < numSpends; i++ {
+		go func() {
+			_, tx, err := w.CreatePendingSpend(
+				[]*wire.TxOut{wire.NewTxOut(10000, pkScript)},
+				&keyScope, 0, 1, 1000, CoinSelectionLargest, "",
+			)
+			txs <- tx
+			errs <- err
+		}()
+	}
+
+	spent := make(map[wire.OutPoint]struct{})
+	for i := 0; i < numSpends; i++ {
+		require.NoError(t, <-errs)
+		for _, txIn := range (<-txs).TxIn {
+			_, ok := spent[txIn.PreviousOutPoint]
+			require.False(t, ok, "input %v selected twice",
+				txIn.PreviousOutPoint)
+			spent[txIn.PreviousOutPoint] = struct{}{}
+		}
+	}
+}
+
+// TestAuthorizeKeyUse ensures that while spends require confirmation, the
+// keys of the wallet are only exported or used for signing once authorized
+// with a valid TOTP code, and that each authorization is used only once.
+func TestAuthorizeKeyUse(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	now := time.Unix(1600000000, 0)
+	w.clock = func() time.Time { return now }
+
+	addr, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0084)
+	require.NoError(t, err)
+
+	// Keys may be used freely while spends do not require confirmation.
+	require.Equal(t, ErrSpendConfirmationDisabled, w.AuthorizeKeyUse("000000"))
+	_, err = w.DumpWIFPrivateKey(addr)
+	require.NoError(t, err)
+
+	secret := []byte("12345678901234567890")
+	w.SetSpendConfirmationSecret(secret)
+
+	_, err = w.DumpWIFPrivateKey(addr)
+	require.Equal(t, ErrKeyUseNotAuthorized, err)
+	_, err = w.DumpPrivKeys()
+	require.Equal(t, ErrKeyUseNotAuthorized, err)
+	_, err = w.SignTransactionWithPrevOutputs(
+		&wire.MsgTx{}, txscript.SigHashAll, nil,
+	)
+	require.Equal(t, ErrKeyUseNotAuthorized, err)
+
+	// A valid code authorizes a single use of the keys.
+	require.NoError(t, w.AuthorizeKeyUse(totp.Code(secret, totp.Step(now))))
+	_, err = w.DumpWIFPrivateKey(addr)
+	require.NoError(t, err)
+	_, err = w.DumpWIFPrivateKey(addr)
+	require.Equal(t, ErrKeyUseNotAuthorized, err)
+
+	// Codes can't be reused, and authorizations expire.
+	require.Equal(t, ErrInvalidSpendCode,
+		w.AuthorizeKeyUse(totp.Code(secret, totp.Step(now))))
+	now = now.Add(totp.Period)
+	require.NoError(t, w.AuthorizeKeyUse(totp.Code(secret, totp.Step(now))))
+	now = now.Add(KeyUseAuthorizationTimeout)
+	_, err = w.DumpPrivKeys()
+	require.Equal(t, ErrKeyUseNotAuthorized, err)
+
+	// After too many invalid codes, even valid codes are refused for a
+	// while.
+	for i := 0; i < maxSpendCodeAttempts; i++ {
+		require.Equal(t, ErrInvalidSpendCode, w.AuthorizeKeyUse("000000"))
+	}
+	now = now.Add(totp.Period)
+	require.Equal(t, ErrInvalidSpendCode,
+		w.AuthorizeKeyUse(totp.Code(secret, totp.Step(now))))
+	now = now.Add(spendCodeBlockDuration)
+	require.NoError(t, w.AuthorizeKeyUse(totp.Code(secret, totp.Step(now))))
+	_, err = w.DumpPrivKeys()
+	require.NoError(t, err)
+}
+
+// TestSpendCodeLimit ensures that invalid codes are limited across all pending
+// spends and key use authorizations, and that the number of pending spends is
+// limited.
+func TestSpendCodeLimit(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	now := time.Unix(1600000000, 0)
+	w.clock = func() time.Time { return now }
+
+	keyScope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.CurrentAddress(0, keyScope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	utxos := &wire.MsgTx{TxIn: []*wire.TxIn{{}}}
+	for i := 0; i <= maxPendingSpends; i++ {
+		utxos.AddTxOut(wire.NewTxOut(100000, pkScript))
+	}
+	addUtxo(t, w, utxos)
+
+	secret := []byte("12345678901234567890")
+	w.SetSpendConfirmationSecret(secret)
+
+	createSpend := func() (string, *wire.MsgTx, error) {
+		return w.CreatePendingSpend(
+			[]*wire.TxOut{wire.NewTxOut(10000, pkScript)},
+			&keyScope, 0, 1, 1000, CoinSelectionLargest, "",
+		)
+	}
+	var tokens []string
+	for i := 0; i < maxPendingSpends; i++ {
+		token, _, err := createSpend()
+		require.NoError(t, err)
+		tokens = append(tokens, token)
+	}
+
+	// No more spends may be pending at once, and the inputs of a refused
+	// spend are unlocked.
+	_, tx, err := createSpend()
+	require.Equal(t, ErrTooManyPendingSpends, err)
+	require.Nil(t, tx)
+	var locked int
+	for i := range utxos.TxOut {
+		op := wire.OutPoint{Hash: utxos.TxHash(), Index: uint32(i)}
+		if w.LockedOutpoint(op) {
+			locked++
+		}
+	}
+	require.Equal(t, maxPendingSpends, locked)
+
+	// Invalid codes spread over different spends, each below the limit
+	// of a single spend, refuse all codes for a while.
+	for i := 0; i < maxSpendCodeAttempts; i++ {
+		_, err = w.ConfirmPendingSpend(tokens[i], "000000")
+		require.Equal(t, ErrInvalidSpendCode, err)
+	}
+	code := totp.Code(secret, totp.Step(now))
+	_, err = w.ConfirmPendingSpend(tokens[0], code)
+	require.Equal(t, ErrInvalidSpendCode, err)
+	require.Equal(t, ErrInvalidSpendCode, w.AuthorizeKeyUse(code))
+
+	now = now.Add(spendCodeBlockDuration)
+	code = totp.Code(secret, totp.Step(now))
+	_, err = w.ConfirmPendingSpend(tokens[maxSpendCodeAttempts], code)
+	require.NoError(t, err)
+}
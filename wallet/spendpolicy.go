@@ -0,0 +1,622 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+const (
+	// spendPolicyWindow is the period over which the amounts sent by an
+	// account are limited by the MaxPerDay limit of its spend policy.
+	spendPolicyWindow = 24 * time.Hour
+
+	// maxBlockTimeSkew is the maximum amount of time a block's timestamp
+	// may be ahead of the time its transactions were received, which
+	// bounds how far back the transaction history is scanned for sends of
+	// the spend policy window.
+	maxBlockTimeSkew = 2 * time.Hour
+)
+
+// Rules of a spend policy reported by SpendPolicyError.
+const (
+	SpendPolicyMaxPerTx  = "maxpertx"
+	SpendPolicyMaxPerDay = "maxperday"
+	SpendPolicyWhitelist = "whitelist"
+)
+
+// SpendPolicyError describes a send which violates the spend policy of the
+// account it spends from.
+type SpendPolicyError struct {
+	// Account is the name of the account.
+	Account string
+
+	// Rule is the violated rule of the policy, one of SpendPolicyMaxPerTx,
+	// SpendPolicyMaxPerDay or SpendPolicyWhitelist.
+	Rule string
+
+	// Limit is the limit of the violated rule, and Amount the amount
+	// which exceeds it, including previous sends for SpendPolicyMaxPerDay.
+	// Both are zero for SpendPolicyWhitelist.
+	Limit  btcutil.Amount
+	Amount btcutil.Amount
+
+	// Address is the destination which is not whitelisted for
+	// SpendPolicyWhitelist.
+	Address string
+}
+
+// Error implements the error interface.
+func (e *SpendPolicyError) Error() string {
+	switch e.Rule {
+	case SpendPolicyWhitelist:
+		return fmt.Sprintf("spend policy of account %q violated: "+
+			"destination %s is not whitelisted", e.Account,
+			e.Address)
+	case SpendPolicyMaxPerDay:
+		return fmt.Sprintf("spend policy of account %q violated: "+
+			"sending %v in 24 hours exceeds the limit of %v",
+			e.Account, e.Amount, e.Limit)
+	default:
+		return fmt.Sprintf("spend policy of account %q violated: "+
+			"sending %v in one transaction exceeds the limit of %v",
+			e.Account, e.Amount, e.Limit)
+	}
+}
+
+// SpendPolicy returns the spend policy of an account, or nil if the account
+// has none.
+func (w *Wallet) SpendPolicy(scope waddrmgr.KeyScope,
+	account uint32) (*waddrmgr.SpendPolicy, error) {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy *waddrmgr.SpendPolicy
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		var err error
+		policy, err = manager.SpendPolicy(addrmgrNs, account)
+		return err
+	})
+	return policy, err
+}
+
+// SetSpendPolicy replaces the spend policy of an account, which is checked
+// by every send spending from the account and before any transaction spending
+// from the account is signed.  A nil policy removes the policy.
+// Changes are recorded by the audit log.
+func (w *Wallet) SetSpendPolicy(scope waddrmgr.KeyScope, account uint32,
+	policy *waddrmgr.SpendPolicy) error {
+
+	if policy != nil {
+		if policy.MaxPerTx < 0 || policy.MaxPerDay < 0 {
+			return fmt.Errorf("spend policy limits must not be " +
+				"negative")
+		}
+		for _, encoded := range policy.Whitelist {
+			_, err := btcutil.DecodeAddress(encoded, w.chainParams)
+			if err != nil {
+				return fmt.Errorf("invalid whitelisted address "+
+					"%q: %v", encoded, err)
+			}
+		}
+	}
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	var name string
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		err := manager.SetSpendPolicy(addrmgrNs, account, policy)
+		if err != nil {
+			return err
+		}
+		name, err = manager.AccountName(addrmgrNs, account)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if policy == nil {
+		auditLog.Infof("Removed spend policy of account %q", name)
+	} else {
+		auditLog.Infof("Set spend policy of account %q: max per "+
+			"transaction %v, max per 24 hours %v, %d whitelisted "+
+			"addresses", name, policy.MaxPerTx, policy.MaxPerDay,
+			len(policy.Whitelist))
+	}
+	return nil
+}
+
+// SpentInPolicyWindow returns the amount sent by an account during the last
+// 24 hours, which is limited by the MaxPerDay limit of its spend policy.  This
+// includes the spends which were permitted by the policy but are not recorded
+// yet, such as those awaiting confirmation.
+func (w *Wallet) SpentInPolicyWindow(scope waddrmgr.KeyScope,
+	account uint32) (btcutil.Amount, error) {
+
+	w.spendPolicyMtx.Lock()
+	defer w.spendPolicyMtx.Unlock()
+
+	var spent btcutil.Amount
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		var err error
+		spent, err = w.spentInPolicyWindow(
+			tx, accountLockKey{scope, account}, nil,
+		)
+		return err
+	})
+	return spent, err
+}
+
+// spendReservation is a spend from an account which was permitted by the
+// account's spend policy, but may not be recorded by the wallet yet.  Until a
+// transaction of the spend is recorded, its amount counts towards the MaxPerDay
+// limit of the policy, so that spends which are created concurrently, held
+// for confirmation or signed without being published can't together exceed
+// the limit.
+type spendReservation struct {
+	account accountLockKey
+	amount  btcutil.Amount
+	created time.Time
+
+	// txHash identifies the transaction of the spend by its hash without
+	// input scripts.
+	txHash *chainhash.Hash
+}
+
+// spendTxHash returns the hash of a transaction without its input scripts,
+// which identifies the transaction of a spend no matter which of its inputs
+// are signed.
+func spendTxHash(tx *wire.MsgTx) chainhash.Hash {
+	stripped := tx.Copy()
+	for _, txIn := range stripped.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	return stripped.TxHash()
+}
+
+// reserveSpend returns a SpendPolicyError when the transaction of a send from
+// an account, paying the fee, violates the account's spend policy, and
+// reserves the amount the account sends otherwise, as given by accountSend.  When no key scope is specified, the policies of the account in
+// every key scope are checked.  The reservations must be passed to
+// releaseSpend if the transaction is not published.  Violations are recorded
+// by the audit log.
+func (w *Wallet) reserveSpend(tx *wire.MsgTx, fee btcutil.Amount,
+	keyScope *waddrmgr.KeyScope, account uint32) ([]*spendReservation, error) {
+
+	var scopes []waddrmgr.KeyScope
+	if keyScope != nil {
+		scopes = []waddrmgr.KeyScope{*keyScope}
+	} else {
+		for _, manager := range w.Manager.ActiveScopedKeyManagers() {
+			scopes = append(scopes, manager.Scope())
+		}
+	}
+
+	txHash := spendTxHash(tx)
+
+	w.spendPolicyMtx.Lock()
+	defer w.spendPolicyMtx.Unlock()
+
+	var (
+		violation    *SpendPolicyError
+		amount       btcutil.Amount
+		reservations []*spendReservation
+	)
+	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
+		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+		for _, scope := range scopes {
+			manager, err := w.Manager.FetchScopedKeyManager(scope)
+			if err != nil {
+				return err
+			}
+			policy, err := manager.SpendPolicy(addrmgrNs, account)
+			switch {
+			// The account may only exist in some of the key
+			// scopes when none is specified.
+			case keyScope == nil && waddrmgr.IsError(
+				err, waddrmgr.ErrAccountNotFound,
+			):
+				continue
+			case err != nil:
+				return err
+			case policy == nil:
+				continue
+			}
+
+			key := accountLockKey{scope, account}
+			var outputs []*wire.TxOut
+			outputs, amount = w.accountSend(addrmgrNs, key, tx, fee)
+			violation, err = w.spendPolicyViolation(
+				dbtx, scope, account, policy, outputs, amount,
+				&txHash,
+			)
+			if err != nil || violation != nil {
+				if violation != nil {
+					violation.Account, err = manager.AccountName(
+						addrmgrNs, account,
+					)
+				}
+				return err
+			}
+			reservations = append(reservations, &spendReservation{
+				account: key,
+				amount:  amount,
+				txHash:  &txHash,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if violation != nil {
+		auditLog.Warnf("Refused send of %v: %v", amount, violation)
+		return nil, violation
+	}
+	w.addSpendReservations(reservations)
+	return reservations, nil
+}
+
+// reserveTxSpend checks a transaction whose inputs are about to be signed by
+// the wallet against the spend policies of the accounts whose outputs it
+// spends, as given by the output scripts spent by its inputs, and reserves the
+// amount each account sends otherwise, as given by accountSend.  The fee is
+// zero when the values of the inputs are not all known.  Inputs with a nil
+// output script are not signed by the wallet and are ignored.  As the
+// transaction may be published by anyone once signed, the reservations are
+// only released once the transaction is recorded, or the spend leaves the
+// spend policy window.
+func (w *Wallet) reserveTxSpend(dbtx walletdb.ReadTx, tx *wire.MsgTx,
+	prevScripts [][]byte, fee btcutil.Amount) error {
+
+	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+
+	w.spendPolicyMtx.Lock()
+	defer w.spendPolicyMtx.Unlock()
+
+	txHash := spendTxHash(tx)
+	checked := make(map[accountLockKey]struct{})
+	var reservations []*spendReservation
+	for _, prevScript := range prevScripts {
+		if prevScript == nil {
+			continue
+		}
+		manager, account, ok := w.scriptAccount(addrmgrNs, prevScript)
+		if !ok {
+			continue
+		}
+		key := accountLockKey{manager.Scope(), account}
+		if _, ok := checked[key]; ok {
+			continue
+		}
+		checked[key] = struct{}{}
+
+		policy, err := manager.SpendPolicy(addrmgrNs, account)
+		if err != nil {
+			return err
+		}
+		if policy == nil {
+			continue
+		}
+
+		outputs, amount := w.accountSend(addrmgrNs, key, tx, fee)
+		violation, err := w.spendPolicyViolation(
+			dbtx, key.scope, account, policy, outputs, amount,
+			&txHash,
+		)
+		if err != nil {
+			return err
+		}
+		if violation != nil {
+			violation.Account, err = manager.AccountName(
+				addrmgrNs, account,
+			)
+			if err != nil {
+				return err
+			}
+			auditLog.Warnf("Refused to sign transaction %v: %v",
+				tx.TxHash(), violation)
+			return violation
+		}
+		reservations = append(reservations, &spendReservation{
+			account: key,
+			amount:  amount,
+			txHash:  &txHash,
+		})
+	}
+
+	// The reservations of a transaction which was signed before are
+	// replaced, so that its spend is not counted twice.
+	for _, r := range reservations {
+		w.removeTxReservation(r.account, txHash)
+	}
+	w.addSpendReservations(reservations)
+	return nil
+}
+
+// accountSend returns the outputs of a transaction spending from an account
+// which do not pay back to the account, along with the amount the account
+// sends, which is the total of those outputs and the fee of the transaction.
+func (w *Wallet) accountSend(addrmgrNs walletdb.ReadBucket, key accountLockKey,
+	tx *wire.MsgTx, fee btcutil.Amount) ([]*wire.TxOut, btcutil.Amount) {
+
+	var outputs []*wire.TxOut
+	amount := fee
+	for _, output := range tx.TxOut {
+		m, acct, ok := w.scriptAccount(addrmgrNs, output.PkScript)
+		if ok && m.Scope() == key.scope && acct == key.account {
+			continue
+		}
+		outputs = append(outputs, output)
+		amount += btcutil.Amount(output.Value)
+	}
+	return outputs, amount
+}
+
+// txFee returns the fee of a transaction spending inputs totalling
+// totalInput.
+func txFee(tx *wire.MsgTx, totalInput btcutil.Amount) btcutil.Amount {
+	fee := totalInput
+	for _, output := range tx.TxOut {
+		fee -= btcutil.Amount(output.Value)
+	}
+	if fee < 0 {
+		return 0
+	}
+	return fee
+}
+
+// scriptAccount returns the scoped manager and account of the address an
+// output script pays to, or false if it does not pay to an address of the
+// wallet.
+func (w *Wallet) scriptAccount(addrmgrNs walletdb.ReadBucket,
+	pkScript []byte) (*waddrmgr.ScopedKeyManager, uint32, bool) {
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+		pkScript, w.chainParams,
+	)
+	if err != nil || len(addrs) == 0 {
+		return nil, 0, false
+	}
+	manager, account, err := w.Manager.AddrAccount(addrmgrNs, addrs[0])
+	if err != nil {
+		return nil, 0, false
+	}
+	return manager, account, true
+}
+
+// addSpendReservations records reservations of spends, pruning those which
+// left the spend policy window.  The spend policy mutex must be held.
+func (w *Wallet) addSpendReservations(reservations []*spendReservation) {
+	now := w.Now()
+	since := now.Add(-spendPolicyWindow)
+	for r := range w.spendReservations {
+		if r.created.Before(since) {
+			delete(w.spendReservations, r)
+		}
+	}
+	if len(reservations) == 0 {
+		return
+	}
+	if w.spendReservations == nil {
+		w.spendReservations = make(map[*spendReservation]struct{})
+	}
+	for _, r := range reservations {
+		r.created = now
+		w.spendReservations[r] = struct{}{}
+	}
+}
+
+// removeTxReservation removes the reservation of an account for the spend of
+// a transaction, if any.  The spend policy mutex must be held.
+func (w *Wallet) removeTxReservation(key accountLockKey, txHash chainhash.Hash) {
+	for r := range w.spendReservations {
+		if r.account == key && r.txHash != nil && *r.txHash == txHash {
+			delete(w.spendReservations, r)
+		}
+	}
+}
+
+// releaseSpend releases reservations of spends which were not created or
+// published.
+func (w *Wallet) releaseSpend(reservations []*spendReservation) {
+	w.spendPolicyMtx.Lock()
+	for _, r := range reservations {
+		delete(w.spendReservations, r)
+	}
+	w.spendPolicyMtx.Unlock()
+}
+
+// spendPolicyViolation returns the violation of the spend policy of an account
+// by a send of the outputs totalling amount, or nil if the send is permitted.
+// When the hash of the transaction of the send is known, any reservation of
+// the send made when the transaction was signed before is not counted.  The
+// spend policy mutex must be held.
+func (w *Wallet) spendPolicyViolation(dbtx walletdb.ReadTx,
+	scope waddrmgr.KeyScope, account uint32, policy *waddrmgr.SpendPolicy,
+	outputs []*wire.TxOut, amount btcutil.Amount,
+	txHash *chainhash.Hash) (*SpendPolicyError, error) {
+
+	if len(policy.Whitelist) > 0 {
+		whitelist := make(map[string]struct{}, len(policy.Whitelist))
+		for _, encoded := range policy.Whitelist {
+			whitelist[encoded] = struct{}{}
+		}
+		for _, output := range outputs {
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				output.PkScript, w.chainParams,
+			)
+			if err != nil || len(addrs) != 1 {
+				return &SpendPolicyError{
+					Rule:    SpendPolicyWhitelist,
+					Address: fmt.Sprintf("%x", output.PkScript),
+				}, nil
+			}
+			encoded := addrs[0].EncodeAddress()
+			if _, ok := whitelist[encoded]; !ok {
+				return &SpendPolicyError{
+					Rule:    SpendPolicyWhitelist,
+					Address: encoded,
+				}, nil
+			}
+		}
+	}
+
+	if policy.MaxPerTx != 0 && amount > policy.MaxPerTx {
+		return &SpendPolicyError{
+			Rule:   SpendPolicyMaxPerTx,
+			Limit:  policy.MaxPerTx,
+			Amount: amount,
+		}, nil
+	}
+
+	if policy.MaxPerDay != 0 {
+		spent, err := w.spentInPolicyWindow(
+			dbtx, accountLockKey{scope, account}, txHash,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if spent+amount > policy.MaxPerDay {
+			return &SpendPolicyError{
+				Rule:   SpendPolicyMaxPerDay,
+				Limit:  policy.MaxPerDay,
+				Amount: spent + amount,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// spentInPolicyWindow returns the amount sent by an account during the spend
+// policy window, which is the amount of the recorded transactions spending from
+// the account along with that of the reserved spends whose transactions are
+// not recorded.  The reservations of the transaction with the hash excluded,
+// if any, are not counted.  The spend policy mutex must be held.
+func (w *Wallet) spentInPolicyWindow(dbtx walletdb.ReadTx, key accountLockKey,
+	excluded *chainhash.Hash) (btcutil.Amount, error) {
+
+	since := w.Now().Add(-spendPolicyWindow)
+	spent, recorded, err := w.spentSince(dbtx, key.scope, key.account, since)
+	if err != nil {
+		return 0, err
+	}
+	for r := range w.spendReservations {
+		if r.account != key || r.created.Before(since) {
+			continue
+		}
+		if r.txHash != nil {
+			if _, ok := recorded[*r.txHash]; ok {
+				continue
+			}
+			if excluded != nil && *r.txHash == *excluded {
+				continue
+			}
+		}
+		spent += r.amount
+	}
+	return spent, nil
+}
+
+// spentSince returns the amount sent by the transactions spending from an
+// account which were received after the time, along with the set of those
+// transactions by their hash without input scripts.  The amount of a
+// transaction is given by accountSend, and its fee is only counted when all of
+// its inputs were spent from the wallet.
+func (w *Wallet) spentSince(dbtx walletdb.ReadTx, scope waddrmgr.KeyScope,
+	account uint32, since time.Time) (btcutil.Amount,
+	map[chainhash.Hash]struct{}, error) {
+
+	addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
+	txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
+	key := accountLockKey{scope, account}
+
+	// ownedByAccount returns whether an output script pays to an address
+	// of the account.
+	ownedByAccount := func(pkScript []byte) bool {
+		manager, acct, ok := w.scriptAccount(addrmgrNs, pkScript)
+		return ok && manager.Scope() == scope && acct == account
+	}
+
+	var spent btcutil.Amount
+	spends := make(map[chainhash.Hash]struct{})
+	rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
+		// Transactions are iterated from the most recent block, so
+		// stop once blocks are too old to contain any transaction
+		// received within the window.
+		block := details[0].Block
+		if block.Height != -1 &&
+			block.Time.Before(since.Add(-maxBlockTimeSkew)) {
+
+			return true, nil
+		}
+
+		for i := range details {
+			detail := &details[i]
+			if detail.Received.Before(since) ||
+				len(detail.Debits) == 0 {
+
+				continue
+			}
+
+			var fromAccount bool
+			for _, debit := range detail.Debits {
+				prevOut := detail.MsgTx.TxIn[debit.Index].PreviousOutPoint
+				prev, err := w.TxStore.TxDetails(
+					txmgrNs, &prevOut.Hash,
+				)
+				if err != nil {
+					return false, err
+				}
+				if prev != nil && ownedByAccount(
+					prev.MsgTx.TxOut[prevOut.Index].PkScript,
+				) {
+					fromAccount = true
+					break
+				}
+			}
+			if !fromAccount {
+				continue
+			}
+
+			var fee btcutil.Amount
+			if len(detail.Debits) == len(detail.MsgTx.TxIn) {
+				var totalInput btcutil.Amount
+				for _, debit := range detail.Debits {
+					totalInput += debit.Amount
+				}
+				fee = txFee(&detail.MsgTx, totalInput)
+			}
+			_, amount := w.accountSend(
+				addrmgrNs, key, &detail.MsgTx, fee,
+			)
+			spends[spendTxHash(&detail.MsgTx)] = struct{}{}
+			spent += amount
+		}
+		return false, nil
+	}
+	err := w.TxStore.RangeTransactions(txmgrNs, -1, 0, rangeFn)
+	return spent, spends, err
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSpendPolicy ensures that sends violating the spend policy of the
+// account they spend from are refused, and that the amounts sent during the
+// last 24 hours are limited.
+func TestSpendPolicy(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	now := time.Now()
+	w.clock = func() time.Time { return now }
+
+	keyScope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.CurrentAddress(0, keyScope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	addUtxo(t, w, &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{},
+		},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(100000, pkScript),
+			wire.NewTxOut(100000, pkScript),
+		},
+	})
+
+	payees := make([][]byte, 2)
+	for i := range payees {
+		hash := make([]byte, 20)
+		hash[0] = byte(i + 1)
+		payee, err := btcutil.NewAddressWitnessPubKeyHash(
+			hash, w.ChainParams(),
+		)
+		require.NoError(t, err)
+		payees[i], err = txscript.PayToAddrScript(payee)
+		require.NoError(t, err)
+		if i == 0 {
+			policy := &waddrmgr.SpendPolicy{
+				MaxPerTx:  50000,
+				MaxPerDay: 70000,
+				Whitelist: []string{payee.EncodeAddress()},
+			}
+			require.NoError(t, w.SetSpendPolicy(keyScope, 0, policy))
+
+			stored, err := w.SpendPolicy(keyScope, 0)
+			require.NoError(t, err)
+			require.Equal(t, policy, stored)
+		}
+	}
+
+	var sent *wire.MsgTx
+	send := func(pkScript []byte, amount int64) error {
+		var err error
+		sent, err = w.SendOutputs(
+			[]*wire.TxOut{wire.NewTxOut(amount, pkScript)},
+			&keyScope, 0, 0, 1000, CoinSelectionLargest, "",
+		)
+		return err
+	}
+	requireViolation := func(err error, rule string) {
+		policyErr, ok := err.(*SpendPolicyError)
+		require.True(t, ok, "unexpected error %v", err)
+		require.Equal(t, rule, policyErr.Rule)
+		require.Equal(t, "default", policyErr.Account)
+	}
+
+	requireViolation(send(payees[1], 10000), SpendPolicyWhitelist)
+	requireViolation(send(payees[0], 60000), SpendPolicyMaxPerTx)
+	require.NoError(t, send(payees[0], 40000))
+
+	// The amount sent includes the fee, but not the change.
+	spent, err := w.SpentInPolicyWindow(keyScope, 0)
+	require.NoError(t, err)
+	require.Equal(t, txFee(sent, 100000)+40000, spent)
+
+	// Sends back to the account only count their fee.
+	require.NoError(t, send(pkScript, 10000))
+	spent += txFee(sent, 100000)
+	spentAfter, err := w.SpentInPolicyWindow(keyScope, 0)
+	require.NoError(t, err)
+	require.Equal(t, spent, spentAfter)
+	requireViolation(send(payees[0], 40000), SpendPolicyMaxPerDay)
+
+	// Sends older than 24 hours no longer count towards the limit.
+	now = now.Add(25 * time.Hour)
+	require.NoError(t, send(payees[0], 40000))
+
+	// Without a policy, any send is permitted.
+	require.NoError(t, w.SetSpendPolicy(keyScope, 0, nil))
+	policy, err := w.SpendPolicy(keyScope, 0)
+	require.NoError(t, err)
+	require.Nil(t, policy)
+	require.NoError(t, send(payees[1], 60000))
+}
+
+// TestSpendPolicyReservations ensures that spends which are not recorded yet,
+// such as concurrent sends, pending spends and transactions signed by the
+// wallet, count towards the limits of the spend policy.
+func TestSpendPolicyReservations(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	keyScope := waddrmgr.KeyScopeBIP0084
+	addr, err := w.CurrentAddress(0, keyScope)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+	incomingTx := &wire.MsgTx{
+		TxIn: []*wire.TxIn{
+			{},
+		},
+		TxOut: []*wire.TxOut{
+			wire.NewTxOut(100000, pkScript),
+			wire.NewTxOut(100000, pkScript),
+			wire.NewTxOut(100000, pkScript),
+		},
+	}
+	addUtxo(t, w, incomingTx)
+
+	payees := make([][]byte, 2)
+	for i := range payees {
+		hash := make([]byte, 20)
+		hash[0] = byte(i + 1)
+		payee, err := btcutil.NewAddressWitnessPubKeyHash(
+			hash, w.ChainParams(),
+		)
+		require.NoError(t, err)
+		payees[i], err = txscript.PayToAddrScript(payee)
+		require.NoError(t, err)
+		if i == 0 {
+			policy := &waddrmgr.SpendPolicy{
+				MaxPerDay: 75000,
+				Whitelist: []string{payee.EncodeAddress()},
+			}
+			require.NoError(t, w.SetSpendPolicy(keyScope, 0, policy))
+		}
+	}
+	requireSpent := func(amount btcutil.Amount) {
+		spent, err := w.SpentInPolicyWindow(keyScope, 0)
+		require.NoError(t, err)
+		require.Equal(t, amount, spent)
+	}
+	requireViolation := func(err error, rule string) {
+		policyErr, ok := err.(*SpendPolicyError)
+		require.True(t, ok, "unexpected error %v", err)
+		require.Equal(t, rule, policyErr.Rule)
+	}
+
+	// Transactions signed by the wallet are checked against the policy,
+	// and their fee counts while their change does not.  Signing a
+	// transaction again does not count it twice.
+	sign := func(outputs ...*wire.TxOut) error {
+		tx := &wire.MsgTx{
+			Version: 2,
+			TxIn: []*wire.TxIn{
+				wire.NewTxIn(&wire.OutPoint{
+					Hash: incomingTx.TxHash(),
+				}, nil, nil),
+			},
+			TxOut: outputs,
+		}
+		_, err := w.SignTransactionWithPrevOutputs(
+			tx, txscript.SigHashAll, nil,
+		)
+		return err
+	}
+	requireViolation(
+		sign(wire.NewTxOut(60000, payees[1])), SpendPolicyWhitelist,
+	)
+	signed := []*wire.TxOut{
+		wire.NewTxOut(30000, payees[0]),
+		wire.NewTxOut(69000, pkScript),
+	}
+	require.NoError(t, sign(signed...))
+	require.NoError(t, sign(signed...))
+	requireSpent(31000)
+
+	// Concurrent pending spends can't together exceed the limit.
+	w.SetSpendConfirmationSecret([]byte("12345678901234567890"))
+	errs := make(chan error, 2)
+	tokens := make(chan string, 2)
+	txs := make(chan *wire.MsgTx, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			token, tx, err := w.CreatePendingSpend(
+				[]*wire.TxOut{wire.NewTxOut(40000, payees[0])},
+				&keyScope, 0, 1, 1000, CoinSelectionLargest, "",
+			)
+			tokens <- token
+			txs <- tx
+			errs <- err
+		}()
+	}
+	var (
+		token      string
+		pendingTx  *wire.MsgTx
+		violations int
+	)
+	for i := 0; i < 2; i++ {
+		if pending := <-tokens; pending != "" {
+			token = pending
+		}
+		if tx := <-txs; tx != nil {
+			pendingTx = tx
+		}
+		if err := <-errs; err != nil {
+			requireViolation(err, SpendPolicyMaxPerDay)
+			violations++
+		}
+	}
+	require.Equal(t, 1, violations)
+	requireSpent(31000 + 40000 + txFee(pendingTx, 100000))
+
+	// Cancelling a pending spend releases its reservation.
+	require.NoError(t, w.CancelPendingSpend(token))
+	requireSpent(31000)
+}
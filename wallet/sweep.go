@@ -0,0 +1,314 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wallet/txsizes"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+)
+
+var (
+	// ErrNoSweepableOutputs is returned when a sweep finds no unspent
+	// outputs paying to the addresses of the key being swept.
+	ErrNoSweepableOutputs = errors.New("no unspent outputs found for key")
+
+	// ErrSweepAmountDust is returned when the total value of the outputs
+	// found for a sweep does not cover the fee required to spend them
+	// while leaving a non-dust output.
+	ErrSweepAmountDust = errors.New("swept amount is too small to " +
+		"cover the transaction fee")
+)
+
+// uncompressedPubKeyExtra is the number of bytes by which an uncompressed
+// public key exceeds the compressed public key that txsizes assumes for P2PKH
+// inputs.
+const uncompressedPubKeyExtra = 65 - 33
+
+// SweepResult describes a transaction created by SweepPrivKey.
+type SweepResult struct {
+	Tx         *wire.MsgTx
+	Address    btcutil.Address
+	InputCount int
+	Total      btcutil.Amount
+	Fee        btcutil.Amount
+}
+
+// sweepSecrets is an implementation of txauthor.SecretsSource for a single
+// private key which is never written to the wallet database.
+type sweepSecrets struct {
+	wif         *btcutil.WIF
+	chainParams *chaincfg.Params
+}
+
+// GetKey returns the private key being swept.  Callers only request keys for
+// the addresses the key was scanned for, so the address is not checked.
+func (s sweepSecrets) GetKey(btcutil.Address) (*btcec.PrivateKey, bool, error) {
+	return s.wif.PrivKey, s.wif.CompressPubKey, nil
+}
+
+// GetScript always errors since a single key is not able to redeem any
+// script hash outputs other than nested witness outputs, which are signed
+// without a script lookup.
+func (s sweepSecrets) GetScript(btcutil.Address) ([]byte, error) {
+	return nil, errors.New("no redeem scripts are known for swept keys")
+}
+
+// ChainParams returns the network parameters of the wallet.
+func (s sweepSecrets) ChainParams() *chaincfg.Params {
+	return s.chainParams
+}
+
+// sweepAddrs returns every address type the wallet is able to spend from
+// using the passed private key.  Witness addresses are only returned for
+// compressed keys since uncompressed public keys are non-standard in witness
+// programs.
+func sweepAddrs(wif *btcutil.WIF, params *chaincfg.Params) ([]btcutil.Address, error) {
+	pubKeyHash := btcutil.Hash160(wif.SerializePubKey())
+	p2pkh, err := btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+	if err != nil {
+		return nil, err
+	}
+	addrs := []btcutil.Address{p2pkh}
+	if !wif.CompressPubKey {
+		return addrs, nil
+	}
+
+	p2wkh, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+	if err != nil {
+		return nil, err
+	}
+	witnessProgram, err := txscript.PayToAddrScript(p2wkh)
+	if err != nil {
+		return nil, err
+	}
+	np2wkh, err := btcutil.NewAddressScriptHash(witnessProgram, params)
+	if err != nil {
+		return nil, err
+	}
+	return append(addrs, p2wkh, np2wkh), nil
+}
+
+// sweepSize returns the estimated virtual size of a sweep transaction which
+// spends inputs of each address type to the output with the key of wif.  P2PKH
+// inputs spent with an uncompressed key are larger than txsizes estimates, as
+// they include the uncompressed public key.
+func sweepSize(wif *btcutil.WIF, p2pkh, p2wpkh, nested int,
+	output *wire.TxOut) int {
+
+	size := txsizes.EstimateVirtualSize(
+		p2pkh, p2wpkh, nested, []*wire.TxOut{output}, 0,
+	)
+	if !wif.CompressPubKey {
+		size += p2pkh * uncompressedPubKeyExtra
+	}
+	return size
+}
+
+// findSweepOutputs scans the blocks from startHeight through the current
+// best block for outputs paying to any of addrs, returning every output that
+// remained unspent by the end of the scan.
+func (w *Wallet) findSweepOutputs(chainClient chain.Interface,
+	addrs []btcutil.Address, startHeight int32) ([]wtxmgr.Credit, error) {
+
+	_, bestHeight, err := chainClient.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+	if startHeight < 0 || startHeight > bestHeight {
+		return nil, fmt.Errorf("start height %d is outside of the "+
+			"range of the current chain (best height %d)",
+			startHeight, bestHeight)
+	}
+
+	// The filter request matches outputs by address, so index the
+	// addresses as external addresses of an unused key scope.
+	filterAddrs := make(map[waddrmgr.ScopedIndex]btcutil.Address, len(addrs))
+	for i, addr := range addrs {
+		filterAddrs[waddrmgr.ScopedIndex{Index: uint32(i)}] = addr
+	}
+
+	unspent := make(map[wire.OutPoint]wtxmgr.Credit)
+	watched := make(map[wire.OutPoint]btcutil.Address)
+
+	log.Infof("Scanning blocks %d-%d for outputs to sweep", startHeight,
+		bestHeight)
+
+	// The blocks of the next batch are fetched while a batch is filtered.
+	done := make(chan struct{})
+	defer close(done)
+	batches := pipelineBlockBatches(
+		chainClient, startHeight, bestHeight, recoveryBatchSize, done,
+	)
+	for next := range batches {
+		if next.err != nil {
+			return nil, next.err
+		}
+		batch := next.blocks
+
+		// Filter the batch, restarting after each block that reports
+		// relevant transactions so that newly found outputs are
+		// watched for spends in later blocks.
+		for len(batch) > 0 {
+			resp, err := chainClient.FilterBlocks(&chain.FilterBlocksRequest{
+				Blocks:           batch,
+				ExternalAddrs:    filterAddrs,
+				InternalAddrs:    map[waddrmgr.ScopedIndex]btcutil.Address{},
+				WatchedOutPoints: watched,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if resp == nil {
+				break
+			}
+
+			for _, tx := range resp.RelevantTxns {
+				for _, in := range tx.TxIn {
+					delete(unspent, in.PreviousOutPoint)
+					delete(watched, in.PreviousOutPoint)
+				}
+				txHash := tx.TxHash()
+				for i, out := range tx.TxOut {
+					op := wire.OutPoint{Hash: txHash, Index: uint32(i)}
+					addr, ok := resp.FoundOutPoints[op]
+					if !ok {
+						continue
+					}
+					unspent[op] = wtxmgr.Credit{
+						OutPoint:     op,
+						BlockMeta:    resp.BlockMeta,
+						Amount:       btcutil.Amount(out.Value),
+						PkScript:     out.PkScript,
+						FromCoinBase: blockchain.IsCoinBaseTx(tx),
+					}
+					watched[op] = addr
+				}
+			}
+
+			batch = batch[resp.BatchIndex+1:]
+		}
+	}
+
+	credits := make([]wtxmgr.Credit, 0, len(unspent))
+	for _, credit := range unspent {
+		// Immature coinbase outputs can not be swept yet.
+		if credit.FromCoinBase {
+			confs := confirms(credit.Height, bestHeight)
+			if confs < int32(w.chainParams.CoinbaseMaturity) {
+				continue
+			}
+		}
+		credits = append(credits, credit)
+	}
+	return credits, nil
+}
+
+// SweepPrivKey finds all unspent outputs paying to the addresses of a private
+// key, beginning the search at block startHeight, and creates and publishes a
+// transaction spending all of them to a new address of the given account.  The
+// private key is only used to sign the sweep transaction and is never imported
+// into the wallet.
+func (w *Wallet) SweepPrivKey(wif *btcutil.WIF, scope waddrmgr.KeyScope,
+	account uint32, startHeight int32, feeSatPerKb btcutil.Amount) (
+	*SweepResult, error) {
+
+	if !wif.IsForNet(w.chainParams) {
+		return nil, errors.New("private key is not intended for " +
+			w.chainParams.Name)
+	}
+
+	chainClient, err := w.requireChainClient()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := sweepAddrs(wif, w.chainParams)
+	if err != nil {
+		return nil, err
+	}
+	credits, err := w.findSweepOutputs(chainClient, addrs, startHeight)
+	if err != nil {
+		return nil, err
+	}
+	if len(credits) == 0 {
+		return nil, ErrNoSweepableOutputs
+	}
+
+	// Create the sweep transaction with a single output to a new address
+	// of the account, initially for the full input value.
+	tx := wire.NewMsgTx(wire.TxVersion)
+	prevScripts := make([][]byte, 0, len(credits))
+	inputValues := make([]btcutil.Amount, 0, len(credits))
+	var total btcutil.Amount
+	var nested, p2wpkh, p2pkh int
+	for i := range credits {
+		credit := &credits[i]
+		tx.AddTxIn(wire.NewTxIn(&credit.OutPoint, nil, nil))
+		prevScripts = append(prevScripts, credit.PkScript)
+		inputValues = append(inputValues, credit.Amount)
+		total += credit.Amount
+
+		switch {
+		case txscript.IsPayToScriptHash(credit.PkScript):
+			nested++
+		case txscript.IsPayToWitnessPubKeyHash(credit.PkScript):
+			p2wpkh++
+		default:
+			p2pkh++
+		}
+	}
+
+	addr, err := w.NewAddress(account, scope)
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+	output := wire.NewTxOut(int64(total), pkScript)
+
+	size := sweepSize(wif, p2pkh, p2wpkh, nested, output)
+	fee := txrules.FeeForSerializeSize(feeSatPerKb, size)
+	output.Value = int64(total - fee)
+	if output.Value <= 0 || txrules.IsDustOutput(output, txrules.DefaultRelayFeePerKb) {
+		return nil, ErrSweepAmountDust
+	}
+	tx.AddTxOut(output)
+
+	secrets := sweepSecrets{wif: wif, chainParams: w.chainParams}
+	err = txauthor.AddAllInputScripts(tx, prevScripts, inputValues, secrets)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMsgTx(tx, prevScripts, inputValues); err != nil {
+		return nil, err
+	}
+
+	if err := w.PublishTransaction(tx, ""); err != nil {
+		return nil, err
+	}
+
+	return &SweepResult{
+		Tx:         tx,
+		Address:    addr,
+		InputCount: len(credits),
+		Total:      total,
+		Fee:        fee,
+	}, nil
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+)
+
+// TestSweepSecrets ensures that transactions spending every address type
+// returned by sweepAddrs can be signed using only the swept private key, and
+// that the size of the signed transactions does not exceed the size the sweep
+// fee is estimated for.
+func TestSweepSecrets(t *testing.T) {
+	t.Parallel()
+
+	params := &chaincfg.TestNet3Params
+
+	tests := []struct {
+		name       string
+		compressed bool
+		numAddrs   int
+	}{
+		{name: "compressed", compressed: true, numAddrs: 3},
+		{name: "uncompressed", compressed: false, numAddrs: 1},
+	}
+
+	for _, test := range tests {
+		privKey, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Fatalf("%s: unable to create key: %v", test.name, err)
+		}
+		wif, err := btcutil.NewWIF(privKey, params, test.compressed)
+		if err != nil {
+			t.Fatalf("%s: unable to create wif: %v", test.name, err)
+		}
+
+		addrs, err := sweepAddrs(wif, params)
+		if err != nil {
+			t.Fatalf("%s: unable to derive addresses: %v",
+				test.name, err)
+		}
+		if len(addrs) != test.numAddrs {
+			t.Fatalf("%s: expected %d addresses, got %d", test.name,
+				test.numAddrs, len(addrs))
+		}
+
+		tx := wire.NewMsgTx(wire.TxVersion)
+		prevScripts := make([][]byte, 0, len(addrs))
+		inputValues := make([]btcutil.Amount, 0, len(addrs))
+		var nested, p2wpkh, p2pkh int
+		for i, addr := range addrs {
+			pkScript, err := txscript.PayToAddrScript(addr)
+			if err != nil {
+				t.Fatalf("%s: unable to create script: %v",
+					test.name, err)
+			}
+			prevOut := wire.NewOutPoint(&chainhash.Hash{}, uint32(i))
+			tx.AddTxIn(wire.NewTxIn(prevOut, nil, nil))
+			prevScripts = append(prevScripts, pkScript)
+			inputValues = append(inputValues, btcutil.SatoshiPerBitcoin)
+
+			switch addr.(type) {
+			case *btcutil.AddressScriptHash:
+				nested++
+			case *btcutil.AddressWitnessPubKeyHash:
+				p2wpkh++
+			default:
+				p2pkh++
+			}
+		}
+		tx.AddTxOut(wire.NewTxOut(1e7, prevScripts[0]))
+
+		secrets := sweepSecrets{wif: wif, chainParams: params}
+		err = txauthor.AddAllInputScripts(
+			tx, prevScripts, inputValues, secrets,
+		)
+		if err != nil {
+			t.Fatalf("%s: unable to sign transaction: %v",
+				test.name, err)
+		}
+		if err := validateMsgTx(tx, prevScripts, inputValues); err != nil {
+			t.Fatalf("%s: invalid signed transaction: %v",
+				test.name, err)
+		}
+
+		weight := blockchain.GetTransactionWeight(btcutil.NewTx(tx))
+		size := int((weight + blockchain.WitnessScaleFactor - 1) /
+			blockchain.WitnessScaleFactor)
+		estimate := sweepSize(wif, p2pkh, p2wpkh, nested, tx.TxOut[0])
+		if size > estimate {
+			t.Fatalf("%s: signed transaction of %d vbytes exceeds "+
+				"the estimate of %d vbytes", test.name, size,
+				estimate)
+		}
+	}
+}
@@ -8,6 +8,7 @@ package txauthor
 import (
 	"errors"
 
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
@@ -63,7 +64,8 @@ type AuthoredTx struct {
 // ChangeSource provides change output scripts for transaction creation.
 type ChangeSource struct {
 	// NewScript is a closure that produces unique change output scripts per
-	// invocation.
+	// invocation.  When nil, no change output is created and any remaining
+	// output value is paid as fee.
 	NewScript func() ([]byte, error)
 
 	// ScriptSize is the size in bytes of scripts produced by `NewScript`.
@@ -143,17 +145,19 @@ func NewUnsignedTransaction(outputs []*wire.TxOut, feeRatePerKb btcutil.Amount,
 
 		changeIndex := -1
 		changeAmount := inputAmount - targetAmount - maxRequiredFee
-		changeScript, err := changeSource.NewScript()
-		if err != nil {
-			return nil, err
-		}
-		change := wire.NewTxOut(int64(changeAmount), changeScript)
-		if changeAmount != 0 && !txrules.IsDustOutput(change,
-			txrules.DefaultRelayFeePerKb) {
+		if changeSource.NewScript != nil {
+			changeScript, err := changeSource.NewScript()
+			if err != nil {
+				return nil, err
+			}
+			change := wire.NewTxOut(int64(changeAmount), changeScript)
+			if changeAmount != 0 && !txrules.IsDustOutput(change,
+				txrules.DefaultRelayFeePerKb) {
 
-			l := len(outputs)
-			unsignedTransaction.TxOut = append(outputs[:l:l], change)
-			changeIndex = l
+				l := len(outputs)
+				unsignedTransaction.TxOut = append(outputs[:l:l], change)
+				changeIndex = l
+			}
 		}
 
 		return &AuthoredTx{
@@ -198,6 +202,79 @@ type SecretsSource interface {
 	ChainParams() *chaincfg.Params
 }
 
+// ExternalSigner is implemented by a SecretsSource which also has access to
+// keys held outside of the source, such as in a hardware security module,
+// whose private keys are never made available.  Witness inputs spending to the
+// addresses of these keys are signed by passing their signature hash to
+// SignHash rather than by looking up their private key.
+type ExternalSigner interface {
+	// ExternalKey returns the public key of an address whose key is held
+	// externally, or nil if the address' key is not.
+	ExternalKey(addr btcutil.Address) (*btcec.PublicKey, error)
+
+	// SignHash signs a signature hash with the external key of an address.
+	SignHash(addr btcutil.Address, hash []byte) (*btcec.Signature, error)
+}
+
+// witnessSignFunc creates the witness of a p2wkh witness program spent by
+// input idx of a transaction, serializing the public key compressed when
+// compress is set.
+type witnessSignFunc func(tx *wire.MsgTx, hashCache *txscript.TxSigHashes,
+	idx int, inputValue int64, witnessProgram []byte,
+	compress bool) (wire.TxWitness, error)
+
+// witnessKey looks up the key of the address of a witness input, returning its
+// public key, whether the key is compressed, and the function signing the
+// input.  Inputs are signed externally if secrets is an ExternalSigner holding
+// the key of the address, and with the private key of the address otherwise.
+func witnessKey(addr btcutil.Address, secrets SecretsSource) (*btcec.PublicKey,
+	bool, witnessSignFunc, error) {
+
+	if signer, ok := secrets.(ExternalSigner); ok {
+		pubKey, err := signer.ExternalKey(addr)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		if pubKey != nil {
+			sign := func(tx *wire.MsgTx, hashCache *txscript.TxSigHashes,
+				idx int, inputValue int64, witnessProgram []byte,
+				_ bool) (wire.TxWitness, error) {
+
+				hash, err := txscript.CalcWitnessSigHash(
+					witnessProgram, hashCache,
+					txscript.SigHashAll, tx, idx, inputValue,
+				)
+				if err != nil {
+					return nil, err
+				}
+				sig, err := signer.SignHash(addr, hash)
+				if err != nil {
+					return nil, err
+				}
+				return wire.TxWitness{
+					append(sig.Serialize(), byte(txscript.SigHashAll)),
+					pubKey.SerializeCompressed(),
+				}, nil
+			}
+			return pubKey, true, sign, nil
+		}
+	}
+
+	privKey, compressed, err := secrets.GetKey(addr)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	sign := func(tx *wire.MsgTx, hashCache *txscript.TxSigHashes,
+		idx int, inputValue int64, witnessProgram []byte,
+		compress bool) (wire.TxWitness, error) {
+
+		return txscript.WitnessSignature(tx, hashCache, idx,
+			inputValue, witnessProgram, txscript.SigHashAll,
+			privKey, compress)
+	}
+	return privKey.PubKey(), compressed, sign, nil
+}
+
 // AddAllInputScripts modifies transaction a transaction by adding inputs
 // scripts for each input.  Previous output scripts being redeemed by each input
 // are passed in prevPkScripts and the slice length must match the number of
@@ -267,11 +344,10 @@ func spendWitnessKeyHash(txIn *wire.TxIn, pkScript []byte,
 	if err != nil {
 		return err
 	}
-	privKey, compressed, err := secrets.GetKey(addrs[0])
+	pubKey, compressed, sign, err := witnessKey(addrs[0], secrets)
 	if err != nil {
 		return err
 	}
-	pubKey := privKey.PubKey()
 
 	// Once we have the key pair, generate a p2wkh address type, respecting
 	// the compression type of the generated key.
@@ -293,8 +369,8 @@ func spendWitnessKeyHash(txIn *wire.TxIn, pkScript []byte,
 	if err != nil {
 		return err
 	}
-	witnessScript, err := txscript.WitnessSignature(tx, hashCache, idx,
-		inputValue, witnessProgram, txscript.SigHashAll, privKey, true)
+	witnessScript, err := sign(tx, hashCache, idx, inputValue,
+		witnessProgram, true)
 	if err != nil {
 		return err
 	}
@@ -321,11 +397,10 @@ func spendNestedWitnessPubKeyHash(txIn *wire.TxIn, pkScript []byte,
 	if err != nil {
 		return err
 	}
-	privKey, compressed, err := secrets.GetKey(addrs[0])
+	pubKey, compressed, sign, err := witnessKey(addrs[0], secrets)
 	if err != nil {
 		return err
 	}
-	pubKey := privKey.PubKey()
 
 	var pubKeyHash []byte
 	if compressed {
@@ -356,8 +431,8 @@ func spendNestedWitnessPubKeyHash(txIn *wire.TxIn, pkScript []byte,
 
 	// With the sigScript in place, we'll next generate the proper witness
 	// that'll allow us to spend the p2wkh output.
-	witnessScript, err := txscript.WitnessSignature(tx, hashCache, idx,
-		inputValue, witnessProgram, txscript.SigHashAll, privKey, compressed)
+	witnessScript, err := sign(tx, hashCache, idx, inputValue,
+		witnessProgram, compressed)
 	if err != nil {
 		return err
 	}
@@ -5,8 +5,12 @@
 package txauthor
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
@@ -220,3 +224,124 @@ func TestNewUnsignedTransaction(t *testing.T) {
 		}
 	}
 }
+
+// TestNewUnsignedTransactionNoChange ensures that a change source without a
+// script function never adds a change output, paying the remaining output
+// value as fee.
+func TestNewUnsignedTransactionNoChange(t *testing.T) {
+	inputSource := makeInputSource(p2pkhOutputs(1e8))
+	outputs := p2pkhOutputs(1e6)
+	tx, err := NewUnsignedTransaction(outputs, 1e3, inputSource, &ChangeSource{})
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	if tx.ChangeIndex >= 0 {
+		t.Fatalf("change output added at index %d", tx.ChangeIndex)
+	}
+	if len(tx.Tx.TxOut) != 1 {
+		t.Fatalf("expected 1 output, got %d", len(tx.Tx.TxOut))
+	}
+	if tx.TotalInput != 1e8 {
+		t.Fatalf("expected total input %v, got %v", btcutil.Amount(1e8),
+			tx.TotalInput)
+	}
+}
+
+// externalSigner is a SecretsSource holding a single key externally, which
+// never provides private keys.
+type externalSigner struct {
+	privKey *btcec.PrivateKey
+	addrs   map[string]struct{}
+}
+
+func (s *externalSigner) GetKey(btcutil.Address) (*btcec.PrivateKey, bool, error) {
+	return nil, false, errors.New("private key requested")
+}
+
+func (s *externalSigner) GetScript(btcutil.Address) ([]byte, error) {
+	return nil, errors.New("script requested")
+}
+
+func (s *externalSigner) ChainParams() *chaincfg.Params {
+	return &chaincfg.RegressionNetParams
+}
+
+func (s *externalSigner) ExternalKey(addr btcutil.Address) (*btcec.PublicKey, error) {
+	if _, ok := s.addrs[addr.EncodeAddress()]; !ok {
+		return nil, nil
+	}
+	return s.privKey.PubKey(), nil
+}
+
+func (s *externalSigner) SignHash(addr btcutil.Address, hash []byte) (*btcec.Signature, error) {
+	if _, ok := s.addrs[addr.EncodeAddress()]; !ok {
+		return nil, errors.New("unknown address")
+	}
+	return s.privKey.Sign(hash)
+}
+
+// TestAddAllInputScriptsExternalSigner ensures that witness inputs spending
+// to externally held keys are signed with signature hashes passed to the
+// ExternalSigner.
+func TestAddAllInputScriptsExternalSigner(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	params := &chaincfg.RegressionNetParams
+	pubKeyHash := btcutil.Hash160(privKey.PubKey().SerializeCompressed())
+	p2wkhAddr, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+	witnessProgram, err := txscript.PayToAddrScript(p2wkhAddr)
+	if err != nil {
+		t.Fatalf("unable to create script: %v", err)
+	}
+	np2wkhAddr, err := btcutil.NewAddressScriptHash(witnessProgram, params)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+	np2wkhScript, err := txscript.PayToAddrScript(np2wkhAddr)
+	if err != nil {
+		t.Fatalf("unable to create script: %v", err)
+	}
+
+	secrets := &externalSigner{
+		privKey: privKey,
+		addrs: map[string]struct{}{
+			p2wkhAddr.EncodeAddress():  {},
+			np2wkhAddr.EncodeAddress(): {},
+		},
+	}
+
+	prevScripts := [][]byte{witnessProgram, np2wkhScript}
+	inputValues := []btcutil.Amount{1e6, 2e6}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for i := range prevScripts {
+		outPoint := wire.OutPoint{Index: uint32(i)}
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(2e6, witnessProgram))
+
+	err = AddAllInputScripts(tx, prevScripts, inputValues, secrets)
+	if err != nil {
+		t.Fatalf("unable to sign inputs: %v", err)
+	}
+
+	hashCache := txscript.NewTxSigHashes(tx)
+	for i, prevScript := range prevScripts {
+		vm, err := txscript.NewEngine(prevScript, tx, i,
+			txscript.StandardVerifyFlags, nil, hashCache,
+			int64(inputValues[i]))
+		if err != nil {
+			t.Fatalf("unable to create engine for input %d: %v", i,
+				err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Fatalf("input %d does not validate: %v", i, err)
+		}
+	}
+}
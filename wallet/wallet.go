@@ -25,6 +25,9 @@ import (
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/internal/bip38"
+	"github.com/btcsuite/btcwallet/internal/walletjson"
+	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
@@ -76,6 +79,11 @@ var (
 	// watch-only mode where we can select coins but not sign any inputs.
 	ErrTxUnsigned = errors.New("watch-only wallet, transaction not signed")
 
+	// ErrListCursorNotFound is returned when a listing is continued after
+	// a transaction which is not listed.
+	ErrListCursorNotFound = errors.New("transaction to continue the " +
+		"listing after not found")
+
 	// Namespace bucket keys.
 	waddrmgrNamespaceKey = []byte("waddrmgr")
 	wtxmgrNamespaceKey   = []byte("wtxmgr")
@@ -115,6 +123,48 @@ type Wallet struct {
 
 	recoveryWindow uint32
 
+	// lookaheadAddrs maps the encoded addresses of the lookahead window
+	// of every account, which are watched for payments although they have
+	// not been handed out, to their derivation.
+	lookaheadWindow uint32
+	lookaheadAddrs  map[string]lookaheadAddr
+	lookaheadMtx    sync.Mutex
+
+	// chainHealth records whether the chain followed by the chain server
+	// appears stalled or on a minority fork.
+	chainHealth       ChainHealth
+	chainStallTimeout time.Duration
+	chainHealthMtx    sync.Mutex
+
+	// unminedExpiry is the duration after which unmined sends are
+	// reported as expired.
+	unminedExpiry time.Duration
+	expiryMtx     sync.Mutex
+
+	// depositWebhook is the webhook large deposits are posted to by the
+	// deposit webhook poster, which reads them from depositWebhookQueue.
+	depositWebhook      DepositWebhook
+	depositWebhookQueue chan LargeDeposit
+	depositMtx          sync.Mutex
+
+	// backupConfig configures the backups made by the backup scheduler,
+	// which makes the next backup once nextBackup has passed.
+	backupConfig *BackupConfig
+	nextBackup   time.Time
+	backupMtx    sync.Mutex
+
+	// priceFeed configures the fetches of fiatRate, the exchange rate
+	// used to value balances, which is next fetched once nextPriceFetch
+	// has passed.
+	priceFeed      *PriceFeedConfig
+	fiatRate       *FiatRate
+	nextPriceFetch time.Time
+	priceMtx       sync.Mutex
+
+	// clock returns the current time.  It is replaced by a fixed clock
+	// when results must be deterministic.
+	clock func() time.Time
+
 	// Channels for rescan processing.  Requests are added and merged with
 	// any waiting requests, before being sent to another goroutine to
 	// call the rescan RPC.
@@ -124,19 +174,66 @@ type Wallet struct {
 	rescanProgress      chan *RescanProgressMsg
 	rescanFinished      chan *RescanFinishedMsg
 
+	// Channels to cancel and list rescan jobs, and the ID of the last
+	// submitted job.
+	rescanCancelRequests chan cancelRescanRequest
+	rescanStatusRequests chan chan []RescanJobStatus
+	lastRescanID         uint64
+	rescanIDMtx          sync.Mutex
+
 	// Channel for transaction creation requests.
 	createTxRequests chan createTxRequest
 
 	// Channels for the manager locker.
-	unlockRequests     chan unlockRequest
-	lockRequests       chan struct{}
-	holdUnlockRequests chan chan heldUnlock
-	lockState          chan bool
-	changePassphrase   chan changePassphraseRequest
-	changePassphrases  chan changePassphrasesRequest
+	unlockRequests       chan unlockRequest
+	extendUnlockRequests chan extendUnlockRequest
+	lockRequests         chan lockRequest
+	holdUnlockRequests   chan chan heldUnlock
+	lockState            chan bool
+	unlockExpiry         chan unlockExpiry
+	changePassphrase     chan changePassphraseRequest
+	changePassphrases    chan changePassphrasesRequest
+
+	// Lock timers of the accounts protected by their own passphrase.  The
+	// mutex is held for reading while transactions are created to prevent
+	// an account from being locked before its inputs are signed.
+	accountLockMtx    sync.RWMutex
+	accountLockTimers map[accountLockKey]*accountLockTimer
+
+	// External signer providers, keyed by name.
+	signerProvidersMtx sync.RWMutex
+	signerProviders    map[string]SignerProvider
+
+	// spendTOTPSecret, when set, requires sends to be confirmed with a
+	// TOTP code before they are published, and signing and key export to
+	// be authorized with a code.  Sends awaiting confirmation are kept in
+	// pendingSpends, keyed by their token.  keyUseAuthorizedUntil is the
+	// expiry of the single key use authorized by AuthorizeKeyUse.
+	// spendCodeAttempts counts the invalid codes passed to either, and
+	// after too many all codes are refused until spendCodeBlockedUntil.
+	spendTOTPSecret       []byte
+	lastSpendCodeStep     uint64
+	pendingSpends         map[string]*pendingSpend
+	keyUseAuthorizedUntil time.Time
+	spendCodeAttempts     int
+	spendCodeBlockedUntil time.Time
+	spendConfirmMtx       sync.Mutex
+
+	// spendPolicyMtx serializes the checks of spend policies along with
+	// the reservations of the spends they permit, so that concurrent
+	// spends can't together exceed the limits of a policy.
+	spendPolicyMtx    sync.Mutex
+	spendReservations map[*spendReservation]struct{}
+
+	// Draft transactions awaiting review, keyed by their ID.
+	draftTxs   map[string]*draftTx
+	draftTxMtx sync.Mutex
 
 	NtfnServer *NotificationServer
 
+	// balances caches the balances of the wallet and its accounts.
+	balances balanceCache
+
 	chainParams *chaincfg.Params
 	wg          sync.WaitGroup
 
@@ -163,9 +260,17 @@ func (w *Wallet) Start() {
 	}
 	w.quitMu.Unlock()
 
-	w.wg.Add(2)
+	w.initChainHealth()
+
+	w.wg.Add(8)
 	go w.txCreator()
 	go w.walletLocker()
+	go w.chainHealthMonitor()
+	go w.unminedExpiryMonitor()
+	go w.rebroadcaster()
+	go w.backupScheduler()
+	go w.depositWebhookPoster()
+	go w.priceFeedPoller()
 }
 
 // SynchronizeRPC associates the wallet with the consensus RPC client,
@@ -341,6 +446,20 @@ func (w *Wallet) activeData(dbtx walletdb.ReadWriteTx) ([]btcutil.Address, []wtx
 		return nil, nil, err
 	}
 
+	// Payments to the lookahead window of each account must also be
+	// detected.  The rescan watches every address of the window, so no
+	// further notifications are requested for it.
+	lookahead, _, err := w.deriveLookahead(addrmgrNs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, la := range lookahead {
+		addrs = append(addrs, la.addr)
+	}
+	dbtx.OnCommit(func() {
+		w.setLookahead(lookahead)
+	})
+
 	// Before requesting the list of spendable UTXOs, we'll delete any
 	// expired output locks.
 	err = w.TxStore.DeleteExpiredLockedOutputs(
@@ -704,66 +823,68 @@ func (w *Wallet) recovery(chainClient chain.Interface,
 	// NOTE: We purposefully don't update our best height since we assume
 	// that a wallet rescan will be performed from the wallet's tip, which
 	// will be of bestHeight after completing the recovery process.
-	var blocks []*waddrmgr.BlockStamp
+	//
+	// The blocks of the next batch are fetched while a batch is recovered.
 	startHeight := w.Manager.SyncedTo().Height + 1
-	for height := startHeight; height <= bestHeight; height++ {
-		hash, err := chainClient.GetBlockHash(int64(height))
-		if err != nil {
-			return err
-		}
-		header, err := chainClient.GetBlockHeader(hash)
-		if err != nil {
-			return err
+	done := make(chan struct{})
+	defer close(done)
+	batches := pipelineBlockBatches(
+		chainClient, startHeight, bestHeight, recoveryBatchSize, done,
+	)
+	for batch := range batches {
+		if batch.err != nil {
+			return batch.err
 		}
-		blocks = append(blocks, &waddrmgr.BlockStamp{
-			Hash:      *hash,
-			Height:    height,
-			Timestamp: header.Timestamp,
-		})
 
 		// It's possible for us to run into blocks before our birthday
 		// if our birthday is after our reorg safe height, so we'll make
 		// sure to not add those to the batch.
-		if height >= birthdayBlock.Height {
-			recoveryMgr.AddToBlockBatch(
-				hash, height, header.Timestamp,
-			)
+		for _, block := range batch.blocks {
+			if block.Height >= birthdayBlock.Height {
+				recoveryMgr.AddToBlockBatch(
+					&block.Hash, block.Height, block.Time,
+				)
+			}
 		}
 
-		// We'll perform our recovery in batches of 2000 blocks.  It's
-		// possible for us to reach our best height without exceeding
-		// the recovery batch size, so we can proceed to commit our
-		// state to disk.
+		// The addresses found in the batch are recovered before the
+		// wallet is synced to it, so that their birthday blocks
+		// precede the blocks they were found in.
 		recoveryBatch := recoveryMgr.BlockBatch()
-		if len(recoveryBatch) == recoveryBatchSize || height == bestHeight {
-			err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
-				ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
-				for _, block := range blocks {
-					err := w.Manager.SetSyncedTo(ns, block)
-					if err != nil {
-						return err
-					}
-				}
-				return w.recoverScopedAddresses(
-					chainClient, tx, ns, recoveryBatch,
-					recoveryMgr.State(), scopedMgrs,
-				)
-			})
+		err := walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+			ns := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+			err := w.recoverScopedAddresses(
+				chainClient, tx, ns, recoveryBatch,
+				recoveryMgr.State(), scopedMgrs,
+			)
 			if err != nil {
 				return err
 			}
-
-			if len(recoveryBatch) > 0 {
-				log.Infof("Recovered addresses from blocks "+
-					"%d-%d", recoveryBatch[0].Height,
-					recoveryBatch[len(recoveryBatch)-1].Height)
+			for _, block := range batch.blocks {
+				err := w.Manager.SetSyncedTo(ns, &waddrmgr.BlockStamp{
+					Hash:      block.Hash,
+					Height:    block.Height,
+					Timestamp: block.Time,
+				})
+				if err != nil {
+					return err
+				}
 			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 
-			// Clear the batch of all processed blocks to reuse the
-			// same memory for future batches.
-			blocks = blocks[:0]
-			recoveryMgr.ResetBlockBatch()
+		if len(recoveryBatch) > 0 {
+			log.Infof("Recovered addresses from blocks %d-%d",
+				recoveryBatch[0].Height,
+				recoveryBatch[len(recoveryBatch)-1].Height)
 		}
+
+		// Clear the batch of all processed blocks to reuse the same
+		// memory for future batches.
+		recoveryMgr.ResetBlockBatch()
 	}
 
 	return nil
@@ -1149,6 +1270,7 @@ type (
 		feeSatPerKB           btcutil.Amount
 		coinSelectionStrategy CoinSelectionStrategy
 		dryRun                bool
+		optFuncs              []TxCreateOption
 		resp                  chan createTxResponse
 	}
 	createTxResponse struct {
@@ -1176,24 +1298,36 @@ out:
 			// If the wallet can be locked because it contains
 			// private key material, we need to prevent it from
 			// doing so while we are assembling the transaction.
+			// Dry runs and unsigned transactions are never
+			// signed, so they are created while the wallet is
+			// locked as well.
+			opts := defaultTxCreateOptions()
+			for _, optFunc := range txr.optFuncs {
+				optFunc(opts)
+			}
+			w.accountLockMtx.RLock()
 			release := func() {}
-			if !w.Manager.WatchOnly() {
-				heldUnlock, err := w.holdUnlock()
+			if !txr.dryRun && !opts.unsigned {
+				var err error
+				release, err = w.holdSpendingAccount(
+					txr.keyScope, txr.account,
+				)
 				if err != nil {
+					w.accountLockMtx.RUnlock()
 					txr.resp <- createTxResponse{nil, err}
 					continue
 				}
-
-				release = heldUnlock.release
 			}
 
 			tx, err := w.txToOutputs(
 				txr.outputs, txr.keyScope, txr.account,
 				txr.minconf, txr.feeSatPerKB,
 				txr.coinSelectionStrategy, txr.dryRun,
+				txr.optFuncs...,
 			)
 
 			release()
+			w.accountLockMtx.RUnlock()
 			txr.resp <- createTxResponse{tx, err}
 		case <-quit:
 			break out
@@ -1202,6 +1336,46 @@ out:
 	w.wg.Done()
 }
 
+// holdSpendingAccount prevents the private keys of the account a transaction
+// is created for from being locked until the returned function is called.
+// Accounts protected by their own passphrase must already be unlocked, and are
+// kept unlocked by the caller holding accountLockMtx for reading.  All other
+// spends hold the unlocked state of the wallet.
+func (w *Wallet) holdSpendingAccount(keyScope *waddrmgr.KeyScope,
+	account uint32) (func(), error) {
+
+	if w.Manager.WatchOnly() {
+		return func() {}, nil
+	}
+
+	if keyScope != nil {
+		hasPassphrase, err := w.AccountHasPassphrase(*keyScope, account)
+		if err != nil {
+			return nil, err
+		}
+		if hasPassphrase {
+			locked, err := w.AccountLocked(*keyScope, account)
+			if err != nil {
+				return nil, err
+			}
+			if locked {
+				return nil, waddrmgr.ManagerError{
+					ErrorCode: waddrmgr.ErrLocked,
+					Description: fmt.Sprintf("account %d is "+
+						"locked", account),
+				}
+			}
+			return func() {}, nil
+		}
+	}
+
+	heldUnlock, err := w.holdUnlock()
+	if err != nil {
+		return nil, err
+	}
+	return heldUnlock.release, nil
+}
+
 // CreateSimpleTx creates a new signed transaction spending unspent outputs with
 // at least minconf confirmations spending to any number of address/amount
 // pairs. Only unspent outputs belonging to the given key scope and account will
@@ -1217,8 +1391,8 @@ out:
 // the database. A tx created with this set to true SHOULD NOT be broadcasted.
 func (w *Wallet) CreateSimpleTx(keyScope *waddrmgr.KeyScope, account uint32,
 	outputs []*wire.TxOut, minconf int32, satPerKb btcutil.Amount,
-	coinSelectionStrategy CoinSelectionStrategy, dryRun bool) (
-	*txauthor.AuthoredTx, error) {
+	coinSelectionStrategy CoinSelectionStrategy, dryRun bool,
+	optFuncs ...TxCreateOption) (*txauthor.AuthoredTx, error) {
 
 	req := createTxRequest{
 		keyScope:              keyScope,
@@ -1228,6 +1402,7 @@ func (w *Wallet) CreateSimpleTx(keyScope *waddrmgr.KeyScope, account uint32,
 		feeSatPerKB:           satPerKb,
 		coinSelectionStrategy: coinSelectionStrategy,
 		dryRun:                dryRun,
+		optFuncs:              optFuncs,
 		resp:                  make(chan createTxResponse),
 	}
 	w.createTxRequests <- req
@@ -1238,8 +1413,34 @@ func (w *Wallet) CreateSimpleTx(keyScope *waddrmgr.KeyScope, account uint32,
 type (
 	unlockRequest struct {
 		passphrase []byte
-		lockAfter  <-chan time.Time // nil prevents the timeout.
+		level      waddrmgr.UnlockLevel
+		lockAfter  <-chan time.Time // nil uses the managed timeout.
+		timeout    time.Duration    // zero prevents the managed timeout.
 		err        chan error
+
+		// quiet suppresses the lock state notification of the unlock,
+		// which is sent by the caller instead.
+		quiet bool
+	}
+
+	// lockRequest requests the wallet to be locked.  When wasUnlocked is
+	// non-nil, it receives whether the wallet was unlocked before.
+	lockRequest struct {
+		quiet       bool
+		wasUnlocked chan bool
+	}
+
+	extendUnlockRequest struct {
+		timeout time.Duration // zero prevents the timeout.
+		err     chan error
+	}
+
+	// unlockExpiry describes the lock state of the wallet and the time
+	// at which the managed timeout locks it again.  The time is zero
+	// while there is no managed timeout.
+	unlockExpiry struct {
+		locked bool
+		until  time.Time
 	}
 
 	changePassphraseRequest struct {
@@ -1260,30 +1461,93 @@ type (
 	// *must* be released (preferably with a defer) or the wallet
 	// will forever remain unlocked.
 	heldUnlock chan struct{}
+
+	// accountLockKey identifies the lock timer of an account protected
+	// by its own passphrase.
+	accountLockKey struct {
+		scope   waddrmgr.KeyScope
+		account uint32
+	}
+
+	// accountLockTimer locks an account protected by its own passphrase
+	// once its timeout expires.
+	accountLockTimer struct {
+		timer *time.Timer
+		until time.Time
+	}
 )
 
 // walletLocker manages the locked/unlocked state of a wallet.
 func (w *Wallet) walletLocker() {
-	var timeout <-chan time.Time
+	// The wallet is locked when timeout fires.  It is either the lock
+	// channel of the caller, or the channel of the timer managed by the
+	// locker, which is replaced whenever the wallet is unlocked or its
+	// timeout is extended so that earlier timeouts never lock the wallet.
+	var (
+		timeout <-chan time.Time
+		timer   *time.Timer
+		until   time.Time
+	)
+	setTimeout := func(lockAfter <-chan time.Time, d time.Duration) {
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+		timeout = lockAfter
+		until = time.Time{}
+		if lockAfter == nil && d > 0 {
+			timer = time.NewTimer(d)
+			timeout = timer.C
+			until = w.clock().Add(d)
+		}
+	}
+
 	holdChan := make(heldUnlock)
 	quit := w.quitChan()
 out:
 	for {
+		var lockReq lockRequest
 		select {
 		case req := <-w.unlockRequests:
 			err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 				addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
-				return w.Manager.Unlock(addrmgrNs, req.passphrase)
+				return w.Manager.UnlockWithLevel(
+					addrmgrNs, req.passphrase, req.level,
+				)
 			})
 			if err != nil {
 				req.err <- err
 				continue
 			}
-			timeout = req.lockAfter
+			setTimeout(req.lockAfter, req.timeout)
+			if timeout == nil {
+				log.Infof("The wallet has been unlocked at the %v "+
+					"level without a time limit", req.level)
+			} else {
+				log.Infof("The wallet has been temporarily unlocked "+
+					"at the %v level", req.level)
+			}
+			req.err <- nil
+			if !req.quiet {
+				w.NtfnServer.notifyLockState(&LockStateChange{
+					Wallet: true,
+				})
+			}
+			continue
+
+		case req := <-w.extendUnlockRequests:
+			if w.Manager.IsLocked() {
+				req.err <- waddrmgr.ManagerError{
+					ErrorCode:   waddrmgr.ErrLocked,
+					Description: "address manager is locked",
+				}
+				continue
+			}
+			setTimeout(nil, req.timeout)
 			if timeout == nil {
 				log.Info("The wallet has been unlocked without a time limit")
 			} else {
-				log.Info("The wallet has been temporarily unlocked")
+				log.Infof("The wallet will be locked in %v", req.timeout)
 			}
 			req.err <- nil
 			continue
@@ -1342,22 +1606,35 @@ out:
 		case w.lockState <- w.Manager.IsLocked():
 			continue
 
+		case w.unlockExpiry <- unlockExpiry{w.Manager.IsLocked(), until}:
+			continue
+
 		case <-quit:
 			break out
 
-		case <-w.lockRequests:
+		case lockReq = <-w.lockRequests:
 		case <-timeout:
 		}
 
 		// Select statement fell through by an explicit lock or the
 		// timer expiring.  Lock the manager here.
-		timeout = nil
+		setTimeout(nil, 0)
+		wasUnlocked := !w.Manager.IsLocked()
 		err := w.Manager.Lock()
 		if err != nil && !waddrmgr.IsError(err, waddrmgr.ErrLocked) {
 			log.Errorf("Could not lock wallet: %v", err)
 		} else {
 			log.Info("The wallet has been locked")
 		}
+		if lockReq.wasUnlocked != nil {
+			lockReq.wasUnlocked <- wasUnlocked
+		}
+		if wasUnlocked && !lockReq.quiet {
+			w.NtfnServer.notifyLockState(&LockStateChange{
+				Locked: true,
+				Wallet: true,
+			})
+		}
 	}
 	w.wg.Done()
 }
@@ -1371,15 +1648,76 @@ func (w *Wallet) Unlock(passphrase []byte, lock <-chan time.Time) error {
 	err := make(chan error, 1)
 	w.unlockRequests <- unlockRequest{
 		passphrase: passphrase,
+		level:      waddrmgr.UnlockFull,
 		lockAfter:  lock,
 		err:        err,
 	}
 	return <-err
 }
 
+// UnlockWithTimeout unlocks the wallet's address manager and relocks it once
+// timeout has elapsed.  A zero timeout keeps the wallet unlocked until Lock is
+// called.  If the wallet is already unlocked and the passphrase is correct,
+// the current timeout is replaced with the new one.  The wallet will be locked
+// if the passphrase is incorrect or any other error occurs during the unlock.
+func (w *Wallet) UnlockWithTimeout(passphrase []byte, timeout time.Duration) error {
+	return w.UnlockWithLevel(passphrase, waddrmgr.UnlockFull, timeout)
+}
+
+// UnlockWithLevel unlocks the wallet like UnlockWithTimeout, but only grants
+// the privilege of the unlock level to the private keys protected by the
+// wallet's private passphrase.  Unlocking an unlocked wallet replaces both its
+// timeout and its unlock level.
+func (w *Wallet) UnlockWithLevel(passphrase []byte, level waddrmgr.UnlockLevel,
+	timeout time.Duration) error {
+
+	err := make(chan error, 1)
+	w.unlockRequests <- unlockRequest{
+		passphrase: passphrase,
+		level:      level,
+		timeout:    timeout,
+		err:        err,
+	}
+	return <-err
+}
+
+// UnlockLevel returns the privilege granted to the private keys protected by
+// the wallet's private passphrase, and whether the wallet is unlocked.
+func (w *Wallet) UnlockLevel() (waddrmgr.UnlockLevel, bool) {
+	if w.Manager.IsLocked() {
+		return 0, false
+	}
+	return w.Manager.UnlockLevel(), true
+}
+
+// ExtendUnlock replaces the timeout of an unlocked wallet so that it is locked
+// once timeout has elapsed from now.  A zero timeout cancels the timeout,
+// keeping the wallet unlocked until Lock is called.  An error is returned if
+// the wallet is locked.
+func (w *Wallet) ExtendUnlock(timeout time.Duration) error {
+	err := make(chan error, 1)
+	w.extendUnlockRequests <- extendUnlockRequest{
+		timeout: timeout,
+		err:     err,
+	}
+	return <-err
+}
+
+// UnlockedUntil returns the time at which the wallet will be locked again and
+// whether it is currently unlocked.  The zero time is returned while the wallet
+// is unlocked without a time limit, or until a lock channel passed to Unlock
+// fires.
+func (w *Wallet) UnlockedUntil() (time.Time, bool) {
+	expiry := <-w.unlockExpiry
+	if expiry.locked {
+		return time.Time{}, false
+	}
+	return expiry.until, true
+}
+
 // Lock locks the wallet's address manager.
 func (w *Wallet) Lock() {
-	w.lockRequests <- struct{}{}
+	w.lockRequests <- lockRequest{}
 }
 
 // Locked returns whether the account manager for a wallet is locked.
@@ -1408,6 +1746,409 @@ func (w *Wallet) holdUnlock() (heldUnlock, error) {
 	return hl, nil
 }
 
+// SetAccountPassphrase protects the private keys of an account with their own
+// passphrase, so that the account is locked and unlocked independently of the
+// wallet.  The account must be unlocked, and an empty passphrase returns the
+// account to the protection of the wallet's private passphrase.  The account
+// keys can still be re-derived by unlocking the wallet with its private
+// passphrase.
+func (w *Wallet) SetAccountPassphrase(scope waddrmgr.KeyScope, account uint32,
+	passphrase []byte) error {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	w.accountLockMtx.Lock()
+	defer w.accountLockMtx.Unlock()
+
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return manager.SetAccountPassphrase(
+			addrmgrNs, account, passphrase,
+			&waddrmgr.DefaultScryptOptions,
+		)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The account remains unlocked with the new passphrase, but any lock
+	// timer which was set for it no longer applies.
+	w.stopAccountLockTimer(accountLockKey{scope, account})
+	return nil
+}
+
+// AccountHasPassphrase returns whether an account is protected by its own
+// passphrase rather than the wallet's private passphrase.
+func (w *Wallet) AccountHasPassphrase(scope waddrmgr.KeyScope,
+	account uint32) (bool, error) {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return false, err
+	}
+
+	var hasPassphrase bool
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		var err error
+		hasPassphrase, err = manager.HasAccountPassphrase(
+			addrmgrNs, account,
+		)
+		return err
+	})
+	return hasPassphrase, err
+}
+
+// AccountLocked returns whether the private keys of an account are locked.
+// Accounts without their own passphrase are locked when the wallet is.
+func (w *Wallet) AccountLocked(scope waddrmgr.KeyScope,
+	account uint32) (bool, error) {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return false, err
+	}
+
+	var locked bool
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		var err error
+		locked, err = manager.IsAccountLocked(addrmgrNs, account)
+		return err
+	})
+	return locked, err
+}
+
+// UnlockAccount unlocks an account protected by its own passphrase and relocks
+// it once timeout has elapsed.  A zero timeout keeps the account unlocked until
+// it is locked with LockAccount.  If the account is already unlocked and the
+// passphrase is correct, the current timeout is replaced with the new one.
+// The account will be locked if the passphrase is incorrect or any other error
+// occurs during the unlock.
+func (w *Wallet) UnlockAccount(scope waddrmgr.KeyScope, account uint32,
+	passphrase []byte, timeout time.Duration) error {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	w.accountLockMtx.Lock()
+	defer w.accountLockMtx.Unlock()
+
+	key := accountLockKey{scope, account}
+	if err := w.unlockAccount(key, manager, passphrase, timeout); err != nil {
+		return err
+	}
+	w.NtfnServer.notifyLockState(&LockStateChange{
+		Accounts: []waddrmgr.ScopedIndex{key.scopedIndex()},
+	})
+	return nil
+}
+
+// unlockAccount unlocks an account protected by its own passphrase and starts
+// its lock timer, without notifying the change of its lock state.
+// accountLockMtx must be held for writes.
+func (w *Wallet) unlockAccount(key accountLockKey,
+	manager *waddrmgr.ScopedKeyManager, passphrase []byte,
+	timeout time.Duration) error {
+
+	w.stopAccountLockTimer(key)
+
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		return manager.UnlockAccount(addrmgrNs, key.account, passphrase)
+	})
+	if err != nil {
+		return err
+	}
+
+	if timeout > 0 {
+		w.startAccountLockTimer(key, manager, timeout)
+	}
+	return nil
+}
+
+// ExtendAccountUnlock replaces the timeout of an unlocked account protected by
+// its own passphrase so that it is locked once timeout has elapsed from now.  A
+// zero timeout cancels the timeout, keeping the account unlocked until it is
+// locked with LockAccount.  An error is returned if the account is locked.
+func (w *Wallet) ExtendAccountUnlock(scope waddrmgr.KeyScope, account uint32,
+	timeout time.Duration) error {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	w.accountLockMtx.Lock()
+	defer w.accountLockMtx.Unlock()
+
+	if err := w.checkAccountUnlocked(scope, account); err != nil {
+		return err
+	}
+
+	key := accountLockKey{scope, account}
+	w.stopAccountLockTimer(key)
+	if timeout > 0 {
+		w.startAccountLockTimer(key, manager, timeout)
+	}
+	return nil
+}
+
+// AccountUnlockedUntil returns the time at which an account protected by its
+// own passphrase will be locked again and whether it is currently unlocked.
+// The zero time is returned while the account is unlocked without a time
+// limit.
+func (w *Wallet) AccountUnlockedUntil(scope waddrmgr.KeyScope,
+	account uint32) (time.Time, bool, error) {
+
+	w.accountLockMtx.RLock()
+	defer w.accountLockMtx.RUnlock()
+
+	err := w.checkAccountUnlocked(scope, account)
+	switch {
+	case waddrmgr.IsError(err, waddrmgr.ErrLocked):
+		return time.Time{}, false, nil
+	case err != nil:
+		return time.Time{}, false, err
+	}
+
+	var until time.Time
+	if t, ok := w.accountLockTimers[accountLockKey{scope, account}]; ok {
+		until = t.until
+	}
+	return until, true, nil
+}
+
+// checkAccountUnlocked returns an error unless the account is protected by its
+// own passphrase and unlocked.
+func (w *Wallet) checkAccountUnlocked(scope waddrmgr.KeyScope,
+	account uint32) error {
+
+	hasPassphrase, err := w.AccountHasPassphrase(scope, account)
+	if err != nil {
+		return err
+	}
+	if !hasPassphrase {
+		return waddrmgr.ManagerError{
+			ErrorCode: waddrmgr.ErrInvalidAccount,
+			Description: fmt.Sprintf("account %d is not protected "+
+				"by its own passphrase", account),
+		}
+	}
+	locked, err := w.AccountLocked(scope, account)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return waddrmgr.ManagerError{
+			ErrorCode:   waddrmgr.ErrLocked,
+			Description: fmt.Sprintf("account %d is locked", account),
+		}
+	}
+	return nil
+}
+
+// LockAccount locks an account protected by its own passphrase.
+func (w *Wallet) LockAccount(scope waddrmgr.KeyScope, account uint32) error {
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	w.accountLockMtx.Lock()
+	defer w.accountLockMtx.Unlock()
+
+	key := accountLockKey{scope, account}
+	w.stopAccountLockTimer(key)
+	if err := manager.LockAccount(account); err != nil {
+		return err
+	}
+	w.NtfnServer.notifyLockState(&LockStateChange{
+		Locked:   true,
+		Accounts: []waddrmgr.ScopedIndex{key.scopedIndex()},
+	})
+	return nil
+}
+
+// UnlockAllAccounts unlocks the wallet and every account protected by its own
+// passphrase which accepts the passphrase, relocking each of them once timeout
+// has elapsed.  A zero timeout keeps them unlocked until they are locked.
+// Accounts which do not accept the passphrase are locked.  An error is only
+// returned when nothing could be unlocked.  No account is locked or unlocked
+// by other callers meanwhile, and a single lock state notification describes
+// everything which was unlocked.
+func (w *Wallet) UnlockAllAccounts(passphrase []byte,
+	timeout time.Duration) error {
+
+	w.accountLockMtx.Lock()
+	defer w.accountLockMtx.Unlock()
+
+	err := make(chan error, 1)
+	w.unlockRequests <- unlockRequest{
+		passphrase: passphrase,
+		level:      waddrmgr.UnlockFull,
+		timeout:    timeout,
+		err:        err,
+		quiet:      true,
+	}
+	unlockErr := <-err
+	change := &LockStateChange{Wallet: unlockErr == nil}
+	for key := range w.passphraseAccounts() {
+		manager, err := w.Manager.FetchScopedKeyManager(key.scope)
+		if err == nil {
+			err = w.unlockAccount(key, manager, passphrase, timeout)
+		}
+		switch {
+		case err == nil:
+			change.Accounts = append(
+				change.Accounts, key.scopedIndex(),
+			)
+		case unlockErr == nil:
+			unlockErr = err
+		}
+	}
+
+	if !change.Wallet && len(change.Accounts) == 0 {
+		return unlockErr
+	}
+	w.NtfnServer.notifyLockState(change)
+	return nil
+}
+
+// LockAllAccounts locks the wallet and every account protected by its own
+// passphrase.  No account is locked or unlocked by other callers meanwhile,
+// and a single lock state notification describes everything which was
+// locked.
+func (w *Wallet) LockAllAccounts() {
+	w.accountLockMtx.Lock()
+	defer w.accountLockMtx.Unlock()
+
+	wasUnlocked := make(chan bool, 1)
+	w.lockRequests <- lockRequest{quiet: true, wasUnlocked: wasUnlocked}
+	change := &LockStateChange{Locked: true, Wallet: <-wasUnlocked}
+	for key := range w.passphraseAccounts() {
+		manager, err := w.Manager.FetchScopedKeyManager(key.scope)
+		if err == nil {
+			w.stopAccountLockTimer(key)
+			err = manager.LockAccount(key.account)
+		}
+		switch {
+		case err == nil:
+			change.Accounts = append(
+				change.Accounts, key.scopedIndex(),
+			)
+		case !waddrmgr.IsError(err, waddrmgr.ErrLocked):
+			log.Errorf("Could not lock account %d: %v",
+				key.account, err)
+		}
+	}
+
+	if change.Wallet || len(change.Accounts) != 0 {
+		w.NtfnServer.notifyLockState(change)
+	}
+}
+
+// AllAccountsUnlocked returns whether the wallet and every account protected by
+// its own passphrase are unlocked.
+func (w *Wallet) AllAccountsUnlocked() (bool, error) {
+	if w.Locked() {
+		return false, nil
+	}
+	for key := range w.passphraseAccounts() {
+		locked, err := w.AccountLocked(key.scope, key.account)
+		if err != nil {
+			return false, err
+		}
+		if locked {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// passphraseAccounts returns the accounts of all active scopes which are
+// protected by their own passphrase.
+func (w *Wallet) passphraseAccounts() map[accountLockKey]struct{} {
+	accounts := make(map[accountLockKey]struct{})
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		for _, manager := range w.Manager.ActiveScopedKeyManagers() {
+			scope := manager.Scope()
+			err := manager.ForEachAccount(addrmgrNs, func(a uint32) error {
+				ok, err := manager.HasAccountPassphrase(addrmgrNs, a)
+				if err != nil || !ok {
+					return err
+				}
+				accounts[accountLockKey{scope, a}] = struct{}{}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Could not list accounts with their own "+
+			"passphrase: %v", err)
+	}
+	return accounts
+}
+
+// startAccountLockTimer locks an account once timeout has elapsed, unless the
+// timer is stopped or replaced first.  accountLockMtx must be held for writes.
+func (w *Wallet) startAccountLockTimer(key accountLockKey,
+	manager *waddrmgr.ScopedKeyManager, timeout time.Duration) {
+
+	t := &accountLockTimer{until: w.clock().Add(timeout)}
+	t.timer = time.AfterFunc(timeout, func() {
+		w.accountLockMtx.Lock()
+		defer w.accountLockMtx.Unlock()
+
+		// The timer may have been replaced while waiting for the
+		// mutex.
+		if w.accountLockTimers[key] != t {
+			return
+		}
+		delete(w.accountLockTimers, key)
+
+		err := manager.LockAccount(key.account)
+		switch {
+		case err == nil:
+			log.Infof("Account %d has been locked", key.account)
+			w.NtfnServer.notifyLockState(&LockStateChange{
+				Locked:   true,
+				Accounts: []waddrmgr.ScopedIndex{key.scopedIndex()},
+			})
+		case waddrmgr.IsError(err, waddrmgr.ErrLocked):
+			log.Infof("Account %d has been locked", key.account)
+		default:
+			log.Errorf("Could not lock account %d: %v",
+				key.account, err)
+		}
+	})
+	w.accountLockTimers[key] = t
+}
+
+// scopedIndex returns the scope and number of the account.
+func (k accountLockKey) scopedIndex() waddrmgr.ScopedIndex {
+	return waddrmgr.ScopedIndex{Scope: k.scope, Index: k.account}
+}
+
+// stopAccountLockTimer stops the lock timer of an account, if any.
+// accountLockMtx must be held for writes.
+func (w *Wallet) stopAccountLockTimer(key accountLockKey) {
+	if t, ok := w.accountLockTimers[key]; ok {
+		t.timer.Stop()
+		delete(w.accountLockTimers, key)
+	}
+}
+
 // release releases the hold on the unlocked-state of the wallet and allows the
 // wallet to be locked again.  If a lock timeout has already expired, the
 // wallet is locked again as soon as release is called.
@@ -1471,6 +2212,23 @@ func (w *Wallet) AccountAddresses(account uint32) (addrs []btcutil.Address, err
 	return
 }
 
+// AccountReceiveAddresses returns the addresses created for an account to
+// receive payments, excluding the change addresses of its internal branch.
+func (w *Wallet) AccountReceiveAddresses(account uint32) ([]btcutil.Address, error) {
+	var addrs []btcutil.Address
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		return w.Manager.ForEachAccountAddress(addrmgrNs, account,
+			func(maddr waddrmgr.ManagedAddress) error {
+				if !maddr.Internal() {
+					addrs = append(addrs, maddr.Address())
+				}
+				return nil
+			})
+	})
+	return addrs, err
+}
+
 // CalculateBalance sums the amounts of all unspent transaction
 // outputs to addresses of a wallet and returns the balance.
 //
@@ -1479,18 +2237,60 @@ func (w *Wallet) AccountAddresses(account uint32) (addrs []btcutil.Address, err
 // a UTXO must be in a block.  If confirmations is 1 or greater,
 // the balance will be calculated based on how many how many blocks
 // include a UTXO.
+//
+// Balances with 0 or 1 confirmations are returned from the wallet's cached
+// balances, which are kept updated as transactions are recorded.
 func (w *Wallet) CalculateBalance(confirms int32) (btcutil.Amount, error) {
 	var balance btcutil.Amount
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
-		var err error
 		blk := w.Manager.SyncedTo()
+		var ok bool
+		var err error
+		balance, ok, err = w.cachedBalance(tx, confirms, blk.Height)
+		if err != nil || ok {
+			return err
+		}
 		balance, err = w.TxStore.Balance(txmgrNs, confirms, blk.Height)
 		return err
 	})
 	return balance, err
 }
 
+// CalculateImmatureBalance returns the total of the coinbase outputs of the
+// wallet which have not yet reached maturity.  These outputs are excluded from
+// the balances returned by CalculateBalance, and are not spent until they
+// mature.
+func (w *Wallet) CalculateImmatureBalance() (btcutil.Amount, error) {
+	var balance btcutil.Amount
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		blk := w.Manager.SyncedTo()
+		var ok bool
+		var err error
+		balance, ok, err = w.cachedImmatureBalance(tx, blk.Height)
+		if err != nil || ok {
+			return err
+		}
+
+		unspent, err := w.TxStore.UnspentOutputs(txmgrNs)
+		if err != nil {
+			return err
+		}
+		maturity := int32(w.chainParams.CoinbaseMaturity)
+		for i := range unspent {
+			output := &unspent[i]
+			if output.FromCoinBase && !confirmed(maturity,
+				output.Height, blk.Height) {
+
+				balance += output.Amount
+			}
+		}
+		return nil
+	})
+	return balance, err
+}
+
 // Balances records total, spendable (by policy), and immature coinbase
 // reward balance amounts.
 type Balances struct {
@@ -1502,9 +2302,10 @@ type Balances struct {
 // CalculateAccountBalances sums the amounts of all unspent transaction
 // outputs to the given account of a wallet and returns the balance.
 //
-// This function is much slower than it needs to be since transactions outputs
-// are not indexed by the accounts they credit to, and all unspent transaction
-// outputs must be iterated.
+// Balances with 0 or 1 confirmations are returned from the wallet's cached
+// balances.  Otherwise, this function is much slower than it needs to be since
+// transactions outputs are not indexed by the accounts they credit to, and all
+// unspent transaction outputs must be iterated.
 func (w *Wallet) CalculateAccountBalances(account uint32, confirms int32) (Balances, error) {
 	var bals Balances
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
@@ -1515,6 +2316,15 @@ func (w *Wallet) CalculateAccountBalances(account uint32, confirms int32) (Balan
 		// the number of tx confirmations.
 		syncBlock := w.Manager.SyncedTo()
 
+		var ok bool
+		var err error
+		bals, ok, err = w.cachedAccountBalances(
+			tx, account, confirms, syncBlock.Height,
+		)
+		if err != nil || ok {
+			return err
+		}
+
 		unspent, err := w.TxStore.UnspentOutputs(txmgrNs)
 		if err != nil {
 			return err
@@ -1548,7 +2358,8 @@ func (w *Wallet) CalculateAccountBalances(account uint32, confirms int32) (Balan
 // CurrentAddress gets the most recently requested Bitcoin payment address
 // from a wallet for a particular key-chain scope.  If the address has already
 // been used (there is at least one transaction spending to it in the
-// blockchain or btcd mempool), the next chained address is returned.
+// blockchain or btcd mempool) or is reserved for an invoice, the next chained
+// address is returned.
 func (w *Wallet) CurrentAddress(account uint32, scope waddrmgr.KeyScope) (btcutil.Address, error) {
 	chainClient, err := w.requireChainClient()
 	if err != nil {
@@ -1579,8 +2390,14 @@ func (w *Wallet) CurrentAddress(account uint32, scope waddrmgr.KeyScope) (btcuti
 		}
 
 		// Get next chained address if the last one has already been
-		// used.
-		if maddr.Used(addrmgrNs) {
+		// used or was reserved by ReserveAddress.
+		reservation, err := w.Manager.AddressReservation(
+			addrmgrNs, maddr.Address(),
+		)
+		if err != nil {
+			return err
+		}
+		if maddr.Used(addrmgrNs) || reservation != nil {
 			addr, props, err = w.newAddress(
 				addrmgrNs, account, scope,
 			)
@@ -1680,8 +2497,13 @@ func (w *Wallet) LabelTransaction(hash chainhash.Hash, label string,
 }
 
 // PrivKeyForAddress looks up the associated private key for a P2PKH or P2PK
-// address.
+// address.  While spends require confirmation, the use of the key must first
+// be authorized with AuthorizeKeyUse.
 func (w *Wallet) PrivKeyForAddress(a btcutil.Address) (*btcec.PrivateKey, error) {
+	if err := w.useKeys(); err != nil {
+		return nil, err
+	}
+
 	var privKey *btcec.PrivateKey
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
@@ -1856,6 +2678,145 @@ func (w *Wallet) RenameAccount(scope waddrmgr.KeyScope, account uint32, newName
 	return err
 }
 
+// MergeAccounts moves all addresses of an account into another account of the
+// same key scope, for wallets which were split into more accounts than needed.
+// The outputs, balances and transaction history of the addresses move with
+// them, without any transaction being created.  The account merged from is
+// kept, and addresses derived from it afterwards belong to it.
+func (w *Wallet) MergeAccounts(scope waddrmgr.KeyScope, from, into uint32) error {
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	var props [2]*waddrmgr.AccountProperties
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		err := manager.MergeAccount(addrmgrNs, from, into)
+		if err != nil {
+			return err
+		}
+
+		// The cached balances attribute the outputs of the merged
+		// addresses to the account merged from.
+		tx.OnCommit(w.balances.reset)
+
+		for i, account := range []uint32{from, into} {
+			props[i], err = manager.AccountProperties(
+				addrmgrNs, account,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range props {
+		w.NtfnServer.notifyAccountProperties(p)
+	}
+	return nil
+}
+
+// AccountMetadata returns the user-provided metadata of an account.
+func (w *Wallet) AccountMetadata(scope waddrmgr.KeyScope,
+	account uint32) (*waddrmgr.AccountMetadata, error) {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var meta *waddrmgr.AccountMetadata
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		var err error
+		meta, err = manager.AccountMetadata(addrmgrNs, account)
+		return err
+	})
+	return meta, err
+}
+
+// SetAccountMetadata replaces the description and purpose tags of an
+// account.
+func (w *Wallet) SetAccountMetadata(scope waddrmgr.KeyScope, account uint32,
+	description string, tags []string) error {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	return walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return manager.SetAccountMetadata(
+			addrmgrNs, account, description, tags,
+		)
+	})
+}
+
+// SetAccountAvoidReuse sets whether transactions spending from an account
+// must avoid combining outputs paying to dirty addresses, those which have
+// previously been spent from, with outputs paying to clean addresses.
+func (w *Wallet) SetAccountAvoidReuse(scope waddrmgr.KeyScope, account uint32,
+	avoidReuse bool) error {
+
+	manager, err := w.Manager.FetchScopedKeyManager(scope)
+	if err != nil {
+		return err
+	}
+
+	return walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		return manager.SetAccountAvoidReuse(addrmgrNs, account, avoidReuse)
+	})
+}
+
+// OutputsReused returns, for each of the passed output scripts, whether the
+// output pays to a dirty address.
+func (w *Wallet) OutputsReused(pkScripts [][]byte) ([]bool, error) {
+	reused := make([]bool, len(pkScripts))
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		for i, pkScript := range pkScripts {
+			reused[i] = w.outputIsReused(addrmgrNs, pkScript)
+		}
+		return nil
+	})
+	return reused, err
+}
+
+// OutputsReceived returns, for each of the passed outpoints, the time the
+// transaction of the output was received by the wallet.  The zero time is
+// returned for outputs of unknown transactions.
+func (w *Wallet) OutputsReceived(ops []wire.OutPoint) ([]time.Time, error) {
+	received := make([]time.Time, len(ops))
+	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+		txReceived := make(map[chainhash.Hash]time.Time)
+		for i := range ops {
+			hash := &ops[i].Hash
+			t, ok := txReceived[*hash]
+			if !ok {
+				details, err := w.TxStore.TxDetails(txmgrNs, hash)
+				if err != nil {
+					return err
+				}
+				if details != nil {
+					t = details.Received
+				}
+				txReceived[*hash] = t
+			}
+			received[i] = t
+		}
+		return nil
+	})
+	return received, err
+}
+
 // NextAccount creates the next account and returns its account number.  The
 // name must be unique to the account.  In order to support automatic seed
 // restoring, new accounts may not be created when all of the previous 100
@@ -1942,7 +2903,7 @@ func RecvCategory(details *wtxmgr.TxDetails, syncHeight int32, net *chaincfg.Par
 //
 // TODO: This should be moved to the legacyrpc package.
 func listTransactions(tx walletdb.ReadTx, details *wtxmgr.TxDetails, addrMgr *waddrmgr.Manager,
-	syncHeight int32, net *chaincfg.Params) []btcjson.ListTransactionsResult {
+	syncHeight int32, net *chaincfg.Params) []walletjson.ListTransactionsResult {
 
 	addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
 
@@ -1957,7 +2918,7 @@ func listTransactions(tx walletdb.ReadTx, details *wtxmgr.TxDetails, addrMgr *wa
 		confirmations = int64(confirms(details.Block.Height, syncHeight))
 	}
 
-	results := []btcjson.ListTransactionsResult{}
+	results := []walletjson.ListTransactionsResult{}
 	txHashStr := details.Hash.String()
 	received := details.Received.Unix()
 	generated := blockchain.IsCoinBaseTx(&details.MsgTx)
@@ -2017,7 +2978,7 @@ outputs:
 		}
 
 		amountF64 := btcutil.Amount(output.Value).ToBTC()
-		result := btcjson.ListTransactionsResult{
+		result := walletjson.ListTransactionsResult{
 			// Fields left zeroed:
 			//   InvolvesWatchOnly
 			//   BlockIndex
@@ -2037,6 +2998,8 @@ outputs:
 			WalletConflicts: []string{},
 			Time:            received,
 			TimeReceived:    received,
+			Comment:         details.Comment,
+			CommentTo:       details.CommentTo,
 		}
 
 		// Add a received/generated/immature result if this is a credit.
@@ -2069,8 +3032,8 @@ outputs:
 // ListSinceBlock returns a slice of objects with details about transactions
 // since the given block. If the block is -1 then all transactions are included.
 // This is intended to be used for listsinceblock RPC replies.
-func (w *Wallet) ListSinceBlock(start, end, syncHeight int32) ([]btcjson.ListTransactionsResult, error) {
-	txList := []btcjson.ListTransactionsResult{}
+func (w *Wallet) ListSinceBlock(start, end, syncHeight int32) ([]walletjson.ListTransactionsResult, error) {
+	txList := []walletjson.ListTransactionsResult{}
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
 
@@ -2092,11 +3055,49 @@ func (w *Wallet) ListSinceBlock(start, end, syncHeight int32) ([]btcjson.ListTra
 	return txList, err
 }
 
+// TimeRange restricts a listing to the transactions received by the wallet at
+// or after Start and before End.  A zero Start or End leaves the range
+// unbounded on that side.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains returns whether the time is within the range.
+func (r *TimeRange) Contains(t time.Time) bool {
+	if !r.Start.IsZero() && t.Before(r.Start) {
+		return false
+	}
+	return r.End.IsZero() || t.Before(r.End)
+}
+
+// TxListFilter restricts the transactions listed by ListTransactionsFiltered.
+type TxListFilter struct {
+	// After, when set, continues the listing after the transaction with
+	// this hash, so that it may be paged through without repeating or
+	// missing transactions when new transactions are recorded.
+	After *chainhash.Hash
+
+	// Received restricts the listing to the transactions received within
+	// the range.
+	Received TimeRange
+}
+
 // ListTransactions returns a slice of objects with details about a recorded
 // transaction.  This is intended to be used for listtransactions RPC
 // replies.
-func (w *Wallet) ListTransactions(from, count int) ([]btcjson.ListTransactionsResult, error) {
-	txList := []btcjson.ListTransactionsResult{}
+func (w *Wallet) ListTransactions(from, count int) ([]walletjson.ListTransactionsResult, error) {
+	return w.ListTransactionsFiltered(from, count, &TxListFilter{})
+}
+
+// ListTransactionsFiltered returns a slice of objects with details about the
+// recorded transactions passing the filter, newest first, skipping the first
+// from transactions.  ErrListCursorNotFound is returned if the transaction to
+// continue the listing after is not found.
+func (w *Wallet) ListTransactionsFiltered(from, count int,
+	filter *TxListFilter) ([]walletjson.ListTransactionsResult, error) {
+
+	txList := []walletjson.ListTransactionsResult{}
 
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
@@ -2110,12 +3111,24 @@ func (w *Wallet) ListTransactions(from, count int) ([]btcjson.ListTransactionsRe
 		skipped := 0
 		n := 0
 
+		// Transactions up to and including the one to continue after
+		// are not listed.
+		listing := filter.After == nil
+
 		rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
 			// Iterate over transactions at this height in reverse order.
 			// This does nothing for unmined transactions, which are
 			// unsorted, but it will process mined transactions in the
 			// reverse order they were marked mined.
 			for i := len(details) - 1; i >= 0; i-- {
+				if !listing {
+					listing = details[i].Hash == *filter.After
+					continue
+				}
+				if !filter.Received.Contains(details[i].Received) {
+					continue
+				}
+
 				if from > skipped {
 					skipped++
 					continue
@@ -2140,53 +3153,85 @@ func (w *Wallet) ListTransactions(from, count int) ([]btcjson.ListTransactionsRe
 
 		// Return newer results first by starting at mempool height and working
 		// down to the genesis block.
-		return w.TxStore.RangeTransactions(txmgrNs, -1, 0, rangeFn)
+		err := w.TxStore.RangeTransactions(txmgrNs, -1, 0, rangeFn)
+		if err != nil {
+			return err
+		}
+		if !listing {
+			return ErrListCursorNotFound
+		}
+		return nil
 	})
 	return txList, err
 }
 
 // ListAddressTransactions returns a slice of objects with details about
 // recorded transactions to or from any address belonging to a set.  This is
-// intended to be used for listaddresstransactions RPC replies.
-func (w *Wallet) ListAddressTransactions(pkHashes map[string]struct{}) ([]btcjson.ListTransactionsResult, error) {
-	txList := []btcjson.ListTransactionsResult{}
+// intended to be used for listaddresstransactions RPC replies.  The
+// transactions paying to the addresses are looked up from the transaction
+// store's index of credits by output script.
+func (w *Wallet) ListAddressTransactions(pkHashes map[string]struct{}) ([]walletjson.ListTransactionsResult, error) {
+	txList := []walletjson.ListTransactionsResult{}
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
 
 		// Get current block.  The block height used for calculating
 		// the number of tx confirmations.
 		syncBlock := w.Manager.SyncedTo()
-		rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
-		loopDetails:
-			for i := range details {
-				detail := &details[i]
 
-				for _, cred := range detail.Credits {
-					pkScript := detail.MsgTx.TxOut[cred.Index].PkScript
-					_, addrs, _, err := txscript.ExtractPkScriptAddrs(
-						pkScript, w.chainParams)
-					if err != nil || len(addrs) != 1 {
-						continue
-					}
-					apkh, ok := addrs[0].(*btcutil.AddressPubKeyHash)
-					if !ok {
-						continue
-					}
-					_, ok = pkHashes[string(apkh.ScriptAddress())]
-					if !ok {
-						continue
-					}
+		var details []*wtxmgr.TxDetails
+		seen := make(map[chainhash.Hash]struct{})
+		for pkHash := range pkHashes {
+			addr, err := btcutil.NewAddressPubKeyHash(
+				[]byte(pkHash), w.chainParams,
+			)
+			if err != nil {
+				continue
+			}
+			pkScript, err := txscript.PayToAddrScript(addr)
+			if err != nil {
+				return err
+			}
+			credits, err := w.TxStore.ScriptCredits(
+				txmgrNs, pkScript,
+			)
+			if err != nil {
+				return err
+			}
+			for _, cred := range credits {
+				if _, ok := seen[cred.Hash]; ok {
+					continue
+				}
+				seen[cred.Hash] = struct{}{}
 
-					jsonResults := listTransactions(tx, detail,
-						w.Manager, syncBlock.Height, w.chainParams)
-					txList = append(txList, jsonResults...)
-					continue loopDetails
+				detail, err := w.TxStore.TxDetails(
+					txmgrNs, &cred.Hash,
+				)
+				if err != nil {
+					return err
+				}
+				if detail != nil {
+					details = append(details, detail)
 				}
 			}
-			return false, nil
 		}
 
-		return w.TxStore.RangeTransactions(txmgrNs, 0, -1, rangeFn)
+		// List mined transactions from the oldest, followed by the
+		// unmined transactions.
+		sort.SliceStable(details, func(i, j int) bool {
+			hi := details[i].Block.Height
+			hj := details[j].Block.Height
+			if hi == -1 || hj == -1 {
+				return hj == -1 && hi != -1
+			}
+			return hi < hj
+		})
+		for _, detail := range details {
+			jsonResults := listTransactions(tx, detail,
+				w.Manager, syncBlock.Height, w.chainParams)
+			txList = append(txList, jsonResults...)
+		}
+		return nil
 	})
 	return txList, err
 }
@@ -2194,8 +3239,8 @@ func (w *Wallet) ListAddressTransactions(pkHashes map[string]struct{}) ([]btcjso
 // ListAllTransactions returns a slice of objects with details about a recorded
 // transaction.  This is intended to be used for listalltransactions RPC
 // replies.
-func (w *Wallet) ListAllTransactions() ([]btcjson.ListTransactionsResult, error) {
-	txList := []btcjson.ListTransactionsResult{}
+func (w *Wallet) ListAllTransactions() ([]walletjson.ListTransactionsResult, error) {
+	txList := []walletjson.ListTransactionsResult{}
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
 
@@ -2487,7 +3532,10 @@ func (w *Wallet) AccountBalances(scope waddrmgr.KeyScope,
 		if err != nil {
 			return err
 		}
-		results = make([]AccountBalanceResult, lastAcct+2)
+		// The last account wraps around to zero accounts in scopes
+		// holding only the imported account.
+		numAccounts := lastAcct + 1
+		results = make([]AccountBalanceResult, int(numAccounts)+1)
 		for i := range results[:len(results)-1] {
 			accountName, err := manager.AccountName(addrmgrNs, uint32(i))
 			if err != nil {
@@ -2726,39 +3774,144 @@ func (w *Wallet) ListLeasedOutputs() ([]*wtxmgr.LockedOutput, error) {
 // private keys in a wallet.
 func (w *Wallet) DumpPrivKeys() ([]string, error) {
 	var privkeys []string
-	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+	err := w.ForEachPrivKey(func(key *DumpedKey) error {
+		// It would be nice to zero out the array here. However, since
+		// strings in go are immutable, and we have no control over the
+		// caller I don't think we can. :(
+		privkeys = append(privkeys, key.WIF.String())
+		return nil
+	})
+	return privkeys, err
+}
+
+// DumpedKey is the private key of a wallet address passed to the function of
+// ForEachPrivKey.
+type DumpedKey struct {
+	Address btcutil.Address
+	WIF     *btcutil.WIF
+
+	// Internal is set for the addresses of the internal (change) branch
+	// of an account.
+	Internal bool
+
+	// Birthday is the birthday block recorded for the address, or nil if
+	// none was recorded.
+	Birthday *waddrmgr.BlockStamp
+
+	// KeyScope and DerivationPath describe how the key is derived from the
+	// HD root of the wallet.  They are only set when Derived is true, as
+	// the derivation of imported keys is unknown.
+	KeyScope       waddrmgr.KeyScope
+	DerivationPath waddrmgr.DerivationPath
+	Derived        bool
+}
+
+// ForEachPrivKey calls f with the private key of each address with a private
+// key in the wallet.  The keys are passed one at a time, and are zeroed after
+// f returns, so that the keys of large wallets are never all held in memory
+// and f must not retain them.  Iteration stops at the first error returned by
+// f, which is returned.  While spends require confirmation, the export must
+// first be authorized with AuthorizeKeyUse.
+func (w *Wallet) ForEachPrivKey(f func(key *DumpedKey) error) error {
+	if err := w.useKeys(); err != nil {
+		return err
+	}
+
+	return walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
-		// Iterate over each active address, appending the private key to
-		// privkeys.
-		return w.Manager.ForEachActiveAddress(addrmgrNs, func(addr btcutil.Address) error {
-			ma, err := w.Manager.Address(addrmgrNs, addr)
-			if err != nil {
-				return err
-			}
 
-			// Only those addresses with keys needed.
-			pka, ok := ma.(waddrmgr.ManagedPubKeyAddress)
-			if !ok {
-				return nil
-			}
+		// The addresses are listed before their keys are exported, as
+		// the address manager can not be used while iterating over its
+		// addresses.
+		var addrs []btcutil.Address
+		err := w.Manager.ForEachActiveAddress(addrmgrNs, func(addr btcutil.Address) error {
+			addrs = append(addrs, addr)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 
-			wif, err := pka.ExportPrivKey()
+		for _, addr := range addrs {
+			err := w.dumpPrivKey(addrmgrNs, addr, f)
 			if err != nil {
-				// It would be nice to zero out the array here. However,
-				// since strings in go are immutable, and we have no
-				// control over the caller I don't think we can. :(
 				return err
 			}
-			privkeys = append(privkeys, wif.String())
-			return nil
-		})
+		}
+		return nil
+	})
+}
+
+// dumpPrivKey calls f with the private key of an address, if it has one,
+// zeroing the key after f returns.
+func (w *Wallet) dumpPrivKey(addrmgrNs walletdb.ReadBucket,
+	addr btcutil.Address, f func(key *DumpedKey) error) error {
+
+	ma, err := w.Manager.Address(addrmgrNs, addr)
+	if err != nil {
+		return err
+	}
+
+	// Only those addresses with keys needed.
+	pka, ok := ma.(waddrmgr.ManagedPubKeyAddress)
+	if !ok {
+		return nil
+	}
+
+	wif, err := pka.ExportPrivKey()
+	if err != nil {
+		return err
+	}
+	defer zero.BigInt(wif.PrivKey.D)
+
+	birthday, err := w.Manager.AddressBirthday(addrmgrNs, addr)
+	if err != nil {
+		return err
+	}
+
+	scope, path, derived := pka.DerivationInfo()
+	return f(&DumpedKey{
+		Address:        addr,
+		WIF:            wif,
+		Internal:       pka.Internal(),
+		Birthday:       birthday,
+		KeyScope:       scope,
+		DerivationPath: path,
+		Derived:        derived,
 	})
-	return privkeys, err
 }
 
 // DumpWIFPrivateKey returns the WIF encoded private key for a
 // single wallet address.
 func (w *Wallet) DumpWIFPrivateKey(addr btcutil.Address) (string, error) {
+	wif, err := w.exportPrivKey(addr)
+	if err != nil {
+		return "", err
+	}
+	return wif.String(), nil
+}
+
+// DumpBIP38PrivateKey returns the private key for a single wallet address,
+// encrypted with the passphrase as a BIP0038 key.
+func (w *Wallet) DumpBIP38PrivateKey(addr btcutil.Address,
+	passphrase []byte) (string, error) {
+
+	wif, err := w.exportPrivKey(addr)
+	if err != nil {
+		return "", err
+	}
+	defer zero.BigInt(wif.PrivKey.D)
+	return bip38.Encrypt(wif, passphrase, w.chainParams)
+}
+
+// exportPrivKey returns the private key of a single wallet address as a WIF.
+// While spends require confirmation, the export must first be authorized with
+// AuthorizeKeyUse.
+func (w *Wallet) exportPrivKey(addr btcutil.Address) (*btcutil.WIF, error) {
+	if err := w.useKeys(); err != nil {
+		return nil, err
+	}
+
 	var maddr waddrmgr.ManagedAddress
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		waddrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
@@ -2768,19 +3921,15 @@ func (w *Wallet) DumpWIFPrivateKey(addr btcutil.Address) (string, error) {
 		return err
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	pka, ok := maddr.(waddrmgr.ManagedPubKeyAddress)
 	if !ok {
-		return "", fmt.Errorf("address %s is not a key type", addr)
+		return nil, fmt.Errorf("address %s is not a key type", addr)
 	}
 
-	wif, err := pka.ExportPrivKey()
-	if err != nil {
-		return "", err
-	}
-	return wif.String(), nil
+	return pka.ExportPrivKey()
 }
 
 // LockedOutpoint returns whether an outpoint has been marked as locked and
@@ -2877,6 +4026,11 @@ func (w *Wallet) ReleaseOutput(id wtxmgr.LockID, op wire.OutPoint) error {
 	})
 }
 
+// Now returns the current time according to the wallet's clock.
+func (w *Wallet) Now() time.Time {
+	return w.clock()
+}
+
 // resendUnminedTxs iterates through all transactions that spend from wallet
 // credits that are not known to have been mined into a block, and attempts
 // to send each to the chain server for relay.
@@ -2908,15 +4062,33 @@ func (w *Wallet) resendUnminedTxs() {
 }
 
 // SortedActivePaymentAddresses returns a slice of all active payment
-// addresses in a wallet.
+// addresses in a wallet.  Change addresses are not payment addresses and are
+// excluded.
 func (w *Wallet) SortedActivePaymentAddresses() ([]string, error) {
 	var addrStrs []string
 	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
-		return w.Manager.ForEachActiveAddress(addrmgrNs, func(addr btcutil.Address) error {
-			addrStrs = append(addrStrs, addr.EncodeAddress())
+		var addrs []btcutil.Address
+		err := w.Manager.ForEachActiveAddress(addrmgrNs, func(addr btcutil.Address) error {
+			addrs = append(addrs, addr)
 			return nil
 		})
+		if err != nil {
+			return err
+		}
+
+		// The addresses are looked up once iterated, as the manager
+		// is locked while iterating.
+		for _, addr := range addrs {
+			maddr, err := w.Manager.Address(addrmgrNs, addr)
+			if err != nil {
+				return err
+			}
+			if !maddr.Internal() {
+				addrStrs = append(addrStrs, addr.EncodeAddress())
+			}
+		}
+		return nil
 	})
 	if err != nil {
 		return nil, err
@@ -2943,7 +4115,10 @@ func (w *Wallet) NewAddress(account uint32,
 		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
 		var err error
 		addr, props, err = w.newAddress(addrmgrNs, account, scope)
-		return err
+		if err != nil {
+			return err
+		}
+		return w.updateLookahead(addrmgrNs)
 	})
 	if err != nil {
 		return nil, err
@@ -2998,7 +4173,10 @@ func (w *Wallet) NewChangeAddress(account uint32,
 		addrmgrNs := tx.ReadWriteBucket(waddrmgrNamespaceKey)
 		var err error
 		addr, err = w.newChangeAddress(addrmgrNs, account, scope)
-		return err
+		if err != nil {
+			return err
+		}
+		return w.updateLookahead(addrmgrNs)
 	})
 	if err != nil {
 		return nil, err
@@ -3067,6 +4245,15 @@ type AccountTotalReceivedResult struct {
 func (w *Wallet) TotalReceivedForAccounts(scope waddrmgr.KeyScope,
 	minConf int32) ([]AccountTotalReceivedResult, error) {
 
+	return w.TotalReceivedForAccountsInRange(scope, minConf, &TimeRange{})
+}
+
+// TotalReceivedForAccountsInRange returns the total amount of Bitcoin received
+// for all accounts by the transactions received within the time range.  Change
+// returned to the internal branch of an account is not counted as received.
+func (w *Wallet) TotalReceivedForAccountsInRange(scope waddrmgr.KeyScope,
+	minConf int32, received *TimeRange) ([]AccountTotalReceivedResult, error) {
+
 	manager, err := w.Manager.FetchScopedKeyManager(scope)
 	if err != nil {
 		return nil, err
@@ -3105,7 +4292,13 @@ func (w *Wallet) TotalReceivedForAccounts(scope waddrmgr.KeyScope,
 		rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
 			for i := range details {
 				detail := &details[i]
+				if !received.Contains(detail.Received) {
+					continue
+				}
 				for _, cred := range detail.Credits {
+					if cred.Change {
+						continue
+					}
 					pkScript := detail.MsgTx.TxOut[cred.Index].PkScript
 					var outputAcct uint32
 					_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, w.chainParams)
@@ -3131,49 +4324,31 @@ func (w *Wallet) TotalReceivedForAccounts(scope waddrmgr.KeyScope,
 	return results, err
 }
 
-// TotalReceivedForAddr iterates through a wallet's transaction history,
-// returning the total amount of bitcoins received for a single wallet
-// address.
+// TotalReceivedForAddr returns the total amount of bitcoins received for a
+// single wallet address, looking up the credits paying to the address from the
+// transaction store's index of credits by output script.
 func (w *Wallet) TotalReceivedForAddr(addr btcutil.Address, minConf int32) (btcutil.Amount, error) {
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return 0, err
+	}
+
 	var amount btcutil.Amount
-	err := walletdb.View(w.db, func(tx walletdb.ReadTx) error {
+	err = walletdb.View(w.db, func(tx walletdb.ReadTx) error {
 		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
 
 		syncBlock := w.Manager.SyncedTo()
 
-		var (
-			addrStr    = addr.EncodeAddress()
-			stopHeight int32
-		)
-
-		if minConf > 0 {
-			stopHeight = syncBlock.Height - minConf + 1
-		} else {
-			stopHeight = -1
+		credits, err := w.TxStore.ScriptCredits(txmgrNs, pkScript)
+		if err != nil {
+			return err
 		}
-		rangeFn := func(details []wtxmgr.TxDetails) (bool, error) {
-			for i := range details {
-				detail := &details[i]
-				for _, cred := range detail.Credits {
-					pkScript := detail.MsgTx.TxOut[cred.Index].PkScript
-					_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
-						w.chainParams)
-					// An error creating addresses from the output script only
-					// indicates a non-standard script, so ignore this credit.
-					if err != nil {
-						continue
-					}
-					for _, a := range addrs {
-						if addrStr == a.EncodeAddress() {
-							amount += cred.Amount
-							break
-						}
-					}
-				}
+		for _, cred := range credits {
+			if confirmed(minConf, cred.Height, syncBlock.Height) {
+				amount += cred.Amount
 			}
-			return false, nil
 		}
-		return w.TxStore.RangeTransactions(txmgrNs, 0, stopHeight, rangeFn)
+		return nil
 	})
 	return amount, err
 }
@@ -3184,30 +4359,25 @@ func (w *Wallet) TotalReceivedForAddr(addr btcutil.Address, minConf int32) (btcu
 // accounts matching the account number provided across all key scopes may be
 // selected. This is done to handle the default account case, where a user wants
 // to fund a PSBT with inputs regardless of their type (NP2WKH, P2WKH, etc.). It
-// returns the transaction upon success.
+// returns the transaction upon success.  While spends require confirmation,
+// ErrSpendConfirmationRequired is returned, and CreatePendingSpend must be used
+// instead.
 func (w *Wallet) SendOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 	account uint32, minconf int32, satPerKb btcutil.Amount,
-	coinSelectionStrategy CoinSelectionStrategy, label string) (
-	*wire.MsgTx, error) {
+	coinSelectionStrategy CoinSelectionStrategy, label string,
+	optFuncs ...TxCreateOption) (*wire.MsgTx, error) {
 
-	// Ensure the outputs to be created adhere to the network's consensus
-	// rules.
-	for _, output := range outputs {
-		err := txrules.CheckOutput(
-			output, txrules.DefaultRelayFeePerKb,
-		)
-		if err != nil {
-			return nil, err
-		}
+	if w.SpendConfirmationRequired() {
+		return nil, ErrSpendConfirmationRequired
 	}
 
 	// Create the transaction and broadcast it to the network. The
 	// transaction will be added to the database in order to ensure that we
 	// continue to re-broadcast the transaction upon restarts until it has
 	// been confirmed.
-	createdTx, err := w.CreateSimpleTx(
-		keyScope, account, outputs, minconf, satPerKb,
-		coinSelectionStrategy, false,
+	createdTx, reservations, err := w.createSendTx(
+		outputs, keyScope, account, minconf, satPerKb,
+		coinSelectionStrategy, optFuncs...,
 	)
 	if err != nil {
 		return nil, err
@@ -3215,13 +4385,22 @@ func (w *Wallet) SendOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 
 	// If our wallet is read-only, we'll get a transaction with coins
 	// selected but no witness data. In such a case we need to inform our
-	// caller that they'll actually need to go ahead and sign the TX.
+	// caller that they'll actually need to go ahead and sign the TX.  The
+	// reservation of the spend is kept, as the transaction may still be
+	// signed and published.
 	if w.Manager.WatchOnly() {
 		return createdTx.Tx, ErrTxUnsigned
 	}
 
-	txHash, err := w.reliablyPublishTransaction(createdTx.Tx, label)
+	opts := defaultTxCreateOptions()
+	for _, optFunc := range optFuncs {
+		optFunc(opts)
+	}
+	txHash, err := w.reliablyPublishTransaction(
+		createdTx.Tx, label, opts.comment, opts.commentTo,
+	)
 	if err != nil {
+		w.releaseSpend(reservations)
 		return nil, err
 	}
 
@@ -3233,6 +4412,68 @@ func (w *Wallet) SendOutputs(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
 	return createdTx.Tx, nil
 }
 
+// createSendTx creates the signed payment transaction of a send, checking that
+// its outputs adhere to the network's consensus rules and that the transaction
+// does not violate the spend policy of the account.  The send is reserved against
+// the spend policy, and the returned reservations must be released if the
+// transaction is not published.
+func (w *Wallet) createSendTx(outputs []*wire.TxOut, keyScope *waddrmgr.KeyScope,
+	account uint32, minconf int32, satPerKb btcutil.Amount,
+	coinSelectionStrategy CoinSelectionStrategy,
+	optFuncs ...TxCreateOption) (*txauthor.AuthoredTx, []*spendReservation,
+	error) {
+
+	for _, output := range outputs {
+		err := txrules.CheckOutput(
+			output, txrules.DefaultRelayFeePerKb,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The outputs are reserved while the transaction is created, so that
+	// concurrent sends can't together violate the spend policy.  The
+	// reservations are then replaced by those of the transaction, which
+	// include its fee.
+	reservations, err := w.reserveSpend(
+		&wire.MsgTx{TxOut: outputs}, 0, keyScope, account,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if health := w.ChainHealth(); health.Risky() {
+		log.Warnf("Sending transaction while the chain is stalled or "+
+			"on a minority fork (stalled=%v, minority fork=%v)",
+			health.Stalled, health.MinorityFork)
+	}
+
+	tx, err := w.CreateSimpleTx(
+		keyScope, account, outputs, minconf, satPerKb,
+		coinSelectionStrategy, false, optFuncs...,
+	)
+	w.releaseSpend(reservations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reservations, err = w.reserveSpend(
+		tx.Tx, txFee(tx.Tx, tx.TotalInput), keyScope, account,
+	)
+	if err != nil {
+		opts := defaultTxCreateOptions()
+		for _, optFunc := range optFuncs {
+			optFunc(opts)
+		}
+		if opts.lockInputs {
+			w.unlockInputs(tx.Tx)
+		}
+		return nil, nil, err
+	}
+	return tx, reservations, nil
+}
+
 // SignatureError records the underlying error when validating a transaction
 // input signature.
 type SignatureError struct {
@@ -3242,11 +4483,15 @@ type SignatureError struct {
 
 // SignTransaction uses secrets of the wallet, as well as additional secrets
 // passed in by the caller, to create and add input signatures to a transaction.
+// The output scripts spent by the inputs are looked up in
+// additionalPrevScripts, and otherwise in the transactions recorded by the
+// wallet.  When keys are passed in additionalKeysByAddress, only those keys and
+// the redeem scripts of p2shRedeemScriptsByAddress are used for signing.
 //
 // Transaction input script validation is used to confirm that all signatures
-// are valid.  For any invalid input, a SignatureError is added to the returns.
-// The final error return is reserved for unexpected or fatal errors, such as
-// being unable to determine a previous output script to redeem.
+// are valid.  For any invalid input, or input spending an unknown output, a
+// SignatureError is added to the returns.  The final error return is reserved
+// for unexpected or fatal errors.
 //
 // The transaction pointed to by tx is modified by this function.
 func (w *Wallet) SignTransaction(tx *wire.MsgTx, hashType txscript.SigHashType,
@@ -3254,120 +4499,14 @@ func (w *Wallet) SignTransaction(tx *wire.MsgTx, hashType txscript.SigHashType,
 	additionalKeysByAddress map[string]*btcutil.WIF,
 	p2shRedeemScriptsByAddress map[string][]byte) ([]SignatureError, error) {
 
-	var signErrors []SignatureError
-	err := walletdb.View(w.db, func(dbtx walletdb.ReadTx) error {
-		addrmgrNs := dbtx.ReadBucket(waddrmgrNamespaceKey)
-		txmgrNs := dbtx.ReadBucket(wtxmgrNamespaceKey)
-
-		for i, txIn := range tx.TxIn {
-			prevOutScript, ok := additionalPrevScripts[txIn.PreviousOutPoint]
-			if !ok {
-				prevHash := &txIn.PreviousOutPoint.Hash
-				prevIndex := txIn.PreviousOutPoint.Index
-				txDetails, err := w.TxStore.TxDetails(txmgrNs, prevHash)
-				if err != nil {
-					return fmt.Errorf("cannot query previous transaction "+
-						"details for %v: %v", txIn.PreviousOutPoint, err)
-				}
-				if txDetails == nil {
-					return fmt.Errorf("%v not found",
-						txIn.PreviousOutPoint)
-				}
-				prevOutScript = txDetails.MsgTx.TxOut[prevIndex].PkScript
-			}
-
-			// Set up our callbacks that we pass to txscript so it can
-			// look up the appropriate keys and scripts by address.
-			getKey := txscript.KeyClosure(func(addr btcutil.Address) (*btcec.PrivateKey, bool, error) {
-				if len(additionalKeysByAddress) != 0 {
-					addrStr := addr.EncodeAddress()
-					wif, ok := additionalKeysByAddress[addrStr]
-					if !ok {
-						return nil, false,
-							errors.New("no key for address")
-					}
-					return wif.PrivKey, wif.CompressPubKey, nil
-				}
-				address, err := w.Manager.Address(addrmgrNs, addr)
-				if err != nil {
-					return nil, false, err
-				}
-
-				pka, ok := address.(waddrmgr.ManagedPubKeyAddress)
-				if !ok {
-					return nil, false, fmt.Errorf("address %v is not "+
-						"a pubkey address", address.Address().EncodeAddress())
-				}
-
-				key, err := pka.PrivKey()
-				if err != nil {
-					return nil, false, err
-				}
-
-				return key, pka.Compressed(), nil
-			})
-			getScript := txscript.ScriptClosure(func(addr btcutil.Address) ([]byte, error) {
-				// If keys were provided then we can only use the
-				// redeem scripts provided with our inputs, too.
-				if len(additionalKeysByAddress) != 0 {
-					addrStr := addr.EncodeAddress()
-					script, ok := p2shRedeemScriptsByAddress[addrStr]
-					if !ok {
-						return nil, errors.New("no script for address")
-					}
-					return script, nil
-				}
-				address, err := w.Manager.Address(addrmgrNs, addr)
-				if err != nil {
-					return nil, err
-				}
-				sa, ok := address.(waddrmgr.ManagedScriptAddress)
-				if !ok {
-					return nil, errors.New("address is not a script" +
-						" address")
-				}
-
-				return sa.Script()
-			})
-
-			// SigHashSingle inputs can only be signed if there's a
-			// corresponding output. However this could be already signed,
-			// so we always verify the output.
-			if (hashType&txscript.SigHashSingle) !=
-				txscript.SigHashSingle || i < len(tx.TxOut) {
-
-				script, err := txscript.SignTxOutput(w.ChainParams(),
-					tx, i, prevOutScript, hashType, getKey,
-					getScript, txIn.SignatureScript)
-				// Failure to sign isn't an error, it just means that
-				// the tx isn't complete.
-				if err != nil {
-					signErrors = append(signErrors, SignatureError{
-						InputIndex: uint32(i),
-						Error:      err,
-					})
-					continue
-				}
-				txIn.SignatureScript = script
-			}
-
-			// Either it was already signed or we just signed it.
-			// Find out if it is completely satisfied or still needs more.
-			vm, err := txscript.NewEngine(prevOutScript, tx, i,
-				txscript.StandardVerifyFlags, nil, nil, 0)
-			if err == nil {
-				err = vm.Execute()
-			}
-			if err != nil {
-				signErrors = append(signErrors, SignatureError{
-					InputIndex: uint32(i),
-					Error:      err,
-				})
-			}
-		}
-		return nil
-	})
-	return signErrors, err
+	prevOutputs := make(map[wire.OutPoint]*PrevOutput, len(additionalPrevScripts))
+	for op, pkScript := range additionalPrevScripts {
+		prevOutputs[op] = &PrevOutput{PkScript: pkScript}
+	}
+	return w.signTransaction(
+		tx, hashType, prevOutputs, additionalKeysByAddress,
+		p2shRedeemScriptsByAddress,
+	)
 }
 
 // ErrDoubleSpend is an error returned from PublishTransaction in case the
@@ -3414,7 +4553,7 @@ func (e *ErrReplacement) Unwrap() error {
 // This function is unstable and will be removed once syncing code is moved out
 // of the wallet.
 func (w *Wallet) PublishTransaction(tx *wire.MsgTx, label string) error {
-	_, err := w.reliablyPublishTransaction(tx, label)
+	_, err := w.reliablyPublishTransaction(tx, label, "", "")
 	return err
 }
 
@@ -3422,9 +4561,10 @@ func (w *Wallet) PublishTransaction(tx *wire.MsgTx, label string) error {
 // the primary logic required for publishing a transaction, updating the
 // relevant database state, and finally possible removing the transaction from
 // the database (along with cleaning up all inputs used, and outputs created) if
-// the transaction is rejected by the backend.
+// the transaction is rejected by the backend.  The label and comments, when not
+// empty, are recorded with the transaction.
 func (w *Wallet) reliablyPublishTransaction(tx *wire.MsgTx,
-	label string) (*chainhash.Hash, error) {
+	label, comment, commentTo string) (*chainhash.Hash, error) {
 
 	chainClient, err := w.requireChainClient()
 	if err != nil {
@@ -3435,7 +4575,7 @@ func (w *Wallet) reliablyPublishTransaction(tx *wire.MsgTx,
 	// we'll write this tx to disk as an unconfirmed transaction. This way,
 	// upon restarts, we'll always rebroadcast it, and also add it to our
 	// set of records.
-	txRec, err := wtxmgr.NewTxRecordFromMsgTx(tx, time.Now())
+	txRec, err := wtxmgr.NewTxRecordFromMsgTx(tx, w.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -3476,6 +4616,15 @@ func (w *Wallet) reliablyPublishTransaction(tx *wire.MsgTx,
 				return err
 			}
 		}
+		if comment != "" || commentTo != "" {
+			txmgrNs := dbTx.ReadWriteBucket(wtxmgrNamespaceKey)
+			err := w.TxStore.PutTxComment(
+				txmgrNs, tx.TxHash(), comment, commentTo,
+			)
+			if err != nil {
+				return err
+			}
+		}
 
 		return w.addRelevantTx(dbTx, txRec, nil)
 	})
@@ -3709,7 +4858,8 @@ func CreateWithCallback(db walletdb.DB, pubPass, privPass []byte,
 	birthday time.Time, cb func(walletdb.ReadWriteTx) error) error {
 
 	return create(
-		db, pubPass, privPass, rootKey, params, birthday, false, cb,
+		db, pubPass, privPass, rootKey, params, birthday, false,
+		false, cb,
 	)
 }
 
@@ -3721,7 +4871,7 @@ func CreateWatchingOnlyWithCallback(db walletdb.DB, pubPass []byte,
 	cb func(walletdb.ReadWriteTx) error) error {
 
 	return create(
-		db, pubPass, nil, nil, params, birthday, true, cb,
+		db, pubPass, nil, nil, params, birthday, true, false, cb,
 	)
 }
 
@@ -3733,7 +4883,8 @@ func Create(db walletdb.DB, pubPass, privPass []byte,
 	birthday time.Time) error {
 
 	return create(
-		db, pubPass, privPass, rootKey, params, birthday, false, nil,
+		db, pubPass, privPass, rootKey, params, birthday, false,
+		false, nil,
 	)
 }
 
@@ -3745,19 +4896,43 @@ func CreateWatchingOnly(db walletdb.DB, pubPass []byte,
 	params *chaincfg.Params, birthday time.Time) error {
 
 	return create(
-		db, pubPass, nil, nil, params, birthday, true, nil,
+		db, pubPass, nil, nil, params, birthday, true, false, nil,
+	)
+}
+
+// CreateBlankWithCallback is the same as CreateBlank with an added callback
+// that will be called in the same transaction the wallet structure is
+// initialized.
+func CreateBlankWithCallback(db walletdb.DB, pubPass, privPass []byte,
+	params *chaincfg.Params, birthday time.Time,
+	cb func(walletdb.ReadWriteTx) error) error {
+
+	return create(
+		db, pubPass, privPass, nil, params, birthday, false, true, cb,
+	)
+}
+
+// CreateBlank creates a new blank wallet, writing it to an empty database.  A
+// blank wallet holds private keys protected by the private passphrase, but has
+// no seed, so it holds no keys until they are imported.
+func CreateBlank(db walletdb.DB, pubPass, privPass []byte,
+	params *chaincfg.Params, birthday time.Time) error {
+
+	return create(
+		db, pubPass, privPass, nil, params, birthday, false, true, nil,
 	)
 }
 
 func create(db walletdb.DB, pubPass, privPass []byte,
 	rootKey *hdkeychain.ExtendedKey, params *chaincfg.Params,
-	birthday time.Time, isWatchingOnly bool,
+	birthday time.Time, isWatchingOnly, isBlank bool,
 	cb func(walletdb.ReadWriteTx) error) error {
 
 	// If no root key was provided, we create one now from a random seed.
 	// But only if this is not a watching-only wallet where the accounts are
-	// created individually from their xpubs.
-	if !isWatchingOnly && rootKey == nil {
+	// created individually from their xpubs, or a blank wallet where keys
+	// are imported.
+	if !isWatchingOnly && !isBlank && rootKey == nil {
 		hdSeed, err := hdkeychain.GenerateSeed(
 			hdkeychain.RecommendedSeedLen,
 		)
@@ -3789,10 +4964,17 @@ func create(db walletdb.DB, pubPass, privPass []byte,
 			return err
 		}
 
-		err = waddrmgr.Create(
-			addrmgrNs, rootKey, pubPass, privPass, params, nil,
-			birthday,
-		)
+		if isBlank {
+			err = waddrmgr.CreateBlank(
+				addrmgrNs, pubPass, privPass, params, nil,
+				birthday,
+			)
+		} else {
+			err = waddrmgr.Create(
+				addrmgrNs, rootKey, pubPass, privPass, params,
+				nil, birthday,
+			)
+		}
 		if err != nil {
 			return err
 		}
@@ -3810,6 +4992,31 @@ func create(db walletdb.DB, pubPass, privPass []byte,
 	})
 }
 
+// dbNeedsUpgrade returns whether the address or transaction manager of the
+// wallet database is at a version older than the latest one, in which case
+// Open will migrate the database.
+func dbNeedsUpgrade(db walletdb.DB) (bool, error) {
+	var needsUpgrade bool
+	err := walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		addrMgrBucket := tx.ReadWriteBucket(waddrmgrNamespaceKey)
+		if addrMgrBucket == nil {
+			return errors.New("missing address manager namespace")
+		}
+		txMgrBucket := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+		if txMgrBucket == nil {
+			return errors.New("missing transaction manager namespace")
+		}
+
+		var err error
+		needsUpgrade, err = migration.NeedsUpgrade(
+			wtxmgr.NewMigrationManager(txMgrBucket),
+			waddrmgr.NewMigrationManager(addrMgrBucket),
+		)
+		return err
+	})
+	return needsUpgrade, err
+}
+
 // Open loads an already-created wallet from the passed database and namespaces.
 func Open(db walletdb.DB, pubPass []byte, cbs *waddrmgr.OpenCallbacks,
 	params *chaincfg.Params, recoveryWindow uint32) (*Wallet, error) {
@@ -3858,32 +5065,45 @@ func Open(db walletdb.DB, pubPass []byte, cbs *waddrmgr.OpenCallbacks,
 	log.Infof("Opened wallet") // TODO: log balance? last sync height?
 
 	w := &Wallet{
-		publicPassphrase:    pubPass,
-		db:                  db,
-		Manager:             addrMgr,
-		TxStore:             txMgr,
-		lockedOutpoints:     map[wire.OutPoint]struct{}{},
-		recoveryWindow:      recoveryWindow,
-		rescanAddJob:        make(chan *RescanJob),
-		rescanBatch:         make(chan *rescanBatch),
-		rescanNotifications: make(chan interface{}),
-		rescanProgress:      make(chan *RescanProgressMsg),
-		rescanFinished:      make(chan *RescanFinishedMsg),
-		createTxRequests:    make(chan createTxRequest),
-		unlockRequests:      make(chan unlockRequest),
-		lockRequests:        make(chan struct{}),
-		holdUnlockRequests:  make(chan chan heldUnlock),
-		lockState:           make(chan bool),
-		changePassphrase:    make(chan changePassphraseRequest),
-		changePassphrases:   make(chan changePassphrasesRequest),
-		chainParams:         params,
-		quit:                make(chan struct{}),
+		publicPassphrase:     pubPass,
+		db:                   db,
+		Manager:              addrMgr,
+		TxStore:              txMgr,
+		lockedOutpoints:      map[wire.OutPoint]struct{}{},
+		recoveryWindow:       recoveryWindow,
+		rescanAddJob:         make(chan *RescanJob),
+		rescanBatch:          make(chan *rescanBatch),
+		rescanNotifications:  make(chan interface{}),
+		rescanProgress:       make(chan *RescanProgressMsg),
+		rescanFinished:       make(chan *RescanFinishedMsg),
+		rescanCancelRequests: make(chan cancelRescanRequest),
+		rescanStatusRequests: make(chan chan []RescanJobStatus),
+		createTxRequests:     make(chan createTxRequest),
+		unlockRequests:       make(chan unlockRequest),
+		extendUnlockRequests: make(chan extendUnlockRequest),
+		lockRequests:         make(chan lockRequest),
+		holdUnlockRequests:   make(chan chan heldUnlock),
+		lockState:            make(chan bool),
+		unlockExpiry:         make(chan unlockExpiry),
+		changePassphrase:     make(chan changePassphraseRequest),
+		changePassphrases:    make(chan changePassphrasesRequest),
+		accountLockTimers:    make(map[accountLockKey]*accountLockTimer),
+		depositWebhookQueue:  make(chan LargeDeposit, depositWebhookQueueLen),
+		chainParams:          params,
+		clock:                time.Now,
+		quit:                 make(chan struct{}),
 	}
 
 	w.NtfnServer = newNotificationServer(w)
 	w.TxStore.NotifyUnspent = func(hash *chainhash.Hash, index uint32) {
 		w.NtfnServer.notifyUnspentOutput(0, hash, index)
 	}
+	w.TxStore.NotifyConflict = func(hash, conflictHash *chainhash.Hash) {
+		log.Infof("Removed transaction %v conflicting with %v",
+			hash, conflictHash)
+		w.NtfnServer.notifyConflictedTransaction(hash, conflictHash)
+	}
+	w.TxStore.NotifyCreditChange = w.balances.creditChanged
 
 	return w, nil
 }
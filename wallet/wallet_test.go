@@ -2,9 +2,14 @@ package wallet
 
 import (
 	"encoding/hex"
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/btcsuite/btcwallet/wtxmgr"
 
@@ -203,3 +208,508 @@ func TestLabelTransaction(t *testing.T) {
 		})
 	}
 }
+
+// TestUnlockTimeout ensures the timeout managed by the wallet locks it once it
+// expires, and can be replaced, extended, and cancelled while unlocked.
+func TestUnlockTimeout(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	privPass := []byte("world")
+	waitLocked := func() {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !w.Locked() {
+			if time.Now().After(deadline) {
+				t.Fatal("wallet was not locked after its timeout")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// A lock channel of the caller is not managed by the wallet, so no
+	// expiry is known.
+	until, unlocked := w.UnlockedUntil()
+	if !unlocked || !until.IsZero() {
+		t.Fatalf("want unlocked without expiry, got unlocked=%v "+
+			"until=%v", unlocked, until)
+	}
+
+	// Unlocking again with a short timeout replaces the previous one, and
+	// re-unlocking with a longer timeout prevents the short one from ever
+	// locking the wallet.
+	if err := w.UnlockWithTimeout(privPass, 50*time.Millisecond); err != nil {
+		t.Fatalf("unable to unlock wallet: %v", err)
+	}
+	if err := w.UnlockWithTimeout(privPass, time.Hour); err != nil {
+		t.Fatalf("unable to unlock wallet: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	until, unlocked = w.UnlockedUntil()
+	if !unlocked {
+		t.Fatal("wallet was locked by a replaced timeout")
+	}
+	if until.Before(time.Now().Add(59 * time.Minute)) {
+		t.Fatalf("unexpected expiry %v", until)
+	}
+
+	// Cancelling the timeout keeps the wallet unlocked until it is locked.
+	if err := w.ExtendUnlock(0); err != nil {
+		t.Fatalf("unable to cancel timeout: %v", err)
+	}
+	until, unlocked = w.UnlockedUntil()
+	if !unlocked || !until.IsZero() {
+		t.Fatalf("want unlocked without expiry, got unlocked=%v "+
+			"until=%v", unlocked, until)
+	}
+
+	// An extended timeout locks the wallet once it expires.
+	if err := w.ExtendUnlock(50 * time.Millisecond); err != nil {
+		t.Fatalf("unable to extend timeout: %v", err)
+	}
+	waitLocked()
+	if _, unlocked := w.UnlockedUntil(); unlocked {
+		t.Fatal("locked wallet reported as unlocked")
+	}
+
+	// The timeout of a locked wallet can't be extended.
+	err := w.ExtendUnlock(time.Hour)
+	if !waddrmgr.IsError(err, waddrmgr.ErrLocked) {
+		t.Fatalf("want ErrLocked, got %v", err)
+	}
+}
+
+// TestLockAllAccounts ensures that locking the wallet together with every
+// account protected by its own passphrase is described by a single lock state
+// notification.
+func TestLockAllAccounts(t *testing.T) {
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	scope := waddrmgr.KeyScopeBIP0084
+	account, err := w.NextAccount(scope, "protected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphrase := []byte("account passphrase")
+	if err := w.SetAccountPassphrase(scope, account, passphrase); err != nil {
+		t.Fatal(err)
+	}
+
+	client := w.NtfnServer.LockStateNotifications()
+	defer client.Done()
+
+	err = w.UnlockAccount(scope, account, passphrase, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Locking must not wait for the client to receive the notification.
+	lockedAll := make(chan struct{})
+	go func() {
+		w.LockAllAccounts()
+		close(lockedAll)
+	}()
+	select {
+	case <-lockedAll:
+	case <-time.After(5 * time.Second):
+		t.Fatal("locking blocked on lock state notification")
+	}
+
+	// The unlock of the account is notified first, followed by the lock
+	// of the wallet together with the account.
+	want := waddrmgr.ScopedIndex{Scope: scope, Index: account}
+	var change *LockStateChange
+	select {
+	case change = <-client.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no lock state notification")
+	}
+	if change.Locked || change.Wallet || len(change.Accounts) != 1 ||
+		change.Accounts[0] != want {
+
+		t.Fatalf("expected account %v to be unlocked, got %+v", want,
+			change)
+	}
+	select {
+	case change = <-client.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no lock state notification")
+	}
+	if !change.Locked || !change.Wallet {
+		t.Fatalf("expected the wallet to be locked, got %+v", change)
+	}
+	if len(change.Accounts) != 1 || change.Accounts[0] != want {
+		t.Fatalf("expected account %v to be locked, got %v", want,
+			change.Accounts)
+	}
+
+	select {
+	case change = <-client.C:
+		t.Fatalf("unexpected lock state notification %+v", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	locked, err := w.AccountLocked(scope, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.Locked() || !locked {
+		t.Fatal("expected the wallet and account to be locked")
+	}
+}
+
+// TestTotalReceivedForAddr ensures that the amount received by an address is
+// totalled from only the credits paying to the address.
+func TestTotalReceivedForAddr(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherAddr, err := w.NewAddress(0, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherScript, err := txscript.PayToAddrScript(otherAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Record two unmined transactions, each paying to both addresses.
+	for i := 0; i < 2; i++ {
+		msgTx := wire.NewMsgTx(wire.TxVersion)
+		msgTx.AddTxIn(wire.NewTxIn(
+			&wire.OutPoint{Index: uint32(i)}, nil, nil,
+		))
+		msgTx.AddTxOut(wire.NewTxOut(1e6, pkScript))
+		msgTx.AddTxOut(wire.NewTxOut(2e6, otherScript))
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+			ns := tx.ReadWriteBucket(wtxmgrNamespaceKey)
+			if err := w.TxStore.InsertTx(ns, rec, nil); err != nil {
+				return err
+			}
+			for idx := uint32(0); idx < 2; idx++ {
+				err := w.TxStore.AddCredit(ns, rec, nil, idx, false)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unable to record transaction: %v", err)
+		}
+	}
+
+	amount, err := w.TotalReceivedForAddr(addr, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount != 2e6 {
+		t.Fatalf("expected %v received, got %v", btcutil.Amount(2e6),
+			amount)
+	}
+
+	// The transactions are unmined, so nothing has been received with a
+	// confirmation.
+	amount, err = w.TotalReceivedForAddr(addr, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount != 0 {
+		t.Fatalf("expected nothing received, got %v", amount)
+	}
+}
+
+// TestChangeNotReceived ensures that change addresses are not listed as
+// payment addresses and that change is not counted as received.
+func TestChangeNotReceived(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	scope := waddrmgr.KeyScopeBIP0044
+	addr, err := w.NewAddress(0, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeAddr, err := w.NewChangeAddress(0, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeScript, err := txscript.PayToAddrScript(changeAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e6, pkScript))
+	msgTx.AddTxOut(wire.NewTxOut(2e6, changeScript))
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return w.addRelevantTx(tx, rec, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := w.TotalReceivedForAccounts(scope, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].TotalReceived != 1e6 {
+		t.Fatalf("expected %v received, got %v", btcutil.Amount(1e6),
+			results[0].TotalReceived)
+	}
+
+	addrs, err := w.AccountReceiveAddresses(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range addrs {
+		if a.String() == changeAddr.String() {
+			t.Fatalf("change address %v listed as receive address",
+				changeAddr)
+		}
+	}
+	allAddrs, err := w.AccountAddresses(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allAddrs) != len(addrs)+1 {
+		t.Fatalf("expected %d account addresses, got %d",
+			len(addrs)+1, len(allAddrs))
+	}
+
+	active, err := w.SortedActivePaymentAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, a := range active {
+		if a == changeAddr.EncodeAddress() {
+			t.Fatalf("change address %v listed as payment address",
+				changeAddr)
+		}
+		found = found || a == addr.EncodeAddress()
+	}
+	if !found {
+		t.Fatalf("payment address %v not listed", addr)
+	}
+}
+
+// TestListTransactionsFiltered ensures that transactions are listed after the
+// transaction continued from, and only when received within the time range.
+func TestListTransactionsFiltered(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	addr, err := w.CurrentAddress(0, waddrmgr.KeyScopeBIP0044)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Record three unmined transactions received an hour apart.
+	base := time.Unix(1600000000, 0)
+	received := make(map[string]time.Time)
+	for i := 0; i < 3; i++ {
+		msgTx := wire.NewMsgTx(wire.TxVersion)
+		msgTx.AddTxIn(wire.NewTxIn(
+			&wire.OutPoint{Index: uint32(i)}, nil, nil,
+		))
+		msgTx.AddTxOut(wire.NewTxOut(1e6, pkScript))
+		when := base.Add(time.Duration(i) * time.Hour)
+		rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, when)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+			return w.addRelevantTx(tx, rec, nil)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		received[rec.Hash.String()] = when
+	}
+
+	txIDs := func(filter *TxListFilter) []string {
+		t.Helper()
+
+		results, err := w.ListTransactionsFiltered(0, 100, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.TxID)
+		}
+		return ids
+	}
+
+	all := txIDs(&TxListFilter{})
+	if len(all) != 3 {
+		t.Fatalf("listed %d transactions, want 3", len(all))
+	}
+
+	// Continuing after the first transaction lists the others in the
+	// same order.
+	first, err := chainhash.NewHashFromStr(all[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := txIDs(&TxListFilter{After: first})
+	if !reflect.DeepEqual(after, all[1:]) {
+		t.Fatalf("listed %v after %v, want %v", after, all[0], all[1:])
+	}
+
+	// Only the transaction received in the second hour is in range.
+	inRange := txIDs(&TxListFilter{Received: TimeRange{
+		Start: base.Add(time.Hour),
+		End:   base.Add(2 * time.Hour),
+	}})
+	if len(inRange) != 1 || !received[inRange[0]].Equal(base.Add(time.Hour)) {
+		t.Fatalf("listed %v in range", inRange)
+	}
+
+	_, err = w.ListTransactionsFiltered(0, 100, &TxListFilter{
+		After: &chainhash.Hash{1},
+	})
+	if err != ErrListCursorNotFound {
+		t.Fatalf("continuing after unknown transaction: got error %v",
+			err)
+	}
+}
+
+// TestMergeAccounts ensures that the addresses of a merged account, and the
+// outputs paying to them, are accounted to the account merged into.
+func TestMergeAccounts(t *testing.T) {
+	t.Parallel()
+
+	w, cleanup := testWallet(t)
+	defer cleanup()
+
+	scope := waddrmgr.KeyScopeBIP0044
+	account, err := w.NextAccount(scope, "savings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := w.NewAddress(account, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1e6, pkScript))
+	rec, err := wtxmgr.NewTxRecordFromMsgTx(msgTx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = walletdb.Update(w.db, func(tx walletdb.ReadWriteTx) error {
+		return w.addRelevantTx(tx, rec, nil)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the balances before merging so that they are cached, and must
+	// be updated for the accounts of the merged addresses.
+	for acct, total := range map[uint32]btcutil.Amount{0: 0, account: 1e6} {
+		balances, err := w.CalculateAccountBalances(acct, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balances.Total != total {
+			t.Fatalf("expected balance %v of account %d before "+
+				"merging, got %v", total, acct, balances.Total)
+		}
+	}
+
+	if err := w.MergeAccounts(scope, account, account); err == nil {
+		t.Fatal("expected error merging account into itself")
+	}
+	err = w.MergeAccounts(scope, waddrmgr.ImportedAddrAccount, 0)
+	if err == nil {
+		t.Fatal("expected error merging imported account")
+	}
+	if err := w.MergeAccounts(scope, account, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	addrAccount, err := w.AccountOfAddress(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addrAccount != 0 {
+		t.Fatalf("expected address of account 0, got %d", addrAccount)
+	}
+	for acct, total := range map[uint32]btcutil.Amount{0: 1e6, account: 0} {
+		balances, err := w.CalculateAccountBalances(acct, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balances.Total != total {
+			t.Fatalf("expected balance %v of account %d, got %v",
+				total, acct, balances.Total)
+		}
+	}
+	addrs, err := w.AccountAddresses(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 0 {
+		t.Fatalf("expected no addresses of merged account, got %v",
+			addrs)
+	}
+
+	// The merged account is kept, and its new addresses belong to it.
+	newAddr, err := w.NewAddress(account, scope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newAddr.String() == addr.String() {
+		t.Fatalf("address %v derived again", addr)
+	}
+	addrAccount, err = w.AccountOfAddress(newAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addrAccount != account {
+		t.Fatalf("expected address of account %d, got %d", account,
+			addrAccount)
+	}
+}
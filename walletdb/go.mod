@@ -5,5 +5,6 @@ go 1.12
 require (
 	github.com/btcsuite/btclog v0.0.0-20170628155309-84c8d2346e9f
 	github.com/davecgh/go-spew v1.1.1
+	github.com/mattn/go-sqlite3 v1.14.6
 	go.etcd.io/bbolt v1.3.5-0.20200615073812-232d8fc87f50
 )
@@ -0,0 +1,723 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package memdb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// errCopyUnsupported is returned when copying a database, as a copy could only
+// be opened again by writing it to disk.
+var errCopyUnsupported = errors.New("in-memory databases can not be copied")
+
+// entry is a key/value pair or nested bucket of a bucket.  Exactly one of
+// value and bucket is set.
+type entry struct {
+	key    []byte
+	value  []byte
+	bucket *bucketData
+}
+
+// bucketData holds the entries of a bucket sorted by key.
+//
+// Committed bucket data is never modified, so that read transactions may keep
+// using the data of the snapshot they began with.  A read-write transaction
+// modifies a copy of each bucket it writes to, which it owns, along with
+// copies of the buckets containing it.
+type bucketData struct {
+	entries  []entry
+	sequence uint64
+	owner    *transaction
+}
+
+// search returns the index of the first entry with a key not less than key,
+// and whether the entry has the key.
+func (d *bucketData) search(key []byte) (int, bool) {
+	i := sort.Search(len(d.entries), func(i int) bool {
+		return bytes.Compare(d.entries[i].key, key) >= 0
+	})
+	return i, i < len(d.entries) && bytes.Equal(d.entries[i].key, key)
+}
+
+// clone returns a copy of the bucket data owned by tx.
+func (d *bucketData) clone(tx *transaction) *bucketData {
+	entries := make([]entry, len(d.entries))
+	copy(entries, d.entries)
+	return &bucketData{entries: entries, sequence: d.sequence, owner: tx}
+}
+
+// insert inserts the entry at index i.
+func (d *bucketData) insert(i int, e entry) {
+	d.entries = append(d.entries, entry{})
+	copy(d.entries[i+1:], d.entries[i:])
+	d.entries[i] = e
+}
+
+// remove removes the entry at index i.
+func (d *bucketData) remove(i int) {
+	copy(d.entries[i:], d.entries[i+1:])
+	d.entries[len(d.entries)-1] = entry{}
+	d.entries = d.entries[:len(d.entries)-1]
+}
+
+// transaction represents a database transaction.  It can either by read-only or
+// read-write and implements the walletdb Tx interfaces.
+type transaction struct {
+	db       *db
+	root     *bucketData
+	writable bool
+	closed   bool
+	onCommit []func()
+
+	// version is incremented whenever a bucket of the transaction is
+	// copied, created or deleted, invalidating the bucket data cached by
+	// bucket handles.
+	version uint64
+}
+
+// Enforce transaction implements the walletdb Tx interfaces.
+var _ walletdb.ReadWriteTx = (*transaction)(nil)
+
+// rootBucket returns the bucket holding all top level buckets.
+func (tx *transaction) rootBucket() *bucket {
+	return &bucket{tx: tx}
+}
+
+func (tx *transaction) ReadBucket(key []byte) walletdb.ReadBucket {
+	return tx.ReadWriteBucket(key)
+}
+
+// ForEachBucket will iterate through all top level buckets.
+func (tx *transaction) ForEachBucket(fn func(key []byte) error) error {
+	return tx.rootBucket().ForEach(func(k, v []byte) error {
+		if v != nil {
+			return nil
+		}
+		return fn(k)
+	})
+}
+
+func (tx *transaction) ReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	return tx.rootBucket().NestedReadWriteBucket(key)
+}
+
+func (tx *transaction) CreateTopLevelBucket(key []byte) (walletdb.ReadWriteBucket, error) {
+	return tx.rootBucket().CreateBucketIfNotExists(key)
+}
+
+func (tx *transaction) DeleteTopLevelBucket(key []byte) error {
+	return tx.rootBucket().DeleteNestedBucket(key)
+}
+
+// Commit commits all changes that have been made through the root bucket and
+// all of its sub-buckets, making them visible to later transactions.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) Commit() error {
+	if tx.closed {
+		return walletdb.ErrTxClosed
+	}
+	if !tx.writable {
+		return walletdb.ErrTxNotWritable
+	}
+
+	tx.closed = true
+	tx.db.mu.Lock()
+	closed := tx.db.closed
+	if !closed {
+		tx.db.root = tx.root
+	}
+	tx.db.mu.Unlock()
+	tx.db.writeMtx.Unlock()
+	if closed {
+		return walletdb.ErrDbNotOpen
+	}
+
+	for _, f := range tx.onCommit {
+		f()
+	}
+	return nil
+}
+
+// Rollback undoes all changes that have been made to the root bucket and all of
+// its sub-buckets.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) Rollback() error {
+	if tx.closed {
+		return walletdb.ErrTxClosed
+	}
+
+	tx.closed = true
+	if tx.writable {
+		tx.db.writeMtx.Unlock()
+	}
+	return nil
+}
+
+// OnCommit takes a function closure that will be executed when the transaction
+// successfully gets committed.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) OnCommit(f func()) {
+	tx.onCommit = append(tx.onCommit, f)
+}
+
+// checkWritable returns ErrTxNotWritable or ErrTxClosed if the transaction
+// may not be written to.
+func (tx *transaction) checkWritable() error {
+	if tx.closed {
+		return walletdb.ErrTxClosed
+	}
+	if !tx.writable {
+		return walletdb.ErrTxNotWritable
+	}
+	return nil
+}
+
+// writableRoot returns the root bucket data owned by the transaction.
+func (tx *transaction) writableRoot() *bucketData {
+	if tx.root.owner != tx {
+		tx.root = tx.root.clone(tx)
+		tx.version++
+	}
+	return tx.root
+}
+
+// bucket is an internal type used to represent a collection of key/value pairs
+// and implements the walletdb Bucket interfaces.  The bucket is identified by
+// its key within its parent, and a nil parent identifies the root bucket.
+type bucket struct {
+	tx     *transaction
+	parent *bucket
+	key    []byte
+
+	// cached is the data of the bucket when the transaction was at
+	// cachedVersion.
+	cached        *bucketData
+	cachedVersion uint64
+}
+
+// Enforce bucket implements the walletdb Bucket interfaces.
+var _ walletdb.ReadWriteBucket = (*bucket)(nil)
+
+// data returns the current data of the bucket, or nil if the bucket no longer
+// exists.
+func (b *bucket) data() *bucketData {
+	if b.parent == nil {
+		return b.tx.root
+	}
+	if b.cached != nil && b.cachedVersion == b.tx.version {
+		return b.cached
+	}
+
+	parent := b.parent.data()
+	if parent == nil {
+		return nil
+	}
+	i, ok := parent.search(b.key)
+	if !ok {
+		return nil
+	}
+	b.cached, b.cachedVersion = parent.entries[i].bucket, b.tx.version
+	return b.cached
+}
+
+// writableData returns the data of the bucket owned by the transaction,
+// copying it and the data of the buckets containing it if necessary.
+func (b *bucket) writableData() (*bucketData, error) {
+	if err := b.tx.checkWritable(); err != nil {
+		return nil, err
+	}
+	if b.parent == nil {
+		return b.tx.writableRoot(), nil
+	}
+
+	d := b.data()
+	if d == nil {
+		return nil, walletdb.ErrBucketNotFound
+	}
+	if d.owner == b.tx {
+		return d, nil
+	}
+
+	parent, err := b.parent.writableData()
+	if err != nil {
+		return nil, err
+	}
+	i, _ := parent.search(b.key)
+	d = d.clone(b.tx)
+	parent.entries[i].bucket = d
+	b.tx.version++
+	b.cached, b.cachedVersion = d, b.tx.version
+	return d, nil
+}
+
+// NestedReadWriteBucket retrieves a nested bucket with the given key.  Returns
+// nil if the bucket does not exist.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) NestedReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	d := b.data()
+	if d == nil {
+		return nil
+	}
+	i, ok := d.search(key)
+	if !ok || d.entries[i].bucket == nil {
+		return nil
+	}
+	return &bucket{tx: b.tx, parent: b, key: d.entries[i].key}
+}
+
+func (b *bucket) NestedReadBucket(key []byte) walletdb.ReadBucket {
+	return b.NestedReadWriteBucket(key)
+}
+
+// CreateBucket creates and returns a new nested bucket with the given key.
+// Returns ErrBucketExists if the bucket already exists, ErrBucketNameRequired
+// if the key is empty, or ErrIncompatibleValue if the key is used by a
+// key/value pair.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) CreateBucket(key []byte) (walletdb.ReadWriteBucket, error) {
+	if len(key) == 0 {
+		return nil, walletdb.ErrBucketNameRequired
+	}
+	d, err := b.writableData()
+	if err != nil {
+		return nil, err
+	}
+
+	i, ok := d.search(key)
+	if ok {
+		if d.entries[i].bucket == nil {
+			return nil, walletdb.ErrIncompatibleValue
+		}
+		return nil, walletdb.ErrBucketExists
+	}
+
+	key = append([]byte(nil), key...)
+	d.insert(i, entry{key: key, bucket: &bucketData{owner: b.tx}})
+	b.tx.version++
+	return &bucket{tx: b.tx, parent: b, key: key}, nil
+}
+
+// CreateBucketIfNotExists creates and returns a new nested bucket with the
+// given key if it does not already exist.  Returns ErrBucketNameRequired if the
+// key is empty or ErrIncompatibleValue if the key is used by a key/value pair.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) CreateBucketIfNotExists(key []byte) (walletdb.ReadWriteBucket, error) {
+	nested, err := b.CreateBucket(key)
+	if err == walletdb.ErrBucketExists {
+		return b.NestedReadWriteBucket(key), nil
+	}
+	return nested, err
+}
+
+// DeleteNestedBucket removes a nested bucket with the given key.  Returns
+// ErrTxNotWritable if attempted against a read-only transaction and
+// ErrBucketNotFound if the specified bucket does not exist.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) DeleteNestedBucket(key []byte) error {
+	d, err := b.writableData()
+	if err != nil {
+		return err
+	}
+
+	// An empty key never names a bucket, which is reported the same way
+	// as by the bdb driver.
+	if len(key) == 0 {
+		return walletdb.ErrIncompatibleValue
+	}
+	i, ok := d.search(key)
+	if !ok {
+		return walletdb.ErrBucketNotFound
+	}
+	if d.entries[i].bucket == nil {
+		return walletdb.ErrIncompatibleValue
+	}
+
+	d.remove(i)
+	b.tx.version++
+	return nil
+}
+
+// ForEach invokes the passed function with every key/value pair in the bucket.
+// This includes nested buckets, in which case the value is nil, but it does not
+// include the key/value pairs within those nested buckets.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) ForEach(fn func(k, v []byte) error) error {
+	d := b.data()
+	if d == nil {
+		return walletdb.ErrBucketNotFound
+	}
+
+	for _, e := range d.entries {
+		if err := fn(e.key, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Put saves the specified key/value pair to the bucket.  Keys that do not
+// already exist are added and keys that already exist are overwritten.
+// Returns ErrTxNotWritable if attempted against a read-only transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) Put(key, value []byte) error {
+	if len(key) == 0 {
+		return walletdb.ErrKeyRequired
+	}
+	d, err := b.writableData()
+	if err != nil {
+		return err
+	}
+
+	value = append([]byte{}, value...)
+	i, ok := d.search(key)
+	switch {
+	case ok && d.entries[i].bucket != nil:
+		return walletdb.ErrIncompatibleValue
+	case ok:
+		d.entries[i].value = value
+	default:
+		key = append([]byte(nil), key...)
+		d.insert(i, entry{key: key, value: value})
+	}
+	return nil
+}
+
+// Get returns the value for the given key.  Returns nil if the key does not
+// exist in this bucket, or if the key names a nested bucket.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) Get(key []byte) []byte {
+	d := b.data()
+	if d == nil {
+		return nil
+	}
+	i, ok := d.search(key)
+	if !ok {
+		return nil
+	}
+	return d.entries[i].value
+}
+
+// Delete removes the specified key from the bucket.  Deleting a key that does
+// not exist does not return an error.  Returns ErrTxNotWritable if attempted
+// against a read-only transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) Delete(key []byte) error {
+	d, err := b.writableData()
+	if err != nil {
+		return err
+	}
+
+	i, ok := d.search(key)
+	switch {
+	case !ok:
+		return nil
+	case d.entries[i].bucket != nil:
+		return walletdb.ErrIncompatibleValue
+	}
+	d.remove(i)
+	return nil
+}
+
+func (b *bucket) ReadCursor() walletdb.ReadCursor {
+	return b.ReadWriteCursor()
+}
+
+// ReadWriteCursor returns a new cursor, allowing for iteration over the bucket's
+// key/value pairs and nested buckets in forward or backward order.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) ReadWriteCursor() walletdb.ReadWriteCursor {
+	return &cursor{bucket: b}
+}
+
+// Tx returns the bucket's transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) Tx() walletdb.ReadWriteTx {
+	return b.tx
+}
+
+// NextSequence returns an autoincrementing integer for the bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) NextSequence() (uint64, error) {
+	d, err := b.writableData()
+	if err != nil {
+		return 0, err
+	}
+	d.sequence++
+	return d.sequence, nil
+}
+
+// SetSequence updates the sequence number for the bucket.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) SetSequence(v uint64) error {
+	d, err := b.writableData()
+	if err != nil {
+		return err
+	}
+	d.sequence = v
+	return nil
+}
+
+// Sequence returns the current integer for the bucket without incrementing it.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) Sequence() uint64 {
+	d := b.data()
+	if d == nil {
+		return 0
+	}
+	return d.sequence
+}
+
+// cursor represents a cursor over key/value pairs and nested buckets of a
+// bucket.  The cursor is positioned by key, so that it remains valid while the
+// bucket is modified.
+type cursor struct {
+	bucket *bucket
+	key    []byte
+}
+
+// Enforce cursor implements the walletdb.ReadWriteCursor interface.
+var _ walletdb.ReadWriteCursor = (*cursor)(nil)
+
+// moveTo positions the cursor at the entry with index i of the bucket data,
+// returning its key and value, or nil if there is no such entry.
+func (c *cursor) moveTo(d *bucketData, i int) (key, value []byte) {
+	if d == nil || i < 0 || i >= len(d.entries) {
+		c.key = nil
+		return nil, nil
+	}
+	c.key = d.entries[i].key
+	return c.key, d.entries[i].value
+}
+
+// Delete removes the current key/value pair the cursor is at without
+// invalidating the cursor.
+//
+// This function is part of the walletdb.ReadWriteCursor interface
+// implementation.
+func (c *cursor) Delete() error {
+	if c.key == nil {
+		return nil
+	}
+	return c.bucket.Delete(c.key)
+}
+
+// First positions the cursor at the first key/value pair and returns the pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) First() (key, value []byte) {
+	return c.moveTo(c.bucket.data(), 0)
+}
+
+// Last positions the cursor at the last key/value pair and returns the pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Last() (key, value []byte) {
+	d := c.bucket.data()
+	if d == nil {
+		return nil, nil
+	}
+	return c.moveTo(d, len(d.entries)-1)
+}
+
+// Next moves the cursor one key/value pair forward and returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Next() (key, value []byte) {
+	d := c.bucket.data()
+	if d == nil || c.key == nil {
+		return nil, nil
+	}
+	i, ok := d.search(c.key)
+	if ok {
+		i++
+	}
+	return c.moveTo(d, i)
+}
+
+// Prev moves the cursor one key/value pair backward and returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Prev() (key, value []byte) {
+	d := c.bucket.data()
+	if d == nil || c.key == nil {
+		return nil, nil
+	}
+	i, _ := d.search(c.key)
+	return c.moveTo(d, i-1)
+}
+
+// Seek positions the cursor at the passed seek key.  If the key does not exist,
+// the cursor is moved to the next key after seek.  Returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Seek(seek []byte) (key, value []byte) {
+	d := c.bucket.data()
+	if d == nil {
+		return nil, nil
+	}
+	i, _ := d.search(seek)
+	return c.moveTo(d, i)
+}
+
+// db represents a collection of namespaces held in memory and implements the
+// walletdb.DB interface.  All data is lost when the database is closed.
+type db struct {
+	// writeMtx is held by the open read-write transaction, if any.
+	writeMtx sync.Mutex
+
+	// mu protects root and closed.
+	mu     sync.RWMutex
+	root   *bucketData
+	closed bool
+}
+
+// Enforce db implements the walletdb.DB interface.
+var _ walletdb.DB = (*db)(nil)
+
+// newDB returns a new empty database.
+func newDB() *db {
+	return &db{root: &bucketData{}}
+}
+
+func (db *db) beginTx(writable bool) (*transaction, error) {
+	if writable {
+		db.writeMtx.Lock()
+	}
+
+	db.mu.RLock()
+	root, closed := db.root, db.closed
+	db.mu.RUnlock()
+	if closed {
+		if writable {
+			db.writeMtx.Unlock()
+		}
+		return nil, walletdb.ErrDbNotOpen
+	}
+
+	return &transaction{db: db, root: root, writable: writable}, nil
+}
+
+func (db *db) BeginReadTx() (walletdb.ReadTx, error) {
+	return db.beginTx(false)
+}
+
+func (db *db) BeginReadWriteTx() (walletdb.ReadWriteTx, error) {
+	return db.beginTx(true)
+}
+
+// Copy is unsupported by in-memory databases, as the copy could only be
+// opened again by writing it to disk.
+//
+// This function is part of the walletdb.Db interface implementation.
+func (db *db) Copy(w io.Writer) error {
+	return errCopyUnsupported
+}
+
+// Close releases the data of the database.
+//
+// This function is part of the walletdb.Db interface implementation.
+func (db *db) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return walletdb.ErrDbNotOpen
+	}
+	db.closed = true
+	db.root = nil
+	return nil
+}
+
+// View opens a database read transaction and executes the function f with the
+// transaction passed as a parameter. After f exits, the transaction is rolled
+// back. If f errors, its error is returned, not a rollback error (if any
+// occur). The passed reset function is called before the start of the
+// transaction and can be used to reset intermediate state.
+func (db *db) View(f func(tx walletdb.ReadTx) error, reset func()) error {
+	// Transactions are never retried, so the reset function is only
+	// called once.
+	reset()
+
+	tx, err := db.beginTx(false)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the transaction rolls back in the event of a panic.
+	defer func() {
+		if !tx.closed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = f(tx)
+	rollbackErr := tx.Rollback()
+	if err != nil {
+		return err
+	}
+
+	return rollbackErr
+}
+
+// Update opens a database read/write transaction and executes the function f
+// with the transaction passed as a parameter. After f exits, if f did not
+// error, the transaction is committed. Otherwise, if f did error, the
+// transaction is rolled back. If the rollback fails, the original error
+// returned by f is still returned. If the commit fails, the commit error is
+// returned.
+func (db *db) Update(f func(tx walletdb.ReadWriteTx) error, reset func()) error {
+	// Transactions are never retried, so the reset function is only
+	// called once.
+	reset()
+
+	tx, err := db.beginTx(true)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the transaction rolls back in the event of a panic.
+	defer func() {
+		if !tx.closed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = f(tx)
+	if err != nil {
+		// Want to return the original error, not a rollback error if
+		// any occur.
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PrintStats returns all collected stats pretty printed into a string.
+func (db *db) PrintStats() string {
+	return "<no stats are collected by memdb backend>"
+}
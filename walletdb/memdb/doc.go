@@ -0,0 +1,29 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package memdb implements an instance of walletdb that holds all data in memory.
+
+Nothing is ever written to disk, and all data is lost when the database is
+closed or the process exits.  This suits throwaway wallets used for testing,
+and wallets which must not be stored on untrusted storage.
+
+Read transactions observe a snapshot of the database taken when they begin,
+and are not blocked by a concurrent read-write transaction.  Only one
+read-write transaction may be open at a time.  Keys are compared as byte
+strings, giving the same iteration order as the bdb driver.
+
+Usage
+
+This package is only a driver to the walletdb package and provides the database
+type of "memdb".  The Create function takes no parameters and returns a new
+empty database.  There is never an existing database to open, so Open always
+returns walletdb.ErrDbDoesNotExist:
+
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		// Handle error
+	}
+*/
+package memdb
@@ -0,0 +1,46 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package memdb
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+const (
+	dbType = "memdb"
+)
+
+// openDBDriver is the callback provided during driver registration.  In-memory
+// databases do not outlive the process which created them, so there is never
+// an existing database to open.
+func openDBDriver(args ...interface{}) (walletdb.DB, error) {
+	return nil, walletdb.ErrDbDoesNotExist
+}
+
+// createDBDriver is the callback provided during driver registration that
+// creates and opens an empty database.
+func createDBDriver(args ...interface{}) (walletdb.DB, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("invalid arguments to %s.Create -- "+
+			"expected no arguments", dbType)
+	}
+
+	return newDB(), nil
+}
+
+func init() {
+	// Register the driver.
+	driver := walletdb.Driver{
+		DbType: dbType,
+		Create: createDBDriver,
+		Open:   openDBDriver,
+	}
+	if err := walletdb.RegisterDriver(driver); err != nil {
+		panic(fmt.Sprintf("Failed to register database driver '%s': %v",
+			dbType, err))
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package memdb_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+)
+
+// dbType is the database type name for this driver.
+const dbType = "memdb"
+
+// TestCreateOpenFail ensures that errors related to creating and opening a
+// database are handled properly.
+func TestCreateOpenFail(t *testing.T) {
+	// In-memory databases can never be opened again.
+	wantErr := walletdb.ErrDbDoesNotExist
+	if _, err := walletdb.Open(dbType); err != wantErr {
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to create a database with parameters returns
+	// the expected error.
+	wantErr = fmt.Errorf("invalid arguments to %s.Create -- expected "+
+		"no arguments", dbType)
+	if _, err := walletdb.Create(dbType, "db"); err == nil ||
+		err.Error() != wantErr.Error() {
+
+		t.Errorf("Create: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure operations on a closed database return the expected error.
+	db, err := walletdb.Create(dbType)
+	if err != nil {
+		t.Fatalf("Create: unexpected error: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+	wantErr = walletdb.ErrDbNotOpen
+	if _, err := db.BeginReadTx(); err != wantErr {
+		t.Errorf("BeginReadTx: did not receive expected error - got "+
+			"%v, want %v", err, wantErr)
+	}
+	if err := db.Close(); err != wantErr {
+		t.Errorf("Close: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+	}
+}
+
+// TestSnapshotIsolation ensures that a read transaction observes the database
+// as it was when the transaction began, while a read-write transaction commits
+// changes to the same buckets.
+func TestSnapshotIsolation(t *testing.T) {
+	db, err := walletdb.Create(dbType)
+	if err != nil {
+		t.Fatalf("Create: unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	bucketKey := []byte("bucket")
+	nestedKey := []byte("nested")
+	key := []byte("key")
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		b, err := tx.CreateTopLevelBucket(bucketKey)
+		if err != nil {
+			return err
+		}
+		nested, err := b.CreateBucket(nestedKey)
+		if err != nil {
+			return err
+		}
+		return nested.Put(key, []byte("old"))
+	})
+	if err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+
+	readTx, err := db.BeginReadTx()
+	if err != nil {
+		t.Fatalf("BeginReadTx: unexpected error: %v", err)
+	}
+	defer readTx.Rollback()
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		nested := tx.ReadWriteBucket(bucketKey).
+			NestedReadWriteBucket(nestedKey)
+		if err := nested.Put(key, []byte("new")); err != nil {
+			return err
+		}
+		return nested.Put([]byte("other"), []byte("value"))
+	})
+	if err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+
+	nested := readTx.ReadBucket(bucketKey).NestedReadBucket(nestedKey)
+	if v := nested.Get(key); !bytes.Equal(v, []byte("old")) {
+		t.Fatalf("read transaction observed value %q, want %q", v,
+			"old")
+	}
+	if v := nested.Get([]byte("other")); v != nil {
+		t.Fatalf("read transaction observed uncommitted key with "+
+			"value %q", v)
+	}
+
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		nested := tx.ReadBucket(bucketKey).NestedReadBucket(nestedKey)
+		if v := nested.Get(key); !bytes.Equal(v, []byte("new")) {
+			return fmt.Errorf("observed value %q, want %q", v,
+				"new")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+}
+
+// TestCursorDelete ensures that deleting keys through a cursor while iterating
+// visits every key of the bucket.
+func TestCursorDelete(t *testing.T) {
+	db, err := walletdb.Create(dbType)
+	if err != nil {
+		t.Fatalf("Create: unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		b, err := tx.CreateTopLevelBucket([]byte("bucket"))
+		if err != nil {
+			return err
+		}
+		for i := byte(0); i < 10; i++ {
+			if err := b.Put([]byte{i}, []byte{i}); err != nil {
+				return err
+			}
+		}
+
+		var visited int
+		c := b.ReadWriteCursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			visited++
+			if k[0]%2 == 0 {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+		if visited != 10 {
+			return fmt.Errorf("visited %d keys, want 10", visited)
+		}
+
+		var remaining int
+		err = b.ForEach(func(k, v []byte) error {
+			if k[0]%2 == 0 {
+				return fmt.Errorf("deleted key %x remains", k)
+			}
+			remaining++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if remaining != 5 {
+			return fmt.Errorf("%d keys remain, want 5", remaining)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+}
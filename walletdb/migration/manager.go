@@ -101,6 +101,30 @@ func Upgrade(mgrs ...Manager) error {
 	return nil
 }
 
+// NeedsUpgrade determines whether any of the given services has migrations
+// pending, which callers may use to back up the database before calling
+// Upgrade. ErrReversion is returned if a service is at a version newer than
+// the latest one known.
+func NeedsUpgrade(mgrs ...Manager) (bool, error) {
+	var needsUpgrade bool
+	for _, mgr := range mgrs {
+		currentVersion, err := mgr.CurrentVersion(mgr.Namespace())
+		if err != nil {
+			return false, err
+		}
+		latestVersion := GetLatestVersion(mgr.Versions())
+
+		switch {
+		case currentVersion > latestVersion:
+			return false, ErrReversion
+		case currentVersion < latestVersion:
+			needsUpgrade = true
+		}
+	}
+
+	return needsUpgrade, nil
+}
+
 // upgrade attempts to upgrade a service expose through its implementation of
 // the Manager interface. This function will determine whether any new versions
 // need to be applied based on the service's current version and latest
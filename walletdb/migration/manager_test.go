@@ -341,3 +341,50 @@ func TestUpgradeMultipleVersions(t *testing.T) {
 			latestVersion)
 	}
 }
+
+// TestNeedsUpgrade ensures that pending migrations are detected across all of
+// the given services.
+func TestNeedsUpgrade(t *testing.T) {
+	t.Parallel()
+
+	versions := []migration.Version{
+		{
+			Number:    0,
+			Migration: nil,
+		},
+		{
+			Number:    1,
+			Migration: nil,
+		},
+	}
+
+	latest := &mockMigrationManager{currentVersion: 1, versions: versions}
+	behind := &mockMigrationManager{currentVersion: 0, versions: versions}
+	ahead := &mockMigrationManager{currentVersion: 2, versions: versions}
+
+	needsUpgrade, err := migration.NeedsUpgrade(latest)
+	if err != nil {
+		t.Fatalf("unable to determine pending upgrades: %v", err)
+	}
+	if needsUpgrade {
+		t.Fatal("expected no upgrade for the latest version")
+	}
+
+	needsUpgrade, err = migration.NeedsUpgrade(latest, behind)
+	if err != nil {
+		t.Fatalf("unable to determine pending upgrades: %v", err)
+	}
+	if !needsUpgrade {
+		t.Fatal("expected upgrade for a previous version")
+	}
+
+	// Checking for upgrades must not apply them.
+	if behind.currentVersion != 0 {
+		t.Fatalf("expected version 0, got %d", behind.currentVersion)
+	}
+
+	_, err = migration.NeedsUpgrade(behind, ahead)
+	if err != migration.ErrReversion {
+		t.Fatalf("expected ErrReversion, got %v", err)
+	}
+}
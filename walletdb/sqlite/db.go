@@ -0,0 +1,836 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+
+	// Register the database/sql driver used by this package.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// rootBucketID is the id of the bucket holding all top level buckets.
+const rootBucketID = 0
+
+// schema creates the tables of the database.  Every bucket, including the root
+// bucket holding the top level buckets, has a row in the buckets table
+// recording its sequence.  The key/value pairs of a bucket are rows of the kv
+// table, where nested buckets are stored as a row with a NULL value and the id
+// of the nested bucket as the child.
+const schema = `
+CREATE TABLE IF NOT EXISTS buckets (
+	id       INTEGER PRIMARY KEY,
+	sequence INTEGER NOT NULL DEFAULT 0
+);
+INSERT OR IGNORE INTO buckets (id) VALUES (0);
+CREATE TABLE IF NOT EXISTS kv (
+	bucket INTEGER NOT NULL,
+	key    BLOB NOT NULL,
+	value  BLOB,
+	child  INTEGER,
+	PRIMARY KEY (bucket, key)
+) WITHOUT ROWID;
+`
+
+// subBucketsQuery selects the id of a bucket and of all buckets nested within
+// it.
+const subBucketsQuery = `
+WITH RECURSIVE sub(id) AS (
+	SELECT ?
+	UNION ALL
+	SELECT kv.child FROM kv JOIN sub ON kv.bucket = sub.id
+	WHERE kv.child IS NOT NULL
+)
+SELECT id FROM sub`
+
+// convertErr converts some database/sql errors to the equivalent walletdb
+// error.
+func convertErr(err error) error {
+	switch err {
+	case sql.ErrConnDone:
+		return walletdb.ErrDbNotOpen
+	case sql.ErrTxDone:
+		return walletdb.ErrTxClosed
+	}
+
+	// Return the original error if none of the above applies.
+	return err
+}
+
+// transaction represents a database transaction.  It can either by read-only or
+// read-write and implements the walletdb Tx interfaces.
+//
+// Reads through the walletdb interfaces are unable to return errors, so the
+// first error of a read is recorded and returned when the transaction is
+// committed instead.
+type transaction struct {
+	sqlTx    *sql.Tx
+	writable bool
+	closed   bool
+	err      error
+	onCommit []func()
+}
+
+// Enforce transaction implements the walletdb Tx interfaces.
+var _ walletdb.ReadWriteTx = (*transaction)(nil)
+
+// setErr records the first error of a read made by the transaction.
+func (tx *transaction) setErr(err error) {
+	if tx.err == nil {
+		tx.err = convertErr(err)
+	}
+}
+
+// root returns the bucket holding all top level buckets.
+func (tx *transaction) root() *bucket {
+	return &bucket{tx: tx, id: rootBucketID}
+}
+
+func (tx *transaction) ReadBucket(key []byte) walletdb.ReadBucket {
+	return tx.ReadWriteBucket(key)
+}
+
+// ForEachBucket will iterate through all top level buckets.
+func (tx *transaction) ForEachBucket(fn func(key []byte) error) error {
+	return tx.root().ForEach(func(k, v []byte) error {
+		if v != nil {
+			return nil
+		}
+		return fn(k)
+	})
+}
+
+func (tx *transaction) ReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	return tx.root().NestedReadWriteBucket(key)
+}
+
+func (tx *transaction) CreateTopLevelBucket(key []byte) (walletdb.ReadWriteBucket, error) {
+	return tx.root().CreateBucketIfNotExists(key)
+}
+
+func (tx *transaction) DeleteTopLevelBucket(key []byte) error {
+	return tx.root().DeleteNestedBucket(key)
+}
+
+// Commit commits all changes that have been made through the root bucket and
+// all of its sub-buckets to persistent storage.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) Commit() error {
+	if tx.closed {
+		return walletdb.ErrTxClosed
+	}
+	if !tx.writable {
+		return walletdb.ErrTxNotWritable
+	}
+	if tx.err != nil {
+		_ = tx.Rollback()
+		return tx.err
+	}
+
+	tx.closed = true
+	if err := tx.sqlTx.Commit(); err != nil {
+		return convertErr(err)
+	}
+	for _, f := range tx.onCommit {
+		f()
+	}
+	return nil
+}
+
+// Rollback undoes all changes that have been made to the root bucket and all of
+// its sub-buckets.
+//
+// This function is part of the walletdb.ReadTx interface implementation.
+func (tx *transaction) Rollback() error {
+	if tx.closed {
+		return walletdb.ErrTxClosed
+	}
+
+	tx.closed = true
+	return convertErr(tx.sqlTx.Rollback())
+}
+
+// OnCommit takes a function closure that will be executed when the transaction
+// successfully gets committed.
+//
+// This function is part of the walletdb.ReadWriteTx interface implementation.
+func (tx *transaction) OnCommit(f func()) {
+	tx.onCommit = append(tx.onCommit, f)
+}
+
+// checkWritable returns ErrTxNotWritable or ErrTxClosed if the transaction
+// may not be written to.
+func (tx *transaction) checkWritable() error {
+	if tx.closed {
+		return walletdb.ErrTxClosed
+	}
+	if !tx.writable {
+		return walletdb.ErrTxNotWritable
+	}
+	return nil
+}
+
+// entry looks up the key in the bucket with the passed id.  It returns whether
+// the key exists, and either its value or the id of the nested bucket stored
+// under it.
+func (tx *transaction) entry(bucketID int64, key []byte) (bool, []byte,
+	sql.NullInt64, error) {
+
+	var (
+		value []byte
+		child sql.NullInt64
+	)
+	err := tx.sqlTx.QueryRow(
+		"SELECT value, child FROM kv WHERE bucket = ? AND key = ?",
+		bucketID, key,
+	).Scan(&value, &child)
+	switch {
+	case err == sql.ErrNoRows:
+		return false, nil, child, nil
+	case err != nil:
+		return false, nil, child, convertErr(err)
+	}
+
+	if !child.Valid && value == nil {
+		value = []byte{}
+	}
+	return true, value, child, nil
+}
+
+// bucket is an internal type used to represent a collection of key/value pairs
+// and implements the walletdb Bucket interfaces.
+type bucket struct {
+	tx *transaction
+	id int64
+}
+
+// Enforce bucket implements the walletdb Bucket interfaces.
+var _ walletdb.ReadWriteBucket = (*bucket)(nil)
+
+// NestedReadWriteBucket retrieves a nested bucket with the given key.  Returns
+// nil if the bucket does not exist.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) NestedReadWriteBucket(key []byte) walletdb.ReadWriteBucket {
+	exists, _, child, err := b.tx.entry(b.id, key)
+	if err != nil {
+		b.tx.setErr(err)
+		return nil
+	}
+	if !exists || !child.Valid {
+		return nil
+	}
+	return &bucket{tx: b.tx, id: child.Int64}
+}
+
+func (b *bucket) NestedReadBucket(key []byte) walletdb.ReadBucket {
+	return b.NestedReadWriteBucket(key)
+}
+
+// CreateBucket creates and returns a new nested bucket with the given key.
+// Returns ErrBucketExists if the bucket already exists, ErrBucketNameRequired
+// if the key is empty, or ErrIncompatibleValue if the key value is otherwise
+// invalid.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) CreateBucket(key []byte) (walletdb.ReadWriteBucket, error) {
+	if err := b.tx.checkWritable(); err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, walletdb.ErrBucketNameRequired
+	}
+
+	exists, _, child, err := b.tx.entry(b.id, key)
+	switch {
+	case err != nil:
+		return nil, err
+	case exists && child.Valid:
+		return nil, walletdb.ErrBucketExists
+	case exists:
+		return nil, walletdb.ErrIncompatibleValue
+	}
+
+	res, err := b.tx.sqlTx.Exec("INSERT INTO buckets DEFAULT VALUES")
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	_, err = b.tx.sqlTx.Exec(
+		"INSERT INTO kv (bucket, key, child) VALUES (?, ?, ?)",
+		b.id, key, id,
+	)
+	if err != nil {
+		return nil, convertErr(err)
+	}
+
+	return &bucket{tx: b.tx, id: id}, nil
+}
+
+// CreateBucketIfNotExists creates and returns a new nested bucket with the
+// given key if it does not already exist.  Returns ErrBucketNameRequired if the
+// key is empty or ErrIncompatibleValue if the key value is otherwise invalid.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) CreateBucketIfNotExists(key []byte) (walletdb.ReadWriteBucket, error) {
+	nested, err := b.CreateBucket(key)
+	if err == walletdb.ErrBucketExists {
+		return b.NestedReadWriteBucket(key), nil
+	}
+	return nested, err
+}
+
+// DeleteNestedBucket removes a nested bucket with the given key.  Returns
+// ErrTxNotWritable if attempted against a read-only transaction and
+// ErrBucketNotFound if the specified bucket does not exist.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) DeleteNestedBucket(key []byte) error {
+	if err := b.tx.checkWritable(); err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return walletdb.ErrIncompatibleValue
+	}
+
+	exists, _, child, err := b.tx.entry(b.id, key)
+	switch {
+	case err != nil:
+		return err
+	case !exists:
+		return walletdb.ErrBucketNotFound
+	case !child.Valid:
+		return walletdb.ErrIncompatibleValue
+	}
+
+	// Remove the nested bucket along with every bucket nested within it.
+	// The buckets are removed first as their ids are found through the
+	// key/value pairs.
+	_, err = b.tx.sqlTx.Exec(
+		"DELETE FROM buckets WHERE id IN ("+subBucketsQuery+")",
+		child.Int64,
+	)
+	if err != nil {
+		return convertErr(err)
+	}
+	_, err = b.tx.sqlTx.Exec(
+		"DELETE FROM kv WHERE bucket IN ("+subBucketsQuery+")",
+		child.Int64,
+	)
+	if err != nil {
+		return convertErr(err)
+	}
+	_, err = b.tx.sqlTx.Exec(
+		"DELETE FROM kv WHERE bucket = ? AND key = ?", b.id, key,
+	)
+	return convertErr(err)
+}
+
+// ForEach invokes the passed function with every key/value pair in the bucket.
+// This includes nested buckets, in which case the value is nil, but it does not
+// include the key/value pairs within those nested buckets.
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) ForEach(fn func(k, v []byte) error) error {
+	if b.tx.closed {
+		return walletdb.ErrTxClosed
+	}
+
+	rows, err := b.tx.sqlTx.Query(
+		"SELECT key, value, child FROM kv WHERE bucket = ? "+
+			"ORDER BY key", b.id,
+	)
+	if err != nil {
+		return convertErr(err)
+	}
+
+	// Read all pairs before invoking fn so that the callback is free to
+	// make further queries with the transaction.
+	var keys, values [][]byte
+	for rows.Next() {
+		var (
+			key, value []byte
+			child      sql.NullInt64
+		)
+		if err := rows.Scan(&key, &value, &child); err != nil {
+			rows.Close()
+			return convertErr(err)
+		}
+		if !child.Valid && value == nil {
+			value = []byte{}
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return convertErr(err)
+	}
+	if err := rows.Close(); err != nil {
+		return convertErr(err)
+	}
+
+	for i := range keys {
+		if err := fn(keys[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Put saves the specified key/value pair to the bucket.  Keys that do not
+// already exist are added and keys that already exist are overwritten.  Returns
+// ErrTxNotWritable if attempted against a read-only transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) Put(key, value []byte) error {
+	if err := b.tx.checkWritable(); err != nil {
+		return err
+	}
+	if len(key) == 0 {
+		return walletdb.ErrKeyRequired
+	}
+
+	exists, _, child, err := b.tx.entry(b.id, key)
+	switch {
+	case err != nil:
+		return err
+	case exists && child.Valid:
+		return walletdb.ErrIncompatibleValue
+	}
+
+	if value == nil {
+		value = []byte{}
+	}
+	_, err = b.tx.sqlTx.Exec(
+		"INSERT INTO kv (bucket, key, value) VALUES (?, ?, ?) "+
+			"ON CONFLICT (bucket, key) DO UPDATE SET "+
+			"value = excluded.value",
+		b.id, key, value,
+	)
+	return convertErr(err)
+}
+
+// Get returns the value for the given key.  Returns nil if the key does
+// not exist in this bucket (or nested buckets).
+//
+// This function is part of the walletdb.ReadBucket interface implementation.
+func (b *bucket) Get(key []byte) []byte {
+	exists, value, _, err := b.tx.entry(b.id, key)
+	if err != nil {
+		b.tx.setErr(err)
+		return nil
+	}
+	if !exists {
+		return nil
+	}
+	return value
+}
+
+// Delete removes the specified key from the bucket.  Deleting a key that does
+// not exist does not return an error.  Returns ErrTxNotWritable if attempted
+// against a read-only transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) Delete(key []byte) error {
+	if err := b.tx.checkWritable(); err != nil {
+		return err
+	}
+
+	exists, _, child, err := b.tx.entry(b.id, key)
+	switch {
+	case err != nil:
+		return err
+	case !exists:
+		return nil
+	case child.Valid:
+		return walletdb.ErrIncompatibleValue
+	}
+
+	_, err = b.tx.sqlTx.Exec(
+		"DELETE FROM kv WHERE bucket = ? AND key = ?", b.id, key,
+	)
+	return convertErr(err)
+}
+
+func (b *bucket) ReadCursor() walletdb.ReadCursor {
+	return b.ReadWriteCursor()
+}
+
+// ReadWriteCursor returns a new cursor, allowing for iteration over the bucket's
+// key/value pairs and nested buckets in forward or backward order.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) ReadWriteCursor() walletdb.ReadWriteCursor {
+	return &cursor{bucket: b}
+}
+
+// Tx returns the bucket's transaction.
+//
+// This function is part of the walletdb.ReadWriteBucket interface implementation.
+func (b *bucket) Tx() walletdb.ReadWriteTx {
+	return b.tx
+}
+
+// NextSequence returns an autoincrementing integer for the bucket.
+func (b *bucket) NextSequence() (uint64, error) {
+	if err := b.tx.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	_, err := b.tx.sqlTx.Exec(
+		"UPDATE buckets SET sequence = sequence + 1 WHERE id = ?", b.id,
+	)
+	if err != nil {
+		return 0, convertErr(err)
+	}
+	return b.sequence()
+}
+
+// SetSequence updates the sequence number for the bucket.
+func (b *bucket) SetSequence(v uint64) error {
+	if err := b.tx.checkWritable(); err != nil {
+		return err
+	}
+
+	_, err := b.tx.sqlTx.Exec(
+		"UPDATE buckets SET sequence = ? WHERE id = ?", int64(v), b.id,
+	)
+	return convertErr(err)
+}
+
+// Sequence returns the current integer for the bucket without incrementing it.
+func (b *bucket) Sequence() uint64 {
+	seq, err := b.sequence()
+	if err != nil {
+		b.tx.setErr(err)
+	}
+	return seq
+}
+
+// sequence reads the sequence of the bucket.
+func (b *bucket) sequence() (uint64, error) {
+	var seq int64
+	err := b.tx.sqlTx.QueryRow(
+		"SELECT sequence FROM buckets WHERE id = ?", b.id,
+	).Scan(&seq)
+	if err != nil {
+		return 0, convertErr(err)
+	}
+	return uint64(seq), nil
+}
+
+// cursor represents a cursor over key/value pairs and nested buckets of a
+// bucket.  Each move of the cursor queries the pair following or preceding
+// the key the cursor is positioned at, so the cursor remains valid across
+// modifications of the bucket.
+type cursor struct {
+	bucket *bucket
+	key    []byte
+}
+
+// Enforce cursor implements the walletdb cursor interfaces.
+var _ walletdb.ReadWriteCursor = (*cursor)(nil)
+
+// move positions the cursor at the first pair selected by the query, which
+// is passed the id of the bucket followed by args, and returns the pair.
+func (c *cursor) move(query string, args ...interface{}) (key, value []byte) {
+	var child sql.NullInt64
+	args = append([]interface{}{c.bucket.id}, args...)
+	err := c.bucket.tx.sqlTx.QueryRow(
+		"SELECT key, value, child FROM kv WHERE bucket = ? "+query,
+		args...,
+	).Scan(&key, &value, &child)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			c.bucket.tx.setErr(err)
+		}
+		c.key = nil
+		return nil, nil
+	}
+
+	if !child.Valid && value == nil {
+		value = []byte{}
+	}
+	c.key = key
+	return key, value
+}
+
+// Delete removes the current key/value pair the cursor is at without
+// invalidating the cursor. Returns ErrTxNotWritable if attempted on a read-only
+// transaction, or ErrIncompatibleValue if attempted when the cursor points to a
+// nested bucket.
+//
+// This function is part of the walletdb.ReadWriteCursor interface implementation.
+func (c *cursor) Delete() error {
+	if c.key == nil {
+		return nil
+	}
+	return c.bucket.Delete(c.key)
+}
+
+// First positions the cursor at the first key/value pair and returns the pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) First() (key, value []byte) {
+	return c.move("ORDER BY key LIMIT 1")
+}
+
+// Last positions the cursor at the last key/value pair and returns the pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Last() (key, value []byte) {
+	return c.move("ORDER BY key DESC LIMIT 1")
+}
+
+// Next moves the cursor one key/value pair forward and returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Next() (key, value []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+	return c.move("AND key > ? ORDER BY key LIMIT 1", c.key)
+}
+
+// Prev moves the cursor one key/value pair backward and returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Prev() (key, value []byte) {
+	if c.key == nil {
+		return nil, nil
+	}
+	return c.move("AND key < ? ORDER BY key DESC LIMIT 1", c.key)
+}
+
+// Seek positions the cursor at the passed seek key. If the key does not exist,
+// the cursor is moved to the next key after seek. Returns the new pair.
+//
+// This function is part of the walletdb.ReadCursor interface implementation.
+func (c *cursor) Seek(seek []byte) (key, value []byte) {
+	if seek == nil {
+		seek = []byte{}
+	}
+	return c.move("AND key >= ? ORDER BY key LIMIT 1", seek)
+}
+
+// db represents a collection of namespaces which are persisted and implements
+// the walletdb.Db interface.
+//
+// Read-write transactions are made on a single connection which takes the
+// write lock of the database when the transaction begins, so that writers are
+// serialized as with the bdb driver.  Read-only transactions are made on a
+// separate pool of connections and, as the database uses write-ahead logging,
+// never wait on a writer.
+type db struct {
+	writeDB *sql.DB
+	readDB  *sql.DB
+}
+
+// Enforce db implements the walletdb.Db interface.
+var _ walletdb.DB = (*db)(nil)
+
+func (db *db) beginTx(writable bool) (*transaction, error) {
+	sqlDB := db.readDB
+	if writable {
+		sqlDB = db.writeDB
+	}
+	sqlTx, err := sqlDB.Begin()
+	if err != nil {
+		return nil, convertErr(err)
+	}
+	return &transaction{sqlTx: sqlTx, writable: writable}, nil
+}
+
+func (db *db) BeginReadTx() (walletdb.ReadTx, error) {
+	return db.beginTx(false)
+}
+
+func (db *db) BeginReadWriteTx() (walletdb.ReadWriteTx, error) {
+	return db.beginTx(true)
+}
+
+// Copy writes a copy of the database to the provided writer.  This call will
+// start a read-only transaction to perform all operations.
+//
+// This function is part of the walletdb.Db interface implementation.
+func (db *db) Copy(w io.Writer) error {
+	tempDir, err := ioutil.TempDir("", "walletdb-sqlite")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	copyPath := filepath.Join(tempDir, "copy.db")
+	if _, err := db.readDB.Exec("VACUUM INTO ?", copyPath); err != nil {
+		return convertErr(err)
+	}
+
+	f, err := os.Open(copyPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Close cleanly shuts down the database and syncs all data.
+//
+// This function is part of the walletdb.Db interface implementation.
+func (db *db) Close() error {
+	readErr := db.readDB.Close()
+	if err := db.writeDB.Close(); err != nil {
+		return convertErr(err)
+	}
+	return convertErr(readErr)
+}
+
+// View opens a database read transaction and executes the function f with the
+// transaction passed as a parameter. After f exits, the transaction is rolled
+// back. If f errors, its error is returned, not a rollback error (if any
+// occur). The passed reset function is called before the start of the
+// transaction and can be used to reset intermediate state.
+func (db *db) View(f func(tx walletdb.ReadTx) error, reset func()) error {
+	// We don't do any retries with sqlite so we just initially call the
+	// reset function once.
+	reset()
+
+	tx, err := db.beginTx(false)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the transaction rolls back in the event of a panic.
+	defer func() {
+		if !tx.closed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = f(tx)
+	readErr := tx.err
+	rollbackErr := tx.Rollback()
+	if err != nil {
+		return err
+	}
+	if readErr != nil {
+		return readErr
+	}
+
+	return rollbackErr
+}
+
+// Update opens a database read/write transaction and executes the function f
+// with the transaction passed as a parameter. After f exits, if f did not
+// error, the transaction is committed. Otherwise, if f did error, the
+// transaction is rolled back. If the rollback fails, the original error
+// returned by f is still returned. If the commit fails, the commit error is
+// returned.
+func (db *db) Update(f func(tx walletdb.ReadWriteTx) error, reset func()) error {
+	// We don't do any retries with sqlite so we just initially call the
+	// reset function once.
+	reset()
+
+	tx, err := db.beginTx(true)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the transaction rolls back in the event of a panic.
+	defer func() {
+		if !tx.closed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = f(tx)
+	if err != nil {
+		// Want to return the original error, not a rollback error if
+		// any occur.
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PrintStats returns all collected stats pretty printed into a string.
+func (db *db) PrintStats() string {
+	return "<no stats are collected by sqlite backend>"
+}
+
+// fileExists reports whether the named file or directory exists.
+func fileExists(name string) bool {
+	if _, err := os.Stat(name); err != nil {
+		if os.IsNotExist(err) {
+			return false
+		}
+	}
+	return true
+}
+
+// dsn returns the data source name opening the database at dbPath with the
+// given transaction locking mode.
+func dsn(dbPath string, timeout time.Duration, txLock string) string {
+	params := url.Values{}
+	params.Set("_journal_mode", "WAL")
+	params.Set("_synchronous", "FULL")
+	params.Set("_busy_timeout", fmt.Sprint(timeout.Milliseconds()))
+	params.Set("_txlock", txLock)
+	return "file:" + dbPath + "?" + params.Encode()
+}
+
+// openDB opens the database at the provided path.  walletdb.ErrDbDoesNotExist
+// is returned if the database doesn't exist and the create flag is not set.
+func openDB(dbPath string, create bool,
+	timeout time.Duration) (walletdb.DB, error) {
+
+	if !create && !fileExists(dbPath) {
+		return nil, walletdb.ErrDbDoesNotExist
+	}
+
+	// A single connection is used for writes, beginning transactions
+	// immediately so that a transaction never fails to upgrade a read lock
+	// to a write lock.
+	writeDB, err := sql.Open("sqlite3", dsn(dbPath, timeout, "immediate"))
+	if err != nil {
+		return nil, err
+	}
+	writeDB.SetMaxOpenConns(1)
+
+	if _, err := writeDB.Exec(schema); err != nil {
+		writeDB.Close()
+		return nil, err
+	}
+	if create {
+		if err := os.Chmod(dbPath, 0600); err != nil {
+			writeDB.Close()
+			return nil, err
+		}
+	}
+
+	readDB, err := sql.Open("sqlite3", dsn(dbPath, timeout, "deferred"))
+	if err != nil {
+		writeDB.Close()
+		return nil, err
+	}
+
+	return &db{writeDB: writeDB, readDB: readDB}, nil
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package sqlite implements an instance of walletdb that uses SQLite for the
+backing datastore.
+
+Buckets and their key/value pairs are stored in tables of a single SQLite
+database opened in write-ahead logging mode, so the wallet database may be
+inspected with regular SQL tooling.  Keys are compared as byte strings, giving
+the same iteration order as the bdb driver.
+
+The driver requires cgo.  Binaries built without cgo still register the driver,
+but opening or creating a database will fail.
+
+Usage
+
+This package is only a driver to the walletdb package and provides the database
+type of "sqlite".  The only parameters the Open and Create functions take are
+the database path as a string and a timeout value, as a time.Duration, for how
+long to wait for a lock held by another connection to the database:
+
+	db, err := walletdb.Open("sqlite", "path/to/database.db", 60*time.Second)
+	if err != nil {
+		// Handle error
+	}
+
+	db, err := walletdb.Create("sqlite", "path/to/database.db", 60*time.Second)
+	if err != nil {
+		// Handle error
+	}
+*/
+package sqlite
@@ -0,0 +1,196 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sqlite_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcwallet/walletdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/sqlite"
+)
+
+const (
+	// dbType is the database type name for this driver.
+	dbType = "sqlite"
+
+	// defaultDBTimeout is the value of db timeout for testing.
+	defaultDBTimeout = 10 * time.Second
+)
+
+// TestCreateOpenFail ensures that errors related to creating and opening a
+// database are handled properly.
+func TestCreateOpenFail(t *testing.T) {
+	// Ensure that attempting to open a database that doesn't exist returns
+	// the expected error.
+	wantErr := walletdb.ErrDbDoesNotExist
+	if _, err := walletdb.Open(
+		dbType, "noexist.db", defaultDBTimeout,
+	); err != wantErr {
+
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to open a database with the wrong number of
+	// parameters returns the expected error.
+	wantErr = fmt.Errorf("invalid arguments to %s.Open -- expected "+
+		"database path and timeout option", dbType)
+	if _, err := walletdb.Open(
+		dbType, "noexist.db", true, defaultDBTimeout,
+	); err.Error() != wantErr.Error() {
+
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to create a database with an invalid type for
+	// the second parameter returns the expected error.
+	wantErr = fmt.Errorf("second argument to %s.Create is invalid -- "+
+		"expected timeout time.Duration", dbType)
+	if _, err := walletdb.Create(
+		dbType, "noexist.db", 1,
+	); err.Error() != wantErr.Error() {
+
+		t.Errorf("Create: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+}
+
+// TestPersistence ensures that values committed to a database are available
+// after reopening it and in a copy of the database.
+func TestPersistence(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "persistencetest")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "db")
+	db, err := walletdb.Create(dbType, dbPath, defaultDBTimeout)
+	if err != nil {
+		t.Fatalf("Failed to create test database (%s) %v", dbType, err)
+	}
+
+	ns := []byte("ns")
+	nestedKey := []byte("nested")
+	key, value := []byte("key"), []byte("value")
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		bucket, err := tx.CreateTopLevelBucket(ns)
+		if err != nil {
+			return err
+		}
+		nested, err := bucket.CreateBucket(nestedKey)
+		if err != nil {
+			return err
+		}
+		return nested.Put(key, value)
+	})
+	if err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+
+	checkValue := func(db walletdb.DB) {
+		t.Helper()
+
+		err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+			bucket := tx.ReadBucket(ns)
+			if bucket == nil {
+				return fmt.Errorf("missing bucket %s", ns)
+			}
+			nested := bucket.NestedReadBucket(nestedKey)
+			if nested == nil {
+				return fmt.Errorf("missing bucket %s", nestedKey)
+			}
+			if v := nested.Get(key); !bytes.Equal(v, value) {
+				return fmt.Errorf("unexpected value - got %s, "+
+					"want %s", v, value)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("View: %v", err)
+		}
+	}
+
+	// Copy the database before closing it.
+	copyPath := filepath.Join(tempDir, "copy.db")
+	f, err := os.Create(copyPath)
+	if err != nil {
+		t.Fatalf("unable to create copy: %v", err)
+	}
+	if err := db.Copy(f); err != nil {
+		t.Fatalf("Copy: unexpected error: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("unable to close copy: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+
+	for _, path := range []string{dbPath, copyPath} {
+		db, err := walletdb.Open(dbType, path, defaultDBTimeout)
+		if err != nil {
+			t.Fatalf("Open: unexpected error: %v", err)
+		}
+		checkValue(db)
+		if err := db.Close(); err != nil {
+			t.Fatalf("Close: unexpected error: %v", err)
+		}
+	}
+}
+
+// TestReadDuringWrite ensures that a read transaction can be made while a
+// read-write transaction is open, and that it does not observe the
+// uncommitted changes of the writer.
+func TestReadDuringWrite(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "readwritetest")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	db, err := walletdb.Create(
+		dbType, filepath.Join(tempDir, "db"), defaultDBTimeout,
+	)
+	if err != nil {
+		t.Fatalf("Failed to create test database (%s) %v", dbType, err)
+	}
+	defer db.Close()
+
+	ns := []byte("ns")
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		_, err := tx.CreateTopLevelBucket(ns)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		if err := tx.ReadWriteBucket(ns).Put([]byte("k"), nil); err != nil {
+			return err
+		}
+
+		return walletdb.View(db, func(rtx walletdb.ReadTx) error {
+			if v := rtx.ReadBucket(ns).Get([]byte("k")); v != nil {
+				return fmt.Errorf("read uncommitted value %x", v)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+}
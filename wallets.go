@@ -0,0 +1,319 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcwallet/internal/lockfile"
+	"github.com/btcsuite/btcwallet/netparams"
+	"github.com/btcsuite/btcwallet/rpc/legacyrpc"
+	"github.com/btcsuite/btcwallet/wallet"
+)
+
+// errSPVWalletLoading is returned when loading a wallet at runtime in SPV
+// mode, as the neutrino chain service can not be shared between wallets.
+var errSPVWalletLoading = errors.New("wallets can not be loaded at " +
+	"runtime in SPV mode")
+
+// errNetworkBackend is returned when loading a wallet of a network other than
+// the active network with a chain backend other than btcd, as only btcd
+// servers of other networks may be configured.
+var errNetworkBackend = errors.New("wallets of other networks require " +
+	"the btcd chain backend")
+
+// loadedWallet is a wallet loaded at runtime by the walletManager.
+type loadedWallet struct {
+	loader *wallet.Loader
+	net    *netparams.Params
+	dbDir  string
+
+	// quit is closed to end the connect loop of the wallet's chain
+	// client.
+	quit chan struct{}
+}
+
+// netLock is the lock of the network directory of a network other than the
+// active network, held while wallets of the network are loaded.
+type netLock struct {
+	lock    *lockfile.Lock
+	wallets int
+}
+
+// walletManager loads and unloads wallets at runtime, in addition to the
+// wallet opened at startup.  Each wallet is synchronized over its own chain
+// server connection, which is closed when the wallet is unloaded so that the
+// chain server stops tracking the addresses and outputs of the wallet.
+//
+// Wallets may be bound to networks other than the active network, in which
+// case they are synchronized with the btcd server of their network set by the
+// netrpcconnect option.  Networks are bound per wallet, so every account of a
+// wallet belongs to the network of the wallet.  The network directory of
+// another network is locked while wallets of the network are loaded, as the
+// network directory of the active network is for the life of the process.
+//
+// Backups are only scheduled for the wallet opened at startup.
+type walletManager struct {
+	mu       sync.Mutex
+	wallets  map[string]*loadedWallet
+	netLocks map[string]*netLock
+}
+
+// Enforce walletManager implements the legacyrpc.WalletManager interface.
+var _ legacyrpc.WalletManager = (*walletManager)(nil)
+
+// newWalletManager returns a walletManager with no wallets loaded.
+func newWalletManager() *walletManager {
+	return &walletManager{
+		wallets:  make(map[string]*loadedWallet),
+		netLocks: make(map[string]*netLock),
+	}
+}
+
+// walletNet returns the parameters of the network named by network, or the
+// active network when it is empty.
+func walletNet(network string) (*netparams.Params, error) {
+	if network == "" || network == activeNet.Params.Name {
+		return activeNet, nil
+	}
+	net, ok := netparams.ByName(network)
+	if !ok {
+		return nil, legacyrpc.ErrUnknownNetwork
+	}
+	if cfg.UseBitcoind || cfg.UseElectrum {
+		return nil, errNetworkBackend
+	}
+	return net, nil
+}
+
+// walletDBDir returns the directory of the wallet database named by a wallet
+// name within the directory of the wallet's network.  The name must have been
+// checked with legacyrpc.ValidateWalletName.
+func walletDBDir(net *netparams.Params, name string) string {
+	netDir := networkDir(cfg.AppDataDir.Value, net.Params)
+	return filepath.Join(netDir, name)
+}
+
+// LoadWallet opens the existing wallet database in the directory named by
+// name with the public passphrase of the configuration, and begins
+// synchronizing the wallet over a new chain server connection of the network.
+//
+// This function is part of the legacyrpc.WalletManager interface
+// implementation.
+func (m *walletManager) LoadWallet(name, network string) (*wallet.Wallet, error) {
+	return m.openWallet(name, network, func(loader *wallet.Loader, dbDir string) (*wallet.Wallet, error) {
+		exists, err := loader.WalletExists()
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("no wallet database in %v",
+				dbDir)
+		}
+		return loader.OpenExistingWallet([]byte(cfg.WalletPass), false)
+	})
+}
+
+// CreateWallet creates a new wallet database in the directory named by name,
+// protected by the public passphrase of the configuration, and begins
+// synchronizing the wallet over a new chain server connection of the network.
+// The wallet is created with a random seed unless it is watching-only or
+// blank.
+//
+// This function is part of the legacyrpc.WalletManager interface
+// implementation.
+func (m *walletManager) CreateWallet(name, network string,
+	privPassphrase []byte, watchingOnly, blank bool) (*wallet.Wallet, error) {
+
+	return m.openWallet(name, network, func(loader *wallet.Loader, _ string) (*wallet.Wallet, error) {
+		pubPassphrase := []byte(cfg.WalletPass)
+		bday := time.Now()
+		switch {
+		case watchingOnly:
+			return loader.CreateNewWatchingOnlyWallet(
+				pubPassphrase, bday,
+			)
+		case blank:
+			return loader.CreateNewBlankWallet(
+				pubPassphrase, privPassphrase, bday,
+			)
+		default:
+			return loader.CreateNewWallet(
+				pubPassphrase, privPassphrase, nil, bday,
+			)
+		}
+	})
+}
+
+// openWallet loads the wallet named by name of the network with the open
+// function, which is passed the loader and directory of the wallet database,
+// and begins synchronizing it over a new chain server connection.
+func (m *walletManager) openWallet(name, network string,
+	open func(*wallet.Loader, string) (*wallet.Wallet, error)) (*wallet.Wallet, error) {
+
+	if cfg.UseSPV {
+		return nil, errSPVWalletLoading
+	}
+
+	if err := legacyrpc.ValidateWalletName(name); err != nil {
+		return nil, err
+	}
+	net, err := walletNet(network)
+	if err != nil {
+		return nil, err
+	}
+	dbDir := walletDBDir(net, name)
+	if !cfg.MemoryWallet &&
+		dbDir == networkDir(cfg.AppDataDir.Value, activeNet.Params) {
+
+		return nil, fmt.Errorf("wallet %q is the wallet opened at "+
+			"startup", name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for loadedName, lw := range m.wallets {
+		if loadedName == name || lw.dbDir == dbDir {
+			return nil, wallet.ErrLoaded
+		}
+	}
+
+	if err := m.lockNet(net); err != nil {
+		return nil, err
+	}
+	loader := newWalletLoader(net.Params, dbDir)
+	loader.RunAfterLoad(configureWallet)
+	w, err := open(loader, dbDir)
+	if err != nil {
+		m.releaseNet(net)
+		return nil, err
+	}
+
+	lw := &loadedWallet{
+		loader: loader,
+		net:    net,
+		dbDir:  dbDir,
+		quit:   make(chan struct{}),
+	}
+	m.wallets[name] = lw
+	go rpcClientConnectLoop(nil, loader, net, lw.quit)
+
+	log.Infof("Loaded %v wallet %q from %v", net.Params.Name, name, dbDir)
+	return w, nil
+}
+
+// UnloadWallet closes the chain server connection of the wallet loaded with
+// the name, and closes the wallet.
+//
+// This function is part of the legacyrpc.WalletManager interface
+// implementation.
+func (m *walletManager) UnloadWallet(name string) error {
+	m.mu.Lock()
+	lw, ok := m.wallets[name]
+	if !ok {
+		m.mu.Unlock()
+		return wallet.ErrNotLoaded
+	}
+	delete(m.wallets, name)
+	m.mu.Unlock()
+
+	close(lw.quit)
+	err := lw.loader.UnloadWallet()
+
+	// The network directory remains locked until the wallet's database
+	// is closed.
+	m.mu.Lock()
+	m.releaseNet(lw.net)
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Unloaded wallet %q", name)
+	return nil
+}
+
+// Wallet returns the wallet loaded with the name.
+//
+// This function is part of the legacyrpc.WalletManager interface
+// implementation.
+func (m *walletManager) Wallet(name string) (*wallet.Wallet, bool) {
+	m.mu.Lock()
+	lw, ok := m.wallets[name]
+	m.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	return lw.loader.LoadedWallet()
+}
+
+// WalletNames returns the names of the loaded wallets in sorted order.
+//
+// This function is part of the legacyrpc.WalletManager interface
+// implementation.
+func (m *walletManager) WalletNames() []string {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.wallets))
+	for name := range m.wallets {
+		names = append(names, name)
+	}
+	m.mu.Unlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// lockNet acquires the lock of the network directory of a network other than
+// the active network for a wallet being loaded, or counts the wallet when the
+// lock is already held.  Wallets of the active network are covered by the lock
+// acquired at startup.  The manager's mutex must be held.
+func (m *walletManager) lockNet(net *netparams.Params) error {
+	if net == activeNet || cfg.MemoryWallet {
+		return nil
+	}
+	if nl, ok := m.netLocks[net.Params.Name]; ok {
+		nl.wallets++
+		return nil
+	}
+	lock, err := lockDataDir(networkDir(cfg.AppDataDir.Value, net.Params))
+	if err != nil {
+		return err
+	}
+	m.netLocks[net.Params.Name] = &netLock{lock: lock, wallets: 1}
+	return nil
+}
+
+// releaseNet releases the lock of the network directory of a network once no
+// wallets of the network remain loaded.  The manager's mutex must be held.
+func (m *walletManager) releaseNet(net *netparams.Params) {
+	nl, ok := m.netLocks[net.Params.Name]
+	if !ok {
+		return
+	}
+	nl.wallets--
+	if nl.wallets > 0 {
+		return
+	}
+	delete(m.netLocks, net.Params.Name)
+	if err := nl.lock.Release(); err != nil {
+		log.Errorf("Unable to release %v data directory lock: %v",
+			net.Params.Name, err)
+	}
+}
+
+// unloadAll unloads every loaded wallet.
+func (m *walletManager) unloadAll() {
+	for _, name := range m.WalletNames() {
+		if err := m.UnloadWallet(name); err != nil {
+			log.Errorf("Failed to close wallet %q: %v", name, err)
+		}
+	}
+}
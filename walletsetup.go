@@ -6,6 +6,8 @@ package main
 
 import (
 	"bufio"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,12 +16,16 @@ import (
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcwallet/internal/legacy/keystore"
 	"github.com/btcsuite/btcwallet/internal/prompt"
+	"github.com/btcsuite/btcwallet/internal/zero"
 	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/btcsuite/btcwallet/wallet"
 	"github.com/btcsuite/btcwallet/walletdb"
 	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/memdb"
+	_ "github.com/btcsuite/btcwallet/walletdb/sqlite"
 )
 
 // networkDir returns the directory name of a network directory to hold wallet
@@ -103,6 +109,10 @@ func createWallet(cfg *config) error {
 	loader := wallet.NewLoader(
 		activeNet.Params, dbDir, true, cfg.DBTimeout, 250,
 	)
+	loader.SetDBDriver(cfg.DBDriver)
+	if cfg.DBPass != "" {
+		loader.SetDBPassphrase([]byte(cfg.DBPass))
+	}
 
 	// When there is a legacy keystore, open it now to ensure any errors
 	// don't end up exiting the process after the user has spent time
@@ -131,6 +141,7 @@ func createWallet(cfg *config) error {
 	if err != nil {
 		return err
 	}
+	defer zero.Bytes(privPass)
 
 	// When there exists a legacy keystore, unlock it now and set up a
 	// callback to import all keystore keys into the new walletdb
@@ -186,6 +197,7 @@ func createWallet(cfg *config) error {
 	if err != nil {
 		return err
 	}
+	defer zero.Bytes(seed)
 
 	fmt.Println("Creating the wallet...")
 	w, err := loader.CreateNewWallet(pubPass, privPass, seed, time.Now())
@@ -213,8 +225,12 @@ func createSimulationWallet(cfg *config) error {
 	dbPath := filepath.Join(netDir, wallet.WalletDBName)
 	fmt.Println("Creating the wallet...")
 
-	// Create the wallet database backed by bolt db.
-	db, err := walletdb.Create("bdb", dbPath, true, cfg.DBTimeout)
+	// Create the wallet database with the configured driver.
+	dbArgs := []interface{}{dbPath, true, cfg.DBTimeout}
+	if cfg.DBDriver == wallet.SQLiteDBDriver {
+		dbArgs = []interface{}{dbPath, cfg.DBTimeout}
+	}
+	db, err := walletdb.Create(cfg.DBDriver, dbArgs...)
 	if err != nil {
 		return err
 	}
@@ -230,6 +246,77 @@ func createSimulationWallet(cfg *config) error {
 	return nil
 }
 
+// newMemoryWalletLoader returns a loader of a wallet which is held in memory
+// and never written to disk.
+func newMemoryWalletLoader() (*wallet.Loader, error) {
+	db, err := walletdb.Create("memdb")
+	if err != nil {
+		return nil, err
+	}
+
+	// The wallet exists once it has been created in the database, which
+	// creates its top level buckets.
+	walletExists := func() (bool, error) {
+		var exists bool
+		err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+			return tx.ForEachBucket(func([]byte) error {
+				exists = true
+				return nil
+			})
+		})
+		return exists, err
+	}
+
+	return wallet.NewLoaderWithDB(activeNet.Params, 250, db, walletExists)
+}
+
+// createMemoryWallet creates a wallet from a random seed with the loader of an
+// in-memory wallet.  The private passphrase is set by the memorywalletpass
+// option.  When it is unset, a random passphrase is used which is never
+// revealed, so the wallet can watch and receive payments but its private keys
+// can not be unlocked.
+func createMemoryWallet(loader *wallet.Loader, cfg *config) error {
+	seed, err := hdkeychain.GenerateSeed(hdkeychain.RecommendedSeedLen)
+	if err != nil {
+		return err
+	}
+
+	var privPass []byte
+	randomPass := cfg.MemoryWalletPass == ""
+	if randomPass {
+		var b [32]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return err
+		}
+		privPass = make([]byte, hex.EncodedLen(len(b)))
+		hex.Encode(privPass, b[:])
+		zero.Bytea32(&b)
+	} else {
+		privPass = []byte(cfg.MemoryWalletPass)
+	}
+	defer zero.Bytes(privPass)
+
+	_, err = loader.CreateNewWallet(
+		[]byte(cfg.WalletPass), privPass, seed, time.Now(),
+	)
+	zero.Bytes(seed)
+	if err != nil {
+		return err
+	}
+
+	if randomPass {
+		log.Infof("Created in-memory wallet with a random private " +
+			"passphrase, its private keys can not be unlocked " +
+			"(set memorywalletpass to spend from it), it is lost " +
+			"when btcwallet exits")
+	} else {
+		log.Infof("Created in-memory wallet with the private " +
+			"passphrase of memorywalletpass, it is lost when " +
+			"btcwallet exits")
+	}
+	return nil
+}
+
 // checkCreateDir checks that the path exists and is a directory.
 // If path does not exist, it is created.
 func checkCreateDir(path string) error {
@@ -7,6 +7,7 @@ package wtxmgr
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
 	"time"
@@ -63,6 +64,7 @@ var (
 	bucketBlocks         = []byte("b")
 	bucketTxRecords      = []byte("t")
 	bucketTxLabels       = []byte("l")
+	bucketTxComments     = []byte("cm")
 	bucketCredits        = []byte("c")
 	bucketUnspent        = []byte("u")
 	bucketDebits         = []byte("d")
@@ -70,6 +72,7 @@ var (
 	bucketUnminedCredits = []byte("mc")
 	bucketUnminedInputs  = []byte("mi")
 	bucketLockedOutputs  = []byte("lo")
+	bucketScriptCredits  = []byte("sc")
 )
 
 // Root (namespace) bucket keys
@@ -1288,6 +1291,73 @@ func deleteRawUnminedInput(ns walletdb.ReadWriteBucket, outPointKey []byte,
 	return nil
 }
 
+// Credits are indexed by the output script they pay to, so that the credits
+// (and through their spenders, the debits) of a single script can be found
+// without iterating over every transaction.  The index is keyed as such:
+//
+//   [0:32]  SHA256 hash of the output script (32 bytes)
+//   [32:68] Canonical outpoint of the credit (36 bytes)
+//
+// The value is empty.  An entry is added when a credit is first recorded, and
+// is kept while the credit moves between the mined and unmined buckets, since
+// its outpoint does not change.  Entries of credits which no longer exist are
+// skipped when reading the index.
+
+func keyScriptCredit(pkScript []byte, txHash *chainhash.Hash,
+	index uint32) []byte {
+
+	scriptHash := sha256.Sum256(pkScript)
+	k := make([]byte, 68)
+	copy(k, scriptHash[:])
+	copy(k[32:68], canonicalOutPoint(txHash, index))
+	return k
+}
+
+func putScriptCredit(ns walletdb.ReadWriteBucket, pkScript []byte,
+	txHash *chainhash.Hash, index uint32) error {
+
+	k := keyScriptCredit(pkScript, txHash, index)
+	err := ns.NestedReadWriteBucket(bucketScriptCredits).Put(k, nil)
+	if err != nil {
+		str := "failed to put script credit"
+		return storeError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+func deleteScriptCredit(ns walletdb.ReadWriteBucket, pkScript []byte,
+	txHash *chainhash.Hash, index uint32) error {
+
+	k := keyScriptCredit(pkScript, txHash, index)
+	err := ns.NestedReadWriteBucket(bucketScriptCredits).Delete(k)
+	if err != nil {
+		str := "failed to delete script credit"
+		return storeError(ErrDatabase, str, err)
+	}
+	return nil
+}
+
+// forEachScriptCredit calls f with the outpoint of each indexed credit paying
+// to the output script.
+func forEachScriptCredit(ns walletdb.ReadBucket, pkScript []byte,
+	f func(op wire.OutPoint) error) error {
+
+	scriptHash := sha256.Sum256(pkScript)
+	prefix := scriptHash[:]
+	c := ns.NestedReadBucket(bucketScriptCredits).ReadCursor()
+	ck, _ := c.Seek(prefix)
+	for ; bytes.HasPrefix(ck, prefix); ck, _ = c.Next() {
+		var op wire.OutPoint
+		if err := readCanonicalOutPoint(ck[32:], &op); err != nil {
+			return err
+		}
+		if err := f(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // serializeLockedOutput serializes the value of a locked output.
 func serializeLockedOutput(id LockID, expiry time.Time) []byte {
 	var v [len(id) + 8]byte
@@ -1504,6 +1574,10 @@ func createBuckets(ns walletdb.ReadWriteBucket) error {
 		str := "failed to create locked outputs bucket"
 		return storeError(ErrDatabase, str, err)
 	}
+	if _, err := ns.CreateBucket(bucketScriptCredits); err != nil {
+		str := "failed to create script credits bucket"
+		return storeError(ErrDatabase, str, err)
+	}
 
 	return nil
 }
@@ -1548,6 +1622,11 @@ func deleteBuckets(ns walletdb.ReadWriteBucket) error {
 		str := "failed to delete locked outputs bucket"
 		return storeError(ErrDatabase, str, err)
 	}
+	err = ns.DeleteNestedBucket(bucketScriptCredits)
+	if err != nil && err != walletdb.ErrBucketNotFound {
+		str := "failed to delete script credits bucket"
+		return storeError(ErrDatabase, str, err)
+	}
 
 	return nil
 }
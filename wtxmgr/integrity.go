@@ -0,0 +1,494 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wtxmgr
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// Inconsistency describes a record of the transaction store which is corrupt
+// or inconsistent with the other records of the store.
+type Inconsistency struct {
+	// Bucket is the name of the bucket holding the record.
+	Bucket string
+
+	// Key is the key of the record.
+	Key []byte
+
+	// Description describes the inconsistency.
+	Description string
+
+	// Repairable is set when the inconsistency is repaired in place by
+	// RepairIntegrity.  Other inconsistencies mean that transaction
+	// history was lost, and require the history to be rebuilt by
+	// rescanning the chain.
+	Repairable bool
+
+	// repair repairs the inconsistency.  It is nil when the inconsistency
+	// is not repairable.
+	repair func(ns walletdb.ReadWriteBucket) error
+}
+
+// String returns a description of the inconsistency and the record it was
+// found in.
+func (i Inconsistency) String() string {
+	return fmt.Sprintf("%s %x: %s", i.Bucket, i.Key, i.Description)
+}
+
+// integrityCheck checks one kind of record of the store.  Checks are run in
+// order, so that a check sees the repairs of the checks run before it.
+type integrityCheck func(ns walletdb.ReadBucket) ([]Inconsistency, error)
+
+// integrityChecks are the checks run over the store, ordered such that the
+// records an index refers to are checked before the index.
+var integrityChecks = []integrityCheck{
+	checkTxRecords,
+	checkBlockRecords,
+	checkCredits,
+	checkDebits,
+	checkUnspent,
+	checkUnmined,
+	checkUnminedCredits,
+	checkUnminedInputs,
+	checkMinedBalance,
+}
+
+// CheckIntegrity validates every record of the transaction store, verifying
+// transaction records against the checksum of their transaction hash, and
+// cross-checks the unspent output index, the spent credits and debits, and the
+// unmined transaction indexes against the transaction records.  The
+// inconsistencies found are returned, and no records are modified.
+func CheckIntegrity(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var found []Inconsistency
+	for _, check := range integrityChecks {
+		inconsistencies, err := check(ns)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, inconsistencies...)
+	}
+	return found, nil
+}
+
+// RepairIntegrity checks the transaction store as CheckIntegrity does,
+// repairing every repairable inconsistency.  Orphaned index entries are
+// removed, missing unspent index entries are recreated and the mined balance is
+// recalculated.  All inconsistencies found are returned, including the ones
+// which could not be repaired.
+func RepairIntegrity(ns walletdb.ReadWriteBucket) ([]Inconsistency, error) {
+	var found []Inconsistency
+	for _, check := range integrityChecks {
+		inconsistencies, err := check(ns)
+		if err != nil {
+			return nil, err
+		}
+		for i := range inconsistencies {
+			if inconsistencies[i].repair == nil {
+				continue
+			}
+			if err := inconsistencies[i].repair(ns); err != nil {
+				return nil, err
+			}
+		}
+		found = append(found, inconsistencies...)
+	}
+	return found, nil
+}
+
+// copyKey copies a key read during a bucket iteration, which is only valid
+// during the iteration.
+func copyKey(k []byte) []byte {
+	return append([]byte(nil), k...)
+}
+
+// checkTxRecords ensures every mined transaction record can be decoded and
+// matches the checksum of its transaction hash.
+func checkTxRecords(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var found []Inconsistency
+	err := ns.NestedReadBucket(bucketTxRecords).ForEach(func(k, v []byte) error {
+		var block Block
+		err := readRawTxRecordBlock(k, &block)
+		if err == nil {
+			var txHash chainhash.Hash
+			copy(txHash[:], k)
+			err = readCheckedTxRecord(&txHash, v)
+		}
+		if err != nil {
+			found = append(found, Inconsistency{
+				Bucket:      "transaction records",
+				Key:         copyKey(k),
+				Description: fmt.Sprintf("corrupt record: %v", err),
+			})
+		}
+		return nil
+	})
+	return found, err
+}
+
+// readCheckedTxRecord decodes a transaction record keyed by the transaction
+// hash, using the hash as the checksum of the serialized transaction.  An
+// error is returned if the record can not be decoded or the transaction does
+// not match the hash.
+func readCheckedTxRecord(txHash *chainhash.Hash, v []byte) error {
+	var rec TxRecord
+	if err := readRawTxRecord(txHash, v, &rec); err != nil {
+		return err
+	}
+	if hash := rec.MsgTx.TxHash(); hash != *txHash {
+		return fmt.Errorf("checksum mismatch: transaction hashes "+
+			"to %v", hash)
+	}
+	return nil
+}
+
+// checkBlockRecords ensures every block record can be decoded and that every
+// transaction it lists is recorded as mined in the block.  Transactions which
+// are not recorded are removed from the block record by repairs.
+func checkBlockRecords(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var found []Inconsistency
+	err := ns.NestedReadBucket(bucketBlocks).ForEach(func(k, v []byte) error {
+		var block blockRecord
+		if err := readRawBlockRecord(k, v, &block); err != nil {
+			found = append(found, Inconsistency{
+				Bucket:      "blocks",
+				Key:         copyKey(k),
+				Description: fmt.Sprintf("corrupt record: %v", err),
+			})
+			return nil
+		}
+
+		var missing bool
+		kept := make([]chainhash.Hash, 0, len(block.transactions))
+		for i := range block.transactions {
+			txHash := &block.transactions[i]
+			if existsRawTxRecord(ns, keyTxRecord(txHash, &block.Block)) == nil {
+				missing = true
+				continue
+			}
+			kept = append(kept, *txHash)
+		}
+		if !missing {
+			return nil
+		}
+
+		blockKey := copyKey(k)
+		found = append(found, Inconsistency{
+			Bucket: "blocks",
+			Key:    blockKey,
+			Description: fmt.Sprintf("%d of %d transactions of block "+
+				"%v are not recorded", len(block.transactions)-len(kept),
+				len(block.transactions), block.Hash),
+			Repairable: true,
+			repair: func(ns walletdb.ReadWriteBucket) error {
+				if len(kept) == 0 {
+					return deleteBlockRecord(ns, block.Height)
+				}
+				meta := BlockMeta{Block: block.Block, Time: block.Time}
+				v := valueBlockRecord(&meta, &kept[0])
+				for i := range kept[1:] {
+					var err error
+					v, err = appendRawBlockRecord(v, &kept[i+1])
+					if err != nil {
+						return err
+					}
+				}
+				return putRawBlockRecord(ns, blockKey, v)
+			},
+		})
+		return nil
+	})
+	return found, err
+}
+
+// checkCredits ensures every credit is an output of a recorded transaction with
+// a matching amount, that unspent credits are recorded in the unspent index,
+// and that spent credits are spent by a recorded debit.  Missing unspent index
+// entries are recreated by repairs.
+func checkCredits(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var found []Inconsistency
+	err := ns.NestedReadBucket(bucketCredits).ForEach(func(k, v []byte) error {
+		credKey := copyKey(k)
+		newInconsistency := func(format string, args ...interface{}) {
+			found = append(found, Inconsistency{
+				Bucket:      "credits",
+				Key:         credKey,
+				Description: fmt.Sprintf(format, args...),
+			})
+		}
+
+		amount, spent, err := fetchRawCreditAmountSpent(v)
+		if err != nil || len(k) != 72 {
+			newInconsistency("corrupt record")
+			return nil
+		}
+
+		var txHash chainhash.Hash
+		copy(txHash[:], k)
+		index := byteOrder.Uint32(k[68:72])
+		recV := existsRawTxRecord(ns, k[:68])
+		if recV == nil {
+			newInconsistency("output %v:%d of an unrecorded "+
+				"transaction", txHash, index)
+			return nil
+		}
+		var rec TxRecord
+		if err := readRawTxRecord(&txHash, recV, &rec); err != nil {
+			// Reported by checkTxRecords.
+			return nil
+		}
+		if int(index) >= len(rec.MsgTx.TxOut) {
+			newInconsistency("output %v:%d does not exist", txHash,
+				index)
+			return nil
+		}
+		if value := rec.MsgTx.TxOut[index].Value; int64(amount) != value {
+			newInconsistency("amount %v of output %v:%d does not "+
+				"match the transaction amount %v", amount,
+				txHash, index, btcutil.Amount(value))
+			return nil
+		}
+
+		if spent {
+			if len(v) < 81 || ns.NestedReadBucket(bucketDebits).Get(v[9:81]) == nil {
+				newInconsistency("output %v:%d is spent by an "+
+					"unrecorded transaction", txHash, index)
+			}
+			return nil
+		}
+
+		opKey := canonicalOutPoint(&txHash, index)
+		if bytes.Equal(existsRawUnspent(ns, opKey), k) {
+			return nil
+		}
+		unspentV := copyKey(k[32:68])
+		found = append(found, Inconsistency{
+			Bucket: "credits",
+			Key:    credKey,
+			Description: fmt.Sprintf("unspent output %v:%d is "+
+				"missing from the unspent index", txHash, index),
+			Repairable: true,
+			repair: func(ns walletdb.ReadWriteBucket) error {
+				return putRawUnspent(ns, opKey, unspentV)
+			},
+		})
+		return nil
+	})
+	return found, err
+}
+
+// checkDebits ensures every debit is an input of a recorded transaction which
+// spends a recorded credit.
+func checkDebits(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var found []Inconsistency
+	err := ns.NestedReadBucket(bucketDebits).ForEach(func(k, v []byte) error {
+		newInconsistency := func(description string) {
+			found = append(found, Inconsistency{
+				Bucket:      "debits",
+				Key:         copyKey(k),
+				Description: description,
+			})
+		}
+
+		if len(k) != 72 || len(v) < 80 {
+			newInconsistency("corrupt record")
+			return nil
+		}
+		if existsRawTxRecord(ns, k[:68]) == nil {
+			newInconsistency("input of an unrecorded transaction")
+			return nil
+		}
+		if existsRawCredit(ns, v[8:80]) == nil {
+			newInconsistency("input spends an unrecorded output")
+		}
+		return nil
+	})
+	return found, err
+}
+
+// checkUnspent ensures every entry of the unspent index refers to a recorded
+// unspent credit.  Orphaned entries are removed by repairs.
+func checkUnspent(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var found []Inconsistency
+	err := ns.NestedReadBucket(bucketUnspent).ForEach(func(k, v []byte) error {
+		var description string
+		credKey := existsRawUnspent(ns, k)
+		switch {
+		case credKey == nil:
+			description = "corrupt record"
+		case existsRawCredit(ns, credKey) == nil:
+			description = "unspent output is not recorded"
+		default:
+			_, spent, err := fetchRawCreditAmountSpent(
+				existsRawCredit(ns, credKey),
+			)
+			if err != nil || !spent {
+				// Corrupt credits are reported by
+				// checkCredits.
+				return nil
+			}
+			description = "unspent output is recorded as spent"
+		}
+
+		opKey := copyKey(k)
+		found = append(found, Inconsistency{
+			Bucket:      "unspent",
+			Key:         opKey,
+			Description: description,
+			Repairable:  true,
+			repair: func(ns walletdb.ReadWriteBucket) error {
+				return deleteRawUnspent(ns, opKey)
+			},
+		})
+		return nil
+	})
+	return found, err
+}
+
+// checkUnmined ensures every unmined transaction record can be decoded and
+// matches the checksum of its transaction hash.
+func checkUnmined(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var found []Inconsistency
+	err := ns.NestedReadBucket(bucketUnmined).ForEach(func(k, v []byte) error {
+		var txHash chainhash.Hash
+		err := readRawUnminedHash(k, &txHash)
+		if err == nil {
+			err = readCheckedTxRecord(&txHash, v)
+		}
+		if err != nil {
+			found = append(found, Inconsistency{
+				Bucket:      "unmined",
+				Key:         copyKey(k),
+				Description: fmt.Sprintf("corrupt record: %v", err),
+			})
+		}
+		return nil
+	})
+	return found, err
+}
+
+// checkUnminedCredits ensures every unmined credit is an output of a recorded
+// unmined transaction.  Orphaned unmined credits are removed by repairs.
+func checkUnminedCredits(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var found []Inconsistency
+	bucket := ns.NestedReadBucket(bucketUnminedCredits)
+	err := bucket.ForEach(func(k, v []byte) error {
+		var op wire.OutPoint
+		err := readCanonicalOutPoint(k, &op)
+		if err == nil {
+			_, err = fetchRawUnminedCreditAmount(v)
+		}
+		var description string
+		switch {
+		case err != nil:
+			description = "corrupt record"
+		case existsRawUnmined(ns, op.Hash[:]) == nil:
+			description = fmt.Sprintf("output %v of an unrecorded "+
+				"unmined transaction", op)
+		default:
+			return nil
+		}
+
+		opKey := copyKey(k)
+		found = append(found, Inconsistency{
+			Bucket:      "unmined credits",
+			Key:         opKey,
+			Description: description,
+			Repairable:  true,
+			repair: func(ns walletdb.ReadWriteBucket) error {
+				return deleteRawUnminedCredit(ns, opKey)
+			},
+		})
+		return nil
+	})
+	return found, err
+}
+
+// checkUnminedInputs ensures every transaction recorded as spending an output
+// is a recorded unmined transaction.  Unrecorded spenders are removed by
+// repairs.
+func checkUnminedInputs(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var found []Inconsistency
+	bucket := ns.NestedReadBucket(bucketUnminedInputs)
+	err := bucket.ForEach(func(k, v []byte) error {
+		opKey := copyKey(k)
+		if len(k) != 36 || len(v)%32 != 0 {
+			found = append(found, Inconsistency{
+				Bucket:      "unmined inputs",
+				Key:         opKey,
+				Description: "corrupt record",
+			})
+			return nil
+		}
+
+		for _, spendHash := range fetchUnminedInputSpendTxHashes(ns, k) {
+			if existsRawUnmined(ns, spendHash[:]) != nil {
+				continue
+			}
+			spendHash := spendHash
+			found = append(found, Inconsistency{
+				Bucket: "unmined inputs",
+				Key:    opKey,
+				Description: fmt.Sprintf("output is spent by "+
+					"unrecorded unmined transaction %v",
+					spendHash),
+				Repairable: true,
+				repair: func(ns walletdb.ReadWriteBucket) error {
+					return deleteRawUnminedInput(
+						ns, opKey, spendHash,
+					)
+				},
+			})
+		}
+		return nil
+	})
+	return found, err
+}
+
+// checkMinedBalance ensures the recorded mined balance is the total of the
+// outputs in the unspent index.  The balance is recalculated by repairs.
+func checkMinedBalance(ns walletdb.ReadBucket) ([]Inconsistency, error) {
+	var total btcutil.Amount
+	err := ns.NestedReadBucket(bucketUnspent).ForEach(func(k, v []byte) error {
+		credKey := existsRawUnspent(ns, k)
+		if credKey == nil {
+			return nil
+		}
+		amount, err := fetchRawCreditAmount(existsRawCredit(ns, credKey))
+		if err != nil {
+			// Reported by checkUnspent and checkCredits.
+			return nil
+		}
+		total += amount
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := fetchMinedBalance(ns)
+	if err != nil {
+		return nil, err
+	}
+	if balance == total {
+		return nil, nil
+	}
+
+	return []Inconsistency{{
+		Bucket: "balance",
+		Key:    copyKey(rootMinedBalance),
+		Description: fmt.Sprintf("mined balance %v does not match the "+
+			"unspent outputs total %v", balance, total),
+		Repairable: true,
+		repair: func(ns walletdb.ReadWriteBucket) error {
+			return putMinedBalance(ns, total)
+		},
+	}}, nil
+}
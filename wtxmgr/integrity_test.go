@@ -0,0 +1,200 @@
+// Copyright (c) 2021 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wtxmgr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/walletdb"
+)
+
+// TestIntegrityRepair ensures that inconsistencies between the records of the
+// store are detected, that repairable inconsistencies are repaired, and that
+// lost transaction records are reported as unrepairable.
+func TestIntegrityRepair(t *testing.T) {
+	t.Parallel()
+
+	s, db, teardown, err := testStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	rec, err := NewTxRecord(TstRecvSerializedTx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(repair bool) []Inconsistency {
+		t.Helper()
+
+		var found []Inconsistency
+		err := walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+			ns := tx.ReadWriteBucket(namespaceKey)
+			var err error
+			if repair {
+				found, err = RepairIntegrity(ns)
+			} else {
+				found, err = CheckIntegrity(ns)
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+	update := func(f func(ns walletdb.ReadWriteBucket) error) {
+		t.Helper()
+
+		err := walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+			return f(tx.ReadWriteBucket(namespaceKey))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A consistent store has no inconsistencies.
+	update(func(ns walletdb.ReadWriteBucket) error {
+		err := s.InsertTx(ns, rec, TstRecvTxBlockDetails)
+		if err != nil {
+			return err
+		}
+		return s.AddCredit(ns, rec, TstRecvTxBlockDetails, 0, false)
+	})
+	if found := check(false); len(found) != 0 {
+		t.Fatalf("found inconsistencies in consistent store: %v", found)
+	}
+
+	// Remove the credit from the unspent index, record a credit of an
+	// unrecorded unmined transaction and corrupt the mined balance.
+	orphanHash := chainhash.Hash{1}
+	update(func(ns walletdb.ReadWriteBucket) error {
+		err := deleteRawUnspent(ns, canonicalOutPoint(&rec.Hash, 0))
+		if err != nil {
+			return err
+		}
+		err = putRawUnminedCredit(
+			ns, canonicalOutPoint(&orphanHash, 0),
+			valueUnminedCredit(1, false),
+		)
+		if err != nil {
+			return err
+		}
+		return putMinedBalance(ns, 1)
+	})
+	found := check(false)
+	if len(found) != 3 {
+		t.Fatalf("found %d inconsistencies, expected 3: %v",
+			len(found), found)
+	}
+	for _, i := range found {
+		if !i.Repairable {
+			t.Fatalf("inconsistency %v is not repairable", i.String())
+		}
+	}
+
+	// Repairing the store must fix every inconsistency found and restore
+	// the balance.
+	if found := check(true); len(found) != 3 {
+		t.Fatalf("repaired %d inconsistencies, expected 3: %v",
+			len(found), found)
+	}
+	if found := check(false); len(found) != 0 {
+		t.Fatalf("found inconsistencies after repair: %v", found)
+	}
+	err = walletdb.View(db, func(tx walletdb.ReadTx) error {
+		ns := tx.ReadBucket(namespaceKey)
+		bal, err := s.Balance(ns, 1, TstRecvCurrentHeight)
+		if err != nil {
+			return err
+		}
+		if bal != btcutil.Amount(TstRecvAmt) {
+			t.Fatalf("balance after repair is %v, expected %v",
+				bal, btcutil.Amount(TstRecvAmt))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Losing the transaction record leaves its credit unrepairable.
+	update(func(ns walletdb.ReadWriteBucket) error {
+		return deleteTxRecord(
+			ns, &rec.Hash, &TstRecvTxBlockDetails.Block,
+		)
+	})
+	var unrepairable int
+	for _, i := range check(true) {
+		if !i.Repairable {
+			unrepairable++
+		}
+	}
+	if unrepairable != 1 {
+		t.Fatalf("found %d unrepairable inconsistencies, expected 1",
+			unrepairable)
+	}
+}
+
+// TestIntegrityChecksum ensures that transaction records which do not match
+// the hash they are keyed by are reported as unrepairable.
+func TestIntegrityChecksum(t *testing.T) {
+	t.Parallel()
+
+	_, db, teardown, err := testStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	rec, err := NewTxRecord(TstRecvSerializedTx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := valueTxRecord(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Record the transaction under another hash, both mined and unmined.
+	otherHash := chainhash.Hash{1}
+	var found []Inconsistency
+	err = walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+		ns := tx.ReadWriteBucket(namespaceKey)
+		err := putRawTxRecord(
+			ns, keyTxRecord(&otherHash, &TstRecvTxBlockDetails.Block),
+			v,
+		)
+		if err != nil {
+			return err
+		}
+		if err := putRawUnmined(ns, otherHash[:], v); err != nil {
+			return err
+		}
+		found, err = CheckIntegrity(ns)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buckets := make(map[string]bool)
+	for _, i := range found {
+		if i.Repairable {
+			t.Fatalf("inconsistency %v is repairable", i.String())
+		}
+		buckets[i.Bucket] = true
+	}
+	if len(found) != 2 || !buckets["transaction records"] ||
+		!buckets["unmined"] {
+
+		t.Fatalf("expected checksum mismatches of the mined and "+
+			"unmined records, got %v", found)
+	}
+}
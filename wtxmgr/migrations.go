@@ -1,6 +1,9 @@
 package wtxmgr
 
 import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcwallet/walletdb"
 	"github.com/btcsuite/btcwallet/walletdb/migration"
 )
@@ -18,6 +21,10 @@ var versions = []migration.Version{
 		Number:    2,
 		Migration: dropTransactionHistory,
 	},
+	{
+		Number:    3,
+		Migration: indexScriptCredits,
+	},
 }
 
 // getLatestVersion returns the version number of the latest database version.
@@ -108,3 +115,83 @@ func dropTransactionHistory(ns walletdb.ReadWriteBucket) error {
 	// Finally, we'll insert a 0 value for our mined balance.
 	return putMinedBalance(ns, 0)
 }
+
+// indexScriptCredits is a migration that indexes every existing mined and
+// unmined credit by the output script it pays to.
+func indexScriptCredits(ns walletdb.ReadWriteBucket) error {
+	log.Info("Indexing wallet credits by output script")
+
+	_, err := ns.CreateBucketIfNotExists(bucketScriptCredits)
+	if err != nil {
+		str := "failed to create script credits bucket"
+		return storeError(ErrDatabase, str, err)
+	}
+
+	// The credits are collected before they are indexed, as buckets must
+	// not be modified while iterating over them.
+	type indexedCredit struct {
+		pkScript []byte
+		outPoint wire.OutPoint
+	}
+	var credits []indexedCredit
+
+	err = ns.NestedReadBucket(bucketCredits).ForEach(func(k, v []byte) error {
+		if len(k) < 72 {
+			str := fmt.Sprintf("%s: short key (expected %d bytes, "+
+				"read %d)", bucketCredits, 72, len(k))
+			return storeError(ErrData, str, nil)
+		}
+		recKey := extractRawCreditTxRecordKey(k)
+		recVal := existsRawTxRecord(ns, recKey)
+		if recVal == nil {
+			return nil
+		}
+		index := extractRawCreditIndex(k)
+		pkScript, err := fetchRawTxRecordPkScript(recKey, recVal, index)
+		if err != nil {
+			return err
+		}
+		credit := indexedCredit{pkScript: pkScript}
+		copy(credit.outPoint.Hash[:], k[:32])
+		credit.outPoint.Index = index
+		credits = append(credits, credit)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = ns.NestedReadBucket(bucketUnminedCredits).ForEach(func(k, v []byte) error {
+		var credit indexedCredit
+		if err := readCanonicalOutPoint(k, &credit.outPoint); err != nil {
+			return err
+		}
+		recVal := existsRawUnmined(ns, credit.outPoint.Hash[:])
+		if recVal == nil {
+			return nil
+		}
+		pkScript, err := fetchRawTxRecordPkScript(
+			k, recVal, credit.outPoint.Index,
+		)
+		if err != nil {
+			return err
+		}
+		credit.pkScript = pkScript
+		credits = append(credits, credit)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, credit := range credits {
+		err := putScriptCredit(
+			ns, credit.pkScript, &credit.outPoint.Hash,
+			credit.outPoint.Index,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
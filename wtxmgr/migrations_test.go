@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcwallet/walletdb"
 )
 
@@ -185,3 +187,99 @@ func TestMigrationDropTransactionHistory(t *testing.T) {
 		false,
 	)
 }
+
+// TestMigrationIndexScriptCredits ensures that the existing mined and unmined
+// credits of a transaction store are indexed by their output scripts.
+func TestMigrationIndexScriptCredits(t *testing.T) {
+	t.Parallel()
+
+	script := []byte{0x51}
+	var outPoints []wire.OutPoint
+
+	checkCredits := func(ns walletdb.ReadWriteBucket, s *Store,
+		afterMigration bool) error {
+
+		credits, err := s.ScriptCredits(ns, script)
+		if err != nil {
+			return err
+		}
+		if !afterMigration {
+			if len(credits) != 0 {
+				return fmt.Errorf("expected no indexed credits "+
+					"before migration, found %d",
+					len(credits))
+			}
+			return nil
+		}
+		if len(credits) != len(outPoints) {
+			return fmt.Errorf("expected %d indexed credits, "+
+				"found %d", len(outPoints), len(credits))
+		}
+		for _, op := range outPoints {
+			found := false
+			for _, credit := range credits {
+				found = found || credit.OutPoint == op
+			}
+			if !found {
+				return fmt.Errorf("credit %v not indexed", op)
+			}
+		}
+		return nil
+	}
+
+	beforeMigration := func(ns walletdb.ReadWriteBucket, s *Store) error {
+		// Record a mined credit and an unmined credit spending it, and
+		// then drop the index as it would not exist before the
+		// migration.
+		b := &BlockMeta{Block: Block{Height: 100}}
+		confirmed := spendOutput(&chainhash.Hash{1}, 0, 5e7)
+		confirmed.TxOut[0].PkScript = script
+		confirmedRec, err := NewTxRecordFromMsgTx(confirmed, timeNow())
+		if err != nil {
+			return err
+		}
+		if err := s.InsertTx(ns, confirmedRec, b); err != nil {
+			return err
+		}
+		err = s.AddCredit(ns, confirmedRec, b, 0, false)
+		if err != nil {
+			return err
+		}
+
+		unconfirmed := spendOutput(&confirmedRec.Hash, 0, 4e7)
+		unconfirmed.TxOut[0].PkScript = script
+		unconfirmedRec, err := NewTxRecordFromMsgTx(
+			unconfirmed, timeNow(),
+		)
+		if err != nil {
+			return err
+		}
+		if err := s.InsertTx(ns, unconfirmedRec, nil); err != nil {
+			return err
+		}
+		err = s.AddCredit(ns, unconfirmedRec, nil, 0, false)
+		if err != nil {
+			return err
+		}
+		outPoints = []wire.OutPoint{
+			{Hash: confirmedRec.Hash},
+			{Hash: unconfirmedRec.Hash},
+		}
+
+		if err := ns.DeleteNestedBucket(bucketScriptCredits); err != nil {
+			return err
+		}
+		if _, err := ns.CreateBucket(bucketScriptCredits); err != nil {
+			return err
+		}
+		return checkCredits(ns, s, false)
+	}
+
+	afterMigration := func(ns walletdb.ReadWriteBucket, s *Store) error {
+		return checkCredits(ns, s, true)
+	}
+
+	applyMigration(
+		t, beforeMigration, afterMigration, indexScriptCredits, false,
+	)
+}
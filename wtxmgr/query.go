@@ -8,7 +8,9 @@ package wtxmgr
 import (
 	"fmt"
 
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/walletdb"
 )
@@ -40,6 +42,12 @@ type TxDetails struct {
 	Credits []CreditRecord
 	Debits  []DebitRecord
 	Label   string
+
+	// Comment and CommentTo are the comments of a send created by the
+	// wallet, describing its purpose and the person or organization it
+	// pays.
+	Comment   string
+	CommentTo string
 }
 
 // minedTxDetails fetches the TxDetails for the mined transaction with hash
@@ -95,11 +103,15 @@ func (s *Store) minedTxDetails(ns walletdb.ReadBucket, txHash *chainhash.Hash, r
 		return nil, debIter.err
 	}
 
-	// Finally, we add the transaction label to details.
+	// Finally, we add the transaction label and comments to details.
 	details.Label, err = s.TxLabel(ns, *txHash)
 	if err != nil {
 		return nil, err
 	}
+	details.Comment, details.CommentTo, err = s.TxComment(ns, *txHash)
+	if err != nil {
+		return nil, err
+	}
 
 	return &details, nil
 }
@@ -169,11 +181,15 @@ func (s *Store) unminedTxDetails(ns walletdb.ReadBucket, txHash *chainhash.Hash,
 		})
 	}
 
-	// Finally, we add the transaction label to details.
+	// Finally, we add the transaction label and comments to details.
 	details.Label, err = s.TxLabel(ns, *txHash)
 	if err != nil {
 		return nil, err
 	}
+	details.Comment, details.CommentTo, err = s.TxComment(ns, *txHash)
+	if err != nil {
+		return nil, err
+	}
 
 	return &details, nil
 }
@@ -250,6 +266,100 @@ func (s *Store) UniqueTxDetails(ns walletdb.ReadBucket, txHash *chainhash.Hash,
 	return s.minedTxDetails(ns, txHash, k, v)
 }
 
+// ScriptCredits returns every credit paying to the output script, mined or
+// unmined, along with the transaction spending each of them.  The credits are
+// looked up from an index of credits by output script, so that only the
+// transactions of the script are read.  The order is undefined.
+func (s *Store) ScriptCredits(ns walletdb.ReadBucket,
+	pkScript []byte) ([]ScriptCredit, error) {
+
+	var credits []ScriptCredit
+	err := forEachScriptCredit(ns, pkScript, func(op wire.OutPoint) error {
+		credit, err := s.scriptCredit(ns, &op)
+		if err != nil {
+			return err
+		}
+		if credit != nil {
+			credits = append(credits, *credit)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credits, nil
+}
+
+// scriptCredit looks up the credit of an indexed outpoint.  Nil is returned if
+// the credit no longer exists.
+func (s *Store) scriptCredit(ns walletdb.ReadBucket,
+	op *wire.OutPoint) (*ScriptCredit, error) {
+
+	var (
+		credit ScriptCredit
+		rec    TxRecord
+	)
+	credit.OutPoint = *op
+	opKey := canonicalOutPoint(&op.Hash, op.Index)
+	if v := existsRawUnminedCredit(ns, opKey); v != nil {
+		recVal := existsRawUnmined(ns, op.Hash[:])
+		if err := readRawTxRecord(&op.Hash, recVal, &rec); err != nil {
+			return nil, err
+		}
+		credit.Height = -1
+	} else {
+		recKey, recVal := latestTxRecord(ns, &op.Hash)
+		if recVal == nil {
+			return nil, nil
+		}
+		err := readRawTxRecordBlock(recKey, &credit.Block)
+		if err != nil {
+			return nil, err
+		}
+		credKey := keyCredit(&op.Hash, op.Index, &credit.Block)
+		v := existsRawCredit(ns, credKey)
+		if v == nil {
+			return nil, nil
+		}
+		if err := readRawTxRecord(&op.Hash, recVal, &rec); err != nil {
+			return nil, err
+		}
+		credit.Time, err = fetchBlockTime(ns, credit.Height)
+		if err != nil {
+			return nil, err
+		}
+
+		// Spends by mined transactions are recorded in the credit.
+		_, spent, err := fetchRawCreditAmountSpent(v)
+		if err != nil {
+			return nil, err
+		}
+		if spent && len(v) >= 81 {
+			var spender chainhash.Hash
+			copy(spender[:], v[9:41])
+			credit.SpentBy = &spender
+		}
+	}
+	if int(op.Index) >= len(rec.MsgTx.TxOut) {
+		str := "indexed credit index exceeds number of outputs"
+		return nil, storeError(ErrData, str, nil)
+	}
+
+	if credit.SpentBy == nil {
+		spenders := fetchUnminedInputSpendTxHashes(ns, opKey)
+		if len(spenders) > 0 {
+			credit.SpentBy = &spenders[0]
+		}
+	}
+
+	txOut := rec.MsgTx.TxOut[op.Index]
+	credit.Amount = btcutil.Amount(txOut.Value)
+	credit.PkScript = txOut.PkScript
+	credit.Received = rec.Received
+	credit.FromCoinBase = blockchain.IsCoinBaseTx(&rec.MsgTx)
+	return &credit, nil
+}
+
 // rangeUnminedTransactions executes the function f with TxDetails for every
 // unmined transaction.  f is not executed if no unmined transactions exist.
 // Error returns from f (if any) are propigated to the caller.  Returns true
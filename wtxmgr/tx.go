@@ -44,6 +44,10 @@ var (
 	// transaction hash.
 	ErrTxLabelNotFound = errors.New("label for transaction not found")
 
+	// ErrCommentTooLong is returned when an attempt to write a
+	// transaction comment that is too long is made.
+	ErrCommentTooLong = errors.New("transaction comment exceeds limit")
+
 	// ErrUnknownOutput is an error returned when an output not known to the
 	// wallet is attempted to be locked.
 	ErrUnknownOutput = errors.New("unknown output")
@@ -126,6 +130,41 @@ type TxRecord struct {
 	SerializedTx []byte // Optional: may be nil
 }
 
+// CreditChangeType describes how a change affects the unspent credits of the
+// store.
+type CreditChangeType uint8
+
+const (
+	// CreditAdded indicates that an output was recorded as a new unspent
+	// credit.
+	CreditAdded CreditChangeType = iota
+
+	// CreditSpent indicates that an output, if it is an unspent credit,
+	// was spent by a mined or unmined transaction.
+	CreditSpent
+
+	// CreditMined indicates that an unmined credit, if it is unspent, was
+	// mined in a block.
+	CreditMined
+
+	// CreditsReset indicates that the unspent credits were changed in a
+	// way not described by any other change, such as by a rollback or the
+	// removal of an unmined transaction, and must be reloaded.
+	CreditsReset
+)
+
+// CreditChange describes a change to the unspent credits of the store.  The
+// amount, output script and coinbase flag are only set for added credits, and
+// the height is only set for added and mined credits.
+type CreditChange struct {
+	Type         CreditChangeType
+	OutPoint     wire.OutPoint
+	Amount       btcutil.Amount
+	PkScript     []byte
+	Height       int32
+	FromCoinBase bool
+}
+
 // LockedOutput is a type that contains an outpoint of an UTXO and its lock
 // lease information.
 type LockedOutput struct {
@@ -182,6 +221,16 @@ type Credit struct {
 	FromCoinBase bool
 }
 
+// ScriptCredit is a credit paying to an output script, along with the
+// transaction spending it, if any.
+type ScriptCredit struct {
+	Credit
+
+	// SpentBy is the hash of the mined or unmined transaction spending
+	// the credit, or nil when the credit is unspent.
+	SpentBy *chainhash.Hash
+}
+
 // LockID represents a unique context-specific ID assigned to an output lock.
 type LockID [32]byte
 
@@ -196,6 +245,17 @@ type Store struct {
 	// Event callbacks.  These execute in the same goroutine as the wtxmgr
 	// caller.
 	NotifyUnspent func(hash *chainhash.Hash, index uint32)
+
+	// NotifyConflict is called with the hash of each unmined transaction
+	// removed for double spending an output also spent by another
+	// transaction, and the hash of that transaction, once the database
+	// transaction removing it has been committed.
+	NotifyConflict func(hash, conflictHash *chainhash.Hash)
+
+	// NotifyCreditChange is called with each change to the unspent
+	// credits once the database transaction making the change has been
+	// committed.  Changes are notified in the order they were made.
+	NotifyCreditChange func(change CreditChange)
 }
 
 // Open opens the wallet transaction store from a walletdb namespace.  If the
@@ -208,7 +268,7 @@ func Open(ns walletdb.ReadBucket, chainParams *chaincfg.Params) (*Store, error)
 	if err != nil {
 		return nil, err
 	}
-	s := &Store{chainParams, clock.NewDefaultClock(), nil} // TODO: set callbacks
+	s := &Store{chainParams, clock.NewDefaultClock(), nil, nil, nil} // TODO: set callbacks
 	return s, nil
 }
 
@@ -219,6 +279,19 @@ func Create(ns walletdb.ReadWriteBucket) error {
 	return createStore(ns)
 }
 
+// notifyCreditChange calls the NotifyCreditChange callback, if set, with the
+// change once the database transaction of ns has been committed.
+func (s *Store) notifyCreditChange(ns walletdb.ReadWriteBucket,
+	change CreditChange) {
+
+	if s.NotifyCreditChange == nil {
+		return
+	}
+	ns.Tx().OnCommit(func() {
+		s.NotifyCreditChange(change)
+	})
+}
+
 // updateMinedBalance updates the mined balance within the store, if changed,
 // after processing the given transaction record.
 func (s *Store) updateMinedBalance(ns walletdb.ReadWriteBucket, rec *TxRecord,
@@ -278,6 +351,10 @@ func (s *Store) updateMinedBalance(ns walletdb.ReadWriteBucket, rec *TxRecord,
 		if err := deleteRawUnspent(ns, unspentKey); err != nil {
 			return err
 		}
+		s.notifyCreditChange(ns, CreditChange{
+			Type:     CreditSpent,
+			OutPoint: input.PreviousOutPoint,
+		})
 
 		newMinedBalance -= amt
 	}
@@ -320,6 +397,11 @@ func (s *Store) updateMinedBalance(ns walletdb.ReadWriteBucket, rec *TxRecord,
 		if err != nil {
 			return err
 		}
+		s.notifyCreditChange(ns, CreditChange{
+			Type:     CreditMined,
+			OutPoint: cred.outPoint,
+			Height:   block.Height,
+		})
 
 		newMinedBalance += amount
 	}
@@ -508,7 +590,22 @@ func (s *Store) addCredit(ns walletdb.ReadWriteBucket, rec *TxRecord, block *Blo
 			return false, nil
 		}
 		v := valueUnminedCredit(btcutil.Amount(rec.MsgTx.TxOut[index].Value), change)
-		return true, putRawUnminedCredit(ns, k, v)
+		if err := putRawUnminedCredit(ns, k, v); err != nil {
+			return false, err
+		}
+		pkScript := rec.MsgTx.TxOut[index].PkScript
+		err := putScriptCredit(ns, pkScript, &rec.Hash, index)
+		if err != nil {
+			return false, err
+		}
+		s.notifyCreditChange(ns, CreditChange{
+			Type:     CreditAdded,
+			OutPoint: wire.OutPoint{Hash: rec.Hash, Index: index},
+			Amount:   btcutil.Amount(rec.MsgTx.TxOut[index].Value),
+			PkScript: pkScript,
+			Height:   -1,
+		})
+		return true, nil
 	}
 
 	k, v := existsCredit(ns, &rec.Hash, index, &block.Block)
@@ -535,6 +632,11 @@ func (s *Store) addCredit(ns walletdb.ReadWriteBucket, rec *TxRecord, block *Blo
 	if err != nil {
 		return false, err
 	}
+	pkScript := rec.MsgTx.TxOut[index].PkScript
+	err = putScriptCredit(ns, pkScript, &rec.Hash, index)
+	if err != nil {
+		return false, err
+	}
 
 	minedBalance, err := fetchMinedBalance(ns)
 	if err != nil {
@@ -545,7 +647,19 @@ func (s *Store) addCredit(ns walletdb.ReadWriteBucket, rec *TxRecord, block *Blo
 		return false, err
 	}
 
-	return true, putUnspent(ns, &cred.outPoint, &block.Block)
+	err = putUnspent(ns, &cred.outPoint, &block.Block)
+	if err != nil {
+		return false, err
+	}
+	s.notifyCreditChange(ns, CreditChange{
+		Type:         CreditAdded,
+		OutPoint:     cred.outPoint,
+		Amount:       txOutAmt,
+		PkScript:     pkScript,
+		Height:       block.Height,
+		FromCoinBase: blockchain.IsCoinBaseTx(&rec.MsgTx),
+	})
+	return true, nil
 }
 
 // Rollback removes all blocks at height onwards, moving any transactions within
@@ -555,6 +669,8 @@ func (s *Store) Rollback(ns walletdb.ReadWriteBucket, height int32) error {
 }
 
 func (s *Store) rollback(ns walletdb.ReadWriteBucket, height int32) error {
+	s.notifyCreditChange(ns, CreditChange{Type: CreditsReset})
+
 	minedBalance, err := fetchMinedBalance(ns)
 	if err != nil {
 		return err
@@ -626,6 +742,13 @@ func (s *Store) rollback(ns walletdb.ReadWriteBucket, height int32) error {
 					if err != nil {
 						return err
 					}
+					err = deleteScriptCredit(
+						ns, output.PkScript, &rec.Hash,
+						uint32(i),
+					)
+					if err != nil {
+						return err
+					}
 				}
 
 				continue
@@ -794,9 +917,23 @@ func (s *Store) rollback(ns walletdb.ReadWriteBucket, height int32) error {
 	return putMinedBalance(ns, minedBalance)
 }
 
-// UnspentOutputs returns all unspent received transaction outputs.
-// The order is undefined.
+// UnspentOutputs returns all unspent received transaction outputs, excluding
+// those which are locked.  The order is undefined.
 func (s *Store) UnspentOutputs(ns walletdb.ReadBucket) ([]Credit, error) {
+	return s.unspentOutputs(ns, false)
+}
+
+// UnspentOutputsWithLocked returns all unspent received transaction outputs,
+// including those which are locked.  The order is undefined.
+func (s *Store) UnspentOutputsWithLocked(ns walletdb.ReadBucket) ([]Credit,
+	error) {
+
+	return s.unspentOutputs(ns, true)
+}
+
+func (s *Store) unspentOutputs(ns walletdb.ReadBucket,
+	includeLocked bool) ([]Credit, error) {
+
 	var unspent []Credit
 
 	var op wire.OutPoint
@@ -809,7 +946,7 @@ func (s *Store) UnspentOutputs(ns walletdb.ReadBucket) ([]Credit, error) {
 
 		// Skip the output if it's locked.
 		_, _, isLocked := isLockedOutput(ns, op, s.clock.Now())
-		if isLocked {
+		if isLocked && !includeLocked {
 			return nil
 		}
 
@@ -866,7 +1003,7 @@ func (s *Store) UnspentOutputs(ns walletdb.ReadBucket) ([]Credit, error) {
 
 		// Skip the output if it's locked.
 		_, _, isLocked := isLockedOutput(ns, op, s.clock.Now())
-		if isLocked {
+		if isLocked && !includeLocked {
 			return nil
 		}
 
@@ -1156,6 +1293,82 @@ func DeserializeLabel(v []byte) (string, error) {
 	return label, nil
 }
 
+// PutTxComment writes the comments of a send to disk, keyed by the
+// transaction hash.  The comment describes the purpose of the transaction and
+// commentTo the person or organization paid, and either may be empty.  Each
+// comment is limited to TxLabelLimit bytes.  Writing two empty comments
+// removes the comments of the transaction.
+//
+// The comments are written to disk in length value format:
+// [0:2] Comment length
+// [2: +len] Comment
+// [+0:+2] CommentTo length
+// [+2: +len] CommentTo
+func (s *Store) PutTxComment(ns walletdb.ReadWriteBucket, txid chainhash.Hash,
+	comment, commentTo string) error {
+
+	if len(comment) > TxLabelLimit || len(commentTo) > TxLabelLimit {
+		return ErrCommentTooLong
+	}
+
+	if comment == "" && commentTo == "" {
+		commentBucket := ns.NestedReadWriteBucket(bucketTxComments)
+		if commentBucket == nil {
+			return nil
+		}
+		return commentBucket.Delete(txid[:])
+	}
+
+	commentBucket, err := ns.CreateBucketIfNotExists(bucketTxComments)
+	if err != nil {
+		return err
+	}
+
+	v := make([]byte, 4+len(comment)+len(commentTo))
+	binary.BigEndian.PutUint16(v[0:2], uint16(len(comment)))
+	copy(v[2:], comment)
+	off := 2 + len(comment)
+	binary.BigEndian.PutUint16(v[off:off+2], uint16(len(commentTo)))
+	copy(v[off+2:], commentTo)
+	return commentBucket.Put(txid[:], v)
+}
+
+// TxComment looks up the comments of a send written by PutTxComment.  Empty
+// comments and no error are returned if the transaction has no comments.
+func (s *Store) TxComment(ns walletdb.ReadBucket, txid chainhash.Hash) (
+	comment, commentTo string, err error) {
+
+	commentBucket := ns.NestedReadBucket(bucketTxComments)
+	if commentBucket == nil {
+		return "", "", nil
+	}
+	v := commentBucket.Get(txid[:])
+	if v == nil {
+		return "", "", nil
+	}
+
+	if len(v) < 2 {
+		str := fmt.Sprintf("%s: short read for comments of %v",
+			bucketTxComments, txid)
+		return "", "", storeError(ErrData, str, nil)
+	}
+	off := 2 + int(binary.BigEndian.Uint16(v[0:2]))
+	if len(v) < off+2 {
+		str := fmt.Sprintf("%s: short read for comments of %v",
+			bucketTxComments, txid)
+		return "", "", storeError(ErrData, str, nil)
+	}
+	comment = string(v[2:off])
+	end := off + 2 + int(binary.BigEndian.Uint16(v[off:off+2]))
+	if len(v) != end {
+		str := fmt.Sprintf("%s: malformed comments of %v",
+			bucketTxComments, txid)
+		return "", "", storeError(ErrData, str, nil)
+	}
+	commentTo = string(v[off+2 : end])
+	return comment, commentTo, nil
+}
+
 // isKnownOutput returns whether the output is known to the transaction store
 // either as confirmed or unconfirmed.
 func isKnownOutput(ns walletdb.ReadWriteBucket, op wire.OutPoint) bool {
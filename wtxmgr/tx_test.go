@@ -11,11 +11,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/walletdb"
@@ -2380,6 +2382,70 @@ func TestTxLabel(t *testing.T) {
 	}
 }
 
+// TestTxComment tests reading and writing of the comments of sends.
+func TestTxComment(t *testing.T) {
+	t.Parallel()
+
+	store, db, teardown, err := testStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	txid := chainhash.Hash{1}
+
+	putComment := func(comment, commentTo string) error {
+		return walletdb.Update(db, func(tx walletdb.ReadWriteTx) error {
+			ns := tx.ReadWriteBucket(namespaceKey)
+			return store.PutTxComment(ns, txid, comment, commentTo)
+		})
+	}
+	readComment := func() (string, string) {
+		var comment, commentTo string
+		err := walletdb.View(db, func(tx walletdb.ReadTx) error {
+			var err error
+			ns := tx.ReadBucket(namespaceKey)
+			comment, commentTo, err = store.TxComment(ns, txid)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return comment, commentTo
+	}
+
+	// Transactions without comments have empty comments, even before any
+	// comment is written.
+	if comment, commentTo := readComment(); comment != "" || commentTo != "" {
+		t.Fatalf("expected no comments, got %q, %q", comment, commentTo)
+	}
+
+	longComment := string(make([]byte, TxLabelLimit+1))
+	if err := putComment("", longComment); err != ErrCommentTooLong {
+		t.Fatalf("expected: %v, got: %v", ErrCommentTooLong, err)
+	}
+
+	tests := []struct {
+		comment   string
+		commentTo string
+	}{
+		{"rent", "landlord"},
+		{"", "landlord"},
+		{"rent", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if err := putComment(test.comment, test.commentTo); err != nil {
+			t.Fatal(err)
+		}
+		comment, commentTo := readComment()
+		if comment != test.comment || commentTo != test.commentTo {
+			t.Fatalf("expected comments %q, %q, got %q, %q",
+				test.comment, test.commentTo, comment, commentTo)
+		}
+	}
+}
+
 func assertBalance(t *testing.T, s *Store, ns walletdb.ReadWriteBucket,
 	confirmed bool, blockHeight int32, exp btcutil.Amount) {
 
@@ -2848,3 +2914,393 @@ func TestOutputLocks(t *testing.T) {
 		})
 	}
 }
+
+// TestRemoveDoubleSpendsNotifyConflict ensures that an unmined transaction
+// double spending the output spent by a transaction accepted to the mempool is
+// removed along with its spenders, and that the conflict is notified.
+func TestRemoveDoubleSpendsNotifyConflict(t *testing.T) {
+	t.Parallel()
+
+	store, db, teardown, err := testStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	type conflict struct {
+		hash, conflictHash chainhash.Hash
+	}
+	var conflicts []conflict
+	store.NotifyConflict = func(hash, conflictHash *chainhash.Hash) {
+		conflicts = append(conflicts, conflict{*hash, *conflictHash})
+	}
+
+	b100 := BlockMeta{
+		Block: Block{Height: 100},
+		Time:  time.Now(),
+	}
+	cb := newCoinBase(1e8)
+	cbRec, err := NewTxRecordFromMsgTx(cb, b100.Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		if err := store.InsertTx(ns, cbRec, &b100); err != nil {
+			t.Fatal(err)
+		}
+		err := store.AddCredit(ns, cbRec, &b100, 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// Our unmined spend of the coinbase output is spent in turn by
+	// another unmined transaction.
+	spend := spendOutput(&cbRec.Hash, 0, 5e7)
+	spendRec, err := NewTxRecordFromMsgTx(spend, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := spendOutput(&spendRec.Hash, 0, 4e7)
+	childRec, err := NewTxRecordFromMsgTx(child, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range []*TxRecord{spendRec, childRec} {
+		commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+			if err := store.InsertTx(ns, rec, nil); err != nil {
+				t.Fatal(err)
+			}
+			err := store.AddCredit(ns, rec, nil, 0, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// A transaction double spending the coinbase output is accepted to
+	// the mempool, superseding both of our transactions.
+	doubleSpend := spendOutput(&cbRec.Hash, 0, 6e7)
+	doubleSpendRec, err := NewTxRecordFromMsgTx(doubleSpend, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		err := store.RemoveDoubleSpends(ns, doubleSpendRec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.InsertTx(ns, doubleSpendRec, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		// The conflict is only notified once the removal is
+		// committed.
+		if len(conflicts) != 0 {
+			t.Fatalf("conflict notified before commit: %v",
+				conflicts)
+		}
+	})
+
+	expConflict := conflict{spendRec.Hash, doubleSpendRec.Hash}
+	if len(conflicts) != 1 || conflicts[0] != expConflict {
+		t.Fatalf("expected conflict %v, got %v", expConflict,
+			conflicts)
+	}
+
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		hashes, err := store.UnminedTxHashes(ns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hashes) != 1 || *hashes[0] != doubleSpendRec.Hash {
+			t.Fatalf("expected only unmined transaction %v, got %v",
+				doubleSpendRec.Hash, hashes)
+		}
+	})
+}
+
+// TestScriptCredits ensures the credits paying to an output script are found
+// through the script credit index as they are mined, spent, unmined by a
+// rollback and removed.
+func TestScriptCredits(t *testing.T) {
+	t.Parallel()
+
+	store, db, teardown, err := testStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	script := []byte{txscript.OP_TRUE}
+	otherScript := []byte{txscript.OP_TRUE, txscript.OP_TRUE}
+
+	cb := newCoinBase(1e8, 2e8)
+	cb.TxOut[0].PkScript = script
+	cb.TxOut[1].PkScript = otherScript
+	cbRec, err := NewTxRecordFromMsgTx(cb, timeNow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spend := spendOutput(&cbRec.Hash, 0, 5e7, 4e7)
+	spend.TxOut[0].PkScript = script
+	spend.TxOut[1].PkScript = script
+	spendRec, err := NewTxRecordFromMsgTx(spend, timeNow())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type expectedCredit struct {
+		outPoint wire.OutPoint
+		height   int32
+		spentBy  *chainhash.Hash
+	}
+	assertCredits := func(ns walletdb.ReadBucket, pkScript []byte,
+		expected ...expectedCredit) {
+
+		t.Helper()
+
+		credits, err := store.ScriptCredits(ns, pkScript)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(credits) != len(expected) {
+			t.Fatalf("expected %d credits, found %d",
+				len(expected), len(credits))
+		}
+	next:
+		for _, exp := range expected {
+			for _, credit := range credits {
+				if credit.OutPoint != exp.outPoint {
+					continue
+				}
+				if credit.Height != exp.height {
+					t.Fatalf("expected credit %v at height "+
+						"%d, found %d", exp.outPoint,
+						exp.height, credit.Height)
+				}
+				if !bytes.Equal(credit.PkScript, pkScript) {
+					t.Fatalf("credit %v pays to script %x",
+						exp.outPoint, credit.PkScript)
+				}
+				switch {
+				case exp.spentBy == nil && credit.SpentBy != nil,
+					exp.spentBy != nil && (credit.SpentBy == nil ||
+						*credit.SpentBy != *exp.spentBy):
+
+					t.Fatalf("expected credit %v spent by "+
+						"%v, found %v", exp.outPoint,
+						exp.spentBy, credit.SpentBy)
+				}
+				continue next
+			}
+			t.Fatalf("credit %v not found", exp.outPoint)
+		}
+	}
+
+	// The coinbase's credits are mined, and each is found only through its
+	// own script.
+	b100 := &BlockMeta{Block: Block{Height: 100}, Time: timeNow()}
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		if err := store.InsertTx(ns, cbRec, b100); err != nil {
+			t.Fatal(err)
+		}
+		for i := uint32(0); i < 2; i++ {
+			err := store.AddCredit(ns, cbRec, b100, i, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		assertCredits(ns, script, expectedCredit{
+			outPoint: wire.OutPoint{Hash: cbRec.Hash, Index: 0},
+			height:   100,
+		})
+		assertCredits(ns, otherScript, expectedCredit{
+			outPoint: wire.OutPoint{Hash: cbRec.Hash, Index: 1},
+			height:   100,
+		})
+	})
+
+	// An unmined transaction spending the first credit debits it, and its
+	// credits are found as unmined.
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		if err := store.InsertTx(ns, spendRec, nil); err != nil {
+			t.Fatal(err)
+		}
+		for i := uint32(0); i < 2; i++ {
+			err := store.AddCredit(ns, spendRec, nil, i, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		assertCredits(ns, script, expectedCredit{
+			outPoint: wire.OutPoint{Hash: cbRec.Hash, Index: 0},
+			height:   100,
+			spentBy:  &spendRec.Hash,
+		}, expectedCredit{
+			outPoint: wire.OutPoint{Hash: spendRec.Hash, Index: 0},
+			height:   -1,
+		}, expectedCredit{
+			outPoint: wire.OutPoint{Hash: spendRec.Hash, Index: 1},
+			height:   -1,
+		})
+	})
+
+	// Once the spend is mined, the debit is recorded by the spent credit.
+	b101 := &BlockMeta{Block: Block{Height: 101}, Time: timeNow()}
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		if err := store.InsertTx(ns, spendRec, b101); err != nil {
+			t.Fatal(err)
+		}
+		for i := uint32(0); i < 2; i++ {
+			err := store.AddCredit(ns, spendRec, b101, i, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		assertCredits(ns, script, expectedCredit{
+			outPoint: wire.OutPoint{Hash: cbRec.Hash, Index: 0},
+			height:   100,
+			spentBy:  &spendRec.Hash,
+		}, expectedCredit{
+			outPoint: wire.OutPoint{Hash: spendRec.Hash, Index: 0},
+			height:   101,
+		}, expectedCredit{
+			outPoint: wire.OutPoint{Hash: spendRec.Hash, Index: 1},
+			height:   101,
+		})
+	})
+
+	// Rolling back both blocks removes the coinbase, and the spend of it
+	// which is then unmined.
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		if err := store.Rollback(ns, 100); err != nil {
+			t.Fatal(err)
+		}
+		assertCredits(ns, script)
+		assertCredits(ns, otherScript)
+	})
+}
+
+// TestNotifyCreditChange ensures that each change to the unspent credits of the
+// store is notified in order once its database transaction is committed.
+func TestNotifyCreditChange(t *testing.T) {
+	t.Parallel()
+
+	store, db, teardown, err := testStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	var changes []CreditChange
+	store.NotifyCreditChange = func(change CreditChange) {
+		changes = append(changes, change)
+	}
+	assertChanges := func(expected ...CreditChange) {
+		t.Helper()
+
+		if !reflect.DeepEqual(changes, expected) {
+			t.Fatalf("expected credit changes %v, found %v",
+				expected, changes)
+		}
+		changes = nil
+	}
+
+	script := []byte{txscript.OP_TRUE}
+	cb := newCoinBase(1e8)
+	cb.TxOut[0].PkScript = script
+	cbRec, err := NewTxRecordFromMsgTx(cb, timeNow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cbOutPoint := wire.OutPoint{Hash: cbRec.Hash, Index: 0}
+	spend := spendOutput(&cbRec.Hash, 0, 9e7)
+	spend.TxOut[0].PkScript = script
+	spendRec, err := NewTxRecordFromMsgTx(spend, timeNow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spendOutPoint := wire.OutPoint{Hash: spendRec.Hash, Index: 0}
+
+	b100 := &BlockMeta{Block: Block{Height: 100}, Time: timeNow()}
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		if err := store.InsertTx(ns, cbRec, b100); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.AddCredit(ns, cbRec, b100, 0, false); err != nil {
+			t.Fatal(err)
+		}
+
+		// Changes are not notified before they are committed.
+		assertChanges()
+	})
+	assertChanges(CreditChange{
+		Type:         CreditAdded,
+		OutPoint:     cbOutPoint,
+		Amount:       1e8,
+		PkScript:     script,
+		Height:       100,
+		FromCoinBase: true,
+	})
+
+	// Changes of a database transaction which is rolled back are never
+	// notified.
+	dbTx, err := db.BeginReadWriteTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns := dbTx.ReadWriteBucket(namespaceKey)
+	if err := store.InsertTx(ns, spendRec, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := dbTx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	assertChanges()
+
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		if err := store.InsertTx(ns, spendRec, nil); err != nil {
+			t.Fatal(err)
+		}
+		err := store.AddCredit(ns, spendRec, nil, 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	assertChanges(CreditChange{
+		Type:     CreditSpent,
+		OutPoint: cbOutPoint,
+	}, CreditChange{
+		Type:     CreditAdded,
+		OutPoint: spendOutPoint,
+		Amount:   9e7,
+		PkScript: script,
+		Height:   -1,
+	})
+
+	b101 := &BlockMeta{
+		Block: Block{Hash: chainhash.Hash{1}, Height: 101},
+		Time:  timeNow(),
+	}
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		if err := store.InsertTx(ns, spendRec, b101); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assertChanges(CreditChange{
+		Type:     CreditSpent,
+		OutPoint: cbOutPoint,
+	}, CreditChange{
+		Type:     CreditMined,
+		OutPoint: spendOutPoint,
+		Height:   101,
+	})
+
+	commitDBTx(t, store, db, func(ns walletdb.ReadWriteBucket) {
+		if err := store.Rollback(ns, 101); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assertChanges(CreditChange{Type: CreditsReset})
+}
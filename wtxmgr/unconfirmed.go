@@ -53,6 +53,10 @@ func (s *Store) insertMemPoolTx(ns walletdb.ReadWriteBucket, rec *TxRecord) erro
 		if err != nil {
 			return err
 		}
+		s.notifyCreditChange(ns, CreditChange{
+			Type:     CreditSpent,
+			OutPoint: *prevOut,
+		})
 	}
 
 	// TODO: increment credit amount for each credit (but those are unknown
@@ -61,10 +65,19 @@ func (s *Store) insertMemPoolTx(ns walletdb.ReadWriteBucket, rec *TxRecord) erro
 	return nil
 }
 
+// RemoveDoubleSpends removes every unmined transaction which double spends an
+// output spent by rec, along with all transactions which spend it.  This should
+// be called for unmined transactions accepted to the mempool of the chain
+// server, which supersede any conflicting transactions of the wallet.
+func (s *Store) RemoveDoubleSpends(ns walletdb.ReadWriteBucket, rec *TxRecord) error {
+	return s.removeDoubleSpends(ns, rec)
+}
+
 // removeDoubleSpends checks for any unmined transactions which would introduce
 // a double spend if tx was added to the store (either as a confirmed or unmined
 // transaction).  Each conflicting transaction and all transactions which spend
-// it are recursively removed.
+// it are recursively removed.  The NotifyConflict callback is only called for
+// the conflicting transactions, and not for the transactions spending them.
 func (s *Store) removeDoubleSpends(ns walletdb.ReadWriteBucket, rec *TxRecord) error {
 	for _, input := range rec.MsgTx.TxIn {
 		prevOut := &input.PreviousOutPoint
@@ -104,17 +117,33 @@ func (s *Store) removeDoubleSpends(ns walletdb.ReadWriteBucket, rec *TxRecord) e
 			if err := s.removeConflict(ns, &doubleSpend); err != nil {
 				return err
 			}
+			s.notifyConflict(ns, &doubleSpend.Hash, &rec.Hash)
 		}
 	}
 
 	return nil
 }
 
+// notifyConflict calls the NotifyConflict callback, if set, once the database
+// transaction of the namespace bucket has been committed.
+func (s *Store) notifyConflict(ns walletdb.ReadWriteBucket, hash,
+	conflictHash *chainhash.Hash) {
+
+	if s.NotifyConflict == nil {
+		return
+	}
+	ns.Tx().OnCommit(func() {
+		s.NotifyConflict(hash, conflictHash)
+	})
+}
+
 // removeConflict removes an unmined transaction record and all spend chains
 // deriving from it from the store.  This is designed to remove transactions
 // that would otherwise result in double spend conflicts if left in the store,
 // and to remove transactions that spend coinbase transactions on reorgs.
 func (s *Store) removeConflict(ns walletdb.ReadWriteBucket, rec *TxRecord) error {
+	s.notifyCreditChange(ns, CreditChange{Type: CreditsReset})
+
 	// For each potential credit for this record, each spender (if any) must
 	// be recursively removed as well.  Once the spenders are removed, the
 	// credit is deleted.
@@ -148,6 +177,12 @@ func (s *Store) removeConflict(ns walletdb.ReadWriteBucket, rec *TxRecord) error
 		if err := deleteRawUnminedCredit(ns, k); err != nil {
 			return err
 		}
+		err := deleteScriptCredit(
+			ns, rec.MsgTx.TxOut[i].PkScript, &rec.Hash, uint32(i),
+		)
+		if err != nil {
+			return err
+		}
 	}
 
 	// If this tx spends any previous credits (either mined or unmined), set